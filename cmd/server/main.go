@@ -18,10 +18,12 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/config_access"
+	mtlsaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/mtls_access"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/credcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/homeplugins"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
@@ -68,7 +70,7 @@ func shouldEnableExampleAPIKeySafeMode(cfg *config.Config, commandMode, tuiMode,
 
 // main is the entry point of the application.
 // It parses command-line flags, loads configuration, and starts the appropriate
-// service based on the provided flags (login, codex-login, or server mode).
+// service based on the provided flags and subcommand (login, codex-login, or server mode).
 func main() {
 	fmt.Printf("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s\n", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
 
@@ -90,6 +92,8 @@ func main() {
 	var tuiMode bool
 	var standalone bool
 	var localModel bool
+	var selfTest bool
+	var evalSpec string
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&codexLogin, "codex-login", false, "Login to Codex using OAuth")
@@ -109,6 +113,8 @@ func main() {
 	flag.BoolVar(&tuiMode, "tui", false, "Start with terminal management UI")
 	flag.BoolVar(&standalone, "standalone", false, "In TUI mode, start an embedded local server")
 	flag.BoolVar(&localModel, "local-model", false, "Use embedded models.json and codex_client_models.json only, skip remote model catalog fetching")
+	flag.BoolVar(&selfTest, "selftest", false, "Validate config, probe credentials and routing rules, print a report and exit (non-zero on failure)")
+	flag.StringVar(&evalSpec, "eval", "", "Replay a prompt set through two routing configurations of a running proxy and print a comparison report (eval spec YAML file)")
 
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
@@ -135,6 +141,7 @@ func main() {
 			}
 			_, _ = fmt.Fprint(out, s+"\n")
 		})
+		_, _ = fmt.Fprint(out, "Subcommands:\n  login <provider> [-device] [-no-browser] [-oauth-callback-port N]\n    \tSign in to a subscription provider: anthropic, openai, google, kimi or xai\n")
 	}
 
 	pluginHost := pluginhost.New()
@@ -577,6 +584,7 @@ func main() {
 		cfg.AuthDir = resolvedAuthDir
 	}
 	managementasset.SetCurrentConfig(cfg)
+	credcrypt.Default().Apply(cfg.CredentialEncryption)
 
 	// Create login options to be used in authentication flows.
 	options := &cmd.LoginOptions{
@@ -584,7 +592,8 @@ func main() {
 		CallbackPort: oauthCallbackPort,
 	}
 
-	commandMode := vertexImport != "" || antigravityLogin || codexLogin || codexDeviceLogin || claudeLogin || kimiLogin || xaiLogin
+	loginCommand := cmd.IsLoginCommand(flag.Args())
+	commandMode := selfTest || evalSpec != "" || vertexImport != "" || loginCommand || antigravityLogin || codexLogin || codexDeviceLogin || claudeLogin || kimiLogin || xaiLogin
 	cloudConfigMissing := isCloudDeploy && !configFileExists
 	homeMode := configLoadedFromHome || (cfg != nil && cfg.Home.Enabled)
 	exampleAPIKeySafeMode := shouldEnableExampleAPIKeySafeMode(cfg, commandMode, tuiMode, standalone, cloudConfigMissing, homeMode)
@@ -608,6 +617,7 @@ func main() {
 
 	// Register built-in access providers before constructing services.
 	configaccess.Register(&cfg.SDKConfig)
+	mtlsaccess.Register(&cfg.TLS)
 	pluginHost.ApplyConfig(context.Background(), cfg)
 	if configLoadedFromHome && homePluginStatusReady {
 		errHomePluginLoad := homeplugins.MarkLoadResults(&homePluginSyncReport, pluginHost)
@@ -633,25 +643,52 @@ func main() {
 
 	// Handle different command modes based on the provided flags.
 
-	if vertexImport != "" {
+	if selfTest {
+		// Handle deployment self-test
+		if exitCode := cmd.DoSelfTest(cfg, configFilePath); exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	} else if evalSpec != "" {
+		// Handle prompt set evaluation against a running proxy
+		if exitCode := cmd.DoEval(cfg, evalSpec); exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	} else if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport, vertexImportPrefix)
+	} else if loginCommand {
+		// Handle "login <provider>"
+		if exitCode := cmd.DoLogin(cfg, options, flag.Args()); exitCode != 0 {
+			os.Exit(exitCode)
+		}
 	} else if antigravityLogin {
 		// Handle Antigravity login
-		cmd.DoAntigravityLogin(cfg, options)
+		if errLogin := cmd.DoAntigravityLogin(cfg, options); errLogin != nil {
+			os.Exit(1)
+		}
 	} else if codexLogin {
 		// Handle Codex login
-		cmd.DoCodexLogin(cfg, options)
+		if errLogin := cmd.DoCodexLogin(cfg, options); errLogin != nil {
+			os.Exit(1)
+		}
 	} else if codexDeviceLogin {
 		// Handle Codex device-code login
-		cmd.DoCodexDeviceLogin(cfg, options)
+		if errLogin := cmd.DoCodexDeviceLogin(cfg, options); errLogin != nil {
+			os.Exit(1)
+		}
 	} else if claudeLogin {
 		// Handle Claude login
-		cmd.DoClaudeLogin(cfg, options)
+		if errLogin := cmd.DoClaudeLogin(cfg, options); errLogin != nil {
+			os.Exit(1)
+		}
 	} else if kimiLogin {
-		cmd.DoKimiLogin(cfg, options)
+		if errLogin := cmd.DoKimiLogin(cfg, options); errLogin != nil {
+			os.Exit(1)
+		}
 	} else if xaiLogin {
-		cmd.DoXAILogin(cfg, options)
+		if errLogin := cmd.DoXAILogin(cfg, options); errLogin != nil {
+			os.Exit(1)
+		}
 	} else {
 		// In cloud deploy mode without config file, just wait for shutdown signals
 		if isCloudDeploy && !configFileExists {
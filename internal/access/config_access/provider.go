@@ -5,19 +5,24 @@ import (
 	"net/http"
 	"strings"
 
+	oidcaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/oidc_access"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 )
 
-// Register ensures the config-access provider is available to the access manager.
+// Register ensures the config-access provider is available to the access manager. It also
+// installs the OIDC/JWT provider, which can replace the static api-keys entirely, and the
+// tenant API key provider.
 func Register(cfg *sdkconfig.SDKConfig) {
+	oidcaccess.Register(cfg)
+	registerTenants(cfg)
 	if cfg == nil {
 		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeConfigAPIKey)
 		return
 	}
 
 	keys := normalizeKeys(cfg.APIKeys)
-	if len(keys) == 0 {
+	if len(keys) == 0 || (cfg.OIDCAuth.Enable && cfg.OIDCAuth.DisableAPIKeys) {
 		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeConfigAPIKey)
 		return
 	}
@@ -59,36 +64,12 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 	if len(p.keys) == 0 {
 		return nil, sdkaccess.NewNotHandledError()
 	}
-	authHeader := r.Header.Get("Authorization")
-	authHeaderGoogle := r.Header.Get("X-Goog-Api-Key")
-	authHeaderAnthropic := r.Header.Get("X-Api-Key")
-	queryKey := ""
-	queryAuthToken := ""
-	if r.URL != nil {
-		queryKey = r.URL.Query().Get("key")
-		queryAuthToken = r.URL.Query().Get("auth_token")
-	}
-	if authHeader == "" && authHeaderGoogle == "" && authHeaderAnthropic == "" && queryKey == "" && queryAuthToken == "" {
+	candidates := requestKeyCandidates(r)
+	if len(candidates) == 0 {
 		return nil, sdkaccess.NewNoCredentialsError()
 	}
 
-	apiKey := extractBearerToken(authHeader)
-
-	candidates := []struct {
-		value  string
-		source string
-	}{
-		{apiKey, "authorization"},
-		{authHeaderGoogle, "x-goog-api-key"},
-		{authHeaderAnthropic, "x-api-key"},
-		{queryKey, "query-key"},
-		{queryAuthToken, "query-auth-token"},
-	}
-
 	for _, candidate := range candidates {
-		if candidate.value == "" {
-			continue
-		}
 		if _, ok := p.keys[candidate.value]; ok {
 			return &sdkaccess.Result{
 				Provider:  p.Identifier(),
@@ -103,6 +84,35 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 	return nil, sdkaccess.NewInvalidCredentialError()
 }
 
+type keyCandidate struct {
+	value  string
+	source string
+}
+
+// requestKeyCandidates lists the non-empty API key values of r with the place each came from.
+func requestKeyCandidates(r *http.Request) []keyCandidate {
+	queryKey := ""
+	queryAuthToken := ""
+	if r.URL != nil {
+		queryKey = r.URL.Query().Get("key")
+		queryAuthToken = r.URL.Query().Get("auth_token")
+	}
+	all := []keyCandidate{
+		{extractBearerToken(r.Header.Get("Authorization")), "authorization"},
+		{r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key"},
+		{r.Header.Get("X-Api-Key"), "x-api-key"},
+		{queryKey, "query-key"},
+		{queryAuthToken, "query-auth-token"},
+	}
+	candidates := all[:0]
+	for _, candidate := range all {
+		if candidate.value != "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
 func extractBearerToken(header string) string {
 	if header == "" {
 		return ""
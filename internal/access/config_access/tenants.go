@@ -0,0 +1,65 @@
+package configaccess
+
+import (
+	"context"
+	"net/http"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// registerTenants installs the tenant API key provider when tenants are configured and static
+// API keys are accepted, and removes it otherwise.
+func registerTenants(cfg *sdkconfig.SDKConfig) {
+	if cfg == nil || len(cfg.Tenants) == 0 || (cfg.OIDCAuth.Enable && cfg.OIDCAuth.DisableAPIKeys) {
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeTenant)
+		return
+	}
+	p := &tenantProvider{keys: make(map[string]map[string]string)}
+	for _, tenant := range cfg.Tenants {
+		meta := map[string]string{sdkaccess.MetadataTenant: tenant.Name}
+		sdkaccess.AddScopeMetadata(meta, tenant.AccessScope)
+		if tenant.CredentialPrefix != "" {
+			meta[sdkaccess.MetadataCredentialPrefix] = tenant.CredentialPrefix
+		}
+		if tenant.DisableRequestLog {
+			meta[sdkaccess.MetadataDisableRequestLog] = "true"
+		}
+		for _, key := range tenant.APIKeys {
+			p.keys[key] = meta
+		}
+	}
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeTenant, p)
+}
+
+// tenantProvider authenticates the API keys of tenants and attaches the tenant's scope.
+type tenantProvider struct {
+	keys map[string]map[string]string
+}
+
+func (p *tenantProvider) Identifier() string {
+	return sdkaccess.AccessProviderTypeTenant
+}
+
+func (p *tenantProvider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil || len(p.keys) == 0 || r == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	candidates := requestKeyCandidates(r)
+	if len(candidates) == 0 {
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	for _, candidate := range candidates {
+		tenantMeta, ok := p.keys[candidate.value]
+		if !ok {
+			continue
+		}
+		meta := make(map[string]string, len(tenantMeta)+1)
+		for key, value := range tenantMeta {
+			meta[key] = value
+		}
+		meta["source"] = candidate.source
+		return &sdkaccess.Result{Provider: p.Identifier(), Principal: candidate.value, Metadata: meta}, nil
+	}
+	return nil, sdkaccess.NewInvalidCredentialError()
+}
@@ -0,0 +1,53 @@
+package configaccess
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestTenantProviderAttachesTenantScope(t *testing.T) {
+	Register(&sdkconfig.SDKConfig{Tenants: []sdkconfig.Tenant{{
+		Name:              "acme",
+		APIKeys:           []string{"acme-key"},
+		AccessScope:       sdkconfig.AccessScope{AllowedModels: []string{"claude-*"}},
+		CredentialPrefix:  "acme",
+		DisableRequestLog: true,
+	}}})
+	t.Cleanup(func() { Register(nil) })
+
+	var tenant sdkaccess.Provider
+	for _, provider := range sdkaccess.RegisteredProviders() {
+		if provider.Identifier() == sdkaccess.AccessProviderTypeTenant {
+			tenant = provider
+		}
+	}
+	if tenant == nil {
+		t.Fatal("tenant provider not registered")
+	}
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	req.Header.Set("X-Api-Key", "acme-key")
+	result, authErr := tenant.Authenticate(context.Background(), req)
+	if authErr != nil {
+		t.Fatalf("Authenticate: %v", authErr)
+	}
+	if result.Principal != "acme-key" || result.Metadata["source"] != "x-api-key" {
+		t.Fatalf("result = %+v", result)
+	}
+	scope := sdkaccess.ScopeFromMetadata(result.Metadata)
+	if scope.Tenant != "acme" || scope.CredentialPrefix != "acme" || !scope.AllowsModel("claude-sonnet-4-5") || scope.AllowsModel("gpt-5") {
+		t.Fatalf("scope = %+v", scope)
+	}
+	if result.Metadata[sdkaccess.MetadataDisableRequestLog] != "true" {
+		t.Fatal("request log policy missing from metadata")
+	}
+
+	req.Header.Set("X-Api-Key", "other-key")
+	if _, authErr = tenant.Authenticate(context.Background(), req); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("unknown key err = %v, want invalid credential", authErr)
+	}
+}
@@ -0,0 +1,161 @@
+// Package mtlsaccess authenticates clients by the TLS client certificate verified during the
+// handshake, mapping certificate identities such as SPIFFE IDs to access scopes.
+package mtlsaccess
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+// principalPrefix keeps certificate principals apart from static API keys in usage accounting,
+// rate limits and quotas.
+const principalPrefix = "mtls:"
+
+type connStateKey struct{}
+
+// ConnContext records the TLS state of connections that reach net/http wrapped in another
+// net.Conn, where the request's TLS field stays empty. Use it as http.Server.ConnContext.
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	stater, ok := conn.(interface{ ConnectionState() tls.ConnectionState })
+	if !ok {
+		return ctx
+	}
+	state := stater.ConnectionState()
+	if !state.HandshakeComplete {
+		return ctx
+	}
+	return context.WithValue(ctx, connStateKey{}, &state)
+}
+
+// Register installs the client certificate provider when tls.client-auth is enabled and
+// removes it otherwise.
+func Register(cfg *config.TLSConfig) {
+	if cfg == nil || !cfg.Enable || !cfg.ClientAuth.Enabled() {
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeMTLS)
+		return
+	}
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeMTLS, &provider{cfg: cfg.ClientAuth})
+}
+
+type provider struct {
+	cfg config.TLSClientAuthConfig
+}
+
+func (p *provider) Identifier() string {
+	return sdkaccess.AccessProviderTypeMTLS
+}
+
+// Authenticate accepts the verified client certificate of the connection. Requests without a
+// certificate are left to the other providers.
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil || r == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	state := r.TLS
+	if state == nil {
+		state, _ = ctx.Value(connStateKey{}).(*tls.ConnectionState)
+	}
+	// VerifiedChains is only set when the handshake verified the certificate against the CA.
+	if state == nil || len(state.PeerCertificates) == 0 || len(state.VerifiedChains) == 0 {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	cert := state.PeerCertificates[0]
+
+	spiffeID, errSPIFFE := spiffeIDOf(cert)
+	if errSPIFFE != "" {
+		return nil, sdkaccess.NewForbiddenError(errSPIFFE)
+	}
+	if spiffeID != nil && len(p.cfg.TrustDomains) > 0 && !containsFold(p.cfg.TrustDomains, spiffeID.Host) {
+		return nil, sdkaccess.NewForbiddenError("Client certificate trust domain is not accepted")
+	}
+
+	identities := certificateIdentities(cert, spiffeID)
+	if len(identities) == 0 {
+		return nil, sdkaccess.NewForbiddenError("Client certificate carries no identity")
+	}
+	meta := map[string]string{
+		"source":      "client-certificate",
+		"certificate": identities[0],
+	}
+	if spiffeID != nil {
+		meta["spiffe-id"] = spiffeID.String()
+	}
+
+	identity, matched := p.match(identities)
+	if !matched {
+		if p.cfg.RequireIdentity {
+			return nil, sdkaccess.NewForbiddenError("Client certificate identity is not allowed")
+		}
+		return &sdkaccess.Result{Provider: p.Identifier(), Principal: principalPrefix + identities[0], Metadata: meta}, nil
+	}
+	sdkaccess.AddScopeMetadata(meta, identity.AccessScope)
+	return &sdkaccess.Result{Provider: p.Identifier(), Principal: principalPrefix + identities[0], Metadata: meta}, nil
+}
+
+// match returns the first configured identity matching any certificate identity.
+func (p *provider) match(identities []string) (config.TLSClientIdentity, bool) {
+	for _, configured := range p.cfg.Identities {
+		for _, identity := range identities {
+			if sdkaccess.MatchWildcard(configured.Match, identity) {
+				return configured, true
+			}
+		}
+	}
+	return config.TLSClientIdentity{}, false
+}
+
+// spiffeIDOf returns the certificate's SPIFFE ID. A certificate may carry at most one; a
+// message is returned when the certificate is not a valid SPIFFE SVID.
+func spiffeIDOf(cert *x509.Certificate) (*url.URL, string) {
+	var id *url.URL
+	for _, uri := range cert.URIs {
+		if !strings.EqualFold(uri.Scheme, "spiffe") {
+			continue
+		}
+		if id != nil {
+			return nil, "Client certificate carries more than one SPIFFE ID"
+		}
+		if uri.Host == "" || uri.User != nil || uri.Port() != "" || uri.RawQuery != "" || uri.Fragment != "" {
+			return nil, "Client certificate carries an invalid SPIFFE ID"
+		}
+		id = uri
+	}
+	return id, ""
+}
+
+// certificateIdentities lists the identities a certificate can be matched by, most specific
+// first: SPIFFE ID, other URI SANs, DNS names, email addresses, then the subject common name.
+func certificateIdentities(cert *x509.Certificate, spiffeID *url.URL) []string {
+	var identities []string
+	if spiffeID != nil {
+		identities = append(identities, spiffeID.String())
+	}
+	for _, uri := range cert.URIs {
+		if uri != spiffeID {
+			identities = append(identities, uri.String())
+		}
+	}
+	identities = append(identities, cert.DNSNames...)
+	identities = append(identities, cert.EmailAddresses...)
+	if cn := strings.TrimSpace(cert.Subject.CommonName); cn != "" {
+		identities = append(identities, cn)
+	}
+	return identities
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package mtlsaccess
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+func requestWithCert(cert *x509.Certificate, verified bool) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if cert == nil {
+		return req
+	}
+	state := &tls.ConnectionState{HandshakeComplete: true, PeerCertificates: []*x509.Certificate{cert}}
+	if verified {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	req.TLS = state
+	return req
+}
+
+func spiffeCert(t *testing.T, ids ...string) *x509.Certificate {
+	t.Helper()
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "workload"}}
+	for _, id := range ids {
+		uri, errParse := url.Parse(id)
+		if errParse != nil {
+			t.Fatalf("parse %s: %v", id, errParse)
+		}
+		cert.URIs = append(cert.URIs, uri)
+	}
+	return cert
+}
+
+func TestProviderMapsCertificateIdentities(t *testing.T) {
+	p := &provider{cfg: config.TLSClientAuthConfig{
+		Mode:         config.TLSClientAuthRequire,
+		TrustDomains: []string{"corp.example"},
+		Identities: []config.TLSClientIdentity{
+			{Match: "spiffe://corp.example/ns/ml/*", AccessScope: config.AccessScope{AllowedProviders: []string{"claude"}, AllowedModels: []string{"claude-*"}}},
+			{Match: "batch.internal", AccessScope: config.AccessScope{ReadOnly: true}},
+		},
+	}}
+	ctx := context.Background()
+
+	res, authErr := p.Authenticate(ctx, requestWithCert(spiffeCert(t, "spiffe://corp.example/ns/ml/sa/trainer"), true))
+	if authErr != nil {
+		t.Fatalf("SPIFFE cert rejected: %v", authErr)
+	}
+	if res.Principal != "mtls:spiffe://corp.example/ns/ml/sa/trainer" || res.Metadata["spiffe-id"] == "" {
+		t.Fatalf("result = %+v", res)
+	}
+	scope := sdkaccess.ScopeFromMetadata(res.Metadata)
+	if !scope.AllowsProvider("claude") || scope.AllowsProvider("codex") || scope.AllowsModel("gpt-5") {
+		t.Fatalf("scope = %+v", scope)
+	}
+
+	dnsCert := &x509.Certificate{DNSNames: []string{"batch.internal"}}
+	res, authErr = p.Authenticate(ctx, requestWithCert(dnsCert, true))
+	if authErr != nil || !sdkaccess.ScopeFromMetadata(res.Metadata).ReadOnly {
+		t.Fatalf("DNS identity = %+v, %v", res, authErr)
+	}
+
+	// Unmatched identities authenticate without a scope unless require-identity is set.
+	res, authErr = p.Authenticate(ctx, requestWithCert(spiffeCert(t, "spiffe://corp.example/ns/web/sa/api"), true))
+	if authErr != nil || res.Metadata[sdkaccess.MetadataAllowedProviders] != "" {
+		t.Fatalf("unmatched identity = %+v, %v", res, authErr)
+	}
+	p.cfg.RequireIdentity = true
+	if _, authErr = p.Authenticate(ctx, requestWithCert(spiffeCert(t, "spiffe://corp.example/ns/web/sa/api"), true)); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeForbidden) {
+		t.Fatalf("require-identity error = %v", authErr)
+	}
+
+	rejected := map[string]*x509.Certificate{
+		"foreign trust domain": spiffeCert(t, "spiffe://other.example/ns/ml/sa/trainer"),
+		"two SPIFFE IDs":       spiffeCert(t, "spiffe://corp.example/a", "spiffe://corp.example/b"),
+		"SPIFFE ID with port":  spiffeCert(t, "spiffe://corp.example:8443/ns/ml"),
+	}
+	for name, cert := range rejected {
+		if _, authErr = p.Authenticate(ctx, requestWithCert(cert, true)); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeForbidden) {
+			t.Fatalf("%s: error = %v, want forbidden", name, authErr)
+		}
+	}
+
+	if _, authErr = p.Authenticate(ctx, requestWithCert(nil, false)); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("no certificate error = %v, want not handled", authErr)
+	}
+	if _, authErr = p.Authenticate(ctx, requestWithCert(spiffeCert(t, "spiffe://corp.example/ns/ml/x"), false)); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("unverified certificate error = %v, want not handled", authErr)
+	}
+}
+
+type fakeTLSConn struct {
+	tls.Conn
+	state tls.ConnectionState
+}
+
+func (c *fakeTLSConn) ConnectionState() tls.ConnectionState { return c.state }
+
+func TestConnContextExposesWrappedTLSState(t *testing.T) {
+	cert := spiffeCert(t, "spiffe://corp.example/ns/ml/sa/trainer")
+	conn := &fakeTLSConn{state: tls.ConnectionState{
+		HandshakeComplete: true,
+		PeerCertificates:  []*x509.Certificate{cert},
+		VerifiedChains:    [][]*x509.Certificate{{cert}},
+	}}
+	ctx := ConnContext(context.Background(), conn)
+
+	p := &provider{cfg: config.TLSClientAuthConfig{Mode: config.TLSClientAuthOptional}}
+	res, authErr := p.Authenticate(ctx, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if authErr != nil || res.Principal != "mtls:spiffe://corp.example/ns/ml/sa/trainer" {
+		t.Fatalf("wrapped connection = %+v, %v", res, authErr)
+	}
+}
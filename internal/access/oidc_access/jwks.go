@@ -0,0 +1,233 @@
+package oidcaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// minRefreshInterval spaces out JWKS fetches, so tokens with bogus kid values or an unreachable
+// issuer cannot make every request hit the issuer.
+const minRefreshInterval = 30 * time.Second
+
+// maxJWKSBytes bounds discovery and JWKS response bodies.
+const maxJWKSBytes = 1 << 20
+
+// keySet fetches and caches the issuer's signing keys.
+type keySet struct {
+	client  *http.Client
+	issuer  string
+	jwksURL string
+	ttl     time.Duration
+	now     func() time.Time
+
+	flight singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	refreshing  bool
+}
+
+// key returns the public key for kid. A stale set keeps serving its keys while one shared
+// refresh runs in the background; callers with an unknown kid wait for that refresh instead
+// of holding the lock across the fetch. An empty kid matches the only key of a single-key set.
+func (s *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	now := s.now()
+	stale := s.keys == nil || now.Sub(s.fetchedAt) >= s.ttl
+	key, found := s.lookupLocked(kid)
+	if found && !stale {
+		s.mu.Unlock()
+		return key, nil
+	}
+	if !s.refreshing && !s.lastAttempt.IsZero() && now.Sub(s.lastAttempt) < minRefreshInterval {
+		s.mu.Unlock()
+		if found {
+			return key, nil
+		}
+		return nil, errUnknownKey
+	}
+	if !s.refreshing {
+		s.refreshing = true
+		s.lastAttempt = now
+	}
+	// The refresh outlives the request that started it, so other callers can share it.
+	done := s.flight.DoChan("jwks", func() (any, error) {
+		return nil, s.refresh(context.WithoutCancel(ctx))
+	})
+	s.mu.Unlock()
+	if found {
+		return key, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-done:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+	}
+	s.mu.Lock()
+	key, found = s.lookupLocked(kid)
+	s.mu.Unlock()
+	if !found {
+		return nil, errUnknownKey
+	}
+	return key, nil
+}
+
+func (s *keySet) lookupLocked(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh fetches the key set and replaces the cache. A failed refresh keeps the cached keys.
+func (s *keySet) refresh(ctx context.Context) error {
+	keys, errFetch := s.fetch(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshing = false
+	if errFetch != nil {
+		if s.keys != nil {
+			log.Warnf("oidc-auth: JWKS refresh failed, using cached keys: %v", errFetch)
+		}
+		return errFetch
+	}
+	s.keys = keys
+	s.fetchedAt = s.now()
+	return nil
+}
+
+func (s *keySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	jwksURL := s.jwksURL
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if errDiscover := s.getJSON(ctx, strings.TrimRight(s.issuer, "/")+"/.well-known/openid-configuration", &discovery); errDiscover != nil {
+			return nil, fmt.Errorf("oidc discovery: %w", errDiscover)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.New("oidc discovery: jwks_uri missing")
+		}
+		jwksURL = discovery.JWKSURI
+	}
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if errFetch := s.getJSON(ctx, jwksURL, &document); errFetch != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", errFetch)
+	}
+	keys := make(map[string]crypto.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, errKey := jwk.publicKey()
+		if errKey != nil {
+			log.Debugf("oidc-auth: skipping JWKS key %q: %v", jwk.Kid, errKey)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (s *keySet) getJSON(ctx context.Context, url string, out any) error {
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if errReq != nil {
+		return errReq
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, errDo := s.client.Do(req)
+	if errDo != nil {
+		return errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Debugf("oidc-auth: close response body: %v", errClose)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(out)
+}
+
+// jsonWebKey is the subset of RFC 7517 fields needed for RSA and EC verification keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, errN := decodeBigInt(k.N)
+		e, errE := decodeBigInt(k.E)
+		if errN != nil || errE != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := decodeBigInt(k.X)
+		y, errY := decodeBigInt(k.Y)
+		if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid EC key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, errDecode := base64.RawURLEncoding.DecodeString(value)
+	if errDecode != nil || len(raw) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
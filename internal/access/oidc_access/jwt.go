@@ -0,0 +1,215 @@
+package oidcaccess
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	errMalformedToken = errors.New("malformed token")
+	errUnknownKey     = errors.New("unknown signing key")
+)
+
+// signingMethod describes one supported JWS algorithm.
+type signingMethod struct {
+	hash crypto.Hash
+	// pss selects RSASSA-PSS instead of PKCS#1 v1.5 for RSA keys.
+	pss bool
+	// ecSize is the byte length of each ECDSA signature half; 0 for RSA.
+	ecSize int
+}
+
+var signingMethods = map[string]signingMethod{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"PS256": {hash: crypto.SHA256, pss: true},
+	"PS384": {hash: crypto.SHA384, pss: true},
+	"PS512": {hash: crypto.SHA512, pss: true},
+	"ES256": {hash: crypto.SHA256, ecSize: 32},
+	"ES384": {hash: crypto.SHA384, ecSize: 48},
+	"ES512": {hash: crypto.SHA512, ecSize: 66},
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// parsedToken is a JWT whose segments are decoded but whose signature is not yet verified.
+type parsedToken struct {
+	header    jwtHeader
+	claims    map[string]any
+	signed    string
+	signature []byte
+}
+
+// looksLikeJWT reports whether value has the three-segment shape of a compact JWS, so opaque API
+// keys are left to the other providers.
+func looksLikeJWT(value string) bool {
+	return strings.Count(value, ".") == 2 && strings.HasPrefix(value, "eyJ")
+}
+
+func parseToken(raw string) (*parsedToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+	headerJSON, errHeader := base64.RawURLEncoding.DecodeString(parts[0])
+	claimsJSON, errClaims := base64.RawURLEncoding.DecodeString(parts[1])
+	signature, errSig := base64.RawURLEncoding.DecodeString(parts[2])
+	if errHeader != nil || errClaims != nil || errSig != nil {
+		return nil, errMalformedToken
+	}
+	token := &parsedToken{signed: parts[0] + "." + parts[1], signature: signature}
+	if errUnmarshal := json.Unmarshal(headerJSON, &token.header); errUnmarshal != nil {
+		return nil, errMalformedToken
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(claimsJSON)))
+	decoder.UseNumber()
+	if errDecode := decoder.Decode(&token.claims); errDecode != nil || token.claims == nil {
+		return nil, errMalformedToken
+	}
+	return token, nil
+}
+
+// verifySignature checks the token signature with key, which must match the header algorithm.
+func (t *parsedToken) verifySignature(key crypto.PublicKey) error {
+	method, ok := signingMethods[t.header.Alg]
+	if !ok {
+		return fmt.Errorf("unsupported signing algorithm %q", t.header.Alg)
+	}
+	hasher := method.hash.New()
+	hasher.Write([]byte(t.signed))
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if method.ecSize != 0 {
+			return fmt.Errorf("algorithm %s does not match RSA key", t.header.Alg)
+		}
+		if method.pss {
+			return rsa.VerifyPSS(pub, method.hash, digest, t.signature, nil)
+		}
+		return rsa.VerifyPKCS1v15(pub, method.hash, digest, t.signature)
+	case *ecdsa.PublicKey:
+		if method.ecSize == 0 || len(t.signature) != 2*method.ecSize {
+			return fmt.Errorf("algorithm %s does not match EC key", t.header.Alg)
+		}
+		r := new(big.Int).SetBytes(t.signature[:method.ecSize])
+		s := new(big.Int).SetBytes(t.signature[method.ecSize:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key type")
+	}
+}
+
+// validateClaims checks iss, aud, exp, nbf and iat against now with the given skew.
+func (t *parsedToken) validateClaims(issuer string, audiences []string, now time.Time, skew time.Duration) error {
+	if iss, _ := t.claims["iss"].(string); iss != issuer && strings.TrimRight(iss, "/") != issuer {
+		return fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if len(audiences) > 0 && !containsAny(claimValues(t.claims, "aud"), audiences) {
+		return errors.New("token audience is not accepted")
+	}
+	exp, hasExp := numericClaim(t.claims, "exp")
+	if !hasExp {
+		return errors.New("token has no expiry")
+	}
+	if now.After(exp.Add(skew)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := numericClaim(t.claims, "nbf"); ok && now.Add(skew).Before(nbf) {
+		return errors.New("token not yet valid")
+	}
+	if iat, ok := numericClaim(t.claims, "iat"); ok && now.Add(skew).Before(iat) {
+		return errors.New("token issued in the future")
+	}
+	return nil
+}
+
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, errFloat := number.Float64()
+	if errFloat != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// claimValues returns the string values of a claim reached by a dotted path. String claims are
+// split on whitespace so OAuth "scope" claims yield their individual scopes.
+func claimValues(claims map[string]any, path string) []string {
+	var current any = claims
+	for _, segment := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = object[segment]
+	}
+	switch value := current.(type) {
+	case string:
+		return strings.Fields(value)
+	case json.Number:
+		return []string{value.String()}
+	case bool:
+		return []string{fmt.Sprint(value)}
+	case []any:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			switch typed := item.(type) {
+			case string:
+				out = append(out, typed)
+			case json.Number:
+				out = append(out, typed.String())
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// claimString returns a claim as a single string, keeping embedded spaces.
+func claimString(claims map[string]any, path string) string {
+	var current any = claims
+	for _, segment := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return ""
+		}
+		current = object[segment]
+	}
+	switch value := current.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	}
+	return ""
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, value := range values {
+		for _, candidate := range wanted {
+			if value == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
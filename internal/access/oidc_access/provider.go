@@ -0,0 +1,210 @@
+// Package oidcaccess authenticates clients with JWTs issued by an OIDC provider. Tokens are
+// verified against the issuer's JWKS and their claims are mapped to access scopes.
+package oidcaccess
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// principalPrefix keeps JWT principals apart from static API keys in usage accounting, rate
+// limits and quotas.
+const principalPrefix = "oidc:"
+
+// fetchTimeout bounds discovery and JWKS requests.
+const fetchTimeout = 10 * time.Second
+
+var (
+	registerMu sync.Mutex
+	current    *provider
+)
+
+// Register installs the OIDC provider when oidc-auth is enabled and removes it otherwise. An
+// unchanged configuration keeps the existing provider so its JWKS cache survives reloads.
+func Register(cfg *sdkconfig.SDKConfig) {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	if cfg == nil || !cfg.OIDCAuth.Enable {
+		current = nil
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeOIDC)
+		return
+	}
+	if current == nil || !reflect.DeepEqual(current.cfg, cfg.OIDCAuth) || current.proxyURL != cfg.ProxyURL {
+		if len(cfg.OIDCAuth.Audiences) == 0 {
+			log.Warn("oidc-auth: audiences is empty, so any token signed by the issuer is accepted, including tokens minted for other applications; set audiences to this proxy's client ID")
+		}
+		current = newProvider(cfg)
+	}
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeOIDC, current)
+}
+
+type provider struct {
+	cfg      sdkconfig.OIDCAuthConfig
+	proxyURL string
+	keys     *keySet
+	now      func() time.Time
+}
+
+func newProvider(cfg *sdkconfig.SDKConfig) *provider {
+	client := util.SetProxy(cfg, &http.Client{Timeout: fetchTimeout})
+	return &provider{
+		cfg:      cfg.OIDCAuth,
+		proxyURL: cfg.ProxyURL,
+		now:      time.Now,
+		keys: &keySet{
+			client:  client,
+			issuer:  cfg.OIDCAuth.Issuer,
+			jwksURL: cfg.OIDCAuth.JWKSURL,
+			ttl:     cfg.OIDCAuth.CacheTTL(),
+			now:     time.Now,
+		},
+	}
+}
+
+func (p *provider) Identifier() string {
+	return sdkaccess.AccessProviderTypeOIDC
+}
+
+// Authenticate validates a JWT presented as a bearer token or API key header. Opaque API keys
+// are left to the other providers.
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil || r == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	raw, source, present := bearerJWT(r)
+	if raw == "" {
+		if !present {
+			return nil, sdkaccess.NewNoCredentialsError()
+		}
+		return nil, sdkaccess.NewNotHandledError()
+	}
+
+	token, errParse := parseToken(raw)
+	if errParse != nil {
+		return nil, invalidToken(errParse.Error())
+	}
+	key, errKey := p.keys.key(ctx, token.header.Kid)
+	if errKey != nil {
+		if errKey != errUnknownKey {
+			log.Warnf("oidc-auth: signing keys unavailable: %v", errKey)
+			return nil, sdkaccess.NewInternalAuthError("Token verification unavailable", errKey)
+		}
+		return nil, invalidToken(errKey.Error())
+	}
+	if errVerify := token.verifySignature(key); errVerify != nil {
+		return nil, invalidToken("invalid signature")
+	}
+	if errClaims := token.validateClaims(p.cfg.Issuer, p.cfg.Audiences, p.now(), p.cfg.Skew()); errClaims != nil {
+		return nil, invalidToken(errClaims.Error())
+	}
+	principal := claimString(token.claims, p.cfg.PrincipalClaim)
+	if principal == "" {
+		return nil, invalidToken("missing " + p.cfg.PrincipalClaim + " claim")
+	}
+
+	scope, matched := p.scopeFor(token.claims)
+	if !matched && p.cfg.RequireScope {
+		return nil, sdkaccess.NewForbiddenError("Token grants no access to this proxy")
+	}
+	meta := map[string]string{
+		"source":  source,
+		"subject": claimString(token.claims, "sub"),
+	}
+	sdkaccess.AddScopeMetadata(meta, scope)
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: principalPrefix + principal,
+		Metadata:  meta,
+	}, nil
+}
+
+// scopeFor merges the scopes whose claim value the token carries. The token is read-only only if
+// every match is; otherwise only the writable matches count, so a read-only mapping never widens
+// the providers or models a token may write to. Among the counted matches, one without provider
+// or model limits lifts that limit.
+func (p *provider) scopeFor(claims map[string]any) (sdkconfig.AccessScope, bool) {
+	var matches []sdkconfig.OIDCScopeMapping
+	readOnly := true
+	for _, mapping := range p.cfg.Scopes {
+		if !containsAny(claimValues(claims, mapping.Claim), []string{mapping.Value}) {
+			continue
+		}
+		matches = append(matches, mapping)
+		readOnly = readOnly && mapping.ReadOnly
+	}
+	if len(matches) == 0 {
+		return sdkconfig.AccessScope{}, false
+	}
+
+	var (
+		scope                 sdkconfig.AccessScope
+		anyProvider, anyModel bool
+	)
+	scope.ReadOnly = readOnly
+	for _, mapping := range matches {
+		if mapping.ReadOnly && !readOnly {
+			continue
+		}
+		if len(mapping.AllowedProviders) == 0 {
+			anyProvider = true
+		}
+		if len(mapping.AllowedModels) == 0 {
+			anyModel = true
+		}
+		scope.AllowedProviders = append(scope.AllowedProviders, mapping.AllowedProviders...)
+		scope.AllowedModels = append(scope.AllowedModels, mapping.AllowedModels...)
+	}
+	if anyProvider {
+		scope.AllowedProviders = nil
+	}
+	if anyModel {
+		scope.AllowedModels = nil
+	}
+	return scope, true
+}
+
+// bearerJWT returns the first JWT-shaped credential of r and where it came from. present
+// reports whether the request carried any credential at all.
+func bearerJWT(r *http.Request) (token, source string, present bool) {
+	authHeader := r.Header.Get("Authorization")
+	if parts := strings.SplitN(authHeader, " ", 2); len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+		authHeader = strings.TrimSpace(parts[1])
+	}
+	candidates := []struct{ value, source string }{
+		{authHeader, "authorization"},
+		{r.Header.Get("X-Api-Key"), "x-api-key"},
+		{r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key"},
+	}
+	if r.URL != nil {
+		candidates = append(candidates, struct{ value, source string }{r.URL.Query().Get("key"), "query-key"})
+		candidates = append(candidates, struct{ value, source string }{r.URL.Query().Get("auth_token"), "query-auth-token"})
+	}
+	for _, candidate := range candidates {
+		if candidate.value == "" {
+			continue
+		}
+		present = true
+		if looksLikeJWT(candidate.value) {
+			return candidate.value, candidate.source, true
+		}
+	}
+	return "", "", present
+}
+
+func invalidToken(reason string) *sdkaccess.AuthError {
+	return &sdkaccess.AuthError{
+		Code:       sdkaccess.AuthErrorCodeInvalidCredential,
+		Message:    "Invalid token: " + reason,
+		StatusCode: http.StatusUnauthorized,
+	}
+}
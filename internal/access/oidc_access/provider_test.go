@@ -0,0 +1,264 @@
+package oidcaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+type testIssuer struct {
+	server    *httptest.Server
+	rsaKey    *rsa.PrivateKey
+	ecKey     *ecdsa.PrivateKey
+	jwksFetch atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, errRSA := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, errEC := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if errRSA != nil || errEC != nil {
+		t.Fatalf("generate keys: %v %v", errRSA, errEC)
+	}
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		issuer.jwksFetch.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch alg {
+	case "RS256":
+		sig, errSign := rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest[:])
+		if errSign != nil {
+			t.Fatalf("sign: %v", errSign)
+		}
+		signature = sig
+	case "ES256":
+		r, s, errSign := ecdsa.Sign(rand.Reader, i.ecKey, digest[:])
+		if errSign != nil {
+			t.Fatalf("sign: %v", errSign)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *testIssuer) claims(extra map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss": i.server.URL,
+		"aud": []string{"cliproxy"},
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return claims
+}
+
+func authenticate(p *provider, header, value string) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if value != "" {
+		req.Header.Set(header, value)
+	}
+	return p.Authenticate(context.Background(), req)
+}
+
+func TestProviderValidatesTokens(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := newProvider(&sdkconfig.SDKConfig{OIDCAuth: sdkconfig.OIDCAuthConfig{
+		Enable:         true,
+		Issuer:         issuer.server.URL,
+		Audiences:      []string{"cliproxy"},
+		PrincipalClaim: "email",
+		Scopes: []sdkconfig.OIDCScopeMapping{
+			{Claim: "groups", Value: "ml", AccessScope: sdkconfig.AccessScope{AllowedProviders: []string{"claude"}, AllowedModels: []string{"claude-*"}}},
+			{Claim: "scope", Value: "proxy.read", AccessScope: sdkconfig.AccessScope{ReadOnly: true}},
+		},
+	}})
+
+	res, authErr := authenticate(p, "Authorization", "Bearer "+issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]any{
+		"email": "dev@example.com", "groups": []string{"ml", "eng"},
+	})))
+	if authErr != nil {
+		t.Fatalf("valid RS256 token rejected: %v", authErr)
+	}
+	if res.Principal != "oidc:dev@example.com" {
+		t.Fatalf("principal = %q", res.Principal)
+	}
+	scope := sdkaccess.ScopeFromMetadata(res.Metadata)
+	if !scope.AllowsModel("claude-sonnet-4") || scope.AllowsModel("gpt-5") || scope.AllowsProvider("codex") || scope.ReadOnly {
+		t.Fatalf("scope = %+v", scope)
+	}
+
+	res, authErr = authenticate(p, "Authorization", "Bearer "+issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]any{
+		"email": "dev@example.com", "groups": []string{"ml"}, "scope": "proxy.read",
+	})))
+	if authErr != nil {
+		t.Fatalf("token with both scopes rejected: %v", authErr)
+	}
+	if scope = sdkaccess.ScopeFromMetadata(res.Metadata); scope.ReadOnly || scope.AllowsModel("gpt-5") || scope.AllowsProvider("codex") {
+		t.Fatalf("read-only mapping widened the writable scope: %+v", scope)
+	}
+
+	res, authErr = authenticate(p, "X-Api-Key", issuer.sign(t, "ES256", "ec-1", issuer.claims(map[string]any{
+		"email": "bot@example.com", "scope": "openid proxy.read",
+	})))
+	if authErr != nil {
+		t.Fatalf("valid ES256 token rejected: %v", authErr)
+	}
+	if !sdkaccess.ScopeFromMetadata(res.Metadata).ReadOnly {
+		t.Fatalf("read-only scope not applied: %+v", res.Metadata)
+	}
+
+	rejected := map[string]map[string]any{
+		"expired":      issuer.claims(map[string]any{"email": "a@b", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"audience":     issuer.claims(map[string]any{"email": "a@b", "aud": "other"}),
+		"issuer":       issuer.claims(map[string]any{"email": "a@b", "iss": "https://evil.example"}),
+		"no principal": issuer.claims(nil),
+	}
+	for name, claims := range rejected {
+		_, authErr = authenticate(p, "Authorization", "Bearer "+issuer.sign(t, "RS256", "rsa-1", claims))
+		if !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Fatalf("%s: error = %v, want invalid credential", name, authErr)
+		}
+	}
+
+	tampered := issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]any{"email": "a@b"}))
+	tampered = tampered[:len(tampered)-4] + "AAAA"
+	if _, authErr = authenticate(p, "Authorization", "Bearer "+tampered); authErr == nil || authErr.Message != "Invalid token: invalid signature" {
+		t.Fatalf("tampered token error = %v", authErr)
+	}
+
+	if _, authErr = authenticate(p, "Authorization", "Bearer sk-static-key"); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("opaque key error = %v, want not handled", authErr)
+	}
+	if _, authErr = authenticate(p, "Authorization", ""); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeNoCredentials) {
+		t.Fatalf("missing credential error = %v", authErr)
+	}
+	if got := issuer.jwksFetch.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want cached after the first fetch", got)
+	}
+}
+
+func TestProviderRequireScope(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := newProvider(&sdkconfig.SDKConfig{OIDCAuth: sdkconfig.OIDCAuthConfig{
+		Enable:         true,
+		Issuer:         issuer.server.URL,
+		PrincipalClaim: "sub",
+		RequireScope:   true,
+		Scopes:         []sdkconfig.OIDCScopeMapping{{Claim: "realm_access.roles", Value: "proxy-user"}},
+	}})
+
+	token := issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]any{"realm_access": map[string]any{"roles": []string{"viewer"}}}))
+	if _, authErr := authenticate(p, "Authorization", "Bearer "+token); !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeForbidden) {
+		t.Fatalf("unmapped token error = %v, want forbidden", authErr)
+	}
+	token = issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]any{"realm_access": map[string]any{"roles": []string{"proxy-user"}}}))
+	res, authErr := authenticate(p, "Authorization", "Bearer "+token)
+	if authErr != nil || res.Principal != "oidc:user-1" {
+		t.Fatalf("mapped token = %+v, %v", res, authErr)
+	}
+}
+
+func TestKeySetRefreshesOutsideTheLock(t *testing.T) {
+	issuer := newTestIssuer(t)
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/openid-configuration" {
+			<-release
+		}
+		issuer.server.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(upstream.Close)
+	defer close(release)
+
+	sim := clock.NewSim(time.Now())
+	keys := &keySet{client: upstream.Client(), issuer: upstream.URL + "/", ttl: time.Minute, now: sim.Now}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errKey := keys.key(context.Background(), "rsa-1")
+			errs <- errKey
+		}()
+	}
+	release <- struct{}{}
+	wg.Wait()
+	close(errs)
+	for errKey := range errs {
+		if errKey != nil {
+			t.Fatalf("key: %v", errKey)
+		}
+	}
+	if got := issuer.jwksFetch.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want one shared fetch", got)
+	}
+
+	// A stale set keeps answering while its refresh is stuck upstream.
+	sim.Advance(2 * time.Minute)
+	served := make(chan error, 1)
+	go func() {
+		_, errKey := keys.key(context.Background(), "ec-1")
+		served <- errKey
+	}()
+	select {
+	case errKey := <-served:
+		if errKey != nil {
+			t.Fatalf("stale key: %v", errKey)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stale lookup waited for the refresh")
+	}
+	if _, errKey := keys.key(context.Background(), "rsa-1"); errKey != nil {
+		t.Fatalf("cached key during refresh: %v", errKey)
+	}
+	release <- struct{}{}
+	for deadline := time.Now().Add(5 * time.Second); issuer.jwksFetch.Load() != 2; {
+		if time.Now().After(deadline) {
+			t.Fatalf("JWKS fetched %d times, want the background refresh", issuer.jwksFetch.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
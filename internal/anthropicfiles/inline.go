@@ -0,0 +1,72 @@
+package anthropicfiles
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Inline replaces image and document blocks whose source references a file stored for owner
+// with an inline source: text for text/* documents, base64 otherwise. Blocks nested in
+// tool_result content are handled too. References to unknown file IDs are left untouched so
+// an Anthropic upstream can still resolve them. body is returned unchanged when local storage
+// is disabled or nothing references a stored file.
+func (s *Store) Inline(owner string, body []byte) ([]byte, error) {
+	if _, errDir := s.localDir(); errDir != nil {
+		return body, nil
+	}
+	if !strings.Contains(string(body), "file_id") {
+		return body, nil
+	}
+	var errInline error
+	gjson.GetBytes(body, "messages").ForEach(func(msgIndex, message gjson.Result) bool {
+		path := fmt.Sprintf("messages.%d.content", msgIndex.Int())
+		body, errInline = s.inlineContent(owner, body, path, message.Get("content"))
+		return errInline == nil
+	})
+	return body, errInline
+}
+
+func (s *Store) inlineContent(owner string, body []byte, path string, content gjson.Result) ([]byte, error) {
+	if !content.IsArray() {
+		return body, nil
+	}
+	var errInline error
+	content.ForEach(func(blockIndex, block gjson.Result) bool {
+		blockPath := fmt.Sprintf("%s.%d", path, blockIndex.Int())
+		switch block.Get("type").String() {
+		case "tool_result":
+			body, errInline = s.inlineContent(owner, body, blockPath+".content", block.Get("content"))
+		case "image", "document":
+			body, errInline = s.inlineBlock(owner, body, blockPath, block)
+		}
+		return errInline == nil
+	})
+	return body, errInline
+}
+
+func (s *Store) inlineBlock(owner string, body []byte, path string, block gjson.Result) ([]byte, error) {
+	source := block.Get("source")
+	if source.Get("type").String() != "file" {
+		return body, nil
+	}
+	meta, content, errContent := s.Content(owner, source.Get("file_id").String())
+	if errors.Is(errContent, ErrNotFound) {
+		return body, nil
+	}
+	if errContent != nil {
+		return body, errContent
+	}
+
+	inline := map[string]string{"type": "base64", "media_type": meta.MimeType}
+	if strings.HasPrefix(meta.MimeType, "text/") && block.Get("type").String() == "document" {
+		inline = map[string]string{"type": "text", "media_type": "text/plain", "data": string(content)}
+	} else {
+		inline["data"] = base64.StdEncoding.EncodeToString(content)
+	}
+	return sjson.SetBytes(body, path+".source", inline)
+}
@@ -0,0 +1,149 @@
+package anthropicfiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+// ErrMixedCredentials is returned when a Messages request references passthrough files
+// uploaded through different Claude credentials, which no single upstream request can resolve.
+var ErrMixedCredentials = errors.New("anthropic files: referenced files were uploaded through different credentials")
+
+// passthroughRecord remembers which client API key uploaded a passthrough file and which
+// credential holds it upstream:
+//
+//	<owner>/passthrough/<id>.json
+type passthroughRecord struct {
+	ID     string `json:"id"`
+	AuthID string `json:"auth_id"`
+}
+
+// RecordPassthrough records that owner uploaded the file id through the credential authID.
+func (s *Store) RecordPassthrough(owner, id, authID string) error {
+	dir, errDir := s.passthroughDir()
+	if errDir != nil {
+		return errDir
+	}
+	if !util.ValidObjectID(id) {
+		return fmt.Errorf("anthropic files: invalid upstream file id %q", id)
+	}
+	data, errMarshal := json.Marshal(passthroughRecord{ID: id, AuthID: authID})
+	if errMarshal != nil {
+		return errMarshal
+	}
+	return util.WriteFileAtomic(passthroughPath(dir, owner, id), data)
+}
+
+// PassthroughAuth returns the credential holding the passthrough file id of owner. It fails
+// with ErrNotFound for files owner did not upload.
+func (s *Store) PassthroughAuth(owner, id string) (string, error) {
+	dir, errDir := s.passthroughDir()
+	if errDir != nil {
+		return "", errDir
+	}
+	if !util.ValidObjectID(id) {
+		return "", ErrNotFound
+	}
+	data, errRead := os.ReadFile(passthroughPath(dir, owner, id))
+	if errors.Is(errRead, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if errRead != nil {
+		return "", errRead
+	}
+	var record passthroughRecord
+	if errUnmarshal := json.Unmarshal(data, &record); errUnmarshal != nil {
+		return "", fmt.Errorf("parse passthrough record %s: %w", id, errUnmarshal)
+	}
+	return record.AuthID, nil
+}
+
+// ForgetPassthrough removes the ownership record of a deleted passthrough file.
+func (s *Store) ForgetPassthrough(owner, id string) error {
+	dir, errDir := s.passthroughDir()
+	if errDir != nil {
+		return errDir
+	}
+	if !util.ValidObjectID(id) {
+		return ErrNotFound
+	}
+	if errRemove := os.Remove(passthroughPath(dir, owner, id)); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+		return errRemove
+	}
+	return nil
+}
+
+// PassthroughReferencesAuth returns the credential holding every file a Messages request of
+// owner references, or "" when it references none. It fails with ErrNotFound when a referenced
+// file was not uploaded by owner and with ErrMixedCredentials when the files live on different
+// credentials.
+func (s *Store) PassthroughReferencesAuth(owner string, body []byte) (string, error) {
+	if _, errDir := s.passthroughDir(); errDir != nil || !strings.Contains(string(body), "file_id") {
+		return "", nil
+	}
+	authID := ""
+	for _, id := range fileReferences(body) {
+		fileAuth, errAuth := s.PassthroughAuth(owner, id)
+		if errAuth != nil {
+			return "", errAuth
+		}
+		if authID != "" && fileAuth != authID {
+			return "", ErrMixedCredentials
+		}
+		authID = fileAuth
+	}
+	return authID, nil
+}
+
+// passthroughDir returns the storage directory, or ErrDisabled unless passthrough mode is active.
+func (s *Store) passthroughDir() (string, error) {
+	if s == nil {
+		return "", ErrDisabled
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cfg.Enable || s.cfg.Mode != config.AnthropicFilesModePassthrough {
+		return "", ErrDisabled
+	}
+	return s.dir, nil
+}
+
+func passthroughPath(dir, owner, id string) string {
+	return filepath.Join(ownerDir(dir, owner), "passthrough", id+".json")
+}
+
+// fileReferences lists the file IDs referenced by image and document blocks of a Messages
+// request, including blocks nested in tool_result content.
+func fileReferences(body []byte) []string {
+	var ids []string
+	var visit func(content gjson.Result)
+	visit = func(content gjson.Result) {
+		if !content.IsArray() {
+			return
+		}
+		content.ForEach(func(_, block gjson.Result) bool {
+			switch block.Get("type").String() {
+			case "tool_result":
+				visit(block.Get("content"))
+			case "image", "document":
+				if source := block.Get("source"); source.Get("type").String() == "file" {
+					ids = append(ids, source.Get("file_id").String())
+				}
+			}
+			return true
+		})
+	}
+	gjson.GetBytes(body, "messages").ForEach(func(_, message gjson.Result) bool {
+		visit(message.Get("content"))
+		return true
+	})
+	return ids
+}
@@ -0,0 +1,299 @@
+// Package anthropicfiles stores files uploaded through the Anthropic Files API and inlines
+// file references in Messages requests, so providers without file support can serve them.
+package anthropicfiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrNotFound is returned for unknown IDs and for files owned by another client API key.
+	ErrNotFound = errors.New("anthropic files: not found")
+	// ErrDisabled is returned while local file storage is not enabled.
+	ErrDisabled = errors.New("anthropic files: local storage is disabled")
+	// ErrTooLarge is returned for uploads above the configured size limit.
+	ErrTooLarge = errors.New("anthropic files: file too large")
+)
+
+// File is the Anthropic file object.
+type File struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Filename     string `json:"filename"`
+	MimeType     string `json:"mime_type"`
+	SizeBytes    int64  `json:"size_bytes"`
+	CreatedAt    string `json:"created_at"`
+	Downloadable bool   `json:"downloadable"`
+}
+
+// Store keeps uploads under one directory per client API key:
+//
+//	<owner>/<id>.json  file metadata
+//	<owner>/<id>.bin   file content
+//
+// <owner> is a hash of the client API key.
+type Store struct {
+	mu  sync.Mutex
+	cfg config.AnthropicFilesConfig
+	dir string
+
+	keys  *contentcrypt.Keyring
+	clock clock.Clock
+}
+
+// NewStore creates a disabled store. Apply enables it.
+func NewStore() *Store {
+	return &Store{clock: clock.Default()}
+}
+
+// SetKeyring sets the keyring sealing stored file content. Deleting a file shreds its key.
+func (s *Store) SetKeyring(keys *contentcrypt.Keyring) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+// Apply updates the store configuration. Existing files are kept when the directory changes.
+func (s *Store) Apply(cfg config.AnthropicFilesConfig, authDir string) {
+	if s == nil {
+		return
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		base, errResolve := util.ResolveAuthDir(authDir)
+		if errResolve != nil {
+			log.Warnf("anthropic files: %v", errResolve)
+			base = "."
+		}
+		dir = filepath.Join(base, config.DefaultAnthropicFilesDir)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	s.dir = dir
+}
+
+// Enabled reports whether the Anthropic /v1/files endpoints are served.
+func (s *Store) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg.Enable
+}
+
+// Passthrough reports whether file requests are forwarded to Anthropic instead of stored locally.
+func (s *Store) Passthrough() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg.Enable && s.cfg.Mode == config.AnthropicFilesModePassthrough
+}
+
+// MaxFileBytes returns the upload size limit.
+func (s *Store) MaxFileBytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(s.cfg.MaxFileSizeMB) << 20
+}
+
+// Create stores content for owner and returns its metadata.
+func (s *Store) Create(owner, filename, mimeType string, content []byte) (File, error) {
+	dir, errDir := s.localDir()
+	if errDir != nil {
+		return File{}, errDir
+	}
+	if int64(len(content)) > s.MaxFileBytes() {
+		return File{}, ErrTooLarge
+	}
+	mimeType = strings.TrimSpace(mimeType)
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	meta := File{
+		ID:           "file_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Type:         "file",
+		Filename:     filepath.Base(filename),
+		MimeType:     mimeType,
+		SizeBytes:    int64(len(content)),
+		CreatedAt:    s.clock.Now().UTC().Format(time.RFC3339),
+		Downloadable: true,
+	}
+	data, errMarshal := json.Marshal(meta)
+	if errMarshal != nil {
+		return File{}, errMarshal
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sealed, errSeal := s.keys.Seal(owner, keyID(meta.ID), content)
+	if errSeal != nil {
+		return File{}, errSeal
+	}
+	if errWrite := util.WriteFileAtomic(filePath(dir, owner, meta.ID, ".bin"), sealed); errWrite != nil {
+		return File{}, errWrite
+	}
+	return meta, util.WriteFileAtomic(filePath(dir, owner, meta.ID, ".json"), data)
+}
+
+// File returns the metadata of a file owned by owner.
+func (s *Store) File(owner, id string) (File, error) {
+	dir, errDir := s.localDir()
+	if errDir != nil {
+		return File{}, errDir
+	}
+	if !util.ValidObjectID(id) {
+		return File{}, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return readMeta(filePath(dir, owner, id, ".json"))
+}
+
+// Content returns the metadata and content of a file owned by owner.
+func (s *Store) Content(owner, id string) (File, []byte, error) {
+	meta, errMeta := s.File(owner, id)
+	if errMeta != nil {
+		return File{}, nil, errMeta
+	}
+	dir, errDir := s.localDir()
+	if errDir != nil {
+		return File{}, nil, errDir
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, errRead := os.ReadFile(filePath(dir, owner, id, ".bin"))
+	if errors.Is(errRead, os.ErrNotExist) {
+		return File{}, nil, ErrNotFound
+	}
+	if errRead != nil {
+		return File{}, nil, errRead
+	}
+	content, errOpen := s.keys.Open(owner, keyID(id), content)
+	if errors.Is(errOpen, contentcrypt.ErrShredded) {
+		return File{}, nil, ErrNotFound
+	}
+	return meta, content, errOpen
+}
+
+// Delete removes a file owned by owner.
+func (s *Store) Delete(owner, id string) error {
+	dir, errDir := s.localDir()
+	if errDir != nil {
+		return errDir
+	}
+	if !util.ValidObjectID(id) {
+		return ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errRemove := os.Remove(filePath(dir, owner, id, ".json"))
+	if errors.Is(errRemove, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if errRemove != nil {
+		return errRemove
+	}
+	_ = os.Remove(filePath(dir, owner, id, ".bin"))
+	return s.keys.Shred(owner, keyID(id))
+}
+
+// List returns the files of owner, newest first.
+func (s *Store) List(owner string) ([]File, error) {
+	dir, errDir := s.localDir()
+	if errDir != nil {
+		return nil, errDir
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, errRead := os.ReadDir(ownerDir(dir, owner))
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errRead
+	}
+	var files []File
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		meta, errMeta := readMeta(filepath.Join(ownerDir(dir, owner), entry.Name()))
+		if errMeta != nil {
+			continue
+		}
+		files = append(files, meta)
+	}
+	sort.SliceStable(files, func(i, k int) bool {
+		if files[i].CreatedAt != files[k].CreatedAt {
+			return files[i].CreatedAt > files[k].CreatedAt
+		}
+		return files[i].ID > files[k].ID
+	})
+	return files, nil
+}
+
+// localDir returns the storage directory, or ErrDisabled unless local mode is active.
+func (s *Store) localDir() (string, error) {
+	if s == nil {
+		return "", ErrDisabled
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cfg.Enable || s.cfg.Mode == config.AnthropicFilesModePassthrough {
+		return "", ErrDisabled
+	}
+	return s.dir, nil
+}
+
+// keyID names the content key of a file so it cannot collide with other stores of the same owner.
+func keyID(id string) string {
+	return "anthropic-files/" + id
+}
+
+func ownerDir(dir, owner string) string {
+	sum := sha256.Sum256([]byte(owner))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16]))
+}
+
+func filePath(dir, owner, id, ext string) string {
+	return filepath.Join(ownerDir(dir, owner), id+ext)
+}
+
+func readMeta(path string) (File, error) {
+	var meta File
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return File{}, ErrNotFound
+		}
+		return File{}, errRead
+	}
+	if errUnmarshal := json.Unmarshal(data, &meta); errUnmarshal != nil {
+		return File{}, fmt.Errorf("parse %s: %w", filepath.Base(path), errUnmarshal)
+	}
+	return meta, nil
+}
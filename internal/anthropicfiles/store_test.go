@@ -0,0 +1,171 @@
+package anthropicfiles
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/tidwall/gjson"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s := NewStore()
+	s.Apply(config.AnthropicFilesConfig{Enable: true, Mode: config.AnthropicFilesModeLocal, Dir: t.TempDir(), MaxFileSizeMB: 1}, "")
+	return s
+}
+
+func TestStore_CreateListDeleteScopedToOwner(t *testing.T) {
+	s := newTestStore(t)
+	meta, errCreate := s.Create("key-a", "../notes.txt", "text/plain", []byte("hello"))
+	if errCreate != nil {
+		t.Fatalf("Create error: %v", errCreate)
+	}
+	if meta.Type != "file" || meta.Filename != "notes.txt" || meta.SizeBytes != 5 || meta.MimeType != "text/plain" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+
+	if _, errOther := s.File("key-b", meta.ID); !errors.Is(errOther, ErrNotFound) {
+		t.Fatalf("File for other owner error = %v, want ErrNotFound", errOther)
+	}
+	files, errList := s.List("key-a")
+	if errList != nil || len(files) != 1 || files[0].ID != meta.ID {
+		t.Fatalf("List = %+v, %v", files, errList)
+	}
+	_, content, errContent := s.Content("key-a", meta.ID)
+	if errContent != nil || string(content) != "hello" {
+		t.Fatalf("Content = %q, %v", content, errContent)
+	}
+
+	if errDelete := s.Delete("key-a", meta.ID); errDelete != nil {
+		t.Fatalf("Delete error: %v", errDelete)
+	}
+	if errDelete := s.Delete("key-a", meta.ID); !errors.Is(errDelete, ErrNotFound) {
+		t.Fatalf("second Delete error = %v, want ErrNotFound", errDelete)
+	}
+}
+
+func TestStore_EncryptsContentAndShredsOnDelete(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ANTHROPIC_FILES_TEST_KEY", "master")
+	keys := contentcrypt.NewKeyring()
+	keys.Apply(config.ContentEncryptionConfig{Enable: true, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "ANTHROPIC_FILES_TEST_KEY"}, KeysDir: t.TempDir()}, "")
+	s := NewStore()
+	s.SetKeyring(keys)
+	s.Apply(config.AnthropicFilesConfig{Enable: true, Mode: config.AnthropicFilesModeLocal, Dir: dir, MaxFileSizeMB: 1}, "")
+
+	meta, errCreate := s.Create("key-a", "notes.txt", "text/plain", []byte("top secret"))
+	if errCreate != nil {
+		t.Fatalf("Create error: %v", errCreate)
+	}
+	raw, errRead := os.ReadFile(filePath(dir, "key-a", meta.ID, ".bin"))
+	if errRead != nil || bytes.Contains(raw, []byte("top secret")) {
+		t.Fatalf("stored content = %q, %v; want ciphertext", raw, errRead)
+	}
+	if _, content, errContent := s.Content("key-a", meta.ID); errContent != nil || string(content) != "top secret" {
+		t.Fatalf("Content = %q, %v", content, errContent)
+	}
+
+	if errDelete := s.Delete("key-a", meta.ID); errDelete != nil {
+		t.Fatalf("Delete error: %v", errDelete)
+	}
+	if _, errOpen := keys.Open("key-a", keyID(meta.ID), raw); !errors.Is(errOpen, contentcrypt.ErrShredded) {
+		t.Fatalf("Open of surviving copy error = %v, want ErrShredded", errOpen)
+	}
+}
+
+func TestStore_RejectsLargeFilesAndDisabledModes(t *testing.T) {
+	s := newTestStore(t)
+	if _, errCreate := s.Create("key-a", "big.bin", "", make([]byte, 2<<20)); !errors.Is(errCreate, ErrTooLarge) {
+		t.Fatalf("Create error = %v, want ErrTooLarge", errCreate)
+	}
+
+	s.Apply(config.AnthropicFilesConfig{Enable: true, Mode: config.AnthropicFilesModePassthrough, MaxFileSizeMB: 1}, t.TempDir())
+	if !s.Passthrough() {
+		t.Fatal("expected passthrough mode")
+	}
+	if _, errCreate := s.Create("key-a", "a.txt", "", []byte("a")); !errors.Is(errCreate, ErrDisabled) {
+		t.Fatalf("Create in passthrough mode error = %v, want ErrDisabled", errCreate)
+	}
+}
+
+func TestStore_InlineReplacesStoredFileReferences(t *testing.T) {
+	s := newTestStore(t)
+	image, _ := s.Create("key-a", "cat.png", "image/png", []byte{0x89, 'P', 'N', 'G'})
+	text, _ := s.Create("key-a", "notes.txt", "text/plain", []byte("some notes"))
+
+	body := []byte(`{"model":"claude","messages":[{"role":"user","content":[` +
+		`{"type":"image","source":{"type":"file","file_id":"` + image.ID + `"}},` +
+		`{"type":"document","source":{"type":"file","file_id":"` + text.ID + `"}},` +
+		`{"type":"document","source":{"type":"file","file_id":"file_remote"}},` +
+		`{"type":"tool_result","tool_use_id":"t1","content":[{"type":"image","source":{"type":"file","file_id":"` + image.ID + `"}}]}]}]}`)
+
+	out, errInline := s.Inline("key-a", body)
+	if errInline != nil {
+		t.Fatalf("Inline error: %v", errInline)
+	}
+	content := gjson.GetBytes(out, "messages.0.content")
+	wantImage := base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'})
+	if got := content.Get("0.source"); got.Get("type").String() != "base64" || got.Get("media_type").String() != "image/png" || got.Get("data").String() != wantImage {
+		t.Fatalf("image source = %s", got.Raw)
+	}
+	if got := content.Get("1.source"); got.Get("type").String() != "text" || got.Get("data").String() != "some notes" {
+		t.Fatalf("text document source = %s", got.Raw)
+	}
+	if got := content.Get("2.source.file_id").String(); got != "file_remote" {
+		t.Fatalf("unknown file reference rewritten: %s", content.Get("2").Raw)
+	}
+	if got := content.Get("3.content.0.source.type").String(); got != "base64" {
+		t.Fatalf("tool_result image source type = %q", got)
+	}
+
+	other, _ := s.Inline("key-b", body)
+	if string(other) != string(body) {
+		t.Fatal("Inline resolved files of another owner")
+	}
+}
+
+func TestStore_PassthroughRecordsScopedToOwner(t *testing.T) {
+	s := NewStore()
+	s.Apply(config.AnthropicFilesConfig{Enable: true, Mode: config.AnthropicFilesModePassthrough, Dir: t.TempDir()}, "")
+
+	if errRecord := s.RecordPassthrough("key-a", "file_a", "claude-1"); errRecord != nil {
+		t.Fatalf("RecordPassthrough error: %v", errRecord)
+	}
+	_ = s.RecordPassthrough("key-a", "file_b", "claude-2")
+	if authID, errAuth := s.PassthroughAuth("key-a", "file_a"); errAuth != nil || authID != "claude-1" {
+		t.Fatalf("PassthroughAuth = (%q, %v), want claude-1", authID, errAuth)
+	}
+	if _, errAuth := s.PassthroughAuth("key-b", "file_a"); !errors.Is(errAuth, ErrNotFound) {
+		t.Fatalf("PassthroughAuth of another owner error = %v, want ErrNotFound", errAuth)
+	}
+
+	reference := func(ids ...string) []byte {
+		blocks := make([]string, 0, len(ids))
+		for _, id := range ids {
+			blocks = append(blocks, `{"type":"document","source":{"type":"file","file_id":"`+id+`"}}`)
+		}
+		return []byte(`{"messages":[{"role":"user","content":[` + strings.Join(blocks, ",") + `]}]}`)
+	}
+	if authID, errAuth := s.PassthroughReferencesAuth("key-a", reference("file_a")); errAuth != nil || authID != "claude-1" {
+		t.Fatalf("PassthroughReferencesAuth = (%q, %v), want claude-1", authID, errAuth)
+	}
+	if _, errAuth := s.PassthroughReferencesAuth("key-b", reference("file_a")); !errors.Is(errAuth, ErrNotFound) {
+		t.Fatalf("PassthroughReferencesAuth of another owner error = %v, want ErrNotFound", errAuth)
+	}
+	if _, errAuth := s.PassthroughReferencesAuth("key-a", reference("file_a", "file_b")); !errors.Is(errAuth, ErrMixedCredentials) {
+		t.Fatalf("PassthroughReferencesAuth across credentials error = %v, want ErrMixedCredentials", errAuth)
+	}
+
+	if errForget := s.ForgetPassthrough("key-a", "file_a"); errForget != nil {
+		t.Fatalf("ForgetPassthrough error: %v", errForget)
+	}
+	if _, errAuth := s.PassthroughAuth("key-a", "file_a"); !errors.Is(errAuth, ErrNotFound) {
+		t.Fatalf("PassthroughAuth after forget error = %v, want ErrNotFound", errAuth)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newACMEManager builds the certificate manager for tls.acme. A relative cache directory is
+// resolved against the config file directory.
+func newACMEManager(cfg config.TLSACMEConfig, configFilePath string) (*autocert.Manager, error) {
+	cacheDir := cfg.CacheDir
+	if !filepath.IsAbs(cacheDir) && configFilePath != "" {
+		cacheDir = filepath.Join(filepath.Dir(configFilePath), cacheDir)
+	}
+	if errMkdir := os.MkdirAll(cacheDir, 0o700); errMkdir != nil {
+		return nil, fmt.Errorf("create tls.acme.cache-dir: %w", errMkdir)
+	}
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      cfg.Email,
+	}
+	if cfg.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	}
+	return manager, nil
+}
+
+// applyACMETLS serves certificates from manager and offers the TLS-ALPN-01 protocol, so the
+// main listener can answer challenges itself.
+func applyACMETLS(tlsConfig *tls.Config, manager *autocert.Manager) {
+	tlsConfig.GetCertificate = manager.GetCertificate
+	tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+}
+
+// startACMEHTTPChallenge serves HTTP-01 challenges on addr and redirects other plain HTTP
+// requests to HTTPS. A failure is not fatal because TLS-ALPN-01 still works on the main port.
+func startACMEHTTPChallenge(manager *autocert.Manager, addr string) *http.Server {
+	listener, errListen := net.Listen("tcp", addr)
+	if errListen != nil {
+		log.Warnf("tls.acme: HTTP-01 listener on %s unavailable, relying on TLS-ALPN-01: %v", addr, errListen)
+		return nil
+	}
+	server := &http.Server{
+		Handler:           manager.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if errServe := server.Serve(listener); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			log.Errorf("tls.acme: HTTP-01 listener on %s stopped: %v", addr, errServe)
+		}
+	}()
+	log.Infof("tls.acme: answering HTTP-01 challenges on %s", addr)
+	return server
+}
+
+// stopACMEHTTPChallenge shuts down the HTTP-01 listener if it is running.
+func (s *Server) stopACMEHTTPChallenge(ctx context.Context) {
+	if s.acmeHTTPServer == nil {
+		return
+	}
+	if errShutdown := s.acmeHTTPServer.Shutdown(ctx); errShutdown != nil {
+		log.Debugf("tls.acme: failed to stop HTTP-01 listener: %v", errShutdown)
+	}
+	s.acmeHTTPServer = nil
+}
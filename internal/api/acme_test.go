@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"golang.org/x/crypto/acme"
+)
+
+func TestNewACMEManager(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.TLS.Enable = true
+	cfg.TLS.ACME = config.TLSACMEConfig{Enable: true, Domains: []string{" Proxy.Example.com. ", "proxy.example.com"}}
+	cfg.SanitizeTLSACME()
+	if !cfg.TLS.ACME.Enable || len(cfg.TLS.ACME.Domains) != 1 || cfg.TLS.ACME.HTTPChallengeAddr != config.DefaultACMEHTTPChallengeAddr {
+		t.Fatalf("sanitized acme = %+v", cfg.TLS.ACME)
+	}
+
+	manager, errManager := newACMEManager(cfg.TLS.ACME, filepath.Join(dir, "config.yaml"))
+	if errManager != nil {
+		t.Fatalf("newACMEManager: %v", errManager)
+	}
+	if info, errStat := os.Stat(filepath.Join(dir, "acme")); errStat != nil || !info.IsDir() {
+		t.Fatalf("cache dir not created next to the config: %v", errStat)
+	}
+	if errPolicy := manager.HostPolicy(context.Background(), "proxy.example.com"); errPolicy != nil {
+		t.Fatalf("configured domain refused: %v", errPolicy)
+	}
+	if errPolicy := manager.HostPolicy(context.Background(), "other.example.com"); errPolicy == nil {
+		t.Fatal("unconfigured domain accepted")
+	}
+
+	tlsConfig := &tls.Config{NextProtos: []string{"h2", "http/1.1"}}
+	applyACMETLS(tlsConfig, manager)
+	if tlsConfig.GetCertificate == nil || tlsConfig.NextProtos[len(tlsConfig.NextProtos)-1] != acme.ALPNProto {
+		t.Fatalf("tls config = %+v", tlsConfig)
+	}
+}
+
+func TestSanitizeTLSACMERequiresTLSAndDomains(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.TLS.ACME = config.TLSACMEConfig{Enable: true, Domains: []string{"proxy.example.com"}}
+	cfg.SanitizeTLSACME()
+	if cfg.TLS.ACME.Enable {
+		t.Fatal("acme stayed enabled without tls.enable")
+	}
+	cfg.TLS.Enable = true
+	cfg.TLS.ACME = config.TLSACMEConfig{Enable: true, Domains: []string{" "}}
+	cfg.SanitizeTLSACME()
+	if cfg.TLS.ACME.Enable {
+		t.Fatal("acme stayed enabled without domains")
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/requestqueue"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// admitDetached applies the checks of UsageQuotaMiddleware, UsageBudgetMiddleware,
+// RateLimitMiddleware and RequestQueueMiddleware, in that order, to a request that does not go
+// through the HTTP router, such as a gRPC call or a batch line. c must carry the authenticated
+// client API key. Requests marked with the batch priority wait in the batch queue class.
+func (s *Server) admitDetached(c *gin.Context) (func(), *interfaces.ErrorMessage) {
+	apiKey := strings.TrimSpace(c.GetString("userApiKey"))
+
+	ok, warned, retryAfter := s.usageAccounting.Check(apiKey)
+	if warned {
+		log.Warnf("usage quota (warn-only): key %s would have been limited", util.HideAPIKey(apiKey))
+	}
+	if !ok {
+		reportQuotaExceeded(s.usageAccounting, apiKey, retryAfter)
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusTooManyRequests,
+			Error:      errors.New(errQuotaExceeded),
+			Addon:      http.Header{"Retry-After": []string{ceilSeconds(retryAfter)}},
+		}
+	}
+
+	if status, withinBudget := s.usageAccounting.CheckBudget(apiKey); !withinBudget {
+		status.APIKey = util.HideAPIKey(status.APIKey)
+		reportBudgetExceeded(s.usageAccounting, status)
+		body, _ := json.Marshal(budgetExceededBody(status))
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusPaymentRequired,
+			Error:      errors.New(string(body)),
+			Addon:      http.Header{"Retry-After": []string{ceilSeconds(status.ResetsAt.Sub(clock.Default().Now()))}},
+		}
+	}
+
+	var release []func()
+	if s.rateLimiter != nil {
+		allowed, limitWarned, wait := s.rateLimiter.Check(apiKey)
+		if limitWarned {
+			log.Warnf("rate limit (warn-only): key %s would have been limited", util.HideAPIKey(apiKey))
+		}
+		if !allowed {
+			errLimited := &handlers.RateLimitError{RetryAfter: wait}
+			return nil, &interfaces.ErrorMessage{StatusCode: errLimited.StatusCode(), Error: errLimited, Addon: errLimited.Headers()}
+		}
+		s.rateLimiter.Begin(apiKey)
+		release = append(release, func() { s.rateLimiter.End(apiKey) })
+	}
+
+	var releaseSlot func()
+	var errAcquire error
+	if c.GetString(handlers.RequestPriorityGinKey) == config.RequestPriorityBatch {
+		releaseSlot, errAcquire = s.requestQueue.AcquireClass(c.Request.Context(), apiKey, requestqueue.Batch)
+	} else {
+		releaseSlot, errAcquire = s.requestQueue.Acquire(c.Request.Context(), apiKey)
+	}
+	if errAcquire != nil {
+		for _, fn := range release {
+			fn()
+		}
+		var errQueue *requestqueue.Error
+		if !errors.As(errAcquire, &errQueue) {
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusRequestTimeout, Error: errAcquire}
+		}
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: errQueue.StatusCode(),
+			Error:      errQueue,
+			Addon:      http.Header{"Retry-After": []string{ceilSeconds(errQueue.RetryAfter())}},
+		}
+	}
+	release = append(release, releaseSlot)
+
+	return func() {
+		for i := len(release) - 1; i >= 0; i-- {
+			release[i]()
+		}
+	}, nil
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+)
+
+func TestAdmitDetachedAppliesRateLimit(t *testing.T) {
+	s := &Server{rateLimiter: ratelimit.NewLimiter(config.RateLimitConfig{Enable: true, RPS: 0.5, Burst: 1})}
+	ginCtx := func() *gin.Context {
+		ctx := handlers.DetachedContext(context.Background(), http.MethodPost, "/v1/chat/completions", nil, map[string]any{"userApiKey": "key-a"})
+		return ctx.Value("gin").(*gin.Context)
+	}
+
+	release, errMsg := s.admitDetached(ginCtx())
+	if errMsg != nil {
+		t.Fatalf("first request rejected: %v", errMsg.Error)
+	}
+	if got := s.rateLimiter.InFlight("key-a"); got != 1 {
+		t.Fatalf("in flight = %d, want 1 while admitted", got)
+	}
+	release()
+	if got := s.rateLimiter.InFlight("key-a"); got != 0 {
+		t.Fatalf("in flight = %d after release, want 0", got)
+	}
+
+	_, errMsg = s.admitDetached(ginCtx())
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests || errMsg.Addon.Get("Retry-After") == "" {
+		t.Fatalf("second request = %+v, want 429 with Retry-After", errMsg)
+	}
+}
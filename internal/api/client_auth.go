@@ -0,0 +1,32 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// applyClientAuthTLS configures client certificate verification on tlsConfig according to
+// tls.client-auth. Certificates that fail verification abort the handshake.
+func applyClientAuthTLS(tlsConfig *tls.Config, clientAuth config.TLSClientAuthConfig) error {
+	if tlsConfig == nil || !clientAuth.Enabled() {
+		return nil
+	}
+	pem, errRead := os.ReadFile(clientAuth.CA)
+	if errRead != nil {
+		return fmt.Errorf("read tls.client-auth.ca: %w", errRead)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("tls.client-auth.ca %s contains no certificates", clientAuth.CA)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if clientAuth.Mode == config.TLSClientAuthRequire {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// ConfigModule receives configuration updates after config.yaml is reloaded.
+// oldCfg is nil for the initial notification made when the server is built.
+type ConfigModule interface {
+	OnConfigUpdated(oldCfg, newCfg *config.Config)
+}
+
+// ConfigModuleFunc adapts a function to the ConfigModule interface.
+type ConfigModuleFunc func(oldCfg, newCfg *config.Config)
+
+// OnConfigUpdated calls f(oldCfg, newCfg).
+func (f ConfigModuleFunc) OnConfigUpdated(oldCfg, newCfg *config.Config) {
+	if f != nil {
+		f(oldCfg, newCfg)
+	}
+}
+
+// WithConfigModule registers modules notified on every configuration reload.
+func WithConfigModule(modules ...ConfigModule) ServerOption {
+	return func(cfg *serverOptionConfig) {
+		cfg.configModules = append(cfg.configModules, modules...)
+	}
+}
+
+// RegisterConfigModule adds a module notified on every configuration reload.
+// The module is immediately notified with the current configuration.
+func (s *Server) RegisterConfigModule(module ConfigModule) {
+	if s == nil || module == nil {
+		return
+	}
+	s.configModulesMu.Lock()
+	s.configModules = append(s.configModules, module)
+	cfg := s.cfg
+	s.configModulesMu.Unlock()
+	if cfg != nil {
+		notifyConfigModule(module, nil, cfg)
+	}
+}
+
+// notifyConfigModules delivers a configuration update to every registered module.
+func (s *Server) notifyConfigModules(oldCfg, newCfg *config.Config) {
+	if s == nil || newCfg == nil {
+		return
+	}
+	s.configModulesMu.Lock()
+	modules := append([]ConfigModule(nil), s.configModules...)
+	s.configModulesMu.Unlock()
+	for _, module := range modules {
+		notifyConfigModule(module, oldCfg, newCfg)
+	}
+}
+
+func notifyConfigModule(module ConfigModule, oldCfg, newCfg *config.Config) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Errorf("config module %T panicked during config update: %v", module, recovered)
+		}
+	}()
+	module.OnConfigUpdated(oldCfg, newCfg)
+}
+
+// restartRequiredChanges lists reloaded settings that only take effect after a restart
+// because they are bound when the listeners are opened.
+func restartRequiredChanges(oldCfg, newCfg *config.Config) []string {
+	if oldCfg == nil || newCfg == nil {
+		return nil
+	}
+	var changes []string
+	if strings.TrimSpace(oldCfg.Host) != strings.TrimSpace(newCfg.Host) {
+		changes = append(changes, "host")
+	}
+	if oldCfg.Port != newCfg.Port {
+		changes = append(changes, "port")
+	}
+	if !reflect.DeepEqual(oldCfg.TLS, newCfg.TLS) {
+		changes = append(changes, "tls")
+	}
+	if oldCfg.Listen != newCfg.Listen {
+		changes = append(changes, "listen")
+	}
+	if oldCfg.HTTP2.H2C != newCfg.HTTP2.H2C || oldCfg.HTTP2.MaxConcurrentStreams != newCfg.HTTP2.MaxConcurrentStreams {
+		changes = append(changes, "http2")
+	}
+	if oldCfg.Shutdown.ReusePort != newCfg.Shutdown.ReusePort {
+		changes = append(changes, "shutdown.reuse-port")
+	}
+	return changes
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	proxyconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestConfigModulesNotifiedOnUpdate(t *testing.T) {
+	type update struct {
+		oldPort int
+		newPort int
+		initial bool
+	}
+	var updates []update
+	module := ConfigModuleFunc(func(oldCfg, newCfg *proxyconfig.Config) {
+		entry := update{newPort: newCfg.Port, initial: oldCfg == nil}
+		if oldCfg != nil {
+			entry.oldPort = oldCfg.Port
+		}
+		updates = append(updates, entry)
+	})
+
+	server := newTestServerWithOptions(t, WithConfigModule(module))
+	if len(updates) != 1 || !updates[0].initial {
+		t.Fatalf("updates after NewServer = %+v, want one initial notification", updates)
+	}
+
+	next := *server.cfg
+	next.Port = 9999
+	server.UpdateClients(&next)
+	if len(updates) != 2 {
+		t.Fatalf("updates after reload = %d, want 2", len(updates))
+	}
+	if got := updates[1]; got.initial || got.oldPort != 0 || got.newPort != 9999 {
+		t.Fatalf("reload update = %+v, want old port 0 and new port 9999", got)
+	}
+
+	var late int
+	server.RegisterConfigModule(ConfigModuleFunc(func(oldCfg, newCfg *proxyconfig.Config) { late++ }))
+	if late != 1 {
+		t.Fatalf("late module notifications = %d, want 1", late)
+	}
+}
+
+func TestConfigModulePanicIsContained(t *testing.T) {
+	server := newTestServer(t)
+	server.RegisterConfigModule(ConfigModuleFunc(func(oldCfg, newCfg *proxyconfig.Config) { panic("boom") }))
+	next := *server.cfg
+	server.UpdateClients(&next)
+}
+
+func TestRestartRequiredChanges(t *testing.T) {
+	oldCfg := &proxyconfig.Config{Host: "127.0.0.1", Port: 8317}
+	newCfg := *oldCfg
+	if got := restartRequiredChanges(oldCfg, &newCfg); len(got) != 0 {
+		t.Fatalf("restartRequiredChanges(unchanged) = %v, want none", got)
+	}
+	newCfg.Port = 8318
+	newCfg.Listen.UnixSocket = "/run/cliproxy.sock"
+	newCfg.Shutdown.ReusePort = true
+	want := []string{"port", "listen", "shutdown.reuse-port"}
+	if got := restartRequiredChanges(oldCfg, &newCfg); !reflect.DeepEqual(got, want) {
+		t.Fatalf("restartRequiredChanges = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestServeDashboard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name       string
+		cfg        config.Config
+		management bool
+		want       int
+	}{
+		{name: "enabled", management: true, want: http.StatusOK},
+		{name: "management disabled", management: false, want: http.StatusNotFound},
+		{name: "control panel disabled", cfg: config.Config{RemoteManagement: config.RemoteManagement{DisableControlPanel: true}}, management: true, want: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		cfg := tc.cfg
+		s := &Server{cfg: &cfg}
+		s.managementRoutesEnabled.Store(tc.management)
+		engine := gin.New()
+		engine.GET("/dashboard", s.serveDashboard)
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+		if rec.Code != tc.want {
+			t.Fatalf("%s: status = %d, want %d", tc.name, rec.Code, tc.want)
+		}
+		if tc.want == http.StatusOK {
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+				t.Fatalf("%s: content type = %q", tc.name, ct)
+			}
+			if !strings.Contains(rec.Body.String(), "/v0/management/bandwidth") {
+				t.Fatalf("%s: dashboard page missing management API calls", tc.name)
+			}
+		}
+	}
+}
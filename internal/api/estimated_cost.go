@@ -0,0 +1,90 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// EstimatedCostHeader carries the estimated USD cost of the upstream usage behind a response.
+const EstimatedCostHeader = "X-Estimated-Cost"
+
+// EstimatedCostMiddleware returns a Gin middleware that attaches a usage cost tracker to the request
+// and reports the accumulated cost in the X-Estimated-Cost response header. Streaming responses
+// commit their headers before usage is known and therefore carry no estimate.
+func EstimatedCostMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tracker := &usage.CostTracker{}
+		c.Set(usage.CostTrackerGinKey, tracker)
+		c.Writer = &estimatedCostWriter{ResponseWriter: c.Writer, tracker: tracker}
+		c.Next()
+	}
+}
+
+type estimatedCostWriter struct {
+	gin.ResponseWriter
+	tracker *usage.CostTracker
+	applied bool
+}
+
+func (w *estimatedCostWriter) applyHeader() {
+	if w.applied {
+		return
+	}
+	w.applied = true
+	if w.ResponseWriter.Written() {
+		return
+	}
+	if cost, ok := w.tracker.Total(); ok {
+		w.Header().Set(EstimatedCostHeader, strconv.FormatFloat(cost, 'f', 6, 64))
+	}
+}
+
+func (w *estimatedCostWriter) WriteHeader(code int) {
+	w.applyHeader()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *estimatedCostWriter) WriteHeaderNow() {
+	w.applyHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *estimatedCostWriter) Write(data []byte) (int, error) {
+	w.applyHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *estimatedCostWriter) WriteString(s string) (int, error) {
+	w.applyHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// applyModelPricingConfig installs configured model prices as registry pricing overrides.
+func applyModelPricingConfig(cfg *config.Config) {
+	var overrides map[string]registry.ModelPricing
+	if cfg != nil && len(cfg.ModelPricing) > 0 {
+		overrides = make(map[string]registry.ModelPricing, len(cfg.ModelPricing))
+		for _, entry := range cfg.ModelPricing {
+			overrides[entry.Model] = registry.ModelPricing{
+				InputPerMillion:      entry.InputPerMillion,
+				OutputPerMillion:     entry.OutputPerMillion,
+				CacheReadPerMillion:  entry.CacheReadPerMillion,
+				CacheWritePerMillion: entry.CacheWritePerMillion,
+			}
+		}
+	}
+	registry.SetModelPricingOverrides(overrides)
+}
+
+// applyModelCatalogConfig points the registry at the configured catalog URL and override file.
+func applyModelCatalogConfig(cfg *config.Config) {
+	var catalog config.ModelCatalogConfig
+	if cfg != nil {
+		catalog = cfg.ModelCatalog
+	}
+	registry.SetModelCatalogSource(catalog.URL, catalog.OverrideFile)
+}
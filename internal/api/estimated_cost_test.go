@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestEstimatedCostMiddleware_SetsHeaderFromTrackedCost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(EstimatedCostMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		value, _ := c.Get(coreusage.CostTrackerGinKey)
+		tracker := value.(*coreusage.CostTracker)
+		tracker.Add(0.0125)
+		tracker.Add(0.0005)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	engine.POST("/v1/unpriced", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	if got := rec.Header().Get(EstimatedCostHeader); got != "0.013000" {
+		t.Fatalf("%s = %q, want %q", EstimatedCostHeader, got, "0.013000")
+	}
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/unpriced", nil))
+	if got := rec.Header().Get(EstimatedCostHeader); got != "" {
+		t.Fatalf("%s = %q, want empty for unpriced usage", EstimatedCostHeader, got)
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers/openai"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// grpcGracefulStopTimeout bounds how long running streams may finish when the gRPC
+// listener is stopped or moved to a new address.
+const grpcGracefulStopTimeout = 10 * time.Second
+
+// grpcIngress runs the gRPC listener serving the chat completions service, restarting it
+// when its address or TLS settings change.
+type grpcIngress struct {
+	mu       sync.Mutex
+	handler  *openai.OpenAIGRPCHandler
+	server   *grpc.Server
+	settings grpcIngressSettings
+	// getCertificate serves ACME-managed certificates when tls.acme is enabled.
+	getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+type grpcIngressSettings struct {
+	addr string
+	tls  grpcTLSSettings
+	// reusePort opens the listener with SO_REUSEPORT, like the main listener, so a reuse-port
+	// upgrade can bind the gRPC port while the old process drains.
+	reusePort bool
+}
+
+// grpcTLSSettings holds the certificate files of the gRPC listener; it is comparable so Apply
+// can detect changes. Client certificate authentication is only offered on the HTTP listener.
+type grpcTLSSettings struct {
+	Enable bool
+	Cert   string
+	Key    string
+	ACME   bool
+}
+
+func newGRPCIngress(handler *openai.OpenAIGRPCHandler) *grpcIngress {
+	return &grpcIngress{handler: handler}
+}
+
+// Apply starts, restarts or stops the listener to match cfg.
+func (g *grpcIngress) Apply(cfg *config.Config) {
+	if g == nil || cfg == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !cfg.GRPC.Enable {
+		g.stopLocked()
+		return
+	}
+	host := cfg.GRPC.Host
+	if host == "" {
+		host = cfg.Host
+	}
+	settings := grpcIngressSettings{addr: net.JoinHostPort(host, fmt.Sprint(cfg.GRPC.Port)), reusePort: cfg.Shutdown.ReusePort}
+	if cfg.TLS.Enable {
+		settings.tls = grpcTLSSettings{Enable: true, Cert: cfg.TLS.Cert, Key: cfg.TLS.Key, ACME: cfg.TLS.ACME.Enable}
+	}
+	if g.server != nil && g.settings == settings {
+		return
+	}
+	g.stopLocked()
+	if errStart := g.startLocked(settings); errStart != nil {
+		log.Errorf("grpc: failed to start listener on %s: %v", settings.addr, errStart)
+	}
+}
+
+// SetCertificateSource makes the listener use the certificates of the HTTPS server's ACME
+// manager instead of the tls.cert and tls.key files.
+func (g *grpcIngress) SetCertificateSource(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.getCertificate = getCertificate
+}
+
+// Stop stops the listener, letting running requests finish for a bounded time.
+func (g *grpcIngress) Stop() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopLocked()
+}
+
+func (g *grpcIngress) startLocked(settings grpcIngressSettings) error {
+	var opts []grpc.ServerOption
+	switch {
+	case settings.tls.ACME:
+		if g.getCertificate == nil {
+			return fmt.Errorf("ACME certificates are not available yet")
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{GetCertificate: g.getCertificate})))
+	case settings.tls.Enable:
+		creds, errCreds := credentials.NewServerTLSFromFile(strings.TrimSpace(settings.tls.Cert), strings.TrimSpace(settings.tls.Key))
+		if errCreds != nil {
+			return errCreds
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	listener, errListen := listenTCP(settings.addr, settings.reusePort)
+	if errListen != nil {
+		return errListen
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(grpcRecoverUnary), grpc.ChainStreamInterceptor(grpcRecoverStream))
+	server := grpc.NewServer(opts...)
+	server.RegisterService(&openai.ChatCompletionsServiceDesc, g.handler)
+	g.server = server
+	g.settings = settings
+	go func() {
+		if errServe := server.Serve(listener); errServe != nil {
+			log.Errorf("grpc: listener on %s stopped: %v", settings.addr, errServe)
+		}
+	}()
+	log.Infof("gRPC chat completions service listening on %s", settings.addr)
+	return nil
+}
+
+func (g *grpcIngress) stopLocked() {
+	if g.server == nil {
+		return
+	}
+	server := g.server
+	g.server = nil
+	g.settings = grpcIngressSettings{}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(grpcGracefulStopTimeout):
+		server.Stop()
+	}
+}
+
+// grpcRecoverUnary turns a panic in a unary handler into an Internal status instead of taking the
+// process down, as gin.Recovery does for the HTTP listener.
+func grpcRecoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = grpcPanicStatus(info.FullMethod, recovered)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// grpcRecoverStream is the streaming counterpart of grpcRecoverUnary.
+func grpcRecoverStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = grpcPanicStatus(info.FullMethod, recovered)
+		}
+	}()
+	return handler(srv, stream)
+}
+
+func grpcPanicStatus(method string, recovered any) error {
+	log.Errorf("grpc: panic in %s: %v\n%s", method, recovered, debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
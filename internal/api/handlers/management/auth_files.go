@@ -28,6 +28,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/kimi"
 	xaiauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/xai"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/credcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginhost"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
@@ -343,7 +344,34 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 		nameJ, _ := files[j]["name"].(string)
 		return strings.ToLower(nameI) < strings.ToLower(nameJ)
 	})
-	c.JSON(200, gin.H{"files": files})
+	writeAuthFileList(c, files)
+}
+
+// writeAuthFileList applies the provider filter and the shared list query to auth file entries.
+func writeAuthFileList(c *gin.Context, files []gin.H) {
+	if provider := strings.ToLower(strings.TrimSpace(c.Query("provider"))); provider != "" {
+		kept := files[:0]
+		for _, file := range files {
+			if fileType, _ := file["type"].(string); strings.ToLower(fileType) == provider {
+				kept = append(kept, file)
+			}
+		}
+		files = kept
+	}
+	writeListPage(c, "files", files,
+		func(file gin.H) string {
+			name, _ := file["name"].(string)
+			return strings.ToLower(name)
+		},
+		func(file gin.H) string {
+			fields := make([]string, 0, 4)
+			for _, field := range []string{"name", "type", "email", "label"} {
+				if value, ok := file[field].(string); ok {
+					fields = append(fields, value)
+				}
+			}
+			return strings.Join(fields, " ")
+		})
 }
 
 func lockedAuthIndex(auth *coreauth.Auth) string {
@@ -513,7 +541,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 			files = append(files, fileData)
 		}
 	}
-	c.JSON(200, gin.H{"files": files})
+	writeAuthFileList(c, files)
 }
 
 func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
@@ -1227,6 +1255,10 @@ func (h *Handler) buildAuthFromFileData(path string, data []byte) (*coreauth.Aut
 			return nil, fmt.Errorf("failed to read auth file: %w", err)
 		}
 	}
+	data, errOpen := credcrypt.Default().OpenJSON(data)
+	if errOpen != nil {
+		return nil, fmt.Errorf("failed to decrypt auth file: %w", errOpen)
+	}
 	metadata := make(map[string]any)
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("invalid auth file: %w", err)
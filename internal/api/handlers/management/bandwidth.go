@@ -0,0 +1,57 @@
+package management
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/bandwidth"
+)
+
+// GetBandwidth reports upstream request and response bytes per provider and credential, and per
+// client API key, since the server started or the counters were last reset. Optional query
+// parameter: provider.
+func (h *Handler) GetBandwidth(c *gin.Context) {
+	meter := h.currentBandwidthMeter(c)
+	if meter == nil {
+		return
+	}
+	c.JSON(http.StatusOK, meter.ProviderSnapshot(c.Query("provider")))
+}
+
+// DeleteBandwidth resets the bandwidth counters.
+func (h *Handler) DeleteBandwidth(c *gin.Context) {
+	meter := h.currentBandwidthMeter(c)
+	if meter == nil {
+		return
+	}
+	meter.Reset()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetMetrics exposes the bandwidth counters and the count of streams cancelled by their client
+// in the Prometheus text exposition format.
+func (h *Handler) GetMetrics(c *gin.Context) {
+	meter := h.currentBandwidthMeter(c)
+	if meter == nil {
+		return
+	}
+	var body bytes.Buffer
+	errWrite := meter.Snapshot().WritePrometheus(&body)
+	if errWrite == nil && h.cancelStats != nil {
+		errWrite = h.cancelStats.Snapshot().WritePrometheus(&body)
+	}
+	if errWrite != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errWrite.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", body.Bytes())
+}
+
+func (h *Handler) currentBandwidthMeter(c *gin.Context) *bandwidth.Meter {
+	if h == nil || h.bandwidth == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil
+	}
+	return h.bandwidth
+}
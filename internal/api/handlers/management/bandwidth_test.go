@@ -0,0 +1,63 @@
+package management
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/bandwidth"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cancelstats"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestGetBandwidth_FiltersByProviderAndResets(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	meter := bandwidth.NewMeter()
+	for _, provider := range []string{"claude", "gemini"} {
+		meter.Counter(provider, provider+"-auth", "", "").ObserveRequest(&http.Request{Body: io.NopCloser(strings.NewReader("abc")), ContentLength: 3})
+	}
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, nil)
+	h.bandwidth = meter
+	cancelled := cancelstats.NewStore()
+	cancelled.Record("gpt-5", cancelstats.StageMidStream)
+	h.cancelStats = cancelled
+
+	do := func(method, target string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(rec)
+		ginCtx.Request = httptest.NewRequest(method, target, nil)
+		handler(ginCtx)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/v0/management/bandwidth?provider=Gemini", h.GetBandwidth)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var report bandwidth.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(report.Providers) != 1 || report.Providers[0].Provider != "gemini" || report.Totals.RequestBytes != 3 {
+		t.Fatalf("report = %+v, want only gemini", report)
+	}
+
+	rec = do(http.MethodGet, "/v0/management/metrics", h.GetMetrics)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `cliproxy_upstream_requests_total{provider="claude",auth_id="claude-auth"} 1`) ||
+		!strings.Contains(rec.Body.String(), `cliproxy_client_cancelled_streams_total{model="gpt-5",stage="mid_stream"} 1`) {
+		t.Fatalf("metrics status = %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	if rec = do(http.MethodDelete, "/v0/management/bandwidth", h.DeleteBandwidth); rec.Code != http.StatusOK {
+		t.Fatalf("reset status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if report = meter.Snapshot(); len(report.Providers) != 0 {
+		t.Fatalf("providers after reset = %+v, want none", report.Providers)
+	}
+}
@@ -221,6 +221,81 @@ func (h *Handler) PutLogsMaxTotalSizeMB(c *gin.Context) {
 	h.persist(c)
 }
 
+// LogOutput
+func (h *Handler) GetLogOutput(c *gin.Context) {
+	c.JSON(200, gin.H{"log-output": h.cfg.LogOutput})
+}
+
+// PutLogOutput replaces the log-output section. Changes take effect on the config reload that
+// follows the save, without a restart.
+func (h *Handler) PutLogOutput(c *gin.Context) {
+	var body struct {
+		Value *config.LogOutputConfig `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || body.Value == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	h.applyLogOutput(c, *body.Value)
+}
+
+// PatchLogOutput updates only the log-output fields present in the request.
+func (h *Handler) PatchLogOutput(c *gin.Context) {
+	var body struct {
+		Value *struct {
+			Format      *string `json:"format"`
+			MaxSizeMB   *int    `json:"max-size-mb"`
+			MaxAgeDays  *int    `json:"max-age-days"`
+			MaxBackups  *int    `json:"max-backups"`
+			Compress    *bool   `json:"compress"`
+			ErrorOutput *string `json:"error-output"`
+		} `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || body.Value == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	next := h.cfg.LogOutput
+	if body.Value.Format != nil {
+		next.Format = *body.Value.Format
+	}
+	if body.Value.MaxSizeMB != nil {
+		next.MaxSizeMB = *body.Value.MaxSizeMB
+	}
+	if body.Value.MaxAgeDays != nil {
+		next.MaxAgeDays = *body.Value.MaxAgeDays
+	}
+	if body.Value.MaxBackups != nil {
+		next.MaxBackups = *body.Value.MaxBackups
+	}
+	if body.Value.Compress != nil {
+		next.Compress = *body.Value.Compress
+	}
+	if body.Value.ErrorOutput != nil {
+		next.ErrorOutput = *body.Value.ErrorOutput
+	}
+	h.applyLogOutput(c, next)
+}
+
+func (h *Handler) applyLogOutput(c *gin.Context, next config.LogOutputConfig) {
+	if _, ok := config.NormalizeLogFormat(next.Format); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format"})
+		return
+	}
+	if next.MaxSizeMB < 0 || next.MaxAgeDays < 0 || next.MaxBackups < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rotation limits must not be negative"})
+		return
+	}
+	errorOutput := strings.TrimSpace(next.ErrorOutput)
+	if errorOutput != "" && !strings.EqualFold(errorOutput, config.LogErrorOutputStderr) && filepath.Base(errorOutput) != errorOutput {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error-output must be stderr or a file name inside the logs directory"})
+		return
+	}
+	h.cfg.LogOutput = next
+	h.cfg.SanitizeLogOutput()
+	h.persist(c)
+}
+
 // ErrorLogsMaxFiles
 func (h *Handler) GetErrorLogsMaxFiles(c *gin.Context) {
 	c.JSON(200, gin.H{"error-logs-max-files": h.cfg.ErrorLogsMaxFiles})
@@ -11,22 +11,10 @@ import (
 
 // Generic helpers for list[string]
 func (h *Handler) putStringList(c *gin.Context, set func([]string), after func()) {
-	data, err := c.GetRawData()
-	if err != nil {
-		c.JSON(400, gin.H{"error": "failed to read body"})
+	arr, ok := readStringItems(c)
+	if !ok {
 		return
 	}
-	var arr []string
-	if err = json.Unmarshal(data, &arr); err != nil {
-		var obj struct {
-			Items []string `json:"items"`
-		}
-		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
-			c.JSON(400, gin.H{"error": "invalid body"})
-			return
-		}
-		arr = obj.Items
-	}
 	set(arr)
 	if after != nil {
 		after()
@@ -75,6 +63,9 @@ func (h *Handler) patchStringList(c *gin.Context, target *[]string, after func()
 }
 
 func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if idxStr := c.Query("index"); idxStr != "" {
 		var idx int
 		_, err := fmt.Sscanf(idxStr, "%d", &idx)
@@ -83,7 +74,7 @@ func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after f
 			if after != nil {
 				after()
 			}
-			h.persist(c)
+			h.persistLocked(c)
 			return
 		}
 	}
@@ -98,14 +89,92 @@ func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after f
 		if after != nil {
 			after()
 		}
-		h.persist(c)
+		h.persistLocked(c)
 		return
 	}
 	c.JSON(400, gin.H{"error": "missing index or value"})
 }
 
+// readStringItems reads a JSON array of strings or an {"items": [...]} object from the body.
+func readStringItems(c *gin.Context) ([]string, bool) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return nil, false
+	}
+	var arr []string
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []string `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return nil, false
+		}
+		arr = obj.Items
+	}
+	return arr, true
+}
+
+// bulkAddToStringList appends every value from the body that is not already present.
+func (h *Handler) bulkAddToStringList(c *gin.Context, target *[]string, after func()) {
+	values, ok := readStringItems(c)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seen := make(map[string]struct{}, len(*target)+len(values))
+	for _, v := range *target {
+		seen[strings.TrimSpace(v)] = struct{}{}
+	}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if _, exists := seen[v]; exists {
+			continue
+		}
+		seen[v] = struct{}{}
+		*target = append(*target, v)
+	}
+	if after != nil {
+		after()
+	}
+	h.persistLocked(c)
+}
+
+// bulkDeleteFromStringList removes every value listed in the body.
+func (h *Handler) bulkDeleteFromStringList(c *gin.Context, target *[]string, after func()) {
+	values, ok := readStringItems(c)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
+	remove := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		remove[strings.TrimSpace(v)] = struct{}{}
+	}
+	out := make([]string, 0, len(*target))
+	for _, v := range *target {
+		if _, drop := remove[strings.TrimSpace(v)]; !drop {
+			out = append(out, v)
+		}
+	}
+	*target = out
+	if after != nil {
+		after()
+	}
+	h.persistLocked(c)
+}
+
 // api-keys
-func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.cfg.APIKeys}) }
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	writeListPage(c, "api-keys", h.cfg.APIKeys, func(key string) string { return key }, func(key string) string { return key })
+}
 func (h *Handler) PutAPIKeys(c *gin.Context) {
 	h.putStringList(c, func(v []string) {
 		h.cfg.APIKeys = append([]string(nil), v...)
@@ -117,10 +186,20 @@ func (h *Handler) PatchAPIKeys(c *gin.Context) {
 func (h *Handler) DeleteAPIKeys(c *gin.Context) {
 	h.deleteFromStringList(c, &h.cfg.APIKeys, func() {})
 }
+func (h *Handler) PostAPIKeysBulk(c *gin.Context) {
+	h.bulkAddToStringList(c, &h.cfg.APIKeys, nil)
+}
+func (h *Handler) DeleteAPIKeysBulk(c *gin.Context) {
+	h.bulkDeleteFromStringList(c, &h.cfg.APIKeys, nil)
+}
 
 // gemini-api-key: []GeminiKey
 func (h *Handler) GetGeminiKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"gemini-api-key": h.geminiKeysWithAuthIndex()})
+	writeListPage(c, "gemini-api-key", h.geminiKeysWithAuthIndex(),
+		func(k geminiKeyWithAuthIndex) string { return providerKeyListKey(k.BaseURL, k.APIKey) },
+		func(k geminiKeyWithAuthIndex) string {
+			return strings.Join([]string{k.APIKey, k.BaseURL, k.Prefix}, " ")
+		})
 }
 func (h *Handler) PutGeminiKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -220,6 +299,7 @@ func (h *Handler) PatchGeminiKey(c *gin.Context) {
 func (h *Handler) DeleteGeminiKey(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if val := strings.TrimSpace(c.Query("api-key")); val != "" {
 		if baseRaw, okBase := c.GetQuery("base-url"); okBase {
 			base := strings.TrimSpace(baseRaw)
@@ -277,7 +357,11 @@ func (h *Handler) DeleteGeminiKey(c *gin.Context) {
 
 // interactions-api-key: []GeminiKey
 func (h *Handler) GetInteractionsKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"interactions-api-key": h.interactionsKeysWithAuthIndex()})
+	writeListPage(c, "interactions-api-key", h.interactionsKeysWithAuthIndex(),
+		func(k geminiKeyWithAuthIndex) string { return providerKeyListKey(k.BaseURL, k.APIKey) },
+		func(k geminiKeyWithAuthIndex) string {
+			return strings.Join([]string{k.APIKey, k.BaseURL, k.Prefix}, " ")
+		})
 }
 func (h *Handler) PutInteractionsKeys(c *gin.Context) {
 	data, errRead := c.GetRawData()
@@ -380,6 +464,7 @@ func (h *Handler) PatchInteractionsKey(c *gin.Context) {
 func (h *Handler) DeleteInteractionsKey(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if val := strings.TrimSpace(c.Query("api-key")); val != "" {
 		if baseRaw, okBase := c.GetQuery("base-url"); okBase {
 			base := strings.TrimSpace(baseRaw)
@@ -438,7 +523,11 @@ func (h *Handler) DeleteInteractionsKey(c *gin.Context) {
 
 // claude-api-key: []ClaudeKey
 func (h *Handler) GetClaudeKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"claude-api-key": h.claudeKeysWithAuthIndex()})
+	writeListPage(c, "claude-api-key", h.claudeKeysWithAuthIndex(),
+		func(k claudeKeyWithAuthIndex) string { return providerKeyListKey(k.BaseURL, k.APIKey) },
+		func(k claudeKeyWithAuthIndex) string {
+			return strings.Join([]string{k.APIKey, k.BaseURL, k.Prefix}, " ")
+		})
 }
 func (h *Handler) PutClaudeKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -476,6 +565,7 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 		Headers                 *map[string]string    `json:"headers"`
 		ExcludedModels          *[]string             `json:"excluded-models"`
 		RebuildMidSystemMessage *bool                 `json:"rebuild-mid-system-message"`
+		Workspace               *string               `json:"workspace"`
 	}
 	var body struct {
 		Index *int            `json:"index"`
@@ -532,6 +622,9 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 	if body.Value.RebuildMidSystemMessage != nil {
 		entry.RebuildMidSystemMessage = *body.Value.RebuildMidSystemMessage
 	}
+	if body.Value.Workspace != nil {
+		entry.Workspace = strings.TrimSpace(*body.Value.Workspace)
+	}
 	normalizeClaudeKey(&entry)
 	h.cfg.ClaudeKey[targetIndex] = entry
 	h.cfg.SanitizeClaudeKeys()
@@ -541,6 +634,7 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 func (h *Handler) DeleteClaudeKey(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if val := strings.TrimSpace(c.Query("api-key")); val != "" {
 		if baseRaw, okBase := c.GetQuery("base-url"); okBase {
 			base := strings.TrimSpace(baseRaw)
@@ -593,7 +687,11 @@ func (h *Handler) DeleteClaudeKey(c *gin.Context) {
 
 // openai-compatibility: []OpenAICompatibility
 func (h *Handler) GetOpenAICompat(c *gin.Context) {
-	c.JSON(200, gin.H{"openai-compatibility": h.openAICompatibilityWithAuthIndex()})
+	writeListPage(c, "openai-compatibility", h.openAICompatibilityWithAuthIndex(),
+		func(p openAICompatibilityWithAuthIndex) string { return p.Name },
+		func(p openAICompatibilityWithAuthIndex) string {
+			return strings.Join([]string{p.Name, p.BaseURL, p.Prefix}, " ")
+		})
 }
 func (h *Handler) PutOpenAICompat(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -707,6 +805,7 @@ func (h *Handler) PatchOpenAICompat(c *gin.Context) {
 func (h *Handler) DeleteOpenAICompat(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if name := c.Query("name"); name != "" {
 		out := make([]config.OpenAICompatibility, 0, len(h.cfg.OpenAICompatibility))
 		for _, v := range h.cfg.OpenAICompatibility {
@@ -734,7 +833,11 @@ func (h *Handler) DeleteOpenAICompat(c *gin.Context) {
 
 // vertex-api-key: []VertexCompatKey
 func (h *Handler) GetVertexCompatKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"vertex-api-key": h.vertexCompatKeysWithAuthIndex()})
+	writeListPage(c, "vertex-api-key", h.vertexCompatKeysWithAuthIndex(),
+		func(k vertexCompatKeyWithAuthIndex) string { return providerKeyListKey(k.BaseURL, k.APIKey) },
+		func(k vertexCompatKeyWithAuthIndex) string {
+			return strings.Join([]string{k.APIKey, k.BaseURL, k.Prefix}, " ")
+		})
 }
 func (h *Handler) PutVertexCompatKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -853,6 +956,7 @@ func (h *Handler) PatchVertexCompatKey(c *gin.Context) {
 func (h *Handler) DeleteVertexCompatKey(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if val := strings.TrimSpace(c.Query("api-key")); val != "" {
 		if baseRaw, okBase := c.GetQuery("base-url"); okBase {
 			base := strings.TrimSpace(baseRaw)
@@ -973,6 +1077,9 @@ func (h *Handler) DeleteOAuthExcludedModels(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "missing provider"})
 		return
 	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if h.cfg.OAuthExcludedModels == nil {
 		c.JSON(404, gin.H{"error": "provider not found"})
 		return
@@ -985,7 +1092,7 @@ func (h *Handler) DeleteOAuthExcludedModels(c *gin.Context) {
 	if len(h.cfg.OAuthExcludedModels) == 0 {
 		h.cfg.OAuthExcludedModels = nil
 	}
-	h.persist(c)
+	h.persistLocked(c)
 }
 
 // oauth-model-alias: map[string][]OAuthModelAlias
@@ -1070,6 +1177,9 @@ func (h *Handler) DeleteOAuthModelAlias(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "missing channel"})
 		return
 	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if h.cfg.OAuthModelAlias == nil {
 		c.JSON(404, gin.H{"error": "channel not found"})
 		return
@@ -1082,12 +1192,16 @@ func (h *Handler) DeleteOAuthModelAlias(c *gin.Context) {
 	if len(h.cfg.OAuthModelAlias) == 0 {
 		h.cfg.OAuthModelAlias = nil
 	}
-	h.persist(c)
+	h.persistLocked(c)
 }
 
 // codex-api-key: []CodexKey
 func (h *Handler) GetCodexKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"codex-api-key": h.codexKeysWithAuthIndex()})
+	writeListPage(c, "codex-api-key", h.codexKeysWithAuthIndex(),
+		func(k codexKeyWithAuthIndex) string { return providerKeyListKey(k.BaseURL, k.APIKey) },
+		func(k codexKeyWithAuthIndex) string {
+			return strings.Join([]string{k.APIKey, k.BaseURL, k.Prefix}, " ")
+		})
 }
 func (h *Handler) PutCodexKeys(c *gin.Context) {
 	data, err := c.GetRawData()
@@ -1131,6 +1245,8 @@ func (h *Handler) PatchCodexKey(c *gin.Context) {
 		Models         *[]config.CodexModel `json:"models"`
 		Headers        *map[string]string   `json:"headers"`
 		ExcludedModels *[]string            `json:"excluded-models"`
+		Organization   *string              `json:"organization"`
+		Project        *string              `json:"project"`
 	}
 	var body struct {
 		Index *int           `json:"index"`
@@ -1191,6 +1307,12 @@ func (h *Handler) PatchCodexKey(c *gin.Context) {
 	if body.Value.ExcludedModels != nil {
 		entry.ExcludedModels = config.NormalizeExcludedModels(*body.Value.ExcludedModels)
 	}
+	if body.Value.Organization != nil {
+		entry.Organization = strings.TrimSpace(*body.Value.Organization)
+	}
+	if body.Value.Project != nil {
+		entry.Project = strings.TrimSpace(*body.Value.Project)
+	}
 	normalizeCodexKey(&entry)
 	h.cfg.CodexKey[targetIndex] = entry
 	h.cfg.SanitizeCodexKeys()
@@ -1200,6 +1322,7 @@ func (h *Handler) PatchCodexKey(c *gin.Context) {
 func (h *Handler) DeleteCodexKey(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if val := strings.TrimSpace(c.Query("api-key")); val != "" {
 		if baseRaw, okBase := c.GetQuery("base-url"); okBase {
 			base := strings.TrimSpace(baseRaw)
@@ -1252,7 +1375,9 @@ func (h *Handler) DeleteCodexKey(c *gin.Context) {
 
 // xai-api-key: []XAIKey
 func (h *Handler) GetXAIKeys(c *gin.Context) {
-	c.JSON(200, gin.H{"xai-api-key": h.xaiKeysWithAuthIndex()})
+	writeListPage(c, "xai-api-key", h.xaiKeysWithAuthIndex(),
+		func(k xaiKeyWithAuthIndex) string { return providerKeyListKey(k.BaseURL, k.APIKey) },
+		func(k xaiKeyWithAuthIndex) string { return strings.Join([]string{k.APIKey, k.BaseURL, k.Prefix}, " ") })
 }
 
 func (h *Handler) PutXAIKeys(c *gin.Context) {
@@ -1378,6 +1503,7 @@ func (h *Handler) PatchXAIKey(c *gin.Context) {
 func (h *Handler) DeleteXAIKey(c *gin.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
 	if val := strings.TrimSpace(c.Query("api-key")); val != "" {
 		if baseRaw, okBase := c.GetQuery("base-url"); okBase {
 			base := strings.TrimSpace(baseRaw)
@@ -0,0 +1,53 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestPatchLogOutputKeepsUnsetFields(t *testing.T) {
+	h := &Handler{
+		cfg:            &config.Config{LogOutput: config.LogOutputConfig{Format: config.LogFormatPretty, MaxSizeMB: 10, Compress: true}},
+		configFilePath: writeTestConfigFile(t),
+	}
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPatch, "/v0/management/log-output", strings.NewReader(`{"value": {"format": "JSON", "error-output": "app-error.log"}}`))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+
+	h.PatchLogOutput(ctx)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	want := config.LogOutputConfig{Format: config.LogFormatJSON, MaxSizeMB: 10, Compress: true, ErrorOutput: "app-error.log"}
+	if got := h.cfg.LogOutput; got != want {
+		t.Fatalf("log-output = %+v, want %+v", got, want)
+	}
+}
+
+func TestPutLogOutputRejectsInvalidValues(t *testing.T) {
+	for _, body := range []string{
+		`{"value": {"format": "xml"}}`,
+		`{"value": {"max-backups": -1}}`,
+		`{"value": {"error-output": "../escape.log"}}`,
+	} {
+		h := &Handler{cfg: &config.Config{}, configFilePath: writeTestConfigFile(t)}
+		rec := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rec)
+		ctx.Request = httptest.NewRequest(http.MethodPut, "/v0/management/log-output", strings.NewReader(body))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+
+		h.PutLogOutput(ctx)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("body %s: status = %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+)
+
+// SetContentKeyring updates the keyring backing the content shredding endpoint.
+func (h *Handler) SetContentKeyring(keys *contentcrypt.Keyring) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.contentKeys = keys
+	h.mu.Unlock()
+}
+
+// PostContentShred destroys every content key of a client API key, making all batch files,
+// Anthropic file uploads, stream transcripts and stored responses it created unreadable. The sealed files themselves are left in place.
+func (h *Handler) PostContentShred(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return
+	}
+	var body struct {
+		APIKey string `json:"api-key"`
+	}
+	if errBind := c.ShouldBindJSON(&body); errBind != nil || strings.TrimSpace(body.APIKey) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api-key is required"})
+		return
+	}
+	h.mu.Lock()
+	keys := h.contentKeys
+	h.mu.Unlock()
+	if keys == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content encryption unavailable"})
+		return
+	}
+	destroyed, errShred := keys.ShredOwner(strings.TrimSpace(body.APIKey))
+	if errShred != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errShred.Error(), "destroyed": destroyed})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "destroyed": destroyed})
+}
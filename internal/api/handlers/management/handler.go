@@ -14,10 +14,22 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/bandwidth"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cancelstats"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/health"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginhost"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginstore"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/racestats"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/redaction"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/region"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/signingaudit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/transcripts"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
@@ -47,6 +59,7 @@ type Handler struct {
 	attemptsMu              sync.Mutex
 	failedAttempts          map[string]*attemptInfo // keyed by client IP
 	authManager             *coreauth.Manager
+	accessManager           *sdkaccess.Manager
 	tokenStore              coreauth.Store
 	localPassword           string
 	allowRemoteOverride     bool
@@ -55,6 +68,19 @@ type Handler struct {
 	postAuthHook            coreauth.PostAuthHook
 	postAuthPersistHook     coreauth.PostAuthHook
 	pluginHost              *pluginhost.Host
+	providerHealth          *health.Prober
+	usageAccounting         *usageaccounting.Tracker
+	bandwidth               *bandwidth.Meter
+	raceStats               *racestats.Store
+	cancelStats             *cancelstats.Store
+	transcripts             *transcripts.Store
+	signingAudit            *signingaudit.Recorder
+	redactionAudit          *redaction.Audit
+	scheduler               *scheduler.Scheduler
+	regionRouter            *region.Router
+	contentKeys             *contentcrypt.Keyring
+	trashMu                 sync.Mutex
+	trash                   *trashState
 	configReloadHook        func(context.Context, *config.Config)
 	pluginStoreRegistryURL  string
 	pluginStoreHTTPClient   pluginstore.HTTPDoer
@@ -80,6 +106,12 @@ func NewHandler(cfg *config.Config, configFilePath string, manager *coreauth.Man
 		tokenStore:          sdkAuth.GetTokenStore(),
 		allowRemoteOverride: envSecret != "",
 		envSecret:           envSecret,
+		bandwidth:           bandwidth.Default(),
+		raceStats:           racestats.Default(),
+		cancelStats:         cancelstats.Default(),
+		transcripts:         transcripts.Default(),
+		signingAudit:        signingaudit.Default(),
+		redactionAudit:      redaction.DefaultAudit(),
 	}
 	h.startAttemptCleanup()
 	return h
@@ -411,6 +443,7 @@ func (h *Handler) persistLocked(c *gin.Context) bool {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
 		return false
 	}
+	h.finishSoftDeleteLocked(c)
 	snapshot := h.reloadSnapshotConfigLocked()
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	var reqCtx context.Context
@@ -0,0 +1,115 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// model-aliases: []ModelAliasRule
+func (h *Handler) GetModelAliases(c *gin.Context) {
+	h.mu.Lock()
+	rules := append([]config.ModelAliasRule(nil), h.cfg.ModelAliases...)
+	h.mu.Unlock()
+	if rules == nil {
+		rules = []config.ModelAliasRule{}
+	}
+	c.JSON(200, gin.H{"model-aliases": rules})
+}
+
+func (h *Handler) PutModelAliases(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var arr []config.ModelAliasRule
+	if err = json.Unmarshal(data, &arr); err != nil {
+		var obj struct {
+			Items []config.ModelAliasRule `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		arr = obj.Items
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cfg.ModelAliases = arr
+	h.cfg.SanitizeModelAliases()
+	h.persistLocked(c)
+}
+
+// PatchModelAliases replaces the rule at index, or the rule whose match equals match. A match
+// that is not configured yet adds the rule.
+func (h *Handler) PatchModelAliases(c *gin.Context) {
+	var body struct {
+		Index *int                   `json:"index"`
+		Match *string                `json:"match"`
+		Value *config.ModelAliasRule `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Value == nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.ModelAliases) {
+		targetIndex = *body.Index
+	}
+	if targetIndex == -1 && body.Match != nil {
+		match := strings.TrimSpace(*body.Match)
+		for i := range h.cfg.ModelAliases {
+			if strings.EqualFold(h.cfg.ModelAliases[i].Match, match) {
+				targetIndex = i
+				break
+			}
+		}
+		if targetIndex == -1 {
+			h.cfg.ModelAliases = append(h.cfg.ModelAliases, *body.Value)
+			h.cfg.SanitizeModelAliases()
+			h.persistLocked(c)
+			return
+		}
+	}
+	if targetIndex == -1 {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+	h.cfg.ModelAliases[targetIndex] = *body.Value
+	h.cfg.SanitizeModelAliases()
+	h.persistLocked(c)
+}
+
+func (h *Handler) DeleteModelAliases(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beginSoftDeleteLocked(c)
+	if match := strings.TrimSpace(c.Query("match")); match != "" {
+		out := make([]config.ModelAliasRule, 0, len(h.cfg.ModelAliases))
+		for _, rule := range h.cfg.ModelAliases {
+			if !strings.EqualFold(rule.Match, match) {
+				out = append(out, rule)
+			}
+		}
+		h.cfg.ModelAliases = out
+		h.persistLocked(c)
+		return
+	}
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		_, err := fmt.Sscanf(idxStr, "%d", &idx)
+		if err == nil && idx >= 0 && idx < len(h.cfg.ModelAliases) {
+			h.cfg.ModelAliases = append(h.cfg.ModelAliases[:idx], h.cfg.ModelAliases[idx+1:]...)
+			h.persistLocked(c)
+			return
+		}
+	}
+	c.JSON(400, gin.H{"error": "missing match or index"})
+}
@@ -0,0 +1,57 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestModelAliasesPutPatchDelete(t *testing.T) {
+	h := &Handler{cfg: &config.Config{}, configFilePath: writeTestConfigFile(t)}
+
+	call := func(method, target, body string, handler gin.HandlerFunc) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rec)
+		ctx.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		handler(ctx)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s status = %d; body=%s", method, target, rec.Code, rec.Body.String())
+		}
+	}
+
+	call(http.MethodPut, "/v0/management/model-aliases", `[
+		{"match": " GPT-4o ", "model": "gpt-5"},
+		{"regex": "(", "model": "broken"},
+		{"regex": "^claude-(.*)$", "model": "claude-sonnet-$1", "provider": "Claude"}
+	]`, h.PutModelAliases)
+	if len(h.cfg.ModelAliases) != 2 {
+		t.Fatalf("rules after put = %+v, want invalid regex dropped", h.cfg.ModelAliases)
+	}
+	if got := h.cfg.ModelAliases[0]; got.Match != "GPT-4o" || got.Model != "gpt-5" {
+		t.Fatalf("rule 0 = %+v", got)
+	}
+	if got := h.cfg.ModelAliases[1].Provider; got != "claude" {
+		t.Fatalf("provider = %q, want claude", got)
+	}
+
+	call(http.MethodPatch, "/v0/management/model-aliases", `{"match": "gpt-4o", "value": {"match": "gpt-4o", "model": "gpt-5-mini"}}`, h.PatchModelAliases)
+	if got := h.cfg.ModelAliases[0].Model; got != "gpt-5-mini" {
+		t.Fatalf("patched model = %q", got)
+	}
+	call(http.MethodPatch, "/v0/management/model-aliases", `{"match": "o3", "value": {"match": "o3", "model": "gpt-5"}}`, h.PatchModelAliases)
+	if len(h.cfg.ModelAliases) != 3 || h.cfg.ModelAliases[2].Match != "o3" {
+		t.Fatalf("rules after upsert = %+v", h.cfg.ModelAliases)
+	}
+
+	call(http.MethodDelete, "/v0/management/model-aliases?match=GPT-4O", "", h.DeleteModelAliases)
+	call(http.MethodDelete, "/v0/management/model-aliases?index=0", "", h.DeleteModelAliases)
+	if len(h.cfg.ModelAliases) != 1 || h.cfg.ModelAliases[0].Match != "o3" {
+		t.Fatalf("rules after delete = %+v", h.cfg.ModelAliases)
+	}
+}
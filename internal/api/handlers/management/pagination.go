@@ -0,0 +1,116 @@
+package management
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listQuery holds the filter and pagination parameters shared by list endpoints:
+// q matches items case-insensitively, limit bounds the page size and cursor resumes
+// after the last item of the previous page.
+type listQuery struct {
+	search string
+	limit  int
+	after  string
+}
+
+// listCursor is the opaque pagination cursor. It records the sort key of the last
+// returned item so pages stay stable while items are added or removed.
+type listCursor struct {
+	After string `json:"after"`
+}
+
+func parseListQuery(c *gin.Context) (listQuery, error) {
+	query := listQuery{search: strings.ToLower(strings.TrimSpace(c.Query("q")))}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		return listQuery{}, fmt.Errorf("invalid limit: %w", errLimit)
+	}
+	query.limit = limit
+	if raw := strings.TrimSpace(c.Query("cursor")); raw != "" {
+		after, errCursor := decodeListCursor(raw)
+		if errCursor != nil {
+			return listQuery{}, errCursor
+		}
+		query.after = after
+	}
+	return query, nil
+}
+
+func (q listQuery) paged() bool {
+	return q.limit > 0 || q.after != ""
+}
+
+func (q listQuery) matches(text string) bool {
+	return q.search == "" || strings.Contains(strings.ToLower(text), q.search)
+}
+
+func encodeListCursor(after string) string {
+	raw, _ := json.Marshal(listCursor{After: after})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeListCursor(raw string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor encoding")
+	}
+	var cursor listCursor
+	if errUnmarshal := json.Unmarshal(data, &cursor); errUnmarshal != nil || cursor.After == "" {
+		return "", fmt.Errorf("invalid cursor payload")
+	}
+	return cursor.After, nil
+}
+
+// pageList filters items by the search term and, when the query is paged, orders them by
+// key and returns the page following the cursor together with the cursor of the next page.
+// Unpaged queries keep the original item order.
+func pageList[T any](items []T, query listQuery, key func(T) string, text func(T) string) (page []T, total int, next string) {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if query.matches(text(item)) {
+			filtered = append(filtered, item)
+		}
+	}
+	if !query.paged() {
+		return filtered, len(filtered), ""
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return key(filtered[i]) < key(filtered[j]) })
+	start := 0
+	if query.after != "" {
+		start = sort.Search(len(filtered), func(i int) bool { return key(filtered[i]) > query.after })
+	}
+	end := len(filtered)
+	if query.limit > 0 && start+query.limit < end {
+		end = start + query.limit
+		next = encodeListCursor(key(filtered[end-1]))
+	}
+	return filtered[start:end], len(filtered), next
+}
+
+// writeListPage responds with items under field. Paged responses also carry the filtered
+// total and next-cursor, which is empty on the last page.
+func writeListPage[T any](c *gin.Context, field string, items []T, key func(T) string, text func(T) string) {
+	query, errQuery := parseListQuery(c)
+	if errQuery != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errQuery.Error()})
+		return
+	}
+	page, total, next := pageList(items, query, key, text)
+	if !query.paged() {
+		c.JSON(http.StatusOK, gin.H{field: page})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{field: page, "total": total, "next-cursor": next})
+}
+
+// providerKeyListKey orders provider credentials by base URL and key.
+func providerKeyListKey(baseURL, apiKey string) string {
+	return baseURL + "|" + apiKey
+}
@@ -0,0 +1,90 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+type apiKeysPage struct {
+	APIKeys    []string `json:"api-keys"`
+	Total      int      `json:"total"`
+	NextCursor string   `json:"next-cursor"`
+}
+
+func getAPIKeysPage(t *testing.T, h *Handler, query string) (int, apiKeysPage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/api-keys?"+query, nil)
+	h.GetAPIKeys(c)
+	var page apiKeysPage
+	if rec.Code == http.StatusOK {
+		if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &page); errUnmarshal != nil {
+			t.Fatalf("decode page: %v", errUnmarshal)
+		}
+	}
+	return rec.Code, page
+}
+
+func TestGetAPIKeysPaginatesWithStableCursor(t *testing.T) {
+	t.Parallel()
+
+	h := &Handler{cfg: &config.Config{}}
+	h.cfg.APIKeys = []string{"key-d", "key-b", "key-a", "key-c", "other"}
+
+	status, first := getAPIKeysPage(t, h, "q=KEY&limit=2")
+	if status != http.StatusOK || strings.Join(first.APIKeys, ",") != "key-a,key-b" || first.Total != 4 || first.NextCursor == "" {
+		t.Fatalf("first page = %d %+v", status, first)
+	}
+
+	// Removing an already returned item must not shift the next page.
+	h.cfg.APIKeys = []string{"key-d", "key-c", "other"}
+	_, second := getAPIKeysPage(t, h, "q=key&limit=2&cursor="+first.NextCursor)
+	if strings.Join(second.APIKeys, ",") != "key-c,key-d" || second.NextCursor != "" {
+		t.Fatalf("second page = %+v", second)
+	}
+
+	if status, _ = getAPIKeysPage(t, h, "cursor=not-a-cursor"); status != http.StatusBadRequest {
+		t.Fatalf("invalid cursor status = %d", status)
+	}
+}
+
+func TestGetAPIKeysUnpagedKeepsOrder(t *testing.T) {
+	t.Parallel()
+
+	h := &Handler{cfg: &config.Config{}}
+	h.cfg.APIKeys = []string{"b", "a"}
+	_, page := getAPIKeysPage(t, h, "")
+	if strings.Join(page.APIKeys, ",") != "b,a" {
+		t.Fatalf("api-keys = %v", page.APIKeys)
+	}
+}
+
+func TestAPIKeysBulkAddAndDelete(t *testing.T) {
+	t.Parallel()
+
+	h := &Handler{cfg: &config.Config{}, configFilePath: writeTestConfigFile(t)}
+	h.cfg.APIKeys = []string{"a"}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/api-keys/bulk", strings.NewReader(`{"items":["b","a"," c ",""]}`))
+	h.PostAPIKeysBulk(c)
+	if rec.Code != http.StatusOK || strings.Join(h.cfg.APIKeys, ",") != "a,b,c" {
+		t.Fatalf("bulk add = %d %v", rec.Code, h.cfg.APIKeys)
+	}
+
+	rec = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/v0/management/api-keys/bulk", strings.NewReader(`["a","c","missing"]`))
+	h.DeleteAPIKeysBulk(c)
+	if rec.Code != http.StatusOK || strings.Join(h.cfg.APIKeys, ",") != "b" {
+		t.Fatalf("bulk delete = %d %v", rec.Code, h.cfg.APIKeys)
+	}
+}
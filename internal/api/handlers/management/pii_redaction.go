@@ -0,0 +1,48 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/redaction"
+)
+
+// defaultPIIRedactionAuditLimit bounds the entries GetPIIRedaction returns without a limit.
+const defaultPIIRedactionAuditLimit = 100
+
+// GetPIIRedaction reports the redaction totals per pattern and the most recent redacted
+// requests, newest first. Optional query parameter: limit (default 100).
+func (h *Handler) GetPIIRedaction(c *gin.Context) {
+	audit := h.currentRedactionAudit(c)
+	if audit == nil {
+		return
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	if limit == 0 {
+		limit = defaultPIIRedactionAuditLimit
+	}
+	c.JSON(http.StatusOK, audit.Snapshot(limit))
+}
+
+// DeletePIIRedaction clears the redaction audit log.
+func (h *Handler) DeletePIIRedaction(c *gin.Context) {
+	audit := h.currentRedactionAudit(c)
+	if audit == nil {
+		return
+	}
+	audit.Reset()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *Handler) currentRedactionAudit(c *gin.Context) *redaction.Audit {
+	if h == nil || h.redactionAudit == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil
+	}
+	return h.redactionAudit
+}
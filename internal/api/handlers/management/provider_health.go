@@ -0,0 +1,56 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/health"
+)
+
+// SetProviderHealth updates the prober backing the provider health endpoint.
+func (h *Handler) SetProviderHealth(prober *health.Prober) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.providerHealth = prober
+	h.mu.Unlock()
+}
+
+// GetProviderHealth reports reachability and auth validity for every configured credential.
+// Passing refresh=true runs a probe round before responding.
+func (h *Handler) GetProviderHealth(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return
+	}
+
+	h.mu.Lock()
+	prober := h.providerHealth
+	h.mu.Unlock()
+	if prober == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "provider health prober unavailable"})
+		return
+	}
+
+	if refresh, errParse := strconv.ParseBool(strings.TrimSpace(c.Query("refresh"))); errParse == nil && refresh {
+		prober.ProbeAll(c.Request.Context())
+	}
+
+	entries := prober.Snapshot()
+	summary := map[string]int{
+		health.StateHealthy:   0,
+		health.StateUnhealthy: 0,
+		health.StateDisabled:  0,
+		health.StateUnknown:   0,
+	}
+	for _, entry := range entries {
+		summary[entry.State]++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"providers": entries,
+		"summary":   summary,
+	})
+}
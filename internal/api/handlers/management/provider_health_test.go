@@ -0,0 +1,83 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/health"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestGetProviderHealth_ReportsAuthState(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{
+		ID:       "codex-oauth",
+		Provider: "codex",
+		Status:   coreauth.StatusActive,
+		Metadata: map[string]any{"email": "user@example.com"},
+	}); err != nil {
+		t.Fatalf("register codex auth: %v", err)
+	}
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{
+		ID:       "claude-disabled",
+		Provider: "claude",
+		Disabled: true,
+		Status:   coreauth.StatusDisabled,
+	}); err != nil {
+		t.Fatalf("register claude auth: %v", err)
+	}
+
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, manager)
+	h.SetProviderHealth(health.NewProber(manager))
+
+	rec := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(rec)
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/v0/health/providers", nil)
+	h.GetProviderHealth(ginCtx)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var payload struct {
+		Providers []health.ProviderHealth `json:"providers"`
+		Summary   map[string]int          `json:"summary"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if len(payload.Providers) != 2 {
+		t.Fatalf("providers len = %d, want 2", len(payload.Providers))
+	}
+	if payload.Providers[0].AuthID != "claude-disabled" || payload.Providers[0].State != health.StateDisabled {
+		t.Fatalf("first provider = %+v, want disabled claude", payload.Providers[0])
+	}
+	if payload.Providers[1].AuthID != "codex-oauth" || payload.Providers[1].State != health.StateHealthy {
+		t.Fatalf("second provider = %+v, want healthy codex", payload.Providers[1])
+	}
+	if payload.Summary[health.StateHealthy] != 1 || payload.Summary[health.StateDisabled] != 1 {
+		t.Fatalf("summary = %v, want 1 healthy and 1 disabled", payload.Summary)
+	}
+}
+
+func TestGetProviderHealth_UnavailableWithoutProber(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, coreauth.NewManager(nil, nil, nil))
+
+	rec := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(rec)
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/v0/health/providers", nil)
+	h.GetProviderHealth(ginCtx)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,47 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/racestats"
+)
+
+// GetRaceStats reports the attempts, wins and latency of raced requests per model and
+// provider since the server started or the stats were last reset. Optional query parameter: model.
+func (h *Handler) GetRaceStats(c *gin.Context) {
+	store := h.currentRaceStats(c)
+	if store == nil {
+		return
+	}
+	report := store.Snapshot()
+	if model := strings.ToLower(strings.TrimSpace(c.Query("model"))); model != "" {
+		filtered := make([]racestats.Stat, 0, len(report.Stats))
+		for _, stat := range report.Stats {
+			if stat.Model == model {
+				filtered = append(filtered, stat)
+			}
+		}
+		report.Stats = filtered
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// DeleteRaceStats resets the race stats.
+func (h *Handler) DeleteRaceStats(c *gin.Context) {
+	store := h.currentRaceStats(c)
+	if store == nil {
+		return
+	}
+	store.Reset()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *Handler) currentRaceStats(c *gin.Context) *racestats.Store {
+	if h == nil || h.raceStats == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil
+	}
+	return h.raceStats
+}
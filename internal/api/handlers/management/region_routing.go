@@ -0,0 +1,77 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/region"
+)
+
+// SetRegionRouter updates the router backing the region routing endpoints.
+func (h *Handler) SetRegionRouter(router *region.Router) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.regionRouter = router
+	h.mu.Unlock()
+}
+
+// GetRegionRouting reports the regions, probe metrics and selected region of every credential
+// that lists regions. Passing refresh=true runs a probe round before responding.
+func (h *Handler) GetRegionRouting(c *gin.Context) {
+	router := h.currentRegionRouter(c)
+	if router == nil {
+		return
+	}
+	if refresh, errParse := strconv.ParseBool(strings.TrimSpace(c.Query("refresh"))); errParse == nil && refresh {
+		router.ProbeAll(c.Request.Context())
+	}
+	h.mu.Lock()
+	enabled := h.cfg != nil && h.cfg.RegionRouting.Enable
+	h.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "credentials": router.Snapshot()})
+}
+
+// PutRegionPin pins a credential to a region until the pin is cleared or the server restarts.
+// An empty region removes the manual pin.
+func (h *Handler) PutRegionPin(c *gin.Context) {
+	router := h.currentRegionRouter(c)
+	if router == nil {
+		return
+	}
+	var body struct {
+		AuthID string `json:"auth_id"`
+		Region string `json:"region"`
+	}
+	if errBind := c.ShouldBindJSON(&body); errBind != nil || strings.TrimSpace(body.AuthID) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "auth_id is required"})
+		return
+	}
+	errPin := router.Pin(strings.TrimSpace(body.AuthID), body.Region)
+	switch {
+	case errors.Is(errPin, region.ErrUnknownAuth), errors.Is(errPin, region.ErrUnknownRegion):
+		c.JSON(http.StatusNotFound, gin.H{"error": errPin.Error()})
+	case errPin != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errPin.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+func (h *Handler) currentRegionRouter(c *gin.Context) *region.Router {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil
+	}
+	h.mu.Lock()
+	router := h.regionRouter
+	h.mu.Unlock()
+	if router == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "region router unavailable"})
+	}
+	return router
+}
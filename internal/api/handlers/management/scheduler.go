@@ -0,0 +1,64 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler"
+)
+
+// SetScheduler updates the scheduler backing the scheduler endpoints.
+func (h *Handler) SetScheduler(s *scheduler.Scheduler) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.scheduler = s
+	h.mu.Unlock()
+}
+
+// GetSchedulerJobs lists the registered background jobs with their schedule and last-run status.
+func (h *Handler) GetSchedulerJobs(c *gin.Context) {
+	s := h.currentScheduler(c)
+	if s == nil {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": s.Jobs()})
+}
+
+// RunSchedulerJob starts the named job immediately. The job runs in the background; poll
+// GetSchedulerJobs for its outcome.
+func (h *Handler) RunSchedulerJob(c *gin.Context) {
+	s := h.currentScheduler(c)
+	if s == nil {
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(c.Param("name")))
+	errTrigger := s.Trigger(name)
+	switch {
+	case errors.Is(errTrigger, scheduler.ErrUnknownJob):
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown job: " + name})
+	case errors.Is(errTrigger, scheduler.ErrJobRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": "job is already running: " + name})
+	case errTrigger != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errTrigger.Error()})
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"status": "started", "job": name})
+	}
+}
+
+func (h *Handler) currentScheduler(c *gin.Context) *scheduler.Scheduler {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil
+	}
+	h.mu.Lock()
+	s := h.scheduler
+	h.mu.Unlock()
+	if s == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scheduler unavailable"})
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler"
+	"github.com/tidwall/gjson"
+)
+
+func TestRunSchedulerJob(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	s := scheduler.New()
+	t.Cleanup(s.Stop)
+	done := make(chan struct{})
+	s.Register("usage-flush", "", func(context.Context) error {
+		close(done)
+		return nil
+	})
+
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, nil)
+	h.SetScheduler(s)
+
+	run := func(name string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(rec)
+		ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v0/management/scheduler/jobs/"+name+"/run", nil)
+		ginCtx.Params = gin.Params{{Key: "name", Value: name}}
+		h.RunSchedulerJob(ginCtx)
+		return rec
+	}
+
+	if rec := run("missing"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown job status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := run("Usage-Flush"); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d body=%s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	<-done
+
+	rec := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(rec)
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/scheduler/jobs", nil)
+	h.GetSchedulerJobs(ginCtx)
+	if got := gjson.GetBytes(rec.Body.Bytes(), "jobs.0.name").String(); got != "usage-flush" {
+		t.Fatalf("jobs.0.name = %q; body=%s", got, rec.Body.String())
+	}
+}
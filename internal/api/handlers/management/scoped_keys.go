@@ -0,0 +1,112 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+// SetAccessManager wires the request access manager that owns scoped API keys.
+func (h *Handler) SetAccessManager(manager *sdkaccess.Manager) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.accessManager = manager
+	h.mu.Unlock()
+}
+
+func (h *Handler) scopedKeyManager(c *gin.Context) *sdkaccess.Manager {
+	h.mu.Lock()
+	manager := h.accessManager
+	h.mu.Unlock()
+	if manager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "scoped API keys are unavailable"})
+	}
+	return manager
+}
+
+type scopedKeyView struct {
+	sdkaccess.ScopedKey
+	Status string `json:"status"`
+}
+
+func newScopedKeyView(key sdkaccess.ScopedKey, now time.Time) scopedKeyView {
+	status := "active"
+	switch {
+	case key.RevokedAt != nil:
+		status = "revoked"
+	case !key.Active(now):
+		status = "expired"
+	}
+	key.Hash = ""
+	return scopedKeyView{ScopedKey: key, Status: status}
+}
+
+// GetScopedKeys lists the scoped API keys without their secrets.
+func (h *Handler) GetScopedKeys(c *gin.Context) {
+	manager := h.scopedKeyManager(c)
+	if manager == nil {
+		return
+	}
+	now := clock.Default().Now()
+	keys := manager.Keys()
+	views := make([]scopedKeyView, 0, len(keys))
+	for _, key := range keys {
+		views = append(views, newScopedKeyView(key, now))
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": views})
+}
+
+// PostScopedKey creates a scoped API key. The response carries the secret, which cannot be
+// retrieved again.
+func (h *Handler) PostScopedKey(c *gin.Context) {
+	manager := h.scopedKeyManager(c)
+	if manager == nil {
+		return
+	}
+	var spec sdkaccess.KeySpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if spec.ExpiresAt != nil && !spec.ExpiresAt.After(clock.Default().Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	secret, key, errCreate := manager.CreateKey(c.Request.Context(), spec)
+	if errCreate != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save key", "message": errCreate.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"key": secret, "item": newScopedKeyView(key, clock.Default().Now())})
+}
+
+// DeleteScopedKey revokes the scoped key named by ?id=. The key stays listed as revoked.
+func (h *Handler) DeleteScopedKey(c *gin.Context) {
+	manager := h.scopedKeyManager(c)
+	if manager == nil {
+		return
+	}
+	id := strings.TrimSpace(c.Query("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"})
+		return
+	}
+	key, errRevoke := manager.RevokeKey(c.Request.Context(), id)
+	switch {
+	case errors.Is(errRevoke, sdkaccess.ErrKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+	case errors.Is(errRevoke, sdkaccess.ErrKeyRevoked):
+		c.JSON(http.StatusConflict, gin.H{"error": errRevoke.Error()})
+	case errRevoke != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save key", "message": errRevoke.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"item": newScopedKeyView(key, clock.Default().Now())})
+	}
+}
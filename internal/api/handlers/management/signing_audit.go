@@ -0,0 +1,62 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/signingaudit"
+)
+
+// defaultSigningAuditLimit bounds the client requests GetSigningAudit returns without a limit.
+const defaultSigningAuditLimit = 100
+
+// GetSigningAudit lists the auth material attached to the upstream requests of the most recent
+// client requests, newest first. Optional query parameter: limit (default 100).
+func (h *Handler) GetSigningAudit(c *gin.Context) {
+	recorder := h.currentSigningAuditRecorder(c)
+	if recorder == nil {
+		return
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	if limit == 0 {
+		limit = defaultSigningAuditLimit
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": recorder.Recent(limit)})
+}
+
+// GetSigningAuditRequest returns the auth material attached to each upstream attempt of one
+// client request, identified by its request ID.
+func (h *Handler) GetSigningAuditRequest(c *gin.Context) {
+	recorder := h.currentSigningAuditRecorder(c)
+	if recorder == nil {
+		return
+	}
+	requestID := strings.TrimSpace(c.Param("request_id"))
+	entries := recorder.Lookup(requestID)
+	if len(entries) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no signing audit entries for request"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"request_id": requestID, "entries": entries})
+}
+
+func (h *Handler) currentSigningAuditRecorder(c *gin.Context) *signingaudit.Recorder {
+	if h == nil || h.signingAudit == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil
+	}
+	h.mu.Lock()
+	enabled := h.cfg != nil && h.cfg.SigningAudit.Enable
+	h.mu.Unlock()
+	if !enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "signing audit disabled"})
+		return nil
+	}
+	return h.signingAudit
+}
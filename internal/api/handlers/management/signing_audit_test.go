@@ -0,0 +1,61 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/signingaudit"
+)
+
+func TestGetSigningAuditRequest(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	recorder := signingaudit.NewRecorder()
+	headers := http.Header{}
+	headers.Set("x-api-key", "sk-secret")
+	recorder.Record(10, "req-1", signingaudit.Attempt{Provider: "claude", AuthID: "auth-1", Headers: headers, AuthValue: "sk-secret"})
+
+	cfg := &config.Config{AuthDir: t.TempDir()}
+	h := NewHandlerWithoutConfigFilePath(cfg, nil)
+	h.signingAudit = recorder
+
+	do := func(requestID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(rec)
+		ginCtx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/signing-audit/"+requestID, nil)
+		ginCtx.Params = gin.Params{{Key: "request_id", Value: requestID}}
+		h.GetSigningAuditRequest(ginCtx)
+		return rec
+	}
+
+	if rec := do("req-1"); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	cfg.SigningAudit.Enable = true
+	rec := do("req-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var body struct {
+		Entries []signingaudit.Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Entries) != 1 || body.Entries[0].AuthID != "auth-1" || len(body.Entries[0].Headers) != 1 || body.Entries[0].Headers[0].Name != "X-Api-Key" {
+		t.Fatalf("entries = %+v", body.Entries)
+	}
+	if body.Entries[0].Headers[0].Fingerprint != signingaudit.Fingerprint("sk-secret") {
+		t.Fatalf("fingerprint = %q", body.Entries[0].Headers[0].Fingerprint)
+	}
+
+	if rec := do("missing"); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
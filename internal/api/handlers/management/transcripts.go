@@ -0,0 +1,62 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/transcripts"
+)
+
+// GetTranscripts returns the stored stream transcripts of a conversation, oldest first. The
+// conversation is the X-Session-ID the client sent with its requests; the api-key query
+// parameter names the client API key that sent them.
+func (h *Handler) GetTranscripts(c *gin.Context) {
+	store, conversation := h.transcriptRequest(c)
+	if store == nil {
+		return
+	}
+	list, errList := store.List(c.Request.Context(), c.Query("api-key"), conversation)
+	if errList != nil {
+		writeTranscriptError(c, errList)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"conversation": conversation, "transcripts": list})
+}
+
+// DeleteTranscripts removes the stored stream transcripts of a conversation of the client API
+// key named by the api-key query parameter and shreds their data keys.
+func (h *Handler) DeleteTranscripts(c *gin.Context) {
+	store, conversation := h.transcriptRequest(c)
+	if store == nil {
+		return
+	}
+	removed, errDelete := store.Delete(c.Request.Context(), c.Query("api-key"), conversation)
+	if errDelete != nil {
+		writeTranscriptError(c, errDelete)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+}
+
+func (h *Handler) transcriptRequest(c *gin.Context) (*transcripts.Store, string) {
+	if h == nil || h.transcripts == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil, ""
+	}
+	conversation := strings.TrimSpace(c.Param("conversation"))
+	if conversation == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "conversation is required"})
+		return nil, ""
+	}
+	return h.transcripts, conversation
+}
+
+func writeTranscriptError(c *gin.Context, err error) {
+	if errors.Is(err, transcripts.ErrDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transcripts are disabled"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,486 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultTrashRetention = 7 * 24 * time.Hour
+	// trashFileName is stored next to the config file; it holds deleted secrets and is written 0600.
+	trashFileName = "management-trash.json"
+	// maxTrashAuditEvents bounds the audit trail kept alongside the trash.
+	maxTrashAuditEvents = 1000
+	// trashActorHeader lets operators sharing a management key identify themselves in the audit trail.
+	trashActorHeader = "X-Management-Actor"
+)
+
+var errTrashConflict = errors.New("an identical item already exists")
+
+// trashActor identifies the management client behind a delete or restore.
+type trashActor struct {
+	Actor     string `json:"actor,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// trashEntry is one soft-deleted item of a managed resource list.
+type trashEntry struct {
+	ID        string          `json:"id"`
+	Resource  string          `json:"resource"`
+	Label     string          `json:"label"`
+	Item      json.RawMessage `json:"item"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	DeletedBy trashActor      `json:"deleted_by"`
+}
+
+// trashAuditEvent records who deleted, restored or purged what.
+type trashAuditEvent struct {
+	Time     time.Time  `json:"time"`
+	Action   string     `json:"action"`
+	Resource string     `json:"resource"`
+	Label    string     `json:"label"`
+	TrashID  string     `json:"trash_id"`
+	By       trashActor `json:"by"`
+}
+
+type trashState struct {
+	Entries []trashEntry      `json:"entries"`
+	Audit   []trashAuditEvent `json:"audit"`
+}
+
+// trashResource snapshots a config-backed resource as JSON items and restores single items into it.
+type trashResource struct {
+	snapshot func(cfg *config.Config) []json.RawMessage
+	restore  func(cfg *config.Config, item json.RawMessage) error
+}
+
+// trashResources lists the management resources whose DELETE endpoints soft-delete, keyed by route name.
+var trashResources = map[string]trashResource{
+	"api-keys":             sliceTrashResource(func(cfg *config.Config) *[]string { return &cfg.APIKeys }, nil),
+	"gemini-api-key":       sliceTrashResource(func(cfg *config.Config) *[]config.GeminiKey { return &cfg.GeminiKey }, (*config.Config).SanitizeGeminiKeys),
+	"interactions-api-key": sliceTrashResource(func(cfg *config.Config) *[]config.GeminiKey { return &cfg.InteractionsKey }, (*config.Config).SanitizeInteractionsKeys),
+	"claude-api-key":       sliceTrashResource(func(cfg *config.Config) *[]config.ClaudeKey { return &cfg.ClaudeKey }, (*config.Config).SanitizeClaudeKeys),
+	"codex-api-key":        sliceTrashResource(func(cfg *config.Config) *[]config.CodexKey { return &cfg.CodexKey }, (*config.Config).SanitizeCodexKeys),
+	"xai-api-key":          sliceTrashResource(func(cfg *config.Config) *[]config.XAIKey { return &cfg.XAIKey }, (*config.Config).SanitizeXAIKeys),
+	"openai-compatibility": sliceTrashResource(func(cfg *config.Config) *[]config.OpenAICompatibility { return &cfg.OpenAICompatibility }, (*config.Config).SanitizeOpenAICompatibility),
+	"vertex-api-key":       sliceTrashResource(func(cfg *config.Config) *[]config.VertexCompatKey { return &cfg.VertexCompatAPIKey }, (*config.Config).SanitizeVertexCompatKeys),
+	"model-aliases":        sliceTrashResource(func(cfg *config.Config) *[]config.ModelAliasRule { return &cfg.ModelAliases }, (*config.Config).SanitizeModelAliases),
+	"oauth-excluded-models": mapTrashResource(func(cfg *config.Config) *map[string][]string { return &cfg.OAuthExcludedModels }, func(cfg *config.Config) {
+		cfg.OAuthExcludedModels = config.NormalizeOAuthExcludedModels(cfg.OAuthExcludedModels)
+	}),
+	"oauth-model-alias": mapTrashResource(func(cfg *config.Config) *map[string][]config.OAuthModelAlias { return &cfg.OAuthModelAlias }, (*config.Config).SanitizeOAuthModelAlias),
+}
+
+func sliceTrashResource[T any](field func(*config.Config) *[]T, sanitize func(*config.Config)) trashResource {
+	return trashResource{
+		snapshot: func(cfg *config.Config) []json.RawMessage {
+			items := *field(cfg)
+			out := make([]json.RawMessage, 0, len(items))
+			for i := range items {
+				if data, errMarshal := json.Marshal(items[i]); errMarshal == nil {
+					out = append(out, data)
+				}
+			}
+			return out
+		},
+		restore: func(cfg *config.Config, raw json.RawMessage) error {
+			var item T
+			if errUnmarshal := json.Unmarshal(raw, &item); errUnmarshal != nil {
+				return fmt.Errorf("decode item: %w", errUnmarshal)
+			}
+			canonical, errMarshal := json.Marshal(item)
+			if errMarshal != nil {
+				return fmt.Errorf("encode item: %w", errMarshal)
+			}
+			for _, existing := range *field(cfg) {
+				if data, errExisting := json.Marshal(existing); errExisting == nil && string(data) == string(canonical) {
+					return errTrashConflict
+				}
+			}
+			*field(cfg) = append(*field(cfg), item)
+			if sanitize != nil {
+				sanitize(cfg)
+			}
+			return nil
+		},
+	}
+}
+
+type trashMapItem[V any] struct {
+	Key   string `json:"key"`
+	Value V      `json:"value"`
+}
+
+func mapTrashResource[V any](field func(*config.Config) *map[string]V, sanitize func(*config.Config)) trashResource {
+	return trashResource{
+		snapshot: func(cfg *config.Config) []json.RawMessage {
+			items := *field(cfg)
+			keys := make([]string, 0, len(items))
+			for key := range items {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			out := make([]json.RawMessage, 0, len(keys))
+			for _, key := range keys {
+				if data, errMarshal := json.Marshal(trashMapItem[V]{Key: key, Value: items[key]}); errMarshal == nil {
+					out = append(out, data)
+				}
+			}
+			return out
+		},
+		restore: func(cfg *config.Config, raw json.RawMessage) error {
+			var item trashMapItem[V]
+			if errUnmarshal := json.Unmarshal(raw, &item); errUnmarshal != nil {
+				return fmt.Errorf("decode item: %w", errUnmarshal)
+			}
+			if _, exists := (*field(cfg))[item.Key]; exists {
+				return fmt.Errorf("%q already exists", item.Key)
+			}
+			if *field(cfg) == nil {
+				*field(cfg) = make(map[string]V)
+			}
+			(*field(cfg))[item.Key] = item.Value
+			if sanitize != nil {
+				sanitize(cfg)
+			}
+			return nil
+		},
+	}
+}
+
+// removedTrashItems returns the items of before that are missing from after, counting duplicates.
+func removedTrashItems(before, after []json.RawMessage) []json.RawMessage {
+	remaining := make(map[string]int, len(after))
+	for _, item := range after {
+		remaining[string(item)]++
+	}
+	var removed []json.RawMessage
+	for _, item := range before {
+		if remaining[string(item)] > 0 {
+			remaining[string(item)]--
+			continue
+		}
+		removed = append(removed, item)
+	}
+	return removed
+}
+
+// trashLabel summarizes an item for listings and the audit trail without exposing full secrets.
+func trashLabel(item json.RawMessage) string {
+	parsed := gjson.ParseBytes(item)
+	if parsed.Type == gjson.String {
+		return util.HideAPIKey(parsed.String())
+	}
+	if key := parsed.Get("key"); key.Exists() {
+		return key.String()
+	}
+	if name := parsed.Get("name"); name.String() != "" {
+		return name.String()
+	}
+	if model := parsed.Get("model"); model.String() != "" && parsed.Get("api-key").String() == "" {
+		match := parsed.Get("match").String()
+		if match == "" {
+			match = parsed.Get("regex").String()
+		}
+		return match + " -> " + model.String()
+	}
+	label := util.HideAPIKey(parsed.Get("api-key").String())
+	if base := parsed.Get("base-url").String(); base != "" {
+		label += " @ " + base
+	}
+	return label
+}
+
+func trashActorFromContext(c *gin.Context) trashActor {
+	if c == nil || c.Request == nil {
+		return trashActor{}
+	}
+	return trashActor{
+		Actor:     strings.TrimSpace(c.GetHeader(trashActorHeader)),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// softDeleteContextKey holds the pendingSoftDelete of a request wrapped by SoftDelete.
+const softDeleteContextKey = "managementSoftDelete"
+
+// pendingSoftDelete carries a soft delete from SoftDelete to the write of the wrapped handler.
+// The resource is snapshotted before and after the change in the handler's h.mu critical
+// section, so concurrent config writes are never mistaken for deleted items.
+type pendingSoftDelete struct {
+	kind      trashResource
+	retention time.Duration
+	before    []json.RawMessage
+	captured  bool
+	removed   []json.RawMessage
+}
+
+func pendingSoftDeleteFromContext(c *gin.Context) *pendingSoftDelete {
+	if c == nil {
+		return nil
+	}
+	value, ok := c.Get(softDeleteContextKey)
+	if !ok {
+		return nil
+	}
+	pending, _ := value.(*pendingSoftDelete)
+	return pending
+}
+
+// beginSoftDeleteLocked snapshots the resource of a soft-deleted request before the delete
+// handler changes it. Delete handlers call it right after taking h.mu.
+func (h *Handler) beginSoftDeleteLocked(c *gin.Context) {
+	pending := pendingSoftDeleteFromContext(c)
+	if pending == nil || h.cfg == nil {
+		return
+	}
+	pending.retention = h.trashRetentionLocked()
+	if pending.retention <= 0 {
+		return
+	}
+	pending.before = pending.kind.snapshot(h.cfg)
+	pending.captured = true
+}
+
+// finishSoftDeleteLocked records the items removed since beginSoftDeleteLocked. persistLocked
+// calls it once the config has been saved, still holding h.mu.
+func (h *Handler) finishSoftDeleteLocked(c *gin.Context) {
+	pending := pendingSoftDeleteFromContext(c)
+	if pending == nil || !pending.captured || h.cfg == nil {
+		return
+	}
+	pending.removed = removedTrashItems(pending.before, pending.kind.snapshot(h.cfg))
+}
+
+// SoftDelete wraps the DELETE handler of a managed resource so removed items are kept in the
+// trash for the retention period and can be restored with POST /trash/:id/restore.
+func (h *Handler) SoftDelete(resource string, next gin.HandlerFunc) gin.HandlerFunc {
+	kind, ok := trashResources[resource]
+	if !ok {
+		return next
+	}
+	return func(c *gin.Context) {
+		pending := &pendingSoftDelete{kind: kind}
+		c.Set(softDeleteContextKey, pending)
+
+		next(c)
+
+		if len(pending.removed) == 0 || c.Writer.Status() != http.StatusOK {
+			return
+		}
+		now := time.Now().UTC()
+		actor := trashActorFromContext(c)
+		h.trashMu.Lock()
+		defer h.trashMu.Unlock()
+		state := h.loadTrashLocked(now)
+		for _, item := range pending.removed {
+			entry := trashEntry{
+				ID:        uuid.NewString(),
+				Resource:  resource,
+				Label:     trashLabel(item),
+				Item:      item,
+				DeletedAt: now,
+				ExpiresAt: now.Add(pending.retention),
+				DeletedBy: actor,
+			}
+			state.Entries = append(state.Entries, entry)
+			h.auditTrashLocked(state, "delete", entry, actor, now)
+		}
+		h.saveTrashLocked(state)
+	}
+}
+
+// GetTrash lists soft-deleted items that can still be restored, newest first.
+func (h *Handler) GetTrash(c *gin.Context) {
+	h.trashMu.Lock()
+	state := h.loadTrashLocked(time.Now().UTC())
+	items := make([]trashEntry, len(state.Entries))
+	copy(items, state.Entries)
+	h.trashMu.Unlock()
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetTrashAudit returns the delete, restore and purge audit trail, newest first.
+func (h *Handler) GetTrashAudit(c *gin.Context) {
+	h.trashMu.Lock()
+	state := h.loadTrashLocked(time.Now().UTC())
+	events := make([]trashAuditEvent, 0, len(state.Audit))
+	for i := len(state.Audit) - 1; i >= 0; i-- {
+		events = append(events, state.Audit[i])
+	}
+	h.trashMu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// RestoreTrashItem puts a soft-deleted item back into its resource list and saves the config.
+func (h *Handler) RestoreTrashItem(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	now := time.Now().UTC()
+
+	h.trashMu.Lock()
+	defer h.trashMu.Unlock()
+	state := h.loadTrashLocked(now)
+	index := trashEntryIndex(state, id)
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		return
+	}
+	entry := state.Entries[index]
+	kind, ok := trashResources[entry.Resource]
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "resource no longer supports restore"})
+		return
+	}
+
+	h.mu.Lock()
+	if errRestore := kind.restore(h.cfg, entry.Item); errRestore != nil {
+		h.mu.Unlock()
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("restore failed: %v", errRestore)})
+		return
+	}
+	saved := h.persistLocked(c)
+	h.mu.Unlock()
+	if !saved {
+		return
+	}
+
+	state.Entries = append(state.Entries[:index], state.Entries[index+1:]...)
+	h.auditTrashLocked(state, "restore", entry, trashActorFromContext(c), now)
+	h.saveTrashLocked(state)
+}
+
+// DeleteTrashItem permanently removes a soft-deleted item.
+func (h *Handler) DeleteTrashItem(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	now := time.Now().UTC()
+
+	h.trashMu.Lock()
+	defer h.trashMu.Unlock()
+	state := h.loadTrashLocked(now)
+	index := trashEntryIndex(state, id)
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		return
+	}
+	entry := state.Entries[index]
+	state.Entries = append(state.Entries[:index], state.Entries[index+1:]...)
+	h.auditTrashLocked(state, "purge", entry, trashActorFromContext(c), now)
+	h.saveTrashLocked(state)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func trashEntryIndex(state *trashState, id string) int {
+	if id == "" {
+		return -1
+	}
+	for i := range state.Entries {
+		if state.Entries[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// trashRetentionLocked returns the configured retention, or zero when soft-delete is disabled.
+// It expects the caller to hold h.mu.
+func (h *Handler) trashRetentionLocked() time.Duration {
+	if h.cfg == nil {
+		return defaultTrashRetention
+	}
+	hours := h.cfg.RemoteManagement.TrashRetentionHours
+	switch {
+	case hours < 0:
+		return 0
+	case hours == 0:
+		return defaultTrashRetention
+	default:
+		return time.Duration(hours) * time.Hour
+	}
+}
+
+func (h *Handler) auditTrashLocked(state *trashState, action string, entry trashEntry, actor trashActor, now time.Time) {
+	state.Audit = append(state.Audit, trashAuditEvent{
+		Time:     now,
+		Action:   action,
+		Resource: entry.Resource,
+		Label:    entry.Label,
+		TrashID:  entry.ID,
+		By:       actor,
+	})
+	if excess := len(state.Audit) - maxTrashAuditEvents; excess > 0 {
+		state.Audit = append([]trashAuditEvent(nil), state.Audit[excess:]...)
+	}
+	log.Infof("management %s %s item %s (trash id %s) from %s", action, entry.Resource, entry.Label, entry.ID, actor.IP)
+}
+
+// trashPath returns the trash file location, or "" to keep the trash in memory only.
+func (h *Handler) trashPath() string {
+	if strings.TrimSpace(h.configFilePath) == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(h.configFilePath), trashFileName)
+}
+
+// loadTrashLocked returns the trash, reading it from disk on first use and dropping expired entries.
+// It expects the caller to hold h.trashMu.
+func (h *Handler) loadTrashLocked(now time.Time) *trashState {
+	if h.trash == nil {
+		h.trash = &trashState{}
+		if path := h.trashPath(); path != "" {
+			data, errRead := os.ReadFile(path)
+			switch {
+			case errRead == nil:
+				if errUnmarshal := json.Unmarshal(data, h.trash); errUnmarshal != nil {
+					log.Warnf("management trash: failed to parse %s: %v", path, errUnmarshal)
+					h.trash = &trashState{}
+				}
+			case !os.IsNotExist(errRead):
+				log.Warnf("management trash: failed to read %s: %v", path, errRead)
+			}
+		}
+	}
+	kept := h.trash.Entries[:0]
+	for _, entry := range h.trash.Entries {
+		if now.Before(entry.ExpiresAt) {
+			kept = append(kept, entry)
+		}
+	}
+	h.trash.Entries = kept
+	return h.trash
+}
+
+// saveTrashLocked writes the trash file. Failures are logged: the delete itself already succeeded.
+// It expects the caller to hold h.trashMu.
+func (h *Handler) saveTrashLocked(state *trashState) {
+	path := h.trashPath()
+	if path == "" {
+		return
+	}
+	data, errMarshal := json.Marshal(state)
+	if errMarshal != nil {
+		log.Warnf("management trash: failed to encode: %v", errMarshal)
+		return
+	}
+	if errWrite := util.WriteFileAtomic(path, data); errWrite != nil {
+		log.Warnf("management trash: failed to write %s: %v", path, errWrite)
+	}
+}
@@ -0,0 +1,179 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newTrashTestHandler(t *testing.T, cfg *config.Config) *Handler {
+	t.Helper()
+	return &Handler{cfg: cfg, configFilePath: writeTestConfigFile(t)}
+}
+
+func serveTrashTest(h *Handler, handler gin.HandlerFunc, method, target, param string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(method, target, nil)
+	c.Request.Header.Set(trashActorHeader, "alice")
+	if param != "" {
+		c.Params = gin.Params{{Key: "id", Value: param}}
+	}
+	handler(c)
+	return rec
+}
+
+func TestSoftDelete_RestoresDeletedGeminiKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTrashTestHandler(t, &config.Config{
+		GeminiKey: []config.GeminiKey{
+			{APIKey: "gemini-key-one", BaseURL: "https://a.example.com"},
+			{APIKey: "gemini-key-two"},
+		},
+	})
+
+	rec := serveTrashTest(h, h.SoftDelete("gemini-api-key", h.DeleteGeminiKey), http.MethodDelete, "/v0/management/gemini-api-key?api-key=gemini-key-one", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if len(h.cfg.GeminiKey) != 1 {
+		t.Fatalf("gemini keys = %d, want 1 after delete", len(h.cfg.GeminiKey))
+	}
+
+	rec = serveTrashTest(h, h.GetTrash, http.MethodGet, "/v0/management/trash", "")
+	var listing struct {
+		Items []trashEntry `json:"items"`
+	}
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &listing); errUnmarshal != nil {
+		t.Fatalf("decode trash: %v", errUnmarshal)
+	}
+	if len(listing.Items) != 1 {
+		t.Fatalf("trash items = %+v, want one", listing.Items)
+	}
+	entry := listing.Items[0]
+	if entry.Resource != "gemini-api-key" || entry.Label != "gemi...-one @ https://a.example.com" || entry.DeletedBy.Actor != "alice" {
+		t.Fatalf("trash entry = %+v", entry)
+	}
+	if _, errStat := os.Stat(filepath.Join(filepath.Dir(h.configFilePath), trashFileName)); errStat != nil {
+		t.Fatalf("trash file not written: %v", errStat)
+	}
+
+	rec = serveTrashTest(h, h.RestoreTrashItem, http.MethodPost, "/v0/management/trash/"+entry.ID+"/restore", entry.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if len(h.cfg.GeminiKey) != 2 || h.cfg.GeminiKey[1].BaseURL != "https://a.example.com" {
+		t.Fatalf("gemini keys after restore = %+v", h.cfg.GeminiKey)
+	}
+
+	rec = serveTrashTest(h, h.RestoreTrashItem, http.MethodPost, "/v0/management/trash/"+entry.ID+"/restore", entry.ID)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("second restore status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = serveTrashTest(h, h.GetTrashAudit, http.MethodGet, "/v0/management/trash/audit", "")
+	var audit struct {
+		Events []trashAuditEvent `json:"events"`
+	}
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &audit); errUnmarshal != nil {
+		t.Fatalf("decode audit: %v", errUnmarshal)
+	}
+	if len(audit.Events) != 2 || audit.Events[0].Action != "restore" || audit.Events[1].Action != "delete" {
+		t.Fatalf("audit events = %+v, want restore then delete", audit.Events)
+	}
+}
+
+func TestSoftDelete_TrashSurvivesHandlerRestart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTrashTestHandler(t, &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"client-key-1", "client-key-2"}}})
+
+	rec := serveTrashTest(h, h.SoftDelete("api-keys", h.DeleteAPIKeys), http.MethodDelete, "/v0/management/api-keys?value=client-key-2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+
+	restarted := &Handler{cfg: &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"client-key-1"}}}, configFilePath: h.configFilePath}
+	restarted.trashMu.Lock()
+	state := restarted.loadTrashLocked(h.trash.Entries[0].DeletedAt)
+	restarted.trashMu.Unlock()
+	if len(state.Entries) != 1 {
+		t.Fatalf("reloaded trash = %+v, want one entry", state.Entries)
+	}
+
+	id := state.Entries[0].ID
+	rec = serveTrashTest(restarted, restarted.RestoreTrashItem, http.MethodPost, "/v0/management/trash/"+id+"/restore", id)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if len(restarted.cfg.APIKeys) != 2 || restarted.cfg.APIKeys[1] != "client-key-2" {
+		t.Fatalf("api keys after restore = %v", restarted.cfg.APIKeys)
+	}
+}
+
+func TestSoftDelete_RestoreConflictsWithExistingAlias(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTrashTestHandler(t, &config.Config{
+		OAuthModelAlias: map[string][]config.OAuthModelAlias{"codex": {{Name: "gpt-5", Alias: "g5"}}},
+	})
+
+	rec := serveTrashTest(h, h.SoftDelete("oauth-model-alias", h.DeleteOAuthModelAlias), http.MethodDelete, "/v0/management/oauth-model-alias?channel=codex", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	id := h.trash.Entries[0].ID
+	h.cfg.OAuthModelAlias = map[string][]config.OAuthModelAlias{"codex": {{Name: "gpt-5", Alias: "other"}}}
+
+	rec = serveTrashTest(h, h.RestoreTrashItem, http.MethodPost, "/v0/management/trash/"+id+"/restore", id)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("restore status = %d, want %d; body=%s", rec.Code, http.StatusConflict, rec.Body.String())
+	}
+	if len(h.trash.Entries) != 1 {
+		t.Fatalf("trash entries = %d, want entry kept after conflict", len(h.trash.Entries))
+	}
+
+	rec = serveTrashTest(h, h.DeleteTrashItem, http.MethodDelete, "/v0/management/trash/"+id, id)
+	if rec.Code != http.StatusOK || len(h.trash.Entries) != 0 {
+		t.Fatalf("purge status = %d, entries = %d", rec.Code, len(h.trash.Entries))
+	}
+}
+
+func TestSoftDelete_DisabledByNegativeRetention(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTrashTestHandler(t, &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"client-key-1"}}})
+	h.cfg.RemoteManagement.TrashRetentionHours = -1
+
+	rec := serveTrashTest(h, h.SoftDelete("api-keys", h.DeleteAPIKeys), http.MethodDelete, "/v0/management/api-keys?index=0", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if h.trash != nil && len(h.trash.Entries) != 0 {
+		t.Fatalf("trash entries = %+v, want none when disabled", h.trash.Entries)
+	}
+}
+
+func TestSoftDelete_IgnoresConcurrentConfigWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTrashTestHandler(t, &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"client-key-1", "client-key-2"}}})
+
+	// Another request replaces the key list after the wrapper starts but before the delete
+	// handler takes the lock; only the key removed by the delete itself is trashed.
+	concurrentThenDelete := func(c *gin.Context) {
+		h.mu.Lock()
+		h.cfg.APIKeys = []string{"client-key-1"}
+		h.mu.Unlock()
+		h.DeleteAPIKeys(c)
+	}
+	rec := serveTrashTest(h, h.SoftDelete("api-keys", concurrentThenDelete), http.MethodDelete, "/v0/management/api-keys?value=client-key-1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if h.trash == nil || len(h.trash.Entries) != 1 || string(h.trash.Entries[0].Item) != `"client-key-1"` {
+		t.Fatalf("trash entries = %+v, want only client-key-1", h.trash)
+	}
+}
@@ -0,0 +1,223 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/bandwidth"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
+)
+
+// SetUsageAccounting updates the tracker backing the usage endpoint.
+func (h *Handler) SetUsageAccounting(tracker *usageaccounting.Tracker) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.usageAccounting = tracker
+	h.mu.Unlock()
+}
+
+// GetUsage reports token usage grouped by client API key and model, and the upstream bandwidth
+// of each client API key.
+// Optional query parameters: from and to (RFC3339 or YYYY-MM-DD; to is exclusive),
+// api_key and model. Entries can be narrowed with q and paged with limit and cursor.
+func (h *Handler) GetUsage(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return
+	}
+
+	h.mu.Lock()
+	tracker := h.usageAccounting
+	h.mu.Unlock()
+	// Report not found while disabled so the legacy /usage route keeps its removed-endpoint response.
+	if tracker == nil || !tracker.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "usage accounting disabled"})
+		return
+	}
+
+	from, errFrom := parseUsageTime(c.Query("from"))
+	if errFrom != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + errFrom.Error()})
+		return
+	}
+	to, errTo := parseUsageTime(c.Query("to"))
+	if errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + errTo.Error()})
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+
+	query, errQuery := parseListQuery(c)
+	if errQuery != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errQuery.Error()})
+		return
+	}
+
+	apiKey := strings.TrimSpace(c.Query("api_key"))
+	report := usageReport{
+		Report: tracker.Query(usageaccounting.Filter{
+			From:   from,
+			To:     to,
+			APIKey: apiKey,
+			Model:  strings.TrimSpace(c.Query("model")),
+		}),
+		Bandwidth: h.clientBandwidth(apiKey),
+	}
+	if query.search == "" && !query.paged() {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	page := usagePage{usageReport: report}
+	page.Entries, page.Total, page.NextCursor = pageList(report.Entries, query,
+		func(e usageaccounting.Entry) string { return e.KeyID + "|" + e.Model },
+		func(e usageaccounting.Entry) string { return e.APIKey + " " + e.Model })
+	c.JSON(http.StatusOK, page)
+}
+
+// usageReport is a usage report with the upstream traffic of each client API key. Bandwidth
+// covers the bandwidth counters' window, not the from/to range of the report.
+type usageReport struct {
+	usageaccounting.Report
+	Bandwidth []bandwidth.ClientStats `json:"bandwidth"`
+}
+
+// clientBandwidth returns the upstream traffic per client API key, limited to apiKey when set.
+func (h *Handler) clientBandwidth(apiKey string) []bandwidth.ClientStats {
+	clients := h.bandwidth.Snapshot().Clients
+	if apiKey == "" {
+		return clients
+	}
+	for _, client := range clients {
+		if client.APIKey == apiKey {
+			return []bandwidth.ClientStats{client}
+		}
+	}
+	return []bandwidth.ClientStats{}
+}
+
+// usagePage is a usage report whose entries are limited to one page.
+type usagePage struct {
+	usageReport
+	Total      int    `json:"total"`
+	NextCursor string `json:"next-cursor"`
+}
+
+func parseUsageTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ts, errParse := time.Parse(time.RFC3339, value); errParse == nil {
+		return ts, nil
+	}
+	if ts, errParse := time.Parse(time.DateOnly, value); errParse == nil {
+		return ts, nil
+	}
+	return time.Time{}, fmt.Errorf("expected RFC3339 timestamp or YYYY-MM-DD date")
+}
+
+// GetUsageBudgets reports the spend of the current period against every configured budget.
+func (h *Handler) GetUsageBudgets(c *gin.Context) {
+	tracker, ok := h.enabledUsageAccounting(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budgets": tracker.Budgets()})
+}
+
+// budgetOverrideRequest targets one key or tenant. The override ends at until (RFC3339) or
+// after duration (e.g. "2h"); without either it lasts until the end of the day (UTC).
+type budgetOverrideRequest struct {
+	APIKey   string `json:"api_key"`
+	Tenant   string `json:"tenant"`
+	Until    string `json:"until"`
+	Duration string `json:"duration"`
+}
+
+// PutUsageBudgetOverride admits a key or tenant past its budgets for a while.
+func (h *Handler) PutUsageBudgetOverride(c *gin.Context) {
+	tracker, ok := h.enabledUsageAccounting(c)
+	if !ok {
+		return
+	}
+	var body budgetOverrideRequest
+	if errBind := c.ShouldBindJSON(&body); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	apiKey, tenant := strings.TrimSpace(body.APIKey), strings.TrimSpace(body.Tenant)
+	if (apiKey == "") == (tenant == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of api_key and tenant is required"})
+		return
+	}
+	now := time.Now().UTC()
+	until := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	switch {
+	case strings.TrimSpace(body.Until) != "":
+		ts, errParse := time.Parse(time.RFC3339, strings.TrimSpace(body.Until))
+		if errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until: expected RFC3339 timestamp"})
+			return
+		}
+		until = ts
+	case strings.TrimSpace(body.Duration) != "":
+		duration, errParse := time.ParseDuration(strings.TrimSpace(body.Duration))
+		if errParse != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid duration"})
+			return
+		}
+		until = now.Add(duration)
+	}
+	if !until.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "override must end in the future"})
+		return
+	}
+	if !tracker.OverrideBudget(apiKey, tenant, until) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no budget configured for target"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "until": until})
+}
+
+// DeleteUsageBudgetOverride ends the override of the key or tenant given by the api_key or
+// tenant query parameter.
+func (h *Handler) DeleteUsageBudgetOverride(c *gin.Context) {
+	tracker, ok := h.enabledUsageAccounting(c)
+	if !ok {
+		return
+	}
+	apiKey, tenant := strings.TrimSpace(c.Query("api_key")), strings.TrimSpace(c.Query("tenant"))
+	if (apiKey == "") == (tenant == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of api_key and tenant is required"})
+		return
+	}
+	if !tracker.ClearBudgetOverride(apiKey, tenant) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "override not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// enabledUsageAccounting returns the tracker, answering 404 when usage accounting is disabled.
+func (h *Handler) enabledUsageAccounting(c *gin.Context) (*usageaccounting.Tracker, bool) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
+		return nil, false
+	}
+	h.mu.Lock()
+	tracker := h.usageAccounting
+	h.mu.Unlock()
+	if tracker == nil || !tracker.Enabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "usage accounting disabled"})
+		return nil, false
+	}
+	return tracker, true
+}
@@ -0,0 +1,153 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/bandwidth"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestGetUsage_FiltersByTimeRange(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	tracker := usageaccounting.NewTracker()
+	tracker.Apply(config.UsageAccountingConfig{Enable: true}, t.TempDir())
+	t.Cleanup(tracker.Stop)
+	for _, at := range []time.Time{
+		time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC),
+	} {
+		tracker.HandleUsage(context.Background(), coreusage.Record{
+			Provider:    "openai-compatibility",
+			Model:       "gpt-5",
+			APIKey:      "client-key",
+			RequestedAt: at,
+			Detail:      coreusage.Detail{InputTokens: 3, OutputTokens: 2},
+		})
+	}
+
+	meter := bandwidth.NewMeter()
+	for _, apiKey := range []string{"client-key", "other-key"} {
+		meter.Counter("claude", "auth-1", "", apiKey).ObserveRequest(&http.Request{Body: io.NopCloser(strings.NewReader("abc")), ContentLength: 3})
+	}
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, nil)
+	h.SetUsageAccounting(tracker)
+	h.bandwidth = meter
+
+	do := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(rec)
+		ginCtx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage"+query, nil)
+		h.GetUsage(ginCtx)
+		return rec
+	}
+
+	rec := do("?from=2026-02-01&to=2026-03-01T00:00:00Z&api_key=client-key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var report usageReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if report.Totals.Requests != 1 || report.Totals.TotalTokens != 5 {
+		t.Fatalf("totals = %+v, want only the February request", report.Totals)
+	}
+	if len(report.Bandwidth) != 1 || report.Bandwidth[0].APIKey != "client-key" || report.Bandwidth[0].RequestBytes != 3 {
+		t.Fatalf("bandwidth = %+v, want only client-key", report.Bandwidth)
+	}
+
+	if rec = do("?from=yesterday"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid from status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetUsage_DisabledReturnsNotFound(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, nil)
+	h.SetUsageAccounting(usageaccounting.NewTracker())
+
+	rec := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(rec)
+	ginCtx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/usage", nil)
+	h.GetUsage(ginCtx)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestUsageBudgetOverride_SetAndClear(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	tracker := usageaccounting.NewTracker()
+	tracker.Apply(config.UsageAccountingConfig{
+		Enable:  true,
+		Budgets: []config.UsageBudget{{APIKey: "client-key", Tokens: 1}},
+	}, t.TempDir())
+	t.Cleanup(tracker.Stop)
+	tracker.HandleUsage(context.Background(), coreusage.Record{
+		Provider: "openai-compatibility",
+		Model:    "gpt-5",
+		APIKey:   "client-key",
+		Detail:   coreusage.Detail{InputTokens: 3, OutputTokens: 2},
+	})
+
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, nil)
+	h.SetUsageAccounting(tracker)
+
+	do := func(handler gin.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ginCtx, _ := gin.CreateTestContext(rec)
+		ginCtx.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+		ginCtx.Request.Header.Set("Content-Type", "application/json")
+		handler(ginCtx)
+		return rec
+	}
+
+	const overridePath = "/v0/management/usage/budgets/override"
+	if rec := do(h.PutUsageBudgetOverride, http.MethodPut, overridePath, `{"tenant":"acme","duration":"1h"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("override without budget status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := do(h.PutUsageBudgetOverride, http.MethodPut, overridePath, `{"api_key":"client-key","duration":"-1h"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("negative duration status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := do(h.PutUsageBudgetOverride, http.MethodPut, overridePath, `{"api_key":"client-key","duration":"1h"}`); rec.Code != http.StatusOK {
+		t.Fatalf("override status = %d, want %d body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if _, ok := tracker.CheckBudget("client-key"); !ok {
+		t.Fatal("overridden key still over budget")
+	}
+
+	rec := do(h.GetUsageBudgets, http.MethodGet, "/v0/management/usage/budgets", "")
+	var body struct {
+		Budgets []usageaccounting.BudgetStatus `json:"budgets"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Budgets) != 1 || body.Budgets[0].UsedTokens != 5 || body.Budgets[0].OverrideUntil == nil {
+		t.Fatalf("budgets = %+v, want the overridden client-key budget", body.Budgets)
+	}
+
+	if rec = do(h.DeleteUsageBudgetOverride, http.MethodDelete, overridePath+"?api_key=client-key", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if _, ok := tracker.CheckBudget("client-key"); ok {
+		t.Fatal("key admitted after its override was deleted")
+	}
+}
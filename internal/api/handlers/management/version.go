@@ -0,0 +1,65 @@
+package management
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+)
+
+type versionResponse struct {
+	Version    string          `json:"version"`
+	Commit     string          `json:"commit"`
+	BuildDate  string          `json:"build_date"`
+	GoVersion  string          `json:"go_version"`
+	Platform   string          `json:"platform"`
+	Modules    []versionModule `json:"modules"`
+	ConfigHash string          `json:"config_hash,omitempty"`
+}
+
+type versionModule struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// GetVersion reports the build identification of the running binary, the loaded plugin
+// modules and a hash of the config file, so bug reports can name the exact build and setup.
+func (h *Handler) GetVersion(c *gin.Context) {
+	resp := versionResponse{
+		Version:   buildinfo.Version,
+		Commit:    buildinfo.Commit,
+		BuildDate: buildinfo.BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Modules:   []versionModule{},
+	}
+	if h == nil {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	h.mu.Lock()
+	host := h.pluginHost
+	configPath := h.configFilePath
+	h.mu.Unlock()
+
+	if host != nil {
+		for _, info := range host.RegisteredPlugins() {
+			resp.Modules = append(resp.Modules, versionModule{ID: info.ID, Name: info.Metadata.Name, Version: info.Metadata.Version})
+		}
+		sort.Slice(resp.Modules, func(i, j int) bool { return resp.Modules[i].ID < resp.Modules[j].ID })
+	}
+	if configPath != "" {
+		if data, errRead := os.ReadFile(configPath); errRead == nil {
+			sum := sha256.Sum256(data)
+			resp.ConfigHash = "sha256:" + hex.EncodeToString(sum[:])
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,42 @@
+package management
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestGetVersionReportsBuildAndConfigHash(t *testing.T) {
+	h := &Handler{cfg: &config.Config{}, configFilePath: writeTestConfigFile(t)}
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/version", nil)
+	h.GetVersion(ctx)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var resp versionResponse
+	if errDecode := json.Unmarshal(rec.Body.Bytes(), &resp); errDecode != nil {
+		t.Fatalf("decode: %v", errDecode)
+	}
+	if resp.Version != buildinfo.Version || resp.Commit != buildinfo.Commit || resp.GoVersion != runtime.Version() {
+		t.Fatalf("build info = %+v", resp)
+	}
+	sum := sha256.Sum256([]byte("{}\n"))
+	if want := "sha256:" + hex.EncodeToString(sum[:]); resp.ConfigHash != want {
+		t.Fatalf("config_hash = %q, want %q", resp.ConfigHash, want)
+	}
+	if resp.Modules == nil {
+		t.Fatal("modules should be an empty list, not null")
+	}
+}
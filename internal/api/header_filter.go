@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// requestHeaderFilterMiddleware strips cookies, oversized and client-identifying headers
+// from API requests so executors never forward them upstream. The original request is
+// restored after the handler returns so outer middleware still logs the real client.
+func (s *Server) requestHeaderFilterMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s == nil || s.cfg == nil || s.cfg.HeaderFilter.Disable || c.Request == nil {
+			c.Next()
+			return
+		}
+		original := c.Request
+		filtered := original.Clone(original.Context())
+		removed := handlers.FilterRequestHeaders(filtered.Header, s.cfg.HeaderFilter)
+		if len(removed) == 0 {
+			c.Next()
+			return
+		}
+		log.Debugf("header filter stripped inbound headers: %v", removed)
+		if clientIP := c.ClientIP(); clientIP != "" {
+			filtered.RemoteAddr = net.JoinHostPort(clientIP, "0")
+		}
+		c.Request = filtered
+		defer func() { c.Request = original }()
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestRequestHeaderFilterMiddleware_StripsForHandlerAndRestores(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s := &Server{cfg: &config.Config{}}
+	var seenCookie, seenForwarded, seenClientIP, restoredCookie string
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Next()
+		restoredCookie = c.GetHeader("Cookie")
+	}, s.requestHeaderFilterMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		seenCookie = c.GetHeader("Cookie")
+		seenForwarded = c.GetHeader("X-Forwarded-For")
+		seenClientIP = c.ClientIP()
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seenCookie != "" || seenForwarded != "" {
+		t.Fatalf("handler saw Cookie=%q X-Forwarded-For=%q, want both stripped", seenCookie, seenForwarded)
+	}
+	if seenClientIP != "203.0.113.9" {
+		t.Fatalf("handler ClientIP = %q, want %q", seenClientIP, "203.0.113.9")
+	}
+	if restoredCookie != "session=secret" {
+		t.Fatalf("outer middleware Cookie = %q, want original request restored", restoredCookie)
+	}
+}
+
+func TestRequestHeaderFilterMiddleware_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.HeaderFilter.Disable = true
+	s := &Server{cfg: cfg}
+	var seenCookie string
+	engine := gin.New()
+	engine.Use(s.requestHeaderFilterMiddleware())
+	engine.GET("/v1/models", func(c *gin.Context) {
+		seenCookie = c.GetHeader("Cookie")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("Cookie", "session=secret")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seenCookie != "session=secret" {
+		t.Fatalf("Cookie = %q, want forwarded unchanged when disabled", seenCookie)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// configureHTTP2 enables HTTP/2 on server: negotiated through ALPN with TLS, or as cleartext
+// h2c when http2.h2c is set and TLS is off.
+func configureHTTP2(server *http.Server, cfg config.HTTP2Config, useTLS bool) {
+	h2s := &http2.Server{MaxConcurrentStreams: cfg.MaxConcurrentStreams}
+	if useTLS {
+		if errHTTP2 := http2.ConfigureServer(server, h2s); errHTTP2 != nil {
+			log.Warnf("failed to configure HTTP/2: %v", errHTTP2)
+		}
+		return
+	}
+	if cfg.H2C {
+		server.Handler = h2c.NewHandler(server.Handler, h2s)
+		log.Debug("accepting cleartext HTTP/2 (h2c)")
+	}
+}
+
+// applyUpstreamTransportConfig tunes connection reuse of the transports used to reach providers.
+func applyUpstreamTransportConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	upstream := cfg.HTTP2.Upstream
+	pingInterval, pingTimeout := upstream.Ping()
+	proxyutil.SetTransportTuning(proxyutil.TransportTuning{
+		DisableHTTP2:        upstream.DisableHTTP2,
+		MaxIdleConnsPerHost: upstream.MaxIdleConnsPerHost,
+		IdleConnTimeout:     upstream.IdleTimeout(),
+		PingInterval:        pingInterval,
+		PingTimeout:         pingTimeout,
+	})
+}
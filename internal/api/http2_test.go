@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"golang.org/x/net/http2"
+)
+
+func TestConfigureHTTP2ServesH2C(t *testing.T) {
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("listen: %v", errListen)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})}
+	configureHTTP2(server, config.HTTP2Config{H2C: true, MaxConcurrentStreams: 10}, false)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}}
+	resp, errGet := client.Get("http://" + listener.Addr().String() + "/")
+	if errGet != nil {
+		t.Fatalf("h2c request: %v", errGet)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+
+	// HTTP/1.1 clients keep working on the same listener.
+	plain, errPlain := http.Get("http://" + listener.Addr().String() + "/")
+	if errPlain != nil {
+		t.Fatalf("http/1.1 request: %v", errPlain)
+	}
+	defer func() { _ = plain.Body.Close() }()
+	if plain.ProtoMajor != 1 {
+		t.Fatalf("protocol = %s, want HTTP/1.1", plain.Proto)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/idempotency"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// idempotencyKeyHeader carries the client-chosen key of a retryable request.
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks responses served from the idempotency store.
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// IdempotencyMiddleware returns a Gin middleware that replays the stored response of a
+// non-streaming POST when a request with the same Idempotency-Key is retried. Keys are scoped
+// to the client API key. Only successful responses are stored, so failed attempts can be
+// retried with the same key. It must run after AuthMiddleware and before the rate limit and
+// quota middlewares, so replays do not count against them.
+func IdempotencyMiddleware(store *idempotency.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+		if idempotencyKey == "" || !store.Enabled() || !requestMayCarryJSON(c.Request) || strings.Contains(c.Param("action"), "streamGenerateContent") {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.Data(http.StatusBadRequest, "application/json", handlers.BuildErrorResponseBody(http.StatusBadRequest, "Idempotency-Key must be at most 255 characters"))
+			c.Abort()
+			return
+		}
+		body, errRead := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if errRead != nil || gjson.GetBytes(body, "stream").Bool() {
+			c.Next()
+			return
+		}
+
+		key := idempotencyHash(strings.TrimSpace(c.GetString("userApiKey")), idempotencyKey)
+		fingerprint := idempotencyHash(c.Request.URL.Path, c.Request.URL.RawQuery, string(body))
+		outcome, stored := store.Begin(key, fingerprint)
+		switch outcome {
+		case idempotency.OutcomeReplay:
+			for name, values := range stored.Header {
+				for _, value := range values {
+					c.Writer.Header().Add(name, value)
+				}
+			}
+			c.Header(idempotentReplayedHeader, "true")
+			c.Data(stored.StatusCode, stored.Header.Get("Content-Type"), stored.Body)
+			c.Abort()
+			return
+		case idempotency.OutcomeInFlight:
+			c.Data(http.StatusConflict, "application/json", handlers.BuildErrorResponseBody(http.StatusConflict, "a request with this Idempotency-Key is still in progress"))
+			c.Abort()
+			return
+		case idempotency.OutcomeMismatch:
+			c.Data(http.StatusUnprocessableEntity, "application/json", handlers.BuildErrorResponseBody(http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request"))
+			c.Abort()
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				store.Abort(key)
+			}
+		}()
+		writer := &responseCacheWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status < http.StatusOK || status >= http.StatusMultipleChoices || writer.overflow || writer.body.Len() == 0 || strings.Contains(writer.Header().Get("Content-Type"), "text/event-stream") {
+			return
+		}
+		store.Complete(key, &idempotency.Response{
+			StatusCode: status,
+			Header:     replayableHeaders(writer.Header()),
+			Body:       bytes.Clone(writer.body.Bytes()),
+		})
+		completed = true
+	}
+}
+
+// replayableHeaders drops headers describing the original exchange rather than the response:
+// per-request rate limit and quota state, CORS and framing headers.
+func replayableHeaders(header http.Header) http.Header {
+	out := make(http.Header, len(header))
+	for name, values := range header {
+		canonical := http.CanonicalHeaderKey(name)
+		switch {
+		case strings.HasPrefix(canonical, "X-Cpa-"), strings.HasPrefix(canonical, "Access-Control-"):
+			continue
+		case canonical == "Content-Length", canonical == "Date", canonical == "Retry-After", canonical == ResponseCacheHeader:
+			continue
+		}
+		out[canonical] = append([]string(nil), values...)
+	}
+	return out
+}
+
+func idempotencyHash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/idempotency"
+)
+
+func TestIdempotencyMiddleware_ReplaysSuccessfulResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := idempotency.New(config.IdempotencyConfig{Enable: true})
+	calls := 0
+	status := http.StatusInternalServerError
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, IdempotencyMiddleware(store))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		calls++
+		c.Header(rateLimitRemainingHeader, "9")
+		c.Header("X-Request-Id", "req-1")
+		c.JSON(status, gin.H{"id": "chatcmpl-1"})
+	})
+
+	do := func(apiKey, idempotencyKey, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Key", apiKey)
+		req.Header.Set(idempotencyKeyHeader, idempotencyKey)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Failed attempts are not stored, so the retry runs again.
+	if rec := do("alice", "k1", `{"model":"m"}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("first attempt status = %d", rec.Code)
+	}
+	status = http.StatusOK
+	if rec := do("alice", "k1", `{"model":"m"}`); rec.Code != http.StatusOK || rec.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatalf("retry after failure = %d replayed=%q", rec.Code, rec.Header().Get(idempotentReplayedHeader))
+	}
+
+	rec := do("alice", "k1", `{"model":"m"}`)
+	if rec.Header().Get(idempotentReplayedHeader) != "true" || rec.Body.String() != `{"id":"chatcmpl-1"}` {
+		t.Fatalf("replay = %q %s", rec.Header().Get(idempotentReplayedHeader), rec.Body.String())
+	}
+	if rec.Header().Get("X-Request-Id") != "req-1" || rec.Header().Get(rateLimitRemainingHeader) != "" {
+		t.Fatalf("replayed headers = %v", rec.Header())
+	}
+	if calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+
+	if rec := do("alice", "k1", `{"model":"other"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("different body status = %d, want 422", rec.Code)
+	}
+	if rec := do("bob", "k1", `{"model":"m"}`); rec.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatal("idempotency key leaked across client API keys")
+	}
+	if rec := do("alice", "", `{"model":"m"}`); rec.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatal("request without Idempotency-Key was replayed")
+	}
+	if rec := do("alice", "k2", `{"model":"m","stream":true}`); rec.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatal("streaming request was replayed")
+	}
+	if rec := do("alice", "k2", `{"model":"m","stream":true}`); rec.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatal("streaming request was stored")
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/lifecycle"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// RequestLifecycleMiddleware returns a Gin middleware that emits request.started before the
+// request is handled and request.completed or request.failed once the response is written. It
+// must run after AuthMiddleware so events carry the client API key, and before
+// EstimatedCostMiddleware so completed events can report the estimated cost.
+func RequestLifecycleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		data := map[string]any{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+		}
+		if requestID := logging.GetGinRequestID(c); requestID != "" {
+			data["request_id"] = requestID
+		}
+		if apiKey := strings.TrimSpace(c.GetString("userApiKey")); apiKey != "" {
+			data["api_key"] = util.HideAPIKey(apiKey)
+		}
+		lifecycle.Default().Emit(lifecycle.EventRequestStarted, data)
+
+		c.Next()
+
+		done := make(map[string]any, len(data)+3)
+		for key, value := range data {
+			done[key] = value
+		}
+		status := c.Writer.Status()
+		done["status"] = status
+		done["duration_ms"] = time.Since(start).Milliseconds()
+		if value, exists := c.Get(usage.CostTrackerGinKey); exists {
+			if tracker, ok := value.(*usage.CostTracker); ok {
+				if cost, priced := tracker.Total(); priced {
+					done["estimated_cost_usd"] = cost
+				}
+			}
+		}
+		eventType := lifecycle.EventRequestCompleted
+		if status >= http.StatusBadRequest {
+			eventType = lifecycle.EventRequestFailed
+		}
+		lifecycle.Default().Emit(eventType, done)
+	}
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// systemdListenFDsStart is the first file descriptor passed by systemd socket activation.
+const systemdListenFDsStart = 3
+
+// systemdListeners returns the sockets passed by systemd socket activation, or nil when the
+// process was not socket-activated. The activation variables are cleared so child processes
+// do not inherit them.
+func systemdListeners() ([]net.Listener, error) {
+	pid, errPID := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	count, errCount := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if errPID != nil || errCount != nil || pid != os.Getpid() || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(systemdListenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(systemdListenFDsStart+i), name)
+		listener, errListener := net.FileListener(file)
+		_ = file.Close()
+		if errListener != nil {
+			for _, opened := range listeners {
+				_ = opened.Close()
+			}
+			return nil, fmt.Errorf("systemd socket %s: %w", name, errListener)
+		}
+		listeners = append(listeners, localListener(listener))
+	}
+	return listeners, nil
+}
+
+// openMainListener returns the systemd-activated sockets when listen.systemd-activation is
+// set and the process was socket-activated, and a TCP listener on addr otherwise.
+func (s *Server) openMainListener(addr string) (net.Listener, error) {
+	if s.cfg != nil && s.cfg.Listen.SystemdActivation {
+		activated, errActivated := systemdListeners()
+		if errActivated != nil {
+			return nil, errActivated
+		}
+		if len(activated) > 0 {
+			for _, listener := range activated {
+				log.Infof("serving systemd-activated socket %s", listener.Addr())
+			}
+			return newMultiListener(activated), nil
+		}
+		log.Debug("listen.systemd-activation is set but no sockets were passed; listening on host:port")
+	}
+	reusePort := s.cfg != nil && s.cfg.Shutdown.ReusePort
+	if reusePort && !reusePortSupported {
+		log.Warn("shutdown.reuse-port is not supported on this platform")
+	}
+	return listenTCP(addr, reusePort)
+}
+
+// listenTCP opens a TCP listener on addr, with SO_REUSEPORT when reusePort is set and the
+// platform supports it, so a replacement process can bind the same port while this one drains.
+func listenTCP(addr string, reusePort bool) (net.Listener, error) {
+	if reusePort && reusePortSupported {
+		listenConfig := net.ListenConfig{Control: reusePortControl}
+		return listenConfig.Listen(context.Background(), "tcp", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// startUnixSocket serves listen.unix-socket as plain HTTP through the protocol multiplexer. A
+// failure is logged and leaves the TCP listener running.
+func (s *Server) startUnixSocket(httpListener *muxListener) {
+	if s.cfg == nil || s.cfg.Listen.UnixSocket == "" {
+		return
+	}
+	listener, errListen := listenUnixSocket(s.cfg.Listen, s.cfg.Shutdown.ReusePort)
+	if errListen != nil {
+		log.Errorf("failed to listen on unix socket %s: %v", s.cfg.Listen.UnixSocket, errListen)
+		return
+	}
+	s.unixListener = listener
+	log.Infof("API server listening on unix socket %s", s.cfg.Listen.UnixSocket)
+	go func() {
+		if errAccept := normalizeListenerError(s.acceptMuxConnections(listener, httpListener)); errAccept != nil {
+			log.Errorf("unix socket listener stopped: %v", errAccept)
+		}
+	}()
+}
+
+// listenUnixSocket creates the Unix socket at path with the given permissions, replacing a
+// stale socket left by an earlier run. With keepOnClose the socket file is left in place when
+// the listener closes: during a reuse-port upgrade the replacement process has already bound
+// a new socket at the same path, and the draining process must not unlink it.
+func listenUnixSocket(cfg config.ListenConfig, keepOnClose bool) (net.Listener, error) {
+	path := cfg.UnixSocket
+	if info, errStat := os.Lstat(path); errStat == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen.unix-socket %s exists and is not a socket", path)
+		}
+		if errRemove := os.Remove(path); errRemove != nil {
+			return nil, fmt.Errorf("remove stale socket %s: %w", path, errRemove)
+		}
+	}
+	listener, errListen := net.Listen("unix", path)
+	if errListen != nil {
+		return nil, errListen
+	}
+	if errChmod := os.Chmod(path, os.FileMode(cfg.SocketMode())); errChmod != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("chmod %s: %w", path, errChmod)
+	}
+	if unixListener, ok := listener.(*net.UnixListener); ok && keepOnClose {
+		unixListener.SetUnlinkOnClose(false)
+	}
+	return localListener(listener), nil
+}
+
+// localListener makes connections from Unix sockets report a loopback remote address, so
+// localhost-only features such as local management access treat them as local clients.
+func localListener(listener net.Listener) net.Listener {
+	if listener == nil || listener.Addr().Network() != "unix" {
+		return listener
+	}
+	return &unixLocalListener{Listener: listener}
+}
+
+type unixLocalListener struct {
+	net.Listener
+}
+
+func (l *unixLocalListener) Accept() (net.Conn, error) {
+	conn, errAccept := l.Listener.Accept()
+	if errAccept != nil {
+		return nil, errAccept
+	}
+	return &unixLocalConn{Conn: conn}, nil
+}
+
+var loopbackAddr = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+
+type unixLocalConn struct {
+	net.Conn
+}
+
+func (c *unixLocalConn) RemoteAddr() net.Addr { return loopbackAddr }
+
+// multiListener merges several listeners into one, so systemd-activated sockets share the
+// TLS and protocol multiplexing of the main listener.
+type multiListener struct {
+	listeners []net.Listener
+	conns     chan net.Conn
+	errs      chan error
+	closeCh   chan struct{}
+	once      sync.Once
+}
+
+func newMultiListener(listeners []net.Listener) net.Listener {
+	if len(listeners) == 1 {
+		return listeners[0]
+	}
+	m := &multiListener{
+		listeners: listeners,
+		conns:     make(chan net.Conn),
+		errs:      make(chan error, len(listeners)),
+		closeCh:   make(chan struct{}),
+	}
+	for _, listener := range listeners {
+		go m.serve(listener)
+	}
+	return m
+}
+
+func (m *multiListener) serve(listener net.Listener) {
+	for {
+		conn, errAccept := listener.Accept()
+		if errAccept != nil {
+			if !errors.Is(errAccept, net.ErrClosed) {
+				log.Errorf("listener %s stopped: %v", listener.Addr(), errAccept)
+			}
+			m.errs <- errAccept
+			return
+		}
+		select {
+		case m.conns <- conn:
+		case <-m.closeCh:
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+func (m *multiListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-m.conns:
+		return conn, nil
+	case errAccept := <-m.errs:
+		// A failed socket stops the whole listener, as it would for a single socket.
+		return nil, errAccept
+	case <-m.closeCh:
+		return nil, net.ErrClosed
+	}
+}
+
+func (m *multiListener) Close() error {
+	var errClose error
+	m.once.Do(func() {
+		close(m.closeCh)
+		for _, listener := range m.listeners {
+			if errListener := listener.Close(); errListener != nil && !errors.Is(errListener, net.ErrClosed) && errClose == nil {
+				errClose = errListener
+			}
+		}
+	})
+	return errClose
+}
+
+func (m *multiListener) Addr() net.Addr { return m.listeners[0].Addr() }
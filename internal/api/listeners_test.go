@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestListenUnixSocketReportsLoopbackClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cliproxy.sock")
+	cfg := config.ListenConfig{UnixSocket: path, UnixSocketMode: "0600"}
+
+	// A stale socket from an earlier run is replaced.
+	stale, errStale := net.Listen("unix", path)
+	if errStale != nil {
+		t.Skipf("unix sockets unavailable: %v", errStale)
+	}
+	if unixStale, ok := stale.(*net.UnixListener); ok {
+		unixStale.SetUnlinkOnClose(false)
+	}
+	_ = stale.Close()
+
+	listener, errListen := listenUnixSocket(cfg, false)
+	if errListen != nil {
+		t.Fatalf("listenUnixSocket: %v", errListen)
+	}
+	defer func() { _ = listener.Close() }()
+	info, errStat := os.Stat(path)
+	if errStat != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, %v; want 0600", info.Mode().Perm(), errStat)
+	}
+
+	go func() {
+		client, errDial := net.Dial("unix", path)
+		if errDial == nil {
+			_ = client.Close()
+		}
+	}()
+	conn, errAccept := listener.Accept()
+	if errAccept != nil {
+		t.Fatalf("accept: %v", errAccept)
+	}
+	defer func() { _ = conn.Close() }()
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); !ok || !addr.IP.IsLoopback() {
+		t.Fatalf("remote addr = %v, want loopback", conn.RemoteAddr())
+	}
+
+	regular := filepath.Join(t.TempDir(), "not-a-socket")
+	if errWrite := os.WriteFile(regular, nil, 0o600); errWrite != nil {
+		t.Fatalf("write: %v", errWrite)
+	}
+	if _, errListen = listenUnixSocket(config.ListenConfig{UnixSocket: regular}, false); errListen == nil {
+		t.Fatal("expected an error for a path that is not a socket")
+	}
+}
+
+func TestMultiListenerMergesSockets(t *testing.T) {
+	first, errFirst := net.Listen("tcp", "127.0.0.1:0")
+	second, errSecond := net.Listen("tcp", "127.0.0.1:0")
+	if errFirst != nil || errSecond != nil {
+		t.Fatalf("listen: %v %v", errFirst, errSecond)
+	}
+	merged := newMultiListener([]net.Listener{first, second})
+
+	for _, target := range []net.Listener{first, second} {
+		client, errDial := net.Dial("tcp", target.Addr().String())
+		if errDial != nil {
+			t.Fatalf("dial: %v", errDial)
+		}
+		conn, errAccept := merged.Accept()
+		if errAccept != nil {
+			t.Fatalf("accept: %v", errAccept)
+		}
+		if conn.LocalAddr().String() != target.Addr().String() {
+			t.Fatalf("accepted on %s, want %s", conn.LocalAddr(), target.Addr())
+		}
+		_ = conn.Close()
+		_ = client.Close()
+	}
+
+	if errClose := merged.Close(); errClose != nil {
+		t.Fatalf("close: %v", errClose)
+	}
+	if _, errAccept := merged.Accept(); errAccept == nil {
+		t.Fatal("expected accept to fail after close")
+	}
+	if _, errDial := net.Dial("tcp", first.Addr().String()); errDial == nil {
+		t.Fatal("expected underlying listeners to be closed")
+	}
+}
+
+func TestSystemdListenersIgnoresForeignPID(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, errListeners := systemdListeners()
+	if errListeners != nil || listeners != nil {
+		t.Fatalf("systemdListeners = %v, %v; want none", listeners, errListeners)
+	}
+}
+
+func TestOpenMainListenerReusePort(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT unsupported on this platform")
+	}
+	s := &Server{cfg: &config.Config{Shutdown: config.ShutdownConfig{ReusePort: true}}}
+	first, errFirst := s.openMainListener("127.0.0.1:0")
+	if errFirst != nil {
+		t.Fatalf("first listener: %v", errFirst)
+	}
+	defer func() { _ = first.Close() }()
+	second, errSecond := s.openMainListener(first.Addr().String())
+	if errSecond != nil {
+		t.Fatalf("second listener on the same port: %v", errSecond)
+	}
+	_ = second.Close()
+}
+
+func TestListenUnixSocketKeepsSocketForUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cliproxy.sock")
+	cfg := config.ListenConfig{UnixSocket: path}
+
+	draining, errListen := listenUnixSocket(cfg, true)
+	if errListen != nil {
+		t.Skipf("unix sockets unavailable: %v", errListen)
+	}
+	replacement, errReplace := listenUnixSocket(cfg, true)
+	if errReplace != nil {
+		t.Fatalf("replacement listener: %v", errReplace)
+	}
+	defer func() { _ = replacement.Close() }()
+
+	_ = draining.Close()
+	client, errDial := net.Dial("unix", path)
+	if errDial != nil {
+		t.Fatalf("dial after the draining listener closed: %v", errDial)
+	}
+	_ = client.Close()
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/klauspost/compress/zstd"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -63,6 +64,7 @@ func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
 		if !loggerEnabled {
 			wrapper.logOnErrorOnly = true
 		}
+		wrapper.logDisabled = func() bool { return requestLogDisabled(c) }
 		c.Writer = wrapper
 		attachRequestLogSources(c, logger, loggerEnabled)
 		attachDeferredRequestBodyCapture(c.Request, logger, requestInfo, loggerEnabled, captureBody)
@@ -78,6 +80,17 @@ func RequestLoggingMiddleware(logger logging.RequestLogger) gin.HandlerFunc {
 	}
 }
 
+// requestLogDisabled reports whether the client authenticated for c, such as a tenant with
+// disable-request-log, must not appear in request logs.
+func requestLogDisabled(c *gin.Context) bool {
+	value, exists := c.Get("accessMetadata")
+	if !exists {
+		return false
+	}
+	meta, _ := value.(map[string]string)
+	return meta[sdkaccess.MetadataDisableRequestLog] == "true"
+}
+
 type fileBodySourceFactory interface {
 	NewFileBodySource(prefix string) (*logging.FileBodySource, error)
 }
@@ -45,6 +45,7 @@ type ResponseWriterWrapper struct {
 	headers             map[string][]string        // headers stores the response headers.
 	logOnErrorOnly      bool                       // logOnErrorOnly enables logging only when an error response is detected.
 	firstChunkTimestamp time.Time                  // firstChunkTimestamp captures TTFB for streaming responses.
+	logDisabled         func() bool                // logDisabled reports whether the client's policy forbids logging the request.
 }
 
 // NewResponseWriterWrapper creates and initializes a new ResponseWriterWrapper.
@@ -102,6 +103,9 @@ func (w *ResponseWriterWrapper) Write(data []byte) (int, error) {
 }
 
 func (w *ResponseWriterWrapper) shouldBufferResponseBody() bool {
+	if w.requestLogDisabled() {
+		return false
+	}
 	if w.logger != nil && w.logger.IsEnabled() {
 		return true
 	}
@@ -161,7 +165,7 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 	w.isStreaming = w.detectStreaming(contentType)
 
 	// If streaming, initialize streaming log writer
-	if w.isStreaming && w.logger.IsEnabled() {
+	if w.isStreaming && w.logger.IsEnabled() && !w.requestLogDisabled() {
 		streamWriter, err := w.logger.LogStreamingRequest(
 			w.requestInfo.URL,
 			w.requestInfo.Method,
@@ -187,6 +191,12 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// requestLogDisabled reports whether the authenticated client's policy keeps the request out of
+// request logs.
+func (w *ResponseWriterWrapper) requestLogDisabled() bool {
+	return w.logDisabled != nil && w.logDisabled()
+}
+
 // ensureHeadersCaptured is a helper function to make sure response headers are captured.
 // It is safe to call this method multiple times; it will always refresh the headers
 // with the latest state from the underlying ResponseWriter.
@@ -289,7 +299,7 @@ func (w *ResponseWriterWrapper) Finalize(c *gin.Context) error {
 	apiRequestSource := w.extractAPIRequestSource(c)
 	apiResponseSource := w.extractAPIResponseSource(c)
 	apiWebsocketTimelineSource := w.extractAPIWebsocketTimelineSource(c)
-	if !w.logger.IsEnabled() && !forceLog {
+	if (!w.logger.IsEnabled() && !forceLog) || w.requestLogDisabled() {
 		cleanupFileBodySources(websocketTimelineSource, apiRequestSource, apiResponseSource, apiWebsocketTimelineSource)
 		return nil
 	}
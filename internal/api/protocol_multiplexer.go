@@ -10,6 +10,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
 )
 
 func normalizeHTTPServeError(err error) error {
@@ -75,6 +76,13 @@ func (s *Server) routeMuxConnection(conn net.Conn, httpListener *muxListener) {
 			return
 		}
 		proto := strings.TrimSpace(tlsConn.ConnectionState().NegotiatedProtocol)
+		if proto == acme.ALPNProto {
+			// A TLS-ALPN-01 challenge is complete once the handshake presented the certificate.
+			if errClose := conn.Close(); errClose != nil {
+				log.Debugf("failed to close ACME challenge connection: %v", errClose)
+			}
+			return
+		}
 		if proto == "h2" || proto == "http/1.1" {
 			if httpListener == nil {
 				if errClose := conn.Close(); errClose != nil {
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// Saturation headers let clients throttle themselves before hitting 429.
+const (
+	rateLimitLimitHeader       = "X-CPA-RateLimit-Limit"
+	rateLimitRemainingHeader   = "X-CPA-RateLimit-Remaining"
+	rateLimitResetHeader       = "X-CPA-RateLimit-Reset"
+	inFlightHeader             = "X-CPA-In-Flight"
+	estimatedWaitHeader        = "X-CPA-Estimated-Wait"
+	quotaLimitTokensHeader     = "X-CPA-Quota-Limit-Tokens"
+	quotaRemainingTokensHeader = "X-CPA-Quota-Remaining-Tokens"
+	rateLimitWarningHeader     = handlers.RateLimitWarningHeader
+	quotaWarningHeader         = "X-CPA-Quota-Warning"
+)
+
+// RateLimitMiddleware returns a Gin middleware that rejects requests with 429 once the
+// authenticated client API key has exhausted its token bucket. It must run after AuthMiddleware.
+// Responses of rate-limited keys carry the bucket state, the number of requests the key has in
+// flight and the estimated wait before the next request is admitted. Limits still in their
+// warn-only period admit the request, log it and set X-CPA-RateLimit-Warning instead.
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		apiKey := strings.TrimSpace(c.GetString("userApiKey"))
+		ok, warned, retryAfter := limiter.Check(apiKey)
+		writeRateLimitHeaders(c, limiter, apiKey, retryAfter)
+		if warned {
+			log.Warnf("rate limit (warn-only): key %s would have been limited", util.HideAPIKey(apiKey))
+			c.Header(rateLimitWarningHeader, "key")
+		}
+		if ok {
+			limiter.Begin(apiKey)
+			defer limiter.End(apiKey)
+			c.Next()
+			return
+		}
+		errLimited := &handlers.RateLimitError{RetryAfter: retryAfter}
+		c.Header("Retry-After", errLimited.Headers().Get("Retry-After"))
+		c.Data(http.StatusTooManyRequests, "application/json", handlers.BuildErrorResponseBody(http.StatusTooManyRequests, errLimited.Error()))
+		c.Abort()
+	}
+}
+
+func writeRateLimitHeaders(c *gin.Context, limiter *ratelimit.Limiter, apiKey string, wait time.Duration) {
+	status, ok := limiter.Status(apiKey)
+	if !ok {
+		return
+	}
+	c.Header(rateLimitLimitHeader, strconv.Itoa(status.Limit))
+	c.Header(rateLimitRemainingHeader, strconv.Itoa(status.Remaining))
+	c.Header(rateLimitResetHeader, ceilSeconds(status.Reset))
+	c.Header(inFlightHeader, strconv.Itoa(limiter.InFlight(apiKey)))
+	c.Header(estimatedWaitHeader, ceilSeconds(wait))
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
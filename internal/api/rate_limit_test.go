@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
+	"github.com/tidwall/gjson"
+)
+
+func TestRateLimitMiddleware_RejectsWithRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.NewLimiter(config.RateLimitConfig{Enable: true, RPS: 0.5, Burst: 1})
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, RateLimitMiddleware(limiter))
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set("X-Test-Key", key)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("alice")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get(rateLimitRemainingHeader); got != "0" {
+		t.Fatalf("%s = %q, want 0", rateLimitRemainingHeader, got)
+	}
+	rec = do("alice")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want %q", got, "2")
+	}
+	for header, want := range map[string]string{
+		rateLimitLimitHeader:     "1",
+		rateLimitRemainingHeader: "0",
+		rateLimitResetHeader:     "2",
+		inFlightHeader:           "0",
+		estimatedWaitHeader:      "2",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Fatalf("%s = %q, want %q", header, got, want)
+		}
+	}
+	if got := gjson.GetBytes(rec.Body.Bytes(), "error.type").String(); got != "rate_limit_error" {
+		t.Fatalf("error.type = %q, want rate_limit_error; body=%s", got, rec.Body.String())
+	}
+	if rec := do("bob"); rec.Code != http.StatusOK {
+		t.Fatalf("other key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimitMiddleware_WarnOnlyAdmitsRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.NewLimiter(config.RateLimitConfig{Enable: true, RPS: 0.5, Burst: 1, WarnOnlyUntil: "2999-01-01T00:00:00Z"})
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", "alice")
+		c.Next()
+	}, RateLimitMiddleware(limiter))
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, wantWarning := range []string{"", "key"} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get(rateLimitWarningHeader); got != wantWarning {
+			t.Fatalf("request %d %s = %q, want %q", i, rateLimitWarningHeader, got, wantWarning)
+		}
+	}
+}
+
+func TestRateLimitMiddleware_ReportsQueueDepth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := ratelimit.NewLimiter(config.RateLimitConfig{Enable: true, RPS: 10, Burst: 10})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", "alice")
+		c.Next()
+	}, RateLimitMiddleware(limiter))
+	engine.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if got := rec.Header().Get(inFlightHeader); got != "1" {
+		t.Fatalf("%s = %q, want 1 while another request is in flight", inFlightHeader, got)
+	}
+
+	close(release)
+	<-done
+	if got := limiter.InFlight("alice"); got != 0 {
+		t.Fatalf("in flight after completion = %d, want 0", got)
+	}
+}
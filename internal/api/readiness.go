@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readyzHandler reports whether the server can route requests to at least one usable
+// provider credential, so orchestrators hold traffic until credentials are loaded.
+// Liveness stays on /healthz, which answers as soon as the HTTP server is up.
+func (s *Server) readyzHandler(c *gin.Context) {
+	ready, total, usable := s.readiness()
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	if c.Request.Method == http.MethodHead {
+		c.Status(status)
+		return
+	}
+	body := gin.H{"status": "ok", "credentials": gin.H{"total": total, "usable": usable}}
+	if !ready {
+		body["status"] = "not ready"
+		body["reason"] = "no usable provider credentials"
+	}
+	c.JSON(status, body)
+}
+
+func (s *Server) readiness() (ready bool, total, usable int) {
+	if s.cfg != nil && s.cfg.Home.Enabled {
+		// Credentials are served by the home control plane rather than loaded locally.
+		return true, 0, 0
+	}
+	for _, entry := range s.providerHealth.Snapshot() {
+		total++
+		if entry.Usable() {
+			usable++
+		}
+	}
+	return usable > 0, total, usable
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestReadyzRequiresUsableCredential(t *testing.T) {
+	server := newTestServer(t)
+	readyz := func(method string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, httptest.NewRequest(method, "/readyz", nil))
+		return rr
+	}
+
+	if rr := readyz(http.MethodGet); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz without credentials = %d, want %d; body=%s", rr.Code, http.StatusServiceUnavailable, rr.Body.String())
+	}
+
+	manager := server.handlers.AuthManager
+	if _, errRegister := manager.Register(context.Background(), &auth.Auth{ID: "off", Provider: "claude", Disabled: true, Status: auth.StatusDisabled}); errRegister != nil {
+		t.Fatalf("register disabled auth: %v", errRegister)
+	}
+	if rr := readyz(http.MethodHead); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz with only disabled credentials = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	if _, errRegister := manager.Register(context.Background(), &auth.Auth{ID: "on", Provider: "claude", Status: auth.StatusActive}); errRegister != nil {
+		t.Fatalf("register active auth: %v", errRegister)
+	}
+	if rr := readyz(http.MethodGet); rr.Code != http.StatusOK {
+		t.Fatalf("readyz with active credential = %d, want %d; body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if rr := readyz(http.MethodHead); rr.Code != http.StatusOK || rr.Body.Len() != 0 {
+		t.Fatalf("HEAD readyz = %d body %q, want 200 with empty body", rr.Code, rr.Body.String())
+	}
+}
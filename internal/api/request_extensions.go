@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// RequestExtensionsMiddleware returns a Gin middleware that removes the reserved "cliproxy"
+// object from JSON request bodies before any handler sees them, so it can never be forwarded
+// upstream. Parsed extensions are stored on the gin context; invalid extensions are rejected
+// with 400 and dry-run requests are answered with the execution plan.
+func RequestExtensionsMiddleware(h *handlers.BaseAPIHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requestMayCarryJSON(c.Request) {
+			c.Next()
+			return
+		}
+		body, errRead := handlers.ReadRequestBody(c)
+		if errRead != nil {
+			abortInvalidRequest(c, "failed to read request body: "+errRead.Error())
+			return
+		}
+		ext, stripped, errExtract := handlers.ExtractRequestExtensions(body)
+		if errExtract != nil {
+			abortInvalidRequest(c, errExtract.Error())
+			return
+		}
+		// The body is now decoded; drop the encoding so handlers do not decode it twice.
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Body = io.NopCloser(bytes.NewReader(stripped))
+		c.Request.ContentLength = int64(len(stripped))
+		if ext == nil {
+			c.Next()
+			return
+		}
+
+		c.Set(handlers.RequestExtensionsGinKey, ext)
+		if ext.Cache.SessionID != "" {
+			c.Request.Header.Set(handlers.SessionIDHeader, ext.Cache.SessionID)
+		}
+		if ext.DryRun {
+			c.AbortWithStatusJSON(http.StatusOK, h.PlanExecution(requestExtensionsModel(c, stripped), ext))
+			return
+		}
+		c.Next()
+	}
+}
+
+func requestMayCarryJSON(req *http.Request) bool {
+	if req == nil || req.Body == nil || req.Body == http.NoBody {
+		return false
+	}
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return false
+	}
+	contentType := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Type")))
+	return contentType == "" || strings.Contains(contentType, "json")
+}
+
+// requestExtensionsModel returns the requested model from the body or, for Gemini routes,
+// from the "models/{model}:{method}" path.
+func requestExtensionsModel(c *gin.Context, body []byte) string {
+	if model := strings.TrimSpace(gjson.GetBytes(body, "model").String()); model != "" {
+		return model
+	}
+	action := strings.TrimPrefix(c.Param("action"), "/")
+	model, _, _ := strings.Cut(action, ":")
+	return strings.TrimSpace(model)
+}
+
+func abortInvalidRequest(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, handlers.ErrorResponse{Error: handlers.ErrorDetail{
+		Message: message,
+		Type:    "invalid_request_error",
+	}})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func newRequestExtensionsTestEngine(t *testing.T, seen *string, ext **handlers.RequestExtensions) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestExtensionsMiddleware(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		body, errRead := handlers.ReadRequestBody(c)
+		if errRead != nil {
+			t.Fatalf("read body: %v", errRead)
+		}
+		*seen = string(body)
+		*ext = handlers.RequestExtensionsFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"session": c.GetHeader(handlers.SessionIDHeader)})
+	})
+	return engine
+}
+
+func TestRequestExtensionsMiddleware_StripsExtensionBeforeHandler(t *testing.T) {
+	var seen string
+	var ext *handlers.RequestExtensions
+	engine := newRequestExtensionsTestEngine(t, &seen, &ext)
+
+	rec := httptest.NewRecorder()
+	body := `{"model":"gpt-5","cliproxy":{"routing":{"provider":"Codex"},"cache":{"session_id":"s-1"},"tags":["ci"," ci ","nightly"]},"messages":[]}`
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(seen, "cliproxy") || !strings.Contains(seen, `"messages":[]`) {
+		t.Fatalf("handler body = %s, want extension stripped and payload kept", seen)
+	}
+	if ext == nil || ext.Routing.Provider != "codex" || len(ext.Tags) != 2 {
+		t.Fatalf("extensions = %+v, want normalized provider and deduplicated tags", ext)
+	}
+	if !strings.Contains(rec.Body.String(), `"session":"s-1"`) {
+		t.Fatalf("response = %s, want session id applied as header", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-5"}`)))
+	if rec.Code != http.StatusOK || seen != `{"model":"gpt-5"}` || ext != nil {
+		t.Fatalf("plain request: status = %d, body = %s, extensions = %+v", rec.Code, seen, ext)
+	}
+}
+
+func TestRequestExtensionsMiddleware_RejectsUnknownField(t *testing.T) {
+	var seen string
+	var ext *handlers.RequestExtensions
+	engine := newRequestExtensionsTestEngine(t, &seen, &ext)
+
+	rec := httptest.NewRecorder()
+	body := `{"model":"gpt-5","cliproxy":{"dryrun":true}}`
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if seen != "" {
+		t.Fatal("handler ran for an invalid extension")
+	}
+}
+
+func TestRequestExtensionsMiddleware_DryRunReturnsPlan(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("dry-run-auth", "claude", []*registry.ModelInfo{{ID: "dry-run-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("dry-run-auth") })
+
+	var seen string
+	var ext *handlers.RequestExtensions
+	engine := newRequestExtensionsTestEngine(t, &seen, &ext)
+
+	rec := httptest.NewRecorder()
+	body := `{"model":"dry-run-model","cliproxy":{"dry_run":true,"tags":["ci"]}}`
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if seen != "" {
+		t.Fatal("handler ran for a dry-run request")
+	}
+	var plan handlers.ExecutionPlan
+	if errDecode := json.Unmarshal(rec.Body.Bytes(), &plan); errDecode != nil {
+		t.Fatalf("decode plan: %v", errDecode)
+	}
+	if len(plan.Targets) != 1 || len(plan.Targets[0].Providers) != 1 || plan.Targets[0].Providers[0] != "claude" {
+		t.Fatalf("plan = %+v, want claude target", plan)
+	}
+	if len(plan.Tags) != 1 || plan.Tags[0] != "ci" {
+		t.Fatalf("plan tags = %v, want [ci]", plan.Tags)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/requestqueue"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+)
+
+// requestPriorityHeader reports the priority class a request was scheduled in.
+const requestPriorityHeader = "X-CPA-Priority"
+
+// RequestQueueMiddleware returns a Gin middleware that holds POST requests until the priority
+// scheduler grants them an execution slot, which they keep until the response, including a
+// stream, is finished. It must run after AuthMiddleware. Requests are rejected with 429 when
+// their priority class queue is full and with 503 when they waited longer than max-wait.
+func RequestQueueMiddleware(queue *requestqueue.Queue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if queue == nil || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		apiKey := c.GetString("userApiKey")
+		release, errAcquire := queue.Acquire(c.Request.Context(), apiKey)
+		if errAcquire != nil {
+			var errQueue *requestqueue.Error
+			if !errors.As(errAcquire, &errQueue) {
+				// The client went away while waiting.
+				c.Abort()
+				return
+			}
+			c.Header("Retry-After", ceilSeconds(errQueue.RetryAfter()))
+			c.Data(errQueue.StatusCode(), "application/json", handlers.BuildErrorResponseBody(errQueue.StatusCode(), errQueue.Error()))
+			c.Abort()
+			return
+		}
+		defer release()
+		c.Header(requestPriorityHeader, queue.ClassOf(apiKey).String())
+		c.Next()
+	}
+}
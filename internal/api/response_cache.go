@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+	"github.com/tidwall/gjson"
+)
+
+// ResponseCacheHeader reports whether a response was served from the response cache ("HIT") or not ("MISS").
+const ResponseCacheHeader = "X-Cache"
+
+// maxCachedResponseBytes bounds the size of a response that is stored in the cache.
+const maxCachedResponseBytes = 4 << 20
+
+// ResponseCacheMiddleware returns a Gin middleware that serves GET /models and non-streaming
+// temperature-0 completions from the response cache. Entries are scoped to the client API key.
+// It must run after AuthMiddleware and before the quota, budget and rate-limit middlewares, so
+// cache hits are not charged. Requests carrying "cliproxy" extensions or an encoded body, which
+// RequestExtensionsMiddleware handles later, are not cached.
+func ResponseCacheMiddleware(cache *responsecache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cache.Enabled() {
+			c.Next()
+			return
+		}
+		key, ttl, ok := responseCacheKey(c, cache)
+		if !ok {
+			c.Next()
+			return
+		}
+		if entry, hit := cache.Get(c.Request.Context(), key); hit {
+			c.Header(ResponseCacheHeader, "HIT")
+			c.Data(entry.StatusCode, entry.ContentType, entry.Body)
+			c.Abort()
+			return
+		}
+
+		c.Header(ResponseCacheHeader, "MISS")
+		writer := &responseCacheWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		contentType := writer.Header().Get("Content-Type")
+		if writer.Status() != http.StatusOK || writer.overflow || writer.body.Len() == 0 || strings.Contains(contentType, "text/event-stream") {
+			return
+		}
+		cache.Set(c.Request.Context(), key, &responsecache.Entry{
+			StatusCode:  http.StatusOK,
+			ContentType: contentType,
+			Body:        bytes.Clone(writer.body.Bytes()),
+		}, ttl)
+	}
+}
+
+// responseCacheKey returns the cache key and TTL of a cacheable request.
+// Clients can bypass the cache with Cache-Control: no-cache or no-store.
+func responseCacheKey(c *gin.Context, cache *responsecache.Cache) (string, time.Duration, bool) {
+	cacheControl := strings.ToLower(c.GetHeader("Cache-Control"))
+	if strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "no-store") {
+		return "", 0, false
+	}
+	apiKey := strings.TrimSpace(c.GetString("userApiKey"))
+	path := c.Request.URL.Path
+
+	switch c.Request.Method {
+	case http.MethodGet:
+		if !strings.HasSuffix(path, "/models") {
+			return "", 0, false
+		}
+		variant := "openai"
+		if isAnthropicModelsRequest(c) {
+			variant = "anthropic"
+		}
+		return responsecache.Key(http.MethodGet, path, c.Request.URL.RawQuery, variant, apiKey), cache.ModelsTTL(), true
+	case http.MethodPost:
+		if !requestMayCarryJSON(c.Request) || strings.Contains(c.Param("action"), "streamGenerateContent") || isResponsesAPIPath(path) {
+			return "", 0, false
+		}
+		if encoding := strings.TrimSpace(c.GetHeader("Content-Encoding")); encoding != "" && !strings.EqualFold(encoding, "identity") {
+			return "", 0, false
+		}
+		body, errRead := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if errRead != nil || gjson.GetBytes(body, "cliproxy").Exists() {
+			return "", 0, false
+		}
+		normalized, ok := deterministicRequestBody(body)
+		if !ok {
+			return "", 0, false
+		}
+		return responsecache.Key(http.MethodPost, path, c.Request.URL.RawQuery, apiKey, string(normalized)), cache.TTL(), true
+	default:
+		return "", 0, false
+	}
+}
+
+// isResponsesAPIPath reports whether path is a Responses API endpoint. Those responses carry
+// their own ids and may be stored server-side, so replaying one would hand out a duplicate id.
+func isResponsesAPIPath(path string) bool {
+	return strings.HasSuffix(path, "/responses") || strings.Contains(path, "/responses/")
+}
+
+// deterministicRequestBody returns the body with sorted keys and no insignificant whitespace when
+// it is a non-streaming request with temperature 0.
+func deterministicRequestBody(body []byte) ([]byte, bool) {
+	if gjson.GetBytes(body, "stream").Bool() {
+		return nil, false
+	}
+	temperature := gjson.GetBytes(body, "temperature")
+	if !temperature.Exists() {
+		temperature = gjson.GetBytes(body, "generationConfig.temperature")
+	}
+	if temperature.Type != gjson.Number || temperature.Float() != 0 {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var payload any
+	if errDecode := decoder.Decode(&payload); errDecode != nil {
+		return nil, false
+	}
+	normalized, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		return nil, false
+	}
+	return normalized, true
+}
+
+// responseCacheWriter copies the response body so it can be stored after the handler returns.
+type responseCacheWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *responseCacheWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxCachedResponseBytes {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+func (w *responseCacheWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseCacheWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+)
+
+func TestResponseCacheMiddleware_CachesDeterministicRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cache := responsecache.New(config.ResponseCacheConfig{Enable: true})
+	calls := 0
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, ResponseCacheMiddleware(cache))
+	engine.GET("/v1/models", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"object": "list"})
+	})
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"id": "chatcmpl-1"})
+	})
+
+	do := func(method, key, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/v1/chat/completions", strings.NewReader(body))
+		if method == http.MethodGet {
+			req = httptest.NewRequest(method, "/v1/models", nil)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Key", key)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "alice", ""); rec.Header().Get(ResponseCacheHeader) != "MISS" {
+		t.Fatalf("first models request X-Cache = %q, want MISS", rec.Header().Get(ResponseCacheHeader))
+	}
+	rec := do(http.MethodGet, "alice", "")
+	if rec.Header().Get(ResponseCacheHeader) != "HIT" || rec.Body.String() != `{"object":"list"}` {
+		t.Fatalf("second models request = %q %s, want cached body", rec.Header().Get(ResponseCacheHeader), rec.Body.String())
+	}
+	if do(http.MethodGet, "bob", ""); calls != 2 {
+		t.Fatalf("handler calls = %d, want 2 (entries are scoped to the client key)", calls)
+	}
+
+	do(http.MethodPost, "alice", `{"model":"m","temperature":0,"messages":[]}`)
+	rec = do(http.MethodPost, "alice", `{ "messages": [], "temperature": 0, "model": "m" }`)
+	if rec.Header().Get(ResponseCacheHeader) != "HIT" {
+		t.Fatalf("reordered temperature-0 request X-Cache = %q, want HIT", rec.Header().Get(ResponseCacheHeader))
+	}
+	if calls != 3 {
+		t.Fatalf("handler calls = %d, want 3", calls)
+	}
+
+	for _, body := range []string{
+		`{"model":"m","temperature":0.7,"messages":[]}`,
+		`{"model":"m","messages":[]}`,
+		`{"model":"m","temperature":0,"stream":true,"messages":[]}`,
+	} {
+		before := calls
+		do(http.MethodPost, "alice", body)
+		do(http.MethodPost, "alice", body)
+		if calls != before+2 {
+			t.Fatalf("body %s was served from cache", body)
+		}
+	}
+}
+
+func TestResponseCacheMiddleware_SkipsResponsesAPIAndExtensions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cache := responsecache.New(config.ResponseCacheConfig{Enable: true})
+	calls := 0
+	engine := gin.New()
+	engine.Use(ResponseCacheMiddleware(cache))
+	handler := func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"id": "resp-1"})
+	}
+	engine.POST("/v1/responses", handler)
+	engine.POST("/v1/responses/compact", handler)
+	engine.POST("/v1/chat/completions", handler)
+
+	for _, tc := range []struct{ path, body string }{
+		{"/v1/responses", `{"model":"m","temperature":0,"input":"hi"}`},
+		{"/v1/responses/compact", `{"model":"m","temperature":0,"input":"hi"}`},
+		{"/v1/chat/completions", `{"model":"m","temperature":0,"messages":[],"cliproxy":{"dry_run":true}}`},
+	} {
+		before := calls
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			engine.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		if calls != before+2 {
+			t.Fatalf("%s %s was served from cache", tc.path, tc.body)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package api
+
+import "syscall"
+
+// reusePortControl is unused where SO_REUSEPORT is unavailable.
+func reusePortControl(_, _ string, _ syscall.RawConn) error {
+	return nil
+}
+
+const reusePortSupported = false
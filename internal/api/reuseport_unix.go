@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package api
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reusePortControl sets SO_REUSEPORT so an upgraded process can bind the port while the old
+// one drains.
+func reusePortControl(_, _ string, conn syscall.RawConn) error {
+	var errSockopt error
+	errControl := conn.Control(func(fd uintptr) {
+		errSockopt = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if errControl != nil {
+		return errControl
+	}
+	return errSockopt
+}
+
+const reusePortSupported = true
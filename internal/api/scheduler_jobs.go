@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler/jobs"
+	log "github.com/sirupsen/logrus"
+)
+
+// registerSchedulerJobs exposes the server's background routines as scheduler jobs. Every job
+// can be triggered from the management API; a job with an active cron schedule takes over the
+// periodic runs of its built-in loop. Token refresh keeps its loop, which refreshes each
+// credential when it is due, and the schedule adds sweeps on top of it.
+func (s *Server) registerSchedulerJobs() {
+	s.scheduler.Register(jobs.TokenRefresh, "Refresh credentials whose tokens are due", func(ctx context.Context) error {
+		if s.handlers == nil || s.handlers.AuthManager == nil {
+			return errors.New("auth manager unavailable")
+		}
+		if refreshed := s.handlers.AuthManager.RefreshDue(ctx); refreshed > 0 {
+			log.Infof("scheduler: refreshed %d credential(s)", refreshed)
+		}
+		return nil
+	})
+	s.scheduler.Register(jobs.ModelRefresh, "Fetch the remote model catalog", registry.RefreshModels)
+	s.scheduler.Register(jobs.HealthProbe, "Probe every credential's model-list endpoint", func(ctx context.Context) error {
+		s.providerHealth.ProbeAll(ctx)
+		return nil
+	})
+	s.scheduler.Register(jobs.RegionProbe, "Probe the regional endpoints of every credential", func(ctx context.Context) error {
+		s.regionRouter.ProbeAll(ctx)
+		return nil
+	})
+	s.scheduler.Register(jobs.UsageFlush, "Persist pending usage accounting buckets", func(context.Context) error {
+		s.usageAccounting.Flush()
+		return nil
+	})
+	s.scheduler.Register(jobs.LogCleanup, "Enforce logs-max-total-size-mb", func(context.Context) error {
+		removed, errClean := logging.CleanLogDirectory()
+		if removed > 0 {
+			log.Infof("scheduler: removed %d old log file(s)", removed)
+		}
+		return errClean
+	})
+}
+
+// applyScheduler applies the scheduler configuration and hands the periodic runs of scheduled
+// jobs from their built-in loops to the scheduler.
+func (s *Server) applyScheduler(cfg config.SchedulerConfig) {
+	s.scheduler.Apply(cfg)
+	jobs.SetScheduled(s.scheduler.Scheduled())
+}
+
+// stopScheduler stops the scheduler and returns the periodic runs to the built-in loops.
+func (s *Server) stopScheduler() {
+	s.scheduler.Stop()
+	jobs.SetScheduled(nil)
+}
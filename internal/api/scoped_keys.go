@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	log "github.com/sirupsen/logrus"
+)
+
+// scopedKeysFileName is stored next to the config file and holds hashed scoped API keys.
+const scopedKeysFileName = "scoped-keys.json"
+
+// attachScopedKeyStore backs the access manager's scoped keys with a file next to the config,
+// unless an embedder already supplied a store.
+func attachScopedKeyStore(manager *sdkaccess.Manager, configFilePath string) {
+	if manager == nil || manager.KeyStore() != nil || strings.TrimSpace(configFilePath) == "" {
+		return
+	}
+	store := sdkaccess.NewFileKeyStore(filepath.Join(filepath.Dir(configFilePath), scopedKeysFileName))
+	if errStore := manager.SetKeyStore(context.Background(), store); errStore != nil {
+		log.Warnf("failed to load scoped API keys: %v", errStore)
+	}
+}
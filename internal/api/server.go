@@ -25,19 +25,38 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/access"
+	mtlsaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/mtls_access"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/anthropicfiles"
 	managementHandlers "github.com/router-for-me/CLIProxyAPI/v7/internal/api/handlers/management"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api/middleware"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/credcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/health"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/idempotency"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginhost"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/region"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/requestqueue"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsecache"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsestore"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/safemode"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sharedstate"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamresume"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/transcripts"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/upgrade"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/wasmfilter"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers/claude"
@@ -46,9 +65,9 @@ import (
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/net/http2"
 	"gopkg.in/yaml.v3"
 )
 
@@ -62,10 +81,21 @@ var corsExposedResponseHeaders = []string{
 	"X-CPA-COMMIT",
 	"X-CPA-BUILD-DATE",
 	"X-CPA-SUPPORT-PLUGIN",
+	versionHeader,
 	"X-CPA-HOME-VERSION",
 	"X-CPA-HOME-BUILD-DATE",
 	"X-SERVER-VERSION",
 	"X-SERVER-BUILD-DATE",
+	rateLimitLimitHeader,
+	rateLimitRemainingHeader,
+	rateLimitResetHeader,
+	inFlightHeader,
+	estimatedWaitHeader,
+	quotaLimitTokensHeader,
+	quotaRemainingTokensHeader,
+	rateLimitWarningHeader,
+	quotaWarningHeader,
+	idempotentReplayedHeader,
 }
 
 var corsExposedResponseHeadersJoined = strings.Join(corsExposedResponseHeaders, ", ")
@@ -77,6 +107,7 @@ const (
 
 type serverOptionConfig struct {
 	extraMiddleware       []gin.HandlerFunc
+	pipelineMiddleware    []handlers.PipelineMiddleware
 	engineConfigurator    func(*gin.Engine)
 	routerConfigurator    func(*gin.Engine, *handlers.BaseAPIHandler, *config.Config)
 	requestLoggerFactory  func(*config.Config, string) logging.RequestLogger
@@ -88,6 +119,7 @@ type serverOptionConfig struct {
 	postAuthPersistHook   auth.PostAuthHook
 	pluginHost            *pluginhost.Host
 	configReloadHook      func(context.Context, *config.Config)
+	configModules         []ConfigModule
 	exampleAPIKeySafeMode bool
 }
 
@@ -121,6 +153,13 @@ func WithMiddleware(mw ...gin.HandlerFunc) ServerOption {
 	}
 }
 
+// WithPipelineMiddleware appends request and response interceptors to the provider handler pipeline.
+func WithPipelineMiddleware(mw ...handlers.PipelineMiddleware) ServerOption {
+	return func(cfg *serverOptionConfig) {
+		cfg.pipelineMiddleware = append(cfg.pipelineMiddleware, mw...)
+	}
+}
+
 // WithEngineConfigurator allows callers to mutate the Gin engine prior to middleware setup.
 func WithEngineConfigurator(fn func(*gin.Engine)) ServerOption {
 	return func(cfg *serverOptionConfig) {
@@ -211,6 +250,9 @@ type Server struct {
 	// muxHTTPListener receives HTTP connections selected by the multiplexer.
 	muxHTTPListener *muxListener
 
+	// unixListener serves the API on listen.unix-socket as plain HTTP.
+	unixListener net.Listener
+
 	// handlers contains the API handlers for processing requests.
 	handlers *handlers.BaseAPIHandler
 
@@ -246,6 +288,56 @@ type Server struct {
 	// pluginHost owns dynamic plugin Management API route dispatch.
 	pluginHost *pluginhost.Host
 
+	// providerHealth probes configured credentials in the background for /v0/health/providers.
+	providerHealth *health.Prober
+
+	// regionRouter probes regional endpoints and selects the fastest healthy region per credential.
+	regionRouter *region.Router
+
+	// rateLimiter enforces per-client-API-key request budgets.
+	rateLimiter *ratelimit.Limiter
+
+	// requestQueue schedules requests by priority class in front of upstream execution.
+	requestQueue *requestqueue.Queue
+
+	// responseCache serves repeated model list and deterministic completion requests.
+	responseCache *responsecache.Cache
+
+	// idempotency replays stored responses for retried requests carrying an Idempotency-Key.
+	idempotency *idempotency.Store
+
+	// streamResume buffers SSE events so interrupted streams can be resumed with Last-Event-ID.
+	streamResume *streamresume.Registry
+
+	// usageAccounting records token usage per client API key and enforces monthly quotas.
+	usageAccounting *usageaccounting.Tracker
+
+	// scheduler runs background jobs on cron schedules and on demand.
+	scheduler *scheduler.Scheduler
+
+	// batches stores files and runs emulated OpenAI batches.
+	batches *batch.Manager
+
+	// anthropicFiles stores Anthropic Files API uploads.
+	anthropicFiles *anthropicfiles.Store
+
+	// contentKeys seals stored batch files, Anthropic file uploads, stream transcripts and
+	// stored Responses API responses.
+	contentKeys *contentcrypt.Keyring
+
+	// wasmFilters rewrites API requests and responses with WebAssembly modules.
+	wasmFilters *wasmfilter.Engine
+
+	// grpcIngress serves the chat completions API over gRPC on its own port.
+	grpcIngress *grpcIngress
+
+	// acmeHTTPServer answers ACME HTTP-01 challenges while tls.acme is enabled.
+	acmeHTTPServer *http.Server
+
+	// configModules are notified with the old and new configuration on every reload.
+	configModulesMu sync.Mutex
+	configModules   []ConfigModule
+
 	// managementRoutesRegistered tracks whether the management routes have been attached to the engine.
 	managementRoutesRegistered atomic.Bool
 	// managementRoutesEnabled controls whether management endpoints serve real handlers.
@@ -341,12 +433,41 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		envManagementSecret: envManagementSecret,
 		wsRoutes:            make(map[string]struct{}),
 		pluginHost:          optionState.pluginHost,
+		providerHealth:      health.NewProber(authManager),
+		regionRouter:        region.NewRouter(authManager),
+		rateLimiter:         ratelimit.NewLimiter(cfg.RateLimit),
+		requestQueue:        requestqueue.NewQueue(cfg.RequestQueue),
+		responseCache:       responsecache.New(cfg.ResponseCache),
+		idempotency:         idempotency.New(cfg.Idempotency),
+		streamResume:        streamresume.New(cfg.StreamResume),
+		usageAccounting:     usageaccounting.NewTracker(),
+		scheduler:           scheduler.New(),
+		batches:             batch.NewManager(),
+		anthropicFiles:      anthropicfiles.NewStore(),
+		contentKeys:         contentcrypt.NewKeyring(),
+		wasmFilters:         wasmfilter.NewEngine(),
 
 		exampleAPIKeySafeModeEnabled: optionState.exampleAPIKeySafeMode,
 	}
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	s.exampleAPIKeySafeModeActive.Store(s.exampleAPIKeySafeModeRequired(cfg))
 	s.handlers.SetPluginHost(optionState.pluginHost)
+	s.handlers.SetRateLimiter(s.rateLimiter)
+	s.handlers.SetAdmission(s.admitDetached)
+	s.handlers.UsePipelineMiddleware(optionState.pipelineMiddleware...)
+	coreusage.RegisterNamedPlugin("usage-accounting", s.usageAccounting)
+	s.usageAccounting.SetTenants(cfg.Tenants)
+	s.registerSchedulerJobs()
+	s.contentKeys.Apply(cfg.ContentEncryption, cfg.AuthDir)
+	credcrypt.Default().Apply(cfg.CredentialEncryption)
+	s.wasmFilters.Apply(cfg.WasmFilters)
+	s.batches.SetKeyring(s.contentKeys)
+	s.anthropicFiles.SetKeyring(s.contentKeys)
+	transcripts.Default().SetKeyring(s.contentKeys)
+	responsestore.Default().SetKeyring(s.contentKeys)
+	s.batches.Apply(cfg.Batch, cfg.AuthDir)
+	s.anthropicFiles.Apply(cfg.AnthropicFiles, cfg.AuthDir)
+	s.grpcIngress = newGRPCIngress(openai.NewOpenAIGRPCHandler(s.handlers, s.accessManager))
 	if optionState.pluginHost != nil {
 		optionState.pluginHost.SetModelExecutor(s.handlers)
 		optionState.pluginHost.SetAuthManager(authManager)
@@ -361,9 +482,23 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	auth.SetTransientErrorCooldownSeconds(cfg.TransientErrorCooldownSeconds)
 	applySignatureCacheConfig(nil, cfg)
+	applyModelPricingConfig(cfg)
+	applyModelCatalogConfig(cfg)
+	applyUpstreamTransportConfig(cfg)
+	sharedstate.Default().Update(cfg.SharedState)
+	responsestore.Default().Update(cfg.EffectiveResponsesStore())
+	attachScopedKeyStore(accessManager, configFilePath)
+	s.configModules = append(s.configModules, optionState.configModules...)
+	s.notifyConfigModules(nil, cfg)
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
+	s.mgmt.SetAccessManager(accessManager)
 	s.mgmt.SetPluginHost(optionState.pluginHost)
+	s.mgmt.SetProviderHealth(s.providerHealth)
+	s.mgmt.SetUsageAccounting(s.usageAccounting)
+	s.mgmt.SetScheduler(s.scheduler)
+	s.mgmt.SetRegionRouter(s.regionRouter)
+	s.mgmt.SetContentKeyring(s.contentKeys)
 	s.mgmt.SetConfigReloadHook(optionState.configReloadHook)
 	if optionState.localPassword != "" {
 		s.mgmt.SetLocalPassword(optionState.localPassword)
@@ -382,6 +517,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// subscribe-config heartbeat connection is healthy.
 	engine.Use(s.homeHeartbeatMiddleware())
 	engine.Use(s.exampleAPIKeySafeModeMiddleware())
+	engine.Use(s.versionHeaderMiddleware())
 
 	// Setup routes
 	s.setupRoutes()
@@ -502,6 +638,25 @@ func isExampleAPIKeySafeModeProxyPath(path string) bool {
 	}
 }
 
+// clientAPIMiddlewares returns the middleware chain shared by every client-facing API group.
+func (s *Server) clientAPIMiddlewares() []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		AuthMiddleware(s.accessManager),
+		StreamResumeMiddleware(s.streamResume),
+		RequestLifecycleMiddleware(),
+		IdempotencyMiddleware(s.idempotency),
+		ResponseCacheMiddleware(s.responseCache),
+		UsageQuotaMiddleware(s.usageAccounting),
+		UsageBudgetMiddleware(s.usageAccounting),
+		RateLimitMiddleware(s.rateLimiter),
+		RequestQueueMiddleware(s.requestQueue),
+		EstimatedCostMiddleware(),
+		s.requestHeaderFilterMiddleware(),
+		RequestExtensionsMiddleware(s.handlers),
+		WasmFilterMiddleware(s.wasmFilters),
+	}
+}
+
 // setupRoutes configures the API routes for the server.
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
@@ -515,20 +670,29 @@ func (s *Server) setupRoutes() {
 	}
 	s.engine.GET("/healthz", healthzHandler)
 	s.engine.HEAD("/healthz", healthzHandler)
+	s.engine.GET("/readyz", s.readyzHandler)
+	s.engine.HEAD("/readyz", s.readyzHandler)
 
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
+	s.engine.GET("/dashboard", s.serveDashboard)
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
+	claudeCodeHandlers.SetFileStore(s.anthropicFiles)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiBatchHandlers := openai.NewOpenAIBatchAPIHandler(s.handlers, s.batches)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(s.clientAPIMiddlewares()...)
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/fim/completions", openaiHandlers.FIMCompletions)
+		v1.POST("/audio/transcriptions", openaiHandlers.AudioTranscriptions)
+		v1.POST("/audio/speech", openaiHandlers.AudioSpeech)
 		v1.POST("/images/generations", openaiHandlers.ImagesGenerations)
 		v1.POST("/images/edits", openaiHandlers.ImagesEdits)
 		v1.POST("/videos", openaiHandlers.XAIVideosGenerations)
@@ -541,11 +705,24 @@ func (s *Server) setupRoutes() {
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.POST("/responses/input_tokens", openaiResponsesHandlers.InputTokens)
+		v1.GET("/responses/:response_id", openaiResponsesHandlers.GetResponse)
+		v1.DELETE("/responses/:response_id", openaiResponsesHandlers.DeleteResponse)
+		v1.POST("/responses/:response_id/cancel", openaiResponsesHandlers.CancelResponse)
 		v1.POST("/alpha/search", s.codexAlphaSearch)
+		v1.POST("/files", unifiedFilesHandler(claudeCodeHandlers, claudeCodeHandlers.ClaudeUploadFile, openaiBatchHandlers.UploadFile))
+		v1.GET("/files", unifiedFilesHandler(claudeCodeHandlers, claudeCodeHandlers.ClaudeListFiles, openaiBatchHandlers.ListFiles))
+		v1.GET("/files/:file_id", unifiedFilesHandler(claudeCodeHandlers, claudeCodeHandlers.ClaudeRetrieveFile, openaiBatchHandlers.RetrieveFile))
+		v1.GET("/files/:file_id/content", unifiedFilesHandler(claudeCodeHandlers, claudeCodeHandlers.ClaudeFileContent, openaiBatchHandlers.FileContent))
+		v1.DELETE("/files/:file_id", unifiedFilesHandler(claudeCodeHandlers, claudeCodeHandlers.ClaudeDeleteFile, openaiBatchHandlers.DeleteFile))
+		v1.POST("/batches", openaiBatchHandlers.CreateBatch)
+		v1.GET("/batches", openaiBatchHandlers.ListBatches)
+		v1.GET("/batches/:batch_id", openaiBatchHandlers.RetrieveBatch)
+		v1.POST("/batches/:batch_id/cancel", openaiBatchHandlers.CancelBatch)
 	}
 
 	openaiV1 := s.engine.Group("/openai/v1")
-	openaiV1.Use(AuthMiddleware(s.accessManager))
+	openaiV1.Use(s.clientAPIMiddlewares()...)
 	{
 		openaiV1.POST("/videos", openaiHandlers.VideosCreate)
 		openaiV1.GET("/videos/:video_id/content", openaiHandlers.VideosContent)
@@ -554,7 +731,7 @@ func (s *Server) setupRoutes() {
 
 	// Codex CLI direct route aliases (chatgpt_base_url compatible)
 	codexDirect := s.engine.Group("/backend-api/codex")
-	codexDirect.Use(AuthMiddleware(s.accessManager))
+	codexDirect.Use(s.clientAPIMiddlewares()...)
 	{
 		codexDirect.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		codexDirect.POST("/responses", openaiResponsesHandlers.Responses)
@@ -564,7 +741,7 @@ func (s *Server) setupRoutes() {
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(s.clientAPIMiddlewares()...)
 	{
 		v1beta.GET("/models", s.geminiModelsHandler(geminiHandlers))
 		v1beta.POST("/interactions", geminiHandlers.Interactions)
@@ -579,6 +756,10 @@ func (s *Server) setupRoutes() {
 			"endpoints": []string{
 				"POST /v1/chat/completions",
 				"POST /v1/completions",
+				"POST /v1/embeddings",
+				"POST /v1/fim/completions",
+				"POST /v1/audio/transcriptions",
+				"POST /v1/audio/speech",
 				"GET /v1/models",
 			},
 		})
@@ -886,6 +1067,14 @@ func (s *Server) codexAlphaSearch(c *gin.Context) {
 	_, _ = c.Writer.Write(upstreamBody)
 }
 
+// Handlers returns the shared provider handler that executes requests for every API route.
+func (s *Server) Handlers() *handlers.BaseAPIHandler {
+	if s == nil {
+		return nil
+	}
+	return s.handlers
+}
+
 // AttachWebsocketRoute registers a websocket upgrade handler on the primary Gin engine.
 // The handler is served as-is without additional middleware beyond the standard stack already configured.
 func (s *Server) AttachWebsocketRoute(path string, handler http.Handler) {
@@ -935,6 +1124,7 @@ func (s *Server) registerManagementRoutes() {
 
 	s.engine.POST("/v0/management/oauth-callback", s.managementAvailabilityMiddleware(), s.mgmt.PostOAuthCallback)
 	s.engine.GET("/v0/management/oauth-callback", s.managementAvailabilityMiddleware(), s.mgmt.GetOAuthCallback)
+	s.engine.GET("/v0/health/providers", s.managementAvailabilityMiddleware(), s.mgmt.Middleware(), s.mgmt.GetProviderHealth)
 
 	mgmt := s.engine.Group("/v0/management")
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
@@ -943,6 +1133,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
+		mgmt.GET("/version", s.mgmt.GetVersion)
 		mgmt.GET("/plugins", s.mgmt.ListPlugins)
 		mgmt.GET("/plugin-store", s.mgmt.ListPluginStore)
 		mgmt.POST("/plugin-store/:id/install", s.mgmt.InstallPluginFromStore)
@@ -964,6 +1155,10 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
 		mgmt.PATCH("/logs-max-total-size-mb", s.mgmt.PutLogsMaxTotalSizeMB)
 
+		mgmt.GET("/log-output", s.mgmt.GetLogOutput)
+		mgmt.PUT("/log-output", s.mgmt.PutLogOutput)
+		mgmt.PATCH("/log-output", s.mgmt.PatchLogOutput)
+
 		mgmt.GET("/error-logs-max-files", s.mgmt.GetErrorLogsMaxFiles)
 		mgmt.PUT("/error-logs-max-files", s.mgmt.PutErrorLogsMaxFiles)
 		mgmt.PATCH("/error-logs-max-files", s.mgmt.PutErrorLogsMaxFiles)
@@ -991,19 +1186,49 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/api-keys", s.mgmt.GetAPIKeys)
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
-		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+		mgmt.DELETE("/api-keys", s.mgmt.SoftDelete("api-keys", s.mgmt.DeleteAPIKeys))
+		mgmt.POST("/api-keys/bulk", s.mgmt.PostAPIKeysBulk)
+		mgmt.DELETE("/api-keys/bulk", s.mgmt.SoftDelete("api-keys", s.mgmt.DeleteAPIKeysBulk))
+		mgmt.GET("/scoped-keys", s.mgmt.GetScopedKeys)
+		mgmt.POST("/scoped-keys", s.mgmt.PostScopedKey)
+		mgmt.DELETE("/scoped-keys", s.mgmt.DeleteScopedKey)
 		mgmt.GET("/api-key-usage", s.mgmt.GetAPIKeyUsage)
 		mgmt.GET("/usage-queue", s.mgmt.GetUsageQueue)
+		mgmt.GET("/usage", s.mgmt.GetUsage)
+		mgmt.GET("/usage/budgets", s.mgmt.GetUsageBudgets)
+		mgmt.PUT("/usage/budgets/override", s.mgmt.PutUsageBudgetOverride)
+		mgmt.DELETE("/usage/budgets/override", s.mgmt.DeleteUsageBudgetOverride)
+		mgmt.GET("/bandwidth", s.mgmt.GetBandwidth)
+		mgmt.DELETE("/bandwidth", s.mgmt.DeleteBandwidth)
+		mgmt.GET("/race-stats", s.mgmt.GetRaceStats)
+		mgmt.DELETE("/race-stats", s.mgmt.DeleteRaceStats)
+		mgmt.GET("/transcripts/:conversation", s.mgmt.GetTranscripts)
+		mgmt.DELETE("/transcripts/:conversation", s.mgmt.DeleteTranscripts)
+		mgmt.GET("/signing-audit", s.mgmt.GetSigningAudit)
+		mgmt.GET("/signing-audit/:request_id", s.mgmt.GetSigningAuditRequest)
+		mgmt.GET("/pii-redaction", s.mgmt.GetPIIRedaction)
+		mgmt.DELETE("/pii-redaction", s.mgmt.DeletePIIRedaction)
+		mgmt.GET("/metrics", s.mgmt.GetMetrics)
+
+		mgmt.GET("/scheduler/jobs", s.mgmt.GetSchedulerJobs)
+		mgmt.POST("/scheduler/jobs/:name/run", s.mgmt.RunSchedulerJob)
+
+		mgmt.GET("/readiness", s.mgmt.GetReadiness)
+
+		mgmt.GET("/region-routing", s.mgmt.GetRegionRouting)
+		mgmt.PUT("/region-routing/pin", s.mgmt.PutRegionPin)
+
+		mgmt.POST("/content-encryption/shred", s.mgmt.PostContentShred)
 
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
 		mgmt.PATCH("/gemini-api-key", s.mgmt.PatchGeminiKey)
-		mgmt.DELETE("/gemini-api-key", s.mgmt.DeleteGeminiKey)
+		mgmt.DELETE("/gemini-api-key", s.mgmt.SoftDelete("gemini-api-key", s.mgmt.DeleteGeminiKey))
 
 		mgmt.GET("/interactions-api-key", s.mgmt.GetInteractionsKeys)
 		mgmt.PUT("/interactions-api-key", s.mgmt.PutInteractionsKeys)
 		mgmt.PATCH("/interactions-api-key", s.mgmt.PatchInteractionsKey)
-		mgmt.DELETE("/interactions-api-key", s.mgmt.DeleteInteractionsKey)
+		mgmt.DELETE("/interactions-api-key", s.mgmt.SoftDelete("interactions-api-key", s.mgmt.DeleteInteractionsKey))
 
 		mgmt.GET("/logs", s.mgmt.GetLogs)
 		mgmt.DELETE("/logs", s.mgmt.DeleteLogs)
@@ -1035,37 +1260,42 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
 		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
 		mgmt.PATCH("/claude-api-key", s.mgmt.PatchClaudeKey)
-		mgmt.DELETE("/claude-api-key", s.mgmt.DeleteClaudeKey)
+		mgmt.DELETE("/claude-api-key", s.mgmt.SoftDelete("claude-api-key", s.mgmt.DeleteClaudeKey))
 
 		mgmt.GET("/codex-api-key", s.mgmt.GetCodexKeys)
 		mgmt.PUT("/codex-api-key", s.mgmt.PutCodexKeys)
 		mgmt.PATCH("/codex-api-key", s.mgmt.PatchCodexKey)
-		mgmt.DELETE("/codex-api-key", s.mgmt.DeleteCodexKey)
+		mgmt.DELETE("/codex-api-key", s.mgmt.SoftDelete("codex-api-key", s.mgmt.DeleteCodexKey))
 
 		mgmt.GET("/xai-api-key", s.mgmt.GetXAIKeys)
 		mgmt.PUT("/xai-api-key", s.mgmt.PutXAIKeys)
 		mgmt.PATCH("/xai-api-key", s.mgmt.PatchXAIKey)
-		mgmt.DELETE("/xai-api-key", s.mgmt.DeleteXAIKey)
+		mgmt.DELETE("/xai-api-key", s.mgmt.SoftDelete("xai-api-key", s.mgmt.DeleteXAIKey))
 
 		mgmt.GET("/openai-compatibility", s.mgmt.GetOpenAICompat)
 		mgmt.PUT("/openai-compatibility", s.mgmt.PutOpenAICompat)
 		mgmt.PATCH("/openai-compatibility", s.mgmt.PatchOpenAICompat)
-		mgmt.DELETE("/openai-compatibility", s.mgmt.DeleteOpenAICompat)
+		mgmt.DELETE("/openai-compatibility", s.mgmt.SoftDelete("openai-compatibility", s.mgmt.DeleteOpenAICompat))
 
 		mgmt.GET("/vertex-api-key", s.mgmt.GetVertexCompatKeys)
 		mgmt.PUT("/vertex-api-key", s.mgmt.PutVertexCompatKeys)
 		mgmt.PATCH("/vertex-api-key", s.mgmt.PatchVertexCompatKey)
-		mgmt.DELETE("/vertex-api-key", s.mgmt.DeleteVertexCompatKey)
+		mgmt.DELETE("/vertex-api-key", s.mgmt.SoftDelete("vertex-api-key", s.mgmt.DeleteVertexCompatKey))
 
 		mgmt.GET("/oauth-excluded-models", s.mgmt.GetOAuthExcludedModels)
 		mgmt.PUT("/oauth-excluded-models", s.mgmt.PutOAuthExcludedModels)
 		mgmt.PATCH("/oauth-excluded-models", s.mgmt.PatchOAuthExcludedModels)
-		mgmt.DELETE("/oauth-excluded-models", s.mgmt.DeleteOAuthExcludedModels)
+		mgmt.DELETE("/oauth-excluded-models", s.mgmt.SoftDelete("oauth-excluded-models", s.mgmt.DeleteOAuthExcludedModels))
+
+		mgmt.GET("/model-aliases", s.mgmt.GetModelAliases)
+		mgmt.PUT("/model-aliases", s.mgmt.PutModelAliases)
+		mgmt.PATCH("/model-aliases", s.mgmt.PatchModelAliases)
+		mgmt.DELETE("/model-aliases", s.mgmt.SoftDelete("model-aliases", s.mgmt.DeleteModelAliases))
 
 		mgmt.GET("/oauth-model-alias", s.mgmt.GetOAuthModelAlias)
 		mgmt.PUT("/oauth-model-alias", s.mgmt.PutOAuthModelAlias)
 		mgmt.PATCH("/oauth-model-alias", s.mgmt.PatchOAuthModelAlias)
-		mgmt.DELETE("/oauth-model-alias", s.mgmt.DeleteOAuthModelAlias)
+		mgmt.DELETE("/oauth-model-alias", s.mgmt.SoftDelete("oauth-model-alias", s.mgmt.DeleteOAuthModelAlias))
 
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
@@ -1077,6 +1307,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
+		mgmt.GET("/trash", s.mgmt.GetTrash)
+		mgmt.GET("/trash/audit", s.mgmt.GetTrashAudit)
+		mgmt.POST("/trash/:id/restore", s.mgmt.RestoreTrashItem)
+		mgmt.DELETE("/trash/:id", s.mgmt.DeleteTrashItem)
+
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
 		mgmt.GET("/codex-auth-url", s.mgmt.RequestCodexToken)
 		mgmt.GET("/antigravity-auth-url", s.mgmt.RequestAntigravityToken)
@@ -1340,6 +1575,19 @@ func (s *Server) unifiedModelsHandler(openaiHandler *openai.OpenAIAPIHandler, cl
 	}
 }
 
+// unifiedFilesHandler routes a /v1/files request to the Anthropic Files API when it is
+// enabled and the request comes from an Anthropic client (Anthropic-Version or
+// Anthropic-Beta header, or a claude-cli User-Agent); otherwise to the OpenAI batch files.
+func unifiedFilesHandler(claudeHandler *claude.ClaudeCodeAPIHandler, anthropicHandler, openaiHandler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claudeHandler.FilesEnabled() && (isAnthropicModelsRequest(c) || c.GetHeader("Anthropic-Beta") != "") {
+			anthropicHandler(c)
+			return
+		}
+		openaiHandler(c)
+	}
+}
+
 // handleHomeCodexClientModels builds the Codex client catalog from Home model IDs.
 // Template metadata still comes from the local/remote codex_client_models catalog.
 func (s *Server) handleHomeCodexClientModels(c *gin.Context) {
@@ -1771,46 +2019,79 @@ func (s *Server) Start() error {
 	}
 
 	addr := s.server.Addr
-	listener, errListen := net.Listen("tcp", addr)
+	listener, errListen := s.openMainListener(addr)
 	if errListen != nil {
 		return fmt.Errorf("failed to start HTTP server: %v", errListen)
 	}
 
 	useTLS := s.cfg != nil && s.cfg.TLS.Enable
 	if useTLS {
-		certPath := strings.TrimSpace(s.cfg.TLS.Cert)
-		keyPath := strings.TrimSpace(s.cfg.TLS.Key)
-		if certPath == "" || keyPath == "" {
-			if errClose := listener.Close(); errClose != nil {
-				log.Errorf("failed to close listener after TLS validation failure: %v", errClose)
+		tlsConfig := &tls.Config{
+			NextProtos: []string{"h2", "http/1.1"},
+		}
+		if s.cfg.TLS.ACME.Enable {
+			manager, errACME := newACMEManager(s.cfg.TLS.ACME, s.configFilePath)
+			if errACME != nil {
+				if errClose := listener.Close(); errClose != nil {
+					log.Errorf("failed to close listener after ACME setup failure: %v", errClose)
+				}
+				return fmt.Errorf("failed to start HTTPS server: %v", errACME)
+			}
+			applyACMETLS(tlsConfig, manager)
+			s.grpcIngress.SetCertificateSource(manager.GetCertificate)
+			if s.cfg.TLS.ACME.HTTPChallengeEnabled() {
+				s.acmeHTTPServer = startACMEHTTPChallenge(manager, s.cfg.TLS.ACME.HTTPChallengeAddr)
+			}
+		} else {
+			certPath := strings.TrimSpace(s.cfg.TLS.Cert)
+			keyPath := strings.TrimSpace(s.cfg.TLS.Key)
+			if certPath == "" || keyPath == "" {
+				if errClose := listener.Close(); errClose != nil {
+					log.Errorf("failed to close listener after TLS validation failure: %v", errClose)
+				}
+				return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
+			}
+			certPair, errLoad := tls.LoadX509KeyPair(certPath, keyPath)
+			if errLoad != nil {
+				if errClose := listener.Close(); errClose != nil {
+					log.Errorf("failed to close listener after TLS key pair load failure: %v", errClose)
+				}
+				return fmt.Errorf("failed to start HTTPS server: %v", errLoad)
 			}
-			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
+			tlsConfig.Certificates = []tls.Certificate{certPair}
 		}
-		certPair, errLoad := tls.LoadX509KeyPair(certPath, keyPath)
-		if errLoad != nil {
+		if errClientAuth := applyClientAuthTLS(tlsConfig, s.cfg.TLS.ClientAuth); errClientAuth != nil {
 			if errClose := listener.Close(); errClose != nil {
-				log.Errorf("failed to close listener after TLS key pair load failure: %v", errClose)
+				log.Errorf("failed to close listener after client CA load failure: %v", errClose)
 			}
-			return fmt.Errorf("failed to start HTTPS server: %v", errLoad)
-		}
-
-		tlsConfig := &tls.Config{
-			Certificates: []tls.Certificate{certPair},
-			NextProtos:   []string{"h2", "http/1.1"},
+			return fmt.Errorf("failed to start HTTPS server: %v", errClientAuth)
 		}
 		s.server.TLSConfig = tlsConfig
-		if errHTTP2 := http2.ConfigureServer(s.server, &http2.Server{}); errHTTP2 != nil {
-			log.Warnf("failed to configure HTTP/2: %v", errHTTP2)
-		}
+		s.server.ConnContext = mtlsaccess.ConnContext
+		configureHTTP2(s.server, s.cfg.HTTP2, true)
 		listener = tls.NewListener(listener, tlsConfig)
 		log.Debugf("Starting API server on %s with TLS", addr)
 	} else {
+		if s.cfg != nil {
+			configureHTTP2(s.server, s.cfg.HTTP2, false)
+		}
 		log.Debugf("Starting API server on %s", addr)
 	}
 
+	if s.cfg != nil {
+		s.providerHealth.Apply(s.cfg.HealthCheck)
+		s.regionRouter.Apply(s.cfg.RegionRouting)
+		s.usageAccounting.Apply(s.cfg.UsageAccounting, s.cfg.AuthDir)
+		transcripts.Default().Apply(s.cfg.Transcripts, s.cfg.AuthDir)
+		s.applyScheduler(s.cfg.Scheduler)
+		s.grpcIngress.Apply(s.cfg)
+	}
+
 	httpListener := newMuxListener(listener.Addr(), 1024)
 	s.muxBaseListener = listener
 	s.muxHTTPListener = httpListener
+	s.startUnixSocket(httpListener)
+	upgrade.NotifyReady()
 
 	httpErrCh := make(chan error, 1)
 	acceptErrCh := make(chan error, 1)
@@ -1882,6 +2163,8 @@ func (s *Server) Stop(ctx context.Context) error {
 		}
 	}
 
+	// Stop accepting first, so a new process sharing the port takes new connections while
+	// in-flight requests drain.
 	if s.muxHTTPListener != nil {
 		_ = s.muxHTTPListener.Close()
 	}
@@ -1890,10 +2173,35 @@ func (s *Server) Stop(ctx context.Context) error {
 			log.Debugf("failed to close shared listener: %v", errClose)
 		}
 	}
+	if s.unixListener != nil {
+		if errClose := s.unixListener.Close(); errClose != nil && !errors.Is(errClose, net.ErrClosed) {
+			log.Debugf("failed to close unix socket listener: %v", errClose)
+		}
+	}
 
-	// Shutdown the HTTP server.
-	if err := s.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
+	// Wait for in-flight requests, including streaming responses, until ctx expires; then
+	// close what is left. Subsystems stay up meanwhile so draining requests still record usage.
+	log.Info("draining in-flight requests...")
+	errShutdown := s.server.Shutdown(ctx)
+	if errShutdown != nil {
+		log.Warnf("drain deadline reached, closing remaining connections: %v", errShutdown)
+		if errClose := s.server.Close(); errClose != nil {
+			log.Debugf("failed to close remaining connections: %v", errClose)
+		}
+	}
+
+	s.stopScheduler()
+	s.grpcIngress.Stop()
+	s.stopACMEHTTPChallenge(ctx)
+	s.batches.Stop()
+	s.providerHealth.Stop()
+	s.regionRouter.Stop()
+	s.usageAccounting.Stop()
+	s.responseCache.Close()
+	s.wasmFilters.Close()
+
+	if errShutdown != nil {
+		return fmt.Errorf("failed to shutdown HTTP server: %v", errShutdown)
 	}
 
 	log.Debug("API server stopped")
@@ -1978,7 +2286,7 @@ func (s *Server) UpdateClientsContext(ctx context.Context, cfg *config.Config) b
 		}
 	}
 
-	if oldCfg == nil || oldCfg.LoggingToFile != cfg.LoggingToFile || oldCfg.LogsMaxTotalSizeMB != cfg.LogsMaxTotalSizeMB {
+	if oldCfg == nil || oldCfg.LoggingToFile != cfg.LoggingToFile || oldCfg.LogsMaxTotalSizeMB != cfg.LogsMaxTotalSizeMB || oldCfg.LogOutput != cfg.LogOutput {
 		if err := logging.ConfigureLogOutput(cfg); err != nil {
 			log.Errorf("failed to reconfigure log output: %v", err)
 		}
@@ -2013,6 +2321,9 @@ func (s *Server) UpdateClientsContext(ctx context.Context, cfg *config.Config) b
 	}
 
 	applySignatureCacheConfig(oldCfg, cfg)
+	applyModelPricingConfig(cfg)
+	applyModelCatalogConfig(cfg)
+	applyUpstreamTransportConfig(cfg)
 
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
@@ -2083,6 +2394,34 @@ func (s *Server) UpdateClientsContext(ctx context.Context, cfg *config.Config) b
 		s.pluginHost.SetAuthManager(s.handlers.AuthManager)
 	}
 
+	s.providerHealth.SetAuthManager(s.handlers.AuthManager)
+	if oldCfg == nil || oldCfg.HealthCheck != cfg.HealthCheck {
+		s.providerHealth.Apply(cfg.HealthCheck)
+	}
+	s.regionRouter.SetAuthManager(s.handlers.AuthManager)
+	s.regionRouter.Apply(cfg.RegionRouting)
+	s.rateLimiter.Update(cfg.RateLimit)
+	s.requestQueue.Update(cfg.RequestQueue)
+	s.responseCache.Update(cfg.ResponseCache)
+	s.idempotency.Update(cfg.Idempotency)
+	s.streamResume.Update(cfg.StreamResume)
+	sharedstate.Default().Update(cfg.SharedState)
+	responsestore.Default().Update(cfg.EffectiveResponsesStore())
+	s.usageAccounting.SetTenants(cfg.Tenants)
+	s.usageAccounting.Apply(cfg.UsageAccounting, cfg.AuthDir)
+	transcripts.Default().Apply(cfg.Transcripts, cfg.AuthDir)
+	s.applyScheduler(cfg.Scheduler)
+	s.contentKeys.Apply(cfg.ContentEncryption, cfg.AuthDir)
+	credcrypt.Default().Apply(cfg.CredentialEncryption)
+	s.wasmFilters.Apply(cfg.WasmFilters)
+	s.batches.Apply(cfg.Batch, cfg.AuthDir)
+	s.anthropicFiles.Apply(cfg.AnthropicFiles, cfg.AuthDir)
+	s.grpcIngress.Apply(cfg)
+	s.notifyConfigModules(oldCfg, cfg)
+	if restart := restartRequiredChanges(oldCfg, cfg); len(restart) > 0 {
+		log.Warnf("config changes to %s take effect after restart", strings.Join(restart, ", "))
+	}
+
 	if s.mgmt != nil {
 		s.mgmt.SetConfig(cfg)
 		s.mgmt.SetAuthManager(s.handlers.AuthManager)
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamresume"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+)
+
+const (
+	// StreamIDHeader reports the ID of a resumable stream.
+	StreamIDHeader = "X-CPA-Stream-ID"
+	// lastEventIDHeader is sent by reconnecting SSE clients.
+	lastEventIDHeader = "Last-Event-ID"
+	// resumeKeepAliveInterval is how often a resumed stream sends a heartbeat while it waits.
+	resumeKeepAliveInterval = 15 * time.Second
+)
+
+// StreamResumeMiddleware returns a Gin middleware that makes SSE responses resumable. Events
+// are sent with "id: <stream-id>:<seq>" lines and buffered; a request carrying a Last-Event-ID
+// of a buffered stream owned by the same client API key receives the later events instead of
+// running again. When a client disconnects mid-stream, the upstream request keeps running for
+// the resume window so the client can reconnect. It must run after AuthMiddleware.
+func StreamResumeMiddleware(registry *streamresume.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || !registry.Enabled() {
+			c.Next()
+			return
+		}
+		owner := strings.TrimSpace(c.GetString("userApiKey"))
+		if streamID, seq, ok := parseStreamEventID(c.GetHeader(lastEventIDHeader)); ok {
+			resumeStream(c, registry, owner, streamID, seq)
+			c.Abort()
+			return
+		}
+
+		// The handler runs on a context the middleware cancels, so a stream can outlive its
+		// client for the resume window.
+		clientCtx := c.Request.Context()
+		streamCtx, cancel := context.WithCancel(context.WithoutCancel(clientCtx))
+		defer cancel()
+		writer := &resumableWriter{ResponseWriter: c.Writer, registry: registry, owner: owner, cancel: cancel}
+		c.Writer = writer
+		c.Request = c.Request.WithContext(streamCtx)
+
+		handlerDone := make(chan struct{})
+		defer close(handlerDone)
+		go func() {
+			select {
+			case <-clientCtx.Done():
+				writer.clientGone()
+			case <-handlerDone:
+			}
+		}()
+		c.Next()
+		writer.finish()
+	}
+}
+
+// resumeStream replays the events of streamID after seq and follows the stream until it ends.
+func resumeStream(c *gin.Context, registry *streamresume.Registry, owner, streamID string, seq uint64) {
+	stream, found := registry.Lookup(owner, streamID)
+	if !found {
+		c.Data(http.StatusGone, "application/json", handlers.BuildErrorResponseBody(http.StatusGone, "stream is no longer resumable"))
+		return
+	}
+	events, changed, done, ok := stream.Since(seq)
+	if !ok {
+		c.Data(http.StatusGone, "application/json", handlers.BuildErrorResponseBody(http.StatusGone, "events after the Last-Event-ID are no longer buffered"))
+		return
+	}
+	stream.Attach()
+	defer stream.Detach()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header(StreamIDHeader, stream.ID)
+	c.Status(http.StatusOK)
+	keepAlive := time.NewTicker(resumeKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		for _, event := range events {
+			_, _ = c.Writer.Write(streamEventWithID(stream.ID, event))
+			seq = event.Seq
+		}
+		c.Writer.Flush()
+		if done {
+			return
+		}
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			_, _ = c.Writer.Write([]byte(": keep-alive\n\n"))
+		case <-c.Request.Context().Done():
+			return
+		}
+		if events, changed, done, ok = stream.Since(seq); !ok {
+			return
+		}
+	}
+}
+
+// parseStreamEventID splits a Last-Event-ID of the form "<stream-id>:<seq>".
+func parseStreamEventID(value string) (string, uint64, bool) {
+	value = strings.TrimSpace(value)
+	idx := strings.LastIndexByte(value, ':')
+	if idx <= 0 {
+		return "", 0, false
+	}
+	seq, errParse := strconv.ParseUint(value[idx+1:], 10, 64)
+	if errParse != nil {
+		return "", 0, false
+	}
+	return value[:idx], seq, true
+}
+
+func streamEventWithID(streamID string, event streamresume.Event) []byte {
+	return append(fmt.Appendf(nil, "id: %s:%d\n", streamID, event.Seq), event.Data...)
+}
+
+// resumableWriter numbers and buffers the events of an SSE response. Other responses pass
+// through unchanged.
+type resumableWriter struct {
+	gin.ResponseWriter
+	registry *streamresume.Registry
+	owner    string
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	decided bool
+	stream  *streamresume.Stream
+	pending []byte
+	gone    bool
+}
+
+// beginLocked decides, before the headers are sent, whether the response is a resumable stream.
+func (w *resumableWriter) beginLocked() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	if w.ResponseWriter.Status() != http.StatusOK || !strings.Contains(w.ResponseWriter.Header().Get("Content-Type"), "text/event-stream") {
+		return
+	}
+	if w.stream = w.registry.Start(w.owner, w.cancel); w.stream != nil {
+		w.ResponseWriter.Header().Set(StreamIDHeader, w.stream.ID)
+	}
+}
+
+func (w *resumableWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.beginLocked()
+	if w.stream == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	w.pending = append(w.pending, data...)
+	for {
+		end := bytes.Index(w.pending, []byte("\n\n"))
+		if end < 0 {
+			break
+		}
+		w.emitLocked(bytes.Clone(w.pending[:end+2]))
+		w.pending = w.pending[end+2:]
+	}
+	return len(data), nil
+}
+
+func (w *resumableWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *resumableWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	w.beginLocked()
+	w.mu.Unlock()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *resumableWriter) Flush() {
+	w.mu.Lock()
+	w.beginLocked()
+	w.mu.Unlock()
+	w.ResponseWriter.Flush()
+}
+
+// emitLocked sends one complete event. Comments such as heartbeats are not numbered or buffered.
+func (w *resumableWriter) emitLocked(event []byte) {
+	if isSSEComment(event) {
+		w.writeClientLocked(event)
+		return
+	}
+	seq := w.stream.Append(event)
+	w.writeClientLocked(streamEventWithID(w.stream.ID, streamresume.Event{Seq: seq, Data: event}))
+}
+
+func (w *resumableWriter) writeClientLocked(data []byte) {
+	if !w.gone {
+		_, _ = w.ResponseWriter.Write(data)
+	}
+}
+
+// clientGone stops writing to the disconnected client. A resumable stream keeps running for the
+// resume window; any other response is cancelled at once.
+func (w *resumableWriter) clientGone() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.gone = true
+	if w.stream != nil {
+		w.stream.Detach()
+		return
+	}
+	w.decided = true
+	w.cancel()
+}
+
+// finish sends a trailing partial event and marks the stream complete.
+func (w *resumableWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stream == nil {
+		return
+	}
+	if len(bytes.TrimSpace(w.pending)) > 0 {
+		w.emitLocked(w.pending)
+	}
+	w.pending = nil
+	w.stream.Finish()
+}
+
+// isSSEComment reports whether every line of event is a comment or blank.
+func isSSEComment(event []byte) bool {
+	for _, line := range bytes.Split(event, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != ':' {
+			return false
+		}
+	}
+	return true
+}
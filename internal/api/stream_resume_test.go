@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamresume"
+)
+
+func TestStreamResumeMiddleware_ResumesAfterClientDisconnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := streamresume.New(config.StreamResumeConfig{Enable: true, Window: "1m"})
+	wrote := make(chan struct{})
+	release := make(chan struct{})
+	handlerCancelled := make(chan bool, 1)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, StreamResumeMiddleware(registry))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		_, _ = c.Writer.Write([]byte("data: one\n\n"))
+		_, _ = c.Writer.Write([]byte(": keep-alive\n\n"))
+		_, _ = c.Writer.Write([]byte("data: two\n"))
+		_, _ = c.Writer.Write([]byte("\n"))
+		c.Writer.Flush()
+		close(wrote)
+		<-release
+		handlerCancelled <- c.Request.Context().Err() != nil
+		_, _ = c.Writer.Write([]byte("data: [DONE]\n\n"))
+	})
+
+	do := func(ctx context.Context, apiKey, lastEventID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"stream":true}`)).WithContext(ctx)
+		req.Header.Set("X-Test-Key", apiKey)
+		if lastEventID != "" {
+			req.Header.Set(lastEventIDHeader, lastEventID)
+		}
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	clientCtx, disconnect := context.WithCancel(context.Background())
+	firstDone := make(chan *httptest.ResponseRecorder, 1)
+	go func() { firstDone <- do(clientCtx, "alice", "") }()
+	<-wrote
+	disconnect()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	first := <-firstDone
+	if <-handlerCancelled {
+		t.Fatal("handler context was cancelled right after the client disconnected")
+	}
+
+	streamID := first.Header().Get(StreamIDHeader)
+	if streamID == "" {
+		t.Fatal("missing stream ID header")
+	}
+	wantFirst := "id: " + streamID + ":1\ndata: one\n\n: keep-alive\n\nid: " + streamID + ":2\ndata: two\n\n"
+	if first.Body.String() != wantFirst {
+		t.Fatalf("first body = %q, want %q", first.Body.String(), wantFirst)
+	}
+
+	resumed := do(context.Background(), "alice", streamID+":1")
+	wantResumed := "id: " + streamID + ":2\ndata: two\n\nid: " + streamID + ":3\ndata: [DONE]\n\n"
+	if resumed.Code != http.StatusOK || resumed.Body.String() != wantResumed {
+		t.Fatalf("resumed = %d %q, want %q", resumed.Code, resumed.Body.String(), wantResumed)
+	}
+	if rec := do(context.Background(), "bob", streamID+":1"); rec.Code != http.StatusGone {
+		t.Fatalf("resume by another key = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestStreamResumeMiddleware_CancelsAbandonedStreamAfterWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := streamresume.New(config.StreamResumeConfig{Enable: true, Window: "30ms"})
+	wrote := make(chan struct{})
+	engine := gin.New()
+	engine.Use(StreamResumeMiddleware(registry))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		_, _ = c.Writer.Write([]byte("data: one\n\n"))
+		close(wrote)
+		<-c.Request.Context().Done()
+	})
+
+	clientCtx, disconnect := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil).WithContext(clientCtx)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-wrote
+	disconnect()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("abandoned stream was not cancelled after the resume window")
+	}
+}
+
+func TestParseStreamEventID(t *testing.T) {
+	if id, seq, ok := parseStreamEventID(" 6f1c:a:12 "); !ok || id != "6f1c:a" || seq != 12 {
+		t.Fatalf("parse = %q %d %t", id, seq, ok)
+	}
+	for _, value := range []string{"", "12", ":3", "abc:x"} {
+		if _, _, ok := parseStreamEventID(value); ok {
+			t.Fatalf("parse(%q) succeeded", value)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/lifecycle"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+// budgetExceededType is the error type and code of responses rejected by a spend budget.
+const budgetExceededType = "budget_exceeded"
+
+// UsageBudgetMiddleware returns a Gin middleware that rejects requests with 402 once the
+// authenticated client API key or its tenant has reached a token or cost budget of the current
+// period. It must run after AuthMiddleware. The error body carries the budget that was reached.
+func UsageBudgetMiddleware(tracker *usageaccounting.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tracker == nil {
+			c.Next()
+			return
+		}
+		apiKey := strings.TrimSpace(c.GetString("userApiKey"))
+		status, ok := tracker.CheckBudget(apiKey)
+		if ok {
+			c.Next()
+			return
+		}
+		status.APIKey = util.HideAPIKey(status.APIKey)
+		reportBudgetExceeded(tracker, status)
+		c.Header("Retry-After", ceilSeconds(status.ResetsAt.Sub(clock.Default().Now())))
+		c.JSON(http.StatusPaymentRequired, budgetExceededBody(status))
+		c.Abort()
+	}
+}
+
+// budgetExceededBody is the error body of requests rejected by the budget in status.
+func budgetExceededBody(status usageaccounting.BudgetStatus) gin.H {
+	return gin.H{"error": gin.H{
+		"message": fmt.Sprintf("%s %s budget exceeded", status.Period, status.Exceeded),
+		"type":    budgetExceededType,
+		"code":    budgetExceededType,
+		"budget":  status,
+	}}
+}
+
+// reportBudgetExceeded emits budget.exceeded for the first rejection by the budget in status
+// until its period ends.
+func reportBudgetExceeded(tracker *usageaccounting.Tracker, status usageaccounting.BudgetStatus) {
+	id := "budget|key:" + status.APIKey + "|" + status.Period
+	if status.Tenant != "" {
+		id = "budget|tenant:" + status.Tenant + "|" + status.Period
+	}
+	if !tracker.FirstRejection(id, status.ResetsAt) {
+		return
+	}
+	data := map[string]any{"period": status.Period, "limit": status.Exceeded, "used_tokens": status.UsedTokens, "used_cost": status.UsedCost}
+	if status.Tenant != "" {
+		data["scope"], data["tenant"] = "tenant", status.Tenant
+	} else {
+		data["scope"], data["api_key"] = "client", status.APIKey
+	}
+	if status.Tokens > 0 {
+		data["tokens"] = status.Tokens
+	}
+	if status.Cost > 0 {
+		data["cost"] = status.Cost
+	}
+	lifecycle.Default().Emit(lifecycle.EventBudgetExceeded, data)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestUsageBudgetMiddleware_RejectsOverBudgetKeyWith402(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tracker := usageaccounting.NewTracker()
+	tracker.Apply(config.UsageAccountingConfig{
+		Enable:  true,
+		Budgets: []config.UsageBudget{{APIKey: "alice", Period: config.UsageBudgetDaily, Cost: 0.5}},
+	}, t.TempDir())
+	t.Cleanup(tracker.Stop)
+	tracker.HandleUsage(context.Background(), coreusage.Record{
+		Provider:      "openai-compatibility",
+		Model:         "gpt-5",
+		APIKey:        "alice",
+		Detail:        coreusage.Detail{InputTokens: 8, OutputTokens: 4},
+		EstimatedCost: 0.75,
+	})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, UsageBudgetMiddleware(tracker))
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set("X-Test-Key", key)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("alice")
+	if rec.Code != http.StatusPaymentRequired {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusPaymentRequired)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After header")
+	}
+	body := rec.Body.Bytes()
+	if got := gjson.GetBytes(body, "error.type").String(); got != budgetExceededType {
+		t.Fatalf("error.type = %q; body=%s", got, body)
+	}
+	if got := gjson.GetBytes(body, "error.budget.exceeded").String(); got != usageaccounting.BudgetLimitCost {
+		t.Fatalf("error.budget.exceeded = %q; body=%s", got, body)
+	}
+	if got := gjson.GetBytes(body, "error.budget.api_key").String(); got == "alice" {
+		t.Fatalf("error.budget.api_key exposes the key; body=%s", body)
+	}
+
+	tracker.OverrideBudget("alice", "", tracker.Budgets()[0].ResetsAt)
+	if rec = do("alice"); rec.Code != http.StatusOK {
+		t.Fatalf("overridden key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec = do("bob"); rec.Code != http.StatusOK {
+		t.Fatalf("other key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/lifecycle"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// UsageQuotaMiddleware returns a Gin middleware that rejects requests with 429 once the
+// authenticated client API key has used up its monthly token quota. It must run after AuthMiddleware.
+// Responses of keys with a quota report the quota and the tokens left this month. Quotas still in
+// their warn-only period admit the request, log it and set X-CPA-Quota-Warning instead.
+// A quota.exceeded lifecycle event is emitted once per key until its quota resets.
+func UsageQuotaMiddleware(tracker *usageaccounting.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tracker == nil {
+			c.Next()
+			return
+		}
+		apiKey := strings.TrimSpace(c.GetString("userApiKey"))
+		ok, warned, retryAfter := tracker.Check(apiKey)
+		if quota, remaining, hasQuota := tracker.QuotaRemaining(apiKey); hasQuota {
+			c.Header(quotaLimitTokensHeader, strconv.FormatInt(quota, 10))
+			c.Header(quotaRemainingTokensHeader, strconv.FormatInt(remaining, 10))
+		}
+		if warned {
+			log.Warnf("usage quota (warn-only): key %s would have been limited", util.HideAPIKey(apiKey))
+			c.Header(quotaWarningHeader, "monthly-tokens")
+		}
+		if ok {
+			c.Next()
+			return
+		}
+		reportQuotaExceeded(tracker, apiKey, retryAfter)
+		c.Header("Retry-After", ceilSeconds(retryAfter))
+		c.Data(http.StatusTooManyRequests, "application/json", handlers.BuildErrorResponseBody(http.StatusTooManyRequests, errQuotaExceeded))
+		c.Abort()
+	}
+}
+
+// errQuotaExceeded is the error message of requests rejected by a monthly token quota.
+const errQuotaExceeded = "monthly token quota exceeded"
+
+// reportQuotaExceeded emits quota.exceeded for the first rejection of apiKey until its quota
+// resets after retryAfter.
+func reportQuotaExceeded(tracker *usageaccounting.Tracker, apiKey string, retryAfter time.Duration) {
+	if !tracker.FirstRejection("quota|"+apiKey, clock.Default().Now().Add(retryAfter)) {
+		return
+	}
+	data := map[string]any{"scope": "client", "api_key": util.HideAPIKey(apiKey), "limit": "monthly-tokens"}
+	if quota, _, hasQuota := tracker.QuotaRemaining(apiKey); hasQuota {
+		data["quota"] = quota
+	}
+	lifecycle.Default().Emit(lifecycle.EventQuotaExceeded, data)
+}
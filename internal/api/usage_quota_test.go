@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usageaccounting"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestUsageQuotaMiddleware_RejectsExhaustedKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tracker := usageaccounting.NewTracker()
+	tracker.Apply(config.UsageAccountingConfig{
+		Enable: true,
+		Quotas: []config.UsageQuota{{APIKey: "alice", MonthlyTokens: 10}},
+	}, t.TempDir())
+	t.Cleanup(tracker.Stop)
+	tracker.HandleUsage(context.Background(), coreusage.Record{
+		Provider: "openai-compatibility",
+		Model:    "gpt-5",
+		APIKey:   "alice",
+		Detail:   coreusage.Detail{InputTokens: 8, OutputTokens: 4},
+	})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, UsageQuotaMiddleware(tracker))
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set("X-Test-Key", key)
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("alice")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After header")
+	}
+	if got := rec.Header().Get(quotaLimitTokensHeader); got != "10" {
+		t.Fatalf("%s = %q, want 10", quotaLimitTokensHeader, got)
+	}
+	if got := rec.Header().Get(quotaRemainingTokensHeader); got != "0" {
+		t.Fatalf("%s = %q, want 0", quotaRemainingTokensHeader, got)
+	}
+	if got := gjson.GetBytes(rec.Body.Bytes(), "error.message").String(); got != "monthly token quota exceeded" {
+		t.Fatalf("error.message = %q; body=%s", got, rec.Body.String())
+	}
+	if rec = do("bob"); rec.Code != http.StatusOK {
+		t.Fatalf("other key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get(quotaLimitTokensHeader); got != "" {
+		t.Fatalf("key without quota got %s = %q", quotaLimitTokensHeader, got)
+	}
+}
+
+func TestServerEnforcesTenantQuotaWithoutReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authDir := t.TempDir()
+	cfg := &config.Config{
+		AuthDir:         authDir,
+		UsageAccounting: config.UsageAccountingConfig{Enable: true},
+	}
+	cfg.Tenants = []config.Tenant{{Name: "acme", APIKeys: []string{"acme-1", "acme-2"}, MonthlyTokens: 10}}
+	server := NewServer(cfg, auth.NewManager(nil, nil, nil), sdkaccess.NewManager(), filepath.Join(authDir, "config.yaml"))
+	server.usageAccounting.Apply(cfg.UsageAccounting, cfg.AuthDir)
+	t.Cleanup(server.usageAccounting.Stop)
+
+	server.usageAccounting.HandleUsage(context.Background(), coreusage.Record{
+		Provider: "openai-compatibility",
+		Model:    "gpt-5",
+		APIKey:   "acme-1",
+		Detail:   coreusage.Detail{InputTokens: 8, OutputTokens: 4},
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer acme-2")
+	server.engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusTooManyRequests, rec.Body.String())
+	}
+}
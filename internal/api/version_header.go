@@ -0,0 +1,20 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+)
+
+// versionHeader identifies the build that produced a response when version-header is enabled.
+const versionHeader = "X-CLIProxy-Version"
+
+// versionHeaderMiddleware sets X-CLIProxy-Version ("<version> (<commit>)") on every response
+// while version-header is enabled.
+func (s *Server) versionHeaderMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s != nil && s.cfg != nil && s.cfg.VersionHeader {
+			c.Header(versionHeader, buildinfo.Version+" ("+buildinfo.Commit+")")
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestVersionHeaderMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, enabled := range []bool{false, true} {
+		s := &Server{cfg: &config.Config{VersionHeader: enabled}}
+		engine := gin.New()
+		engine.Use(s.versionHeaderMiddleware())
+		engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+
+		want := ""
+		if enabled {
+			want = buildinfo.Version + " (" + buildinfo.Commit + ")"
+		}
+		if got := rec.Header().Get(versionHeader); got != want {
+			t.Fatalf("enabled=%v: %s = %q, want %q", enabled, versionHeader, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/wasmfilter"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// WasmFilterMiddleware returns a Gin middleware that runs the configured WebAssembly filters on
+// matching routes. Filters see the request headers and JSON body before the handler and the
+// buffered response afterwards; streaming responses and websocket upgrades pass through
+// unfiltered. It runs after ResponseCacheMiddleware, so the cache stores filtered responses and
+// replays them without running the filters again.
+func WasmFilterMiddleware(engine *wasmfilter.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if c.IsWebsocket() || !engine.Matches(path) {
+			c.Next()
+			return
+		}
+		session, errBegin := engine.Begin(c.Request.Context(), path)
+		if errBegin != nil {
+			log.Errorf("wasm filters: %v", errBegin)
+			c.Data(http.StatusInternalServerError, "application/json", handlers.BuildErrorResponseBody(http.StatusInternalServerError, "request filter failed"))
+			c.Abort()
+			return
+		}
+		defer session.Close()
+
+		filterBody := requestMayCarryJSON(c.Request)
+		var body []byte
+		if filterBody {
+			var errRead error
+			body, errRead = io.ReadAll(c.Request.Body)
+			if errRead != nil {
+				c.Data(http.StatusBadRequest, "application/json", handlers.BuildErrorResponseBody(http.StatusBadRequest, "failed to read request body"))
+				c.Abort()
+				return
+			}
+		}
+		out, local, errFilter := session.Request(c.Request.Context(), c.Request.Header, body)
+		if errFilter != nil {
+			log.Errorf("wasm filters: %v", errFilter)
+			c.Data(http.StatusInternalServerError, "application/json", handlers.BuildErrorResponseBody(http.StatusInternalServerError, "request filter failed"))
+			c.Abort()
+			return
+		}
+		if local != nil {
+			writeWasmLocalResponse(c.Writer, local)
+			c.Abort()
+			return
+		}
+		if filterBody {
+			c.Request.Body = io.NopCloser(bytes.NewReader(out))
+			c.Request.ContentLength = int64(len(out))
+			c.Request.Header.Set("Content-Length", strconv.Itoa(len(out)))
+		}
+
+		if !session.FiltersResponses() {
+			c.Next()
+			return
+		}
+		writer := &wasmFilterWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		if writer.passthrough {
+			return
+		}
+
+		header := writer.Header()
+		out, local, errFilter = session.Response(c.Request.Context(), header, writer.body.Bytes())
+		switch {
+		case errFilter != nil:
+			log.Errorf("wasm filters: %v", errFilter)
+			for name := range header {
+				header.Del(name)
+			}
+			local = &wasmfilter.LocalResponse{StatusCode: http.StatusBadGateway, Body: handlers.BuildErrorResponseBody(http.StatusBadGateway, "response filter failed")}
+		case local == nil:
+			header.Del("Content-Length")
+			_, _ = writer.ResponseWriter.Write(out)
+			return
+		}
+		header.Del("Content-Length")
+		writeWasmLocalResponse(writer.ResponseWriter, local)
+	}
+}
+
+func writeWasmLocalResponse(w gin.ResponseWriter, local *wasmfilter.LocalResponse) {
+	for name, values := range local.Header {
+		w.Header().Del(name)
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(local.StatusCode)
+	_, _ = w.Write(local.Body)
+}
+
+// wasmFilterWriter holds back the response until the filters have run. Event streams switch it
+// to passthrough on the first write or flush so streaming is never delayed.
+type wasmFilterWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (w *wasmFilterWriter) streaming() bool {
+	if !w.passthrough && strings.Contains(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.passthrough = true
+		if w.body.Len() > 0 {
+			_, _ = w.ResponseWriter.Write(w.body.Bytes())
+			w.body.Reset()
+		}
+	}
+	return w.passthrough
+}
+
+func (w *wasmFilterWriter) Write(data []byte) (int, error) {
+	if w.streaming() {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *wasmFilterWriter) WriteString(s string) (int, error) {
+	if w.streaming() {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+func (w *wasmFilterWriter) Flush() {
+	if w.streaming() {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// Written reports held-back output as written so handlers do not write a second response.
+func (w *wasmFilterWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *wasmFilterWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
@@ -4,6 +4,7 @@
 package claude
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -59,10 +60,13 @@ func (ts *ClaudeTokenStorage) SetMetadata(meta map[string]any) {
 //   - error: An error if the operation fails, nil otherwise
 func (ts *ClaudeTokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "claude"
+	payload, err := ts.MarshalToken()
+	if err != nil {
+		return err
+	}
 
 	// Create directory structure if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 
@@ -75,15 +79,26 @@ func (ts *ClaudeTokenStorage) SaveTokenToFile(authFilePath string) error {
 		_ = f.Close()
 	}()
 
+	if _, err = f.Write(payload); err != nil {
+		return fmt.Errorf("failed to write token to file: %w", err)
+	}
+	return nil
+}
+
+// MarshalToken returns the JSON payload SaveTokenToFile writes, with any injected metadata
+// merged into the top-level object.
+func (ts *ClaudeTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "claude"
+
 	// Merge metadata using helper
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("failed to merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("failed to merge metadata: %w", errMerge)
 	}
 
-	// Encode and write the token data as JSON
-	if err = json.NewEncoder(f).Encode(data); err != nil {
-		return fmt.Errorf("failed to write token to file: %w", err)
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
 	}
-	return nil
+	return buf.Bytes(), nil
 }
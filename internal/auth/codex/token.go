@@ -4,6 +4,7 @@
 package codex
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -55,8 +56,12 @@ func (ts *CodexTokenStorage) SetMetadata(meta map[string]any) {
 //   - error: An error if the operation fails, nil otherwise
 func (ts *CodexTokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "codex"
-	if err := os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
+	payload, err := ts.MarshalToken()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 
@@ -68,15 +73,26 @@ func (ts *CodexTokenStorage) SaveTokenToFile(authFilePath string) error {
 		_ = f.Close()
 	}()
 
+	if _, err = f.Write(payload); err != nil {
+		return fmt.Errorf("failed to write token to file: %w", err)
+	}
+	return nil
+}
+
+// MarshalToken returns the JSON payload SaveTokenToFile writes, with any injected metadata
+// merged into the top-level object.
+func (ts *CodexTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "codex"
+
 	// Merge metadata using helper
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("failed to merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("failed to merge metadata: %w", errMerge)
 	}
 
-	if err = json.NewEncoder(f).Encode(data); err != nil {
-		return fmt.Errorf("failed to write token to file: %w", err)
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
 	}
-	return nil
-
+	return buf.Bytes(), nil
 }
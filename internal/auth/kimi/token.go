@@ -4,6 +4,7 @@
 package kimi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -81,9 +82,12 @@ type DeviceCodeResponse struct {
 // SaveTokenToFile serializes the Kimi token storage to a JSON file.
 func (ts *KimiTokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "kimi"
+	payload, err := ts.MarshalToken()
+	if err != nil {
+		return err
+	}
 
-	if err := os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
+	if err = os.MkdirAll(filepath.Dir(authFilePath), 0700); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 
@@ -95,18 +99,30 @@ func (ts *KimiTokenStorage) SaveTokenToFile(authFilePath string) error {
 		_ = f.Close()
 	}()
 
+	if _, err = f.Write(payload); err != nil {
+		return fmt.Errorf("failed to write token to file: %w", err)
+	}
+	return nil
+}
+
+// MarshalToken returns the JSON payload SaveTokenToFile writes, with any injected metadata
+// merged into the top-level object.
+func (ts *KimiTokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "kimi"
+
 	// Merge metadata using helper
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("failed to merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("failed to merge metadata: %w", errMerge)
 	}
 
-	encoder := json.NewEncoder(f)
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
 	encoder.SetIndent("", "  ")
-	if err = encoder.Encode(data); err != nil {
-		return fmt.Errorf("failed to write token to file: %w", err)
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to encode token: %w", err)
 	}
-	return nil
+	return buf.Bytes(), nil
 }
 
 // IsExpired checks if the token has expired.
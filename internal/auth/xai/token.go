@@ -1,6 +1,7 @@
 package xai
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -40,8 +41,10 @@ func (ts *TokenStorage) SetMetadata(meta map[string]any) {
 // SaveTokenToFile writes xAI credentials to a JSON auth file.
 func (ts *TokenStorage) SaveTokenToFile(authFilePath string) error {
 	misc.LogSavingCredentials(authFilePath)
-	ts.Type = "xai"
-	ts.AuthKind = "oauth"
+	payload, err := ts.MarshalToken()
+	if err != nil {
+		return err
+	}
 	if errMkdirAll := os.MkdirAll(filepath.Dir(authFilePath), 0o700); errMkdirAll != nil {
 		return fmt.Errorf("xai token storage: create directory: %w", errMkdirAll)
 	}
@@ -54,17 +57,27 @@ func (ts *TokenStorage) SaveTokenToFile(authFilePath string) error {
 			log.Errorf("xai token storage: close token file error: %v", errClose)
 		}
 	}()
+	if _, err = file.Write(payload); err != nil {
+		return fmt.Errorf("xai token storage: write token file: %w", err)
+	}
+	return nil
+}
 
+// MarshalToken returns the JSON payload SaveTokenToFile writes.
+func (ts *TokenStorage) MarshalToken() ([]byte, error) {
+	ts.Type = "xai"
+	ts.AuthKind = "oauth"
 	data, errMerge := misc.MergeMetadata(ts, ts.Metadata)
 	if errMerge != nil {
-		return fmt.Errorf("xai token storage: merge metadata: %w", errMerge)
+		return nil, fmt.Errorf("xai token storage: merge metadata: %w", errMerge)
 	}
-	encoder := json.NewEncoder(file)
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
 	encoder.SetIndent("", "  ")
-	if err = encoder.Encode(data); err != nil {
-		return fmt.Errorf("xai token storage: write token file: %w", err)
+	if errEncode := encoder.Encode(data); errEncode != nil {
+		return nil, fmt.Errorf("xai token storage: encode token: %w", errEncode)
 	}
-	return nil
+	return buf.Bytes(), nil
 }
 
 // CredentialFileName returns the filename used for xAI credentials.
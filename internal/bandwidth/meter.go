@@ -0,0 +1,284 @@
+// Package bandwidth counts the bytes exchanged with upstream providers per provider, credential
+// and client API key, for deployments that pay for metered egress.
+package bandwidth
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+// Counters holds the request and byte totals of one provider credential. Byte counts cover
+// request and response bodies.
+type Counters struct {
+	Requests      int64 `json:"requests"`
+	RequestBytes  int64 `json:"request_bytes"`
+	ResponseBytes int64 `json:"response_bytes"`
+	// CompressedResponseBytes is the part of ResponseBytes that arrived with a content
+	// encoding still applied; the rest was received uncompressed or decompressed by the
+	// HTTP transport.
+	CompressedResponseBytes int64 `json:"compressed_response_bytes"`
+}
+
+func (c *Counters) add(other Counters) {
+	c.Requests += other.Requests
+	c.RequestBytes += other.RequestBytes
+	c.ResponseBytes += other.ResponseBytes
+	c.CompressedResponseBytes += other.CompressedResponseBytes
+}
+
+type counterKey struct {
+	provider string
+	authID   string
+	apiKey   string
+}
+
+// Counter accumulates the traffic one client API key sent through one provider credential.
+// A nil Counter records nothing.
+type Counter struct {
+	authIndex string
+
+	requests                atomic.Int64
+	requestBytes            atomic.Int64
+	responseBytes           atomic.Int64
+	compressedResponseBytes atomic.Int64
+}
+
+func (c *Counter) snapshot() Counters {
+	return Counters{
+		Requests:                c.requests.Load(),
+		RequestBytes:            c.requestBytes.Load(),
+		ResponseBytes:           c.responseBytes.Load(),
+		CompressedResponseBytes: c.compressedResponseBytes.Load(),
+	}
+}
+
+// Meter aggregates Counters by provider, credential and client API key since it was created or
+// last reset.
+type Meter struct {
+	mu       sync.Mutex
+	counters map[counterKey]*Counter
+	since    time.Time
+
+	clock clock.Clock
+}
+
+// NewMeter creates an empty Meter.
+func NewMeter() *Meter {
+	m := &Meter{
+		counters: make(map[counterKey]*Counter),
+		clock:    clock.Default(),
+	}
+	m.since = m.clock.Now()
+	return m
+}
+
+var defaultMeter = NewMeter()
+
+// Default returns the process-wide Meter fed by the provider executors.
+func Default() *Meter { return defaultMeter }
+
+// Counter returns the Counter of a provider credential and the client API key using it, creating
+// it on first use. apiKey is empty for requests without a client key.
+func (m *Meter) Counter(provider, authID, authIndex, apiKey string) *Counter {
+	if m == nil {
+		return nil
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		provider = "unknown"
+	}
+	key := counterKey{provider: provider, authID: strings.TrimSpace(authID), apiKey: strings.TrimSpace(apiKey)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counter, ok := m.counters[key]
+	if !ok {
+		counter = &Counter{authIndex: strings.TrimSpace(authIndex)}
+		m.counters[key] = counter
+	}
+	return counter
+}
+
+// Reset clears all counters and restarts the reporting window.
+func (m *Meter) Reset() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.counters = make(map[counterKey]*Counter)
+	m.since = m.clock.Now()
+	m.mu.Unlock()
+}
+
+// KeyStats is the traffic of one credential within a provider.
+type KeyStats struct {
+	AuthID    string `json:"auth_id"`
+	AuthIndex string `json:"auth_index,omitempty"`
+	Counters
+}
+
+// ProviderStats is the traffic of one provider and its credentials.
+type ProviderStats struct {
+	Provider string `json:"provider"`
+	Counters
+	Keys []KeyStats `json:"keys"`
+}
+
+// ClientStats is the traffic of one client API key across all providers.
+type ClientStats struct {
+	APIKey string `json:"api_key"`
+	Counters
+}
+
+// Report is the bandwidth summary returned by the management API.
+type Report struct {
+	Since     time.Time       `json:"since"`
+	Totals    Counters        `json:"totals"`
+	Providers []ProviderStats `json:"providers"`
+	// Clients lists the traffic of each client API key; requests without a key are left out.
+	Clients []ClientStats `json:"clients"`
+}
+
+// Snapshot reports the current counters sorted by provider and credential, and by client API key.
+func (m *Meter) Snapshot() Report {
+	return m.ProviderSnapshot("")
+}
+
+// ProviderSnapshot is Snapshot limited to the traffic of provider; an empty provider reports all.
+func (m *Meter) ProviderSnapshot(provider string) Report {
+	report := Report{Providers: []ProviderStats{}, Clients: []ClientStats{}}
+	if m == nil {
+		return report
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	m.mu.Lock()
+	report.Since = m.since
+	providers := make(map[string]*ProviderStats)
+	credentials := make(map[[2]string]*KeyStats)
+	clients := make(map[string]*ClientStats)
+	for key, counter := range m.counters {
+		if provider != "" && key.provider != provider {
+			continue
+		}
+		counters := counter.snapshot()
+		stats, ok := providers[key.provider]
+		if !ok {
+			stats = &ProviderStats{Provider: key.provider}
+			providers[key.provider] = stats
+		}
+		stats.add(counters)
+		credential, ok := credentials[[2]string{key.provider, key.authID}]
+		if !ok {
+			credential = &KeyStats{AuthID: key.authID, AuthIndex: counter.authIndex}
+			credentials[[2]string{key.provider, key.authID}] = credential
+		}
+		credential.add(counters)
+		if key.apiKey != "" {
+			client, ok := clients[key.apiKey]
+			if !ok {
+				client = &ClientStats{APIKey: key.apiKey}
+				clients[key.apiKey] = client
+			}
+			client.add(counters)
+		}
+		report.Totals.add(counters)
+	}
+	m.mu.Unlock()
+
+	for id, credential := range credentials {
+		providers[id[0]].Keys = append(providers[id[0]].Keys, *credential)
+	}
+	for _, stats := range providers {
+		sort.Slice(stats.Keys, func(i, j int) bool { return stats.Keys[i].AuthID < stats.Keys[j].AuthID })
+		report.Providers = append(report.Providers, *stats)
+	}
+	sort.Slice(report.Providers, func(i, j int) bool { return report.Providers[i].Provider < report.Providers[j].Provider })
+	for _, client := range clients {
+		report.Clients = append(report.Clients, *client)
+	}
+	sort.Slice(report.Clients, func(i, j int) bool { return report.Clients[i].APIKey < report.Clients[j].APIKey })
+	return report
+}
+
+// ObserveRequest counts one upstream request and the bytes of its body. When the body length
+// is unknown, the request is copied with a body that counts bytes as the transport sends them.
+func (c *Counter) ObserveRequest(req *http.Request) *http.Request {
+	if c == nil || req == nil {
+		return req
+	}
+	c.requests.Add(1)
+	if req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+	if req.ContentLength > 0 {
+		c.requestBytes.Add(req.ContentLength)
+		return req
+	}
+	counted := req.Clone(req.Context())
+	counted.Body = &countingReadCloser{ReadCloser: req.Body, add: c.requestBytes.Add}
+	return counted
+}
+
+// ObserveResponse wraps the response body so bytes are counted as the caller reads them.
+func (c *Counter) ObserveResponse(resp *http.Response) {
+	if c == nil || resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	compressed := !resp.Uncompressed && strings.TrimSpace(resp.Header.Get("Content-Encoding")) != "" &&
+		!strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "identity")
+	resp.Body = &countingReadCloser{
+		ReadCloser: resp.Body,
+		add: func(n int64) int64 {
+			if compressed {
+				c.compressedResponseBytes.Add(n)
+			}
+			return c.responseBytes.Add(n)
+		},
+	}
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	add func(int64) int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, errRead := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.add(int64(n))
+	}
+	return n, errRead
+}
+
+// WritePrometheus writes the report in the Prometheus text exposition format.
+func (r Report) WritePrometheus(w io.Writer) error {
+	metrics := []struct {
+		name  string
+		help  string
+		value func(Counters) int64
+	}{
+		{"cliproxy_upstream_requests_total", "Upstream requests sent.", func(c Counters) int64 { return c.Requests }},
+		{"cliproxy_upstream_request_bytes_total", "Upstream request body bytes sent.", func(c Counters) int64 { return c.RequestBytes }},
+		{"cliproxy_upstream_response_bytes_total", "Upstream response body bytes received.", func(c Counters) int64 { return c.ResponseBytes }},
+		{"cliproxy_upstream_compressed_response_bytes_total", "Upstream response body bytes received with a content encoding applied.", func(c Counters) int64 { return c.CompressedResponseBytes }},
+	}
+	var out strings.Builder
+	for _, metric := range metrics {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s counter\n", metric.name, metric.help, metric.name)
+		for _, provider := range r.Providers {
+			for _, key := range provider.Keys {
+				fmt.Fprintf(&out, "%s{provider=\"%s\",auth_id=\"%s\"} %d\n", metric.name, util.EscapePrometheusLabel(provider.Provider), util.EscapePrometheusLabel(key.AuthID), metric.value(key.Counters))
+			}
+		}
+	}
+	_, errWrite := io.WriteString(w, out.String())
+	return errWrite
+}
@@ -0,0 +1,122 @@
+package bandwidth
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterObservesRequestAndResponseBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	meter := NewMeter()
+	counter := meter.Counter("Claude", "auth-1", "idx-1", "")
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader([]byte(`{"a":1}`)))
+	resp, errDo := http.DefaultTransport.RoundTrip(counter.ObserveRequest(req))
+	if errDo != nil {
+		t.Fatalf("round trip: %v", errDo)
+	}
+	counter.ObserveResponse(resp)
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	// A body of unknown length is counted as the transport reads it.
+	req, _ = http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("abcd")))
+	req.ContentLength = 0
+	resp, errDo = http.DefaultTransport.RoundTrip(counter.ObserveRequest(req))
+	if errDo != nil {
+		t.Fatalf("round trip: %v", errDo)
+	}
+	_ = resp.Body.Close()
+
+	report := meter.Snapshot()
+	want := Counters{Requests: 2, RequestBytes: 11, ResponseBytes: 10}
+	if report.Totals != want {
+		t.Fatalf("totals = %+v, want %+v", report.Totals, want)
+	}
+	if len(report.Providers) != 1 || report.Providers[0].Provider != "claude" {
+		t.Fatalf("providers = %+v, want claude", report.Providers)
+	}
+	if keys := report.Providers[0].Keys; len(keys) != 1 || keys[0].AuthID != "auth-1" || keys[0].AuthIndex != "idx-1" {
+		t.Fatalf("keys = %+v, want auth-1", keys)
+	}
+}
+
+func TestCounterSeparatesCompressedResponseBytes(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(strings.Repeat("x", 1000)))
+	_ = writer.Close()
+
+	meter := NewMeter()
+	counter := meter.Counter("gemini", "auth-1", "", "")
+	resp := &http.Response{Header: http.Header{"Content-Encoding": []string{"gzip"}}, Body: io.NopCloser(bytes.NewReader(compressed.Bytes()))}
+	counter.ObserveResponse(resp)
+	_, _ = io.ReadAll(resp.Body)
+
+	plain := &http.Response{Header: http.Header{}, Uncompressed: true, Body: io.NopCloser(strings.NewReader("hello"))}
+	counter.ObserveResponse(plain)
+	_, _ = io.ReadAll(plain.Body)
+
+	totals := meter.Snapshot().Totals
+	if totals.CompressedResponseBytes != int64(compressed.Len()) {
+		t.Fatalf("compressed bytes = %d, want %d", totals.CompressedResponseBytes, compressed.Len())
+	}
+	if totals.ResponseBytes != int64(compressed.Len())+5 {
+		t.Fatalf("response bytes = %d, want %d", totals.ResponseBytes, compressed.Len()+5)
+	}
+}
+
+func TestMeterGroupsByCredentialAndClientKey(t *testing.T) {
+	meter := NewMeter()
+	request := func() *http.Request {
+		return &http.Request{Body: io.NopCloser(strings.NewReader("ab")), ContentLength: 2}
+	}
+	meter.Counter("claude", "auth-1", "", "key-a").ObserveRequest(request())
+	meter.Counter("claude", "auth-1", "", "key-b").ObserveRequest(request())
+	meter.Counter("gemini", "auth-2", "", "key-a").ObserveRequest(request())
+	meter.Counter("gemini", "auth-2", "", "").ObserveRequest(request())
+
+	report := meter.Snapshot()
+	if keys := report.Providers[0].Keys; len(keys) != 1 || keys[0].AuthID != "auth-1" || keys[0].RequestBytes != 4 {
+		t.Fatalf("claude keys = %+v, want auth-1 with both clients", keys)
+	}
+	want := []ClientStats{
+		{APIKey: "key-a", Counters: Counters{Requests: 2, RequestBytes: 4}},
+		{APIKey: "key-b", Counters: Counters{Requests: 1, RequestBytes: 2}},
+	}
+	if len(report.Clients) != len(want) || report.Clients[0] != want[0] || report.Clients[1] != want[1] {
+		t.Fatalf("clients = %+v, want %+v", report.Clients, want)
+	}
+	if report.Totals.Requests != 4 {
+		t.Fatalf("total requests = %d, want 4", report.Totals.Requests)
+	}
+}
+
+func TestMeterResetAndPrometheus(t *testing.T) {
+	meter := NewMeter()
+	counter := meter.Counter("codex", `a"b`, "", "")
+	counter.ObserveRequest(&http.Request{Body: io.NopCloser(strings.NewReader("x")), ContentLength: 1})
+
+	var out bytes.Buffer
+	if errWrite := meter.Snapshot().WritePrometheus(&out); errWrite != nil {
+		t.Fatalf("WritePrometheus: %v", errWrite)
+	}
+	if !strings.Contains(out.String(), `cliproxy_upstream_request_bytes_total{provider="codex",auth_id="a\"b"} 1`) {
+		t.Fatalf("unexpected exposition:\n%s", out.String())
+	}
+
+	meter.Reset()
+	if report := meter.Snapshot(); len(report.Providers) != 0 || report.Totals != (Counters{}) {
+		t.Fatalf("report after reset = %+v, want empty", report)
+	}
+}
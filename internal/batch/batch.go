@@ -0,0 +1,677 @@
+// Package batch emulates the OpenAI Batch API. Uploaded JSONL files are split into
+// requests that run through the regular model execution pipeline with bounded
+// concurrency; results are persisted as output and error files.
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Endpoints a batch may target.
+const (
+	EndpointChatCompletions = "/v1/chat/completions"
+	EndpointResponses       = "/v1/responses"
+	EndpointEmbeddings      = "/v1/embeddings"
+)
+
+// File purposes.
+const (
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+)
+
+// Batch statuses.
+const (
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+// completionWindow is the only completion window accepted, as in the OpenAI API.
+const completionWindow = "24h"
+
+// progressSaveInterval throttles how often request counts of a running batch are persisted.
+const progressSaveInterval = time.Second
+
+// ValidationError reports an invalid upload or batch request; handlers map it to 400.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+func invalidf(format string, args ...any) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// File is an uploaded or generated file in OpenAI's file object shape.
+type File struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status"`
+}
+
+// Batch is a batch in OpenAI's batch object shape.
+type Batch struct {
+	ID               string            `json:"id"`
+	Object           string            `json:"object"`
+	Endpoint         string            `json:"endpoint"`
+	Errors           *Errors           `json:"errors,omitempty"`
+	InputFileID      string            `json:"input_file_id"`
+	CompletionWindow string            `json:"completion_window"`
+	Status           string            `json:"status"`
+	OutputFileID     string            `json:"output_file_id,omitempty"`
+	ErrorFileID      string            `json:"error_file_id,omitempty"`
+	CreatedAt        int64             `json:"created_at"`
+	InProgressAt     int64             `json:"in_progress_at,omitempty"`
+	ExpiresAt        int64             `json:"expires_at,omitempty"`
+	FinalizingAt     int64             `json:"finalizing_at,omitempty"`
+	CompletedAt      int64             `json:"completed_at,omitempty"`
+	FailedAt         int64             `json:"failed_at,omitempty"`
+	CancellingAt     int64             `json:"cancelling_at,omitempty"`
+	CancelledAt      int64             `json:"cancelled_at,omitempty"`
+	RequestCounts    RequestCounts     `json:"request_counts"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+
+	// access is the access metadata of the key that created the batch. It is stored with the
+	// batch but never returned to clients.
+	access map[string]string
+}
+
+// Errors lists batch-level failures.
+type Errors struct {
+	Object string  `json:"object"`
+	Data   []Error `json:"data"`
+}
+
+// Error is one batch-level failure.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// RequestCounts tracks the progress of a batch.
+type RequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// CreateRequest is the body of POST /v1/batches.
+type CreateRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+// Executor runs one batch request for the client API key owner, under the scope given by the
+// key's access metadata, and returns the HTTP status code and response body.
+type Executor func(ctx context.Context, owner string, access map[string]string, endpoint string, body []byte) (int, []byte)
+
+// request is one line of a batch input file.
+type request struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+type result struct {
+	status int
+	body   []byte
+}
+
+// Manager stores files and batches and runs batch requests in the background.
+type Manager struct {
+	mu       sync.Mutex
+	cfg      config.BatchConfig
+	store    *fileStore
+	sem      chan struct{}
+	executor Executor
+	keys     *contentcrypt.Keyring
+	running  map[string]context.CancelFunc
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+
+	now func() time.Time
+}
+
+// NewManager creates a manager that stays disabled until Apply enables it.
+func NewManager() *Manager {
+	ctx, stop := context.WithCancel(context.Background())
+	return &Manager{
+		running: make(map[string]context.CancelFunc),
+		ctx:     ctx,
+		stop:    stop,
+		now:     time.Now,
+	}
+}
+
+// Apply updates the configuration. Opening a store directory for the first time fails
+// batches that were still running when the previous process stopped.
+func (m *Manager) Apply(cfg config.BatchConfig, authDir string) {
+	if m == nil {
+		return
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		base, errResolve := util.ResolveAuthDir(authDir)
+		if errResolve != nil {
+			log.Warnf("batch: %v", errResolve)
+			base = "."
+		}
+		dir = filepath.Join(base, config.DefaultBatchDir)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cfg = cfg
+	if m.sem == nil || cap(m.sem) != cfg.Concurrency {
+		m.sem = make(chan struct{}, max(1, cfg.Concurrency))
+	}
+	if !cfg.Enable || (m.store != nil && m.store.dir == dir) {
+		return
+	}
+	m.store = newFileStore(dir)
+	m.store.keys = m.keys
+	m.recoverInterruptedLocked()
+}
+
+// Enabled reports whether the batch endpoints are enabled.
+func (m *Manager) Enabled() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cfg.Enable && m.store != nil
+}
+
+// MaxFileBytes returns the upload size limit.
+func (m *Manager) MaxFileBytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(m.cfg.MaxFileSizeMB) << 20
+}
+
+// SetExecutor installs the function that runs individual batch requests.
+func (m *Manager) SetExecutor(executor Executor) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.executor = executor
+	m.mu.Unlock()
+}
+
+// SetKeyring sets the keyring sealing stored file content. Deleting a file shreds its key.
+func (m *Manager) SetKeyring(keys *contentcrypt.Keyring) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = keys
+	if m.store != nil {
+		m.store.mu.Lock()
+		m.store.keys = keys
+		m.store.mu.Unlock()
+	}
+}
+
+// Stop cancels running batches and waits for their workers to exit.
+func (m *Manager) Stop() {
+	if m == nil {
+		return
+	}
+	m.stop()
+	m.wg.Wait()
+}
+
+func (m *Manager) currentStore() (*fileStore, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.cfg.Enable || m.store == nil {
+		return nil, errors.New("batch API is disabled")
+	}
+	return m.store, nil
+}
+
+// CreateFile stores an upload. Files with purpose "batch" are validated as batch input.
+func (m *Manager) CreateFile(owner, filename, purpose string, content []byte) (File, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return File{}, errStore
+	}
+	if purpose != PurposeBatch {
+		return File{}, invalidf("unsupported purpose %q, only %q is supported", purpose, PurposeBatch)
+	}
+	if int64(len(content)) > m.MaxFileBytes() {
+		return File{}, invalidf("file exceeds the %d MB limit", m.MaxFileBytes()>>20)
+	}
+	if _, errParse := m.parseRequests(content, ""); errParse != nil {
+		return File{}, errParse
+	}
+	meta := File{
+		ID:        "file-" + newID(),
+		Object:    "file",
+		Bytes:     int64(len(content)),
+		CreatedAt: m.now().Unix(),
+		Filename:  filename,
+		Purpose:   purpose,
+		Status:    "processed",
+	}
+	return meta, store.saveFile(owner, meta, content)
+}
+
+// File returns the metadata of a file owned by owner.
+func (m *Manager) File(owner, id string) (File, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return File{}, errStore
+	}
+	return store.file(owner, id)
+}
+
+// FileContent returns the content of a file owned by owner.
+func (m *Manager) FileContent(owner, id string) ([]byte, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return nil, errStore
+	}
+	return store.fileContent(owner, id)
+}
+
+// DeleteFile removes a file owned by owner.
+func (m *Manager) DeleteFile(owner, id string) error {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return errStore
+	}
+	return store.deleteFile(owner, id)
+}
+
+// Files lists the files of owner, newest first, optionally filtered by purpose.
+func (m *Manager) Files(owner, purpose string) ([]File, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return nil, errStore
+	}
+	return store.listFiles(owner, purpose)
+}
+
+// Batch returns a batch owned by owner.
+func (m *Manager) Batch(owner, id string) (Batch, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return Batch{}, errStore
+	}
+	return store.batch(owner, id)
+}
+
+// Batches lists the batches of owner, newest first.
+func (m *Manager) Batches(owner string) ([]Batch, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return nil, errStore
+	}
+	return store.listBatches(owner)
+}
+
+// Create validates the input file and starts processing the batch in the background. access is
+// the access metadata of the creating key; every line of the batch runs under it.
+func (m *Manager) Create(owner string, access map[string]string, req CreateRequest) (Batch, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return Batch{}, errStore
+	}
+	switch req.Endpoint {
+	case EndpointChatCompletions, EndpointResponses, EndpointEmbeddings:
+	default:
+		return Batch{}, invalidf("unsupported endpoint %q", req.Endpoint)
+	}
+	if req.CompletionWindow != completionWindow {
+		return Batch{}, invalidf("completion_window must be %q", completionWindow)
+	}
+	input, errFile := store.file(owner, req.InputFileID)
+	if errFile != nil {
+		if errors.Is(errFile, ErrNotFound) {
+			return Batch{}, invalidf("input file %s not found", req.InputFileID)
+		}
+		return Batch{}, errFile
+	}
+	if input.Purpose != PurposeBatch {
+		return Batch{}, invalidf("input file %s does not have purpose %q", input.ID, PurposeBatch)
+	}
+	content, errContent := store.fileContent(owner, input.ID)
+	if errContent != nil {
+		return Batch{}, errContent
+	}
+	requests, errParse := m.parseRequests(content, req.Endpoint)
+	if errParse != nil {
+		return Batch{}, errParse
+	}
+
+	m.mu.Lock()
+	executor := m.executor
+	m.mu.Unlock()
+	if executor == nil {
+		return Batch{}, errors.New("batch executor unavailable")
+	}
+
+	now := m.now()
+	b := Batch{
+		ID:               "batch_" + newID(),
+		Object:           "batch",
+		Endpoint:         req.Endpoint,
+		InputFileID:      input.ID,
+		CompletionWindow: completionWindow,
+		Status:           StatusInProgress,
+		CreatedAt:        now.Unix(),
+		InProgressAt:     now.Unix(),
+		ExpiresAt:        now.Add(24 * time.Hour).Unix(),
+		RequestCounts:    RequestCounts{Total: len(requests)},
+		Metadata:         req.Metadata,
+		access:           access,
+	}
+	if errSave := store.saveBatch(owner, b); errSave != nil {
+		return Batch{}, errSave
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.mu.Lock()
+	m.running[b.ID] = cancel
+	m.mu.Unlock()
+	m.wg.Add(1)
+	go m.run(ctx, store, executor, owner, b, requests)
+	return b, nil
+}
+
+// Cancel stops a running batch. Requests already in flight finish and their results are kept.
+func (m *Manager) Cancel(owner, id string) (Batch, error) {
+	store, errStore := m.currentStore()
+	if errStore != nil {
+		return Batch{}, errStore
+	}
+	b, errBatch := store.batch(owner, id)
+	if errBatch != nil {
+		return Batch{}, errBatch
+	}
+	m.mu.Lock()
+	cancel, running := m.running[id]
+	m.mu.Unlock()
+	if !running || b.Status != StatusInProgress {
+		return b, nil
+	}
+	b.Status = StatusCancelling
+	b.CancellingAt = m.now().Unix()
+	if errSave := store.saveBatch(owner, b); errSave != nil {
+		return Batch{}, errSave
+	}
+	cancel()
+	return b, nil
+}
+
+func (m *Manager) run(ctx context.Context, store *fileStore, executor Executor, owner string, b Batch, requests []request) {
+	defer m.wg.Done()
+	defer func() {
+		m.mu.Lock()
+		if cancel, ok := m.running[b.ID]; ok {
+			cancel()
+			delete(m.running, b.ID)
+		}
+		m.mu.Unlock()
+	}()
+
+	m.mu.Lock()
+	sem := m.sem
+	m.mu.Unlock()
+
+	var (
+		progressMu sync.Mutex
+		lastSave   time.Time
+		workers    sync.WaitGroup
+	)
+	results := make([]*result, len(requests))
+	finish := func(i int, status int, body []byte) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		results[i] = &result{status: status, body: body}
+		if status == 200 {
+			b.RequestCounts.Completed++
+		} else {
+			b.RequestCounts.Failed++
+		}
+		if now := m.now(); now.Sub(lastSave) >= progressSaveInterval {
+			lastSave = now
+			m.saveProgress(store, owner, b)
+		}
+	}
+
+dispatch:
+	for i, req := range requests {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+		workers.Add(1)
+		go func(i int, req request) {
+			defer workers.Done()
+			defer func() { <-sem }()
+			status, body := executor(ctx, owner, b.access, req.URL, req.Body)
+			finish(i, status, body)
+		}(i, req)
+	}
+	workers.Wait()
+
+	now := m.now()
+	b.FinalizingAt = now.Unix()
+	output, errorsOut := buildResultFiles(requests, results)
+	if len(output) > 0 {
+		if meta, errSave := m.saveResultFile(store, owner, b.ID+"_output.jsonl", output); errSave == nil {
+			b.OutputFileID = meta.ID
+		} else {
+			log.Warnf("batch %s: failed to save output file: %v", b.ID, errSave)
+		}
+	}
+	if len(errorsOut) > 0 {
+		if meta, errSave := m.saveResultFile(store, owner, b.ID+"_error.jsonl", errorsOut); errSave == nil {
+			b.ErrorFileID = meta.ID
+		} else {
+			log.Warnf("batch %s: failed to save error file: %v", b.ID, errSave)
+		}
+	}
+
+	// Pick up a concurrent Cancel, which only persists the cancelling state.
+	if stored, errBatch := store.batch(owner, b.ID); errBatch == nil && stored.CancellingAt > 0 {
+		b.CancellingAt = stored.CancellingAt
+	}
+	if ctx.Err() != nil {
+		b.Status = StatusCancelled
+		b.CancelledAt = now.Unix()
+		if b.CancellingAt == 0 {
+			// Cancelled by shutdown rather than by the client.
+			b.Status = StatusFailed
+			b.CancelledAt = 0
+			b.FailedAt = now.Unix()
+			b.Errors = &Errors{Object: "list", Data: []Error{{Code: "interrupted", Message: "batch was interrupted by a server shutdown"}}}
+		}
+	} else {
+		b.Status = StatusCompleted
+		b.CompletedAt = now.Unix()
+	}
+	if errSave := store.saveBatch(owner, b); errSave != nil {
+		log.Warnf("batch %s: failed to save final state: %v", b.ID, errSave)
+	}
+}
+
+func (m *Manager) saveProgress(store *fileStore, owner string, b Batch) {
+	if stored, errBatch := store.batch(owner, b.ID); errBatch == nil && stored.Status == StatusCancelling {
+		b.Status = stored.Status
+		b.CancellingAt = stored.CancellingAt
+	}
+	if errSave := store.saveBatch(owner, b); errSave != nil {
+		log.Debugf("batch %s: failed to save progress: %v", b.ID, errSave)
+	}
+}
+
+func (m *Manager) saveResultFile(store *fileStore, owner, filename string, content []byte) (File, error) {
+	meta := File{
+		ID:        "file-" + newID(),
+		Object:    "file",
+		Bytes:     int64(len(content)),
+		CreatedAt: m.now().Unix(),
+		Filename:  filename,
+		Purpose:   PurposeBatchOutput,
+		Status:    "processed",
+	}
+	return meta, store.saveFile(owner, meta, content)
+}
+
+// buildResultFiles renders successful responses into the output file and failed ones into
+// the error file, both in input order. Requests that never ran are omitted.
+func buildResultFiles(requests []request, results []*result) (output, errorsOut []byte) {
+	var out, errOut bytes.Buffer
+	for i, res := range results {
+		if res == nil {
+			continue
+		}
+		line := map[string]any{
+			"id":        "batch_req_" + newID(),
+			"custom_id": requests[i].CustomID,
+			"response": map[string]any{
+				"status_code": res.status,
+				"request_id":  "",
+				"body":        rawBody(res.body),
+			},
+			"error": nil,
+		}
+		data, errMarshal := json.Marshal(line)
+		if errMarshal != nil {
+			continue
+		}
+		target := &out
+		if res.status != 200 {
+			target = &errOut
+		}
+		target.Write(data)
+		target.WriteByte('\n')
+	}
+	return out.Bytes(), errOut.Bytes()
+}
+
+// rawBody embeds JSON response bodies as-is and wraps anything else as a JSON string.
+func rawBody(body []byte) any {
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return string(body)
+}
+
+// parseRequests validates a batch input file. When endpoint is set, every request must
+// target it.
+func (m *Manager) parseRequests(content []byte, endpoint string) ([]request, error) {
+	m.mu.Lock()
+	maxRequests := m.cfg.MaxRequests
+	m.mu.Unlock()
+
+	var requests []request
+	seen := make(map[string]struct{})
+	for n, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		lineNo := n + 1
+		var req request
+		if errUnmarshal := json.Unmarshal(line, &req); errUnmarshal != nil {
+			return nil, invalidf("line %d: invalid JSON: %v", lineNo, errUnmarshal)
+		}
+		req.CustomID = strings.TrimSpace(req.CustomID)
+		if req.CustomID == "" {
+			return nil, invalidf("line %d: custom_id is required", lineNo)
+		}
+		if _, duplicate := seen[req.CustomID]; duplicate {
+			return nil, invalidf("line %d: duplicate custom_id %q", lineNo, req.CustomID)
+		}
+		seen[req.CustomID] = struct{}{}
+		if !strings.EqualFold(req.Method, "POST") {
+			return nil, invalidf("line %d: method must be POST", lineNo)
+		}
+		switch req.URL {
+		case EndpointChatCompletions, EndpointResponses, EndpointEmbeddings:
+		default:
+			return nil, invalidf("line %d: unsupported url %q", lineNo, req.URL)
+		}
+		if endpoint != "" && req.URL != endpoint {
+			return nil, invalidf("line %d: url %q does not match batch endpoint %q", lineNo, req.URL, endpoint)
+		}
+		if len(req.Body) == 0 || req.Body[0] != '{' {
+			return nil, invalidf("line %d: body must be a JSON object", lineNo)
+		}
+		requests = append(requests, req)
+		if maxRequests > 0 && len(requests) > maxRequests {
+			return nil, invalidf("batch input exceeds %d requests", maxRequests)
+		}
+	}
+	if len(requests) == 0 {
+		return nil, invalidf("batch input file contains no requests")
+	}
+	return requests, nil
+}
+
+// recoverInterruptedLocked fails batches left unfinished by a previous process.
+func (m *Manager) recoverInterruptedLocked() {
+	now := m.now().Unix()
+	errRecover := m.store.updateAllBatches(func(b *Batch) bool {
+		if _, running := m.running[b.ID]; running {
+			return false
+		}
+		switch b.Status {
+		case StatusInProgress, StatusFinalizing:
+			b.Status = StatusFailed
+			b.FailedAt = now
+			b.Errors = &Errors{Object: "list", Data: []Error{{Code: "interrupted", Message: "batch was interrupted by a server restart"}}}
+			return true
+		case StatusCancelling:
+			b.Status = StatusCancelled
+			b.CancelledAt = now
+			return true
+		default:
+			return false
+		}
+	})
+	if errRecover != nil {
+		log.Warnf("batch: failed to recover interrupted batches: %v", errRecover)
+	}
+}
+
+func newID() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
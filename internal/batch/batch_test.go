@@ -0,0 +1,193 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+const testInput = `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-5","messages":[]}}
+{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"bad","messages":[]}}
+
+{"custom_id":"c","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-5","messages":[]}}
+`
+
+func newTestManager(t *testing.T, executor Executor) *Manager {
+	t.Helper()
+	m := NewManager()
+	m.Apply(config.BatchConfig{Enable: true, Dir: t.TempDir(), Concurrency: 2, MaxFileSizeMB: 1, MaxRequests: 10}, "")
+	m.SetExecutor(executor)
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func waitForStatus(t *testing.T, m *Manager, owner, id string, statuses ...string) Batch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		b, errBatch := m.Batch(owner, id)
+		if errBatch != nil {
+			t.Fatalf("Batch error: %v", errBatch)
+		}
+		for _, status := range statuses {
+			if b.Status == status {
+				return b
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not reach %v", id, statuses)
+	return Batch{}
+}
+
+func TestManager_RunsBatchAndWritesResultFiles(t *testing.T) {
+	m := newTestManager(t, func(ctx context.Context, owner string, access map[string]string, endpoint string, body []byte) (int, []byte) {
+		if owner != "key-a" || endpoint != EndpointChatCompletions || access["tenant"] != "acme" {
+			t.Errorf("executor got owner=%q access=%v endpoint=%q", owner, access, endpoint)
+		}
+		if gjson.GetBytes(body, "model").String() == "bad" {
+			return http.StatusBadRequest, []byte(`{"error":{"message":"unknown model"}}`)
+		}
+		return http.StatusOK, []byte(`{"id":"chatcmpl-1","object":"chat.completion"}`)
+	})
+
+	input, errFile := m.CreateFile("key-a", "input.jsonl", PurposeBatch, []byte(testInput))
+	if errFile != nil {
+		t.Fatalf("CreateFile error: %v", errFile)
+	}
+	created, errCreate := m.Create("key-a", map[string]string{"tenant": "acme"}, CreateRequest{InputFileID: input.ID, Endpoint: EndpointChatCompletions, CompletionWindow: "24h"})
+	if errCreate != nil {
+		t.Fatalf("Create error: %v", errCreate)
+	}
+	if created.Status != StatusInProgress || created.RequestCounts.Total != 3 {
+		t.Fatalf("created = %+v, want in_progress with 3 requests", created)
+	}
+
+	done := waitForStatus(t, m, "key-a", created.ID, StatusCompleted)
+	if done.RequestCounts.Completed != 2 || done.RequestCounts.Failed != 1 {
+		t.Fatalf("request counts = %+v, want 2 completed and 1 failed", done.RequestCounts)
+	}
+	if done.access["tenant"] != "acme" {
+		t.Fatalf("stored access metadata = %v, want the creating key's", done.access)
+	}
+	if raw, _ := json.Marshal(done); bytes.Contains(raw, []byte("acme")) {
+		t.Fatalf("batch object exposes the access metadata: %s", raw)
+	}
+
+	output, errOutput := m.FileContent("key-a", done.OutputFileID)
+	if errOutput != nil {
+		t.Fatalf("output content error: %v", errOutput)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 2 || gjson.Get(lines[0], "custom_id").String() != "a" || gjson.Get(lines[1], "custom_id").String() != "c" {
+		t.Fatalf("output lines = %q, want a and c in input order", lines)
+	}
+	if got := gjson.Get(lines[0], "response.body.id").String(); got != "chatcmpl-1" {
+		t.Fatalf("response.body.id = %q, want chatcmpl-1", got)
+	}
+	errorsOut, errErrors := m.FileContent("key-a", done.ErrorFileID)
+	if errErrors != nil {
+		t.Fatalf("error content error: %v", errErrors)
+	}
+	if got := gjson.GetBytes(bytes.TrimSpace(errorsOut), "response.status_code").Int(); got != http.StatusBadRequest {
+		t.Fatalf("error file status = %d, want 400; content=%s", got, errorsOut)
+	}
+
+	if _, errOther := m.Batch("key-b", created.ID); !errors.Is(errOther, ErrNotFound) {
+		t.Fatalf("other key Batch error = %v, want ErrNotFound", errOther)
+	}
+	if files, _ := m.Files("key-a", PurposeBatchOutput); len(files) != 2 {
+		t.Fatalf("output files = %d, want 2", len(files))
+	}
+}
+
+func TestManager_CancelStopsDispatch(t *testing.T) {
+	release := make(chan struct{})
+	m := newTestManager(t, func(ctx context.Context, owner string, _ map[string]string, endpoint string, body []byte) (int, []byte) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return http.StatusOK, []byte(`{}`)
+	})
+	m.Apply(config.BatchConfig{Enable: true, Dir: m.store.dir, Concurrency: 1, MaxFileSizeMB: 1, MaxRequests: 10}, "")
+
+	input, _ := m.CreateFile("key-a", "input.jsonl", PurposeBatch, []byte(testInput))
+	created, errCreate := m.Create("key-a", nil, CreateRequest{InputFileID: input.ID, Endpoint: EndpointChatCompletions, CompletionWindow: "24h"})
+	if errCreate != nil {
+		t.Fatalf("Create error: %v", errCreate)
+	}
+	cancelling, errCancel := m.Cancel("key-a", created.ID)
+	if errCancel != nil || cancelling.Status != StatusCancelling {
+		t.Fatalf("Cancel = (%+v, %v), want cancelling", cancelling, errCancel)
+	}
+	close(release)
+
+	done := waitForStatus(t, m, "key-a", created.ID, StatusCancelled)
+	if done.RequestCounts.Completed+done.RequestCounts.Failed >= 3 {
+		t.Fatalf("request counts = %+v, want dispatch stopped early", done.RequestCounts)
+	}
+	if done.CancelledAt == 0 || done.CancellingAt == 0 {
+		t.Fatalf("batch = %+v, want cancelling and cancelled timestamps", done)
+	}
+}
+
+func TestManager_ValidatesInput(t *testing.T) {
+	m := newTestManager(t, func(context.Context, string, map[string]string, string, []byte) (int, []byte) {
+		return http.StatusOK, nil
+	})
+	var validation *ValidationError
+
+	cases := map[string]string{
+		"invalid json":     "{not json}\n",
+		"missing id":       `{"method":"POST","url":"/v1/chat/completions","body":{}}`,
+		"duplicate id":     `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{}}` + "\n" + `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{}}`,
+		"unsupported url":  `{"custom_id":"a","method":"POST","url":"/v1/moderations","body":{}}`,
+		"non-object body":  `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":"x"}`,
+		"empty":            "\n\n",
+		"unsupported verb": `{"custom_id":"a","method":"GET","url":"/v1/chat/completions","body":{}}`,
+	}
+	for name, content := range cases {
+		if _, errFile := m.CreateFile("key-a", "input.jsonl", PurposeBatch, []byte(content)); !errors.As(errFile, &validation) {
+			t.Fatalf("%s: CreateFile error = %v, want ValidationError", name, errFile)
+		}
+	}
+	if _, errFile := m.CreateFile("key-a", "input.jsonl", "fine-tune", []byte(testInput)); !errors.As(errFile, &validation) {
+		t.Fatalf("purpose: CreateFile error = %v, want ValidationError", errFile)
+	}
+
+	input, _ := m.CreateFile("key-a", "input.jsonl", PurposeBatch, []byte(testInput))
+	if _, errCreate := m.Create("key-a", nil, CreateRequest{InputFileID: input.ID, Endpoint: EndpointResponses, CompletionWindow: "24h"}); !errors.As(errCreate, &validation) {
+		t.Fatalf("endpoint mismatch: Create error = %v, want ValidationError", errCreate)
+	}
+	if _, errCreate := m.Create("key-b", nil, CreateRequest{InputFileID: input.ID, Endpoint: EndpointChatCompletions, CompletionWindow: "24h"}); !errors.As(errCreate, &validation) {
+		t.Fatalf("other key: Create error = %v, want ValidationError", errCreate)
+	}
+}
+
+func TestManager_FailsBatchesInterruptedByRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := newFileStore(dir)
+	if errSave := store.saveBatch("key-a", Batch{ID: "batch_1", Object: "batch", Status: StatusInProgress}); errSave != nil {
+		t.Fatalf("saveBatch error: %v", errSave)
+	}
+
+	m := NewManager()
+	t.Cleanup(m.Stop)
+	m.Apply(config.BatchConfig{Enable: true, Dir: dir, Concurrency: 1}, "")
+	b, errBatch := m.Batch("key-a", "batch_1")
+	if errBatch != nil {
+		t.Fatalf("Batch error: %v", errBatch)
+	}
+	if b.Status != StatusFailed || b.Errors == nil || b.Errors.Data[0].Code != "interrupted" {
+		t.Fatalf("batch = %+v, want failed as interrupted", b)
+	}
+}
@@ -0,0 +1,242 @@
+package batch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+// ErrNotFound is returned for unknown IDs and for objects owned by another client API key.
+var ErrNotFound = errors.New("batch: not found")
+
+// fileStore keeps uploaded and generated files plus batch state under one directory per
+// client API key, so keys never see each other's objects:
+//
+//	<owner>/files/<id>.json    file metadata
+//	<owner>/files/<id>.jsonl   file content
+//	<owner>/batches/<id>.json  batch state
+//
+// <owner> is a hash of the client API key.
+type fileStore struct {
+	mu   sync.Mutex
+	dir  string
+	keys *contentcrypt.Keyring
+}
+
+func newFileStore(dir string) *fileStore {
+	return &fileStore{dir: dir}
+}
+
+func (s *fileStore) ownerDir(owner string) string {
+	sum := sha256.Sum256([]byte(owner))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:16]))
+}
+
+func (s *fileStore) filePath(owner, id, ext string) string {
+	return filepath.Join(s.ownerDir(owner), "files", id+ext)
+}
+
+func (s *fileStore) batchPath(owner, id string) string {
+	return filepath.Join(s.ownerDir(owner), "batches", id+".json")
+}
+
+func (s *fileStore) saveFile(owner string, meta File, content []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sealed, errSeal := s.keys.Seal(owner, keyID(meta.ID), content)
+	if errSeal != nil {
+		return errSeal
+	}
+	if errWrite := util.WriteFileAtomic(s.filePath(owner, meta.ID, ".jsonl"), sealed); errWrite != nil {
+		return errWrite
+	}
+	return writeJSON(s.filePath(owner, meta.ID, ".json"), meta)
+}
+
+func (s *fileStore) file(owner, id string) (File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var meta File
+	if !util.ValidObjectID(id) {
+		return File{}, ErrNotFound
+	}
+	errRead := readJSON(s.filePath(owner, id, ".json"), &meta)
+	return meta, errRead
+}
+
+func (s *fileStore) fileContent(owner, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !util.ValidObjectID(id) {
+		return nil, ErrNotFound
+	}
+	content, errRead := os.ReadFile(s.filePath(owner, id, ".jsonl"))
+	if errors.Is(errRead, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if errRead != nil {
+		return nil, errRead
+	}
+	content, errOpen := s.keys.Open(owner, keyID(id), content)
+	if errors.Is(errOpen, contentcrypt.ErrShredded) {
+		return nil, ErrNotFound
+	}
+	return content, errOpen
+}
+
+func (s *fileStore) deleteFile(owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !util.ValidObjectID(id) {
+		return ErrNotFound
+	}
+	errRemove := os.Remove(s.filePath(owner, id, ".json"))
+	if errors.Is(errRemove, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if errRemove != nil {
+		return errRemove
+	}
+	_ = os.Remove(s.filePath(owner, id, ".jsonl"))
+	return s.keys.Shred(owner, keyID(id))
+}
+
+// keyID names the content key of a file so it cannot collide with other stores of the same owner.
+func keyID(id string) string {
+	return "batch/" + id
+}
+
+func (s *fileStore) listFiles(owner, purpose string) ([]File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var files []File
+	errList := visitJSON(filepath.Join(s.ownerDir(owner), "files"), func(_ string, data []byte) {
+		var meta File
+		if json.Unmarshal(data, &meta) == nil && (purpose == "" || meta.Purpose == purpose) {
+			files = append(files, meta)
+		}
+	})
+	sort.Slice(files, func(i, k int) bool { return files[i].CreatedAt > files[k].CreatedAt })
+	return files, errList
+}
+
+// storedBatch is the stored form of a batch, which also keeps the access metadata of the key
+// that created it.
+type storedBatch struct {
+	Batch
+	AccessMetadata map[string]string `json:"access_metadata,omitempty"`
+}
+
+func (s *fileStore) saveBatch(owner string, b Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeJSON(s.batchPath(owner, b.ID), storedBatch{Batch: b, AccessMetadata: b.access})
+}
+
+func (s *fileStore) batch(owner, id string) (Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stored storedBatch
+	if !util.ValidObjectID(id) {
+		return Batch{}, ErrNotFound
+	}
+	errRead := readJSON(s.batchPath(owner, id), &stored)
+	stored.Batch.access = stored.AccessMetadata
+	return stored.Batch, errRead
+}
+
+// listBatches returns the batches of owner, newest first.
+func (s *fileStore) listBatches(owner string) ([]Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var batches []Batch
+	errList := visitJSON(filepath.Join(s.ownerDir(owner), "batches"), func(_ string, data []byte) {
+		var b Batch
+		if json.Unmarshal(data, &b) == nil {
+			batches = append(batches, b)
+		}
+	})
+	sort.Slice(batches, func(i, k int) bool { return batches[i].CreatedAt > batches[k].CreatedAt })
+	return batches, errList
+}
+
+// updateAllBatches rewrites every stored batch for which update returns true. It is used
+// to fail batches interrupted by a restart, whose owners are not known at startup.
+func (s *fileStore) updateAllBatches(update func(b *Batch) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owners, errRead := os.ReadDir(s.dir)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return nil
+		}
+		return errRead
+	}
+	for _, owner := range owners {
+		if !owner.IsDir() {
+			continue
+		}
+		_ = visitJSON(filepath.Join(s.dir, owner.Name(), "batches"), func(path string, data []byte) {
+			var stored storedBatch
+			if json.Unmarshal(data, &stored) != nil || !update(&stored.Batch) {
+				return
+			}
+			_ = writeJSON(path, stored)
+		})
+	}
+	return nil
+}
+
+func visitJSON(dir string, visit func(path string, data []byte)) error {
+	entries, errRead := os.ReadDir(dir)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return nil
+		}
+		return errRead
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, errFile := os.ReadFile(path)
+		if errFile != nil {
+			continue
+		}
+		visit(path, data)
+	}
+	return nil
+}
+
+func readJSON(path string, out any) error {
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return errRead
+	}
+	if errUnmarshal := json.Unmarshal(data, out); errUnmarshal != nil {
+		return fmt.Errorf("parse %s: %w", filepath.Base(path), errUnmarshal)
+	}
+	return nil
+}
+
+func writeJSON(path string, value any) error {
+	data, errMarshal := json.Marshal(value)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	return util.WriteFileAtomic(path, data)
+}
@@ -93,7 +93,7 @@ func CacheAntigravityReasoningReplayItemsBestEffort(ctx context.Context, modelNa
 	}
 
 	cacheCleanupOnce.Do(startCacheCleanup)
-	now := time.Now()
+	now := cacheClock().Now()
 	antigravityReasoningReplayMu.Lock()
 	defer antigravityReasoningReplayMu.Unlock()
 	antigravityReasoningReplayEntries[key] = antigravityReasoningReplayEntry{
@@ -150,7 +150,7 @@ func GetAntigravityReasoningReplayItemsRequired(ctx context.Context, modelName,
 	}
 
 	cacheCleanupOnce.Do(startCacheCleanup)
-	now := time.Now()
+	now := cacheClock().Now()
 	antigravityReasoningReplayMu.Lock()
 	defer antigravityReasoningReplayMu.Unlock()
 	entry, ok := antigravityReasoningReplayEntries[key]
@@ -102,7 +102,7 @@ func CacheCodexReasoningReplayItemsBestEffort(ctx context.Context, modelName, se
 	}
 
 	cacheCleanupOnce.Do(startCacheCleanup)
-	now := time.Now()
+	now := cacheClock().Now()
 	codexReasoningReplayMu.Lock()
 	defer codexReasoningReplayMu.Unlock()
 	codexReasoningReplayEntries[key] = codexReasoningReplayEntry{
@@ -171,7 +171,7 @@ func AppendCodexReasoningReplayItemsBestEffort(ctx context.Context, modelName, s
 	}
 
 	cacheCleanupOnce.Do(startCacheCleanup)
-	now := time.Now()
+	now := cacheClock().Now()
 	codexReasoningReplayMu.Lock()
 	entry := codexReasoningReplayEntries[key]
 	if now.Sub(entry.Timestamp) > CodexReasoningReplayCacheTTL {
@@ -278,7 +278,7 @@ func GetCodexReasoningReplayItemsRequired(ctx context.Context, modelName, sessio
 	}
 
 	cacheCleanupOnce.Do(startCacheCleanup)
-	now := time.Now()
+	now := cacheClock().Now()
 	codexReasoningReplayMu.Lock()
 	defer codexReasoningReplayMu.Unlock()
 	entry, ok := codexReasoningReplayEntries[key]
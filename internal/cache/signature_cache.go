@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
 	homekv "github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	log "github.com/sirupsen/logrus"
 )
@@ -51,6 +52,11 @@ var currentSignatureKVClient = func() (signatureKVClient, bool, error) {
 	return homekv.CurrentKVClient()
 }
 
+// cacheClock returns the clock used for entry timestamps and expiry across the caches in
+// this package. It is read on every use so the simulated time mode applies to the
+// process-wide caches as well.
+var cacheClock = clock.Default
+
 // groupCache is the inner map type
 type groupCache struct {
 	mu      sync.RWMutex
@@ -80,9 +86,9 @@ func getOrCreateGroupCache(groupKey string) *groupCache {
 // removes caches where all entries have expired.
 func startCacheCleanup() {
 	go func() {
-		ticker := time.NewTicker(CacheCleanupInterval)
+		ticker := cacheClock().NewTicker(CacheCleanupInterval)
 		defer ticker.Stop()
-		for range ticker.C {
+		for range ticker.C() {
 			purgeExpiredCaches()
 		}
 	}()
@@ -90,7 +96,7 @@ func startCacheCleanup() {
 
 // purgeExpiredCaches removes caches with no valid (non-expired) entries.
 func purgeExpiredCaches() {
-	now := time.Now()
+	now := cacheClock().Now()
 	signatureCache.Range(func(key, value any) bool {
 		sc := value.(*groupCache)
 		sc.mu.Lock()
@@ -149,7 +155,7 @@ func CacheSignatureBestEffort(ctx context.Context, modelName, text, signature st
 
 	sc.entries[textHash] = SignatureEntry{
 		Signature: signature,
-		Timestamp: time.Now(),
+		Timestamp: cacheClock().Now(),
 	}
 	return true
 }
@@ -207,7 +213,7 @@ func GetCachedSignatureRequired(ctx context.Context, modelName, text string) (st
 
 	textHash := hashText(text)
 
-	now := time.Now()
+	now := cacheClock().Now()
 
 	sc.mu.Lock()
 	entry, exists := sc.entries[textHash]
@@ -118,7 +118,7 @@ func StoreXAIReasoningReplayItems(ctx context.Context, modelName, sessionKey str
 	}
 
 	cacheCleanupOnce.Do(startCacheCleanup)
-	now := time.Now()
+	now := cacheClock().Now()
 	xaiReasoningReplayMu.Lock()
 	defer xaiReasoningReplayMu.Unlock()
 	xaiReasoningReplayEntries[key] = xaiReasoningReplayEntry{
@@ -175,7 +175,7 @@ func GetXAIReasoningReplayItemsRequired(ctx context.Context, modelName, sessionK
 	}
 
 	cacheCleanupOnce.Do(startCacheCleanup)
-	now := time.Now()
+	now := cacheClock().Now()
 	xaiReasoningReplayMu.Lock()
 	defer xaiReasoningReplayMu.Unlock()
 	entry, ok := xaiReasoningReplayEntries[key]
@@ -0,0 +1,126 @@
+// Package cancelstats counts streaming requests abandoned by their client before the response
+// finished, so deployments can see how much upstream work is cut short by disconnects.
+package cancelstats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+// Stage tells how far a stream got before its client went away.
+type Stage string
+
+const (
+	// StageBeforeFirstChunk is a stream cancelled while waiting for the upstream's first chunk.
+	StageBeforeFirstChunk Stage = "before_first_chunk"
+	// StageMidStream is a stream cancelled after chunks were sent to the client.
+	StageMidStream Stage = "mid_stream"
+)
+
+// Stat is the number of cancelled streams of one model at one stage.
+type Stat struct {
+	Model   string `json:"model"`
+	Stage   Stage  `json:"stage"`
+	Streams int64  `json:"streams"`
+}
+
+// Report is a snapshot of the recorded cancellations, sorted by model and stage.
+type Report struct {
+	Since time.Time `json:"since"`
+	Total int64     `json:"total"`
+	Stats []Stat    `json:"stats"`
+}
+
+type statKey struct {
+	model string
+	stage Stage
+}
+
+// Store counts cancelled streams since it was created or last reset. A nil Store records nothing.
+type Store struct {
+	mu     sync.Mutex
+	counts map[statKey]int64
+	since  time.Time
+
+	clock clock.Clock
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	s := &Store{
+		counts: make(map[statKey]int64),
+		clock:  clock.Default(),
+	}
+	s.since = s.clock.Now()
+	return s
+}
+
+var defaultStore = NewStore()
+
+// Default returns the process-wide Store fed by the API handlers.
+func Default() *Store { return defaultStore }
+
+// Record counts one stream of model cancelled by its client at stage.
+func (s *Store) Record(model string, stage Stage) {
+	if s == nil {
+		return
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		model = "unknown"
+	}
+	s.mu.Lock()
+	s.counts[statKey{model: model, stage: stage}]++
+	s.mu.Unlock()
+}
+
+// Reset clears all counts and restarts the reporting window.
+func (s *Store) Reset() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.counts = make(map[statKey]int64)
+	s.since = s.clock.Now()
+	s.mu.Unlock()
+}
+
+// Snapshot returns the counts recorded since the last reset.
+func (s *Store) Snapshot() Report {
+	if s == nil {
+		return Report{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := Report{Since: s.since, Stats: make([]Stat, 0, len(s.counts))}
+	for key, count := range s.counts {
+		report.Total += count
+		report.Stats = append(report.Stats, Stat{Model: key.model, Stage: key.stage, Streams: count})
+	}
+	sort.Slice(report.Stats, func(i, j int) bool {
+		if report.Stats[i].Model != report.Stats[j].Model {
+			return report.Stats[i].Model < report.Stats[j].Model
+		}
+		return report.Stats[i].Stage < report.Stats[j].Stage
+	})
+	return report
+}
+
+// WritePrometheus writes the report in the Prometheus text exposition format.
+func (r Report) WritePrometheus(w io.Writer) error {
+	const name = "cliproxy_client_cancelled_streams_total"
+	var out strings.Builder
+	fmt.Fprintf(&out, "# HELP %s Streaming requests abandoned by the client before the response finished.\n# TYPE %s counter\n", name, name)
+	for _, stat := range r.Stats {
+		fmt.Fprintf(&out, "%s{model=\"%s\",stage=\"%s\"} %d\n", name, util.EscapePrometheusLabel(stat.Model), stat.Stage, stat.Streams)
+	}
+	_, errWrite := io.WriteString(w, out.String())
+	return errWrite
+}
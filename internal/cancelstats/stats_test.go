@@ -0,0 +1,50 @@
+package cancelstats
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStoreSnapshotCountsPerModelAndStage(t *testing.T) {
+	store := NewStore()
+	store.Record("gpt-5", StageMidStream)
+	store.Record(" gpt-5 ", StageMidStream)
+	store.Record("gpt-5", StageBeforeFirstChunk)
+	store.Record("", StageMidStream)
+
+	report := store.Snapshot()
+	if report.Total != 4 || len(report.Stats) != 3 {
+		t.Fatalf("report = %+v, want 4 streams in 3 entries", report)
+	}
+	if first := report.Stats[0]; first.Model != "gpt-5" || first.Stage != StageBeforeFirstChunk || first.Streams != 1 {
+		t.Fatalf("first = %+v", first)
+	}
+	if second := report.Stats[1]; second.Model != "gpt-5" || second.Stage != StageMidStream || second.Streams != 2 {
+		t.Fatalf("second = %+v", second)
+	}
+	if third := report.Stats[2]; third.Model != "unknown" {
+		t.Fatalf("third = %+v, want the unnamed model reported as unknown", third)
+	}
+
+	var out strings.Builder
+	if errWrite := report.WritePrometheus(&out); errWrite != nil {
+		t.Fatalf("WritePrometheus: %v", errWrite)
+	}
+	if !strings.Contains(out.String(), `cliproxy_client_cancelled_streams_total{model="gpt-5",stage="mid_stream"} 2`) {
+		t.Fatalf("prometheus output = %s", out.String())
+	}
+
+	store.Reset()
+	if report = store.Snapshot(); report.Total != 0 || len(report.Stats) != 0 {
+		t.Fatalf("report after reset = %+v, want empty", report)
+	}
+}
+
+func TestNilStoreIsSafe(t *testing.T) {
+	var store *Store
+	store.Record("gpt-5", StageMidStream)
+	store.Reset()
+	if report := store.Snapshot(); report.Total != 0 {
+		t.Fatalf("nil store report = %+v", report)
+	}
+}
@@ -0,0 +1,42 @@
+package clock
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Rand is the source of randomness injected into components.
+type Rand interface {
+	// Int64N returns a value in [0, n). It panics if n <= 0.
+	Int64N(n int64) int64
+	// Float64 returns a value in [0.0, 1.0).
+	Float64() float64
+}
+
+// NewRand returns a Rand safe for concurrent use whose sequence is determined by seed.
+func NewRand(seed uint64) Rand {
+	return &lockedRand{r: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+// Duration returns a duration in [0, n) drawn from r, like rand.N for durations.
+func Duration(r Rand, n time.Duration) time.Duration {
+	return time.Duration(r.Int64N(int64(n)))
+}
+
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Int64N(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int64N(n)
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
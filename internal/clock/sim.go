@@ -0,0 +1,175 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sim is a Clock whose time only moves when Advance or Set is called. Timers and tickers
+// fire synchronously during the call that moves time past their deadline; like the time
+// package, a ticker drops ticks its reader has not consumed.
+type Sim struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*simWaiter
+}
+
+type simWaiter struct {
+	sim      *Sim
+	ch       chan time.Time
+	deadline time.Time
+	period   time.Duration
+	active   bool
+}
+
+// NewSim returns a simulated clock reading start.
+func NewSim(start time.Time) *Sim {
+	return &Sim{now: start}
+}
+
+// Now returns the simulated time.
+func (s *Sim) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// Since returns the simulated time elapsed since t.
+func (s *Sim) Since(t time.Time) time.Duration {
+	return s.Now().Sub(t)
+}
+
+// NewTimer returns a timer firing once the simulated time reaches now+d.
+func (s *Sim) NewTimer(d time.Duration) Timer {
+	w := &simWaiter{sim: s, ch: make(chan time.Time, 1)}
+	s.mu.Lock()
+	s.armLocked(w, d)
+	s.mu.Unlock()
+	s.fireDue()
+	return simTimer{w}
+}
+
+// NewTicker returns a ticker firing every d of simulated time. It panics if d <= 0.
+func (s *Sim) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &simWaiter{sim: s, ch: make(chan time.Time, 1), period: d}
+	s.mu.Lock()
+	s.armLocked(w, d)
+	s.mu.Unlock()
+	return simTicker{w}
+}
+
+// Advance moves the simulated time forward by d and fires the timers that became due.
+func (s *Sim) Advance(d time.Duration) {
+	s.mu.Lock()
+	s.now = s.now.Add(d)
+	s.mu.Unlock()
+	s.fireDue()
+}
+
+// Set moves the simulated time to t and fires the timers that became due. Moving time
+// backwards fires nothing.
+func (s *Sim) Set(t time.Time) {
+	s.mu.Lock()
+	s.now = t
+	s.mu.Unlock()
+	s.fireDue()
+}
+
+// Waiters returns the number of active timers and tickers, letting tests wait until a
+// goroutine has armed its timer before advancing time.
+func (s *Sim) Waiters() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiters)
+}
+
+func (s *Sim) armLocked(w *simWaiter, d time.Duration) bool {
+	wasActive := w.active
+	if !wasActive {
+		s.waiters = append(s.waiters, w)
+	}
+	w.deadline = s.now.Add(d)
+	w.active = true
+	return wasActive
+}
+
+func (s *Sim) removeLocked(w *simWaiter) bool {
+	if !w.active {
+		return false
+	}
+	w.active = false
+	for i, other := range s.waiters {
+		if other == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// fireDue delivers the due timers and tickers in deadline order, rescheduling tickers.
+func (s *Sim) fireDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		sort.SliceStable(s.waiters, func(i, j int) bool { return s.waiters[i].deadline.Before(s.waiters[j].deadline) })
+		if len(s.waiters) == 0 || s.waiters[0].deadline.After(s.now) {
+			return
+		}
+		w := s.waiters[0]
+		select {
+		case w.ch <- w.deadline:
+		default:
+		}
+		if w.period > 0 {
+			// Skip the ticks the buffered channel would drop anyway.
+			missed := s.now.Sub(w.deadline) / w.period
+			w.deadline = w.deadline.Add((missed + 1) * w.period)
+			continue
+		}
+		s.removeLocked(w)
+	}
+}
+
+type simTimer struct{ *simWaiter }
+
+func (t simTimer) C() <-chan time.Time { return t.ch }
+
+func (t simTimer) Stop() bool {
+	t.sim.mu.Lock()
+	defer t.sim.mu.Unlock()
+	return t.sim.removeLocked(t.simWaiter)
+}
+
+func (t simTimer) Reset(d time.Duration) bool {
+	t.sim.mu.Lock()
+	wasActive := t.sim.armLocked(t.simWaiter, d)
+	t.sim.mu.Unlock()
+	t.sim.fireDue()
+	return wasActive
+}
+
+type simTicker struct{ *simWaiter }
+
+func (t simTicker) C() <-chan time.Time { return t.ch }
+
+func (t simTicker) Stop() {
+	t.sim.mu.Lock()
+	defer t.sim.mu.Unlock()
+	t.sim.removeLocked(t.simWaiter)
+}
+
+// Reset changes the period and restarts the ticker. It panics if d <= 0.
+func (t simTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.sim.mu.Lock()
+	t.period = d
+	t.sim.armLocked(t.simWaiter, d)
+	t.sim.mu.Unlock()
+}
@@ -0,0 +1,93 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimTimerFiresOnAdvance(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	sim := NewSim(start)
+	timer := sim.NewTimer(time.Minute)
+
+	sim.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+	if sim.Waiters() != 1 {
+		t.Fatalf("Waiters() = %d, want 1", sim.Waiters())
+	}
+
+	sim.Advance(time.Second)
+	select {
+	case fired := <-timer.C():
+		if !fired.Equal(start.Add(time.Minute)) {
+			t.Fatalf("fired at %v, want %v", fired, start.Add(time.Minute))
+		}
+	default:
+		t.Fatal("timer did not fire at its deadline")
+	}
+	if timer.Stop() {
+		t.Fatal("Stop() = true for a fired timer")
+	}
+	if timer.Reset(time.Second) {
+		t.Fatal("Reset() = true for a fired timer")
+	}
+	if !timer.Stop() || sim.Waiters() != 0 {
+		t.Fatalf("Stop() did not disarm the reset timer, waiters = %d", sim.Waiters())
+	}
+}
+
+func TestSimTickerDropsUnreadTicks(t *testing.T) {
+	sim := NewSim(time.Unix(0, 0))
+	ticker := sim.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	sim.Advance(10 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker delivered more than one pending tick")
+	default:
+	}
+
+	sim.Advance(time.Second)
+	select {
+	case fired := <-ticker.C():
+		if want := time.Unix(11, 0); !fired.Equal(want) {
+			t.Fatalf("tick at %v, want %v", fired, want)
+		}
+	default:
+		t.Fatal("ticker did not tick after the next period")
+	}
+}
+
+func TestNewRandIsDeterministic(t *testing.T) {
+	a, b := NewRand(42), NewRand(42)
+	for i := 0; i < 16; i++ {
+		if x, y := a.Int64N(1000), b.Int64N(1000); x != y {
+			t.Fatalf("draw %d differs: %d != %d", i, x, y)
+		}
+	}
+	if d := Duration(a, time.Second); d < 0 || d >= time.Second {
+		t.Fatalf("Duration() = %v, want in [0, 1s)", d)
+	}
+}
+
+func TestSetDefaultReplacesDefaults(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	sim := NewSim(start)
+	restore := SetDefault(sim, NewRand(7))
+	if Default() != Clock(sim) || !Default().Now().Equal(start) {
+		t.Fatalf("Default() = %v, want the simulated clock", Default())
+	}
+	if got, want := DefaultRand().Int64N(1<<30), NewRand(7).Int64N(1<<30); got != want {
+		t.Fatalf("DefaultRand() draw = %d, want %d", got, want)
+	}
+	restore()
+	if _, ok := Default().(realClock); !ok {
+		t.Fatalf("Default() after restore = %T, want realClock", Default())
+	}
+}
@@ -19,7 +19,10 @@ import (
 // Parameters:
 //   - cfg: The application configuration
 //   - options: Login options including browser behavior and prompts
-func DoClaudeLogin(cfg *config.Config, options *LoginOptions) {
+//
+// Returns:
+//   - error: An error if the login failed
+func DoClaudeLogin(cfg *config.Config, options *LoginOptions) error {
 	if options == nil {
 		options = &LoginOptions{}
 	}
@@ -45,10 +48,10 @@ func DoClaudeLogin(cfg *config.Config, options *LoginOptions) {
 			if authErr.Type == claude.ErrPortInUse.Type {
 				os.Exit(claude.ErrPortInUse.Code)
 			}
-			return
+			return err
 		}
 		fmt.Printf("Claude authentication failed: %v\n", err)
-		return
+		return err
 	}
 
 	if savedPath != "" {
@@ -56,4 +59,5 @@ func DoClaudeLogin(cfg *config.Config, options *LoginOptions) {
 	}
 
 	fmt.Println("Claude authentication successful!")
+	return nil
 }
@@ -10,7 +10,8 @@ import (
 )
 
 // DoAntigravityLogin triggers the OAuth flow for the antigravity provider and saves tokens.
-func DoAntigravityLogin(cfg *config.Config, options *LoginOptions) {
+// It returns an error if the login fails.
+func DoAntigravityLogin(cfg *config.Config, options *LoginOptions) error {
 	if options == nil {
 		options = &LoginOptions{}
 	}
@@ -31,7 +32,7 @@ func DoAntigravityLogin(cfg *config.Config, options *LoginOptions) {
 	record, savedPath, err := manager.Login(context.Background(), "antigravity", cfg, authOpts)
 	if err != nil {
 		log.Errorf("Antigravity authentication failed: %v", err)
-		return
+		return err
 	}
 
 	if savedPath != "" {
@@ -41,4 +42,5 @@ func DoAntigravityLogin(cfg *config.Config, options *LoginOptions) {
 		fmt.Printf("Authenticated as %s\n", record.Label)
 	}
 	fmt.Println("Antigravity authentication successful!")
+	return nil
 }
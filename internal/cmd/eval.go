@@ -0,0 +1,405 @@
+// Package cmd contains CLI helpers. This file implements the -eval command, which replays a
+// prompt set through two routing configurations of a running proxy and compares the results.
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEvalTimeout bounds a single replayed or judge request.
+const defaultEvalTimeout = 5 * time.Minute
+
+// evalJudgePrompt asks the judge model to pick the better of two responses.
+const evalJudgePrompt = `You are comparing two assistant responses to the same conversation.
+
+Conversation:
+%s
+
+Response A:
+%s
+
+Response B:
+%s
+
+Which response is better? Answer with exactly one word: A, B or TIE.`
+
+// EvalSpec describes an evaluation run.
+//
+//	prompts: eval/prompts.jsonl
+//	routes:
+//	  - name: baseline
+//	    model: gpt-5
+//	  - name: candidate
+//	    model: claude-sonnet-4-5
+//	    provider: claude
+//	judge:
+//	  model: gpt-5
+type EvalSpec struct {
+	// Prompts is the JSONL prompt set. Each line is either {"prompt": "..."}, an OpenAI chat
+	// completions request body, or a shadow traffic log record with an "openai" request.
+	Prompts string `yaml:"prompts"`
+	// BaseURL is the proxy to replay against; defaults to the local server from the config.
+	BaseURL string `yaml:"base-url"`
+	// APIKey authenticates replayed requests; defaults to the first configured api-keys entry.
+	APIKey string `yaml:"api-key"`
+	// Timeout bounds each request, e.g. "2m"; defaults to 5m.
+	Timeout string `yaml:"timeout"`
+	// Routes are the two routing configurations being compared.
+	Routes []EvalRoute `yaml:"routes"`
+	// Judge optionally scores each pair of responses with a model.
+	Judge *EvalRoute `yaml:"judge"`
+	// Output optionally writes the per-prompt results as JSON.
+	Output string `yaml:"output"`
+}
+
+// EvalRoute is a routing configuration: the model to request and, optionally, the provider
+// the request is restricted to through the cliproxy routing extension.
+type EvalRoute struct {
+	Name     string `yaml:"name" json:"name"`
+	Model    string `yaml:"model" json:"model"`
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+}
+
+type evalPrompt struct {
+	id   string
+	body []byte
+}
+
+// evalResult is the outcome of one prompt on one route.
+type evalResult struct {
+	Status           int    `json:"status"`
+	Error            string `json:"error,omitempty"`
+	LatencyMs        int64  `json:"latency_ms"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	Response         string `json:"response,omitempty"`
+}
+
+// evalCase holds one prompt's results on both routes and the judge's verdict.
+type evalCase struct {
+	ID      string        `json:"id"`
+	Results [2]evalResult `json:"results"`
+	// Winner is the name of the preferred route, "tie", or empty when not judged.
+	Winner     string `json:"winner,omitempty"`
+	JudgeError string `json:"judge_error,omitempty"`
+}
+
+type evalReport struct {
+	Routes  [2]EvalRoute `json:"routes"`
+	Judge   *EvalRoute   `json:"judge,omitempty"`
+	Skipped int          `json:"skipped"`
+	Cases   []evalCase   `json:"cases"`
+}
+
+// DoEval runs the evaluation described by the spec file against a running proxy and prints a
+// comparison report to stdout. It returns the process exit code: 1 when the spec or prompt
+// set cannot be loaded, 0 otherwise.
+func DoEval(cfg *config.Config, specPath string) int {
+	spec, errLoad := loadEvalSpec(cfg, specPath)
+	if errLoad != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "eval: %v\n", errLoad)
+		return 1
+	}
+	prompts, skipped, errPrompts := loadEvalPrompts(spec.Prompts)
+	if errPrompts != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "eval: %v\n", errPrompts)
+		return 1
+	}
+	report := runEval(context.Background(), spec, prompts)
+	report.Skipped = skipped
+	report.write(os.Stdout)
+	if spec.Output != "" {
+		data, errMarshal := json.MarshalIndent(report, "", "  ")
+		if errMarshal == nil {
+			errMarshal = os.WriteFile(spec.Output, data, 0o600)
+		}
+		if errMarshal != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "eval: failed to write %s: %v\n", spec.Output, errMarshal)
+			return 1
+		}
+	}
+	return 0
+}
+
+func loadEvalSpec(cfg *config.Config, specPath string) (*EvalSpec, error) {
+	data, errRead := os.ReadFile(specPath)
+	if errRead != nil {
+		return nil, errRead
+	}
+	spec := &EvalSpec{}
+	if errUnmarshal := yaml.Unmarshal(data, spec); errUnmarshal != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", specPath, errUnmarshal)
+	}
+	if strings.TrimSpace(spec.Prompts) == "" {
+		return nil, fmt.Errorf("spec %s: prompts is required", specPath)
+	}
+	if len(spec.Routes) != 2 {
+		return nil, fmt.Errorf("spec %s: exactly two routes are required, got %d", specPath, len(spec.Routes))
+	}
+	for i := range spec.Routes {
+		route := &spec.Routes[i]
+		if strings.TrimSpace(route.Model) == "" {
+			return nil, fmt.Errorf("spec %s: route %d has no model", specPath, i+1)
+		}
+		if route.Name == "" {
+			route.Name = route.Model
+		}
+	}
+	if spec.Routes[0].Name == spec.Routes[1].Name {
+		spec.Routes[1].Name += " (2)"
+	}
+	if spec.Judge != nil && strings.TrimSpace(spec.Judge.Model) == "" {
+		spec.Judge = nil
+	}
+	if spec.Timeout != "" {
+		if _, errParse := time.ParseDuration(spec.Timeout); errParse != nil {
+			return nil, fmt.Errorf("spec %s: invalid timeout %q", specPath, spec.Timeout)
+		}
+	}
+	if spec.BaseURL == "" && cfg != nil {
+		scheme := "http"
+		if cfg.TLS.Enable {
+			scheme = "https"
+		}
+		host := cfg.Host
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		spec.BaseURL = fmt.Sprintf("%s://%s:%d", scheme, host, cfg.Port)
+	}
+	spec.BaseURL = strings.TrimRight(spec.BaseURL, "/")
+	if spec.APIKey == "" && cfg != nil && len(cfg.APIKeys) > 0 {
+		spec.APIKey = cfg.APIKeys[0]
+	}
+	return spec, nil
+}
+
+// loadEvalPrompts reads the prompt set, returning the number of lines that cannot be replayed
+// as OpenAI chat completions requests.
+func loadEvalPrompts(path string) ([]evalPrompt, int, error) {
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		return nil, 0, errOpen
+	}
+	defer func() { _ = file.Close() }()
+
+	var prompts []evalPrompt
+	skipped := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if !gjson.ValidBytes(raw) {
+			return nil, 0, fmt.Errorf("%s:%d: invalid JSON", path, line)
+		}
+		id := gjson.GetBytes(raw, "id").String()
+		if id == "" {
+			id = fmt.Sprintf("%d", line)
+		}
+		var body []byte
+		switch {
+		case gjson.GetBytes(raw, "prompt").Exists():
+			body, _ = sjson.SetBytes([]byte(`{"messages":[{"role":"user"}]}`), "messages.0.content", gjson.GetBytes(raw, "prompt").String())
+		case gjson.GetBytes(raw, "messages").IsArray():
+			body = bytes.Clone(raw)
+		case gjson.GetBytes(raw, "request").Exists():
+			// Shadow traffic log record.
+			request := []byte(gjson.GetBytes(raw, "request").String())
+			if gjson.GetBytes(raw, "protocol").String() != "openai" || !gjson.GetBytes(request, "messages").IsArray() {
+				skipped++
+				continue
+			}
+			body = request
+		default:
+			skipped++
+			continue
+		}
+		prompts = append(prompts, evalPrompt{id: id, body: body})
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return nil, 0, errScan
+	}
+	return prompts, skipped, nil
+}
+
+func runEval(ctx context.Context, spec *EvalSpec, prompts []evalPrompt) *evalReport {
+	timeout := defaultEvalTimeout
+	if spec.Timeout != "" {
+		timeout, _ = time.ParseDuration(spec.Timeout)
+	}
+	client := &http.Client{Timeout: timeout}
+	report := &evalReport{Routes: [2]EvalRoute{spec.Routes[0], spec.Routes[1]}, Judge: spec.Judge}
+	for i, prompt := range prompts {
+		c := evalCase{ID: prompt.id}
+		for r, route := range report.Routes {
+			c.Results[r] = sendEvalRequest(ctx, client, spec, route, prompt.body)
+		}
+		if spec.Judge != nil && c.Results[0].Error == "" && c.Results[1].Error == "" {
+			// Alternate the order the responses are shown in to offset position bias.
+			swap := i%2 == 1
+			c.Winner, c.JudgeError = judgeEval(ctx, client, spec, report.Routes, prompt.body, c.Results, swap)
+		}
+		report.Cases = append(report.Cases, c)
+	}
+	return report
+}
+
+// sendEvalRequest replays a chat completions body on a route. Requests are tagged with the
+// route name so their usage records can be told apart.
+func sendEvalRequest(ctx context.Context, client *http.Client, spec *EvalSpec, route EvalRoute, body []byte) evalResult {
+	body, _ = sjson.SetBytes(body, "model", route.Model)
+	body, _ = sjson.SetBytes(body, "stream", false)
+	body, _ = sjson.DeleteBytes(body, "stream_options")
+	tag := "eval:" + route.Name
+	if len(tag) > 64 {
+		tag = tag[:64]
+	}
+	body, _ = sjson.SetBytes(body, "cliproxy.tags", []string{"eval", tag})
+	if route.Provider != "" {
+		body, _ = sjson.SetBytes(body, "cliproxy.routing.provider", route.Provider)
+	}
+
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, spec.BaseURL+"/v1/chat/completions", bytes.NewReader(body))
+	if errReq != nil {
+		return evalResult{Error: errReq.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if spec.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+spec.APIKey)
+	}
+	started := time.Now()
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return evalResult{Error: errDo.Error(), LatencyMs: time.Since(started).Milliseconds()}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, errRead := io.ReadAll(resp.Body)
+	result := evalResult{Status: resp.StatusCode, LatencyMs: time.Since(started).Milliseconds()}
+	switch {
+	case errRead != nil:
+		result.Error = errRead.Error()
+	case resp.StatusCode != http.StatusOK:
+		result.Error = strings.TrimSpace(gjson.GetBytes(data, "error.message").String())
+		if result.Error == "" {
+			result.Error = http.StatusText(resp.StatusCode)
+		}
+	default:
+		result.Response = gjson.GetBytes(data, "choices.0.message.content").String()
+		result.PromptTokens = gjson.GetBytes(data, "usage.prompt_tokens").Int()
+		result.CompletionTokens = gjson.GetBytes(data, "usage.completion_tokens").Int()
+	}
+	return result
+}
+
+// judgeEval asks the judge model which response is better and returns the winning route's
+// name, "tie", or an error message.
+func judgeEval(ctx context.Context, client *http.Client, spec *EvalSpec, routes [2]EvalRoute, body []byte, results [2]evalResult, swap bool) (string, string) {
+	first, second := 0, 1
+	if swap {
+		first, second = 1, 0
+	}
+	conversation := gjson.GetBytes(body, "messages").Raw
+	prompt := fmt.Sprintf(evalJudgePrompt, conversation, results[first].Response, results[second].Response)
+	judgeBody, _ := sjson.SetBytes([]byte(`{"messages":[{"role":"user"}]}`), "messages.0.content", prompt)
+	judged := sendEvalRequest(ctx, client, spec, *spec.Judge, judgeBody)
+	if judged.Error != "" {
+		return "", judged.Error
+	}
+	verdict := strings.ToUpper(strings.Trim(strings.TrimSpace(judged.Response), ".*\"'"))
+	switch {
+	case strings.HasPrefix(verdict, "TIE"):
+		return "tie", ""
+	case strings.HasPrefix(verdict, "A"):
+		return routes[first].Name, ""
+	case strings.HasPrefix(verdict, "B"):
+		return routes[second].Name, ""
+	}
+	return "", fmt.Sprintf("unrecognized verdict %q", judged.Response)
+}
+
+// write prints the per-route summary of the report.
+func (r *evalReport) write(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Evaluated %d prompts", len(r.Cases))
+	if r.Skipped > 0 {
+		_, _ = fmt.Fprintf(w, " (%d skipped)", r.Skipped)
+	}
+	_, _ = fmt.Fprintln(w)
+	judged, ties := 0, 0
+	for _, c := range r.Cases {
+		if c.Winner != "" {
+			judged++
+		}
+		if c.Winner == "tie" {
+			ties++
+		}
+	}
+	for i, route := range r.Routes {
+		var latencies []int64
+		var promptTokens, completionTokens int64
+		failed, wins := 0, 0
+		for _, c := range r.Cases {
+			result := c.Results[i]
+			if result.Error != "" {
+				failed++
+				continue
+			}
+			latencies = append(latencies, result.LatencyMs)
+			promptTokens += result.PromptTokens
+			completionTokens += result.CompletionTokens
+			if c.Winner == route.Name {
+				wins++
+			}
+		}
+		name := route.Name
+		if route.Provider != "" {
+			name += " [" + route.Model + " via " + route.Provider + "]"
+		} else if route.Model != route.Name {
+			name += " [" + route.Model + "]"
+		}
+		_, _ = fmt.Fprintf(w, "\n%s\n", name)
+		_, _ = fmt.Fprintf(w, "  succeeded: %d, failed: %d\n", len(latencies), failed)
+		if len(latencies) > 0 {
+			slices.Sort(latencies)
+			var total int64
+			for _, latency := range latencies {
+				total += latency
+			}
+			_, _ = fmt.Fprintf(w, "  latency ms: avg %d, p50 %d, p95 %d\n",
+				total/int64(len(latencies)), evalPercentile(latencies, 50), evalPercentile(latencies, 95))
+		}
+		_, _ = fmt.Fprintf(w, "  tokens: %d prompt, %d completion\n", promptTokens, completionTokens)
+		if r.Judge != nil {
+			_, _ = fmt.Fprintf(w, "  judge wins: %d of %d\n", wins, judged)
+		}
+	}
+	if r.Judge != nil {
+		_, _ = fmt.Fprintf(w, "\nJudge %s: %d judged, %d ties\n", r.Judge.Model, judged, ties)
+	}
+}
+
+// evalPercentile returns the nearest-rank percentile of sorted values.
+func evalPercentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
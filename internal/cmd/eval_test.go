@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestRunEvalComparesRoutesAndJudges(t *testing.T) {
+	var bodies []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer client-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch gjson.GetBytes(body, "model").String() {
+		case "model-a":
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"short"}}],"usage":{"prompt_tokens":10,"completion_tokens":2}}`))
+		case "model-b":
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"detailed"}}],"usage":{"prompt_tokens":10,"completion_tokens":7}}`))
+		case "judge":
+			// Always prefer the response mentioning "detailed".
+			prompt := gjson.GetBytes(body, "messages.0.content").String()
+			verdict := "A"
+			if strings.Index(prompt, "detailed") > strings.Index(prompt, "short") {
+				verdict = "B"
+			}
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"` + verdict + `"}}]}`))
+		}
+	}))
+	defer proxy.Close()
+
+	dir := t.TempDir()
+	promptsPath := filepath.Join(dir, "prompts.jsonl")
+	prompts := strings.Join([]string{
+		`{"id":"hello","prompt":"Say hello"}`,
+		`{"model":"gpt-4o","messages":[{"role":"user","content":"Explain DNS"}],"stream":true}`,
+		`{"protocol":"claude","request":"{\"messages\":[]}"}`,
+	}, "\n")
+	if errWrite := os.WriteFile(promptsPath, []byte(prompts), 0o600); errWrite != nil {
+		t.Fatalf("write prompts: %v", errWrite)
+	}
+	specPath := filepath.Join(dir, "eval.yaml")
+	spec := "prompts: " + promptsPath + "\nbase-url: " + proxy.URL + "/\nroutes:\n  - name: baseline\n    model: model-a\n  - model: model-b\n    provider: claude\njudge:\n  model: judge\n"
+	if errWrite := os.WriteFile(specPath, []byte(spec), 0o600); errWrite != nil {
+		t.Fatalf("write spec: %v", errWrite)
+	}
+
+	cfg := &config.Config{SDKConfig: config.SDKConfig{APIKeys: []string{"client-key"}}}
+	loaded, errLoad := loadEvalSpec(cfg, specPath)
+	if errLoad != nil {
+		t.Fatalf("loadEvalSpec: %v", errLoad)
+	}
+	loadedPrompts, skipped, errPrompts := loadEvalPrompts(loaded.Prompts)
+	if errPrompts != nil {
+		t.Fatalf("loadEvalPrompts: %v", errPrompts)
+	}
+	if len(loadedPrompts) != 2 || skipped != 1 {
+		t.Fatalf("loaded %d prompts, skipped %d; want 2 and 1", len(loadedPrompts), skipped)
+	}
+
+	report := runEval(context.Background(), loaded, loadedPrompts)
+	report.Skipped = skipped
+	if len(report.Cases) != 2 {
+		t.Fatalf("cases = %d, want 2", len(report.Cases))
+	}
+	for _, c := range report.Cases {
+		if c.Winner != "model-b" {
+			t.Fatalf("case %s winner = %q (%s), want model-b", c.ID, c.Winner, c.JudgeError)
+		}
+	}
+	if got := report.Cases[1].Results[1]; got.PromptTokens != 10 || got.CompletionTokens != 7 || got.Response != "detailed" {
+		t.Fatalf("route B result = %+v", got)
+	}
+	replayed := bodies[4]
+	if gjson.Get(replayed, "stream").Bool() || gjson.Get(replayed, "cliproxy.routing.provider").String() != "claude" || gjson.Get(replayed, "cliproxy.tags.1").String() != "eval:model-b" {
+		t.Fatalf("replayed body = %s", replayed)
+	}
+
+	var out bytes.Buffer
+	report.write(&out)
+	for _, want := range []string{"Evaluated 2 prompts (1 skipped)", "baseline [model-a]", "model-b [model-b via claude]", "tokens: 20 prompt, 14 completion", "judge wins: 2 of 2", "2 judged, 0 ties"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLoadEvalSpecRequiresTwoRoutes(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "eval.yaml")
+	if errWrite := os.WriteFile(specPath, []byte("prompts: p.jsonl\nroutes:\n  - model: a\n"), 0o600); errWrite != nil {
+		t.Fatalf("write spec: %v", errWrite)
+	}
+	if _, errLoad := loadEvalSpec(&config.Config{Port: 8317}, specPath); errLoad == nil {
+		t.Fatal("expected an error for a spec with one route")
+	}
+}
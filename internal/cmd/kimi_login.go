@@ -16,7 +16,10 @@ import (
 // Parameters:
 //   - cfg: The application configuration containing proxy and auth directory settings
 //   - options: Login options including browser behavior settings
-func DoKimiLogin(cfg *config.Config, options *LoginOptions) {
+//
+// Returns:
+//   - error: An error if the login failed
+func DoKimiLogin(cfg *config.Config, options *LoginOptions) error {
 	if options == nil {
 		options = &LoginOptions{}
 	}
@@ -31,7 +34,7 @@ func DoKimiLogin(cfg *config.Config, options *LoginOptions) {
 	record, savedPath, err := manager.Login(context.Background(), "kimi", cfg, authOpts)
 	if err != nil {
 		log.Errorf("Kimi authentication failed: %v", err)
-		return
+		return err
 	}
 
 	if savedPath != "" {
@@ -41,4 +44,5 @@ func DoKimiLogin(cfg *config.Config, options *LoginOptions) {
 		fmt.Printf("Authenticated as %s\n", record.Label)
 	}
 	fmt.Println("Kimi authentication successful!")
+	return nil
 }
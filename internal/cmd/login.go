@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// loginFlow starts one OAuth login flow and saves the resulting credential to auth-dir. It
+// returns an error when the login fails.
+type loginFlow func(*config.Config, *LoginOptions) error
+
+// loginProvider lists the flows of one subscription provider. device is nil when the provider
+// only offers a browser flow; kimi and xai only offer a device flow and use it for both.
+type loginProvider struct {
+	browser loginFlow
+	device  loginFlow
+}
+
+// loginProviders maps the names accepted by the login subcommand to their flows. Vendor names
+// are aliases of the products whose subscriptions they sign in to.
+var loginProviders = map[string]loginProvider{
+	"anthropic":   {browser: DoClaudeLogin},
+	"claude":      {browser: DoClaudeLogin},
+	"openai":      {browser: DoCodexLogin, device: DoCodexDeviceLogin},
+	"codex":       {browser: DoCodexLogin, device: DoCodexDeviceLogin},
+	"google":      {browser: DoAntigravityLogin},
+	"antigravity": {browser: DoAntigravityLogin},
+	"kimi":        {browser: DoKimiLogin, device: DoKimiLogin},
+	"xai":         {browser: DoXAILogin, device: DoXAILogin},
+}
+
+// IsLoginCommand reports whether the positional arguments start the login subcommand.
+func IsLoginCommand(args []string) bool {
+	return len(args) > 0 && args[0] == "login"
+}
+
+// DoLogin runs "login <provider> [-device] [-no-browser] [-oauth-callback-port N]": it signs in
+// to a subscription provider and stores the credential in auth-dir, where the proxy picks it up
+// and refreshes its access token in the background. Refresh tokens are encrypted on disk when
+// credential-encryption is enabled. It returns the process exit code.
+func DoLogin(cfg *config.Config, options *LoginOptions, args []string) int {
+	if options == nil {
+		options = &LoginOptions{}
+	}
+	if IsLoginCommand(args) {
+		args = args[1:]
+	}
+	return runLogin(cfg, *options, args, os.Stderr, loginProviders)
+}
+
+func runLogin(cfg *config.Config, options LoginOptions, args []string, stderr io.Writer, providers map[string]loginProvider) int {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	usage := "usage: login <provider> [-device] [-no-browser] [-oauth-callback-port N]\nproviders: " + strings.Join(names, ", ")
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		_, _ = fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	name := strings.ToLower(strings.TrimSpace(args[0]))
+	provider, ok := providers[name]
+	if !ok {
+		_, _ = fmt.Fprintf(stderr, "unknown login provider %q\n%s\n", args[0], usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("login "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	device := fs.Bool("device", false, "Use the device code flow instead of the browser callback")
+	fs.BoolVar(&options.NoBrowser, "no-browser", options.NoBrowser, "Don't open browser automatically")
+	fs.IntVar(&options.CallbackPort, "oauth-callback-port", options.CallbackPort, "Override OAuth callback port")
+	if errParse := fs.Parse(args[1:]); errParse != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		_, _ = fmt.Fprintf(stderr, "unexpected arguments: %s\n%s\n", strings.Join(fs.Args(), " "), usage)
+		return 2
+	}
+
+	flow := provider.browser
+	if *device {
+		if provider.device == nil {
+			_, _ = fmt.Fprintf(stderr, "%s does not support device code login\n", name)
+			return 2
+		}
+		flow = provider.device
+	}
+	if errLogin := flow(cfg, &options); errLogin != nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestRunLogin_SelectsFlowAndOptions(t *testing.T) {
+	var called string
+	var got LoginOptions
+	record := func(name string) loginFlow {
+		return func(_ *config.Config, options *LoginOptions) error {
+			called = name
+			got = *options
+			return nil
+		}
+	}
+	providers := map[string]loginProvider{
+		"openai": {browser: record("browser"), device: record("device")},
+		"google": {browser: record("google")},
+	}
+
+	var stderr bytes.Buffer
+	if code := runLogin(nil, LoginOptions{}, []string{"OpenAI", "-device", "-no-browser"}, &stderr, providers); code != 0 {
+		t.Fatalf("exit code = %d, stderr=%s", code, stderr.String())
+	}
+	if called != "device" || !got.NoBrowser {
+		t.Fatalf("called %q with %+v, want the device flow without browser", called, got)
+	}
+
+	called = ""
+	if code := runLogin(nil, LoginOptions{CallbackPort: 1}, []string{"google", "-oauth-callback-port", "8085"}, &stderr, providers); code != 0 || called != "google" || got.CallbackPort != 8085 {
+		t.Fatalf("google login = (%d, %q, %+v), want the browser flow on port 8085", code, called, got)
+	}
+
+	for _, args := range [][]string{nil, {"-device"}, {"unknown"}, {"google", "-device"}, {"openai", "extra"}} {
+		called = ""
+		stderr.Reset()
+		if code := runLogin(nil, LoginOptions{}, args, &stderr, providers); code != 2 || called != "" {
+			t.Fatalf("args %q: exit code = %d, called %q; want usage error", args, code, called)
+		}
+		if !strings.Contains(stderr.String(), "login <provider>") && !strings.Contains(stderr.String(), "device code") {
+			t.Fatalf("args %q: stderr = %q, want a usage message", args, stderr.String())
+		}
+	}
+}
+
+func TestRunLogin_FailedFlowExitsNonZero(t *testing.T) {
+	providers := map[string]loginProvider{
+		"claude": {browser: func(*config.Config, *LoginOptions) error { return errors.New("authentication failed") }},
+	}
+	var stderr bytes.Buffer
+	if code := runLogin(nil, LoginOptions{}, []string{"claude"}, &stderr, providers); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+}
+
+func TestIsLoginCommand(t *testing.T) {
+	if !IsLoginCommand([]string{"login", "openai"}) || IsLoginCommand([]string{"openai"}) || IsLoginCommand(nil) {
+		t.Fatal("IsLoginCommand misdetects the login subcommand")
+	}
+}
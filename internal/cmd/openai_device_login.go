@@ -18,8 +18,8 @@ const (
 )
 
 // DoCodexDeviceLogin triggers the Codex device-code flow while keeping the
-// existing codex-login OAuth callback flow intact.
-func DoCodexDeviceLogin(cfg *config.Config, options *LoginOptions) {
+// existing codex-login OAuth callback flow intact. It returns an error if the login fails.
+func DoCodexDeviceLogin(cfg *config.Config, options *LoginOptions) error {
 	if options == nil {
 		options = &LoginOptions{}
 	}
@@ -47,14 +47,15 @@ func DoCodexDeviceLogin(cfg *config.Config, options *LoginOptions) {
 			if authErr.Type == codex.ErrPortInUse.Type {
 				os.Exit(codex.ErrPortInUse.Code)
 			}
-			return
+			return err
 		}
 		fmt.Printf("Codex device authentication failed: %v\n", err)
-		return
+		return err
 	}
 
 	if savedPath != "" {
 		fmt.Printf("Authentication saved to %s\n", savedPath)
 	}
 	fmt.Println("Codex device authentication successful!")
+	return nil
 }
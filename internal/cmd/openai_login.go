@@ -33,7 +33,10 @@ type LoginOptions struct {
 // Parameters:
 //   - cfg: The application configuration
 //   - options: Login options including browser behavior and prompts
-func DoCodexLogin(cfg *config.Config, options *LoginOptions) {
+//
+// Returns:
+//   - error: An error if the login failed
+func DoCodexLogin(cfg *config.Config, options *LoginOptions) error {
 	if options == nil {
 		options = &LoginOptions{}
 	}
@@ -59,14 +62,15 @@ func DoCodexLogin(cfg *config.Config, options *LoginOptions) {
 			if authErr.Type == codex.ErrPortInUse.Type {
 				os.Exit(codex.ErrPortInUse.Code)
 			}
-			return
+			return err
 		}
 		fmt.Printf("Codex authentication failed: %v\n", err)
-		return
+		return err
 	}
 
 	if savedPath != "" {
 		fmt.Printf("Authentication saved to %s\n", savedPath)
 	}
 	fmt.Println("Codex authentication successful!")
+	return nil
 }
@@ -44,6 +44,8 @@ func StartServiceWithPluginHost(cfg *config.Config, configPath string, localPass
 
 	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+	stopWatchingUpgrades := watchUpgradeSignals(cfg, cancel)
+	defer stopWatchingUpgrades()
 
 	runCtx := ctxSignal
 	if localPassword != "" {
@@ -0,0 +1,368 @@
+// Package cmd contains CLI helpers. This file implements the -selftest command, which
+// validates a deployment without starting the proxy and prints a pass/fail report.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/health"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/safemode"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/synthesizer"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// selfTestProbeTimeout bounds the credential probe round.
+const selfTestProbeTimeout = 30 * time.Second
+
+type selfTestStatus string
+
+const (
+	selfTestPass selfTestStatus = "PASS"
+	selfTestWarn selfTestStatus = "WARN"
+	selfTestFail selfTestStatus = "FAIL"
+	selfTestSkip selfTestStatus = "SKIP"
+)
+
+var selfTestColors = map[selfTestStatus]string{
+	selfTestPass: "\033[32m",
+	selfTestWarn: "\033[33m",
+	selfTestFail: "\033[31m",
+	selfTestSkip: "\033[90m",
+}
+
+type selfTestCheck struct {
+	section string
+	name    string
+	status  selfTestStatus
+	detail  string
+}
+
+type selfTestReport struct {
+	checks []selfTestCheck
+}
+
+func (r *selfTestReport) add(section, name string, status selfTestStatus, detail string) {
+	r.checks = append(r.checks, selfTestCheck{section: section, name: name, status: status, detail: detail})
+}
+
+func (r *selfTestReport) failed() bool {
+	for _, check := range r.checks {
+		if check.status == selfTestFail {
+			return true
+		}
+	}
+	return false
+}
+
+// write prints the report grouped by section, followed by a summary line.
+func (r *selfTestReport) write(w io.Writer, color bool) {
+	counts := make(map[selfTestStatus]int)
+	section := ""
+	for _, check := range r.checks {
+		if check.section != section {
+			section = check.section
+			_, _ = fmt.Fprintf(w, "\n%s\n", section)
+		}
+		counts[check.status]++
+		status := string(check.status)
+		if color {
+			status = selfTestColors[check.status] + status + "\033[0m"
+		}
+		line := fmt.Sprintf("  [%s] %s", status, check.name)
+		if check.detail != "" {
+			line += ": " + check.detail
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+	_, _ = fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed, %d skipped\n",
+		counts[selfTestPass], counts[selfTestWarn], counts[selfTestFail], counts[selfTestSkip])
+}
+
+// DoSelfTest validates the loaded configuration, probes every credential with a minimal
+// authenticated request and checks that routing rules reference configured providers.
+// It prints a color-coded report to stdout and returns the process exit code: 1 when
+// any check failed, 0 otherwise.
+func DoSelfTest(cfg *config.Config, configPath string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestProbeTimeout)
+	defer cancel()
+	report := runSelfTest(ctx, cfg, configPath)
+	report.write(os.Stdout, selfTestColorEnabled())
+	if report.failed() {
+		return 1
+	}
+	return 0
+}
+
+func runSelfTest(ctx context.Context, cfg *config.Config, configPath string) *selfTestReport {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	report := &selfTestReport{}
+	checkSelfTestConfig(report, cfg, configPath)
+	auths := selfTestAuths(cfg)
+	checkSelfTestCredentials(ctx, report, cfg, auths)
+	checkSelfTestRouting(report, cfg, auths)
+	return report
+}
+
+func checkSelfTestConfig(report *selfTestReport, cfg *config.Config, configPath string) {
+	const section = "Configuration"
+	if info, errStat := os.Stat(configPath); errStat != nil {
+		report.add(section, "config file", selfTestFail, errStat.Error())
+	} else if info.IsDir() {
+		report.add(section, "config file", selfTestFail, configPath+" is a directory")
+	} else {
+		report.add(section, "config file", selfTestPass, configPath)
+	}
+
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		report.add(section, "port", selfTestFail, fmt.Sprintf("invalid port %d", cfg.Port))
+	} else {
+		report.add(section, "port", selfTestPass, fmt.Sprintf("%d", cfg.Port))
+	}
+
+	switch {
+	case len(safemode.ExampleAPIKeys(cfg.APIKeys)) > 0:
+		report.add(section, "api-keys", selfTestFail, "example API keys are configured; proxy endpoints stay disabled until they are replaced")
+	case len(cfg.APIKeys) == 0 && len(cfg.Tenants) == 0:
+		report.add(section, "api-keys", selfTestWarn, "no client API keys configured")
+	case len(cfg.APIKeys) == 0:
+		report.add(section, "api-keys", selfTestPass, fmt.Sprintf("%d tenants configured", len(cfg.Tenants)))
+	default:
+		report.add(section, "api-keys", selfTestPass, fmt.Sprintf("%d configured", len(cfg.APIKeys)))
+	}
+
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil {
+		report.add(section, "auth-dir", selfTestFail, errResolve.Error())
+	} else if errWritable := selfTestDirWritable(authDir); errWritable != nil {
+		report.add(section, "auth-dir", selfTestFail, errWritable.Error())
+	} else {
+		report.add(section, "auth-dir", selfTestPass, authDir)
+	}
+
+	if cfg.TLS.Enable {
+		missing := make([]string, 0, 2)
+		for _, path := range []string{cfg.TLS.Cert, cfg.TLS.Key} {
+			if _, errStat := os.Stat(path); strings.TrimSpace(path) == "" || errStat != nil {
+				missing = append(missing, fmt.Sprintf("%q", path))
+			}
+		}
+		if len(missing) > 0 {
+			report.add(section, "tls", selfTestFail, "missing certificate files: "+strings.Join(missing, ", "))
+		} else {
+			report.add(section, "tls", selfTestPass, "certificate and key found")
+		}
+	}
+}
+
+func selfTestDirWritable(dir string) error {
+	info, errStat := os.Stat(dir)
+	if errStat != nil {
+		return errStat
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	probe, errCreate := os.CreateTemp(dir, ".selftest-*")
+	if errCreate != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, errCreate)
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	return nil
+}
+
+// selfTestAuths synthesizes the credentials the server would load from config and the auth directory.
+func selfTestAuths(cfg *config.Config) []*coreauth.Auth {
+	authDir, _ := util.ResolveAuthDir(cfg.AuthDir)
+	synthCtx := &synthesizer.SynthesisContext{
+		Config:      cfg,
+		AuthDir:     authDir,
+		Now:         time.Now(),
+		IDGenerator: synthesizer.NewStableIDGenerator(),
+	}
+	var auths []*coreauth.Auth
+	if fromConfig, errConfig := synthesizer.NewConfigSynthesizer().Synthesize(synthCtx); errConfig == nil {
+		auths = append(auths, fromConfig...)
+	}
+	if fromFiles, errFiles := synthesizer.NewFileSynthesizer().Synthesize(synthCtx); errFiles == nil {
+		auths = append(auths, fromFiles...)
+	}
+	return auths
+}
+
+// selfTestProviderKey returns the executor key used for auth, matching the auth manager's lookup.
+func selfTestProviderKey(auth *coreauth.Auth) string {
+	if auth.Attributes != nil {
+		if compatName := strings.TrimSpace(auth.Attributes["compat_name"]); compatName != "" {
+			if providerKey := strings.TrimSpace(auth.Attributes["provider_key"]); providerKey != "" {
+				compatName = providerKey
+			}
+			return util.OpenAICompatibleProviderKey(compatName)
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(auth.Provider))
+}
+
+// selfTestExecutor returns an executor able to sign probe requests for auth.
+// Only API key providers are probed, so other providers return nil.
+func selfTestExecutor(cfg *config.Config, auth *coreauth.Auth) coreauth.ProviderExecutor {
+	providerKey := selfTestProviderKey(auth)
+	if auth.Attributes != nil && strings.TrimSpace(auth.Attributes["compat_name"]) != "" {
+		return executor.NewOpenAICompatExecutor(providerKey, cfg)
+	}
+	switch providerKey {
+	case "claude":
+		return executor.NewClaudeExecutor(cfg)
+	case "gemini":
+		return executor.NewGeminiExecutor(cfg)
+	case "codex":
+		return executor.NewCodexAutoExecutor(cfg)
+	case "xai":
+		return executor.NewXAIAutoExecutor(cfg)
+	case "openai-compatibility":
+		return executor.NewOpenAICompatExecutor(providerKey, cfg)
+	default:
+		return nil
+	}
+}
+
+func checkSelfTestCredentials(ctx context.Context, report *selfTestReport, cfg *config.Config, auths []*coreauth.Auth) {
+	const section = "Credentials"
+	if len(auths) == 0 {
+		report.add(section, "credentials", selfTestWarn, "no credentials configured")
+		return
+	}
+	manager := coreauth.NewManager(nil, nil, nil)
+	registered := make(map[string]struct{})
+	for _, auth := range auths {
+		if providerKey := selfTestProviderKey(auth); providerKey != "" {
+			if _, done := registered[providerKey]; !done {
+				if exec := selfTestExecutor(cfg, auth); exec != nil {
+					manager.RegisterExecutor(exec)
+				}
+				registered[providerKey] = struct{}{}
+			}
+		}
+		if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+			report.add(section, auth.ID, selfTestFail, errRegister.Error())
+		}
+	}
+
+	prober := health.NewProber(manager)
+	prober.ProbeAll(ctx)
+	for _, entry := range prober.Snapshot() {
+		name := entry.Provider
+		if entry.Label != "" {
+			name += " " + entry.Label
+		}
+		name += " (" + entry.AuthIndex + ")"
+		switch {
+		case entry.State == health.StateDisabled:
+			report.add(section, name, selfTestSkip, "disabled")
+		case entry.State == health.StateUnhealthy:
+			report.add(section, name, selfTestFail, selfTestHealthDetail(entry))
+		case entry.Reachable != nil && entry.StatusCode == 0:
+			report.add(section, name, selfTestPass, fmt.Sprintf("token refreshed in %dms", entry.LatencyMs))
+		case entry.Reachable != nil:
+			report.add(section, name, selfTestPass, fmt.Sprintf("HTTP %d in %dms", entry.StatusCode, entry.LatencyMs))
+		case entry.AuthValid:
+			report.add(section, name, selfTestWarn, "not probed; no model-list endpoint for this credential type")
+		default:
+			report.add(section, name, selfTestFail, selfTestHealthDetail(entry))
+		}
+	}
+}
+
+func selfTestHealthDetail(entry health.ProviderHealth) string {
+	detail := entry.Error
+	if entry.StatusCode != 0 {
+		detail = strings.TrimSpace(fmt.Sprintf("HTTP %d %s", entry.StatusCode, detail))
+	}
+	if detail == "" {
+		detail = "credential unavailable"
+	}
+	return detail
+}
+
+// checkSelfTestRouting verifies that model-aliases and model-failover only reference providers
+// that have at least one enabled credential.
+func checkSelfTestRouting(report *selfTestReport, cfg *config.Config, auths []*coreauth.Auth) {
+	const section = "Routing"
+	providers := make(map[string]struct{})
+	for _, auth := range auths {
+		if auth.Disabled {
+			continue
+		}
+		providers[selfTestProviderKey(auth)] = struct{}{}
+		if compatName := strings.ToLower(strings.TrimSpace(auth.Attributes["compat_name"])); compatName != "" {
+			providers[compatName] = struct{}{}
+		}
+	}
+	hasProvider := func(provider string) bool {
+		if _, ok := providers[provider]; ok {
+			return true
+		}
+		_, ok := providers[util.OpenAICompatibleProviderKey(provider)]
+		return ok
+	}
+
+	checked := 0
+	for _, rule := range cfg.ModelAliases {
+		if rule.Provider == "" {
+			continue
+		}
+		checked++
+		pattern := rule.Match
+		if pattern == "" {
+			pattern = rule.Regex
+		}
+		name := fmt.Sprintf("model-aliases %s -> %s", pattern, rule.Model)
+		if hasProvider(rule.Provider) {
+			report.add(section, name, selfTestPass, "provider "+rule.Provider)
+		} else {
+			report.add(section, name, selfTestFail, "no enabled credential for provider "+rule.Provider)
+		}
+	}
+	for _, rule := range cfg.ModelFailover {
+		for _, target := range rule.Targets {
+			if target.Provider == "" {
+				continue
+			}
+			checked++
+			name := fmt.Sprintf("model-failover %s -> %s", rule.Alias, target.Model)
+			if hasProvider(target.Provider) {
+				report.add(section, name, selfTestPass, "provider "+target.Provider)
+			} else {
+				report.add(section, name, selfTestFail, "no enabled credential for provider "+target.Provider)
+			}
+		}
+	}
+	for _, compat := range cfg.OpenAICompatibility {
+		if compat.Disabled || len(compat.Models) > 0 {
+			continue
+		}
+		checked++
+		report.add(section, "openai-compatibility "+compat.Name, selfTestWarn, "no models declared; only models reported by the upstream are routable")
+	}
+	if checked == 0 {
+		report.add(section, "routing rules", selfTestSkip, "no provider-pinned aliases or failover targets")
+	}
+}
+
+func selfTestColorEnabled() bool {
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor {
+		return false
+	}
+	info, errStat := os.Stdout.Stat()
+	return errStat == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestRunSelfTestReportsCredentialAndRoutingResults(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if errWrite := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); errWrite != nil {
+		t.Fatalf("write config: %v", errWrite)
+	}
+	cfg := &config.Config{
+		Port:    8317,
+		AuthDir: filepath.Join(dir, "auths"),
+		SDKConfig: config.SDKConfig{
+			APIKeys: []string{"client-key"},
+			ModelAliases: []config.ModelAliasRule{
+				{Match: "gpt-4o", Model: "gpt-5", Provider: "good"},
+				{Match: "sonnet", Model: "claude-sonnet-4-5", Provider: "claude"},
+			},
+		},
+		OpenAICompatibility: []config.OpenAICompatibility{
+			{Name: "good", BaseURL: upstream.URL, APIKeyEntries: []config.OpenAICompatibilityAPIKey{{APIKey: "good-key"}}, Models: []config.OpenAICompatibilityModel{{Name: "gpt-5"}}},
+			{Name: "bad", BaseURL: upstream.URL, APIKeyEntries: []config.OpenAICompatibilityAPIKey{{APIKey: "bad-key"}}, Models: []config.OpenAICompatibilityModel{{Name: "gpt-5"}}},
+		},
+	}
+	if errMkdir := os.MkdirAll(cfg.AuthDir, 0o700); errMkdir != nil {
+		t.Fatalf("create auth dir: %v", errMkdir)
+	}
+
+	report := runSelfTest(context.Background(), cfg, configPath)
+
+	var out strings.Builder
+	report.write(&out, false)
+	text := out.String()
+	for _, want := range []string{
+		"[PASS] config file",
+		"[PASS] api-keys",
+		"[PASS] good",
+		"[FAIL] bad",
+		"[PASS] model-aliases gpt-4o -> gpt-5: provider good",
+		"[FAIL] model-aliases sonnet -> claude-sonnet-4-5: no enabled credential for provider claude",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("report missing %q:\n%s", want, text)
+		}
+	}
+	if !report.failed() {
+		t.Fatal("report should fail when a credential is rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/upgrade"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchUpgradeSignals starts a replacement process on SIGUSR2 when shutdown.reuse-port is
+// enabled, and calls stop once the replacement listens so this process drains and exits.
+// The returned function stops watching.
+func watchUpgradeSignals(cfg *config.Config, stop context.CancelFunc) func() {
+	signals := upgrade.Signals()
+	if len(signals) == 0 {
+		return func() {}
+	}
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, signals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signalCh:
+			}
+			if cfg == nil || !cfg.Shutdown.ReusePort {
+				log.Warn("upgrade signal ignored: shutdown.reuse-port is disabled")
+				continue
+			}
+			log.Info("upgrade requested, starting new process")
+			process, errSpawn := upgrade.Spawn(context.Background(), upgrade.DefaultReadyTimeout)
+			if errSpawn != nil {
+				log.Errorf("upgrade failed, keeping the current process: %v", errSpawn)
+				continue
+			}
+			log.Infof("new process %d is listening, draining this one", process.Pid)
+			stop()
+			return
+		}
+	}()
+	return func() {
+		signal.Stop(signalCh)
+		close(done)
+	}
+}
@@ -10,7 +10,8 @@ import (
 )
 
 // DoXAILogin triggers the OAuth device-code flow for the xAI provider and saves tokens.
-func DoXAILogin(cfg *config.Config, options *LoginOptions) {
+// It returns an error if the login fails.
+func DoXAILogin(cfg *config.Config, options *LoginOptions) error {
 	if options == nil {
 		options = &LoginOptions{}
 	}
@@ -31,7 +32,7 @@ func DoXAILogin(cfg *config.Config, options *LoginOptions) {
 	record, savedPath, err := manager.Login(context.Background(), "xai", cfg, authOpts)
 	if err != nil {
 		log.Errorf("xAI authentication failed: %v", err)
-		return
+		return err
 	}
 
 	if savedPath != "" {
@@ -41,4 +42,5 @@ func DoXAILogin(cfg *config.Config, options *LoginOptions) {
 		fmt.Printf("Authenticated as %s\n", record.Label)
 	}
 	fmt.Println("xAI authentication successful!")
+	return nil
 }
@@ -0,0 +1,13 @@
+package config
+
+// AccessScope restricts what an authenticated client may do. Scoped identities (OIDC scopes,
+// client certificate identities and tenants) embed it; the zero value allows everything.
+type AccessScope struct {
+	// AllowedProviders restricts routing to these providers; empty allows every provider.
+	AllowedProviders []string `yaml:"allowed-providers,omitempty" json:"allowed-providers,omitempty"`
+	// AllowedModels restricts requests to models matching these patterns ('*' wildcard);
+	// empty allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+	// ReadOnly limits the client to model listing.
+	ReadOnly bool `yaml:"read-only,omitempty" json:"read-only,omitempty"`
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAccessScopeLoadsInline(t *testing.T) {
+	var cfg Config
+	raw := `
+tenants:
+  - name: acme
+    allowed-providers: [claude]
+    allowed-models: ["claude-*"]
+    read-only: true
+oidc-auth:
+  scopes:
+    - claim: groups
+      value: ml
+      allowed-models: ["gpt-*"]
+tls:
+  client-auth:
+    identities:
+      - match: batch.internal
+        read-only: true
+`
+	if errUnmarshal := yaml.Unmarshal([]byte(raw), &cfg); errUnmarshal != nil {
+		t.Fatalf("unmarshal: %v", errUnmarshal)
+	}
+	tenant := cfg.Tenants[0].AccessScope
+	if len(tenant.AllowedProviders) != 1 || tenant.AllowedProviders[0] != "claude" || len(tenant.AllowedModels) != 1 || !tenant.ReadOnly {
+		t.Fatalf("tenant scope = %+v", tenant)
+	}
+	if scope := cfg.OIDCAuth.Scopes[0].AccessScope; len(scope.AllowedModels) != 1 || scope.AllowedModels[0] != "gpt-*" {
+		t.Fatalf("oidc scope = %+v", scope)
+	}
+	if scope := cfg.TLS.ClientAuth.Identities[0].AccessScope; !scope.ReadOnly {
+		t.Fatalf("identity scope = %+v", scope)
+	}
+}
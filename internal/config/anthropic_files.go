@@ -0,0 +1,46 @@
+package config
+
+import "strings"
+
+// DefaultAnthropicFilesDir is the directory name used for Anthropic file uploads when
+// anthropic-files.dir is unset. It is placed inside auth-dir.
+const DefaultAnthropicFilesDir = "anthropic-files"
+
+// Anthropic Files API modes.
+const (
+	// AnthropicFilesModeLocal stores uploads locally and inlines them into /v1/messages.
+	AnthropicFilesModeLocal = "local"
+	// AnthropicFilesModePassthrough forwards file requests to a Claude API key credential.
+	AnthropicFilesModePassthrough = "passthrough"
+)
+
+const defaultAnthropicFilesMaxFileSizeMB = 32
+
+// AnthropicFilesConfig configures the Anthropic-flavored /v1/files endpoints.
+type AnthropicFilesConfig struct {
+	// Enable serves /v1/files for Anthropic clients (requests carrying Anthropic-Version).
+	Enable bool `yaml:"enable" json:"enable"`
+	// Mode is "local" (default) or "passthrough".
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// Dir stores uploads in local mode and file ownership records in passthrough mode.
+	// Default: <auth-dir>/anthropic-files.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// MaxFileSizeMB rejects larger uploads. Default: 32.
+	MaxFileSizeMB int `yaml:"max-file-size-mb,omitempty" json:"max-file-size-mb,omitempty"`
+}
+
+// SanitizeAnthropicFiles normalizes the mode and applies defaults.
+func (cfg *Config) SanitizeAnthropicFiles() {
+	if cfg == nil {
+		return
+	}
+	f := &cfg.AnthropicFiles
+	f.Dir = strings.TrimSpace(f.Dir)
+	f.Mode = strings.ToLower(strings.TrimSpace(f.Mode))
+	if f.Mode != AnthropicFilesModePassthrough {
+		f.Mode = AnthropicFilesModeLocal
+	}
+	if f.MaxFileSizeMB <= 0 {
+		f.MaxFileSizeMB = defaultAnthropicFilesMaxFileSizeMB
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import "strings"
+
+const (
+	// DefaultAzureOpenAIAPIVersion is the api-version query value used when an entry does not set one.
+	DefaultAzureOpenAIAPIVersion = "2024-10-21"
+
+	// DefaultAzureAuthorityHost is the Microsoft Entra ID authority used for client credential grants.
+	DefaultAzureAuthorityHost = "https://login.microsoftonline.com"
+)
+
+// AzureOpenAIKey configures an Azure OpenAI resource. Requests authenticate with the
+// resource api-key when set, otherwise with a Microsoft Entra ID token obtained through
+// the client credentials grant of TenantID/ClientID/ClientSecret.
+type AzureOpenAIKey struct {
+	// APIKey is the resource key sent in the api-key header.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Endpoint is the resource endpoint, e.g. "https://my-resource.openai.azure.com".
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+
+	// APIVersion is sent as the api-version query parameter; defaults to DefaultAzureOpenAIAPIVersion.
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// TenantID is the Entra ID tenant of the service principal.
+	TenantID string `yaml:"tenant-id,omitempty" json:"tenant-id,omitempty"`
+
+	// ClientID is the application (client) ID of the service principal.
+	ClientID string `yaml:"client-id,omitempty" json:"client-id,omitempty"`
+
+	// ClientSecret is the client secret of the service principal.
+	ClientSecret string `yaml:"client-secret,omitempty" json:"client-secret,omitempty"`
+
+	// AuthorityHost overrides the Entra ID authority, e.g. for sovereign clouds.
+	AuthorityHost string `yaml:"authority-host,omitempty" json:"authority-host,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "azure/gpt-4o").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps deployment names (name) to the model names clients request (alias).
+	// Without mappings the requested model name is used as the deployment name.
+	Models []AzureOpenAIModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// DisableCooling disables auth/model cooldown scheduling for this credential when true.
+	DisableCooling bool `yaml:"disable-cooling,omitempty" json:"disable-cooling,omitempty"`
+}
+
+// GetAPIKey returns the credential identity: the resource key or the Entra ID client ID.
+func (k AzureOpenAIKey) GetAPIKey() string {
+	if key := strings.TrimSpace(k.APIKey); key != "" {
+		return key
+	}
+	return k.ClientID
+}
+
+func (k AzureOpenAIKey) GetBaseURL() string { return k.Endpoint }
+
+// UsesEntraID reports whether the entry authenticates with an Entra ID service principal.
+func (k AzureOpenAIKey) UsesEntraID() bool {
+	return strings.TrimSpace(k.APIKey) == "" && k.TenantID != "" && k.ClientID != "" && k.ClientSecret != ""
+}
+
+// AzureOpenAIModel maps a deployment name (Name) to a client-facing model name (Alias).
+type AzureOpenAIModel = ClaudeModel
+
+// SanitizeAzureOpenAIKeys trims Azure OpenAI entries, applies defaults and drops entries
+// without an endpoint or without either an api-key or a complete service principal.
+func (cfg *Config) SanitizeAzureOpenAIKeys() {
+	if cfg == nil || len(cfg.AzureOpenAIKey) == 0 {
+		return
+	}
+	out := cfg.AzureOpenAIKey[:0]
+	for i := range cfg.AzureOpenAIKey {
+		entry := cfg.AzureOpenAIKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.Endpoint = strings.TrimSuffix(strings.TrimSpace(entry.Endpoint), "/")
+		entry.APIVersion = strings.TrimSpace(entry.APIVersion)
+		if entry.APIVersion == "" {
+			entry.APIVersion = DefaultAzureOpenAIAPIVersion
+		}
+		entry.TenantID = strings.TrimSpace(entry.TenantID)
+		entry.ClientID = strings.TrimSpace(entry.ClientID)
+		entry.ClientSecret = strings.TrimSpace(entry.ClientSecret)
+		entry.AuthorityHost = strings.TrimSuffix(strings.TrimSpace(entry.AuthorityHost), "/")
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		if entry.Endpoint == "" || (entry.APIKey == "" && !entry.UsesEntraID()) {
+			continue
+		}
+		out = append(out, entry)
+	}
+	cfg.AzureOpenAIKey = out
+}
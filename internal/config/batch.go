@@ -0,0 +1,45 @@
+package config
+
+import "strings"
+
+// DefaultBatchDir is the directory name used for batch files when batch.dir is unset.
+// It is placed inside auth-dir.
+const DefaultBatchDir = "batches"
+
+const (
+	defaultBatchConcurrency   = 4
+	defaultBatchMaxFileSizeMB = 100
+	defaultBatchMaxRequests   = 50000
+)
+
+// BatchConfig configures the emulated OpenAI Batch API (/v1/files and /v1/batches).
+type BatchConfig struct {
+	// Enable exposes the batch and file endpoints.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Dir stores uploaded files, result files and batch state. Default: <auth-dir>/batches.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Concurrency caps the batch requests executed at the same time across all batches. Default: 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	// MaxFileSizeMB rejects larger uploads. Default: 100.
+	MaxFileSizeMB int `yaml:"max-file-size-mb,omitempty" json:"max-file-size-mb,omitempty"`
+	// MaxRequests caps the number of requests in one batch input file. Default: 50000.
+	MaxRequests int `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+}
+
+// SanitizeBatch applies batch defaults.
+func (cfg *Config) SanitizeBatch() {
+	if cfg == nil {
+		return
+	}
+	b := &cfg.Batch
+	b.Dir = strings.TrimSpace(b.Dir)
+	if b.Concurrency <= 0 {
+		b.Concurrency = defaultBatchConcurrency
+	}
+	if b.MaxFileSizeMB <= 0 {
+		b.MaxFileSizeMB = defaultBatchMaxFileSizeMB
+	}
+	if b.MaxRequests <= 0 {
+		b.MaxRequests = defaultBatchMaxRequests
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import "strings"
+
+// DefaultBedrockRegion is the AWS region used when a bedrock-api-key entry does not set one.
+const DefaultBedrockRegion = "us-east-1"
+
+// BedrockKey configures an AWS Bedrock credential. Requests authenticate with the Bedrock
+// API key when set, otherwise they are signed with SigV4 using the IAM access key pair.
+type BedrockKey struct {
+	// APIKey is a Bedrock API key sent as a bearer token.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// AccessKeyID is the IAM access key ID used for SigV4 signing.
+	AccessKeyID string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+
+	// SecretAccessKey is the IAM secret access key used for SigV4 signing.
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+
+	// SessionToken is the optional STS session token for temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// AWSRegion selects the bedrock-runtime endpoint region; defaults to us-east-1.
+	AWSRegion string `yaml:"aws-region,omitempty" json:"aws-region,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "aws/claude-sonnet-4-5").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL overrides the bedrock-runtime endpoint, e.g. for a VPC interface endpoint.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps Bedrock model IDs or inference profile IDs to client-facing aliases.
+	Models []BedrockModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// DisableCooling disables auth/model cooldown scheduling for this credential when true.
+	DisableCooling bool `yaml:"disable-cooling,omitempty" json:"disable-cooling,omitempty"`
+}
+
+// GetAPIKey returns the credential identity: the Bedrock API key or the IAM access key ID.
+func (k BedrockKey) GetAPIKey() string {
+	if key := strings.TrimSpace(k.APIKey); key != "" {
+		return key
+	}
+	return k.AccessKeyID
+}
+
+func (k BedrockKey) GetBaseURL() string { return k.BaseURL }
+
+// BedrockModel uses the Claude model mapping structure for Bedrock models.
+type BedrockModel = ClaudeModel
+
+// SanitizeBedrockKeys trims Bedrock credentials, applies the default region and drops
+// entries that have neither an API key nor a complete IAM access key pair.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil || len(cfg.BedrockKey) == 0 {
+		return
+	}
+	out := cfg.BedrockKey[:0]
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+		entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+		entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+		entry.AWSRegion = strings.ToLower(strings.TrimSpace(entry.AWSRegion))
+		if entry.AWSRegion == "" {
+			entry.AWSRegion = DefaultBedrockRegion
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.BaseURL = strings.TrimSpace(entry.BaseURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		if entry.APIKey == "" && (entry.AccessKeyID == "" || entry.SecretAccessKey == "") {
+			continue
+		}
+		out = append(out, entry)
+	}
+	cfg.BedrockKey = out
+}
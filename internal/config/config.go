@@ -65,6 +65,9 @@ type Config struct {
 	// CommercialMode disables high-overhead request logging and HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
+	// VersionHeader adds X-CLIProxy-Version ("<version> (<commit>)") to every response.
+	VersionHeader bool `yaml:"version-header,omitempty" json:"version-header,omitempty"`
+
 	// LoggingToFile controls whether application logs are written to rotating files or stdout.
 	LoggingToFile bool `yaml:"logging-to-file" json:"logging-to-file"`
 
@@ -76,6 +79,9 @@ type Config struct {
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// LogOutput configures the application log format, rotation and separate error log.
+	LogOutput LogOutputConfig `yaml:"log-output" json:"log-output"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
@@ -112,6 +118,91 @@ type Config struct {
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// HealthCheck configures the background provider health prober.
+	HealthCheck HealthCheckConfig `yaml:"health-check" json:"health-check"`
+
+	// RateLimit configures per-client-API-key token-bucket request limits.
+	RateLimit RateLimitConfig `yaml:"rate-limit" json:"rate-limit"`
+
+	// RequestQueue configures priority scheduling of requests in front of upstream execution.
+	RequestQueue RequestQueueConfig `yaml:"request-queue" json:"request-queue"`
+
+	// ResponseCache configures caching of deterministic completions and model lists.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache" json:"response-cache"`
+
+	// TLSFingerprint configures per-provider uTLS client hello and HTTP/2 settings profiles.
+	TLSFingerprint TLSFingerprintConfig `yaml:"tls-fingerprint" json:"tls-fingerprint"`
+
+	// ProviderProxies overrides the global proxy-url per provider.
+	ProviderProxies []ProviderProxy `yaml:"provider-proxies,omitempty" json:"provider-proxies,omitempty"`
+
+	// UsageAccounting configures per-client-API-key token accounting and monthly quotas.
+	UsageAccounting UsageAccountingConfig `yaml:"usage-accounting" json:"usage-accounting"`
+
+	// Transcripts tees streamed completions into a store keyed by conversation.
+	Transcripts TranscriptsConfig `yaml:"transcripts" json:"transcripts"`
+
+	// Batch configures the emulated OpenAI Batch API.
+	Batch BatchConfig `yaml:"batch" json:"batch"`
+
+	// AnthropicFiles serves the Anthropic Files API for Claude clients.
+	AnthropicFiles AnthropicFilesConfig `yaml:"anthropic-files" json:"anthropic-files"`
+
+	// ContentEncryption encrypts stored request content with per-object data keys.
+	ContentEncryption ContentEncryptionConfig `yaml:"content-encryption" json:"content-encryption"`
+
+	// CredentialEncryption encrypts the refresh tokens stored in OAuth credential files.
+	CredentialEncryption CredentialEncryptionConfig `yaml:"credential-encryption" json:"credential-encryption"`
+
+	// WasmFilters rewrites API requests and responses with WebAssembly modules.
+	WasmFilters []WasmFilter `yaml:"wasm-filters,omitempty" json:"wasm-filters,omitempty"`
+
+	// Vision fetches remote images and downscales oversized images for upstream providers.
+	Vision VisionConfig `yaml:"vision" json:"vision"`
+
+	// RegionRouting probes the regional endpoints of credentials and selects the fastest healthy one.
+	RegionRouting RegionRoutingConfig `yaml:"region-routing" json:"region-routing"`
+
+	// Idempotency replays stored responses for retried requests carrying an Idempotency-Key header.
+	Idempotency IdempotencyConfig `yaml:"idempotency" json:"idempotency"`
+
+	// StreamResume lets clients resume interrupted SSE streams with Last-Event-ID.
+	StreamResume StreamResumeConfig `yaml:"stream-resume" json:"stream-resume"`
+
+	// ResponsesStore keeps OpenAI Responses API state for previous_response_id chaining and
+	// background responses.
+	ResponsesStore ResponsesStoreConfig `yaml:"responses-store" json:"responses-store"`
+
+	// SharedState shares rate limits, quota counters and stored responses between instances.
+	SharedState SharedStateConfig `yaml:"shared-state" json:"shared-state"`
+
+	// SigningAudit records which credential signed each upstream request.
+	SigningAudit SigningAuditConfig `yaml:"signing-audit" json:"signing-audit"`
+
+	// LifecycleHooks delivers lifecycle events to a script or webhook.
+	LifecycleHooks LifecycleHooksConfig `yaml:"lifecycle-hooks" json:"lifecycle-hooks"`
+
+	// GRPC serves the chat completions API over gRPC on a separate port.
+	GRPC GRPCConfig `yaml:"grpc" json:"grpc"`
+
+	// Shutdown controls graceful draining and SO_REUSEPORT binary upgrades.
+	Shutdown ShutdownConfig `yaml:"shutdown" json:"shutdown"`
+
+	// Listen adds a Unix domain socket and systemd socket activation to the TCP listener.
+	Listen ListenConfig `yaml:"listen" json:"listen"`
+
+	// HTTP2 configures HTTP/2 and h2c on the listener and upstream connection reuse.
+	HTTP2 HTTP2Config `yaml:"http2" json:"http2"`
+
+	// Scheduler runs background jobs on cron schedules and exposes them in the management API.
+	Scheduler SchedulerConfig `yaml:"scheduler" json:"scheduler"`
+
+	// ModelPricing sets per-model token prices used to estimate request cost.
+	ModelPricing []ModelPricingEntry `yaml:"model-pricing,omitempty" json:"model-pricing,omitempty"`
+
+	// ModelCatalog syncs model definitions from a custom remote catalog and a local override file.
+	ModelCatalog ModelCatalogConfig `yaml:"model-catalog,omitempty" json:"model-catalog,omitempty"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -134,6 +225,21 @@ type Config struct {
 	// XAIKey defines xAI API key configurations using the same structure as Codex API keys.
 	XAIKey []XAIKey `yaml:"xai-api-key" json:"xai-api-key"`
 
+	// BedrockKey defines AWS Bedrock credentials using a Bedrock API key or IAM access keys.
+	BedrockKey []BedrockKey `yaml:"bedrock-api-key,omitempty" json:"bedrock-api-key,omitempty"`
+
+	// AzureOpenAIKey defines Azure OpenAI resources using an api-key or an Entra ID service principal.
+	AzureOpenAIKey []AzureOpenAIKey `yaml:"azure-openai-api-key,omitempty" json:"azure-openai-api-key,omitempty"`
+
+	// MistralKey defines Mistral AI API keys, including Codestral endpoint keys.
+	MistralKey []MistralKey `yaml:"mistral-api-key,omitempty" json:"mistral-api-key,omitempty"`
+
+	// DeepSeekKey defines DeepSeek API keys.
+	DeepSeekKey []DeepSeekKey `yaml:"deepseek-api-key,omitempty" json:"deepseek-api-key,omitempty"`
+
+	// CohereKey defines Cohere API keys.
+	CohereKey []CohereKey `yaml:"cohere-api-key,omitempty" json:"cohere-api-key,omitempty"`
+
 	// Codex configures provider-wide Codex request behavior.
 	Codex CodexConfig `yaml:"codex" json:"codex"`
 
@@ -163,6 +269,10 @@ type Config struct {
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
 
+	// VertexServiceAccount defines Vertex AI credentials backed by a credentials file or
+	// Application Default Credentials (workload identity).
+	VertexServiceAccount []VertexServiceAccount `yaml:"vertex-service-account,omitempty" json:"vertex-service-account,omitempty"`
+
 	// OAuthExcludedModels defines per-provider global model exclusions applied to OAuth/file-backed auth entries.
 	OAuthExcludedModels map[string][]string `yaml:"oauth-excluded-models,omitempty" json:"oauth-excluded-models,omitempty"`
 
@@ -303,6 +413,10 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// ACME obtains and renews the certificate automatically; Cert and Key are then unused.
+	ACME TLSACMEConfig `yaml:"acme,omitempty" json:"acme,omitempty"`
+	// ClientAuth verifies client certificates (mTLS) and maps their identities to access scopes.
+	ClientAuth TLSClientAuthConfig `yaml:"client-auth,omitempty" json:"client-auth,omitempty"`
 }
 
 // PprofConfig holds pprof HTTP server settings.
@@ -327,6 +441,9 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// TrashRetentionHours controls how long resources deleted through the management API stay restorable.
+	// Zero uses the default of 168 hours (7 days); a negative value disables soft-delete.
+	TrashRetentionHours int `yaml:"trash-retention-hours,omitempty"`
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -360,6 +477,15 @@ type RoutingConfig struct {
 	// SessionAffinityTTL specifies how long session-to-auth bindings are retained.
 	// Default: 1h. Accepts duration strings like "30m", "1h", "2h30m".
 	SessionAffinityTTL string `yaml:"session-affinity-ttl,omitempty" json:"session-affinity-ttl,omitempty"`
+
+	// SessionAffinityHeader names an extra client header carrying a conversation or thread ID
+	// (e.g. "X-Conversation-ID"). It is checked right after X-Session-ID; its value is hashed.
+	SessionAffinityHeader string `yaml:"session-affinity-header,omitempty" json:"session-affinity-header,omitempty"`
+
+	// MaxConcurrency caps local in-flight requests per upstream credential. When every eligible
+	// credential is saturated the request is rejected with 429 (or retried per request-retry).
+	// 0 disables the limit. Individual API key entries may override it with max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -461,6 +587,9 @@ type ClaudeKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
@@ -468,6 +597,13 @@ type ClaudeKey struct {
 	// If empty, the default Claude API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// Regions lists alternative regional base URLs. With region-routing enabled, requests use
+	// the fastest healthy region instead of base-url.
+	Regions []RegionEndpoint `yaml:"regions,omitempty" json:"regions,omitempty"`
+
+	// Region pins the credential to the named region, bypassing latency-based selection.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
@@ -477,6 +613,10 @@ type ClaudeKey struct {
 	// Headers optionally adds extra HTTP headers for requests sent with this key.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 
+	// Workspace labels the Anthropic workspace the key belongs to. Anthropic keys are already
+	// bound to one workspace, so it is only used for usage attribution.
+	Workspace string `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
 
@@ -528,6 +668,9 @@ type CodexKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-5-codex").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
@@ -535,6 +678,13 @@ type CodexKey struct {
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// Regions lists alternative regional base URLs. With region-routing enabled, requests use
+	// the fastest healthy region instead of base-url.
+	Regions []RegionEndpoint `yaml:"regions,omitempty" json:"regions,omitempty"`
+
+	// Region pins the credential to the named region, bypassing latency-based selection.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
 	// Websockets enables the Responses API websocket transport for this credential.
 	Websockets bool `yaml:"websockets,omitempty" json:"websockets,omitempty"`
 
@@ -547,6 +697,12 @@ type CodexKey struct {
 	// Headers optionally adds extra HTTP headers for requests sent with this key.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 
+	// Organization is sent as the OpenAI-Organization header and attributed in usage records.
+	Organization string `yaml:"organization,omitempty" json:"organization,omitempty"`
+
+	// Project is sent as the OpenAI-Project header and attributed in usage records.
+	Project string `yaml:"project,omitempty" json:"project,omitempty"`
+
 	// ExcludedModels lists model IDs that should be excluded for this provider.
 	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
 
@@ -593,12 +749,22 @@ type GeminiKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gemini-3-pro-preview").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
 	// BaseURL optionally overrides the Gemini API endpoint.
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
+	// Regions lists alternative regional base URLs. With region-routing enabled, requests use
+	// the fastest healthy region instead of base-url.
+	Regions []RegionEndpoint `yaml:"regions,omitempty" json:"regions,omitempty"`
+
+	// Region pins the credential to the named region, bypassing latency-based selection.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
 	// ProxyURL optionally overrides the global proxy for this API key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
@@ -648,6 +814,9 @@ type OpenAICompatibility struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// MaxConcurrency caps in-flight requests on each of this provider's credentials; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// Disabled prevents this provider from being used for routing.
 	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
 
@@ -663,6 +832,14 @@ type OpenAICompatibility struct {
 	// Models defines the model configurations including aliases for routing.
 	Models []OpenAICompatibilityModel `yaml:"models" json:"models"`
 
+	// DiscoverModels registers the upstream GET {base-url}/models listing in addition to
+	// the static Models list, so vendors such as OpenRouter or vLLM need no per-model config.
+	DiscoverModels bool `yaml:"discover-models,omitempty" json:"discover-models,omitempty"`
+
+	// PromptCacheKey sends a prompt_cache_key derived from the cache_control breakpoints of
+	// Claude-format requests, so upstreams with implicit prefix caching reuse the same cache.
+	PromptCacheKey bool `yaml:"prompt-cache-key,omitempty" json:"prompt-cache-key,omitempty"`
+
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
 
@@ -677,6 +854,12 @@ type OpenAICompatibilityAPIKey struct {
 
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Organization is sent as the OpenAI-Organization header and attributed in usage records.
+	Organization string `yaml:"organization,omitempty" json:"organization,omitempty"`
+
+	// Project is sent as the OpenAI-Project header and attributed in usage records.
+	Project string `yaml:"project,omitempty" json:"project,omitempty"`
 }
 
 // OpenAICompatibilityModel represents a model configuration for OpenAI compatibility,
@@ -697,6 +880,9 @@ type OpenAICompatibilityModel struct {
 	// Image marks this model as callable through /v1/images/generations and /v1/images/edits.
 	Image bool `yaml:"image,omitempty" json:"image,omitempty"`
 
+	// Audio marks this model as callable through /v1/audio/transcriptions and /v1/audio/speech.
+	Audio bool `yaml:"audio,omitempty" json:"audio,omitempty"`
+
 	// InputModalities declares chat/responses input capabilities (e.g. text, image) for Codex and other clients.
 	// This is separate from Image, which only enables /v1/images/* endpoints.
 	InputModalities []string `yaml:"input-modalities,omitempty" json:"input-modalities,omitempty"`
@@ -843,12 +1029,30 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Vertex-compatible API keys.
 	cfg.SanitizeVertexCompatKeys()
 
+	// Sanitize Vertex service accounts: ADC entries need a project ID
+	cfg.SanitizeVertexServiceAccounts()
+
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
 
 	// Sanitize xAI keys: drop entries without base-url
 	cfg.SanitizeXAIKeys()
 
+	// Sanitize Bedrock keys: drop entries without usable credentials
+	cfg.SanitizeBedrockKeys()
+
+	// Sanitize Azure OpenAI keys: drop entries without an endpoint or credentials
+	cfg.SanitizeAzureOpenAIKeys()
+
+	// Sanitize Mistral keys: drop entries without an API key
+	cfg.SanitizeMistralKeys()
+
+	// Sanitize DeepSeek keys: drop entries without an API key
+	cfg.SanitizeDeepSeekKeys()
+
+	// Sanitize Cohere keys: drop entries without an API key
+	cfg.SanitizeCohereKeys()
+
 	// Sanitize Codex header defaults.
 	cfg.SanitizeCodexHeaderDefaults()
 
@@ -870,6 +1074,127 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Drop invalid rate limit entries and normalize burst sizes.
+	cfg.SanitizeRateLimit()
+
+	// Normalize request queue priorities and drop invalid key entries.
+	cfg.SanitizeRequestQueue()
+
+	// Normalize TLS fingerprint profiles and drop unknown entries.
+	cfg.SanitizeTLSFingerprint()
+
+	// Normalize per-provider proxies and drop invalid entries.
+	cfg.SanitizeProviderProxies()
+
+	// Normalize usage accounting store settings and drop invalid quotas.
+	cfg.SanitizeUsageAccounting()
+
+	// Normalize the transcript store and apply default limits.
+	cfg.SanitizeTranscripts()
+
+	// Drop model pricing entries with missing names or invalid prices.
+	cfg.SanitizeModelPricing()
+
+	// Trim model catalog sources.
+	cfg.SanitizeModelCatalog()
+
+	// Normalize model failover rules and drop rules without targets.
+	cfg.SanitizeModelFailover()
+	cfg.SanitizeShadowTraffic()
+	cfg.SanitizeExperiments()
+
+	// Drop model alias rules without a target or with an invalid pattern.
+	cfg.SanitizeModelAliases()
+
+	// Drop synthetic models without a name or base model.
+	cfg.SanitizeSyntheticModels()
+
+	// Drop prompt rules without a template, prepend or append text.
+	cfg.SanitizePromptRules()
+
+	// Canonicalize locale hint tags and per-key overrides.
+	cfg.SanitizeLocaleHints()
+
+	// Normalize log output format and rotation settings.
+	cfg.SanitizeLogOutput()
+
+	// Apply response cache defaults and trim Redis settings.
+	cfg.SanitizeResponseCache()
+
+	// Normalize response header allow and deny patterns.
+	cfg.SanitizeResponseHeaders()
+
+	// Apply pre-flight token check defaults.
+	cfg.SanitizePreflightTokenCheck()
+
+	// Drop invalid PII redaction patterns and apply detector defaults.
+	cfg.SanitizePIIRedaction()
+
+	// Apply content moderation defaults.
+	cfg.SanitizeModeration()
+
+	// Normalize the reasoning output mode.
+	cfg.SanitizeReasoningOutput()
+
+	// Drop an invalid streaming hedge delay.
+	cfg.SanitizeStreamingHedge()
+	cfg.SanitizeStreamingTimeouts()
+	cfg.SanitizeRace()
+
+	// Normalize scheduler job entries.
+	cfg.SanitizeScheduler()
+
+	// Apply batch API defaults.
+	cfg.SanitizeBatch()
+
+	// Normalize Anthropic Files API settings.
+	cfg.SanitizeAnthropicFiles()
+	cfg.SanitizeContentEncryption()
+	cfg.SanitizeCredentialEncryption()
+	cfg.SanitizeWasmFilters()
+
+	// Apply image normalization defaults.
+	cfg.SanitizeVision()
+
+	// Apply idempotency defaults.
+	cfg.SanitizeIdempotency()
+
+	// Apply stream resume defaults.
+	cfg.SanitizeStreamResume()
+
+	// Apply Responses API state defaults.
+	cfg.SanitizeResponsesStore()
+
+	// Trim shared state settings.
+	cfg.SanitizeSharedState()
+
+	// Normalize tenants.
+	cfg.SanitizeTenants()
+
+	// Apply signing audit defaults.
+	cfg.SanitizeSigningAudit()
+
+	// Normalize lifecycle hook settings.
+	cfg.SanitizeLifecycleHooks()
+
+	// Apply gRPC ingress defaults.
+	cfg.SanitizeGRPC()
+
+	// Normalize OIDC/JWT authentication settings.
+	cfg.SanitizeOIDCAuth()
+
+	// Normalize Unix socket listener settings.
+	cfg.SanitizeListen()
+
+	// Apply HTTP/2 and upstream transport defaults.
+	cfg.SanitizeHTTP2()
+
+	// Normalize ACME certificate provisioning settings.
+	cfg.SanitizeTLSACME()
+
+	// Normalize mTLS client certificate settings.
+	cfg.SanitizeTLSClientAuth()
+
 	// Return the populated configuration struct.
 	return &cfg, nil
 }
@@ -1037,6 +1362,10 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 		e.Prefix = normalizeModelPrefix(e.Prefix)
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
+		for j := range e.APIKeyEntries {
+			e.APIKeyEntries[j].Organization = strings.TrimSpace(e.APIKeyEntries[j].Organization)
+			e.APIKeyEntries[j].Project = strings.TrimSpace(e.APIKeyEntries[j].Project)
+		}
 		if e.BaseURL == "" {
 			// Skip providers with no base-url; treated as removed
 			continue
@@ -1075,6 +1404,10 @@ func sanitizeCodexKeyEntries(entries []CodexKey) []CodexKey {
 		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
 		e.ExcludedModels = NormalizeExcludedModels(e.ExcludedModels)
+		e.Organization = strings.TrimSpace(e.Organization)
+		e.Project = strings.TrimSpace(e.Project)
+		e.Regions = NormalizeRegions(e.Regions)
+		e.Region = strings.ToLower(strings.TrimSpace(e.Region))
 		if e.BaseURL == "" {
 			continue
 		}
@@ -1093,6 +1426,9 @@ func (cfg *Config) SanitizeClaudeKeys() {
 		entry.Prefix = normalizeModelPrefix(entry.Prefix)
 		entry.Headers = NormalizeHeaders(entry.Headers)
 		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		entry.Workspace = strings.TrimSpace(entry.Workspace)
+		entry.Regions = NormalizeRegions(entry.Regions)
+		entry.Region = strings.ToLower(strings.TrimSpace(entry.Region))
 	}
 }
 
@@ -1110,6 +1446,8 @@ func sanitizeGeminiKeyEntries(entries []GeminiKey) []GeminiKey {
 		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
 		entry.Headers = NormalizeHeaders(entry.Headers)
 		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		entry.Regions = NormalizeRegions(entry.Regions)
+		entry.Region = strings.ToLower(strings.TrimSpace(entry.Region))
 		uniqueKey := entry.APIKey + "|" + entry.BaseURL
 		if _, exists := seen[uniqueKey]; exists {
 			continue
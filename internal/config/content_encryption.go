@@ -0,0 +1,28 @@
+package config
+
+import "strings"
+
+// DefaultContentKeysDir is the directory name used for wrapped data keys when
+// content-encryption.keys-dir is unset. It is placed inside auth-dir.
+const DefaultContentKeysDir = "content-keys"
+
+// ContentEncryptionConfig configures envelope encryption of stored request content such as
+// Anthropic file uploads and batch files. Every stored object gets its own data key, wrapped
+// with a master key that never touches disk; deleting the data key destroys the object.
+type ContentEncryptionConfig struct {
+	// Enable encrypts newly stored content. Existing plaintext content stays readable.
+	Enable          bool `yaml:"enable" json:"enable"`
+	MasterKeyConfig `yaml:",inline"`
+	// KeysDir stores the wrapped data keys. Default: <auth-dir>/content-keys.
+	KeysDir string `yaml:"keys-dir,omitempty" json:"keys-dir,omitempty"`
+}
+
+// SanitizeContentEncryption trims the configured paths and names.
+func (cfg *Config) SanitizeContentEncryption() {
+	if cfg == nil {
+		return
+	}
+	c := &cfg.ContentEncryption
+	c.MasterKeyConfig.sanitize()
+	c.KeysDir = strings.TrimSpace(c.KeysDir)
+}
@@ -0,0 +1,18 @@
+package config
+
+// CredentialEncryptionConfig configures encryption of the refresh tokens in OAuth credential
+// files, so a copy of auth-dir does not grant lasting access to the accounts behind it.
+type CredentialEncryptionConfig struct {
+	// Enable encrypts refresh tokens whenever a credential file is written. Existing plaintext
+	// files stay readable and are encrypted the next time they are saved.
+	Enable          bool `yaml:"enable" json:"enable"`
+	MasterKeyConfig `yaml:",inline"`
+}
+
+// SanitizeCredentialEncryption trims the configured key sources.
+func (cfg *Config) SanitizeCredentialEncryption() {
+	if cfg == nil {
+		return
+	}
+	cfg.CredentialEncryption.MasterKeyConfig.sanitize()
+}
@@ -0,0 +1,72 @@
+package config
+
+import "strings"
+
+// DefaultDeepSeekBaseURL is the DeepSeek API base used when an entry does not set one.
+const DefaultDeepSeekBaseURL = "https://api.deepseek.com"
+
+// DeepSeekKey configures a DeepSeek API key.
+type DeepSeekKey struct {
+	// APIKey is the DeepSeek API key sent as a bearer token.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// BaseURL overrides the API base; defaults to DefaultDeepSeekBaseURL.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "deepseek/deepseek-reasoner").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps upstream model names (name) to the model names clients request (alias).
+	// Without mappings the built-in DeepSeek model list is registered.
+	Models []DeepSeekModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// DisableCooling disables auth/model cooldown scheduling for this credential when true.
+	DisableCooling bool `yaml:"disable-cooling,omitempty" json:"disable-cooling,omitempty"`
+}
+
+func (k DeepSeekKey) GetAPIKey() string  { return k.APIKey }
+func (k DeepSeekKey) GetBaseURL() string { return k.BaseURL }
+
+// DeepSeekModel maps an upstream DeepSeek model name (Name) to a client-facing alias (Alias).
+type DeepSeekModel = ClaudeModel
+
+// SanitizeDeepSeekKeys trims DeepSeek entries, applies the default base URL and drops entries
+// without an API key.
+func (cfg *Config) SanitizeDeepSeekKeys() {
+	if cfg == nil || len(cfg.DeepSeekKey) == 0 {
+		return
+	}
+	out := cfg.DeepSeekKey[:0]
+	for i := range cfg.DeepSeekKey {
+		entry := cfg.DeepSeekKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		entry.BaseURL = strings.TrimSuffix(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.BaseURL == "" {
+			entry.BaseURL = DefaultDeepSeekBaseURL
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		out = append(out, entry)
+	}
+	cfg.DeepSeekKey = out
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Experiment bucketing keys.
+const (
+	ExperimentBucketByAPIKey       = "api-key"
+	ExperimentBucketByConversation = "conversation"
+)
+
+// Experiment splits the requests for matching models between model targets (arms). Clients
+// are assigned to arms by hashing a bucketing key, so the same client or conversation always
+// lands in the same arm while the experiment is unchanged.
+type Experiment struct {
+	// Name identifies the experiment in response headers and usage tags.
+	Name string `yaml:"name" json:"name"`
+	// Models are case-insensitive patterns of requested model names, where '*' matches any substring.
+	Models []string `yaml:"models" json:"models"`
+	// BucketBy selects the bucketing key: "api-key" (default) or "conversation", which uses the
+	// X-Session-ID header (or cliproxy.cache.session_id) and falls back to the API key.
+	BucketBy string `yaml:"bucket-by,omitempty" json:"bucket-by,omitempty"`
+	// Arms split the traffic by percentage. Requests in the remaining share keep their model.
+	Arms []ExperimentArm `yaml:"arms" json:"arms"`
+}
+
+// ExperimentArm is one model target of an experiment.
+type ExperimentArm struct {
+	// Name identifies the arm, e.g. "control" or "treatment".
+	Name string `yaml:"name" json:"name"`
+	// Model is the model requests assigned to the arm are sent to.
+	Model string `yaml:"model" json:"model"`
+	// Provider restricts the arm to one provider. Empty allows every provider serving Model.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Percent is the share of matching requests assigned to the arm, from 0 to 100.
+	Percent float64 `yaml:"percent" json:"percent"`
+}
+
+// SanitizeExperiments normalizes experiments and drops those that cannot assign any arm.
+// Experiments whose arms add up to more than 100 percent are dropped.
+func (cfg *Config) SanitizeExperiments() {
+	if cfg == nil {
+		return
+	}
+	experiments := make([]Experiment, 0, len(cfg.Experiments))
+	seen := make(map[string]struct{}, len(cfg.Experiments))
+	for _, experiment := range cfg.Experiments {
+		experiment.Name = strings.TrimSpace(experiment.Name)
+		key := strings.ToLower(experiment.Name)
+		if key == "" {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			log.Warnf("experiments: ignoring duplicate experiment %q", experiment.Name)
+			continue
+		}
+		switch experiment.BucketBy = strings.ToLower(strings.TrimSpace(experiment.BucketBy)); experiment.BucketBy {
+		case ExperimentBucketByAPIKey, ExperimentBucketByConversation:
+		case "":
+			experiment.BucketBy = ExperimentBucketByAPIKey
+		default:
+			log.Warnf("experiments: %s: unknown bucket-by %q, using %s", experiment.Name, experiment.BucketBy, ExperimentBucketByAPIKey)
+			experiment.BucketBy = ExperimentBucketByAPIKey
+		}
+		models := make([]string, 0, len(experiment.Models))
+		for _, model := range experiment.Models {
+			if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+				models = append(models, model)
+			}
+		}
+		experiment.Models = models
+		arms := make([]ExperimentArm, 0, len(experiment.Arms))
+		total := 0.0
+		for _, arm := range experiment.Arms {
+			arm.Name = strings.TrimSpace(arm.Name)
+			arm.Model = strings.TrimSpace(arm.Model)
+			arm.Provider = strings.ToLower(strings.TrimSpace(arm.Provider))
+			if arm.Name == "" || arm.Model == "" || arm.Percent <= 0 {
+				continue
+			}
+			total += arm.Percent
+			arms = append(arms, arm)
+		}
+		if total > 100 {
+			log.Warnf("experiments: ignoring %s: arms add up to %.2f percent", experiment.Name, total)
+			continue
+		}
+		if len(models) == 0 || len(arms) == 0 {
+			continue
+		}
+		experiment.Arms = arms
+		seen[key] = struct{}{}
+		experiments = append(experiments, experiment)
+	}
+	cfg.Experiments = experiments
+}
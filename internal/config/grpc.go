@@ -0,0 +1,27 @@
+package config
+
+import "strings"
+
+// DefaultGRPCPort is the gRPC ingress port used when grpc.port is unset.
+const DefaultGRPCPort = 8318
+
+// GRPCConfig configures the gRPC ingress mirroring the chat completions API.
+type GRPCConfig struct {
+	// Enable starts the gRPC listener.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Host is the interface to bind. Default: the HTTP server host.
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+	// Port is the listening port. Default: 8318.
+	Port int `yaml:"port,omitempty" json:"port,omitempty"`
+}
+
+// SanitizeGRPC trims the host and applies the default port.
+func (cfg *Config) SanitizeGRPC() {
+	if cfg == nil {
+		return
+	}
+	cfg.GRPC.Host = strings.TrimSpace(cfg.GRPC.Host)
+	if cfg.GRPC.Port <= 0 || cfg.GRPC.Port > 65535 {
+		cfg.GRPC.Port = DefaultGRPCPort
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+// DefaultHeaderFilterMaxBytes is the largest inbound header (name plus values) kept when
+// header-filter.max-header-bytes is unset.
+const DefaultHeaderFilterMaxBytes = 8 << 10
+
+// HeaderFilterConfig controls which inbound client headers are stripped before a request
+// reaches the provider executors. Cookies, client network identity headers and oversized
+// headers are stripped by default to avoid upstream 431 responses and fingerprinting.
+type HeaderFilterConfig struct {
+	// Disable turns the filter off and forwards inbound headers unchanged.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+	// MaxHeaderBytes drops any header whose name plus values exceed this size.
+	// Default: 8192. Negative values disable the size check.
+	MaxHeaderBytes int `yaml:"max-header-bytes,omitempty" json:"max-header-bytes,omitempty"`
+	// Strip lists additional header names to remove.
+	Strip []string `yaml:"strip,omitempty" json:"strip,omitempty"`
+	// Allow lists header names that are never stripped, including default entries such as Cookie.
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+}
+
+// MaxHeaderBytesLimit returns the effective per-header size limit; 0 means unlimited.
+func (c HeaderFilterConfig) MaxHeaderBytesLimit() int {
+	switch {
+	case c.MaxHeaderBytes < 0:
+		return 0
+	case c.MaxHeaderBytes == 0:
+		return DefaultHeaderFilterMaxBytes
+	default:
+		return c.MaxHeaderBytes
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultHealthCheckInterval is the probe interval used when health-check.interval is unset or invalid.
+const DefaultHealthCheckInterval = 5 * time.Minute
+
+// minHealthCheckInterval prevents the prober from hammering upstream model endpoints.
+const minHealthCheckInterval = 30 * time.Second
+
+// HealthCheckConfig configures the background provider health prober.
+type HealthCheckConfig struct {
+	// Enable toggles the background prober. When false, /v0/health/providers only
+	// reports the credential state tracked by the auth manager.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Interval controls how often each credential is probed.
+	// Default: 5m. Accepts duration strings like "1m", "5m", "1h". Values below 30s are raised to 30s.
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// IntervalDuration returns the parsed probe interval with defaults and bounds applied.
+func (c HealthCheckConfig) IntervalDuration() time.Duration {
+	raw := strings.TrimSpace(c.Interval)
+	if raw == "" {
+		return DefaultHealthCheckInterval
+	}
+	interval, errParse := time.ParseDuration(raw)
+	if errParse != nil || interval <= 0 {
+		return DefaultHealthCheckInterval
+	}
+	if interval < minHealthCheckInterval {
+		return minHealthCheckInterval
+	}
+	return interval
+}
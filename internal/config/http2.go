@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHTTP2MaxConcurrentStreams bounds concurrent streams per inbound HTTP/2 connection.
+	DefaultHTTP2MaxConcurrentStreams = 250
+	// DefaultUpstreamMaxIdleConnsPerHost keeps enough idle upstream connections for bursts of
+	// HTTP/1.1 streaming requests to the same provider host.
+	DefaultUpstreamMaxIdleConnsPerHost = 64
+)
+
+// HTTP2Config tunes HTTP/2 on the API listener and connection reuse to upstream providers.
+type HTTP2Config struct {
+	// H2C accepts cleartext HTTP/2 (prior knowledge and Upgrade) when TLS is disabled, for
+	// internal deployments behind a trusted network or sidecar. Takes effect after a restart.
+	H2C bool `yaml:"h2c" json:"h2c"`
+	// MaxConcurrentStreams bounds concurrent streams per inbound HTTP/2 connection.
+	// Default: 250. Takes effect after a restart.
+	MaxConcurrentStreams uint32 `yaml:"max-concurrent-streams,omitempty" json:"max-concurrent-streams,omitempty"`
+	// Upstream tunes the transports used to reach providers.
+	Upstream UpstreamTransportConfig `yaml:"upstream,omitempty" json:"upstream,omitempty"`
+}
+
+// UpstreamTransportConfig tunes outbound connection reuse.
+type UpstreamTransportConfig struct {
+	// DisableHTTP2 restricts upstream connections to HTTP/1.1.
+	DisableHTTP2 bool `yaml:"disable-http2,omitempty" json:"disable-http2,omitempty"`
+	// MaxIdleConnsPerHost is the number of idle connections kept per upstream host. Default: 64.
+	MaxIdleConnsPerHost int `yaml:"max-idle-conns-per-host,omitempty" json:"max-idle-conns-per-host,omitempty"`
+	// IdleConnTimeout closes idle upstream connections after this duration. Default: "90s".
+	IdleConnTimeout string `yaml:"idle-conn-timeout,omitempty" json:"idle-conn-timeout,omitempty"`
+	// PingInterval sends an HTTP/2 health-check ping when a connection has been silent this
+	// long, so dead connections are dropped instead of stalling streams. Default: "30s"; "0" disables.
+	PingInterval string `yaml:"ping-interval,omitempty" json:"ping-interval,omitempty"`
+	// PingTimeout closes the connection when a ping is not answered in time. Default: "15s".
+	PingTimeout string `yaml:"ping-timeout,omitempty" json:"ping-timeout,omitempty"`
+}
+
+// IdleTimeout returns the parsed idle-conn-timeout.
+func (c UpstreamTransportConfig) IdleTimeout() time.Duration {
+	return parseDurationOr(c.IdleConnTimeout, 90*time.Second)
+}
+
+// Ping returns the parsed ping-interval and ping-timeout.
+func (c UpstreamTransportConfig) Ping() (interval, timeout time.Duration) {
+	return parseDurationOr(c.PingInterval, 30*time.Second), parseDurationOr(c.PingTimeout, 15*time.Second)
+}
+
+// SanitizeHTTP2 applies the HTTP/2 and upstream transport defaults.
+func (cfg *Config) SanitizeHTTP2() {
+	if cfg == nil {
+		return
+	}
+	if cfg.HTTP2.MaxConcurrentStreams == 0 {
+		cfg.HTTP2.MaxConcurrentStreams = DefaultHTTP2MaxConcurrentStreams
+	}
+	upstream := &cfg.HTTP2.Upstream
+	if upstream.MaxIdleConnsPerHost <= 0 {
+		upstream.MaxIdleConnsPerHost = DefaultUpstreamMaxIdleConnsPerHost
+	}
+	upstream.IdleConnTimeout = strings.TrimSpace(upstream.IdleConnTimeout)
+	upstream.PingInterval = strings.TrimSpace(upstream.PingInterval)
+	upstream.PingTimeout = strings.TrimSpace(upstream.PingTimeout)
+}
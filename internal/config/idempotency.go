@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultIdempotencyTTL is how long a response is replayed for an Idempotency-Key when
+// idempotency.ttl is unset or invalid.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultIdempotencyMaxEntries bounds the stored responses when idempotency.max-entries is unset.
+const DefaultIdempotencyMaxEntries = 10000
+
+// IdempotencyConfig configures replay of non-streaming responses for requests carrying an
+// Idempotency-Key header.
+type IdempotencyConfig struct {
+	// Enable honors the Idempotency-Key header.
+	Enable bool `yaml:"enable" json:"enable"`
+	// TTL controls how long a stored response is replayed. Default: 24h.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	// MaxEntries bounds the stored responses; the oldest are evicted first. Default: 10000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+}
+
+// TTLDuration returns the parsed replay TTL with defaults applied.
+func (c IdempotencyConfig) TTLDuration() time.Duration {
+	raw := strings.TrimSpace(c.TTL)
+	if raw == "" {
+		return DefaultIdempotencyTTL
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil || ttl <= 0 {
+		return DefaultIdempotencyTTL
+	}
+	return ttl
+}
+
+// SanitizeIdempotency trims the TTL and applies the default entry limit.
+func (cfg *Config) SanitizeIdempotency() {
+	if cfg == nil {
+		return
+	}
+	cfg.Idempotency.TTL = strings.TrimSpace(cfg.Idempotency.TTL)
+	if cfg.Idempotency.MaxEntries <= 0 {
+		cfg.Idempotency.MaxEntries = DefaultIdempotencyMaxEntries
+	}
+}
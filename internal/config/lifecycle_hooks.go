@@ -0,0 +1,181 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultLifecycleHookTimeout bounds one hook delivery when lifecycle-hooks.timeout-seconds is unset.
+const DefaultLifecycleHookTimeout = 10 * time.Second
+
+// DefaultLifecycleWebhookRetries is how often a failed webhook delivery is retried when
+// lifecycle-hooks.retries is unset.
+const DefaultLifecycleWebhookRetries = 3
+
+// LifecycleHooksConfig delivers lifecycle events (server started, config reloaded, plugin
+// registered or failed, credential refreshed or expired, request started, completed or failed,
+// quota exceeded) to a script and/or webhooks.
+type LifecycleHooksConfig struct {
+	// Command runs once per event with the JSON event on stdin and the event type in the
+	// CLIPROXY_EVENT environment variable. The first element is the executable.
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+	// WebhookURL receives each event as a JSON POST.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+	// Secret signs webhook bodies with HMAC-SHA256 in the X-CLIProxy-Signature header.
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+	// Headers are added to webhook requests.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Events limits delivery to these event types; "credential.*" matches a whole group.
+	// Empty delivers every event.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+	// TimeoutSeconds bounds each script run and webhook call. Default: 10.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+	// Webhooks are additional webhook targets, each with its own secret, headers and events.
+	Webhooks []LifecycleWebhook `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	// Retries is how often a webhook delivery failing with a network error, 429 or 5xx is
+	// retried, with exponential backoff. Default: 3; negative disables retries.
+	Retries int `yaml:"retries,omitempty" json:"retries,omitempty"`
+}
+
+// LifecycleWebhook is one webhook target of lifecycle-hooks.webhooks.
+type LifecycleWebhook struct {
+	// URL receives each event as a JSON POST.
+	URL string `yaml:"url" json:"url"`
+	// Secret signs bodies with HMAC-SHA256 in the X-CLIProxy-Signature header.
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+	// Headers are added to requests.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Events limits delivery to these event types, like lifecycle-hooks.events.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+	// Format is "json" (the event itself) or "slack" (a {"text": ...} message for Slack
+	// incoming webhooks). Default: json.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+}
+
+// Lifecycle webhook formats.
+const (
+	LifecycleWebhookFormatJSON  = "json"
+	LifecycleWebhookFormatSlack = "slack"
+)
+
+// Wants reports whether events of eventType are delivered to the webhook.
+func (w LifecycleWebhook) Wants(eventType string) bool {
+	return matchEventPatterns(w.Events, eventType)
+}
+
+// Enabled reports whether a script or webhook is configured.
+func (c LifecycleHooksConfig) Enabled() bool {
+	return len(c.Command) > 0 || c.WebhookURL != "" || len(c.Webhooks) > 0
+}
+
+// Targets returns every webhook target: webhook-url with the top-level secret, headers and
+// events first, then the webhooks list.
+func (c LifecycleHooksConfig) Targets() []LifecycleWebhook {
+	targets := make([]LifecycleWebhook, 0, len(c.Webhooks)+1)
+	if c.WebhookURL != "" {
+		targets = append(targets, LifecycleWebhook{URL: c.WebhookURL, Secret: c.Secret, Headers: c.Headers, Events: c.Events})
+	}
+	return append(targets, c.Webhooks...)
+}
+
+// WantsAny reports whether the script or any webhook receives events of eventType.
+func (c LifecycleHooksConfig) WantsAny(eventType string) bool {
+	if len(c.Command) > 0 && c.Wants(eventType) {
+		return true
+	}
+	for _, target := range c.Targets() {
+		if target.Wants(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryCount returns the number of webhook retries with the default applied.
+func (c LifecycleHooksConfig) RetryCount() int {
+	switch {
+	case c.Retries < 0:
+		return 0
+	case c.Retries == 0:
+		return DefaultLifecycleWebhookRetries
+	}
+	return c.Retries
+}
+
+// Timeout returns the per-delivery timeout with the default applied.
+func (c LifecycleHooksConfig) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultLifecycleHookTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// Wants reports whether events of eventType are delivered to the script and webhook-url.
+func (c LifecycleHooksConfig) Wants(eventType string) bool {
+	return matchEventPatterns(c.Events, eventType)
+}
+
+func matchEventPatterns(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeLifecycleHooks trims the hook settings and drops an empty command.
+func (cfg *Config) SanitizeLifecycleHooks() {
+	if cfg == nil {
+		return
+	}
+	hooks := &cfg.LifecycleHooks
+	hooks.WebhookURL = strings.TrimSpace(hooks.WebhookURL)
+	hooks.Secret = strings.TrimSpace(hooks.Secret)
+	hooks.Headers = NormalizeHeaders(hooks.Headers)
+	if len(hooks.Command) > 0 && strings.TrimSpace(hooks.Command[0]) == "" {
+		hooks.Command = nil
+	}
+	hooks.Events = normalizeEventPatterns(hooks.Events)
+	if hooks.TimeoutSeconds < 0 {
+		hooks.TimeoutSeconds = 0
+	}
+	webhooks := make([]LifecycleWebhook, 0, len(hooks.Webhooks))
+	for _, webhook := range hooks.Webhooks {
+		webhook.URL = strings.TrimSpace(webhook.URL)
+		if webhook.URL == "" {
+			continue
+		}
+		webhook.Secret = strings.TrimSpace(webhook.Secret)
+		webhook.Headers = NormalizeHeaders(webhook.Headers)
+		webhook.Events = normalizeEventPatterns(webhook.Events)
+		switch webhook.Format = strings.ToLower(strings.TrimSpace(webhook.Format)); webhook.Format {
+		case "", LifecycleWebhookFormatJSON:
+			webhook.Format = LifecycleWebhookFormatJSON
+		case LifecycleWebhookFormatSlack:
+		default:
+			log.Warnf("lifecycle-hooks.webhooks: unknown format %q for %s, using json", webhook.Format, webhook.URL)
+			webhook.Format = LifecycleWebhookFormatJSON
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	hooks.Webhooks = webhooks
+}
+
+func normalizeEventPatterns(patterns []string) []string {
+	events := make([]string, 0, len(patterns))
+	for _, event := range patterns {
+		if event = strings.ToLower(strings.TrimSpace(event)); event != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultUnixSocketMode is the permission of the Unix socket unless listen.unix-socket-mode is set.
+const DefaultUnixSocketMode = "0660"
+
+// ListenConfig adds local listeners to the host:port TCP listener. Changes take effect after a
+// restart.
+type ListenConfig struct {
+	// UnixSocket is the path of a Unix domain socket serving the API as plain HTTP, so local
+	// agents can connect without a network port. Connections count as loopback clients.
+	UnixSocket string `yaml:"unix-socket,omitempty" json:"unix-socket,omitempty"`
+	// UnixSocketMode is the octal file mode applied to the socket. Default: "0660".
+	UnixSocketMode string `yaml:"unix-socket-mode,omitempty" json:"unix-socket-mode,omitempty"`
+	// SystemdActivation serves the sockets passed by systemd socket activation (LISTEN_FDS)
+	// instead of opening host:port. Without activated sockets host:port is used as usual.
+	SystemdActivation bool `yaml:"systemd-activation" json:"systemd-activation"`
+}
+
+// SocketMode returns the parsed unix-socket-mode.
+func (c ListenConfig) SocketMode() uint32 {
+	mode, errParse := strconv.ParseUint(c.UnixSocketMode, 8, 32)
+	if errParse != nil {
+		mode, _ = strconv.ParseUint(DefaultUnixSocketMode, 8, 32)
+	}
+	return uint32(mode)
+}
+
+// SanitizeListen trims the socket path and validates the socket mode.
+func (cfg *Config) SanitizeListen() {
+	if cfg == nil {
+		return
+	}
+	cfg.Listen.UnixSocket = strings.TrimSpace(cfg.Listen.UnixSocket)
+	cfg.Listen.UnixSocketMode = strings.TrimSpace(cfg.Listen.UnixSocketMode)
+	if cfg.Listen.UnixSocketMode == "" {
+		cfg.Listen.UnixSocketMode = DefaultUnixSocketMode
+	}
+	if mode, errParse := strconv.ParseUint(cfg.Listen.UnixSocketMode, 8, 32); errParse != nil || mode > 0o777 {
+		log.Warnf("listen.unix-socket-mode %q is not an octal permission; using %s", cfg.Listen.UnixSocketMode, DefaultUnixSocketMode)
+		cfg.Listen.UnixSocketMode = DefaultUnixSocketMode
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+)
+
+// DefaultLocaleHintTemplate is the system prompt hint added when locale-hints.template is unset.
+const DefaultLocaleHintTemplate = "Respond in {{language}} ({{locale}}) unless the user explicitly asks for another language."
+
+// LocaleHintsConfig turns the client's preferred language into a system prompt hint, so
+// responses come back in a consistent language without editing every prompt.
+type LocaleHintsConfig struct {
+	// Enable turns locale hints on.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Default is the locale used when the client sends no Accept-Language header, e.g. "en-US".
+	// Empty adds no hint to such requests.
+	Default string `yaml:"default,omitempty" json:"default,omitempty"`
+	// Template is appended to the system prompt. "{{locale}}" expands to the BCP 47 tag and
+	// "{{language}}" to its English name. Default: DefaultLocaleHintTemplate.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+	// Keys overrides the behavior for specific client API keys.
+	Keys []LocaleHintKey `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// LocaleHintKey configures locale hints for one client API key.
+type LocaleHintKey struct {
+	// APIKey is the inbound client API key.
+	APIKey string `yaml:"api-key" json:"api-key"`
+	// Locale pins the locale for this key, ignoring the client's Accept-Language header.
+	Locale string `yaml:"locale,omitempty" json:"locale,omitempty"`
+	// Disable sends this key's requests without a locale hint.
+	Disable bool `yaml:"disable,omitempty" json:"disable,omitempty"`
+}
+
+// ForKey returns the override for apiKey, if any.
+func (c LocaleHintsConfig) ForKey(apiKey string) (LocaleHintKey, bool) {
+	if apiKey == "" {
+		return LocaleHintKey{}, false
+	}
+	for _, entry := range c.Keys {
+		if entry.APIKey == apiKey {
+			return entry, true
+		}
+	}
+	return LocaleHintKey{}, false
+}
+
+// SanitizeLocaleHints canonicalizes locale tags, drops invalid ones and duplicate key entries,
+// and applies defaults.
+func (cfg *Config) SanitizeLocaleHints() {
+	if cfg == nil {
+		return
+	}
+	hints := &cfg.LocaleHints
+	hints.Default = canonicalLocale(hints.Default, "locale-hints.default")
+	if strings.TrimSpace(hints.Template) == "" {
+		hints.Template = DefaultLocaleHintTemplate
+	}
+	keys := make([]LocaleHintKey, 0, len(hints.Keys))
+	seen := make(map[string]struct{}, len(hints.Keys))
+	for _, entry := range hints.Keys {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		if _, exists := seen[entry.APIKey]; exists {
+			continue
+		}
+		seen[entry.APIKey] = struct{}{}
+		entry.Locale = canonicalLocale(entry.Locale, "locale-hints.keys.locale")
+		if entry.Locale == "" && !entry.Disable {
+			continue
+		}
+		keys = append(keys, entry)
+	}
+	hints.Keys = keys
+}
+
+func canonicalLocale(value, field string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	tag, errParse := language.Parse(value)
+	if errParse != nil {
+		log.Warnf("%s: invalid locale %q ignored", field, value)
+		return ""
+	}
+	return tag.String()
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Log output formats accepted by log-output.format.
+const (
+	LogFormatPretty = "pretty"
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
+)
+
+// LogErrorOutputStderr sends error-level application logs to stderr in addition to the main output.
+const LogErrorOutputStderr = "stderr"
+
+// defaultLogMaxSizeMB is the size at which the main log file is rotated.
+const defaultLogMaxSizeMB = 10
+
+// LogOutputConfig controls the application log format, file rotation and error log destination.
+type LogOutputConfig struct {
+	// Format is one of "pretty" (default), "json" or "logfmt".
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// MaxSizeMB rotates a log file once it reaches this size. Defaults to 10.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+	// MaxAgeDays removes rotated files older than this many days. 0 keeps them.
+	MaxAgeDays int `yaml:"max-age-days,omitempty" json:"max-age-days,omitempty"`
+	// MaxBackups limits the number of rotated files kept. 0 keeps them all.
+	MaxBackups int `yaml:"max-backups,omitempty" json:"max-backups,omitempty"`
+	// Compress gzips rotated files.
+	Compress bool `yaml:"compress,omitempty" json:"compress,omitempty"`
+	// ErrorOutput additionally writes error-level logs to "stderr" or to a file name inside the
+	// logs directory (e.g. "app-error.log"). Empty disables the separate error log.
+	ErrorOutput string `yaml:"error-output,omitempty" json:"error-output,omitempty"`
+}
+
+// NormalizeLogFormat returns the canonical log format name and whether it is supported.
+func NormalizeLogFormat(format string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", LogFormatPretty, "text":
+		return LogFormatPretty, true
+	case LogFormatJSON:
+		return LogFormatJSON, true
+	case LogFormatLogfmt:
+		return LogFormatLogfmt, true
+	default:
+		return "", false
+	}
+}
+
+// SanitizeLogOutput normalizes the log format, clamps rotation limits and keeps file error
+// outputs inside the logs directory.
+func (cfg *Config) SanitizeLogOutput() {
+	if cfg == nil {
+		return
+	}
+	out := &cfg.LogOutput
+	format, ok := NormalizeLogFormat(out.Format)
+	if !ok {
+		log.Warnf("log-output: unsupported format %q, using %s", out.Format, LogFormatPretty)
+		format = LogFormatPretty
+	}
+	out.Format = format
+	if out.MaxSizeMB <= 0 {
+		out.MaxSizeMB = defaultLogMaxSizeMB
+	}
+	if out.MaxAgeDays < 0 {
+		out.MaxAgeDays = 0
+	}
+	if out.MaxBackups < 0 {
+		out.MaxBackups = 0
+	}
+	out.ErrorOutput = strings.TrimSpace(out.ErrorOutput)
+	if out.ErrorOutput == "" || strings.EqualFold(out.ErrorOutput, LogErrorOutputStderr) {
+		out.ErrorOutput = strings.ToLower(out.ErrorOutput)
+		return
+	}
+	name := filepath.Base(out.ErrorOutput)
+	if name == "." || name == ".." || name == string(filepath.Separator) || name == "main.log" {
+		log.Warnf("log-output: invalid error-output %q, separate error log disabled", out.ErrorOutput)
+		name = ""
+	}
+	out.ErrorOutput = name
+}
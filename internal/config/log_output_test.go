@@ -0,0 +1,20 @@
+package config
+
+import "testing"
+
+func TestSanitizeLogOutput(t *testing.T) {
+	cfg := &Config{LogOutput: LogOutputConfig{Format: " LOGFMT ", MaxSizeMB: -1, MaxAgeDays: -3, MaxBackups: 2, ErrorOutput: "nested/app-error.log"}}
+
+	cfg.SanitizeLogOutput()
+
+	want := LogOutputConfig{Format: LogFormatLogfmt, MaxSizeMB: 10, MaxBackups: 2, ErrorOutput: "app-error.log"}
+	if cfg.LogOutput != want {
+		t.Fatalf("log-output = %+v, want %+v", cfg.LogOutput, want)
+	}
+
+	cfg.LogOutput = LogOutputConfig{Format: "xml", ErrorOutput: " STDERR "}
+	cfg.SanitizeLogOutput()
+	if cfg.LogOutput.Format != LogFormatPretty || cfg.LogOutput.ErrorOutput != LogErrorOutputStderr {
+		t.Fatalf("log-output = %+v, want pretty format and stderr error output", cfg.LogOutput)
+	}
+}
@@ -0,0 +1,18 @@
+package config
+
+import "strings"
+
+// MasterKeyConfig locates the master key secret used by content-encryption and
+// credential-encryption. The secret itself never appears in the configuration file.
+type MasterKeyConfig struct {
+	// MasterKeyEnv names the environment variable holding the master key secret.
+	MasterKeyEnv string `yaml:"master-key-env,omitempty" json:"master-key-env,omitempty"`
+	// MasterKeyFile is a file holding the master key secret. Used when MasterKeyEnv is unset.
+	MasterKeyFile string `yaml:"master-key-file,omitempty" json:"master-key-file,omitempty"`
+}
+
+// sanitize trims the configured key sources.
+func (c *MasterKeyConfig) sanitize() {
+	c.MasterKeyEnv = strings.TrimSpace(c.MasterKeyEnv)
+	c.MasterKeyFile = strings.TrimSpace(c.MasterKeyFile)
+}
@@ -0,0 +1,73 @@
+package config
+
+import "strings"
+
+// DefaultMistralBaseURL is the La Plateforme API base used when an entry does not set one.
+const DefaultMistralBaseURL = "https://api.mistral.ai/v1"
+
+// MistralKey configures a Mistral AI API key. Codestral keys issued for the dedicated
+// endpoint use base-url "https://codestral.mistral.ai/v1".
+type MistralKey struct {
+	// APIKey is the Mistral API key sent as a bearer token.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// BaseURL overrides the API base; defaults to DefaultMistralBaseURL.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "mistral/codestral-latest").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps upstream model names (name) to the model names clients request (alias).
+	// Without mappings the built-in Mistral model list is registered.
+	Models []MistralModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+
+	// DisableCooling disables auth/model cooldown scheduling for this credential when true.
+	DisableCooling bool `yaml:"disable-cooling,omitempty" json:"disable-cooling,omitempty"`
+}
+
+func (k MistralKey) GetAPIKey() string  { return k.APIKey }
+func (k MistralKey) GetBaseURL() string { return k.BaseURL }
+
+// MistralModel maps an upstream Mistral model name (Name) to a client-facing alias (Alias).
+type MistralModel = ClaudeModel
+
+// SanitizeMistralKeys trims Mistral entries, applies the default base URL and drops entries
+// without an API key.
+func (cfg *Config) SanitizeMistralKeys() {
+	if cfg == nil || len(cfg.MistralKey) == 0 {
+		return
+	}
+	out := cfg.MistralKey[:0]
+	for i := range cfg.MistralKey {
+		entry := cfg.MistralKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		entry.BaseURL = strings.TrimSuffix(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.BaseURL == "" {
+			entry.BaseURL = DefaultMistralBaseURL
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		out = append(out, entry)
+	}
+	cfg.MistralKey = out
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ModelAliasRule rewrites an inbound model name before provider lookup.
+// Exactly one of Match or Regex is used; Match wins when both are set.
+type ModelAliasRule struct {
+	// Match is an exact, case-insensitive model name such as "gpt-4o".
+	Match string `yaml:"match,omitempty" json:"match,omitempty"`
+	// Regex is matched against the whole model name. Model may reference capture groups ($1, ${name}).
+	Regex string `yaml:"regex,omitempty" json:"regex,omitempty"`
+	// Model is the model name requests are rewritten to.
+	Model string `yaml:"model" json:"model"`
+	// Provider optionally restricts rewritten requests to one provider, e.g. "gemini" or an
+	// openai-compatibility name.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+}
+
+// SanitizeModelAliases trims alias rules and drops rules without a target model, without a
+// pattern, or with a regex that does not compile.
+func (cfg *Config) SanitizeModelAliases() {
+	if cfg == nil {
+		return
+	}
+	rules := make([]ModelAliasRule, 0, len(cfg.ModelAliases))
+	for _, rule := range cfg.ModelAliases {
+		rule.Match = strings.TrimSpace(rule.Match)
+		rule.Regex = strings.TrimSpace(rule.Regex)
+		rule.Model = strings.TrimSpace(rule.Model)
+		rule.Provider = strings.ToLower(strings.TrimSpace(rule.Provider))
+		if rule.Model == "" {
+			continue
+		}
+		if rule.Match != "" {
+			rule.Regex = ""
+		} else if rule.Regex == "" {
+			continue
+		} else if _, errCompile := regexp.Compile(rule.Regex); errCompile != nil {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	cfg.ModelAliases = rules
+}
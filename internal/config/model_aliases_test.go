@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestSanitizeModelAliases(t *testing.T) {
+	cfg := &Config{SDKConfig: SDKConfig{ModelAliases: []ModelAliasRule{
+		{Match: " gpt-4o ", Regex: "ignored", Model: " gemini-2.5-pro ", Provider: " Gemini "},
+		{Regex: "gpt-4o-(mini", Model: "broken"},
+		{Regex: "gpt-4o-(.*)", Model: "gpt-5-$1"},
+		{Match: "no-target"},
+		{Model: "no-pattern"},
+	}}}
+
+	cfg.SanitizeModelAliases()
+
+	if got := len(cfg.ModelAliases); got != 2 {
+		t.Fatalf("rules = %d, want 2: %+v", got, cfg.ModelAliases)
+	}
+	if rule := cfg.ModelAliases[0]; rule != (ModelAliasRule{Match: "gpt-4o", Model: "gemini-2.5-pro", Provider: "gemini"}) {
+		t.Fatalf("exact rule = %+v, want trimmed match without regex", rule)
+	}
+	if rule := cfg.ModelAliases[1]; rule.Regex != "gpt-4o-(.*)" {
+		t.Fatalf("regex rule = %+v", rule)
+	}
+}
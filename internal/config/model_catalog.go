@@ -0,0 +1,22 @@
+package config
+
+import "strings"
+
+// ModelCatalogConfig configures where built-in model definitions come from.
+type ModelCatalogConfig struct {
+	// URL replaces the default models.json URLs fetched by the remote model updater.
+	// The catalog may be JSON or YAML with the same sections as models.json.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+	// OverrideFile is a local JSON or YAML catalog whose models replace entries with the
+	// same ID or are appended to their section. It also applies when remote updates are off.
+	OverrideFile string `yaml:"override-file,omitempty" json:"override-file,omitempty"`
+}
+
+// SanitizeModelCatalog trims the catalog URL and override path.
+func (cfg *Config) SanitizeModelCatalog() {
+	if cfg == nil {
+		return
+	}
+	cfg.ModelCatalog.URL = strings.TrimSpace(cfg.ModelCatalog.URL)
+	cfg.ModelCatalog.OverrideFile = strings.TrimSpace(cfg.ModelCatalog.OverrideFile)
+}
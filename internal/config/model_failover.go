@@ -0,0 +1,56 @@
+package config
+
+import "strings"
+
+// ModelFailoverRule routes a client-facing model alias through an ordered list of targets.
+type ModelFailoverRule struct {
+	// Alias is the model name clients request, e.g. "gpt-4-class".
+	Alias string `yaml:"alias" json:"alias"`
+	// Targets are tried in order until one does not fail with 429 or 5xx.
+	Targets []ModelFailoverTarget `yaml:"targets" json:"targets"`
+}
+
+// ModelFailoverTarget is one provider/model pair of a failover rule.
+type ModelFailoverTarget struct {
+	// Provider restricts the target to one provider (e.g. "claude", "gemini" or an
+	// openai-compatibility name). Empty allows every provider serving Model.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Model is the model name sent to the target provider.
+	Model string `yaml:"model" json:"model"`
+}
+
+// SanitizeModelFailover normalizes failover rules and drops rules without an alias or targets.
+// Aliases are matched case-insensitively; the first rule for an alias wins.
+func (cfg *Config) SanitizeModelFailover() {
+	if cfg == nil {
+		return
+	}
+	rules := make([]ModelFailoverRule, 0, len(cfg.ModelFailover))
+	seen := make(map[string]struct{}, len(cfg.ModelFailover))
+	for _, rule := range cfg.ModelFailover {
+		rule.Alias = strings.TrimSpace(rule.Alias)
+		key := strings.ToLower(rule.Alias)
+		if key == "" {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		targets := make([]ModelFailoverTarget, 0, len(rule.Targets))
+		for _, target := range rule.Targets {
+			target.Provider = strings.ToLower(strings.TrimSpace(target.Provider))
+			target.Model = strings.TrimSpace(target.Model)
+			if target.Model == "" {
+				continue
+			}
+			targets = append(targets, target)
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		seen[key] = struct{}{}
+		rule.Targets = targets
+		rules = append(rules, rule)
+	}
+	cfg.ModelFailover = rules
+}
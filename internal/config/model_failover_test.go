@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestSanitizeModelFailover(t *testing.T) {
+	cfg := &Config{SDKConfig: SDKConfig{ModelFailover: []ModelFailoverRule{
+		{Alias: " gpt-4-class ", Targets: []ModelFailoverTarget{
+			{Provider: " Codex ", Model: " gpt-5 "},
+			{Provider: "claude", Model: ""},
+			{Model: "gemini-2.5-pro"},
+		}},
+		{Alias: "GPT-4-CLASS", Targets: []ModelFailoverTarget{{Model: "ignored"}}},
+		{Alias: "empty", Targets: []ModelFailoverTarget{{Provider: "claude"}}},
+		{Alias: "", Targets: []ModelFailoverTarget{{Model: "gpt-5"}}},
+	}}}
+
+	cfg.SanitizeModelFailover()
+
+	if got := len(cfg.ModelFailover); got != 1 {
+		t.Fatalf("rules = %d, want 1: %+v", got, cfg.ModelFailover)
+	}
+	rule := cfg.ModelFailover[0]
+	if rule.Alias != "gpt-4-class" || len(rule.Targets) != 2 {
+		t.Fatalf("rule = %+v, want trimmed alias with two targets", rule)
+	}
+	if rule.Targets[0] != (ModelFailoverTarget{Provider: "codex", Model: "gpt-5"}) {
+		t.Fatalf("first target = %+v, want normalized codex/gpt-5", rule.Targets[0])
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"math"
+	"strings"
+)
+
+// ModelPricingEntry sets the token prices of one model for usage cost estimation.
+// Prices are in USD per million tokens and override models.json pricing.
+type ModelPricingEntry struct {
+	// Model is the upstream model name or client-facing alias.
+	Model string `yaml:"model" json:"model"`
+	// InputPerMillion is the price of uncached prompt tokens.
+	InputPerMillion float64 `yaml:"input-per-million" json:"input-per-million"`
+	// OutputPerMillion is the price of completion tokens, including reasoning.
+	OutputPerMillion float64 `yaml:"output-per-million" json:"output-per-million"`
+	// CacheReadPerMillion is the price of cache-read prompt tokens. Defaults to InputPerMillion.
+	CacheReadPerMillion float64 `yaml:"cache-read-per-million,omitempty" json:"cache-read-per-million,omitempty"`
+	// CacheWritePerMillion is the price of cache-write prompt tokens. Defaults to InputPerMillion.
+	CacheWritePerMillion float64 `yaml:"cache-write-per-million,omitempty" json:"cache-write-per-million,omitempty"`
+}
+
+// SanitizeModelPricing drops entries without a model name or with invalid prices.
+func (cfg *Config) SanitizeModelPricing() {
+	if cfg == nil {
+		return
+	}
+	entries := make([]ModelPricingEntry, 0, len(cfg.ModelPricing))
+	seen := make(map[string]struct{}, len(cfg.ModelPricing))
+	for _, entry := range cfg.ModelPricing {
+		entry.Model = strings.TrimSpace(entry.Model)
+		if entry.Model == "" || !validModelPrice(entry.InputPerMillion) || !validModelPrice(entry.OutputPerMillion) ||
+			!validModelPrice(entry.CacheReadPerMillion) || !validModelPrice(entry.CacheWritePerMillion) {
+			continue
+		}
+		key := strings.ToLower(entry.Model)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		entries = append(entries, entry)
+	}
+	cfg.ModelPricing = entries
+}
+
+func validModelPrice(price float64) bool {
+	return price >= 0 && !math.IsNaN(price) && !math.IsInf(price, 0)
+}
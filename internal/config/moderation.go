@@ -0,0 +1,131 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Moderation stages and actions.
+const (
+	ModerationStageInput  = "input"
+	ModerationStageOutput = "output"
+
+	ModerationActionBlock    = "block"
+	ModerationActionAnnotate = "annotate"
+	ModerationActionLog      = "log"
+)
+
+const (
+	// DefaultModerationEndpoint is the OpenAI moderations API.
+	DefaultModerationEndpoint = "https://api.openai.com/v1/moderations"
+	// DefaultModerationModel is sent when moderation.model is unset.
+	DefaultModerationModel = "omni-moderation-latest"
+	// DefaultModerationTimeout bounds one moderation call when moderation.timeout-seconds is unset.
+	DefaultModerationTimeout = 10 * time.Second
+	// DefaultModerationStreamCheckChars is how much new streamed text triggers another check.
+	DefaultModerationStreamCheckChars = 2000
+)
+
+// ModerationConfig sends prompts and completions to a moderation backend that speaks the
+// OpenAI moderations API, and blocks, annotates or logs flagged content.
+type ModerationConfig struct {
+	// Enable turns moderation on.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Endpoint is the moderations URL. Default: the OpenAI moderations API.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// APIKey is sent as a bearer token.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+	// Model is the moderation model. Default: omni-moderation-latest.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// Stages lists what is checked: "input" (the latest user turn) and/or "output" (the
+	// completion). Default: both.
+	Stages []string `yaml:"stages,omitempty" json:"stages,omitempty"`
+	// Action is "block", "annotate" (X-CLIProxy-Moderation response header) or "log".
+	// Default: log.
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+	// Categories limits which flagged categories trigger the action. Empty uses the
+	// backend's overall flag.
+	Categories []string `yaml:"categories,omitempty" json:"categories,omitempty"`
+	// FailClosed blocks requests when the moderation backend fails. Default: let them through.
+	FailClosed bool `yaml:"fail-closed,omitempty" json:"fail-closed,omitempty"`
+	// TimeoutSeconds bounds each moderation call. Default: 10.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+	// StreamCheckChars re-checks a streamed completion whenever this much new text arrived.
+	// Default: 2000.
+	StreamCheckChars int `yaml:"stream-check-chars,omitempty" json:"stream-check-chars,omitempty"`
+}
+
+// Checks reports whether stage is moderated.
+func (c ModerationConfig) Checks(stage string) bool {
+	if !c.Enable {
+		return false
+	}
+	for _, configured := range c.Stages {
+		if configured == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Timeout returns the per-call timeout with the default applied.
+func (c ModerationConfig) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultModerationTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// SanitizeModeration normalizes moderation settings and applies defaults.
+func (cfg *Config) SanitizeModeration() {
+	if cfg == nil {
+		return
+	}
+	moderation := &cfg.Moderation
+	moderation.Endpoint = strings.TrimSpace(moderation.Endpoint)
+	if moderation.Endpoint == "" {
+		moderation.Endpoint = DefaultModerationEndpoint
+	}
+	moderation.APIKey = strings.TrimSpace(moderation.APIKey)
+	moderation.Model = strings.TrimSpace(moderation.Model)
+	if moderation.Model == "" {
+		moderation.Model = DefaultModerationModel
+	}
+	if len(moderation.Stages) == 0 {
+		moderation.Stages = []string{ModerationStageInput, ModerationStageOutput}
+	}
+	stages := make([]string, 0, len(moderation.Stages))
+	for _, stage := range moderation.Stages {
+		switch stage = strings.ToLower(strings.TrimSpace(stage)); stage {
+		case ModerationStageInput, ModerationStageOutput:
+			stages = append(stages, stage)
+		default:
+			log.Warnf("moderation: ignoring unknown stage %q", stage)
+		}
+	}
+	moderation.Stages = stages
+	switch action := strings.ToLower(strings.TrimSpace(moderation.Action)); action {
+	case ModerationActionBlock, ModerationActionAnnotate, ModerationActionLog:
+		moderation.Action = action
+	case "":
+		moderation.Action = ModerationActionLog
+	default:
+		log.Warnf("moderation: unknown action %q, using log", action)
+		moderation.Action = ModerationActionLog
+	}
+	categories := make([]string, 0, len(moderation.Categories))
+	for _, category := range moderation.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	moderation.Categories = categories
+	if moderation.TimeoutSeconds < 0 {
+		moderation.TimeoutSeconds = 0
+	}
+	if moderation.StreamCheckChars <= 0 {
+		moderation.StreamCheckChars = DefaultModerationStreamCheckChars
+	}
+}
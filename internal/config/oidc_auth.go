@@ -0,0 +1,114 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultOIDCJWKSCacheTTL is how long fetched signing keys are reused before a refresh.
+	DefaultOIDCJWKSCacheTTL = time.Hour
+	// DefaultOIDCClockSkew tolerates clock drift when checking exp, nbf and iat.
+	DefaultOIDCClockSkew = time.Minute
+)
+
+// OIDCAuthConfig accepts JWTs issued by an OIDC provider as client credentials, so the proxy
+// can sit behind corporate SSO instead of handing out static API keys.
+type OIDCAuthConfig struct {
+	// Enable turns JWT authentication on.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Issuer must equal the token's iss claim. It is also used for discovery of the JWKS URL.
+	Issuer string `yaml:"issuer" json:"issuer"`
+	// Audiences lists accepted aud values; a token must carry at least one. Empty skips the check.
+	Audiences []string `yaml:"audiences,omitempty" json:"audiences,omitempty"`
+	// JWKSURL overrides the jwks_uri from the issuer's discovery document.
+	JWKSURL string `yaml:"jwks-url,omitempty" json:"jwks-url,omitempty"`
+	// JWKSCacheTTL is how long signing keys are cached, e.g. "1h". Unknown key ids always
+	// trigger a refresh. Default: 1h.
+	JWKSCacheTTL string `yaml:"jwks-cache-ttl,omitempty" json:"jwks-cache-ttl,omitempty"`
+	// ClockSkew tolerates clock drift for exp, nbf and iat, e.g. "30s". Default: 1m.
+	ClockSkew string `yaml:"clock-skew,omitempty" json:"clock-skew,omitempty"`
+	// PrincipalClaim names the claim identifying the caller for usage accounting, rate limits
+	// and quotas. Dotted paths reach nested claims. Default: "sub".
+	PrincipalClaim string `yaml:"principal-claim,omitempty" json:"principal-claim,omitempty"`
+	// DisableAPIKeys rejects static api-keys entries while JWT authentication is enabled.
+	DisableAPIKeys bool `yaml:"disable-api-keys,omitempty" json:"disable-api-keys,omitempty"`
+	// RequireScope rejects tokens that match none of the scopes.
+	RequireScope bool `yaml:"require-scope,omitempty" json:"require-scope,omitempty"`
+	// Scopes map claim values to access scopes. A token matching several scopes gets their union.
+	Scopes []OIDCScopeMapping `yaml:"scopes,omitempty" json:"scopes,omitempty"`
+}
+
+// OIDCScopeMapping grants an access scope to tokens whose claim contains a value.
+type OIDCScopeMapping struct {
+	// Claim is the claim to inspect, e.g. "groups", "scope" or "realm_access.roles". String
+	// claims are split on spaces, so OAuth "scope" strings work.
+	Claim string `yaml:"claim" json:"claim"`
+	// Value must be one of the claim's values.
+	Value       string `yaml:"value" json:"value"`
+	AccessScope `yaml:",inline"`
+}
+
+// CacheTTL returns the parsed JWKS cache lifetime.
+func (c OIDCAuthConfig) CacheTTL() time.Duration {
+	return parseDurationOr(c.JWKSCacheTTL, DefaultOIDCJWKSCacheTTL)
+}
+
+// Skew returns the parsed clock skew tolerance.
+func (c OIDCAuthConfig) Skew() time.Duration {
+	return parseDurationOr(c.ClockSkew, DefaultOIDCClockSkew)
+}
+
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	parsed, errParse := time.ParseDuration(strings.TrimSpace(value))
+	if errParse != nil || parsed < 0 {
+		return fallback
+	}
+	return parsed
+}
+
+// SanitizeOIDCAuth trims values, drops incomplete scope mappings and disables JWT
+// authentication when no issuer is configured.
+func (cfg *Config) SanitizeOIDCAuth() {
+	if cfg == nil {
+		return
+	}
+	oidc := &cfg.OIDCAuth
+	oidc.Issuer = strings.TrimRight(strings.TrimSpace(oidc.Issuer), "/")
+	oidc.JWKSURL = strings.TrimSpace(oidc.JWKSURL)
+	oidc.PrincipalClaim = strings.TrimSpace(oidc.PrincipalClaim)
+	if oidc.PrincipalClaim == "" {
+		oidc.PrincipalClaim = "sub"
+	}
+	audiences := make([]string, 0, len(oidc.Audiences))
+	for _, audience := range oidc.Audiences {
+		if audience = strings.TrimSpace(audience); audience != "" {
+			audiences = append(audiences, audience)
+		}
+	}
+	oidc.Audiences = audiences
+	for _, entry := range []struct{ name, value string }{{"jwks-cache-ttl", oidc.JWKSCacheTTL}, {"clock-skew", oidc.ClockSkew}} {
+		if strings.TrimSpace(entry.value) == "" {
+			continue
+		}
+		if parsed, errParse := time.ParseDuration(strings.TrimSpace(entry.value)); errParse != nil || parsed < 0 {
+			log.Warnf("oidc-auth.%s %q is invalid; using the default", entry.name, entry.value)
+		}
+	}
+	scopes := make([]OIDCScopeMapping, 0, len(oidc.Scopes))
+	for _, scope := range oidc.Scopes {
+		scope.Claim = strings.TrimSpace(scope.Claim)
+		scope.Value = strings.TrimSpace(scope.Value)
+		if scope.Claim == "" || scope.Value == "" {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	oidc.Scopes = scopes
+	if oidc.Enable && oidc.Issuer == "" {
+		log.Warn("oidc-auth is enabled without an issuer; JWT authentication stays disabled")
+		oidc.Enable = false
+	}
+}
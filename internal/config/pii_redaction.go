@@ -0,0 +1,104 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Built-in PII redaction detectors.
+const (
+	PIIDetectorEmail      = "email"
+	PIIDetectorCreditCard = "credit-card"
+)
+
+// DefaultPIIRedactionAuditEntries bounds the redaction audit log when
+// pii-redaction.audit-entries is unset.
+const DefaultPIIRedactionAuditEntries = 1000
+
+// PIIRedactionConfig scrubs sensitive text from request bodies before they are sent upstream.
+type PIIRedactionConfig struct {
+	// Enable turns redaction on.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Detectors lists the built-in detectors to run: "email" and "credit-card".
+	// Default: both.
+	Detectors []string `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+	// Patterns are additional regular expressions to redact.
+	Patterns []PIIRedactionPattern `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+	// Terms are dictionary words and phrases redacted case-insensitively.
+	Terms []string `yaml:"terms,omitempty" json:"terms,omitempty"`
+	// ExemptAPIKeys are inbound client API keys whose requests are sent unredacted.
+	ExemptAPIKeys []string `yaml:"exempt-api-keys,omitempty" json:"exempt-api-keys,omitempty"`
+	// AuditEntries bounds the in-memory log of per-request redaction counts. Default: 1000.
+	AuditEntries int `yaml:"audit-entries,omitempty" json:"audit-entries,omitempty"`
+}
+
+// PIIRedactionPattern is a named regular expression to redact.
+type PIIRedactionPattern struct {
+	// Name labels the pattern in replacements and audit counts.
+	Name string `yaml:"name" json:"name"`
+	// Regex is the Go regular expression to match.
+	Regex string `yaml:"regex" json:"regex"`
+}
+
+// SanitizePIIRedaction normalizes detector names, drops unknown detectors, invalid patterns
+// and empty terms, and applies defaults.
+func (cfg *Config) SanitizePIIRedaction() {
+	if cfg == nil {
+		return
+	}
+	redaction := &cfg.PIIRedaction
+	if redaction.Detectors == nil {
+		redaction.Detectors = []string{PIIDetectorEmail, PIIDetectorCreditCard}
+	}
+	detectors := make([]string, 0, len(redaction.Detectors))
+	for _, detector := range redaction.Detectors {
+		detector = strings.ToLower(strings.TrimSpace(detector))
+		switch detector {
+		case PIIDetectorEmail, PIIDetectorCreditCard:
+			detectors = append(detectors, detector)
+		case "":
+		default:
+			log.Warnf("pii-redaction: ignoring unknown detector %q", detector)
+		}
+	}
+	redaction.Detectors = detectors
+
+	patterns := make([]PIIRedactionPattern, 0, len(redaction.Patterns))
+	for _, pattern := range redaction.Patterns {
+		pattern.Name = strings.TrimSpace(pattern.Name)
+		if pattern.Regex == "" {
+			continue
+		}
+		if _, errCompile := regexp.Compile(pattern.Regex); errCompile != nil {
+			log.Warnf("pii-redaction: ignoring pattern %q: %v", pattern.Name, errCompile)
+			continue
+		}
+		if pattern.Name == "" {
+			pattern.Name = "custom"
+		}
+		patterns = append(patterns, pattern)
+	}
+	redaction.Patterns = patterns
+
+	terms := make([]string, 0, len(redaction.Terms))
+	for _, term := range redaction.Terms {
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	redaction.Terms = terms
+
+	keys := make([]string, 0, len(redaction.ExemptAPIKeys))
+	for _, key := range redaction.ExemptAPIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	redaction.ExemptAPIKeys = keys
+
+	if redaction.AuditEntries <= 0 {
+		redaction.AuditEntries = DefaultPIIRedactionAuditEntries
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import "strings"
+
+// defaultPreflightMinRequestBytes is the request size above which the pre-flight token check runs.
+const defaultPreflightMinRequestBytes = 256 << 10
+
+// Preflight truncation strategies for requests that exceed the model's input limit.
+const (
+	// PreflightStrategyReject rejects the request with a 400.
+	PreflightStrategyReject = "reject"
+	// PreflightStrategyDropOldest drops the oldest conversation turns.
+	PreflightStrategyDropOldest = "drop-oldest"
+	// PreflightStrategyDropMiddle keeps the first turn and drops the turns after it.
+	PreflightStrategyDropMiddle = "drop-middle"
+)
+
+// PreflightTokenCheckConfig counts the input tokens of large requests before sending them and
+// rejects or truncates requests that cannot fit the model's input limit, so guaranteed
+// context-overflow failures do not spend upstream quota.
+type PreflightTokenCheckConfig struct {
+	// Enable turns the pre-flight check on.
+	Enable bool `yaml:"enable" json:"enable"`
+	// MinRequestBytes skips requests with smaller bodies. Defaults to 262144 (256 KiB).
+	MinRequestBytes int `yaml:"min-request-bytes,omitempty" json:"min-request-bytes,omitempty"`
+	// ReserveOutputTokens is subtracted from the model's context window when the model has no
+	// separate input limit, leaving room for the response.
+	ReserveOutputTokens int `yaml:"reserve-output-tokens,omitempty" json:"reserve-output-tokens,omitempty"`
+	// Strategy selects what happens to oversized requests: "reject" (default), "drop-oldest"
+	// or "drop-middle". System prompts and the latest turn are always kept. Dropped turns are
+	// removed, not summarized; unknown strategies fall back to "reject".
+	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+}
+
+// SanitizePreflightTokenCheck applies the default size threshold and strategy and clamps
+// negative values.
+func (cfg *Config) SanitizePreflightTokenCheck() {
+	if cfg == nil {
+		return
+	}
+	check := &cfg.PreflightTokenCheck
+	if check.MinRequestBytes <= 0 {
+		check.MinRequestBytes = defaultPreflightMinRequestBytes
+	}
+	if check.ReserveOutputTokens < 0 {
+		check.ReserveOutputTokens = 0
+	}
+	check.Strategy = strings.ToLower(strings.TrimSpace(check.Strategy))
+	switch check.Strategy {
+	case PreflightStrategyDropOldest, PreflightStrategyDropMiddle:
+	default:
+		check.Strategy = PreflightStrategyReject
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import "strings"
+
+// PromptRule rewrites the system prompt of matching requests before they are translated for
+// the upstream provider. A rule matches when every configured selector matches; an empty
+// selector matches all requests. All matching rules apply, in order.
+type PromptRule struct {
+	// Name identifies the rule in logs.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Models are case-insensitive model name patterns where '*' matches any substring, e.g.
+	// "claude-*". They match the model the request is sent to, after aliases and failover.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// APIKeys are the inbound client API keys the rule applies to.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+	// Routes are request path patterns where '*' matches any substring, e.g. "/v1/messages".
+	Routes []string `yaml:"routes,omitempty" json:"routes,omitempty"`
+	// Template replaces the system prompt. "{{system}}" expands to the client's system prompt
+	// and "{{model}}" to the model name.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+	// Prepend is placed before the system prompt.
+	Prepend string `yaml:"prepend,omitempty" json:"prepend,omitempty"`
+	// Append is placed after the system prompt.
+	Append string `yaml:"append,omitempty" json:"append,omitempty"`
+}
+
+// SanitizePromptRules trims prompt rule selectors and drops rules that change nothing.
+func (cfg *Config) SanitizePromptRules() {
+	if cfg == nil {
+		return
+	}
+	rules := make([]PromptRule, 0, len(cfg.PromptRules))
+	for _, rule := range cfg.PromptRules {
+		rule.Name = strings.TrimSpace(rule.Name)
+		rule.Models = trimNonEmpty(rule.Models)
+		rule.APIKeys = trimNonEmpty(rule.APIKeys)
+		rule.Routes = trimNonEmpty(rule.Routes)
+		if strings.TrimSpace(rule.Template) == "" {
+			rule.Template = ""
+		}
+		if strings.TrimSpace(rule.Prepend) == "" {
+			rule.Prepend = ""
+		}
+		if strings.TrimSpace(rule.Append) == "" {
+			rule.Append = ""
+		}
+		if rule.Template == "" && rule.Prepend == "" && rule.Append == "" {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	cfg.PromptRules = rules
+}
+
+func trimNonEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProviderProxy routes one provider's upstream traffic through its own egress proxy.
+type ProviderProxy struct {
+	// Provider is the provider identifier (e.g. "gemini", "claude") or an openai-compatibility name.
+	Provider string `yaml:"provider" json:"provider"`
+	// ProxyURL is an http://, https://, socks5:// or socks5h:// proxy, optionally with
+	// user:password credentials, or "direct" to bypass the global proxy.
+	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
+}
+
+// ProviderProxyURL returns the proxy for provider: its provider-proxies entry when present,
+// otherwise the global proxy-url. Credential and API key proxy-url settings take precedence
+// over both and are resolved by the caller.
+func (cfg *Config) ProviderProxyURL(provider string) string {
+	if cfg == nil {
+		return ""
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider != "" {
+		for _, entry := range cfg.ProviderProxies {
+			if entry.Provider == provider {
+				return entry.ProxyURL
+			}
+		}
+	}
+	return strings.TrimSpace(cfg.ProxyURL)
+}
+
+// SanitizeProviderProxies normalizes provider names and drops entries with an invalid proxy.
+func (cfg *Config) SanitizeProviderProxies() {
+	if cfg == nil {
+		return
+	}
+	entries := make([]ProviderProxy, 0, len(cfg.ProviderProxies))
+	seen := make(map[string]struct{}, len(cfg.ProviderProxies))
+	for _, entry := range cfg.ProviderProxies {
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		if entry.Provider == "" || entry.ProxyURL == "" {
+			continue
+		}
+		if _, errParse := proxyutil.Parse(entry.ProxyURL); errParse != nil {
+			log.Warnf("provider-proxies: ignoring proxy %s for provider %s: %v", proxyutil.Redact(entry.ProxyURL), entry.Provider, errParse)
+			continue
+		}
+		if _, exists := seen[entry.Provider]; exists {
+			continue
+		}
+		seen[entry.Provider] = struct{}{}
+		entries = append(entries, entry)
+	}
+	cfg.ProviderProxies = entries
+}
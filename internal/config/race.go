@@ -0,0 +1,44 @@
+package config
+
+import "strings"
+
+// DefaultRaceMaxFanout caps the number of providers a request may race when MaxFanout is unset.
+const DefaultRaceMaxFanout = 3
+
+// RaceConfig configures race mode, which sends a request to several providers at once, returns
+// the first response and records comparative latency per model and provider.
+type RaceConfig struct {
+	// Fanout is the number of providers raced for requests to matching models, one attempt per
+	// provider. Values <= 1 disable racing unless a client opts in with the X-CPA-Race header.
+	// Models served by a single provider are not raced.
+	Fanout int `yaml:"fanout,omitempty" json:"fanout,omitempty"`
+	// Models limits configured racing to models matching these case-insensitive patterns, where
+	// '*' matches any substring. Empty races every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// AllowHeader lets clients race a single request by sending X-CPA-Race: <n>.
+	AllowHeader bool `yaml:"allow-header,omitempty" json:"allow-header,omitempty"`
+	// MaxFanout caps the providers raced per request. Defaults to DefaultRaceMaxFanout.
+	MaxFanout int `yaml:"max-fanout,omitempty" json:"max-fanout,omitempty"`
+}
+
+// SanitizeRace clamps the fanout limits and normalizes race model patterns.
+func (cfg *Config) SanitizeRace() {
+	if cfg == nil {
+		return
+	}
+	race := &cfg.Race
+	if race.MaxFanout <= 0 {
+		race.MaxFanout = DefaultRaceMaxFanout
+	}
+	if race.Fanout < 0 {
+		race.Fanout = 0
+	}
+	race.Fanout = min(race.Fanout, race.MaxFanout)
+	models := make([]string, 0, len(race.Models))
+	for _, model := range race.Models {
+		if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+			models = append(models, model)
+		}
+	}
+	race.Models = models
+}
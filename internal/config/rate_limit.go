@@ -0,0 +1,134 @@
+package config
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RateLimitConfig configures token-bucket request limits keyed by the inbound client API key.
+type RateLimitConfig struct {
+	// Enable toggles request rate limiting.
+	Enable bool `yaml:"enable" json:"enable"`
+	// RPS is the default sustained request rate allowed for each client API key.
+	// Values <= 0 leave keys without an override unlimited.
+	RPS float64 `yaml:"rps,omitempty" json:"rps,omitempty"`
+	// Burst is the default bucket size for each client API key. Defaults to ceil(rps).
+	Burst int `yaml:"burst,omitempty" json:"burst,omitempty"`
+	// Keys overrides the default limit for specific client API keys.
+	Keys []RateLimitKey `yaml:"keys,omitempty" json:"keys,omitempty"`
+	// Providers limits how fast each client API key may call a given upstream provider.
+	// Every client key gets its own bucket per provider, charged only when a request is sent to
+	// that provider. Providers whose bucket is empty are skipped while others serve the model.
+	Providers []RateLimitProvider `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// WarnOnlyUntil is an RFC3339 timestamp before which exceeded limits are only logged and
+	// reported in a warning header instead of rejecting the request. Entries may set their own.
+	WarnOnlyUntil string `yaml:"warn-only-until,omitempty" json:"warn-only-until,omitempty"`
+}
+
+// RateLimitKey overrides the default rate limit for one client API key.
+type RateLimitKey struct {
+	APIKey        string  `yaml:"api-key" json:"api-key"`
+	RPS           float64 `yaml:"rps" json:"rps"`
+	Burst         int     `yaml:"burst,omitempty" json:"burst,omitempty"`
+	WarnOnlyUntil string  `yaml:"warn-only-until,omitempty" json:"warn-only-until,omitempty"`
+}
+
+// RateLimitProvider configures the per-client limit for one upstream provider.
+type RateLimitProvider struct {
+	Provider      string  `yaml:"provider" json:"provider"`
+	RPS           float64 `yaml:"rps" json:"rps"`
+	Burst         int     `yaml:"burst,omitempty" json:"burst,omitempty"`
+	WarnOnlyUntil string  `yaml:"warn-only-until,omitempty" json:"warn-only-until,omitempty"`
+}
+
+// WarnOnlyDeadline returns the end of the warn-only period of an entry, falling back to the
+// section-wide value. The zero time means the limit is enforced.
+func WarnOnlyDeadline(entry, fallback string) time.Time {
+	value := strings.TrimSpace(entry)
+	if value == "" {
+		value = strings.TrimSpace(fallback)
+	}
+	if value == "" {
+		return time.Time{}
+	}
+	deadline, errParse := time.Parse(time.RFC3339, value)
+	if errParse != nil {
+		return time.Time{}
+	}
+	return deadline
+}
+
+func sanitizeWarnOnlyUntil(section, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	if _, errParse := time.Parse(time.RFC3339, value); errParse != nil {
+		log.Warnf("%s: ignoring warn-only-until %q: expected an RFC3339 timestamp", section, value)
+		return ""
+	}
+	return value
+}
+
+// SanitizeRateLimit drops invalid entries and normalizes burst sizes.
+func (cfg *Config) SanitizeRateLimit() {
+	if cfg == nil {
+		return
+	}
+	rl := &cfg.RateLimit
+	if rl.RPS < 0 || math.IsNaN(rl.RPS) || math.IsInf(rl.RPS, 0) {
+		rl.RPS = 0
+	}
+	rl.Burst = normalizeRateLimitBurst(rl.RPS, rl.Burst)
+	rl.WarnOnlyUntil = sanitizeWarnOnlyUntil("rate-limit", rl.WarnOnlyUntil)
+
+	keys := make([]RateLimitKey, 0, len(rl.Keys))
+	seenKeys := make(map[string]struct{}, len(rl.Keys))
+	for _, entry := range rl.Keys {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" || math.IsNaN(entry.RPS) || math.IsInf(entry.RPS, 0) {
+			continue
+		}
+		if _, exists := seenKeys[entry.APIKey]; exists {
+			continue
+		}
+		seenKeys[entry.APIKey] = struct{}{}
+		if entry.RPS < 0 {
+			entry.RPS = 0
+		}
+		entry.Burst = normalizeRateLimitBurst(entry.RPS, entry.Burst)
+		entry.WarnOnlyUntil = sanitizeWarnOnlyUntil("rate-limit", entry.WarnOnlyUntil)
+		keys = append(keys, entry)
+	}
+	rl.Keys = keys
+
+	providers := make([]RateLimitProvider, 0, len(rl.Providers))
+	seenProviders := make(map[string]struct{}, len(rl.Providers))
+	for _, entry := range rl.Providers {
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		if entry.Provider == "" || entry.RPS <= 0 || math.IsNaN(entry.RPS) || math.IsInf(entry.RPS, 0) {
+			continue
+		}
+		if _, exists := seenProviders[entry.Provider]; exists {
+			continue
+		}
+		seenProviders[entry.Provider] = struct{}{}
+		entry.Burst = normalizeRateLimitBurst(entry.RPS, entry.Burst)
+		entry.WarnOnlyUntil = sanitizeWarnOnlyUntil("rate-limit", entry.WarnOnlyUntil)
+		providers = append(providers, entry)
+	}
+	rl.Providers = providers
+}
+
+func normalizeRateLimitBurst(rps float64, burst int) int {
+	if rps <= 0 {
+		return 0
+	}
+	if burst > 0 {
+		return burst
+	}
+	return max(1, int(math.Ceil(rps)))
+}
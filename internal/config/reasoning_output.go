@@ -0,0 +1,34 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Reasoning output modes.
+const (
+	// ReasoningOutputPassthrough returns reasoning the way the upstream and translators produce it.
+	ReasoningOutputPassthrough = "passthrough"
+	// ReasoningOutputExpose moves reasoning into the client protocol's reasoning field.
+	ReasoningOutputExpose = "expose"
+	// ReasoningOutputStrip removes reasoning from responses.
+	ReasoningOutputStrip = "strip"
+)
+
+// SanitizeReasoningOutput normalizes the reasoning output mode and defaults unknown values to
+// passthrough.
+func (cfg *Config) SanitizeReasoningOutput() {
+	if cfg == nil {
+		return
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(cfg.ReasoningOutput)); mode {
+	case ReasoningOutputExpose, ReasoningOutputStrip:
+		cfg.ReasoningOutput = mode
+	case "", ReasoningOutputPassthrough:
+		cfg.ReasoningOutput = ReasoningOutputPassthrough
+	default:
+		log.Warnf("reasoning-output: unknown mode %q, using %s", mode, ReasoningOutputPassthrough)
+		cfg.ReasoningOutput = ReasoningOutputPassthrough
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultRegionProbeInterval is the latency probe interval used when region-routing.interval is unset or invalid.
+const DefaultRegionProbeInterval = time.Minute
+
+// minRegionProbeInterval prevents the region prober from hammering upstream model endpoints.
+const minRegionProbeInterval = 10 * time.Second
+
+// RegionEndpoint is one regional base URL of a credential.
+type RegionEndpoint struct {
+	// Name identifies the region, e.g. "us-east" or "eu".
+	Name string `yaml:"name" json:"name"`
+	// BaseURL replaces the credential base-url while the region is selected.
+	BaseURL string `yaml:"base-url" json:"base-url"`
+}
+
+// RegionRoutingConfig configures latency-based selection among the regions of a credential.
+type RegionRoutingConfig struct {
+	// Enable toggles region probing and selection. When false, credentials use their base-url.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Interval controls how often every region is probed.
+	// Default: 1m. Values below 10s are raised to 10s.
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// IntervalDuration returns the parsed probe interval with defaults and bounds applied.
+func (c RegionRoutingConfig) IntervalDuration() time.Duration {
+	raw := strings.TrimSpace(c.Interval)
+	if raw == "" {
+		return DefaultRegionProbeInterval
+	}
+	interval, errParse := time.ParseDuration(raw)
+	if errParse != nil || interval <= 0 {
+		return DefaultRegionProbeInterval
+	}
+	if interval < minRegionProbeInterval {
+		return minRegionProbeInterval
+	}
+	return interval
+}
+
+// NormalizeRegions lowercases region names, trims base URLs and drops incomplete or duplicate entries.
+func NormalizeRegions(regions []RegionEndpoint) []RegionEndpoint {
+	if len(regions) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(regions))
+	out := make([]RegionEndpoint, 0, len(regions))
+	for _, region := range regions {
+		region.Name = strings.ToLower(strings.TrimSpace(region.Name))
+		region.BaseURL = strings.TrimRight(strings.TrimSpace(region.BaseURL), "/")
+		if region.Name == "" || region.BaseURL == "" {
+			continue
+		}
+		if _, exists := seen[region.Name]; exists {
+			continue
+		}
+		seen[region.Name] = struct{}{}
+		out = append(out, region)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Request priority classes.
+const (
+	RequestPriorityInteractive = "interactive"
+	RequestPriorityBatch       = "batch"
+)
+
+// DefaultRequestQueueMaxDepth is the number of requests allowed to wait per priority class.
+const DefaultRequestQueueMaxDepth = 100
+
+// RequestQueueConfig configures the priority scheduler that admits requests to upstream execution.
+type RequestQueueConfig struct {
+	// Enable toggles the scheduler.
+	Enable bool `yaml:"enable" json:"enable"`
+	// MaxConcurrent is the number of requests executed at once; further requests wait.
+	// Values <= 0 disable the scheduler.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+	// BatchMaxConcurrent caps the batch requests executed at once so some capacity stays free
+	// for interactive requests. 0 lets batch requests use every slot.
+	BatchMaxConcurrent int `yaml:"batch-max-concurrent,omitempty" json:"batch-max-concurrent,omitempty"`
+	// MaxDepth is the number of requests that may wait in each priority class before new ones
+	// are rejected with 429. Defaults to DefaultRequestQueueMaxDepth.
+	MaxDepth int `yaml:"max-depth,omitempty" json:"max-depth,omitempty"`
+	// MaxWait bounds how long a request waits for a slot before it is rejected with 503,
+	// e.g. "30s". Empty waits until the client gives up.
+	MaxWait string `yaml:"max-wait,omitempty" json:"max-wait,omitempty"`
+	// DefaultPriority is the class of client API keys without an entry in Keys.
+	// Supported values: "interactive" (default), "batch".
+	DefaultPriority string `yaml:"default-priority,omitempty" json:"default-priority,omitempty"`
+	// Keys assigns priority classes to client API keys.
+	Keys []RequestQueueKey `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// RequestQueueKey assigns a priority class to one client API key.
+type RequestQueueKey struct {
+	APIKey   string `yaml:"api-key" json:"api-key"`
+	Priority string `yaml:"priority" json:"priority"`
+}
+
+// MaxWaitDuration returns the parsed wait limit; 0 means no limit.
+func (c RequestQueueConfig) MaxWaitDuration() time.Duration {
+	wait, errParse := time.ParseDuration(strings.TrimSpace(c.MaxWait))
+	if errParse != nil || wait <= 0 {
+		return 0
+	}
+	return wait
+}
+
+// SanitizeRequestQueue normalizes priority classes and drops invalid key entries.
+func (cfg *Config) SanitizeRequestQueue() {
+	if cfg == nil {
+		return
+	}
+	rq := &cfg.RequestQueue
+	if rq.MaxConcurrent < 0 {
+		rq.MaxConcurrent = 0
+	}
+	if rq.BatchMaxConcurrent < 0 || rq.BatchMaxConcurrent >= rq.MaxConcurrent {
+		rq.BatchMaxConcurrent = 0
+	}
+	if rq.MaxDepth <= 0 {
+		rq.MaxDepth = DefaultRequestQueueMaxDepth
+	}
+	rq.MaxWait = strings.TrimSpace(rq.MaxWait)
+	if rq.MaxWait != "" && rq.MaxWaitDuration() == 0 {
+		log.Warnf("request-queue: ignoring max-wait %q: expected a positive duration", rq.MaxWait)
+		rq.MaxWait = ""
+	}
+	rq.DefaultPriority = normalizeRequestPriority(rq.DefaultPriority)
+	if rq.DefaultPriority == "" {
+		rq.DefaultPriority = RequestPriorityInteractive
+	}
+
+	keys := make([]RequestQueueKey, 0, len(rq.Keys))
+	seen := make(map[string]struct{}, len(rq.Keys))
+	for _, entry := range rq.Keys {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		priority := normalizeRequestPriority(entry.Priority)
+		if entry.APIKey == "" || priority == "" {
+			if entry.APIKey != "" {
+				log.Warnf("request-queue: ignoring unknown priority %q", entry.Priority)
+			}
+			continue
+		}
+		if _, exists := seen[entry.APIKey]; exists {
+			continue
+		}
+		seen[entry.APIKey] = struct{}{}
+		entry.Priority = priority
+		keys = append(keys, entry)
+	}
+	rq.Keys = keys
+}
+
+func normalizeRequestPriority(priority string) string {
+	switch priority = strings.ToLower(strings.TrimSpace(priority)); priority {
+	case RequestPriorityInteractive, RequestPriorityBatch:
+		return priority
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultResponseCacheTTL is how long cached responses are served when response-cache.ttl is unset.
+const DefaultResponseCacheTTL = 5 * time.Minute
+
+// DefaultResponseCacheMaxEntries bounds the in-memory response cache when max-entries is unset.
+const DefaultResponseCacheMaxEntries = 1000
+
+// defaultResponseCacheRedisPrefix namespaces response cache keys in a shared Redis instance.
+const defaultResponseCacheRedisPrefix = "cliproxy:response-cache:"
+
+// ResponseCacheConfig configures the opt-in cache for deterministic completions and model lists.
+type ResponseCacheConfig struct {
+	// Enable toggles the response cache.
+	Enable bool `yaml:"enable" json:"enable"`
+	// TTL controls how long a cached completion is served. Default: 5m.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	// ModelsTTL controls how long GET /models responses are served. Defaults to TTL.
+	ModelsTTL string `yaml:"models-ttl,omitempty" json:"models-ttl,omitempty"`
+	// MaxEntries bounds the in-memory LRU. Default: 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// RedisURL stores entries in Redis instead of memory, e.g. "redis://localhost:6379/0".
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+	// RedisPrefix namespaces cache keys in Redis. Default: "cliproxy:response-cache:".
+	RedisPrefix string `yaml:"redis-prefix,omitempty" json:"redis-prefix,omitempty"`
+}
+
+// TTLDuration returns the parsed completion TTL with defaults applied.
+func (c ResponseCacheConfig) TTLDuration() time.Duration {
+	return parseResponseCacheTTL(c.TTL, DefaultResponseCacheTTL)
+}
+
+// ModelsTTLDuration returns the parsed model list TTL, falling back to TTLDuration.
+func (c ResponseCacheConfig) ModelsTTLDuration() time.Duration {
+	return parseResponseCacheTTL(c.ModelsTTL, c.TTLDuration())
+}
+
+func parseResponseCacheTTL(raw string, fallback time.Duration) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return fallback
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil || ttl <= 0 {
+		return fallback
+	}
+	return ttl
+}
+
+// SanitizeResponseCache trims the Redis settings and applies the default entry limit.
+func (cfg *Config) SanitizeResponseCache() {
+	if cfg == nil {
+		return
+	}
+	rc := &cfg.ResponseCache
+	rc.TTL = strings.TrimSpace(rc.TTL)
+	rc.ModelsTTL = strings.TrimSpace(rc.ModelsTTL)
+	if rc.MaxEntries <= 0 {
+		rc.MaxEntries = DefaultResponseCacheMaxEntries
+	}
+	rc.RedisURL = strings.TrimSpace(rc.RedisURL)
+	rc.RedisPrefix = strings.TrimSpace(rc.RedisPrefix)
+	if rc.RedisURL != "" && rc.RedisPrefix == "" {
+		rc.RedisPrefix = defaultResponseCacheRedisPrefix
+	}
+}
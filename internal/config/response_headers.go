@@ -0,0 +1,45 @@
+package config
+
+import "strings"
+
+// ResponseHeadersConfig narrows which upstream response headers are forwarded to clients
+// when passthrough-headers is enabled. Entries are case-insensitive header names; a trailing
+// "*" matches a prefix, e.g. "x-ratelimit-*".
+type ResponseHeadersConfig struct {
+	// Allow forwards only matching headers. Empty forwards every header not denied.
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+	// Deny strips matching headers, even when they are also allowed.
+	Deny []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+}
+
+// SanitizeResponseHeaders lower-cases the allow and deny patterns and drops empty entries.
+func (cfg *Config) SanitizeResponseHeaders() {
+	if cfg == nil {
+		return
+	}
+	cfg.ResponseHeaders.Allow = normalizeHeaderPatterns(cfg.ResponseHeaders.Allow)
+	cfg.ResponseHeaders.Deny = normalizeHeaderPatterns(cfg.ResponseHeaders.Deny)
+}
+
+func normalizeHeaderPatterns(patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(patterns))
+	seen := make(map[string]struct{}, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, exists := seen[pattern]; exists {
+			continue
+		}
+		seen[pattern] = struct{}{}
+		out = append(out, pattern)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
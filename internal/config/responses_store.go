@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultResponsesStoreTTL is how long a stored response can be chained or retrieved when
+// responses-store.ttl is unset or invalid.
+const DefaultResponsesStoreTTL = 24 * time.Hour
+
+// DefaultResponsesStoreMaxEntries bounds the stored responses when responses-store.max-entries
+// is unset.
+const DefaultResponsesStoreMaxEntries = 1000
+
+// defaultResponsesStoreRedisPrefix namespaces stored responses in a shared Redis instance.
+const defaultResponsesStoreRedisPrefix = "cliproxy:responses:"
+
+// ResponsesStoreConfig configures the local state kept for the OpenAI Responses API:
+// previous_response_id chaining and background responses.
+type ResponsesStoreConfig struct {
+	// Enable stores responses locally instead of relying on upstream response state.
+	Enable bool `yaml:"enable" json:"enable"`
+	// TTL controls how long a stored response is kept. Default: 24h.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	// MaxEntries bounds the in-memory store; the oldest are evicted first. Default: 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// RedisURL stores responses in Redis instead of memory, e.g. "redis://localhost:6379/0", so
+	// they survive restarts and are shared between instances. It takes precedence over
+	// SQLitePath.
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+	// RedisPrefix namespaces stored responses in Redis. Default: "cliproxy:responses:".
+	RedisPrefix string `yaml:"redis-prefix,omitempty" json:"redis-prefix,omitempty"`
+	// SQLitePath stores responses in a SQLite database file instead of memory, so they survive
+	// restarts. Instances on one host may share the file.
+	SQLitePath string `yaml:"sqlite-path,omitempty" json:"sqlite-path,omitempty"`
+}
+
+// TTLDuration returns the parsed TTL with defaults applied.
+func (c ResponsesStoreConfig) TTLDuration() time.Duration {
+	raw := strings.TrimSpace(c.TTL)
+	if raw == "" {
+		return DefaultResponsesStoreTTL
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil || ttl <= 0 {
+		return DefaultResponsesStoreTTL
+	}
+	return ttl
+}
+
+// SanitizeResponsesStore trims the TTL, Redis and SQLite settings and applies the default entry limit.
+func (cfg *Config) SanitizeResponsesStore() {
+	if cfg == nil {
+		return
+	}
+	cfg.ResponsesStore.TTL = strings.TrimSpace(cfg.ResponsesStore.TTL)
+	if cfg.ResponsesStore.MaxEntries <= 0 {
+		cfg.ResponsesStore.MaxEntries = DefaultResponsesStoreMaxEntries
+	}
+	cfg.ResponsesStore.RedisURL = strings.TrimSpace(cfg.ResponsesStore.RedisURL)
+	cfg.ResponsesStore.RedisPrefix = strings.TrimSpace(cfg.ResponsesStore.RedisPrefix)
+	cfg.ResponsesStore.SQLitePath = strings.TrimSpace(cfg.ResponsesStore.SQLitePath)
+	if cfg.ResponsesStore.RedisURL != "" && cfg.ResponsesStore.RedisPrefix == "" {
+		cfg.ResponsesStore.RedisPrefix = defaultResponsesStoreRedisPrefix
+	}
+}
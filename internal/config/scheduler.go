@@ -0,0 +1,47 @@
+package config
+
+import "strings"
+
+// SchedulerConfig configures the embedded cron scheduler for background jobs.
+type SchedulerConfig struct {
+	// Enable toggles cron-driven runs. Jobs can still be triggered manually through the
+	// management API while the scheduler is disabled.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Jobs assigns cron expressions to registered jobs. A scheduled job takes over the periodic
+	// runs of its built-in loop; jobs without an entry keep their loop and run on demand.
+	Jobs []SchedulerJob `yaml:"jobs,omitempty" json:"jobs,omitempty"`
+}
+
+// SchedulerJob schedules one registered job.
+type SchedulerJob struct {
+	// Name identifies the job, e.g. "token-refresh" or "model-refresh".
+	Name string `yaml:"name" json:"name"`
+	// Cron is a standard five-field expression (minute hour day-of-month month day-of-week)
+	// or one of @hourly, @daily, @weekly, @monthly and @yearly. Times use the server's local zone.
+	Cron string `yaml:"cron" json:"cron"`
+	// Disabled keeps the entry but stops cron-driven runs of the job.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// SanitizeScheduler normalizes job names and drops entries without a name or cron expression.
+// Later entries for the same job are ignored.
+func (cfg *Config) SanitizeScheduler() {
+	if cfg == nil {
+		return
+	}
+	jobs := make([]SchedulerJob, 0, len(cfg.Scheduler.Jobs))
+	seen := make(map[string]struct{}, len(cfg.Scheduler.Jobs))
+	for _, job := range cfg.Scheduler.Jobs {
+		job.Name = strings.ToLower(strings.TrimSpace(job.Name))
+		job.Cron = strings.Join(strings.Fields(job.Cron), " ")
+		if job.Name == "" || job.Cron == "" {
+			continue
+		}
+		if _, exists := seen[job.Name]; exists {
+			continue
+		}
+		seen[job.Name] = struct{}{}
+		jobs = append(jobs, job)
+	}
+	cfg.Scheduler.Jobs = jobs
+}
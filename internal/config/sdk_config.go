@@ -48,16 +48,79 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// Tenants group client API keys under shared access rules, quotas and logging policy.
+	Tenants []Tenant `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+
 	// PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients.
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
 
+	// ResponseHeaders limits which upstream response headers passthrough-headers forwards.
+	ResponseHeaders ResponseHeadersConfig `yaml:"response-headers" json:"response-headers"`
+
+	// HeaderFilter strips cookies, oversized and client-identifying headers from inbound
+	// requests before they can be forwarded to providers.
+	HeaderFilter HeaderFilterConfig `yaml:"header-filter" json:"header-filter"`
+
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
+	// Race sends requests to several credentials at once and keeps the first response, recording
+	// comparative latency per model and provider.
+	Race RaceConfig `yaml:"race,omitempty" json:"race,omitempty"`
+
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// ModelAliases rewrite inbound model names (exact or regex) before provider lookup.
+	// The first matching rule wins; rewrites are not chained.
+	ModelAliases []ModelAliasRule `yaml:"model-aliases,omitempty" json:"model-aliases,omitempty"`
+
+	// SyntheticModels are client-facing models that send requests to a base model with a preset
+	// system prompt, temperature and tool set.
+	SyntheticModels []SyntheticModel `yaml:"synthetic-models,omitempty" json:"synthetic-models,omitempty"`
+
+	// PromptRules prepend, append or template the system prompt of matching requests.
+	PromptRules []PromptRule `yaml:"prompt-rules,omitempty" json:"prompt-rules,omitempty"`
+
+	// OIDCAuth accepts JWTs from an OIDC issuer as client credentials.
+	OIDCAuth OIDCAuthConfig `yaml:"oidc-auth,omitempty" json:"oidc-auth,omitempty"`
+
+	// LocaleHints add the client's preferred response language to the system prompt.
+	LocaleHints LocaleHintsConfig `yaml:"locale-hints,omitempty" json:"locale-hints,omitempty"`
+
+	// ModelFailover maps client-facing model aliases to ordered provider/model targets.
+	// A target answering with 429 or 5xx hands the request to the next target.
+	ModelFailover []ModelFailoverRule `yaml:"model-failover,omitempty" json:"model-failover,omitempty"`
+
+	// ShadowTraffic mirrors a sample of requests to a second model or provider without
+	// affecting the responses returned to clients.
+	ShadowTraffic ShadowTrafficConfig `yaml:"shadow-traffic,omitempty" json:"shadow-traffic,omitempty"`
+
+	// Experiments split the requests for matching models between model targets with
+	// deterministic per-client bucketing.
+	Experiments []Experiment `yaml:"experiments,omitempty" json:"experiments,omitempty"`
+
+	// PreflightTokenCheck counts the tokens of large requests and rejects those exceeding the
+	// model's input limit before they are sent upstream.
+	PreflightTokenCheck PreflightTokenCheckConfig `yaml:"preflight-token-check,omitempty" json:"preflight-token-check,omitempty"`
+
+	// LocalTokenCount answers count-tokens requests and pre-flight token checks with embedded
+	// tokenizers instead of provider count endpoints, so counting never spends upstream quota.
+	LocalTokenCount bool `yaml:"local-token-count,omitempty" json:"local-token-count,omitempty"`
+
+	// PIIRedaction scrubs emails, card numbers, custom patterns and dictionary terms from
+	// request bodies before they are sent upstream.
+	PIIRedaction PIIRedactionConfig `yaml:"pii-redaction,omitempty" json:"pii-redaction,omitempty"`
+
+	// Moderation checks prompts and completions with a moderation backend.
+	Moderation ModerationConfig `yaml:"moderation,omitempty" json:"moderation,omitempty"`
+
+	// ReasoningOutput controls where model reasoning appears in responses: "passthrough"
+	// (default), "expose" to normalize it into the client protocol's reasoning field
+	// (OpenAI reasoning_content, Anthropic thinking blocks), or "strip" to remove it.
+	ReasoningOutput string `yaml:"reasoning-output,omitempty" json:"reasoning-output,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -70,4 +133,23 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// HedgeAfter enables hedged streaming requests: when no stream chunk has arrived after this
+	// duration (e.g. "3s"), the same request is also sent to another credential and the first
+	// one to answer is used. Empty disables hedging.
+	HedgeAfter string `yaml:"hedge-after,omitempty" json:"hedge-after,omitempty"`
+
+	// HedgeModels limits hedging to models matching these case-insensitive patterns, where '*'
+	// matches any substring. Empty hedges every model.
+	HedgeModels []string `yaml:"hedge-models,omitempty" json:"hedge-models,omitempty"`
+
+	// FirstByteHeartbeatSeconds sends SSE heartbeats (": keep-alive\n\n") at this interval while
+	// waiting for the upstream's first chunk. The response is committed with status 200 by the
+	// first heartbeat, so later failures are reported as in-stream errors.
+	// <= 0 disables first-byte heartbeats. Default is 0.
+	FirstByteHeartbeatSeconds int `yaml:"first-byte-heartbeat-seconds,omitempty" json:"first-byte-heartbeat-seconds,omitempty"`
+
+	// ProviderTimeouts bound upstream streams per provider: the idle timeout limits the silence
+	// between chunks (including before the first one) and the total timeout limits the whole stream.
+	ProviderTimeouts []StreamProviderTimeout `yaml:"provider-timeouts,omitempty" json:"provider-timeouts,omitempty"`
 }
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultShadowTrafficMaxConcurrent caps mirrored requests in flight when MaxConcurrent is unset.
+const DefaultShadowTrafficMaxConcurrent = 8
+
+// DefaultShadowTrafficTimeout bounds a mirrored request when Timeout is unset.
+const DefaultShadowTrafficTimeout = 5 * time.Minute
+
+// ShadowTrafficConfig mirrors a sample of requests to a second model or provider, e.g. to
+// validate a migration. Mirrored responses never reach clients: they are discarded or logged
+// next to the primary response for offline comparison.
+type ShadowTrafficConfig struct {
+	// LogFile appends one JSON line per mirrored request holding the request and both responses.
+	// Relative paths are resolved against the working directory. Empty discards the responses.
+	LogFile string `yaml:"log-file,omitempty" json:"log-file,omitempty"`
+	// MaxConcurrent caps the mirrored requests in flight; sampled requests beyond it are not
+	// mirrored. Defaults to DefaultShadowTrafficMaxConcurrent.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+	// Timeout bounds each mirrored request, e.g. "2m". Defaults to DefaultShadowTrafficTimeout.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Rules select the mirrored requests; the first rule matching the requested model applies.
+	Rules []ShadowTrafficRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// ShadowTrafficRule mirrors a percentage of the requests for matching models to a target model.
+type ShadowTrafficRule struct {
+	// Models are case-insensitive patterns of requested model names, where '*' matches any substring.
+	Models []string `yaml:"models" json:"models"`
+	// TargetModel is the model the mirrored request is sent to.
+	TargetModel string `yaml:"target-model" json:"target-model"`
+	// Provider restricts the mirrored request to one provider (e.g. "gemini" or an
+	// openai-compatibility name). Empty allows every provider serving TargetModel.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Percent is the share of matching requests mirrored, from 0 to 100.
+	Percent float64 `yaml:"percent" json:"percent"`
+}
+
+// TimeoutDuration returns the parsed mirrored request timeout.
+func (c ShadowTrafficConfig) TimeoutDuration() time.Duration {
+	timeout, errParse := time.ParseDuration(strings.TrimSpace(c.Timeout))
+	if errParse != nil || timeout <= 0 {
+		return DefaultShadowTrafficTimeout
+	}
+	return timeout
+}
+
+// SanitizeShadowTraffic normalizes shadow traffic rules and drops rules that cannot mirror anything.
+func (cfg *Config) SanitizeShadowTraffic() {
+	if cfg == nil {
+		return
+	}
+	shadow := &cfg.ShadowTraffic
+	shadow.LogFile = strings.TrimSpace(shadow.LogFile)
+	if shadow.MaxConcurrent <= 0 {
+		shadow.MaxConcurrent = DefaultShadowTrafficMaxConcurrent
+	}
+	shadow.Timeout = strings.TrimSpace(shadow.Timeout)
+	if shadow.Timeout != "" {
+		if timeout, errParse := time.ParseDuration(shadow.Timeout); errParse != nil || timeout <= 0 {
+			log.Warnf("shadow-traffic: ignoring timeout %q: expected a positive duration", shadow.Timeout)
+			shadow.Timeout = ""
+		}
+	}
+	rules := make([]ShadowTrafficRule, 0, len(shadow.Rules))
+	for _, rule := range shadow.Rules {
+		rule.TargetModel = strings.TrimSpace(rule.TargetModel)
+		rule.Provider = strings.ToLower(strings.TrimSpace(rule.Provider))
+		rule.Percent = min(max(rule.Percent, 0), 100)
+		models := make([]string, 0, len(rule.Models))
+		for _, model := range rule.Models {
+			if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+				models = append(models, model)
+			}
+		}
+		rule.Models = models
+		if rule.TargetModel == "" || len(rule.Models) == 0 || rule.Percent == 0 {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	shadow.Rules = rules
+}
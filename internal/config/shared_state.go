@@ -0,0 +1,43 @@
+package config
+
+import "strings"
+
+// defaultSharedStateRedisPrefix namespaces shared state keys in a shared Redis instance.
+const defaultSharedStateRedisPrefix = "cliproxy:shared:"
+
+// SharedStateConfig connects several proxy instances running behind a load balancer so they
+// enforce rate limits and quotas together and share stored responses.
+type SharedStateConfig struct {
+	// RedisURL is the Redis instance shared by every instance, e.g. "redis://localhost:6379/0".
+	// Empty keeps all state local to the process.
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+	// RedisPrefix namespaces shared keys in Redis. Default: "cliproxy:shared:".
+	RedisPrefix string `yaml:"redis-prefix,omitempty" json:"redis-prefix,omitempty"`
+}
+
+// SanitizeSharedState trims the Redis settings and applies the default prefix.
+func (cfg *Config) SanitizeSharedState() {
+	if cfg == nil {
+		return
+	}
+	ss := &cfg.SharedState
+	ss.RedisURL = strings.TrimSpace(ss.RedisURL)
+	ss.RedisPrefix = strings.TrimSpace(ss.RedisPrefix)
+	if ss.RedisURL != "" && ss.RedisPrefix == "" {
+		ss.RedisPrefix = defaultSharedStateRedisPrefix
+	}
+}
+
+// EffectiveResponsesStore returns the responses-store settings, storing responses in the
+// shared-state Redis when the section names neither its own Redis nor a SQLite file.
+func (cfg *Config) EffectiveResponsesStore() ResponsesStoreConfig {
+	if cfg == nil {
+		return ResponsesStoreConfig{}
+	}
+	rs := cfg.ResponsesStore
+	if rs.RedisURL == "" && rs.SQLitePath == "" && cfg.SharedState.RedisURL != "" {
+		rs.RedisURL = cfg.SharedState.RedisURL
+		rs.RedisPrefix = cfg.SharedState.RedisPrefix + "responses:"
+	}
+	return rs
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultShutdownDrainTimeout bounds how long shutdown waits for in-flight responses.
+const DefaultShutdownDrainTimeout = 30 * time.Second
+
+// ShutdownConfig controls graceful draining and zero-downtime binary upgrades.
+type ShutdownConfig struct {
+	// DrainTimeout is how long SIGTERM/SIGINT waits for in-flight requests, including streaming
+	// responses, after the listeners stopped accepting. Remaining connections are then closed.
+	// Default: "30s".
+	DrainTimeout string `yaml:"drain-timeout,omitempty" json:"drain-timeout,omitempty"`
+	// ReusePort opens the TCP and gRPC listeners with SO_REUSEPORT, so a new process can bind
+	// the same ports while the old one drains; the old process then leaves listen.unix-socket
+	// in place for the new one. It also enables SIGUSR2 upgrades: the running process
+	// starts the current binary, waits until it listens, then drains and exits. Unix only;
+	// takes effect after a restart.
+	ReusePort bool `yaml:"reuse-port" json:"reuse-port"`
+}
+
+// DrainDuration returns the parsed drain-timeout.
+func (c ShutdownConfig) DrainDuration() time.Duration {
+	if strings.TrimSpace(c.DrainTimeout) == "" {
+		return DefaultShutdownDrainTimeout
+	}
+	return parseDurationOr(c.DrainTimeout, DefaultShutdownDrainTimeout)
+}
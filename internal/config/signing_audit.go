@@ -0,0 +1,25 @@
+package config
+
+// DefaultSigningAuditMaxRequests bounds the audited requests when signing-audit.max-requests
+// is unset.
+const DefaultSigningAuditMaxRequests = 1000
+
+// SigningAuditConfig configures the record of which credential signed each upstream request.
+type SigningAuditConfig struct {
+	// Enable records the credential, token fingerprints and auth header names of every
+	// upstream request. Header values are never stored.
+	Enable bool `yaml:"enable" json:"enable"`
+	// MaxRequests bounds the audited client requests; the oldest are evicted first.
+	// Default: 1000.
+	MaxRequests int `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+}
+
+// SanitizeSigningAudit applies the default request limit.
+func (cfg *Config) SanitizeSigningAudit() {
+	if cfg == nil {
+		return
+	}
+	if cfg.SigningAudit.MaxRequests <= 0 {
+		cfg.SigningAudit.MaxRequests = DefaultSigningAuditMaxRequests
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultStreamResumeWindow is how long a stream stays resumable when stream-resume.window is
+// unset or invalid.
+const DefaultStreamResumeWindow = time.Minute
+
+// DefaultStreamResumeBufferEvents bounds the events buffered per stream when
+// stream-resume.buffer-events is unset.
+const DefaultStreamResumeBufferEvents = 2048
+
+// StreamResumeConfig lets clients resume an interrupted SSE stream by reconnecting with a
+// Last-Event-ID header.
+type StreamResumeConfig struct {
+	// Enable tags SSE events with resumable IDs and honors Last-Event-ID.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Window is how long a stream keeps running without a connected client, and how long a
+	// finished stream stays resumable. Default: 1m.
+	Window string `yaml:"window,omitempty" json:"window,omitempty"`
+	// BufferEvents is the number of recent events kept per stream. Default: 2048.
+	BufferEvents int `yaml:"buffer-events,omitempty" json:"buffer-events,omitempty"`
+}
+
+// WindowDuration returns the parsed resume window with defaults applied.
+func (c StreamResumeConfig) WindowDuration() time.Duration {
+	raw := strings.TrimSpace(c.Window)
+	if raw == "" {
+		return DefaultStreamResumeWindow
+	}
+	window, errParse := time.ParseDuration(raw)
+	if errParse != nil || window <= 0 {
+		return DefaultStreamResumeWindow
+	}
+	return window
+}
+
+// SanitizeStreamResume trims the window and applies the default buffer size.
+func (cfg *Config) SanitizeStreamResume() {
+	if cfg == nil {
+		return
+	}
+	cfg.StreamResume.Window = strings.TrimSpace(cfg.StreamResume.Window)
+	if cfg.StreamResume.BufferEvents <= 0 {
+		cfg.StreamResume.BufferEvents = DefaultStreamResumeBufferEvents
+	}
+}
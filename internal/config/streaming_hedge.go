@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HedgeAfterDuration returns the hedging delay; 0 means hedging is disabled.
+func (c StreamingConfig) HedgeAfterDuration() time.Duration {
+	delay, errParse := time.ParseDuration(strings.TrimSpace(c.HedgeAfter))
+	if errParse != nil || delay <= 0 {
+		return 0
+	}
+	return delay
+}
+
+// SanitizeStreamingHedge drops an invalid hedge delay and normalizes hedge model patterns.
+func (cfg *Config) SanitizeStreamingHedge() {
+	if cfg == nil {
+		return
+	}
+	streaming := &cfg.Streaming
+	streaming.HedgeAfter = strings.TrimSpace(streaming.HedgeAfter)
+	if streaming.HedgeAfter != "" && streaming.HedgeAfterDuration() == 0 {
+		log.Warnf("streaming: ignoring hedge-after %q: expected a positive duration", streaming.HedgeAfter)
+		streaming.HedgeAfter = ""
+	}
+	models := make([]string, 0, len(streaming.HedgeModels))
+	for _, model := range streaming.HedgeModels {
+		if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+			models = append(models, model)
+		}
+	}
+	streaming.HedgeModels = models
+}
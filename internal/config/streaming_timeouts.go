@@ -0,0 +1,96 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// StreamProviderTimeout bounds the upstream streams served by one provider.
+type StreamProviderTimeout struct {
+	// Provider is the provider identifier (e.g. "claude") or an openai-compatibility name;
+	// "*" applies to providers without their own entry.
+	Provider string `yaml:"provider" json:"provider"`
+	// IdleTimeout is the longest gap between two chunks, e.g. "90s". Empty disables it.
+	IdleTimeout string `yaml:"idle-timeout,omitempty" json:"idle-timeout,omitempty"`
+	// TotalTimeout is the longest a stream may run, e.g. "30m". Empty disables it.
+	TotalTimeout string `yaml:"total-timeout,omitempty" json:"total-timeout,omitempty"`
+}
+
+// IdleTimeoutDuration returns the idle timeout; 0 means no idle timeout.
+func (t StreamProviderTimeout) IdleTimeoutDuration() time.Duration {
+	return positiveDuration(t.IdleTimeout)
+}
+
+// TotalTimeoutDuration returns the total timeout; 0 means no total timeout.
+func (t StreamProviderTimeout) TotalTimeoutDuration() time.Duration {
+	return positiveDuration(t.TotalTimeout)
+}
+
+func positiveDuration(value string) time.Duration {
+	d, errParse := time.ParseDuration(strings.TrimSpace(value))
+	if errParse != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// StreamTimeouts returns the idle and total stream timeouts for provider, falling back to the
+// "*" entry. Zero values disable the corresponding timeout.
+func (c StreamingConfig) StreamTimeouts(provider string) (idle, total time.Duration) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	var fallback *StreamProviderTimeout
+	for i := range c.ProviderTimeouts {
+		entry := &c.ProviderTimeouts[i]
+		if entry.Provider == provider && provider != "" {
+			return entry.IdleTimeoutDuration(), entry.TotalTimeoutDuration()
+		}
+		if entry.Provider == "*" && fallback == nil {
+			fallback = entry
+		}
+	}
+	if fallback == nil {
+		return 0, 0
+	}
+	return fallback.IdleTimeoutDuration(), fallback.TotalTimeoutDuration()
+}
+
+// SanitizeStreamingTimeouts normalizes provider timeout entries, dropping invalid durations and
+// entries without any timeout.
+func (cfg *Config) SanitizeStreamingTimeouts() {
+	if cfg == nil {
+		return
+	}
+	streaming := &cfg.Streaming
+	if streaming.FirstByteHeartbeatSeconds < 0 {
+		streaming.FirstByteHeartbeatSeconds = 0
+	}
+	entries := make([]StreamProviderTimeout, 0, len(streaming.ProviderTimeouts))
+	seen := make(map[string]struct{}, len(streaming.ProviderTimeouts))
+	for _, entry := range streaming.ProviderTimeouts {
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		entry.IdleTimeout = strings.TrimSpace(entry.IdleTimeout)
+		entry.TotalTimeout = strings.TrimSpace(entry.TotalTimeout)
+		if entry.Provider == "" {
+			continue
+		}
+		if entry.IdleTimeout != "" && entry.IdleTimeoutDuration() == 0 {
+			log.Warnf("streaming: ignoring idle-timeout %q for provider %s: expected a positive duration", entry.IdleTimeout, entry.Provider)
+			entry.IdleTimeout = ""
+		}
+		if entry.TotalTimeout != "" && entry.TotalTimeoutDuration() == 0 {
+			log.Warnf("streaming: ignoring total-timeout %q for provider %s: expected a positive duration", entry.TotalTimeout, entry.Provider)
+			entry.TotalTimeout = ""
+		}
+		if entry.IdleTimeout == "" && entry.TotalTimeout == "" {
+			continue
+		}
+		if _, exists := seen[entry.Provider]; exists {
+			continue
+		}
+		seen[entry.Provider] = struct{}{}
+		entries = append(entries, entry)
+	}
+	streaming.ProviderTimeouts = entries
+}
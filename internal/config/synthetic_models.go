@@ -0,0 +1,64 @@
+package config
+
+import "strings"
+
+// SyntheticModel is a client-facing model that bundles a base model with a preset system
+// prompt, temperature and tool set.
+type SyntheticModel struct {
+	// Name is the model name clients request and /v1/models lists, e.g. "code-reviewer".
+	Name string `yaml:"name" json:"name"`
+	// Model is the base model requests are sent to. It may itself be a model alias or a
+	// failover alias.
+	Model string `yaml:"model" json:"model"`
+	// Description is shown in model listings that carry one.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// SystemPrompt is placed before the system prompt sent by the client.
+	SystemPrompt string `yaml:"system-prompt,omitempty" json:"system-prompt,omitempty"`
+	// Temperature is used when the request does not set its own.
+	Temperature *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	// Tools are function tools added to the request. A client tool with the same name wins.
+	Tools []SyntheticModelTool `yaml:"tools,omitempty" json:"tools,omitempty"`
+}
+
+// SyntheticModelTool is a function tool declared independently of the client protocol.
+type SyntheticModelTool struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Parameters is the JSON schema of the tool arguments.
+	Parameters map[string]any `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// SanitizeSyntheticModels trims synthetic models and drops entries without a name or base
+// model, entries whose name repeats an earlier one, and tools without a name.
+func (cfg *Config) SanitizeSyntheticModels() {
+	if cfg == nil {
+		return
+	}
+	models := make([]SyntheticModel, 0, len(cfg.SyntheticModels))
+	seen := make(map[string]struct{}, len(cfg.SyntheticModels))
+	for _, model := range cfg.SyntheticModels {
+		model.Name = strings.TrimSpace(model.Name)
+		model.Model = strings.TrimSpace(model.Model)
+		model.Description = strings.TrimSpace(model.Description)
+		if model.Name == "" || model.Model == "" || strings.EqualFold(model.Name, model.Model) {
+			continue
+		}
+		key := strings.ToLower(model.Name)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		tools := make([]SyntheticModelTool, 0, len(model.Tools))
+		for _, tool := range model.Tools {
+			tool.Name = strings.TrimSpace(tool.Name)
+			if tool.Name == "" {
+				continue
+			}
+			tool.Description = strings.TrimSpace(tool.Description)
+			tools = append(tools, tool)
+		}
+		model.Tools = tools
+		models = append(models, model)
+	}
+	cfg.SyntheticModels = models
+}
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestSanitizeSyntheticModels(t *testing.T) {
+	cfg := &Config{SDKConfig: SDKConfig{SyntheticModels: []SyntheticModel{
+		{Name: " reviewer ", Model: " claude-sonnet-4-5 ", Tools: []SyntheticModelTool{{Name: " read_file "}, {Description: "no name"}}},
+		{Name: "Reviewer", Model: "gpt-5"},
+		{Name: "no-base"},
+		{Model: "no-name"},
+		{Name: "loop", Model: "LOOP"},
+	}}}
+
+	cfg.SanitizeSyntheticModels()
+
+	if got := len(cfg.SyntheticModels); got != 1 {
+		t.Fatalf("models = %d, want 1: %+v", got, cfg.SyntheticModels)
+	}
+	model := cfg.SyntheticModels[0]
+	if model.Name != "reviewer" || model.Model != "claude-sonnet-4-5" {
+		t.Fatalf("model = %+v, want trimmed name and base model", model)
+	}
+	if len(model.Tools) != 1 || model.Tools[0].Name != "read_file" {
+		t.Fatalf("tools = %+v, want only the named tool", model.Tools)
+	}
+}
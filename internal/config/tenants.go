@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Tenant groups client API keys under shared access rules, quota and logging policy.
+type Tenant struct {
+	// Name identifies the tenant in logs, usage reports and quota errors.
+	Name string `yaml:"name" json:"name"`
+	// APIKeys authenticate clients as members of the tenant. A key may belong to one tenant.
+	APIKeys     []string `yaml:"api-keys" json:"api-keys"`
+	AccessScope `yaml:",inline"`
+	// MonthlyTokens caps the tokens all keys of the tenant may consume per calendar month (UTC).
+	// Enforced when usage-accounting is enabled. 0 means unlimited.
+	MonthlyTokens int64 `yaml:"monthly-tokens,omitempty" json:"monthly-tokens,omitempty"`
+	// DisableRequestLog keeps the tenant's requests out of request logs, including error logs.
+	DisableRequestLog bool `yaml:"disable-request-log,omitempty" json:"disable-request-log,omitempty"`
+	// CredentialPrefix dedicates the upstream credentials configured with this prefix to the
+	// tenant: its requests for unprefixed models use them when they serve the model, and other
+	// clients may not request "<prefix>/<model>". The credentials register their models under
+	// the prefix only, so unprefixed requests of other clients never reach them.
+	CredentialPrefix string `yaml:"credential-prefix,omitempty" json:"credential-prefix,omitempty"`
+}
+
+// TenantByName returns the tenant called name.
+func (c *SDKConfig) TenantByName(name string) (Tenant, bool) {
+	if c == nil || name == "" {
+		return Tenant{}, false
+	}
+	for _, tenant := range c.Tenants {
+		if tenant.Name == name {
+			return tenant, true
+		}
+	}
+	return Tenant{}, false
+}
+
+// IsTenantCredentialPrefix reports whether prefix is the credential prefix of a tenant.
+func (c *SDKConfig) IsTenantCredentialPrefix(prefix string) bool {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if c == nil || prefix == "" {
+		return false
+	}
+	for _, tenant := range c.Tenants {
+		if strings.EqualFold(tenant.CredentialPrefix, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeTenants trims tenant settings and drops tenants without a name or keys. Duplicate
+// tenant names and keys already claimed by an earlier tenant are dropped with a warning.
+func (cfg *Config) SanitizeTenants() {
+	if cfg == nil {
+		return
+	}
+	tenants := make([]Tenant, 0, len(cfg.Tenants))
+	seenNames := make(map[string]struct{}, len(cfg.Tenants))
+	seenKeys := make(map[string]struct{})
+	for _, tenant := range cfg.Tenants {
+		tenant.Name = strings.TrimSpace(tenant.Name)
+		if tenant.Name == "" {
+			continue
+		}
+		if _, exists := seenNames[tenant.Name]; exists {
+			log.Warnf("tenants: ignoring duplicate tenant %q", tenant.Name)
+			continue
+		}
+		keys := make([]string, 0, len(tenant.APIKeys))
+		for _, key := range tenant.APIKeys {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			if _, exists := seenKeys[key]; exists {
+				log.Warnf("tenants: ignoring an api key of tenant %q that belongs to another tenant", tenant.Name)
+				continue
+			}
+			seenKeys[key] = struct{}{}
+			keys = append(keys, key)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		seenNames[tenant.Name] = struct{}{}
+		tenant.APIKeys = keys
+		tenant.AllowedProviders = normalizeTenantList(tenant.AllowedProviders)
+		tenant.AllowedModels = normalizeTenantList(tenant.AllowedModels)
+		if tenant.MonthlyTokens < 0 {
+			tenant.MonthlyTokens = 0
+		}
+		tenant.CredentialPrefix = strings.Trim(strings.TrimSpace(tenant.CredentialPrefix), "/")
+		tenants = append(tenants, tenant)
+	}
+	cfg.Tenants = tenants
+}
+
+func normalizeTenantList(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			out = append(out, value)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultACMEHTTPChallengeAddr is where HTTP-01 challenges are answered unless configured.
+const DefaultACMEHTTPChallengeAddr = ":80"
+
+// TLSACMEConfig obtains and renews the HTTPS certificate from an ACME CA such as Let's Encrypt,
+// so small deployments need no reverse proxy for TLS.
+type TLSACMEConfig struct {
+	// Enable provisions certificates through ACME instead of loading tls.cert and tls.key.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Domains lists the host names to obtain certificates for. Other SNI names are refused.
+	Domains []string `yaml:"domains,omitempty" json:"domains,omitempty"`
+	// Email is the account contact for expiry and problem notices from the CA.
+	Email string `yaml:"email,omitempty" json:"email,omitempty"`
+	// CacheDir stores the account key and certificates. Relative paths resolve against the
+	// config file directory. Default: "acme" next to the config file.
+	CacheDir string `yaml:"cache-dir,omitempty" json:"cache-dir,omitempty"`
+	// DirectoryURL selects the ACME directory, e.g. the Let's Encrypt staging endpoint.
+	// Default: Let's Encrypt production.
+	DirectoryURL string `yaml:"directory-url,omitempty" json:"directory-url,omitempty"`
+	// HTTPChallengeAddr is the listener answering HTTP-01 challenges and redirecting other
+	// plain HTTP requests to HTTPS. Default ":80"; "off" disables it, leaving TLS-ALPN-01 on
+	// the main port as the only challenge.
+	HTTPChallengeAddr string `yaml:"http-challenge-addr,omitempty" json:"http-challenge-addr,omitempty"`
+}
+
+// HTTPChallengeEnabled reports whether the HTTP-01 listener should run.
+func (c TLSACMEConfig) HTTPChallengeEnabled() bool {
+	return c.HTTPChallengeAddr != "off"
+}
+
+// SanitizeTLSACME normalizes domains and defaults, and turns ACME off when TLS is disabled or
+// no domain is configured.
+func (cfg *Config) SanitizeTLSACME() {
+	if cfg == nil {
+		return
+	}
+	acme := &cfg.TLS.ACME
+	acme.Email = strings.TrimSpace(acme.Email)
+	acme.CacheDir = strings.TrimSpace(acme.CacheDir)
+	if acme.CacheDir == "" {
+		acme.CacheDir = "acme"
+	}
+	acme.DirectoryURL = strings.TrimSpace(acme.DirectoryURL)
+	acme.HTTPChallengeAddr = strings.TrimSpace(acme.HTTPChallengeAddr)
+	if acme.HTTPChallengeAddr == "" {
+		acme.HTTPChallengeAddr = DefaultACMEHTTPChallengeAddr
+	}
+	domains := make([]string, 0, len(acme.Domains))
+	seen := make(map[string]struct{}, len(acme.Domains))
+	for _, domain := range acme.Domains {
+		domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain == "" {
+			continue
+		}
+		if _, exists := seen[domain]; exists {
+			continue
+		}
+		seen[domain] = struct{}{}
+		domains = append(domains, domain)
+	}
+	acme.Domains = domains
+	if !acme.Enable {
+		return
+	}
+	if !cfg.TLS.Enable {
+		log.Warn("tls.acme requires tls.enable; ACME certificates are not provisioned")
+		acme.Enable = false
+	} else if len(acme.Domains) == 0 {
+		log.Warn("tls.acme.domains is empty; ACME certificates are not provisioned")
+		acme.Enable = false
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Client certificate modes for tls.client-auth.mode.
+const (
+	TLSClientAuthNone     = "none"
+	TLSClientAuthOptional = "optional"
+	TLSClientAuthRequire  = "require"
+)
+
+// TLSClientAuthConfig lets internal workloads authenticate with client certificates instead of
+// shared API keys. Certificates are verified against CA during the TLS handshake.
+type TLSClientAuthConfig struct {
+	// Mode is "none" (default), "optional" (verify a certificate when the client sends one) or
+	// "require" (reject handshakes without a valid certificate, on every route).
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// CA is the PEM bundle of certificate authorities trusted for client certificates, e.g. a
+	// SPIFFE trust bundle.
+	CA string `yaml:"ca,omitempty" json:"ca,omitempty"`
+	// TrustDomains limits accepted SPIFFE IDs to these trust domains. Empty accepts any.
+	TrustDomains []string `yaml:"trust-domains,omitempty" json:"trust-domains,omitempty"`
+	// RequireIdentity rejects certificates that match none of Identities.
+	RequireIdentity bool `yaml:"require-identity,omitempty" json:"require-identity,omitempty"`
+	// Identities map certificate identities to access scopes. The first match wins.
+	Identities []TLSClientIdentity `yaml:"identities,omitempty" json:"identities,omitempty"`
+}
+
+// TLSClientIdentity grants an access scope to certificates carrying a matching identity.
+type TLSClientIdentity struct {
+	// Match is compared with the certificate's SPIFFE ID, DNS and email SANs and subject common
+	// name. '*' matches any substring, e.g. "spiffe://corp.example/ns/ml/*".
+	Match       string `yaml:"match" json:"match"`
+	AccessScope `yaml:",inline"`
+}
+
+// Enabled reports whether client certificates are verified and used for authentication.
+func (c TLSClientAuthConfig) Enabled() bool {
+	return c.Mode == TLSClientAuthOptional || c.Mode == TLSClientAuthRequire
+}
+
+// SanitizeTLSClientAuth normalizes the mode, trust domains and identities. Client
+// authentication is turned off when TLS is disabled or no CA bundle is configured.
+func (cfg *Config) SanitizeTLSClientAuth() {
+	if cfg == nil {
+		return
+	}
+	clientAuth := &cfg.TLS.ClientAuth
+	clientAuth.Mode = strings.ToLower(strings.TrimSpace(clientAuth.Mode))
+	clientAuth.CA = strings.TrimSpace(clientAuth.CA)
+	switch clientAuth.Mode {
+	case "", TLSClientAuthNone:
+		clientAuth.Mode = TLSClientAuthNone
+	case TLSClientAuthOptional, TLSClientAuthRequire:
+		if !cfg.TLS.Enable {
+			log.Warn("tls.client-auth requires tls.enable; client certificates are ignored")
+			clientAuth.Mode = TLSClientAuthNone
+		} else if clientAuth.CA == "" {
+			log.Warn("tls.client-auth.ca is empty; client certificates are ignored")
+			clientAuth.Mode = TLSClientAuthNone
+		}
+	default:
+		log.Warnf("tls.client-auth.mode %q is unknown; client certificates are ignored", clientAuth.Mode)
+		clientAuth.Mode = TLSClientAuthNone
+	}
+	domains := make([]string, 0, len(clientAuth.TrustDomains))
+	for _, domain := range clientAuth.TrustDomains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "spiffe://")
+		if domain = strings.TrimRight(domain, "/"); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	clientAuth.TrustDomains = domains
+	identities := make([]TLSClientIdentity, 0, len(clientAuth.Identities))
+	for _, identity := range clientAuth.Identities {
+		if identity.Match = strings.TrimSpace(identity.Match); identity.Match != "" {
+			identities = append(identities, identity)
+		}
+	}
+	clientAuth.Identities = identities
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TLS fingerprint profile names accepted by tls-fingerprint.providers[].profile.
+const (
+	TLSFingerprintProfileChrome     = "chrome"
+	TLSFingerprintProfileFirefox    = "firefox"
+	TLSFingerprintProfileSafari     = "safari"
+	TLSFingerprintProfileEdge       = "edge"
+	TLSFingerprintProfileIOS        = "ios"
+	TLSFingerprintProfileRandomized = "randomized"
+)
+
+var tlsFingerprintProfiles = map[string]struct{}{
+	TLSFingerprintProfileChrome:     {},
+	TLSFingerprintProfileFirefox:    {},
+	TLSFingerprintProfileSafari:     {},
+	TLSFingerprintProfileEdge:       {},
+	TLSFingerprintProfileIOS:        {},
+	TLSFingerprintProfileRandomized: {},
+}
+
+// TLSFingerprintConfig configures uTLS client hello profiles for upstream provider requests.
+// Providers without an entry keep the built-in transport behavior.
+type TLSFingerprintConfig struct {
+	// Providers lists per-provider client profiles.
+	Providers []TLSFingerprintProvider `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// TLSFingerprintProvider selects the client hello profile and HTTP/2 settings for one provider.
+type TLSFingerprintProvider struct {
+	// Provider is the provider identifier (e.g. "gemini", "claude") or an openai-compatibility name.
+	Provider string `yaml:"provider" json:"provider"`
+	// Profile is one of chrome, firefox, safari, edge, ios or randomized.
+	Profile string `yaml:"profile" json:"profile"`
+	// HTTP2 overrides the HTTP/2 SETTINGS emulated for the profile.
+	HTTP2 TLSFingerprintHTTP2 `yaml:"http2,omitempty" json:"http2,omitempty"`
+}
+
+// TLSFingerprintHTTP2 holds HTTP/2 connection settings sent to the upstream.
+// Zero values keep the profile defaults.
+type TLSFingerprintHTTP2 struct {
+	// HeaderTableSize is SETTINGS_HEADER_TABLE_SIZE.
+	HeaderTableSize uint32 `yaml:"header-table-size,omitempty" json:"header-table-size,omitempty"`
+	// InitialWindowSize is SETTINGS_INITIAL_WINDOW_SIZE.
+	InitialWindowSize uint32 `yaml:"initial-window-size,omitempty" json:"initial-window-size,omitempty"`
+	// ConnectionWindowSize is the connection-level flow-control window announced after the preface.
+	ConnectionWindowSize uint32 `yaml:"connection-window-size,omitempty" json:"connection-window-size,omitempty"`
+	// MaxHeaderListSize is SETTINGS_MAX_HEADER_LIST_SIZE.
+	MaxHeaderListSize uint32 `yaml:"max-header-list-size,omitempty" json:"max-header-list-size,omitempty"`
+	// MaxFrameSize is SETTINGS_MAX_FRAME_SIZE.
+	MaxFrameSize uint32 `yaml:"max-frame-size,omitempty" json:"max-frame-size,omitempty"`
+}
+
+// Lookup returns the profile configured for provider, matched case-insensitively.
+func (c TLSFingerprintConfig) Lookup(provider string) (TLSFingerprintProvider, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return TLSFingerprintProvider{}, false
+	}
+	for _, entry := range c.Providers {
+		if entry.Provider == provider {
+			return entry, true
+		}
+	}
+	return TLSFingerprintProvider{}, false
+}
+
+// SanitizeTLSFingerprint normalizes provider and profile names and drops invalid entries.
+func (cfg *Config) SanitizeTLSFingerprint() {
+	if cfg == nil {
+		return
+	}
+	providers := make([]TLSFingerprintProvider, 0, len(cfg.TLSFingerprint.Providers))
+	seen := make(map[string]struct{}, len(cfg.TLSFingerprint.Providers))
+	for _, entry := range cfg.TLSFingerprint.Providers {
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		entry.Profile = strings.ToLower(strings.TrimSpace(entry.Profile))
+		if entry.Provider == "" {
+			continue
+		}
+		if _, ok := tlsFingerprintProfiles[entry.Profile]; !ok {
+			log.Warnf("tls-fingerprint: ignoring unknown profile %q for provider %s", entry.Profile, entry.Provider)
+			continue
+		}
+		if _, exists := seen[entry.Provider]; exists {
+			continue
+		}
+		seen[entry.Provider] = struct{}{}
+		providers = append(providers, entry)
+	}
+	cfg.TLSFingerprint.Providers = providers
+}
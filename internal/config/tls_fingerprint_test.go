@@ -0,0 +1,25 @@
+package config
+
+import "testing"
+
+func TestSanitizeTLSFingerprint(t *testing.T) {
+	cfg := &Config{TLSFingerprint: TLSFingerprintConfig{Providers: []TLSFingerprintProvider{
+		{Provider: " Gemini ", Profile: "Chrome"},
+		{Provider: "gemini", Profile: "firefox"},
+		{Provider: "claude", Profile: "netscape"},
+		{Provider: "", Profile: "chrome"},
+	}}}
+
+	cfg.SanitizeTLSFingerprint()
+
+	if got := len(cfg.TLSFingerprint.Providers); got != 1 {
+		t.Fatalf("providers = %d, want 1: %+v", got, cfg.TLSFingerprint.Providers)
+	}
+	entry, ok := cfg.TLSFingerprint.Lookup("GEMINI")
+	if !ok || entry.Profile != TLSFingerprintProfileChrome {
+		t.Fatalf("Lookup(gemini) = %+v, %v; want chrome profile", entry, ok)
+	}
+	if _, ok = cfg.TLSFingerprint.Lookup("claude"); ok {
+		t.Fatal("expected unknown profile entry to be dropped")
+	}
+}
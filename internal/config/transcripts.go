@@ -0,0 +1,83 @@
+package config
+
+import "strings"
+
+// Transcript store backends accepted by transcripts.store.
+const (
+	TranscriptStoreFile   = "file"
+	TranscriptStoreSQLite = "sqlite"
+	TranscriptStoreS3     = "s3"
+)
+
+// DefaultTranscriptDir is the directory used by the file store when transcripts.dir is unset.
+// It is placed inside auth-dir.
+const DefaultTranscriptDir = "transcripts"
+
+// DefaultTranscriptDatabase is the database file used by the sqlite store when transcripts.path
+// is unset. It is placed inside auth-dir.
+const DefaultTranscriptDatabase = "transcripts.db"
+
+// Defaults applied to transcript limits left unset.
+const (
+	DefaultTranscriptsPerConversation = 20
+	DefaultTranscriptMaxBytes         = 4 << 20
+)
+
+// TranscriptsConfig tees streamed completions into a persistent store keyed by conversation, so
+// output a client lost can be recovered through the management API.
+type TranscriptsConfig struct {
+	// Enable toggles transcript recording and the /v0/management/transcripts endpoints.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Store selects the persistence backend: file (default), sqlite or s3.
+	Store string `yaml:"store,omitempty" json:"store,omitempty"`
+	// Dir is the directory used by the file store. Default: <auth-dir>/transcripts.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Path is the database file used by the sqlite store. Default: <auth-dir>/transcripts.db.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// S3 configures the S3-compatible bucket used by the s3 store.
+	S3 TranscriptS3Config `yaml:"s3,omitempty" json:"s3,omitempty"`
+	// MaxPerConversation is the number of transcripts kept per conversation; older ones are
+	// deleted. Default: 20.
+	MaxPerConversation int `yaml:"max-per-conversation,omitempty" json:"max-per-conversation,omitempty"`
+	// MaxBytes caps the output stored per transcript; longer output is truncated. Default: 4 MiB.
+	MaxBytes int `yaml:"max-bytes,omitempty" json:"max-bytes,omitempty"`
+}
+
+// TranscriptS3Config locates the bucket of the s3 transcript store.
+type TranscriptS3Config struct {
+	Endpoint  string `yaml:"endpoint" json:"endpoint"`
+	Bucket    string `yaml:"bucket" json:"bucket"`
+	AccessKey string `yaml:"access-key" json:"-"`
+	SecretKey string `yaml:"secret-key" json:"-"`
+	Region    string `yaml:"region,omitempty" json:"region,omitempty"`
+	// Prefix is prepended to every object key.
+	Prefix    string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	UseSSL    bool   `yaml:"use-ssl,omitempty" json:"use-ssl,omitempty"`
+	PathStyle bool   `yaml:"path-style,omitempty" json:"path-style,omitempty"`
+}
+
+// SanitizeTranscripts normalizes the store name and applies default limits.
+func (cfg *Config) SanitizeTranscripts() {
+	if cfg == nil {
+		return
+	}
+	tc := &cfg.Transcripts
+	tc.Store = strings.ToLower(strings.TrimSpace(tc.Store))
+	if tc.Store != TranscriptStoreS3 && tc.Store != TranscriptStoreSQLite {
+		tc.Store = TranscriptStoreFile
+	}
+	tc.Dir = strings.TrimSpace(tc.Dir)
+	tc.Path = strings.TrimSpace(tc.Path)
+	tc.S3.Endpoint = strings.TrimSpace(tc.S3.Endpoint)
+	tc.S3.Bucket = strings.TrimSpace(tc.S3.Bucket)
+	tc.S3.AccessKey = strings.TrimSpace(tc.S3.AccessKey)
+	tc.S3.SecretKey = strings.TrimSpace(tc.S3.SecretKey)
+	tc.S3.Region = strings.TrimSpace(tc.S3.Region)
+	tc.S3.Prefix = strings.Trim(strings.TrimSpace(tc.S3.Prefix), "/")
+	if tc.MaxPerConversation <= 0 {
+		tc.MaxPerConversation = DefaultTranscriptsPerConversation
+	}
+	if tc.MaxBytes <= 0 {
+		tc.MaxBytes = DefaultTranscriptMaxBytes
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Usage accounting store backends accepted by usage-accounting.store.
+const (
+	UsageAccountingStoreMemory   = "memory"
+	UsageAccountingStoreSQLite   = "sqlite"
+	UsageAccountingStorePostgres = "postgres"
+)
+
+// DefaultUsageAccountingFile is the database file used by the sqlite store when usage-accounting.path
+// is unset. It is placed inside auth-dir.
+const DefaultUsageAccountingFile = "usage-accounting.db"
+
+// UsageAccountingConfig configures per-client-API-key token accounting and monthly quotas.
+type UsageAccountingConfig struct {
+	// Enable toggles token accounting, quota enforcement and the /v0/management/usage endpoint.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Store selects the persistence backend: memory (default), sqlite or postgres.
+	Store string `yaml:"store,omitempty" json:"store,omitempty"`
+	// Path is the database file used by the sqlite store. Default: <auth-dir>/usage-accounting.db.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// DSN is the PostgreSQL connection string used by the postgres store.
+	DSN string `yaml:"dsn,omitempty" json:"-"`
+	// Table is the PostgreSQL table name. Default: usage_accounting.
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+	// RetentionDays controls how long hourly usage buckets are kept. Zero uses the default of
+	// 90 days; a negative value keeps them forever. Buckets of the current month are always kept.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+	// Quotas caps the tokens each client API key may consume per calendar month (UTC).
+	Quotas []UsageQuota `yaml:"quotas,omitempty" json:"quotas,omitempty"`
+	// WarnOnlyUntil is an RFC3339 timestamp before which exceeded quotas are only logged and
+	// reported in a warning header. Quotas may set their own.
+	WarnOnlyUntil string `yaml:"warn-only-until,omitempty" json:"warn-only-until,omitempty"`
+	// Budgets cap the tokens or estimated cost of a client API key or tenant per day or month.
+	// Requests over budget are rejected with 402 until the period ends or an override is set.
+	Budgets []UsageBudget `yaml:"budgets,omitempty" json:"budgets,omitempty"`
+}
+
+// Usage budget periods. Days and months are calendar periods in UTC.
+const (
+	UsageBudgetDaily   = "daily"
+	UsageBudgetMonthly = "monthly"
+)
+
+// UsageBudget caps the spend of one client API key or tenant per period. Exactly one of
+// APIKey and Tenant is set.
+type UsageBudget struct {
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+	// Period is daily or monthly (default).
+	Period string `yaml:"period,omitempty" json:"period,omitempty"`
+	// Tokens caps prompt plus completion tokens per period. 0 means no token cap.
+	Tokens int64 `yaml:"tokens,omitempty" json:"tokens,omitempty"`
+	// Cost caps the estimated cost in USD per period. 0 means no cost cap.
+	Cost float64 `yaml:"cost,omitempty" json:"cost,omitempty"`
+}
+
+// UsageQuota caps the monthly token consumption of one client API key.
+type UsageQuota struct {
+	APIKey string `yaml:"api-key" json:"api-key"`
+	// MonthlyTokens is the maximum number of prompt plus completion tokens per month. 0 means unlimited.
+	MonthlyTokens int64 `yaml:"monthly-tokens" json:"monthly-tokens"`
+	// WarnOnlyUntil overrides the section-wide warn-only period for this key.
+	WarnOnlyUntil string `yaml:"warn-only-until,omitempty" json:"warn-only-until,omitempty"`
+}
+
+// MonthlyTokenQuota returns the configured monthly token quota for apiKey; 0 means unlimited.
+func (c UsageAccountingConfig) MonthlyTokenQuota(apiKey string) int64 {
+	if apiKey == "" {
+		return 0
+	}
+	for _, quota := range c.Quotas {
+		if quota.APIKey == apiKey {
+			return quota.MonthlyTokens
+		}
+	}
+	return 0
+}
+
+// QuotaWarnOnlyDeadline returns the end of the warn-only period of apiKey's quota.
+func (c UsageAccountingConfig) QuotaWarnOnlyDeadline(apiKey string) time.Time {
+	for _, quota := range c.Quotas {
+		if apiKey != "" && quota.APIKey == apiKey {
+			return WarnOnlyDeadline(quota.WarnOnlyUntil, c.WarnOnlyUntil)
+		}
+	}
+	return WarnOnlyDeadline("", c.WarnOnlyUntil)
+}
+
+// SanitizeUsageAccounting normalizes the store name and drops invalid quota entries.
+func (cfg *Config) SanitizeUsageAccounting() {
+	if cfg == nil {
+		return
+	}
+	ua := &cfg.UsageAccounting
+	ua.Store = strings.ToLower(strings.TrimSpace(ua.Store))
+	switch ua.Store {
+	case UsageAccountingStoreSQLite, UsageAccountingStorePostgres:
+	default:
+		ua.Store = UsageAccountingStoreMemory
+	}
+	ua.Path = strings.TrimSpace(ua.Path)
+	ua.DSN = strings.TrimSpace(ua.DSN)
+	ua.Table = strings.TrimSpace(ua.Table)
+	ua.WarnOnlyUntil = sanitizeWarnOnlyUntil("usage-accounting", ua.WarnOnlyUntil)
+
+	quotas := make([]UsageQuota, 0, len(ua.Quotas))
+	seen := make(map[string]struct{}, len(ua.Quotas))
+	for _, quota := range ua.Quotas {
+		quota.APIKey = strings.TrimSpace(quota.APIKey)
+		if quota.APIKey == "" || quota.MonthlyTokens <= 0 {
+			continue
+		}
+		if _, exists := seen[quota.APIKey]; exists {
+			continue
+		}
+		seen[quota.APIKey] = struct{}{}
+		quota.WarnOnlyUntil = sanitizeWarnOnlyUntil("usage-accounting", quota.WarnOnlyUntil)
+		quotas = append(quotas, quota)
+	}
+	ua.Quotas = quotas
+
+	budgets := make([]UsageBudget, 0, len(ua.Budgets))
+	for _, budget := range ua.Budgets {
+		budget.APIKey = strings.TrimSpace(budget.APIKey)
+		budget.Tenant = strings.TrimSpace(budget.Tenant)
+		if (budget.APIKey == "") == (budget.Tenant == "") {
+			log.Warnf("usage-accounting: ignoring budget without exactly one of api-key and tenant")
+			continue
+		}
+		budget.Period = strings.ToLower(strings.TrimSpace(budget.Period))
+		switch budget.Period {
+		case UsageBudgetDaily, UsageBudgetMonthly:
+		case "":
+			budget.Period = UsageBudgetMonthly
+		default:
+			log.Warnf("usage-accounting: ignoring budget with unknown period %q", budget.Period)
+			continue
+		}
+		budget.Tokens = max(budget.Tokens, 0)
+		budget.Cost = max(budget.Cost, 0)
+		if budget.Tokens == 0 && budget.Cost == 0 {
+			continue
+		}
+		budgets = append(budgets, budget)
+	}
+	ua.Budgets = budgets
+}
@@ -0,0 +1,84 @@
+package config
+
+import "strings"
+
+// DefaultVertexLocation is the Vertex AI region used when an entry does not set one.
+const DefaultVertexLocation = "us-central1"
+
+// VertexServiceAccount configures Google Vertex AI access with Google Cloud credentials.
+// CredentialsFile points at a service account or workload identity federation JSON file;
+// when it is empty, Application Default Credentials are used, which covers GKE workload
+// identity and the metadata server on Compute Engine and Cloud Run.
+type VertexServiceAccount struct {
+	// CredentialsFile is the path of a Google Cloud credentials JSON file.
+	CredentialsFile string `yaml:"credentials-file,omitempty" json:"credentials-file,omitempty"`
+
+	// ProjectID is the Google Cloud project billed for requests. Required with Application
+	// Default Credentials; defaults to the project_id of CredentialsFile otherwise.
+	ProjectID string `yaml:"project-id,omitempty" json:"project-id,omitempty"`
+
+	// Location is the Vertex AI region (e.g. "us-central1" or "global").
+	Location string `yaml:"location,omitempty" json:"location,omitempty"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// MaxConcurrency caps in-flight requests on this credential; 0 falls back to routing.max-concurrency.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "vertex/gemini-2.5-pro").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Models defines the model configurations including aliases for routing.
+	Models []VertexCompatModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// UsesADC reports whether the entry authenticates with Application Default Credentials.
+func (k VertexServiceAccount) UsesADC() bool { return strings.TrimSpace(k.CredentialsFile) == "" }
+
+// SanitizeVertexServiceAccounts normalizes Vertex service account entries and drops
+// Application Default Credentials entries without a project ID.
+func (cfg *Config) SanitizeVertexServiceAccounts() {
+	if cfg == nil || len(cfg.VertexServiceAccount) == 0 {
+		return
+	}
+	out := cfg.VertexServiceAccount[:0]
+	for i := range cfg.VertexServiceAccount {
+		entry := cfg.VertexServiceAccount[i]
+		entry.CredentialsFile = strings.TrimSpace(entry.CredentialsFile)
+		entry.ProjectID = strings.TrimSpace(entry.ProjectID)
+		entry.Location = strings.TrimSpace(entry.Location)
+		if entry.Location == "" {
+			entry.Location = DefaultVertexLocation
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		if entry.UsesADC() && entry.ProjectID == "" {
+			continue
+		}
+
+		sanitizedModels := make([]VertexCompatModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Alias = strings.TrimSpace(model.Alias)
+			model.Name = strings.TrimSpace(model.Name)
+			if model.Alias != "" && model.Name != "" {
+				sanitizedModels = append(sanitizedModels, model)
+			}
+		}
+		entry.Models = sanitizedModels
+		out = append(out, entry)
+	}
+	cfg.VertexServiceAccount = out
+}
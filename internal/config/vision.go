@@ -0,0 +1,40 @@
+package config
+
+const (
+	defaultVisionMaxFetchSizeMB = 20
+	defaultVisionFetchTimeout   = 30
+)
+
+// VisionConfig configures image normalization for upstream requests.
+type VisionConfig struct {
+	// FetchRemoteImages downloads http(s) image URLs and inlines them as base64 for Claude
+	// and Gemini upstreams, which otherwise reject or ignore URLs they cannot fetch. Downloads
+	// connect directly so internal addresses can be refused; credentials that use a proxy keep
+	// their image URLs.
+	FetchRemoteImages bool `yaml:"fetch-remote-images" json:"fetch-remote-images"`
+	// MaxFetchSizeMB skips remote images larger than this. Default: 20.
+	MaxFetchSizeMB int `yaml:"max-fetch-size-mb,omitempty" json:"max-fetch-size-mb,omitempty"`
+	// FetchTimeoutSeconds bounds each remote image download. Default: 30.
+	FetchTimeoutSeconds int `yaml:"fetch-timeout-seconds,omitempty" json:"fetch-timeout-seconds,omitempty"`
+	// Downscale re-encodes inline images that exceed the target provider's size or
+	// dimension limits.
+	Downscale bool `yaml:"downscale" json:"downscale"`
+}
+
+// Enabled reports whether any image normalization is configured.
+func (v VisionConfig) Enabled() bool {
+	return v.FetchRemoteImages || v.Downscale
+}
+
+// SanitizeVision applies image normalization defaults.
+func (cfg *Config) SanitizeVision() {
+	if cfg == nil {
+		return
+	}
+	if cfg.Vision.MaxFetchSizeMB <= 0 {
+		cfg.Vision.MaxFetchSizeMB = defaultVisionMaxFetchSizeMB
+	}
+	if cfg.Vision.FetchTimeoutSeconds <= 0 {
+		cfg.Vision.FetchTimeoutSeconds = defaultVisionFetchTimeout
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultWasmFilterTimeoutMs = 1000
+
+// WasmFilter loads a WebAssembly module that rewrites request and response bodies and headers
+// through a subset of the proxy-wasm ABI.
+type WasmFilter struct {
+	// Name identifies the filter in logs.
+	Name string `yaml:"name" json:"name"`
+	// Path is the .wasm module file.
+	Path string `yaml:"path" json:"path"`
+	// Routes limits the filter to request paths. Entries ending in "*" match by prefix; an empty
+	// list matches every API route.
+	Routes []string `yaml:"routes,omitempty" json:"routes,omitempty"`
+	// Config is passed to the module as its plugin configuration buffer.
+	Config string `yaml:"config,omitempty" json:"config,omitempty"`
+	// TimeoutMs bounds one filter invocation. Default: 1000.
+	TimeoutMs int `yaml:"timeout-ms,omitempty" json:"timeout-ms,omitempty"`
+	// FailOpen forwards the unmodified request or response when the filter fails instead of
+	// answering with an error.
+	FailOpen bool `yaml:"fail-open,omitempty" json:"fail-open,omitempty"`
+}
+
+// Matches reports whether the filter applies to path.
+func (f WasmFilter) Matches(path string) bool {
+	if len(f.Routes) == 0 {
+		return true
+	}
+	for _, route := range f.Routes {
+		if prefix, ok := strings.CutSuffix(route, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+			continue
+		}
+		if path == route {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeWasmFilters drops filters without a module path and applies defaults.
+func (cfg *Config) SanitizeWasmFilters() {
+	if cfg == nil || len(cfg.WasmFilters) == 0 {
+		return
+	}
+	out := cfg.WasmFilters[:0]
+	for i, filter := range cfg.WasmFilters {
+		filter.Name = strings.TrimSpace(filter.Name)
+		filter.Path = strings.TrimSpace(filter.Path)
+		if filter.Path == "" {
+			log.Warnf("wasm-filters[%d]: path is required; filter ignored", i)
+			continue
+		}
+		if filter.Name == "" {
+			filter.Name = filter.Path
+		}
+		routes := make([]string, 0, len(filter.Routes))
+		for _, route := range filter.Routes {
+			if route = strings.TrimSpace(route); route != "" {
+				routes = append(routes, route)
+			}
+		}
+		filter.Routes = routes
+		if filter.TimeoutMs <= 0 {
+			filter.TimeoutMs = defaultWasmFilterTimeoutMs
+		}
+		out = append(out, filter)
+	}
+	cfg.WasmFilters = out
+}
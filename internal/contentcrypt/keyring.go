@@ -0,0 +1,364 @@
+// Package contentcrypt encrypts stored request content with envelope encryption. Every stored
+// object is sealed with its own random data key; data keys are wrapped with a master key taken
+// from the environment or a secret file and kept on disk grouped by owner, or in a KeyStore for
+// content shared between instances. Deleting a data key (crypto-shredding) makes the object
+// unrecoverable even if copies of the ciphertext survive.
+package contentcrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/masterkey"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// sealedPrefix marks sealed content so plaintext written before encryption was enabled stays readable.
+var sealedPrefix = []byte("cpaenc1:")
+
+var (
+	// ErrNoMasterKey is returned when content must be sealed or opened but no master key is configured.
+	ErrNoMasterKey = errors.New("contentcrypt: master key unavailable")
+	// ErrShredded is returned when the data key of sealed content has been destroyed.
+	ErrShredded = errors.New("contentcrypt: content key destroyed")
+)
+
+// KeyStore keeps wrapped data keys for content that several instances read, such as a store
+// shared through Redis, so every instance opens the content with the same key.
+type KeyStore interface {
+	// LoadKey returns the wrapped data key of object id owned by owner, or nil when there is none.
+	LoadKey(owner, id string) ([]byte, error)
+	// AddKey stores wrapped unless the object has a key already, and returns the stored key.
+	AddKey(owner, id string, wrapped []byte) ([]byte, error)
+	// RemoveOwnerKeys destroys every data key of owner and returns how many were destroyed.
+	RemoveOwnerKeys(owner string) (int, error)
+}
+
+// Keyring seals and opens stored content. A nil Keyring leaves content unchanged.
+type Keyring struct {
+	mu      sync.Mutex
+	enabled bool
+	master  []byte
+	dir     string
+	stores  []KeyStore
+}
+
+// NewKeyring creates a disabled keyring. Apply enables it.
+func NewKeyring() *Keyring {
+	return &Keyring{}
+}
+
+// Apply loads the master key and key directory from cfg. The master key is loaded whenever
+// one is configured so content sealed earlier stays readable after encryption is disabled.
+func (k *Keyring) Apply(cfg config.ContentEncryptionConfig, authDir string) {
+	if k == nil {
+		return
+	}
+	dir := cfg.KeysDir
+	if dir == "" {
+		base, errResolve := util.ResolveAuthDir(authDir)
+		if errResolve != nil {
+			log.Warnf("content encryption: %v", errResolve)
+			base = "."
+		}
+		dir = filepath.Join(base, config.DefaultContentKeysDir)
+	}
+	master, errMaster := masterkey.Load(cfg.MasterKeyConfig)
+	if errMaster != nil {
+		log.Errorf("content encryption: %v", errMaster)
+	} else if cfg.Enable && master == nil {
+		log.Error("content encryption: enabled without master-key-env or master-key-file; storing content will fail")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.enabled = cfg.Enable
+	k.master = master
+	k.dir = dir
+}
+
+// Enabled reports whether new content is sealed.
+func (k *Keyring) Enabled() bool {
+	if k == nil {
+		return false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.enabled
+}
+
+// Seal encrypts plaintext under the data key of object id owned by owner, creating the key on
+// first use. Plaintext is returned unchanged when encryption is disabled.
+func (k *Keyring) Seal(owner, id string, plaintext []byte) ([]byte, error) {
+	if k == nil {
+		return plaintext, nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.enabled {
+		return plaintext, nil
+	}
+	if k.master == nil {
+		return nil, ErrNoMasterKey
+	}
+	dataKey, errKey := k.dataKeyLocked(owner, id, true)
+	if errKey != nil {
+		return nil, errKey
+	}
+	return sealWith(dataKey, owner, id, plaintext)
+}
+
+// AddKeyStore makes ShredOwner destroy the data keys held in store too.
+func (k *Keyring) AddKeyStore(store KeyStore) {
+	if k == nil || store == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, existing := range k.stores {
+		if existing == store {
+			return
+		}
+	}
+	k.stores = append(k.stores, store)
+}
+
+// SealWith is Seal with the data key kept in store instead of the keys directory. Store calls
+// run without holding the keyring's lock.
+func (k *Keyring) SealWith(store KeyStore, owner, id string, plaintext []byte) ([]byte, error) {
+	if k == nil {
+		return plaintext, nil
+	}
+	k.mu.Lock()
+	enabled, master := k.enabled, k.master
+	k.mu.Unlock()
+	if !enabled {
+		return plaintext, nil
+	}
+	if master == nil {
+		return nil, ErrNoMasterKey
+	}
+	dataKey, errKey := storedDataKey(store, master, owner, id, true)
+	if errKey != nil {
+		return nil, errKey
+	}
+	return sealWith(dataKey, owner, id, plaintext)
+}
+
+// OpenWith decrypts content produced by SealWith with the same store.
+func (k *Keyring) OpenWith(store KeyStore, owner, id string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, sealedPrefix) {
+		return data, nil
+	}
+	if k == nil {
+		return nil, ErrNoMasterKey
+	}
+	k.mu.Lock()
+	master := k.master
+	k.mu.Unlock()
+	if master == nil {
+		return nil, ErrNoMasterKey
+	}
+	dataKey, errKey := storedDataKey(store, master, owner, id, false)
+	if errKey != nil {
+		return nil, errKey
+	}
+	return masterkey.Open(dataKey, data[len(sealedPrefix):], objectAAD(owner, id))
+}
+
+// Open decrypts content produced by Seal. Content without the sealed marker is returned as is.
+func (k *Keyring) Open(owner, id string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, sealedPrefix) {
+		return data, nil
+	}
+	if k == nil {
+		return nil, ErrNoMasterKey
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.master == nil {
+		return nil, ErrNoMasterKey
+	}
+	dataKey, errKey := k.dataKeyLocked(owner, id, false)
+	if errKey != nil {
+		return nil, errKey
+	}
+	return masterkey.Open(dataKey, data[len(sealedPrefix):], objectAAD(owner, id))
+}
+
+// Shred destroys the data key of one object. Missing keys are not an error.
+func (k *Keyring) Shred(owner, id string) error {
+	if k == nil {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.dir == "" {
+		return nil
+	}
+	return destroyFile(k.keyPath(owner, id))
+}
+
+// ShredOwner destroys every data key of owner, in the keys directory and in the added key
+// stores, and returns how many were destroyed.
+func (k *Keyring) ShredOwner(owner string) (int, error) {
+	if k == nil {
+		return 0, nil
+	}
+	k.mu.Lock()
+	destroyed, errShred := k.shredOwnerFilesLocked(owner)
+	stores := append([]KeyStore(nil), k.stores...)
+	k.mu.Unlock()
+	if errShred != nil {
+		return destroyed, errShred
+	}
+	for _, store := range stores {
+		removed, errRemove := store.RemoveOwnerKeys(owner)
+		destroyed += removed
+		if errRemove != nil {
+			return destroyed, errRemove
+		}
+	}
+	return destroyed, nil
+}
+
+func (k *Keyring) shredOwnerFilesLocked(owner string) (int, error) {
+	if k.dir == "" {
+		return 0, nil
+	}
+	dir := k.ownerDir(owner)
+	entries, errRead := os.ReadDir(dir)
+	if errors.Is(errRead, os.ErrNotExist) {
+		return 0, nil
+	}
+	if errRead != nil {
+		return 0, errRead
+	}
+	destroyed := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if errDestroy := destroyFile(filepath.Join(dir, entry.Name())); errDestroy != nil {
+			return destroyed, errDestroy
+		}
+		destroyed++
+	}
+	return destroyed, os.Remove(dir)
+}
+
+func (k *Keyring) ownerDir(owner string) string {
+	return filepath.Join(k.dir, hashName(owner))
+}
+
+func (k *Keyring) keyPath(owner, id string) string {
+	return filepath.Join(k.ownerDir(owner), hashName(id)+".key")
+}
+
+// dataKeyLocked unwraps the data key of an object, generating and storing a new one when
+// create is set and none exists.
+func (k *Keyring) dataKeyLocked(owner, id string, create bool) ([]byte, error) {
+	path := k.keyPath(owner, id)
+	wrapped, errRead := os.ReadFile(path)
+	if errRead == nil {
+		dataKey, errOpen := masterkey.Open(k.master, wrapped, objectAAD(owner, id))
+		if errOpen != nil {
+			return nil, fmt.Errorf("unwrap content key: %w", errOpen)
+		}
+		return dataKey, nil
+	}
+	if !errors.Is(errRead, os.ErrNotExist) {
+		return nil, errRead
+	}
+	if !create {
+		return nil, ErrShredded
+	}
+	dataKey := make([]byte, 32)
+	if _, errRand := io.ReadFull(rand.Reader, dataKey); errRand != nil {
+		return nil, errRand
+	}
+	wrapped, errWrap := masterkey.Seal(k.master, dataKey, objectAAD(owner, id))
+	if errWrap != nil {
+		return nil, errWrap
+	}
+	if errWrite := util.WriteFileAtomic(path, wrapped); errWrite != nil {
+		return nil, errWrite
+	}
+	return dataKey, nil
+}
+
+// storedDataKey unwraps the data key of an object kept in store, adding a new one when create is
+// set and none exists. Instances racing to add a key all use the one stored first.
+func storedDataKey(store KeyStore, master []byte, owner, id string, create bool) ([]byte, error) {
+	wrapped, errLoad := store.LoadKey(owner, id)
+	if errLoad != nil {
+		return nil, errLoad
+	}
+	if wrapped == nil {
+		if !create {
+			return nil, ErrShredded
+		}
+		dataKey := make([]byte, 32)
+		if _, errRand := io.ReadFull(rand.Reader, dataKey); errRand != nil {
+			return nil, errRand
+		}
+		fresh, errWrap := masterkey.Seal(master, dataKey, objectAAD(owner, id))
+		if errWrap != nil {
+			return nil, errWrap
+		}
+		var errAdd error
+		if wrapped, errAdd = store.AddKey(owner, id, fresh); errAdd != nil {
+			return nil, errAdd
+		}
+	}
+	dataKey, errOpen := masterkey.Open(master, wrapped, objectAAD(owner, id))
+	if errOpen != nil {
+		return nil, fmt.Errorf("unwrap content key: %w", errOpen)
+	}
+	return dataKey, nil
+}
+
+func sealWith(dataKey []byte, owner, id string, plaintext []byte) ([]byte, error) {
+	sealed, errSeal := masterkey.Seal(dataKey, plaintext, objectAAD(owner, id))
+	if errSeal != nil {
+		return nil, errSeal
+	}
+	return append(append([]byte(nil), sealedPrefix...), sealed...), nil
+}
+
+// objectAAD binds ciphertext and wrapped keys to their object so they cannot be swapped.
+func objectAAD(owner, id string) []byte {
+	return []byte(hashName(owner) + "/" + id)
+}
+
+func hashName(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:16])
+}
+
+// destroyFile overwrites a key file with zeros before removing it, so the wrapped key does not
+// linger in the file's former blocks on filesystems that reuse them in place.
+func destroyFile(path string) error {
+	info, errStat := os.Stat(path)
+	if errors.Is(errStat, os.ErrNotExist) {
+		return nil
+	}
+	if errStat != nil {
+		return errStat
+	}
+	if f, errOpen := os.OpenFile(path, os.O_WRONLY, 0); errOpen == nil {
+		_, _ = f.Write(make([]byte, info.Size()))
+		_ = f.Sync()
+		_ = f.Close()
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,95 @@
+package contentcrypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newTestKeyring(t *testing.T, enable bool) (*Keyring, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("CONTENTCRYPT_TEST_KEY", "master secret")
+	k := NewKeyring()
+	k.Apply(config.ContentEncryptionConfig{Enable: enable, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "CONTENTCRYPT_TEST_KEY"}, KeysDir: dir}, "")
+	return k, dir
+}
+
+func TestKeyringSealOpenAndShred(t *testing.T) {
+	k, dir := newTestKeyring(t, true)
+	plaintext := []byte(`{"messages":[{"role":"user","content":"secret"}]}`)
+
+	sealed, errSeal := k.Seal("owner", "obj-1", plaintext)
+	if errSeal != nil {
+		t.Fatalf("Seal() error = %v", errSeal)
+	}
+	if bytes.Contains(sealed, []byte("secret")) {
+		t.Fatal("sealed content leaks plaintext")
+	}
+	opened, errOpen := k.Open("owner", "obj-1", sealed)
+	if errOpen != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open() = %s, %v", opened, errOpen)
+	}
+	if _, errOpen = k.Open("owner", "obj-2", sealed); errOpen == nil {
+		t.Fatal("expected content to be bound to its object")
+	}
+
+	if errShred := k.Shred("owner", "obj-1"); errShred != nil {
+		t.Fatalf("Shred() error = %v", errShred)
+	}
+	if _, errOpen = k.Open("owner", "obj-1", sealed); !errors.Is(errOpen, ErrShredded) {
+		t.Fatalf("Open() after Shred error = %v, want ErrShredded", errOpen)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, hashName("owner")))
+	if len(entries) != 0 {
+		t.Fatalf("key files left after Shred: %d", len(entries))
+	}
+}
+
+func TestKeyringShredOwner(t *testing.T) {
+	k, _ := newTestKeyring(t, true)
+	first, _ := k.Seal("owner", "a", []byte("one"))
+	second, _ := k.Seal("owner", "b", []byte("two"))
+	other, _ := k.Seal("other", "a", []byte("three"))
+
+	destroyed, errShred := k.ShredOwner("owner")
+	if errShred != nil || destroyed != 2 {
+		t.Fatalf("ShredOwner() = %d, %v", destroyed, errShred)
+	}
+	for id, sealed := range map[string][]byte{"a": first, "b": second} {
+		if _, errOpen := k.Open("owner", id, sealed); !errors.Is(errOpen, ErrShredded) {
+			t.Fatalf("Open(%s) error = %v, want ErrShredded", id, errOpen)
+		}
+	}
+	if opened, errOpen := k.Open("other", "a", other); errOpen != nil || string(opened) != "three" {
+		t.Fatalf("other owner Open() = %s, %v", opened, errOpen)
+	}
+}
+
+func TestKeyringDisabledAndPlaintext(t *testing.T) {
+	k, _ := newTestKeyring(t, false)
+	out, errSeal := k.Seal("owner", "id", []byte("plain"))
+	if errSeal != nil || string(out) != "plain" {
+		t.Fatalf("disabled Seal() = %s, %v", out, errSeal)
+	}
+	if out, errOpen := k.Open("owner", "id", []byte("plain")); errOpen != nil || string(out) != "plain" {
+		t.Fatalf("plaintext Open() = %s, %v", out, errOpen)
+	}
+
+	var nilKeyring *Keyring
+	if out, errSeal = nilKeyring.Seal("owner", "id", []byte("plain")); errSeal != nil || string(out) != "plain" {
+		t.Fatalf("nil Seal() = %s, %v", out, errSeal)
+	}
+}
+
+func TestKeyringFailsClosedWithoutMasterKey(t *testing.T) {
+	k := NewKeyring()
+	k.Apply(config.ContentEncryptionConfig{Enable: true, KeysDir: t.TempDir()}, "")
+	if _, errSeal := k.Seal("owner", "id", []byte("plain")); !errors.Is(errSeal, ErrNoMasterKey) {
+		t.Fatalf("Seal() error = %v, want ErrNoMasterKey", errSeal)
+	}
+}
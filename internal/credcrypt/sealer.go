@@ -0,0 +1,260 @@
+// Package credcrypt encrypts the refresh tokens in OAuth credential files. Only the refresh
+// token is sealed: it is the long-lived secret, while access tokens expire within hours and are
+// refreshed in the background. Sealed values are tagged so plaintext files written before
+// encryption was enabled stay readable.
+package credcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/masterkey"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// sealedPrefix marks an encrypted field value.
+const sealedPrefix = "cpacred1:"
+
+// sealedFields lists the credential file fields kept encrypted on disk.
+var sealedFields = []string{"refresh_token"}
+
+// ErrNoMasterKey is returned when a sealed value must be opened but no master key is configured.
+var ErrNoMasterKey = errors.New("credcrypt: master key unavailable")
+
+// Sealer seals and opens credential file fields. A nil Sealer leaves them unchanged.
+type Sealer struct {
+	mu      sync.RWMutex
+	enabled bool
+	master  []byte
+}
+
+// New creates a disabled sealer. Apply enables it.
+func New() *Sealer {
+	return &Sealer{}
+}
+
+var defaultSealer = New()
+
+// Default returns the process-wide sealer used by the credential file store and loaders.
+func Default() *Sealer { return defaultSealer }
+
+// Apply loads the master key from cfg. The key is loaded whenever one is configured so files
+// sealed earlier stay readable after encryption is disabled.
+func (s *Sealer) Apply(cfg config.CredentialEncryptionConfig) {
+	if s == nil {
+		return
+	}
+	master, errMaster := masterkey.Load(cfg.MasterKeyConfig)
+	if errMaster != nil {
+		log.Errorf("credential encryption: %v", errMaster)
+	} else if cfg.Enable && master == nil {
+		log.Error("credential encryption: enabled without master-key-env or master-key-file; refresh tokens are stored in plaintext")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = cfg.Enable && master != nil
+	s.master = master
+}
+
+// Enabled reports whether newly written credential files are sealed.
+func (s *Sealer) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled
+}
+
+// SealJSON returns the credential file payload data with its refresh token encrypted. When
+// previous, the payload currently on disk, holds a sealed value of the same token, that
+// ciphertext is kept so saving an unchanged credential leaves the file byte-identical. data is
+// returned unchanged while encryption is disabled.
+func (s *Sealer) SealJSON(data, previous []byte) ([]byte, error) {
+	if s == nil {
+		return data, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.enabled {
+		return data, nil
+	}
+	for _, field := range sealedFields {
+		value := gjson.GetBytes(data, field)
+		if value.Type != gjson.String || value.Str == "" || strings.HasPrefix(value.Str, sealedPrefix) {
+			continue
+		}
+		sealed := ""
+		if old := gjson.GetBytes(previous, field); strings.HasPrefix(old.Str, sealedPrefix) {
+			if plain, errOpen := s.openLocked(field, old.Str); errOpen == nil && plain == value.Str {
+				sealed = old.Str
+			}
+		}
+		if sealed == "" {
+			var errSeal error
+			if sealed, errSeal = s.sealLocked(field, value.Str); errSeal != nil {
+				return nil, errSeal
+			}
+		}
+		updated, errSet := sjson.SetBytes(data, field, sealed)
+		if errSet != nil {
+			return nil, errSet
+		}
+		data = updated
+	}
+	return data, nil
+}
+
+// SaveStorage writes the credential file of a token storage to path. While encryption is
+// enabled, storages that can marshal their payload have it sealed in memory and written once,
+// atomically, so the refresh token never reaches the disk in plaintext. Other storages are
+// saved as usual and sealed afterwards.
+func (s *Sealer) SaveStorage(storage interface{ SaveTokenToFile(string) error }, path string) error {
+	if !s.Enabled() {
+		return storage.SaveTokenToFile(path)
+	}
+	previous, _ := os.ReadFile(path)
+	marshaler, ok := storage.(interface{ MarshalToken() ([]byte, error) })
+	if !ok {
+		if errSave := storage.SaveTokenToFile(path); errSave != nil {
+			return errSave
+		}
+		return s.SealFile(path, previous)
+	}
+	misc.LogSavingCredentials(path)
+	data, errMarshal := marshaler.MarshalToken()
+	if errMarshal != nil {
+		return errMarshal
+	}
+	sealed, errSeal := s.SealJSON(data, previous)
+	if errSeal != nil {
+		return fmt.Errorf("credcrypt: encrypt credentials: %w", errSeal)
+	}
+	if bytes.Equal(sealed, previous) {
+		return nil
+	}
+	if errWrite := util.WriteFileAtomic(path, sealed); errWrite != nil {
+		return fmt.Errorf("credcrypt: write file: %w", errWrite)
+	}
+	return nil
+}
+
+// SealFile seals the refresh token of a credential file just written by a token storage, keeping
+// the ciphertext of previous when the token did not change. A missing file has nothing to seal.
+func (s *Sealer) SealFile(path string, previous []byte) error {
+	if !s.Enabled() {
+		return nil
+	}
+	data, errRead := os.ReadFile(path)
+	if errors.Is(errRead, os.ErrNotExist) {
+		return nil
+	}
+	if errRead != nil {
+		return fmt.Errorf("credcrypt: read saved file: %w", errRead)
+	}
+	sealed, errSeal := s.SealJSON(data, previous)
+	if errSeal != nil {
+		return fmt.Errorf("credcrypt: encrypt credentials: %w", errSeal)
+	}
+	if bytes.Equal(sealed, data) {
+		return nil
+	}
+	if errWrite := util.WriteFileAtomic(path, sealed); errWrite != nil {
+		return fmt.Errorf("credcrypt: write file: %w", errWrite)
+	}
+	return nil
+}
+
+// OpenJSON returns the credential file payload data with sealed fields decrypted. Payloads
+// without sealed fields are returned as is.
+func (s *Sealer) OpenJSON(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(sealedPrefix)) {
+		return data, nil
+	}
+	if s == nil {
+		return nil, ErrNoMasterKey
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, field := range sealedFields {
+		value := gjson.GetBytes(data, field)
+		if !strings.HasPrefix(value.Str, sealedPrefix) {
+			continue
+		}
+		plain, errOpen := s.openLocked(field, value.Str)
+		if errOpen != nil {
+			return nil, fmt.Errorf("credcrypt: open %s: %w", field, errOpen)
+		}
+		updated, errSet := sjson.SetBytes(data, field, plain)
+		if errSet != nil {
+			return nil, errSet
+		}
+		data = updated
+	}
+	return data, nil
+}
+
+// OpenMetadata decrypts the sealed fields of parsed credential metadata in place.
+func (s *Sealer) OpenMetadata(metadata map[string]any) error {
+	for _, field := range sealedFields {
+		value, _ := metadata[field].(string)
+		if !strings.HasPrefix(value, sealedPrefix) {
+			continue
+		}
+		if s == nil {
+			return ErrNoMasterKey
+		}
+		s.mu.RLock()
+		plain, errOpen := s.openLocked(field, value)
+		s.mu.RUnlock()
+		if errOpen != nil {
+			return fmt.Errorf("credcrypt: open %s: %w", field, errOpen)
+		}
+		metadata[field] = plain
+	}
+	return nil
+}
+
+// MarshalMetadata encodes credential metadata for storage, sealing its refresh token like
+// SealJSON.
+func (s *Sealer) MarshalMetadata(metadata map[string]any, previous []byte) ([]byte, error) {
+	raw, errMarshal := json.Marshal(metadata)
+	if errMarshal != nil {
+		return nil, errMarshal
+	}
+	return s.SealJSON(raw, previous)
+}
+
+func (s *Sealer) sealLocked(field, plaintext string) (string, error) {
+	sealed, errSeal := masterkey.Seal(s.master, []byte(plaintext), []byte(field))
+	if errSeal != nil {
+		return "", errSeal
+	}
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *Sealer) openLocked(field, value string) (string, error) {
+	if s.master == nil {
+		return "", ErrNoMasterKey
+	}
+	sealed, errDecode := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, sealedPrefix))
+	if errDecode != nil {
+		return "", errDecode
+	}
+	plain, errOpen := masterkey.Open(s.master, sealed, []byte(field))
+	if errOpen != nil {
+		return "", errOpen
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,90 @@
+package credcrypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func newTestSealer(t *testing.T, enable bool) *Sealer {
+	t.Helper()
+	t.Setenv("TEST_CRED_KEY", "correct horse battery staple")
+	s := New()
+	s.Apply(config.CredentialEncryptionConfig{Enable: enable, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "TEST_CRED_KEY"}})
+	return s
+}
+
+func TestSealer_SealsRefreshTokenAndOpensIt(t *testing.T) {
+	s := newTestSealer(t, true)
+	plain := []byte(`{"type":"claude","access_token":"at","refresh_token":"rt-secret","email":"a@example.com"}`)
+
+	sealed, errSeal := s.SealJSON(plain, nil)
+	if errSeal != nil {
+		t.Fatalf("SealJSON: %v", errSeal)
+	}
+	if bytes.Contains(sealed, []byte("rt-secret")) {
+		t.Fatalf("sealed payload still holds the refresh token: %s", sealed)
+	}
+	if got := gjson.GetBytes(sealed, "access_token").String(); got != "at" {
+		t.Fatalf("access_token = %q, want it untouched", got)
+	}
+
+	again, _ := s.SealJSON(plain, sealed)
+	if !bytes.Equal(again, sealed) {
+		t.Fatal("resealing an unchanged token changed the ciphertext")
+	}
+
+	opened, errOpen := s.OpenJSON(sealed)
+	if errOpen != nil || gjson.GetBytes(opened, "refresh_token").String() != "rt-secret" {
+		t.Fatalf("OpenJSON = (%s, %v), want the plaintext token", opened, errOpen)
+	}
+	metadata := map[string]any{"refresh_token": gjson.GetBytes(sealed, "refresh_token").String()}
+	if errMeta := s.OpenMetadata(metadata); errMeta != nil || metadata["refresh_token"] != "rt-secret" {
+		t.Fatalf("OpenMetadata = (%v, %v), want the plaintext token", metadata, errMeta)
+	}
+}
+
+func TestSealer_DisabledKeepsPlaintextButOpensSealedFiles(t *testing.T) {
+	sealed, _ := newTestSealer(t, true).SealJSON([]byte(`{"refresh_token":"rt"}`), nil)
+
+	s := newTestSealer(t, false)
+	plain := []byte(`{"refresh_token":"rt"}`)
+	if out, _ := s.SealJSON(plain, nil); !bytes.Equal(out, plain) {
+		t.Fatalf("disabled sealer changed the payload: %s", out)
+	}
+	if opened, errOpen := s.OpenJSON(sealed); errOpen != nil || gjson.GetBytes(opened, "refresh_token").String() != "rt" {
+		t.Fatalf("OpenJSON = (%s, %v), want files sealed earlier to stay readable", opened, errOpen)
+	}
+
+	if _, errOpen := New().OpenJSON(sealed); !errors.Is(errOpen, ErrNoMasterKey) {
+		t.Fatalf("OpenJSON without key err = %v, want ErrNoMasterKey", errOpen)
+	}
+}
+
+func TestSealer_SealFileRewritesSavedCredential(t *testing.T) {
+	s := newTestSealer(t, true)
+	path := filepath.Join(t.TempDir(), "claude.json")
+	if errWrite := os.WriteFile(path, []byte(`{"type":"claude","refresh_token":"rt-secret"}`), 0o600); errWrite != nil {
+		t.Fatalf("write: %v", errWrite)
+	}
+	if errSeal := s.SealFile(path, nil); errSeal != nil {
+		t.Fatalf("SealFile: %v", errSeal)
+	}
+	sealed, _ := os.ReadFile(path)
+	if bytes.Contains(sealed, []byte("rt-secret")) {
+		t.Fatalf("saved file still holds the refresh token: %s", sealed)
+	}
+
+	_ = os.WriteFile(path, []byte(`{"type":"claude","refresh_token":"rt-secret"}`), 0o600)
+	if errSeal := s.SealFile(path, sealed); errSeal != nil {
+		t.Fatalf("SealFile: %v", errSeal)
+	}
+	if again, _ := os.ReadFile(path); !bytes.Equal(again, sealed) {
+		t.Fatal("resealing an unchanged token changed the ciphertext")
+	}
+}
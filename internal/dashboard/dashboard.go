@@ -0,0 +1,11 @@
+// Package dashboard embeds the built-in monitoring dashboard served at /dashboard.
+// The page is a self-contained single HTML file that talks to the management API
+// with the management key entered by the operator.
+package dashboard
+
+import _ "embed"
+
+// IndexHTML holds the dashboard page, embedded into the binary at compile time.
+//
+//go:embed index.html
+var IndexHTML []byte
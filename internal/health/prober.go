@@ -0,0 +1,512 @@
+// Package health probes configured provider credentials in the background and
+// reports their reachability and auth validity for the management API.
+package health
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler/jobs"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// Health states reported for each credential.
+const (
+	StateHealthy   = "healthy"
+	StateUnhealthy = "unhealthy"
+	StateDisabled  = "disabled"
+	StateUnknown   = "unknown"
+)
+
+const (
+	defaultClaudeBaseURL = "https://api.anthropic.com"
+	defaultGeminiBaseURL = "https://generativelanguage.googleapis.com"
+)
+
+// oauthRefreshLead is how close to expiry an OAuth access token must be before a probe
+// refreshes it. Tokens further from expiry are never refreshed by the prober.
+const oauthRefreshLead = 5 * time.Minute
+
+// claudeOAuthBeta lets Claude OAuth access tokens call the model-list endpoint.
+const claudeOAuthBeta = "oauth-2025-04-20"
+
+// ProviderHealth is the latest health snapshot for a single credential.
+type ProviderHealth struct {
+	AuthID     string     `json:"auth_id"`
+	AuthIndex  string     `json:"auth_index,omitempty"`
+	Provider   string     `json:"provider"`
+	Label      string     `json:"label,omitempty"`
+	State      string     `json:"state"`
+	AuthValid  bool       `json:"auth_valid"`
+	Reachable  *bool      `json:"reachable,omitempty"`
+	StatusCode int        `json:"status_code,omitempty"`
+	LatencyMs  int64      `json:"latency_ms,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+}
+
+type probeResult struct {
+	reachable  bool
+	statusCode int
+	latency    time.Duration
+	err        string
+	checkedAt  time.Time
+}
+
+// Prober periodically issues lightweight model-list requests for each credential and keeps
+// the latest result in memory.
+type Prober struct {
+	mu       sync.Mutex
+	manager  *coreauth.Manager
+	results  map[string]probeResult
+	inFlight map[string]struct{}
+	interval time.Duration
+	cancel   context.CancelFunc
+}
+
+// NewProber creates a prober bound to the given auth manager. The prober is idle until Apply enables it.
+func NewProber(manager *coreauth.Manager) *Prober {
+	return &Prober{
+		manager:  manager,
+		results:  make(map[string]probeResult),
+		inFlight: make(map[string]struct{}),
+	}
+}
+
+// SetAuthManager updates the auth manager used for listing and probing credentials.
+func (p *Prober) SetAuthManager(manager *coreauth.Manager) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.manager = manager
+	p.mu.Unlock()
+}
+
+// Apply starts, restarts, or stops the background loop according to cfg.
+func (p *Prober) Apply(cfg config.HealthCheckConfig) {
+	if p == nil {
+		return
+	}
+	interval := cfg.IntervalDuration()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !cfg.Enable {
+		p.stopLocked()
+		return
+	}
+	if p.cancel != nil && p.interval == interval {
+		return
+	}
+	p.stopLocked()
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.interval = interval
+	go p.run(ctx, interval)
+	log.Infof("provider health prober started (interval=%s)", interval)
+}
+
+// Stop halts the background loop. In-flight probes are cancelled.
+func (p *Prober) Stop() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.stopLocked()
+	p.mu.Unlock()
+}
+
+func (p *Prober) stopLocked() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.cancel = nil
+	p.interval = 0
+	log.Info("provider health prober stopped")
+}
+
+func (p *Prober) run(ctx context.Context, interval time.Duration) {
+	p.ProbeAll(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !jobs.Scheduled(jobs.HealthProbe) {
+				p.ProbeAll(ctx)
+			}
+		}
+	}
+}
+
+// ProbeAll probes every enabled credential that exposes a model-list endpoint, refreshes OAuth
+// credentials whose access token is about to expire, and waits for the round to finish.
+// Credentials whose previous probe is still running are skipped.
+func (p *Prober) ProbeAll(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mu.Lock()
+	manager := p.manager
+	p.mu.Unlock()
+	if manager == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	seen := make(map[string]struct{})
+	for _, auth := range manager.List() {
+		if auth == nil || auth.ID == "" {
+			continue
+		}
+		seen[auth.ID] = struct{}{}
+		if auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		target := probeURL(auth)
+		refresh := refreshDue(manager, auth, time.Now())
+		if target == "" && !refresh {
+			continue
+		}
+		if !p.beginProbe(auth.ID) {
+			continue
+		}
+		wg.Add(1)
+		go func(auth *coreauth.Auth, target string, refresh bool) {
+			defer wg.Done()
+			var result probeResult
+			if refresh {
+				result = probeRefresh(ctx, manager, auth)
+			} else {
+				result = probe(ctx, manager, auth, target)
+			}
+			p.finishProbe(auth.ID, result, ctx.Err() == nil)
+		}(auth, target, refresh)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	for id := range p.results {
+		if _, ok := seen[id]; !ok {
+			delete(p.results, id)
+		}
+	}
+	p.mu.Unlock()
+}
+
+func (p *Prober) beginProbe(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, busy := p.inFlight[id]; busy {
+		return false
+	}
+	p.inFlight[id] = struct{}{}
+	return true
+}
+
+func (p *Prober) finishProbe(id string, result probeResult, store bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inFlight, id)
+	if store {
+		p.results[id] = result
+	}
+}
+
+func probe(ctx context.Context, manager *coreauth.Manager, auth *coreauth.Auth, target string) probeResult {
+	start := time.Now()
+	result := probeResult{checkedAt: start.UTC()}
+	req, errRequest := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if errRequest != nil {
+		result.err = errRequest.Error()
+		return result
+	}
+	if kind, _ := auth.AccountInfo(); kind == "oauth" && strings.EqualFold(auth.Provider, "claude") {
+		req.Header.Set("anthropic-beta", claudeOAuthBeta)
+	}
+	resp, errDo := manager.HttpRequest(ctx, auth, req)
+	result.latency = time.Since(start)
+	if errDo != nil {
+		result.err = errDo.Error()
+		return result
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("provider health: failed to close probe response body: %v", errClose)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	result.reachable = true
+	result.statusCode = resp.StatusCode
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		result.err = http.StatusText(resp.StatusCode)
+	}
+	return result
+}
+
+// probeRefresh probes an OAuth credential whose access token is about to expire by refreshing
+// it through its executor, which proves that the grant is still valid.
+func probeRefresh(ctx context.Context, manager *coreauth.Manager, auth *coreauth.Auth) probeResult {
+	start := time.Now()
+	result := probeResult{checkedAt: start.UTC()}
+	_, errRefresh := manager.RefreshAuth(ctx, auth.ID)
+	result.latency = time.Since(start)
+	if errRefresh == nil {
+		result.reachable = true
+		return result
+	}
+	result.err = errRefresh.Error()
+	var coder interface{ StatusCode() int }
+	if errors.As(errRefresh, &coder) && coder != nil && coder.StatusCode() > 0 {
+		result.reachable = true
+		result.statusCode = coder.StatusCode()
+	}
+	return result
+}
+
+// refreshDue reports whether auth is an OAuth credential whose access token expires within
+// oauthRefreshLead and whose executor can refresh it.
+func refreshDue(manager *coreauth.Manager, auth *coreauth.Auth, now time.Time) bool {
+	if kind, _ := auth.AccountInfo(); kind != "oauth" {
+		return false
+	}
+	expiresAt, ok := auth.ExpirationTime()
+	if !ok || expiresAt.Sub(now) > oauthRefreshLead {
+		return false
+	}
+	_, ok = manager.Executor(auth.Provider)
+	return ok
+}
+
+// probeURL returns the model-list endpoint used to probe a credential with its current key or
+// access token. OAuth credentials are only probed this way for Claude, whose model list accepts
+// OAuth tokens; the others are reported from auth state unless their token is about to expire.
+func probeURL(auth *coreauth.Auth) string {
+	kind, _ := auth.AccountInfo()
+	switch {
+	case kind == "api_key":
+	case kind == "oauth" && strings.EqualFold(auth.Provider, "claude"):
+	default:
+		return ""
+	}
+	baseURL := ""
+	if auth.Attributes != nil {
+		baseURL = auth.Attributes["base_url"]
+	}
+	return ModelsURL(auth, baseURL)
+}
+
+// ModelsURL returns the model-list endpoint of auth's provider at baseURL, falling back to
+// the provider default when baseURL is empty. It returns an empty string for providers
+// without a known model-list endpoint.
+func ModelsURL(auth *coreauth.Auth, baseURL string) string {
+	if auth == nil {
+		return ""
+	}
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	compatName := ""
+	if auth.Attributes != nil {
+		compatName = strings.TrimSpace(auth.Attributes["compat_name"])
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	switch {
+	case compatName != "" || provider == "openai-compatibility":
+		if baseURL == "" {
+			return ""
+		}
+		return baseURL + "/models"
+	case provider == "claude":
+		if baseURL == "" {
+			baseURL = defaultClaudeBaseURL
+		}
+		return baseURL + "/v1/models"
+	case provider == "gemini":
+		if baseURL == "" {
+			baseURL = defaultGeminiBaseURL
+		}
+		return baseURL + "/v1beta/models"
+	case provider == "codex" || provider == "xai":
+		if baseURL == "" {
+			return ""
+		}
+		return baseURL + "/models"
+	case provider == "mistral":
+		if baseURL == "" {
+			baseURL = config.DefaultMistralBaseURL
+		}
+		return baseURL + "/models"
+	case provider == "deepseek":
+		if baseURL == "" {
+			baseURL = config.DefaultDeepSeekBaseURL
+		}
+		return baseURL + "/models"
+	case provider == "cohere":
+		if baseURL == "" {
+			baseURL = config.DefaultCohereBaseURL
+		}
+		return baseURL + "/v1/models"
+	case provider == "azure-openai":
+		if baseURL == "" {
+			return ""
+		}
+		apiVersion := strings.TrimSpace(auth.Attributes["api_version"])
+		if apiVersion == "" {
+			apiVersion = config.DefaultAzureOpenAIAPIVersion
+		}
+		return baseURL + "/openai/models?api-version=" + url.QueryEscape(apiVersion)
+	case provider == "bedrock":
+		return bedrockModelsURL(auth, baseURL)
+	default:
+		return ""
+	}
+}
+
+// bedrockModelsURL returns the foundation model list of the Bedrock control plane. Model
+// listing is not served by bedrock-runtime, so the control plane host is derived from the
+// region or from a bedrock-runtime base URL; other custom endpoints cannot be probed.
+func bedrockModelsURL(auth *coreauth.Auth, baseURL string) string {
+	if baseURL == "" {
+		region := strings.TrimSpace(auth.Attributes["aws_region"])
+		if region == "" {
+			region = config.DefaultBedrockRegion
+		}
+		return "https://bedrock." + region + ".amazonaws.com/foundation-models"
+	}
+	parsed, errParse := url.Parse(baseURL)
+	if errParse != nil || !strings.HasPrefix(parsed.Host, "bedrock-runtime.") {
+		return ""
+	}
+	parsed.Host = "bedrock." + strings.TrimPrefix(parsed.Host, "bedrock-runtime.")
+	parsed.Path = "/foundation-models"
+	return parsed.String()
+}
+
+// Snapshot merges auth manager state with the latest probe results.
+// Entries are sorted by provider and auth ID.
+func (p *Prober) Snapshot() []ProviderHealth {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	manager := p.manager
+	results := make(map[string]probeResult, len(p.results))
+	for id, result := range p.results {
+		results[id] = result
+	}
+	p.mu.Unlock()
+	if manager == nil {
+		return nil
+	}
+
+	now := time.Now()
+	out := make([]ProviderHealth, 0)
+	for _, auth := range manager.List() {
+		if auth == nil || auth.ID == "" {
+			continue
+		}
+		result, probed := results[auth.ID]
+		out = append(out, evaluate(auth, result, probed, now))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+func evaluate(auth *coreauth.Auth, result probeResult, probed bool, now time.Time) ProviderHealth {
+	entry := ProviderHealth{
+		AuthID:    auth.ID,
+		AuthIndex: auth.EnsureIndex(),
+		Provider:  strings.ToLower(strings.TrimSpace(auth.Provider)),
+		Label:     auth.Label,
+		State:     StateUnknown,
+		AuthValid: true,
+	}
+	if compatName := strings.TrimSpace(auth.Attributes["compat_name"]); compatName != "" {
+		entry.Provider = strings.ToLower(compatName)
+	}
+	if expiresAt, ok := auth.ExpirationTime(); ok {
+		expiresAt = expiresAt.UTC()
+		entry.ExpiresAt = &expiresAt
+		if expiresAt.Before(now) {
+			entry.AuthValid = false
+			entry.Error = "credential expired"
+		}
+	}
+	if auth.LastError != nil && isAuthFailureStatus(auth.LastError.HTTPStatus) {
+		entry.AuthValid = false
+		entry.Error = auth.LastError.Message
+	}
+
+	if auth.Disabled || auth.Status == coreauth.StatusDisabled {
+		entry.State = StateDisabled
+		return entry
+	}
+
+	if probed {
+		reachable := result.reachable
+		checkedAt := result.checkedAt
+		entry.Reachable = &reachable
+		entry.CheckedAt = &checkedAt
+		entry.StatusCode = result.statusCode
+		entry.LatencyMs = result.latency.Milliseconds()
+		if result.err != "" {
+			entry.Error = result.err
+		}
+		switch {
+		case isAuthFailureStatus(result.statusCode):
+			entry.AuthValid = false
+			entry.State = StateUnhealthy
+		case reachable && result.err == "":
+			// A successful probe proves the credential works even if a stale error is recorded.
+			entry.AuthValid = true
+			entry.Error = ""
+			entry.State = StateHealthy
+		default:
+			entry.State = StateUnhealthy
+		}
+		return entry
+	}
+
+	switch {
+	case !entry.AuthValid:
+		entry.State = StateUnhealthy
+	case auth.Status == coreauth.StatusError || auth.Unavailable:
+		entry.State = StateUnhealthy
+		if entry.Error == "" && auth.LastError != nil {
+			entry.Error = auth.LastError.Message
+		}
+	case auth.Status == coreauth.StatusActive:
+		entry.State = StateHealthy
+	}
+	return entry
+}
+
+func isAuthFailureStatus(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// Usable reports whether the credential can currently serve requests: it is enabled,
+// its auth is valid, and it is not known to be unhealthy.
+func (h ProviderHealth) Usable() bool {
+	return h.AuthValid && (h.State == StateHealthy || h.State == StateUnknown)
+}
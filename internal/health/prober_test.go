@@ -0,0 +1,225 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+type probeTestExecutor struct {
+	provider      string
+	refreshErrors map[string]error
+	refreshed     atomic.Int32
+}
+
+type probeStatusError struct{ code int }
+
+func (e probeStatusError) Error() string   { return http.StatusText(e.code) }
+func (e probeStatusError) StatusCode() int { return e.code }
+
+func (e *probeTestExecutor) Identifier() string { return e.provider }
+
+func (e *probeTestExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func (e *probeTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	chunks := make(chan coreexecutor.StreamChunk)
+	close(chunks)
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (e *probeTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	e.refreshed.Add(1)
+	if errRefresh := e.refreshErrors[auth.ID]; errRefresh != nil {
+		return nil, errRefresh
+	}
+	return auth, nil
+}
+
+func (e *probeTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func (e *probeTestExecutor) HttpRequest(_ context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	token := auth.Attributes["api_key"]
+	if token == "" {
+		token, _ = auth.Metadata["access_token"].(string)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return http.DefaultClient.Do(req)
+}
+
+func TestProberProbeAllReportsReachabilityAndAuthValidity(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer upstream.Close()
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&probeTestExecutor{provider: "claude"})
+	for _, auth := range []*coreauth.Auth{
+		{ID: "good", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"api_key": "good-key", "base_url": upstream.URL}},
+		{ID: "bad", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"api_key": "bad-key", "base_url": upstream.URL}},
+		{ID: "off", Provider: "claude", Disabled: true, Status: coreauth.StatusDisabled, Attributes: map[string]string{"api_key": "good-key", "base_url": upstream.URL}},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+
+	prober := NewProber(manager)
+	prober.ProbeAll(context.Background())
+
+	got := make(map[string]ProviderHealth)
+	for _, entry := range prober.Snapshot() {
+		got[entry.AuthID] = entry
+	}
+
+	good := got["good"]
+	if good.State != StateHealthy || !good.AuthValid || good.Reachable == nil || !*good.Reachable || good.StatusCode != http.StatusOK {
+		t.Fatalf("good entry = %+v, want healthy reachable valid 200", good)
+	}
+	if good.CheckedAt == nil {
+		t.Fatalf("good entry missing checked_at")
+	}
+
+	bad := got["bad"]
+	if bad.State != StateUnhealthy || bad.AuthValid || bad.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad entry = %+v, want unhealthy invalid 401", bad)
+	}
+
+	off := got["off"]
+	if off.State != StateDisabled || off.CheckedAt != nil {
+		t.Fatalf("disabled entry = %+v, want disabled without probe", off)
+	}
+}
+
+func TestProberProbeAllProbesOAuthCredentialsWithoutRefreshing(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer oauth-token" || r.Header.Get("anthropic-beta") != claudeOAuthBeta {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer upstream.Close()
+
+	soon := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	later := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	codex := &probeTestExecutor{provider: "codex", refreshErrors: map[string]error{
+		"revoked": probeStatusError{code: http.StatusUnauthorized},
+	}}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(codex)
+	manager.RegisterExecutor(&probeTestExecutor{provider: "claude"})
+	for _, auth := range []*coreauth.Auth{
+		{ID: "claude", Provider: "claude", Status: coreauth.StatusActive, Attributes: map[string]string{"base_url": upstream.URL}, Metadata: map[string]any{"email": "a@example.com", "access_token": "oauth-token", "expired": later}},
+		{ID: "fresh", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "b@example.com", "expired": later}},
+		{ID: "expiring", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "c@example.com", "expired": soon}},
+		{ID: "revoked", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "d@example.com", "expired": soon}},
+		{ID: "no-executor", Provider: "kimi", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "e@example.com", "expired": soon}},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+
+	prober := NewProber(manager)
+	prober.ProbeAll(context.Background())
+
+	got := make(map[string]ProviderHealth)
+	for _, entry := range prober.Snapshot() {
+		got[entry.AuthID] = entry
+	}
+	if entry := got["claude"]; entry.State != StateHealthy || entry.StatusCode != http.StatusOK {
+		t.Fatalf("claude entry = %+v, want healthy model-list probe with the access token", entry)
+	}
+	if entry := got["fresh"]; entry.CheckedAt != nil {
+		t.Fatalf("fresh entry = %+v, want no probe", entry)
+	}
+	if entry := got["expiring"]; entry.State != StateHealthy || entry.Reachable == nil || !*entry.Reachable {
+		t.Fatalf("expiring entry = %+v, want healthy after refresh", entry)
+	}
+	if entry := got["revoked"]; entry.State != StateUnhealthy || entry.AuthValid || entry.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("revoked entry = %+v, want unhealthy invalid 401", entry)
+	}
+	if entry := got["no-executor"]; entry.CheckedAt != nil {
+		t.Fatalf("no-executor entry = %+v, want no probe", entry)
+	}
+	if refreshed := codex.refreshed.Load(); refreshed != 2 {
+		t.Fatalf("refreshes = %d, want only the two expiring tokens", refreshed)
+	}
+}
+
+func TestProberSnapshotUsesAuthStateWithoutProbe(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	expired := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "oauth-ok", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "a@example.com"}},
+		{ID: "oauth-expired", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "b@example.com", "expired": expired}},
+		{ID: "oauth-error", Provider: "codex", Status: coreauth.StatusError, LastError: &coreauth.Error{Message: "upstream down", HTTPStatus: http.StatusBadGateway}},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+
+	got := make(map[string]ProviderHealth)
+	for _, entry := range NewProber(manager).Snapshot() {
+		got[entry.AuthID] = entry
+	}
+
+	if entry := got["oauth-ok"]; entry.State != StateHealthy || !entry.AuthValid || entry.Reachable != nil {
+		t.Fatalf("oauth-ok entry = %+v, want healthy without probe", entry)
+	}
+	if entry := got["oauth-expired"]; entry.State != StateUnhealthy || entry.AuthValid || entry.ExpiresAt == nil {
+		t.Fatalf("oauth-expired entry = %+v, want unhealthy expired", entry)
+	}
+	if entry := got["oauth-error"]; entry.State != StateUnhealthy || !entry.AuthValid || entry.Error != "upstream down" {
+		t.Fatalf("oauth-error entry = %+v, want unhealthy with last error", entry)
+	}
+}
+
+func TestProbeURL(t *testing.T) {
+	cases := []struct {
+		name string
+		auth *coreauth.Auth
+		want string
+	}{
+		{name: "claude default", auth: &coreauth.Auth{Provider: "claude", Attributes: map[string]string{"api_key": "k"}}, want: "https://api.anthropic.com/v1/models"},
+		{name: "gemini custom", auth: &coreauth.Auth{Provider: "gemini", Attributes: map[string]string{"api_key": "k", "base_url": "https://g.example.com/"}}, want: "https://g.example.com/v1beta/models"},
+		{name: "compat", auth: &coreauth.Auth{Provider: "openrouter", Attributes: map[string]string{"api_key": "k", "base_url": "https://openrouter.ai/api/v1", "compat_name": "openrouter"}}, want: "https://openrouter.ai/api/v1/models"},
+		{name: "mistral default", auth: &coreauth.Auth{Provider: "mistral", Attributes: map[string]string{"api_key": "k"}}, want: "https://api.mistral.ai/v1/models"},
+		{name: "deepseek default", auth: &coreauth.Auth{Provider: "deepseek", Attributes: map[string]string{"api_key": "k"}}, want: "https://api.deepseek.com/models"},
+		{name: "cohere custom", auth: &coreauth.Auth{Provider: "cohere", Attributes: map[string]string{"api_key": "k", "base_url": "https://cohere.example.com"}}, want: "https://cohere.example.com/v1/models"},
+		{name: "azure openai", auth: &coreauth.Auth{Provider: "azure-openai", Attributes: map[string]string{"api_key": "k", "base_url": "https://res.openai.azure.com/", "api_version": "2025-01-01"}}, want: "https://res.openai.azure.com/openai/models?api-version=2025-01-01"},
+		{name: "azure openai without endpoint", auth: &coreauth.Auth{Provider: "azure-openai", Attributes: map[string]string{"api_key": "k"}}, want: ""},
+		{name: "bedrock region", auth: &coreauth.Auth{Provider: "bedrock", Attributes: map[string]string{"api_key": "k", "aws_region": "eu-west-1"}}, want: "https://bedrock.eu-west-1.amazonaws.com/foundation-models"},
+		{name: "bedrock runtime url", auth: &coreauth.Auth{Provider: "bedrock", Attributes: map[string]string{"api_key": "k", "base_url": "https://bedrock-runtime.us-west-2.amazonaws.com"}}, want: "https://bedrock.us-west-2.amazonaws.com/foundation-models"},
+		{name: "bedrock private endpoint", auth: &coreauth.Auth{Provider: "bedrock", Attributes: map[string]string{"api_key": "k", "base_url": "https://vpce.example.com"}}, want: ""},
+		{name: "claude oauth", auth: &coreauth.Auth{Provider: "claude", Metadata: map[string]any{"email": "a@example.com"}}, want: "https://api.anthropic.com/v1/models"},
+		{name: "oauth", auth: &coreauth.Auth{Provider: "codex", Metadata: map[string]any{"email": "a@example.com"}}, want: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := probeURL(tc.auth); got != tc.want {
+				t.Fatalf("probeURL() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
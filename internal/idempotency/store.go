@@ -0,0 +1,161 @@
+// Package idempotency stores responses to requests carrying an Idempotency-Key so retried
+// requests are answered without calling the upstream again.
+package idempotency
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// Outcome is the result of Begin.
+type Outcome int
+
+const (
+	// OutcomeNew means the caller owns the key and must call Complete or Abort.
+	OutcomeNew Outcome = iota
+	// OutcomeReplay means a stored response is available.
+	OutcomeReplay
+	// OutcomeInFlight means another request with the same key is still running.
+	OutcomeInFlight
+	// OutcomeMismatch means the key was used with a different request.
+	OutcomeMismatch
+)
+
+// Response is a stored response.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type entry struct {
+	key         string
+	fingerprint string
+	response    *Response
+	expiresAt   time.Time
+}
+
+// Store keeps pending and completed requests in memory, evicting the oldest completed
+// entries beyond the configured limit. A nil Store is disabled.
+type Store struct {
+	mu         sync.Mutex
+	enabled    bool
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+
+	clock clock.Clock
+}
+
+// New creates a store using the provided configuration.
+func New(cfg config.IdempotencyConfig) *Store {
+	s := &Store{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		clock:   clock.Default(),
+	}
+	s.Update(cfg)
+	return s
+}
+
+// Update applies cfg. Stored responses are dropped when the store is disabled.
+func (s *Store) Update(cfg config.IdempotencyConfig) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = cfg.Enable
+	s.ttl = cfg.TTLDuration()
+	s.maxEntries = cfg.MaxEntries
+	if s.maxEntries <= 0 {
+		s.maxEntries = config.DefaultIdempotencyMaxEntries
+	}
+	if !s.enabled {
+		s.entries = make(map[string]*list.Element)
+		s.order.Init()
+		return
+	}
+	s.evictLocked()
+}
+
+// Enabled reports whether Idempotency-Key headers are honored.
+func (s *Store) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabled
+}
+
+// Begin claims key for a request identified by fingerprint. With OutcomeReplay the stored
+// response is returned.
+func (s *Store) Begin(key, fingerprint string) (Outcome, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	if elem, ok := s.entries[key]; ok {
+		e := elem.Value.(*entry)
+		if e.response != nil && !now.Before(e.expiresAt) {
+			s.removeLocked(elem)
+		} else {
+			switch {
+			case e.fingerprint != fingerprint:
+				return OutcomeMismatch, nil
+			case e.response == nil:
+				return OutcomeInFlight, nil
+			default:
+				return OutcomeReplay, e.response
+			}
+		}
+	}
+	s.entries[key] = s.order.PushFront(&entry{key: key, fingerprint: fingerprint})
+	return OutcomeNew, nil
+}
+
+// Complete stores the response of a request claimed with Begin.
+func (s *Store) Complete(key string, response *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	e := elem.Value.(*entry)
+	e.response = response
+	e.expiresAt = s.clock.Now().Add(s.ttl)
+	s.order.MoveToFront(elem)
+	s.evictLocked()
+}
+
+// Abort releases a key claimed with Begin without storing a response, so the request can be retried.
+func (s *Store) Abort(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok && elem.Value.(*entry).response == nil {
+		s.removeLocked(elem)
+	}
+}
+
+// evictLocked drops the oldest completed entries beyond maxEntries. Pending entries are kept.
+func (s *Store) evictLocked() {
+	for elem := s.order.Back(); elem != nil && s.order.Len() > s.maxEntries; {
+		prev := elem.Prev()
+		if elem.Value.(*entry).response != nil {
+			s.removeLocked(elem)
+		}
+		elem = prev
+	}
+}
+
+func (s *Store) removeLocked(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*entry).key)
+}
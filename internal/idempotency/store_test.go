@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestStore_BeginCompleteAndExpire(t *testing.T) {
+	now := clock.NewSim(time.Unix(1_700_000_000, 0))
+	s := New(config.IdempotencyConfig{Enable: true, TTL: "1m"})
+	s.clock = now
+
+	if outcome, _ := s.Begin("k", "f1"); outcome != OutcomeNew {
+		t.Fatalf("first Begin = %v, want new", outcome)
+	}
+	if outcome, _ := s.Begin("k", "f1"); outcome != OutcomeInFlight {
+		t.Fatalf("concurrent Begin = %v, want in flight", outcome)
+	}
+	s.Complete("k", &Response{StatusCode: 200, Body: []byte("ok")})
+	if outcome, resp := s.Begin("k", "f1"); outcome != OutcomeReplay || string(resp.Body) != "ok" {
+		t.Fatalf("retry Begin = %v %v, want replay", outcome, resp)
+	}
+	if outcome, _ := s.Begin("k", "f2"); outcome != OutcomeMismatch {
+		t.Fatalf("different request Begin = %v, want mismatch", outcome)
+	}
+
+	now.Advance(2 * time.Minute)
+	if outcome, _ := s.Begin("k", "f2"); outcome != OutcomeNew {
+		t.Fatalf("Begin after expiry = %v, want new", outcome)
+	}
+	s.Abort("k")
+	if outcome, _ := s.Begin("k", "f1"); outcome != OutcomeNew {
+		t.Fatalf("Begin after abort = %v, want new", outcome)
+	}
+}
+
+func TestStore_EvictsOldestCompletedEntries(t *testing.T) {
+	s := New(config.IdempotencyConfig{Enable: true, MaxEntries: 2})
+	s.Begin("pending", "f")
+	for _, key := range []string{"a", "b", "c"} {
+		s.Begin(key, "f")
+		s.Complete(key, &Response{StatusCode: 200})
+	}
+	if outcome, _ := s.Begin("a", "f"); outcome != OutcomeNew {
+		t.Fatalf("oldest entry Begin = %v, want new after eviction", outcome)
+	}
+	if outcome, _ := s.Begin("pending", "f"); outcome != OutcomeInFlight {
+		t.Fatalf("pending entry Begin = %v, want in flight", outcome)
+	}
+}
@@ -0,0 +1,234 @@
+// Package lifecycle delivers server lifecycle events to operator-configured scripts and
+// webhooks, so reactions such as re-running a login flow can be automated without polling logs.
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event types.
+const (
+	EventServerStarted       = "server.started"
+	EventConfigReloaded      = "config.reloaded"
+	EventModuleRegistered    = "module.registered"
+	EventModuleFailed        = "module.failed"
+	EventCredentialRefreshed = "credential.refreshed"
+	EventCredentialExpired   = "credential.expired"
+	EventRequestStarted      = "request.started"
+	EventRequestCompleted    = "request.completed"
+	EventRequestFailed       = "request.failed"
+	EventQuotaExceeded       = "quota.exceeded"
+	EventBudgetExceeded      = "budget.exceeded"
+)
+
+// queueSize bounds the events waiting for delivery; further events are dropped.
+const queueSize = 256
+
+// retryBackoff is the delay before the first webhook retry; it doubles for every further retry.
+var retryBackoff = time.Second
+
+// Event is the JSON payload delivered to hooks.
+type Event struct {
+	Type string         `json:"type"`
+	Time time.Time      `json:"time"`
+	Data map[string]any `json:"data,omitempty"`
+}
+
+type queuedEvent struct {
+	event Event
+	cfg   config.LifecycleHooksConfig
+}
+
+// Dispatcher delivers events to the configured hooks in order on a background goroutine.
+type Dispatcher struct {
+	// Client sends webhook requests.
+	Client *http.Client
+
+	mu    sync.RWMutex
+	cfg   config.LifecycleHooksConfig
+	queue chan queuedEvent
+	start sync.Once
+}
+
+// NewDispatcher returns a Dispatcher with no hooks configured.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{Client: &http.Client{}, queue: make(chan queuedEvent, queueSize)}
+}
+
+var defaultDispatcher = NewDispatcher()
+
+// Default returns the process-wide Dispatcher.
+func Default() *Dispatcher { return defaultDispatcher }
+
+// Configure replaces the hook configuration. Events already queued keep the configuration
+// they were emitted with.
+func (d *Dispatcher) Configure(cfg config.LifecycleHooksConfig) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.cfg = cfg
+	d.mu.Unlock()
+}
+
+// Emit queues an event for delivery without blocking. It does nothing when no hook wants the
+// event, and drops the event when the queue is full.
+func (d *Dispatcher) Emit(eventType string, data map[string]any) {
+	if d == nil {
+		return
+	}
+	d.mu.RLock()
+	cfg := d.cfg
+	d.mu.RUnlock()
+	if !cfg.Enabled() || !cfg.WantsAny(eventType) {
+		return
+	}
+	d.start.Do(func() { go d.run() })
+	queued := queuedEvent{event: Event{Type: eventType, Time: clock.Default().Now().UTC(), Data: data}, cfg: cfg}
+	select {
+	case d.queue <- queued:
+	default:
+		log.Warnf("lifecycle: dropping %s event, delivery queue is full", eventType)
+	}
+}
+
+func (d *Dispatcher) run() {
+	for queued := range d.queue {
+		d.deliver(context.Background(), queued.cfg, queued.event)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, cfg config.LifecycleHooksConfig, event Event) {
+	body, errMarshal := json.Marshal(event)
+	if errMarshal != nil {
+		log.Warnf("lifecycle: failed to encode %s event: %v", event.Type, errMarshal)
+		return
+	}
+	if len(cfg.Command) > 0 && cfg.Wants(event.Type) {
+		if errRun := runCommand(ctx, cfg, event.Type, body); errRun != nil {
+			log.Warnf("lifecycle: hook command failed for %s: %v", event.Type, errRun)
+		}
+	}
+	for _, target := range cfg.Targets() {
+		if !target.Wants(event.Type) {
+			continue
+		}
+		payload := body
+		if target.Format == config.LifecycleWebhookFormatSlack {
+			payload, _ = json.Marshal(map[string]string{"text": slackText(event)})
+		}
+		if errPost := d.postWebhookWithRetry(ctx, cfg, target, event.Type, payload); errPost != nil {
+			log.Warnf("lifecycle: webhook %s failed for %s: %v", target.URL, event.Type, errPost)
+		}
+	}
+}
+
+func runCommand(ctx context.Context, cfg config.LifecycleHooksConfig, eventType string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, cfg.Command[0], cfg.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), "CLIPROXY_EVENT="+eventType)
+	output, errRun := cmd.CombinedOutput()
+	if errRun != nil {
+		return fmt.Errorf("%w: %s", errRun, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// postWebhookWithRetry posts body to target, retrying network errors, 429 and 5xx responses
+// with exponential backoff.
+func (d *Dispatcher) postWebhookWithRetry(ctx context.Context, cfg config.LifecycleHooksConfig, target config.LifecycleWebhook, eventType string, body []byte) error {
+	backoff := retryBackoff
+	retries := cfg.RetryCount()
+	for attempt := 0; ; attempt++ {
+		retryable, errPost := d.postWebhook(ctx, cfg, target, eventType, body)
+		if errPost == nil || !retryable || attempt >= retries {
+			return errPost
+		}
+		log.Debugf("lifecycle: webhook %s failed for %s, retrying in %s: %v", target.URL, eventType, backoff, errPost)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errPost
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook sends one delivery and reports whether a failure is worth retrying.
+func (d *Dispatcher) postWebhook(ctx context.Context, cfg config.LifecycleHooksConfig, target config.LifecycleWebhook, eventType string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout())
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if errReq != nil {
+		return false, errReq
+	}
+	for name, value := range target.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CLIProxy-Event", eventType)
+	if target.Secret != "" {
+		req.Header.Set("X-CLIProxy-Signature", Sign(target.Secret, body))
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return true, errDo
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retryable, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// slackText renders an event as a one-line Slack message: the event type followed by its data
+// as sorted key=value pairs.
+func slackText(event Event) string {
+	keys := make([]string, 0, len(event.Data))
+	for key := range event.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var text strings.Builder
+	text.WriteString("*" + event.Type + "*")
+	for _, key := range keys {
+		fmt.Fprintf(&text, " %s=%v", key, event.Data[key])
+	}
+	return text.String()
+}
+
+// Sign returns the X-CLIProxy-Signature value of a webhook body: "sha256=" followed by the
+// hex HMAC-SHA256 of body keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
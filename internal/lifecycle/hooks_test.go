@@ -0,0 +1,157 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestEmitPostsSignedWebhook(t *testing.T) {
+	type received struct {
+		event     Event
+		body      []byte
+		signature string
+		header    string
+	}
+	got := make(chan received, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var event Event
+		_ = json.Unmarshal(body, &event)
+		got <- received{event: event, body: body, signature: r.Header.Get("X-CLIProxy-Signature"), header: r.Header.Get("X-Team")}
+	}))
+	defer server.Close()
+
+	d := NewDispatcher()
+	d.Configure(config.LifecycleHooksConfig{
+		WebhookURL: server.URL,
+		Secret:     "s3cret",
+		Headers:    map[string]string{"X-Team": "ops"},
+		Events:     []string{"credential.*"},
+	})
+	d.Emit(EventServerStarted, nil)
+	d.Emit(EventCredentialExpired, map[string]any{"auth_id": "a1"})
+
+	select {
+	case r := <-got:
+		if r.event.Type != EventCredentialExpired || r.event.Data["auth_id"] != "a1" {
+			t.Fatalf("event = %+v", r.event)
+		}
+		if r.signature != Sign("s3cret", r.body) {
+			t.Fatalf("signature = %q", r.signature)
+		}
+		if r.header != "ops" {
+			t.Fatalf("custom header = %q", r.header)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+	select {
+	case r := <-got:
+		t.Fatalf("unexpected event %s", r.event.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDeliverRunsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	cfg := config.LifecycleHooksConfig{Command: []string{"sh", "-c", `cat > "$0/event.json"; printf %s "$CLIPROXY_EVENT" > "$0/type"`, dir}}
+	NewDispatcher().deliver(context.Background(), cfg, Event{Type: EventConfigReloaded})
+
+	data, errRead := os.ReadFile(filepath.Join(dir, "event.json"))
+	if errRead != nil {
+		t.Fatalf("read hook stdin: %v", errRead)
+	}
+	var event Event
+	if errDecode := json.Unmarshal(data, &event); errDecode != nil || event.Type != EventConfigReloaded {
+		t.Fatalf("hook stdin = %s (%v)", data, errDecode)
+	}
+	if envType, _ := os.ReadFile(filepath.Join(dir, "type")); string(envType) != EventConfigReloaded {
+		t.Fatalf("CLIPROXY_EVENT = %q", envType)
+	}
+}
+
+func TestWebhooksRetryAndFilterPerTarget(t *testing.T) {
+	previousBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = previousBackoff }()
+
+	attempts := 0
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("X-CLIProxy-Signature") == "" {
+			t.Error("missing signature")
+		}
+	}))
+	defer flaky.Close()
+	rejected := 0
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rejected++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	credentialOnly := 0
+	filtered := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentialOnly++
+	}))
+	defer filtered.Close()
+
+	cfg := config.LifecycleHooksConfig{Webhooks: []config.LifecycleWebhook{
+		{URL: flaky.URL, Secret: "s3cret", Events: []string{"request.*"}},
+		{URL: rejecting.URL},
+		{URL: filtered.URL, Events: []string{"credential.*"}},
+	}}
+	NewDispatcher().deliver(context.Background(), cfg, Event{Type: EventRequestFailed})
+
+	if attempts != 3 {
+		t.Fatalf("flaky webhook attempts = %d, want 3", attempts)
+	}
+	if rejected != 1 {
+		t.Fatalf("client error attempts = %d, want 1 (no retry)", rejected)
+	}
+	if credentialOnly != 0 {
+		t.Fatalf("filtered webhook called %d times", credentialOnly)
+	}
+
+	attempts = 0
+	cfg.Retries = -1
+	NewDispatcher().deliver(context.Background(), cfg, Event{Type: EventRequestFailed})
+	if attempts != 1 {
+		t.Fatalf("attempts with retries disabled = %d, want 1", attempts)
+	}
+}
+
+func TestSlackWebhookFormat(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	cfg := config.LifecycleHooksConfig{Webhooks: []config.LifecycleWebhook{{URL: server.URL, Format: config.LifecycleWebhookFormatSlack}}}
+	NewDispatcher().deliver(context.Background(), cfg, Event{Type: EventQuotaExceeded, Data: map[string]any{"scope": "client", "api_key": "sk-***"}})
+
+	var message map[string]string
+	if errDecode := json.Unmarshal(body, &message); errDecode != nil {
+		t.Fatalf("decode slack body %s: %v", body, errDecode)
+	}
+	if want := "*quota.exceeded* api_key=sk-*** scope=client"; message["text"] != want {
+		t.Fatalf("text = %q, want %q", message["text"], want)
+	}
+}
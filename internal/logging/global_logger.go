@@ -20,6 +20,7 @@ var (
 	setupOnce      sync.Once
 	writerMu       sync.Mutex
 	logWriter      *lumberjack.Logger
+	errorLogWriter *lumberjack.Logger
 	ginInfoWriter  *io.PipeWriter
 	ginErrorWriter *io.PipeWriter
 )
@@ -111,6 +112,7 @@ func SetupBaseLogger() {
 			log.StandardLogger().Infof(format, values...)
 		}
 
+		log.AddHook(errorHook)
 		log.RegisterExitHandler(closeLogOutputs)
 	})
 }
@@ -156,7 +158,8 @@ func ResolveLogDirectory(cfg *config.Config) string {
 	return logDir
 }
 
-// ConfigureLogOutput switches the global log destination between rotating files and stdout.
+// ConfigureLogOutput switches the global log destination between rotating files and stdout
+// and applies the log-output format, rotation and error log settings.
 // When logsMaxTotalSizeMB > 0, a background cleaner removes the oldest log files in the logs directory
 // until the total size is within the limit.
 func ConfigureLogOutput(cfg *config.Config) error {
@@ -166,6 +169,9 @@ func ConfigureLogOutput(cfg *config.Config) error {
 	defer writerMu.Unlock()
 
 	logDir := ResolveLogDirectory(cfg)
+	output := cfg.LogOutput
+	formatter := newLogFormatter(output.Format)
+	log.SetFormatter(formatter)
 
 	protectedPath := ""
 	if cfg.LoggingToFile {
@@ -176,13 +182,7 @@ func ConfigureLogOutput(cfg *config.Config) error {
 			_ = logWriter.Close()
 		}
 		protectedPath = filepath.Join(logDir, "main.log")
-		logWriter = &lumberjack.Logger{
-			Filename:   protectedPath,
-			MaxSize:    10,
-			MaxBackups: 0,
-			MaxAge:     0,
-			Compress:   false,
-		}
+		logWriter = newRotatingLogWriter(protectedPath, output)
 		log.SetOutput(logWriter)
 	} else {
 		if logWriter != nil {
@@ -192,10 +192,43 @@ func ConfigureLogOutput(cfg *config.Config) error {
 		log.SetOutput(os.Stdout)
 	}
 
+	if errorLogWriter != nil {
+		_ = errorLogWriter.Close()
+		errorLogWriter = nil
+	}
+	switch output.ErrorOutput {
+	case "":
+		errorHook.set(nil, nil)
+	case config.LogErrorOutputStderr:
+		errorHook.set(os.Stderr, formatter)
+	default:
+		if err := os.MkdirAll(logDir, 0o755); err != nil {
+			errorHook.set(nil, nil)
+			return fmt.Errorf("logging: failed to create log directory: %w", err)
+		}
+		errorLogWriter = newRotatingLogWriter(filepath.Join(logDir, output.ErrorOutput), output)
+		errorHook.set(errorLogWriter, formatter)
+	}
+
 	configureLogDirCleanerLocked(logDir, cfg.LogsMaxTotalSizeMB, protectedPath)
 	return nil
 }
 
+// newRotatingLogWriter returns a lumberjack writer using the log-output rotation settings.
+func newRotatingLogWriter(path string, output config.LogOutputConfig) *lumberjack.Logger {
+	maxSize := output.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = 10
+	}
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: output.MaxBackups,
+		MaxAge:     output.MaxAgeDays,
+		Compress:   output.Compress,
+	}
+}
+
 func closeLogOutputs() {
 	writerMu.Lock()
 	defer writerMu.Unlock()
@@ -206,6 +239,11 @@ func closeLogOutputs() {
 		_ = logWriter.Close()
 		logWriter = nil
 	}
+	errorHook.set(nil, nil)
+	if errorLogWriter != nil {
+		_ = errorLogWriter.Close()
+		errorLogWriter = nil
+	}
 	if ginInfoWriter != nil {
 		_ = ginInfoWriter.Close()
 		ginInfoWriter = nil
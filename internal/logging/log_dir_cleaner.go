@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler/jobs"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -15,8 +16,16 @@ const logDirCleanerInterval = time.Minute
 
 var logDirCleanerCancel context.CancelFunc
 
+// logDirCleanerTarget holds the settings of the active cleaner for on-demand runs.
+var logDirCleanerTarget struct {
+	logDir        string
+	maxBytes      int64
+	protectedPath string
+}
+
 func configureLogDirCleanerLocked(logDir string, maxTotalSizeMB int, protectedPath string) {
 	stopLogDirCleanerLocked()
+	logDirCleanerTarget.logDir, logDirCleanerTarget.maxBytes, logDirCleanerTarget.protectedPath = "", 0, ""
 
 	if maxTotalSizeMB <= 0 {
 		return
@@ -32,6 +41,10 @@ func configureLogDirCleanerLocked(logDir string, maxTotalSizeMB int, protectedPa
 		return
 	}
 
+	logDirCleanerTarget.logDir = filepath.Clean(dir)
+	logDirCleanerTarget.maxBytes = maxBytes
+	logDirCleanerTarget.protectedPath = strings.TrimSpace(protectedPath)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	logDirCleanerCancel = cancel
 	go runLogDirCleaner(ctx, filepath.Clean(dir), maxBytes, strings.TrimSpace(protectedPath))
@@ -45,6 +58,15 @@ func stopLogDirCleanerLocked() {
 	logDirCleanerCancel = nil
 }
 
+// CleanLogDirectory enforces logs-max-total-size-mb immediately and returns the number of
+// removed files. It does nothing when no size limit is configured.
+func CleanLogDirectory() (int, error) {
+	writerMu.Lock()
+	target := logDirCleanerTarget
+	writerMu.Unlock()
+	return enforceLogDirSizeLimit(target.logDir, target.maxBytes, target.protectedPath)
+}
+
 func runLogDirCleaner(ctx context.Context, logDir string, maxBytes int64, protectedPath string) {
 	ticker := time.NewTicker(logDirCleanerInterval)
 	defer ticker.Stop()
@@ -66,7 +88,9 @@ func runLogDirCleaner(ctx context.Context, logDir string, maxBytes int64, protec
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			cleanOnce()
+			if !jobs.Scheduled(jobs.LogCleanup) {
+				cleanOnce()
+			}
 		}
 	}
 }
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// newLogFormatter returns the logrus formatter for a log-output.format value.
+// Unknown formats fall back to the pretty LogFormatter.
+func newLogFormatter(format string) log.Formatter {
+	switch format {
+	case config.LogFormatJSON:
+		return &log.JSONFormatter{
+			TimestampFormat:  time.RFC3339Nano,
+			CallerPrettyfier: prettyLogCaller,
+		}
+	case config.LogFormatLogfmt:
+		return &log.TextFormatter{
+			DisableColors:    true,
+			FullTimestamp:    true,
+			TimestampFormat:  time.RFC3339Nano,
+			CallerPrettyfier: prettyLogCaller,
+		}
+	default:
+		return &LogFormatter{}
+	}
+}
+
+// prettyLogCaller reduces the caller to "file.go:line" and omits the function name.
+func prettyLogCaller(frame *runtime.Frame) (string, string) {
+	return "", fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+}
+
+// errorOutputHook copies error-level entries to a separate destination.
+// It is registered once; ConfigureLogOutput swaps its writer and formatter.
+type errorOutputHook struct {
+	mu        sync.Mutex
+	writer    io.Writer
+	formatter log.Formatter
+}
+
+var errorHook = &errorOutputHook{}
+
+// Levels implements log.Hook.
+func (h *errorOutputHook) Levels() []log.Level {
+	return []log.Level{log.PanicLevel, log.FatalLevel, log.ErrorLevel}
+}
+
+// Fire implements log.Hook.
+func (h *errorOutputHook) Fire(entry *log.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.writer == nil || h.formatter == nil {
+		return nil
+	}
+	line, errFormat := h.formatter.Format(entry)
+	if errFormat != nil {
+		return errFormat
+	}
+	_, errWrite := h.writer.Write(line)
+	return errWrite
+}
+
+func (h *errorOutputHook) set(writer io.Writer, formatter log.Formatter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writer = writer
+	h.formatter = formatter
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestNewLogFormatterSelectsFormat(t *testing.T) {
+	logger := log.New()
+	logger.SetReportCaller(true)
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+
+	logger.SetFormatter(newLogFormatter(config.LogFormatJSON))
+	logger.WithField("model", "gpt-5").Info("json entry")
+	var decoded map[string]any
+	if errDecode := json.Unmarshal(out.Bytes(), &decoded); errDecode != nil {
+		t.Fatalf("json output %q: %v", out.String(), errDecode)
+	}
+	if decoded["msg"] != "json entry" || decoded["model"] != "gpt-5" {
+		t.Fatalf("json output = %v", decoded)
+	}
+	if file, _ := decoded["file"].(string); !strings.HasPrefix(file, "log_format_test.go:") {
+		t.Fatalf("json caller = %q, want base file name and line", file)
+	}
+
+	out.Reset()
+	logger.SetFormatter(newLogFormatter(config.LogFormatLogfmt))
+	logger.Info("logfmt entry")
+	if line := out.String(); !strings.Contains(line, `level=info msg="logfmt entry"`) {
+		t.Fatalf("logfmt output = %q", line)
+	}
+
+	if _, ok := newLogFormatter(config.LogFormatPretty).(*LogFormatter); !ok {
+		t.Fatal("pretty format should use LogFormatter")
+	}
+}
+
+func TestErrorOutputHookCopiesErrorsOnly(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(&bytes.Buffer{})
+	var errOut bytes.Buffer
+	hook := &errorOutputHook{}
+	hook.set(&errOut, newLogFormatter(config.LogFormatLogfmt))
+	logger.AddHook(hook)
+
+	logger.Warn("just a warning")
+	logger.Error("upstream failed")
+
+	if got := errOut.String(); strings.Contains(got, "just a warning") || !strings.Contains(got, "upstream failed") {
+		t.Fatalf("error output = %q, want only the error entry", got)
+	}
+
+	hook.set(nil, nil)
+	errOut.Reset()
+	logger.Error("after disable")
+	if errOut.Len() != 0 {
+		t.Fatalf("error output after disable = %q, want empty", errOut.String())
+	}
+}
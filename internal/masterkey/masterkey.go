@@ -0,0 +1,82 @@
+// Package masterkey loads the master key secret shared by content and credential encryption
+// and seals values with AES-256-GCM under it. Sealed values carry their random nonce as a
+// prefix.
+package masterkey
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// ErrTruncated is returned by Open when sealed data is shorter than its nonce.
+var ErrTruncated = errors.New("masterkey: sealed data truncated")
+
+// Load derives the 32-byte master key from the secret named by cfg. It returns nil, nil when
+// no key source is configured.
+func Load(cfg config.MasterKeyConfig) ([]byte, error) {
+	var secret string
+	switch {
+	case cfg.MasterKeyEnv != "":
+		secret = os.Getenv(cfg.MasterKeyEnv)
+		if strings.TrimSpace(secret) == "" {
+			return nil, fmt.Errorf("master key environment variable %s is empty", cfg.MasterKeyEnv)
+		}
+	case cfg.MasterKeyFile != "":
+		data, errRead := os.ReadFile(cfg.MasterKeyFile)
+		if errRead != nil {
+			return nil, fmt.Errorf("read master key file: %w", errRead)
+		}
+		secret = string(data)
+	default:
+		return nil, nil
+	}
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return nil, errors.New("master key is empty")
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:], nil
+}
+
+// Seal encrypts plaintext under key, binding it to aad.
+func Seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, errGCM := newGCM(key)
+	if errGCM != nil {
+		return nil, errGCM
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, errRand := io.ReadFull(rand.Reader, nonce); errRand != nil {
+		return nil, errRand
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// Open decrypts data produced by Seal with the same key and aad.
+func Open(key, sealed, aad []byte) ([]byte, error) {
+	gcm, errGCM := newGCM(key)
+	if errGCM != nil {
+		return nil, errGCM
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrTruncated
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, errCipher := aes.NewCipher(key)
+	if errCipher != nil {
+		return nil, errCipher
+	}
+	return cipher.NewGCM(block)
+}
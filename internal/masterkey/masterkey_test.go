@@ -0,0 +1,52 @@
+package masterkey
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestLoadReadsEnvironmentBeforeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "master.key")
+	if errWrite := os.WriteFile(path, []byte("file-secret\n"), 0o600); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	t.Setenv("MASTERKEY_TEST_KEY", "env-secret")
+
+	fromEnv, errEnv := Load(config.MasterKeyConfig{MasterKeyEnv: "MASTERKEY_TEST_KEY", MasterKeyFile: path})
+	fromFile, errFile := Load(config.MasterKeyConfig{MasterKeyFile: path})
+	if errEnv != nil || errFile != nil {
+		t.Fatalf("Load = %v, %v", errEnv, errFile)
+	}
+	if len(fromEnv) != 32 || len(fromFile) != 32 || bytes.Equal(fromEnv, fromFile) {
+		t.Fatalf("keys = %x, %x; want distinct 32-byte keys", fromEnv, fromFile)
+	}
+	if key, errNone := Load(config.MasterKeyConfig{}); key != nil || errNone != nil {
+		t.Fatalf("Load(unset) = %x, %v; want nil, nil", key, errNone)
+	}
+	t.Setenv("MASTERKEY_TEST_KEY", "  ")
+	if _, errEmpty := Load(config.MasterKeyConfig{MasterKeyEnv: "MASTERKEY_TEST_KEY"}); errEmpty == nil {
+		t.Fatal("Load accepted an empty environment variable")
+	}
+}
+
+func TestSealOpenBindsAAD(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	sealed, errSeal := Seal(key, []byte("secret"), []byte("a"))
+	if errSeal != nil {
+		t.Fatalf("Seal: %v", errSeal)
+	}
+	if plain, errOpen := Open(key, sealed, []byte("a")); errOpen != nil || string(plain) != "secret" {
+		t.Fatalf("Open = %q, %v", plain, errOpen)
+	}
+	if _, errOpen := Open(key, sealed, []byte("b")); errOpen == nil {
+		t.Fatal("Open accepted a different aad")
+	}
+	if _, errOpen := Open(key, sealed[:4], []byte("a")); !errors.Is(errOpen, ErrTruncated) {
+		t.Fatalf("Open(truncated) = %v, want ErrTruncated", errOpen)
+	}
+}
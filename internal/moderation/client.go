@@ -0,0 +1,100 @@
+// Package moderation calls moderation backends that speak the OpenAI moderations API.
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+// maxResponseBytes bounds the moderation response read.
+const maxResponseBytes = 1 << 20
+
+// Result is the verdict on one text.
+type Result struct {
+	// Flagged reports whether the text triggers the configured action.
+	Flagged bool
+	// Categories lists the flagged categories, sorted.
+	Categories []string
+}
+
+// Check sends text to the moderation backend of cfg. When cfg.Categories is set only those
+// categories flag the text; otherwise the backend's overall flag is used.
+func Check(ctx context.Context, client *http.Client, cfg config.ModerationConfig, text string) (Result, error) {
+	if text == "" {
+		return Result{}, nil
+	}
+	body, errMarshal := json.Marshal(map[string]string{"model": cfg.Model, "input": text})
+	if errMarshal != nil {
+		return Result{}, errMarshal
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout())
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Endpoint, bytes.NewReader(body))
+	if errReq != nil {
+		return Result{}, errReq
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return Result{}, errDo
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, errRead := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if errRead != nil {
+		return Result{}, errRead
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Result{}, fmt.Errorf("moderation: backend returned status %d", resp.StatusCode)
+	}
+	results := gjson.GetBytes(data, "results")
+	if !results.IsArray() {
+		return Result{}, fmt.Errorf("moderation: response has no results")
+	}
+	return parseResults(results, cfg.Categories), nil
+}
+
+func parseResults(results gjson.Result, wanted []string) Result {
+	flaggedCategories := make(map[string]struct{})
+	overall := false
+	for _, result := range results.Array() {
+		if result.Get("flagged").Bool() {
+			overall = true
+		}
+		result.Get("categories").ForEach(func(name, value gjson.Result) bool {
+			if value.Bool() {
+				flaggedCategories[name.String()] = struct{}{}
+			}
+			return true
+		})
+	}
+	var out Result
+	if len(wanted) == 0 {
+		out.Flagged = overall
+		for name := range flaggedCategories {
+			out.Categories = append(out.Categories, name)
+		}
+	} else {
+		for _, name := range wanted {
+			if _, ok := flaggedCategories[name]; ok {
+				out.Categories = append(out.Categories, name)
+			}
+		}
+		out.Flagged = len(out.Categories) > 0
+	}
+	sort.Strings(out.Categories)
+	return out
+}
@@ -0,0 +1,54 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestCheck(t *testing.T) {
+	var gotAuth, gotModel, gotInput string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotModel, gotInput = body["model"], body["input"]
+		_, _ = w.Write([]byte(`{"results":[{"flagged":true,"categories":{"violence":true,"hate":false,"harassment":true}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.ModerationConfig{Endpoint: server.URL, APIKey: "k", Model: "m"}
+	result, errCheck := Check(context.Background(), server.Client(), cfg, "text")
+	if errCheck != nil {
+		t.Fatalf("Check: %v", errCheck)
+	}
+	if gotAuth != "Bearer k" || gotModel != "m" || gotInput != "text" {
+		t.Fatalf("request auth=%q model=%q input=%q", gotAuth, gotModel, gotInput)
+	}
+	if !result.Flagged || len(result.Categories) != 2 || result.Categories[0] != "harassment" {
+		t.Fatalf("result = %+v", result)
+	}
+
+	cfg.Categories = []string{"hate"}
+	if result, _ = Check(context.Background(), server.Client(), cfg, "text"); result.Flagged {
+		t.Fatalf("unwanted categories flagged: %+v", result)
+	}
+	cfg.Categories = []string{"hate", "violence"}
+	if result, _ = Check(context.Background(), server.Client(), cfg, "text"); !result.Flagged || len(result.Categories) != 1 || result.Categories[0] != "violence" {
+		t.Fatalf("category filter result = %+v", result)
+	}
+}
+
+func TestCheckReportsBackendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	if _, errCheck := Check(context.Background(), server.Client(), config.ModerationConfig{Endpoint: server.URL}, "text"); errCheck == nil {
+		t.Fatal("expected an error for a 429 response")
+	}
+}
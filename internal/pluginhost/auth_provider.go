@@ -435,16 +435,25 @@ func (s *pluginTokenStorage) RawJSON() []byte {
 	return payload
 }
 
-func (s *pluginTokenStorage) SaveTokenToFile(path string) error {
+// MarshalToken returns the payload SaveTokenToFile writes.
+func (s *pluginTokenStorage) MarshalToken() ([]byte, error) {
 	if s == nil {
-		return fmt.Errorf("plugin token storage is nil")
+		return nil, fmt.Errorf("plugin token storage is nil")
 	}
 	payload, errPayload := mergedStorageJSON(s.rawJSON, s.meta, s.provider)
 	if errPayload != nil {
-		return errPayload
+		return nil, errPayload
 	}
 	if len(bytes.TrimSpace(payload)) == 0 {
-		return fmt.Errorf("plugin token storage payload is empty")
+		return nil, fmt.Errorf("plugin token storage payload is empty")
+	}
+	return payload, nil
+}
+
+func (s *pluginTokenStorage) SaveTokenToFile(path string) error {
+	payload, errPayload := s.MarshalToken()
+	if errPayload != nil {
+		return errPayload
 	}
 	if pluginTokenStorageFileCurrent(path, payload) {
 		return nil
@@ -11,6 +11,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginabi"
@@ -88,7 +89,7 @@ type Host struct {
 func New() *Host {
 	h := &Host{
 		applyMu:                make(chan struct{}, 1),
-		loader:                 defaultPluginLoader(),
+		loader:                 withSubprocessLoader(defaultPluginLoader()),
 		loaded:                 make(map[string]*loadedPlugin),
 		retired:                make(map[string][]*loadedPlugin),
 		loading:                make(map[string]*pluginLoadRequest),
@@ -285,6 +286,11 @@ func (h *Host) ApplyConfig(ctx context.Context, cfg *config.Config) {
 			if loadResult.err != nil {
 				h.cleanupPluginLoad(file.ID, request, loadResult.loaded)
 				log.Warnf("pluginhost: failed to load plugin %s from %s: %v", file.ID, file.Path, loadResult.err)
+				lifecycle.Default().Emit(lifecycle.EventModuleFailed, map[string]any{
+					"id":    file.ID,
+					"path":  file.Path,
+					"error": loadResult.err.Error(),
+				})
 				continue
 			}
 
@@ -313,6 +319,11 @@ func (h *Host) ApplyConfig(ctx context.Context, cfg *config.Config) {
 			registeredNow = loadResult.initialized
 			h.mu.Unlock()
 			log.WithFields(pluginLogFields(file.ID, "", file.Version, file.Path)).Info("pluginhost: plugin loaded")
+			lifecycle.Default().Emit(lifecycle.EventModuleRegistered, map[string]any{
+				"id":      file.ID,
+				"version": file.Version,
+				"path":    file.Path,
+			})
 		}
 
 		if !registeredNow {
@@ -0,0 +1,281 @@
+package pluginhost
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// subprocessPluginExtension marks plugin files that run as child processes instead of being
+// loaded as dynamic libraries. Such plugins speak the same RPC methods over stdin and stdout.
+const subprocessPluginExtension = ".rpc"
+
+// subprocessShutdownGrace bounds how long a plugin process may take to exit after stdin closes.
+const subprocessShutdownGrace = 5 * time.Second
+
+// subprocessMessage is one newline-delimited JSON frame exchanged with a subprocess plugin.
+// Frames carrying Method are requests; the others answer the request with the same ID.
+// Host and plugin number their own requests independently.
+type subprocessMessage struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+func isSubprocessPluginPath(path string) bool {
+	return strings.HasSuffix(strings.ToLower(filepath.Base(path)), subprocessPluginExtension)
+}
+
+// subprocessAwareLoader opens subprocess plugins itself and delegates every other file to native.
+type subprocessAwareLoader struct {
+	native pluginLoader
+}
+
+func withSubprocessLoader(native pluginLoader) pluginLoader {
+	return subprocessAwareLoader{native: native}
+}
+
+func (l subprocessAwareLoader) Open(file pluginFile, host *Host) (pluginClient, error) {
+	if isSubprocessPluginPath(file.Path) {
+		return startSubprocessPlugin(file, host)
+	}
+	if l.native == nil {
+		return nil, fmt.Errorf("no loader for plugin %s", file.Path)
+	}
+	return l.native.Open(file, host)
+}
+
+type subprocessClient struct {
+	file  pluginFile
+	host  *Host
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan subprocessMessage
+	closed  bool
+	exitErr error
+	done    chan struct{}
+
+	// stderrDone is closed once logStderr has read stderr to EOF.
+	stderrDone chan struct{}
+}
+
+// subprocessCommand builds the command that runs a subprocess plugin; tests replace it.
+var subprocessCommand = func(path string) *exec.Cmd { return exec.Command(path) }
+
+func startSubprocessPlugin(file pluginFile, host *Host) (*subprocessClient, error) {
+	cmd := subprocessCommand(file.Path)
+	cmd.Dir = filepath.Dir(file.Path)
+	stdin, errStdin := cmd.StdinPipe()
+	if errStdin != nil {
+		return nil, fmt.Errorf("plugin %s stdin: %w", file.ID, errStdin)
+	}
+	stdout, errStdout := cmd.StdoutPipe()
+	if errStdout != nil {
+		return nil, fmt.Errorf("plugin %s stdout: %w", file.ID, errStdout)
+	}
+	stderr, errStderr := cmd.StderrPipe()
+	if errStderr != nil {
+		return nil, fmt.Errorf("plugin %s stderr: %w", file.ID, errStderr)
+	}
+	if errStart := cmd.Start(); errStart != nil {
+		return nil, fmt.Errorf("start plugin %s: %w", file.Path, errStart)
+	}
+	c := &subprocessClient{
+		file:       file,
+		host:       host,
+		cmd:        cmd,
+		stdin:      stdin,
+		pending:    make(map[uint64]chan subprocessMessage),
+		done:       make(chan struct{}),
+		stderrDone: make(chan struct{}),
+	}
+	go c.logStderr(stderr)
+	go c.readLoop(stdout)
+	return c, nil
+}
+
+func (c *subprocessClient) Call(ctx context.Context, method string, request []byte) ([]byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	params := json.RawMessage(request)
+	if len(request) == 0 {
+		params = nil
+	} else if !json.Valid(request) {
+		return nil, fmt.Errorf("plugin call %s: request is not valid JSON", method)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		errExit := c.exitErr
+		c.mu.Unlock()
+		return nil, fmt.Errorf("plugin %s is not running: %v", c.file.ID, errExit)
+	}
+	c.nextID++
+	id := c.nextID
+	reply := make(chan subprocessMessage, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+
+	if errWrite := c.write(subprocessMessage{ID: id, Method: method, Params: params}); errWrite != nil {
+		c.forget(id)
+		return nil, fmt.Errorf("plugin call %s: %w", method, errWrite)
+	}
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			return nil, fmt.Errorf("plugin %s exited during %s", c.file.ID, method)
+		}
+		if msg.Error != "" {
+			return nil, fmt.Errorf("plugin call %s: %s", method, msg.Error)
+		}
+		return []byte(msg.Result), nil
+	case <-ctx.Done():
+		c.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+func (c *subprocessClient) Shutdown() {
+	c.mu.Lock()
+	if c.stdin == nil {
+		c.mu.Unlock()
+		return
+	}
+	stdin := c.stdin
+	c.stdin = nil
+	c.mu.Unlock()
+
+	_ = stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(subprocessShutdownGrace):
+		_ = c.cmd.Process.Kill()
+		<-c.done
+	}
+}
+
+func (c *subprocessClient) write(msg subprocessMessage) error {
+	raw, errMarshal := json.Marshal(msg)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	c.mu.Lock()
+	stdin := c.stdin
+	c.mu.Unlock()
+	if stdin == nil {
+		return errors.New("plugin is shutting down")
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, errWrite := stdin.Write(append(raw, '\n'))
+	return errWrite
+}
+
+func (c *subprocessClient) forget(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *subprocessClient) readLoop(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			c.dispatch(line)
+		}
+		if errRead != nil {
+			break
+		}
+	}
+	// Wait closes the stderr pipe, so it must not run while logStderr is still reading.
+	<-c.stderrDone
+	errWait := c.cmd.Wait()
+	c.mu.Lock()
+	c.closed = true
+	c.exitErr = errWait
+	for id, reply := range c.pending {
+		close(reply)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	close(c.done)
+	if errWait != nil {
+		log.WithFields(pluginLogFields(c.file.ID, "", c.file.Version, c.file.Path)).Warnf("pluginhost: subprocess plugin exited: %v", errWait)
+	}
+}
+
+func (c *subprocessClient) dispatch(line []byte) {
+	var msg subprocessMessage
+	if errUnmarshal := json.Unmarshal(line, &msg); errUnmarshal != nil {
+		log.WithFields(pluginLogFields(c.file.ID, "", c.file.Version, c.file.Path)).Warnf("pluginhost: invalid subprocess plugin frame: %v", errUnmarshal)
+		return
+	}
+	if msg.Method != "" {
+		go c.serveHostCall(msg)
+		return
+	}
+	c.mu.Lock()
+	reply, ok := c.pending[msg.ID]
+	delete(c.pending, msg.ID)
+	c.mu.Unlock()
+	if ok {
+		reply <- msg
+	}
+}
+
+// serveHostCall answers a callback request from the plugin, mirroring the native host API.
+func (c *subprocessClient) serveHostCall(msg subprocessMessage) {
+	var resp []byte
+	if c.host == nil {
+		resp = marshalRPCError("host_call_failed", "plugin host unavailable")
+	} else {
+		ctx := withHostCallbackPluginID(context.Background(), c.file.ID)
+		var errCall error
+		resp, errCall = c.host.callFromPlugin(ctx, msg.Method, msg.Params)
+		if errCall != nil {
+			resp = marshalRPCError("host_call_failed", errCall.Error())
+		}
+	}
+	if len(resp) == 0 {
+		resp = nil
+	}
+	if errWrite := c.write(subprocessMessage{ID: msg.ID, Result: resp}); errWrite != nil {
+		log.WithFields(pluginLogFields(c.file.ID, "", c.file.Version, c.file.Path)).Debugf("pluginhost: failed to answer host callback %s: %v", msg.Method, errWrite)
+	}
+}
+
+func (c *subprocessClient) logStderr(stderr io.Reader) {
+	defer close(c.stderrDone)
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	entry := log.WithFields(pluginLogFields(c.file.ID, "", c.file.Version, ""))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			entry.Info(line)
+		}
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		// Keep draining so a plugin writing an oversized line does not block on a full pipe.
+		entry.Warnf("pluginhost: stopped logging subprocess plugin stderr: %v", errScan)
+		_, _ = io.Copy(io.Discard, stderr)
+	}
+}
@@ -0,0 +1,157 @@
+package pluginhost
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginabi"
+)
+
+const subprocessHelperEnv = "CLIPROXY_SUBPROCESS_PLUGIN_HELPER"
+
+// TestSubprocessPluginHelper is the fake plugin process started by the subprocess tests.
+func TestSubprocessPluginHelper(t *testing.T) {
+	if os.Getenv(subprocessHelperEnv) != "1" {
+		t.Skip("helper process")
+	}
+	reader := bufio.NewReader(os.Stdin)
+	write := func(msg subprocessMessage) {
+		raw, _ := json.Marshal(msg)
+		fmt.Println(string(raw))
+	}
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if errRead != nil {
+			os.Exit(0)
+		}
+		var msg subprocessMessage
+		_ = json.Unmarshal(line, &msg)
+		switch msg.Method {
+		case "echo":
+			write(subprocessMessage{ID: msg.ID, Result: msg.Params})
+		case "callback":
+			write(subprocessMessage{ID: 1, Method: pluginabi.MethodHostLog, Params: json.RawMessage(`{"message":"from plugin"}`)})
+			replyLine, _ := reader.ReadBytes('\n')
+			var reply subprocessMessage
+			_ = json.Unmarshal(replyLine, &reply)
+			write(subprocessMessage{ID: msg.ID, Result: reply.Result})
+		case "fail":
+			write(subprocessMessage{ID: msg.ID, Error: "boom"})
+		case "noisy":
+			fmt.Fprintln(os.Stderr, strings.Repeat("x", 2<<20))
+			fmt.Fprintln(os.Stderr, "after the long line")
+			write(subprocessMessage{ID: msg.ID, Result: msg.Params})
+		case "crash":
+			fmt.Fprintln(os.Stderr, "crashing")
+			os.Exit(3)
+		}
+	}
+}
+
+func startTestSubprocessPlugin(t *testing.T) *subprocessClient {
+	t.Helper()
+	original := subprocessCommand
+	subprocessCommand = func(string) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSubprocessPluginHelper$")
+		cmd.Env = append(os.Environ(), subprocessHelperEnv+"=1")
+		return cmd
+	}
+	t.Cleanup(func() { subprocessCommand = original })
+
+	client, errStart := startSubprocessPlugin(pluginFile{ID: "helper", Path: filepath.Join(t.TempDir(), "helper.rpc")}, New())
+	if errStart != nil {
+		t.Fatalf("startSubprocessPlugin() error = %v", errStart)
+	}
+	t.Cleanup(client.Shutdown)
+	return client
+}
+
+func TestSubprocessPluginCallsAndHostCallbacks(t *testing.T) {
+	client := startTestSubprocessPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	out, errCall := client.Call(ctx, "echo", []byte(`{"value":1}`))
+	if errCall != nil || string(out) != `{"value":1}` {
+		t.Fatalf("echo = %s, %v", out, errCall)
+	}
+	if _, errCall = client.Call(ctx, "echo", []byte("not json")); errCall == nil {
+		t.Fatal("expected invalid JSON request to fail")
+	}
+	if _, errCall = client.Call(ctx, "fail", nil); errCall == nil || !strings.Contains(errCall.Error(), "boom") {
+		t.Fatalf("fail error = %v", errCall)
+	}
+
+	out, errCall = client.Call(ctx, "callback", nil)
+	if errCall != nil {
+		t.Fatalf("callback error = %v", errCall)
+	}
+	var envelope pluginabi.Envelope
+	if errUnmarshal := json.Unmarshal(out, &envelope); errUnmarshal != nil || !envelope.OK {
+		t.Fatalf("host callback reply = %s", out)
+	}
+}
+
+func TestSubprocessPluginExitFailsCalls(t *testing.T) {
+	client := startTestSubprocessPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, errCall := client.Call(ctx, "crash", nil); errCall == nil {
+		t.Fatal("expected call to fail when the plugin exits")
+	}
+	<-client.done
+	if _, errCall := client.Call(ctx, "echo", []byte(`{}`)); errCall == nil || !strings.Contains(errCall.Error(), "not running") {
+		t.Fatalf("call after exit error = %v", errCall)
+	}
+}
+
+func TestSubprocessPluginOversizedStderrLineDoesNotBlock(t *testing.T) {
+	client := startTestSubprocessPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if out, errCall := client.Call(ctx, "noisy", []byte(`{}`)); errCall != nil || string(out) != `{}` {
+		t.Fatalf("noisy = %s, %v", out, errCall)
+	}
+	if out, errCall := client.Call(ctx, "echo", []byte(`{"after":true}`)); errCall != nil || string(out) != `{"after":true}` {
+		t.Fatalf("echo after noisy = %s, %v", out, errCall)
+	}
+}
+
+func TestSelectPluginFilesIncludesSubprocessPlugins(t *testing.T) {
+	root := t.TempDir()
+	archDir := filepath.Join(root, runtime.GOOS, runtime.GOARCH)
+	if errMkdirAll := os.MkdirAll(archDir, 0o755); errMkdirAll != nil {
+		t.Fatalf("MkdirAll() error = %v", errMkdirAll)
+	}
+	for _, name := range []string{"native" + pluginExtension(runtime.GOOS), "external-v1.2.0.rpc"} {
+		if errWriteFile := os.WriteFile(filepath.Join(archDir, name), []byte("x"), 0o755); errWriteFile != nil {
+			t.Fatalf("WriteFile(%s) error = %v", name, errWriteFile)
+		}
+	}
+
+	files, errSelect := selectPluginFiles(root)
+	if errSelect != nil {
+		t.Fatalf("selectPluginFiles() error = %v", errSelect)
+	}
+	want := []pluginFile{
+		{ID: "external", Path: filepath.Join(archDir, "external-v1.2.0.rpc"), Version: "1.2.0"},
+		{ID: "native", Path: filepath.Join(archDir, "native"+pluginExtension(runtime.GOOS))},
+	}
+	if len(files) != len(want) || files[0] != want[0] || files[1] != want[1] {
+		t.Fatalf("selectPluginFiles() = %v, want %v", files, want)
+	}
+	if !isSubprocessPluginPath(files[0].Path) || isSubprocessPluginPath(files[1].Path) {
+		t.Fatalf("isSubprocessPluginPath mismatch for %v", files)
+	}
+}
@@ -0,0 +1,169 @@
+// Package racestats aggregates the outcome of raced requests per model and provider, so the
+// latency of backends serving the same model can be compared.
+package racestats
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+)
+
+// Outcome is the result of one attempt of a raced request.
+type Outcome struct {
+	// Provider is the provider of the credential the attempt ran on.
+	Provider string
+	// Latency is the time to the first stream chunk, or to the full response for non-streaming
+	// requests. 0 means the attempt was cancelled before it could be measured.
+	Latency time.Duration
+	// Won is set for the attempt whose response was returned to the client.
+	Won bool
+	// Failed is set for attempts that ended with an error.
+	Failed bool
+}
+
+// Stat summarizes the raced attempts of one provider for one model.
+type Stat struct {
+	Model        string  `json:"model"`
+	Provider     string  `json:"provider"`
+	Attempts     int64   `json:"attempts"`
+	Wins         int64   `json:"wins"`
+	Failures     int64   `json:"failures"`
+	WinRate      float64 `json:"win_rate"`
+	Samples      int64   `json:"samples"`
+	AvgLatencyMs int64   `json:"avg_latency_ms,omitempty"`
+	MinLatencyMs int64   `json:"min_latency_ms,omitempty"`
+	MaxLatencyMs int64   `json:"max_latency_ms,omitempty"`
+}
+
+// Report is a snapshot of the recorded races, sorted by model and then by average latency.
+type Report struct {
+	Since time.Time `json:"since"`
+	Stats []Stat    `json:"stats"`
+}
+
+type statKey struct {
+	model    string
+	provider string
+}
+
+type counters struct {
+	attempts, wins, failures, samples int64
+	total, minimum, maximum           time.Duration
+}
+
+// Store accumulates race outcomes since it was created or last reset. A nil Store records nothing.
+type Store struct {
+	mu    sync.Mutex
+	stats map[statKey]*counters
+	since time.Time
+
+	clock clock.Clock
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	s := &Store{
+		stats: make(map[statKey]*counters),
+		clock: clock.Default(),
+	}
+	s.since = s.clock.Now()
+	return s
+}
+
+var defaultStore = NewStore()
+
+// Default returns the process-wide Store fed by the API handlers.
+func Default() *Store { return defaultStore }
+
+// Record adds the outcome of one attempt of a raced request for model.
+func (s *Store) Record(model string, outcome Outcome) {
+	if s == nil {
+		return
+	}
+	key := statKey{
+		model:    strings.ToLower(strings.TrimSpace(model)),
+		provider: strings.ToLower(strings.TrimSpace(outcome.Provider)),
+	}
+	if key.provider == "" {
+		key.provider = "unknown"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.stats[key]
+	if !ok {
+		c = &counters{}
+		s.stats[key] = c
+	}
+	c.attempts++
+	if outcome.Won {
+		c.wins++
+	}
+	if outcome.Failed {
+		c.failures++
+	}
+	if outcome.Latency > 0 && !outcome.Failed {
+		if c.samples == 0 || outcome.Latency < c.minimum {
+			c.minimum = outcome.Latency
+		}
+		c.maximum = max(c.maximum, outcome.Latency)
+		c.total += outcome.Latency
+		c.samples++
+	}
+}
+
+// Reset clears all stats and restarts the reporting window.
+func (s *Store) Reset() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.stats = make(map[statKey]*counters)
+	s.since = s.clock.Now()
+	s.mu.Unlock()
+}
+
+// Snapshot returns the stats recorded so far.
+func (s *Store) Snapshot() Report {
+	if s == nil {
+		return Report{Stats: []Stat{}}
+	}
+	s.mu.Lock()
+	report := Report{Since: s.since, Stats: make([]Stat, 0, len(s.stats))}
+	for key, c := range s.stats {
+		stat := Stat{
+			Model:    key.model,
+			Provider: key.provider,
+			Attempts: c.attempts,
+			Wins:     c.wins,
+			Failures: c.failures,
+			Samples:  c.samples,
+		}
+		if c.attempts > 0 {
+			stat.WinRate = float64(c.wins) / float64(c.attempts)
+		}
+		if c.samples > 0 {
+			stat.AvgLatencyMs = (c.total / time.Duration(c.samples)).Milliseconds()
+			stat.MinLatencyMs = c.minimum.Milliseconds()
+			stat.MaxLatencyMs = c.maximum.Milliseconds()
+		}
+		report.Stats = append(report.Stats, stat)
+	}
+	s.mu.Unlock()
+	sort.Slice(report.Stats, func(i, j int) bool {
+		a, b := report.Stats[i], report.Stats[j]
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		if (a.Samples == 0) != (b.Samples == 0) {
+			return a.Samples > 0
+		}
+		if a.AvgLatencyMs != b.AvgLatencyMs {
+			return a.AvgLatencyMs < b.AvgLatencyMs
+		}
+		return a.Provider < b.Provider
+	})
+	return report
+}
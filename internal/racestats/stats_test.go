@@ -0,0 +1,35 @@
+package racestats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreSnapshotComparesProvidersPerModel(t *testing.T) {
+	store := NewStore()
+	store.Record("GPT-5", Outcome{Provider: "codex", Latency: 300 * time.Millisecond})
+	store.Record("gpt-5", Outcome{Provider: "openai-compatibility", Latency: 100 * time.Millisecond, Won: true})
+	store.Record("gpt-5", Outcome{Provider: "codex", Latency: 200 * time.Millisecond, Won: true})
+	store.Record("gpt-5", Outcome{Provider: "openai-compatibility", Failed: true, Latency: 50 * time.Millisecond})
+	store.Record("gpt-5", Outcome{Provider: "gemini"})
+
+	report := store.Snapshot()
+	if len(report.Stats) != 3 {
+		t.Fatalf("stats = %+v, want 3 entries", report.Stats)
+	}
+	first, second, third := report.Stats[0], report.Stats[1], report.Stats[2]
+	if first.Provider != "openai-compatibility" || first.Attempts != 2 || first.Wins != 1 || first.Failures != 1 || first.Samples != 1 || first.AvgLatencyMs != 100 {
+		t.Fatalf("first = %+v, want openai-compatibility averaging 100ms over one sample", first)
+	}
+	if second.Provider != "codex" || second.AvgLatencyMs != 250 || second.MinLatencyMs != 200 || second.MaxLatencyMs != 300 || second.WinRate != 0.5 {
+		t.Fatalf("second = %+v, want codex averaging 250ms", second)
+	}
+	if third.Provider != "gemini" || third.Samples != 0 || third.Model != "gpt-5" {
+		t.Fatalf("third = %+v, want unmeasured gemini last", third)
+	}
+
+	store.Reset()
+	if got := store.Snapshot().Stats; len(got) != 0 {
+		t.Fatalf("stats after reset = %+v, want none", got)
+	}
+}
@@ -0,0 +1,293 @@
+// Package ratelimit provides token-bucket request limits keyed by the inbound client API key.
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sharedstate"
+	log "github.com/sirupsen/logrus"
+)
+
+type rule struct {
+	rps   float64
+	burst float64
+	// warnUntil ends the warn-only period during which an empty bucket does not reject requests.
+	warnUntil time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter enforces per-key and per-key-per-provider token buckets. Buckets live in the shared
+// state when it is configured, so every instance draws from the same bucket, and fall back to
+// local buckets while it is unreachable. A nil Limiter allows every request.
+type Limiter struct {
+	mu sync.Mutex
+
+	cfg       config.RateLimitConfig
+	enabled   bool
+	fallback  rule
+	keys      map[string]rule
+	providers map[string]rule
+	buckets   map[string]*bucket
+	inFlight  map[string]int
+
+	shared *sharedstate.State
+	clock  clock.Clock
+}
+
+// Status describes the request bucket of a client API key.
+type Status struct {
+	// Limit is the bucket capacity.
+	Limit int
+	// Remaining is the number of whole requests that may be sent now.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+// NewLimiter creates a limiter using the provided configuration.
+func NewLimiter(cfg config.RateLimitConfig) *Limiter {
+	l := &Limiter{clock: clock.Default(), shared: sharedstate.Default(), inFlight: make(map[string]int)}
+	l.Update(cfg)
+	return l
+}
+
+// Update replaces the limiter configuration. Buckets are reset only when the configuration changes.
+func (l *Limiter) Update(cfg config.RateLimitConfig) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets != nil && reflect.DeepEqual(l.cfg, cfg) {
+		return
+	}
+
+	l.cfg = cfg
+	l.enabled = cfg.Enable
+	l.fallback = newRule(cfg.RPS, cfg.Burst, config.WarnOnlyDeadline("", cfg.WarnOnlyUntil))
+	l.keys = make(map[string]rule, len(cfg.Keys))
+	for _, entry := range cfg.Keys {
+		if key := strings.TrimSpace(entry.APIKey); key != "" {
+			l.keys[key] = newRule(entry.RPS, entry.Burst, config.WarnOnlyDeadline(entry.WarnOnlyUntil, cfg.WarnOnlyUntil))
+		}
+	}
+	l.providers = make(map[string]rule, len(cfg.Providers))
+	for _, entry := range cfg.Providers {
+		if provider := strings.ToLower(strings.TrimSpace(entry.Provider)); provider != "" {
+			l.providers[provider] = newRule(entry.RPS, entry.Burst, config.WarnOnlyDeadline(entry.WarnOnlyUntil, cfg.WarnOnlyUntil))
+		}
+	}
+	l.buckets = make(map[string]*bucket)
+}
+
+// Allow consumes one token from the bucket of the given client API key.
+// When the bucket is empty it returns false and the wait until the next token is available.
+func (l *Limiter) Allow(apiKey string) (bool, time.Duration) {
+	allowed, _, wait := l.Check(apiKey)
+	return allowed, wait
+}
+
+// Check is Allow that also reports warned when the request exceeded a limit that is still in
+// its warn-only period and was admitted only because of it.
+func (l *Limiter) Check(apiKey string) (allowed, warned bool, wait time.Duration) {
+	if l == nil || apiKey == "" {
+		return true, false, 0
+	}
+	l.mu.Lock()
+	if !l.enabled {
+		l.mu.Unlock()
+		return true, false, 0
+	}
+	r, ok := l.keys[apiKey]
+	if !ok {
+		r = l.fallback
+	}
+	l.mu.Unlock()
+	return l.take("key\x00"+apiKey, r)
+}
+
+// AllowProvider consumes one token from the bucket shared by the client API key and upstream provider.
+func (l *Limiter) AllowProvider(apiKey, provider string) (bool, time.Duration) {
+	allowed, _, wait := l.CheckProvider(apiKey, provider)
+	return allowed, wait
+}
+
+// CheckProvider is AllowProvider that also reports limits exceeded during their warn-only period.
+func (l *Limiter) CheckProvider(apiKey, provider string) (allowed, warned bool, wait time.Duration) {
+	if l == nil || apiKey == "" {
+		return true, false, 0
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	l.mu.Lock()
+	if !l.enabled {
+		l.mu.Unlock()
+		return true, false, 0
+	}
+	r, ok := l.providers[provider]
+	l.mu.Unlock()
+	if !ok {
+		return true, false, 0
+	}
+	return l.take("provider\x00"+provider+"\x00"+apiKey, r)
+}
+
+// PeekProvider is CheckProvider without consuming a token: it reports whether the next request
+// of apiKey to provider would be admitted.
+func (l *Limiter) PeekProvider(apiKey, provider string) (allowed, warned bool, wait time.Duration) {
+	if l == nil || apiKey == "" {
+		return true, false, 0
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	l.mu.Lock()
+	if !l.enabled {
+		l.mu.Unlock()
+		return true, false, 0
+	}
+	r, ok := l.providers[provider]
+	l.mu.Unlock()
+	if !ok || r.rps <= 0 {
+		return true, false, 0
+	}
+	now := l.clock.Now()
+	tokens := l.peek("provider\x00"+provider+"\x00"+apiKey, r, now)
+	return r.decide(tokens >= 1, tokens, now)
+}
+
+// Status reports the bucket of the given client API key without consuming a token.
+// ok is false when no limit applies to the key.
+func (l *Limiter) Status(apiKey string) (Status, bool) {
+	if l == nil || apiKey == "" {
+		return Status{}, false
+	}
+	l.mu.Lock()
+	if !l.enabled {
+		l.mu.Unlock()
+		return Status{}, false
+	}
+	r, ok := l.keys[apiKey]
+	if !ok {
+		r = l.fallback
+	}
+	l.mu.Unlock()
+	if r.rps <= 0 {
+		return Status{}, false
+	}
+	tokens := l.peek("key\x00"+apiKey, r, l.clock.Now())
+	return Status{
+		Limit:     int(r.burst),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((r.burst - tokens) / r.rps * float64(time.Second)),
+	}, true
+}
+
+// InFlight returns the number of requests of apiKey currently between Begin and End.
+func (l *Limiter) InFlight(apiKey string) int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight[apiKey]
+}
+
+// Begin marks a request of apiKey as in flight. Every Begin must be paired with End.
+func (l *Limiter) Begin(apiKey string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.inFlight[apiKey]++
+	l.mu.Unlock()
+}
+
+// End marks a request of apiKey started with Begin as finished.
+func (l *Limiter) End(apiKey string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[apiKey] <= 1 {
+		delete(l.inFlight, apiKey)
+		return
+	}
+	l.inFlight[apiKey]--
+}
+
+// peek returns the tokens in bucket id at now without consuming one.
+func (l *Limiter) peek(id string, r rule, now time.Time) float64 {
+	if tokens, errPeek := l.shared.Peek(sharedstate.HashKey(id), r.rps, r.burst, now); errPeek == nil {
+		return tokens
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, exists := l.buckets[id]
+	if !exists {
+		return r.burst
+	}
+	tokens := b.tokens
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		tokens = math.Min(r.burst, tokens+elapsed.Seconds()*r.rps)
+	}
+	return tokens
+}
+
+func (l *Limiter) take(id string, r rule) (allowed, warned bool, wait time.Duration) {
+	if r.rps <= 0 {
+		return true, false, 0
+	}
+	now := l.clock.Now()
+	if taken, tokens, errTake := l.shared.Take(sharedstate.HashKey(id), r.rps, r.burst, now); errTake == nil {
+		return r.decide(taken, tokens, now)
+	} else if !errors.Is(errTake, sharedstate.ErrDisabled) {
+		log.Debugf("rate limit: shared bucket unavailable, using local bucket: %v", errTake)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		l.buckets[id] = b
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(r.burst, b.tokens+elapsed.Seconds()*r.rps)
+		b.last = now
+	}
+	allowed = b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return r.decide(allowed, b.tokens, now)
+}
+
+// decide applies the warn-only period to the outcome of a bucket holding tokens after the take.
+func (r rule) decide(allowed bool, tokens float64, now time.Time) (bool, bool, time.Duration) {
+	if allowed {
+		return true, false, 0
+	}
+	wait := time.Duration((1 - tokens) / r.rps * float64(time.Second))
+	if now.Before(r.warnUntil) {
+		return true, true, wait
+	}
+	return false, false, wait
+}
+
+func newRule(rps float64, burst int, warnUntil time.Time) rule {
+	if rps <= 0 {
+		return rule{}
+	}
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(rps)))
+	}
+	return rule{rps: rps, burst: float64(burst), warnUntil: warnUntil}
+}
@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newTestLimiter(cfg config.RateLimitConfig) (*Limiter, *clock.Sim) {
+	sim := clock.NewSim(time.Unix(1_700_000_000, 0))
+	l := NewLimiter(cfg)
+	l.clock = sim
+	return l, sim
+}
+
+func TestLimiterAllow_PerKeyBucketRefills(t *testing.T) {
+	l, now := newTestLimiter(config.RateLimitConfig{Enable: true, RPS: 1, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("alice"); !ok {
+			t.Fatalf("request %d rejected within burst", i)
+		}
+	}
+	ok, wait := l.Allow("alice")
+	if ok {
+		t.Fatal("request beyond burst allowed")
+	}
+	if wait != time.Second {
+		t.Fatalf("retry after = %v, want 1s", wait)
+	}
+	if ok, _ := l.Allow("bob"); !ok {
+		t.Fatal("other key should not share alice's bucket")
+	}
+
+	now.Advance(500 * time.Millisecond)
+	if ok, wait := l.Allow("alice"); ok || wait != 500*time.Millisecond {
+		t.Fatalf("half refill = (%v, %v), want (false, 500ms)", ok, wait)
+	}
+	now.Advance(500 * time.Millisecond)
+	if ok, _ := l.Allow("alice"); !ok {
+		t.Fatal("request after refill rejected")
+	}
+}
+
+func TestLimiterAllow_KeyOverrideAndDisabled(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{
+		Enable: true,
+		Keys:   []config.RateLimitKey{{APIKey: "alice", RPS: 1, Burst: 1}},
+	})
+
+	if ok, _ := l.Allow("alice"); !ok {
+		t.Fatal("first request rejected")
+	}
+	if ok, _ := l.Allow("alice"); ok {
+		t.Fatal("override burst not enforced")
+	}
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("bob"); !ok {
+			t.Fatal("key without default or override should be unlimited")
+		}
+	}
+
+	l.Update(config.RateLimitConfig{Enable: false, Keys: []config.RateLimitKey{{APIKey: "alice", RPS: 1, Burst: 1}}})
+	if ok, _ := l.Allow("alice"); !ok {
+		t.Fatal("disabled limiter rejected request")
+	}
+}
+
+func TestLimiterAllowProvider_PerKeyPerProvider(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{
+		Enable:    true,
+		Providers: []config.RateLimitProvider{{Provider: "Claude", RPS: 1, Burst: 1}},
+	})
+
+	if ok, _ := l.AllowProvider("alice", "claude"); !ok {
+		t.Fatal("first claude request rejected")
+	}
+	if ok, wait := l.AllowProvider("alice", "claude"); ok || wait != time.Second {
+		t.Fatalf("second claude request = (%v, %v), want (false, 1s)", ok, wait)
+	}
+	if ok, _ := l.AllowProvider("bob", "claude"); !ok {
+		t.Fatal("provider bucket should be per client key")
+	}
+	if ok, _ := l.AllowProvider("alice", "gemini"); !ok {
+		t.Fatal("provider without a limit should be unlimited")
+	}
+}
+
+func TestLimiterUpdate_KeepsBucketsForUnchangedConfig(t *testing.T) {
+	cfg := config.RateLimitConfig{Enable: true, RPS: 1, Burst: 1}
+	l, _ := newTestLimiter(cfg)
+
+	if ok, _ := l.Allow("alice"); !ok {
+		t.Fatal("first request rejected")
+	}
+	l.Update(cfg)
+	if ok, _ := l.Allow("alice"); ok {
+		t.Fatal("reapplying the same config should not refill buckets")
+	}
+	l.Update(config.RateLimitConfig{Enable: true, RPS: 1, Burst: 3})
+	if ok, _ := l.Allow("alice"); !ok {
+		t.Fatal("changed config should reset buckets")
+	}
+}
+
+func TestLimiterStatus_ReportsBucketWithoutConsuming(t *testing.T) {
+	l, now := newTestLimiter(config.RateLimitConfig{Enable: true, RPS: 1, Burst: 4})
+
+	status, ok := l.Status("alice")
+	if !ok || status.Limit != 4 || status.Remaining != 4 || status.Reset != 0 {
+		t.Fatalf("fresh status = (%+v, %v), want full bucket", status, ok)
+	}
+	for i := 0; i < 3; i++ {
+		l.Allow("alice")
+	}
+	now.Advance(500 * time.Millisecond)
+	status, _ = l.Status("alice")
+	if status.Remaining != 1 || status.Reset != 2500*time.Millisecond {
+		t.Fatalf("status = %+v, want 1 remaining and 2.5s reset", status)
+	}
+	if again, _ := l.Status("alice"); again != status {
+		t.Fatalf("status consumed tokens: %+v then %+v", status, again)
+	}
+
+	l.Update(config.RateLimitConfig{})
+	if _, ok := l.Status("alice"); ok {
+		t.Fatal("expected no status when rate limiting is disabled")
+	}
+}
+
+func TestLimiterInFlight_BeginEnd(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{Enable: true, RPS: 1})
+
+	l.Begin("alice")
+	l.Begin("alice")
+	l.Begin("bob")
+	if got := l.InFlight("alice"); got != 2 {
+		t.Fatalf("alice in flight = %d, want 2", got)
+	}
+	l.End("alice")
+	l.End("alice")
+	l.End("alice")
+	if got := l.InFlight("alice"); got != 0 {
+		t.Fatalf("alice in flight after end = %d, want 0", got)
+	}
+	if got := l.InFlight("bob"); got != 1 {
+		t.Fatalf("bob in flight = %d, want 1", got)
+	}
+}
+
+func TestLimiterCheck_WarnOnlyPeriod(t *testing.T) {
+	l, now := newTestLimiter(config.RateLimitConfig{
+		Enable:        true,
+		RPS:           1,
+		Burst:         1,
+		WarnOnlyUntil: time.Unix(1_700_000_060, 0).UTC().Format(time.RFC3339),
+		Keys:          []config.RateLimitKey{{APIKey: "enforced", RPS: 1, Burst: 1}},
+		Providers:     []config.RateLimitProvider{{Provider: "claude", RPS: 1, Burst: 1, WarnOnlyUntil: "2000-01-01T00:00:00Z"}},
+	})
+
+	if allowed, warned, _ := l.Check("alice"); !allowed || warned {
+		t.Fatalf("first request = (%v, %v), want admitted without warning", allowed, warned)
+	}
+	if allowed, warned, wait := l.Check("alice"); !allowed || !warned || wait != time.Second {
+		t.Fatalf("over-limit request = (%v, %v, %v), want admitted with warning", allowed, warned, wait)
+	}
+	// Key overrides inherit the section-wide warn-only period.
+	l.Check("enforced")
+	if allowed, warned, _ := l.Check("enforced"); !allowed || !warned {
+		t.Fatalf("override = (%v, %v), want inherited warn-only period", allowed, warned)
+	}
+	l.CheckProvider("alice", "claude")
+	if allowed, warned, _ := l.CheckProvider("alice", "claude"); allowed || warned {
+		t.Fatalf("expired provider warn-only = (%v, %v), want enforced", allowed, warned)
+	}
+
+	now.Advance(time.Minute)
+	l.Check("alice")
+	if allowed, warned, _ := l.Check("alice"); allowed || warned {
+		t.Fatalf("after warn-only period = (%v, %v), want enforced", allowed, warned)
+	}
+}
+
+func TestLimiterPeekProvider_DoesNotConsume(t *testing.T) {
+	l, _ := newTestLimiter(config.RateLimitConfig{
+		Enable:    true,
+		Providers: []config.RateLimitProvider{{Provider: "claude", RPS: 1, Burst: 1}},
+	})
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := l.PeekProvider("alice", "claude"); !ok {
+			t.Fatalf("peek %d reported an exhausted bucket", i)
+		}
+	}
+	if ok, _ := l.AllowProvider("alice", "claude"); !ok {
+		t.Fatal("first claude request rejected after peeking")
+	}
+	if ok, _, wait := l.PeekProvider("alice", "claude"); ok || wait != time.Second {
+		t.Fatalf("peek after the bucket emptied = (%v, %v), want (false, 1s)", ok, wait)
+	}
+}
@@ -0,0 +1,132 @@
+// Package readiness explains whether the proxy can serve model requests and, when it cannot,
+// what is missing. It backs the empty /v1/models notice, the 503 returned by generate
+// endpoints and the management readiness report.
+package readiness
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// Reasons reported when the proxy is not ready.
+const (
+	ReasonNoCredentials          = "no_credentials"
+	ReasonCredentialsUnavailable = "credentials_unavailable"
+	ReasonNoModels               = "no_models"
+)
+
+// Report describes the credentials and models available to serve requests.
+type Report struct {
+	Ready       bool                  `json:"ready"`
+	Reason      string                `json:"reason,omitempty"`
+	Message     string                `json:"message,omitempty"`
+	Credentials CredentialSummary     `json:"credentials"`
+	Models      int                   `json:"models"`
+	Unhealthy   []UnhealthyCredential `json:"unhealthy,omitempty"`
+	Remediation []string              `json:"remediation,omitempty"`
+}
+
+// CredentialSummary counts the credentials known to the auth manager.
+type CredentialSummary struct {
+	Total       int            `json:"total"`
+	Usable      int            `json:"usable"`
+	Disabled    int            `json:"disabled"`
+	Unavailable int            `json:"unavailable"`
+	Errored     int            `json:"errored"`
+	ByProvider  map[string]int `json:"by_provider,omitempty"`
+}
+
+// UnhealthyCredential names an enabled credential that cannot currently serve requests.
+type UnhealthyCredential struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Check inspects the credentials held by manager and the models in the global registry.
+// The proxy is always ready when requests are delegated to the home control plane.
+func Check(manager *coreauth.Manager) Report {
+	report := Report{Models: len(registry.GetGlobalRegistry().GetAvailableModels("openai"))}
+	if manager != nil && manager.HomeEnabled() {
+		report.Ready = true
+		return report
+	}
+	if manager != nil {
+		for _, auth := range manager.List() {
+			report.addCredential(auth)
+		}
+	}
+	sort.Slice(report.Unhealthy, func(i, j int) bool { return report.Unhealthy[i].ID < report.Unhealthy[j].ID })
+
+	switch {
+	case report.Credentials.Total == report.Credentials.Disabled:
+		report.Reason = ReasonNoCredentials
+		report.Message = "No provider credentials are configured, so there are no models to serve."
+		report.Remediation = []string{
+			"Add an API key under gemini-api-key, claude-api-key, codex-api-key or openai-compatibility in config.yaml.",
+			"Or sign in with an OAuth account, for example: cli-proxy-api --claude-login, --codex-login or --antigravity-login.",
+			"Or copy existing credential files into the auth-dir configured in config.yaml.",
+		}
+		if report.Credentials.Disabled > 0 {
+			report.Remediation = append(report.Remediation, "Re-enable one of the disabled credentials via the management API (PATCH /v0/management/auth-files/status).")
+		}
+	case report.Credentials.Usable == 0:
+		report.Reason = ReasonCredentialsUnavailable
+		report.Message = "All configured credentials are currently unavailable."
+		report.Remediation = []string{
+			"Inspect the failing credentials via GET /v0/management/auth-files and check their status messages.",
+			"Re-run the provider login for credentials whose tokens expired or were revoked.",
+			"Wait for quota cooldowns to end, or add another credential for the same provider.",
+		}
+	case report.Models == 0:
+		report.Reason = ReasonNoModels
+		report.Message = "Credentials are configured but no models are registered for them."
+		report.Remediation = []string{
+			"Check that excluded-models and oauth-excluded-models do not exclude every model.",
+			"For openai-compatibility providers, list the models to expose under models.",
+			"Check the server log for model catalog fetch errors.",
+		}
+	default:
+		report.Ready = true
+	}
+	return report
+}
+
+func (r *Report) addCredential(auth *coreauth.Auth) {
+	if auth == nil {
+		return
+	}
+	r.Credentials.Total++
+	if auth.Disabled || auth.Status == coreauth.StatusDisabled {
+		r.Credentials.Disabled++
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	if r.Credentials.ByProvider == nil {
+		r.Credentials.ByProvider = make(map[string]int)
+	}
+	r.Credentials.ByProvider[provider]++
+
+	switch {
+	case auth.Status == coreauth.StatusError:
+		r.Credentials.Errored++
+	case auth.Unavailable:
+		r.Credentials.Unavailable++
+	default:
+		r.Credentials.Usable++
+		return
+	}
+	message := strings.TrimSpace(auth.StatusMessage)
+	if message == "" && auth.LastError != nil {
+		message = strings.TrimSpace(auth.LastError.Message)
+	}
+	status := string(auth.Status)
+	if auth.Unavailable && auth.Status != coreauth.StatusError {
+		status = "unavailable"
+	}
+	r.Unhealthy = append(r.Unhealthy, UnhealthyCredential{ID: auth.ID, Provider: provider, Status: status, Message: message})
+}
@@ -0,0 +1,78 @@
+package readiness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func registerAuths(t *testing.T, auths ...*coreauth.Auth) *coreauth.Manager {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range auths {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	return manager
+}
+
+func TestCheckWithoutCredentials(t *testing.T) {
+	manager := registerAuths(t, &coreauth.Auth{ID: "off", Provider: "claude", Disabled: true, Status: coreauth.StatusDisabled})
+
+	report := Check(manager)
+	if report.Ready || report.Reason != ReasonNoCredentials {
+		t.Fatalf("report = %+v, want not ready with %q", report, ReasonNoCredentials)
+	}
+	if report.Credentials.Total != 1 || report.Credentials.Disabled != 1 {
+		t.Fatalf("credentials = %+v, want one disabled credential", report.Credentials)
+	}
+	if len(report.Remediation) != 4 {
+		t.Fatalf("remediation = %v, want login hints plus the re-enable hint", report.Remediation)
+	}
+}
+
+func TestCheckWithUnhealthyCredentials(t *testing.T) {
+	manager := registerAuths(t,
+		&coreauth.Auth{ID: "b", Provider: "Codex", Status: coreauth.StatusError, StatusMessage: "token revoked"},
+		&coreauth.Auth{ID: "a", Provider: "claude", Status: coreauth.StatusActive, Unavailable: true, LastError: &coreauth.Error{Message: "quota exceeded"}},
+	)
+
+	report := Check(manager)
+	if report.Ready || report.Reason != ReasonCredentialsUnavailable {
+		t.Fatalf("report = %+v, want not ready with %q", report, ReasonCredentialsUnavailable)
+	}
+	if report.Credentials.Errored != 1 || report.Credentials.Unavailable != 1 || report.Credentials.ByProvider["codex"] != 1 {
+		t.Fatalf("credentials = %+v", report.Credentials)
+	}
+	want := []UnhealthyCredential{
+		{ID: "a", Provider: "claude", Status: "unavailable", Message: "quota exceeded"},
+		{ID: "b", Provider: "codex", Status: string(coreauth.StatusError), Message: "token revoked"},
+	}
+	if len(report.Unhealthy) != len(want) || report.Unhealthy[0] != want[0] || report.Unhealthy[1] != want[1] {
+		t.Fatalf("unhealthy = %+v, want %+v", report.Unhealthy, want)
+	}
+}
+
+func TestCheckReadyWithModels(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("readiness-test-client", "claude", []*registry.ModelInfo{{ID: "readiness-test-model"}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("readiness-test-client") })
+	manager := registerAuths(t, &coreauth.Auth{ID: "ok", Provider: "claude", Status: coreauth.StatusActive})
+
+	if report := Check(manager); !report.Ready || report.Reason != "" || report.Credentials.Usable != 1 {
+		t.Fatalf("report = %+v, want ready", report)
+	}
+}
+
+func TestCheckReadyWhenHomeEnabled(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.SetConfig(&config.Config{Home: config.HomeConfig{Enabled: true}})
+
+	if report := Check(manager); !report.Ready {
+		t.Fatalf("report = %+v, want ready when Home is enabled", report)
+	}
+}
@@ -0,0 +1,93 @@
+package redaction
+
+import (
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+)
+
+// AuditEntry records the redactions applied to one request.
+type AuditEntry struct {
+	RequestID string         `json:"request_id,omitempty"`
+	Time      time.Time      `json:"time"`
+	APIKey    string         `json:"api_key,omitempty"`
+	Model     string         `json:"model,omitempty"`
+	Counts    map[string]int `json:"counts"`
+}
+
+// AuditReport summarizes redactions since the server started or the audit was last reset.
+type AuditReport struct {
+	Since            time.Time        `json:"since"`
+	RedactedRequests int64            `json:"redacted_requests"`
+	Totals           map[string]int64 `json:"totals"`
+	// Entries lists the most recent redacted requests, newest first.
+	Entries []AuditEntry `json:"entries"`
+}
+
+// Audit keeps redaction totals and the most recent redacted requests.
+type Audit struct {
+	mu       sync.Mutex
+	since    time.Time
+	requests int64
+	totals   map[string]int64
+	entries  []AuditEntry
+}
+
+// NewAudit returns an empty Audit.
+func NewAudit() *Audit {
+	return &Audit{since: clock.Default().Now().UTC(), totals: make(map[string]int64)}
+}
+
+var defaultAudit = NewAudit()
+
+// DefaultAudit returns the process-wide Audit fed by the API handlers.
+func DefaultAudit() *Audit { return defaultAudit }
+
+// Record adds entry, keeping at most maxEntries entries.
+func (a *Audit) Record(maxEntries int, entry AuditEntry) {
+	if a == nil || len(entry.Counts) == 0 {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = clock.Default().Now().UTC()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests++
+	for name, count := range entry.Counts {
+		a.totals[name] += int64(count)
+	}
+	a.entries = append(a.entries, entry)
+	if maxEntries > 0 && len(a.entries) > maxEntries {
+		a.entries = append([]AuditEntry(nil), a.entries[len(a.entries)-maxEntries:]...)
+	}
+}
+
+// Snapshot returns the totals and the latest limit entries; a limit of zero or less returns
+// all of them.
+func (a *Audit) Snapshot(limit int) AuditReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	report := AuditReport{Since: a.since, RedactedRequests: a.requests, Totals: make(map[string]int64, len(a.totals)), Entries: make([]AuditEntry, 0)}
+	for name, count := range a.totals {
+		report.Totals[name] = count
+	}
+	for i := len(a.entries) - 1; i >= 0; i-- {
+		if limit > 0 && len(report.Entries) >= limit {
+			break
+		}
+		report.Entries = append(report.Entries, a.entries[i])
+	}
+	return report
+}
+
+// Reset clears the totals and entries.
+func (a *Audit) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.since = clock.Default().Now().UTC()
+	a.requests = 0
+	a.totals = make(map[string]int64)
+	a.entries = nil
+}
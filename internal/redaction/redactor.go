@@ -0,0 +1,205 @@
+// Package redaction scrubs personal and confidential text from request bodies before they are
+// sent to upstream providers and keeps an audit log of how much was redacted.
+package redaction
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+var (
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	creditCardPattern = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+// skippedKeys hold identifiers, enums and binary data rather than prompt text.
+var skippedKeys = map[string]struct{}{
+	"model": {}, "id": {}, "type": {}, "role": {}, "data": {}, "signature": {},
+	"thoughtSignature": {}, "thought_signature": {}, "encrypted_content": {},
+	"tool_use_id": {}, "tool_call_id": {}, "call_id": {},
+	"mimeType": {}, "mime_type": {}, "media_type": {},
+}
+
+type rule struct {
+	name     string
+	pattern  *regexp.Regexp
+	validate func(match string) bool
+}
+
+// Redactor replaces configured patterns in text with "[REDACTED:<name>]".
+type Redactor struct {
+	rules []rule
+}
+
+// New compiles the detectors, patterns and terms of cfg. Patterns that do not compile are
+// skipped; SanitizePIIRedaction already drops them from loaded configs.
+func New(cfg config.PIIRedactionConfig) *Redactor {
+	r := &Redactor{}
+	for _, detector := range cfg.Detectors {
+		switch detector {
+		case config.PIIDetectorEmail:
+			r.rules = append(r.rules, rule{name: config.PIIDetectorEmail, pattern: emailPattern})
+		case config.PIIDetectorCreditCard:
+			r.rules = append(r.rules, rule{name: config.PIIDetectorCreditCard, pattern: creditCardPattern, validate: luhnValid})
+		}
+	}
+	for _, custom := range cfg.Patterns {
+		pattern, errCompile := regexp.Compile(custom.Regex)
+		if errCompile != nil {
+			log.Warnf("pii redaction: skipping pattern %q: %v", custom.Name, errCompile)
+			continue
+		}
+		r.rules = append(r.rules, rule{name: custom.Name, pattern: pattern})
+	}
+	if len(cfg.Terms) > 0 {
+		alternatives := make([]string, 0, len(cfg.Terms))
+		for _, term := range cfg.Terms {
+			alternatives = append(alternatives, termPattern(term))
+		}
+		r.rules = append(r.rules, rule{name: "term", pattern: regexp.MustCompile(`(?i)(?:` + strings.Join(alternatives, "|") + `)`)})
+	}
+	return r
+}
+
+var (
+	cacheMu  sync.Mutex
+	cacheKey string
+	cached   *Redactor
+)
+
+// For returns the Redactor of cfg, reusing the last compiled one while cfg is unchanged.
+func For(cfg config.PIIRedactionConfig) *Redactor {
+	rawKey, _ := json.Marshal(cfg)
+	key := string(rawKey)
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cached == nil || key != cacheKey {
+		cached, cacheKey = New(cfg), key
+	}
+	return cached
+}
+
+// RedactText returns text with every match replaced and the match counts per rule name.
+func (r *Redactor) RedactText(text string) (string, map[string]int) {
+	if r == nil || len(r.rules) == 0 || text == "" {
+		return text, nil
+	}
+	var counts map[string]int
+	for _, rule := range r.rules {
+		replacement := "[REDACTED:" + rule.name + "]"
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.validate != nil && !rule.validate(match) {
+				return match
+			}
+			if counts == nil {
+				counts = make(map[string]int)
+			}
+			counts[rule.name]++
+			return replacement
+		})
+	}
+	return text, counts
+}
+
+// Redact rewrites the string values of a JSON payload, leaving keys, identifiers and binary
+// data untouched. Non-JSON payloads are returned unchanged.
+func (r *Redactor) Redact(payload []byte) ([]byte, map[string]int) {
+	if r == nil || len(r.rules) == 0 || !gjson.ValidBytes(payload) {
+		return payload, nil
+	}
+	type edit struct {
+		path  string
+		value string
+	}
+	var edits []edit
+	counts := make(map[string]int)
+	var walk func(value gjson.Result, path, key string)
+	walk = func(value gjson.Result, path, key string) {
+		switch {
+		case value.IsObject():
+			value.ForEach(func(childKey, child gjson.Result) bool {
+				walk(child, util.JoinGJSONPath(path, util.EscapeGJSONPathKey(childKey.String())), childKey.String())
+				return true
+			})
+		case value.IsArray():
+			for i, child := range value.Array() {
+				walk(child, util.JoinGJSONPath(path, strconv.Itoa(i)), key)
+			}
+		case value.Type == gjson.String:
+			if _, skip := skippedKeys[key]; skip || strings.HasPrefix(value.Str, "data:") {
+				return
+			}
+			redacted, found := r.RedactText(value.Str)
+			if len(found) == 0 {
+				return
+			}
+			for name, count := range found {
+				counts[name] += count
+			}
+			edits = append(edits, edit{path: path, value: redacted})
+		}
+	}
+	walk(gjson.ParseBytes(payload), "", "")
+
+	out := payload
+	for _, e := range edits {
+		updated, errSet := sjson.SetBytes(out, e.path, e.value)
+		if errSet != nil {
+			log.Debugf("pii redaction: failed to update %s: %v", e.path, errSet)
+			continue
+		}
+		out = updated
+	}
+	if len(counts) == 0 {
+		return payload, nil
+	}
+	return out, counts
+}
+
+// termPattern matches term as a whole word where its ends are word characters.
+func termPattern(term string) string {
+	pattern := regexp.QuoteMeta(term)
+	if first, _ := utf8.DecodeRuneInString(term); isWordRune(first) {
+		pattern = `\b` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(term); isWordRune(last) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// luhnValid reports whether the digits of match form a 13 to 19 digit number passing the Luhn check.
+func luhnValid(match string) bool {
+	sum, digits := 0, 0
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
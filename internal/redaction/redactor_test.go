@@ -0,0 +1,61 @@
+package redaction
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func testRedactor() *Redactor {
+	return New(config.PIIRedactionConfig{
+		Detectors: []string{config.PIIDetectorEmail, config.PIIDetectorCreditCard},
+		Patterns:  []config.PIIRedactionPattern{{Name: "employee-id", Regex: `EMP-[0-9]{6}`}},
+		Terms:     []string{"Project Falcon"},
+	})
+}
+
+func TestRedactText(t *testing.T) {
+	text := "Mail jane.doe@example.co.uk about project falcon, card 4111 1111 1111 1111, order 1234567890123, EMP-123456."
+	got, counts := testRedactor().RedactText(text)
+	want := "Mail [REDACTED:email] about [REDACTED:term], card [REDACTED:credit-card], order 1234567890123, [REDACTED:employee-id]."
+	if got != want {
+		t.Fatalf("redacted = %q\nwant      %q", got, want)
+	}
+	for name, count := range map[string]int{"email": 1, "credit-card": 1, "employee-id": 1, "term": 1} {
+		if counts[name] != count {
+			t.Fatalf("counts = %v", counts)
+		}
+	}
+}
+
+func TestRedactSkipsIdentifiersAndBinaryData(t *testing.T) {
+	payload := []byte(`{"model":"a@b.io","messages":[{"role":"user","id":"x@y.io","content":[{"type":"text","text":"ping a@b.io"},{"type":"image_url","image_url":{"url":"data:image/png;base64,a@b.io"}}]}]}`)
+	out, counts := testRedactor().Redact(payload)
+	if counts["email"] != 1 {
+		t.Fatalf("counts = %v", counts)
+	}
+	if got := gjson.GetBytes(out, "messages.0.content.0.text").String(); got != "ping [REDACTED:email]" {
+		t.Fatalf("text = %q", got)
+	}
+	for _, path := range []string{"model", "messages.0.id", "messages.0.content.1.image_url.url"} {
+		if gjson.GetBytes(out, path).String() != gjson.GetBytes(payload, path).String() {
+			t.Fatalf("%s changed: %s", path, out)
+		}
+	}
+}
+
+func TestAuditKeepsLatestEntries(t *testing.T) {
+	audit := NewAudit()
+	for _, id := range []string{"r1", "r2", "r3"} {
+		audit.Record(2, AuditEntry{RequestID: id, Counts: map[string]int{"email": 2}})
+	}
+	audit.Record(2, AuditEntry{RequestID: "none"})
+	report := audit.Snapshot(0)
+	if report.RedactedRequests != 3 || report.Totals["email"] != 6 {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Entries) != 2 || report.Entries[0].RequestID != "r3" || report.Entries[1].RequestID != "r2" {
+		t.Fatalf("entries = %+v", report.Entries)
+	}
+}
@@ -89,6 +89,7 @@ func (p *usageQueuePlugin) HandleUsage(ctx context.Context, record coreusage.Rec
 		TTFTMs:          record.TTFT.Milliseconds(),
 		Source:          record.Source,
 		AuthIndex:       record.AuthIndex,
+		Scope:           record.Scope,
 		Tokens:          tokens,
 		Failed:          failed,
 		Generate:        coreusage.GenerateEnabled(record.Generate),
@@ -141,6 +142,7 @@ type requestDetail struct {
 	TTFTMs          int64       `json:"ttft_ms"`
 	Source          string      `json:"source"`
 	AuthIndex       string      `json:"auth_index"`
+	Scope           string      `json:"scope,omitempty"`
 	Tokens          tokenStats  `json:"tokens"`
 	Failed          bool        `json:"failed"`
 	Generate        bool        `json:"generate"`
@@ -0,0 +1,528 @@
+// Package region probes the regional endpoints of credentials and routes each credential
+// to its fastest healthy region, or to a manually pinned one.
+package region
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/health"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler/jobs"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// Pin sources reported for a credential.
+const (
+	PinSourceConfig = "config"
+	PinSourceManual = "manual"
+)
+
+const (
+	probeTimeout = 10 * time.Second
+	// latencySmoothing weights the newest sample in the moving latency average.
+	latencySmoothing = 0.3
+	// switchRatio keeps the current region unless another one is clearly faster,
+	// so close latencies do not flap between regions.
+	switchRatio = 0.8
+)
+
+var (
+	// ErrUnknownAuth is returned by Pin for credentials that do not exist or have no regions.
+	ErrUnknownAuth = errors.New("region: unknown credential or credential has no regions")
+	// ErrUnknownRegion is returned by Pin for region names the credential does not list.
+	ErrUnknownRegion = errors.New("region: unknown region")
+)
+
+// RegionStatus reports the probe metrics of one regional endpoint.
+type RegionStatus struct {
+	Name         string     `json:"name"`
+	BaseURL      string     `json:"base_url"`
+	Healthy      bool       `json:"healthy"`
+	StatusCode   int        `json:"status_code,omitempty"`
+	LatencyMs    int64      `json:"latency_ms,omitempty"`
+	AvgLatencyMs int64      `json:"avg_latency_ms,omitempty"`
+	Probes       int64      `json:"probes"`
+	Failures     int64      `json:"failures"`
+	Error        string     `json:"error,omitempty"`
+	CheckedAt    *time.Time `json:"checked_at,omitempty"`
+}
+
+// CredentialRegions reports the regions of a credential and the one currently in use.
+type CredentialRegions struct {
+	AuthID    string         `json:"auth_id"`
+	AuthIndex string         `json:"auth_index,omitempty"`
+	Provider  string         `json:"provider"`
+	Label     string         `json:"label,omitempty"`
+	Selected  string         `json:"selected,omitempty"`
+	Pinned    string         `json:"pinned,omitempty"`
+	PinSource string         `json:"pin_source,omitempty"`
+	Regions   []RegionStatus `json:"regions"`
+}
+
+type probeResult struct {
+	statusCode int
+	latency    time.Duration
+	err        string
+}
+
+type regionStats struct {
+	baseURL    string
+	healthy    bool
+	statusCode int
+	latency    time.Duration
+	avgLatency time.Duration
+	probes     int64
+	failures   int64
+	err        string
+	checkedAt  time.Time
+}
+
+// Router implements coreauth.EndpointSelector. Probe results and manual pins live in memory.
+type Router struct {
+	mu       sync.Mutex
+	manager  *coreauth.Manager
+	enabled  bool
+	interval time.Duration
+	cancel   context.CancelFunc
+
+	// stats holds probe metrics by auth ID and region name.
+	stats map[string]map[string]*regionStats
+	// selected holds the latency-selected region name by auth ID.
+	selected map[string]string
+	// pins holds manual pins set through the management API by auth ID.
+	pins     map[string]string
+	inFlight map[string]struct{}
+
+	probe func(ctx context.Context, manager *coreauth.Manager, auth *coreauth.Auth, target string) probeResult
+	clock clock.Clock
+}
+
+// NewRouter creates an idle router and registers it as the endpoint selector of manager.
+func NewRouter(manager *coreauth.Manager) *Router {
+	r := &Router{
+		stats:    make(map[string]map[string]*regionStats),
+		selected: make(map[string]string),
+		pins:     make(map[string]string),
+		inFlight: make(map[string]struct{}),
+		probe:    probe,
+		clock:    clock.Default(),
+	}
+	r.SetAuthManager(manager)
+	return r
+}
+
+// SetAuthManager updates the auth manager used for probing and registers the router as its
+// endpoint selector.
+func (r *Router) SetAuthManager(manager *coreauth.Manager) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	previous := r.manager
+	r.manager = manager
+	r.mu.Unlock()
+	if previous != nil && previous != manager {
+		previous.SetEndpointSelector(nil)
+	}
+	if manager != nil {
+		manager.SetEndpointSelector(r)
+	}
+}
+
+// Apply starts, restarts, or stops the probe loop according to cfg.
+func (r *Router) Apply(cfg config.RegionRoutingConfig) {
+	if r == nil {
+		return
+	}
+	interval := cfg.IntervalDuration()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = cfg.Enable
+	if !cfg.Enable {
+		r.stopLocked()
+		return
+	}
+	if r.cancel != nil && r.interval == interval {
+		return
+	}
+	r.stopLocked()
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.interval = interval
+	go r.run(ctx, interval)
+	log.Infof("region router started (interval=%s)", interval)
+}
+
+// Stop halts the probe loop. Selections are kept until the router is disabled.
+func (r *Router) Stop() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.stopLocked()
+	r.mu.Unlock()
+}
+
+func (r *Router) stopLocked() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.cancel = nil
+	r.interval = 0
+	log.Info("region router stopped")
+}
+
+func (r *Router) run(ctx context.Context, interval time.Duration) {
+	r.ProbeAll(ctx)
+	ticker := r.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if !jobs.Scheduled(jobs.RegionProbe) {
+				r.ProbeAll(ctx)
+			}
+		}
+	}
+}
+
+// BaseURLFor returns the base URL of the pinned or selected region of auth.
+// It reports false while routing is disabled or no region has been chosen yet.
+func (r *Router) BaseURLFor(auth *coreauth.Auth) (string, bool) {
+	if r == nil || auth == nil {
+		return "", false
+	}
+	regions := authRegions(auth)
+	if len(regions) == 0 {
+		return "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enabled {
+		return "", false
+	}
+	name, _ := r.pinLocked(auth)
+	if name == "" {
+		name = r.selected[auth.ID]
+	}
+	for _, region := range regions {
+		if region.Name == name {
+			return region.BaseURL, true
+		}
+	}
+	return "", false
+}
+
+// ProbeAll probes every region of every enabled credential that lists regions and waits
+// for the round to finish. Credentials whose previous round is still running are skipped.
+func (r *Router) ProbeAll(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	r.mu.Lock()
+	manager := r.manager
+	r.mu.Unlock()
+	if manager == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	seen := make(map[string]struct{})
+	for _, auth := range manager.List() {
+		regions := authRegions(auth)
+		if auth == nil || auth.ID == "" || len(regions) == 0 {
+			continue
+		}
+		seen[auth.ID] = struct{}{}
+		if auth.Disabled || auth.Status == coreauth.StatusDisabled || !r.beginProbe(auth.ID) {
+			continue
+		}
+		wg.Add(1)
+		go func(auth *coreauth.Auth, regions []config.RegionEndpoint) {
+			defer wg.Done()
+			defer r.finishProbe(auth.ID)
+			results := make([]probeResult, len(regions))
+			var regionWG sync.WaitGroup
+			for i, region := range regions {
+				target := health.ModelsURL(auth, region.BaseURL)
+				if target == "" {
+					results[i] = probeResult{err: "provider has no probe endpoint"}
+					continue
+				}
+				regionWG.Add(1)
+				go func(i int, target string) {
+					defer regionWG.Done()
+					results[i] = r.probe(ctx, manager, auth, target)
+				}(i, target)
+			}
+			regionWG.Wait()
+			if ctx.Err() != nil {
+				return
+			}
+			r.record(auth.ID, regions, results, r.clock.Now().UTC())
+		}(auth, regions)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	for id := range r.stats {
+		if _, ok := seen[id]; !ok {
+			delete(r.stats, id)
+			delete(r.selected, id)
+			delete(r.pins, id)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// Pin routes a credential to the named region regardless of latency. An empty name removes
+// the manual pin; a region pinned in the config file still applies.
+func (r *Router) Pin(authID, name string) error {
+	if r == nil {
+		return ErrUnknownAuth
+	}
+	r.mu.Lock()
+	manager := r.manager
+	r.mu.Unlock()
+	if manager == nil {
+		return ErrUnknownAuth
+	}
+	auth, ok := manager.GetByID(authID)
+	regions := authRegions(auth)
+	if !ok || len(regions) == 0 {
+		return ErrUnknownAuth
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == "" {
+		delete(r.pins, authID)
+		return nil
+	}
+	for _, region := range regions {
+		if region.Name == name {
+			r.pins[authID] = name
+			return nil
+		}
+	}
+	return ErrUnknownRegion
+}
+
+// Snapshot reports the regions and probe metrics of every credential that lists regions,
+// sorted by provider and auth ID.
+func (r *Router) Snapshot() []CredentialRegions {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	manager := r.manager
+	r.mu.Unlock()
+	if manager == nil {
+		return nil
+	}
+
+	out := make([]CredentialRegions, 0)
+	for _, auth := range manager.List() {
+		regions := authRegions(auth)
+		if auth == nil || auth.ID == "" || len(regions) == 0 {
+			continue
+		}
+		entry := CredentialRegions{
+			AuthID:    auth.ID,
+			AuthIndex: auth.EnsureIndex(),
+			Provider:  strings.ToLower(strings.TrimSpace(auth.Provider)),
+			Label:     auth.Label,
+			Regions:   make([]RegionStatus, 0, len(regions)),
+		}
+		r.mu.Lock()
+		entry.Pinned, entry.PinSource = r.pinLocked(auth)
+		entry.Selected = r.selected[auth.ID]
+		if entry.Pinned != "" {
+			entry.Selected = entry.Pinned
+		}
+		for _, region := range regions {
+			status := RegionStatus{Name: region.Name, BaseURL: region.BaseURL}
+			if stats := r.stats[auth.ID][region.Name]; stats != nil && stats.baseURL == region.BaseURL {
+				checkedAt := stats.checkedAt
+				status.Healthy = stats.healthy
+				status.StatusCode = stats.statusCode
+				status.LatencyMs = stats.latency.Milliseconds()
+				status.AvgLatencyMs = stats.avgLatency.Milliseconds()
+				status.Probes = stats.probes
+				status.Failures = stats.failures
+				status.Error = stats.err
+				status.CheckedAt = &checkedAt
+			}
+			entry.Regions = append(entry.Regions, status)
+		}
+		r.mu.Unlock()
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, k int) bool {
+		if out[i].Provider != out[k].Provider {
+			return out[i].Provider < out[k].Provider
+		}
+		return out[i].AuthID < out[k].AuthID
+	})
+	return out
+}
+
+// record stores one probe round of a credential and reselects its region.
+func (r *Router) record(authID string, regions []config.RegionEndpoint, results []probeResult, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byName := r.stats[authID]
+	if byName == nil {
+		byName = make(map[string]*regionStats)
+		r.stats[authID] = byName
+	}
+	current := make(map[string]struct{}, len(regions))
+	for i, region := range regions {
+		current[region.Name] = struct{}{}
+		stats := byName[region.Name]
+		if stats == nil || stats.baseURL != region.BaseURL {
+			stats = &regionStats{baseURL: region.BaseURL}
+			byName[region.Name] = stats
+		}
+		result := results[i]
+		stats.probes++
+		stats.checkedAt = now
+		stats.statusCode = result.statusCode
+		stats.latency = result.latency
+		stats.err = result.err
+		stats.healthy = result.err == "" && result.statusCode >= http.StatusOK && result.statusCode < http.StatusMultipleChoices
+		if !stats.healthy {
+			stats.failures++
+			continue
+		}
+		if stats.avgLatency == 0 {
+			stats.avgLatency = result.latency
+		} else {
+			stats.avgLatency = time.Duration(latencySmoothing*float64(result.latency) + (1-latencySmoothing)*float64(stats.avgLatency))
+		}
+	}
+	for name := range byName {
+		if _, ok := current[name]; !ok {
+			delete(byName, name)
+		}
+	}
+
+	previous := r.selected[authID]
+	next := selectRegion(byName, previous)
+	if next == "" {
+		// Keep routing to the last choice while every region is failing.
+		return
+	}
+	if next != previous {
+		r.selected[authID] = next
+		log.Infof("region router: credential %s now uses region %s", authID, next)
+	}
+}
+
+// selectRegion returns the healthy region with the lowest average latency. The current
+// region is kept unless it is unhealthy or another region is clearly faster.
+func selectRegion(byName map[string]*regionStats, current string) string {
+	best := ""
+	var bestLatency time.Duration
+	for name, stats := range byName {
+		if !stats.healthy {
+			continue
+		}
+		if best == "" || stats.avgLatency < bestLatency || (stats.avgLatency == bestLatency && name < best) {
+			best, bestLatency = name, stats.avgLatency
+		}
+	}
+	if cur := byName[current]; cur != nil && cur.healthy && best != current {
+		if float64(bestLatency) >= switchRatio*float64(cur.avgLatency) {
+			return current
+		}
+	}
+	return best
+}
+
+func (r *Router) pinLocked(auth *coreauth.Auth) (string, string) {
+	if name := r.pins[auth.ID]; name != "" {
+		return name, PinSourceManual
+	}
+	if auth.Attributes != nil {
+		if name := strings.TrimSpace(auth.Attributes["region"]); name != "" {
+			return name, PinSourceConfig
+		}
+	}
+	return "", ""
+}
+
+func (r *Router) beginProbe(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, busy := r.inFlight[id]; busy {
+		return false
+	}
+	r.inFlight[id] = struct{}{}
+	return true
+}
+
+func (r *Router) finishProbe(id string) {
+	r.mu.Lock()
+	delete(r.inFlight, id)
+	r.mu.Unlock()
+}
+
+// authRegions decodes the regions recorded on a config credential.
+func authRegions(auth *coreauth.Auth) []config.RegionEndpoint {
+	if auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	raw := strings.TrimSpace(auth.Attributes["regions"])
+	if raw == "" {
+		return nil
+	}
+	var regions []config.RegionEndpoint
+	if errUnmarshal := json.Unmarshal([]byte(raw), &regions); errUnmarshal != nil {
+		return nil
+	}
+	return config.NormalizeRegions(regions)
+}
+
+func probe(ctx context.Context, manager *coreauth.Manager, auth *coreauth.Auth, target string) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	req, errRequest := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if errRequest != nil {
+		return probeResult{err: errRequest.Error()}
+	}
+	start := time.Now()
+	resp, errDo := manager.HttpRequest(ctx, auth, req)
+	latency := time.Since(start)
+	if errDo != nil {
+		return probeResult{latency: latency, err: errDo.Error()}
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("region router: failed to close probe response body: %v", errClose)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	result := probeResult{statusCode: resp.StatusCode, latency: latency}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		result.err = http.StatusText(resp.StatusCode)
+	}
+	return result
+}
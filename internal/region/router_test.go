@@ -0,0 +1,116 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+const testRegions = `[{"name":"us","base-url":"https://us.example.com"},{"name":"eu","base-url":"https://eu.example.com"}]`
+
+func newTestRouter(t *testing.T, attrs map[string]string, latencies map[string]time.Duration) (*Router, *coreauth.Manager) {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	if attrs == nil {
+		attrs = map[string]string{}
+	}
+	attrs["api_key"] = "sk-test"
+	attrs["regions"] = testRegions
+	if _, errRegister := manager.Register(context.Background(), &coreauth.Auth{ID: "claude-1", Provider: "claude", Status: coreauth.StatusActive, Attributes: attrs}); errRegister != nil {
+		t.Fatalf("Register error: %v", errRegister)
+	}
+	r := NewRouter(manager)
+	r.enabled = true
+	r.probe = func(_ context.Context, _ *coreauth.Manager, _ *coreauth.Auth, target string) probeResult {
+		for host, latency := range latencies {
+			if strings.Contains(target, host) {
+				if latency < 0 {
+					return probeResult{statusCode: http.StatusServiceUnavailable, err: "Service Unavailable"}
+				}
+				return probeResult{statusCode: http.StatusOK, latency: latency}
+			}
+		}
+		return probeResult{err: "unexpected target " + target}
+	}
+	return r, manager
+}
+
+func baseURL(t *testing.T, r *Router, manager *coreauth.Manager) string {
+	t.Helper()
+	auth, _ := manager.GetByID("claude-1")
+	url, _ := r.BaseURLFor(auth)
+	return url
+}
+
+func TestRouter_SelectsFastestHealthyRegion(t *testing.T) {
+	latencies := map[string]time.Duration{"us.example.com": 80 * time.Millisecond, "eu.example.com": 20 * time.Millisecond}
+	r, manager := newTestRouter(t, nil, latencies)
+
+	if got := baseURL(t, r, manager); got != "" {
+		t.Fatalf("BaseURLFor before probing = %q, want none", got)
+	}
+	r.ProbeAll(context.Background())
+	if got := baseURL(t, r, manager); got != "https://eu.example.com" {
+		t.Fatalf("BaseURLFor = %q, want eu", got)
+	}
+
+	// A slightly faster region does not trigger a switch.
+	latencies["us.example.com"] = 18 * time.Millisecond
+	r.ProbeAll(context.Background())
+	if got := baseURL(t, r, manager); got != "https://eu.example.com" {
+		t.Fatalf("BaseURLFor after small change = %q, want eu", got)
+	}
+
+	// An unhealthy region is abandoned immediately.
+	latencies["eu.example.com"] = -1
+	r.ProbeAll(context.Background())
+	if got := baseURL(t, r, manager); got != "https://us.example.com" {
+		t.Fatalf("BaseURLFor after eu failure = %q, want us", got)
+	}
+
+	snapshot := r.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Selected != "us" || len(snapshot[0].Regions) != 2 {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	for _, status := range snapshot[0].Regions {
+		if status.Name == "eu" && (status.Healthy || status.Probes != 3 || status.Failures != 1) {
+			t.Fatalf("unexpected eu metrics: %+v", status)
+		}
+	}
+
+	r.enabled = false
+	if got := baseURL(t, r, manager); got != "" {
+		t.Fatalf("BaseURLFor while disabled = %q, want none", got)
+	}
+}
+
+func TestRouter_PinsOverrideLatencySelection(t *testing.T) {
+	latencies := map[string]time.Duration{"us.example.com": 10 * time.Millisecond, "eu.example.com": 90 * time.Millisecond}
+	r, manager := newTestRouter(t, map[string]string{"region": "eu"}, latencies)
+	r.ProbeAll(context.Background())
+	if got := baseURL(t, r, manager); got != "https://eu.example.com" {
+		t.Fatalf("config pin BaseURLFor = %q, want eu", got)
+	}
+
+	if errPin := r.Pin("claude-1", "US"); errPin != nil {
+		t.Fatalf("Pin error: %v", errPin)
+	}
+	if got := baseURL(t, r, manager); got != "https://us.example.com" {
+		t.Fatalf("manual pin BaseURLFor = %q, want us", got)
+	}
+	if snapshot := r.Snapshot(); snapshot[0].PinSource != PinSourceManual {
+		t.Fatalf("pin source = %q, want manual", snapshot[0].PinSource)
+	}
+
+	if errPin := r.Pin("claude-1", "ap"); !errors.Is(errPin, ErrUnknownRegion) {
+		t.Fatalf("Pin unknown region error = %v", errPin)
+	}
+	if errPin := r.Pin("missing", "us"); !errors.Is(errPin, ErrUnknownAuth) {
+		t.Fatalf("Pin unknown auth error = %v", errPin)
+	}
+}
@@ -0,0 +1,29 @@
+package registry
+
+// azureOpenAIModelInfos lists the models exposed for Azure OpenAI credentials without model
+// mappings. Each ID doubles as the deployment name, so resources deploying under other names
+// should configure models with name (deployment) and alias entries instead.
+func azureOpenAIModelInfos() []*ModelInfo {
+	model := func(id, displayName string, contextLength, maxCompletion int, thinking *ThinkingSupport) *ModelInfo {
+		return &ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             1735689600, // 2025-01-01
+			OwnedBy:             "azure",
+			Type:                "azure-openai",
+			DisplayName:         displayName,
+			ContextLength:       contextLength,
+			MaxCompletionTokens: maxCompletion,
+			Thinking:            thinking,
+		}
+	}
+	reasoning := func() *ThinkingSupport { return &ThinkingSupport{Levels: []string{"low", "medium", "high"}} }
+	return []*ModelInfo{
+		model("gpt-4.1", "GPT-4.1 (Azure)", 1047576, 32768, nil),
+		model("gpt-4.1-mini", "GPT-4.1 mini (Azure)", 1047576, 32768, nil),
+		model("gpt-4o", "GPT-4o (Azure)", 128000, 16384, nil),
+		model("gpt-4o-mini", "GPT-4o mini (Azure)", 128000, 16384, nil),
+		model("o3", "o3 (Azure)", 200000, 100000, reasoning()),
+		model("o4-mini", "o4-mini (Azure)", 200000, 100000, reasoning()),
+	}
+}
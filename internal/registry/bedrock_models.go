@@ -0,0 +1,35 @@
+package registry
+
+// bedrockModelInfos lists the Bedrock-hosted models exposed by default. IDs are the
+// cross-region inference profiles Bedrock requires for on-demand use of newer models.
+func bedrockModelInfos() []*ModelInfo {
+	model := func(id, ownedBy, displayName string, contextLength, maxCompletion int, thinking *ThinkingSupport) *ModelInfo {
+		return &ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             1735689600, // 2025-01-01
+			OwnedBy:             ownedBy,
+			Type:                "bedrock",
+			DisplayName:         displayName,
+			ContextLength:       contextLength,
+			MaxCompletionTokens: maxCompletion,
+			Thinking:            thinking,
+		}
+	}
+	// Claude keeps the extended thinking budget range of the first-party API.
+	claudeThinking := func() *ThinkingSupport { return &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: true} }
+	return []*ModelInfo{
+		model("us.anthropic.claude-opus-4-1-20250805-v1:0", "anthropic", "Claude Opus 4.1 (Bedrock)", 200000, 32000, claudeThinking()),
+		model("us.anthropic.claude-sonnet-4-5-20250929-v1:0", "anthropic", "Claude Sonnet 4.5 (Bedrock)", 200000, 64000, claudeThinking()),
+		model("us.anthropic.claude-sonnet-4-20250514-v1:0", "anthropic", "Claude Sonnet 4 (Bedrock)", 200000, 64000, claudeThinking()),
+		model("us.anthropic.claude-haiku-4-5-20251001-v1:0", "anthropic", "Claude Haiku 4.5 (Bedrock)", 200000, 64000, claudeThinking()),
+		model("us.anthropic.claude-3-5-haiku-20241022-v1:0", "anthropic", "Claude 3.5 Haiku (Bedrock)", 200000, 8192, nil),
+		model("us.meta.llama4-maverick-17b-instruct-v1:0", "meta", "Llama 4 Maverick 17B Instruct (Bedrock)", 1000000, 8192, nil),
+		model("us.meta.llama4-scout-17b-instruct-v1:0", "meta", "Llama 4 Scout 17B Instruct (Bedrock)", 3500000, 8192, nil),
+		model("us.meta.llama3-3-70b-instruct-v1:0", "meta", "Llama 3.3 70B Instruct (Bedrock)", 128000, 8192, nil),
+		model("amazon.titan-text-premier-v1:0", "amazon", "Titan Text Premier (Bedrock)", 32000, 3072, nil),
+		model("amazon.titan-text-express-v1", "amazon", "Titan Text Express (Bedrock)", 8000, 8192, nil),
+		model("us.amazon.nova-pro-v1:0", "amazon", "Nova Pro (Bedrock)", 300000, 10000, nil),
+		model("us.amazon.nova-lite-v1:0", "amazon", "Nova Lite (Bedrock)", 300000, 10000, nil),
+	}
+}
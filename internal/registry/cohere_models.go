@@ -0,0 +1,32 @@
+package registry
+
+// cohereModelInfos lists the models exposed for Cohere credentials without model mappings.
+// Command A Vision is the only model that takes images, and it does not support tools.
+func cohereModelInfos() []*ModelInfo {
+	model := func(id, displayName string, created int64, contextLength, maxCompletion int) *ModelInfo {
+		vision := id == "command-a-vision-07-2025"
+		return &ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             created,
+			OwnedBy:             "cohere",
+			Type:                "cohere",
+			DisplayName:         displayName,
+			ContextLength:       contextLength,
+			MaxCompletionTokens: maxCompletion,
+			Capabilities: &ModelCapabilities{
+				Vision:          capabilityFlag(vision),
+				Tools:           capabilityFlag(!vision),
+				JSONMode:        capabilityFlag(true),
+				MaxOutputTokens: maxCompletion,
+			},
+		}
+	}
+	return []*ModelInfo{
+		model("command-a-03-2025", "Command A", 1741132800, 262144, 8192),               // 2025-03-05
+		model("command-a-vision-07-2025", "Command A Vision", 1753920000, 131072, 8192), // 2025-07-31
+		model("command-r-plus-08-2024", "Command R+", 1724976000, 131072, 4096),         // 2024-08-30
+		model("command-r-08-2024", "Command R", 1724976000, 131072, 4096),               // 2024-08-30
+		model("command-r7b-12-2024", "Command R7B", 1734048000, 131072, 4096),           // 2024-12-13
+	}
+}
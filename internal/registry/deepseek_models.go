@@ -0,0 +1,29 @@
+package registry
+
+// deepSeekModelInfos lists the models exposed for DeepSeek credentials without model mappings.
+// deepseek-reasoner always thinks and streams its chain of thought as reasoning_content; it takes
+// no effort or budget setting, so Thinking stays nil and thinking config is stripped upstream.
+func deepSeekModelInfos() []*ModelInfo {
+	model := func(id, displayName string, maxCompletion int) *ModelInfo {
+		return &ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             1735689600, // 2025-01-01
+			OwnedBy:             "deepseek",
+			Type:                "deepseek",
+			DisplayName:         displayName,
+			ContextLength:       131072,
+			MaxCompletionTokens: maxCompletion,
+			Capabilities: &ModelCapabilities{
+				Vision:          capabilityFlag(false),
+				Tools:           capabilityFlag(true),
+				JSONMode:        capabilityFlag(true),
+				MaxOutputTokens: maxCompletion,
+			},
+		}
+	}
+	return []*ModelInfo{
+		model("deepseek-chat", "DeepSeek Chat", 8192),
+		model("deepseek-reasoner", "DeepSeek Reasoner", 65536),
+	}
+}
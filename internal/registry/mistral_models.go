@@ -0,0 +1,38 @@
+package registry
+
+import "strings"
+
+// mistralModelInfos lists the models exposed for Mistral credentials without model mappings.
+// The "-latest" IDs are Mistral's rolling aliases, so the list does not need a release bump
+// for every model update.
+func mistralModelInfos() []*ModelInfo {
+	model := func(id, displayName string, contextLength int) *ModelInfo {
+		info := &ModelInfo{
+			ID:            id,
+			Object:        "model",
+			Created:       1735689600, // 2025-01-01
+			OwnedBy:       "mistralai",
+			Type:          "mistral",
+			DisplayName:   displayName,
+			ContextLength: contextLength,
+		}
+		if MistralModelSupportsFIM(id) {
+			info.SupportedEndpoints = []string{EndpointChatCompletions, EndpointFIMCompletions}
+		}
+		return info
+	}
+	return []*ModelInfo{
+		model("mistral-large-latest", "Mistral Large", 131072),
+		model("mistral-medium-latest", "Mistral Medium", 131072),
+		model("mistral-small-latest", "Mistral Small", 131072),
+		model("magistral-medium-latest", "Magistral Medium", 131072),
+		model("devstral-medium-latest", "Devstral Medium", 131072),
+		model("codestral-latest", "Codestral", 262144),
+	}
+}
+
+// MistralModelSupportsFIM reports whether a Mistral model serves /v1/fim/completions.
+// Mistral only offers fill-in-the-middle on the Codestral family.
+func MistralModelSupportsFIM(model string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(model)), "codestral")
+}
@@ -0,0 +1,58 @@
+package registry
+
+// ModelCapabilities declares what a model accepts, so handlers can reject requests that are
+// bound to fail upstream. Nil flags and zero limits mean the capability is unknown and is not
+// enforced.
+type ModelCapabilities struct {
+	// Vision reports whether the model accepts image input.
+	Vision *bool `json:"vision,omitempty" yaml:"vision,omitempty"`
+	// Tools reports whether the model accepts tool or function declarations.
+	Tools *bool `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// JSONMode reports whether the model supports JSON object or JSON schema output.
+	JSONMode *bool `json:"json_mode,omitempty" yaml:"json-mode,omitempty"`
+	// MaxContextTokens is the context window; it takes precedence over ContextLength.
+	MaxContextTokens int `json:"max_context_tokens,omitempty" yaml:"max-context-tokens,omitempty"`
+	// MaxOutputTokens is the largest output token limit a request may ask for.
+	MaxOutputTokens int `json:"max_output_tokens,omitempty" yaml:"max-output-tokens,omitempty"`
+}
+
+// capabilityFlag returns a pointer to v for ModelCapabilities literals.
+func capabilityFlag(v bool) *bool { return &v }
+
+// capabilityDenied reports whether flag explicitly marks a capability as unsupported.
+func capabilityDenied(flag *bool) bool { return flag != nil && !*flag }
+
+// DeniesVision reports whether the model is declared not to accept images.
+func (m *ModelInfo) DeniesVision() bool {
+	return m != nil && m.Capabilities != nil && capabilityDenied(m.Capabilities.Vision)
+}
+
+// DeniesTools reports whether the model is declared not to accept tools.
+func (m *ModelInfo) DeniesTools() bool {
+	return m != nil && m.Capabilities != nil && capabilityDenied(m.Capabilities.Tools)
+}
+
+// DeniesJSONMode reports whether the model is declared not to support structured JSON output.
+func (m *ModelInfo) DeniesJSONMode() bool {
+	return m != nil && m.Capabilities != nil && capabilityDenied(m.Capabilities.JSONMode)
+}
+
+// MaxOutputTokens returns the declared output token limit, or 0 when unknown.
+func (m *ModelInfo) MaxOutputTokens() int {
+	if m == nil || m.Capabilities == nil {
+		return 0
+	}
+	return m.Capabilities.MaxOutputTokens
+}
+
+// ContextWindow returns the declared context window, preferring
+// Capabilities.MaxContextTokens over ContextLength. It returns 0 when unknown.
+func (m *ModelInfo) ContextWindow() int {
+	if m == nil {
+		return 0
+	}
+	if m.Capabilities != nil && m.Capabilities.MaxContextTokens > 0 {
+		return m.Capabilities.MaxContextTokens
+	}
+	return m.ContextLength
+}
@@ -0,0 +1,218 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// catalogSource holds the configured remote catalog URL, the local override file and the
+// ETag cache of remote fetches.
+type catalogSource struct {
+	mu           sync.Mutex
+	url          string
+	overrideFile string
+	override     *staticModelsJSON
+	remote       map[string]cachedCatalog
+}
+
+// cachedCatalog is the last catalog fetched from a URL and the ETag it was served with.
+type cachedCatalog struct {
+	etag string
+	data *staticModelsJSON
+}
+
+var modelCatalogSource = &catalogSource{remote: make(map[string]cachedCatalog)}
+
+// SetModelCatalogSource configures where model definitions come from. A non-empty url
+// replaces the default models.json URLs used by the remote updater; the catalog may be JSON
+// or YAML with the same sections as models.json. overrideFile names a local JSON or YAML file
+// whose models replace catalog entries with the same ID or are appended to their section.
+// The override is re-read on every call and every refresh, and providers whose definitions
+// change are reported to the refresh callback.
+func SetModelCatalogSource(url, overrideFile string) {
+	url = strings.TrimSpace(url)
+	overrideFile = strings.TrimSpace(overrideFile)
+
+	modelCatalogSource.mu.Lock()
+	urlChanged := modelCatalogSource.url != url
+	modelCatalogSource.url = url
+	modelCatalogSource.overrideFile = overrideFile
+	modelCatalogSource.mu.Unlock()
+
+	reloadModelCatalogOverride()
+	if urlChanged && url != "" && updaterStarted.Load() {
+		go tryRefreshModels(context.Background(), "model catalog source refresh")
+	}
+}
+
+// catalogURLs returns the URLs the remote updater fetches, in order.
+func catalogURLs() []string {
+	modelCatalogSource.mu.Lock()
+	defer modelCatalogSource.mu.Unlock()
+	if modelCatalogSource.url != "" {
+		return []string{modelCatalogSource.url}
+	}
+	return modelsURLs
+}
+
+// cachedRemoteCatalog returns the ETag and catalog last fetched from url.
+func cachedRemoteCatalog(url string) cachedCatalog {
+	modelCatalogSource.mu.Lock()
+	defer modelCatalogSource.mu.Unlock()
+	return modelCatalogSource.remote[url]
+}
+
+func storeRemoteCatalog(url, etag string, data *staticModelsJSON) {
+	modelCatalogSource.mu.Lock()
+	defer modelCatalogSource.mu.Unlock()
+	if etag == "" {
+		delete(modelCatalogSource.remote, url)
+		return
+	}
+	modelCatalogSource.remote[url] = cachedCatalog{etag: etag, data: data}
+}
+
+// reloadModelCatalogOverride re-reads the override file and rebuilds the effective catalog.
+func reloadModelCatalogOverride() {
+	override := currentModelCatalogOverride()
+
+	modelsCatalogStore.mu.Lock()
+	oldData := modelsCatalogStore.data
+	newData := applyModelCatalogOverride(modelsCatalogStore.base, override)
+	modelsCatalogStore.data = newData
+	modelsCatalogStore.mu.Unlock()
+
+	notifyModelRefresh(detectChangedProviders(oldData, newData))
+}
+
+func loadModelCatalogOverride(path string) (*staticModelsJSON, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := decodeModelsCatalog(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range catalogSections(parsed) {
+		if len(*section.models) == 0 {
+			continue
+		}
+		if err = validateModelSection(section.name, *section.models); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// currentModelCatalogOverride re-reads the override file so edits are picked up by the next
+// refresh. A file that cannot be read or parsed keeps the previously loaded override.
+func currentModelCatalogOverride() *staticModelsJSON {
+	modelCatalogSource.mu.Lock()
+	path := modelCatalogSource.overrideFile
+	if path == "" {
+		modelCatalogSource.override = nil
+	}
+	override := modelCatalogSource.override
+	modelCatalogSource.mu.Unlock()
+	if path == "" {
+		return nil
+	}
+	loaded, err := loadModelCatalogOverride(path)
+	if err != nil {
+		log.Warnf("model catalog override %s: %v; keeping previous override", path, err)
+		return override
+	}
+	modelCatalogSource.mu.Lock()
+	modelCatalogSource.override = loaded
+	modelCatalogSource.mu.Unlock()
+	return loaded
+}
+
+// decodeModelsCatalog parses a catalog in JSON or YAML. YAML is converted to JSON first so
+// ModelInfo keeps a single set of field names.
+func decodeModelsCatalog(data []byte) (*staticModelsJSON, error) {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode models catalog: %w", err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("decode models catalog: %w", err)
+		}
+		data = converted
+	}
+	var parsed staticModelsJSON
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("decode models catalog: %w", err)
+	}
+	return &parsed, nil
+}
+
+type catalogSection struct {
+	name   string
+	models *[]*ModelInfo
+}
+
+func catalogSections(data *staticModelsJSON) []catalogSection {
+	return []catalogSection{
+		{name: "claude", models: &data.Claude},
+		{name: "gemini", models: &data.Gemini},
+		{name: "vertex", models: &data.Vertex},
+		{name: "aistudio", models: &data.AIStudio},
+		{name: "codex-free", models: &data.CodexFree},
+		{name: "codex-team", models: &data.CodexTeam},
+		{name: "codex-plus", models: &data.CodexPlus},
+		{name: "codex-pro", models: &data.CodexPro},
+		{name: "kimi", models: &data.Kimi},
+		{name: "antigravity", models: &data.Antigravity},
+		{name: "xai", models: &data.XAI},
+	}
+}
+
+// applyModelCatalogOverride returns base with the override models merged in by ID. base is
+// not modified.
+func applyModelCatalogOverride(base, override *staticModelsJSON) *staticModelsJSON {
+	if base == nil || override == nil {
+		return base
+	}
+	merged := *base
+	mergedSections := catalogSections(&merged)
+	for i, section := range catalogSections(override) {
+		if len(*section.models) == 0 {
+			continue
+		}
+		*mergedSections[i].models = mergeModelSection(*mergedSections[i].models, *section.models)
+	}
+	return &merged
+}
+
+func mergeModelSection(base, override []*ModelInfo) []*ModelInfo {
+	out := make([]*ModelInfo, 0, len(base)+len(override))
+	out = append(out, base...)
+	index := make(map[string]int, len(out))
+	for i, model := range out {
+		if model != nil {
+			index[model.ID] = i
+		}
+	}
+	for _, model := range override {
+		if i, ok := index[model.ID]; ok {
+			out[i] = model
+			continue
+		}
+		index[model.ID] = len(out)
+		out = append(out, model)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchModelsFromRemoteReusesCatalogOnNotModified(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("claude:\n  - id: claude-remote\n    object: model\n    owned_by: anthropic\n"))
+	}))
+	defer server.Close()
+
+	SetModelCatalogSource(server.URL, "")
+	t.Cleanup(func() {
+		SetModelCatalogSource("", "")
+		storeRemoteCatalog(server.URL, "", nil)
+	})
+
+	first, url := fetchModelsFromRemote(context.Background())
+	if first == nil || url != server.URL || len(first.Claude) != 1 || first.Claude[0].ID != "claude-remote" {
+		t.Fatalf("first fetch = %+v from %q", first, url)
+	}
+	second, _ := fetchModelsFromRemote(context.Background())
+	if second != first {
+		t.Fatalf("second fetch did not reuse the cached catalog: %+v", second)
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want 2", requests)
+	}
+}
+
+func TestModelCatalogOverrideFileReplacesAndAppendsModels(t *testing.T) {
+	modelsCatalogStore.mu.RLock()
+	base := modelsCatalogStore.base
+	modelsCatalogStore.mu.RUnlock()
+	if base == nil || len(base.Claude) == 0 {
+		t.Skip("embedded catalog has no claude models")
+	}
+	existing := base.Claude[0].ID
+
+	path := filepath.Join(t.TempDir(), "override.yaml")
+	override := "claude:\n" +
+		"  - id: " + existing + "\n    display_name: Overridden\n" +
+		"  - id: claude-next\n    display_name: Claude Next\n    context_length: 500000\n"
+	if err := os.WriteFile(path, []byte(override), 0o600); err != nil {
+		t.Fatalf("write override: %v", err)
+	}
+	SetModelCatalogSource("", path)
+	t.Cleanup(func() { SetModelCatalogSource("", "") })
+
+	models := GetClaudeModels()
+	if len(models) != len(base.Claude)+1 {
+		t.Fatalf("claude models = %d, want %d", len(models), len(base.Claude)+1)
+	}
+	byID := make(map[string]*ModelInfo, len(models))
+	for _, model := range models {
+		byID[model.ID] = model
+	}
+	if byID[existing].DisplayName != "Overridden" {
+		t.Fatalf("%s display name = %q", existing, byID[existing].DisplayName)
+	}
+	if next := byID["claude-next"]; next == nil || next.ContextLength != 500000 {
+		t.Fatalf("claude-next = %+v", next)
+	}
+	if base.Claude[0].DisplayName == "Overridden" {
+		t.Fatal("override mutated the base catalog")
+	}
+}
@@ -13,6 +13,10 @@ const (
 	xaiBuiltinImageQualityModelID   = "grok-imagine-image-quality"
 	xaiBuiltinVideoModelID          = "grok-imagine-video"
 	xaiBuiltinVideo15PreviewModelID = "grok-imagine-video-1.5-preview"
+	geminiBuiltinEmbeddingModelID   = "gemini-embedding-001"
+	geminiBuiltinImagenModelID      = "imagen-4.0-generate-001"
+	geminiBuiltinImagenFastModelID  = "imagen-4.0-fast-generate-001"
+	geminiBuiltinImagenUltraModelID = "imagen-4.0-ultra-generate-001"
 )
 
 // staticModelsJSON mirrors the top-level structure of models.json.
@@ -37,7 +41,7 @@ func GetClaudeModels() []*ModelInfo {
 
 // GetGeminiModels returns the standard Gemini model definitions.
 func GetGeminiModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Gemini)
+	return WithGeminiBuiltins(cloneModelInfos(getModels().Gemini))
 }
 
 // GetGeminiVertexModels returns Gemini model definitions for Vertex AI.
@@ -110,6 +114,31 @@ func GetXAIModels() []*ModelInfo {
 	return WithXAIBuiltins(cloneModelInfos(getModels().XAI))
 }
 
+// GetBedrockModels returns the built-in AWS Bedrock model definitions.
+func GetBedrockModels() []*ModelInfo {
+	return bedrockModelInfos()
+}
+
+// GetAzureOpenAIModels returns the built-in Azure OpenAI model definitions.
+func GetAzureOpenAIModels() []*ModelInfo {
+	return azureOpenAIModelInfos()
+}
+
+// GetMistralModels returns the built-in Mistral AI model definitions.
+func GetMistralModels() []*ModelInfo {
+	return mistralModelInfos()
+}
+
+// GetDeepSeekModels returns the built-in DeepSeek model definitions.
+func GetDeepSeekModels() []*ModelInfo {
+	return deepSeekModelInfos()
+}
+
+// GetCohereModels returns the built-in Cohere model definitions.
+func GetCohereModels() []*ModelInfo {
+	return cohereModelInfos()
+}
+
 // WithCodexBuiltins injects hard-coded Codex-only model definitions that should
 // not depend on remote models.json updates. Built-ins replace any matching IDs
 // already present in the provided slice.
@@ -123,6 +152,17 @@ func WithXAIBuiltins(models []*ModelInfo) []*ModelInfo {
 	return upsertModelInfos(models, xaiBuiltinImageModelInfo(), xaiBuiltinImageQualityModelInfo(), xaiBuiltinVideoModelInfo(), xaiBuiltinVideo15PreviewModelInfo())
 }
 
+// WithGeminiBuiltins injects hard-coded Gemini API embedding and Imagen model definitions
+// that should not depend on remote models.json updates.
+func WithGeminiBuiltins(models []*ModelInfo) []*ModelInfo {
+	return upsertModelInfos(models,
+		geminiBuiltinEmbeddingModelInfo(),
+		geminiBuiltinImagenModelInfo(geminiBuiltinImagenModelID, "Imagen 4"),
+		geminiBuiltinImagenModelInfo(geminiBuiltinImagenFastModelID, "Imagen 4 Fast"),
+		geminiBuiltinImagenModelInfo(geminiBuiltinImagenUltraModelID, "Imagen 4 Ultra"),
+	)
+}
+
 func normalizeAntigravityCapabilityModelID(modelID string) string {
 	modelID = strings.ToLower(strings.TrimSpace(modelID))
 	if open := strings.LastIndex(modelID, "("); open >= 0 && strings.HasSuffix(modelID, ")") {
@@ -207,6 +247,38 @@ func xaiBuiltinVideo15PreviewModelInfo() *ModelInfo {
 	}
 }
 
+func geminiBuiltinEmbeddingModelInfo() *ModelInfo {
+	return &ModelInfo{
+		ID:                         geminiBuiltinEmbeddingModelID,
+		Object:                     "model",
+		Created:                    1735689600, // 2025-01-01
+		OwnedBy:                    "google",
+		Type:                       "gemini",
+		DisplayName:                "Gemini Embedding 001",
+		Name:                       "models/" + geminiBuiltinEmbeddingModelID,
+		Description:                "Gemini text embedding model.",
+		InputTokenLimit:            2048,
+		SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+		SupportedEndpoints:         []string{EndpointEmbeddings},
+	}
+}
+
+func geminiBuiltinImagenModelInfo(id, displayName string) *ModelInfo {
+	return &ModelInfo{
+		ID:                         id,
+		Object:                     "model",
+		Created:                    1735689600, // 2025-01-01
+		OwnedBy:                    "google",
+		Type:                       "gemini",
+		DisplayName:                displayName,
+		Name:                       "models/" + id,
+		Description:                "Gemini API Imagen image generation model.",
+		SupportedGenerationMethods: []string{"predict"},
+		SupportedOutputModalities:  []string{"IMAGE"},
+		SupportedEndpoints:         []string{EndpointImagesGenerations},
+	}
+}
+
 func upsertModelInfos(models []*ModelInfo, extras ...*ModelInfo) []*ModelInfo {
 	if len(extras) == 0 {
 		return models
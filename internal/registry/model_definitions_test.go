@@ -81,3 +81,41 @@ func TestAntigravityWebSearchModelForRequiresRequestedModelCapability(t *testing
 		t.Fatalf("unknown model should not get Antigravity web search model, got %q", got)
 	}
 }
+
+func TestGetGeminiModelsIncludesEmbeddingBuiltin(t *testing.T) {
+	var found *ModelInfo
+	for _, model := range GetGeminiModels() {
+		if model.ID == geminiBuiltinEmbeddingModelID {
+			found = model
+		}
+	}
+	if found == nil {
+		t.Fatalf("%s missing from Gemini models", geminiBuiltinEmbeddingModelID)
+	}
+	if !found.SupportsEndpoint(EndpointEmbeddings) {
+		t.Fatalf("SupportedEndpoints = %v, want %s", found.SupportedEndpoints, EndpointEmbeddings)
+	}
+	if found.SupportsEndpoint("/v1/chat/completions") {
+		t.Fatal("embedding model reported chat completions support")
+	}
+	var chat ModelInfo
+	if !chat.SupportsEndpoint(EndpointEmbeddings) {
+		t.Fatal("model without SupportedEndpoints should not be restricted")
+	}
+}
+
+func TestGetGeminiModelsIncludesImagenBuiltins(t *testing.T) {
+	found := make(map[string]*ModelInfo)
+	for _, model := range GetGeminiModels() {
+		found[model.ID] = model
+	}
+	for _, id := range []string{geminiBuiltinImagenModelID, geminiBuiltinImagenFastModelID, geminiBuiltinImagenUltraModelID} {
+		model := found[id]
+		if model == nil {
+			t.Fatalf("%s missing from Gemini models", id)
+		}
+		if !model.SupportsEndpoint(EndpointImagesGenerations) || model.SupportsEndpoint(EndpointEmbeddings) {
+			t.Fatalf("%s SupportedEndpoints = %v, want only %s", id, model.SupportedEndpoints, EndpointImagesGenerations)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelPricing holds model token prices in USD per million tokens.
+// Cache prices fall back to the input price when unset.
+type ModelPricing struct {
+	InputPerMillion      float64 `json:"input_per_million,omitempty"`
+	OutputPerMillion     float64 `json:"output_per_million,omitempty"`
+	CacheReadPerMillion  float64 `json:"cache_read_per_million,omitempty"`
+	CacheWritePerMillion float64 `json:"cache_write_per_million,omitempty"`
+}
+
+// EstimateCost returns the USD cost of the given token counts.
+func (p *ModelPricing) EstimateCost(uncachedInput, cacheRead, cacheWrite, output int64) float64 {
+	if p == nil {
+		return 0
+	}
+	cacheReadPrice := p.CacheReadPerMillion
+	if cacheReadPrice == 0 {
+		cacheReadPrice = p.InputPerMillion
+	}
+	cacheWritePrice := p.CacheWritePerMillion
+	if cacheWritePrice == 0 {
+		cacheWritePrice = p.InputPerMillion
+	}
+	cost := float64(uncachedInput)*p.InputPerMillion +
+		float64(cacheRead)*cacheReadPrice +
+		float64(cacheWrite)*cacheWritePrice +
+		float64(output)*p.OutputPerMillion
+	return cost / 1_000_000
+}
+
+var (
+	pricingOverridesMu sync.RWMutex
+	pricingOverrides   map[string]ModelPricing
+)
+
+// SetModelPricingOverrides replaces the configured per-model prices. Keys are model IDs
+// matched case-insensitively; overrides take precedence over models.json pricing.
+func SetModelPricingOverrides(overrides map[string]ModelPricing) {
+	normalized := make(map[string]ModelPricing, len(overrides))
+	for model, pricing := range overrides {
+		if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+			normalized[model] = pricing
+		}
+	}
+	pricingOverridesMu.Lock()
+	pricingOverrides = normalized
+	pricingOverridesMu.Unlock()
+}
+
+// LookupModelPricing returns the configured or catalog pricing for a model, or nil when unpriced.
+func LookupModelPricing(modelID string, provider ...string) *ModelPricing {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil
+	}
+	pricingOverridesMu.RLock()
+	override, ok := pricingOverrides[strings.ToLower(modelID)]
+	pricingOverridesMu.RUnlock()
+	if ok {
+		return &override
+	}
+	if info := LookupModelInfo(modelID, provider...); info != nil && info.Pricing != nil {
+		return info.Pricing
+	}
+	return nil
+}
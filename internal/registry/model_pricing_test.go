@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestModelPricingEstimateCostFallsBackToInputPriceForCache(t *testing.T) {
+	pricing := &ModelPricing{InputPerMillion: 2, OutputPerMillion: 8, CacheReadPerMillion: 0.5}
+
+	got := pricing.EstimateCost(1_000_000, 2_000_000, 1_000_000, 500_000)
+	want := 2.0 + 1.0 + 2.0 + 4.0
+	if math.Abs(got-want) > 1e-9 {
+		t.Fatalf("EstimateCost() = %v, want %v", got, want)
+	}
+
+	var unpriced *ModelPricing
+	if cost := unpriced.EstimateCost(10, 10, 10, 10); cost != 0 {
+		t.Fatalf("nil pricing cost = %v, want 0", cost)
+	}
+}
+
+func TestLookupModelPricingPrefersOverrides(t *testing.T) {
+	t.Cleanup(func() { SetModelPricingOverrides(nil) })
+
+	SetModelPricingOverrides(map[string]ModelPricing{" Custom-Model ": {InputPerMillion: 1, OutputPerMillion: 3}})
+
+	pricing := LookupModelPricing("custom-model")
+	if pricing == nil || pricing.OutputPerMillion != 3 {
+		t.Fatalf("LookupModelPricing() = %+v, want configured override", pricing)
+	}
+	if got := LookupModelPricing("unknown-model-without-pricing"); got != nil {
+		t.Fatalf("LookupModelPricing(unknown) = %+v, want nil", got)
+	}
+
+	SetModelPricingOverrides(nil)
+	if got := LookupModelPricing("custom-model"); got != nil {
+		t.Fatalf("LookupModelPricing() after reset = %+v, want nil", got)
+	}
+}
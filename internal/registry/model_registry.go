@@ -59,6 +59,9 @@ type ModelInfo struct {
 	SupportedInputModalities []string `json:"supportedInputModalities,omitempty"`
 	// SupportedOutputModalities lists supported output modalities (e.g., TEXT, IMAGE)
 	SupportedOutputModalities []string `json:"supportedOutputModalities,omitempty"`
+	// SupportedEndpoints lists the OpenAI-style endpoints the model serves (e.g. "/v1/embeddings").
+	// Empty means the model serves the regular generation endpoints.
+	SupportedEndpoints []string `json:"supported_endpoints,omitempty"`
 	// SupportsWebSearch indicates this Antigravity model is listed by
 	// fetchAvailableModels.webSearchModelIds and can execute native googleSearch.
 	SupportsWebSearch bool `json:"supports_web_search,omitempty"`
@@ -70,12 +73,52 @@ type ModelInfo struct {
 	// Config holds model-specific runtime overrides loaded from models.json.
 	Config *ModelConfig `json:"config,omitempty"`
 
+	// Pricing holds per-token prices used for usage cost estimation.
+	Pricing *ModelPricing `json:"pricing,omitempty"`
+
+	// Capabilities declares input features and limits that handlers validate requests against.
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
+
 	// UserDefined indicates this model was defined through config file's models[]
 	// array (e.g., openai-compatibility.*.models[], *-api-key.models[]).
 	// UserDefined models have thinking configuration passed through without validation.
 	UserDefined bool `json:"-"`
 }
 
+// EndpointEmbeddings is the SupportedEndpoints entry of models served by /v1/embeddings.
+const EndpointEmbeddings = "/v1/embeddings"
+
+// EndpointImagesGenerations is the SupportedEndpoints entry of models whose provider translates
+// /v1/images/generations requests, such as Gemini Imagen.
+const EndpointImagesGenerations = "/v1/images/generations"
+
+// Audio endpoints. Unlike embeddings, models must list them explicitly to be served.
+const (
+	EndpointAudioTranscriptions = "/v1/audio/transcriptions"
+	EndpointAudioSpeech         = "/v1/audio/speech"
+)
+
+// EndpointChatCompletions is listed next to opt-in endpoints by models that also chat.
+const EndpointChatCompletions = "/v1/chat/completions"
+
+// EndpointFIMCompletions is the SupportedEndpoints entry of fill-in-the-middle models such as
+// Codestral. Like audio, models must list it explicitly to be served.
+const EndpointFIMCompletions = "/v1/fim/completions"
+
+// SupportsEndpoint reports whether the model serves endpoint. Models without
+// SupportedEndpoints are not restricted.
+func (m *ModelInfo) SupportsEndpoint(endpoint string) bool {
+	if m == nil || len(m.SupportedEndpoints) == 0 {
+		return true
+	}
+	for _, supported := range m.SupportedEndpoints {
+		if supported == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
 // ModelConfig holds optional runtime overrides for a model definition.
 type ModelConfig struct {
 	// OverrideHeader forces upstream request headers when non-empty.
@@ -579,6 +622,9 @@ func cloneModelInfo(model *ModelInfo) *ModelInfo {
 	if len(model.SupportedOutputModalities) > 0 {
 		copyModel.SupportedOutputModalities = append([]string(nil), model.SupportedOutputModalities...)
 	}
+	if len(model.SupportedEndpoints) > 0 {
+		copyModel.SupportedEndpoints = append([]string(nil), model.SupportedEndpoints...)
+	}
 	if model.Thinking != nil {
 		copyThinking := *model.Thinking
 		if len(model.Thinking.Levels) > 0 {
@@ -586,6 +632,14 @@ func cloneModelInfo(model *ModelInfo) *ModelInfo {
 		}
 		copyModel.Thinking = &copyThinking
 	}
+	if model.Pricing != nil {
+		copyPricing := *model.Pricing
+		copyModel.Pricing = &copyPricing
+	}
+	if model.Capabilities != nil {
+		copyCapabilities := *model.Capabilities
+		copyModel.Capabilities = &copyCapabilities
+	}
 	if model.Config != nil {
 		copyConfig := *model.Config
 		if len(model.Config.OverrideHeader) > 0 {
@@ -1193,6 +1247,9 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		if len(model.SupportedParameters) > 0 {
 			result["supported_parameters"] = append([]string(nil), model.SupportedParameters...)
 		}
+		if len(model.SupportedEndpoints) > 0 {
+			result["supported_endpoints"] = append([]string(nil), model.SupportedEndpoints...)
+		}
 		return result
 
 	case "claude":
@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler/jobs"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -27,8 +30,11 @@ var modelsURLs = []string{
 //go:embed models/models.json
 var embeddedModelsJSON []byte
 
+// modelStore holds the catalog loaded from the embed or a remote URL (base) and the
+// effective catalog with the local override applied (data).
 type modelStore struct {
 	mu   sync.RWMutex
+	base *staticModelsJSON
 	data *staticModelsJSON
 }
 
@@ -36,6 +42,9 @@ var modelsCatalogStore = &modelStore{}
 
 var updaterOnce sync.Once
 
+// updaterStarted reports whether remote model updates are enabled for this process.
+var updaterStarted atomic.Bool
+
 // ModelRefreshCallback is invoked when startup or periodic model refresh detects changes.
 // changedProviders contains the provider names whose model definitions changed.
 type ModelRefreshCallback func(changedProviders []string)
@@ -76,6 +85,7 @@ func init() {
 // Safe to call multiple times; only one updater will run.
 func StartModelsUpdater(ctx context.Context) {
 	updaterOnce.Do(func() {
+		updaterStarted.Store(true)
 		go runModelsUpdater(ctx)
 	})
 }
@@ -94,7 +104,9 @@ func periodicRefresh(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			tryPeriodicRefresh(ctx)
+			if !jobs.Scheduled(jobs.ModelRefresh) {
+				tryPeriodicRefresh(ctx)
+			}
 		}
 	}
 }
@@ -112,6 +124,17 @@ func tryStartupRefresh(ctx context.Context) {
 	tryRefreshModels(ctx, "startup model refresh")
 }
 
+// RefreshModels fetches the remote model catalog once, outside the periodic updater,
+// and notifies the refresh callback about changed providers. It fails when remote model
+// updates are disabled, e.g. in local-model or home mode.
+func RefreshModels(ctx context.Context) error {
+	if !updaterStarted.Load() {
+		return fmt.Errorf("registry: remote model updates are disabled")
+	}
+	tryRefreshModels(ctx, "scheduled model refresh")
+	return nil
+}
+
 func tryRefreshModels(ctx context.Context, label string) {
 	oldData := getModels()
 
@@ -120,13 +143,19 @@ func tryRefreshModels(ctx context.Context, label string) {
 		log.Warnf("%s: fetch failed from all URLs, keeping current data", label)
 		return
 	}
+	if !slices.Contains(catalogURLs(), url) {
+		log.Infof("%s: catalog source changed during fetch from %s, discarding result", label, url)
+		return
+	}
 
 	// Detect changes before updating store.
-	changed := detectChangedProviders(oldData, parsed)
+	merged := applyModelCatalogOverride(parsed, currentModelCatalogOverride())
+	changed := detectChangedProviders(oldData, merged)
 
 	// Update store with new data regardless.
 	modelsCatalogStore.mu.Lock()
-	modelsCatalogStore.data = parsed
+	modelsCatalogStore.base = parsed
+	modelsCatalogStore.data = merged
 	modelsCatalogStore.mu.Unlock()
 
 	if len(changed) == 0 {
@@ -140,9 +169,11 @@ func tryRefreshModels(ctx context.Context, label string) {
 
 // fetchModelsFromRemote tries all remote URLs and returns the parsed model catalog
 // along with the URL it was fetched from. Returns (nil, "") if all fetches fail.
+// Requests carry the ETag of the previous fetch, and a 304 reuses the cached catalog.
 func fetchModelsFromRemote(ctx context.Context) (*staticModelsJSON, string) {
 	client := &http.Client{Timeout: modelsFetchTimeout}
-	for _, url := range modelsURLs {
+	for _, url := range catalogURLs() {
+		cached := cachedRemoteCatalog(url)
 		reqCtx, cancel := context.WithTimeout(ctx, modelsFetchTimeout)
 		req, err := http.NewRequestWithContext(reqCtx, "GET", url, nil)
 		if err != nil {
@@ -150,6 +181,9 @@ func fetchModelsFromRemote(ctx context.Context) (*staticModelsJSON, string) {
 			log.Debugf("models fetch request creation failed for %s: %v", url, err)
 			continue
 		}
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
 
 		resp, err := client.Do(req)
 		if err != nil {
@@ -158,6 +192,12 @@ func fetchModelsFromRemote(ctx context.Context) (*staticModelsJSON, string) {
 			continue
 		}
 
+		if resp.StatusCode == http.StatusNotModified && cached.data != nil {
+			resp.Body.Close()
+			cancel()
+			log.Debugf("models catalog not modified at %s", url)
+			return cached.data, url
+		}
 		if resp.StatusCode != 200 {
 			resp.Body.Close()
 			cancel()
@@ -174,17 +214,18 @@ func fetchModelsFromRemote(ctx context.Context) (*staticModelsJSON, string) {
 			continue
 		}
 
-		var parsed staticModelsJSON
-		if err := json.Unmarshal(data, &parsed); err != nil {
+		parsed, err := decodeModelsCatalog(data)
+		if err != nil {
 			log.Warnf("models parse failed from %s: %v", url, err)
 			continue
 		}
-		if err := validateModelsCatalog(&parsed); err != nil {
+		if err := validateModelsCatalog(parsed); err != nil {
 			log.Warnf("models validate failed from %s: %v", url, err)
 			continue
 		}
+		storeRemoteCatalog(url, resp.Header.Get("ETag"), parsed)
 
-		return &parsed, url
+		return parsed, url
 	}
 	return nil, ""
 }
@@ -295,16 +336,17 @@ func mergeProviderNames(existing, incoming []string) []string {
 }
 
 func loadModelsFromBytes(data []byte, source string) error {
-	var parsed staticModelsJSON
-	if err := json.Unmarshal(data, &parsed); err != nil {
-		return fmt.Errorf("%s: decode models catalog: %w", source, err)
+	parsed, err := decodeModelsCatalog(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
 	}
-	if err := validateModelsCatalog(&parsed); err != nil {
+	if err := validateModelsCatalog(parsed); err != nil {
 		return fmt.Errorf("%s: validate models catalog: %w", source, err)
 	}
 
 	modelsCatalogStore.mu.Lock()
-	modelsCatalogStore.data = &parsed
+	modelsCatalogStore.base = parsed
+	modelsCatalogStore.data = applyModelCatalogOverride(parsed, currentModelCatalogOverride())
 	modelsCatalogStore.mu.Unlock()
 	return nil
 }
@@ -0,0 +1,348 @@
+// Package requestqueue schedules requests in front of upstream execution by priority class.
+// Interactive requests are always admitted before batch requests, and within a class waiting
+// client API keys take turns so one busy key cannot monopolize the queue.
+package requestqueue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// Class is a request priority class. Lower values are admitted first.
+type Class int
+
+const (
+	// Interactive is the class of latency-sensitive requests such as CLI sessions.
+	Interactive Class = iota
+	// Batch is the class of background requests that only use spare capacity.
+	Batch
+
+	classCount
+)
+
+// String returns the configuration name of the class.
+func (c Class) String() string {
+	if c == Batch {
+		return config.RequestPriorityBatch
+	}
+	return config.RequestPriorityInteractive
+}
+
+// fullRetryAfter is the hint returned when a priority class has no room left to wait.
+const fullRetryAfter = time.Second
+
+// Error reports a request the scheduler did not admit.
+type Error struct {
+	// Class is the priority class of the rejected request.
+	Class Class
+	// Timeout is set when the request waited max-wait without getting a slot; otherwise the
+	// class queue was full.
+	Timeout bool
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e != nil && e.Timeout {
+		return "request queue wait exceeded for " + e.Class.String() + " requests"
+	}
+	return "request queue is full for " + e.Class.String() + " requests"
+}
+
+// StatusCode returns 503 for timed out requests and 429 for a full queue.
+func (e *Error) StatusCode() int {
+	if e != nil && e.Timeout {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusTooManyRequests
+}
+
+// RetryAfter returns the wait suggested to the client.
+func (e *Error) RetryAfter() time.Duration { return fullRetryAfter }
+
+type waiter struct {
+	class   Class
+	apiKey  string
+	ready   chan struct{}
+	granted bool
+}
+
+// classQueue holds the waiting requests of one class as per-key FIFO lists visited round-robin.
+type classQueue struct {
+	order   []string
+	waiting map[string][]*waiter
+	depth   int
+}
+
+func (q *classQueue) push(w *waiter) {
+	if q.waiting == nil {
+		q.waiting = make(map[string][]*waiter)
+	}
+	if len(q.waiting[w.apiKey]) == 0 {
+		q.order = append(q.order, w.apiKey)
+	}
+	q.waiting[w.apiKey] = append(q.waiting[w.apiKey], w)
+	q.depth++
+}
+
+// pop removes the head request of the next key in turn; the key moves to the back of the order.
+func (q *classQueue) pop() *waiter {
+	if q.depth == 0 {
+		return nil
+	}
+	key := q.order[0]
+	q.order = q.order[1:]
+	list := q.waiting[key]
+	w := list[0]
+	if len(list) == 1 {
+		delete(q.waiting, key)
+	} else {
+		q.waiting[key] = list[1:]
+		q.order = append(q.order, key)
+	}
+	q.depth--
+	return w
+}
+
+func (q *classQueue) remove(w *waiter) {
+	list := q.waiting[w.apiKey]
+	for i, candidate := range list {
+		if candidate != w {
+			continue
+		}
+		list = append(list[:i:i], list[i+1:]...)
+		q.depth--
+		if len(list) > 0 {
+			q.waiting[w.apiKey] = list
+			return
+		}
+		delete(q.waiting, w.apiKey)
+		for j, key := range q.order {
+			if key == w.apiKey {
+				q.order = append(q.order[:j:j], q.order[j+1:]...)
+				break
+			}
+		}
+		return
+	}
+}
+
+// Queue admits requests up to a concurrency limit and queues the rest by priority class.
+// A nil Queue admits every request.
+type Queue struct {
+	mu sync.Mutex
+
+	cfg          config.RequestQueueConfig
+	enabled      bool
+	defaultClass Class
+	keys         map[string]Class
+
+	running [classCount]int
+	queues  [classCount]classQueue
+}
+
+// NewQueue creates a scheduler using the provided configuration.
+func NewQueue(cfg config.RequestQueueConfig) *Queue {
+	q := &Queue{}
+	q.Update(cfg)
+	return q
+}
+
+// Update replaces the scheduler configuration. Running and waiting requests are kept, and
+// waiting requests are admitted at once if the new limits allow it.
+func (q *Queue) Update(cfg config.RequestQueueConfig) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.keys != nil && reflect.DeepEqual(q.cfg, cfg) {
+		return
+	}
+	q.cfg = cfg
+	q.enabled = cfg.Enable && cfg.MaxConcurrent > 0
+	q.defaultClass = classFromName(cfg.DefaultPriority)
+	q.keys = make(map[string]Class, len(cfg.Keys))
+	for _, entry := range cfg.Keys {
+		if key := strings.TrimSpace(entry.APIKey); key != "" {
+			q.keys[key] = classFromName(entry.Priority)
+		}
+	}
+	q.dispatchLocked()
+}
+
+// ClassOf returns the priority class of a client API key.
+func (q *Queue) ClassOf(apiKey string) Class {
+	if q == nil {
+		return Interactive
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.classOfLocked(apiKey)
+}
+
+// Acquire waits for an execution slot for a request of the client API key. The returned
+// release func frees the slot and is idempotent. Acquire fails with *Error when the class
+// queue is full or max-wait elapses, and with the context error when ctx ends first.
+func (q *Queue) Acquire(ctx context.Context, apiKey string) (func(), error) {
+	return q.acquire(ctx, apiKey, nil)
+}
+
+// AcquireClass waits like Acquire for a slot in class instead of the key's own class. Background
+// work such as batch lines uses it to only take spare capacity.
+func (q *Queue) AcquireClass(ctx context.Context, apiKey string, class Class) (func(), error) {
+	return q.acquire(ctx, apiKey, &class)
+}
+
+func (q *Queue) acquire(ctx context.Context, apiKey string, forced *Class) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+	q.mu.Lock()
+	if !q.enabled {
+		q.mu.Unlock()
+		return func() {}, nil
+	}
+	class := q.classOfLocked(apiKey)
+	if forced != nil && *forced >= 0 && *forced < classCount {
+		class = *forced
+	}
+	if q.queues[class].depth == 0 && q.canRunLocked(class) {
+		q.running[class]++
+		q.mu.Unlock()
+		return q.releaser(class), nil
+	}
+	if q.queues[class].depth >= q.maxDepthLocked() {
+		q.mu.Unlock()
+		return nil, &Error{Class: class}
+	}
+	w := &waiter{class: class, apiKey: apiKey, ready: make(chan struct{})}
+	q.queues[class].push(w)
+	maxWait := q.cfg.MaxWaitDuration()
+	q.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var errWait error
+	select {
+	case <-w.ready:
+		return q.releaser(class), nil
+	case <-ctx.Done():
+		errWait = ctx.Err()
+	case <-timeout:
+		errWait = &Error{Class: class, Timeout: true}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if w.granted {
+		// The slot was granted while giving up; hand it to the next request.
+		q.running[class]--
+		q.dispatchLocked()
+	} else {
+		q.queues[class].remove(w)
+	}
+	if errWait == nil {
+		errWait = errors.New("request queue wait aborted")
+	}
+	return nil, errWait
+}
+
+// Waiting returns the number of requests waiting in a class.
+func (q *Queue) Waiting(class Class) int {
+	if q == nil || class < 0 || class >= classCount {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.queues[class].depth
+}
+
+// Running returns the number of executing requests of a class.
+func (q *Queue) Running(class Class) int {
+	if q == nil || class < 0 || class >= classCount {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running[class]
+}
+
+func (q *Queue) releaser(class Class) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.running[class]--
+			q.dispatchLocked()
+		})
+	}
+}
+
+func (q *Queue) classOfLocked(apiKey string) Class {
+	if class, ok := q.keys[strings.TrimSpace(apiKey)]; ok {
+		return class
+	}
+	return q.defaultClass
+}
+
+func (q *Queue) maxDepthLocked() int {
+	if q.cfg.MaxDepth > 0 {
+		return q.cfg.MaxDepth
+	}
+	return config.DefaultRequestQueueMaxDepth
+}
+
+// canRunLocked reports whether a request of class may start now. Batch requests never start
+// while interactive requests wait, and stay within batch-max-concurrent.
+func (q *Queue) canRunLocked(class Class) bool {
+	if !q.enabled {
+		return true
+	}
+	total := 0
+	for _, running := range q.running {
+		total += running
+	}
+	if total >= q.cfg.MaxConcurrent {
+		return false
+	}
+	if class == Batch {
+		if q.queues[Interactive].depth > 0 {
+			return false
+		}
+		if limit := q.cfg.BatchMaxConcurrent; limit > 0 && q.running[Batch] >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatchLocked admits waiting requests, highest class first, while slots are free.
+func (q *Queue) dispatchLocked() {
+	for class := Interactive; class < classCount; class++ {
+		for q.queues[class].depth > 0 && q.canRunLocked(class) {
+			w := q.queues[class].pop()
+			w.granted = true
+			q.running[class]++
+			close(w.ready)
+		}
+	}
+}
+
+func classFromName(name string) Class {
+	if strings.EqualFold(strings.TrimSpace(name), config.RequestPriorityBatch) {
+		return Batch
+	}
+	return Interactive
+}
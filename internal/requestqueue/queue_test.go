@@ -0,0 +1,145 @@
+package requestqueue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newTestQueue(t *testing.T, cfg config.RequestQueueConfig) *Queue {
+	t.Helper()
+	cfg.Enable = true
+	wrapped := &config.Config{RequestQueue: cfg}
+	wrapped.SanitizeRequestQueue()
+	return NewQueue(wrapped.RequestQueue)
+}
+
+// acquireAsync starts an Acquire and reports the order in which waiters are admitted.
+func acquireAsync(t *testing.T, q *Queue, ctx context.Context, apiKey string, admitted chan<- string) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	before := q.Waiting(q.ClassOf(apiKey))
+	go func() {
+		release, err := q.Acquire(ctx, apiKey)
+		if err == nil {
+			admitted <- apiKey
+			release()
+		}
+		done <- err
+	}()
+	waitFor(t, func() bool { return q.Waiting(q.ClassOf(apiKey)) == before+1 })
+	return done
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueue_InteractiveBeforeBatchAndFairAcrossKeys(t *testing.T) {
+	q := newTestQueue(t, config.RequestQueueConfig{
+		MaxConcurrent: 1,
+		Keys:          []config.RequestQueueKey{{APIKey: "batch-a", Priority: "batch"}},
+	})
+	release, err := q.Acquire(context.Background(), "cli")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	admitted := make(chan string, 4)
+	// Serialize admissions: each admitted request releases before the next one is granted.
+	acquireAsync(t, q, context.Background(), "batch-a", admitted)
+	acquireAsync(t, q, context.Background(), "alice", admitted)
+	acquireAsync(t, q, context.Background(), "alice", admitted)
+	acquireAsync(t, q, context.Background(), "bob", admitted)
+	release()
+
+	var order []string
+	for range 4 {
+		order = append(order, <-admitted)
+	}
+	want := []string{"alice", "bob", "alice", "batch-a"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("admission order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestQueue_BatchMaxConcurrentKeepsSlotsForInteractive(t *testing.T) {
+	q := newTestQueue(t, config.RequestQueueConfig{
+		MaxConcurrent:      2,
+		BatchMaxConcurrent: 1,
+		DefaultPriority:    "batch",
+		Keys:               []config.RequestQueueKey{{APIKey: "cli", Priority: "interactive"}},
+	})
+	if _, err := q.Acquire(context.Background(), "job"); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.Acquire(ctx, "job"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second batch request error = %v, want to wait for a batch slot", err)
+	}
+	if q.Waiting(Batch) != 0 {
+		t.Fatalf("abandoned waiter still queued")
+	}
+	if _, err := q.Acquire(context.Background(), "cli"); err != nil {
+		t.Fatalf("interactive request blocked by batch: %v", err)
+	}
+}
+
+func TestQueue_AcquireClassUsesBatchSlots(t *testing.T) {
+	q := newTestQueue(t, config.RequestQueueConfig{MaxConcurrent: 2, BatchMaxConcurrent: 1})
+	if _, err := q.AcquireClass(context.Background(), "cli", Batch); err != nil {
+		t.Fatalf("AcquireClass() error = %v", err)
+	}
+	if q.Running(Batch) != 1 || q.Running(Interactive) != 0 {
+		t.Fatalf("running = (interactive %d, batch %d), want the slot counted as batch", q.Running(Interactive), q.Running(Batch))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.AcquireClass(ctx, "cli", Batch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second batch request error = %v, want to wait for a batch slot", err)
+	}
+}
+
+func TestQueue_DepthAndWaitLimits(t *testing.T) {
+	q := newTestQueue(t, config.RequestQueueConfig{MaxConcurrent: 1, MaxDepth: 1, MaxWait: "30ms"})
+	release, err := q.Acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	defer release()
+
+	done := acquireAsync(t, q, context.Background(), "b", make(chan string, 1))
+	var errQueue *Error
+	if _, err := q.Acquire(context.Background(), "c"); !errors.As(err, &errQueue) || errQueue.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("full queue error = %v, want 429", err)
+	}
+	if err := <-done; !errors.As(err, &errQueue) || !errQueue.Timeout || errQueue.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("wait error = %v, want 503 timeout", err)
+	}
+}
+
+func TestQueue_DisabledAdmitsEverything(t *testing.T) {
+	q := NewQueue(config.RequestQueueConfig{MaxConcurrent: 1})
+	for range 3 {
+		if _, err := q.Acquire(context.Background(), "a"); err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+	}
+	if q.Running(Interactive) != 0 {
+		t.Fatal("disabled queue tracked running requests")
+	}
+}
@@ -0,0 +1,260 @@
+// Package responsecache stores responses to deterministic requests in an in-memory LRU or in Redis.
+package responsecache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry is one cached response.
+type Entry struct {
+	StatusCode  int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+type backend interface {
+	get(ctx context.Context, key string) (*Entry, bool)
+	set(ctx context.Context, key string, entry *Entry, ttl time.Duration)
+	close()
+}
+
+// Cache serves cached responses according to the response-cache configuration.
+// A nil Cache is disabled.
+type Cache struct {
+	mu sync.RWMutex
+
+	cfg       config.ResponseCacheConfig
+	enabled   bool
+	ttl       time.Duration
+	modelsTTL time.Duration
+	backend   backend
+
+	clock clock.Clock
+}
+
+// New creates a cache using the provided configuration.
+func New(cfg config.ResponseCacheConfig) *Cache {
+	c := &Cache{clock: clock.Default()}
+	c.Update(cfg)
+	return c
+}
+
+// Update replaces the cache configuration. Cached entries are dropped only when the configuration changes.
+func (c *Cache) Update(cfg config.ResponseCacheConfig) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backend != nil && c.cfg == cfg {
+		return
+	}
+	if c.backend != nil {
+		c.backend.close()
+	}
+
+	c.cfg = cfg
+	c.enabled = cfg.Enable
+	c.ttl = cfg.TTLDuration()
+	c.modelsTTL = cfg.ModelsTTLDuration()
+	c.backend = c.newBackend(cfg)
+}
+
+func (c *Cache) newBackend(cfg config.ResponseCacheConfig) backend {
+	if cfg.Enable && cfg.RedisURL != "" {
+		options, errParse := redis.ParseURL(cfg.RedisURL)
+		if errParse == nil {
+			return &redisBackend{client: redis.NewClient(options), prefix: cfg.RedisPrefix}
+		}
+		log.Warnf("response-cache: invalid redis-url, falling back to memory: %v", errParse)
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = config.DefaultResponseCacheMaxEntries
+	}
+	return newMemoryBackend(maxEntries, c.clock.Now)
+}
+
+// Enabled reports whether responses should be looked up and stored.
+func (c *Cache) Enabled() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.enabled
+}
+
+// TTL returns how long completion responses are cached.
+func (c *Cache) TTL() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ttl
+}
+
+// ModelsTTL returns how long model list responses are cached.
+func (c *Cache) ModelsTTL() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.modelsTTL
+}
+
+// Get returns the unexpired entry stored under key.
+func (c *Cache) Get(ctx context.Context, key string) (*Entry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	b, enabled := c.backend, c.enabled
+	c.mu.RUnlock()
+	if !enabled || b == nil {
+		return nil, false
+	}
+	return b.get(ctx, key)
+}
+
+// Set stores entry under key for ttl.
+func (c *Cache) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) {
+	if c == nil || entry == nil || ttl <= 0 {
+		return
+	}
+	c.mu.RLock()
+	b, enabled := c.backend, c.enabled
+	c.mu.RUnlock()
+	if !enabled || b == nil {
+		return
+	}
+	b.set(ctx, key, entry, ttl)
+}
+
+// Close releases the backend connection.
+func (c *Cache) Close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.backend != nil {
+		c.backend.close()
+		c.backend = nil
+	}
+}
+
+// Key derives a cache key from the given parts.
+func Key(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+type memoryItem struct {
+	key       string
+	entry     *Entry
+	expiresAt time.Time
+}
+
+// memoryBackend is a fixed-size LRU with per-entry expiry.
+type memoryBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+	now        func() time.Time
+}
+
+func newMemoryBackend(maxEntries int, now func() time.Time) *memoryBackend {
+	return &memoryBackend{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		now:        now,
+	}
+}
+
+func (m *memoryBackend) get(_ context.Context, key string) (*Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*memoryItem)
+	if !m.now().Before(item.expiresAt) {
+		m.order.Remove(elem)
+		delete(m.items, key)
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return item.entry, true
+}
+
+func (m *memoryBackend) set(_ context.Context, key string, entry *Entry, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expiresAt := m.now().Add(ttl)
+	if elem, ok := m.items[key]; ok {
+		item := elem.Value.(*memoryItem)
+		item.entry = entry
+		item.expiresAt = expiresAt
+		m.order.MoveToFront(elem)
+		return
+	}
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, entry: entry, expiresAt: expiresAt})
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+func (m *memoryBackend) close() {}
+
+// redisBackend stores JSON-encoded entries with a Redis TTL.
+type redisBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+func (r *redisBackend) get(ctx context.Context, key string) (*Entry, bool) {
+	raw, errGet := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errGet != nil {
+		if !errors.Is(errGet, redis.Nil) {
+			log.Debugf("response-cache: redis get failed: %v", errGet)
+		}
+		return nil, false
+	}
+	var entry Entry
+	if errUnmarshal := json.Unmarshal(raw, &entry); errUnmarshal != nil {
+		log.Debugf("response-cache: discarding malformed entry: %v", errUnmarshal)
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (r *redisBackend) set(ctx context.Context, key string, entry *Entry, ttl time.Duration) {
+	raw, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		return
+	}
+	if errSet := r.client.Set(ctx, r.prefix+key, raw, ttl).Err(); errSet != nil {
+		log.Debugf("response-cache: redis set failed: %v", errSet)
+	}
+}
+
+func (r *redisBackend) close() {
+	if errClose := r.client.Close(); errClose != nil {
+		log.Debugf("response-cache: failed to close redis client: %v", errClose)
+	}
+}
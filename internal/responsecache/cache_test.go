@@ -0,0 +1,53 @@
+package responsecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestMemoryBackend_EvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	m := newMemoryBackend(2, func() time.Time { return now })
+	ctx := context.Background()
+
+	m.set(ctx, "a", &Entry{Body: []byte("a")}, time.Minute)
+	m.set(ctx, "b", &Entry{Body: []byte("b")}, time.Minute)
+	if _, ok := m.get(ctx, "a"); !ok {
+		t.Fatal("entry a missing")
+	}
+	m.set(ctx, "c", &Entry{Body: []byte("c")}, time.Minute)
+	if _, ok := m.get(ctx, "b"); ok {
+		t.Fatal("least recently used entry b was not evicted")
+	}
+	if _, ok := m.get(ctx, "a"); !ok {
+		t.Fatal("recently used entry a was evicted")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := m.get(ctx, "c"); ok {
+		t.Fatal("expired entry c was served")
+	}
+}
+
+func TestCache_UpdateDisablesAndDefaults(t *testing.T) {
+	c := New(config.ResponseCacheConfig{Enable: true, TTL: "30s"})
+	if c.TTL() != 30*time.Second || c.ModelsTTL() != 30*time.Second {
+		t.Fatalf("ttl = %v/%v, want 30s/30s", c.TTL(), c.ModelsTTL())
+	}
+	ctx := context.Background()
+	c.Set(ctx, "k", &Entry{StatusCode: 200, Body: []byte("{}")}, c.TTL())
+	if _, ok := c.Get(ctx, "k"); !ok {
+		t.Fatal("entry missing")
+	}
+
+	c.Update(config.ResponseCacheConfig{Enable: false, TTL: "30s"})
+	if c.Enabled() {
+		t.Fatal("cache still enabled")
+	}
+	if _, ok := c.Get(ctx, "k"); ok {
+		t.Fatal("disabled cache served an entry")
+	}
+}
@@ -0,0 +1,398 @@
+package responsestore
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sqlitedb"
+	log "github.com/sirupsen/logrus"
+)
+
+// redisTimeout bounds each Redis call.
+const redisTimeout = 5 * time.Second
+
+// replaceRunningScript replaces the stored response only while it is still running, so a
+// response cancelled by another instance is not overwritten.
+var replaceRunningScript = redis.NewScript(`
+local raw = redis.call('GET', KEYS[1])
+if not raw then
+  return 0
+end
+local status = cjson.decode(raw).status
+if status ~= 'queued' and status ~= 'in_progress' then
+  return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+type memoryItem struct {
+	response  Response
+	expiresAt time.Time
+}
+
+// memoryBackend keeps responses in an LRU. Running responses neither expire nor are evicted,
+// since they disappear with the process running them.
+type memoryBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List
+	keys       map[string][]byte
+	now        func() time.Time
+}
+
+func newMemoryBackend(maxEntries int, now func() time.Time) *memoryBackend {
+	return &memoryBackend{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		keys:       make(map[string][]byte),
+		now:        now,
+	}
+}
+
+func (m *memoryBackend) get(id string) (Response, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.items[id]
+	if !ok {
+		return Response{}, false
+	}
+	item := elem.Value.(*memoryItem)
+	if !running(item.response.Status) && !m.now().Before(item.expiresAt) {
+		m.removeLocked(id)
+		return Response{}, false
+	}
+	return item.response, true
+}
+
+func (m *memoryBackend) put(r Response, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.putLocked(r, ttl)
+}
+
+func (m *memoryBackend) replaceRunning(r Response, ttl time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.items[r.ID]
+	if !ok || !running(elem.Value.(*memoryItem).response.Status) {
+		return false
+	}
+	m.putLocked(r, ttl)
+	return true
+}
+
+func (m *memoryBackend) putLocked(r Response, ttl time.Duration) {
+	item := &memoryItem{response: r, expiresAt: m.now().Add(ttl)}
+	if elem, ok := m.items[r.ID]; ok {
+		elem.Value = item
+		m.order.MoveToFront(elem)
+	} else {
+		m.items[r.ID] = m.order.PushFront(item)
+	}
+	m.evict()
+}
+
+func (m *memoryBackend) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(id)
+}
+
+func (m *memoryBackend) removeLocked(id string) {
+	if elem, ok := m.items[id]; ok {
+		m.order.Remove(elem)
+		delete(m.items, id)
+	}
+}
+
+func (m *memoryBackend) resize(maxEntries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxEntries = maxEntries
+	m.evict()
+}
+
+// evict drops the oldest finished responses beyond maxEntries.
+func (m *memoryBackend) evict() {
+	for elem := m.order.Back(); elem != nil && m.order.Len() > m.maxEntries; {
+		prev := elem.Prev()
+		if r := elem.Value.(*memoryItem).response; !running(r.Status) {
+			m.removeLocked(r.ID)
+		}
+		elem = prev
+	}
+}
+
+func (m *memoryBackend) getKey(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.keys[name], nil
+}
+
+func (m *memoryBackend) addKey(name string, wrapped []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.keys[name]; ok {
+		return existing, nil
+	}
+	m.keys[name] = wrapped
+	return wrapped, nil
+}
+
+func (m *memoryBackend) removeKey(name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.keys[name]
+	delete(m.keys, name)
+	return ok, nil
+}
+
+func (m *memoryBackend) close() {}
+
+// redisBackend stores JSON-encoded responses with a Redis TTL. Running responses expire too, so
+// responses left running by an instance that stopped do not linger.
+type redisBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+func (r *redisBackend) get(id string) (Response, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	raw, errGet := r.client.Get(ctx, r.prefix+id).Bytes()
+	if errGet != nil {
+		if !errors.Is(errGet, redis.Nil) {
+			log.Debugf("responses-store: redis get failed: %v", errGet)
+		}
+		return Response{}, false
+	}
+	var response Response
+	if errUnmarshal := json.Unmarshal(raw, &response); errUnmarshal != nil {
+		log.Debugf("responses-store: discarding malformed response: %v", errUnmarshal)
+		return Response{}, false
+	}
+	return response, true
+}
+
+func (r *redisBackend) put(response Response, ttl time.Duration) {
+	raw, errMarshal := json.Marshal(response)
+	if errMarshal != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if errSet := r.client.Set(ctx, r.prefix+response.ID, raw, ttl).Err(); errSet != nil {
+		log.Warnf("responses-store: redis set failed: %v", errSet)
+	}
+}
+
+func (r *redisBackend) replaceRunning(response Response, ttl time.Duration) bool {
+	raw, errMarshal := json.Marshal(response)
+	if errMarshal != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	replaced, errRun := replaceRunningScript.Run(ctx, r.client, []string{r.prefix + response.ID}, raw, ttl.Milliseconds()).Int()
+	if errRun != nil {
+		log.Warnf("responses-store: redis replace failed: %v", errRun)
+		return false
+	}
+	return replaced == 1
+}
+
+func (r *redisBackend) remove(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if errDel := r.client.Del(ctx, r.prefix+id).Err(); errDel != nil {
+		log.Warnf("responses-store: redis delete failed: %v", errDel)
+	}
+}
+
+func (r *redisBackend) keyName(name string) string {
+	return r.prefix + "keys:" + name
+}
+
+func (r *redisBackend) getKey(name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	wrapped, errGet := r.client.Get(ctx, r.keyName(name)).Bytes()
+	if errors.Is(errGet, redis.Nil) {
+		return nil, nil
+	}
+	if errGet != nil {
+		return nil, fmt.Errorf("redis get key: %w", errGet)
+	}
+	return wrapped, nil
+}
+
+func (r *redisBackend) addKey(name string, wrapped []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	added, errSet := r.client.SetNX(ctx, r.keyName(name), wrapped, 0).Result()
+	if errSet != nil {
+		return nil, fmt.Errorf("redis add key: %w", errSet)
+	}
+	if added {
+		return wrapped, nil
+	}
+	existing, errGet := r.client.Get(ctx, r.keyName(name)).Bytes()
+	if errGet != nil {
+		return nil, fmt.Errorf("redis get key: %w", errGet)
+	}
+	return existing, nil
+}
+
+func (r *redisBackend) removeKey(name string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	removed, errDel := r.client.Del(ctx, r.keyName(name)).Result()
+	if errDel != nil {
+		return false, fmt.Errorf("redis delete key: %w", errDel)
+	}
+	return removed > 0, nil
+}
+
+func (r *redisBackend) close() {
+	if errClose := r.client.Close(); errClose != nil {
+		log.Debugf("responses-store: failed to close redis client: %v", errClose)
+	}
+}
+
+// sqliteBackend stores JSON-encoded responses in a local SQLite database, so they survive
+// restarts. Like in Redis, running responses expire too.
+type sqliteBackend struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func newSQLiteBackend(path string, now func() time.Time) (*sqliteBackend, error) {
+	db, errOpen := sqlitedb.Open(path)
+	if errOpen != nil {
+		return nil, errOpen
+	}
+	if _, errCreate := db.Exec(`
+		CREATE TABLE IF NOT EXISTS responses (
+			id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			data BLOB NOT NULL,
+			expires_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS responses_expires_at ON responses (expires_at);
+		CREATE TABLE IF NOT EXISTS response_keys (
+			name TEXT PRIMARY KEY,
+			data BLOB NOT NULL
+		);
+	`); errCreate != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create table: %w", errCreate)
+	}
+	return &sqliteBackend{db: db, now: now}, nil
+}
+
+func (b *sqliteBackend) get(id string) (Response, bool) {
+	var raw []byte
+	errQuery := b.db.QueryRow("SELECT data FROM responses WHERE id = ? AND expires_at > ?", id, b.now().UnixNano()).Scan(&raw)
+	if errQuery != nil {
+		if !errors.Is(errQuery, sql.ErrNoRows) {
+			log.Debugf("responses-store: sqlite get failed: %v", errQuery)
+		}
+		return Response{}, false
+	}
+	var response Response
+	if errUnmarshal := json.Unmarshal(raw, &response); errUnmarshal != nil {
+		log.Debugf("responses-store: discarding malformed response: %v", errUnmarshal)
+		return Response{}, false
+	}
+	return response, true
+}
+
+func (b *sqliteBackend) put(response Response, ttl time.Duration) {
+	raw, errMarshal := json.Marshal(response)
+	if errMarshal != nil {
+		return
+	}
+	now := b.now()
+	if _, errExec := b.db.Exec(`
+		INSERT INTO responses (id, status, data, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, data = excluded.data, expires_at = excluded.expires_at
+	`, response.ID, response.Status, raw, now.Add(ttl).UnixNano()); errExec != nil {
+		log.Warnf("responses-store: sqlite put failed: %v", errExec)
+		return
+	}
+	if _, errPrune := b.db.Exec("DELETE FROM responses WHERE expires_at <= ?", now.UnixNano()); errPrune != nil {
+		log.Debugf("responses-store: sqlite prune failed: %v", errPrune)
+	}
+}
+
+func (b *sqliteBackend) replaceRunning(response Response, ttl time.Duration) bool {
+	raw, errMarshal := json.Marshal(response)
+	if errMarshal != nil {
+		return false
+	}
+	now := b.now()
+	result, errExec := b.db.Exec(`
+		UPDATE responses SET status = ?, data = ?, expires_at = ?
+		WHERE id = ? AND expires_at > ? AND status IN (?, ?)
+	`, response.Status, raw, now.Add(ttl).UnixNano(), response.ID, now.UnixNano(), StatusQueued, StatusInProgress)
+	if errExec != nil {
+		log.Warnf("responses-store: sqlite replace failed: %v", errExec)
+		return false
+	}
+	updated, errRows := result.RowsAffected()
+	return errRows == nil && updated == 1
+}
+
+func (b *sqliteBackend) remove(id string) {
+	if _, errExec := b.db.Exec("DELETE FROM responses WHERE id = ?", id); errExec != nil {
+		log.Warnf("responses-store: sqlite delete failed: %v", errExec)
+	}
+}
+
+func (b *sqliteBackend) getKey(name string) ([]byte, error) {
+	var wrapped []byte
+	errQuery := b.db.QueryRow("SELECT data FROM response_keys WHERE name = ?", name).Scan(&wrapped)
+	if errors.Is(errQuery, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if errQuery != nil {
+		return nil, fmt.Errorf("sqlite get key: %w", errQuery)
+	}
+	return wrapped, nil
+}
+
+func (b *sqliteBackend) addKey(name string, wrapped []byte) ([]byte, error) {
+	if _, errExec := b.db.Exec("INSERT INTO response_keys (name, data) VALUES (?, ?) ON CONFLICT (name) DO NOTHING", name, wrapped); errExec != nil {
+		return nil, fmt.Errorf("sqlite add key: %w", errExec)
+	}
+	stored, errGet := b.getKey(name)
+	if errGet == nil && stored == nil {
+		errGet = errors.New("sqlite add key: key missing after insert")
+	}
+	return stored, errGet
+}
+
+func (b *sqliteBackend) removeKey(name string) (bool, error) {
+	result, errExec := b.db.Exec("DELETE FROM response_keys WHERE name = ?", name)
+	if errExec != nil {
+		return false, fmt.Errorf("sqlite delete key: %w", errExec)
+	}
+	removed, errRows := result.RowsAffected()
+	return errRows == nil && removed > 0, nil
+}
+
+func (b *sqliteBackend) close() {
+	if errClose := b.db.Close(); errClose != nil {
+		log.Debugf("responses-store: failed to close sqlite database: %v", errClose)
+	}
+}
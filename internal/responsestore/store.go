@@ -0,0 +1,405 @@
+// Package responsestore keeps OpenAI Responses API state in memory, SQLite or Redis: completed
+// responses that later requests chain onto with previous_response_id, and background responses
+// that clients poll until they finish.
+package responsestore
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sharedstate"
+	log "github.com/sirupsen/logrus"
+)
+
+// Response statuses, as reported in the status field of a Responses API response object.
+const (
+	StatusQueued     = "queued"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusCancelled  = "cancelled"
+)
+
+var (
+	// ErrNotFound is returned when no stored response matches an ID and owner.
+	ErrNotFound = errors.New("response not found")
+	// ErrNotCancellable is returned when cancelling a response that is not running in the background.
+	ErrNotCancellable = errors.New("only running background responses can be cancelled")
+
+	errStoreDisabled = errors.New("responses-store: disabled")
+)
+
+// Response is a stored Responses API response.
+type Response struct {
+	// ID is the response ID clients refer to.
+	ID string `json:"id"`
+	// Owner is the client API key that created the response.
+	Owner string `json:"owner"`
+	// Status is one of the Status constants.
+	Status string `json:"status"`
+	// Input is the request's full input item array, with any previous_response_id chain expanded.
+	Input []byte `json:"input,omitempty"`
+	// Body is the response object returned to clients.
+	Body []byte `json:"body,omitempty"`
+}
+
+// contentKeyID names the data key sealing the stored responses of an owner. All responses of an
+// owner share it, so shredding the owner's content keys makes every stored response unreadable.
+// The wrapped key is kept in the backend next to the responses, so every instance sharing the
+// backend opens them.
+const contentKeyID = "responses"
+
+// backend persists responses with their Input and Body sealed and their Owner replaced by a
+// digest, so a database never holds client API keys or content in the clear. Backends are
+// safe for concurrent use; Store calls them without holding its lock.
+type backend interface {
+	get(id string) (Response, bool)
+	put(r Response, ttl time.Duration)
+	// replaceRunning stores r only if the stored response with its ID is still running, and
+	// reports whether it did. The check and the write are atomic.
+	replaceRunning(r Response, ttl time.Duration) bool
+	remove(id string)
+	// getKey returns the wrapped content key stored under name, or nil when there is none.
+	getKey(name string) ([]byte, error)
+	// addKey stores wrapped under name unless a key is stored there already, and returns the
+	// stored key. Content keys do not expire.
+	addKey(name string, wrapped []byte) ([]byte, error)
+	// removeKey deletes the key stored under name and reports whether there was one.
+	removeKey(name string) (bool, error)
+	close()
+}
+
+// Store keeps responses in a backend selected by the configuration. The functions stopping
+// background work always stay in the process running it. A nil Store is disabled.
+type Store struct {
+	mu      sync.Mutex
+	cfg     config.ResponsesStoreConfig
+	enabled bool
+	ttl     time.Duration
+	backend backend
+	cancels map[string]func()
+	keys    *contentcrypt.Keyring
+
+	clock clock.Clock
+}
+
+// New creates a store using the provided configuration.
+func New(cfg config.ResponsesStoreConfig) *Store {
+	s := &Store{cancels: make(map[string]func()), clock: clock.Default()}
+	s.Update(cfg)
+	return s
+}
+
+var defaultStore = New(config.ResponsesStoreConfig{})
+
+// Default returns the process-wide Store used by the Responses API handlers.
+func Default() *Store { return defaultStore }
+
+// SetKeyring sets the keyring sealing the input and body of stored responses under a data key of
+// their owner. Shredding the owner's keys in the keyring destroys that data key too.
+func (s *Store) SetKeyring(keys *contentcrypt.Keyring) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	keys.AddKeyStore(s)
+}
+
+// LoadKey returns the wrapped content key of owner kept in the backend.
+func (s *Store) LoadKey(owner, id string) ([]byte, error) {
+	v, ok := s.view()
+	if !ok {
+		return nil, nil
+	}
+	return v.LoadKey(owner, id)
+}
+
+// AddKey keeps the wrapped content key of owner in the backend unless it holds one already.
+func (s *Store) AddKey(owner, id string, wrapped []byte) ([]byte, error) {
+	v, ok := s.view()
+	if !ok {
+		return nil, errStoreDisabled
+	}
+	return v.AddKey(owner, id, wrapped)
+}
+
+// RemoveOwnerKeys destroys the content key of owner kept in the backend.
+func (s *Store) RemoveOwnerKeys(owner string) (int, error) {
+	v, ok := s.view()
+	if !ok {
+		return 0, nil
+	}
+	return v.RemoveOwnerKeys(owner)
+}
+
+// Update applies cfg. Responses held in memory are dropped when the store is disabled or
+// switches backends.
+func (s *Store) Update(cfg config.ResponsesStoreConfig) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.cfg
+	s.cfg = cfg
+	s.enabled = cfg.Enable
+	s.ttl = cfg.TTLDuration()
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = config.DefaultResponsesStoreMaxEntries
+	}
+	if !s.enabled {
+		for id, cancel := range s.cancels {
+			cancel()
+			delete(s.cancels, id)
+		}
+		if s.backend != nil {
+			s.backend.close()
+			s.backend = nil
+		}
+		return
+	}
+	if s.backend != nil && previous.RedisURL == cfg.RedisURL && previous.RedisPrefix == cfg.RedisPrefix && previous.SQLitePath == cfg.SQLitePath {
+		if memory, ok := s.backend.(*memoryBackend); ok {
+			memory.resize(maxEntries)
+		}
+		return
+	}
+	if s.backend != nil {
+		s.backend.close()
+	}
+	s.backend = s.newBackend(cfg, maxEntries)
+}
+
+func (s *Store) newBackend(cfg config.ResponsesStoreConfig, maxEntries int) backend {
+	if cfg.RedisURL != "" {
+		options, errParse := redis.ParseURL(cfg.RedisURL)
+		if errParse == nil {
+			return &redisBackend{client: redis.NewClient(options), prefix: cfg.RedisPrefix}
+		}
+		log.Warnf("responses-store: invalid redis-url, falling back to memory: %v", errParse)
+	} else if cfg.SQLitePath != "" {
+		backend, errOpen := newSQLiteBackend(cfg.SQLitePath, func() time.Time { return s.clock.Now() })
+		if errOpen == nil {
+			return backend
+		}
+		log.Warnf("responses-store: cannot open sqlite-path, falling back to memory: %v", errOpen)
+	}
+	return newMemoryBackend(maxEntries, func() time.Time { return s.clock.Now() })
+}
+
+// Enabled reports whether responses are stored.
+func (s *Store) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabled
+}
+
+// Put stores r, replacing a stored response with the same ID. cancel, when set, stops the
+// background work producing r and is dropped once r is no longer running.
+func (s *Store) Put(r Response, cancel func()) {
+	if s == nil || r.ID == "" {
+		return
+	}
+	s.mu.Lock()
+	v, ok := s.viewLocked()
+	if ok {
+		if running(r.Status) && cancel != nil {
+			s.cancels[r.ID] = cancel
+		} else {
+			delete(s.cancels, r.ID)
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	if sealed, okSeal := v.seal(r); okSeal {
+		v.backend.put(sealed, v.ttl)
+	}
+}
+
+// Finish records the outcome of a background response. It reports false, leaving the stored
+// response untouched, when the response was cancelled or removed in the meantime, possibly by
+// another instance sharing the backend.
+func (s *Store) Finish(r Response) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	delete(s.cancels, r.ID)
+	v, ok := s.viewLocked()
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	sealed, okSeal := v.seal(r)
+	return okSeal && v.backend.replaceRunning(sealed, v.ttl)
+}
+
+// Get returns the response with id created by owner.
+func (s *Store) Get(owner, id string) (Response, error) {
+	v, ok := s.view()
+	if !ok {
+		return Response{}, ErrNotFound
+	}
+	return v.lookup(owner, id)
+}
+
+// Cancel stops a running background response and marks it cancelled with body. A response
+// running in another instance is only marked cancelled; that instance discards its result.
+func (s *Store) Cancel(owner, id string, body func(Response) []byte) (Response, error) {
+	v, ok := s.view()
+	if !ok {
+		return Response{}, ErrNotFound
+	}
+	r, errLookup := v.lookup(owner, id)
+	if errLookup != nil {
+		return Response{}, errLookup
+	}
+	if !running(r.Status) {
+		return r, ErrNotCancellable
+	}
+	r.Status = StatusCancelled
+	if body != nil {
+		r.Body = body(r)
+	}
+	sealed, okSeal := v.seal(r)
+	if !okSeal {
+		return Response{}, ErrNotFound
+	}
+	if !v.backend.replaceRunning(sealed, v.ttl) {
+		// The response finished, or was cancelled elsewhere, after the lookup.
+		return r, ErrNotCancellable
+	}
+	s.stop(id)
+	return r, nil
+}
+
+// Delete removes the response with id created by owner, cancelling it if it is still running.
+func (s *Store) Delete(owner, id string) error {
+	v, ok := s.view()
+	if !ok {
+		return ErrNotFound
+	}
+	if _, errLookup := v.lookup(owner, id); errLookup != nil {
+		return errLookup
+	}
+	v.backend.remove(id)
+	s.stop(id)
+	return nil
+}
+
+// stop calls and drops the cancel function of the background work producing id, if it runs in
+// this process.
+func (s *Store) stop(id string) {
+	s.mu.Lock()
+	cancel := s.cancels[id]
+	delete(s.cancels, id)
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// storeView is the part of the Store configuration a call needs, copied under the store's lock
+// so backend and keyring I/O run without holding it.
+type storeView struct {
+	backend backend
+	keys    *contentcrypt.Keyring
+	ttl     time.Duration
+}
+
+func (s *Store) view() (storeView, bool) {
+	if s == nil {
+		return storeView{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.viewLocked()
+}
+
+func (s *Store) viewLocked() (storeView, bool) {
+	if !s.enabled || s.backend == nil {
+		return storeView{}, false
+	}
+	return storeView{backend: s.backend, keys: s.keys, ttl: s.ttl}, true
+}
+
+func (v storeView) lookup(owner, id string) (Response, error) {
+	r, ok := v.backend.get(id)
+	if !ok || r.Owner != sharedstate.HashKey(owner) {
+		return Response{}, ErrNotFound
+	}
+	r.Owner = owner
+	var errOpen error
+	if r.Input, errOpen = v.keys.OpenWith(v, owner, contentKeyID, r.Input); errOpen == nil {
+		r.Body, errOpen = v.keys.OpenWith(v, owner, contentKeyID, r.Body)
+	}
+	if errOpen != nil {
+		// The owner's content key was shredded, or replaced after being shredded.
+		log.Debugf("responses-store: cannot open response %s: %v", id, errOpen)
+		return Response{}, ErrNotFound
+	}
+	return r, nil
+}
+
+// seal returns r as the backend stores it: input and body sealed, owner replaced by its digest.
+// A response that cannot be sealed is removed instead, so content is never kept in the clear
+// while encryption is enabled.
+func (v storeView) seal(r Response) (Response, bool) {
+	var errSeal error
+	if r.Input, errSeal = v.sealData(r.Owner, r.Input); errSeal == nil {
+		r.Body, errSeal = v.sealData(r.Owner, r.Body)
+	}
+	if errSeal != nil {
+		log.Warnf("responses-store: failed to seal response %s: %v", r.ID, errSeal)
+		v.backend.remove(r.ID)
+		return Response{}, false
+	}
+	r.Owner = sharedstate.HashKey(r.Owner)
+	return r, true
+}
+
+func (v storeView) sealData(owner string, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	return v.keys.SealWith(v, owner, contentKeyID, data)
+}
+
+// LoadKey, AddKey and RemoveOwnerKeys make the view the KeyStore of its content keys, which the
+// backend keeps under the owner's digest.
+func (v storeView) LoadKey(owner, id string) ([]byte, error) {
+	return v.backend.getKey(contentKeyName(owner, id))
+}
+
+func (v storeView) AddKey(owner, id string, wrapped []byte) ([]byte, error) {
+	return v.backend.addKey(contentKeyName(owner, id), wrapped)
+}
+
+func (v storeView) RemoveOwnerKeys(owner string) (int, error) {
+	removed, errRemove := v.backend.removeKey(contentKeyName(owner, contentKeyID))
+	if errRemove != nil || !removed {
+		return 0, errRemove
+	}
+	return 1, nil
+}
+
+func contentKeyName(owner, id string) string {
+	return sharedstate.HashKey(owner) + "/" + id
+}
+
+func running(status string) bool {
+	return status == StatusQueued || status == StatusInProgress
+}
@@ -0,0 +1,244 @@
+package responsestore
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+)
+
+func TestStore_ScopesAndExpiresResponses(t *testing.T) {
+	now := clock.NewSim(time.Unix(1_700_000_000, 0))
+	s := New(config.ResponsesStoreConfig{Enable: true, TTL: "1m"})
+	s.clock = now
+
+	s.Put(Response{ID: "resp_1", Owner: "alice", Status: StatusCompleted, Body: []byte("one")}, nil)
+	if got, err := s.Get("alice", "resp_1"); err != nil || string(got.Body) != "one" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if _, err := s.Get("bob", "resp_1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get by another owner err = %v, want not found", err)
+	}
+
+	now.Advance(2 * time.Minute)
+	if _, err := s.Get("alice", "resp_1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after TTL err = %v, want not found", err)
+	}
+}
+
+func TestStore_CancelStopsRunningResponse(t *testing.T) {
+	s := New(config.ResponsesStoreConfig{Enable: true, MaxEntries: 1})
+	cancelled := false
+	s.Put(Response{ID: "resp_bg", Owner: "alice", Status: StatusInProgress}, func() { cancelled = true })
+	s.Put(Response{ID: "resp_done", Owner: "alice", Status: StatusCompleted}, nil)
+	if _, err := s.Get("alice", "resp_bg"); err != nil {
+		t.Fatalf("running response evicted: %v", err)
+	}
+
+	got, err := s.Cancel("alice", "resp_bg", func(r Response) []byte { return []byte(r.Status) })
+	if err != nil || !cancelled || got.Status != StatusCancelled || string(got.Body) != StatusCancelled {
+		t.Fatalf("Cancel = %+v, %v (cancelled %t)", got, err, cancelled)
+	}
+	if s.Finish(Response{ID: "resp_bg", Owner: "alice", Status: StatusCompleted}) {
+		t.Fatal("Finish overwrote a cancelled response")
+	}
+	if _, err = s.Cancel("alice", "resp_bg", nil); !errors.Is(err, ErrNotCancellable) {
+		t.Fatalf("second Cancel err = %v, want not cancellable", err)
+	}
+	if err = s.Delete("alice", "resp_bg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err = s.Delete("alice", "resp_bg"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete err = %v, want not found", err)
+	}
+}
+
+func TestStore_SharedBackendAcrossInstances(t *testing.T) {
+	a := New(config.ResponsesStoreConfig{Enable: true})
+	b := New(config.ResponsesStoreConfig{Enable: true})
+	b.backend = a.backend
+
+	cancelled := false
+	a.Put(Response{ID: "resp_bg", Owner: "alice", Status: StatusInProgress}, func() { cancelled = true })
+	if got, err := b.Get("alice", "resp_bg"); err != nil || got.Status != StatusInProgress {
+		t.Fatalf("Get through the other instance = %+v, %v", got, err)
+	}
+	if _, err := b.Cancel("alice", "resp_bg", nil); err != nil {
+		t.Fatalf("Cancel through the other instance: %v", err)
+	}
+	if cancelled {
+		t.Fatal("Cancel called the cancel func of another instance")
+	}
+	if a.Finish(Response{ID: "resp_bg", Owner: "alice", Status: StatusCompleted}) {
+		t.Fatal("Finish overwrote a response cancelled by another instance")
+	}
+	if got, _ := a.Get("alice", "resp_bg"); got.Status != StatusCancelled {
+		t.Fatalf("status = %q, want cancelled", got.Status)
+	}
+}
+
+func TestStore_InvalidRedisURLFallsBackToMemory(t *testing.T) {
+	s := New(config.ResponsesStoreConfig{Enable: true, RedisURL: "not a url", RedisPrefix: "p:"})
+	if _, ok := s.backend.(*memoryBackend); !ok {
+		t.Fatalf("backend = %T, want memory", s.backend)
+	}
+	s.Put(Response{ID: "resp_1", Owner: "alice", Status: StatusCompleted}, nil)
+	s.Update(config.ResponsesStoreConfig{Enable: true, RedisURL: "not a url", RedisPrefix: "p:", MaxEntries: 5})
+	if _, err := s.Get("alice", "resp_1"); err != nil {
+		t.Fatalf("Get after reload: %v", err)
+	}
+	s.Update(config.ResponsesStoreConfig{Enable: true, RedisURL: "redis://localhost:6379/0", RedisPrefix: "p:"})
+	if _, ok := s.backend.(*redisBackend); !ok {
+		t.Fatalf("backend = %T, want redis", s.backend)
+	}
+	s.Update(config.ResponsesStoreConfig{})
+}
+
+func TestStore_SealsPayloadsPerOwnerAndShreds(t *testing.T) {
+	t.Setenv("RESPONSES_STORE_TEST_KEY", "master")
+	keys := contentcrypt.NewKeyring()
+	keys.Apply(config.ContentEncryptionConfig{Enable: true, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "RESPONSES_STORE_TEST_KEY"}, KeysDir: t.TempDir()}, "")
+	s := New(config.ResponsesStoreConfig{Enable: true})
+	s.SetKeyring(keys)
+
+	s.Put(Response{ID: "resp_1", Owner: "alice", Status: StatusCompleted, Input: []byte("secret input"), Body: []byte("secret body")}, nil)
+	stored, _ := s.backend.get("resp_1")
+	if bytes.Contains(stored.Input, []byte("secret")) || bytes.Contains(stored.Body, []byte("secret")) {
+		t.Fatalf("backend holds plaintext: %q, %q", stored.Input, stored.Body)
+	}
+	got, err := s.Get("alice", "resp_1")
+	if err != nil || string(got.Input) != "secret input" || string(got.Body) != "secret body" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+
+	if _, errShred := keys.ShredOwner("alice"); errShred != nil {
+		t.Fatalf("ShredOwner: %v", errShred)
+	}
+	if _, err = s.Get("alice", "resp_1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after shredding err = %v, want not found", err)
+	}
+}
+
+func TestStore_BackendNeverHoldsOwnerKey(t *testing.T) {
+	s := New(config.ResponsesStoreConfig{Enable: true})
+	s.Put(Response{ID: "resp_1", Owner: "sk-alice", Status: StatusCompleted}, nil)
+	if stored, _ := s.backend.get("resp_1"); stored.Owner == "sk-alice" || stored.Owner == "" {
+		t.Fatalf("backend owner = %q, want a digest of the key", stored.Owner)
+	}
+	if got, err := s.Get("sk-alice", "resp_1"); err != nil || got.Owner != "sk-alice" {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+}
+
+// blockingBackend stalls get until release is closed.
+type blockingBackend struct {
+	*memoryBackend
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBackend) get(id string) (Response, bool) {
+	close(b.entered)
+	<-b.release
+	return b.memoryBackend.get(id)
+}
+
+func TestStore_BackendCallsDoNotHoldTheStoreLock(t *testing.T) {
+	s := New(config.ResponsesStoreConfig{Enable: true})
+	slow := &blockingBackend{memoryBackend: s.backend.(*memoryBackend), entered: make(chan struct{}), release: make(chan struct{})}
+	s.backend = slow
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = s.Get("alice", "resp_1")
+	}()
+	<-slow.entered
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Put(Response{ID: "resp_2", Owner: "bob", Status: StatusInProgress}, func() {})
+		s.stop("resp_2")
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Put waited for a backend call of another request")
+	}
+	close(slow.release)
+	<-done
+}
+
+func TestStore_SQLiteBackendSurvivesRestart(t *testing.T) {
+	now := clock.NewSim(time.Unix(1_700_000_000, 0))
+	cfg := config.ResponsesStoreConfig{Enable: true, TTL: "1m", SQLitePath: filepath.Join(t.TempDir(), "responses.db")}
+	first := New(cfg)
+	first.clock = now
+	if _, ok := first.backend.(*sqliteBackend); !ok {
+		t.Fatalf("backend = %T, want sqlite", first.backend)
+	}
+	first.Put(Response{ID: "resp_1", Owner: "alice", Status: StatusCompleted, Body: []byte("one")}, nil)
+	first.Put(Response{ID: "resp_bg", Owner: "alice", Status: StatusInProgress}, nil)
+	first.Update(config.ResponsesStoreConfig{})
+
+	second := New(cfg)
+	second.clock = now
+	defer second.Update(config.ResponsesStoreConfig{})
+	if got, err := second.Get("alice", "resp_1"); err != nil || string(got.Body) != "one" {
+		t.Fatalf("Get after restart = %+v, %v", got, err)
+	}
+	if _, err := second.Cancel("alice", "resp_bg", nil); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if second.Finish(Response{ID: "resp_bg", Owner: "alice", Status: StatusCompleted}) {
+		t.Fatal("Finish overwrote a cancelled response")
+	}
+
+	now.Advance(2 * time.Minute)
+	if _, err := second.Get("alice", "resp_1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after TTL err = %v, want not found", err)
+	}
+}
+
+func TestStore_SealedResponsesOpenOnEveryInstance(t *testing.T) {
+	t.Setenv("RESPONSES_STORE_TEST_KEY", "master")
+	newKeyring := func() *contentcrypt.Keyring {
+		keys := contentcrypt.NewKeyring()
+		keys.Apply(config.ContentEncryptionConfig{Enable: true, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "RESPONSES_STORE_TEST_KEY"}, KeysDir: t.TempDir()}, "")
+		return keys
+	}
+	cfg := config.ResponsesStoreConfig{Enable: true, SQLitePath: filepath.Join(t.TempDir(), "responses.db")}
+	a, b := New(cfg), New(cfg)
+	defer a.Update(config.ResponsesStoreConfig{})
+	defer b.Update(config.ResponsesStoreConfig{})
+	keysA, keysB := newKeyring(), newKeyring()
+	a.SetKeyring(keysA)
+	b.SetKeyring(keysB)
+
+	a.Put(Response{ID: "resp_1", Owner: "alice", Status: StatusCompleted, Input: []byte("secret input"), Body: []byte("secret body")}, nil)
+	b.Put(Response{ID: "resp_2", Owner: "alice", Status: StatusInProgress, Body: []byte("secret progress")}, nil)
+	if got, err := b.Get("alice", "resp_1"); err != nil || string(got.Input) != "secret input" || string(got.Body) != "secret body" {
+		t.Fatalf("Get through the other instance = %+v, %v", got, err)
+	}
+	if got, err := a.Get("alice", "resp_2"); err != nil || string(got.Body) != "secret progress" {
+		t.Fatalf("poll through the other instance = %+v, %v", got, err)
+	}
+
+	destroyed, errShred := keysB.ShredOwner("alice")
+	if errShred != nil || destroyed != 1 {
+		t.Fatalf("ShredOwner = %d, %v; want the shared key destroyed", destroyed, errShred)
+	}
+	for _, s := range []*Store{a, b} {
+		for _, id := range []string{"resp_1", "resp_2"} {
+			if _, err := s.Get("alice", id); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get %s after shredding err = %v, want not found", id, err)
+			}
+		}
+	}
+}
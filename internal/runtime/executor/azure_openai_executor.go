@@ -0,0 +1,497 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	azureOpenAIUserAgent = "cli-proxy-azure-openai"
+	azureCognitiveScope  = "https://cognitiveservices.azure.com/.default"
+	// azureTokenRefreshSkew renews Entra ID tokens this long before they expire.
+	azureTokenRefreshSkew = 5 * time.Minute
+)
+
+// AzureOpenAIExecutor executes OpenAI chat completions and embeddings against Azure
+// OpenAI deployments. The upstream model name selects the deployment; model aliases
+// in config map client-facing names to deployment names before the executor runs.
+type AzureOpenAIExecutor struct {
+	cfg *config.Config
+	now func() time.Time
+
+	tokenMu     sync.Mutex
+	tokens      map[string]azureAccessToken
+	tokenFlight singleflight.Group
+}
+
+// azureAccessToken is a cached Entra ID bearer token.
+type azureAccessToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewAzureOpenAIExecutor creates an executor for the azure-openai provider.
+func NewAzureOpenAIExecutor(cfg *config.Config) *AzureOpenAIExecutor {
+	return &AzureOpenAIExecutor{cfg: cfg, now: time.Now, tokens: make(map[string]azureAccessToken)}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *AzureOpenAIExecutor) Identifier() string { return "azure-openai" }
+
+// azureOpenAITarget is the resolved resource and credential for one auth entry.
+type azureOpenAITarget struct {
+	endpoint      string
+	apiVersion    string
+	apiKey        string
+	tenantID      string
+	clientID      string
+	clientSecret  string
+	authorityHost string
+}
+
+func azureOpenAITargetForAuth(auth *cliproxyauth.Auth) azureOpenAITarget {
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	target := azureOpenAITarget{
+		endpoint:   strings.TrimSuffix(strings.TrimSpace(attrs["base_url"]), "/"),
+		apiVersion: strings.TrimSpace(attrs["api_version"]),
+	}
+	if target.apiVersion == "" {
+		target.apiVersion = config.DefaultAzureOpenAIAPIVersion
+	}
+	key := strings.TrimSpace(attrs["api_key"])
+	if secret := strings.TrimSpace(attrs["client_secret"]); secret != "" {
+		target.clientID = key
+		target.clientSecret = secret
+		target.tenantID = strings.TrimSpace(attrs["tenant_id"])
+		target.authorityHost = strings.TrimSuffix(strings.TrimSpace(attrs["authority_host"]), "/")
+		if target.authorityHost == "" {
+			target.authorityHost = config.DefaultAzureAuthorityHost
+		}
+	} else {
+		target.apiKey = key
+	}
+	return target
+}
+
+// deploymentURL returns the data-plane URL for the deployment and operation path.
+func (t azureOpenAITarget) deploymentURL(deployment, operation string) string {
+	return t.endpoint + "/openai/deployments/" + url.PathEscape(deployment) + operation + "?api-version=" + url.QueryEscape(t.apiVersion)
+}
+
+// authorize sets the api-key header or an Entra ID bearer token.
+func (e *AzureOpenAIExecutor) authorize(ctx context.Context, auth *cliproxyauth.Auth, target azureOpenAITarget, req *http.Request) error {
+	if target.apiKey != "" {
+		req.Header.Set("api-key", target.apiKey)
+		return nil
+	}
+	if target.clientSecret == "" {
+		return nil
+	}
+	token, err := e.entraToken(ctx, auth, target)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// entraToken returns a cached Entra ID token for the service principal, requesting a new
+// one through the client credentials grant when the cached token is close to expiry.
+func (e *AzureOpenAIExecutor) entraToken(ctx context.Context, auth *cliproxyauth.Auth, target azureOpenAITarget) (string, error) {
+	cacheKey := target.authorityHost + "|" + target.tenantID + "|" + target.clientID
+	e.tokenMu.Lock()
+	cached, ok := e.tokens[cacheKey]
+	e.tokenMu.Unlock()
+	if ok && e.now().Add(azureTokenRefreshSkew).Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+
+	result, err, _ := e.tokenFlight.Do(cacheKey, func() (any, error) {
+		return e.requestEntraToken(ctx, auth, target)
+	})
+	if err != nil {
+		return "", err
+	}
+	token := result.(azureAccessToken)
+	e.tokenMu.Lock()
+	e.tokens[cacheKey] = token
+	e.tokenMu.Unlock()
+	return token.value, nil
+}
+
+func (e *AzureOpenAIExecutor) requestEntraToken(ctx context.Context, auth *cliproxyauth.Auth, target azureOpenAITarget) (azureAccessToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", target.clientID)
+	form.Set("client_secret", target.clientSecret)
+	form.Set("scope", azureCognitiveScope)
+
+	tokenURL := target.authorityHost + "/" + url.PathEscape(target.tenantID) + "/oauth2/v2.0/token"
+	httpReq, errReq := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if errReq != nil {
+		return azureAccessToken{}, errReq
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, errDo := httpClient.Do(httpReq)
+	if errDo != nil {
+		return azureAccessToken{}, fmt.Errorf("azure openai executor: entra id token request: %w", errDo)
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	body, errRead := io.ReadAll(httpResp.Body)
+	if errRead != nil {
+		return azureAccessToken{}, errRead
+	}
+	if httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		// Token endpoint failures are credential problems, not upstream model errors.
+		return azureAccessToken{}, statusErr{code: http.StatusUnauthorized, msg: fmt.Sprintf("azure openai executor: entra id token request failed with status %d: %s", httpResp.StatusCode, string(body))}
+	}
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if errUnmarshal := json.Unmarshal(body, &tokenResp); errUnmarshal != nil {
+		return azureAccessToken{}, fmt.Errorf("azure openai executor: decode entra id token: %w", errUnmarshal)
+	}
+	if tokenResp.AccessToken == "" {
+		return azureAccessToken{}, statusErr{code: http.StatusUnauthorized, msg: "azure openai executor: entra id token response has no access_token"}
+	}
+	return azureAccessToken{
+		value:     tokenResp.AccessToken,
+		expiresAt: e.now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// PrepareRequest injects Azure OpenAI credentials into the outgoing HTTP request.
+func (e *AzureOpenAIExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	if err := e.authorize(req.Context(), auth, azureOpenAITargetForAuth(auth), req); err != nil {
+		return err
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	return nil
+}
+
+// HttpRequest injects Azure OpenAI credentials into the request and executes it.
+func (e *AzureOpenAIExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("azure openai executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// prepareBody translates the request into an OpenAI chat completions payload.
+func (e *AzureOpenAIExecutor) prepareBody(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (sdktranslator.Format, []byte, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := helps.TranslateRequestWithCodexMultiAgentV2(ctx, opts.Headers, e.cfg, from, to, baseModel, originalPayload, stream)
+	translated := helps.TranslateRequestWithCodexMultiAgentV2(ctx, opts.Headers, e.cfg, from, to, baseModel, req.Payload, stream)
+	translated, err := thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return to, nil, err
+	}
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	requestPath := helps.PayloadRequestPath(opts)
+	translated = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", translated, originalTranslated, requestedModel, requestPath, opts.Headers)
+	translated = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), translated)
+	if stream {
+		translated = helps.SetBoolIfDifferent(translated, "stream_options.include_usage", true)
+	}
+	return to, translated, nil
+}
+
+func (e *AzureOpenAIExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, reporter *helps.UsageReporter, url string, body []byte, stream bool) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", azureOpenAIUserAgent)
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("Cache-Control", "no-cache")
+	}
+	if err = e.authorize(ctx, auth, azureOpenAITargetForAuth(auth), httpReq); err != nil {
+		return nil, err
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient = reporter.TrackHTTPClient(httpClient)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+// Execute performs a non-streaming chat completion or embeddings request.
+func (e *AzureOpenAIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "embeddings" {
+		return e.executeEmbeddings(ctx, auth, req, opts)
+	}
+	if isEndpointAlt(opts.Alt) {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "azure openai executor: endpoint not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	target := azureOpenAITargetForAuth(auth)
+	if target.endpoint == "" {
+		return resp, statusErr{code: http.StatusUnauthorized, msg: "missing azure openai endpoint"}
+	}
+	to, translated, err := e.prepareBody(ctx, auth, req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+	reporter.SetTranslatedReasoningEffort(translated, to.String())
+
+	httpResp, err := e.send(ctx, auth, reporter, target.deploymentURL(baseModel, "/chat/completions"), translated, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, body)
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(body))
+	reporter.EnsurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, cliproxyexecutor.ResponseFormatOrSource(opts), req.Model, opts.OriginalRequest, translated, body, &param)
+	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
+}
+
+// executeEmbeddings forwards an OpenAI embeddings request to the deployment unchanged.
+func (e *AzureOpenAIExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	target := azureOpenAITargetForAuth(auth)
+	if target.endpoint == "" {
+		return resp, statusErr{code: http.StatusUnauthorized, msg: "missing azure openai endpoint"}
+	}
+	payload, _, err := prepareOpenAICompatPassthroughPayload(req.Payload, baseModel, opts, false)
+	if err != nil {
+		return resp, err
+	}
+
+	httpResp, err := e.send(ctx, auth, reporter, target.deploymentURL(baseModel, "/embeddings"), payload, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("azure openai executor: close response body error: %v", errClose)
+		}
+	}()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, body)
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(body))
+	reporter.EnsurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: body, Headers: httpResp.Header.Clone()}, nil
+}
+
+// ExecuteStream performs a streaming chat completion request.
+func (e *AzureOpenAIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if isEndpointAlt(opts.Alt) {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "azure openai executor: endpoint not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	target := azureOpenAITargetForAuth(auth)
+	if target.endpoint == "" {
+		return nil, statusErr{code: http.StatusUnauthorized, msg: "missing azure openai endpoint"}
+	}
+	to, translated, err := e.prepareBody(ctx, auth, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	reporter.SetTranslatedReasoningEffort(translated, to.String())
+	responseFormat := cliproxyexecutor.ResponseFormatOrSource(opts)
+
+	httpResp, err := e.send(ctx, auth, reporter, target.deploymentURL(baseModel, "/chat/completions"), translated, true)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("azure openai executor: close response body error: %v", errClose)
+			}
+		}()
+		send := func(chunks [][]byte) bool {
+			for i := range chunks {
+				select {
+				case out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, 52_428_800) // 50MB
+		var param any
+		var streamUsage helps.StreamUsageBuffer
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			streamUsage.ObserveOpenAIStream(line)
+			trimmed := bytes.TrimSpace(line)
+			if !bytes.HasPrefix(trimmed, []byte("data:")) {
+				continue
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, responseFormat, req.Model, opts.OriginalRequest, translated, bytes.Clone(trimmed), &param)
+			if !send(chunks) {
+				return
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.PublishFailure(ctx, errScan)
+			select {
+			case out <- cliproxyexecutor.StreamChunk{Err: errScan}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		// Flush translators that wait for the terminal marker when upstream omits it.
+		chunks := sdktranslator.TranslateStream(ctx, to, responseFormat, req.Model, opts.OriginalRequest, translated, []byte("data: [DONE]"), &param)
+		if !send(chunks) {
+			return
+		}
+		streamUsage.Publish(ctx, reporter)
+		reporter.EnsurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens estimates the prompt size locally; Azure OpenAI has no token counting endpoint.
+func (e *AzureOpenAIExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := helps.TranslateRequestWithCodexMultiAgentV2(ctx, opts.Headers, e.cfg, from, to, baseModel, req.Payload, false)
+
+	enc, err := helps.TokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("azure openai executor: token counting failed: %w", err)
+	}
+	usageJSON := helps.BuildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, cliproxyexecutor.ResponseFormatOrSource(opts), count, usageJSON)
+	return cliproxyexecutor.Response{Payload: translatedUsage}, nil
+}
+
+// Refresh is a no-op; Entra ID tokens are requested on demand and cached per service principal.
+func (e *AzureOpenAIExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	if refreshed, handled, err := helps.RefreshAuthViaHome(ctx, e.cfg, auth); handled {
+		return refreshed, err
+	}
+	return auth, nil
+}
@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestAzureOpenAIExecutorExecuteUsesDeploymentAndAPIKey(t *testing.T) {
+	var seenPath, seenQuery, seenKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenPath = r.URL.Path
+		seenQuery = r.URL.Query().Get("api-version")
+		seenKey = r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+	}))
+	defer server.Close()
+
+	exec := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "azure-test", Provider: "azure-openai", Attributes: map[string]string{
+		"api_key":     "azure-key",
+		"base_url":    server.URL,
+		"api_version": "2025-01-01-preview",
+	}}
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "prod-gpt-4o",
+		Payload: []byte(`{"model":"prod-gpt-4o","messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if seenPath != "/openai/deployments/prod-gpt-4o/chat/completions" {
+		t.Fatalf("path = %q", seenPath)
+	}
+	if seenQuery != "2025-01-01-preview" {
+		t.Fatalf("api-version = %q", seenQuery)
+	}
+	if seenKey != "azure-key" {
+		t.Fatalf("api-key header = %q", seenKey)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hi" {
+		t.Fatalf("response content = %q, payload %s", got, resp.Payload)
+	}
+}
+
+func TestAzureOpenAIExecutorEntraIDTokenIsCached(t *testing.T) {
+	var tokenRequests atomic.Int32
+	var seenAuth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+			tokenRequests.Add(1)
+			body, _ := io.ReadAll(r.Body)
+			if r.URL.Path != "/tenant-1/oauth2/v2.0/token" || !strings.Contains(string(body), "grant_type=client_credentials") || !strings.Contains(string(body), "client_secret=shh") {
+				t.Errorf("unexpected token request %s: %s", r.URL.Path, body)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"token_type":"Bearer","access_token":"entra-token","expires_in":3600}`))
+			return
+		}
+		seenAuth = append(seenAuth, r.Header.Get("Authorization"))
+		if r.Header.Get("api-key") != "" {
+			t.Errorf("api-key header must not be sent with Entra ID auth")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ok\"}}]}\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	exec := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "azure-entra", Provider: "azure-openai", Attributes: map[string]string{
+		"api_key":        "client-1",
+		"base_url":       server.URL,
+		"tenant_id":      "tenant-1",
+		"client_secret":  "shh",
+		"authority_host": server.URL,
+	}}
+	for i := 0; i < 2; i++ {
+		result, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+			Model:   "gpt-4o",
+			Payload: []byte(`{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hello"}]}`),
+		}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+		if err != nil {
+			t.Fatalf("ExecuteStream() error = %v", err)
+		}
+		var text strings.Builder
+		for chunk := range result.Chunks {
+			if chunk.Err != nil {
+				t.Fatalf("stream error = %v", chunk.Err)
+			}
+			payload := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(chunk.Payload)), "data:"))
+			text.WriteString(gjson.Get(payload, "choices.0.delta.content").String())
+		}
+		if text.String() != "ok" {
+			t.Fatalf("streamed text = %q", text.String())
+		}
+	}
+	if got := tokenRequests.Load(); got != 1 {
+		t.Fatalf("token requests = %d, want 1", got)
+	}
+	for _, got := range seenAuth {
+		if got != "Bearer entra-token" {
+			t.Fatalf("Authorization = %q", got)
+		}
+	}
+}
+
+func TestAzureOpenAIExecutorTokenFailureIsUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exec := NewAzureOpenAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "azure-entra", Provider: "azure-openai", Attributes: map[string]string{
+		"api_key":        "client-1",
+		"base_url":       server.URL,
+		"tenant_id":      "tenant-1",
+		"client_secret":  "wrong",
+		"authority_host": server.URL,
+	}}
+	_, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-4o",
+		Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	status, ok := err.(interface{ StatusCode() int })
+	if !ok || status.StatusCode() != http.StatusUnauthorized {
+		t.Fatalf("Execute() error = %v, want 401 status error", err)
+	}
+}
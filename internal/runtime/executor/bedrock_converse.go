@@ -0,0 +1,385 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// buildBedrockConverseRequest converts an OpenAI chat completions body into a Bedrock
+// Converse request. The model is carried in the URL, so it is not part of the body.
+func buildBedrockConverseRequest(body []byte) ([]byte, error) {
+	if !gjson.ValidBytes(body) {
+		return nil, fmt.Errorf("bedrock executor: invalid chat completions payload")
+	}
+	root := gjson.ParseBytes(body)
+
+	var system []map[string]any
+	var messages []map[string]any
+	appendBlocks := func(role string, blocks []map[string]any) {
+		if len(blocks) == 0 {
+			return
+		}
+		// Converse requires alternating roles, so consecutive turns of the same role are merged.
+		if n := len(messages); n > 0 && messages[n-1]["role"] == role {
+			messages[n-1]["content"] = append(messages[n-1]["content"].([]map[string]any), blocks...)
+			return
+		}
+		messages = append(messages, map[string]any{"role": role, "content": blocks})
+	}
+
+	root.Get("messages").ForEach(func(_, msg gjson.Result) bool {
+		switch msg.Get("role").String() {
+		case "system", "developer":
+			if text := openAIContentText(msg.Get("content")); text != "" {
+				system = append(system, map[string]any{"text": text})
+			}
+		case "user":
+			appendBlocks("user", converseContentBlocks(msg.Get("content")))
+		case "assistant":
+			blocks := converseContentBlocks(msg.Get("content"))
+			msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+				input := map[string]any{}
+				if args := call.Get("function.arguments").String(); args != "" {
+					if errUnmarshal := json.Unmarshal([]byte(args), &input); errUnmarshal != nil {
+						input = map[string]any{}
+					}
+				}
+				blocks = append(blocks, map[string]any{"toolUse": map[string]any{
+					"toolUseId": call.Get("id").String(),
+					"name":      call.Get("function.name").String(),
+					"input":     input,
+				}})
+				return true
+			})
+			appendBlocks("assistant", blocks)
+		case "tool":
+			appendBlocks("user", []map[string]any{{"toolResult": map[string]any{
+				"toolUseId": msg.Get("tool_call_id").String(),
+				"content":   []map[string]any{{"text": openAIContentText(msg.Get("content"))}},
+			}}})
+		}
+		return true
+	})
+
+	req := map[string]any{"messages": messages}
+	if messages == nil {
+		req["messages"] = []map[string]any{}
+	}
+	if len(system) > 0 {
+		req["system"] = system
+	}
+
+	inference := map[string]any{}
+	if v := root.Get("max_completion_tokens"); v.Exists() {
+		inference["maxTokens"] = v.Int()
+	} else if v = root.Get("max_tokens"); v.Exists() {
+		inference["maxTokens"] = v.Int()
+	}
+	if v := root.Get("temperature"); v.Exists() {
+		inference["temperature"] = v.Float()
+	}
+	if v := root.Get("top_p"); v.Exists() {
+		inference["topP"] = v.Float()
+	}
+	if stop := root.Get("stop"); stop.Exists() {
+		var sequences []string
+		if stop.IsArray() {
+			stop.ForEach(func(_, s gjson.Result) bool {
+				sequences = append(sequences, s.String())
+				return true
+			})
+		} else if stop.String() != "" {
+			sequences = []string{stop.String()}
+		}
+		if len(sequences) > 0 {
+			inference["stopSequences"] = sequences
+		}
+	}
+	if len(inference) > 0 {
+		req["inferenceConfig"] = inference
+	}
+
+	var tools []map[string]any
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		fn := tool.Get("function")
+		if !fn.Exists() {
+			return true
+		}
+		var schema any = map[string]any{"type": "object", "properties": map[string]any{}}
+		if params := fn.Get("parameters"); params.IsObject() {
+			schema = json.RawMessage(params.Raw)
+		}
+		spec := map[string]any{"name": fn.Get("name").String(), "inputSchema": map[string]any{"json": schema}}
+		if desc := fn.Get("description").String(); desc != "" {
+			spec["description"] = desc
+		}
+		tools = append(tools, map[string]any{"toolSpec": spec})
+		return true
+	})
+	if len(tools) > 0 {
+		toolConfig := map[string]any{"tools": tools}
+		choice := root.Get("tool_choice")
+		switch {
+		case choice.Type == gjson.String && choice.String() == "auto":
+			toolConfig["toolChoice"] = map[string]any{"auto": map[string]any{}}
+		case choice.Type == gjson.String && choice.String() == "required":
+			toolConfig["toolChoice"] = map[string]any{"any": map[string]any{}}
+		case choice.IsObject() && choice.Get("function.name").String() != "":
+			toolConfig["toolChoice"] = map[string]any{"tool": map[string]any{"name": choice.Get("function.name").String()}}
+		}
+		req["toolConfig"] = toolConfig
+	}
+
+	return json.Marshal(req)
+}
+
+// converseContentBlocks converts OpenAI message content into Converse content blocks.
+// Images must be inline data URLs; Converse cannot fetch remote images.
+func converseContentBlocks(content gjson.Result) []map[string]any {
+	if content.Type == gjson.String {
+		if text := content.String(); text != "" {
+			return []map[string]any{{"text": text}}
+		}
+		return nil
+	}
+	var blocks []map[string]any
+	content.ForEach(func(_, part gjson.Result) bool {
+		switch part.Get("type").String() {
+		case "text":
+			if text := part.Get("text").String(); text != "" {
+				blocks = append(blocks, map[string]any{"text": text})
+			}
+		case "image_url":
+			if format, data, ok := parseImageDataURL(part.Get("image_url.url").String()); ok {
+				blocks = append(blocks, map[string]any{"image": map[string]any{
+					"format": format,
+					"source": map[string]any{"bytes": data},
+				}})
+			}
+		}
+		return true
+	})
+	return blocks
+}
+
+// openAIContentText flattens OpenAI message content into plain text.
+func openAIContentText(content gjson.Result) string {
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	var parts []string
+	content.ForEach(func(_, part gjson.Result) bool {
+		if part.Get("type").String() == "text" {
+			parts = append(parts, part.Get("text").String())
+		}
+		return true
+	})
+	return strings.Join(parts, "\n")
+}
+
+// parseImageDataURL splits a base64 data URL into the Converse image format and payload.
+func parseImageDataURL(raw string) (format, data string, ok bool) {
+	rest, found := strings.CutPrefix(raw, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, payload, found := strings.Cut(rest, ",")
+	if !found || !strings.HasSuffix(meta, ";base64") {
+		return "", "", false
+	}
+	mediaType := strings.TrimSuffix(meta, ";base64")
+	format = strings.TrimPrefix(strings.ToLower(mediaType), "image/")
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	switch format {
+	case "png", "jpeg", "gif", "webp":
+		return format, payload, true
+	default:
+		return "", "", false
+	}
+}
+
+// converseFinishReason maps a Converse stopReason to an OpenAI finish_reason.
+func converseFinishReason(stopReason string) string {
+	switch stopReason {
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens", "model_context_window_exceeded":
+		return "length"
+	case "guardrail_intervened", "content_filtered":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// converseUsage converts Converse token usage into an OpenAI usage object.
+func converseUsage(usage gjson.Result) map[string]any {
+	input := usage.Get("inputTokens").Int()
+	output := usage.Get("outputTokens").Int()
+	total := usage.Get("totalTokens").Int()
+	if total == 0 {
+		total = input + output
+	}
+	out := map[string]any{
+		"prompt_tokens":     input,
+		"completion_tokens": output,
+		"total_tokens":      total,
+	}
+	if cached := usage.Get("cacheReadInputTokens").Int(); cached > 0 {
+		out["prompt_tokens_details"] = map[string]any{"cached_tokens": cached}
+	}
+	return out
+}
+
+// convertBedrockConverseResponse converts a Converse response into an OpenAI chat completion.
+func convertBedrockConverseResponse(body []byte, model string) []byte {
+	root := gjson.ParseBytes(body)
+	message := map[string]any{"role": "assistant", "content": nil}
+	var text, reasoning strings.Builder
+	var toolCalls []map[string]any
+	root.Get("output.message.content").ForEach(func(_, block gjson.Result) bool {
+		switch {
+		case block.Get("text").Exists():
+			text.WriteString(block.Get("text").String())
+		case block.Get("reasoningContent.reasoningText.text").Exists():
+			reasoning.WriteString(block.Get("reasoningContent.reasoningText.text").String())
+		case block.Get("toolUse").Exists():
+			args := block.Get("toolUse.input").Raw
+			if args == "" {
+				args = "{}"
+			}
+			toolCalls = append(toolCalls, map[string]any{
+				"id":   block.Get("toolUse.toolUseId").String(),
+				"type": "function",
+				"function": map[string]any{
+					"name":      block.Get("toolUse.name").String(),
+					"arguments": args,
+				},
+			})
+		}
+		return true
+	})
+	if text.Len() > 0 {
+		message["content"] = text.String()
+	}
+	if reasoning.Len() > 0 {
+		message["reasoning_content"] = reasoning.String()
+	}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+	}
+	out := map[string]any{
+		"id":      bedrockCompletionID(),
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       message,
+			"finish_reason": converseFinishReason(root.Get("stopReason").String()),
+		}},
+		"usage": converseUsage(root.Get("usage")),
+	}
+	data, _ := json.Marshal(out)
+	return data
+}
+
+// bedrockConverseStreamState converts converse-stream events into OpenAI chat completion chunks.
+type bedrockConverseStreamState struct {
+	id        string
+	created   int64
+	model     string
+	toolIndex map[int64]int
+}
+
+func newBedrockConverseStreamState(model string) *bedrockConverseStreamState {
+	return &bedrockConverseStreamState{
+		id:        bedrockCompletionID(),
+		created:   time.Now().Unix(),
+		model:     model,
+		toolIndex: make(map[int64]int),
+	}
+}
+
+// translate returns the SSE data lines for one converse-stream event.
+func (s *bedrockConverseStreamState) translate(eventType string, payload []byte) [][]byte {
+	event := gjson.ParseBytes(payload)
+	switch eventType {
+	case "messageStart":
+		return [][]byte{s.chunk(map[string]any{"role": "assistant", "content": ""}, nil, nil)}
+	case "contentBlockStart":
+		toolUse := event.Get("start.toolUse")
+		if !toolUse.Exists() {
+			return nil
+		}
+		index := len(s.toolIndex)
+		s.toolIndex[event.Get("contentBlockIndex").Int()] = index
+		return [][]byte{s.chunk(map[string]any{"tool_calls": []map[string]any{{
+			"index": index,
+			"id":    toolUse.Get("toolUseId").String(),
+			"type":  "function",
+			"function": map[string]any{
+				"name":      toolUse.Get("name").String(),
+				"arguments": "",
+			},
+		}}}, nil, nil)}
+	case "contentBlockDelta":
+		delta := event.Get("delta")
+		switch {
+		case delta.Get("text").Exists():
+			return [][]byte{s.chunk(map[string]any{"content": delta.Get("text").String()}, nil, nil)}
+		case delta.Get("reasoningContent.text").Exists():
+			return [][]byte{s.chunk(map[string]any{"reasoning_content": delta.Get("reasoningContent.text").String()}, nil, nil)}
+		case delta.Get("toolUse.input").Exists():
+			index, ok := s.toolIndex[event.Get("contentBlockIndex").Int()]
+			if !ok {
+				return nil
+			}
+			return [][]byte{s.chunk(map[string]any{"tool_calls": []map[string]any{{
+				"index":    index,
+				"function": map[string]any{"arguments": delta.Get("toolUse.input").String()},
+			}}}, nil, nil)}
+		}
+	case "messageStop":
+		reason := converseFinishReason(event.Get("stopReason").String())
+		return [][]byte{s.chunk(map[string]any{}, &reason, nil)}
+	case "metadata":
+		if usage := event.Get("usage"); usage.Exists() {
+			return [][]byte{s.chunk(nil, nil, converseUsage(usage))}
+		}
+	}
+	return nil
+}
+
+func (s *bedrockConverseStreamState) chunk(delta map[string]any, finishReason *string, usage map[string]any) []byte {
+	out := map[string]any{
+		"id":      s.id,
+		"object":  "chat.completion.chunk",
+		"created": s.created,
+		"model":   s.model,
+	}
+	if delta != nil {
+		choice := map[string]any{"index": 0, "delta": delta, "finish_reason": nil}
+		if finishReason != nil {
+			choice["finish_reason"] = *finishReason
+		}
+		out["choices"] = []map[string]any{choice}
+	} else {
+		out["choices"] = []map[string]any{}
+	}
+	if usage != nil {
+		out["usage"] = usage
+	}
+	data, _ := json.Marshal(out)
+	return append([]byte("data: "), data...)
+}
+
+func bedrockCompletionID() string {
+	return fmt.Sprintf("chatcmpl-bedrock-%d", time.Now().UnixNano())
+}
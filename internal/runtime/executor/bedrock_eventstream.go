@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// bedrockEventStreamMaxMessage bounds a single event-stream message; Bedrock chunks are far smaller.
+const bedrockEventStreamMaxMessage = 16 << 20
+
+// bedrockEventMessage is one decoded application/vnd.amazon.eventstream message.
+type bedrockEventMessage struct {
+	headers map[string]string
+	payload []byte
+}
+
+// messageType returns the :message-type header ("event" or "exception").
+func (m bedrockEventMessage) messageType() string { return m.headers[":message-type"] }
+
+// eventType returns the :event-type header, or :exception-type for exceptions.
+func (m bedrockEventMessage) eventType() string {
+	if m.messageType() == "exception" {
+		return m.headers[":exception-type"]
+	}
+	return m.headers[":event-type"]
+}
+
+// bedrockEventStreamReader decodes the binary AWS event stream framing used by
+// invoke-model-with-response-stream and converse-stream.
+type bedrockEventStreamReader struct {
+	r io.Reader
+}
+
+func newBedrockEventStreamReader(r io.Reader) *bedrockEventStreamReader {
+	return &bedrockEventStreamReader{r: r}
+}
+
+// Next returns the next message, or io.EOF once the stream ends cleanly between messages.
+func (d *bedrockEventStreamReader) Next() (bedrockEventMessage, error) {
+	var prelude [12]byte
+	if _, errRead := io.ReadFull(d.r, prelude[:]); errRead != nil {
+		if errRead == io.ErrUnexpectedEOF {
+			return bedrockEventMessage{}, fmt.Errorf("bedrock event stream: truncated prelude")
+		}
+		return bedrockEventMessage{}, errRead
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return bedrockEventMessage{}, fmt.Errorf("bedrock event stream: prelude checksum mismatch")
+	}
+	if totalLen < 16 || totalLen > bedrockEventStreamMaxMessage || headersLen > totalLen-16 {
+		return bedrockEventMessage{}, fmt.Errorf("bedrock event stream: invalid message length %d", totalLen)
+	}
+
+	rest := make([]byte, totalLen-12)
+	if _, errRead := io.ReadFull(d.r, rest); errRead != nil {
+		return bedrockEventMessage{}, fmt.Errorf("bedrock event stream: truncated message: %w", errRead)
+	}
+	body := rest[:len(rest)-4]
+	crc := crc32.NewIEEE()
+	crc.Write(prelude[:])
+	crc.Write(body)
+	if crc.Sum32() != binary.BigEndian.Uint32(rest[len(rest)-4:]) {
+		return bedrockEventMessage{}, fmt.Errorf("bedrock event stream: message checksum mismatch")
+	}
+
+	headers, errHeaders := parseBedrockEventHeaders(body[:headersLen])
+	if errHeaders != nil {
+		return bedrockEventMessage{}, errHeaders
+	}
+	return bedrockEventMessage{headers: headers, payload: body[headersLen:]}, nil
+}
+
+// parseBedrockEventHeaders decodes the header block, keeping string-valued headers.
+func parseBedrockEventHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 1+nameLen+1 {
+			return nil, fmt.Errorf("bedrock event stream: truncated header name")
+		}
+		name := string(data[1 : 1+nameLen])
+		valueType := data[1+nameLen]
+		data = data[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true/false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // int
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(data) < 2 {
+				return nil, fmt.Errorf("bedrock event stream: truncated header %q", name)
+			}
+			size = 2 + int(binary.BigEndian.Uint16(data[:2]))
+		default:
+			return nil, fmt.Errorf("bedrock event stream: unknown header type %d", valueType)
+		}
+		if len(data) < size {
+			return nil, fmt.Errorf("bedrock event stream: truncated header %q", name)
+		}
+		if valueType == 7 {
+			headers[name] = string(data[2:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
@@ -0,0 +1,484 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	bedrockAnthropicVersion = "bedrock-2023-05-31"
+	bedrockUserAgent        = "cli-proxy-bedrock"
+)
+
+// BedrockExecutor executes requests against AWS Bedrock. Anthropic models use the
+// invoke-model APIs with the native Messages format; every other model family goes
+// through the Converse APIs, translated from the OpenAI chat completions format.
+type BedrockExecutor struct {
+	cfg *config.Config
+	now func() time.Time
+}
+
+// NewBedrockExecutor creates an executor for the bedrock provider.
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor {
+	return &BedrockExecutor{cfg: cfg, now: time.Now}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// bedrockTarget is the resolved endpoint and credential for one auth entry.
+type bedrockTarget struct {
+	baseURL string
+	region  string
+	apiKey  string
+	creds   bedrockCredentials
+}
+
+func bedrockTargetForAuth(auth *cliproxyauth.Auth) bedrockTarget {
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	target := bedrockTarget{
+		baseURL: strings.TrimSuffix(strings.TrimSpace(attrs["base_url"]), "/"),
+		region:  strings.TrimSpace(attrs["aws_region"]),
+	}
+	if target.region == "" {
+		target.region = config.DefaultBedrockRegion
+	}
+	if target.baseURL == "" {
+		target.baseURL = "https://bedrock-runtime." + target.region + ".amazonaws.com"
+	}
+	key := strings.TrimSpace(attrs["api_key"])
+	if secret := strings.TrimSpace(attrs["aws_secret_access_key"]); secret != "" {
+		target.creds = bedrockCredentials{
+			accessKeyID:     key,
+			secretAccessKey: secret,
+			sessionToken:    strings.TrimSpace(attrs["aws_session_token"]),
+		}
+	} else {
+		target.apiKey = key
+	}
+	return target
+}
+
+// authorize adds the bearer API key or a SigV4 signature. It must run after every
+// other header is set because the signature covers them.
+func (t bedrockTarget) authorize(req *http.Request, body []byte, now time.Time) {
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+		return
+	}
+	if t.creds.accessKeyID != "" {
+		signBedrockRequest(req, body, t.creds, t.region, now)
+	}
+}
+
+// isBedrockAnthropicModel reports whether the model ID or inference profile serves Claude.
+func isBedrockAnthropicModel(model string) bool {
+	model = strings.ToLower(model)
+	return strings.Contains(model, "anthropic.") || strings.Contains(model, "claude")
+}
+
+// PrepareRequest injects Bedrock credentials into the outgoing HTTP request.
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(req, attrs)
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, errRead := io.ReadAll(req.Body)
+		if errRead != nil {
+			return fmt.Errorf("bedrock executor: read request body: %w", errRead)
+		}
+		_ = req.Body.Close()
+		body = data
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+	bedrockTargetForAuth(auth).authorize(req, body, e.now())
+	return nil
+}
+
+// HttpRequest injects Bedrock credentials into the request and executes it.
+func (e *BedrockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bedrock executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// prepareBody translates the request into the Bedrock payload for the model family.
+func (e *BedrockExecutor) prepareBody(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) (to sdktranslator.Format, translated, body []byte, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to = sdktranslator.FromString("openai")
+	if isBedrockAnthropicModel(baseModel) {
+		to = sdktranslator.FromString("claude")
+	}
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := helps.TranslateRequestWithCodexMultiAgentV2(ctx, opts.Headers, e.cfg, from, to, baseModel, originalPayload, stream)
+	translated = helps.TranslateRequestWithCodexMultiAgentV2(ctx, opts.Headers, e.cfg, from, to, baseModel, req.Payload, stream)
+	translated, err = thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return to, nil, nil, err
+	}
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	requestPath := helps.PayloadRequestPath(opts)
+	translated = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", translated, originalTranslated, requestedModel, requestPath, opts.Headers)
+	translated = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), translated)
+
+	if to.String() != "claude" {
+		body, err = buildBedrockConverseRequest(translated)
+		return to, translated, body, err
+	}
+	// invoke-model takes the Messages body with the model in the URL and betas in the body.
+	body, _ = sjson.DeleteBytes(translated, "model")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	if betas := bedrockAnthropicBetas(opts.Headers); len(betas) > 0 && !gjson.GetBytes(body, "anthropic_beta").Exists() {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	return to, translated, body, nil
+}
+
+func bedrockAnthropicBetas(headers http.Header) []string {
+	var betas []string
+	for _, value := range headers.Values("Anthropic-Beta") {
+		for _, beta := range strings.Split(value, ",") {
+			if beta = strings.TrimSpace(beta); beta != "" {
+				betas = append(betas, beta)
+			}
+		}
+	}
+	return betas
+}
+
+// bedrockEndpoint returns the runtime URL for the model and action.
+func bedrockEndpoint(target bedrockTarget, model string, anthropic, stream bool) string {
+	action := "converse"
+	switch {
+	case anthropic && stream:
+		action = "invoke-with-response-stream"
+	case anthropic:
+		action = "invoke"
+	case stream:
+		action = "converse-stream"
+	}
+	return target.baseURL + "/model/" + awsURIEncode(model) + "/" + action
+}
+
+func (e *BedrockExecutor) send(ctx context.Context, auth *cliproxyauth.Auth, reporter *helps.UsageReporter, url string, body []byte, stream bool) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	httpReq.Header.Set("User-Agent", bedrockUserAgent)
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	bedrockTargetForAuth(auth).authorize(httpReq, body, e.now())
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient = reporter.TrackHTTPClient(httpClient)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return httpResp, nil
+}
+
+// Execute performs a non-streaming request against Bedrock.
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if isEndpointAlt(opts.Alt) {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "bedrock executor: endpoint not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	to, translated, body, err := e.prepareBody(ctx, auth, req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+	reporter.SetTranslatedReasoningEffort(translated, to.String())
+	anthropic := to.String() == "claude"
+
+	url := bedrockEndpoint(bedrockTargetForAuth(auth), baseModel, anthropic, false)
+	httpResp, err := e.send(ctx, auth, reporter, url, body, false)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+	if anthropic {
+		reporter.Publish(ctx, helps.ParseClaudeUsage(data))
+	} else {
+		data = convertBedrockConverseResponse(data, req.Model)
+		reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	}
+	reporter.EnsurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, cliproxyexecutor.ResponseFormatOrSource(opts), req.Model, opts.OriginalRequest, translated, data, &param)
+	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
+}
+
+// ExecuteStream performs a streaming request against Bedrock and converts the binary
+// event stream into the SSE lines expected by the response translators.
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if isEndpointAlt(opts.Alt) {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "bedrock executor: endpoint not supported"}
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	to, translated, body, err := e.prepareBody(ctx, auth, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	reporter.SetTranslatedReasoningEffort(translated, to.String())
+	anthropic := to.String() == "claude"
+	responseFormat := cliproxyexecutor.ResponseFormatOrSource(opts)
+
+	url := bedrockEndpoint(bedrockTargetForAuth(auth), baseModel, anthropic, true)
+	httpResp, err := e.send(ctx, auth, reporter, url, body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("bedrock executor: close response body error: %v", errClose)
+			}
+		}()
+		send := func(chunk cliproxyexecutor.StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		fail := func(errStream error) {
+			helps.RecordAPIResponseError(ctx, e.cfg, errStream)
+			reporter.PublishFailure(ctx, errStream)
+			send(cliproxyexecutor.StreamChunk{Err: errStream})
+		}
+
+		var param any
+		var streamUsage helps.StreamUsageBuffer
+		converse := newBedrockConverseStreamState(req.Model)
+		passthrough := anthropic && responseFormat == to
+		forward := func(lines [][]byte) bool {
+			for _, line := range lines {
+				helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+				if anthropic {
+					if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+						reporter.Publish(ctx, detail)
+					}
+				} else {
+					streamUsage.ObserveOpenAIStream(line)
+				}
+				if passthrough {
+					continue
+				}
+				chunks := sdktranslator.TranslateStream(ctx, to, responseFormat, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+				for i := range chunks {
+					if !send(cliproxyexecutor.StreamChunk{Payload: chunks[i]}) {
+						return false
+					}
+				}
+			}
+			if passthrough && len(lines) > 0 {
+				event := bytes.Join(lines, []byte("\n"))
+				return send(cliproxyexecutor.StreamChunk{Payload: append(event, '\n', '\n')})
+			}
+			return true
+		}
+
+		reader := newBedrockEventStreamReader(bufio.NewReader(httpResp.Body))
+		for {
+			msg, errNext := reader.Next()
+			if errNext == io.EOF {
+				break
+			}
+			if errNext != nil {
+				fail(errNext)
+				return
+			}
+			if msg.messageType() == "exception" {
+				fail(statusErr{code: bedrockExceptionStatus(msg.eventType()), msg: string(msg.payload)})
+				return
+			}
+			var lines [][]byte
+			if anthropic {
+				lines = bedrockInvokeStreamLines(msg.payload)
+			} else {
+				lines = converse.translate(msg.eventType(), msg.payload)
+			}
+			if !forward(lines) {
+				return
+			}
+		}
+		if !anthropic {
+			streamUsage.Publish(ctx, reporter)
+			if !forward([][]byte{[]byte("data: [DONE]")}) {
+				return
+			}
+		}
+		reporter.EnsurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// bedrockInvokeStreamLines unwraps an invoke-with-response-stream chunk into the
+// event and data lines of the Anthropic SSE stream.
+func bedrockInvokeStreamLines(payload []byte) [][]byte {
+	encoded := gjson.GetBytes(payload, "bytes").String()
+	if encoded == "" {
+		return nil
+	}
+	event, errDecode := base64.StdEncoding.DecodeString(encoded)
+	if errDecode != nil || !gjson.ValidBytes(event) {
+		return nil
+	}
+	event, _ = sjson.DeleteBytes(event, "amazon-bedrock-invocationMetrics")
+	eventType := gjson.GetBytes(event, "type").String()
+	return [][]byte{
+		[]byte("event: " + eventType),
+		append([]byte("data: "), event...),
+	}
+}
+
+// bedrockExceptionStatus maps event-stream exception types to HTTP status codes.
+func bedrockExceptionStatus(exceptionType string) int {
+	switch exceptionType {
+	case "throttlingException":
+		return http.StatusTooManyRequests
+	case "validationException":
+		return http.StatusBadRequest
+	case "serviceUnavailableException":
+		return http.StatusServiceUnavailable
+	case "modelTimeoutException":
+		return http.StatusGatewayTimeout
+	case "internalServerException":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// CountTokens estimates the prompt size locally; Bedrock has no token counting API for every model family.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := helps.TranslateRequestWithCodexMultiAgentV2(ctx, opts.Headers, e.cfg, from, to, baseModel, req.Payload, false)
+
+	enc, err := helps.TokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("bedrock executor: token counting failed: %w", err)
+	}
+	usageJSON := helps.BuildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, cliproxyexecutor.ResponseFormatOrSource(opts), count, usageJSON)
+	return cliproxyexecutor.Response{Payload: translatedUsage}, nil
+}
+
+// Refresh is a no-op for static Bedrock credentials.
+func (e *BedrockExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	if refreshed, handled, err := helps.RefreshAuthViaHome(ctx, e.cfg, auth); handled {
+		return refreshed, err
+	}
+	return auth, nil
+}
@@ -0,0 +1,269 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// encodeBedrockEventFrame builds one event-stream message with string headers.
+func encodeBedrockEventFrame(headers map[string]string, payload []byte) []byte {
+	var hdr bytes.Buffer
+	for name, value := range headers {
+		hdr.WriteByte(byte(len(name)))
+		hdr.WriteString(name)
+		hdr.WriteByte(7)
+		_ = binary.Write(&hdr, binary.BigEndian, uint16(len(value)))
+		hdr.WriteString(value)
+	}
+	total := 12 + hdr.Len() + len(payload) + 4
+	var msg bytes.Buffer
+	_ = binary.Write(&msg, binary.BigEndian, uint32(total))
+	_ = binary.Write(&msg, binary.BigEndian, uint32(hdr.Len()))
+	_ = binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(hdr.Bytes())
+	msg.Write(payload)
+	_ = binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func bedrockEvent(eventType, payload string) []byte {
+	return encodeBedrockEventFrame(map[string]string{
+		":message-type": "event",
+		":event-type":   eventType,
+		":content-type": "application/json",
+	}, []byte(payload))
+}
+
+func TestBedrockEventStreamReaderDecodesFrames(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(bedrockEvent("messageStart", `{"role":"assistant"}`))
+	stream.Write(encodeBedrockEventFrame(map[string]string{
+		":message-type":   "exception",
+		":exception-type": "throttlingException",
+	}, []byte(`{"message":"slow down"}`)))
+
+	reader := newBedrockEventStreamReader(&stream)
+	first, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if first.messageType() != "event" || first.eventType() != "messageStart" || string(first.payload) != `{"role":"assistant"}` {
+		t.Fatalf("first message = %+v", first)
+	}
+	second, err := reader.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if second.messageType() != "exception" || second.eventType() != "throttlingException" {
+		t.Fatalf("second message = %+v", second)
+	}
+	if got := bedrockExceptionStatus(second.eventType()); got != http.StatusTooManyRequests {
+		t.Fatalf("bedrockExceptionStatus() = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	if _, err = reader.Next(); err != io.EOF {
+		t.Fatalf("Next() at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestBedrockEventStreamReaderRejectsCorruptFrame(t *testing.T) {
+	frame := bedrockEvent("messageStop", `{"stopReason":"end_turn"}`)
+	frame[len(frame)-6] ^= 0xFF
+	if _, err := newBedrockEventStreamReader(bytes.NewReader(frame)).Next(); err == nil {
+		t.Fatal("Next() error = nil, want checksum error")
+	}
+}
+
+func TestSignBedrockRequestAddsSigV4Headers(t *testing.T) {
+	body := []byte(`{"messages":[]}`)
+	req, err := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-west-2.amazonaws.com/model/us.meta.llama3-3-70b-instruct-v1%3A0/converse", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	now := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	creds := bedrockCredentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "secret", sessionToken: "token"}
+	signBedrockRequest(req, body, creds, "us-west-2", now)
+
+	if got := req.Header.Get("X-Amz-Date"); got != "20250304T050607Z" {
+		t.Fatalf("X-Amz-Date = %q", got)
+	}
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Fatalf("X-Amz-Security-Token = %q", got)
+	}
+	authz := req.Header.Get("Authorization")
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20250304/us-west-2/bedrock/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature="
+	if !strings.HasPrefix(authz, wantPrefix) {
+		t.Fatalf("Authorization = %q, want prefix %q", authz, wantPrefix)
+	}
+
+	again, _ := http.NewRequest(http.MethodPost, req.URL.String(), bytes.NewReader(body))
+	again.Header.Set("Content-Type", "application/json")
+	signBedrockRequest(again, body, creds, "us-west-2", now)
+	if again.Header.Get("Authorization") != authz {
+		t.Fatal("signature is not deterministic for identical requests")
+	}
+	if got := bedrockCanonicalURI(req.URL.EscapedPath()); got != "/model/us.meta.llama3-3-70b-instruct-v1%253A0/converse" {
+		t.Fatalf("bedrockCanonicalURI() = %q", got)
+	}
+}
+
+func TestBuildBedrockConverseRequest(t *testing.T) {
+	body := []byte(`{
+		"model":"us.meta.llama3-3-70b-instruct-v1:0",
+		"max_tokens":256,
+		"temperature":0.2,
+		"messages":[
+			{"role":"system","content":"Be brief."},
+			{"role":"user","content":"What is the weather?"},
+			{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}}]},
+			{"role":"tool","tool_call_id":"call_1","content":"Sunny"}
+		],
+		"tools":[{"type":"function","function":{"name":"weather","parameters":{"type":"object"}}}]
+	}`)
+	out, err := buildBedrockConverseRequest(body)
+	if err != nil {
+		t.Fatalf("buildBedrockConverseRequest() error = %v", err)
+	}
+	if got := gjson.GetBytes(out, "system.0.text").String(); got != "Be brief." {
+		t.Fatalf("system = %q, body %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "inferenceConfig.maxTokens").Int(); got != 256 {
+		t.Fatalf("inferenceConfig.maxTokens = %d", got)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content.0.toolUse.input.city").String(); got != "Paris" {
+		t.Fatalf("toolUse input city = %q, body %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "messages.2.role").String(); got != "user" {
+		t.Fatalf("tool result role = %q", got)
+	}
+	if got := gjson.GetBytes(out, "messages.2.content.0.toolResult.toolUseId").String(); got != "call_1" {
+		t.Fatalf("toolResult id = %q, body %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "toolConfig.tools.0.toolSpec.name").String(); got != "weather" {
+		t.Fatalf("toolSpec name = %q", got)
+	}
+}
+
+func bedrockTestAuth(baseURL string, attrs map[string]string) *cliproxyauth.Auth {
+	merged := map[string]string{"base_url": baseURL, "aws_region": "us-west-2"}
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	return &cliproxyauth.Auth{ID: "bedrock-test", Provider: "bedrock", Attributes: merged}
+}
+
+func TestBedrockExecutorExecuteInvokesAnthropicModel(t *testing.T) {
+	var seenPath, seenAuth string
+	var seenBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenPath = r.URL.EscapedPath()
+		seenAuth = r.Header.Get("Authorization")
+		seenBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude","content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	model := "us.anthropic.claude-sonnet-4-5-20250929-v1:0"
+	exec := NewBedrockExecutor(&config.Config{})
+	resp, err := exec.Execute(context.Background(), bedrockTestAuth(server.URL, map[string]string{"api_key": "bedrock-key"}), cliproxyexecutor.Request{
+		Model:   model,
+		Payload: []byte(`{"model":"` + model + `","max_tokens":64,"messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if seenPath != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%3A0/invoke" {
+		t.Fatalf("path = %q", seenPath)
+	}
+	if seenAuth != "Bearer bedrock-key" {
+		t.Fatalf("Authorization = %q", seenAuth)
+	}
+	if got := gjson.GetBytes(seenBody, "anthropic_version").String(); got != "bedrock-2023-05-31" {
+		t.Fatalf("anthropic_version = %q, body %s", got, seenBody)
+	}
+	if gjson.GetBytes(seenBody, "model").Exists() || gjson.GetBytes(seenBody, "stream").Exists() {
+		t.Fatalf("model/stream must be stripped, body %s", seenBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "content.0.text").String(); got != "hi" {
+		t.Fatalf("response text = %q, payload %s", got, resp.Payload)
+	}
+}
+
+func TestBedrockExecutorExecuteStreamConverseWithSigV4(t *testing.T) {
+	var seenPath, seenAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenPath = r.URL.EscapedPath()
+		seenAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		_, _ = w.Write(bedrockEvent("messageStart", `{"role":"assistant"}`))
+		_, _ = w.Write(bedrockEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hel"}}`))
+		_, _ = w.Write(bedrockEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"lo"}}`))
+		_, _ = w.Write(bedrockEvent("messageStop", `{"stopReason":"end_turn"}`))
+		_, _ = w.Write(bedrockEvent("metadata", `{"usage":{"inputTokens":4,"outputTokens":2,"totalTokens":6}}`))
+	}))
+	defer server.Close()
+
+	model := "us.meta.llama3-3-70b-instruct-v1:0"
+	exec := NewBedrockExecutor(&config.Config{})
+	auth := bedrockTestAuth(server.URL, map[string]string{"api_key": "AKIDEXAMPLE", "aws_secret_access_key": "secret"})
+	result, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   model,
+		Payload: []byte(`{"model":"` + model + `","stream":true,"messages":[{"role":"user","content":"hello"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var text strings.Builder
+	var finish string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(chunk.Payload)), "data:"))
+		text.WriteString(gjson.Get(payload, "choices.0.delta.content").String())
+		if reason := gjson.Get(payload, "choices.0.finish_reason").String(); reason != "" {
+			finish = reason
+		}
+	}
+	if seenPath != "/model/us.meta.llama3-3-70b-instruct-v1%3A0/converse-stream" {
+		t.Fatalf("path = %q", seenPath)
+	}
+	if !strings.HasPrefix(seenAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+		t.Fatalf("Authorization = %q", seenAuth)
+	}
+	if text.String() != "Hello" {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+	if finish != "stop" {
+		t.Fatalf("finish_reason = %q", finish)
+	}
+}
+
+func TestBedrockInvokeStreamLinesUnwrapsChunk(t *testing.T) {
+	event := `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"x"},"amazon-bedrock-invocationMetrics":{"inputTokenCount":1}}`
+	payload := []byte(`{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`)
+	lines := bedrockInvokeStreamLines(payload)
+	if len(lines) != 2 || string(lines[0]) != "event: content_block_delta" {
+		t.Fatalf("lines = %q", lines)
+	}
+	if strings.Contains(string(lines[1]), "invocationMetrics") {
+		t.Fatalf("invocation metrics not stripped: %s", lines[1])
+	}
+}
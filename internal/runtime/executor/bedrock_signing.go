@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	bedrockSigningService   = "bedrock"
+	bedrockSigningAlgorithm = "AWS4-HMAC-SHA256"
+	bedrockAmzDateFormat    = "20060102T150405Z"
+)
+
+// bedrockCredentials holds the IAM access key pair used for SigV4 signing.
+type bedrockCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// signBedrockRequest signs req in place with AWS Signature Version 4 for the bedrock service.
+// body must be the exact request payload.
+func signBedrockRequest(req *http.Request, body []byte, creds bedrockCredentials, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(bedrockAmzDateFormat)
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	canonicalHeaders, signedHeaders := bedrockCanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		bedrockCanonicalURI(req.URL.EscapedPath()),
+		bedrockCanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + bedrockSigningService + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		bedrockSigningAlgorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, bedrockSigningService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", bedrockSigningAlgorithm+" Credential="+creds.accessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// bedrockCanonicalHeaders returns the canonical header block and signed header list.
+// Host is always signed; hop-by-hop and tracing headers are left out.
+func bedrockCanonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": strings.TrimSpace(host)}
+	for name, vals := range req.Header {
+		lower := strings.ToLower(name)
+		switch lower {
+		case "authorization", "user-agent", "connection", "expect", "x-amzn-trace-id":
+			continue
+		}
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(values[name])
+		b.WriteByte('\n')
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// bedrockCanonicalURI encodes each segment of the already escaped path once more,
+// as SigV4 requires for every service except S3.
+func bedrockCanonicalURI(escapedPath string) string {
+	if escapedPath == "" {
+		return "/"
+	}
+	segments := strings.Split(escapedPath, "/")
+	for i := range segments {
+		segments[i] = awsURIEncode(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+func bedrockCanonicalQuery(query map[string][]string) string {
+	if len(query) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(query))
+	for key, vals := range query {
+		for _, v := range vals {
+			pairs = append(pairs, awsURIEncode(key)+"="+awsURIEncode(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode percent-encodes every byte except the RFC 3986 unreserved characters.
+func awsURIEncode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0x0F])
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -2979,3 +2979,48 @@ func TestEnsureClaudeThinkingDisplay_SkipsWhenThinkingMissing(t *testing.T) {
 		t.Fatalf("thinking should remain absent: %s", out)
 	}
 }
+
+func TestClaudeExecutor_CountTokens_EstimatesWhenUpstreamLacksEndpoint(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		estimate bool
+	}{
+		{name: "generic not found", status: http.StatusNotFound, body: `{"error":"Not Found"}`, estimate: true},
+		{name: "not implemented", status: http.StatusNotImplemented, body: `not implemented`, estimate: true},
+		{name: "unknown model", status: http.StatusNotFound, body: `{"type":"error","error":{"type":"not_found_error","message":"model: glm-4.6"}}`},
+		{name: "bad request", status: http.StatusBadRequest, body: `{"error":"bad"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			executor := NewClaudeExecutor(&config.Config{})
+			auth := &cliproxyauth.Auth{Attributes: map[string]string{
+				"api_key":  "key-123",
+				"base_url": server.URL,
+			}}
+			resp, err := executor.CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+				Model:   "glm-4.6",
+				Payload: []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hello there"}]}]}`),
+			}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+			if !tc.estimate {
+				if err == nil {
+					t.Fatalf("CountTokens() = %s, want upstream error", resp.Payload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CountTokens() error = %v, want local estimate", err)
+			}
+			if got := gjson.GetBytes(resp.Payload, "input_tokens").Int(); got <= 0 {
+				t.Fatalf("input_tokens = %d, want positive estimate: %s", got, resp.Payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// buildCohereChatRequest converts an OpenAI chat completions body into a Cohere v2 chat
+// request. documents carries the RAG documents for grounded generation; Cohere accepts
+// plain strings or {"id", "data"} objects, so they are forwarded unchanged.
+func buildCohereChatRequest(body []byte, documents gjson.Result, model string, stream bool) ([]byte, error) {
+	if !gjson.ValidBytes(body) {
+		return nil, fmt.Errorf("cohere executor: invalid chat completions payload")
+	}
+	root := gjson.ParseBytes(body)
+
+	messages := make([]map[string]any, 0)
+	root.Get("messages").ForEach(func(_, msg gjson.Result) bool {
+		switch msg.Get("role").String() {
+		case "system", "developer":
+			if text := openAIContentText(msg.Get("content")); text != "" {
+				messages = append(messages, map[string]any{"role": "system", "content": text})
+			}
+		case "user":
+			if content := cohereUserContent(msg.Get("content")); content != nil {
+				messages = append(messages, map[string]any{"role": "user", "content": content})
+			}
+		case "assistant":
+			// Earlier reasoning is not replayed; Cohere only accepts text and tool calls here.
+			out := map[string]any{"role": "assistant"}
+			if text := openAIContentText(msg.Get("content")); text != "" {
+				out["content"] = text
+			}
+			var toolCalls []map[string]any
+			msg.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+				args := call.Get("function.arguments").String()
+				if args == "" {
+					args = "{}"
+				}
+				toolCalls = append(toolCalls, map[string]any{
+					"id":   call.Get("id").String(),
+					"type": "function",
+					"function": map[string]any{
+						"name":      call.Get("function.name").String(),
+						"arguments": args,
+					},
+				})
+				return true
+			})
+			if len(toolCalls) > 0 {
+				out["tool_calls"] = toolCalls
+			}
+			if len(out) > 1 {
+				messages = append(messages, out)
+			}
+		case "tool":
+			messages = append(messages, map[string]any{
+				"role":         "tool",
+				"tool_call_id": msg.Get("tool_call_id").String(),
+				"content":      openAIContentText(msg.Get("content")),
+			})
+		}
+		return true
+	})
+
+	req := map[string]any{"model": model, "messages": messages, "stream": stream}
+	if documents.IsArray() && len(documents.Array()) > 0 {
+		req["documents"] = json.RawMessage(documents.Raw)
+	}
+	if v := root.Get("max_completion_tokens"); v.Exists() {
+		req["max_tokens"] = v.Int()
+	} else if v = root.Get("max_tokens"); v.Exists() {
+		req["max_tokens"] = v.Int()
+	}
+	if v := root.Get("temperature"); v.Exists() {
+		req["temperature"] = v.Float()
+	}
+	if v := root.Get("top_p"); v.Exists() {
+		req["p"] = v.Float()
+	}
+	if v := root.Get("top_k"); v.Exists() {
+		req["k"] = v.Int()
+	}
+	if v := root.Get("seed"); v.Exists() {
+		req["seed"] = v.Int()
+	}
+	if v := root.Get("frequency_penalty"); v.Exists() {
+		req["frequency_penalty"] = v.Float()
+	}
+	if v := root.Get("presence_penalty"); v.Exists() {
+		req["presence_penalty"] = v.Float()
+	}
+	if stop := root.Get("stop"); stop.Exists() {
+		var sequences []string
+		if stop.IsArray() {
+			stop.ForEach(func(_, s gjson.Result) bool {
+				sequences = append(sequences, s.String())
+				return true
+			})
+		} else if stop.String() != "" {
+			sequences = []string{stop.String()}
+		}
+		if len(sequences) > 0 {
+			req["stop_sequences"] = sequences
+		}
+	}
+
+	switch format := root.Get("response_format"); format.Get("type").String() {
+	case "json_object":
+		req["response_format"] = map[string]any{"type": "json_object"}
+	case "json_schema":
+		out := map[string]any{"type": "json_object"}
+		if schema := format.Get("json_schema.schema"); schema.IsObject() {
+			out["json_schema"] = json.RawMessage(schema.Raw)
+		}
+		req["response_format"] = out
+	}
+
+	var tools []map[string]any
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		fn := tool.Get("function")
+		if !fn.Exists() {
+			return true
+		}
+		var schema any = map[string]any{"type": "object", "properties": map[string]any{}}
+		if params := fn.Get("parameters"); params.IsObject() {
+			schema = json.RawMessage(params.Raw)
+		}
+		spec := map[string]any{"name": fn.Get("name").String(), "parameters": schema}
+		if desc := fn.Get("description").String(); desc != "" {
+			spec["description"] = desc
+		}
+		tools = append(tools, map[string]any{"type": "function", "function": spec})
+		return true
+	})
+	if len(tools) > 0 {
+		req["tools"] = tools
+		// Cohere cannot force a named tool, so a specific choice only requires some tool call.
+		choice := root.Get("tool_choice")
+		switch {
+		case choice.Type == gjson.String && choice.String() == "required":
+			req["tool_choice"] = "REQUIRED"
+		case choice.Type == gjson.String && choice.String() == "none":
+			req["tool_choice"] = "NONE"
+		case choice.IsObject() && choice.Get("function.name").String() != "":
+			req["tool_choice"] = "REQUIRED"
+		}
+	}
+
+	return json.Marshal(req)
+}
+
+// cohereUserContent converts OpenAI user content into Cohere content. Text-only content is
+// sent as a string; image parts are kept for the vision models.
+func cohereUserContent(content gjson.Result) any {
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	var parts []map[string]any
+	hasImage := false
+	content.ForEach(func(_, part gjson.Result) bool {
+		switch part.Get("type").String() {
+		case "text":
+			parts = append(parts, map[string]any{"type": "text", "text": part.Get("text").String()})
+		case "image_url":
+			if url := part.Get("image_url.url").String(); url != "" {
+				hasImage = true
+				parts = append(parts, map[string]any{"type": "image_url", "image_url": map[string]any{"url": url}})
+			}
+		}
+		return true
+	})
+	if !hasImage {
+		if text := openAIContentText(content); text != "" {
+			return text
+		}
+		return nil
+	}
+	return parts
+}
+
+// cohereFinishReason maps a Cohere finish_reason to an OpenAI finish_reason.
+func cohereFinishReason(reason string) string {
+	switch reason {
+	case "TOOL_CALL":
+		return "tool_calls"
+	case "MAX_TOKENS":
+		return "length"
+	case "ERROR_TOXIC":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// cohereUsage converts Cohere token usage into an OpenAI usage object. Billed units are the
+// fallback when the raw token counts are absent.
+func cohereUsage(usage gjson.Result) map[string]any {
+	tokens := usage.Get("tokens")
+	if !tokens.Exists() {
+		tokens = usage.Get("billed_units")
+	}
+	input := tokens.Get("input_tokens").Int()
+	output := tokens.Get("output_tokens").Int()
+	return map[string]any{
+		"prompt_tokens":     input,
+		"completion_tokens": output,
+		"total_tokens":      input + output,
+	}
+}
+
+// cohereCitation converts a Cohere citation into the citation object returned on the
+// assistant message. Sources keep their type and ID so clients can match them to the
+// documents or tool results they supplied.
+func cohereCitation(citation gjson.Result) map[string]any {
+	sources := make([]map[string]any, 0)
+	citation.Get("sources").ForEach(func(_, source gjson.Result) bool {
+		sources = append(sources, map[string]any{
+			"type": source.Get("type").String(),
+			"id":   source.Get("id").String(),
+		})
+		return true
+	})
+	return map[string]any{
+		"start":   citation.Get("start").Int(),
+		"end":     citation.Get("end").Int(),
+		"text":    citation.Get("text").String(),
+		"sources": sources,
+	}
+}
+
+// convertCohereChatResponse converts a Cohere v2 chat response into an OpenAI chat completion.
+// Citations from grounded generation are returned as message.citations.
+func convertCohereChatResponse(body []byte, model string) []byte {
+	root := gjson.ParseBytes(body)
+	message := map[string]any{"role": "assistant", "content": nil}
+	var text, reasoning strings.Builder
+	root.Get("message.content").ForEach(func(_, part gjson.Result) bool {
+		switch part.Get("type").String() {
+		case "text":
+			text.WriteString(part.Get("text").String())
+		case "thinking":
+			reasoning.WriteString(part.Get("thinking").String())
+		}
+		return true
+	})
+	if text.Len() > 0 {
+		message["content"] = text.String()
+	}
+	if reasoning.Len() > 0 {
+		message["reasoning_content"] = reasoning.String()
+	}
+	var toolCalls []map[string]any
+	root.Get("message.tool_calls").ForEach(func(_, call gjson.Result) bool {
+		args := call.Get("function.arguments").String()
+		if args == "" {
+			args = "{}"
+		}
+		toolCalls = append(toolCalls, map[string]any{
+			"id":   call.Get("id").String(),
+			"type": "function",
+			"function": map[string]any{
+				"name":      call.Get("function.name").String(),
+				"arguments": args,
+			},
+		})
+		return true
+	})
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+	}
+	var citations []map[string]any
+	root.Get("message.citations").ForEach(func(_, citation gjson.Result) bool {
+		citations = append(citations, cohereCitation(citation))
+		return true
+	})
+	if len(citations) > 0 {
+		message["citations"] = citations
+	}
+	id := root.Get("id").String()
+	if id == "" {
+		id = cohereCompletionID()
+	}
+	out := map[string]any{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       message,
+			"finish_reason": cohereFinishReason(root.Get("finish_reason").String()),
+		}},
+		"usage": cohereUsage(root.Get("usage")),
+	}
+	data, _ := json.Marshal(out)
+	return data
+}
+
+// cohereChatStreamState converts Cohere v2 chat stream events into OpenAI chat completion chunks.
+type cohereChatStreamState struct {
+	id        string
+	created   int64
+	model     string
+	toolIndex map[int64]int
+}
+
+func newCohereChatStreamState(model string) *cohereChatStreamState {
+	return &cohereChatStreamState{
+		id:        cohereCompletionID(),
+		created:   time.Now().Unix(),
+		model:     model,
+		toolIndex: make(map[int64]int),
+	}
+}
+
+// translate returns the SSE data lines for one Cohere stream event. The event type is read
+// from the payload, so the preceding "event:" line is not needed.
+func (s *cohereChatStreamState) translate(payload []byte) [][]byte {
+	event := gjson.ParseBytes(payload)
+	delta := event.Get("delta.message")
+	switch event.Get("type").String() {
+	case "message-start":
+		if id := event.Get("id").String(); id != "" {
+			s.id = id
+		}
+		return [][]byte{s.chunk(map[string]any{"role": "assistant", "content": ""}, nil, nil)}
+	case "content-delta":
+		if text := delta.Get("content.text"); text.Exists() {
+			return [][]byte{s.chunk(map[string]any{"content": text.String()}, nil, nil)}
+		}
+		if thinking := delta.Get("content.thinking"); thinking.Exists() {
+			return [][]byte{s.chunk(map[string]any{"reasoning_content": thinking.String()}, nil, nil)}
+		}
+	case "tool-plan-delta":
+		if plan := delta.Get("tool_plan").String(); plan != "" {
+			return [][]byte{s.chunk(map[string]any{"reasoning_content": plan}, nil, nil)}
+		}
+	case "tool-call-start":
+		call := delta.Get("tool_calls")
+		index := len(s.toolIndex)
+		s.toolIndex[event.Get("index").Int()] = index
+		return [][]byte{s.chunk(map[string]any{"tool_calls": []map[string]any{{
+			"index": index,
+			"id":    call.Get("id").String(),
+			"type":  "function",
+			"function": map[string]any{
+				"name":      call.Get("function.name").String(),
+				"arguments": call.Get("function.arguments").String(),
+			},
+		}}}, nil, nil)}
+	case "tool-call-delta":
+		index, ok := s.toolIndex[event.Get("index").Int()]
+		if !ok {
+			return nil
+		}
+		return [][]byte{s.chunk(map[string]any{"tool_calls": []map[string]any{{
+			"index":    index,
+			"function": map[string]any{"arguments": delta.Get("tool_calls.function.arguments").String()},
+		}}}, nil, nil)}
+	case "citation-start":
+		if citation := delta.Get("citations"); citation.IsObject() {
+			return [][]byte{s.chunk(map[string]any{"citations": []map[string]any{cohereCitation(citation)}}, nil, nil)}
+		}
+	case "message-end":
+		reason := cohereFinishReason(event.Get("delta.finish_reason").String())
+		lines := [][]byte{s.chunk(map[string]any{}, &reason, nil)}
+		if usage := event.Get("delta.usage"); usage.Exists() {
+			lines = append(lines, s.chunk(nil, nil, cohereUsage(usage)))
+		}
+		return lines
+	}
+	return nil
+}
+
+func (s *cohereChatStreamState) chunk(delta map[string]any, finishReason *string, usage map[string]any) []byte {
+	out := map[string]any{
+		"id":      s.id,
+		"object":  "chat.completion.chunk",
+		"created": s.created,
+		"model":   s.model,
+	}
+	if delta != nil {
+		choice := map[string]any{"index": 0, "delta": delta, "finish_reason": nil}
+		if finishReason != nil {
+			choice["finish_reason"] = *finishReason
+		}
+		out["choices"] = []map[string]any{choice}
+	} else {
+		out["choices"] = []map[string]any{}
+	}
+	if usage != nil {
+		out["usage"] = usage
+	}
+	data, _ := json.Marshal(out)
+	return append([]byte("data: "), data...)
+}
+
+func cohereCompletionID() string {
+	return fmt.Sprintf("chatcmpl-cohere-%d", time.Now().UnixNano())
+}
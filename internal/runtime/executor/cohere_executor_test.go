@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildCohereChatRequestTranslatesMessagesAndDocuments(t *testing.T) {
+	body := []byte(`{"model":"command-a-03-2025","max_completion_tokens":128,"top_p":0.5,"stop":"END","messages":[` +
+		`{"role":"system","content":"be brief"},` +
+		`{"role":"user","content":[{"type":"text","text":"weather?"}]},` +
+		`{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}}]},` +
+		`{"role":"tool","tool_call_id":"call_1","content":"sunny"}],` +
+		`"tools":[{"type":"function","function":{"name":"weather","parameters":{"type":"object"}}}],"tool_choice":"required"}`)
+	documents := gjson.Parse(`[{"id":"doc-1","data":{"text":"Paris is in France."}}]`)
+
+	out, err := buildCohereChatRequest(body, documents, "command-a-03-2025", false)
+	if err != nil {
+		t.Fatalf("buildCohereChatRequest() error = %v", err)
+	}
+	root := gjson.ParseBytes(out)
+	if root.Get("messages.0.role").String() != "system" || root.Get("messages.1.content").String() != "weather?" {
+		t.Fatalf("messages = %s", root.Get("messages").Raw)
+	}
+	if root.Get("messages.2.tool_calls.0.function.arguments").String() != `{"city":"Paris"}` || root.Get("messages.3.tool_call_id").String() != "call_1" {
+		t.Fatalf("tool turns = %s", root.Get("messages").Raw)
+	}
+	if root.Get("max_tokens").Int() != 128 || root.Get("p").Float() != 0.5 || root.Get("stop_sequences.0").String() != "END" {
+		t.Fatalf("sampling fields = %s", out)
+	}
+	if root.Get("tool_choice").String() != "REQUIRED" || root.Get("tools.0.function.name").String() != "weather" {
+		t.Fatalf("tools = %s", out)
+	}
+	if root.Get("documents.0.id").String() != "doc-1" {
+		t.Fatalf("documents = %s", root.Get("documents").Raw)
+	}
+}
+
+func TestCohereExecutorMapsCitationsIntoOpenAIResponse(t *testing.T) {
+	var seenPath string
+	var seenBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenPath = r.URL.Path
+		seenBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"c1","finish_reason":"COMPLETE","message":{"role":"assistant",` +
+			`"content":[{"type":"text","text":"Paris is in France."}],` +
+			`"citations":[{"start":0,"end":5,"text":"Paris","type":"TEXT_CONTENT","sources":[{"type":"document","id":"doc-1","document":{"text":"Paris is in France."}}]}]},` +
+			`"usage":{"billed_units":{"input_tokens":20,"output_tokens":5},"tokens":{"input_tokens":120,"output_tokens":5}}}`))
+	}))
+	defer server.Close()
+
+	exec := NewCohereExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "cohere-test", Provider: "cohere", Attributes: map[string]string{
+		"api_key":  "co-key",
+		"base_url": server.URL,
+	}}
+	payload := []byte(`{"model":"command-a-03-2025","messages":[{"role":"user","content":"Where is Paris?"}],` +
+		`"documents":[{"id":"doc-1","data":{"text":"Paris is in France."}}]}`)
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "command-a-03-2025", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if seenPath != "/v2/chat" {
+		t.Fatalf("upstream path = %q", seenPath)
+	}
+	if gjson.GetBytes(seenBody, "documents.0.id").String() != "doc-1" {
+		t.Fatalf("documents not forwarded: %s", seenBody)
+	}
+	out := gjson.ParseBytes(resp.Payload)
+	if out.Get("choices.0.message.content").String() != "Paris is in France." || out.Get("choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+	if out.Get("choices.0.message.citations.0.text").String() != "Paris" || out.Get("choices.0.message.citations.0.sources.0.id").String() != "doc-1" {
+		t.Fatalf("citations = %s", out.Get("choices.0.message.citations").Raw)
+	}
+	if out.Get("usage.prompt_tokens").Int() != 120 {
+		t.Fatalf("usage = %s", out.Get("usage").Raw)
+	}
+}
+
+func TestCohereExecutorStreamsToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"type":"message-start","id":"c2","delta":{"message":{"role":"assistant"}}}`,
+			`{"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"call_9","type":"function","function":{"name":"weather","arguments":""}}}}}`,
+			`{"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":"{\"city\":"}}}}}`,
+			`{"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":"\"Paris\"}"}}}}}`,
+			`{"type":"tool-call-end","index":0}`,
+			`{"type":"message-end","delta":{"finish_reason":"TOOL_CALL","usage":{"tokens":{"input_tokens":30,"output_tokens":12}}}}`,
+		} {
+			eventType := gjson.Get(event, "type").String()
+			_, _ = w.Write([]byte("event: " + eventType + "\ndata: " + event + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	exec := NewCohereExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "cohere-test", Provider: "cohere", Attributes: map[string]string{
+		"api_key":  "co-key",
+		"base_url": server.URL,
+	}}
+	payload := []byte(`{"model":"command-a-03-2025","stream":true,"messages":[{"role":"user","content":"weather in Paris?"}],` +
+		`"tools":[{"type":"function","function":{"name":"weather","parameters":{"type":"object"}}}]}`)
+	result, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{Model: "command-a-03-2025", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var name, args, finish string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(chunk.Payload)), "data:"))
+		for _, call := range gjson.Get(data, "choices.0.delta.tool_calls").Array() {
+			name += call.Get("function.name").String()
+			args += call.Get("function.arguments").String()
+		}
+		if reason := gjson.Get(data, "choices.0.finish_reason").String(); reason != "" {
+			finish = reason
+		}
+	}
+	if name != "weather" || args != `{"city":"Paris"}` || finish != "tool_calls" {
+		t.Fatalf("name = %q, args = %q, finish = %q", name, args, finish)
+	}
+}
@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"strconv"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// NewDeepSeekExecutor creates the executor for deepseek-api-key credentials. DeepSeek serves the
+// OpenAI chat completions protocol and returns reasoning as reasoning_content, which the
+// OpenAI translators already map to Claude thinking blocks, Gemini thought parts and Responses
+// reasoning items.
+func NewDeepSeekExecutor(cfg *config.Config) *OpenAICompatExecutor {
+	return &OpenAICompatExecutor{provider: "deepseek", cfg: cfg, finalizeChatPayload: sanitizeDeepSeekChatPayload}
+}
+
+// sanitizeDeepSeekChatPayload drops reasoning_content from assistant messages of earlier turns.
+// DeepSeek rejects replayed reasoning from previous turns, while assistant tool-call messages
+// after the last user message must keep it so the model can continue the same turn.
+func sanitizeDeepSeekChatPayload(payload []byte) []byte {
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return payload
+	}
+	items := messages.Array()
+	lastUser := -1
+	for i := range items {
+		if items[i].Get("role").String() == "user" {
+			lastUser = i
+		}
+	}
+	for i := lastUser - 1; i >= 0; i-- {
+		if items[i].Get("role").String() != "assistant" || !items[i].Get("reasoning_content").Exists() {
+			continue
+		}
+		if updated, errDelete := sjson.DeleteBytes(payload, "messages."+strconv.Itoa(i)+".reasoning_content"); errDelete == nil {
+			payload = updated
+		}
+	}
+	return payload
+}
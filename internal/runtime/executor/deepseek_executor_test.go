@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestSanitizeDeepSeekChatPayloadKeepsCurrentTurnReasoning(t *testing.T) {
+	payload := []byte(`{"messages":[` +
+		`{"role":"user","content":"q1"},` +
+		`{"role":"assistant","content":"a1","reasoning_content":"old thoughts"},` +
+		`{"role":"user","content":"q2"},` +
+		`{"role":"assistant","reasoning_content":"tool thoughts","tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{}"}}]},` +
+		`{"role":"tool","tool_call_id":"call_1","content":"result"}]}`)
+	out := sanitizeDeepSeekChatPayload(payload)
+	if gjson.GetBytes(out, "messages.1.reasoning_content").Exists() {
+		t.Fatalf("previous-turn reasoning_content kept: %s", out)
+	}
+	if got := gjson.GetBytes(out, "messages.3.reasoning_content").String(); got != "tool thoughts" {
+		t.Fatalf("current-turn reasoning_content = %q: %s", got, out)
+	}
+}
+
+func TestDeepSeekExecutorStreamsReasoningAsClaudeThinking(t *testing.T) {
+	var seenBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id":"d1","object":"chat.completion.chunk","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"Compare "}}]}`,
+			`{"id":"d1","object":"chat.completion.chunk","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"reasoning_content":"the numbers."}}]}`,
+			`{"id":"d1","object":"chat.completion.chunk","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"content":"9.11 < 9.9"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":8,"total_tokens":18}}`,
+		} {
+			_, _ = w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	exec := NewDeepSeekExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "deepseek-test", Provider: "deepseek", Attributes: map[string]string{
+		"api_key":  "ds-key",
+		"base_url": server.URL,
+	}}
+	payload := []byte(`{"model":"deepseek-reasoner","max_tokens":256,"stream":true,"messages":[` +
+		`{"role":"user","content":"hi"},` +
+		`{"role":"assistant","content":[{"type":"thinking","thinking":"earlier","signature":"sig"},{"type":"text","text":"hello"}]},` +
+		`{"role":"user","content":"which is larger, 9.11 or 9.9?"}]}`)
+	result, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{Model: "deepseek-reasoner", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), Stream: true, OriginalRequest: payload})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var thinking, text strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		for _, line := range strings.Split(string(chunk.Payload), "\n") {
+			data := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "data:"))
+			if gjson.Get(data, "type").String() != "content_block_delta" {
+				continue
+			}
+			thinking.WriteString(gjson.Get(data, "delta.thinking").String())
+			text.WriteString(gjson.Get(data, "delta.text").String())
+		}
+	}
+	if thinking.String() != "Compare the numbers." || text.String() != "9.11 < 9.9" {
+		t.Fatalf("thinking = %q, text = %q", thinking.String(), text.String())
+	}
+	for _, message := range gjson.GetBytes(seenBody, "messages").Array() {
+		if message.Get("reasoning_content").Exists() {
+			t.Fatalf("previous-turn reasoning_content sent upstream: %s", seenBody)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package executor
+
+import (
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/sjson"
+)
+
+// geminiCountTokensBody prepares a translated request for the Gemini API countTokens endpoint.
+// Requests translated from Claude are wrapped in a generateContentRequest that keeps their tools
+// and system instruction, so the count matches what generation consumes for the same request;
+// other requests are counted from their bare contents.
+func geminiCountTokensBody(from sdktranslator.Format, baseModel string, translatedReq []byte) []byte {
+	translatedReq, _ = sjson.DeleteBytes(translatedReq, "generationConfig")
+	translatedReq, _ = sjson.DeleteBytes(translatedReq, "safetySettings")
+	if from != sdktranslator.FormatClaude {
+		translatedReq, _ = sjson.DeleteBytes(translatedReq, "tools")
+		return translatedReq
+	}
+	translatedReq, _ = sjson.SetBytes(translatedReq, "model", "models/"+baseModel)
+	body, errSet := sjson.SetRawBytes([]byte(`{}`), "generateContentRequest", translatedReq)
+	if errSet != nil {
+		return translatedReq
+	}
+	return body
+}
+
+// vertexCountTokensBody prepares a translated request for the Vertex AI countTokens endpoint,
+// which accepts tools next to the contents. Tools are kept for requests translated from Claude.
+func vertexCountTokensBody(from sdktranslator.Format, translatedReq []byte) []byte {
+	translatedReq, _ = sjson.DeleteBytes(translatedReq, "generationConfig")
+	translatedReq, _ = sjson.DeleteBytes(translatedReq, "safetySettings")
+	if from != sdktranslator.FormatClaude {
+		translatedReq, _ = sjson.DeleteBytes(translatedReq, "tools")
+	}
+	return translatedReq
+}
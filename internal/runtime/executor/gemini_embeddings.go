@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	geminiembeddings "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/openai/embeddings"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// executeEmbeddings serves an OpenAI /v1/embeddings request with Gemini batchEmbedContents.
+func (e *GeminiExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	body, errConvert := geminiembeddings.ConvertOpenAIEmbeddingsRequestToGemini(baseModel, req.Payload)
+	if errConvert != nil {
+		return resp, statusErr{code: http.StatusBadRequest, msg: errConvert.Error()}
+	}
+	data, headers, err := e.postModelMethod(ctx, auth, reporter, baseModel, "batchEmbedContents", body)
+	if err != nil {
+		return resp, err
+	}
+	originalRequest := opts.OriginalRequest
+	if len(originalRequest) == 0 {
+		originalRequest = req.Payload
+	}
+	out := geminiembeddings.ConvertGeminiEmbeddingsResponseToOpenAI(req.Model, originalRequest, data)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: headers}
+	return resp, nil
+}
+
+// postModelMethod posts body to the models/<model>:<method> endpoint of the Gemini API and
+// returns the response body and headers. It is used by the methods that report no token
+// usage, such as batchEmbedContents and predict, so the request is recorded without it.
+func (e *GeminiExecutor) postModelMethod(ctx context.Context, auth *cliproxyauth.Auth, reporter *helps.UsageReporter, baseModel, method string, body []byte) ([]byte, http.Header, error) {
+	url := fmt.Sprintf("%s/%s/models/%s:%s", resolveGeminiBaseURL(auth), glAPIVersion, baseModel, method)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey := geminiAPIKey(auth); apiKey != "" {
+		httpReq.Header.Set("x-goog-api-key", apiKey)
+	}
+	applyGeminiHeaders(httpReq, auth)
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient = reporter.TrackHTTPClient(httpClient)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("gemini executor: close response body error: %v", errClose)
+		}
+	}()
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		return nil, nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, nil, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+	reporter.EnsurePublished(ctx)
+	return data, httpResp.Header.Clone(), nil
+}
@@ -992,3 +992,132 @@ func TestGeminiExecutorNativeInteractionsResponsesStreamEmitsDone(t *testing.T)
 		t.Fatal("Responses [DONE] chunk not found")
 	}
 }
+
+func TestGeminiExecutorEmbeddingsUsesBatchEmbedContents(t *testing.T) {
+	var gotPath string
+	var upstreamBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		body, errRead := io.ReadAll(r.Body)
+		if errRead != nil {
+			t.Fatalf("read request body: %v", errRead)
+		}
+		upstreamBody = body
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`))
+	}))
+	defer server.Close()
+
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "test-key",
+		"base_url": server.URL,
+	}}
+	payload := []byte(`{"model":"gemini-embedding-001","input":["a","b"],"dimensions":256}`)
+	resp, errExecute := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gemini-embedding-001",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI, OriginalRequest: payload, Alt: "embeddings"})
+	if errExecute != nil {
+		t.Fatalf("Execute() error = %v", errExecute)
+	}
+	if gotPath != "/v1beta/models/gemini-embedding-001:batchEmbedContents" {
+		t.Fatalf("path = %q, want batchEmbedContents endpoint", gotPath)
+	}
+	if got := gjson.GetBytes(upstreamBody, "requests.1.content.parts.0.text").String(); got != "b" {
+		t.Fatalf("second request text = %q, want b; body=%s", got, upstreamBody)
+	}
+	if got := gjson.GetBytes(upstreamBody, "requests.0.outputDimensionality").Int(); got != 256 {
+		t.Fatalf("outputDimensionality = %d, want 256", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "data.1.embedding.1").Float(); got != 0.4 {
+		t.Fatalf("data.1.embedding.1 = %v, want 0.4; payload=%s", got, resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "object").String(); got != "list" {
+		t.Fatalf("object = %q, want list", got)
+	}
+}
+
+func TestGeminiExecutorImagesGenerationsUsesImagenPredict(t *testing.T) {
+	var gotPath string
+	var upstreamBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		body, errRead := io.ReadAll(r.Body)
+		if errRead != nil {
+			t.Fatalf("read request body: %v", errRead)
+		}
+		upstreamBody = body
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"predictions":[{"bytesBase64Encoded":"aW1n","mimeType":"image/png"}]}`))
+	}))
+	defer server.Close()
+
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "test-key",
+		"base_url": server.URL,
+	}}
+	payload := []byte(`{"model":"imagen-4.0-generate-001","prompt":"a lighthouse","size":"1024x1024"}`)
+	resp, errExecute := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "imagen-4.0-generate-001",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FormatOpenAI, OriginalRequest: payload, Alt: imagesGenerationsAlt})
+	if errExecute != nil {
+		t.Fatalf("Execute() error = %v", errExecute)
+	}
+	if gotPath != "/v1beta/models/imagen-4.0-generate-001:predict" {
+		t.Fatalf("path = %q, want predict endpoint", gotPath)
+	}
+	if got := gjson.GetBytes(upstreamBody, "instances.0.prompt").String(); got != "a lighthouse" {
+		t.Fatalf("prompt = %q; body=%s", got, upstreamBody)
+	}
+	if got := gjson.GetBytes(upstreamBody, "parameters.aspectRatio").String(); got != "1:1" {
+		t.Fatalf("aspectRatio = %q, want 1:1", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "data.0.b64_json").String(); got != "aW1n" {
+		t.Fatalf("data.0.b64_json = %q; payload=%s", got, resp.Payload)
+	}
+}
+
+func TestGeminiExecutorCountTokensKeepsToolsForClaudeRequests(t *testing.T) {
+	var upstreamBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, errRead := io.ReadAll(r.Body)
+		if errRead != nil {
+			t.Fatalf("read request body: %v", errRead)
+		}
+		upstreamBody = body
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"totalTokens":123}`))
+	}))
+	defer server.Close()
+
+	exec := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "test-key",
+		"base_url": server.URL,
+	}}
+	resp, err := exec.CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+		Model: "gemini-2.5-pro",
+		Payload: []byte(`{"model":"gemini-2.5-pro","system":"Be brief.","max_tokens":100,
+			"messages":[{"role":"user","content":"hi"}],
+			"tools":[{"name":"read_file","description":"Read a file","input_schema":{"type":"object","properties":{"path":{"type":"string"}}}}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("CountTokens() error = %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "input_tokens").Int(); got != 123 {
+		t.Fatalf("input_tokens = %d, want 123: %s", got, resp.Payload)
+	}
+	inner := gjson.GetBytes(upstreamBody, "generateContentRequest")
+	if inner.Get("model").String() != "models/gemini-2.5-pro" {
+		t.Fatalf("generateContentRequest.model = %q: %s", inner.Get("model").String(), upstreamBody)
+	}
+	if inner.Get("tools.0.functionDeclarations.0.name").String() != "read_file" {
+		t.Fatalf("tools were dropped from count request: %s", upstreamBody)
+	}
+	if !inner.Get("system_instruction").Exists() || inner.Get("generationConfig").Exists() {
+		t.Fatalf("count request = %s, want system instruction without generation config", upstreamBody)
+	}
+}
@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	geminiimages "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/openai/images"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// executeImagen serves an OpenAI /v1/images/generations request with the Imagen predict method.
+func (e *GeminiExecutor) executeImagen(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	body, errConvert := geminiimages.ConvertOpenAIImagesRequestToImagen(req.Payload)
+	if errConvert != nil {
+		return resp, statusErr{code: http.StatusBadRequest, msg: errConvert.Error()}
+	}
+	data, headers, err := e.postModelMethod(ctx, auth, reporter, baseModel, "predict", body)
+	if err != nil {
+		return resp, err
+	}
+	originalRequest := opts.OriginalRequest
+	if len(originalRequest) == 0 {
+		originalRequest = req.Payload
+	}
+	out := geminiimages.ConvertImagenResponseToOpenAI(originalRequest, data)
+	resp = cliproxyexecutor.Response{Payload: out, Headers: headers}
+	return resp, nil
+}
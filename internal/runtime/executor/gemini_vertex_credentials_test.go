@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func writeTestServiceAccount(t *testing.T, tokenURI string) (string, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	data, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "file-project",
+		"private_key_id": "kid",
+		"private_key":    string(pemKey),
+		"client_email":   "sa@file-project.iam.gserviceaccount.com",
+		"client_id":      "123",
+		"token_uri":      tokenURI,
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "sa.json")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path, data
+}
+
+func TestVertexCredsFromCredentialsFile(t *testing.T) {
+	path, data := writeTestServiceAccount(t, "https://oauth2.googleapis.com/token")
+	auth := &cliproxyauth.Auth{ID: "vertex-file", Provider: "vertex", Metadata: map[string]any{
+		"credentials_file": path,
+		"location":         "europe-west4",
+	}}
+	projectID, location, saJSON, err := vertexCreds(auth)
+	if err != nil {
+		t.Fatalf("vertexCreds() error = %v", err)
+	}
+	if projectID != "file-project" || location != "europe-west4" {
+		t.Fatalf("vertexCreds() project=%q location=%q", projectID, location)
+	}
+	if string(saJSON) != string(data) {
+		t.Fatal("vertexCreds() did not return the credentials file contents")
+	}
+}
+
+func TestVertexCredsApplicationDefaultCredentials(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "vertex-adc", Provider: "vertex", Metadata: map[string]any{
+		"credential_source": "adc",
+		"project_id":        "wi-project",
+	}}
+	projectID, location, saJSON, err := vertexCreds(auth)
+	if err != nil {
+		t.Fatalf("vertexCreds() error = %v", err)
+	}
+	if projectID != "wi-project" || location != config.DefaultVertexLocation || saJSON != nil {
+		t.Fatalf("vertexCreds() project=%q location=%q json=%q", projectID, location, saJSON)
+	}
+
+	delete(auth.Metadata, "project_id")
+	if _, _, _, err = vertexCreds(auth); err == nil {
+		t.Fatal("vertexCreds() without project_id error = nil")
+	}
+}
+
+func TestVertexAccessTokenIsCached(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"vertex-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	_, saJSON := writeTestServiceAccount(t, server.URL)
+	auth := &cliproxyauth.Auth{ID: "vertex-token-cache-test"}
+	for i := 0; i < 2; i++ {
+		token, err := vertexAccessToken(context.Background(), &config.Config{}, auth, saJSON)
+		if err != nil {
+			t.Fatalf("vertexAccessToken() error = %v", err)
+		}
+		if token != "vertex-token" {
+			t.Fatalf("vertexAccessToken() = %q", token)
+		}
+	}
+	if got := tokenRequests.Load(); got != 1 {
+		t.Fatalf("token requests = %d, want 1", got)
+	}
+}
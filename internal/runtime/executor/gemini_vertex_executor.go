@@ -11,7 +11,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	vertexauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/vertex"
@@ -32,8 +34,20 @@ import (
 const (
 	// vertexAPIVersion aligns with current public Vertex Generative AI API.
 	vertexAPIVersion = "v1"
+
+	// vertexOAuthScope is the OAuth scope requested for Vertex AI access tokens.
+	vertexOAuthScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// vertexCredentialSourceADC marks auths that use Application Default Credentials.
+	vertexCredentialSourceADC = "adc"
+
+	// vertexTokenRefreshSkew renews cached access tokens this long before they expire.
+	vertexTokenRefreshSkew = time.Minute
 )
 
+// vertexTokenCache maps auth ID and credentials hash to the last issued *oauth2.Token.
+var vertexTokenCache sync.Map
+
 // isImagenModel checks if the model name is an Imagen image generation model.
 // Imagen models use the :predict action instead of :generateContent.
 func isImagenModel(model string) bool {
@@ -238,6 +252,9 @@ func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	if opts.Alt == "responses/compact" {
 		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	if isEndpointAlt(opts.Alt) {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/" + opts.Alt + " not supported"}
+	}
 	// Try API key authentication first
 	apiKey, baseURL := vertexAPICreds(auth)
 
@@ -256,6 +273,9 @@ func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 
 // ExecuteStream performs a streaming request to the Vertex AI API.
 func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	if isEndpointAlt(opts.Alt) {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/" + opts.Alt + " not supported"}
+	}
 	if opts.Alt == "responses/compact" {
 		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
@@ -340,6 +360,7 @@ func (e *GeminiVertexExecutor) executeWithServiceAccount(ctx context.Context, au
 		requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 		requestPath := helps.PayloadRequestPath(opts)
 		body = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", body, originalTranslated, requestedModel, requestPath, opts.Headers)
+		body = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), body)
 		body = helps.SetStringIfDifferent(body, "model", baseModel)
 		body = helps.StripVertexOpenAIResponsesToolCallIDs(body, from.String())
 	}
@@ -465,6 +486,7 @@ func (e *GeminiVertexExecutor) executeWithAPIKey(ctx context.Context, auth *clip
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	requestPath := helps.PayloadRequestPath(opts)
 	body = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", body, originalTranslated, requestedModel, requestPath, opts.Headers)
+	body = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), body)
 	body = helps.SetStringIfDifferent(body, "model", baseModel)
 	body = helps.StripVertexOpenAIResponsesToolCallIDs(body, from.String())
 
@@ -580,6 +602,7 @@ func (e *GeminiVertexExecutor) executeStreamWithServiceAccount(ctx context.Conte
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	requestPath := helps.PayloadRequestPath(opts)
 	body = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", body, originalTranslated, requestedModel, requestPath, opts.Headers)
+	body = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), body)
 	body = helps.SetStringIfDifferent(body, "model", baseModel)
 	body = helps.StripVertexOpenAIResponsesToolCallIDs(body, from.String())
 
@@ -726,6 +749,7 @@ func (e *GeminiVertexExecutor) executeStreamWithAPIKey(ctx context.Context, auth
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	requestPath := helps.PayloadRequestPath(opts)
 	body = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", body, originalTranslated, requestedModel, requestPath, opts.Headers)
+	body = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), body)
 	body = helps.SetStringIfDifferent(body, "model", baseModel)
 	body = helps.StripVertexOpenAIResponsesToolCallIDs(body, from.String())
 
@@ -863,9 +887,7 @@ func (e *GeminiVertexExecutor) countTokensWithServiceAccount(ctx context.Context
 	translatedReq, _ = sjson.SetBytes(translatedReq, "model", baseModel)
 	translatedReq = helps.StripVertexOpenAIResponsesToolCallIDs(translatedReq, from.String())
 	respCtx := context.WithValue(ctx, "alt", opts.Alt)
-	translatedReq, _ = sjson.DeleteBytes(translatedReq, "tools")
-	translatedReq, _ = sjson.DeleteBytes(translatedReq, "generationConfig")
-	translatedReq, _ = sjson.DeleteBytes(translatedReq, "safetySettings")
+	translatedReq = vertexCountTokensBody(from, translatedReq)
 
 	baseURL := vertexBaseURL(location)
 	url := fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:%s", baseURL, vertexAPIVersion, projectID, location, baseModel, "countTokens")
@@ -954,9 +976,7 @@ func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *
 	translatedReq, _ = sjson.SetBytes(translatedReq, "model", baseModel)
 	translatedReq = helps.StripVertexOpenAIResponsesToolCallIDs(translatedReq, from.String())
 	respCtx := context.WithValue(ctx, "alt", opts.Alt)
-	translatedReq, _ = sjson.DeleteBytes(translatedReq, "tools")
-	translatedReq, _ = sjson.DeleteBytes(translatedReq, "generationConfig")
-	translatedReq, _ = sjson.DeleteBytes(translatedReq, "safetySettings")
+	translatedReq = vertexCountTokensBody(from, translatedReq)
 
 	// For API key auth, use simpler URL format without project/location
 	if baseURL == "" {
@@ -1040,20 +1060,21 @@ func vertexCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccou
 			projectID = strings.TrimSpace(v)
 		}
 	}
-	if projectID == "" {
-		return "", "", nil, fmt.Errorf("vertex executor: missing project_id in credentials")
-	}
 	if v, ok := a.Metadata["location"].(string); ok && strings.TrimSpace(v) != "" {
 		location = strings.TrimSpace(v)
 	} else {
-		location = "us-central1"
+		location = config.DefaultVertexLocation
 	}
 	var sa map[string]any
 	if raw, ok := a.Metadata["service_account"].(map[string]any); ok {
 		sa = raw
 	}
 	if sa == nil {
-		return "", "", nil, fmt.Errorf("vertex executor: missing service_account in credentials")
+		projectID, serviceAccountJSON, err = vertexConfiguredCreds(a.Metadata, projectID)
+		return projectID, location, serviceAccountJSON, err
+	}
+	if projectID == "" {
+		return "", "", nil, fmt.Errorf("vertex executor: missing project_id in credentials")
 	}
 	normalized, errNorm := vertexauth.NormalizeServiceAccountMap(sa)
 	if errNorm != nil {
@@ -1066,6 +1087,34 @@ func vertexCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccou
 	return projectID, location, saJSON, nil
 }
 
+// vertexConfiguredCreds resolves credentials of vertex-service-account config entries: the
+// contents of credentials_file, or nil JSON when Application Default Credentials are used.
+func vertexConfiguredCreds(metadata map[string]any, projectID string) (string, []byte, error) {
+	if path, _ := metadata["credentials_file"].(string); strings.TrimSpace(path) != "" {
+		data, errRead := os.ReadFile(strings.TrimSpace(path))
+		if errRead != nil {
+			return "", nil, fmt.Errorf("vertex executor: read credentials file failed: %w", errRead)
+		}
+		if projectID == "" {
+			projectID = strings.TrimSpace(gjson.GetBytes(data, "project_id").String())
+		}
+		if projectID == "" {
+			projectID = strings.TrimSpace(gjson.GetBytes(data, "quota_project_id").String())
+		}
+		if projectID == "" {
+			return "", nil, fmt.Errorf("vertex executor: missing project_id in credentials")
+		}
+		return projectID, data, nil
+	}
+	if source, _ := metadata["credential_source"].(string); source == vertexCredentialSourceADC {
+		if projectID == "" {
+			return "", nil, fmt.Errorf("vertex executor: missing project_id in credentials")
+		}
+		return projectID, nil, nil
+	}
+	return "", nil, fmt.Errorf("vertex executor: missing service_account in credentials")
+}
+
 // vertexAPICreds extracts API key and base URL from auth attributes following the claudeCreds pattern.
 func vertexAPICreds(a *cliproxyauth.Auth) (apiKey, baseURL string) {
 	if a == nil {
@@ -1093,19 +1142,42 @@ func vertexBaseURL(location string) string {
 	return fmt.Sprintf("https://%s-aiplatform.googleapis.com", loc)
 }
 
+// vertexAccessToken returns an access token for the credentials JSON, or for Application
+// Default Credentials when saJSON is nil. Tokens are reused until shortly before expiry.
 func vertexAccessToken(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, saJSON []byte) (string, error) {
+	cacheKey := sha256Hex(saJSON)
+	if auth != nil {
+		cacheKey = auth.ID + "|" + cacheKey
+	}
+	if cached, ok := vertexTokenCache.Load(cacheKey); ok {
+		if tok := cached.(*oauth2.Token); tok.Expiry.After(time.Now().Add(vertexTokenRefreshSkew)) {
+			return tok.AccessToken, nil
+		}
+	}
+
 	if httpClient := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, 0); httpClient != nil {
 		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
 	}
-	// Use cloud-platform scope for Vertex AI.
-	creds, errCreds := google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
-	if errCreds != nil {
-		return "", fmt.Errorf("vertex executor: parse service account json failed: %w", errCreds)
+	var creds *google.Credentials
+	var errCreds error
+	if saJSON == nil {
+		creds, errCreds = google.FindDefaultCredentials(ctx, vertexOAuthScope)
+		if errCreds != nil {
+			return "", fmt.Errorf("vertex executor: find application default credentials failed: %w", errCreds)
+		}
+	} else {
+		creds, errCreds = google.CredentialsFromJSON(ctx, saJSON, vertexOAuthScope)
+		if errCreds != nil {
+			return "", fmt.Errorf("vertex executor: parse service account json failed: %w", errCreds)
+		}
 	}
 	tok, errTok := creds.TokenSource.Token()
 	if errTok != nil {
 		return "", fmt.Errorf("vertex executor: get access token failed: %w", errTok)
 	}
+	if !tok.Expiry.IsZero() {
+		vertexTokenCache.Store(cacheKey, tok)
+	}
 	return tok.AccessToken, nil
 }
 
@@ -76,6 +76,16 @@ func claudeInputTokenizer() (tokenizer.Codec, error) {
 	return claudeInputTokenizerCodec, claudeInputTokenizerErr
 }
 
+// EstimateClaudeInputTokens approximates the input tokens of a Claude Messages request locally,
+// using the same estimate reported in message_start for translated Claude streams.
+func EstimateClaudeInputTokens(payload []byte) (int64, error) {
+	enc, err := claudeInputTokenizer()
+	if err != nil {
+		return 0, err
+	}
+	return countClaudeInputTokens(enc, payload)
+}
+
 func countClaudeInputTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
 	if enc == nil {
 		return 0, fmt.Errorf("encoder is nil")
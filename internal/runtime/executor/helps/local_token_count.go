@@ -0,0 +1,114 @@
+package helps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+// CountInputTokensLocally counts the input tokens of a request in the given format with an
+// embedded tokenizer, without contacting any provider. OpenAI requests use the model's
+// tiktoken encoding; Claude and Gemini requests are approximated with o200k_base.
+func CountInputTokensLocally(format sdktranslator.Format, model string, payload []byte) (int64, error) {
+	if len(payload) > 0 && !gjson.ValidBytes(payload) {
+		return 0, fmt.Errorf("invalid request JSON")
+	}
+	switch format {
+	case sdktranslator.FormatClaude:
+		return EstimateClaudeInputTokens(payload)
+	case sdktranslator.FormatOpenAI:
+		enc, err := TokenizerForModel(model)
+		if err != nil {
+			return 0, err
+		}
+		return CountOpenAIChatTokens(enc, payload)
+	case sdktranslator.FormatOpenAIResponse:
+		enc, err := TokenizerForModel(model)
+		if err != nil {
+			return 0, err
+		}
+		return countOpenAIResponsesTokens(enc, payload)
+	case sdktranslator.FormatGemini:
+		enc, err := claudeInputTokenizer()
+		if err != nil {
+			return 0, err
+		}
+		return countGeminiInputTokens(enc, payload)
+	default:
+		return 0, fmt.Errorf("local token counting is not supported for format %q", format)
+	}
+}
+
+// countOpenAIResponsesTokens approximates input tokens for OpenAI Responses payloads.
+func countOpenAIResponsesTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+	addIfNotEmpty(&segments, root.Get("instructions").String())
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		addIfNotEmpty(&segments, input.String())
+	}
+	for _, item := range input.Array() {
+		addIfNotEmpty(&segments, item.Get("role").String())
+		collectOpenAIContent(item.Get("content"), &segments)
+		switch item.Get("type").String() {
+		case "function_call":
+			addIfNotEmpty(&segments, item.Get("name").String())
+			addIfNotEmpty(&segments, item.Get("arguments").String())
+		case "function_call_output":
+			collectOpenAIContent(item.Get("output"), &segments)
+		}
+	}
+	for _, tool := range root.Get("tools").Array() {
+		addIfNotEmpty(&segments, tool.Raw)
+	}
+	collectOpenAIResponseFormat(root.Get("text.format"), &segments)
+	return countTokenSegments(enc, segments)
+}
+
+// countGeminiInputTokens approximates input tokens for Gemini generateContent payloads.
+func countGeminiInputTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+	collectGeminiParts(root.Get("systemInstruction.parts"), &segments)
+	collectGeminiParts(root.Get("system_instruction.parts"), &segments)
+	for _, content := range root.Get("contents").Array() {
+		addIfNotEmpty(&segments, content.Get("role").String())
+		collectGeminiParts(content.Get("parts"), &segments)
+	}
+	for _, tool := range root.Get("tools").Array() {
+		addIfNotEmpty(&segments, tool.Raw)
+	}
+	return countTokenSegments(enc, segments)
+}
+
+func collectGeminiParts(parts gjson.Result, segments *[]string) {
+	for _, part := range parts.Array() {
+		switch {
+		case part.Get("text").Exists():
+			addIfNotEmpty(segments, part.Get("text").String())
+		case part.Get("functionCall").Exists():
+			addIfNotEmpty(segments, part.Get("functionCall").Raw)
+		case part.Get("functionResponse").Exists():
+			addIfNotEmpty(segments, part.Get("functionResponse").Raw)
+		case part.Get("fileData").Exists():
+			addIfNotEmpty(segments, part.Get("fileData.fileUri").String())
+		}
+	}
+}
+
+func countTokenSegments(enc tokenizer.Codec, segments []string) (int64, error) {
+	joined := strings.TrimSpace(strings.Join(segments, "\n"))
+	if joined == "" {
+		return 0, nil
+	}
+	count, err := enc.Count(joined)
+	if err != nil {
+		return 0, err
+	}
+	return int64(count), nil
+}
@@ -0,0 +1,50 @@
+package helps
+
+import (
+	"testing"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+func TestCountInputTokensLocallyAgreesAcrossFormats(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog while the proxy counts every token locally."
+	payloads := map[sdktranslator.Format]string{
+		sdktranslator.FormatOpenAI:         `{"messages":[{"role":"user","content":"` + text + `"}]}`,
+		sdktranslator.FormatOpenAIResponse: `{"input":[{"role":"user","content":[{"type":"input_text","text":"` + text + `"}]}]}`,
+		sdktranslator.FormatClaude:         `{"messages":[{"role":"user","content":[{"type":"text","text":"` + text + `"}]}]}`,
+		sdktranslator.FormatGemini:         `{"contents":[{"role":"user","parts":[{"text":"` + text + `"}]}]}`,
+	}
+	for format, payload := range payloads {
+		count, err := CountInputTokensLocally(format, "gpt-4o", []byte(payload))
+		if err != nil {
+			t.Fatalf("%s: CountInputTokensLocally() error = %v", format, err)
+		}
+		// Roughly one token per word plus the role; formats differ only in framing.
+		if count < 15 || count > 25 {
+			t.Fatalf("%s: count = %d, want about 19", format, count)
+		}
+	}
+}
+
+func TestCountInputTokensLocallyIncludesToolsAndSystem(t *testing.T) {
+	base := `{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`
+	withExtras := `{"systemInstruction":{"parts":[{"text":"You are a careful assistant."}]},` +
+		`"contents":[{"role":"user","parts":[{"text":"hi"}]},{"role":"model","parts":[{"functionCall":{"name":"lookup","args":{"q":"x"}}}]}],` +
+		`"tools":[{"functionDeclarations":[{"name":"lookup","description":"Look something up"}]}]}`
+
+	small, err := CountInputTokensLocally(sdktranslator.FormatGemini, "gemini-2.5-pro", []byte(base))
+	if err != nil {
+		t.Fatalf("CountInputTokensLocally() error = %v", err)
+	}
+	large, err := CountInputTokensLocally(sdktranslator.FormatGemini, "gemini-2.5-pro", []byte(withExtras))
+	if err != nil {
+		t.Fatalf("CountInputTokensLocally() error = %v", err)
+	}
+	if large <= small+10 {
+		t.Fatalf("counts = %d and %d, want system, tool call and tools counted", small, large)
+	}
+
+	if _, err = CountInputTokensLocally(sdktranslator.FormatGemini, "gemini-2.5-pro", []byte(`{"contents":`)); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
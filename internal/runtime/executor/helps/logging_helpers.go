@@ -14,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/signingaudit"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -62,6 +63,7 @@ func requestLogCaptureEnabled(cfg *config.Config) bool {
 
 // RecordAPIRequest stores the upstream request metadata in Gin context for request logging.
 func RecordAPIRequest(ctx context.Context, cfg *config.Config, info UpstreamRequestLog) {
+	recordSigningAudit(ctx, cfg, info)
 	if cfg == nil || cfg.CommercialMode {
 		return
 	}
@@ -759,3 +761,21 @@ func CreditsUsed(ctx context.Context) bool {
 	}
 	return false
 }
+
+// recordSigningAudit notes which auth material signed the upstream request when the signing
+// audit is enabled. It runs regardless of request logging and commercial mode.
+func recordSigningAudit(ctx context.Context, cfg *config.Config, info UpstreamRequestLog) {
+	if cfg == nil || !cfg.SigningAudit.Enable {
+		return
+	}
+	signingaudit.Default().Record(cfg.SigningAudit.MaxRequests, logging.GetRequestID(ctx), signingaudit.Attempt{
+		URL:       info.URL,
+		Method:    info.Method,
+		Headers:   info.Headers,
+		Provider:  info.Provider,
+		AuthID:    info.AuthID,
+		AuthLabel: info.AuthLabel,
+		AuthType:  info.AuthType,
+		AuthValue: info.AuthValue,
+	})
+}
@@ -0,0 +1,180 @@
+package helps
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// ReasoningNormalizationEnabled reports whether OpenAI-compatible upstream reasoning should be
+// normalized into reasoning_content before translation. Both the expose and strip modes need
+// it: stripping relies on reasoning being in the field the translators understand.
+func ReasoningNormalizationEnabled(cfg *config.Config) bool {
+	if cfg == nil {
+		return false
+	}
+	return cfg.ReasoningOutput == config.ReasoningOutputExpose || cfg.ReasoningOutput == config.ReasoningOutputStrip
+}
+
+// NormalizeOpenAIReasoning moves reasoning that OpenAI-compatible upstreams return in
+// "reasoning", "reasoning_details" or leading <think> tags of a chat completion into
+// reasoning_content, where the protocol translators pick it up.
+func NormalizeOpenAIReasoning(body []byte) []byte {
+	for i, choice := range gjson.GetBytes(body, "choices").Array() {
+		path := fmt.Sprintf("choices.%d.message", i)
+		message := choice.Get("message")
+		if !message.Exists() {
+			continue
+		}
+		reasoning := message.Get("reasoning_content").String()
+		if reasoning == "" {
+			reasoning = alternateOpenAIReasoning(message)
+		}
+		body, _ = sjson.DeleteBytes(body, path+".reasoning")
+		body, _ = sjson.DeleteBytes(body, path+".reasoning_details")
+		if content := message.Get("content"); content.Type == gjson.String {
+			if thought, rest, ok := splitThinkTags(content.String()); ok {
+				reasoning += thought
+				body, _ = sjson.SetBytes(body, path+".content", rest)
+			}
+		}
+		if reasoning != "" {
+			body, _ = sjson.SetBytes(body, path+".reasoning_content", reasoning)
+		}
+	}
+	return body
+}
+
+// alternateOpenAIReasoning returns reasoning from the non-standard fields used by some
+// OpenAI-compatible upstreams.
+func alternateOpenAIReasoning(message gjson.Result) string {
+	if reasoning := message.Get("reasoning"); reasoning.Type == gjson.String {
+		return reasoning.String()
+	}
+	var parts []string
+	for _, detail := range message.Get("reasoning_details").Array() {
+		if text := detail.Get("text").String(); text != "" {
+			parts = append(parts, text)
+		} else if summary := detail.Get("summary").String(); summary != "" {
+			parts = append(parts, summary)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// splitThinkTags splits a leading <think>...</think> section from content. An unterminated
+// section is all reasoning.
+func splitThinkTags(content string) (thought, rest string, ok bool) {
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	if !strings.HasPrefix(trimmed, thinkOpenTag) {
+		return "", content, false
+	}
+	trimmed = trimmed[len(thinkOpenTag):]
+	end := strings.Index(trimmed, thinkCloseTag)
+	if end < 0 {
+		return trimmed, "", true
+	}
+	return trimmed[:end], strings.TrimLeft(trimmed[end+len(thinkCloseTag):], "\r\n"), true
+}
+
+type thinkPhase int
+
+const (
+	thinkPhaseStart thinkPhase = iota
+	thinkPhaseInside
+	thinkPhaseDone
+)
+
+// OpenAIReasoningStream normalizes the reasoning of one OpenAI-compatible chat completion
+// stream into reasoning_content deltas, tracking <think> sections across chunks.
+type OpenAIReasoningStream struct {
+	phases map[int64]thinkPhase
+}
+
+// NewOpenAIReasoningStream creates the normalization state for one stream.
+func NewOpenAIReasoningStream() *OpenAIReasoningStream {
+	return &OpenAIReasoningStream{phases: make(map[int64]thinkPhase)}
+}
+
+// Normalize rewrites one SSE data line.
+func (s *OpenAIReasoningStream) Normalize(line []byte) []byte {
+	payload := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+	if len(payload) == 0 || bytes.Equal(payload, []byte("[DONE]")) || !gjson.ValidBytes(payload) {
+		return line
+	}
+	out := payload
+	changed := false
+	for i, choice := range gjson.GetBytes(payload, "choices").Array() {
+		delta := choice.Get("delta")
+		if !delta.Exists() {
+			continue
+		}
+		path := fmt.Sprintf("choices.%d.delta", i)
+		reasoning := delta.Get("reasoning_content").String()
+		if alt := alternateOpenAIReasoning(delta); alt != "" {
+			reasoning += alt
+			changed = true
+		}
+		if delta.Get("reasoning").Exists() || delta.Get("reasoning_details").Exists() {
+			out, _ = sjson.DeleteBytes(out, path+".reasoning")
+			out, _ = sjson.DeleteBytes(out, path+".reasoning_details")
+			changed = true
+		}
+		if content := delta.Get("content"); content.Type == gjson.String {
+			thought, rest, split := s.splitContent(choice.Get("index").Int(), content.String())
+			if split {
+				reasoning += thought
+				if rest == "" {
+					out, _ = sjson.DeleteBytes(out, path+".content")
+				} else {
+					out, _ = sjson.SetBytes(out, path+".content", rest)
+				}
+				changed = true
+			}
+		}
+		if changed && reasoning != "" {
+			out, _ = sjson.SetBytes(out, path+".reasoning_content", reasoning)
+		}
+	}
+	if !changed {
+		return line
+	}
+	return append([]byte("data: "), out...)
+}
+
+// splitContent advances the <think> state of choice index with one content delta and reports
+// whether the delta carried reasoning.
+func (s *OpenAIReasoningStream) splitContent(index int64, content string) (thought, rest string, split bool) {
+	switch s.phases[index] {
+	case thinkPhaseStart:
+		if strings.TrimSpace(content) == "" {
+			return "", content, false
+		}
+		trimmed := strings.TrimLeft(content, " \t\r\n")
+		if !strings.HasPrefix(trimmed, thinkOpenTag) {
+			s.phases[index] = thinkPhaseDone
+			return "", content, false
+		}
+		s.phases[index] = thinkPhaseInside
+		thought, rest, _ = s.splitContent(index, trimmed[len(thinkOpenTag):])
+		return thought, rest, true
+	case thinkPhaseInside:
+		end := strings.Index(content, thinkCloseTag)
+		if end < 0 {
+			return content, "", true
+		}
+		s.phases[index] = thinkPhaseDone
+		return content[:end], strings.TrimLeft(content[end+len(thinkCloseTag):], "\r\n"), true
+	default:
+		return "", content, false
+	}
+}
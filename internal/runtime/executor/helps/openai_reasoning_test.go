@@ -0,0 +1,74 @@
+package helps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeOpenAIReasoning(t *testing.T) {
+	cases := []struct {
+		name          string
+		body          string
+		wantReasoning string
+		wantContent   string
+	}{
+		{
+			name:          "reasoning field",
+			body:          `{"choices":[{"message":{"role":"assistant","content":"4","reasoning":"2+2"}}]}`,
+			wantReasoning: "2+2",
+			wantContent:   "4",
+		},
+		{
+			name:          "reasoning details",
+			body:          `{"choices":[{"message":{"role":"assistant","content":"4","reasoning_details":[{"type":"reasoning.text","text":"add "},{"type":"reasoning.text","text":"them"}]}}]}`,
+			wantReasoning: "add them",
+			wantContent:   "4",
+		},
+		{
+			name:          "think tags",
+			body:          `{"choices":[{"message":{"role":"assistant","content":"\n<think>2+2</think>\n\n4"}}]}`,
+			wantReasoning: "2+2",
+			wantContent:   "4",
+		},
+		{
+			name:        "no reasoning",
+			body:        `{"choices":[{"message":{"role":"assistant","content":"a <think> in the middle"}}]}`,
+			wantContent: "a <think> in the middle",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := gjson.ParseBytes(NormalizeOpenAIReasoning([]byte(tc.body)))
+			if got := out.Get("choices.0.message.reasoning_content").String(); got != tc.wantReasoning {
+				t.Fatalf("reasoning_content = %q, want %q", got, tc.wantReasoning)
+			}
+			if got := out.Get("choices.0.message.content").String(); got != tc.wantContent {
+				t.Fatalf("content = %q, want %q", got, tc.wantContent)
+			}
+			if out.Get("choices.0.message.reasoning").Exists() || out.Get("choices.0.message.reasoning_details").Exists() {
+				t.Fatalf("non-standard reasoning fields kept: %s", out.Raw)
+			}
+		})
+	}
+}
+
+func TestOpenAIReasoningStreamSplitsThinkTagsAcrossChunks(t *testing.T) {
+	stream := NewOpenAIReasoningStream()
+	var reasoning, content strings.Builder
+	for _, delta := range []string{`"<think>let me"`, `" think</think>\nThe"`, `" answer"`} {
+		line := stream.Normalize([]byte(`data: {"choices":[{"index":0,"delta":{"content":` + delta + `}}]}`))
+		payload := strings.TrimPrefix(string(line), "data: ")
+		reasoning.WriteString(gjson.Get(payload, "choices.0.delta.reasoning_content").String())
+		content.WriteString(gjson.Get(payload, "choices.0.delta.content").String())
+	}
+	if reasoning.String() != "let me think" || content.String() != "The answer" {
+		t.Fatalf("reasoning = %q, content = %q", reasoning.String(), content.String())
+	}
+
+	line := stream.Normalize([]byte(`data: {"choices":[{"index":0,"delta":{"reasoning":"more"}}]}`))
+	if got := gjson.Get(strings.TrimPrefix(string(line), "data: "), "choices.0.delta.reasoning_content").String(); got != "more" {
+		t.Fatalf("reasoning delta = %s", line)
+	}
+}
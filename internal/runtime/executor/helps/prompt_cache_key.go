@@ -0,0 +1,54 @@
+package helps
+
+import (
+	"bytes"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+// ClaudeCacheBreakpointKey derives a prompt_cache_key from the cache_control breakpoints of a
+// Claude Messages request. The key covers the model and the prompt prefix up to the last
+// breakpoint in Anthropic's evaluation order (tools, system, messages), so requests that share
+// a cached prefix share a key. It returns "" when the request has no breakpoints.
+func ClaudeCacheBreakpointKey(model string, payload []byte) string {
+	root := gjson.ParseBytes(payload)
+	var prefix bytes.Buffer
+	breakpoint := -1
+	add := func(item gjson.Result, cached bool) {
+		prefix.WriteString(item.Raw)
+		prefix.WriteByte(0)
+		if cached || item.Get("cache_control").IsObject() {
+			breakpoint = prefix.Len()
+		}
+	}
+
+	for _, tool := range root.Get("tools").Array() {
+		add(tool, false)
+	}
+	if system := root.Get("system"); system.IsArray() {
+		for _, block := range system.Array() {
+			add(block, false)
+		}
+	} else if system.Exists() {
+		add(system, false)
+	}
+	for _, message := range root.Get("messages").Array() {
+		prefix.WriteString(message.Get("role").String())
+		content := message.Get("content")
+		if !content.IsArray() {
+			add(content, message.Get("cache_control").IsObject())
+			continue
+		}
+		blocks := content.Array()
+		for i, block := range blocks {
+			add(block, i == len(blocks)-1 && message.Get("cache_control").IsObject())
+		}
+	}
+	if breakpoint < 0 {
+		return ""
+	}
+
+	identity := append([]byte("cli-proxy-api:prompt-cache:"+model+"\x00"), prefix.Bytes()[:breakpoint]...)
+	return uuid.NewSHA1(uuid.NameSpaceOID, identity).String()
+}
@@ -0,0 +1,35 @@
+package helps
+
+import "testing"
+
+func TestClaudeCacheBreakpointKey(t *testing.T) {
+	first := []byte(`{"system":[{"type":"text","text":"You are terse.","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":"hi"}]}`)
+	second := []byte(`{"system":[{"type":"text","text":"You are terse.","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":"bye"}]}`)
+	otherSystem := []byte(`{"system":[{"type":"text","text":"You are verbose.","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":"hi"}]}`)
+
+	key := ClaudeCacheBreakpointKey("gpt-5", first)
+	if key == "" {
+		t.Fatal("expected a key for a request with a breakpoint")
+	}
+	if got := ClaudeCacheBreakpointKey("gpt-5", second); got != key {
+		t.Fatalf("content after the breakpoint changed the key: %s != %s", got, key)
+	}
+	if got := ClaudeCacheBreakpointKey("gpt-5", otherSystem); got == key {
+		t.Fatal("a different cached prefix produced the same key")
+	}
+	if got := ClaudeCacheBreakpointKey("gpt-5-mini", first); got == key {
+		t.Fatal("a different model produced the same key")
+	}
+	if got := ClaudeCacheBreakpointKey("gpt-5", []byte(`{"messages":[{"role":"user","content":"hi"}]}`)); got != "" {
+		t.Fatalf("expected no key without breakpoints, got %q", got)
+	}
+}
+
+func TestClaudeCacheBreakpointKey_MessageBreakpoint(t *testing.T) {
+	history := `{"role":"user","content":[{"type":"text","text":"long document","cache_control":{"type":"ephemeral"}}]}`
+	first := []byte(`{"messages":[` + history + `,{"role":"user","content":"question one"}]}`)
+	second := []byte(`{"messages":[` + history + `,{"role":"user","content":"question two"}]}`)
+	if a, b := ClaudeCacheBreakpointKey("m", first), ClaudeCacheBreakpointKey("m", second); a == "" || a != b {
+		t.Fatalf("keys = %q, %q", a, b)
+	}
+}
@@ -0,0 +1,128 @@
+package helps
+
+import (
+	"net/http"
+
+	tls "github.com/refraction-networking/utls"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+)
+
+// utlsProfile pairs a utls client hello with the HTTP/2 settings sent on connections using it.
+type utlsProfile struct {
+	hello tls.ClientHelloID
+	http2 config.TLSFingerprintHTTP2
+}
+
+// utlsClientHellos maps tls-fingerprint profile names to utls client hellos.
+var utlsClientHellos = map[string]tls.ClientHelloID{
+	config.TLSFingerprintProfileChrome:     tls.HelloChrome_Auto,
+	config.TLSFingerprintProfileFirefox:    tls.HelloFirefox_Auto,
+	config.TLSFingerprintProfileSafari:     tls.HelloSafari_Auto,
+	config.TLSFingerprintProfileEdge:       tls.HelloEdge_Auto,
+	config.TLSFingerprintProfileIOS:        tls.HelloIOS_Auto,
+	config.TLSFingerprintProfileRandomized: tls.HelloRandomizedALPN,
+}
+
+// utlsHTTP2Presets holds the HTTP/2 SETTINGS each browser profile sends. The randomized
+// profile has no preset and keeps the Go defaults.
+var utlsHTTP2Presets = map[string]config.TLSFingerprintHTTP2{
+	config.TLSFingerprintProfileChrome: {
+		HeaderTableSize:      65536,
+		InitialWindowSize:    6291456,
+		ConnectionWindowSize: 15663105,
+		MaxHeaderListSize:    262144,
+	},
+	config.TLSFingerprintProfileEdge: {
+		HeaderTableSize:      65536,
+		InitialWindowSize:    6291456,
+		ConnectionWindowSize: 15663105,
+		MaxHeaderListSize:    262144,
+	},
+	config.TLSFingerprintProfileFirefox: {
+		HeaderTableSize:      65536,
+		InitialWindowSize:    131072,
+		ConnectionWindowSize: 12517377,
+		MaxFrameSize:         16384,
+	},
+	config.TLSFingerprintProfileSafari: {
+		InitialWindowSize:    4194304,
+		ConnectionWindowSize: 10485760,
+	},
+	config.TLSFingerprintProfileIOS: {
+		InitialWindowSize:    4194304,
+		ConnectionWindowSize: 10485760,
+	},
+}
+
+// defaultUtlsProfile is the Chrome hello used for built-in protected hosts. It keeps the
+// Go HTTP/2 settings so existing behavior is unchanged when tls-fingerprint is unset.
+func defaultUtlsProfile() utlsProfile {
+	return utlsProfile{hello: tls.HelloChrome_Auto}
+}
+
+// tlsFingerprintProfileForAuth resolves the tls-fingerprint entry for the auth provider.
+// openai-compatibility credentials also match on their configured compat name.
+func tlsFingerprintProfileForAuth(cfg *config.Config, auth *cliproxyauth.Auth) (utlsProfile, bool) {
+	if cfg == nil || auth == nil || len(cfg.TLSFingerprint.Providers) == 0 {
+		return utlsProfile{}, false
+	}
+	entry, ok := cfg.TLSFingerprint.Lookup(auth.Provider)
+	if !ok && auth.Attributes != nil {
+		entry, ok = cfg.TLSFingerprint.Lookup(auth.Attributes["compat_name"])
+	}
+	if !ok {
+		return utlsProfile{}, false
+	}
+	hello, ok := utlsClientHellos[entry.Profile]
+	if !ok {
+		log.Debugf("tls-fingerprint: unknown profile %q for provider %s", entry.Profile, entry.Provider)
+		return utlsProfile{}, false
+	}
+	return utlsProfile{hello: hello, http2: mergeHTTP2Settings(utlsHTTP2Presets[entry.Profile], entry.HTTP2)}, true
+}
+
+// mergeHTTP2Settings applies non-zero override fields on top of the preset.
+func mergeHTTP2Settings(preset, override config.TLSFingerprintHTTP2) config.TLSFingerprintHTTP2 {
+	if override.HeaderTableSize != 0 {
+		preset.HeaderTableSize = override.HeaderTableSize
+	}
+	if override.InitialWindowSize != 0 {
+		preset.InitialWindowSize = override.InitialWindowSize
+	}
+	if override.ConnectionWindowSize != 0 {
+		preset.ConnectionWindowSize = override.ConnectionWindowSize
+	}
+	if override.MaxHeaderListSize != 0 {
+		preset.MaxHeaderListSize = override.MaxHeaderListSize
+	}
+	if override.MaxFrameSize != 0 {
+		preset.MaxFrameSize = override.MaxFrameSize
+	}
+	return preset
+}
+
+// http2Transport builds the HTTP/2 transport used on top of the utls connection.
+func (p utlsProfile) http2Transport() *http2.Transport {
+	settings := p.http2
+	if settings == (config.TLSFingerprintHTTP2{}) {
+		return &http2.Transport{}
+	}
+
+	// Flow-control windows are only configurable through the net/http HTTP2Config.
+	h1 := &http.Transport{HTTP2: &http.HTTP2Config{
+		MaxReceiveBufferPerConnection: int(settings.ConnectionWindowSize),
+		MaxReceiveBufferPerStream:     int(settings.InitialWindowSize),
+	}}
+	tr, errConfigure := http2.ConfigureTransports(h1)
+	if errConfigure != nil {
+		log.Debugf("tls-fingerprint: failed to configure http2 settings: %v", errConfigure)
+		tr = &http2.Transport{}
+	}
+	tr.MaxDecoderHeaderTableSize = settings.HeaderTableSize
+	tr.MaxHeaderListSize = settings.MaxHeaderListSize
+	tr.MaxReadFrameSize = settings.MaxFrameSize
+	return tr
+}
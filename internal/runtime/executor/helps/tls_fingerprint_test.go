@@ -0,0 +1,122 @@
+package helps
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	tls "github.com/refraction-networking/utls"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"golang.org/x/net/http2"
+)
+
+func TestTLSFingerprintProfileForAuth(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{TLSFingerprint: config.TLSFingerprintConfig{Providers: []config.TLSFingerprintProvider{
+		{Provider: "gemini", Profile: config.TLSFingerprintProfileFirefox},
+		{Provider: "my-compat", Profile: config.TLSFingerprintProfileChrome, HTTP2: config.TLSFingerprintHTTP2{InitialWindowSize: 1 << 20}},
+	}}}
+
+	profile, ok := tlsFingerprintProfileForAuth(cfg, &cliproxyauth.Auth{Provider: "gemini"})
+	if !ok {
+		t.Fatal("expected gemini profile")
+	}
+	if profile.hello != tls.HelloFirefox_Auto {
+		t.Fatalf("hello = %v, want firefox", profile.hello)
+	}
+	if profile.http2.InitialWindowSize != 131072 {
+		t.Fatalf("initial window = %d, want firefox preset", profile.http2.InitialWindowSize)
+	}
+
+	compat := &cliproxyauth.Auth{Provider: "openai-compatibility", Attributes: map[string]string{"compat_name": "My-Compat"}}
+	profile, ok = tlsFingerprintProfileForAuth(cfg, compat)
+	if !ok {
+		t.Fatal("expected compat profile")
+	}
+	if profile.http2.InitialWindowSize != 1<<20 || profile.http2.HeaderTableSize != 65536 {
+		t.Fatalf("http2 = %+v, want override merged over chrome preset", profile.http2)
+	}
+
+	if _, ok = tlsFingerprintProfileForAuth(cfg, &cliproxyauth.Auth{Provider: "claude"}); ok {
+		t.Fatal("expected no profile for unconfigured provider")
+	}
+}
+
+func TestUtlsProfileHTTP2TransportSendsSettings(t *testing.T) {
+	t.Parallel()
+
+	profile := utlsProfile{http2: utlsHTTP2Presets[config.TLSFingerprintProfileChrome]}
+	clientConn, serverConn := net.Pipe()
+	defer func() { _ = serverConn.Close() }()
+
+	go func() {
+		cc, errConn := profile.http2Transport().NewClientConn(clientConn)
+		if errConn == nil {
+			defer func() { _ = cc.Close() }()
+		}
+	}()
+
+	preface := make([]byte, len(http2.ClientPreface))
+	if _, errRead := io.ReadFull(serverConn, preface); errRead != nil {
+		t.Fatalf("read preface: %v", errRead)
+	}
+	framer := http2.NewFramer(io.Discard, serverConn)
+	frame, errFrame := framer.ReadFrame()
+	if errFrame != nil {
+		t.Fatalf("read settings: %v", errFrame)
+	}
+	settings, ok := frame.(*http2.SettingsFrame)
+	if !ok {
+		t.Fatalf("first frame = %T, want settings", frame)
+	}
+	want := map[http2.SettingID]uint32{
+		http2.SettingHeaderTableSize:   65536,
+		http2.SettingInitialWindowSize: 6291456,
+		http2.SettingMaxHeaderListSize: 262144,
+		http2.SettingEnablePush:        0,
+	}
+	for id, value := range want {
+		got, found := settings.Value(id)
+		if !found || got != value {
+			t.Fatalf("setting %v = %d (found %v), want %d", id, got, found, value)
+		}
+	}
+
+	frame, errFrame = framer.ReadFrame()
+	if errFrame != nil {
+		t.Fatalf("read window update: %v", errFrame)
+	}
+	update, ok := frame.(*http2.WindowUpdateFrame)
+	if !ok || update.Increment != 15663105 {
+		t.Fatalf("frame = %#v, want connection window update of 15663105", frame)
+	}
+}
+
+func TestNewProxyAwareHTTPClientUsesFingerprintTransport(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{TLSFingerprint: config.TLSFingerprintConfig{Providers: []config.TLSFingerprintProvider{
+		{Provider: "gemini", Profile: config.TLSFingerprintProfileSafari},
+	}}}
+
+	client := NewProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{Provider: "gemini"}, 0)
+	transport, ok := client.Transport.(*fallbackRoundTripper)
+	if !ok {
+		t.Fatalf("transport type = %T, want *fallbackRoundTripper", client.Transport)
+	}
+	if !transport.allHosts {
+		t.Fatal("expected fingerprint transport to cover every HTTPS host")
+	}
+	rt, ok := transport.utls.(*utlsRoundTripper)
+	if !ok || rt.profile.hello != tls.HelloSafari_Auto {
+		t.Fatalf("utls transport = %#v, want safari profile", transport.utls)
+	}
+
+	plain := NewProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{Provider: "claude"}, 0)
+	if _, isFallback := plain.Transport.(*fallbackRoundTripper); isFallback {
+		t.Fatal("expected unconfigured provider to keep the standard transport")
+	}
+}
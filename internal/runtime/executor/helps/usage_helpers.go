@@ -13,7 +13,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/bandwidth"
 	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
@@ -31,9 +33,11 @@ type UsageReporter struct {
 	authType     string
 	apiKey       string
 	source       string
+	scope        string
 	reasoning    string
 	serviceTier  string
 	generate     bool
+	tags         []string
 	requestedAt  time.Time
 	ttftMu       sync.RWMutex
 	ttft         time.Duration
@@ -70,9 +74,11 @@ func NewUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		apiKey:      apiKey,
 		source:      resolveUsageSource(auth, apiKey),
 		authType:    resolveUsageAuthType(auth),
+		scope:       resolveUsageScope(auth),
 		reasoning:   usage.ReasoningEffortFromContext(ctx),
 		serviceTier: usage.ServiceTierFromContext(ctx),
 		generate:    usage.GenerateFromContext(ctx),
+		tags:        usage.RequestTagsFromContext(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -121,8 +127,9 @@ func (r *UsageReporter) TrackHTTPClient(client *http.Client) *http.Client {
 		transport = http.DefaultTransport
 	}
 	tracked.Transport = usageTTFTRoundTripper{
-		base:     transport,
-		reporter: r,
+		base:      transport,
+		reporter:  r,
+		bandwidth: bandwidth.Default().Counter(r.provider, r.authID, r.authIndex, r.apiKey),
 	}
 	return &tracked
 }
@@ -237,9 +244,44 @@ func (r *UsageReporter) EnsurePublished(ctx context.Context) {
 
 func (r *UsageReporter) publishRecord(ctx context.Context, record usage.Record) {
 	record.ResponseHeaders = internallogging.GetResponseHeaders(ctx)
+	addRequestCost(ctx, record.EstimatedCost)
 	usage.PublishRecord(ctx, record)
 }
 
+// estimateUsageCost prices the token breakdown using the upstream model, falling back to the client alias.
+func estimateUsageCost(model, alias, provider string, detail usage.Detail) float64 {
+	pricing := registry.LookupModelPricing(model, provider)
+	if pricing == nil && alias != "" && alias != model {
+		pricing = registry.LookupModelPricing(alias, provider)
+	}
+	if pricing == nil {
+		return 0
+	}
+	breakdown := detail.TokenBreakdown
+	return pricing.EstimateCost(
+		breakdown.Input.UncachedTokens,
+		breakdown.Input.CacheReadTokens,
+		breakdown.Input.CacheWriteTokens,
+		breakdown.Output.TotalTokens,
+	)
+}
+
+// addRequestCost accumulates cost on the request's cost tracker for the X-Estimated-Cost header.
+func addRequestCost(ctx context.Context, cost float64) {
+	if ctx == nil || cost <= 0 {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	if tracker, exists := ginCtx.Get(usage.CostTrackerGinKey); exists {
+		if costTracker, okTracker := tracker.(*usage.CostTracker); okTracker {
+			costTracker.Add(cost)
+		}
+	}
+}
+
 func (r *UsageReporter) buildRecord(detail usage.Detail, failed bool, failures ...usage.Failure) usage.Record {
 	var fail usage.Failure
 	if len(failures) > 0 {
@@ -265,6 +307,7 @@ func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, f
 		AuthID:              r.authID,
 		AuthIndex:           r.authIndex,
 		AuthType:            r.authType,
+		Scope:               r.scope,
 		ReasoningEffort:     r.reasoning,
 		ServiceTier:         r.serviceTier,
 		ResponseServiceTier: strings.TrimSpace(detail.ResponseServiceTier),
@@ -275,6 +318,8 @@ func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, f
 		Failed:              failed,
 		Fail:                fail,
 		Detail:              detail,
+		EstimatedCost:       estimateUsageCost(model, r.alias, r.provider, detail),
+		Tags:                r.tags,
 	}
 }
 
@@ -334,16 +379,18 @@ func (r *UsageReporter) ttftDuration() time.Duration {
 }
 
 type usageTTFTRoundTripper struct {
-	base     http.RoundTripper
-	reporter *UsageReporter
+	base      http.RoundTripper
+	reporter  *UsageReporter
+	bandwidth *bandwidth.Counter
 }
 
 func (t usageTTFTRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	t.reporter.StartResponseTTFT()
-	resp, errRoundTrip := t.base.RoundTrip(req)
+	resp, errRoundTrip := t.base.RoundTrip(t.bandwidth.ObserveRequest(req))
 	if errRoundTrip != nil {
 		return resp, errRoundTrip
 	}
+	t.bandwidth.ObserveResponse(resp)
 	t.reporter.ObserveResponse(resp)
 	return resp, nil
 }
@@ -425,6 +472,23 @@ func resolveUsageSource(auth *cliproxyauth.Auth, ctxAPIKey string) string {
 	return ""
 }
 
+// resolveUsageScope returns the credential's configured organization/project or workspace.
+func resolveUsageScope(auth *cliproxyauth.Auth) string {
+	if auth == nil || auth.Attributes == nil {
+		return ""
+	}
+	parts := make([]string, 0, 2)
+	for _, key := range []string{"organization", "project"} {
+		if value := strings.TrimSpace(auth.Attributes[key]); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, "/")
+	}
+	return strings.TrimSpace(auth.Attributes["workspace"])
+}
+
 func resolveUsageAuthType(auth *cliproxyauth.Auth) string {
 	if auth == nil {
 		return ""
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
 )
 
@@ -574,6 +575,21 @@ func TestUsageReporterBuildRecordDefaultsGenerateTrue(t *testing.T) {
 	}
 }
 
+func TestUsageReporterBuildRecordIncludesCredentialScope(t *testing.T) {
+	auth := &cliproxyauth.Auth{ID: "codex-1", Provider: "codex", Attributes: map[string]string{"organization": "org-1", "project": "proj_a"}}
+	reporter := NewUsageReporter(context.Background(), "codex", "gpt-5.4", auth)
+
+	if record := reporter.buildRecord(usage.Detail{TotalTokens: 3}, false); record.Scope != "org-1/proj_a" {
+		t.Fatalf("scope = %q, want org-1/proj_a", record.Scope)
+	}
+
+	auth = &cliproxyauth.Auth{ID: "claude-1", Provider: "claude", Attributes: map[string]string{"workspace": "research"}}
+	reporter = NewUsageReporter(context.Background(), "claude", "claude-sonnet-4-5", auth)
+	if record := reporter.buildRecord(usage.Detail{TotalTokens: 3}, false); record.Scope != "research" {
+		t.Fatalf("scope = %q, want research", record.Scope)
+	}
+}
+
 func TestUsageReporterBuildRecordIncludesGenerateFalse(t *testing.T) {
 	ctx := usage.WithGenerate(context.Background(), false)
 	reporter := NewUsageReporter(ctx, "openai", "gpt-5.4", nil)
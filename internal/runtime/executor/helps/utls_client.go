@@ -2,6 +2,8 @@ package helps
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"net"
 	"net/http"
 	"strings"
@@ -18,15 +20,54 @@ import (
 )
 
 // utlsRoundTripper implements http.RoundTripper using utls with Chrome fingerprint
-// to bypass Cloudflare's TLS fingerprinting on Anthropic domains.
+// to bypass Cloudflare's TLS fingerprinting on Anthropic domains. Hosts that do not
+// negotiate HTTP/2 are served over HTTP/1.1 on the same utls connections.
 type utlsRoundTripper struct {
 	mu          sync.Mutex
 	connections map[string]*http2.ClientConn
 	pending     map[string]*sync.Cond
-	dialer      proxy.Dialer
+	// http1Hosts records hosts whose server picked HTTP/1.1 during the ALPN negotiation.
+	http1Hosts map[string]struct{}
+	// handoff keeps the connection that revealed an HTTP/1.1 host, by address, for the
+	// HTTP/1.1 transport to use instead of handshaking again.
+	handoff map[string]net.Conn
+	http1   *http.Transport
+	dialer  proxy.Dialer
+	profile utlsProfile
+
+	// rootCAs overrides the system roots; tests use it to trust httptest servers.
+	rootCAs *x509.CertPool
+}
+
+// errHTTP1Negotiated reports a utls connection whose server did not select HTTP/2.
+var errHTTP1Negotiated = errors.New("utls: server negotiated HTTP/1.1")
+
+// utlsRoundTripperKey identifies the round tripper shared by clients with the same proxy and profile.
+type utlsRoundTripperKey struct {
+	proxyURL string
+	profile  utlsProfile
+}
+
+var (
+	sharedUtlsMu           sync.Mutex
+	sharedUtlsRoundTripper = make(map[utlsRoundTripperKey]*utlsRoundTripper)
+)
+
+// sharedUtlsRoundTripperFor returns the round tripper for proxyURL and profile, so clients built
+// per request reuse its HTTP/2 connections instead of handshaking again.
+func sharedUtlsRoundTripperFor(proxyURL string, profile utlsProfile) *utlsRoundTripper {
+	key := utlsRoundTripperKey{proxyURL: strings.TrimSpace(proxyURL), profile: profile}
+	sharedUtlsMu.Lock()
+	defer sharedUtlsMu.Unlock()
+	if rt := sharedUtlsRoundTripper[key]; rt != nil {
+		return rt
+	}
+	rt := newUtlsRoundTripper(key.proxyURL, profile)
+	sharedUtlsRoundTripper[key] = rt
+	return rt
 }
 
-func newUtlsRoundTripper(proxyURL string) *utlsRoundTripper {
+func newUtlsRoundTripper(proxyURL string, profile utlsProfile) *utlsRoundTripper {
 	var dialer proxy.Dialer = proxy.Direct
 	if proxyURL != "" {
 		proxyDialer, mode, errBuild := proxyutil.BuildDialer(proxyURL)
@@ -36,11 +77,20 @@ func newUtlsRoundTripper(proxyURL string) *utlsRoundTripper {
 			dialer = proxyDialer
 		}
 	}
-	return &utlsRoundTripper{
+	t := &utlsRoundTripper{
 		connections: make(map[string]*http2.ClientConn),
 		pending:     make(map[string]*sync.Cond),
+		http1Hosts:  make(map[string]struct{}),
+		handoff:     make(map[string]net.Conn),
 		dialer:      dialer,
+		profile:     profile,
 	}
+	t.http1 = &http.Transport{
+		DialTLSContext:      t.dialHTTP1,
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return t
 }
 
 func (t *utlsRoundTripper) getOrCreateConnection(host, addr string) (*http2.ClientConn, error) {
@@ -57,6 +107,10 @@ func (t *utlsRoundTripper) getOrCreateConnection(host, addr string) (*http2.Clie
 			t.mu.Unlock()
 			return h2Conn, nil
 		}
+		if _, ok := t.http1Hosts[host]; ok {
+			t.mu.Unlock()
+			return nil, errHTTP1Negotiated
+		}
 	}
 
 	cond := sync.NewCond(&t.mu)
@@ -80,27 +134,65 @@ func (t *utlsRoundTripper) getOrCreateConnection(host, addr string) (*http2.Clie
 }
 
 func (t *utlsRoundTripper) createConnection(host, addr string) (*http2.ClientConn, error) {
+	tlsConn, err := t.dialUTLS(host, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConn.ConnectionState().NegotiatedProtocol != http2.NextProtoTLS {
+		t.mu.Lock()
+		t.http1Hosts[host] = struct{}{}
+		if previous := t.handoff[addr]; previous != nil {
+			previous.Close()
+		}
+		t.handoff[addr] = tlsConn
+		t.mu.Unlock()
+		return nil, errHTTP1Negotiated
+	}
+
+	tr := t.profile.http2Transport()
+	h2Conn, err := tr.NewClientConn(tlsConn)
+	if err != nil {
+		tlsConn.Close()
+		return nil, err
+	}
+
+	return h2Conn, nil
+}
+
+// dialUTLS connects to addr through the dialer and completes a utls handshake for host.
+func (t *utlsRoundTripper) dialUTLS(host, addr string) (*tls.UConn, error) {
 	conn, err := t.dialer.Dial("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
 
-	tlsConfig := &tls.Config{ServerName: host}
-	tlsConn := tls.UClient(conn, tlsConfig, tls.HelloChrome_Auto)
+	tlsConfig := &tls.Config{ServerName: host, RootCAs: t.rootCAs}
+	tlsConn := tls.UClient(conn, tlsConfig, t.profile.hello)
 
 	if err := tlsConn.Handshake(); err != nil {
 		conn.Close()
 		return nil, err
 	}
+	return tlsConn, nil
+}
 
-	tr := &http2.Transport{}
-	h2Conn, err := tr.NewClientConn(tlsConn)
+// dialHTTP1 supplies utls connections to the HTTP/1.1 transport, starting with the one handed
+// off by createConnection.
+func (t *utlsRoundTripper) dialHTTP1(_ context.Context, _, addr string) (net.Conn, error) {
+	t.mu.Lock()
+	conn := t.handoff[addr]
+	delete(t.handoff, addr)
+	t.mu.Unlock()
+	if conn != nil {
+		return conn, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
-		tlsConn.Close()
 		return nil, err
 	}
-
-	return h2Conn, nil
+	return t.dialUTLS(host, addr)
 }
 
 func (t *utlsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -111,7 +203,17 @@ func (t *utlsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	}
 	addr := net.JoinHostPort(hostname, port)
 
+	t.mu.Lock()
+	_, http1 := t.http1Hosts[hostname]
+	t.mu.Unlock()
+	if http1 {
+		return t.http1.RoundTrip(req)
+	}
+
 	h2Conn, err := t.getOrCreateConnection(hostname, addr)
+	if errors.Is(err, errHTTP1Negotiated) {
+		return t.http1.RoundTrip(req)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -137,14 +239,19 @@ var utlsProtectedHosts = map[string]struct{}{
 }
 
 // fallbackRoundTripper uses utls for protected HTTPS hosts and falls back to
-// standard transport for all other requests.
+// standard transport for all other requests. When allHosts is set, every HTTPS
+// request uses utls.
 type fallbackRoundTripper struct {
 	utls     http.RoundTripper
 	fallback http.RoundTripper
+	allHosts bool
 }
 
 func (f *fallbackRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	if req.URL.Scheme == "https" {
+		if f.allHosts {
+			return f.utls.RoundTrip(req)
+		}
 		if _, ok := utlsProtectedHosts[strings.ToLower(req.URL.Hostname())]; ok {
 			return f.utls.RoundTrip(req)
 		}
@@ -155,35 +262,41 @@ func (f *fallbackRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 // NewUtlsHTTPClient creates an HTTP client using utls Chrome TLS fingerprint.
 // Use this for provider requests that need a Chrome-like TLS fingerprint.
 // Falls back to standard transport for non-HTTPS requests.
+// A tls-fingerprint profile configured for the auth provider replaces the
+// Chrome default and applies to every HTTPS host.
 func NewUtlsHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
-	var proxyURL string
-	if auth != nil {
-		proxyURL = strings.TrimSpace(auth.ProxyURL)
-	}
-	if proxyURL == "" && cfg != nil {
-		proxyURL = strings.TrimSpace(cfg.ProxyURL)
+	profile, configured := tlsFingerprintProfileForAuth(cfg, auth)
+	if !configured {
+		profile = defaultUtlsProfile()
 	}
+	return newUtlsHTTPClient(ctx, cfg, auth, timeout, profile, configured)
+}
+
+func newUtlsHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration, profile utlsProfile, allHosts bool) *http.Client {
+	proxyURL := ProxyURLForAuth(cfg, auth)
 
 	var ctxRoundTripper http.RoundTripper
 	if ctx != nil {
 		ctxRoundTripper, _ = ctx.Value("cliproxy.roundtripper").(http.RoundTripper)
 	}
 
-	var utlsRT http.RoundTripper = newUtlsRoundTripper(proxyURL)
-	var standardTransport http.RoundTripper = http.DefaultTransport
-	if proxyURL != "" {
+	var utlsRT, standardTransport http.RoundTripper
+	if proxyURL == "" && ctxRoundTripper != nil {
+		utlsRT = ctxRoundTripper
+		standardTransport = ctxRoundTripper
+	} else {
+		utlsRT = sharedUtlsRoundTripperFor(proxyURL, profile)
+		standardTransport = http.DefaultTransport
 		if transport := buildProxyTransport(proxyURL); transport != nil {
 			standardTransport = transport
 		}
-	} else if ctxRoundTripper != nil {
-		utlsRT = ctxRoundTripper
-		standardTransport = ctxRoundTripper
 	}
 
 	client := &http.Client{
 		Transport: &fallbackRoundTripper{
 			utls:     utlsRT,
 			fallback: standardTransport,
+			allHosts: allHosts,
 		},
 	}
 	if timeout > 0 {
@@ -2,10 +2,14 @@ package helps
 
 import (
 	"context"
+	"crypto/x509"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 )
 
 type utlsClientRoundTripFunc func(*http.Request) (*http.Response, error)
@@ -43,3 +47,54 @@ func TestNewUtlsHTTPClientUsesContextRoundTripperForProtectedHost(t *testing.T)
 		t.Fatal("expected context RoundTripper to handle protected host request")
 	}
 }
+
+func TestNewUtlsHTTPClientSharesRoundTripperPerProxyAndProfile(t *testing.T) {
+	t.Parallel()
+
+	utlsOf := func(proxyURL string, profile utlsProfile) http.RoundTripper {
+		cfg := &config.Config{}
+		cfg.ProxyURL = proxyURL
+		client := newUtlsHTTPClient(context.Background(), cfg, nil, 0, profile, false)
+		return client.Transport.(*fallbackRoundTripper).utls
+	}
+	chrome := defaultUtlsProfile()
+	firefox := utlsProfile{hello: utlsClientHellos[config.TLSFingerprintProfileFirefox]}
+	if utlsOf("", chrome) != utlsOf("", chrome) {
+		t.Fatal("clients with the same proxy and profile use different round trippers")
+	}
+	if utlsOf("", chrome) == utlsOf("", firefox) {
+		t.Fatal("clients with different profiles share a round tripper")
+	}
+	if utlsOf("", chrome) == utlsOf("http://proxy.example.com:8080", chrome) {
+		t.Fatal("clients with different proxies share a round tripper")
+	}
+}
+
+func TestUtlsRoundTripperFallsBackToHTTP1(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	defer server.Close()
+
+	rt := newUtlsRoundTripper("", defaultUtlsProfile())
+	rt.rootCAs = x509.NewCertPool()
+	rt.rootCAs.AddCert(server.Certificate())
+	client := &http.Client{Transport: rt}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/v1/models")
+		if err != nil {
+			t.Fatalf("request %d returned error: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != "HTTP/1.1" {
+			t.Fatalf("request %d used %q, want HTTP/1.1", i, body)
+		}
+	}
+	if len(rt.handoff) != 0 {
+		t.Fatalf("handoff keeps %d connections, want the probed connection reused", len(rt.handoff))
+	}
+}
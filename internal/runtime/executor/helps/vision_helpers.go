@@ -0,0 +1,31 @@
+package helps
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/vision"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
+)
+
+// NormalizeImages applies the vision settings to a translated upstream payload: remote images
+// are inlined for Claude and Gemini formats and oversized images are downscaled. Remote images
+// are fetched over direct connections whose addresses can be checked, so they are not fetched
+// at all for credentials that use a proxy.
+func NormalizeImages(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, format string, payload []byte) []byte {
+	if cfg == nil || !cfg.Vision.Enabled() {
+		return payload
+	}
+	normalizer := vision.Normalizer{Downscale: cfg.Vision.Downscale}
+	if cfg.Vision.FetchRemoteImages {
+		setting, _ := proxyutil.Parse(ProxyURLForAuth(cfg, auth))
+		normalizer.Fetcher = &vision.Fetcher{
+			Timeout:  time.Duration(cfg.Vision.FetchTimeoutSeconds) * time.Second,
+			MaxBytes: int64(cfg.Vision.MaxFetchSizeMB) << 20,
+			Proxied:  setting.Mode == proxyutil.ModeProxy || setting.Mode == proxyutil.ModeInvalid,
+		}
+	}
+	return normalizer.Normalize(ctx, format, payload)
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// mistralUnsupportedChatFields lists OpenAI chat fields that Mistral rejects with a 422
+// because its request schema forbids unknown properties.
+var mistralUnsupportedChatFields = []string{
+	"stream_options",
+	"store",
+	"metadata",
+	"user",
+	"service_tier",
+	"logprobs",
+	"top_logprobs",
+	"logit_bias",
+	"reasoning_effort",
+	"modalities",
+	"audio",
+}
+
+// NewMistralExecutor creates the executor for mistral-api-key credentials. Mistral serves the
+// OpenAI chat completions protocol at {base-url}/chat/completions and Codestral
+// fill-in-the-middle at {base-url}/fim/completions, so the OpenAI-compatible executor is reused
+// with a payload filter for the fields Mistral does not accept.
+func NewMistralExecutor(cfg *config.Config) *OpenAICompatExecutor {
+	return &OpenAICompatExecutor{provider: "mistral", cfg: cfg, finalizeChatPayload: sanitizeMistralChatPayload}
+}
+
+// sanitizeMistralChatPayload maps max_completion_tokens to max_tokens and drops fields outside
+// the Mistral chat schema.
+func sanitizeMistralChatPayload(payload []byte) []byte {
+	if maxTokens := gjson.GetBytes(payload, "max_completion_tokens"); maxTokens.Exists() {
+		if !gjson.GetBytes(payload, "max_tokens").Exists() {
+			if updated, errSet := sjson.SetRawBytes(payload, "max_tokens", []byte(maxTokens.Raw)); errSet == nil {
+				payload = updated
+			}
+		}
+		if updated, errDelete := sjson.DeleteBytes(payload, "max_completion_tokens"); errDelete == nil {
+			payload = updated
+		}
+	}
+	for _, field := range mistralUnsupportedChatFields {
+		if !gjson.GetBytes(payload, field).Exists() {
+			continue
+		}
+		if updated, errDelete := sjson.DeleteBytes(payload, field); errDelete == nil {
+			payload = updated
+		}
+	}
+	return payload
+}
@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newMistralTestAuth(baseURL string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{ID: "mistral-test", Provider: "mistral", Attributes: map[string]string{
+		"api_key":  "mistral-key",
+		"base_url": baseURL,
+	}}
+}
+
+func TestMistralExecutorStreamDropsUnsupportedChatFields(t *testing.T) {
+	var seenPath string
+	var seenBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenPath = r.URL.Path
+		seenBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"mistral-small-latest\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"bonjour\"},\"finish_reason\":\"stop\"}]}\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	exec := NewMistralExecutor(&config.Config{})
+	if exec.Identifier() != "mistral" {
+		t.Fatalf("Identifier() = %q", exec.Identifier())
+	}
+	result, err := exec.ExecuteStream(context.Background(), newMistralTestAuth(server.URL), cliproxyexecutor.Request{
+		Model:   "mistral-small-latest",
+		Payload: []byte(`{"model":"mistral-small-latest","stream":true,"user":"u1","store":true,"max_completion_tokens":64,"messages":[{"role":"user","content":"salut"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var text strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(chunk.Payload)), "data:"))
+		text.WriteString(gjson.Get(payload, "choices.0.delta.content").String())
+	}
+	if text.String() != "bonjour" {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+	if seenPath != "/chat/completions" {
+		t.Fatalf("path = %q", seenPath)
+	}
+	for _, field := range []string{"stream_options", "user", "store", "max_completion_tokens"} {
+		if gjson.GetBytes(seenBody, field).Exists() {
+			t.Fatalf("upstream body kept %s: %s", field, seenBody)
+		}
+	}
+	if got := gjson.GetBytes(seenBody, "max_tokens").Int(); got != 64 {
+		t.Fatalf("max_tokens = %d, body %s", got, seenBody)
+	}
+}
+
+func TestMistralExecutorFIMCompletions(t *testing.T) {
+	var seenPath, seenAuth string
+	var seenBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenPath = r.URL.Path
+		seenAuth = r.Header.Get("Authorization")
+		seenBody, _ = io.ReadAll(r.Body)
+		if gjson.GetBytes(seenBody, "stream").Bool() {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"id\":\"f1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"return a + b\"}}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"f1","object":"chat.completion","model":"codestral-latest","choices":[{"index":0,"message":{"role":"assistant","content":"return a + b"},"finish_reason":"stop"}],"usage":{"prompt_tokens":9,"completion_tokens":4,"total_tokens":13}}`))
+	}))
+	defer server.Close()
+
+	exec := NewMistralExecutor(&config.Config{})
+	auth := newMistralTestAuth(server.URL)
+	payload := []byte(`{"model":"codestral","prompt":"def add(a, b):\n    ","suffix":"\n\nprint(add(1, 2))","max_tokens":32}`)
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "codestral-latest", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Alt: fimCompletionsAlt})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if seenPath != "/fim/completions" || seenAuth != "Bearer mistral-key" {
+		t.Fatalf("path = %q, Authorization = %q", seenPath, seenAuth)
+	}
+	if gjson.GetBytes(seenBody, "model").String() != "codestral-latest" || gjson.GetBytes(seenBody, "suffix").String() != "\n\nprint(add(1, 2))" {
+		t.Fatalf("upstream body = %s", seenBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "return a + b" {
+		t.Fatalf("response = %s", resp.Payload)
+	}
+
+	result, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{Model: "codestral-latest", Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Alt: fimCompletionsAlt, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var raw strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		raw.Write(chunk.Payload)
+	}
+	if !gjson.GetBytes(seenBody, "stream").Bool() {
+		t.Fatalf("streaming upstream body = %s", seenBody)
+	}
+	if !strings.Contains(raw.String(), `"content":"return a + b"`) || !strings.Contains(raw.String(), "data: [DONE]") {
+		t.Fatalf("relayed stream = %q", raw.String())
+	}
+}
@@ -22,15 +22,19 @@ import (
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
 const (
-	openAICompatImageHandlerType            = "openai-image"
-	openAICompatImagesGenerationsPath       = "/images/generations"
-	openAICompatImagesEditsPath             = "/images/edits"
-	openAICompatDefaultImageEndpoint        = openAICompatImagesGenerationsPath
-	openAICompatMultipartMemory       int64 = 32 << 20
+	openAICompatImageHandlerType             = "openai-image"
+	openAICompatImagesGenerationsPath        = "/images/generations"
+	openAICompatImagesEditsPath              = "/images/edits"
+	openAICompatDefaultImageEndpoint         = openAICompatImagesGenerationsPath
+	openAICompatEmbeddingsPath               = "/embeddings"
+	openAICompatAudioTranscriptionsAlt       = "audio/transcriptions"
+	openAICompatAudioSpeechAlt               = "audio/speech"
+	openAICompatMultipartMemory        int64 = 32 << 20
 )
 
 // OpenAICompatExecutor implements a stateless executor for OpenAI-compatible providers.
@@ -39,6 +43,8 @@ const (
 type OpenAICompatExecutor struct {
 	provider string
 	cfg      *config.Config
+	// finalizeChatPayload adjusts the translated chat body for vendors with a stricter schema.
+	finalizeChatPayload func([]byte) []byte
 }
 
 // NewOpenAICompatExecutor creates an executor bound to a provider key (e.g., "openrouter").
@@ -86,6 +92,16 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	if endpointPath := openAICompatImageEndpointPath(opts); endpointPath != "" {
 		return e.executeImages(ctx, auth, req, opts, endpointPath)
 	}
+	if opts.Alt == "embeddings" {
+		// Embeddings are a plain JSON pass-through, like the JSON image endpoints.
+		return e.executeImages(ctx, auth, req, opts, openAICompatEmbeddingsPath)
+	}
+	if opts.Alt == imagesGenerationsAlt {
+		return e.executeImages(ctx, auth, req, opts, openAICompatImagesGenerationsPath)
+	}
+	if isOpenAIAudioAlt(opts.Alt) || opts.Alt == fimCompletionsAlt {
+		return e.executeImages(ctx, auth, req, opts, "/"+opts.Alt)
+	}
 
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
@@ -122,12 +138,16 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	requestPath := helps.PayloadRequestPath(opts)
 	translated = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", translated, originalTranslated, requestedModel, requestPath, opts.Headers)
+	translated = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), translated)
 	if opts.Alt == "responses/compact" {
 		if updated, errDelete := sjson.DeleteBytes(translated, "stream"); errDelete == nil {
 			translated = updated
 		}
 		translated = sanitizeOpenAIResponsesReasoningEncryptedContent(ctx, "openai compat executor", translated)
+	} else if e.finalizeChatPayload != nil {
+		translated = e.finalizeChatPayload(translated)
 	}
+	translated = e.applyPromptCacheKey(auth, from, baseModel, req.Payload, translated)
 	reporter.SetTranslatedReasoningEffort(translated, to.String())
 
 	url := strings.TrimSuffix(baseURL, "/") + endpoint
@@ -192,6 +212,9 @@ func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	reporter.Publish(ctx, helps.ParseOpenAIUsage(body))
 	// Ensure we at least record the request even if upstream doesn't return usage
 	reporter.EnsurePublished(ctx)
+	if helps.ReasoningNormalizationEnabled(e.cfg) {
+		body = helps.NormalizeOpenAIReasoning(body)
+	}
 	// Translate response back to source format when needed
 	var param any
 	out := sdktranslator.TranslateNonStream(ctx, to, responseFormat, req.Model, opts.OriginalRequest, translated, body, &param)
@@ -211,7 +234,7 @@ func (e *OpenAICompatExecutor) executeImages(ctx context.Context, auth *cliproxy
 		return resp, err
 	}
 
-	payload, contentType, errPrepare := prepareOpenAICompatImagesPayload(req.Payload, baseModel, opts.Headers.Get("Content-Type"), false)
+	payload, contentType, errPrepare := prepareOpenAICompatPassthroughPayload(req.Payload, baseModel, opts, false)
 	if errPrepare != nil {
 		err = errPrepare
 		return resp, err
@@ -292,6 +315,9 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	if endpointPath := openAICompatImageEndpointPath(opts); endpointPath != "" {
 		return e.executeImagesStream(ctx, auth, req, opts, endpointPath)
 	}
+	if isOpenAIAudioAlt(opts.Alt) || opts.Alt == fimCompletionsAlt {
+		return e.executeImagesStream(ctx, auth, req, opts, "/"+opts.Alt)
+	}
 
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
@@ -323,10 +349,15 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	requestPath := helps.PayloadRequestPath(opts)
 	translated = helps.ApplyPayloadConfigWithRequest(e.cfg, baseModel, to.String(), from.String(), "", translated, originalTranslated, requestedModel, requestPath, opts.Headers)
+	translated = helps.NormalizeImages(ctx, e.cfg, auth, to.String(), translated)
 
 	// Request usage data in the final streaming chunk so that token statistics
 	// are captured even when the upstream is an OpenAI-compatible provider.
 	translated = helps.SetBoolIfDifferent(translated, "stream_options.include_usage", true)
+	if e.finalizeChatPayload != nil {
+		translated = e.finalizeChatPayload(translated)
+	}
+	translated = e.applyPromptCacheKey(auth, from, baseModel, req.Payload, translated)
 	reporter.SetTranslatedReasoningEffort(translated, to.String())
 
 	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
@@ -393,6 +424,10 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		scanner := bufio.NewScanner(httpResp.Body)
 		scanner.Buffer(nil, 52_428_800) // 50MB
 		claudeInputTokens := helps.NewClaudeInputTokenState(from, to, responseFormat, originalPayload)
+		var reasoningStream *helps.OpenAIReasoningStream
+		if helps.ReasoningNormalizationEnabled(e.cfg) {
+			reasoningStream = helps.NewOpenAIReasoningStream()
+		}
 		var param any
 		var streamUsage helps.StreamUsageBuffer
 		defer streamUsage.Publish(ctx, reporter)
@@ -424,7 +459,11 @@ func (e *OpenAICompatExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 			}
 
 			// OpenAI-compatible streams must use SSE data lines.
-			chunks := helps.TranslateStreamWithClaudeInputTokens(ctx, to, responseFormat, req.Model, opts.OriginalRequest, translated, bytes.Clone(trimmedLine), &param, claudeInputTokens)
+			dataLine := bytes.Clone(trimmedLine)
+			if reasoningStream != nil {
+				dataLine = reasoningStream.Normalize(dataLine)
+			}
+			chunks := helps.TranslateStreamWithClaudeInputTokens(ctx, to, responseFormat, req.Model, opts.OriginalRequest, translated, dataLine, &param, claudeInputTokens)
 			for i := range chunks {
 				select {
 				case out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}:
@@ -472,7 +511,7 @@ func (e *OpenAICompatExecutor) executeImagesStream(ctx context.Context, auth *cl
 		return nil, err
 	}
 
-	payload, contentType, errPrepare := prepareOpenAICompatImagesPayload(req.Payload, baseModel, opts.Headers.Get("Content-Type"), true)
+	payload, contentType, errPrepare := prepareOpenAICompatPassthroughPayload(req.Payload, baseModel, opts, true)
 	if errPrepare != nil {
 		err = errPrepare
 		return nil, err
@@ -488,7 +527,9 @@ func (e *OpenAICompatExecutor) executeImagesStream(ctx context.Context, auth *cl
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", contentType)
-	httpReq.Header.Set("Accept", "text/event-stream")
+	if opts.Alt != openAICompatAudioSpeechAlt {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
 	httpReq.Header.Set("Cache-Control", "no-cache")
 	if apiKey != "" {
 		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
@@ -630,6 +671,38 @@ func openAICompatImageEndpointPath(opts cliproxyexecutor.Options) string {
 	return openAICompatDefaultImageEndpoint
 }
 
+// imagesGenerationsAlt marks /v1/images/generations requests for models that list the endpoint
+// in the registry, such as Gemini Imagen.
+const imagesGenerationsAlt = "images/generations"
+
+// fimCompletionsAlt marks /v1/fim/completions (fill-in-the-middle) pass-through requests.
+const fimCompletionsAlt = "fim/completions"
+
+// isEndpointAlt reports whether alt marks a non-chat endpoint request (embeddings, image
+// generation, audio or fill-in-the-middle) that only some executors serve.
+func isEndpointAlt(alt string) bool {
+	return alt == "embeddings" || alt == imagesGenerationsAlt || isOpenAIAudioAlt(alt) || alt == fimCompletionsAlt
+}
+
+// isOpenAIAudioAlt reports whether alt marks an /audio/* pass-through request.
+func isOpenAIAudioAlt(alt string) bool {
+	return alt == openAICompatAudioTranscriptionsAlt || alt == openAICompatAudioSpeechAlt
+}
+
+// prepareOpenAICompatPassthroughPayload rewrites the model of a pass-through request body.
+// JSON audio requests keep their stream settings: speech streams its body regardless and
+// selects SSE through stream_format.
+func prepareOpenAICompatPassthroughPayload(payload []byte, model string, opts cliproxyexecutor.Options, stream bool) ([]byte, string, error) {
+	contentType := opts.Headers.Get("Content-Type")
+	if isOpenAIAudioAlt(opts.Alt) && json.Valid(payload) {
+		if model = strings.TrimSpace(model); model != "" {
+			payload = helps.SetStringIfDifferent(payload, "model", model)
+		}
+		return payload, "application/json", nil
+	}
+	return prepareOpenAICompatImagesPayload(payload, model, contentType, stream)
+}
+
 func prepareOpenAICompatImagesPayload(payload []byte, model string, contentType string, stream bool) ([]byte, string, error) {
 	model = strings.TrimSpace(model)
 	contentType = strings.TrimSpace(contentType)
@@ -775,6 +848,22 @@ func (e *OpenAICompatExecutor) resolveCompatConfig(auth *cliproxyauth.Auth) *con
 	return nil
 }
 
+// applyPromptCacheKey translates the cache_control breakpoints of a Claude-format request into
+// a prompt_cache_key when the provider opts in and the client did not send one.
+func (e *OpenAICompatExecutor) applyPromptCacheKey(auth *cliproxyauth.Auth, from sdktranslator.Format, model string, source, translated []byte) []byte {
+	if from != sdktranslator.FormatClaude || gjson.GetBytes(translated, "prompt_cache_key").Exists() {
+		return translated
+	}
+	compat := e.resolveCompatConfig(auth)
+	if compat == nil || !compat.PromptCacheKey {
+		return translated
+	}
+	if key := helps.ClaudeCacheBreakpointKey(model, source); key != "" {
+		translated = helps.SetStringIfDifferent(translated, "prompt_cache_key", key)
+	}
+	return translated
+}
+
 func (e *OpenAICompatExecutor) overrideModel(payload []byte, model string) []byte {
 	if len(payload) == 0 || model == "" {
 		return payload
@@ -442,3 +442,140 @@ func TestOpenAICompatExecutorStreamSkipsKeepAliveUntilDataLine(t *testing.T) {
 		t.Fatalf("stream payload = %s", got.String())
 	}
 }
+
+func TestOpenAICompatExecutorAudioSpeechStreamsBinaryBody(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write([]byte("ID3\x00\x01audio"))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url": server.URL + "/v1",
+		"api_key":  "test",
+	}}
+	result, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "tts-1-hd",
+		Payload: []byte(`{"model":"speech","input":"hello","voice":"alloy"}`),
+	}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai-audio"),
+		Alt:          "audio/speech",
+		Stream:       true,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var audio []byte
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		audio = append(audio, chunk.Payload...)
+	}
+	if gotPath != "/v1/audio/speech" {
+		t.Fatalf("path = %q, want %q", gotPath, "/v1/audio/speech")
+	}
+	if got := gjson.GetBytes(gotBody, "model").String(); got != "tts-1-hd" {
+		t.Fatalf("model = %q, want %q", got, "tts-1-hd")
+	}
+	if gjson.GetBytes(gotBody, "stream").Exists() {
+		t.Fatalf("unexpected stream field in speech body: %s", gotBody)
+	}
+	if string(audio) != "ID3\x00\x01audio" {
+		t.Fatalf("audio = %q", audio)
+	}
+}
+
+func TestOpenAICompatExecutorAudioTranscriptionRewritesMultipartModel(t *testing.T) {
+	var gotPath string
+	var gotModel, gotStream, gotFile string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if errParse := r.ParseMultipartForm(1 << 20); errParse != nil {
+			t.Errorf("parse multipart: %v", errParse)
+			return
+		}
+		gotModel = r.FormValue("model")
+		gotStream = r.FormValue("stream")
+		if file, _, errFile := r.FormFile("file"); errFile == nil {
+			data, _ := io.ReadAll(file)
+			gotFile = string(data)
+			_ = file.Close()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("model", "transcribe")
+	_ = writer.WriteField("stream", "false")
+	part, _ := writer.CreateFormFile("file", "clip.wav")
+	_, _ = part.Write([]byte("RIFF-audio"))
+	_ = writer.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url": server.URL + "/v1",
+		"api_key":  "test",
+	}}
+	headers := http.Header{}
+	headers.Set("Content-Type", writer.FormDataContentType())
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "whisper-1",
+		Payload: body.Bytes(),
+	}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai-audio"),
+		Alt:          "audio/transcriptions",
+		Headers:      headers,
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotPath != "/v1/audio/transcriptions" {
+		t.Fatalf("path = %q, want %q", gotPath, "/v1/audio/transcriptions")
+	}
+	if gotModel != "whisper-1" || gotStream != "" || gotFile != "RIFF-audio" {
+		t.Fatalf("model = %q, stream = %q, file = %q", gotModel, gotStream, gotFile)
+	}
+	if string(resp.Payload) != `{"text":"hello"}` {
+		t.Fatalf("payload = %s", resp.Payload)
+	}
+}
+
+func TestOpenAICompatExecutorPromptCacheKeyFromClaudeBreakpoints(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl_1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	payload := []byte(`{"model":"custom-openai","max_tokens":64,"system":[{"type":"text","text":"rules","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":"hi"}]}`)
+	for _, enabled := range []bool{false, true} {
+		executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{
+			OpenAICompatibility: []config.OpenAICompatibility{{Name: "cache", BaseURL: server.URL, PromptCacheKey: enabled}},
+		})
+		auth := &cliproxyauth.Auth{Provider: "cache", Attributes: map[string]string{"base_url": server.URL, "api_key": "test"}}
+		if _, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+			Model:   "custom-openai",
+			Payload: payload,
+		}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")}); err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		key := gjson.GetBytes(gotBody, "prompt_cache_key").String()
+		if enabled && key == "" {
+			t.Fatalf("expected prompt_cache_key, body = %s", gotBody)
+		}
+		if !enabled && key != "" {
+			t.Fatalf("unexpected prompt_cache_key %q", key)
+		}
+	}
+}
@@ -4634,3 +4634,91 @@ func testValidGrokEncryptedContent() string {
 	}
 	return base64.RawStdEncoding.EncodeToString(buf[:256])
 }
+
+func TestXAIExecutorExecuteOpenAIChatToolCalls(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_tool\",\"object\":\"response\",\"created_at\":0,\"status\":\"completed\",\"model\":\"grok-4.3\",\"output\":[{\"type\":\"function_call\",\"id\":\"fc_1\",\"call_id\":\"call_1\",\"name\":\"lookup\",\"arguments\":\"{\\\"q\\\":\\\"grok\\\"}\"}],\"usage\":{\"input_tokens\":5,\"output_tokens\":3,\"total_tokens\":8}}}\n\n"))
+	}))
+	defer server.Close()
+
+	exec := NewXAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "xai-api-key", Provider: "xai", Attributes: map[string]string{
+		"api_key":   "xai-key",
+		"base_url":  server.URL,
+		"auth_kind": "apikey",
+	}}
+	resp, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "grok-4.3",
+		Payload: []byte(`{"model":"grok-4.3","messages":[{"role":"user","content":"search grok"}],"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object","properties":{"q":{"type":"string"}}}}}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := gjson.GetBytes(gotBody, "tools.#(name==\"lookup\").type").String(); got != "function" {
+		t.Fatalf("upstream tools = %s", gjson.GetBytes(gotBody, "tools").Raw)
+	}
+	call := gjson.GetBytes(resp.Payload, "choices.0.message.tool_calls.0")
+	if call.Get("id").String() != "call_1" || call.Get("function.name").String() != "lookup" {
+		t.Fatalf("tool call = %s; payload %s", call.Raw, resp.Payload)
+	}
+	if got := call.Get("function.arguments").String(); got != `{"q":"grok"}` {
+		t.Fatalf("tool call arguments = %q", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q", got)
+	}
+}
+
+func TestXAIExecutorExecuteStreamOpenAIChatToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"response.created","response":{"id":"resp_tool","object":"response","created_at":0,"status":"in_progress","model":"grok-4.3","output":[]}}`,
+			`{"type":"response.output_item.added","output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"lookup","arguments":""}}`,
+			`{"type":"response.function_call_arguments.delta","output_index":0,"item_id":"fc_1","delta":"{\"q\":\"grok\"}"}`,
+			`{"type":"response.output_item.done","output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"lookup","arguments":"{\"q\":\"grok\"}"}}`,
+			`{"type":"response.completed","response":{"id":"resp_tool","object":"response","created_at":0,"status":"completed","model":"grok-4.3","output":[{"type":"function_call","id":"fc_1","call_id":"call_1","name":"lookup","arguments":"{\"q\":\"grok\"}"}],"usage":{"input_tokens":5,"output_tokens":3,"total_tokens":8}}}`,
+		}
+		for _, event := range events {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+	}))
+	defer server.Close()
+
+	exec := NewXAIExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "xai-api-key", Provider: "xai", Attributes: map[string]string{
+		"api_key":   "xai-key",
+		"base_url":  server.URL,
+		"auth_kind": "apikey",
+	}}
+	result, err := exec.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "grok-4.3",
+		Payload: []byte(`{"model":"grok-4.3","stream":true,"messages":[{"role":"user","content":"search grok"}],"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object"}}}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var name, arguments, finishReason string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(chunk.Payload)), "data:"))
+		if payload == "" || payload == "[DONE]" {
+			continue
+		}
+		if v := gjson.Get(payload, "choices.0.delta.tool_calls.0.function.name").String(); v != "" {
+			name = v
+		}
+		arguments += gjson.Get(payload, "choices.0.delta.tool_calls.0.function.arguments").String()
+		if v := gjson.Get(payload, "choices.0.finish_reason").String(); v != "" {
+			finishReason = v
+		}
+	}
+	if name != "lookup" || arguments != `{"q":"grok"}` || finishReason != "tool_calls" {
+		t.Fatalf("streamed tool call name=%q arguments=%q finish_reason=%q", name, arguments, finishReason)
+	}
+}
@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds Next for expressions that never match, such as "0 0 30 2 *".
+const cronSearchYears = 5
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	min, max int
+}
+
+var (
+	cronMinute     = cronField{0, 59}
+	cronHour       = cronField{0, 23}
+	cronDayOfMonth = cronField{1, 31}
+	cronMonth      = cronField{1, 12}
+	cronDayOfWeek  = cronField{0, 7}
+)
+
+// Schedule is a parsed five-field cron expression.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record unrestricted day fields; when both day fields are restricted
+	// a time matches if either one does, as in classic cron.
+	domStar, dowStar bool
+}
+
+// ParseCron parses a standard five-field cron expression or a predefined @macro.
+// Fields accept "*", single values, ranges ("1-5"), lists ("1,15") and steps ("*/10", "0-30/5").
+// Day-of-week 0 and 7 both mean Sunday.
+func ParseCron(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+	s := &Schedule{}
+	var errParse error
+	if s.minute, errParse = parseCronField(fields[0], cronMinute); errParse != nil {
+		return nil, fmt.Errorf("cron minute: %w", errParse)
+	}
+	if s.hour, errParse = parseCronField(fields[1], cronHour); errParse != nil {
+		return nil, fmt.Errorf("cron hour: %w", errParse)
+	}
+	if s.dom, errParse = parseCronField(fields[2], cronDayOfMonth); errParse != nil {
+		return nil, fmt.Errorf("cron day of month: %w", errParse)
+	}
+	if s.month, errParse = parseCronField(fields[3], cronMonth); errParse != nil {
+		return nil, fmt.Errorf("cron month: %w", errParse)
+	}
+	if s.dow, errParse = parseCronField(fields[4], cronDayOfWeek); errParse != nil {
+		return nil, fmt.Errorf("cron day of week: %w", errParse)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			value, errStep := strconv.Atoi(stepPart)
+			if errStep != nil || value <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = value
+		}
+
+		lo, hi := bounds.min, bounds.max
+		if rangePart != "*" {
+			start, end, isRange := strings.Cut(rangePart, "-")
+			value, errValue := strconv.Atoi(start)
+			if errValue != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = value, value
+			if isRange {
+				if hi, errValue = strconv.Atoi(end); errValue != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else if hasStep {
+				hi = bounds.max
+			}
+		}
+		if lo < bounds.min || hi > bounds.max || lo > hi {
+			return 0, fmt.Errorf("value %q out of range %d-%d", part, bounds.min, bounds.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first matching minute strictly after t, in t's location.
+// It returns the zero time when nothing matches within the next few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.Year() + cronSearchYears
+
+	for t.Year() <= limit {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func has(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
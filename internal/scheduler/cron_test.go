@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	from := time.Date(2026, 3, 14, 10, 7, 30, 0, time.UTC) // Saturday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"30 9-17 * * 1-5", time.Date(2026, 3, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 6", time.Date(2026, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		schedule, errParse := ParseCron(tc.expr)
+		if errParse != nil {
+			t.Fatalf("ParseCron(%q) error: %v", tc.expr, errParse)
+		}
+		if got := schedule.Next(from); !got.Equal(tc.want) {
+			t.Fatalf("Next(%q) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseCron_RejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@sometimes"} {
+		if _, errParse := ParseCron(expr); errParse == nil {
+			t.Fatalf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestScheduleNext_NeverMatching(t *testing.T) {
+	schedule, errParse := ParseCron("0 0 30 2 *")
+	if errParse != nil {
+		t.Fatalf("ParseCron error: %v", errParse)
+	}
+	if got := schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Fatalf("Next = %v, want zero time", got)
+	}
+}
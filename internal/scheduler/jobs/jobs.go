@@ -0,0 +1,38 @@
+// Package jobs names the background jobs known to the scheduler and records which of them
+// currently run on a cron schedule. It has no dependencies so the packages owning the built-in
+// loops of those jobs can consult it without importing the scheduler.
+package jobs
+
+import "sync/atomic"
+
+// Names of the background jobs registered with the scheduler.
+const (
+	TokenRefresh = "token-refresh"
+	ModelRefresh = "model-refresh"
+	HealthProbe  = "health-probe"
+	RegionProbe  = "region-probe"
+	UsageFlush   = "usage-flush"
+	LogCleanup   = "log-cleanup"
+)
+
+var scheduled atomic.Pointer[map[string]struct{}]
+
+// SetScheduled records the jobs that run on an active cron schedule, replacing the previous set.
+func SetScheduled(names []string) {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	scheduled.Store(&set)
+}
+
+// Scheduled reports whether the named job runs on an active cron schedule. The built-in loop
+// of such a job skips its periodic runs and leaves them to the scheduler.
+func Scheduled(name string) bool {
+	set := scheduled.Load()
+	if set == nil {
+		return false
+	}
+	_, ok := (*set)[name]
+	return ok
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import "testing"
+
+func TestSetScheduledReplacesSet(t *testing.T) {
+	t.Cleanup(func() { SetScheduled(nil) })
+
+	if Scheduled(LogCleanup) {
+		t.Fatal("Scheduled before SetScheduled = true")
+	}
+	SetScheduled([]string{LogCleanup, UsageFlush})
+	if !Scheduled(LogCleanup) || !Scheduled(UsageFlush) || Scheduled(ModelRefresh) {
+		t.Fatal("Scheduled does not match the recorded set")
+	}
+	SetScheduled([]string{ModelRefresh})
+	if Scheduled(LogCleanup) || !Scheduled(ModelRefresh) {
+		t.Fatal("SetScheduled did not replace the previous set")
+	}
+}
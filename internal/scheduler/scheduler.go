@@ -0,0 +1,336 @@
+// Package scheduler runs registered background jobs on cron schedules and records
+// their last-run status for the management API.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Triggers recorded for a job run.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	// ErrUnknownJob is returned by Trigger for names that were never registered.
+	ErrUnknownJob = errors.New("scheduler: unknown job")
+	// ErrJobRunning is returned by Trigger while the job is still running.
+	ErrJobRunning = errors.New("scheduler: job is already running")
+)
+
+// JobFunc performs one run of a job. The context is cancelled when the scheduler stops.
+type JobFunc func(ctx context.Context) error
+
+// JobStatus is the schedule and last-run state of a job.
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Description    string     `json:"description,omitempty"`
+	Cron           string     `json:"cron,omitempty"`
+	Enabled        bool       `json:"enabled"`
+	Running        bool       `json:"running"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms,omitempty"`
+	LastTrigger    string     `json:"last_trigger,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Runs           int64      `json:"runs"`
+	Failures       int64      `json:"failures"`
+	ScheduleError  string     `json:"schedule_error,omitempty"`
+}
+
+type job struct {
+	name        string
+	description string
+	fn          JobFunc
+
+	cron          string
+	schedule      *Schedule
+	scheduleError string
+	disabled      bool
+	next          time.Time
+
+	running      bool
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastTrigger  string
+	lastError    string
+	runs         int64
+	failures     int64
+}
+
+// Scheduler owns the registered jobs and a single loop that starts them when their
+// cron expressions match. A job never runs concurrently with itself.
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    map[string]*job
+	enabled bool
+	// loop identifies the current scheduling loop so a loop left over from a quick
+	// disable/enable cycle exits instead of running alongside the new one.
+	loop   int
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	clock clock.Clock
+}
+
+// New creates an idle scheduler. Jobs run on schedule once Apply enables it.
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		jobs:   make(map[string]*job),
+		ctx:    ctx,
+		cancel: cancel,
+		clock:  clock.Default(),
+	}
+}
+
+// Register adds a job under name, replacing any job registered with the same name.
+func (s *Scheduler) Register(name, description string, fn JobFunc) {
+	if s == nil || name == "" || fn == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.jobs[name]; ok {
+		existing.description = description
+		existing.fn = fn
+		return
+	}
+	s.jobs[name] = &job{name: name, description: description, fn: fn}
+}
+
+// Apply updates the job schedules and starts or stops the scheduling loop.
+// Last-run state is kept across reloads.
+func (s *Scheduler) Apply(cfg config.SchedulerConfig) {
+	if s == nil {
+		return
+	}
+	entries := make(map[string]config.SchedulerJob, len(cfg.Jobs))
+	for _, entry := range cfg.Jobs {
+		entries[entry.Name] = entry
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for name, j := range s.jobs {
+		entry, ok := entries[name]
+		delete(entries, name)
+		j.cron, j.schedule, j.scheduleError, j.disabled, j.next = "", nil, "", false, time.Time{}
+		if !ok {
+			continue
+		}
+		j.cron = entry.Cron
+		j.disabled = entry.Disabled
+		schedule, errParse := ParseCron(entry.Cron)
+		if errParse != nil {
+			j.scheduleError = errParse.Error()
+			log.Warnf("scheduler: job %s: %v", name, errParse)
+			continue
+		}
+		j.schedule = schedule
+		j.next = schedule.Next(now)
+	}
+	for name := range entries {
+		log.Warnf("scheduler: ignoring schedule for unknown job %s", name)
+	}
+
+	if cfg.Enable == s.enabled {
+		return
+	}
+	s.enabled = cfg.Enable
+	if s.enabled {
+		s.loop++
+		s.wg.Add(1)
+		go s.run(s.ctx, s.loop)
+		log.Info("scheduler started")
+	} else {
+		log.Info("scheduler paused")
+	}
+}
+
+// Stop ends the scheduling loop and cancels running jobs.
+func (s *Scheduler) Stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.enabled = false
+	cancel := s.cancel
+	s.mu.Unlock()
+	cancel()
+	s.wg.Wait()
+}
+
+// Jobs returns the status of every registered job sorted by name.
+func (s *Scheduler) Jobs() []JobStatus {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := JobStatus{
+			Name:           j.name,
+			Description:    j.description,
+			Cron:           j.cron,
+			Enabled:        s.enabled && j.schedule != nil && !j.disabled,
+			Running:        j.running,
+			LastDurationMs: j.lastDuration.Milliseconds(),
+			LastTrigger:    j.lastTrigger,
+			LastError:      j.lastError,
+			Runs:           j.runs,
+			Failures:       j.failures,
+			ScheduleError:  j.scheduleError,
+		}
+		if status.Enabled && !j.next.IsZero() {
+			next := j.next
+			status.NextRun = &next
+		}
+		if !j.lastRun.IsZero() {
+			lastRun := j.lastRun
+			status.LastRun = &lastRun
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	return out
+}
+
+// Scheduled returns the names of the jobs that run on a cron schedule, sorted. It is empty
+// while the scheduler is disabled.
+func (s *Scheduler) Scheduled() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled {
+		return nil
+	}
+	var names []string
+	for name, j := range s.jobs {
+		if j.schedule != nil && !j.disabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Trigger starts the named job in the background regardless of its schedule.
+func (s *Scheduler) Trigger(name string) error {
+	if s == nil {
+		return ErrUnknownJob
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+	if j.running {
+		return ErrJobRunning
+	}
+	s.startLocked(j, TriggerManual)
+	return nil
+}
+
+func (s *Scheduler) run(ctx context.Context, loop int) {
+	defer s.wg.Done()
+	timer := s.clock.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+		}
+		wait, ok := s.runDue(loop)
+		if !ok {
+			return
+		}
+		timer.Reset(wait)
+	}
+}
+
+// runDue starts every job whose next run has passed and returns the time until the next
+// check. ok is false once the scheduler has been disabled or the loop replaced.
+func (s *Scheduler) runDue(loop int) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled || loop != s.loop {
+		return 0, false
+	}
+	now := s.clock.Now()
+	// Re-check at least every minute so reloaded schedules take effect promptly.
+	wait := time.Minute
+	for _, j := range s.jobs {
+		if j.schedule == nil || j.disabled || j.next.IsZero() {
+			continue
+		}
+		if !j.next.After(now) {
+			if j.running {
+				log.Debugf("scheduler: skipping job %s, previous run still in progress", j.name)
+			} else {
+				s.startLocked(j, TriggerSchedule)
+			}
+			j.next = j.schedule.Next(now)
+			if j.next.IsZero() {
+				continue
+			}
+		}
+		if until := j.next.Sub(now); until < wait {
+			wait = until
+		}
+	}
+	return wait, true
+}
+
+func (s *Scheduler) startLocked(j *job, trigger string) {
+	j.running = true
+	fn := j.fn
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		started := s.clock.Now()
+		errRun := runJob(s.ctx, fn)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		j.running = false
+		j.lastRun = started
+		j.lastDuration = s.clock.Since(started)
+		j.lastTrigger = trigger
+		j.runs++
+		j.lastError = ""
+		if errRun != nil {
+			j.failures++
+			j.lastError = errRun.Error()
+			log.Warnf("scheduler: job %s failed: %v", j.name, errRun)
+			return
+		}
+		log.Debugf("scheduler: job %s finished in %s (%s)", j.name, j.lastDuration, trigger)
+	}()
+}
+
+func runJob(ctx context.Context, fn JobFunc) (errRun error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			errRun = errors.New("job panicked")
+			log.Errorf("scheduler: job panic: %v", recovered)
+		}
+	}()
+	return fn(ctx)
+}
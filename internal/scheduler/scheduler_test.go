@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func waitForRuns(t *testing.T, s *Scheduler, name string, runs int64) JobStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, status := range s.Jobs() {
+			if status.Name == name && status.Runs >= runs && !status.Running {
+				return status
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach %d run(s)", name, runs)
+	return JobStatus{}
+}
+
+func TestSchedulerTrigger_RecordsStatus(t *testing.T) {
+	s := New()
+	t.Cleanup(s.Stop)
+	release := make(chan struct{})
+	s.Register("flaky", "fails on purpose", func(ctx context.Context) error {
+		<-release
+		return errors.New("boom")
+	})
+
+	if errTrigger := s.Trigger("flaky"); errTrigger != nil {
+		t.Fatalf("Trigger error: %v", errTrigger)
+	}
+	if errTrigger := s.Trigger("flaky"); !errors.Is(errTrigger, ErrJobRunning) {
+		t.Fatalf("second Trigger error = %v, want ErrJobRunning", errTrigger)
+	}
+	if errTrigger := s.Trigger("missing"); !errors.Is(errTrigger, ErrUnknownJob) {
+		t.Fatalf("unknown Trigger error = %v, want ErrUnknownJob", errTrigger)
+	}
+	close(release)
+
+	status := waitForRuns(t, s, "flaky", 1)
+	if status.LastError != "boom" || status.Failures != 1 || status.LastTrigger != TriggerManual || status.LastRun == nil {
+		t.Fatalf("status = %+v, want one failed manual run", status)
+	}
+}
+
+func TestSchedulerRunDue_StartsMatchingJobs(t *testing.T) {
+	now := clock.NewSim(time.Date(2026, 3, 14, 10, 0, 30, 0, time.UTC))
+	s := New()
+	s.clock = now
+	t.Cleanup(s.Stop)
+	ran := make(chan struct{}, 4)
+	s.Register("every-minute", "", func(context.Context) error {
+		ran <- struct{}{}
+		return nil
+	})
+	s.Register("paused", "", func(context.Context) error {
+		t.Error("disabled job ran")
+		return nil
+	})
+	s.Register("unscheduled", "", func(context.Context) error {
+		t.Error("unscheduled job ran")
+		return nil
+	})
+
+	s.Apply(config.SchedulerConfig{Jobs: []config.SchedulerJob{
+		{Name: "every-minute", Cron: "* * * * *"},
+		{Name: "paused", Cron: "* * * * *", Disabled: true},
+	}})
+	s.mu.Lock()
+	s.enabled = true
+	s.mu.Unlock()
+
+	now.Advance(time.Minute)
+	wait, ok := s.runDue(s.loop)
+	if !ok {
+		t.Fatal("runDue reported a stopped scheduler")
+	}
+	if wait != 30*time.Second {
+		t.Fatalf("wait = %v, want 30s until the next minute", wait)
+	}
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduled job did not run")
+	}
+	status := waitForRuns(t, s, "every-minute", 1)
+	if status.LastTrigger != TriggerSchedule || !status.Enabled || status.NextRun == nil {
+		t.Fatalf("status = %+v, want scheduled run with next run", status)
+	}
+}
+
+func TestSchedulerApply_ReportsInvalidCron(t *testing.T) {
+	s := New()
+	t.Cleanup(s.Stop)
+	s.Register("job", "", func(context.Context) error { return nil })
+	s.Apply(config.SchedulerConfig{Enable: true, Jobs: []config.SchedulerJob{{Name: "job", Cron: "not a cron"}}})
+
+	jobs := s.Jobs()
+	if len(jobs) != 1 || jobs[0].Enabled || jobs[0].ScheduleError == "" {
+		t.Fatalf("jobs = %+v, want disabled job with schedule error", jobs)
+	}
+}
+
+func TestSchedulerScheduled_ListsActiveSchedules(t *testing.T) {
+	s := New()
+	t.Cleanup(s.Stop)
+	for _, name := range []string{"hourly", "paused", "invalid", "manual"} {
+		s.Register(name, "", func(context.Context) error { return nil })
+	}
+	cfg := config.SchedulerConfig{Jobs: []config.SchedulerJob{
+		{Name: "hourly", Cron: "@hourly"},
+		{Name: "paused", Cron: "@daily", Disabled: true},
+		{Name: "invalid", Cron: "not a cron"},
+	}}
+	s.Apply(cfg)
+	if names := s.Scheduled(); len(names) != 0 {
+		t.Fatalf("Scheduled while disabled = %v, want none", names)
+	}
+	cfg.Enable = true
+	s.Apply(cfg)
+	if names := s.Scheduled(); len(names) != 1 || names[0] != "hourly" {
+		t.Fatalf("Scheduled = %v, want [hourly]", names)
+	}
+}
+
+func TestSchedulerLoop_RunsOnSimulatedTime(t *testing.T) {
+	now := clock.NewSim(time.Date(2026, 3, 14, 10, 0, 30, 0, time.UTC))
+	s := New()
+	s.clock = now
+	t.Cleanup(s.Stop)
+	s.Register("every-minute", "", func(context.Context) error { return nil })
+	s.Apply(config.SchedulerConfig{Enable: true, Jobs: []config.SchedulerJob{{Name: "every-minute", Cron: "* * * * *"}}})
+
+	for minute := int64(1); minute <= 3; minute++ {
+		deadline := time.Now().Add(2 * time.Second)
+		for now.Waiters() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("scheduling loop did not arm its timer")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		now.Advance(time.Minute)
+		waitForRuns(t, s, "every-minute", minute)
+	}
+}
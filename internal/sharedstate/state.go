@@ -0,0 +1,256 @@
+// Package sharedstate holds the Redis connection that lets several proxy instances behind a
+// load balancer enforce rate limits and quotas together.
+package sharedstate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// callTimeout bounds each Redis call so an unreachable Redis cannot stall request admission.
+const callTimeout = time.Second
+
+// ErrDisabled is returned when no shared Redis is configured.
+var ErrDisabled = errors.New("shared state disabled")
+
+// takeScript refills the token bucket stored in a hash by the elapsed time and consumes one
+// token when available. It returns whether a token was taken and the tokens left.
+var takeScript = redis.NewScript(`
+local rps = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil or last == nil then
+  tokens = burst
+  last = now
+elseif now > last then
+  tokens = math.min(burst, tokens + (now - last) / 1000 * rps)
+  last = now
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(last))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rps * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// State is the shared Redis connection. A nil or unconfigured State is disabled and every
+// call returns ErrDisabled, leaving callers to their local state.
+type State struct {
+	mu     sync.RWMutex
+	cfg    config.SharedStateConfig
+	client *redis.Client
+	prefix string
+}
+
+// New creates a State using the provided configuration.
+func New(cfg config.SharedStateConfig) *State {
+	s := &State{}
+	s.Update(cfg)
+	return s
+}
+
+var defaultState = New(config.SharedStateConfig{})
+
+// Default returns the process-wide State.
+func Default() *State { return defaultState }
+
+// Update applies cfg, reconnecting only when the Redis settings change.
+func (s *State) Update(cfg config.SharedStateConfig) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg == cfg && (s.client != nil || cfg.RedisURL == "") {
+		return
+	}
+	if s.client != nil {
+		if errClose := s.client.Close(); errClose != nil {
+			log.Debugf("shared-state: failed to close redis client: %v", errClose)
+		}
+		s.client = nil
+	}
+	s.cfg = cfg
+	s.prefix = cfg.RedisPrefix
+	if cfg.RedisURL == "" {
+		return
+	}
+	options, errParse := redis.ParseURL(cfg.RedisURL)
+	if errParse != nil {
+		log.Warnf("shared-state: invalid redis-url, keeping state local: %v", errParse)
+		return
+	}
+	s.client = redis.NewClient(options)
+	log.Info("shared-state: sharing rate limits and quotas through redis")
+}
+
+// Enabled reports whether a shared Redis is configured.
+func (s *State) Enabled() bool {
+	_, _, ok := s.conn()
+	return ok
+}
+
+func (s *State) conn() (*redis.Client, string, bool) {
+	if s == nil {
+		return nil, "", false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.client, s.prefix, s.client != nil
+}
+
+// Take consumes one token from the shared bucket key refilling at rps up to burst, as of now.
+// It returns whether a token was taken and the tokens left in the bucket.
+func (s *State) Take(key string, rps, burst float64, now time.Time) (bool, float64, error) {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return false, 0, ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	result, errRun := takeScript.Run(ctx, client, []string{prefix + "bucket:" + key},
+		rps, burst, now.UnixMilli()).Slice()
+	if errRun != nil {
+		return false, 0, errRun
+	}
+	if len(result) != 2 {
+		return false, 0, errors.New("shared-state: unexpected bucket result")
+	}
+	allowed, _ := result[0].(int64)
+	text, _ := result[1].(string)
+	tokens, errParse := strconv.ParseFloat(text, 64)
+	if errParse != nil {
+		return false, 0, errParse
+	}
+	return allowed == 1, tokens, nil
+}
+
+// Peek returns the tokens in the shared bucket key as of now without consuming one. A bucket
+// that does not exist yet is full.
+func (s *State) Peek(key string, rps, burst float64, now time.Time) (float64, error) {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return 0, ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	values, errGet := client.HMGet(ctx, prefix+"bucket:"+key, "tokens", "last").Result()
+	if errGet != nil {
+		return 0, errGet
+	}
+	tokensText, _ := values[0].(string)
+	lastText, _ := values[1].(string)
+	tokens, errTokens := strconv.ParseFloat(tokensText, 64)
+	last, errLast := strconv.ParseFloat(lastText, 64)
+	if errTokens != nil || errLast != nil {
+		return burst, nil
+	}
+	if elapsed := float64(now.UnixMilli()) - last; elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed/1000*rps)
+	}
+	return tokens, nil
+}
+
+// Add increments the shared counter key by delta and returns its new value. The counter
+// expires at expireAt.
+func (s *State) Add(key string, delta int64, expireAt time.Time) (int64, error) {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return 0, ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	pipe := client.TxPipeline()
+	incr := pipe.IncrBy(ctx, prefix+"counter:"+key, delta)
+	pipe.ExpireAt(ctx, prefix+"counter:"+key, expireAt)
+	if _, errExec := pipe.Exec(ctx); errExec != nil {
+		return 0, errExec
+	}
+	return incr.Val(), nil
+}
+
+// Seed sets the shared counter key to value unless it already exists, so a counter created after
+// usage was recorded elsewhere starts from that usage. It reports whether the counter was set.
+func (s *State) Seed(key string, value int64, expireAt time.Time) (bool, error) {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return false, ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	return client.SetNX(ctx, prefix+"counter:"+key, value, time.Until(expireAt)).Result()
+}
+
+// Counter returns the value of the shared counter key; a missing counter is 0.
+func (s *State) Counter(key string) (int64, error) {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return 0, ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	value, errGet := client.Get(ctx, prefix+"counter:"+key).Int64()
+	if errors.Is(errGet, redis.Nil) {
+		return 0, nil
+	}
+	return value, errGet
+}
+
+// Put stores value under key until expireAt, replacing any previous value.
+func (s *State) Put(key string, value int64, expireAt time.Time) error {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	return client.Set(ctx, prefix+"value:"+key, value, time.Until(expireAt)).Err()
+}
+
+// Get returns the value stored under key by Put; found is false when it is missing or expired.
+func (s *State) Get(key string) (value int64, found bool, err error) {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return 0, false, ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	value, errGet := client.Get(ctx, prefix+"value:"+key).Int64()
+	if errors.Is(errGet, redis.Nil) {
+		return 0, false, nil
+	}
+	return value, errGet == nil, errGet
+}
+
+// Delete removes the value stored under key by Put.
+func (s *State) Delete(key string) error {
+	client, prefix, ok := s.conn()
+	if !ok {
+		return ErrDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	return client.Del(ctx, prefix+"value:"+key).Err()
+}
+
+// HashKey returns a stable digest of a client API key so keys are not stored in Redis.
+func HashKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
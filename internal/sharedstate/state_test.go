@@ -0,0 +1,49 @@
+package sharedstate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestState_DisabledWithoutValidRedisURL(t *testing.T) {
+	for _, cfg := range []config.SharedStateConfig{{}, {RedisURL: "not a url", RedisPrefix: "p:"}} {
+		s := New(cfg)
+		if s.Enabled() {
+			t.Fatalf("Enabled() = true for %+v", cfg)
+		}
+		if _, _, err := s.Take("k", 1, 1, time.Now()); !errors.Is(err, ErrDisabled) {
+			t.Fatalf("Take err = %v, want ErrDisabled", err)
+		}
+		if _, err := s.Add("k", 1, time.Now().Add(time.Hour)); !errors.Is(err, ErrDisabled) {
+			t.Fatalf("Add err = %v, want ErrDisabled", err)
+		}
+	}
+
+	var nilState *State
+	if _, err := nilState.Counter("k"); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("nil Counter err = %v, want ErrDisabled", err)
+	}
+}
+
+func TestState_UpdateConnectsAndDisconnects(t *testing.T) {
+	s := New(config.SharedStateConfig{RedisURL: "redis://localhost:6379/0", RedisPrefix: "p:"})
+	if !s.Enabled() {
+		t.Fatal("Enabled() = false with a valid redis-url")
+	}
+	s.Update(config.SharedStateConfig{})
+	if s.Enabled() {
+		t.Fatal("Enabled() = true after clearing redis-url")
+	}
+}
+
+func TestHashKey_StableAndOpaque(t *testing.T) {
+	if HashKey("sk-secret") != HashKey("sk-secret") {
+		t.Fatal("HashKey is not stable")
+	}
+	if got := HashKey("sk-secret"); got == HashKey("sk-other") || len(got) != 32 {
+		t.Fatalf("HashKey = %q", got)
+	}
+}
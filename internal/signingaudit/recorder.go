@@ -0,0 +1,235 @@
+// Package signingaudit records which auth material was attached to each upstream request, so
+// "wrong account was billed" incidents can be traced back to the credential that signed the
+// call. Only credential labels, header and query parameter names and short fingerprints are
+// kept; secret values never leave the request.
+package signingaudit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+)
+
+// fingerprintHexLen is the number of SHA-256 hex digits kept in a fingerprint: enough to tell
+// credentials apart, too few to help guess them.
+const fingerprintHexLen = 12
+
+// Attempt describes one outbound upstream request.
+type Attempt struct {
+	URL       string
+	Method    string
+	Headers   http.Header
+	Provider  string
+	AuthID    string
+	AuthLabel string
+	AuthType  string
+	AuthValue string
+}
+
+// Material names one piece of auth material attached to a request.
+type Material struct {
+	Name string `json:"name"`
+	// Scheme is the authorization scheme, e.g. "Bearer", when the value carries one.
+	Scheme      string `json:"scheme,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Entry is the audit record of one upstream request.
+type Entry struct {
+	RequestID string    `json:"request_id"`
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	// URL omits the query string and any user info.
+	URL       string `json:"url,omitempty"`
+	AuthID    string `json:"auth_id,omitempty"`
+	AuthLabel string `json:"auth_label,omitempty"`
+	AuthType  string `json:"auth_type,omitempty"`
+	// CredentialFingerprint identifies the credential value the executor selected.
+	CredentialFingerprint string     `json:"credential_fingerprint,omitempty"`
+	Headers               []Material `json:"headers,omitempty"`
+	QueryParams           []Material `json:"query_params,omitempty"`
+}
+
+// Recorder keeps the audit entries of the most recent client requests.
+type Recorder struct {
+	mu      sync.Mutex
+	entries map[string][]Entry
+	order   []string
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{entries: make(map[string][]Entry)}
+}
+
+var defaultRecorder = NewRecorder()
+
+// Default returns the process-wide Recorder fed by the provider executors.
+func Default() *Recorder { return defaultRecorder }
+
+// Record adds the audit entry of attempt under requestID, evicting the oldest client requests
+// beyond maxRequests. Attempts without a request ID are ignored.
+func (r *Recorder) Record(maxRequests int, requestID string, attempt Attempt) {
+	if r == nil || requestID == "" {
+		return
+	}
+	entry := newEntry(requestID, attempt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[requestID]; !ok {
+		r.order = append(r.order, requestID)
+	}
+	r.entries[requestID] = append(r.entries[requestID], entry)
+	for maxRequests > 0 && len(r.order) > maxRequests {
+		delete(r.entries, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// Lookup returns the entries recorded for requestID in attempt order.
+func (r *Recorder) Lookup(requestID string) []Entry {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.entries[requestID]
+	if len(entries) == 0 {
+		return nil
+	}
+	return append([]Entry(nil), entries...)
+}
+
+// Recent returns the entries of the latest limit client requests, newest request first.
+// A limit of zero or less returns all of them.
+func (r *Recorder) Recent(limit int) []Entry {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Entry, 0)
+	for i := len(r.order) - 1; i >= 0; i-- {
+		if limit > 0 && len(r.order)-i > limit {
+			break
+		}
+		out = append(out, r.entries[r.order[i]]...)
+	}
+	return out
+}
+
+// Reset drops all entries.
+func (r *Recorder) Reset() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = make(map[string][]Entry)
+	r.order = nil
+}
+
+func newEntry(requestID string, attempt Attempt) Entry {
+	entry := Entry{
+		RequestID:             requestID,
+		Time:                  clock.Default().Now().UTC(),
+		Provider:              attempt.Provider,
+		Method:                attempt.Method,
+		AuthID:                attempt.AuthID,
+		AuthLabel:             attempt.AuthLabel,
+		AuthType:              attempt.AuthType,
+		CredentialFingerprint: Fingerprint(attempt.AuthValue),
+	}
+	if parsed, errParse := url.Parse(attempt.URL); errParse == nil {
+		entry.URL = (&url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: parsed.Path}).String()
+		for name, values := range parsed.Query() {
+			if !isSensitiveQueryParam(name) || len(values) == 0 {
+				continue
+			}
+			entry.QueryParams = append(entry.QueryParams, Material{Name: name, Fingerprint: Fingerprint(values[0])})
+		}
+		sortMaterials(entry.QueryParams)
+	}
+	for name, values := range attempt.Headers {
+		if !isSensitiveHeader(name) || len(values) == 0 {
+			continue
+		}
+		material := Material{Name: http.CanonicalHeaderKey(name)}
+		material.Scheme, _ = splitScheme(values[0])
+		material.Fingerprint = Fingerprint(values[0])
+		entry.Headers = append(entry.Headers, material)
+	}
+	sortMaterials(entry.Headers)
+	return entry
+}
+
+// Fingerprint returns a short, non-reversible identifier of a secret value, ignoring any
+// authorization scheme prefix so a bare token and its "Bearer" header match. Empty values
+// have no fingerprint.
+func Fingerprint(value string) string {
+	_, secret := splitScheme(value)
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:])[:fingerprintHexLen]
+}
+
+func splitScheme(value string) (scheme, secret string) {
+	value = strings.TrimSpace(value)
+	if prefix, rest, found := strings.Cut(value, " "); found {
+		switch strings.ToLower(prefix) {
+		case "bearer", "basic", "token":
+			return prefix, strings.TrimSpace(rest)
+		}
+	}
+	return "", value
+}
+
+var sensitiveHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-api-key":           {},
+	"api-key":             {},
+	"x-goog-api-key":      {},
+	"cookie":              {},
+	"chatgpt-account-id":  {},
+	"x-goog-user-project": {},
+	"openai-organization": {},
+	"openai-project":      {},
+}
+
+func isSensitiveHeader(name string) bool {
+	lower := strings.ToLower(name)
+	if _, ok := sensitiveHeaders[lower]; ok {
+		return true
+	}
+	for _, marker := range []string{"token", "secret", "apikey", "api-key", "session", "auth"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveQueryParam(name string) bool {
+	switch strings.ToLower(name) {
+	case "key", "api_key", "apikey", "access_token", "token":
+		return true
+	default:
+		return false
+	}
+}
+
+func sortMaterials(materials []Material) {
+	sort.Slice(materials, func(i, j int) bool { return materials[i].Name < materials[j].Name })
+}
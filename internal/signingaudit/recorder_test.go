@@ -0,0 +1,89 @@
+package signingaudit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRecordKeepsNamesAndFingerprintsOnly(t *testing.T) {
+	recorder := NewRecorder()
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer sk-secret-token")
+	headers.Set("X-Goog-Api-Key", "goog-secret")
+	headers.Set("Content-Type", "application/json")
+	recorder.Record(10, "req-1", Attempt{
+		URL:       "https://api.example.com/v1/chat?key=query-secret&alt=sse",
+		Method:    http.MethodPost,
+		Headers:   headers,
+		Provider:  "claude",
+		AuthID:    "auth-1",
+		AuthLabel: "team@example.com",
+		AuthType:  "oauth",
+		AuthValue: "sk-secret-token",
+	})
+
+	entries := recorder.Lookup("req-1")
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	raw, errMarshal := json.Marshal(entries)
+	if errMarshal != nil {
+		t.Fatalf("marshal: %v", errMarshal)
+	}
+	for _, secret := range []string{"sk-secret-token", "goog-secret", "query-secret"} {
+		if strings.Contains(string(raw), secret) {
+			t.Fatalf("entry leaks %q: %s", secret, raw)
+		}
+	}
+
+	entry := entries[0]
+	if entry.URL != "https://api.example.com/v1/chat" {
+		t.Fatalf("url = %q", entry.URL)
+	}
+	if len(entry.Headers) != 2 || entry.Headers[0].Name != "Authorization" || entry.Headers[1].Name != "X-Goog-Api-Key" {
+		t.Fatalf("headers = %+v", entry.Headers)
+	}
+	if entry.Headers[0].Scheme != "Bearer" {
+		t.Fatalf("scheme = %q, want Bearer", entry.Headers[0].Scheme)
+	}
+	if entry.Headers[0].Fingerprint != entry.CredentialFingerprint || entry.CredentialFingerprint == "" {
+		t.Fatalf("header fingerprint %q does not match credential %q", entry.Headers[0].Fingerprint, entry.CredentialFingerprint)
+	}
+	if len(entry.QueryParams) != 1 || entry.QueryParams[0].Name != "key" || entry.QueryParams[0].Fingerprint != Fingerprint("query-secret") {
+		t.Fatalf("query params = %+v", entry.QueryParams)
+	}
+}
+
+func TestRecordEvictsOldestRequests(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.Record(2, "req-1", Attempt{AuthID: "a"})
+	recorder.Record(2, "req-1", Attempt{AuthID: "b"})
+	recorder.Record(2, "req-2", Attempt{AuthID: "c"})
+	recorder.Record(2, "req-3", Attempt{AuthID: "d"})
+	recorder.Record(2, "", Attempt{AuthID: "ignored"})
+
+	if got := recorder.Lookup("req-1"); got != nil {
+		t.Fatalf("req-1 should be evicted, got %+v", got)
+	}
+	recent := recorder.Recent(0)
+	if len(recent) != 2 || recent[0].RequestID != "req-3" || recent[1].RequestID != "req-2" {
+		t.Fatalf("recent = %+v", recent)
+	}
+	if got := recorder.Recent(1); len(got) != 1 || got[0].AuthID != "d" {
+		t.Fatalf("recent(1) = %+v", got)
+	}
+}
+
+func TestFingerprintIgnoresScheme(t *testing.T) {
+	if Fingerprint("Bearer abc") != Fingerprint("abc") {
+		t.Fatal("bearer and bare token fingerprints differ")
+	}
+	if Fingerprint("") != "" {
+		t.Fatal("empty value should have no fingerprint")
+	}
+	if got := Fingerprint("abc"); !strings.HasPrefix(got, "sha256:") || len(got) != len("sha256:")+fingerprintHexLen {
+		t.Fatalf("fingerprint = %q", got)
+	}
+}
@@ -0,0 +1,38 @@
+// Package sqlitedb opens the SQLite databases behind the optional local stores. The driver is
+// pure Go, so the stores work in builds without cgo as well.
+package sqlitedb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the database at path, creating it and its directory when missing. The database uses
+// write-ahead logging and waits for locks held by other processes sharing the file. The pool
+// holds a single connection, so the statements and transactions of one process run in turn.
+func Open(path string) (*sql.DB, error) {
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return nil, fmt.Errorf("sqlite: create directory: %w", errMkdir)
+	}
+	// Create the file first so only the owner can read it.
+	file, errCreate := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if errCreate != nil {
+		return nil, fmt.Errorf("sqlite: create %s: %w", path, errCreate)
+	}
+	_ = file.Close()
+
+	db, errOpen := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
+	if errOpen != nil {
+		return nil, fmt.Errorf("sqlite: open %s: %w", path, errOpen)
+	}
+	db.SetMaxOpenConns(1)
+	if errPing := db.Ping(); errPing != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite: open %s: %w", path, errPing)
+	}
+	return db, nil
+}
@@ -0,0 +1,50 @@
+package sqlitedb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenCreatesPrivateDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "store.db")
+	db, errOpen := Open(path)
+	if errOpen != nil {
+		t.Fatalf("Open: %v", errOpen)
+	}
+	defer func() { _ = db.Close() }()
+
+	if _, errExec := db.Exec("CREATE TABLE items (id TEXT PRIMARY KEY)"); errExec != nil {
+		t.Fatalf("create table: %v", errExec)
+	}
+	info, errStat := os.Stat(path)
+	if errStat != nil {
+		t.Fatalf("stat: %v", errStat)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("database mode = %o, want 600", perm)
+	}
+}
+
+func TestOpenAppliesPragmas(t *testing.T) {
+	db, errOpen := Open(filepath.Join(t.TempDir(), "store.db"))
+	if errOpen != nil {
+		t.Fatalf("Open: %v", errOpen)
+	}
+	defer func() { _ = db.Close() }()
+
+	var mode string
+	if errQuery := db.QueryRow("PRAGMA journal_mode").Scan(&mode); errQuery != nil {
+		t.Fatalf("journal_mode: %v", errQuery)
+	}
+	if mode != "wal" {
+		t.Fatalf("journal_mode = %q, want wal", mode)
+	}
+	var timeout int
+	if errQuery := db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); errQuery != nil {
+		t.Fatalf("busy_timeout: %v", errQuery)
+	}
+	if timeout != 5000 {
+		t.Fatalf("busy_timeout = %d, want 5000", timeout)
+	}
+}
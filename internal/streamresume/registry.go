@@ -0,0 +1,242 @@
+// Package streamresume buffers the recent events of SSE streams so a client that lost its
+// connection can reconnect with Last-Event-ID and continue where it left off.
+package streamresume
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// Event is one buffered SSE event.
+type Event struct {
+	// Seq numbers the events of a stream from 1.
+	Seq uint64
+	// Data is the event as sent, without its id line and including the blank line ending it.
+	Data []byte
+}
+
+// Registry tracks resumable streams. A nil or disabled Registry tracks nothing.
+type Registry struct {
+	mu        sync.Mutex
+	enabled   bool
+	window    time.Duration
+	maxEvents int
+	streams   map[string]*Stream
+
+	clock clock.Clock
+}
+
+// New creates a registry using the provided configuration.
+func New(cfg config.StreamResumeConfig) *Registry {
+	r := &Registry{streams: make(map[string]*Stream), clock: clock.Default()}
+	r.Update(cfg)
+	return r
+}
+
+// Update applies cfg. Buffered streams are dropped when resuming is disabled.
+func (r *Registry) Update(cfg config.StreamResumeConfig) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = cfg.Enable
+	r.window = cfg.WindowDuration()
+	r.maxEvents = cfg.BufferEvents
+	if r.maxEvents <= 0 {
+		r.maxEvents = config.DefaultStreamResumeBufferEvents
+	}
+	if !r.enabled {
+		r.streams = make(map[string]*Stream)
+	}
+}
+
+// Enabled reports whether streams are made resumable.
+func (r *Registry) Enabled() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// Start registers a new stream owned by owner with one attached client. cancel stops the
+// stream's upstream request once it has run for the resume window without a client.
+func (r *Registry) Start(owner string, cancel func()) *Stream {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enabled {
+		return nil
+	}
+	r.sweepLocked()
+	stream := &Stream{
+		ID:        uuid.NewString(),
+		owner:     owner,
+		window:    r.window,
+		maxEvents: r.maxEvents,
+		cancel:    cancel,
+		attached:  1,
+		changed:   make(chan struct{}),
+		clock:     r.clock,
+	}
+	r.streams[stream.ID] = stream
+	return stream
+}
+
+// Lookup returns the stream id when it is owned by owner and still resumable.
+func (r *Registry) Lookup(owner, id string) (*Stream, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked()
+	stream, ok := r.streams[id]
+	if !ok || stream.owner != owner {
+		return nil, false
+	}
+	return stream, true
+}
+
+// sweepLocked drops streams that finished more than a window ago.
+func (r *Registry) sweepLocked() {
+	now := r.clock.Now()
+	for id, stream := range r.streams {
+		if stream.expired(now) {
+			delete(r.streams, id)
+		}
+	}
+}
+
+// Stream holds the recent events of one SSE response.
+type Stream struct {
+	// ID identifies the stream in event IDs and the X-CPA-Stream-ID header.
+	ID string
+
+	owner     string
+	window    time.Duration
+	maxEvents int
+	cancel    func()
+	clock     clock.Clock
+
+	mu         sync.Mutex
+	events     []Event
+	nextSeq    uint64
+	done       bool
+	finishedAt time.Time
+	attached   int
+	// abandoned is closed to stop the pending cancellation of a stream without clients.
+	abandoned chan struct{}
+	// changed is closed and replaced whenever an event is added or the stream finishes.
+	changed chan struct{}
+}
+
+// Append buffers an event and returns its sequence number. The oldest events are dropped once
+// the buffer is full.
+func (s *Stream) Append(data []byte) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextSeq++
+	s.events = append(s.events, Event{Seq: s.nextSeq, Data: data})
+	if len(s.events) > s.maxEvents {
+		s.events = append(s.events[:0:0], s.events[len(s.events)-s.maxEvents:]...)
+	}
+	s.notifyLocked()
+	return s.nextSeq
+}
+
+// Finish marks the stream complete. It stays resumable for the window.
+func (s *Stream) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	s.done = true
+	s.finishedAt = s.clock.Now()
+	s.stopAbandonedLocked()
+	s.notifyLocked()
+}
+
+// Since returns the buffered events after seq, a channel closed when more arrive, and whether
+// the stream has finished. ok is false when events after seq are no longer buffered.
+func (s *Stream) Since(seq uint64) (events []Event, changed <-chan struct{}, done, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seq > s.nextSeq {
+		return nil, nil, false, false
+	}
+	if len(s.events) > 0 && seq+1 < s.events[0].Seq {
+		return nil, nil, false, false
+	}
+	for _, event := range s.events {
+		if event.Seq > seq {
+			events = append(events, event)
+		}
+	}
+	return events, s.changed, s.done, true
+}
+
+// Attach records a client reading the stream.
+func (s *Stream) Attach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attached++
+	s.stopAbandonedLocked()
+}
+
+// Detach records a client going away. When no client is left on a running stream, the stream is
+// cancelled after the resume window unless another client attaches.
+func (s *Stream) Detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attached > 0 {
+		s.attached--
+	}
+	if s.attached > 0 || s.done || s.abandoned != nil {
+		return
+	}
+	stop := make(chan struct{})
+	s.abandoned = stop
+	timer := s.clock.NewTimer(s.window)
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-stop:
+		case <-timer.C():
+			s.mu.Lock()
+			cancel := s.abandoned == stop
+			s.abandoned = nil
+			s.mu.Unlock()
+			if cancel && s.cancel != nil {
+				s.cancel()
+			}
+		}
+	}()
+}
+
+func (s *Stream) stopAbandonedLocked() {
+	if s.abandoned != nil {
+		close(s.abandoned)
+		s.abandoned = nil
+	}
+}
+
+func (s *Stream) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Stream) expired(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done && now.Sub(s.finishedAt) > s.window
+}
@@ -0,0 +1,79 @@
+package streamresume
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestStreamSinceReplaysBufferedEvents(t *testing.T) {
+	registry := New(config.StreamResumeConfig{Enable: true, BufferEvents: 2})
+	stream := registry.Start("key", nil)
+	for _, data := range []string{"a", "b", "c"} {
+		stream.Append([]byte(data))
+	}
+
+	events, _, done, ok := stream.Since(1)
+	if !ok || done || len(events) != 2 || events[0].Seq != 2 || string(events[1].Data) != "c" {
+		t.Fatalf("Since(1) = %+v done=%t ok=%t", events, done, ok)
+	}
+	if _, _, _, ok = stream.Since(0); ok {
+		t.Fatal("Since(0) succeeded although event 1 was dropped from the buffer")
+	}
+	if _, _, _, ok = stream.Since(4); ok {
+		t.Fatal("Since(4) succeeded for an event that was never sent")
+	}
+
+	_, changed, _, _ := stream.Since(3)
+	stream.Finish()
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Finish did not notify waiting readers")
+	}
+	if _, _, done, _ = stream.Since(3); !done {
+		t.Fatal("finished stream not reported as done")
+	}
+}
+
+func TestLookupIsScopedToOwner(t *testing.T) {
+	registry := New(config.StreamResumeConfig{Enable: true})
+	stream := registry.Start("alice", nil)
+	if got, ok := registry.Lookup("alice", stream.ID); !ok || got != stream {
+		t.Fatal("owner could not look up its stream")
+	}
+	if _, ok := registry.Lookup("bob", stream.ID); ok {
+		t.Fatal("another owner looked up the stream")
+	}
+
+	registry.Update(config.StreamResumeConfig{})
+	if registry.Start("alice", nil) != nil {
+		t.Fatal("disabled registry started a stream")
+	}
+	if _, ok := registry.Lookup("alice", stream.ID); ok {
+		t.Fatal("streams survived disabling the registry")
+	}
+}
+
+func TestDetachCancelsAbandonedStream(t *testing.T) {
+	registry := New(config.StreamResumeConfig{Enable: true, Window: "20ms"})
+	cancelled := make(chan struct{})
+	stream := registry.Start("key", func() { close(cancelled) })
+
+	stream.Detach()
+	stream.Attach()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-cancelled:
+		t.Fatal("stream cancelled although a client reattached")
+	default:
+	}
+
+	stream.Detach()
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("abandoned stream was not cancelled")
+	}
+}
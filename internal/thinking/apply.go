@@ -281,7 +281,7 @@ func ApplyThinking(body []byte, model string, fromFormat string, toFormat string
 // Parsing priority:
 //  1. Special values: "none" → ModeNone, "auto"/"-1" → ModeAuto
 //  2. Level names: "minimal", "low", "medium", "high", "xhigh" → ModeLevel
-//  3. Numeric values: positive integers → ModeBudget, 0 → ModeNone
+//  3. Budgets: "8192", "8k", ":8k-thinking" → ModeBudget, 0 → ModeNone
 //
 // If none of the above match, returns empty ThinkingConfig (treated as no config).
 func parseSuffixToConfig(rawSuffix, provider, model string) ThinkingConfig {
@@ -295,13 +295,13 @@ func parseSuffixToConfig(rawSuffix, provider, model string) ThinkingConfig {
 		}
 	}
 
-	// 2. Try level parsing (minimal, low, medium, high, xhigh)
+	// 2. Try level parsing (minimal, low, medium, high, xhigh, max)
 	if level, ok := ParseLevelSuffix(rawSuffix); ok {
 		return ThinkingConfig{Mode: ModeLevel, Level: level}
 	}
 
-	// 3. Try numeric parsing
-	if budget, ok := ParseNumericSuffix(rawSuffix); ok {
+	// 3. Try budget parsing (plain integers and k-shorthand)
+	if budget, ok := ParseBudgetSuffix(rawSuffix); ok {
 		if budget == 0 {
 			return ThinkingConfig{Mode: ModeNone, Budget: 0}
 		}
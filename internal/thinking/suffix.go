@@ -5,6 +5,7 @@
 package thinking
 
 import (
+	"math"
 	"strconv"
 	"strings"
 )
@@ -14,6 +15,7 @@ import (
 // The suffix format is: model-name(value)
 // Examples:
 //   - "claude-sonnet-4-5(16384)" -> ModelName="claude-sonnet-4-5", RawSuffix="16384"
+//   - "claude-sonnet-4-5(:8k-thinking)" -> ModelName="claude-sonnet-4-5", RawSuffix=":8k-thinking"
 //   - "gpt-5.2(high)" -> ModelName="gpt-5.2", RawSuffix="high"
 //   - "gemini-2.5-pro" -> ModelName="gemini-2.5-pro", HasSuffix=false
 //
@@ -82,6 +84,37 @@ func ParseNumericSuffix(rawSuffix string) (budget int, ok bool) {
 	return value, true
 }
 
+// ParseBudgetSuffix attempts to parse a raw suffix as an explicit thinking budget.
+//
+// In addition to plain integers (see ParseNumericSuffix), it accepts a "k" multiplier
+// of 1024 tokens, an optional leading ":" and an optional "-thinking" marker, so budgets
+// can be written the way they are commonly named. Matching is case-insensitive.
+//
+// Examples:
+//   - "8192" -> budget=8192, ok=true
+//   - "8k" -> budget=8192, ok=true
+//   - ":8k-thinking" -> budget=8192, ok=true
+//   - "32K-thinking" -> budget=32768, ok=true
+//   - "0-thinking" -> budget=0, ok=true (represents ModeNone)
+//   - "1.5k" -> budget=0, ok=false (fractions are not accepted)
+//   - "high" -> budget=0, ok=false (level, use ParseLevelSuffix)
+func ParseBudgetSuffix(rawSuffix string) (budget int, ok bool) {
+	value := strings.ToLower(strings.TrimSpace(rawSuffix))
+	value = strings.TrimPrefix(value, ":")
+	value = strings.TrimSuffix(value, "-thinking")
+
+	multiplier := 1
+	if strings.HasSuffix(value, "k") {
+		multiplier = 1024
+		value = strings.TrimSuffix(value, "k")
+	}
+	budget, ok = ParseNumericSuffix(value)
+	if !ok || budget > math.MaxInt/multiplier {
+		return 0, false
+	}
+	return budget * multiplier, true
+}
+
 // ParseSpecialSuffix attempts to parse a raw suffix as a special thinking mode value.
 //
 // This function handles special strings that represent a change in thinking mode:
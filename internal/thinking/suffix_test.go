@@ -0,0 +1,41 @@
+package thinking
+
+import "testing"
+
+func TestParseBudgetSuffix(t *testing.T) {
+	cases := []struct {
+		raw    string
+		budget int
+		ok     bool
+	}{
+		{raw: "8192", budget: 8192, ok: true},
+		{raw: "8k", budget: 8192, ok: true},
+		{raw: "8K", budget: 8192, ok: true},
+		{raw: ":8k-thinking", budget: 8192, ok: true},
+		{raw: "32k-THINKING", budget: 32768, ok: true},
+		{raw: "0-thinking", budget: 0, ok: true},
+		{raw: "1.5k", ok: false},
+		{raw: "-1k", ok: false},
+		{raw: "k", ok: false},
+		{raw: "high", ok: false},
+		{raw: "", ok: false},
+	}
+	for _, tc := range cases {
+		budget, ok := ParseBudgetSuffix(tc.raw)
+		if budget != tc.budget || ok != tc.ok {
+			t.Errorf("ParseBudgetSuffix(%q) = %d, %v; want %d, %v", tc.raw, budget, ok, tc.budget, tc.ok)
+		}
+	}
+}
+
+func TestParseSuffixToConfigBudgetShorthand(t *testing.T) {
+	if got := parseSuffixToConfig(":8k-thinking", "claude", "claude-sonnet-4-5"); got.Mode != ModeBudget || got.Budget != 8192 {
+		t.Fatalf("config = %+v, want budget 8192", got)
+	}
+	if got := parseSuffixToConfig("0k", "claude", "claude-sonnet-4-5"); got.Mode != ModeNone {
+		t.Fatalf("config = %+v, want none", got)
+	}
+	if got := parseSuffixToConfig("minimal", "gemini", "gemini-2.5-flash"); got.Mode != ModeLevel || got.Level != LevelMinimal {
+		t.Fatalf("config = %+v, want minimal level", got)
+	}
+}
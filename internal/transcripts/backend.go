@@ -0,0 +1,202 @@
+package transcripts
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sqlitedb"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+func openBackend(cfg config.TranscriptsConfig, authDir string) (backend, error) {
+	switch cfg.Store {
+	case config.TranscriptStoreS3:
+		return newS3Backend(cfg.S3)
+	case config.TranscriptStoreSQLite:
+		return newSQLiteBackend(resolvePath(cfg, authDir))
+	default:
+		return &fileBackend{root: resolveDir(cfg, authDir)}, nil
+	}
+}
+
+// fileBackend keeps transcripts as files under <root>/<conversation>/.
+type fileBackend struct {
+	root string
+}
+
+func (b *fileBackend) put(_ context.Context, dir, name string, data []byte) error {
+	return util.WriteFileAtomic(filepath.Join(b.root, dir, name), data)
+}
+
+func (b *fileBackend) list(_ context.Context, dir string) ([]string, error) {
+	entries, errRead := os.ReadDir(filepath.Join(b.root, dir))
+	if errors.Is(errRead, os.ErrNotExist) {
+		return nil, nil
+	}
+	if errRead != nil {
+		return nil, errRead
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (b *fileBackend) get(_ context.Context, dir, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(b.root, dir, name))
+}
+
+func (b *fileBackend) remove(_ context.Context, dir, name string) error {
+	if errRemove := os.Remove(filepath.Join(b.root, dir, name)); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+		return errRemove
+	}
+	// Drop the conversation directory once it is empty; failures leave it for the next call.
+	_ = os.Remove(filepath.Join(b.root, dir))
+	return nil
+}
+
+// sqliteBackend keeps transcripts as rows of a local SQLite database, one per conversation
+// directory and object name.
+type sqliteBackend struct {
+	db *sql.DB
+}
+
+func newSQLiteBackend(path string) (*sqliteBackend, error) {
+	db, errOpen := sqlitedb.Open(path)
+	if errOpen != nil {
+		return nil, errOpen
+	}
+	if _, errCreate := db.Exec(`
+		CREATE TABLE IF NOT EXISTS transcripts (
+			dir TEXT NOT NULL,
+			name TEXT NOT NULL,
+			data BLOB NOT NULL,
+			PRIMARY KEY (dir, name)
+		)
+	`); errCreate != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create table: %w", errCreate)
+	}
+	return &sqliteBackend{db: db}, nil
+}
+
+func (b *sqliteBackend) put(ctx context.Context, dir, name string, data []byte) error {
+	_, errExec := b.db.ExecContext(ctx, `
+		INSERT INTO transcripts (dir, name, data) VALUES (?, ?, ?)
+		ON CONFLICT (dir, name) DO UPDATE SET data = excluded.data
+	`, dir, name, data)
+	return errExec
+}
+
+func (b *sqliteBackend) list(ctx context.Context, dir string) ([]string, error) {
+	rows, errQuery := b.db.QueryContext(ctx, "SELECT name FROM transcripts WHERE dir = ? ORDER BY name", dir)
+	if errQuery != nil {
+		return nil, errQuery
+	}
+	defer func() { _ = rows.Close() }()
+	var names []string
+	for rows.Next() {
+		var name string
+		if errScan := rows.Scan(&name); errScan != nil {
+			return nil, errScan
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (b *sqliteBackend) get(ctx context.Context, dir, name string) ([]byte, error) {
+	var data []byte
+	if errQuery := b.db.QueryRowContext(ctx, "SELECT data FROM transcripts WHERE dir = ? AND name = ?", dir, name).Scan(&data); errQuery != nil {
+		return nil, errQuery
+	}
+	return data, nil
+}
+
+func (b *sqliteBackend) remove(ctx context.Context, dir, name string) error {
+	_, errExec := b.db.ExecContext(ctx, "DELETE FROM transcripts WHERE dir = ? AND name = ?", dir, name)
+	return errExec
+}
+
+func (b *sqliteBackend) close() error {
+	return b.db.Close()
+}
+
+// s3Backend keeps transcripts as objects under <prefix>/<conversation>/ in an S3-compatible bucket.
+type s3Backend struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func newS3Backend(cfg config.TranscriptS3Config) (*s3Backend, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 store requires endpoint and bucket")
+	}
+	options := &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	}
+	if cfg.PathStyle {
+		options.BucketLookup = minio.BucketLookupPath
+	}
+	client, errClient := minio.New(cfg.Endpoint, options)
+	if errClient != nil {
+		return nil, fmt.Errorf("create client: %w", errClient)
+	}
+	return &s3Backend{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix}, nil
+}
+
+func (b *s3Backend) key(dir, name string) string {
+	return path.Join(b.prefix, dir, name)
+}
+
+func (b *s3Backend) put(ctx context.Context, dir, name string, data []byte) error {
+	_, errPut := b.client.PutObject(ctx, b.bucket, b.key(dir, name), bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{ContentType: "application/json"})
+	return errPut
+}
+
+func (b *s3Backend) list(ctx context.Context, dir string) ([]string, error) {
+	prefix := b.key(dir, "") + "/"
+	var names []string
+	for object := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		if name := strings.TrimPrefix(object.Key, prefix); strings.HasSuffix(name, ".json") && !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (b *s3Backend) get(ctx context.Context, dir, name string) ([]byte, error) {
+	object, errGet := b.client.GetObject(ctx, b.bucket, b.key(dir, name), minio.GetObjectOptions{})
+	if errGet != nil {
+		return nil, errGet
+	}
+	defer func() { _ = object.Close() }()
+	return io.ReadAll(object)
+}
+
+func (b *s3Backend) remove(ctx context.Context, dir, name string) error {
+	return b.client.RemoveObject(ctx, b.bucket, b.key(dir, name), minio.RemoveObjectOptions{})
+}
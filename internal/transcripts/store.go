@@ -0,0 +1,371 @@
+// Package transcripts persists the output of streamed completions keyed by client API key and
+// conversation, so a client that lost part of a stream can fetch what the proxy delivered.
+package transcripts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrDisabled is returned by List and Delete when transcripts are not enabled.
+var ErrDisabled = errors.New("transcripts: disabled")
+
+// saveTimeout bounds writing one transcript and pruning old ones.
+const saveTimeout = 30 * time.Second
+
+// Transcript is the output of one streamed completion as delivered to the client.
+type Transcript struct {
+	ID           string `json:"id"`
+	Conversation string `json:"conversation"`
+	Model        string `json:"model"`
+	// Protocol is the response format of Output, such as openai or claude.
+	Protocol   string    `json:"protocol"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Complete is false when the stream failed or its client went away before the end.
+	Complete  bool   `json:"complete"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Output holds the raw stream chunks, for example SSE events, in the order they were sent.
+	Output string `json:"output"`
+}
+
+// backend stores transcript objects grouped in one directory per owner and conversation.
+type backend interface {
+	put(ctx context.Context, dir, name string, data []byte) error
+	// list returns the object names of dir in ascending order.
+	list(ctx context.Context, dir string) ([]string, error)
+	get(ctx context.Context, dir, name string) ([]byte, error)
+	remove(ctx context.Context, dir, name string) error
+}
+
+// Store records transcripts into the backend selected by the transcripts configuration. A nil
+// or disabled Store records nothing.
+type Store struct {
+	mu        sync.RWMutex
+	cfg       config.TranscriptsConfig
+	backend   backend
+	backendID string
+	keys      *contentcrypt.Keyring
+
+	clock clock.Clock
+}
+
+// NewStore creates a disabled Store. Apply enables it.
+func NewStore() *Store {
+	return &Store{clock: clock.Default()}
+}
+
+var defaultStore = NewStore()
+
+// Default returns the process-wide Store fed by the API handlers.
+func Default() *Store { return defaultStore }
+
+// SetKeyring sets the keyring sealing stored transcripts, one data key per transcript owned by
+// the client API key that streamed it. Deleting or pruning a transcript shreds its key.
+func (s *Store) SetKeyring(keys *contentcrypt.Keyring) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+// Apply switches the store to cfg, opening a new backend when its location changed.
+func (s *Store) Apply(cfg config.TranscriptsConfig, authDir string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	if !cfg.Enable {
+		s.closeBackendLocked()
+		return
+	}
+	backendID := backendIdentity(cfg, authDir)
+	if s.backend != nil && s.backendID == backendID {
+		return
+	}
+	s.closeBackendLocked()
+	opened, errOpen := openBackend(cfg, authDir)
+	if errOpen != nil {
+		log.Errorf("transcripts: failed to open %s store: %v", cfg.Store, errOpen)
+		return
+	}
+	s.backend, s.backendID = opened, backendID
+	log.Infof("transcripts enabled (store=%s)", cfg.Store)
+}
+
+// closeBackendLocked drops the backend, closing the ones holding a database.
+func (s *Store) closeBackendLocked() {
+	if closer, ok := s.backend.(interface{ close() error }); ok {
+		if errClose := closer.close(); errClose != nil {
+			log.Debugf("transcripts: failed to close store: %v", errClose)
+		}
+	}
+	s.backend, s.backendID = nil, ""
+}
+
+// Enabled reports whether new transcripts are recorded.
+func (s *Store) Enabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend != nil
+}
+
+func (s *Store) current() (backend, config.TranscriptsConfig, *contentcrypt.Keyring) {
+	if s == nil {
+		return nil, config.TranscriptsConfig{}, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend, s.cfg, s.keys
+}
+
+// Begin starts recording a stream of conversation sent with the client API key owner. It returns
+// nil when transcripts are disabled or conversation is empty.
+func (s *Store) Begin(owner, conversation, model, protocol string) *Recorder {
+	conversation = strings.TrimSpace(conversation)
+	if conversation == "" || !s.Enabled() {
+		return nil
+	}
+	return &Recorder{
+		store: s,
+		owner: strings.TrimSpace(owner),
+		transcript: Transcript{
+			ID:           uuid.NewString(),
+			Conversation: conversation,
+			Model:        model,
+			Protocol:     protocol,
+			StartedAt:    s.clock.Now().UTC(),
+		},
+	}
+}
+
+// List returns the stored transcripts of conversation of the client API key owner, oldest first.
+func (s *Store) List(ctx context.Context, owner, conversation string) ([]Transcript, error) {
+	store, _, keys := s.current()
+	if store == nil {
+		return nil, ErrDisabled
+	}
+	owner = strings.TrimSpace(owner)
+	dir := conversationDir(owner, conversation)
+	names, errList := store.list(ctx, dir)
+	if errList != nil {
+		return nil, errList
+	}
+	transcripts := make([]Transcript, 0, len(names))
+	for _, name := range names {
+		data, errGet := store.get(ctx, dir, name)
+		if errGet != nil {
+			return nil, errGet
+		}
+		data, errOpen := keys.Open(owner, keyID(transcriptID(name)), data)
+		if errors.Is(errOpen, contentcrypt.ErrShredded) {
+			continue
+		}
+		if errOpen != nil {
+			return nil, errOpen
+		}
+		var transcript Transcript
+		if errUnmarshal := json.Unmarshal(data, &transcript); errUnmarshal != nil {
+			log.Warnf("transcripts: skipping unreadable transcript %s/%s: %v", dir, name, errUnmarshal)
+			continue
+		}
+		transcripts = append(transcripts, transcript)
+	}
+	return transcripts, nil
+}
+
+// Delete removes every stored transcript of conversation of the client API key owner and returns
+// how many were removed.
+func (s *Store) Delete(ctx context.Context, owner, conversation string) (int, error) {
+	store, _, keys := s.current()
+	if store == nil {
+		return 0, ErrDisabled
+	}
+	owner = strings.TrimSpace(owner)
+	dir := conversationDir(owner, conversation)
+	names, errList := store.list(ctx, dir)
+	if errList != nil {
+		return 0, errList
+	}
+	for i, name := range names {
+		if errRemove := removeTranscript(ctx, store, keys, owner, dir, name); errRemove != nil {
+			return i, errRemove
+		}
+	}
+	return len(names), nil
+}
+
+// save seals and writes the transcript of owner and deletes the oldest transcripts of its
+// conversation beyond the configured limit.
+func (s *Store) save(owner string, transcript Transcript) error {
+	store, cfg, keys := s.current()
+	if store == nil {
+		return nil
+	}
+	data, errMarshal := json.Marshal(transcript)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	data, errSeal := keys.Seal(owner, keyID(transcript.ID), data)
+	if errSeal != nil {
+		return errSeal
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+	dir := conversationDir(owner, transcript.Conversation)
+	name := fmt.Sprintf("%020d-%s.json", transcript.StartedAt.UnixNano(), transcript.ID)
+	if errPut := store.put(ctx, dir, name, data); errPut != nil {
+		return errPut
+	}
+	if cfg.MaxPerConversation <= 0 {
+		return nil
+	}
+	names, errList := store.list(ctx, dir)
+	if errList != nil {
+		return errList
+	}
+	for len(names) > cfg.MaxPerConversation {
+		if errRemove := removeTranscript(ctx, store, keys, owner, dir, names[0]); errRemove != nil {
+			return errRemove
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// Recorder collects the chunks of one stream until Finish stores them.
+type Recorder struct {
+	store      *Store
+	owner      string
+	mu         sync.Mutex
+	output     bytes.Buffer
+	transcript Transcript
+	finished   bool
+}
+
+// Write appends a chunk sent to the client. Output beyond the configured size is dropped.
+func (r *Recorder) Write(chunk []byte) {
+	if r == nil || len(chunk) == 0 {
+		return
+	}
+	_, cfg, _ := r.store.current()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished || r.transcript.Truncated {
+		return
+	}
+	if cfg.MaxBytes > 0 && r.output.Len()+len(chunk) > cfg.MaxBytes {
+		r.output.Write(chunk[:max(cfg.MaxBytes-r.output.Len(), 0)])
+		r.transcript.Truncated = true
+		return
+	}
+	r.output.Write(chunk)
+}
+
+// Finish stores the transcript. complete tells whether the stream reached its end; errText
+// describes the failure of a stream that did not. Later calls do nothing.
+func (r *Recorder) Finish(complete bool, errText string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+	r.finished = true
+	transcript := r.transcript
+	transcript.FinishedAt = r.store.clock.Now().UTC()
+	transcript.Complete = complete
+	transcript.Error = errText
+	transcript.Output = r.output.String()
+	r.mu.Unlock()
+
+	if errSave := r.store.save(r.owner, transcript); errSave != nil {
+		log.Warnf("transcripts: failed to store transcript of conversation %s: %v", transcript.Conversation, errSave)
+	}
+}
+
+// removeTranscript deletes a stored transcript and shreds its data key.
+func removeTranscript(ctx context.Context, store backend, keys *contentcrypt.Keyring, owner, dir, name string) error {
+	if errRemove := store.remove(ctx, dir, name); errRemove != nil {
+		return errRemove
+	}
+	return keys.Shred(owner, keyID(transcriptID(name)))
+}
+
+// conversationDir maps a conversation of a client API key to a directory name safe for every
+// backend. Clients sending the same session ID never see each other's transcripts.
+func conversationDir(owner, conversation string) string {
+	sum := sha256.Sum256([]byte(owner + "\x00" + strings.TrimSpace(conversation)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func keyID(id string) string {
+	return "transcripts/" + id
+}
+
+// transcriptID returns the transcript ID of an object name "<started-ns>-<id>.json".
+func transcriptID(name string) string {
+	name = strings.TrimSuffix(name, ".json")
+	if _, id, ok := strings.Cut(name, "-"); ok {
+		return id
+	}
+	return name
+}
+
+func backendIdentity(cfg config.TranscriptsConfig, authDir string) string {
+	if cfg.Store == config.TranscriptStoreS3 {
+		return strings.Join([]string{cfg.Store, cfg.S3.Endpoint, cfg.S3.Bucket, cfg.S3.Prefix, cfg.S3.AccessKey, cfg.S3.Region}, "|")
+	}
+	if cfg.Store == config.TranscriptStoreSQLite {
+		return cfg.Store + "|" + resolvePath(cfg, authDir)
+	}
+	return cfg.Store + "|" + resolveDir(cfg, authDir)
+}
+
+func resolveDir(cfg config.TranscriptsConfig, authDir string) string {
+	if cfg.Dir != "" {
+		return cfg.Dir
+	}
+	return filepath.Join(resolveAuthDir(authDir), config.DefaultTranscriptDir)
+}
+
+func resolvePath(cfg config.TranscriptsConfig, authDir string) string {
+	if cfg.Path != "" {
+		return cfg.Path
+	}
+	return filepath.Join(resolveAuthDir(authDir), config.DefaultTranscriptDatabase)
+}
+
+func resolveAuthDir(authDir string) string {
+	base, errResolve := util.ResolveAuthDir(authDir)
+	if errResolve != nil || base == "" {
+		return "."
+	}
+	return base
+}
@@ -0,0 +1,124 @@
+package transcripts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/contentcrypt"
+)
+
+func TestStoreRecordsListsAndPrunesConversation(t *testing.T) {
+	for _, cfg := range []config.TranscriptsConfig{
+		{Store: config.TranscriptStoreFile, Dir: t.TempDir()},
+		{Store: config.TranscriptStoreSQLite, Path: filepath.Join(t.TempDir(), "transcripts.db")},
+	} {
+		t.Run(cfg.Store, func(t *testing.T) {
+			cfg.Enable, cfg.MaxPerConversation, cfg.MaxBytes = true, 2, 8
+			store := NewStore()
+			store.Apply(cfg, "")
+			defer store.Apply(config.TranscriptsConfig{}, "")
+
+			for _, chunk := range []string{"first", "second", "third"} {
+				recorder := store.Begin("key-a", "conv-1", "gpt-5", "openai")
+				recorder.Write([]byte(chunk))
+				recorder.Finish(chunk != "third", "")
+			}
+			truncated := store.Begin("key-a", "conv-2", "gpt-5", "openai")
+			truncated.Write([]byte("data: 12"))
+			truncated.Write([]byte("345"))
+			truncated.Finish(false, "client disconnected")
+
+			transcripts, errList := store.List(context.Background(), "key-a", "conv-1")
+			if errList != nil {
+				t.Fatalf("List: %v", errList)
+			}
+			if len(transcripts) != 2 || transcripts[0].Output != "second" || transcripts[1].Output != "third" {
+				t.Fatalf("transcripts = %+v, want the two newest", transcripts)
+			}
+			if !transcripts[0].Complete || transcripts[1].Complete || transcripts[1].Conversation != "conv-1" || transcripts[1].Model != "gpt-5" {
+				t.Fatalf("transcripts = %+v, want the last one incomplete", transcripts)
+			}
+
+			other, errList := store.List(context.Background(), "key-a", "conv-2")
+			if errList != nil || len(other) != 1 {
+				t.Fatalf("List(conv-2) = %+v, %v", other, errList)
+			}
+			if got := other[0]; got.Output != "data: 12" || !got.Truncated || got.Error != "client disconnected" {
+				t.Fatalf("truncated transcript = %+v", got)
+			}
+
+			removed, errDelete := store.Delete(context.Background(), "key-a", "conv-1")
+			if errDelete != nil || removed != 2 {
+				t.Fatalf("Delete = %d, %v; want 2", removed, errDelete)
+			}
+			if transcripts, _ = store.List(context.Background(), "key-a", "conv-1"); len(transcripts) != 0 {
+				t.Fatalf("transcripts after delete = %+v", transcripts)
+			}
+		})
+	}
+}
+
+func TestStoreDisabled(t *testing.T) {
+	store := NewStore()
+	if recorder := store.Begin("key-a", "conv", "gpt-5", "openai"); recorder != nil {
+		t.Fatal("Begin returned a recorder while disabled")
+	}
+	var recorder *Recorder
+	recorder.Write([]byte("ignored"))
+	recorder.Finish(true, "")
+
+	store.Apply(config.TranscriptsConfig{Enable: true, Store: config.TranscriptStoreFile, Dir: t.TempDir()}, "")
+	if recorder = store.Begin("key-a", " ", "gpt-5", "openai"); recorder != nil {
+		t.Fatal("Begin returned a recorder without a conversation")
+	}
+	store.Apply(config.TranscriptsConfig{}, "")
+	if _, errList := store.List(context.Background(), "key-a", "conv"); !errors.Is(errList, ErrDisabled) {
+		t.Fatalf("List error = %v, want ErrDisabled", errList)
+	}
+}
+
+func TestStoreSealsTranscriptsPerClientKey(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TRANSCRIPTS_TEST_KEY", "master")
+	keys := contentcrypt.NewKeyring()
+	keys.Apply(config.ContentEncryptionConfig{Enable: true, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "TRANSCRIPTS_TEST_KEY"}, KeysDir: t.TempDir()}, "")
+	store := NewStore()
+	store.SetKeyring(keys)
+	store.Apply(config.TranscriptsConfig{Enable: true, Store: config.TranscriptStoreFile, Dir: dir}, "")
+
+	recorder := store.Begin("key-a", "shared-session", "gpt-5", "openai")
+	recorder.Write([]byte("top secret"))
+	recorder.Finish(true, "")
+
+	if other, errList := store.List(context.Background(), "key-b", "shared-session"); errList != nil || len(other) != 0 {
+		t.Fatalf("List of another key = %+v, %v; want none", other, errList)
+	}
+	list, errList := store.List(context.Background(), "key-a", "shared-session")
+	if errList != nil || len(list) != 1 || list[0].Output != "top secret" {
+		t.Fatalf("List = %+v, %v; want the owner's transcript", list, errList)
+	}
+	errWalk := filepath.WalkDir(dir, func(path string, entry os.DirEntry, errEntry error) error {
+		if errEntry != nil || entry.IsDir() {
+			return errEntry
+		}
+		if data, _ := os.ReadFile(path); bytes.Contains(data, []byte("top secret")) {
+			t.Fatalf("%s holds plaintext output", path)
+		}
+		return nil
+	})
+	if errWalk != nil {
+		t.Fatalf("walk store: %v", errWalk)
+	}
+
+	if _, errShred := keys.ShredOwner("key-a"); errShred != nil {
+		t.Fatalf("ShredOwner: %v", errShred)
+	}
+	if list, errList = store.List(context.Background(), "key-a", "shared-session"); errList != nil || len(list) != 0 {
+		t.Fatalf("List after shredding = %+v, %v; want none", list, errList)
+	}
+}
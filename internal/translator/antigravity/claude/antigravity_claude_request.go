@@ -565,7 +565,9 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 						}
 					} else if contentTypeResult.Type == gjson.String && contentTypeResult.String() == "image" {
 						sourceResult := contentResult.Get("source")
-						if sourceResult.Get("type").String() == "base64" {
+						if source, ok := translatorcommon.ImageSourceFromClaude(sourceResult); ok && source.URL != "" {
+							partItems = append(partItems, source.GeminiFileDataPart())
+						} else if sourceResult.Get("type").String() == "base64" {
 							inlineDataJSON := []byte(`{}`)
 							if mimeType := sourceResult.Get("media_type").String(); mimeType != "" {
 								inlineDataJSON, _ = sjson.SetBytes(inlineDataJSON, "mimeType", mimeType)
@@ -108,6 +108,9 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// Structured output: response_format -> responseMimeType/responseJsonSchema
+	out = translatorcommon.ApplyOpenAIResponseFormatToGemini(out, gjson.GetBytes(rawJSON, "response_format"), "request.generationConfig")
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -176,11 +179,11 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 								partItems = append(partItems, antigravityOpenAITextPart(text))
 							}
 						case "image_url":
-							imageURL := item.Get("image_url.url").String()
-							if len(imageURL) > 5 {
-								pieces := strings.SplitN(imageURL[5:], ";", 2)
-								if len(pieces) == 2 && len(pieces[1]) > 7 {
-									part := antigravityOpenAIInlineDataPart(pieces[0], pieces[1][7:], false)
+							if source, ok := translatorcommon.ImageSourceFromURL(item.Get("image_url.url").String()); ok {
+								if source.URL != "" {
+									partItems = append(partItems, source.GeminiFileDataPart())
+								} else {
+									part := antigravityOpenAIInlineDataPart(source.MIMEType, source.Data, false)
 									part, _ = sjson.SetBytes(part, "thoughtSignature", antigravityFunctionThoughtSignature)
 									partItems = append(partItems, part)
 								}
@@ -221,11 +224,11 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 								partItems = append(partItems, antigravityOpenAITextPart(text))
 							}
 						case "image_url":
-							imageURL := item.Get("image_url.url").String()
-							if len(imageURL) > 5 {
-								pieces := strings.SplitN(imageURL[5:], ";", 2)
-								if len(pieces) == 2 && len(pieces[1]) > 7 {
-									part := antigravityOpenAIInlineDataPart(pieces[0], pieces[1][7:], false)
+							if source, ok := translatorcommon.ImageSourceFromURL(item.Get("image_url.url").String()); ok {
+								if source.URL != "" {
+									partItems = append(partItems, source.GeminiFileDataPart())
+								} else {
+									part := antigravityOpenAIInlineDataPart(source.MIMEType, source.Data, false)
 									part, _ = sjson.SetBytes(part, "thoughtSignature", antigravityFunctionThoughtSignature)
 									partItems = append(partItems, part)
 								}
@@ -227,7 +227,12 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 	}
 
 	// System instruction conversion to Claude Code format
-	if sysInstr := root.Get("system_instruction"); sysInstr.Exists() {
+	// Gemini may provide `systemInstruction` or `system_instruction`; support both keys.
+	sysInstr := root.Get("systemInstruction")
+	if !sysInstr.Exists() {
+		sysInstr = root.Get("system_instruction")
+	}
+	if sysInstr.Exists() {
 		if parts := sysInstr.Get("parts"); parts.Exists() && parts.IsArray() {
 			var systemText strings.Builder
 			parts.ForEach(func(_, part gjson.Result) bool {
@@ -404,6 +409,11 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 		out = setClaudeToolChoiceFromGeminiToolConfig(out, toolConfig.Get("functionCallingConfig"))
 	}
 
+	// JSON mode and response schemas -> output_config.format
+	if responseFormat := translatorcommon.OpenAIResponseFormatFromGemini(root.Get("generationConfig")); responseFormat != nil {
+		out = translatorcommon.ApplyOpenAIResponseFormatToClaude(out, gjson.ParseBytes(responseFormat))
+	}
+
 	// Stream setting configuration
 	out, _ = sjson.SetBytes(out, "stream", stream)
 
@@ -112,3 +112,24 @@ func TestConvertGeminiRequestToClaude_SplitsNonImageInlineDataByMIME(t *testing.
 		t.Fatalf("non-image inlineData must not be converted to image. Output: %s", string(out))
 	}
 }
+
+func TestConvertGeminiRequestToClaude_SystemInstructionAndResponseSchema(t *testing.T) {
+	raw := []byte(`{
+		"systemInstruction": {"parts": [{"text": "Be terse."}]},
+		"generationConfig": {
+			"responseMimeType": "application/json",
+			"responseSchema": {"type": "OBJECT", "properties": {"answer": {"type": "STRING"}}}
+		},
+		"contents": [{"role": "user", "parts": [{"text": "hi"}]}]
+	}`)
+
+	out := ConvertGeminiRequestToClaude("claude-sonnet-5", raw, false)
+
+	if got := gjson.GetBytes(out, "messages.0.content.0.text").String(); got != "Be terse." {
+		t.Fatalf("system instruction = %q, want it carried over: %s", got, out)
+	}
+	format := gjson.GetBytes(out, "output_config.format")
+	if format.Get("type").String() != "json_schema" || format.Get("schema.properties.answer.type").String() != "string" || format.Get("schema.additionalProperties").Type != gjson.False {
+		t.Fatalf("output_config.format = %s", format.Raw)
+	}
+}
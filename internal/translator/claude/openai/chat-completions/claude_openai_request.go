@@ -225,28 +225,7 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 							}
 							toolCallID = util.SanitizeClaudeToolID(toolCallID)
 
-							function := toolCall.Get("function")
-							toolUse := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-							toolUse, _ = sjson.SetBytes(toolUse, "id", toolCallID)
-							toolUse, _ = sjson.SetBytes(toolUse, "name", function.Get("name").String())
-
-							// Parse arguments for the tool call
-							if args := function.Get("arguments"); args.Exists() {
-								argsStr := args.String()
-								if argsStr != "" && gjson.Valid(argsStr) {
-									argsJSON := gjson.Parse(argsStr)
-									if argsJSON.IsObject() {
-										toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte(argsJSON.Raw))
-									} else {
-										toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-									}
-								} else {
-									toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-								}
-							} else {
-								toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-							}
-
+							toolUse := common.ClaudeToolUseFromOpenAIToolCall(toolCall, toolCallID, toolCall.Get("function.name").String())
 							contentBlocks = append(contentBlocks, toolUse)
 						}
 						return true
@@ -261,24 +240,30 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 			case "tool":
 				// Handle tool result messages conversion
+				// An empty ID is left for PairClaudeToolResults to match with the pending tool use.
 				toolCallID := message.Get("tool_call_id").String()
-				toolCallID = util.SanitizeClaudeToolID(toolCallID)
+				if toolCallID != "" {
+					toolCallID = util.SanitizeClaudeToolID(toolCallID)
+				}
 				toolContentResult := message.Get("content")
 
-				msg := []byte(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"","content":""}]}`)
-				msg, _ = sjson.SetBytes(msg, "content.0.tool_use_id", toolCallID)
+				toolResult := []byte(`{"type":"tool_result","tool_use_id":"","content":""}`)
+				toolResult, _ = sjson.SetBytes(toolResult, "tool_use_id", toolCallID)
 				toolResultContent, toolResultContentRaw := convertOpenAIToolResultContent(toolContentResult)
 				if toolResultContentRaw {
-					msg, _ = sjson.SetRawBytes(msg, "content.0.content", []byte(toolResultContent))
+					toolResult, _ = sjson.SetRawBytes(toolResult, "content", []byte(toolResultContent))
 				} else {
-					msg, _ = sjson.SetBytes(msg, "content.0.content", toolResultContent)
+					toolResult, _ = sjson.SetBytes(toolResult, "content", toolResultContent)
 				}
-				msg = common.AttachMessageCacheControl(msg, message)
-				messageBlocks = append(messageBlocks, msg)
+				toolResult = common.AttachCacheControl(toolResult, message)
+				// Results of parallel tool calls share one user turn.
+				messageBlocks = common.AppendClaudeToolResult(messageBlocks, toolResult)
 			}
 			return true
 		})
 
+		messageBlocks = common.PairClaudeToolResults(messageBlocks)
+
 		// Preserve a minimal conversational turn for system-only inputs.
 		// Claude payloads with top-level system instructions but no messages are risky for downstream validation.
 		if len(messageBlocks) == 0 && len(systemBlocks) > 0 {
@@ -326,31 +311,14 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
-	// Tool choice mapping from OpenAI format to Claude Code format
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Type {
-		case gjson.String:
-			choice := toolChoice.String()
-			switch choice {
-			case "none":
-				// Don't set tool_choice, Claude Code will not use tools
-			case "auto":
-				out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"auto"}`))
-			case "required":
-				out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"type":"any"}`))
-			}
-		case gjson.JSON:
-			// Specific tool choice mapping
-			if toolChoice.Get("type").String() == "function" {
-				functionName := toolChoice.Get("function.name").String()
-				toolChoiceJSON := []byte(`{"type":"tool","name":""}`)
-				toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "name", functionName)
-				out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoiceJSON)
-			}
-		default:
-		}
+	// Tool choice mapping from OpenAI format to Claude Code format, including parallel_tool_calls
+	if toolChoice := common.ClaudeToolChoiceFromOpenAI(root.Get("tool_choice"), root.Get("parallel_tool_calls")); toolChoice != nil {
+		out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoice)
 	}
 
+	// Structured output: response_format -> output_config.format or a JSON-only system instruction
+	out = common.ApplyOpenAIResponseFormatToClaude(out, root.Get("response_format"))
+
 	return out
 }
 
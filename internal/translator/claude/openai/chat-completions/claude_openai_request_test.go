@@ -406,3 +406,27 @@ func TestConvertOpenAIRequestToClaude_PartCacheControlWinsOverMessageLevel(t *te
 		t.Fatalf("part-level cache_control should win; unexpected ttl: %s", result)
 	}
 }
+
+func TestConvertOpenAIRequestToClaude_MapsResponseFormatJSONSchema(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{"role": "user", "content": "hi"}],
+		"response_format": {
+			"type": "json_schema",
+			"json_schema": {
+				"name": "answer",
+				"schema": {"type": "object", "properties": {"answer": {"type": "string"}}, "required": ["answer"]}
+			}
+		}
+	}`
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+	resultJSON := gjson.ParseBytes(result)
+
+	if got := resultJSON.Get("output_config.format.type").String(); got != "json_schema" {
+		t.Fatalf("output_config.format.type = %q, want json_schema. Output: %s", got, result)
+	}
+	if resultJSON.Get("output_config.format.schema.additionalProperties").Type != gjson.False {
+		t.Fatalf("schema additionalProperties = %s, want false", resultJSON.Get("output_config.format.schema.additionalProperties").Raw)
+	}
+}
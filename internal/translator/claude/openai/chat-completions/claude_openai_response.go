@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -28,6 +29,9 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	Usage        claudeUsageTokens
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// ToolCallCount is the number of tool calls streamed so far; it numbers the OpenAI
+	// tool_calls indexes independently of the Claude content block indexes.
+	ToolCallCount int
 }
 
 type claudeUsageTokens struct {
@@ -43,6 +47,8 @@ type ToolCallAccumulator struct {
 	ID        string
 	Name      string
 	Arguments strings.Builder
+	// Index is the OpenAI tool_calls index of a streamed call.
+	Index int
 }
 
 func (u *claudeUsageTokens) Merge(usage gjson.Result) {
@@ -155,13 +161,18 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 					(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
 				}
 
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index] = &ToolCallAccumulator{
-					ID:   toolCallID,
-					Name: toolName,
-				}
+				params := (*param).(*ConvertAnthropicResponseToOpenAIParams)
+				accumulator := &ToolCallAccumulator{ID: toolCallID, Name: toolName, Index: params.ToolCallCount}
+				params.ToolCallsAccumulator[index] = accumulator
+				params.ToolCallCount++
 
-				// Don't output anything yet - wait for complete tool call
-				return [][]byte{}
+				// Announce the call right away; its arguments follow as input_json_delta fragments.
+				template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls.-1", common.OpenAIToolCallStartDelta(accumulator.Index, toolCallID, toolName))
+				if input := contentBlock.Get("input"); input.IsObject() && len(input.Map()) > 0 {
+					accumulator.Arguments.WriteString(input.Raw)
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.arguments", input.Raw)
+				}
+				return [][]byte{template}
 			}
 		}
 		return [][]byte{}
@@ -186,16 +197,15 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 					hasContent = true
 				}
 			case "input_json_delta":
-				// Tool use input delta - accumulate arguments for tool calls
-				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
+				// Tool use input delta - forward the fragment as a tool_calls arguments delta
+				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() && partialJSON.String() != "" {
 					index := int(root.Get("index").Int())
-					if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
-						if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
-							accumulator.Arguments.WriteString(partialJSON.String())
-						}
+					if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
+						accumulator.Arguments.WriteString(partialJSON.String())
+						template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls.-1", common.OpenAIToolCallArgumentsDelta(accumulator.Index, partialJSON.String()))
+						return [][]byte{template}
 					}
 				}
-				// Don't output anything yet - wait for complete tool call
 				return [][]byte{}
 			}
 		}
@@ -206,24 +216,12 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		}
 
 	case "content_block_stop":
-		// End of content block - output complete tool call if it's a tool_use block
+		// End of content block - a tool call that streamed no arguments gets "{}" so clients can parse them
 		index := int(root.Get("index").Int())
-		if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
-			if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
-				// Build complete tool call with accumulated arguments
-				arguments := accumulator.Arguments.String()
-				if arguments == "" {
-					arguments = "{}"
-				}
-				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.index", index)
-				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.id", accumulator.ID)
-				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.type", "function")
-				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.name", accumulator.Name)
-				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.arguments", arguments)
-
-				// Clean up the accumulator for this index
-				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
-
+		if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
+			delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
+			if accumulator.Arguments.Len() == 0 {
+				template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls.-1", common.OpenAIToolCallArgumentsDelta(accumulator.Index, "{}"))
 				return [][]byte{template}
 			}
 		}
@@ -466,5 +464,5 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		out, _ = sjson.SetBytes(out, "choices.0.finish_reason", finishReason)
 	}
 
-	return out
+	return common.RepairOpenAIChatStructuredOutput(originalRequestRawJSON, out)
 }
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// defaultRemoteImageMIMEType is assumed for remote images whose URL has no image extension.
+const defaultRemoteImageMIMEType = "image/jpeg"
+
+// ImageSource is an image reference shared by the OpenAI, Anthropic and Gemini formats: either
+// base64 Data with its MIME type or a remote http(s) URL.
+type ImageSource struct {
+	MIMEType string
+	Data     string
+	URL      string
+}
+
+// ImageSourceFromURL parses an OpenAI image_url value, which is a base64 data URL or an
+// http(s) URL.
+func ImageSourceFromURL(imageURL string) (ImageSource, bool) {
+	imageURL = strings.TrimSpace(imageURL)
+	lower := strings.ToLower(imageURL)
+	switch {
+	case strings.HasPrefix(lower, "data:"):
+		mimeType, data, ok := NormalizeOpenAIFileData("", "", imageURL)
+		if !ok {
+			return ImageSource{}, false
+		}
+		return ImageSource{MIMEType: mimeType, Data: data}, true
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return ImageSource{MIMEType: remoteImageMIMEType(imageURL), URL: imageURL}, true
+	default:
+		return ImageSource{}, false
+	}
+}
+
+// ImageSourceFromClaude parses the source of an Anthropic image block.
+func ImageSourceFromClaude(source gjson.Result) (ImageSource, bool) {
+	switch source.Get("type").String() {
+	case "base64":
+		mimeType, data := source.Get("media_type").String(), source.Get("data").String()
+		if mimeType == "" || data == "" {
+			return ImageSource{}, false
+		}
+		return ImageSource{MIMEType: mimeType, Data: data}, true
+	case "url":
+		return ImageSourceFromURL(source.Get("url").String())
+	default:
+		return ImageSource{}, false
+	}
+}
+
+// OpenAIURL returns the source as an OpenAI image_url value.
+func (s ImageSource) OpenAIURL() string {
+	if s.URL != "" {
+		return s.URL
+	}
+	return "data:" + s.MIMEType + ";base64," + s.Data
+}
+
+// ClaudeImageBlock returns the source as an Anthropic image block.
+func (s ImageSource) ClaudeImageBlock() []byte {
+	if s.URL != "" {
+		block := []byte(`{"type":"image","source":{"type":"url","url":""}}`)
+		block, _ = sjson.SetBytes(block, "source.url", s.URL)
+		return block
+	}
+	block := []byte(`{"type":"image","source":{"type":"base64","media_type":"","data":""}}`)
+	block, _ = sjson.SetBytes(block, "source.media_type", s.MIMEType)
+	block, _ = sjson.SetBytes(block, "source.data", s.Data)
+	return block
+}
+
+// GeminiFileDataPart returns a remote source as a Gemini fileData part. Remote images can be
+// inlined before sending with the vision fetch-remote-images setting.
+func (s ImageSource) GeminiFileDataPart() []byte {
+	part := []byte(`{"fileData":{"mimeType":"","fileUri":""}}`)
+	part, _ = sjson.SetBytes(part, "fileData.mimeType", s.MIMEType)
+	part, _ = sjson.SetBytes(part, "fileData.fileUri", s.URL)
+	return part
+}
+
+func remoteImageMIMEType(rawURL string) string {
+	parsed, errParse := url.Parse(rawURL)
+	if errParse != nil {
+		return defaultRemoteImageMIMEType
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(parsed.Path), "."))
+	if mimeType := misc.MimeTypes[ext]; strings.HasPrefix(mimeType, "image/") {
+		return mimeType
+	}
+	return defaultRemoteImageMIMEType
+}
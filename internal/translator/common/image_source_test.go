@@ -0,0 +1,52 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestImageSourceFromURL(t *testing.T) {
+	source, ok := ImageSourceFromURL("data:image/png;base64,AAAA")
+	if !ok || source.MIMEType != "image/png" || source.Data != "AAAA" || source.URL != "" {
+		t.Fatalf("data URL = %+v, %v", source, ok)
+	}
+	if got := source.OpenAIURL(); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("OpenAIURL() = %q", got)
+	}
+	if block := gjson.ParseBytes(source.ClaudeImageBlock()); block.Get("source.type").String() != "base64" || block.Get("source.data").String() != "AAAA" {
+		t.Fatalf("ClaudeImageBlock() = %s", block.Raw)
+	}
+
+	source, ok = ImageSourceFromURL("https://example.com/cat.webp?size=large")
+	if !ok || source.URL == "" || source.MIMEType != "image/webp" {
+		t.Fatalf("remote URL = %+v, %v", source, ok)
+	}
+	part := gjson.ParseBytes(source.GeminiFileDataPart())
+	if part.Get("fileData.fileUri").String() != "https://example.com/cat.webp?size=large" || part.Get("fileData.mimeType").String() != "image/webp" {
+		t.Fatalf("GeminiFileDataPart() = %s", part.Raw)
+	}
+
+	if source, _ = ImageSourceFromURL("https://example.com/image"); source.MIMEType != "image/jpeg" {
+		t.Fatalf("MIME type without extension = %q, want image/jpeg", source.MIMEType)
+	}
+	for _, invalid := range []string{"", "ftp://example.com/a.png", "data:image/png,AAAA"} {
+		if _, ok := ImageSourceFromURL(invalid); ok {
+			t.Fatalf("ImageSourceFromURL(%q) succeeded, want failure", invalid)
+		}
+	}
+}
+
+func TestImageSourceFromClaude(t *testing.T) {
+	source, ok := ImageSourceFromClaude(gjson.Parse(`{"type":"url","url":"https://example.com/a.png"}`))
+	if !ok || source.URL != "https://example.com/a.png" || source.MIMEType != "image/png" {
+		t.Fatalf("url source = %+v, %v", source, ok)
+	}
+	source, ok = ImageSourceFromClaude(gjson.Parse(`{"type":"base64","media_type":"image/gif","data":"R0lG"}`))
+	if !ok || source.OpenAIURL() != "data:image/gif;base64,R0lG" {
+		t.Fatalf("base64 source = %+v, %v", source, ok)
+	}
+	if _, ok = ImageSourceFromClaude(gjson.Parse(`{"type":"file","file_id":"file_1"}`)); ok {
+		t.Fatal("file source succeeded, want failure")
+	}
+}
@@ -0,0 +1,394 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// jsonObjectInstruction asks providers without a plain JSON mode for a bare JSON object.
+const jsonObjectInstruction = "Respond only with a single valid JSON object, without Markdown code fences or any other text."
+
+// claudeUnsupportedSchemaKeywords are JSON schema constraints Claude structured outputs reject.
+var claudeUnsupportedSchemaKeywords = []string{
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "maxItems", "minProperties", "maxProperties",
+}
+
+// OpenAIResponseFormat reads an OpenAI Chat Completions response_format. It reports whether the
+// request asks for JSON output and returns the JSON schema for json_schema formats.
+func OpenAIResponseFormat(responseFormat gjson.Result) (schema gjson.Result, jsonOutput bool) {
+	switch strings.ToLower(strings.TrimSpace(responseFormat.Get("type").String())) {
+	case "json_schema":
+		return responseFormat.Get("json_schema.schema"), true
+	case "json_object":
+		return gjson.Result{}, true
+	default:
+		return gjson.Result{}, false
+	}
+}
+
+// ApplyOpenAIResponseFormatToClaude maps an OpenAI response_format onto a Claude Messages
+// request. json_schema becomes output_config.format with the schema adjusted to what Claude
+// accepts; json_object, which Claude has no mode for, becomes a system instruction.
+func ApplyOpenAIResponseFormatToClaude(out []byte, responseFormat gjson.Result) []byte {
+	schema, jsonOutput := OpenAIResponseFormat(responseFormat)
+	if !jsonOutput {
+		return out
+	}
+	if schema.IsObject() {
+		format := []byte(`{"type":"json_schema"}`)
+		format, _ = sjson.SetRawBytes(format, "schema", claudeOutputSchema([]byte(schema.Raw)))
+		out, _ = sjson.SetRawBytes(out, "output_config.format", format)
+		return out
+	}
+	block := []byte(`{"type":"text","text":""}`)
+	block, _ = sjson.SetBytes(block, "text", jsonObjectInstruction)
+	system := gjson.GetBytes(out, "system")
+	switch {
+	case system.IsArray():
+		out, _ = sjson.SetRawBytes(out, "system.-1", block)
+	case system.String() != "":
+		out, _ = sjson.SetBytes(out, "system", system.String()+"\n\n"+jsonObjectInstruction)
+	default:
+		out, _ = sjson.SetRawBytes(out, "system", []byte("["+string(block)+"]"))
+	}
+	return out
+}
+
+// claudeOutputSchema closes every object schema with additionalProperties false, as Claude
+// requires, and drops the numeric and length constraints it does not support.
+func claudeOutputSchema(schema []byte) []byte {
+	root := gjson.ParseBytes(schema)
+	if !root.IsObject() {
+		return schema
+	}
+	out := schema
+	for _, keyword := range claudeUnsupportedSchemaKeywords {
+		out, _ = sjson.DeleteBytes(out, keyword)
+	}
+	if root.Get("type").String() == "object" || root.Get("properties").Exists() {
+		out, _ = sjson.SetBytes(out, "additionalProperties", false)
+	}
+	root.ForEach(func(key, value gjson.Result) bool {
+		switch key.String() {
+		case "properties", "$defs", "definitions":
+			value.ForEach(func(name, child gjson.Result) bool {
+				out, _ = sjson.SetRawBytes(out, key.String()+"."+util.EscapeGJSONPathKey(name.String()), claudeOutputSchema([]byte(child.Raw)))
+				return true
+			})
+		case "items":
+			out, _ = sjson.SetRawBytes(out, "items", claudeOutputSchema([]byte(value.Raw)))
+		case "anyOf", "allOf", "oneOf":
+			for i, child := range value.Array() {
+				out, _ = sjson.SetRawBytes(out, fmt.Sprintf("%s.%d", key.String(), i), claudeOutputSchema([]byte(child.Raw)))
+			}
+		}
+		return true
+	})
+	return out
+}
+
+// ApplyOpenAIResponseFormatToGemini maps an OpenAI response_format onto the Gemini generation
+// config found at configPath ("generationConfig" or "request.generationConfig").
+func ApplyOpenAIResponseFormatToGemini(out []byte, responseFormat gjson.Result, configPath string) []byte {
+	schema, jsonOutput := OpenAIResponseFormat(responseFormat)
+	if !jsonOutput {
+		return out
+	}
+	out, _ = sjson.SetBytes(out, configPath+".responseMimeType", "application/json")
+	if schema.IsObject() {
+		out, _ = sjson.SetRawBytes(out, configPath+".responseJsonSchema", []byte(schema.Raw))
+	}
+	return out
+}
+
+// OpenAIResponseFormatFromGemini returns the OpenAI response_format equivalent to a Gemini
+// generation config asking for JSON output, or nil when it does not. responseJsonSchema is used
+// as-is; the OpenAPI-style responseSchema has its upper-case types lowered, nullable turned
+// into a "null" type and its Gemini-specific keywords dropped.
+func OpenAIResponseFormatFromGemini(genConfig gjson.Result) []byte {
+	if !strings.EqualFold(strings.TrimSpace(genConfig.Get("responseMimeType").String()), "application/json") {
+		return nil
+	}
+	var schema []byte
+	if jsonSchema := genConfig.Get("responseJsonSchema"); jsonSchema.IsObject() {
+		schema = []byte(jsonSchema.Raw)
+	} else if openAPISchema := genConfig.Get("responseSchema"); openAPISchema.IsObject() {
+		schema = jsonSchemaFromGeminiSchema([]byte(openAPISchema.Raw))
+	}
+	if schema == nil {
+		return []byte(`{"type":"json_object"}`)
+	}
+	format := []byte(`{"type":"json_schema","json_schema":{"name":"response"}}`)
+	format, _ = sjson.SetRawBytes(format, "json_schema.schema", schema)
+	return format
+}
+
+// jsonSchemaFromGeminiSchema converts a Gemini OpenAPI schema to JSON schema.
+func jsonSchemaFromGeminiSchema(schema []byte) []byte {
+	root := gjson.ParseBytes(schema)
+	if !root.IsObject() {
+		return schema
+	}
+	out := schema
+	for _, keyword := range []string{"nullable", "propertyOrdering"} {
+		out, _ = sjson.DeleteBytes(out, keyword)
+	}
+	schemaType := root.Get("type")
+	if schemaType.Type == gjson.String {
+		out, _ = sjson.SetBytes(out, "type", strings.ToLower(schemaType.String()))
+	}
+	if root.Get("nullable").Bool() {
+		switch {
+		case schemaType.Type == gjson.String:
+			out, _ = sjson.SetBytes(out, "type", []string{strings.ToLower(schemaType.String()), "null"})
+		case root.Get("anyOf").IsArray():
+			out, _ = sjson.SetRawBytes(out, "anyOf.-1", []byte(`{"type":"null"}`))
+		}
+		if root.Get("enum").IsArray() {
+			out, _ = sjson.SetRawBytes(out, "enum.-1", []byte("null"))
+		}
+	}
+	root.ForEach(func(key, value gjson.Result) bool {
+		switch key.String() {
+		case "properties":
+			value.ForEach(func(name, child gjson.Result) bool {
+				out, _ = sjson.SetRawBytes(out, "properties."+util.EscapeGJSONPathKey(name.String()), jsonSchemaFromGeminiSchema([]byte(child.Raw)))
+				return true
+			})
+		case "items":
+			out, _ = sjson.SetRawBytes(out, "items", jsonSchemaFromGeminiSchema([]byte(value.Raw)))
+		case "anyOf":
+			for i, child := range value.Array() {
+				out, _ = sjson.SetRawBytes(out, fmt.Sprintf("anyOf.%d", i), jsonSchemaFromGeminiSchema([]byte(child.Raw)))
+			}
+		}
+		return true
+	})
+	return out
+}
+
+// RepairOpenAIChatStructuredOutput repairs the message content of a non-streaming OpenAI Chat
+// Completions response when the original request asked for JSON output. Content that is not
+// valid JSON is unwrapped from Markdown fences or surrounding prose and quote-repaired; content
+// that still does not match the requested schema is returned as-is and logged.
+func RepairOpenAIChatStructuredOutput(originalRequest, response []byte) []byte {
+	schema, jsonOutput := OpenAIResponseFormat(gjson.GetBytes(originalRequest, "response_format"))
+	if !jsonOutput {
+		return response
+	}
+	choices := gjson.GetBytes(response, "choices")
+	if !choices.IsArray() {
+		return response
+	}
+	for i, choice := range choices.Array() {
+		content := choice.Get("message.content")
+		if content.Type != gjson.String || content.String() == "" {
+			continue
+		}
+		repaired, ok := RepairJSONOutput(content.String())
+		if !ok {
+			log.Warnf("structured output: choice %d is not valid JSON and could not be repaired", i)
+			continue
+		}
+		if repaired != content.String() {
+			response, _ = sjson.SetBytes(response, fmt.Sprintf("choices.%d.message.content", i), repaired)
+		}
+		if schema.IsObject() {
+			if errValidate := ValidateJSONSchema(schema, gjson.Parse(repaired)); errValidate != nil {
+				log.Warnf("structured output: choice %d does not match the requested schema: %v", i, errValidate)
+			}
+		}
+	}
+	return response
+}
+
+// RepairJSONOutput returns text as valid JSON. It strips Markdown code fences and text around
+// the outermost JSON object or array, then repairs single-quoted strings and trailing commas.
+func RepairJSONOutput(text string) (string, bool) {
+	if gjson.Valid(text) {
+		return text, true
+	}
+	candidate := strings.TrimSpace(text)
+	if strings.HasPrefix(candidate, "```") {
+		candidate = strings.TrimPrefix(candidate, "```")
+		if newline := strings.IndexByte(candidate, '\n'); newline >= 0 {
+			candidate = candidate[newline+1:]
+		}
+		candidate = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(candidate), "```"))
+	}
+	if start := strings.IndexAny(candidate, "{["); start >= 0 {
+		closer := "}"
+		if candidate[start] == '[' {
+			closer = "]"
+		}
+		if end := strings.LastIndex(candidate, closer); end > start {
+			candidate = candidate[start : end+1]
+		}
+	}
+	for _, repair := range []func(string) string{
+		func(s string) string { return s },
+		util.FixJSON,
+		removeTrailingCommas,
+		func(s string) string { return removeTrailingCommas(util.FixJSON(s)) },
+	} {
+		if fixed := repair(candidate); gjson.Valid(fixed) {
+			return fixed, true
+		}
+	}
+	return text, false
+}
+
+// removeTrailingCommas drops commas that directly precede a closing brace or bracket outside
+// of strings.
+func removeTrailingCommas(input string) string {
+	var out strings.Builder
+	out.Grow(len(input))
+	inString := false
+	escaped := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		if c == ',' {
+			j := i + 1
+			for j < len(input) && strings.ContainsRune(" \t\r\n", rune(input[j])) {
+				j++
+			}
+			if j < len(input) && (input[j] == '}' || input[j] == ']') {
+				continue
+			}
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// ValidateJSONSchema checks value against the common subset of JSON schema used for structured
+// outputs: type, enum, const, required, properties, additionalProperties false and items.
+// Unsupported keywords are ignored.
+func ValidateJSONSchema(schema, value gjson.Result) error {
+	return validateJSONSchema(schema, value, "$")
+}
+
+func validateJSONSchema(schema, value gjson.Result, path string) error {
+	if !schema.IsObject() {
+		return nil
+	}
+	if types := schema.Get("type"); types.Exists() && !jsonSchemaTypeMatches(types, value) {
+		return fmt.Errorf("%s: expected type %s", path, types.Raw)
+	}
+	if enum := schema.Get("enum"); enum.IsArray() {
+		matched := false
+		for _, option := range enum.Array() {
+			if jsonValuesEqual(option, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value is not one of %s", path, enum.Raw)
+		}
+	}
+	if constant := schema.Get("const"); constant.Exists() && !jsonValuesEqual(constant, value) {
+		return fmt.Errorf("%s: value must be %s", path, constant.Raw)
+	}
+	if value.IsObject() {
+		for _, required := range schema.Get("required").Array() {
+			if !value.Get(util.EscapeGJSONPathKey(required.String())).Exists() {
+				return fmt.Errorf("%s: missing required property %q", path, required.String())
+			}
+		}
+		properties := schema.Get("properties")
+		closed := schema.Get("additionalProperties").Type == gjson.False
+		var errProperty error
+		value.ForEach(func(key, child gjson.Result) bool {
+			propertySchema := properties.Get(util.EscapeGJSONPathKey(key.String()))
+			if !propertySchema.Exists() {
+				if closed {
+					errProperty = fmt.Errorf("%s: unexpected property %q", path, key.String())
+					return false
+				}
+				return true
+			}
+			errProperty = validateJSONSchema(propertySchema, child, path+"."+key.String())
+			return errProperty == nil
+		})
+		if errProperty != nil {
+			return errProperty
+		}
+	}
+	if value.IsArray() {
+		if items := schema.Get("items"); items.IsObject() {
+			for i, item := range value.Array() {
+				if errItem := validateJSONSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); errItem != nil {
+					return errItem
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func jsonSchemaTypeMatches(types, value gjson.Result) bool {
+	if types.IsArray() {
+		for _, t := range types.Array() {
+			if jsonSchemaTypeMatches(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	switch types.String() {
+	case "object":
+		return value.IsObject()
+	case "array":
+		return value.IsArray()
+	case "string":
+		return value.Type == gjson.String
+	case "number":
+		return value.Type == gjson.Number
+	case "integer":
+		return value.Type == gjson.Number && value.Num == float64(int64(value.Num))
+	case "boolean":
+		return value.IsBool()
+	case "null":
+		return value.Type == gjson.Null
+	default:
+		return true
+	}
+}
+
+func jsonValuesEqual(a, b gjson.Result) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case gjson.String:
+		return a.String() == b.String()
+	case gjson.Number:
+		return a.Num == b.Num
+	case gjson.JSON:
+		return strings.Join(strings.Fields(a.Raw), "") == strings.Join(strings.Fields(b.Raw), "")
+	default:
+		return true
+	}
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyOpenAIResponseFormatToClaudeSchema(t *testing.T) {
+	responseFormat := gjson.Parse(`{"type":"json_schema","json_schema":{"name":"person","strict":true,"schema":{"type":"object","properties":{"name":{"type":"string","maxLength":20},"tags":{"type":"array","items":{"type":"object","properties":{"id":{"type":"integer","minimum":1}}}}},"required":["name"]}}}`)
+
+	out := ApplyOpenAIResponseFormatToClaude([]byte(`{"model":"claude","output_config":{"effort":"high"}}`), responseFormat)
+
+	if got := gjson.GetBytes(out, "output_config.effort").String(); got != "high" {
+		t.Fatalf("output_config.effort = %q, want high: %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "output_config.format.type").String(); got != "json_schema" {
+		t.Fatalf("output_config.format.type = %q, want json_schema: %s", got, out)
+	}
+	schema := gjson.GetBytes(out, "output_config.format.schema")
+	if schema.Get("additionalProperties").Type != gjson.False {
+		t.Fatalf("root schema is not closed: %s", schema.Raw)
+	}
+	if schema.Get("properties.tags.items.additionalProperties").Type != gjson.False {
+		t.Fatalf("nested item schema is not closed: %s", schema.Raw)
+	}
+	if schema.Get("properties.name.maxLength").Exists() || schema.Get("properties.tags.items.properties.id.minimum").Exists() {
+		t.Fatalf("unsupported constraints were kept: %s", schema.Raw)
+	}
+	if got := schema.Get("required.0").String(); got != "name" {
+		t.Fatalf("required = %s, want [name]", schema.Get("required").Raw)
+	}
+}
+
+func TestApplyOpenAIResponseFormatToClaudeJSONObject(t *testing.T) {
+	responseFormat := gjson.Parse(`{"type":"json_object"}`)
+	cases := []struct {
+		name string
+		in   string
+		path string
+	}{
+		{name: "no system", in: `{}`, path: "system.0.text"},
+		{name: "array system", in: `{"system":[{"type":"text","text":"Be brief."}]}`, path: "system.1.text"},
+		{name: "string system", in: `{"system":"Be brief."}`, path: "system"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := ApplyOpenAIResponseFormatToClaude([]byte(tc.in), responseFormat)
+			if got := gjson.GetBytes(out, tc.path).String(); got == "" || !gjson.Valid(string(out)) {
+				t.Fatalf("instruction missing at %s: %s", tc.path, out)
+			}
+			if gjson.GetBytes(out, "output_config").Exists() {
+				t.Fatalf("json_object must not set output_config: %s", out)
+			}
+		})
+	}
+
+	out := ApplyOpenAIResponseFormatToClaude([]byte(`{"system":"x"}`), gjson.Parse(`{"type":"text"}`))
+	if string(out) != `{"system":"x"}` {
+		t.Fatalf("text format changed the request: %s", out)
+	}
+}
+
+func TestApplyOpenAIResponseFormatToGemini(t *testing.T) {
+	out := ApplyOpenAIResponseFormatToGemini([]byte(`{"request":{}}`), gjson.Parse(`{"type":"json_schema","json_schema":{"schema":{"type":"object","properties":{"a":{"type":"string"}}}}}`), "request.generationConfig")
+	if got := gjson.GetBytes(out, "request.generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q: %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "request.generationConfig.responseJsonSchema.properties.a.type").String(); got != "string" {
+		t.Fatalf("responseJsonSchema not set: %s", out)
+	}
+
+	out = ApplyOpenAIResponseFormatToGemini([]byte(`{}`), gjson.Parse(`{"type":"json_object"}`), "generationConfig")
+	if got := gjson.GetBytes(out, "generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q: %s", got, out)
+	}
+	if gjson.GetBytes(out, "generationConfig.responseJsonSchema").Exists() {
+		t.Fatalf("json_object must not set a schema: %s", out)
+	}
+}
+
+func TestOpenAIResponseFormatFromGemini(t *testing.T) {
+	format := OpenAIResponseFormatFromGemini(gjson.Parse(`{"responseMimeType":"application/json","responseSchema":{"type":"OBJECT","nullable":true,"propertyOrdering":["a"],"properties":{"a":{"type":"ARRAY","items":{"type":"STRING"}}}}}`))
+	if got := gjson.GetBytes(format, "type").String(); got != "json_schema" {
+		t.Fatalf("type = %q: %s", got, format)
+	}
+	schema := gjson.GetBytes(format, "json_schema.schema")
+	if schema.Get("type").Raw != `["object","null"]` || schema.Get("properties.a.items.type").String() != "string" || schema.Get("nullable").Exists() || schema.Get("propertyOrdering").Exists() {
+		t.Fatalf("schema not converted to JSON schema: %s", schema.Raw)
+	}
+
+	format = OpenAIResponseFormatFromGemini(gjson.Parse(`{"responseMimeType":"application/json","responseSchema":{"type":"OBJECT","properties":{"tag":{"type":"STRING","enum":["a","b"],"nullable":true},"either":{"anyOf":[{"type":"STRING"},{"type":"INTEGER"}],"nullable":true},"plain":{"type":"STRING","nullable":false}}}}`))
+	schema = gjson.GetBytes(format, "json_schema.schema")
+	if got := schema.Get("properties.tag.type").Raw; got != `["string","null"]` {
+		t.Fatalf("nullable enum type = %s: %s", got, schema.Raw)
+	}
+	if got := schema.Get("properties.tag.enum").Raw; got != `["a","b",null]` {
+		t.Fatalf("nullable enum values = %s: %s", got, schema.Raw)
+	}
+	if got := schema.Get("properties.either.anyOf").Raw; got != `[{"type":"string"},{"type":"integer"},{"type":"null"}]` {
+		t.Fatalf("nullable anyOf = %s: %s", got, schema.Raw)
+	}
+	if got := schema.Get("properties.plain.type").Raw; got != `"string"` {
+		t.Fatalf("non-nullable type = %s: %s", got, schema.Raw)
+	}
+
+	format = OpenAIResponseFormatFromGemini(gjson.Parse(`{"responseMimeType":"application/json","responseJsonSchema":{"type":"object","propertyOrdering":["a"]}}`))
+	if got := gjson.GetBytes(format, "json_schema.schema.propertyOrdering").Raw; got != `["a"]` {
+		t.Fatalf("responseJsonSchema changed: %s", format)
+	}
+	if got := string(OpenAIResponseFormatFromGemini(gjson.Parse(`{"responseMimeType":"application/json"}`))); got != `{"type":"json_object"}` {
+		t.Fatalf("JSON mode = %s, want json_object", got)
+	}
+	if format = OpenAIResponseFormatFromGemini(gjson.Parse(`{"responseMimeType":"text/plain"}`)); format != nil {
+		t.Fatalf("text output produced %s", format)
+	}
+}
+
+func TestRepairJSONOutput(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want string
+		ok   bool
+	}{
+		{name: "valid", text: `{"a":1}`, want: `{"a":1}`, ok: true},
+		{name: "fenced", text: "```json\n{\"a\":1}\n```", want: `{"a":1}`, ok: true},
+		{name: "prose", text: `Here you go: {"a":1} Hope this helps.`, want: `{"a":1}`, ok: true},
+		{name: "array", text: "Result:\n[1,2]", want: `[1,2]`, ok: true},
+		{name: "single quotes", text: `{'a': 'b'}`, want: `{"a": "b"}`, ok: true},
+		{name: "trailing comma", text: `{"a":[1,2,],"b":"x,}",}`, want: `{"a":[1,2],"b":"x,}"}`, ok: true},
+		{name: "not json", text: `no json here`, want: `no json here`, ok: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := RepairJSONOutput(tc.text)
+			if got != tc.want || ok != tc.ok {
+				t.Fatalf("RepairJSONOutput(%q) = %q, %v; want %q, %v", tc.text, got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := gjson.Parse(`{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"},"kind":{"enum":["a","b"]},"tags":{"type":"array","items":{"type":"string"}}},"required":["name"],"additionalProperties":false}`)
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "valid", value: `{"name":"x","age":3,"kind":"a","tags":["t"]}`},
+		{name: "missing required", value: `{"age":3}`, wantErr: true},
+		{name: "wrong type", value: `{"name":1}`, wantErr: true},
+		{name: "not integer", value: `{"name":"x","age":1.5}`, wantErr: true},
+		{name: "enum", value: `{"name":"x","kind":"c"}`, wantErr: true},
+		{name: "extra property", value: `{"name":"x","extra":true}`, wantErr: true},
+		{name: "bad item", value: `{"name":"x","tags":[1]}`, wantErr: true},
+		{name: "not object", value: `[]`, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateJSONSchema(schema, gjson.Parse(tc.value))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ValidateJSONSchema(%s) error = %v, wantErr %v", tc.value, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepairOpenAIChatStructuredOutput(t *testing.T) {
+	request := []byte(`{"response_format":{"type":"json_schema","json_schema":{"schema":{"type":"object"}}}}`)
+	response := []byte(`{"choices":[{"message":{"role":"assistant","content":"` + "```json\\n{\\\"a\\\":1}\\n```" + `"}}]}`)
+
+	out := RepairOpenAIChatStructuredOutput(request, response)
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != `{"a":1}` {
+		t.Fatalf("content = %q, want repaired JSON", got)
+	}
+
+	out = RepairOpenAIChatStructuredOutput([]byte(`{}`), response)
+	if string(out) != string(response) {
+		t.Fatalf("response without response_format was changed: %s", out)
+	}
+}
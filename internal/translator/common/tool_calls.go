@@ -0,0 +1,370 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// missingToolResultText is the tool result synthesized for a tool call the client never answered.
+const missingToolResultText = "Tool call was not executed: no result was provided."
+
+// ClaudeToolInputFromArguments converts OpenAI function call arguments into a Claude tool_use
+// input object. Arguments that are not valid JSON are repaired when possible; anything that is
+// still not an object becomes {} because Claude only accepts object inputs.
+func ClaudeToolInputFromArguments(arguments string) []byte {
+	arguments = strings.TrimSpace(arguments)
+	if arguments == "" {
+		return []byte(`{}`)
+	}
+	if !gjson.Valid(arguments) {
+		arguments = util.FixJSON(arguments)
+	}
+	if gjson.Valid(arguments) {
+		if parsed := gjson.Parse(arguments); parsed.IsObject() {
+			return []byte(parsed.Raw)
+		}
+	}
+	return []byte(`{}`)
+}
+
+// OpenAIArgumentsFromClaudeInput returns the OpenAI function call arguments for a Claude tool_use input.
+func OpenAIArgumentsFromClaudeInput(input gjson.Result) string {
+	if !input.Exists() || input.Type == gjson.Null || strings.TrimSpace(input.Raw) == "" {
+		return "{}"
+	}
+	return input.Raw
+}
+
+// ClaudeToolUseFromOpenAIToolCall builds a Claude tool_use block from an OpenAI tool call.
+// id must already be a valid Claude tool use ID and name the client-facing tool name.
+func ClaudeToolUseFromOpenAIToolCall(toolCall gjson.Result, id, name string) []byte {
+	block := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
+	block, _ = sjson.SetBytes(block, "id", id)
+	block, _ = sjson.SetBytes(block, "name", name)
+	block, _ = sjson.SetRawBytes(block, "input", ClaudeToolInputFromArguments(toolCall.Get("function.arguments").String()))
+	return block
+}
+
+// OpenAIToolCallFromClaudeToolUse builds an OpenAI tool call from a Claude tool_use block.
+func OpenAIToolCallFromClaudeToolUse(block gjson.Result) []byte {
+	toolCall := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
+	toolCall, _ = sjson.SetBytes(toolCall, "id", block.Get("id").String())
+	toolCall, _ = sjson.SetBytes(toolCall, "function.name", block.Get("name").String())
+	toolCall, _ = sjson.SetBytes(toolCall, "function.arguments", OpenAIArgumentsFromClaudeInput(block.Get("input")))
+	return toolCall
+}
+
+// OpenAIToolCallStartDelta returns the first streaming tool_calls entry of a call, announcing its
+// id and name with empty arguments.
+func OpenAIToolCallStartDelta(index int, id, name string) []byte {
+	delta := []byte(`{"index":0,"id":"","type":"function","function":{"name":"","arguments":""}}`)
+	delta, _ = sjson.SetBytes(delta, "index", index)
+	delta, _ = sjson.SetBytes(delta, "id", id)
+	delta, _ = sjson.SetBytes(delta, "function.name", name)
+	return delta
+}
+
+// OpenAIToolCallArgumentsDelta returns a streaming tool_calls entry carrying an arguments fragment.
+func OpenAIToolCallArgumentsDelta(index int, fragment string) []byte {
+	delta := []byte(`{"index":0,"function":{"arguments":""}}`)
+	delta, _ = sjson.SetBytes(delta, "index", index)
+	delta, _ = sjson.SetBytes(delta, "function.arguments", fragment)
+	return delta
+}
+
+// ClaudeToolChoiceFromOpenAI maps an OpenAI tool_choice and parallel_tool_calls onto a Claude
+// tool_choice object. It returns nil when neither is set.
+func ClaudeToolChoiceFromOpenAI(toolChoice, parallelToolCalls gjson.Result) []byte {
+	var out []byte
+	switch {
+	case toolChoice.Type == gjson.String:
+		switch toolChoice.String() {
+		case "none":
+			out = []byte(`{"type":"none"}`)
+		case "auto":
+			out = []byte(`{"type":"auto"}`)
+		case "required":
+			out = []byte(`{"type":"any"}`)
+		}
+	case toolChoice.IsObject() && toolChoice.Get("type").String() == "function":
+		out = []byte(`{"type":"tool","name":""}`)
+		out, _ = sjson.SetBytes(out, "name", toolChoice.Get("function.name").String())
+	}
+	if parallelToolCalls.Exists() && parallelToolCalls.Type == gjson.False {
+		if out == nil {
+			out = []byte(`{"type":"auto"}`)
+		}
+		// Claude rejects disable_parallel_tool_use on tool_choice none.
+		if gjson.GetBytes(out, "type").String() != "none" {
+			out, _ = sjson.SetBytes(out, "disable_parallel_tool_use", true)
+		}
+	}
+	return out
+}
+
+// OpenAIToolChoiceFromClaude maps a Claude tool_choice object onto an OpenAI tool_choice value
+// and reports whether it disables parallel tool use. choice is nil when tool_choice is unset.
+func OpenAIToolChoiceFromClaude(toolChoice gjson.Result) (choice []byte, disableParallel bool) {
+	if !toolChoice.Exists() || toolChoice.Type == gjson.Null {
+		return nil, false
+	}
+	switch toolChoice.Get("type").String() {
+	case "none":
+		choice = []byte(`"none"`)
+	case "any":
+		choice = []byte(`"required"`)
+	case "tool":
+		choice = []byte(`{"type":"function","function":{"name":""}}`)
+		choice, _ = sjson.SetBytes(choice, "function.name", toolChoice.Get("name").String())
+	default:
+		choice = []byte(`"auto"`)
+	}
+	return choice, toolChoice.Get("disable_parallel_tool_use").Bool()
+}
+
+// AppendClaudeToolResult adds a tool_result block to messages. Results of parallel tool calls
+// arrive as consecutive OpenAI tool messages but must share a single Claude user turn, so the
+// block joins the previous message when that message holds only tool results.
+func AppendClaudeToolResult(messages [][]byte, toolResult []byte) [][]byte {
+	if n := len(messages); n > 0 && isClaudeToolResultTurn(gjson.ParseBytes(messages[n-1])) {
+		if updated, errSet := sjson.SetRawBytes(messages[n-1], "content.-1", toolResult); errSet == nil {
+			messages[n-1] = updated
+			return messages
+		}
+	}
+	msg := []byte(`{"role":"user","content":[]}`)
+	msg, _ = sjson.SetRawBytes(msg, "content.-1", toolResult)
+	return append(messages, msg)
+}
+
+func isClaudeToolResultTurn(msg gjson.Result) bool {
+	content := msg.Get("content")
+	if msg.Get("role").String() != "user" || !content.IsArray() {
+		return false
+	}
+	blocks := content.Array()
+	if len(blocks) == 0 {
+		return false
+	}
+	for _, block := range blocks {
+		if block.Get("type").String() != "tool_result" {
+			return false
+		}
+	}
+	return true
+}
+
+// PairClaudeToolResults makes Claude messages satisfy the tool pairing rules: every tool_use of an
+// assistant turn is answered by a tool_result at the start of the following user turn, and no
+// tool_result refers to an unknown tool use. A tool_result without an ID answers the first
+// unanswered tool use, unanswered tool uses followed by further messages receive an error
+// result, and results for unknown tool uses are kept as text so their content still reaches
+// the model. Tool uses in the final message are left for the client to answer.
+func PairClaudeToolResults(messages [][]byte) [][]byte {
+	out := make([][]byte, 0, len(messages)+1)
+	var pending []string
+	for i := 0; i < len(messages); i++ {
+		msg := gjson.ParseBytes(messages[i])
+		role := msg.Get("role").String()
+		if role == "user" {
+			out = append(out, pairClaudeUserTurn(msg, pending))
+			pending = nil
+			continue
+		}
+		if len(pending) > 0 {
+			out = append(out, pairClaudeUserTurn(gjson.Parse(`{"role":"user","content":[]}`), pending))
+			pending = nil
+		}
+		out = append(out, messages[i])
+		if role == "assistant" {
+			msg.Get("content").ForEach(func(_, block gjson.Result) bool {
+				if block.Get("type").String() == "tool_use" {
+					pending = append(pending, block.Get("id").String())
+				}
+				return true
+			})
+		}
+	}
+	return out
+}
+
+func pairClaudeUserTurn(msg gjson.Result, pending []string) []byte {
+	content := msg.Get("content")
+	if !content.IsArray() {
+		if len(pending) == 0 {
+			return []byte(msg.Raw)
+		}
+		text := []byte(`{"type":"text","text":""}`)
+		text, _ = sjson.SetBytes(text, "text", content.String())
+		content = gjson.Parse(string(JoinRawArray([][]byte{text})))
+	}
+
+	answered := make(map[string]bool, len(pending))
+	open := make(map[string]bool, len(pending))
+	for _, id := range pending {
+		open[id] = true
+	}
+	nextOpen := func() string {
+		for _, id := range pending {
+			if open[id] && !answered[id] {
+				return id
+			}
+		}
+		return ""
+	}
+
+	results := make([][]byte, 0, len(pending))
+	others := make([][]byte, 0, 4)
+	changed := false
+	content.ForEach(func(_, block gjson.Result) bool {
+		if block.Get("type").String() != "tool_result" {
+			others = append(others, []byte(block.Raw))
+			return true
+		}
+		id := block.Get("tool_use_id").String()
+		if id == "" {
+			if id = nextOpen(); id != "" {
+				updated, _ := sjson.SetBytes([]byte(block.Raw), "tool_use_id", id)
+				block = gjson.ParseBytes(updated)
+				changed = true
+			}
+		}
+		if !open[id] || answered[id] {
+			others = append(others, orphanToolResultText(block))
+			changed = true
+			return true
+		}
+		answered[id] = true
+		results = append(results, []byte(block.Raw))
+		return true
+	})
+	for _, id := range pending {
+		if answered[id] {
+			continue
+		}
+		missing := []byte(`{"type":"tool_result","tool_use_id":"","content":"","is_error":true}`)
+		missing, _ = sjson.SetBytes(missing, "tool_use_id", id)
+		missing, _ = sjson.SetBytes(missing, "content", missingToolResultText)
+		results = append(results, missing)
+		changed = true
+	}
+	if !changed && len(results) > 0 && len(others) > 0 {
+		// Tool results must precede other content in the turn.
+		changed = content.Array()[0].Get("type").String() != "tool_result"
+	}
+	if !changed {
+		return []byte(msg.Raw)
+	}
+	out, _ := sjson.SetRawBytes([]byte(msg.Raw), "content", JoinRawArray(append(results, others...)))
+	return out
+}
+
+func orphanToolResultText(block gjson.Result) []byte {
+	var parts []string
+	content := block.Get("content")
+	switch {
+	case content.Type == gjson.String:
+		parts = append(parts, content.String())
+	case content.IsArray():
+		content.ForEach(func(_, item gjson.Result) bool {
+			if text := item.Get("text"); text.Exists() {
+				parts = append(parts, text.String())
+			}
+			return true
+		})
+	}
+	text := []byte(`{"type":"text","text":""}`)
+	text, _ = sjson.SetBytes(text, "text", "Result of tool call "+block.Get("tool_use_id").String()+":\n"+strings.Join(parts, "\n"))
+	return text
+}
+
+// PairOpenAIToolMessages makes OpenAI chat messages satisfy the tool pairing rules: an assistant
+// message with tool_calls is followed by one tool message per call before any other message,
+// and every tool message answers a pending call. A tool message without an ID answers the first
+// unanswered call, other unanswered calls receive an error result, and tool messages for unknown
+// calls are kept as user text. Calls of a final assistant message are left for the client.
+func PairOpenAIToolMessages(messages [][]byte) [][]byte {
+	out := make([][]byte, 0, len(messages)+1)
+	var pending []string
+	var orphans [][]byte
+	answered := make(map[string]bool)
+	flush := func() {
+		for _, id := range pending {
+			if answered[id] {
+				continue
+			}
+			missing := []byte(`{"role":"tool","tool_call_id":"","content":""}`)
+			missing, _ = sjson.SetBytes(missing, "tool_call_id", id)
+			missing, _ = sjson.SetBytes(missing, "content", missingToolResultText)
+			out = append(out, missing)
+		}
+		out = append(out, orphans...)
+		pending, orphans = nil, nil
+		answered = make(map[string]bool)
+	}
+	for _, raw := range messages {
+		msg := gjson.ParseBytes(raw)
+		if msg.Get("role").String() != "tool" {
+			flush()
+			out = append(out, raw)
+			msg.Get("tool_calls").ForEach(func(_, toolCall gjson.Result) bool {
+				pending = append(pending, toolCall.Get("id").String())
+				return true
+			})
+			continue
+		}
+		id := msg.Get("tool_call_id").String()
+		if id == "" {
+			for _, candidate := range pending {
+				if !answered[candidate] {
+					id = candidate
+					raw, _ = sjson.SetBytes(raw, "tool_call_id", id)
+					break
+				}
+			}
+		}
+		known := false
+		for _, candidate := range pending {
+			if candidate == id {
+				known = true
+				break
+			}
+		}
+		if !known || answered[id] {
+			// Kept until the pending calls are answered so it does not split their results.
+			text := []byte(`{"role":"user","content":""}`)
+			text, _ = sjson.SetBytes(text, "content", "Result of tool call "+id+":\n"+openAIMessageText(msg.Get("content")))
+			if len(pending) == 0 {
+				out = append(out, text)
+			} else {
+				orphans = append(orphans, text)
+			}
+			continue
+		}
+		answered[id] = true
+		out = append(out, raw)
+	}
+	// Calls of a final assistant message are answered by the client's next request, but once
+	// some of them have results the remaining ones must be answered here.
+	if len(answered) > 0 {
+		flush()
+	}
+	return append(out, orphans...)
+}
+
+func openAIMessageText(content gjson.Result) string {
+	if !content.IsArray() {
+		return content.String()
+	}
+	var parts []string
+	content.ForEach(func(_, part gjson.Result) bool {
+		if text := part.Get("text"); text.Exists() {
+			parts = append(parts, text.String())
+		}
+		return true
+	})
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,293 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestClaudeToolInputFromArguments(t *testing.T) {
+	cases := []struct {
+		name      string
+		arguments string
+		want      string
+	}{
+		{name: "object", arguments: `{"city":"Paris","days":2}`, want: `{"city":"Paris","days":2}`},
+		{name: "padded object", arguments: "  {\"a\":1}\n", want: `{"a":1}`},
+		{name: "empty", arguments: "", want: `{}`},
+		{name: "single quotes repaired", arguments: `{'city': 'Paris'}`, want: `{"city": "Paris"}`},
+		{name: "array", arguments: `[1,2]`, want: `{}`},
+		{name: "scalar", arguments: `"Paris"`, want: `{}`},
+		{name: "truncated", arguments: `{"city":"Par`, want: `{}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(ClaudeToolInputFromArguments(tc.arguments)); got != tc.want {
+				t.Fatalf("ClaudeToolInputFromArguments(%q) = %s, want %s", tc.arguments, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIArgumentsFromClaudeInput(t *testing.T) {
+	cases := []struct {
+		block string
+		want  string
+	}{
+		{block: `{"input":{"city":"Paris"}}`, want: `{"city":"Paris"}`},
+		{block: `{"input":{}}`, want: `{}`},
+		{block: `{"input":null}`, want: `{}`},
+		{block: `{}`, want: `{}`},
+	}
+	for _, tc := range cases {
+		if got := OpenAIArgumentsFromClaudeInput(gjson.Get(tc.block, "input")); got != tc.want {
+			t.Fatalf("OpenAIArgumentsFromClaudeInput(%s) = %s, want %s", tc.block, got, tc.want)
+		}
+	}
+}
+
+func TestToolCallBlockConversionRoundTrip(t *testing.T) {
+	toolCall := gjson.Parse(`{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}`)
+	toolUse := ClaudeToolUseFromOpenAIToolCall(toolCall, "call_1", "get_weather")
+	if got := gjson.GetBytes(toolUse, "input.city").String(); got != "Paris" {
+		t.Fatalf("tool_use = %s, want input.city Paris", toolUse)
+	}
+	back := gjson.ParseBytes(OpenAIToolCallFromClaudeToolUse(gjson.ParseBytes(toolUse)))
+	if back.Get("id").String() != "call_1" || back.Get("type").String() != "function" || back.Get("function.name").String() != "get_weather" {
+		t.Fatalf("tool call = %s, want call_1 get_weather", back.Raw)
+	}
+	if got := back.Get("function.arguments").String(); got != `{"city":"Paris"}` {
+		t.Fatalf("arguments = %s, want {\"city\":\"Paris\"}", got)
+	}
+}
+
+func TestOpenAIToolCallDeltas(t *testing.T) {
+	start := gjson.ParseBytes(OpenAIToolCallStartDelta(2, "toolu_1", "search"))
+	if start.Get("index").Int() != 2 || start.Get("id").String() != "toolu_1" || start.Get("type").String() != "function" ||
+		start.Get("function.name").String() != "search" || !start.Get("function.arguments").Exists() || start.Get("function.arguments").String() != "" {
+		t.Fatalf("start delta = %s", start.Raw)
+	}
+	fragment := gjson.ParseBytes(OpenAIToolCallArgumentsDelta(2, `{"q":`))
+	if fragment.Get("index").Int() != 2 || fragment.Get("id").Exists() || fragment.Get("function.name").Exists() || fragment.Get("function.arguments").String() != `{"q":` {
+		t.Fatalf("arguments delta = %s", fragment.Raw)
+	}
+}
+
+func TestClaudeToolChoiceFromOpenAI(t *testing.T) {
+	cases := []struct {
+		name     string
+		request  string
+		want     string
+		wantNone bool
+	}{
+		{name: "unset", request: `{}`, wantNone: true},
+		{name: "none", request: `{"tool_choice":"none"}`, want: `{"type":"none"}`},
+		{name: "auto", request: `{"tool_choice":"auto"}`, want: `{"type":"auto"}`},
+		{name: "required", request: `{"tool_choice":"required"}`, want: `{"type":"any"}`},
+		{name: "function", request: `{"tool_choice":{"type":"function","function":{"name":"lookup"}}}`, want: `{"type":"tool","name":"lookup"}`},
+		{name: "serial only", request: `{"parallel_tool_calls":false}`, want: `{"type":"auto","disable_parallel_tool_use":true}`},
+		{name: "serial required", request: `{"tool_choice":"required","parallel_tool_calls":false}`, want: `{"type":"any","disable_parallel_tool_use":true}`},
+		{name: "serial none", request: `{"tool_choice":"none","parallel_tool_calls":false}`, want: `{"type":"none"}`},
+		{name: "parallel allowed", request: `{"tool_choice":"auto","parallel_tool_calls":true}`, want: `{"type":"auto"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := gjson.Parse(tc.request)
+			got := ClaudeToolChoiceFromOpenAI(root.Get("tool_choice"), root.Get("parallel_tool_calls"))
+			if tc.wantNone {
+				if got != nil {
+					t.Fatalf("tool_choice = %s, want unset", got)
+				}
+				return
+			}
+			if string(got) != tc.want {
+				t.Fatalf("tool_choice = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIToolChoiceFromClaude(t *testing.T) {
+	cases := []struct {
+		name            string
+		toolChoice      string
+		want            string
+		disableParallel bool
+	}{
+		{name: "none", toolChoice: `{"type":"none"}`, want: `"none"`},
+		{name: "auto", toolChoice: `{"type":"auto"}`, want: `"auto"`},
+		{name: "any", toolChoice: `{"type":"any","disable_parallel_tool_use":true}`, want: `"required"`, disableParallel: true},
+		{name: "tool", toolChoice: `{"type":"tool","name":"lookup"}`, want: `{"type":"function","function":{"name":"lookup"}}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, disableParallel := OpenAIToolChoiceFromClaude(gjson.Parse(tc.toolChoice))
+			if string(got) != tc.want || disableParallel != tc.disableParallel {
+				t.Fatalf("tool_choice = %s (disable parallel %v), want %s (%v)", got, disableParallel, tc.want, tc.disableParallel)
+			}
+		})
+	}
+	if got, _ := OpenAIToolChoiceFromClaude(gjson.Result{}); got != nil {
+		t.Fatalf("unset tool_choice = %s, want nil", got)
+	}
+}
+
+func TestAppendClaudeToolResultGroupsParallelResults(t *testing.T) {
+	messages := [][]byte{[]byte(`{"role":"assistant","content":[{"type":"tool_use","id":"a","name":"f","input":{}},{"type":"tool_use","id":"b","name":"f","input":{}}]}`)}
+	messages = AppendClaudeToolResult(messages, []byte(`{"type":"tool_result","tool_use_id":"a","content":"1"}`))
+	messages = AppendClaudeToolResult(messages, []byte(`{"type":"tool_result","tool_use_id":"b","content":"2"}`))
+	if len(messages) != 2 {
+		t.Fatalf("messages = %d, want the results in one user turn", len(messages))
+	}
+	if ids := gjson.GetBytes(messages[1], "content.#.tool_use_id").String(); ids != `["a","b"]` {
+		t.Fatalf("tool_use_ids = %s, want [a b]", ids)
+	}
+
+	messages = append(messages, []byte(`{"role":"user","content":[{"type":"text","text":"next"}]}`))
+	messages = AppendClaudeToolResult(messages, []byte(`{"type":"tool_result","tool_use_id":"c","content":"3"}`))
+	if len(messages) != 4 {
+		t.Fatalf("messages = %d, want a new turn after a text turn", len(messages))
+	}
+}
+
+func TestPairClaudeToolResults(t *testing.T) {
+	assistant := `{"role":"assistant","content":[{"type":"text","text":"calling"},{"type":"tool_use","id":"a","name":"f","input":{}},{"type":"tool_use","id":"b","name":"f","input":{}}]}`
+
+	t.Run("complete turn is unchanged", func(t *testing.T) {
+		user := `{"role":"user","content":[{"type":"tool_result","tool_use_id":"a","content":"1"},{"type":"tool_result","tool_use_id":"b","content":"2"},{"type":"text","text":"go on"}]}`
+		out := PairClaudeToolResults([][]byte{[]byte(assistant), []byte(user)})
+		if len(out) != 2 || string(out[1]) != user {
+			t.Fatalf("out = %s, want the input unchanged", out)
+		}
+	})
+
+	t.Run("missing result is synthesized", func(t *testing.T) {
+		out := PairClaudeToolResults([][]byte{[]byte(assistant), []byte(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"b","content":"2"}]}`)})
+		blocks := gjson.GetBytes(out[1], "content").Array()
+		if len(blocks) != 2 || blocks[0].Get("tool_use_id").String() != "b" || blocks[1].Get("tool_use_id").String() != "a" || !blocks[1].Get("is_error").Bool() {
+			t.Fatalf("user turn = %s, want b's result then an error result for a", out[1])
+		}
+	})
+
+	t.Run("text-only follow-up gets results first", func(t *testing.T) {
+		out := PairClaudeToolResults([][]byte{[]byte(assistant), []byte(`{"role":"user","content":"never mind"}`)})
+		blocks := gjson.GetBytes(out[1], "content").Array()
+		if len(blocks) != 3 || blocks[0].Get("type").String() != "tool_result" || blocks[1].Get("type").String() != "tool_result" || blocks[2].Get("text").String() != "never mind" {
+			t.Fatalf("user turn = %s, want two error results before the text", out[1])
+		}
+	})
+
+	t.Run("assistant follow-up gets an inserted turn", func(t *testing.T) {
+		out := PairClaudeToolResults([][]byte{[]byte(assistant), []byte(`{"role":"assistant","content":[{"type":"text","text":"again"}]}`)})
+		if len(out) != 3 || gjson.GetBytes(out[1], "role").String() != "user" || gjson.GetBytes(out[1], "content.#").Int() != 2 {
+			t.Fatalf("out = %s, want an inserted user turn with two results", out)
+		}
+	})
+
+	t.Run("results precede text", func(t *testing.T) {
+		out := PairClaudeToolResults([][]byte{[]byte(assistant), []byte(`{"role":"user","content":[{"type":"text","text":"here"},{"type":"tool_result","tool_use_id":"a","content":"1"},{"type":"tool_result","tool_use_id":"b","content":"2"}]}`)})
+		if types := gjson.GetBytes(out[1], "content.#.type").String(); types != `["tool_result","tool_result","text"]` {
+			t.Fatalf("content types = %s, want results first", types)
+		}
+	})
+
+	t.Run("empty id answers first open call", func(t *testing.T) {
+		out := PairClaudeToolResults([][]byte{[]byte(assistant), []byte(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"","content":"1"},{"type":"tool_result","tool_use_id":"b","content":"2"}]}`)})
+		if ids := gjson.GetBytes(out[1], "content.#.tool_use_id").String(); ids != `["a","b"]` {
+			t.Fatalf("tool_use_ids = %s, want [a b]", ids)
+		}
+	})
+
+	t.Run("unknown result becomes text", func(t *testing.T) {
+		out := PairClaudeToolResults([][]byte{[]byte(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"zzz","content":[{"type":"text","text":"stale"}]}]}`)})
+		block := gjson.GetBytes(out[0], "content.0")
+		if block.Get("type").String() != "text" || block.Get("text").String() != "Result of tool call zzz:\nstale" {
+			t.Fatalf("block = %s, want the orphan result as text", block.Raw)
+		}
+	})
+
+	t.Run("final tool uses are left open", func(t *testing.T) {
+		out := PairClaudeToolResults([][]byte{[]byte(`{"role":"user","content":"hi"}`), []byte(assistant)})
+		if len(out) != 2 || string(out[1]) != assistant {
+			t.Fatalf("out = %s, want the trailing assistant turn unchanged", out)
+		}
+	})
+}
+
+func TestPairOpenAIToolMessages(t *testing.T) {
+	assistant := `{"role":"assistant","content":"","tool_calls":[{"id":"a","type":"function","function":{"name":"f","arguments":"{}"}},{"id":"b","type":"function","function":{"name":"f","arguments":"{}"}}]}`
+	roles := func(messages [][]byte) []string {
+		out := make([]string, 0, len(messages))
+		for _, msg := range messages {
+			role := gjson.GetBytes(msg, "role").String()
+			if id := gjson.GetBytes(msg, "tool_call_id").String(); id != "" {
+				role += ":" + id
+			}
+			out = append(out, role)
+		}
+		return out
+	}
+	cases := []struct {
+		name     string
+		messages []string
+		want     []string
+	}{
+		{
+			name:     "complete",
+			messages: []string{assistant, `{"role":"tool","tool_call_id":"a","content":"1"}`, `{"role":"tool","tool_call_id":"b","content":"2"}`, `{"role":"user","content":"thanks"}`},
+			want:     []string{"assistant", "tool:a", "tool:b", "user"},
+		},
+		{
+			name:     "missing before user",
+			messages: []string{assistant, `{"role":"tool","tool_call_id":"b","content":"2"}`, `{"role":"user","content":"thanks"}`},
+			want:     []string{"assistant", "tool:b", "tool:a", "user"},
+		},
+		{
+			name:     "partial at end",
+			messages: []string{assistant, `{"role":"tool","tool_call_id":"a","content":"1"}`},
+			want:     []string{"assistant", "tool:a", "tool:b"},
+		},
+		{
+			name:     "final calls left open",
+			messages: []string{`{"role":"user","content":"hi"}`, assistant},
+			want:     []string{"user", "assistant"},
+		},
+		{
+			name:     "empty id",
+			messages: []string{assistant, `{"role":"tool","tool_call_id":"","content":"1"}`, `{"role":"tool","tool_call_id":"b","content":"2"}`},
+			want:     []string{"assistant", "tool:a", "tool:b"},
+		},
+		{
+			name:     "orphan",
+			messages: []string{`{"role":"user","content":"hi"}`, `{"role":"tool","tool_call_id":"zzz","content":"stale"}`},
+			want:     []string{"user", "user"},
+		},
+		{
+			name:     "duplicate",
+			messages: []string{assistant, `{"role":"tool","tool_call_id":"a","content":"1"}`, `{"role":"tool","tool_call_id":"a","content":"again"}`, `{"role":"tool","tool_call_id":"b","content":"2"}`},
+			want:     []string{"assistant", "tool:a", "tool:b", "user"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			messages := make([][]byte, 0, len(tc.messages))
+			for _, msg := range tc.messages {
+				messages = append(messages, []byte(msg))
+			}
+			got := roles(PairOpenAIToolMessages(messages))
+			if len(got) != len(tc.want) {
+				t.Fatalf("messages = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("messages = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	out := PairOpenAIToolMessages([][]byte{[]byte(`{"role":"user","content":"hi"}`), []byte(`{"role":"tool","tool_call_id":"zzz","content":[{"type":"text","text":"stale"}]}`)})
+	if got := gjson.GetBytes(out[1], "content").String(); got != "Result of tool call zzz:\nstale" {
+		t.Fatalf("orphan content = %q", got)
+	}
+}
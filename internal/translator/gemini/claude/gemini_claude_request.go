@@ -144,18 +144,17 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						}
 
 					case "image":
-						source := contentResult.Get("source")
-						if source.Get("type").String() != "base64" {
+						source, ok := translatorcommon.ImageSourceFromClaude(contentResult.Get("source"))
+						if !ok {
 							return true
 						}
-						mimeType := source.Get("media_type").String()
-						data := source.Get("data").String()
-						if mimeType == "" || data == "" {
+						if source.URL != "" {
+							partItems = append(partItems, source.GeminiFileDataPart())
 							return true
 						}
 						part := []byte(`{"inline_data":{"mime_type":"","data":""}}`)
-						part, _ = sjson.SetBytes(part, "inline_data.mime_type", mimeType)
-						part, _ = sjson.SetBytes(part, "inline_data.data", data)
+						part, _ = sjson.SetBytes(part, "inline_data.mime_type", source.MIMEType)
+						part, _ = sjson.SetBytes(part, "inline_data.data", source.Data)
 						partItems = append(partItems, part)
 					}
 					return true
@@ -302,6 +301,21 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 	if v := gjson.GetBytes(rawJSON, "top_k"); v.Exists() && v.Type == gjson.Number {
 		out, _ = sjson.SetBytes(out, "generationConfig.topK", v.Num)
 	}
+	if v := gjson.GetBytes(rawJSON, "max_tokens"); v.Exists() && v.Type == gjson.Number {
+		out, _ = sjson.SetBytes(out, "generationConfig.maxOutputTokens", v.Num)
+	}
+	if v := gjson.GetBytes(rawJSON, "stop_sequences"); v.IsArray() {
+		var stops []string
+		v.ForEach(func(_, value gjson.Result) bool {
+			if value.Type == gjson.String && value.String() != "" {
+				stops = append(stops, value.String())
+			}
+			return true
+		})
+		if len(stops) > 0 {
+			out, _ = sjson.SetBytes(out, "generationConfig.stopSequences", stops)
+		}
+	}
 
 	result := out
 	result = common.AttachDefaultSafetySettings(result, "safetySettings")
@@ -255,3 +255,21 @@ func TestConvertClaudeRequestToGemini_StringToolResult(t *testing.T) {
 		t.Fatalf("expected result 'alpha', got '%s' (raw=%s)", got, fr.Get("response.result").Raw)
 	}
 }
+
+func TestConvertClaudeRequestToGemini_GenerationLimits(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"max_tokens": 2048,
+		"stop_sequences": ["END", "", "STOP"],
+		"messages": [{"role": "user", "content": "hi"}]
+	}`)
+
+	output := ConvertClaudeRequestToGemini("gemini-2.5-pro", inputJSON, false)
+
+	if got := gjson.GetBytes(output, "generationConfig.maxOutputTokens").Int(); got != 2048 {
+		t.Fatalf("maxOutputTokens = %d, want 2048", got)
+	}
+	if got := gjson.GetBytes(output, "generationConfig.stopSequences").Raw; got != `["END","STOP"]` {
+		t.Fatalf("stopSequences = %s, want the non-empty stop sequences", got)
+	}
+}
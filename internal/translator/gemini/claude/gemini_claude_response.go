@@ -265,7 +265,7 @@ func ConvertGeminiResponseToClaude(_ context.Context, _ string, originalRequestR
 			thoughtsTokenCount := usageResult.Get("thoughtsTokenCount").Int()
 			candidatesTokenCount := usageResult.Get("candidatesTokenCount").Int()
 			template, _ = sjson.SetBytes(template, "usage.output_tokens", candidatesTokenCount+thoughtsTokenCount)
-			template, _ = sjson.SetBytes(template, "usage.input_tokens", usageResult.Get("promptTokenCount").Int())
+			template = setGeminiClaudeInputUsage(template, usageResult)
 
 			appendEvent("message_delta", string(template))
 			(*param).(*Params).HasFinalEvents = true
@@ -296,9 +296,8 @@ func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, origina
 	out, _ = sjson.SetBytes(out, "id", root.Get("responseId").String())
 	out, _ = sjson.SetBytes(out, "model", root.Get("modelVersion").String())
 
-	inputTokens := root.Get("usageMetadata.promptTokenCount").Int()
 	outputTokens := root.Get("usageMetadata.candidatesTokenCount").Int() + root.Get("usageMetadata.thoughtsTokenCount").Int()
-	out, _ = sjson.SetBytes(out, "usage.input_tokens", inputTokens)
+	out = setGeminiClaudeInputUsage(out, root.Get("usageMetadata"))
 	out, _ = sjson.SetBytes(out, "usage.output_tokens", outputTokens)
 
 	parts := root.Get("candidates.0.content.parts")
@@ -383,7 +382,7 @@ func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, origina
 	}
 	out, _ = sjson.SetBytes(out, "stop_reason", stopReason)
 
-	if inputTokens == int64(0) && outputTokens == int64(0) && !root.Get("usageMetadata").Exists() {
+	if root.Get("usageMetadata.promptTokenCount").Int() == 0 && outputTokens == int64(0) && !root.Get("usageMetadata").Exists() {
 		out, _ = sjson.DeleteBytes(out, "usage")
 	}
 
@@ -393,3 +392,15 @@ func ConvertGeminiResponseToClaudeNonStream(_ context.Context, _ string, origina
 func ClaudeTokenCount(ctx context.Context, count int64) []byte {
 	return translatorcommon.ClaudeInputTokensJSON(count)
 }
+
+// setGeminiClaudeInputUsage sets the Claude input usage of a response from Gemini usage
+// metadata. Implicitly cached prompt tokens are reported as cache_read_input_tokens and, as
+// in Claude, excluded from input_tokens.
+func setGeminiClaudeInputUsage(out []byte, usage gjson.Result) []byte {
+	cachedTokens := usage.Get("cachedContentTokenCount").Int()
+	out, _ = sjson.SetBytes(out, "usage.input_tokens", usage.Get("promptTokenCount").Int()-cachedTokens)
+	if cachedTokens > 0 {
+		out, _ = sjson.SetBytes(out, "usage.cache_read_input_tokens", cachedTokens)
+	}
+	return out
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"github.com/tidwall/gjson"
 )
 
 func TestConvertGeminiResponseToClaude_SignatureOnlyPartDoesNotOpenEmptyTextBlock(t *testing.T) {
@@ -60,3 +62,16 @@ func TestConvertGeminiResponseToClaude_SignatureOnlyPartDoesNotOpenEmptyTextBloc
 		t.Fatalf("DONE chunk must still emit message_stop after final events: %s", outputText)
 	}
 }
+
+func TestConvertGeminiResponseToClaudeNonStream_ReportsImplicitCacheHits(t *testing.T) {
+	requestJSON := []byte(`{"model":"gemini-test","messages":[{"role":"user","content":"hi"}]}`)
+	responseJSON := []byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":100,"cachedContentTokenCount":60,"candidatesTokenCount":5,"totalTokenCount":105}}`)
+
+	out := ConvertGeminiResponseToClaudeNonStream(context.Background(), "gemini-test", requestJSON, requestJSON, responseJSON, nil)
+	if got := gjson.GetBytes(out, "usage.input_tokens").Int(); got != 40 {
+		t.Fatalf("input_tokens = %d, want 40; out = %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "usage.cache_read_input_tokens").Int(); got != 60 {
+		t.Fatalf("cache_read_input_tokens = %d, want 60; out = %s", got, out)
+	}
+}
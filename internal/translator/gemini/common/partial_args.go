@@ -0,0 +1,220 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// PartialArgsEncoder rebuilds the JSON arguments of a function call streamed with
+// streamFunctionCallArguments. Gemini sends the arguments as a sequence of partialArgs, each
+// holding a jsonPath and one scalar value; string values may be split across several entries
+// flagged with willContinue. Write returns the JSON text for each entry as soon as it arrives,
+// so clients that concatenate argument fragments (OpenAI tool_calls deltas) see the arguments
+// grow incrementally. The fragments concatenate to a valid JSON object once Close is called.
+type PartialArgsEncoder struct {
+	started bool
+	// path holds the segments leading to the open containers below the root object.
+	path []jsonPathSegment
+	// arrays and counts describe the open containers, root first.
+	arrays []bool
+	counts []int
+	// openString is the path of a string value still awaiting fragments.
+	openString string
+}
+
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Write appends the entries of a partialArgs array and returns the JSON text they add.
+func (e *PartialArgsEncoder) Write(partialArgs gjson.Result) string {
+	var b strings.Builder
+	e.start(&b)
+	partialArgs.ForEach(func(_, arg gjson.Result) bool {
+		e.writeArg(&b, arg)
+		return true
+	})
+	return b.String()
+}
+
+// Close terminates any open string and container and returns the closing JSON text.
+// A call that streamed no arguments yields "{}".
+func (e *PartialArgsEncoder) Close() string {
+	var b strings.Builder
+	e.start(&b)
+	if e.openString != "" {
+		b.WriteByte('"')
+		e.openString = ""
+	}
+	for len(e.path) > 0 {
+		e.closeContainer(&b)
+	}
+	b.WriteByte('}')
+	e.arrays, e.counts = nil, nil
+	return b.String()
+}
+
+func (e *PartialArgsEncoder) start(b *strings.Builder) {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.arrays = []bool{false}
+	e.counts = []int{0}
+	b.WriteByte('{')
+}
+
+func (e *PartialArgsEncoder) writeArg(b *strings.Builder, arg gjson.Result) {
+	rawPath := arg.Get("jsonPath").String()
+	segments := parseJSONPath(rawPath)
+	if len(segments) == 0 || len(e.arrays) == 0 {
+		return
+	}
+	willContinue := arg.Get("willContinue").Bool()
+	stringValue := arg.Get("stringValue")
+
+	if e.openString != "" {
+		if rawPath == e.openString && stringValue.Exists() {
+			b.WriteString(escapeJSONString(stringValue.String()))
+			if !willContinue {
+				b.WriteByte('"')
+				e.openString = ""
+			}
+			return
+		}
+		b.WriteByte('"')
+		e.openString = ""
+	}
+
+	parent, leaf := segments[:len(segments)-1], segments[len(segments)-1]
+	common := 0
+	for common < len(e.path) && common < len(parent) && e.path[common] == parent[common] {
+		common++
+	}
+	for len(e.path) > common {
+		e.closeContainer(b)
+	}
+	for i := common; i < len(parent); i++ {
+		e.writeMember(b, parent[i])
+		next := leaf
+		if i+1 < len(parent) {
+			next = parent[i+1]
+		}
+		if next.isIndex {
+			b.WriteByte('[')
+		} else {
+			b.WriteByte('{')
+		}
+		e.path = append(e.path, parent[i])
+		e.arrays = append(e.arrays, next.isIndex)
+		e.counts = append(e.counts, 0)
+	}
+	e.writeMember(b, leaf)
+
+	switch {
+	case stringValue.Exists():
+		b.WriteByte('"')
+		b.WriteString(escapeJSONString(stringValue.String()))
+		if willContinue {
+			e.openString = rawPath
+		} else {
+			b.WriteByte('"')
+		}
+	case arg.Get("numberValue").Exists():
+		b.WriteString(strconv.FormatFloat(arg.Get("numberValue").Float(), 'f', -1, 64))
+	case arg.Get("boolValue").Exists():
+		b.WriteString(strconv.FormatBool(arg.Get("boolValue").Bool()))
+	default:
+		b.WriteString("null")
+	}
+}
+
+// writeMember writes the separator and, inside objects, the key of the next member of the
+// innermost open container.
+func (e *PartialArgsEncoder) writeMember(b *strings.Builder, segment jsonPathSegment) {
+	level := len(e.counts) - 1
+	if e.counts[level] > 0 {
+		b.WriteByte(',')
+	}
+	e.counts[level]++
+	if !e.arrays[level] {
+		b.WriteByte('"')
+		b.WriteString(escapeJSONString(segment.key))
+		b.WriteString(`":`)
+	}
+}
+
+func (e *PartialArgsEncoder) closeContainer(b *strings.Builder) {
+	level := len(e.arrays) - 1
+	if e.arrays[level] {
+		b.WriteByte(']')
+	} else {
+		b.WriteByte('}')
+	}
+	e.path = e.path[:len(e.path)-1]
+	e.arrays = e.arrays[:level]
+	e.counts = e.counts[:level]
+}
+
+// parseJSONPath splits a path such as $.items[0].name or $['a b'] into segments.
+func parseJSONPath(path string) []jsonPathSegment {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segments []jsonPathSegment
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			if end > 0 {
+				segments = append(segments, jsonPathSegment{key: path[:end]})
+			}
+			path = path[end:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return segments
+			}
+			inner := path[1:end]
+			path = path[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, errParse := strconv.Atoi(inner)
+			if errParse != nil {
+				segments = append(segments, jsonPathSegment{key: inner})
+				continue
+			}
+			segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, jsonPathSegment{key: path[:end]})
+			path = path[end:]
+		}
+	}
+	return segments
+}
+
+// escapeJSONString returns s escaped for use inside a JSON string, without the quotes.
+func escapeJSONString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if errEncode := encoder.Encode(s); errEncode != nil {
+		return ""
+	}
+	encoded := bytes.TrimSpace(buf.Bytes())
+	return string(encoded[1 : len(encoded)-1])
+}
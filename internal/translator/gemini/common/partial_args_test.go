@@ -0,0 +1,47 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestPartialArgsEncoderBuildsArguments(t *testing.T) {
+	var encoder PartialArgsEncoder
+	chunks := []string{
+		`[{"jsonPath":"$.location","stringValue":"Bos","willContinue":true}]`,
+		`[{"jsonPath":"$.location","stringValue":"ton \"MA\""}]`,
+		`[{"jsonPath":"$.days","numberValue":3},{"jsonPath":"$.options.metric","boolValue":true}]`,
+		`[{"jsonPath":"$.options.tags[0]","stringValue":"a"},{"jsonPath":"$.options.tags[1]","stringValue":"b"}]`,
+		`[{"jsonPath":"$.items[0].name","stringValue":"x"},{"jsonPath":"$.items[1].name","stringValue":"y"},{"jsonPath":"$['odd key']","nullValue":null}]`,
+	}
+	var out string
+	for _, chunk := range chunks {
+		out += encoder.Write(gjson.Parse(chunk))
+	}
+	out += encoder.Close()
+
+	var got map[string]any
+	if errUnmarshal := json.Unmarshal([]byte(out), &got); errUnmarshal != nil {
+		t.Fatalf("arguments %q are not valid JSON: %v", out, errUnmarshal)
+	}
+	want := map[string]any{
+		"location": `Boston "MA"`,
+		"days":     float64(3),
+		"options":  map[string]any{"metric": true, "tags": []any{"a", "b"}},
+		"items":    []any{map[string]any{"name": "x"}, map[string]any{"name": "y"}},
+		"odd key":  nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("arguments = %s, want %v", out, want)
+	}
+}
+
+func TestPartialArgsEncoderWithoutArguments(t *testing.T) {
+	var encoder PartialArgsEncoder
+	if out := encoder.Write(gjson.Result{}) + encoder.Close(); out != "{}" {
+		t.Fatalf("arguments = %q, want {}", out)
+	}
+}
@@ -109,6 +109,9 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// Structured output: response_format -> responseMimeType/responseJsonSchema
+	out = translatorcommon.ApplyOpenAIResponseFormatToGemini(out, gjson.GetBytes(rawJSON, "response_format"), "generationConfig")
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -179,11 +182,11 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 								partItems = append(partItems, geminiTextPart(text))
 							}
 						case "image_url":
-							imageURL := item.Get("image_url.url").String()
-							if len(imageURL) > 5 {
-								pieces := strings.SplitN(imageURL[5:], ";", 2)
-								if len(pieces) == 2 && len(pieces[1]) > 7 {
-									partItems = append(partItems, geminiInlineDataPart(pieces[0], pieces[1][7:], geminiFunctionThoughtSignature))
+							if source, ok := translatorcommon.ImageSourceFromURL(item.Get("image_url.url").String()); ok {
+								if source.URL != "" {
+									partItems = append(partItems, source.GeminiFileDataPart())
+								} else {
+									partItems = append(partItems, geminiInlineDataPart(source.MIMEType, source.Data, geminiFunctionThoughtSignature))
 								}
 							}
 						case "video_url":
@@ -231,11 +234,11 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 								partItems = append(partItems, geminiTextPart(text))
 							}
 						case "image_url":
-							imageURL := item.Get("image_url.url").String()
-							if len(imageURL) > 5 {
-								pieces := strings.SplitN(imageURL[5:], ";", 2)
-								if len(pieces) == 2 && len(pieces[1]) > 7 {
-									partItems = append(partItems, geminiInlineDataPart(pieces[0], pieces[1][7:], geminiFunctionThoughtSignature))
+							if source, ok := translatorcommon.ImageSourceFromURL(item.Get("image_url.url").String()); ok {
+								if source.URL != "" {
+									partItems = append(partItems, source.GeminiFileDataPart())
+								} else {
+									partItems = append(partItems, geminiInlineDataPart(source.MIMEType, source.Data, geminiFunctionThoughtSignature))
 								}
 							}
 						}
@@ -299,3 +299,27 @@ func TestConvertOpenAIRequestToGeminiCleansToolSchemaRequiredFields(t *testing.T
 		t.Fatalf("required[1] = %q, want industry. Schema: %s", got, schema.Raw)
 	}
 }
+
+func TestConvertOpenAIRequestToGeminiMapsResponseFormat(t *testing.T) {
+	body := `{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"answer","schema":{"type":"object","properties":{"answer":{"type":"string"}},"required":["answer"]}}}}`
+
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(body), false)
+
+	if got := gjson.GetBytes(out, "generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("generationConfig.responseMimeType = %q, want application/json. Output: %s", got, out)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.responseJsonSchema.required.0").String(); got != "answer" {
+		t.Fatalf("generationConfig.responseJsonSchema not mapped. Output: %s", out)
+	}
+}
+
+func TestConvertOpenAIRequestToGeminiKeepsRemoteImageURL(t *testing.T) {
+	body := `{"model":"gemini-2.5-pro","messages":[{"role":"user","content":[{"type":"text","text":"describe"},{"type":"image_url","image_url":{"url":"https://example.com/cat.png"}}]}]}`
+
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(body), false)
+
+	part := gjson.GetBytes(out, "contents.0.parts.1")
+	if part.Get("fileData.fileUri").String() != "https://example.com/cat.png" || part.Get("fileData.mimeType").String() != "image/png" {
+		t.Fatalf("remote image part = %s, want fileData. Output: %s", part.Raw, out)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -27,6 +29,15 @@ type convertGeminiResponseToOpenAIChatParams struct {
 	SawToolCall          map[int]bool
 	UpstreamFinishReason map[int]string
 	SanitizedNameMap     map[string]string
+	// StreamingCalls tracks, per candidate index, the function call whose arguments are still
+	// being streamed as partialArgs.
+	StreamingCalls map[int]*streamingToolCall
+}
+
+// streamingToolCall is a function call streamed with streamFunctionCallArguments.
+type streamingToolCall struct {
+	index int
+	args  common.PartialArgsEncoder
 }
 
 // functionCallIDCounter provides a process-wide unique counter for function call identifiers.
@@ -55,6 +66,7 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 			SawToolCall:          make(map[int]bool),
 			UpstreamFinishReason: make(map[int]string),
 			SanitizedNameMap:     util.SanitizedToolNameMap(originalRequestRawJSON),
+			StreamingCalls:       make(map[int]*streamingToolCall),
 		}
 	}
 
@@ -72,6 +84,9 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	if p.SanitizedNameMap == nil {
 		p.SanitizedNameMap = util.SanitizedToolNameMap(originalRequestRawJSON)
 	}
+	if p.StreamingCalls == nil {
+		p.StreamingCalls = make(map[int]*streamingToolCall)
+	}
 
 	if bytes.HasPrefix(rawJSON, []byte("data:")) {
 		rawJSON = bytes.TrimSpace(rawJSON[5:])
@@ -194,6 +209,32 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 					} else if functionCallResult.Exists() {
 						// Handle function call content.
 						p.SawToolCall[candidateIndex] = true
+						fcNameResult := functionCallResult.Get("name")
+						partialArgsResult := functionCallResult.Get("partialArgs")
+						willContinue := functionCallResult.Get("willContinue").Bool()
+
+						// Arguments of a call streamed with streamFunctionCallArguments arrive in
+						// later parts without a name; forward them as arguments deltas of the open call.
+						if open := p.StreamingCalls[candidateIndex]; open != nil {
+							fragment := ""
+							if fcNameResult.String() == "" {
+								fragment = open.args.Write(partialArgsResult)
+							}
+							if fcNameResult.String() != "" || !willContinue {
+								fragment += open.args.Close()
+								delete(p.StreamingCalls, candidateIndex)
+							}
+							if fragment != "" {
+								template = appendToolCallArgumentsDelta(template, open.index, fragment)
+							}
+							if fcNameResult.String() == "" {
+								continue
+							}
+						} else if fcNameResult.String() == "" && !functionCallResult.Get("args").Exists() {
+							// A stray terminator of a streamed call that was already closed.
+							continue
+						}
+
 						toolCallsResult := gjson.GetBytes(template, "choices.0.delta.tool_calls")
 
 						// Retrieve the function index for this specific candidate.
@@ -213,6 +254,15 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 						functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.name", fcName)
 						if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
 							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.arguments", fcArgsResult.Raw)
+						} else if willContinue || partialArgsResult.Exists() {
+							call := &streamingToolCall{index: functionCallIndex}
+							arguments := call.args.Write(partialArgsResult)
+							if willContinue {
+								p.StreamingCalls[candidateIndex] = call
+							} else {
+								arguments += call.args.Close()
+							}
+							functionCallTemplate, _ = sjson.SetBytes(functionCallTemplate, "function.arguments", arguments)
 						}
 						setAssistantRole()
 						template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls.-1", functionCallTemplate)
@@ -274,6 +324,18 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	return responseStrings
 }
 
+// appendToolCallArgumentsDelta appends an arguments fragment for the tool call at index.
+func appendToolCallArgumentsDelta(template []byte, index int, fragment string) []byte {
+	if !gjson.GetBytes(template, "choices.0.delta.tool_calls").IsArray() {
+		template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls", []byte(`[]`))
+	}
+	delta := []byte(`{"index":0,"function":{"arguments":""}}`)
+	delta, _ = sjson.SetBytes(delta, "index", index)
+	delta, _ = sjson.SetBytes(delta, "function.arguments", fragment)
+	template, _ = sjson.SetRawBytes(template, "choices.0.delta.tool_calls.-1", delta)
+	return template
+}
+
 // ConvertGeminiResponseToOpenAINonStream converts a non-streaming Gemini response to a non-streaming OpenAI response.
 // This function processes the complete Gemini response and transforms it into a single OpenAI-compatible
 // JSON response. It handles message content, tool calls, reasoning content, and usage metadata, combining all
@@ -424,5 +486,5 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 		})
 	}
 
-	return template
+	return translatorcommon.RepairOpenAIChatStructuredOutput(originalRequestRawJSON, template)
 }
@@ -38,3 +38,49 @@ func TestGeminiFinishReasonOnlyOnFinalChunk(t *testing.T) {
 		t.Fatalf("expected native_finish_reason stop, got %s", nfr3)
 	}
 }
+
+func TestGeminiStreamedFunctionCallArgumentsBecomeDeltas(t *testing.T) {
+	ctx := context.Background()
+	var param any
+	chunks := [][]byte{
+		[]byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"get_weather","partialArgs":[{"jsonPath":"$.city","stringValue":"Par","willContinue":true}],"willContinue":true}}]}}]}`),
+		[]byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"partialArgs":[{"jsonPath":"$.city","stringValue":"is"}],"willContinue":true}}]}}]}`),
+		[]byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"partialArgs":[{"jsonPath":"$.days","numberValue":2}],"willContinue":true}}]}}]}`),
+		[]byte(`{"candidates":[{"content":{"parts":[{"functionCall":{}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":1,"totalTokenCount":2}}`),
+	}
+
+	var arguments string
+	var finishReason string
+	for i, chunk := range chunks {
+		results := ConvertGeminiResponseToOpenAI(ctx, "model", nil, nil, chunk, &param)
+		if len(results) != 1 {
+			t.Fatalf("chunk %d: expected 1 result, got %d", i, len(results))
+		}
+		toolCalls := gjson.GetBytes(results[0], "choices.0.delta.tool_calls").Array()
+		if len(toolCalls) != 1 {
+			t.Fatalf("chunk %d: expected 1 tool call delta, got %s", i, results[0])
+		}
+		if index := toolCalls[0].Get("index").Int(); index != 0 {
+			t.Fatalf("chunk %d: tool call index = %d, want 0", i, index)
+		}
+		if i == 0 {
+			if name := toolCalls[0].Get("function.name").String(); name != "get_weather" {
+				t.Fatalf("first delta name = %q, want get_weather", name)
+			}
+			if toolCalls[0].Get("id").String() == "" {
+				t.Fatal("first delta has no id")
+			}
+		} else if toolCalls[0].Get("id").Exists() {
+			t.Fatalf("chunk %d: continuation delta repeats the id: %s", i, results[0])
+		}
+		arguments += toolCalls[0].Get("function.arguments").String()
+		finishReason = gjson.GetBytes(results[0], "choices.0.finish_reason").String()
+	}
+
+	if arguments != `{"city":"Paris","days":2}` {
+		t.Fatalf("arguments = %s, want {\"city\":\"Paris\",\"days\":2}", arguments)
+	}
+	if finishReason != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", finishReason)
+	}
+}
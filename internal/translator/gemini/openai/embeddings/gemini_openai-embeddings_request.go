@@ -0,0 +1,48 @@
+// Package embeddings converts OpenAI /v1/embeddings requests to Gemini batchEmbedContents
+// requests and converts the Gemini response back. Embeddings are not a chat format, so these
+// functions are called directly by the executor instead of being registered as translators.
+package embeddings
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingsRequestToGemini builds a batchEmbedContents body from an OpenAI
+// embeddings request. The "input" field may be a string or an array of strings; token arrays
+// are rejected because Gemini only embeds text.
+func ConvertOpenAIEmbeddingsRequestToGemini(modelName string, inputRawJSON []byte) ([]byte, error) {
+	input := gjson.GetBytes(inputRawJSON, "input")
+	var texts []string
+	switch {
+	case input.Type == gjson.String:
+		texts = append(texts, input.String())
+	case input.IsArray():
+		for _, item := range input.Array() {
+			if item.Type != gjson.String {
+				return nil, fmt.Errorf("input must be a string or an array of strings")
+			}
+			texts = append(texts, item.String())
+		}
+	default:
+		return nil, fmt.Errorf("input must be a string or an array of strings")
+	}
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("input must not be empty")
+	}
+
+	dimensions := gjson.GetBytes(inputRawJSON, "dimensions").Int()
+	out := []byte(`{"requests":[]}`)
+	for _, text := range texts {
+		request := []byte(`{}`)
+		request, _ = sjson.SetBytes(request, "model", "models/"+modelName)
+		request, _ = sjson.SetBytes(request, "content.parts.0.text", text)
+		if dimensions > 0 {
+			request, _ = sjson.SetBytes(request, "outputDimensionality", dimensions)
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", request)
+	}
+	return out, nil
+}
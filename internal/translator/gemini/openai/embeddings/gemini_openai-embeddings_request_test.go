@@ -0,0 +1,39 @@
+package embeddings
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIEmbeddingsRequestToGemini_StringInput(t *testing.T) {
+	out, err := ConvertOpenAIEmbeddingsRequestToGemini("gemini-embedding-001", []byte(`{"model":"gemini-embedding-001","input":"hello"}`))
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if got := gjson.GetBytes(out, "requests.#").Int(); got != 1 {
+		t.Fatalf("requests = %d, want 1", got)
+	}
+	if got := gjson.GetBytes(out, "requests.0.model").String(); got != "models/gemini-embedding-001" {
+		t.Fatalf("model = %q", got)
+	}
+	if gjson.GetBytes(out, "requests.0.outputDimensionality").Exists() {
+		t.Fatalf("outputDimensionality set without dimensions: %s", out)
+	}
+}
+
+func TestConvertOpenAIEmbeddingsRequestToGemini_RejectsTokenInput(t *testing.T) {
+	for _, body := range []string{`{"input":[1,2,3]}`, `{"input":[]}`, `{"model":"m"}`} {
+		if _, err := ConvertOpenAIEmbeddingsRequestToGemini("m", []byte(body)); err == nil {
+			t.Fatalf("body %s accepted", body)
+		}
+	}
+}
+
+func TestConvertGeminiEmbeddingsResponseToOpenAI_Base64(t *testing.T) {
+	out := ConvertGeminiEmbeddingsResponseToOpenAI("m", []byte(`{"encoding_format":"base64"}`), []byte(`{"embeddings":[{"values":[1.0]}]}`))
+	// 1.0 as little-endian float32 is 00 00 80 3f.
+	if got := gjson.GetBytes(out, "data.0.embedding").String(); got != "AACAPw==" {
+		t.Fatalf("embedding = %q, want AACAPw==", got)
+	}
+}
@@ -0,0 +1,42 @@
+package embeddings
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingsResponseToOpenAI converts a batchEmbedContents response to an OpenAI
+// embeddings list. Vectors are base64-encoded little-endian float32 values when the original
+// request asked for encoding_format "base64".
+func ConvertGeminiEmbeddingsResponseToOpenAI(modelName string, originalRequestRawJSON, rawJSON []byte) []byte {
+	base64Encoding := gjson.GetBytes(originalRequestRawJSON, "encoding_format").String() == "base64"
+	out := []byte(`{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	for index, embedding := range gjson.GetBytes(rawJSON, "embeddings").Array() {
+		item := []byte(`{"object":"embedding","index":0}`)
+		item, _ = sjson.SetBytes(item, "index", index)
+		values := embedding.Get("values")
+		if base64Encoding {
+			item, _ = sjson.SetBytes(item, "embedding", encodeEmbeddingBase64(values))
+		} else if values.IsArray() {
+			item, _ = sjson.SetRawBytes(item, "embedding", []byte(values.Raw))
+		} else {
+			item, _ = sjson.SetRawBytes(item, "embedding", []byte(`[]`))
+		}
+		out, _ = sjson.SetRawBytes(out, "data.-1", item)
+	}
+	return out
+}
+
+func encodeEmbeddingBase64(values gjson.Result) string {
+	floats := values.Array()
+	buf := make([]byte, 4*len(floats))
+	for i, value := range floats {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(value.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,84 @@
+// Package images converts OpenAI /v1/images/generations requests to Gemini Imagen predict
+// requests and converts the Imagen response back. Image generation is not a chat format, so
+// these functions are called directly by the executor instead of being registered as translators.
+package images
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxImagenSampleCount is the largest number of images Imagen returns for one prompt.
+const maxImagenSampleCount = 4
+
+// imagenAspectRatios are the aspect ratios Imagen accepts, with their width/height value.
+var imagenAspectRatios = []struct {
+	name  string
+	ratio float64
+}{
+	{"1:1", 1},
+	{"3:4", 3.0 / 4.0},
+	{"4:3", 4.0 / 3.0},
+	{"9:16", 9.0 / 16.0},
+	{"16:9", 16.0 / 9.0},
+}
+
+// ConvertOpenAIImagesRequestToImagen builds a predict body from an OpenAI image generation
+// request. size is mapped to the closest supported aspect ratio, n to sampleCount,
+// output_format to the output MIME type and quality "hd"/"high" to 2K output.
+func ConvertOpenAIImagesRequestToImagen(inputRawJSON []byte) ([]byte, error) {
+	prompt := strings.TrimSpace(gjson.GetBytes(inputRawJSON, "prompt").String())
+	if prompt == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+
+	out := []byte(`{"instances":[{"prompt":""}],"parameters":{"sampleCount":1}}`)
+	out, _ = sjson.SetBytes(out, "instances.0.prompt", prompt)
+	if n := gjson.GetBytes(inputRawJSON, "n").Int(); n > 1 {
+		out, _ = sjson.SetBytes(out, "parameters.sampleCount", min(n, maxImagenSampleCount))
+	}
+	if size := strings.TrimSpace(gjson.GetBytes(inputRawJSON, "size").String()); size != "" && size != "auto" {
+		aspectRatio, ok := imagenAspectRatio(size)
+		if !ok {
+			return nil, fmt.Errorf("size %q is not supported", size)
+		}
+		out, _ = sjson.SetBytes(out, "parameters.aspectRatio", aspectRatio)
+	}
+	switch strings.ToLower(strings.TrimSpace(gjson.GetBytes(inputRawJSON, "output_format").String())) {
+	case "jpeg", "jpg":
+		out, _ = sjson.SetBytes(out, "parameters.outputOptions.mimeType", "image/jpeg")
+	case "png":
+		out, _ = sjson.SetBytes(out, "parameters.outputOptions.mimeType", "image/png")
+	}
+	switch strings.ToLower(strings.TrimSpace(gjson.GetBytes(inputRawJSON, "quality").String())) {
+	case "hd", "high":
+		out, _ = sjson.SetBytes(out, "parameters.sampleImageSize", "2K")
+	}
+	return out, nil
+}
+
+// imagenAspectRatio maps an OpenAI "WIDTHxHEIGHT" size to the closest Imagen aspect ratio.
+func imagenAspectRatio(size string) (string, bool) {
+	widthRaw, heightRaw, ok := strings.Cut(strings.ToLower(size), "x")
+	if !ok {
+		return "", false
+	}
+	width, errWidth := strconv.Atoi(strings.TrimSpace(widthRaw))
+	height, errHeight := strconv.Atoi(strings.TrimSpace(heightRaw))
+	if errWidth != nil || errHeight != nil || width <= 0 || height <= 0 {
+		return "", false
+	}
+	target := float64(width) / float64(height)
+	best := imagenAspectRatios[0]
+	for _, candidate := range imagenAspectRatios[1:] {
+		if math.Abs(math.Log(candidate.ratio/target)) < math.Abs(math.Log(best.ratio/target)) {
+			best = candidate
+		}
+	}
+	return best.name, true
+}
@@ -0,0 +1,53 @@
+package images
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIImagesRequestToImagen_MapsParameters(t *testing.T) {
+	out, err := ConvertOpenAIImagesRequestToImagen([]byte(`{"prompt":"a cat","n":6,"size":"1792x1024","output_format":"jpeg","quality":"hd"}`))
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if got := gjson.GetBytes(out, "instances.0.prompt").String(); got != "a cat" {
+		t.Fatalf("prompt = %q", got)
+	}
+	if got := gjson.GetBytes(out, "parameters.sampleCount").Int(); got != maxImagenSampleCount {
+		t.Fatalf("sampleCount = %d, want %d", got, maxImagenSampleCount)
+	}
+	if got := gjson.GetBytes(out, "parameters.aspectRatio").String(); got != "16:9" {
+		t.Fatalf("aspectRatio = %q, want 16:9", got)
+	}
+	if got := gjson.GetBytes(out, "parameters.outputOptions.mimeType").String(); got != "image/jpeg" {
+		t.Fatalf("mimeType = %q", got)
+	}
+	if got := gjson.GetBytes(out, "parameters.sampleImageSize").String(); got != "2K" {
+		t.Fatalf("sampleImageSize = %q", got)
+	}
+}
+
+func TestConvertOpenAIImagesRequestToImagen_RejectsInvalidRequests(t *testing.T) {
+	for _, body := range []string{`{"prompt":""}`, `{"prompt":"x","size":"large"}`} {
+		if _, err := ConvertOpenAIImagesRequestToImagen([]byte(body)); err == nil {
+			t.Fatalf("body %s accepted", body)
+		}
+	}
+}
+
+func TestConvertImagenResponseToOpenAI_URLFormat(t *testing.T) {
+	raw := []byte(`{"predictions":[{"bytesBase64Encoded":"aGk=","mimeType":"image/jpeg"},{"raiFilteredReason":"blocked"}]}`)
+	out := ConvertImagenResponseToOpenAI([]byte(`{"response_format":"url"}`), raw)
+	if got := gjson.GetBytes(out, "data.#").Int(); got != 1 {
+		t.Fatalf("data = %d, want 1", got)
+	}
+	if got := gjson.GetBytes(out, "data.0.url").String(); got != "data:image/jpeg;base64,aGk=" {
+		t.Fatalf("url = %q", got)
+	}
+
+	out = ConvertImagenResponseToOpenAI([]byte(`{}`), raw)
+	if got := gjson.GetBytes(out, "data.0.b64_json").String(); got != "aGk=" {
+		t.Fatalf("b64_json = %q", got)
+	}
+}
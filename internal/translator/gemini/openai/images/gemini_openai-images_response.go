@@ -0,0 +1,38 @@
+package images
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertImagenResponseToOpenAI converts an Imagen predict response to an OpenAI images
+// response. Imagen only returns inline bytes, so response_format "url" yields data URLs.
+func ConvertImagenResponseToOpenAI(originalRequestRawJSON, rawJSON []byte) []byte {
+	asURL := strings.EqualFold(strings.TrimSpace(gjson.GetBytes(originalRequestRawJSON, "response_format").String()), "url")
+	out := []byte(`{"created":0,"data":[]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	for _, prediction := range gjson.GetBytes(rawJSON, "predictions").Array() {
+		encoded := prediction.Get("bytesBase64Encoded").String()
+		if encoded == "" {
+			continue
+		}
+		item := []byte(`{}`)
+		if asURL {
+			mimeType := prediction.Get("mimeType").String()
+			if mimeType == "" {
+				mimeType = "image/png"
+			}
+			item, _ = sjson.SetBytes(item, "url", "data:"+mimeType+";base64,"+encoded)
+		} else {
+			item, _ = sjson.SetBytes(item, "b64_json", encoded)
+		}
+		if revised := prediction.Get("prompt").String(); revised != "" {
+			item, _ = sjson.SetBytes(item, "revised_prompt", revised)
+		}
+		out, _ = sjson.SetRawBytes(out, "data.-1", item)
+	}
+	return out
+}
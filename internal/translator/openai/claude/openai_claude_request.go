@@ -158,7 +158,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 			if contentResult.Exists() && contentResult.IsArray() {
 				contentItems := make([][]byte, 0)
 				var reasoningParts []string // Accumulate thinking text for reasoning_content
-				var toolCalls []interface{}
+				toolCalls := make([][]byte, 0)
 				toolResults := make([][]byte, 0) // Collect tool_result messages to emit after the main message
 
 				contentResult.ForEach(func(_, part gjson.Result) bool {
@@ -190,18 +190,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 					case "tool_use":
 						// Only allow tool_use -> tool_calls for assistant messages (security: prevent injection).
 						if role == "assistant" {
-							toolCallJSON := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
-							toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "id", part.Get("id").String())
-							toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.name", part.Get("name").String())
-
-							// Convert input to arguments JSON string
-							if input := part.Get("input"); input.Exists() {
-								toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.arguments", input.Raw)
-							} else {
-								toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.arguments", "{}")
-							}
-
-							toolCalls = append(toolCalls, gjson.ParseBytes(toolCallJSON).Value())
+							toolCalls = append(toolCalls, translatorcommon.OpenAIToolCallFromClaudeToolUse(part))
 						}
 
 					case "tool_result":
@@ -256,7 +245,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 
 						// Add tool_calls if present (in same message as content)
 						if hasToolCalls {
-							msgJSON, _ = sjson.SetBytes(msgJSON, "tool_calls", toolCalls)
+							msgJSON, _ = sjson.SetRawBytes(msgJSON, "tool_calls", translatorcommon.JoinRawArray(toolCalls))
 						}
 
 						messageItems = append(messageItems, msgJSON)
@@ -287,7 +276,8 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 		})
 	}
 
-	// Set messages.
+	// Set messages, answering every tool call exactly once as OpenAI requires.
+	messageItems = translatorcommon.PairOpenAIToolMessages(messageItems)
 	if len(messageItems) > 0 {
 		out = translatorcommon.SetRawArrayItems(out, "messages", messageItems)
 	}
@@ -296,6 +286,11 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() {
 		var toolItems [][]byte
 		tools.ForEach(func(_, tool gjson.Result) bool {
+			// Anthropic server tools (web search, code execution, ...) run on Anthropic's side and
+			// have no schema an OpenAI backend could call them with.
+			if toolType := tool.Get("type").String(); toolType != "" && toolType != "custom" && !tool.Get("input_schema").Exists() {
+				return true
+			}
 			openAIToolJSON := []byte(`{"type":"function","function":{"name":"","description":""}}`)
 			openAIToolJSON, _ = sjson.SetBytes(openAIToolJSON, "function.name", tool.Get("name").String())
 			openAIToolJSON, _ = sjson.SetBytes(openAIToolJSON, "function.description", tool.Get("description").String())
@@ -315,21 +310,10 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Tool choice mapping - convert Anthropic tool_choice to OpenAI format
-	if toolChoice := root.Get("tool_choice"); toolChoice.Exists() {
-		switch toolChoice.Get("type").String() {
-		case "auto":
-			out, _ = sjson.SetBytes(out, "tool_choice", "auto")
-		case "any":
-			out, _ = sjson.SetBytes(out, "tool_choice", "required")
-		case "tool":
-			// Specific tool choice
-			toolName := toolChoice.Get("name").String()
-			toolChoiceJSON := []byte(`{"type":"function","function":{"name":""}}`)
-			toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "function.name", toolName)
-			out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoiceJSON)
-		default:
-			// Default to auto if not specified
-			out, _ = sjson.SetBytes(out, "tool_choice", "auto")
+	if toolChoice, disableParallel := translatorcommon.OpenAIToolChoiceFromClaude(root.Get("tool_choice")); toolChoice != nil {
+		out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoice)
+		if disableParallel {
+			out, _ = sjson.SetBytes(out, "parallel_tool_calls", false)
 		}
 	}
 
@@ -540,6 +540,24 @@ func TestConvertClaudeRequestToOpenAI_ToolSchemaAddsMissingObjectProperties(t *t
 	}
 }
 
+func TestConvertClaudeRequestToOpenAI_SkipsAnthropicServerTools(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "claude-sonnet-4-5",
+		"tools": [
+			{"type": "web_search_20250305", "name": "web_search", "max_uses": 5},
+			{"type": "custom", "name": "Read", "input_schema": {"type": "object", "properties": {"path": {"type": "string"}}}},
+			{"name": "Bash", "input_schema": {"type": "object", "properties": {"command": {"type": "string"}}}}
+		],
+		"messages": [{"role": "user", "content": "hello"}]
+	}`)
+
+	output := ConvertClaudeRequestToOpenAI("gpt-5", inputJSON, false)
+	tools := gjson.GetBytes(output, "tools").Array()
+	if len(tools) != 2 || tools[0].Get("function.name").String() != "Read" || tools[1].Get("function.name").String() != "Bash" {
+		t.Fatalf("tools = %s, want Read and Bash only", gjson.GetBytes(output, "tools").Raw)
+	}
+}
+
 func TestConvertClaudeRequestToOpenAI_ToolResultOrderAndContent(t *testing.T) {
 	inputJSON := `{
 		"model": "claude-3-opus",
@@ -100,6 +100,11 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 			}
 		}
 
+		// JSON mode and response schemas -> response_format
+		if responseFormat := translatorcommon.OpenAIResponseFormatFromGemini(genConfig); responseFormat != nil {
+			out, _ = sjson.SetRawBytes(out, "response_format", responseFormat)
+		}
+
 		// Map Gemini thinkingConfig to OpenAI reasoning_effort.
 		// Always perform conversion to support allowCompat models that may not be in registry.
 		// Note: Google official Python SDK sends snake_case fields (thinking_level/thinking_budget).
@@ -169,3 +169,20 @@ func TestConvertGeminiRequestToOpenAI_SplitsNonImageInlineDataByMIME(t *testing.
 		t.Fatalf("non-image inlineData must not be converted to image_url. Output: %s", string(out))
 	}
 }
+
+func TestConvertGeminiRequestToOpenAI_ResponseSchemaToResponseFormat(t *testing.T) {
+	raw := []byte(`{
+		"generationConfig": {
+			"responseMimeType": "application/json",
+			"responseJsonSchema": {"type": "object", "properties": {"answer": {"type": "string"}}}
+		},
+		"contents": [{"role": "user", "parts": [{"text": "hi"}]}]
+	}`)
+
+	out := ConvertGeminiRequestToOpenAI("gpt-5", raw, false)
+
+	format := gjson.GetBytes(out, "response_format")
+	if format.Get("type").String() != "json_schema" || format.Get("json_schema.name").String() == "" || format.Get("json_schema.schema.properties.answer.type").String() != "string" {
+		t.Fatalf("response_format = %s", format.Raw)
+	}
+}
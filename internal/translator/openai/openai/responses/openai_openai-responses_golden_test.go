@@ -0,0 +1,211 @@
+package responses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// streamedOutputItem mirrors the per-item snapshot kept by the official SDK stream accumulator.
+type streamedOutputItem struct {
+	id        string
+	itemType  string
+	parts     []string
+	arguments string
+	summary   string
+}
+
+// validateResponsesEventStream replays events the way the official SDK accumulates a
+// Responses stream and fails on anything its parser would reject or misattribute.
+func validateResponsesEventStream(t *testing.T, events []gjson.Result) {
+	t.Helper()
+
+	if len(events) == 0 {
+		t.Fatal("empty event stream")
+	}
+	if got := events[0].Get("type").String(); got != "response.created" {
+		t.Fatalf("first event = %s, want response.created", got)
+	}
+	if got := events[len(events)-1].Get("type").String(); got != "response.completed" {
+		t.Fatalf("last event = %s, want response.completed", got)
+	}
+	responseID := events[0].Get("response.id").String()
+	if responseID == "" {
+		t.Fatal("response.created missing response.id")
+	}
+
+	var output []*streamedOutputItem
+	lookup := func(i int, event gjson.Result) *streamedOutputItem {
+		t.Helper()
+		ix := int(event.Get("output_index").Int())
+		if ix < 0 || ix >= len(output) {
+			t.Fatalf("event %d (%s) output_index %d out of range (%d items)", i, event.Get("type").String(), ix, len(output))
+		}
+		item := output[ix]
+		if itemID := event.Get("item_id"); itemID.Exists() && itemID.String() != item.id {
+			t.Fatalf("event %d (%s) item_id = %s, want %s", i, event.Get("type").String(), itemID.String(), item.id)
+		}
+		return item
+	}
+
+	for i, event := range events {
+		eventType := event.Get("type").String()
+		if got := event.Get("sequence_number").Int(); got != int64(i) {
+			t.Fatalf("event %d (%s) sequence_number = %d, want %d", i, eventType, got, i)
+		}
+		if id := event.Get("response.id"); id.Exists() && id.String() != responseID {
+			t.Fatalf("event %d (%s) response.id = %s, want %s", i, eventType, id.String(), responseID)
+		}
+
+		switch eventType {
+		case "response.output_item.added":
+			if got := int(event.Get("output_index").Int()); got != len(output) {
+				t.Fatalf("event %d output_item.added output_index = %d, want %d", i, got, len(output))
+			}
+			id := event.Get("item.id").String()
+			if id == "" {
+				t.Fatalf("event %d output_item.added missing item.id", i)
+			}
+			for _, existing := range output {
+				if existing.id == id {
+					t.Fatalf("event %d output_item.added reuses item id %s", i, id)
+				}
+			}
+			output = append(output, &streamedOutputItem{id: id, itemType: event.Get("item.type").String()})
+		case "response.content_part.added":
+			item := lookup(i, event)
+			if item.itemType != "message" {
+				t.Fatalf("event %d content_part.added on %s item", i, item.itemType)
+			}
+			if got := int(event.Get("content_index").Int()); got != len(item.parts) {
+				t.Fatalf("event %d content_index = %d, want %d", i, got, len(item.parts))
+			}
+			item.parts = append(item.parts, "")
+		case "response.output_text.delta":
+			item := lookup(i, event)
+			ix := int(event.Get("content_index").Int())
+			if ix >= len(item.parts) {
+				t.Fatalf("event %d output_text.delta before content_part.added", i)
+			}
+			item.parts[ix] += event.Get("delta").String()
+		case "response.output_text.done":
+			item := lookup(i, event)
+			ix := int(event.Get("content_index").Int())
+			if ix >= len(item.parts) || item.parts[ix] != event.Get("text").String() {
+				t.Fatalf("event %d output_text.done text mismatch", i)
+			}
+		case "response.function_call_arguments.delta":
+			item := lookup(i, event)
+			if item.itemType != "function_call" {
+				t.Fatalf("event %d arguments delta on %s item", i, item.itemType)
+			}
+			item.arguments += event.Get("delta").String()
+		case "response.function_call_arguments.done":
+			item := lookup(i, event)
+			if item.arguments != event.Get("arguments").String() {
+				t.Fatalf("event %d arguments.done = %q, want %q", i, event.Get("arguments").String(), item.arguments)
+			}
+		case "response.reasoning_summary_part.added", "response.reasoning_summary_part.done":
+			if item := lookup(i, event); item.itemType != "reasoning" {
+				t.Fatalf("event %d %s on %s item", i, eventType, item.itemType)
+			}
+		case "response.reasoning_summary_text.delta":
+			lookup(i, event).summary += event.Get("delta").String()
+		case "response.reasoning_summary_text.done":
+			if item := lookup(i, event); item.summary != event.Get("text").String() {
+				t.Fatalf("event %d reasoning text mismatch", i)
+			}
+		case "response.output_item.done":
+			item := lookup(i, event)
+			if got := event.Get("item.id").String(); got != item.id {
+				t.Fatalf("event %d output_item.done item.id = %s, want %s", i, got, item.id)
+			}
+		case "response.completed":
+			completedOutput := event.Get("response.output").Array()
+			if len(completedOutput) != len(output) {
+				t.Fatalf("response.completed output len = %d, want %d", len(completedOutput), len(output))
+			}
+			for ix, item := range completedOutput {
+				if item.Get("id").String() != output[ix].id {
+					t.Fatalf("response.completed output[%d].id = %s, want %s", ix, item.Get("id").String(), output[ix].id)
+				}
+			}
+		}
+	}
+}
+
+func readGoldenStreamInput(t *testing.T, name string) [][]byte {
+	t.Helper()
+	raw, errRead := os.ReadFile(filepath.Join("testdata", name+".sse"))
+	if errRead != nil {
+		t.Fatalf("read input: %v", errRead)
+	}
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	return lines
+}
+
+func TestConvertOpenAIChatCompletionsResponseToOpenAIResponses_GoldenEventStreams(t *testing.T) {
+	t.Parallel()
+
+	request := []byte(`{"model":"gpt-5.4","stream":true,"tools":[{"type":"function","name":"read","parameters":{"type":"object"}}]}`)
+
+	for _, name := range []string{"chat_text_stream", "chat_reasoning_tool_stream"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var param any
+			var events []gjson.Result
+			for _, line := range readGoldenStreamInput(t, name) {
+				for _, chunk := range ConvertOpenAIChatCompletionsResponseToOpenAIResponses(context.Background(), "gpt-5.4", request, request, line, &param) {
+					eventName, data := parseOpenAIResponsesSSEEvent(t, chunk)
+					if eventName != data.Get("type").String() {
+						t.Fatalf("SSE event %q does not match payload type %q", eventName, data.Get("type").String())
+					}
+					events = append(events, data)
+				}
+			}
+
+			validateResponsesEventStream(t, events)
+
+			responseID := events[0].Get("response.id").String()
+			var summary strings.Builder
+			for _, event := range events {
+				line := event.Get("type").String()
+				if ix := event.Get("output_index"); ix.Exists() {
+					line += fmt.Sprintf(" output_index=%d", ix.Int())
+				}
+				if id := event.Get("item_id"); id.Exists() {
+					line += " item_id=" + id.String()
+				} else if id := event.Get("item.id"); id.Exists() {
+					line += " item.id=" + id.String()
+				}
+				if model := event.Get("response.model"); model.Exists() {
+					line += " model=" + model.String()
+				}
+				summary.WriteString(strings.ReplaceAll(line, responseID, "{response_id}"))
+				summary.WriteByte('\n')
+			}
+
+			want, errRead := os.ReadFile(filepath.Join("testdata", name+".golden"))
+			if errRead != nil {
+				t.Fatalf("read golden: %v", errRead)
+			}
+			if got := summary.String(); got != string(want) {
+				t.Fatalf("event stream mismatch\n--- got ---\n%s--- want ---\n%s", got, want)
+			}
+		})
+	}
+}
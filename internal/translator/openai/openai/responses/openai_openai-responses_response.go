@@ -23,6 +23,7 @@ type oaiToResponsesState struct {
 	Seq               int
 	ResponseID        string
 	Created           int64
+	Model             string
 	Started           bool
 	CompletionPending bool
 	CompletedEmitted  bool
@@ -73,6 +74,9 @@ func buildResponsesCompletedEvent(st *oaiToResponsesState, requestRawJSON []byte
 	completed, _ = sjson.SetBytes(completed, "sequence_number", nextSeq())
 	completed, _ = sjson.SetBytes(completed, "response.id", st.ResponseID)
 	completed, _ = sjson.SetBytes(completed, "response.created_at", st.Created)
+	if st.Model != "" {
+		completed, _ = sjson.SetBytes(completed, "response.model", st.Model)
+	}
 	// Inject original request fields into response as per docs/response.completed.json
 	if requestRawJSON != nil {
 		req := gjson.ParseBytes(requestRawJSON)
@@ -212,6 +216,18 @@ func buildResponsesCompletedEvent(st *oaiToResponsesState, requestRawJSON []byte
 	return emitRespEvent("response.completed", completed)
 }
 
+// responsesStreamModel picks the model reported on streamed response objects:
+// the client-requested model first, then the upstream chunk model.
+func responsesStreamModel(requestRawJSON []byte, chunk gjson.Result, modelName string) string {
+	if model := gjson.GetBytes(requestRawJSON, "model").String(); model != "" {
+		return model
+	}
+	if model := chunk.Get("model").String(); model != "" {
+		return model
+	}
+	return modelName
+}
+
 // ConvertOpenAIChatCompletionsResponseToOpenAIResponses converts OpenAI Chat Completions streaming chunks
 // to OpenAI Responses SSE events (response.*).
 func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
@@ -248,7 +264,7 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 	if bytes.Equal(rawJSON, []byte("[DONE]")) {
 		if st.CompletionPending && !st.CompletedEmitted {
 			st.CompletedEmitted = true
-			return [][]byte{buildResponsesCompletedEvent(st, requestForNamespace, func() int { st.Seq++; return st.Seq })}
+			return [][]byte{buildResponsesCompletedEvent(st, requestForNamespace, func() int { seq := st.Seq; st.Seq++; return seq })}
 		}
 		return [][]byte{}
 	}
@@ -291,7 +307,8 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 		}
 	}
 
-	nextSeq := func() int { st.Seq++; return st.Seq }
+	// Sequence numbers start at zero, matching the official Responses stream.
+	nextSeq := func() int { seq := st.Seq; st.Seq++; return seq }
 	allocOutputIndex := func() int {
 		ix := st.NextOutputIx
 		st.NextOutputIx++
@@ -362,8 +379,16 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 	}
 
 	if !st.Started {
+		// Chat-only upstreams may omit id/created on chunks; synthesize them so item IDs stay unique.
 		st.ResponseID = root.Get("id").String()
+		if st.ResponseID == "" {
+			st.ResponseID = fmt.Sprintf("resp_%x_%d", time.Now().UnixNano(), atomic.AddUint64(&responseIDCounter, 1))
+		}
 		st.Created = root.Get("created").Int()
+		if st.Created == 0 {
+			st.Created = time.Now().Unix()
+		}
+		st.Model = responsesStreamModel(requestForNamespace, root, modelName)
 		// reset aggregation state for a new streaming response
 		st.MsgTextBuf = make(map[int]*strings.Builder)
 		st.ReasoningBuf.Reset()
@@ -397,12 +422,18 @@ func ConvertOpenAIChatCompletionsResponseToOpenAIResponses(ctx context.Context,
 		created, _ = sjson.SetBytes(created, "sequence_number", nextSeq())
 		created, _ = sjson.SetBytes(created, "response.id", st.ResponseID)
 		created, _ = sjson.SetBytes(created, "response.created_at", st.Created)
+		if st.Model != "" {
+			created, _ = sjson.SetBytes(created, "response.model", st.Model)
+		}
 		out = append(out, emitRespEvent("response.created", created))
 
 		inprog := []byte(`{"type":"response.in_progress","sequence_number":0,"response":{"id":"","object":"response","created_at":0,"status":"in_progress"}}`)
 		inprog, _ = sjson.SetBytes(inprog, "sequence_number", nextSeq())
 		inprog, _ = sjson.SetBytes(inprog, "response.id", st.ResponseID)
 		inprog, _ = sjson.SetBytes(inprog, "response.created_at", st.Created)
+		if st.Model != "" {
+			inprog, _ = sjson.SetBytes(inprog, "response.model", st.Model)
+		}
 		out = append(out, emitRespEvent("response.in_progress", inprog))
 		st.Started = true
 	}
@@ -0,0 +1,298 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	translatorapi "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/translator"
+	"github.com/tidwall/gjson"
+)
+
+const openAIParallelToolRequest = `{
+	"model": "claude-test",
+	"messages": [
+		{"role": "user", "content": "Weather in Paris and Rome?"},
+		{"role": "assistant", "content": null, "tool_calls": [
+			{"id": "call_paris", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}},
+			{"id": "call_rome", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Rome\",\"units\":[\"c\"]}"}}
+		]},
+		{"role": "tool", "tool_call_id": "call_paris", "content": "18C"},
+		{"role": "tool", "tool_call_id": "call_rome", "content": "24C"},
+		{"role": "user", "content": "Thanks"}
+	],
+	"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}}],
+	"tool_choice": "required",
+	"parallel_tool_calls": false
+}`
+
+func TestToolCallFidelity_OpenAIRequestToClaude(t *testing.T) {
+	out := translatorapi.Request("openai", "claude", "claude-test", []byte(openAIParallelToolRequest), false)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 4 {
+		t.Fatalf("messages = %d, want user, assistant, tool results, user: %s", len(messages), gjson.GetBytes(out, "messages").Raw)
+	}
+
+	toolUses := messages[1].Get("content.#(type==\"tool_use\")#").Array()
+	if len(toolUses) != 2 || toolUses[0].Get("id").String() != "call_paris" || toolUses[1].Get("id").String() != "call_rome" {
+		t.Fatalf("assistant content = %s, want both tool uses", messages[1].Raw)
+	}
+	if toolUses[1].Get("input.city").String() != "Rome" || toolUses[1].Get("input.units.0").String() != "c" {
+		t.Fatalf("tool use input = %s, want the parsed arguments", toolUses[1].Get("input").Raw)
+	}
+
+	results := messages[2].Get("content").Array()
+	if messages[2].Get("role").String() != "user" || len(results) != 2 {
+		t.Fatalf("tool results turn = %s, want both results in one user turn", messages[2].Raw)
+	}
+	if results[0].Get("tool_use_id").String() != "call_paris" || results[0].Get("content").String() != "18C" ||
+		results[1].Get("tool_use_id").String() != "call_rome" || results[1].Get("content").String() != "24C" {
+		t.Fatalf("tool results = %s", messages[2].Raw)
+	}
+	if got := messages[3].Get("content.0.text").String(); got != "Thanks" {
+		t.Fatalf("last turn = %s, want the follow-up text", messages[3].Raw)
+	}
+
+	if got := gjson.GetBytes(out, "tool_choice").Raw; got != `{"type":"any","disable_parallel_tool_use":true}` {
+		t.Fatalf("tool_choice = %s", got)
+	}
+}
+
+const claudeParallelToolRequest = `{
+	"model": "gpt-test",
+	"max_tokens": 1024,
+	"messages": [
+		{"role": "user", "content": "Weather in Paris and Rome?"},
+		{"role": "assistant", "content": [
+			{"type": "text", "text": "Checking both."},
+			{"type": "tool_use", "id": "toolu_paris", "name": "get_weather", "input": {"city": "Paris"}},
+			{"type": "tool_use", "id": "toolu_rome", "name": "get_weather", "input": {}}
+		]},
+		{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "toolu_paris", "content": "18C"},
+			{"type": "tool_result", "tool_use_id": "toolu_rome", "content": [{"type": "text", "text": "24C"}]},
+			{"type": "text", "text": "Which is warmer?"}
+		]}
+	],
+	"tools": [{"name": "get_weather", "input_schema": {"type": "object", "properties": {"city": {"type": "string"}}}}],
+	"tool_choice": {"type": "none"}
+}`
+
+func TestToolCallFidelity_ClaudeRequestToOpenAI(t *testing.T) {
+	out := translatorapi.Request("claude", "openai", "gpt-test", []byte(claudeParallelToolRequest), false)
+	messages := gjson.GetBytes(out, "messages").Array()
+
+	var roles []string
+	for _, msg := range messages {
+		roles = append(roles, msg.Get("role").String())
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,tool,tool,user" {
+		t.Fatalf("roles = %s, want user,assistant,tool,tool,user: %s", got, gjson.GetBytes(out, "messages").Raw)
+	}
+
+	toolCalls := messages[1].Get("tool_calls").Array()
+	if len(toolCalls) != 2 {
+		t.Fatalf("tool_calls = %s, want two calls", messages[1].Get("tool_calls").Raw)
+	}
+	if toolCalls[0].Get("id").String() != "toolu_paris" || toolCalls[0].Get("function.arguments").String() != `{"city": "Paris"}` {
+		t.Fatalf("first tool call = %s", toolCalls[0].Raw)
+	}
+	if toolCalls[1].Get("function.arguments").String() != `{}` {
+		t.Fatalf("second tool call = %s, want empty object arguments", toolCalls[1].Raw)
+	}
+	if messages[2].Get("tool_call_id").String() != "toolu_paris" || messages[3].Get("tool_call_id").String() != "toolu_rome" {
+		t.Fatalf("tool messages = %s %s", messages[2].Raw, messages[3].Raw)
+	}
+	if got := gjson.GetBytes(out, "tool_choice").String(); got != "none" {
+		t.Fatalf("tool_choice = %q, want none", got)
+	}
+}
+
+func TestToolCallFidelity_OpenAIClaudeRoundTrip(t *testing.T) {
+	claudeRequest := translatorapi.Request("openai", "claude", "claude-test", []byte(openAIParallelToolRequest), false)
+	back := translatorapi.Request("claude", "openai", "gpt-test", claudeRequest, false)
+
+	original := gjson.Get(openAIParallelToolRequest, "messages").Array()
+	messages := gjson.GetBytes(back, "messages").Array()
+	if len(messages) != len(original) {
+		t.Fatalf("round trip messages = %s, want %d messages", gjson.GetBytes(back, "messages").Raw, len(original))
+	}
+	for i, toolCall := range original[1].Get("tool_calls").Array() {
+		got := messages[1].Get("tool_calls").Array()[i]
+		if got.Get("id").String() != toolCall.Get("id").String() || got.Get("function.name").String() != toolCall.Get("function.name").String() {
+			t.Fatalf("tool call %d = %s, want %s", i, got.Raw, toolCall.Raw)
+		}
+		if gjson.Parse(got.Get("function.arguments").String()).Raw != gjson.Parse(toolCall.Get("function.arguments").String()).Raw {
+			t.Fatalf("tool call %d arguments = %s, want %s", i, got.Get("function.arguments").String(), toolCall.Get("function.arguments").String())
+		}
+	}
+	for i := 2; i <= 3; i++ {
+		if messages[i].Get("role").String() != "tool" || messages[i].Get("tool_call_id").String() != original[i].Get("tool_call_id").String() ||
+			messages[i].Get("content").String() != original[i].Get("content").String() {
+			t.Fatalf("tool message %d = %s, want %s", i, messages[i].Raw, original[i].Raw)
+		}
+	}
+	if got := gjson.GetBytes(back, "parallel_tool_calls"); !got.Exists() || got.Bool() {
+		t.Fatalf("parallel_tool_calls = %s, want false", got.Raw)
+	}
+}
+
+func TestToolCallFidelity_ClaudeStreamToOpenAIDeltas(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":5,"output_tokens":0}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_paris","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_now","name":"get_time","input":{}}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	var param any
+	type call struct{ id, name, arguments string }
+	calls := map[int]*call{}
+	var order []int
+	finishReason := ""
+	for _, event := range events {
+		for _, chunk := range translatorapi.Response("claude", "openai", context.Background(), "claude-test", nil, nil, []byte("data: "+event), &param) {
+			if reason := gjson.GetBytes(chunk, "choices.0.finish_reason").String(); reason != "" {
+				finishReason = reason
+			}
+			for _, delta := range gjson.GetBytes(chunk, "choices.0.delta.tool_calls").Array() {
+				index := int(delta.Get("index").Int())
+				c, ok := calls[index]
+				if !ok {
+					if !delta.Get("id").Exists() {
+						t.Fatalf("first delta of tool call %d has no id: %s", index, delta.Raw)
+					}
+					c = &call{}
+					calls[index] = c
+					order = append(order, index)
+				}
+				if id := delta.Get("id").String(); id != "" {
+					c.id = id
+				}
+				if name := delta.Get("function.name").String(); name != "" {
+					c.name = name
+				}
+				c.arguments += delta.Get("function.arguments").String()
+			}
+		}
+	}
+
+	if len(order) != 2 || order[0] != 0 || order[1] != 1 {
+		t.Fatalf("tool call indexes = %v, want [0 1] independent of content block indexes", order)
+	}
+	if c := calls[0]; c.id != "toolu_paris" || c.name != "get_weather" || c.arguments != `{"city":"Paris"}` {
+		t.Fatalf("first tool call = %+v", *c)
+	}
+	if c := calls[1]; c.id != "toolu_now" || c.name != "get_time" || c.arguments != `{}` {
+		t.Fatalf("second tool call = %+v, want {} arguments", *c)
+	}
+	if finishReason != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", finishReason)
+	}
+}
+
+func TestToolCallFidelity_OpenAIStreamToClaudeBlocks(t *testing.T) {
+	chunks := []string{
+		`{"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_paris","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_rome","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"{\"city\":\"Rome\"}"}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`{"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`[DONE]`,
+	}
+	originalRequest := []byte(`{"model":"gpt-test","stream":true,"tools":[{"name":"get_weather","input_schema":{"type":"object"}}],"messages":[{"role":"user","content":"hi"}]}`)
+
+	var param any
+	type block struct{ id, name, input string }
+	blocks := map[int]*block{}
+	stopReason := ""
+	for _, chunk := range chunks {
+		for _, out := range translatorapi.Response("openai", "claude", context.Background(), "gpt-test", originalRequest, nil, []byte("data: "+chunk), &param) {
+			for _, line := range strings.Split(string(out), "\n") {
+				data, ok := strings.CutPrefix(line, "data: ")
+				if !ok {
+					continue
+				}
+				event := gjson.Parse(data)
+				index := int(event.Get("index").Int())
+				switch event.Get("type").String() {
+				case "content_block_start":
+					if event.Get("content_block.type").String() == "tool_use" {
+						blocks[index] = &block{id: event.Get("content_block.id").String(), name: event.Get("content_block.name").String()}
+					}
+				case "content_block_delta":
+					if b, exists := blocks[index]; exists {
+						b.input += event.Get("delta.partial_json").String()
+					}
+				case "message_delta":
+					stopReason = event.Get("delta.stop_reason").String()
+				}
+			}
+		}
+	}
+
+	if len(blocks) != 2 {
+		t.Fatalf("tool_use blocks = %d, want 2", len(blocks))
+	}
+	byID := map[string]*block{}
+	for _, b := range blocks {
+		byID[b.id] = b
+	}
+	for id, city := range map[string]string{"call_paris": "Paris", "call_rome": "Rome"} {
+		b, ok := byID[id]
+		if !ok || b.name != "get_weather" || gjson.Get(b.input, "city").String() != city {
+			t.Fatalf("tool_use %s = %+v, want get_weather for %s", id, b, city)
+		}
+	}
+	if stopReason != "tool_use" {
+		t.Fatalf("stop_reason = %q, want tool_use", stopReason)
+	}
+}
+
+func TestToolCallFidelity_NonStreamParallelCalls(t *testing.T) {
+	openAIResponse := []byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[
+		{"id":"call_paris","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}},
+		{"id":"call_rome","type":"function","function":{"name":"get_weather","arguments":"{'city': 'Rome'}"}}
+	]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}`)
+	originalRequest := []byte(`{"model":"gpt-test","tools":[{"name":"get_weather","input_schema":{"type":"object"}}],"messages":[{"role":"user","content":"hi"}]}`)
+	claudeOut := translatorapi.ResponseNonStream("openai", "claude", context.Background(), "gpt-test", originalRequest, nil, openAIResponse, nil)
+	toolUses := gjson.GetBytes(claudeOut, "content.#(type==\"tool_use\")#").Array()
+	if len(toolUses) != 2 || toolUses[0].Get("input.city").String() != "Paris" || toolUses[1].Get("input.city").String() != "Rome" {
+		t.Fatalf("claude content = %s, want both tool uses with repaired arguments", gjson.GetBytes(claudeOut, "content").Raw)
+	}
+	if got := gjson.GetBytes(claudeOut, "stop_reason").String(); got != "tool_use" {
+		t.Fatalf("stop_reason = %q, want tool_use", got)
+	}
+
+	claudeResponse := strings.Join([]string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-test","usage":{"input_tokens":5,"output_tokens":0}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_paris","name":"get_weather","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Paris\"}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_rome","name":"get_weather","input":{}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Rome\"}"}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`data: {"type":"message_stop"}`,
+	}, "\n")
+	openAIOut := translatorapi.ResponseNonStream("claude", "openai", context.Background(), "claude-test", nil, nil, []byte(claudeResponse), nil)
+	toolCalls := gjson.GetBytes(openAIOut, "choices.0.message.tool_calls").Array()
+	if len(toolCalls) != 2 || toolCalls[0].Get("id").String() != "toolu_paris" || toolCalls[1].Get("id").String() != "toolu_rome" {
+		t.Fatalf("tool_calls = %s, want both calls in order", gjson.GetBytes(openAIOut, "choices.0.message.tool_calls").Raw)
+	}
+	if gjson.Get(toolCalls[1].Get("function.arguments").String(), "city").String() != "Rome" {
+		t.Fatalf("second arguments = %s", toolCalls[1].Get("function.arguments").String())
+	}
+	if got := gjson.GetBytes(openAIOut, "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish_reason = %q, want tool_calls", got)
+	}
+}
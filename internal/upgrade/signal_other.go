@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package upgrade
+
+import "os"
+
+// Signals returns no signals: upgrades need SO_REUSEPORT, which this platform lacks.
+func Signals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package upgrade
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals returns the signals that request a zero-downtime upgrade.
+func Signals() []os.Signal {
+	return []os.Signal{syscall.SIGUSR2}
+}
@@ -0,0 +1,103 @@
+// Package upgrade replaces the running proxy with a new process without dropping connections.
+// The new process binds the same port through SO_REUSEPORT and reports readiness over an
+// inherited pipe; the old process then drains its in-flight requests and exits.
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readyFDEnv names the inherited file descriptor the new process writes to once it listens.
+const readyFDEnv = "CLIPROXY_UPGRADE_READY_FD"
+
+// DefaultReadyTimeout bounds how long Spawn waits for the new process to listen.
+const DefaultReadyTimeout = time.Minute
+
+var readyOnce sync.Once
+
+// NotifyReady tells the parent process of an upgrade that this process accepts connections.
+// It is a no-op when the process was not started by Spawn.
+func NotifyReady() {
+	readyOnce.Do(func() {
+		fd, errParse := strconv.Atoi(os.Getenv(readyFDEnv))
+		if errParse != nil || fd < 3 {
+			return
+		}
+		_ = os.Unsetenv(readyFDEnv)
+		pipe := os.NewFile(uintptr(fd), "upgrade-ready")
+		if pipe == nil {
+			return
+		}
+		if _, errWrite := pipe.Write([]byte{1}); errWrite != nil {
+			log.Warnf("upgrade: failed to report readiness: %v", errWrite)
+		}
+		_ = pipe.Close()
+	})
+}
+
+// Spawn starts the current executable with the same arguments and environment and waits until
+// it reports readiness through NotifyReady. The new process keeps running independently; on
+// error it is killed so the caller can keep serving.
+func Spawn(ctx context.Context, timeout time.Duration) (*os.Process, error) {
+	executable, errExecutable := os.Executable()
+	if errExecutable != nil {
+		return nil, fmt.Errorf("locate executable: %w", errExecutable)
+	}
+	readR, readW, errPipe := os.Pipe()
+	if errPipe != nil {
+		return nil, fmt.Errorf("create readiness pipe: %w", errPipe)
+	}
+	defer func() { _ = readR.Close() }()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{readW}
+	// ExtraFiles[0] becomes descriptor 3 in the child.
+	cmd.Env = append(os.Environ(), readyFDEnv+"=3")
+	if errStart := cmd.Start(); errStart != nil {
+		_ = readW.Close()
+		return nil, fmt.Errorf("start %s: %w", executable, errStart)
+	}
+	_ = readW.Close()
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, errRead := readR.Read(buf)
+		ready <- errRead
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var errWait error
+	select {
+	case errRead := <-ready:
+		if errRead == nil {
+			return cmd.Process, nil
+		}
+		// The pipe closed without a byte: the child exited or closed it before listening.
+		errWait = fmt.Errorf("new process did not report readiness: %w", errRead)
+	case errExit := <-exited:
+		errWait = fmt.Errorf("new process exited before listening: %v", errExit)
+		return nil, errWait
+	case <-timer.C:
+		errWait = errors.New("timed out waiting for the new process to listen")
+	case <-ctx.Done():
+		errWait = ctx.Err()
+	}
+	_ = cmd.Process.Kill()
+	return nil, errWait
+}
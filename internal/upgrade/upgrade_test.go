@@ -0,0 +1,44 @@
+package upgrade
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// helperEnv makes the re-executed test binary act as the new process.
+const helperEnv = "CLIPROXY_UPGRADE_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(helperEnv) {
+	case "ready":
+		NotifyReady()
+		os.Exit(0)
+	case "exit":
+		os.Exit(3)
+	}
+	os.Exit(m.Run())
+}
+
+func TestSpawnWaitsForReadiness(t *testing.T) {
+	t.Setenv(helperEnv, "ready")
+	process, errSpawn := Spawn(context.Background(), 30*time.Second)
+	if errSpawn != nil {
+		t.Fatalf("Spawn: %v", errSpawn)
+	}
+	if process == nil || process.Pid <= 0 {
+		t.Fatalf("process = %+v", process)
+	}
+}
+
+func TestSpawnFailsWhenProcessExitsEarly(t *testing.T) {
+	t.Setenv(helperEnv, "exit")
+	if _, errSpawn := Spawn(context.Background(), 30*time.Second); errSpawn == nil {
+		t.Fatal("expected an error when the new process exits before listening")
+	}
+}
+
+func TestNotifyReadyWithoutParentIsNoop(t *testing.T) {
+	NotifyReady()
+}
@@ -0,0 +1,390 @@
+package usageaccounting
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sharedstate"
+	log "github.com/sirupsen/logrus"
+)
+
+// Budget limits reported in BudgetStatus.Exceeded.
+const (
+	BudgetLimitTokens = "tokens"
+	BudgetLimitCost   = "cost"
+)
+
+// costMicros converts USD to the integer micro-dollars kept in shared counters.
+const costMicros = 1e6
+
+// budgetKey identifies the spend counter of a key or tenant for one budget period.
+type budgetKey struct {
+	id     string
+	period string
+}
+
+// budgetCounter is the spend within the current period, e.g. "2026-03-15" for a daily budget.
+type budgetCounter struct {
+	current string
+	tokens  int64
+	cost    float64
+}
+
+// BudgetStatus reports the spend of a key or tenant against a budget in the current period.
+type BudgetStatus struct {
+	APIKey     string  `json:"api_key,omitempty"`
+	Tenant     string  `json:"tenant,omitempty"`
+	Period     string  `json:"period"`
+	Tokens     int64   `json:"tokens,omitempty"`
+	Cost       float64 `json:"cost,omitempty"`
+	UsedTokens int64   `json:"used_tokens"`
+	UsedCost   float64 `json:"used_cost"`
+	// Exceeded names the limit that was reached: tokens or cost. Empty while within budget.
+	Exceeded      string     `json:"exceeded,omitempty"`
+	ResetsAt      time.Time  `json:"resets_at"`
+	OverrideUntil *time.Time `json:"override_until,omitempty"`
+}
+
+func budgetID(budget config.UsageBudget) string {
+	if budget.Tenant != "" {
+		return tenantCounterID(budget.Tenant)
+	}
+	return keyID(budget.APIKey)
+}
+
+// periodOf returns the calendar day or month of ts for the given budget period.
+func periodOf(ts time.Time, period string) string {
+	if period == config.UsageBudgetDaily {
+		return ts.UTC().Format(time.DateOnly)
+	}
+	return monthOf(ts)
+}
+
+// periodEnd returns when the budget period containing ts ends.
+func periodEnd(ts time.Time, period string) time.Time {
+	ts = ts.UTC()
+	if period == config.UsageBudgetDaily {
+		return time.Date(ts.Year(), ts.Month(), ts.Day()+1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(ts.Year(), ts.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// rebuildBudgetCountersLocked recreates the counters of the configured budgets from the hourly
+// buckets of their current period.
+func (t *Tracker) rebuildBudgetCountersLocked() {
+	now := t.clock.Now()
+	t.budgets = make(map[budgetKey]*budgetCounter, len(t.cfg.Budgets))
+	for _, budget := range t.cfg.Budgets {
+		t.budgets[budgetKey{id: budgetID(budget), period: budget.Period}] = &budgetCounter{current: periodOf(now, budget.Period)}
+	}
+	if len(t.budgets) == 0 {
+		return
+	}
+	for key, totals := range t.rows {
+		t.addBudgetSpendLocked(t.spendIDsLocked(key.apiKey), time.Unix(key.hour, 0), *totals)
+	}
+}
+
+// spendIDsLocked lists the counter ids charged for usage of the key id: the key and its tenant.
+func (t *Tracker) spendIDsLocked(id string) []string {
+	ids := []string{id}
+	if tenant, ok := t.tenantOf[id]; ok {
+		ids = append(ids, tenantCounterID(tenant))
+	}
+	return ids
+}
+
+// addBudgetSpendLocked charges delta at ts to the budget counters of ids and returns the
+// counters it changed. Counters move to a new period as usage of that period arrives; usage
+// reported late for an earlier period is ignored.
+func (t *Tracker) addBudgetSpendLocked(ids []string, ts time.Time, delta Totals) []budgetKey {
+	var charged []budgetKey
+	for _, id := range ids {
+		for _, period := range []string{config.UsageBudgetDaily, config.UsageBudgetMonthly} {
+			key := budgetKey{id: id, period: period}
+			counter, ok := t.budgets[key]
+			if !ok {
+				continue
+			}
+			current := periodOf(ts, period)
+			if current < counter.current {
+				continue
+			}
+			if current > counter.current {
+				*counter = budgetCounter{current: current}
+			}
+			counter.tokens += delta.TotalTokens
+			counter.cost += delta.EstimatedCost
+			charged = append(charged, key)
+		}
+	}
+	return charged
+}
+
+// shareBudgetSpend adds delta to the shared counters of the charged budgets.
+func (t *Tracker) shareBudgetSpend(charged []budgetKey, ts time.Time, delta Totals) {
+	micros := int64(math.Round(delta.EstimatedCost * costMicros))
+	for _, key := range charged {
+		current := periodOf(ts, key.period)
+		// Keep the counters through the following period so late reports still find them.
+		expireAt := periodEnd(periodEnd(ts, key.period), key.period)
+		for name, value := range map[string]int64{"tokens": delta.TotalTokens, "cost": micros} {
+			if value == 0 {
+				continue
+			}
+			if _, errAdd := t.shared.Add(budgetCounterKey(key.id, current, name), value, expireAt); errAdd != nil && !errors.Is(errAdd, sharedstate.ErrDisabled) {
+				log.Debugf("usage accounting: failed to update shared budget counter: %v", errAdd)
+			}
+		}
+	}
+}
+
+// budgetSpend returns the spend of id in the period current across all instances, falling back
+// to the local counter when the shared state is unavailable.
+func (t *Tracker) budgetSpend(id, current string, local budgetCounter) (int64, float64) {
+	tokens, errTokens := t.shared.Counter(budgetCounterKey(id, current, "tokens"))
+	micros, errCost := t.shared.Counter(budgetCounterKey(id, current, "cost"))
+	if errTokens != nil || errCost != nil {
+		if errShared := errors.Join(errTokens, errCost); !errors.Is(errShared, sharedstate.ErrDisabled) {
+			log.Debugf("usage accounting: failed to read shared budget counter: %v", errShared)
+		}
+		if local.current != current {
+			return 0, 0
+		}
+		return local.tokens, local.cost
+	}
+	return tokens, float64(micros) / costMicros
+}
+
+// budgetStatusesLocked snapshots the budgets matching match with their local counters. The
+// spend is filled in by fillBudgetSpend once the lock is released.
+func (t *Tracker) budgetStatusesLocked(now time.Time, match func(id string) bool) ([]BudgetStatus, []budgetCounter) {
+	var statuses []BudgetStatus
+	var locals []budgetCounter
+	for _, budget := range t.cfg.Budgets {
+		id := budgetID(budget)
+		if !match(id) {
+			continue
+		}
+		status := BudgetStatus{
+			APIKey:   budget.APIKey,
+			Tenant:   budget.Tenant,
+			Period:   budget.Period,
+			Tokens:   budget.Tokens,
+			Cost:     budget.Cost,
+			ResetsAt: periodEnd(now, budget.Period),
+		}
+		if until, ok := t.overrides[hashedID(id)]; ok && now.Before(until) {
+			status.OverrideUntil = &until
+		}
+		var local budgetCounter
+		if counter, ok := t.budgets[budgetKey{id: id, period: budget.Period}]; ok {
+			local = *counter
+		}
+		statuses = append(statuses, status)
+		locals = append(locals, local)
+	}
+	return statuses, locals
+}
+
+func (t *Tracker) fillBudgetSpend(statuses []BudgetStatus, locals []budgetCounter, now time.Time) {
+	for i := range statuses {
+		status := &statuses[i]
+		id := keyID(status.APIKey)
+		if status.Tenant != "" {
+			id = tenantCounterID(status.Tenant)
+		}
+		status.UsedTokens, status.UsedCost = t.budgetSpend(id, periodOf(now, status.Period), locals[i])
+		status.OverrideUntil = t.sharedOverride(id, status.OverrideUntil, now)
+		switch {
+		case status.Tokens > 0 && status.UsedTokens >= status.Tokens:
+			status.Exceeded = BudgetLimitTokens
+		case status.Cost > 0 && status.UsedCost >= status.Cost:
+			status.Exceeded = BudgetLimitCost
+		}
+	}
+}
+
+// CheckBudget reports the first budget of apiKey or its tenant that is exhausted and not
+// overridden. ok is true when the request is within every budget.
+func (t *Tracker) CheckBudget(apiKey string) (BudgetStatus, bool) {
+	if t == nil || apiKey == "" {
+		return BudgetStatus{}, true
+	}
+	t.mu.Lock()
+	if t.store == nil || len(t.cfg.Budgets) == 0 {
+		t.mu.Unlock()
+		return BudgetStatus{}, true
+	}
+	now := t.clock.Now().UTC()
+	ids := t.spendIDsLocked(keyID(apiKey))
+	statuses, locals := t.budgetStatusesLocked(now, func(id string) bool {
+		for _, candidate := range ids {
+			if id == candidate {
+				return true
+			}
+		}
+		return false
+	})
+	t.mu.Unlock()
+	t.fillBudgetSpend(statuses, locals, now)
+	for _, status := range statuses {
+		if status.Exceeded != "" && status.OverrideUntil == nil {
+			return status, false
+		}
+	}
+	return BudgetStatus{}, true
+}
+
+// Budgets reports the spend against every configured budget, sorted by tenant, key and period.
+func (t *Tracker) Budgets() []BudgetStatus {
+	statuses := []BudgetStatus{}
+	if t == nil {
+		return statuses
+	}
+	t.mu.Lock()
+	now := t.clock.Now().UTC()
+	found, locals := t.budgetStatusesLocked(now, func(string) bool { return true })
+	t.mu.Unlock()
+	t.fillBudgetSpend(found, locals, now)
+	statuses = append(statuses, found...)
+	sort.SliceStable(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if a.Tenant != b.Tenant {
+			return a.Tenant < b.Tenant
+		}
+		if a.APIKey != b.APIKey {
+			return a.APIKey < b.APIKey
+		}
+		return a.Period < b.Period
+	})
+	return statuses
+}
+
+// sharedOverride returns the override of the budget target id as recorded in the shared state,
+// which every instance sees, falling back to local, this instance's copy, when the shared state
+// is unavailable.
+func (t *Tracker) sharedOverride(id string, local *time.Time, now time.Time) *time.Time {
+	value, found, errGet := t.shared.Get(budgetOverrideKey(id))
+	if errGet != nil {
+		if !errors.Is(errGet, sharedstate.ErrDisabled) {
+			log.Debugf("usage accounting: failed to read shared budget override: %v", errGet)
+		}
+		return local
+	}
+	if !found {
+		return nil
+	}
+	until := time.UnixMilli(value).UTC()
+	if !now.Before(until) {
+		return nil
+	}
+	return &until
+}
+
+// OverrideBudget admits the requests of apiKey or, when tenant is set, of the tenant past their
+// budgets until the given time. Overrides are saved in the store and the shared state, so they
+// survive restarts and apply on every instance. It returns false when no budget is configured
+// for the target.
+func (t *Tracker) OverrideBudget(apiKey, tenant string, until time.Time) bool {
+	if t == nil {
+		return false
+	}
+	id := budgetTargetID(apiKey, tenant)
+	t.mu.Lock()
+	if !t.hasBudgetLocked(id) {
+		t.mu.Unlock()
+		return false
+	}
+	now := t.clock.Now()
+	for key, expiry := range t.overrides {
+		if !now.Before(expiry) {
+			delete(t.overrides, key)
+		}
+	}
+	t.overrides[hashedID(id)] = until
+	t.saveOverridesLocked()
+	t.mu.Unlock()
+	if errPut := t.shared.Put(budgetOverrideKey(id), until.UnixMilli(), until); errPut != nil && !errors.Is(errPut, sharedstate.ErrDisabled) {
+		log.Warnf("usage accounting: failed to share budget override: %v", errPut)
+	}
+	return true
+}
+
+// ClearBudgetOverride removes the override of apiKey or tenant and reports whether one was set.
+func (t *Tracker) ClearBudgetOverride(apiKey, tenant string) bool {
+	if t == nil {
+		return false
+	}
+	id := budgetTargetID(apiKey, tenant)
+	t.mu.Lock()
+	_, ok := t.overrides[hashedID(id)]
+	delete(t.overrides, hashedID(id))
+	t.saveOverridesLocked()
+	t.mu.Unlock()
+	if _, found, errGet := t.shared.Get(budgetOverrideKey(id)); errGet == nil && found {
+		ok = true
+	}
+	if errDelete := t.shared.Delete(budgetOverrideKey(id)); errDelete != nil && !errors.Is(errDelete, sharedstate.ErrDisabled) {
+		log.Warnf("usage accounting: failed to clear shared budget override: %v", errDelete)
+	}
+	return ok
+}
+
+// loadOverridesLocked replaces the overrides with the unexpired ones saved in the store.
+func (t *Tracker) loadOverridesLocked(store Store) {
+	persistent, ok := store.(overrideStore)
+	if !ok {
+		return
+	}
+	overrides, errLoad := persistent.LoadOverrides(context.Background())
+	if errLoad != nil {
+		log.Errorf("usage accounting: failed to load budget overrides: %v", errLoad)
+		return
+	}
+	now := t.clock.Now()
+	t.overrides = make(map[string]time.Time, len(overrides))
+	for key, until := range overrides {
+		if now.Before(until) {
+			t.overrides[key] = until
+		}
+	}
+}
+
+func (t *Tracker) saveOverridesLocked() {
+	persistent, ok := t.store.(overrideStore)
+	if !ok {
+		return
+	}
+	if errSave := persistent.SaveOverrides(context.Background(), t.overrides); errSave != nil {
+		log.Errorf("usage accounting: failed to save budget overrides: %v", errSave)
+	}
+}
+
+func (t *Tracker) hasBudgetLocked(id string) bool {
+	for _, budget := range t.cfg.Budgets {
+		if budgetID(budget) == id {
+			return true
+		}
+	}
+	return false
+}
+
+func budgetTargetID(apiKey, tenant string) string {
+	if tenant != "" {
+		return tenantCounterID(tenant)
+	}
+	return keyID(apiKey)
+}
+
+func budgetOverrideKey(id string) string {
+	return "budget-override:" + hashedID(id)
+}
+
+func budgetCounterKey(id, current, name string) string {
+	return "budget:" + hashedID(id) + ":" + current + ":" + name
+}
@@ -0,0 +1,116 @@
+package usageaccounting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestTrackerDailyCostBudgetOfTenant(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, config.UsageAccountingConfig{
+		Budgets: []config.UsageBudget{{Tenant: "acme", Period: config.UsageBudgetDaily, Cost: 1}},
+	}, now)
+	tracker.SetTenants([]config.Tenant{{Name: "acme", APIKeys: []string{"acme-1", "acme-2"}}})
+
+	record := usageRecord("acme-1", "gpt-5", now, 10, 5)
+	record.EstimatedCost = 0.6
+	tracker.HandleUsage(context.Background(), record)
+	if _, ok := tracker.CheckBudget("acme-2"); !ok {
+		t.Fatal("tenant key rejected below the tenant budget")
+	}
+
+	record.APIKey = "acme-2"
+	tracker.HandleUsage(context.Background(), record)
+	status, ok := tracker.CheckBudget("acme-1")
+	if ok || status.Tenant != "acme" || status.Exceeded != BudgetLimitCost || status.UsedCost != 1.2 {
+		t.Fatalf("CheckBudget = (%+v, %v), want the exceeded acme cost budget", status, ok)
+	}
+	if want := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC); !status.ResetsAt.Equal(want) {
+		t.Fatalf("ResetsAt = %v, want %v", status.ResetsAt, want)
+	}
+	if _, ok := tracker.CheckBudget("other"); !ok {
+		t.Fatal("key outside the tenant rejected")
+	}
+
+	if tracker.OverrideBudget("", "unknown", now.Add(time.Hour)) {
+		t.Fatal("override accepted for a tenant without budget")
+	}
+	if !tracker.OverrideBudget("", "acme", now.Add(time.Hour)) {
+		t.Fatal("override rejected")
+	}
+	if _, ok := tracker.CheckBudget("acme-1"); !ok {
+		t.Fatal("overridden tenant still rejected")
+	}
+	if !tracker.ClearBudgetOverride("", "acme") {
+		t.Fatal("ClearBudgetOverride found no override")
+	}
+	if _, ok := tracker.CheckBudget("acme-1"); ok {
+		t.Fatal("tenant admitted after its override was cleared")
+	}
+
+	tracker.clock.(*clock.Sim).Advance(12 * time.Hour)
+	if _, ok := tracker.CheckBudget("acme-1"); !ok {
+		t.Fatal("tenant still rejected after the daily budget reset")
+	}
+}
+
+func TestTrackerMonthlyTokenBudgetRebuiltOnReload(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, config.UsageAccountingConfig{}, now)
+
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now.Add(-24*time.Hour), 60, 40))
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now.AddDate(0, -1, 0), 500, 0))
+	tracker.Apply(config.UsageAccountingConfig{
+		Enable:  true,
+		Budgets: []config.UsageBudget{{APIKey: "key-a", Period: config.UsageBudgetMonthly, Tokens: 100}},
+	}, t.TempDir())
+
+	status, ok := tracker.CheckBudget("key-a")
+	if ok || status.Exceeded != BudgetLimitTokens || status.UsedTokens != 100 {
+		t.Fatalf("CheckBudget = (%+v, %v), want the exceeded token budget counting only this month", status, ok)
+	}
+	budgets := tracker.Budgets()
+	if len(budgets) != 1 || budgets[0].APIKey != "key-a" || budgets[0].Exceeded != BudgetLimitTokens {
+		t.Fatalf("Budgets = %+v, want the exceeded key-a budget", budgets)
+	}
+}
+
+func TestTrackerBudgetOverrideSurvivesRestart(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	cfg := config.UsageAccountingConfig{
+		Enable:  true,
+		Store:   config.UsageAccountingStoreSQLite,
+		Budgets: []config.UsageBudget{{APIKey: "key-a", Period: config.UsageBudgetMonthly, Tokens: 10}},
+	}
+	open := func() *Tracker {
+		tracker := NewTracker()
+		tracker.clock = clock.NewSim(now)
+		tracker.Apply(cfg, dir)
+		return tracker
+	}
+
+	first := open()
+	first.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now, 10, 5))
+	if !first.OverrideBudget("key-a", "", now.Add(time.Hour)) {
+		t.Fatal("override rejected")
+	}
+	first.Stop()
+
+	second := open()
+	t.Cleanup(second.Stop)
+	status, ok := second.CheckBudget("key-a")
+	if !ok {
+		t.Fatalf("CheckBudget = (%+v, false) after restart, want the override kept", status)
+	}
+	if !second.ClearBudgetOverride("key-a", "") {
+		t.Fatal("ClearBudgetOverride found no override after restart")
+	}
+	if _, ok := second.CheckBudget("key-a"); ok {
+		t.Fatal("key admitted after its override was cleared")
+	}
+}
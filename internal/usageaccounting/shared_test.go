@@ -0,0 +1,173 @@
+package usageaccounting
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sharedstate"
+)
+
+// fakeRedis serves the handful of commands the shared state issues for counters.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeSharedState(t *testing.T) *sharedstate.State {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	server := &fakeRedis{values: make(map[string]string)}
+	go func() {
+		for {
+			conn, errAccept := listener.Accept()
+			if errAccept != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	state := sharedstate.New(config.SharedStateConfig{RedisURL: "redis://" + listener.Addr().String() + "/0"})
+	t.Cleanup(func() { state.Update(config.SharedStateConfig{}) })
+	return state
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	var queued []string
+	inMulti := false
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		var reply string
+		switch {
+		case name == "MULTI":
+			inMulti, queued = true, nil
+			reply = "+OK\r\n"
+		case name == "EXEC":
+			reply = fmt.Sprintf("*%d\r\n%s", len(queued), strings.Join(queued, ""))
+			inMulti, queued = false, nil
+		case inMulti:
+			queued = append(queued, f.run(name, args))
+			reply = "+QUEUED\r\n"
+		default:
+			reply = f.run(name, args)
+		}
+		if _, errWrite := conn.Write([]byte(reply)); errWrite != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) run(name string, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch name {
+	case "GET":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		for _, arg := range args[3:] {
+			if strings.EqualFold(arg, "NX") {
+				if _, exists := f.values[args[1]]; exists {
+					return "$-1\r\n"
+				}
+			}
+		}
+		f.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "INCRBY":
+		current, _ := strconv.ParseInt(f.values[args[1]], 10, 64)
+		delta, _ := strconv.ParseInt(args[2], 10, 64)
+		current += delta
+		f.values[args[1]] = strconv.FormatInt(current, 10)
+		return fmt.Sprintf(":%d\r\n", current)
+	case "EXPIREAT":
+		return ":1\r\n"
+	case "HELLO":
+		return "-ERR unknown command 'HELLO'\r\n"
+	default:
+		return "+OK\r\n"
+	}
+}
+
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, count)
+	for range count {
+		header, errHeader := reader.ReadString('\n')
+		if errHeader != nil {
+			return nil, errHeader
+		}
+		size, errSize := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if errSize != nil {
+			return nil, errSize
+		}
+		buf := make([]byte, size+2)
+		if _, errRead := io.ReadFull(reader, buf); errRead != nil {
+			return nil, errRead
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func TestTrackerSeedsSharedQuotaCounterFromPersistedUsage(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "usage.db")
+	cfg := config.UsageAccountingConfig{
+		Enable: true,
+		Store:  config.UsageAccountingStoreSQLite,
+		Path:   path,
+		Quotas: []config.UsageQuota{{APIKey: "key-a", MonthlyTokens: 100}},
+	}
+
+	first := NewTracker()
+	first.clock = clock.NewSim(now)
+	first.shared = sharedstate.New(config.SharedStateConfig{})
+	first.Apply(cfg, "")
+	first.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now, 40, 20))
+	first.Stop()
+
+	second := NewTracker()
+	second.clock = clock.NewSim(now)
+	second.shared = newFakeSharedState(t)
+	second.Apply(cfg, "")
+	defer second.Stop()
+
+	if _, remaining, _ := second.QuotaRemaining("key-a"); remaining != 40 {
+		t.Fatalf("remaining = %d, want 40 from the persisted usage", remaining)
+	}
+	second.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now, 5, 5))
+	if _, remaining, _ := second.QuotaRemaining("key-a"); remaining != 30 {
+		t.Fatalf("remaining = %d, want 30 after more usage", remaining)
+	}
+}
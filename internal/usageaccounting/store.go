@@ -0,0 +1,359 @@
+package usageaccounting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sqlitedb"
+)
+
+const defaultPostgresTable = "usage_accounting"
+
+// Store persists hourly usage buckets. Save receives the full totals of every
+// changed bucket and must replace any previously stored values. Prune deletes the
+// buckets of hours before the given time.
+type Store interface {
+	Load(ctx context.Context) ([]Row, error)
+	Save(ctx context.Context, rows []Row) error
+	Prune(ctx context.Context, before time.Time) error
+	Close() error
+}
+
+// overrideStore is implemented by stores that also persist budget overrides, keyed by the hashed
+// key or tenant they apply to. SaveOverrides receives the full set and replaces the stored one.
+type overrideStore interface {
+	LoadOverrides(ctx context.Context) (map[string]time.Time, error)
+	SaveOverrides(ctx context.Context, overrides map[string]time.Time) error
+}
+
+func openStore(cfg config.UsageAccountingConfig, authDir string) (Store, error) {
+	switch cfg.Store {
+	case config.UsageAccountingStoreSQLite:
+		return newSQLiteStore(context.Background(), resolveSQLitePath(cfg, authDir))
+	case config.UsageAccountingStorePostgres:
+		return newPostgresStore(context.Background(), cfg.DSN, cfg.Table)
+	default:
+		return newMemoryStore(), nil
+	}
+}
+
+// memoryStore keeps no state; usage lives only in the tracker until restart.
+type memoryStore struct{}
+
+func newMemoryStore() *memoryStore { return &memoryStore{} }
+
+func (s *memoryStore) Load(context.Context) ([]Row, error) { return nil, nil }
+
+func (s *memoryStore) Save(context.Context, []Row) error { return nil }
+
+func (s *memoryStore) Prune(context.Context, time.Time) error { return nil }
+
+func (s *memoryStore) Close() error { return nil }
+
+// sqliteStore keeps one row per client API key, model and hour in a local SQLite database, and
+// budget overrides in a second table.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func newSQLiteStore(ctx context.Context, path string) (*sqliteStore, error) {
+	db, errOpen := sqlitedb.Open(path)
+	if errOpen != nil {
+		return nil, fmt.Errorf("sqlite usage store: %w", errOpen)
+	}
+	if _, errCreate := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS usage_accounting (
+			api_key TEXT NOT NULL,
+			key_label TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL,
+			hour INTEGER NOT NULL,
+			requests INTEGER NOT NULL DEFAULT 0,
+			failed_requests INTEGER NOT NULL DEFAULT 0,
+			prompt_tokens INTEGER NOT NULL DEFAULT 0,
+			completion_tokens INTEGER NOT NULL DEFAULT 0,
+			total_tokens INTEGER NOT NULL DEFAULT 0,
+			estimated_cost REAL NOT NULL DEFAULT 0,
+			PRIMARY KEY (api_key, model, hour)
+		);
+		CREATE TABLE IF NOT EXISTS usage_accounting_budget_overrides (
+			target TEXT PRIMARY KEY,
+			until INTEGER NOT NULL
+		);
+	`); errCreate != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite usage store: create tables: %w", errCreate)
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) Load(ctx context.Context) ([]Row, error) {
+	rows, errQuery := s.db.QueryContext(ctx,
+		"SELECT api_key, key_label, model, hour, requests, failed_requests, prompt_tokens, completion_tokens, total_tokens, estimated_cost FROM usage_accounting")
+	if errQuery != nil {
+		return nil, fmt.Errorf("sqlite usage store: load: %w", errQuery)
+	}
+	defer rows.Close()
+	var out []Row
+	for rows.Next() {
+		var row Row
+		var hour int64
+		if errScan := rows.Scan(&row.APIKey, &row.KeyLabel, &row.Model, &hour, &row.Requests, &row.FailedRequests, &row.PromptTokens, &row.CompletionTokens, &row.TotalTokens, &row.EstimatedCost); errScan != nil {
+			return nil, fmt.Errorf("sqlite usage store: scan: %w", errScan)
+		}
+		row.Hour = time.Unix(hour, 0).UTC()
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) Save(ctx context.Context, rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tx, errBegin := s.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("sqlite usage store: begin: %w", errBegin)
+	}
+	const query = `
+		INSERT INTO usage_accounting (api_key, key_label, model, hour, requests, failed_requests, prompt_tokens, completion_tokens, total_tokens, estimated_cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (api_key, model, hour) DO UPDATE SET
+			key_label = excluded.key_label,
+			requests = excluded.requests,
+			failed_requests = excluded.failed_requests,
+			prompt_tokens = excluded.prompt_tokens,
+			completion_tokens = excluded.completion_tokens,
+			total_tokens = excluded.total_tokens,
+			estimated_cost = excluded.estimated_cost
+	`
+	for _, row := range rows {
+		if _, errExec := tx.ExecContext(ctx, query, row.APIKey, row.KeyLabel, row.Model, row.Hour.UTC().Unix(), row.Requests, row.FailedRequests, row.PromptTokens, row.CompletionTokens, row.TotalTokens, row.EstimatedCost); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("sqlite usage store: upsert: %w", errExec)
+		}
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("sqlite usage store: commit: %w", errCommit)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Prune(ctx context.Context, before time.Time) error {
+	if _, errDelete := s.db.ExecContext(ctx, "DELETE FROM usage_accounting WHERE hour < ?", before.UTC().Unix()); errDelete != nil {
+		return fmt.Errorf("sqlite usage store: prune: %w", errDelete)
+	}
+	return nil
+}
+
+func (s *sqliteStore) LoadOverrides(ctx context.Context) (map[string]time.Time, error) {
+	rows, errQuery := s.db.QueryContext(ctx, "SELECT target, until FROM usage_accounting_budget_overrides")
+	if errQuery != nil {
+		return nil, fmt.Errorf("sqlite usage store: load overrides: %w", errQuery)
+	}
+	defer rows.Close()
+	overrides := make(map[string]time.Time)
+	for rows.Next() {
+		var target string
+		var until int64
+		if errScan := rows.Scan(&target, &until); errScan != nil {
+			return nil, fmt.Errorf("sqlite usage store: scan override: %w", errScan)
+		}
+		overrides[target] = time.Unix(0, until).UTC()
+	}
+	return overrides, rows.Err()
+}
+
+func (s *sqliteStore) SaveOverrides(ctx context.Context, overrides map[string]time.Time) error {
+	tx, errBegin := s.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("sqlite usage store: begin: %w", errBegin)
+	}
+	if _, errDelete := tx.ExecContext(ctx, "DELETE FROM usage_accounting_budget_overrides"); errDelete != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("sqlite usage store: clear overrides: %w", errDelete)
+	}
+	for target, until := range overrides {
+		if _, errExec := tx.ExecContext(ctx, "INSERT INTO usage_accounting_budget_overrides (target, until) VALUES (?, ?)", target, until.UnixNano()); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("sqlite usage store: insert override: %w", errExec)
+		}
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("sqlite usage store: commit: %w", errCommit)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// postgresStore keeps one row per client API key, model and hour, and budget overrides in a
+// second table named after the first.
+type postgresStore struct {
+	db             *sql.DB
+	table          string
+	overridesTable string
+}
+
+func newPostgresStore(ctx context.Context, dsn, table string) (*postgresStore, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("postgres usage store: DSN is required")
+	}
+	if table == "" {
+		table = defaultPostgresTable
+	}
+	db, errOpen := sql.Open("pgx", dsn)
+	if errOpen != nil {
+		return nil, fmt.Errorf("postgres usage store: open database: %w", errOpen)
+	}
+	s := &postgresStore{db: db, table: quoteIdentifier(table), overridesTable: quoteIdentifier(table + "_budget_overrides")}
+	if _, errCreate := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			api_key TEXT NOT NULL,
+			key_label TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL,
+			hour TIMESTAMPTZ NOT NULL,
+			requests BIGINT NOT NULL DEFAULT 0,
+			failed_requests BIGINT NOT NULL DEFAULT 0,
+			prompt_tokens BIGINT NOT NULL DEFAULT 0,
+			completion_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0,
+			estimated_cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+			PRIMARY KEY (api_key, model, hour)
+		)
+	`, s.table)); errCreate != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("postgres usage store: create table: %w", errCreate)
+	}
+	if _, errCreate := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			target TEXT PRIMARY KEY,
+			until TIMESTAMPTZ NOT NULL
+		)
+	`, s.overridesTable)); errCreate != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("postgres usage store: create overrides table: %w", errCreate)
+	}
+	return s, nil
+}
+
+func (s *postgresStore) Load(ctx context.Context) ([]Row, error) {
+	rows, errQuery := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT api_key, key_label, model, hour, requests, failed_requests, prompt_tokens, completion_tokens, total_tokens, estimated_cost FROM %s", s.table))
+	if errQuery != nil {
+		return nil, fmt.Errorf("postgres usage store: load: %w", errQuery)
+	}
+	defer rows.Close()
+	var out []Row
+	for rows.Next() {
+		var row Row
+		if errScan := rows.Scan(&row.APIKey, &row.KeyLabel, &row.Model, &row.Hour, &row.Requests, &row.FailedRequests, &row.PromptTokens, &row.CompletionTokens, &row.TotalTokens, &row.EstimatedCost); errScan != nil {
+			return nil, fmt.Errorf("postgres usage store: scan: %w", errScan)
+		}
+		out = append(out, row)
+	}
+	if errRows := rows.Err(); errRows != nil {
+		return nil, errRows
+	}
+	return out, nil
+}
+
+func (s *postgresStore) Save(ctx context.Context, rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tx, errBegin := s.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("postgres usage store: begin: %w", errBegin)
+	}
+	if errUpsert := s.upsert(ctx, tx, rows); errUpsert != nil {
+		_ = tx.Rollback()
+		return errUpsert
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("postgres usage store: commit: %w", errCommit)
+	}
+	return nil
+}
+
+func (s *postgresStore) upsert(ctx context.Context, tx *sql.Tx, rows []Row) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (api_key, key_label, model, hour, requests, failed_requests, prompt_tokens, completion_tokens, total_tokens, estimated_cost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (api_key, model, hour) DO UPDATE SET
+			key_label = EXCLUDED.key_label,
+			requests = EXCLUDED.requests,
+			failed_requests = EXCLUDED.failed_requests,
+			prompt_tokens = EXCLUDED.prompt_tokens,
+			completion_tokens = EXCLUDED.completion_tokens,
+			total_tokens = EXCLUDED.total_tokens,
+			estimated_cost = EXCLUDED.estimated_cost
+	`, s.table)
+	for _, row := range rows {
+		if _, errExec := tx.ExecContext(ctx, query, row.APIKey, row.KeyLabel, row.Model, row.Hour.UTC(), row.Requests, row.FailedRequests, row.PromptTokens, row.CompletionTokens, row.TotalTokens, row.EstimatedCost); errExec != nil {
+			return fmt.Errorf("postgres usage store: upsert: %w", errExec)
+		}
+	}
+	return nil
+}
+
+func (s *postgresStore) Prune(ctx context.Context, before time.Time) error {
+	if _, errDelete := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE hour < $1", s.table), before.UTC()); errDelete != nil {
+		return fmt.Errorf("postgres usage store: prune: %w", errDelete)
+	}
+	return nil
+}
+
+func (s *postgresStore) LoadOverrides(ctx context.Context) (map[string]time.Time, error) {
+	rows, errQuery := s.db.QueryContext(ctx, fmt.Sprintf("SELECT target, until FROM %s", s.overridesTable))
+	if errQuery != nil {
+		return nil, fmt.Errorf("postgres usage store: load overrides: %w", errQuery)
+	}
+	defer rows.Close()
+	overrides := make(map[string]time.Time)
+	for rows.Next() {
+		var target string
+		var until time.Time
+		if errScan := rows.Scan(&target, &until); errScan != nil {
+			return nil, fmt.Errorf("postgres usage store: scan override: %w", errScan)
+		}
+		overrides[target] = until
+	}
+	return overrides, rows.Err()
+}
+
+func (s *postgresStore) SaveOverrides(ctx context.Context, overrides map[string]time.Time) error {
+	tx, errBegin := s.db.BeginTx(ctx, nil)
+	if errBegin != nil {
+		return fmt.Errorf("postgres usage store: begin: %w", errBegin)
+	}
+	if _, errDelete := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", s.overridesTable)); errDelete != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("postgres usage store: clear overrides: %w", errDelete)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (target, until) VALUES ($1, $2)", s.overridesTable)
+	for target, until := range overrides {
+		if _, errExec := tx.ExecContext(ctx, query, target, until.UTC()); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("postgres usage store: insert override: %w", errExec)
+		}
+	}
+	if errCommit := tx.Commit(); errCommit != nil {
+		return fmt.Errorf("postgres usage store: commit: %w", errCommit)
+	}
+	return nil
+}
+
+func (s *postgresStore) Close() error {
+	return s.db.Close()
+}
+
+func quoteIdentifier(identifier string) string {
+	return "\"" + strings.ReplaceAll(identifier, "\"", "\"\"") + "\""
+}
@@ -0,0 +1,761 @@
+// Package usageaccounting aggregates token usage per client API key and model,
+// persists hourly totals and enforces optional monthly token quotas and spend budgets.
+package usageaccounting
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduler/jobs"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sharedstate"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// flushInterval controls how often dirty hourly buckets are written to the store.
+const flushInterval = 30 * time.Second
+
+// defaultRetention is how long hourly buckets are kept when retention-days is not set, and
+// pruneInterval how often buckets past their retention are deleted.
+const (
+	defaultRetention = 90 * 24 * time.Hour
+	pruneInterval    = time.Hour
+)
+
+// Totals accumulates request and token counts and the estimated USD cost.
+type Totals struct {
+	Requests         int64   `json:"requests"`
+	FailedRequests   int64   `json:"failed_requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	EstimatedCost    float64 `json:"estimated_cost"`
+}
+
+func (t *Totals) add(other Totals) {
+	t.Requests += other.Requests
+	t.FailedRequests += other.FailedRequests
+	t.PromptTokens += other.PromptTokens
+	t.CompletionTokens += other.CompletionTokens
+	t.TotalTokens += other.TotalTokens
+	t.EstimatedCost += other.EstimatedCost
+}
+
+// Row is the persisted hourly bucket for one client API key and model. APIKey holds the hashed
+// key and KeyLabel its masked form; rows written before keys were hashed have no KeyLabel and
+// are migrated by the store on load.
+type Row struct {
+	APIKey   string    `json:"api_key"`
+	KeyLabel string    `json:"key_label,omitempty"`
+	Model    string    `json:"model"`
+	Hour     time.Time `json:"hour"`
+	Totals
+}
+
+type rowKey struct {
+	apiKey string
+	model  string
+	hour   int64
+}
+
+type monthKey struct {
+	apiKey string
+	month  string
+}
+
+// Tracker records usage events delivered by the usage manager. Monthly quota consumption is
+// also counted in the shared state when it is configured, so quotas hold across instances.
+// A nil Tracker records nothing and allows every request.
+type Tracker struct {
+	mu sync.Mutex
+
+	cfg     config.UsageAccountingConfig
+	storeID string
+	store   Store
+	rows    map[rowKey]*Totals
+	dirty   map[rowKey]struct{}
+	monthly map[monthKey]int64
+	// labels maps hashed key ids to the masked key shown in reports.
+	labels map[string]string
+	// lastPrune is when buckets past their retention were last deleted.
+	lastPrune time.Time
+	// seeded holds the shared monthly counters this instance has seeded from its persisted totals.
+	seeded map[monthKey]struct{}
+	cancel context.CancelFunc
+
+	// tenantOf maps the hashed ids of tenant API keys to their tenant; tenantQuotas holds the
+	// monthly token quota shared by the keys of each tenant.
+	tenantOf     map[string]string
+	tenantQuotas map[string]int64
+
+	// budgets counts the spend of keys and tenants with a budget in the current period;
+	// overrides admits a key or tenant, by hashed ID, past its budgets until the given time.
+	budgets   map[budgetKey]*budgetCounter
+	overrides map[string]time.Time
+
+	// notified holds, per exceeded quota or budget, when its period ends, so its lifecycle event
+	// is emitted once per period whichever route rejected the request.
+	notified map[string]time.Time
+
+	shared *sharedstate.State
+	clock  clock.Clock
+}
+
+// NewTracker creates a disabled tracker. Call Apply to enable it.
+func NewTracker() *Tracker {
+	return &Tracker{
+		rows:      make(map[rowKey]*Totals),
+		dirty:     make(map[rowKey]struct{}),
+		monthly:   make(map[monthKey]int64),
+		labels:    make(map[string]string),
+		seeded:    make(map[monthKey]struct{}),
+		budgets:   make(map[budgetKey]*budgetCounter),
+		overrides: make(map[string]time.Time),
+		notified:  make(map[string]time.Time),
+		shared:    sharedstate.Default(),
+		clock:     clock.Default(),
+	}
+}
+
+// Apply updates the configuration, reopening the store when its settings change.
+func (t *Tracker) Apply(cfg config.UsageAccountingConfig, authDir string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !cfg.Enable {
+		t.stopLocked()
+		t.cfg = cfg
+		return
+	}
+	if cfg.Store == "" {
+		cfg.Store = config.UsageAccountingStoreMemory
+	}
+	cfg.Budgets = append([]config.UsageBudget(nil), cfg.Budgets...)
+	for i := range cfg.Budgets {
+		if cfg.Budgets[i].Period != config.UsageBudgetDaily {
+			cfg.Budgets[i].Period = config.UsageBudgetMonthly
+		}
+	}
+	storeID := storeIdentity(cfg, authDir)
+	t.cfg = cfg
+	if t.store != nil && t.storeID == storeID {
+		t.rebuildBudgetCountersLocked()
+		return
+	}
+	t.stopLocked()
+
+	store, errOpen := openStore(cfg, authDir)
+	if errOpen != nil {
+		log.Errorf("usage accounting: failed to open %s store: %v", cfg.Store, errOpen)
+		store = newMemoryStore()
+	}
+	rows, errLoad := store.Load(context.Background())
+	if errLoad != nil {
+		log.Errorf("usage accounting: failed to load usage: %v", errLoad)
+	}
+	for _, row := range rows {
+		key := rowKey{apiKey: row.APIKey, model: row.Model, hour: row.Hour.UTC().Unix()}
+		totals := row.Totals
+		t.rows[key] = &totals
+		t.monthly[monthKey{apiKey: row.APIKey, month: monthOf(row.Hour)}] += row.TotalTokens
+		t.labels[row.APIKey] = row.KeyLabel
+	}
+	t.rebuildTenantCountersLocked()
+	t.rebuildBudgetCountersLocked()
+	t.loadOverridesLocked(store)
+	t.store = store
+	t.storeID = storeID
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	go t.run(ctx)
+	log.Infof("usage accounting started (store=%s)", cfg.Store)
+}
+
+// SetTenants applies the tenants whose keys share a monthly token quota and budgets.
+func (t *Tracker) SetTenants(tenants []config.Tenant) {
+	if t == nil {
+		return
+	}
+	tenantOf := make(map[string]string)
+	tenantQuotas := make(map[string]int64)
+	for _, tenant := range tenants {
+		if tenant.MonthlyTokens > 0 {
+			tenantQuotas[tenant.Name] = tenant.MonthlyTokens
+		}
+		for _, key := range tenant.APIKeys {
+			tenantOf[keyID(key)] = tenant.Name
+		}
+	}
+	t.mu.Lock()
+	t.tenantOf = tenantOf
+	t.tenantQuotas = tenantQuotas
+	t.rebuildTenantCountersLocked()
+	t.rebuildBudgetCountersLocked()
+	t.mu.Unlock()
+}
+
+// rebuildTenantCountersLocked recomputes the monthly tenant counters from the hourly buckets.
+func (t *Tracker) rebuildTenantCountersLocked() {
+	for key := range t.monthly {
+		if strings.HasPrefix(key.apiKey, tenantCounterPrefix) {
+			delete(t.monthly, key)
+		}
+	}
+	for key, totals := range t.rows {
+		if tenant, ok := t.tenantOf[key.apiKey]; ok {
+			t.monthly[monthKey{apiKey: tenantCounterID(tenant), month: monthOf(time.Unix(key.hour, 0))}] += totals.TotalTokens
+		}
+	}
+}
+
+// Stop flushes pending buckets and closes the store.
+func (t *Tracker) Stop() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.stopLocked()
+	t.mu.Unlock()
+}
+
+func (t *Tracker) stopLocked() {
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	if t.store == nil {
+		return
+	}
+	t.flushLocked()
+	if errClose := t.store.Close(); errClose != nil {
+		log.Errorf("usage accounting: failed to close store: %v", errClose)
+	}
+	t.store = nil
+	t.storeID = ""
+	t.rows = make(map[rowKey]*Totals)
+	t.dirty = make(map[rowKey]struct{})
+	t.monthly = make(map[monthKey]int64)
+	t.labels = make(map[string]string)
+	t.lastPrune = time.Time{}
+	t.seeded = make(map[monthKey]struct{})
+	t.budgets = make(map[budgetKey]*budgetCounter)
+	log.Info("usage accounting stopped")
+}
+
+func (t *Tracker) run(ctx context.Context) {
+	ticker := t.clock.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if !jobs.Scheduled(jobs.UsageFlush) {
+				t.Flush()
+			}
+		}
+	}
+}
+
+// Flush writes dirty hourly buckets to the store.
+func (t *Tracker) Flush() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flushLocked()
+}
+
+func (t *Tracker) flushLocked() {
+	if t.store == nil {
+		return
+	}
+	t.pruneLocked()
+	if len(t.dirty) == 0 {
+		return
+	}
+	rows := make([]Row, 0, len(t.dirty))
+	for key := range t.dirty {
+		if totals, ok := t.rows[key]; ok {
+			rows = append(rows, Row{APIKey: key.apiKey, KeyLabel: t.labels[key.apiKey], Model: key.model, Hour: time.Unix(key.hour, 0).UTC(), Totals: *totals})
+		}
+	}
+	if errSave := t.store.Save(context.Background(), rows); errSave != nil {
+		log.Errorf("usage accounting: failed to persist usage: %v", errSave)
+		return
+	}
+	t.dirty = make(map[rowKey]struct{})
+}
+
+// pruneLocked deletes the hourly buckets older than the retention, at most once per
+// pruneInterval. Buckets of the current month are kept so monthly quotas still see them.
+func (t *Tracker) pruneLocked() {
+	if t.cfg.RetentionDays < 0 {
+		return
+	}
+	now := t.clock.Now().UTC()
+	if !t.lastPrune.IsZero() && now.Sub(t.lastPrune) < pruneInterval {
+		return
+	}
+	t.lastPrune = now
+	retention := defaultRetention
+	if t.cfg.RetentionDays > 0 {
+		retention = time.Duration(t.cfg.RetentionDays) * 24 * time.Hour
+	}
+	cutoff := now.Add(-retention).Truncate(time.Hour)
+	if monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC); monthStart.Before(cutoff) {
+		cutoff = monthStart
+	}
+	if errPrune := t.store.Prune(context.Background(), cutoff); errPrune != nil {
+		log.Errorf("usage accounting: failed to prune usage: %v", errPrune)
+		return
+	}
+	for key := range t.rows {
+		if key.hour < cutoff.Unix() {
+			delete(t.rows, key)
+			delete(t.dirty, key)
+		}
+	}
+	cutoffMonth := monthOf(cutoff)
+	for key := range t.monthly {
+		if key.month < cutoffMonth {
+			delete(t.monthly, key)
+		}
+	}
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (t *Tracker) HandleUsage(_ context.Context, record coreusage.Record) {
+	if t == nil {
+		return
+	}
+	apiKey := strings.TrimSpace(record.APIKey)
+	if apiKey == "" {
+		return
+	}
+	model := strings.TrimSpace(record.Model)
+	if model == "" {
+		model = "unknown"
+	}
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = t.clock.Now()
+	}
+	detail := coreusage.EnsureTokenBreakdownForProvider(record.Detail, record.Provider, record.ExecutorType)
+	delta := Totals{
+		Requests:         1,
+		PromptTokens:     detail.TokenBreakdown.Input.TotalTokens,
+		CompletionTokens: detail.TokenBreakdown.Output.TotalTokens,
+		TotalTokens:      detail.TokenBreakdown.TotalTokens,
+		EstimatedCost:    record.EstimatedCost,
+	}
+	if record.Failed {
+		delta.FailedRequests = 1
+	}
+
+	id := keyID(apiKey)
+
+	t.mu.Lock()
+	if t.store == nil {
+		t.mu.Unlock()
+		return
+	}
+	t.labels[id] = util.HideAPIKey(apiKey)
+	key := rowKey{apiKey: id, model: model, hour: timestamp.UTC().Truncate(time.Hour).Unix()}
+	totals, ok := t.rows[key]
+	if !ok {
+		totals = &Totals{}
+		t.rows[key] = totals
+	}
+	totals.add(delta)
+	t.dirty[key] = struct{}{}
+	month := monthOf(timestamp)
+	counters := t.spendIDsLocked(id)
+	previous := make([]int64, len(counters))
+	for i, id := range counters {
+		key := monthKey{apiKey: id, month: month}
+		previous[i] = t.monthly[key]
+		t.monthly[key] += delta.TotalTokens
+	}
+	charged := t.addBudgetSpendLocked(counters, timestamp, delta)
+	t.mu.Unlock()
+
+	t.shareBudgetSpend(charged, timestamp, delta)
+	if delta.TotalTokens <= 0 {
+		return
+	}
+	expireAt := monthCounterExpiry(month)
+	for i, id := range counters {
+		t.seedMonthCounter(id, month, previous[i])
+		if _, errAdd := t.shared.Add(monthCounterKey(id, month), delta.TotalTokens, expireAt); errAdd != nil && !errors.Is(errAdd, sharedstate.ErrDisabled) {
+			log.Debugf("usage accounting: failed to update shared quota counter: %v", errAdd)
+		}
+	}
+}
+
+// seedMonthCounter creates the shared monthly counter of id from local, the persisted total seen
+// by this instance, unless another instance created it first. Without it a counter created after
+// usage was persisted, such as when shared state is enabled mid-month, would start from zero.
+// Each counter is seeded once per instance.
+func (t *Tracker) seedMonthCounter(id, month string, local int64) {
+	key := monthKey{apiKey: id, month: month}
+	t.mu.Lock()
+	_, done := t.seeded[key]
+	t.mu.Unlock()
+	if done {
+		return
+	}
+	if _, errSeed := t.shared.Seed(monthCounterKey(id, month), local, monthCounterExpiry(month)); errSeed != nil {
+		if !errors.Is(errSeed, sharedstate.ErrDisabled) {
+			log.Debugf("usage accounting: failed to seed shared quota counter: %v", errSeed)
+		}
+		return
+	}
+	t.mu.Lock()
+	t.seeded[key] = struct{}{}
+	t.mu.Unlock()
+}
+
+// quotaLimit is one monthly token quota applying to a request: the key's own or its tenant's.
+type quotaLimit struct {
+	id        string
+	quota     int64
+	local     int64
+	warnUntil time.Time
+}
+
+// quotaLimitsLocked lists the quotas applying to apiKey in month.
+func (t *Tracker) quotaLimitsLocked(apiKey, month string) []quotaLimit {
+	var limits []quotaLimit
+	id := keyID(apiKey)
+	if quota := t.cfg.MonthlyTokenQuota(apiKey); quota > 0 {
+		limits = append(limits, quotaLimit{
+			id:        id,
+			quota:     quota,
+			local:     t.monthly[monthKey{apiKey: id, month: month}],
+			warnUntil: t.cfg.QuotaWarnOnlyDeadline(apiKey),
+		})
+	}
+	if tenant, ok := t.tenantOf[id]; ok && t.tenantQuotas[tenant] > 0 {
+		id := tenantCounterID(tenant)
+		limits = append(limits, quotaLimit{
+			id:        id,
+			quota:     t.tenantQuotas[tenant],
+			local:     t.monthly[monthKey{apiKey: id, month: month}],
+			warnUntil: config.WarnOnlyDeadline("", t.cfg.WarnOnlyUntil),
+		})
+	}
+	return limits
+}
+
+// monthUsage returns the tokens the key or tenant id consumed in month across all instances,
+// falling back to local, the count seen by this instance, when the shared state is unavailable.
+func (t *Tracker) monthUsage(id, month string, local int64) int64 {
+	t.seedMonthCounter(id, month, local)
+	used, errCounter := t.shared.Counter(monthCounterKey(id, month))
+	if errCounter != nil {
+		if !errors.Is(errCounter, sharedstate.ErrDisabled) {
+			log.Debugf("usage accounting: failed to read shared quota counter: %v", errCounter)
+		}
+		return local
+	}
+	return used
+}
+
+// Allow reports whether apiKey is still within its monthly token quota. When the
+// quota is exhausted it also returns the time remaining until the quota resets.
+func (t *Tracker) Allow(apiKey string) (bool, time.Duration) {
+	allowed, _, retryAfter := t.Check(apiKey)
+	return allowed, retryAfter
+}
+
+// Check is Allow that also reports warned when the key exceeded a quota that is still in its
+// warn-only period and was admitted only because of it.
+func (t *Tracker) Check(apiKey string) (allowed, warned bool, retryAfter time.Duration) {
+	if t == nil || apiKey == "" {
+		return true, false, 0
+	}
+	t.mu.Lock()
+	if t.store == nil {
+		t.mu.Unlock()
+		return true, false, 0
+	}
+	now := t.clock.Now().UTC()
+	month := monthOf(now)
+	limits := t.quotaLimitsLocked(apiKey, month)
+	t.mu.Unlock()
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	for _, limit := range limits {
+		if t.monthUsage(limit.id, month, limit.local) < limit.quota {
+			continue
+		}
+		if !now.Before(limit.warnUntil) {
+			return false, false, nextMonth.Sub(now)
+		}
+		warned = true
+	}
+	if warned {
+		return true, true, nextMonth.Sub(now)
+	}
+	return true, false, 0
+}
+
+// QuotaRemaining returns the monthly token quota of apiKey and the tokens left in the
+// current month, from its own or its tenant's quota, whichever has fewer tokens left. ok is
+// false when no quota applies to the key.
+func (t *Tracker) QuotaRemaining(apiKey string) (quota, remaining int64, ok bool) {
+	if t == nil || apiKey == "" {
+		return 0, 0, false
+	}
+	t.mu.Lock()
+	if t.store == nil {
+		t.mu.Unlock()
+		return 0, 0, false
+	}
+	month := monthOf(t.clock.Now().UTC())
+	limits := t.quotaLimitsLocked(apiKey, month)
+	t.mu.Unlock()
+	for _, limit := range limits {
+		left := max(0, limit.quota-t.monthUsage(limit.id, month, limit.local))
+		if !ok || left < remaining {
+			quota, remaining, ok = limit.quota, left, true
+		}
+	}
+	return quota, remaining, ok
+}
+
+// FirstRejection records that the quota or budget id rejected a request until the period ending
+// at until and reports whether this is the first rejection of that period. Callers emit the
+// exceeded lifecycle event only for the first one. Entries of ended periods are dropped.
+func (t *Tracker) FirstRejection(id string, until time.Time) bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now()
+	for key, end := range t.notified {
+		if !now.Before(end) {
+			delete(t.notified, key)
+		}
+	}
+	if _, seen := t.notified[id]; seen {
+		return false
+	}
+	t.notified[id] = until
+	return true
+}
+
+// Enabled reports whether the tracker is currently recording usage.
+func (t *Tracker) Enabled() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.store != nil
+}
+
+// Filter narrows a usage report. Zero values match everything.
+type Filter struct {
+	From   time.Time
+	To     time.Time
+	APIKey string
+	Model  string
+}
+
+// Entry is the aggregated usage of one client API key and model within a report. KeyID is the
+// hashed key and APIKey its masked form.
+type Entry struct {
+	KeyID  string `json:"key_id"`
+	APIKey string `json:"api_key"`
+	Model  string `json:"model"`
+	Totals
+}
+
+// QuotaStatus reports the current month's consumption against a configured quota.
+type QuotaStatus struct {
+	APIKey        string `json:"api_key,omitempty"`
+	Tenant        string `json:"tenant,omitempty"`
+	Month         string `json:"month"`
+	MonthlyTokens int64  `json:"monthly_tokens"`
+	UsedTokens    int64  `json:"used_tokens"`
+	Exceeded      bool   `json:"exceeded"`
+}
+
+// Report is the usage summary returned by the management API.
+type Report struct {
+	Totals  Totals        `json:"totals"`
+	Entries []Entry       `json:"entries"`
+	Quotas  []QuotaStatus `json:"quotas,omitempty"`
+}
+
+// Query aggregates hourly buckets matching filter. From is inclusive and To is exclusive;
+// buckets are matched by the start of their hour.
+func (t *Tracker) Query(filter Filter) Report {
+	report := Report{Entries: []Entry{}}
+	if t == nil {
+		return report
+	}
+	filterID := ""
+	if filter.APIKey != "" {
+		filterID = keyID(filter.APIKey)
+	}
+	t.mu.Lock()
+	entries := make(map[[2]string]*Entry)
+	for key, totals := range t.rows {
+		if filterID != "" && key.apiKey != filterID {
+			continue
+		}
+		if filter.Model != "" && !strings.EqualFold(key.model, filter.Model) {
+			continue
+		}
+		hour := time.Unix(key.hour, 0)
+		if !filter.From.IsZero() && hour.Before(filter.From.Truncate(time.Hour)) {
+			continue
+		}
+		if !filter.To.IsZero() && !hour.Before(filter.To) {
+			continue
+		}
+		id := [2]string{key.apiKey, key.model}
+		entry, ok := entries[id]
+		if !ok {
+			entry = &Entry{KeyID: key.apiKey, APIKey: t.labels[key.apiKey], Model: key.model}
+			entries[id] = entry
+		}
+		entry.add(*totals)
+		report.Totals.add(*totals)
+	}
+	for _, entry := range entries {
+		report.Entries = append(report.Entries, *entry)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+		if a.APIKey != b.APIKey {
+			return a.APIKey < b.APIKey
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.KeyID < b.KeyID
+	})
+
+	month := monthOf(t.clock.Now())
+	quotas := t.cfg.Quotas
+	local := make(map[string]int64, len(quotas)+len(t.tenantQuotas))
+	for _, quota := range quotas {
+		local[quota.APIKey] = t.monthly[monthKey{apiKey: keyID(quota.APIKey), month: month}]
+	}
+	tenantQuotas := make(map[string]int64, len(t.tenantQuotas))
+	for tenant, quota := range t.tenantQuotas {
+		if filterID == "" || t.tenantOf[filterID] == tenant {
+			tenantQuotas[tenant] = quota
+			local[tenantCounterID(tenant)] = t.monthly[monthKey{apiKey: tenantCounterID(tenant), month: month}]
+		}
+	}
+	t.mu.Unlock()
+
+	for _, quota := range quotas {
+		if filter.APIKey != "" && quota.APIKey != filter.APIKey {
+			continue
+		}
+		used := t.monthUsage(keyID(quota.APIKey), month, local[quota.APIKey])
+		report.Quotas = append(report.Quotas, QuotaStatus{
+			APIKey:        quota.APIKey,
+			Month:         month,
+			MonthlyTokens: quota.MonthlyTokens,
+			UsedTokens:    used,
+			Exceeded:      used >= quota.MonthlyTokens,
+		})
+	}
+	tenants := make([]string, 0, len(tenantQuotas))
+	for tenant := range tenantQuotas {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		used := t.monthUsage(tenantCounterID(tenant), month, local[tenantCounterID(tenant)])
+		report.Quotas = append(report.Quotas, QuotaStatus{
+			Tenant:        tenant,
+			Month:         month,
+			MonthlyTokens: tenantQuotas[tenant],
+			UsedTokens:    used,
+			Exceeded:      used >= tenantQuotas[tenant],
+		})
+	}
+	return report
+}
+
+// tenantCounterPrefix keeps tenant counters apart from the per-key monthly counters.
+const tenantCounterPrefix = "tenant\x00"
+
+func tenantCounterID(tenant string) string {
+	return tenantCounterPrefix + tenant
+}
+
+// keyID is the identity a client API key is counted and stored under.
+func keyID(apiKey string) string {
+	return sharedstate.HashKey(apiKey)
+}
+
+// hashedID returns the key or tenant id as used in shared state keys and budget overrides. Key
+// ids are already hashed.
+func hashedID(id string) string {
+	if strings.HasPrefix(id, tenantCounterPrefix) {
+		return sharedstate.HashKey(id)
+	}
+	return id
+}
+
+func monthCounterKey(id, month string) string {
+	return "quota:" + hashedID(id) + ":" + month
+}
+
+// monthCounterExpiry keeps the counter of month through the following month so late reports
+// still find it.
+func monthCounterExpiry(month string) time.Time {
+	start, errParse := time.Parse("2006-01", month)
+	if errParse != nil {
+		start = time.Now().UTC()
+	}
+	return time.Date(start.Year(), start.Month()+2, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthOf(ts time.Time) string {
+	return ts.UTC().Format("2006-01")
+}
+
+func storeIdentity(cfg config.UsageAccountingConfig, authDir string) string {
+	switch cfg.Store {
+	case config.UsageAccountingStoreSQLite:
+		return cfg.Store + "|" + resolveSQLitePath(cfg, authDir)
+	case config.UsageAccountingStorePostgres:
+		return cfg.Store + "|" + cfg.DSN + "|" + cfg.Table
+	default:
+		return config.UsageAccountingStoreMemory
+	}
+}
+
+func resolveSQLitePath(cfg config.UsageAccountingConfig, authDir string) string {
+	if cfg.Path != "" {
+		return cfg.Path
+	}
+	dir, errResolve := util.ResolveAuthDir(authDir)
+	if errResolve != nil {
+		log.Warnf("usage accounting: %v", errResolve)
+		dir = "."
+	}
+	return filepath.Join(dir, config.DefaultUsageAccountingFile)
+}
@@ -0,0 +1,261 @@
+package usageaccounting
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func newTestTracker(t *testing.T, cfg config.UsageAccountingConfig, now time.Time) *Tracker {
+	t.Helper()
+	tracker := NewTracker()
+	tracker.clock = clock.NewSim(now)
+	cfg.Enable = true
+	tracker.Apply(cfg, t.TempDir())
+	t.Cleanup(tracker.Stop)
+	return tracker
+}
+
+func usageRecord(apiKey, model string, at time.Time, input, output int64) coreusage.Record {
+	return coreusage.Record{
+		Provider:    "openai-compatibility",
+		Model:       model,
+		APIKey:      apiKey,
+		RequestedAt: at,
+		Detail:      coreusage.Detail{InputTokens: input, OutputTokens: output},
+	}
+}
+
+func TestTrackerQueryFiltersByKeyModelAndRange(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 30, 0, 0, time.UTC)
+	tracker := newTestTracker(t, config.UsageAccountingConfig{}, now)
+
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now.Add(-48*time.Hour), 10, 5))
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now, 20, 10))
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "claude", now, 1, 1))
+	tracker.HandleUsage(context.Background(), usageRecord("key-b", "gpt-5", now, 100, 50))
+	tracker.HandleUsage(context.Background(), usageRecord("", "gpt-5", now, 100, 50))
+
+	all := tracker.Query(Filter{})
+	if all.Totals.Requests != 4 || all.Totals.TotalTokens != 197 {
+		t.Fatalf("totals = %+v, want 4 requests and 197 tokens", all.Totals)
+	}
+
+	report := tracker.Query(Filter{APIKey: "key-a", Model: "gpt-5", From: now.Add(-time.Hour)})
+	if len(report.Entries) != 1 {
+		t.Fatalf("entries = %+v, want one", report.Entries)
+	}
+	entry := report.Entries[0]
+	if entry.Requests != 1 || entry.PromptTokens != 20 || entry.CompletionTokens != 10 || entry.TotalTokens != 30 {
+		t.Fatalf("entry = %+v, want only the in-range request", entry)
+	}
+
+	if got := tracker.Query(Filter{To: now.Add(-24 * time.Hour)}); got.Totals.TotalTokens != 15 {
+		t.Fatalf("to-filtered tokens = %d, want 15", got.Totals.TotalTokens)
+	}
+}
+
+func TestTrackerAllowEnforcesMonthlyQuota(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, config.UsageAccountingConfig{Quotas: []config.UsageQuota{{APIKey: "key-a", MonthlyTokens: 100}}}, now)
+
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now.Add(-40*24*time.Hour), 500, 500))
+	if ok, _ := tracker.Allow("key-a"); !ok {
+		t.Fatal("expected usage from last month to be ignored")
+	}
+
+	if quota, remaining, ok := tracker.QuotaRemaining("key-a"); !ok || quota != 100 || remaining != 100 {
+		t.Fatalf("QuotaRemaining = (%d, %d, %v), want (100, 100, true)", quota, remaining, ok)
+	}
+
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now, 60, 40))
+	if _, remaining, _ := tracker.QuotaRemaining("key-a"); remaining != 0 {
+		t.Fatalf("remaining = %d, want 0", remaining)
+	}
+	if _, _, ok := tracker.QuotaRemaining("key-b"); ok {
+		t.Fatal("expected no quota for key-b")
+	}
+	ok, retryAfter := tracker.Allow("key-a")
+	if ok {
+		t.Fatal("expected quota to be exhausted")
+	}
+	if retryAfter != time.Hour {
+		t.Fatalf("retryAfter = %v, want time until next month", retryAfter)
+	}
+	if ok, _ = tracker.Allow("key-b"); !ok {
+		t.Fatal("expected key without quota to be allowed")
+	}
+
+	report := tracker.Query(Filter{APIKey: "key-a"})
+	if len(report.Quotas) != 1 || !report.Quotas[0].Exceeded || report.Quotas[0].UsedTokens != 100 {
+		t.Fatalf("quotas = %+v, want exceeded with 100 used", report.Quotas)
+	}
+}
+
+func TestTrackerSQLiteStorePersistsAcrossRestart(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "usage.db")
+	cfg := config.UsageAccountingConfig{Enable: true, Store: config.UsageAccountingStoreSQLite, Path: path}
+
+	first := NewTracker()
+	first.clock = clock.NewSim(now)
+	first.Apply(cfg, "")
+	first.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now, 7, 3))
+	first.Stop()
+
+	second := NewTracker()
+	second.clock = clock.NewSim(now)
+	second.Apply(cfg, "")
+	defer second.Stop()
+
+	report := second.Query(Filter{APIKey: "key-a"})
+	if report.Totals.Requests != 1 || report.Totals.TotalTokens != 10 {
+		t.Fatalf("totals after reload = %+v, want persisted usage", report.Totals)
+	}
+}
+
+func TestTrackerStoresHashedKeys(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "usage.db")
+	tracker := newTestTracker(t, config.UsageAccountingConfig{Store: config.UsageAccountingStoreSQLite, Path: path}, now)
+
+	tracker.HandleUsage(context.Background(), usageRecord("sk-secret-client-key", "gpt-5", now, 7, 3))
+	tracker.Flush()
+
+	for _, row := range loadStoredRows(t, path) {
+		if strings.Contains(row.APIKey+row.KeyLabel, "sk-secret-client-key") {
+			t.Fatalf("stored row holds the raw key: %+v", row)
+		}
+	}
+	report := tracker.Query(Filter{APIKey: "sk-secret-client-key"})
+	if len(report.Entries) != 1 || report.Entries[0].APIKey != "sk-s...-key" || report.Entries[0].KeyID != keyID("sk-secret-client-key") {
+		t.Fatalf("entries = %+v, want one masked entry", report.Entries)
+	}
+}
+
+func TestTrackerPrunesRowsPastRetention(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "usage.db")
+	tracker := newTestTracker(t, config.UsageAccountingConfig{Store: config.UsageAccountingStoreSQLite, Path: path, RetentionDays: 7}, now)
+
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now.Add(-30*24*time.Hour), 1, 1))
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now.Add(-10*24*time.Hour), 2, 2))
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", now, 3, 3))
+	tracker.Flush()
+
+	// Buckets older than seven days but within the current month are kept for the monthly quota.
+	if got := tracker.Query(Filter{}); got.Totals.Requests != 2 || got.Totals.TotalTokens != 10 {
+		t.Fatalf("totals = %+v, want the two buckets of March", got.Totals)
+	}
+	if rows := loadStoredRows(t, path); len(rows) != 2 {
+		t.Fatalf("stored rows = %+v, want two", rows)
+	}
+}
+
+// loadStoredRows reads the rows of the sqlite store at path through a second connection.
+func loadStoredRows(t *testing.T, path string) []Row {
+	t.Helper()
+	store, errOpen := newSQLiteStore(context.Background(), path)
+	if errOpen != nil {
+		t.Fatalf("open store: %v", errOpen)
+	}
+	defer func() { _ = store.Close() }()
+	rows, errLoad := store.Load(context.Background())
+	if errLoad != nil {
+		t.Fatalf("load rows: %v", errLoad)
+	}
+	return rows
+}
+
+func TestTrackerDisabledRecordsNothing(t *testing.T) {
+	tracker := NewTracker()
+	tracker.HandleUsage(context.Background(), usageRecord("key-a", "gpt-5", time.Now(), 1, 1))
+	if tracker.Enabled() {
+		t.Fatal("expected new tracker to be disabled")
+	}
+	if got := tracker.Query(Filter{}); got.Totals.Requests != 0 {
+		t.Fatalf("totals = %+v, want none", got.Totals)
+	}
+}
+
+func TestTrackerAggregatesEstimatedCost(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, config.UsageAccountingConfig{}, now)
+
+	for _, cost := range []float64{0.25, 0.5} {
+		record := usageRecord("key-a", "gpt-5", now, 10, 5)
+		record.EstimatedCost = cost
+		tracker.HandleUsage(context.Background(), record)
+	}
+
+	report := tracker.Query(Filter{APIKey: "key-a"})
+	if report.Totals.EstimatedCost != 0.75 {
+		t.Fatalf("estimated cost = %v, want 0.75", report.Totals.EstimatedCost)
+	}
+	if len(report.Entries) != 1 || report.Entries[0].EstimatedCost != 0.75 {
+		t.Fatalf("entries = %+v, want one entry costing 0.75", report.Entries)
+	}
+}
+
+func TestTrackerTenantQuotaSharedByItsKeys(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, config.UsageAccountingConfig{}, now)
+	tracker.SetTenants([]config.Tenant{{Name: "acme", APIKeys: []string{"acme-1", "acme-2"}, MonthlyTokens: 100}})
+
+	tracker.HandleUsage(context.Background(), usageRecord("acme-1", "gpt-5", now, 40, 20))
+	if ok, _ := tracker.Allow("acme-2"); !ok {
+		t.Fatal("tenant key rejected below the tenant quota")
+	}
+	if quota, remaining, ok := tracker.QuotaRemaining("acme-2"); !ok || quota != 100 || remaining != 40 {
+		t.Fatalf("QuotaRemaining = (%d, %d, %v), want (100, 40, true)", quota, remaining, ok)
+	}
+
+	tracker.HandleUsage(context.Background(), usageRecord("acme-2", "gpt-5", now, 30, 10))
+	if ok, retryAfter := tracker.Allow("acme-1"); ok || retryAfter <= 0 {
+		t.Fatalf("Allow = (%v, %v), want rejection once the tenant quota is used up", ok, retryAfter)
+	}
+	if ok, _ := tracker.Allow("other"); !ok {
+		t.Fatal("key outside the tenant rejected")
+	}
+
+	report := tracker.Query(Filter{APIKey: "acme-1"})
+	if len(report.Quotas) != 1 || report.Quotas[0].Tenant != "acme" || report.Quotas[0].UsedTokens != 100 || !report.Quotas[0].Exceeded {
+		t.Fatalf("quotas = %+v, want the exceeded acme tenant quota", report.Quotas)
+	}
+
+	tracker.SetTenants(nil)
+	if ok, _ := tracker.Allow("acme-1"); !ok {
+		t.Fatal("key still limited after its tenant quota was removed")
+	}
+}
+
+func TestTrackerFirstRejectionOncePerPeriod(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(t, config.UsageAccountingConfig{}, now)
+
+	if !tracker.FirstRejection("quota|key-a", now.Add(time.Hour)) {
+		t.Fatal("first rejection not reported")
+	}
+	if tracker.FirstRejection("quota|key-a", now.Add(time.Hour)) {
+		t.Fatal("second rejection of the same period reported again")
+	}
+	if !tracker.FirstRejection("quota|key-b", now.Add(time.Hour)) {
+		t.Fatal("rejection of another key not reported")
+	}
+
+	tracker.clock.(*clock.Sim).Advance(time.Hour)
+	if !tracker.FirstRejection("quota|key-a", now.Add(2*time.Hour)) {
+		t.Fatal("rejection after the period ended not reported")
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if _, stale := tracker.notified["quota|key-b"]; stale {
+		t.Fatal("ended period not pruned")
+	}
+}
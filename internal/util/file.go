@@ -0,0 +1,33 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to path through a temporary file and a rename, so readers never
+// observe a partial file. Missing parent directories are created.
+func WriteFileAtomic(path string, data []byte) error {
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return errMkdir
+	}
+	tmp := path + ".tmp"
+	if errWrite := os.WriteFile(tmp, data, 0o600); errWrite != nil {
+		return errWrite
+	}
+	return os.Rename(tmp, path)
+}
+
+// ValidObjectID reports whether id is safe to use as the file name of a stored object: 1 to 128
+// ASCII letters, digits, dashes or underscores.
+func ValidObjectID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r != '-' && r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomicCreatesParents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a", "b", "object.json")
+	if errWrite := WriteFileAtomic(path, []byte("one")); errWrite != nil {
+		t.Fatalf("WriteFileAtomic: %v", errWrite)
+	}
+	if errWrite := WriteFileAtomic(path, []byte("two")); errWrite != nil {
+		t.Fatalf("WriteFileAtomic overwrite: %v", errWrite)
+	}
+	data, errRead := os.ReadFile(path)
+	if errRead != nil || string(data) != "two" {
+		t.Fatalf("ReadFile = %q, %v", data, errRead)
+	}
+	if _, errStat := os.Stat(path + ".tmp"); !os.IsNotExist(errStat) {
+		t.Fatalf("temporary file left behind: %v", errStat)
+	}
+}
+
+func TestValidObjectID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"file_011CNha8iCJcU1wXNR6q4V8w": true,
+		"batch-1":                       true,
+		"":                              false,
+		"../etc":                        false,
+		"a/b":                           false,
+		strings.Repeat("a", 129):        false,
+	} {
+		if got := ValidObjectID(id); got != want {
+			t.Errorf("ValidObjectID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
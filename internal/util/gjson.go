@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strings"
 	"unsafe"
 
 	"github.com/tidwall/gjson"
@@ -14,3 +15,19 @@ func GetGJSONBytesNoCopy(data []byte, path string) gjson.Result {
 	}
 	return gjson.Get(unsafe.String(unsafe.SliceData(data), len(data)), path)
 }
+
+var gjsonPathKeyEscaper = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`, "@", `\@`)
+
+// JoinGJSONPath appends key to a GJSON/SJSON path. An empty parent yields key alone.
+func JoinGJSONPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// EscapeGJSONPathKey escapes the GJSON path syntax characters in an object key so it can be
+// used as a single path component.
+func EscapeGJSONPathKey(key string) string {
+	return gjsonPathKeyEscaper.Replace(key)
+}
@@ -0,0 +1,11 @@
+package util
+
+import "strings"
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// EscapePrometheusLabel escapes value for use inside a quoted label value of the Prometheus
+// text exposition format.
+func EscapePrometheusLabel(value string) string {
+	return prometheusLabelEscaper.Replace(value)
+}
@@ -0,0 +1,9 @@
+package util
+
+import "testing"
+
+func TestEscapePrometheusLabel(t *testing.T) {
+	if got, want := EscapePrometheusLabel("a\\b\"c\nd"), `a\\b\"c\nd`; got != want {
+		t.Fatalf("EscapePrometheusLabel() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package vision
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+)
+
+// maxDownscaleAttempts bounds how often Downscale shrinks an image that is still too large.
+const maxDownscaleAttempts = 6
+
+const downscaleJPEGQuality = 85
+
+// maxDecodePixels caps the declared size of images Downscale decodes. A small file can declare
+// huge dimensions, and decoding allocates memory for every declared pixel.
+const maxDecodePixels = 48_000_000
+
+// ErrTooLarge reports that an image could not be brought within the limits.
+var ErrTooLarge = errors.New("vision: image exceeds provider limits after downscaling")
+
+// ErrTooManyPixels reports an image whose declared dimensions exceed the decode budget.
+var ErrTooManyPixels = errors.New("vision: image dimensions exceed the decode budget")
+
+// Downscale re-encodes data when it exceeds limits, shrinking it until both the dimension and
+// the encoded size limits hold. It reports changed=false when the image already fits. Opaque
+// images are re-encoded as JPEG; images with transparency stay PNG.
+func Downscale(data []byte, mimeType string, limits Limits) (out []byte, outMimeType string, changed bool, err error) {
+	if limits == (Limits{}) || len(data) == 0 {
+		return data, mimeType, false, nil
+	}
+	header, _, errConfig := image.DecodeConfig(bytes.NewReader(data))
+	if errConfig != nil {
+		return data, mimeType, false, fmt.Errorf("vision: decode image header: %w", errConfig)
+	}
+	if fits(header.Width, header.Height, len(data), limits) {
+		return data, mimeType, false, nil
+	}
+	if int64(header.Width)*int64(header.Height) > maxDecodePixels {
+		return data, mimeType, false, ErrTooManyPixels
+	}
+	src, _, errDecode := image.Decode(bytes.NewReader(data))
+	if errDecode != nil {
+		return data, mimeType, false, fmt.Errorf("vision: decode image: %w", errDecode)
+	}
+
+	scale := 1.0
+	if longest := max(header.Width, header.Height); limits.MaxDimension > 0 && longest > limits.MaxDimension {
+		scale = float64(limits.MaxDimension) / float64(longest)
+	}
+	opaque := isOpaque(src)
+	for attempt := 0; attempt < maxDownscaleAttempts; attempt++ {
+		width := max(1, int(float64(header.Width)*scale))
+		height := max(1, int(float64(header.Height)*scale))
+		resized := resize(src, width, height)
+		var buf bytes.Buffer
+		outMimeType = "image/png"
+		if opaque {
+			outMimeType = "image/jpeg"
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: downscaleJPEGQuality})
+		} else {
+			err = png.Encode(&buf, resized)
+		}
+		if err != nil {
+			return data, mimeType, false, fmt.Errorf("vision: encode image: %w", err)
+		}
+		if fits(width, height, buf.Len(), limits) {
+			return buf.Bytes(), outMimeType, true, nil
+		}
+		scale *= 0.75
+	}
+	return data, mimeType, false, ErrTooLarge
+}
+
+func fits(width, height, size int, limits Limits) bool {
+	if limits.MaxDimension > 0 && max(width, height) > limits.MaxDimension {
+		return false
+	}
+	return limits.MaxEncodedBytes <= 0 || base64.StdEncoding.EncodedLen(size) <= limits.MaxEncodedBytes
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// resize scales src to width x height by averaging the source pixels covered by each
+// destination pixel.
+func resize(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	in := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(in, in.Bounds(), src, bounds.Min, draw.Src)
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	for y := 0; y < height; y++ {
+		y0 := y * srcH / height
+		y1 := max(y0+1, (y+1)*srcH/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcW / width
+			x1 := max(x0+1, (x+1)*srcW/width)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := in.Pix[sy*in.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					px := row[sx*4 : sx*4+4]
+					r += uint64(px[0])
+					g += uint64(px[1])
+					b += uint64(px[2])
+					a += uint64(px[3])
+					n++
+				}
+			}
+			out.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+	return out
+}
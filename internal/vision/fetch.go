@@ -0,0 +1,199 @@
+package vision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress reports a remote image URL that resolves to a private, loopback or
+// otherwise internal address.
+var ErrBlockedAddress = errors.New("vision: remote image address is not public")
+
+// ErrProxied reports a fetch refused because the credential's traffic goes through a proxy,
+// which would connect to an address the fetcher cannot check.
+var ErrProxied = errors.New("vision: remote images are not fetched through a proxy")
+
+// Fetcher downloads remote images over direct connections. Every connection is checked in the
+// dialer, after name resolution, so a host cannot pass the check and then resolve to an
+// internal address.
+type Fetcher struct {
+	// Timeout bounds each download, redirects included. Zero means no timeout.
+	Timeout time.Duration
+	// MaxBytes rejects larger images. Zero means unlimited.
+	MaxBytes int64
+	// Proxied refuses every fetch: the request would leave through the credential's proxy.
+	Proxied bool
+
+	// allowPrivate permits internal addresses; tests use it to reach httptest servers.
+	allowPrivate bool
+
+	clientOnce sync.Once
+	client     *http.Client
+}
+
+// Fetch downloads an http(s) image and returns its bytes and MIME type.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) ([]byte, string, error) {
+	if f == nil {
+		return nil, "", errors.New("vision: fetcher not configured")
+	}
+	if f.Proxied {
+		return nil, "", ErrProxied
+	}
+	if errCheck := f.checkURL(rawURL); errCheck != nil {
+		return nil, "", errCheck
+	}
+
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if errReq != nil {
+		return nil, "", errReq
+	}
+	req.Header.Set("Accept", "image/*")
+	resp, errDo := f.httpClient().Do(req)
+	if errDo != nil {
+		return nil, "", errDo
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("vision: fetch image: status %d", resp.StatusCode)
+	}
+	if f.MaxBytes > 0 && resp.ContentLength > f.MaxBytes {
+		return nil, "", fmt.Errorf("vision: remote image is %d bytes, limit %d", resp.ContentLength, f.MaxBytes)
+	}
+	reader := io.Reader(resp.Body)
+	if f.MaxBytes > 0 {
+		reader = io.LimitReader(resp.Body, f.MaxBytes+1)
+	}
+	data, errRead := io.ReadAll(reader)
+	if errRead != nil {
+		return nil, "", errRead
+	}
+	if f.MaxBytes > 0 && int64(len(data)) > f.MaxBytes {
+		return nil, "", fmt.Errorf("vision: remote image exceeds %d bytes", f.MaxBytes)
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, "", fmt.Errorf("vision: remote content is %s, not an image", mimeType)
+	}
+	return data, mimeType, nil
+}
+
+// httpClient returns the client of the fetcher. It never uses a proxy and rejects connections to
+// internal addresses in the dialer.
+func (f *Fetcher) httpClient() *http.Client {
+	f.clientOnce.Do(func() {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, Control: f.checkDial}
+		transport := &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			// Fetchers live for one request; idle connections would only pile up.
+			DisableKeepAlives: true,
+		}
+		f.client = &http.Client{
+			Timeout:   f.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("vision: too many redirects")
+				}
+				return f.checkURL(req.URL.String())
+			},
+		}
+	})
+	return f.client
+}
+
+// checkURL accepts http(s) URLs and rejects literal internal addresses early. Host names are
+// checked when they are dialed.
+func (f *Fetcher) checkURL(rawURL string) error {
+	parsed, errParse := url.Parse(rawURL)
+	if errParse != nil {
+		return errParse
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("vision: unsupported image URL scheme %q", parsed.Scheme)
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !f.allowPrivate && !isPublicIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// checkDial rejects connections to internal addresses. It runs for every address the dialer
+// tries, after name resolution.
+func (f *Fetcher) checkDial(_, address string, _ syscall.RawConn) error {
+	if f.allowPrivate {
+		return nil
+	}
+	host, _, errSplit := net.SplitHostPort(address)
+	if errSplit != nil {
+		return errSplit
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// blockedNetworks lists the ranges the fetcher never connects to: private, loopback,
+// link-local, shared (carrier-grade NAT, home of some cloud metadata endpoints), benchmarking,
+// documentation, NAT64 and multicast addresses. IPv4-mapped IPv6 addresses match the IPv4 ranges.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"100::/64",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, errParse := net.ParseCIDR(cidr)
+		if errParse != nil {
+			panic(errParse)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func isPublicIP(ip net.IP) bool {
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,284 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Normalizer rewrites the image content of translated upstream requests.
+type Normalizer struct {
+	// Fetcher inlines remote image URLs for Claude and Gemini requests. Nil disables fetching.
+	Fetcher *Fetcher
+	// Downscale shrinks inline images that exceed the target family's limits.
+	Downscale bool
+}
+
+// imageMarkers are substrings one of which every image-bearing payload contains.
+var imageMarkers = [][]byte{[]byte(`"image`), []byte(`"inlineData"`), []byte(`"inline_data"`), []byte(`"fileData"`), []byte(`"file_data"`), []byte(`"input_image"`)}
+
+// Normalize returns payload with remote images inlined and oversized images downscaled for
+// the upstream request format. Images that cannot be fetched or decoded are left unchanged.
+func (n *Normalizer) Normalize(ctx context.Context, format string, payload []byte) []byte {
+	if n == nil || (n.Fetcher == nil && !n.Downscale) || !gjson.ValidBytes(payload) {
+		return payload
+	}
+	family := FamilyOf(format)
+	if family == FamilyUnknown || !containsAny(payload, imageMarkers) {
+		return payload
+	}
+	type edit struct {
+		path string
+		raw  []byte
+	}
+	var edits []edit
+	var walk func(value gjson.Result, path string)
+	walk = func(value gjson.Result, path string) {
+		switch {
+		case value.IsObject():
+			if path != "" {
+				if replaced, ok := n.normalizeNode(ctx, family, value); ok {
+					edits = append(edits, edit{path: path, raw: replaced})
+					return
+				}
+			}
+			value.ForEach(func(key, child gjson.Result) bool {
+				walk(child, util.JoinGJSONPath(path, util.EscapeGJSONPathKey(key.String())))
+				return true
+			})
+		case value.IsArray():
+			for i, child := range value.Array() {
+				walk(child, util.JoinGJSONPath(path, strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(gjson.ParseBytes(payload), "")
+
+	out := payload
+	for _, e := range edits {
+		updated, errSet := sjson.SetRawBytes(out, e.path, e.raw)
+		if errSet != nil {
+			log.Debugf("vision: failed to update %s: %v", e.path, errSet)
+			continue
+		}
+		out = updated
+	}
+	return out
+}
+
+// normalizeNode returns the replacement for an image node, or ok=false when node is not an
+// image or needs no change.
+func (n *Normalizer) normalizeNode(ctx context.Context, family Family, node gjson.Result) ([]byte, bool) {
+	switch family {
+	case FamilyClaude:
+		return n.normalizeClaudeImage(ctx, node)
+	case FamilyGemini:
+		return n.normalizeGeminiPart(ctx, node)
+	case FamilyOpenAI:
+		return n.normalizeOpenAIImage(node)
+	default:
+		return nil, false
+	}
+}
+
+func (n *Normalizer) normalizeClaudeImage(ctx context.Context, node gjson.Result) ([]byte, bool) {
+	if node.Get("type").String() != "image" || !node.Get("source").IsObject() {
+		return nil, false
+	}
+	source := node.Get("source")
+	var data []byte
+	var mimeType string
+	fetched := false
+	switch source.Get("type").String() {
+	case "url":
+		if n.Fetcher == nil {
+			return nil, false
+		}
+		var errFetch error
+		data, mimeType, errFetch = n.Fetcher.Fetch(ctx, source.Get("url").String())
+		if errFetch != nil {
+			log.Warnf("vision: keeping image URL, fetch failed: %v", errFetch)
+			return nil, false
+		}
+		fetched = true
+	case "base64":
+		if !n.Downscale {
+			return nil, false
+		}
+		var ok bool
+		if data, ok = decodeBase64(source.Get("data").String()); !ok {
+			return nil, false
+		}
+		mimeType = source.Get("media_type").String()
+	default:
+		return nil, false
+	}
+	data, mimeType, changed := n.downscale(data, mimeType, LimitsFor(FamilyClaude))
+	if !fetched && !changed {
+		return nil, false
+	}
+	out := []byte(node.Raw)
+	out, _ = sjson.SetRawBytes(out, "source", []byte(`{"type":"base64","media_type":"","data":""}`))
+	out, _ = sjson.SetBytes(out, "source.media_type", mimeType)
+	out, _ = sjson.SetBytes(out, "source.data", base64.StdEncoding.EncodeToString(data))
+	return out, true
+}
+
+func (n *Normalizer) normalizeGeminiPart(ctx context.Context, node gjson.Result) ([]byte, bool) {
+	if inlineKey := firstExisting(node, "inlineData", "inline_data"); inlineKey != "" {
+		if !n.Downscale {
+			return nil, false
+		}
+		inline := node.Get(inlineKey)
+		mimeKey := firstExisting(inline, "mimeType", "mime_type")
+		mimeType := inline.Get(mimeKey).String()
+		if !strings.HasPrefix(strings.ToLower(mimeType), "image/") {
+			return nil, false
+		}
+		data, ok := decodeBase64(inline.Get("data").String())
+		if !ok {
+			return nil, false
+		}
+		data, mimeType, changed := n.downscale(data, mimeType, LimitsFor(FamilyGemini))
+		if !changed {
+			return nil, false
+		}
+		out := []byte(node.Raw)
+		out, _ = sjson.SetBytes(out, inlineKey+"."+mimeKey, mimeType)
+		out, _ = sjson.SetBytes(out, inlineKey+".data", base64.StdEncoding.EncodeToString(data))
+		return out, true
+	}
+
+	fileKey := firstExisting(node, "fileData", "file_data")
+	if fileKey == "" || n.Fetcher == nil {
+		return nil, false
+	}
+	file := node.Get(fileKey)
+	uri := file.Get(firstExisting(file, "fileUri", "file_uri")).String()
+	declared := strings.ToLower(file.Get(firstExisting(file, "mimeType", "mime_type")).String())
+	// Files API URIs are resolved by Gemini itself and cannot be fetched without credentials.
+	if !isHTTPURL(uri) || strings.Contains(strings.ToLower(uri), "generativelanguage.googleapis.com") ||
+		(declared != "" && !strings.HasPrefix(declared, "image/")) {
+		return nil, false
+	}
+	data, mimeType, errFetch := n.Fetcher.Fetch(ctx, uri)
+	if errFetch != nil {
+		log.Warnf("vision: keeping image URL, fetch failed: %v", errFetch)
+		return nil, false
+	}
+	data, mimeType, _ = n.downscale(data, mimeType, LimitsFor(FamilyGemini))
+	inlineKey, mimeKey := "inlineData", "mimeType"
+	if fileKey == "file_data" {
+		inlineKey, mimeKey = "inline_data", "mime_type"
+	}
+	out, _ := sjson.DeleteBytes([]byte(node.Raw), fileKey)
+	out, _ = sjson.SetBytes(out, inlineKey+"."+mimeKey, mimeType)
+	out, _ = sjson.SetBytes(out, inlineKey+".data", base64.StdEncoding.EncodeToString(data))
+	return out, true
+}
+
+func (n *Normalizer) normalizeOpenAIImage(node gjson.Result) ([]byte, bool) {
+	if !n.Downscale {
+		return nil, false
+	}
+	var path string
+	switch node.Get("type").String() {
+	case "image_url":
+		path = "image_url.url"
+		if node.Get("image_url").Type == gjson.String {
+			path = "image_url"
+		}
+	case "input_image":
+		path = "image_url"
+	default:
+		return nil, false
+	}
+	mimeType, encoded, ok := parseDataURL(node.Get(path).String())
+	if !ok {
+		return nil, false
+	}
+	data, ok := decodeBase64(encoded)
+	if !ok {
+		return nil, false
+	}
+	data, mimeType, changed := n.downscale(data, mimeType, LimitsFor(FamilyOpenAI))
+	if !changed {
+		return nil, false
+	}
+	out, _ := sjson.SetBytes([]byte(node.Raw), path, "data:"+mimeType+";base64,"+base64.StdEncoding.EncodeToString(data))
+	return out, true
+}
+
+func (n *Normalizer) downscale(data []byte, mimeType string, limits Limits) ([]byte, string, bool) {
+	if !n.Downscale {
+		return data, mimeType, false
+	}
+	out, outMimeType, changed, errDownscale := Downscale(data, mimeType, limits)
+	if errDownscale != nil {
+		log.Debugf("vision: keeping image unchanged: %v", errDownscale)
+		return data, mimeType, false
+	}
+	if changed {
+		log.Debugf("vision: downscaled %s image from %d to %d bytes", mimeType, len(data), len(out))
+	}
+	return out, outMimeType, changed
+}
+
+func parseDataURL(value string) (mimeType, data string, ok bool) {
+	const prefix = "data:"
+	if len(value) < len(prefix) || !strings.EqualFold(value[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	metadata, payload, found := strings.Cut(value[len(prefix):], ",")
+	if !found || payload == "" {
+		return "", "", false
+	}
+	fields := strings.Split(metadata, ";")
+	for _, field := range fields[1:] {
+		if strings.EqualFold(strings.TrimSpace(field), "base64") {
+			return strings.TrimSpace(fields[0]), payload, true
+		}
+	}
+	return "", "", false
+}
+
+func decodeBase64(value string) ([]byte, bool) {
+	if value == "" {
+		return nil, false
+	}
+	data, errDecode := base64.StdEncoding.DecodeString(value)
+	if errDecode != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func isHTTPURL(value string) bool {
+	lower := strings.ToLower(value)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+func firstExisting(node gjson.Result, keys ...string) string {
+	for _, key := range keys {
+		if node.Get(key).Exists() {
+			return key
+		}
+	}
+	return ""
+}
+
+func containsAny(payload []byte, markers [][]byte) bool {
+	for _, marker := range markers {
+		if bytes.Contains(payload, marker) {
+			return true
+		}
+	}
+	return false
+}
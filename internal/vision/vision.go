@@ -0,0 +1,56 @@
+// Package vision normalizes image content in upstream requests: it inlines remote image URLs
+// for providers that cannot fetch them and downscales images that exceed provider limits.
+package vision
+
+import "strings"
+
+// Family groups upstream request formats that share an image representation.
+type Family int
+
+const (
+	// FamilyUnknown formats are left untouched.
+	FamilyUnknown Family = iota
+	// FamilyClaude uses Anthropic image blocks with base64 or url sources.
+	FamilyClaude
+	// FamilyGemini uses inlineData and fileData parts.
+	FamilyGemini
+	// FamilyOpenAI uses image_url and input_image parts.
+	FamilyOpenAI
+)
+
+// FamilyOf returns the image family of an upstream request format such as "claude",
+// "gemini", "antigravity", "openai" or "codex".
+func FamilyOf(format string) Family {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "claude":
+		return FamilyClaude
+	case "gemini", "gemini-cli", "antigravity", "aistudio", "vertex":
+		return FamilyGemini
+	case "openai", "openai-response", "codex", "xai", "kimi":
+		return FamilyOpenAI
+	default:
+		return FamilyUnknown
+	}
+}
+
+// Limits bounds the images a provider accepts. Zero values are unlimited.
+type Limits struct {
+	// MaxEncodedBytes is the largest accepted base64 payload.
+	MaxEncodedBytes int
+	// MaxDimension is the largest accepted width or height in pixels.
+	MaxDimension int
+}
+
+// LimitsFor returns the documented per-image limits of a family.
+func LimitsFor(family Family) Limits {
+	switch family {
+	case FamilyClaude:
+		return Limits{MaxEncodedBytes: 5 << 20, MaxDimension: 8000}
+	case FamilyGemini:
+		return Limits{MaxEncodedBytes: 20 << 20, MaxDimension: 3072}
+	case FamilyOpenAI:
+		return Limits{MaxEncodedBytes: 20 << 20}
+	default:
+		return Limits{}
+	}
+}
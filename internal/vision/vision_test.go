@@ -0,0 +1,202 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func testPNG(t *testing.T, width, height int, opaque bool) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	alpha := uint8(255)
+	if !opaque {
+		alpha = 128
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 90, A: alpha})
+		}
+	}
+	var buf bytes.Buffer
+	if errEncode := png.Encode(&buf, img); errEncode != nil {
+		t.Fatalf("encode png: %v", errEncode)
+	}
+	return buf.Bytes()
+}
+
+func TestDownscaleRespectsDimensionLimit(t *testing.T) {
+	data := testPNG(t, 400, 200, true)
+
+	out, mimeType, changed, errDownscale := Downscale(data, "image/png", Limits{MaxDimension: 100})
+	if errDownscale != nil || !changed {
+		t.Fatalf("Downscale() changed=%v err=%v, want resized image", changed, errDownscale)
+	}
+	if mimeType != "image/jpeg" {
+		t.Fatalf("mime type = %q, want image/jpeg for an opaque image", mimeType)
+	}
+	header, _, errConfig := image.DecodeConfig(bytes.NewReader(out))
+	if errConfig != nil || header.Width != 100 || header.Height != 50 {
+		t.Fatalf("resized to %dx%d (err %v), want 100x50", header.Width, header.Height, errConfig)
+	}
+
+	_, _, changed, _ = Downscale(data, "image/png", Limits{MaxDimension: 400})
+	if changed {
+		t.Fatal("Downscale() changed an image within limits")
+	}
+}
+
+func TestDownscaleKeepsTransparencyAsPNG(t *testing.T) {
+	data := testPNG(t, 64, 64, false)
+
+	_, mimeType, changed, errDownscale := Downscale(data, "image/png", Limits{MaxDimension: 32})
+	if errDownscale != nil || !changed || mimeType != "image/png" {
+		t.Fatalf("Downscale() = %q changed=%v err=%v, want PNG output", mimeType, changed, errDownscale)
+	}
+}
+
+func TestDownscaleRejectsDecompressionBombs(t *testing.T) {
+	// Rewrite the IHDR chunk of a tiny PNG so it declares 50000x50000 pixels.
+	data := testPNG(t, 1, 1, true)
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], 50000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 50000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+
+	out, _, changed, errDownscale := Downscale(data, "image/png", Limits{MaxDimension: 2048})
+	if !errors.Is(errDownscale, ErrTooManyPixels) || changed || !bytes.Equal(out, data) {
+		t.Fatalf("Downscale() changed=%v err=%v, want ErrTooManyPixels and the input unchanged", changed, errDownscale)
+	}
+}
+
+func TestNormalizeInlinesRemoteImages(t *testing.T) {
+	imageData := testPNG(t, 8, 8, true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(imageData)
+	}))
+	defer server.Close()
+
+	normalizer := Normalizer{Fetcher: &Fetcher{MaxBytes: 1 << 20, allowPrivate: true}}
+	encoded := base64.StdEncoding.EncodeToString(imageData)
+
+	claude := normalizer.Normalize(context.Background(), "claude", []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"url","url":"`+server.URL+`/a.png"},"cache_control":{"type":"ephemeral"}}]}]}`))
+	block := gjson.GetBytes(claude, "messages.0.content.0")
+	if block.Get("source.type").String() != "base64" || block.Get("source.media_type").String() != "image/png" || block.Get("source.data").String() != encoded {
+		t.Fatalf("claude image not inlined: %s", claude)
+	}
+	if !block.Get("cache_control").Exists() {
+		t.Fatalf("claude block lost cache_control: %s", claude)
+	}
+
+	gemini := normalizer.Normalize(context.Background(), "antigravity", []byte(`{"request":{"contents":[{"role":"user","parts":[{"fileData":{"mimeType":"image/jpeg","fileUri":"`+server.URL+`/b"}},{"fileData":{"mimeType":"application/pdf","fileUri":"`+server.URL+`/c.pdf"}}]}]}}`))
+	parts := gjson.GetBytes(gemini, "request.contents.0.parts")
+	if parts.Get("0.inlineData.mimeType").String() != "image/png" || parts.Get("0.inlineData.data").String() != encoded || parts.Get("0.fileData").Exists() {
+		t.Fatalf("gemini image not inlined: %s", gemini)
+	}
+	if !parts.Get("1.fileData").Exists() {
+		t.Fatalf("non-image file data was changed: %s", gemini)
+	}
+}
+
+func TestNormalizeDownscalesOpenAIDataURL(t *testing.T) {
+	normalizer := Normalizer{Downscale: true}
+	dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 1200, 1200, true))
+	// OpenAI has no dimension limit, so the image is only shrunk when it is too large.
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"` + dataURL + `"}}]}]}`)
+	if out := normalizer.Normalize(context.Background(), "openai", payload); !bytes.Equal(out, payload) {
+		t.Fatal("image within the OpenAI limits was changed")
+	}
+
+	gemini := []byte(`{"contents":[{"parts":[{"inlineData":{"mime_type":"image/png","data":"` + base64.StdEncoding.EncodeToString(testPNG(t, 4000, 100, true)) + `"}}]}]}`)
+	out := normalizer.Normalize(context.Background(), "gemini", gemini)
+	data, _ := base64.StdEncoding.DecodeString(gjson.GetBytes(out, "contents.0.parts.0.inlineData.data").String())
+	header, _, errConfig := image.DecodeConfig(bytes.NewReader(data))
+	if errConfig != nil || header.Width != 3072 {
+		t.Fatalf("gemini image width = %d (err %v), want 3072", header.Width, errConfig)
+	}
+	if got := gjson.GetBytes(out, "contents.0.parts.0.inlineData.mime_type").String(); got != "image/jpeg" {
+		t.Fatalf("mime_type = %q, want image/jpeg", got)
+	}
+}
+
+func TestFetcherBlocksPrivateAddresses(t *testing.T) {
+	fetcher := &Fetcher{}
+	for _, rawURL := range []string{"http://127.0.0.1/a.png", "http://10.0.0.1/a.png", "http://[::1]/a.png", "http://169.254.169.254/latest"} {
+		if _, _, errFetch := fetcher.Fetch(context.Background(), rawURL); !errors.Is(errFetch, ErrBlockedAddress) {
+			t.Fatalf("Fetch(%s) error = %v, want ErrBlockedAddress", rawURL, errFetch)
+		}
+	}
+	if _, _, errFetch := fetcher.Fetch(context.Background(), "file:///etc/passwd"); errFetch == nil {
+		t.Fatal("Fetch(file://) succeeded, want error")
+	}
+}
+
+func TestIsPublicIPRejectsInternalRanges(t *testing.T) {
+	blocked := []string{
+		"0.1.2.3", "10.1.2.3", "100.64.0.1", "100.100.100.200", "127.0.0.1", "169.254.169.254",
+		"172.16.5.4", "192.0.0.8", "192.0.2.1", "192.168.1.1", "198.18.0.1", "198.19.255.254",
+		"198.51.100.7", "203.0.113.9", "224.0.0.1", "240.0.0.1", "255.255.255.255",
+		"::", "::1", "::ffff:10.0.0.1", "::ffff:100.100.100.200", "64:ff9b::a9fe:a9fe",
+		"64:ff9b:1::1", "100::1", "2001:db8::1", "fd00::1", "fe80::1", "ff02::1",
+	}
+	for _, raw := range blocked {
+		if isPublicIP(net.ParseIP(raw)) {
+			t.Errorf("isPublicIP(%s) = true, want false", raw)
+		}
+	}
+	for _, raw := range []string{"8.8.8.8", "100.63.255.255", "100.128.0.1", "198.20.0.1", "2606:4700::1111"} {
+		if !isPublicIP(net.ParseIP(raw)) {
+			t.Errorf("isPublicIP(%s) = false, want true", raw)
+		}
+	}
+}
+
+func TestFetcherChecksResolvedAddressAndRefusesProxies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte{0x89, 'P', 'N', 'G'})
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// The host name passes the URL check; the dialer rejects the loopback address it resolves to.
+	if _, _, errFetch := (&Fetcher{}).Fetch(context.Background(), "http://localhost:"+port+"/a.png"); !errors.Is(errFetch, ErrBlockedAddress) {
+		t.Fatalf("Fetch(localhost) error = %v, want ErrBlockedAddress", errFetch)
+	}
+	if _, _, errFetch := (&Fetcher{Proxied: true, allowPrivate: true}).Fetch(context.Background(), server.URL+"/a.png"); !errors.Is(errFetch, ErrProxied) {
+		t.Fatalf("Fetch through a proxy error = %v, want ErrProxied", errFetch)
+	}
+}
+
+func TestFetcherRejectsNonImagesAndOversizedBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			_, _ = w.Write([]byte("hello"))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(make([]byte, 64))
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{MaxBytes: 32, allowPrivate: true}
+	if _, _, errFetch := fetcher.Fetch(context.Background(), server.URL+"/text"); errFetch == nil {
+		t.Fatal("Fetch() accepted a text response")
+	}
+	if _, _, errFetch := fetcher.Fetch(context.Background(), server.URL+"/big"); errFetch == nil {
+		t.Fatal("Fetch() accepted a body above MaxBytes")
+	}
+}
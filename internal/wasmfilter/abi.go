@@ -0,0 +1,348 @@
+package wasmfilter
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// The host implements this subset of the proxy-wasm ABI (module "env"):
+//
+//	proxy_log(level, message_ptr, message_size)
+//	proxy_get_buffer_bytes(buffer_type, start, max_size, return_ptr_ptr, return_size_ptr)
+//	proxy_set_buffer_bytes(buffer_type, start, size, data_ptr, data_size)
+//	proxy_get_header_map_pairs(map_type, return_ptr_ptr, return_size_ptr)
+//	proxy_get_header_map_value(map_type, key_ptr, key_size, return_ptr_ptr, return_size_ptr)
+//	proxy_add_header_map_value(map_type, key_ptr, key_size, value_ptr, value_size)
+//	proxy_replace_header_map_value(map_type, key_ptr, key_size, value_ptr, value_size)
+//	proxy_remove_header_map_value(map_type, key_ptr, key_size)
+//	proxy_send_local_response(status_code, details_ptr, details_size, body_ptr, body_size,
+//	                          headers_ptr, headers_size, grpc_status)
+//	proxy_set_effective_context(context_id)
+//
+// Modules must export proxy_on_memory_allocate (or malloc) and may export _initialize,
+// proxy_on_context_create, proxy_on_vm_start, proxy_on_configure, proxy_on_request_headers,
+// proxy_on_request_body, proxy_on_response_headers and proxy_on_response_body. Bodies are
+// delivered whole with end_of_stream set; streaming responses are not filtered.
+
+// proxy-wasm status codes.
+const (
+	statusOK          = 0
+	statusNotFound    = 1
+	statusBadArgument = 2
+)
+
+// proxy-wasm buffer types.
+const (
+	bufferRequestBody       = 0
+	bufferResponseBody      = 1
+	bufferPluginConfig      = 7
+	mapRequestHeaders       = 0
+	mapResponseHeaders      = 2
+	logLevelWarn            = 3
+	logLevelError           = 4
+	defaultLocalContentType = "application/json"
+)
+
+type callStateKey struct{}
+
+// callState is the request data a module instance sees through host calls.
+type callState struct {
+	filterName string
+	config     []byte
+
+	request bool
+	header  http.Header
+	body    []byte
+	local   *LocalResponse
+}
+
+func (s *callState) begin(header http.Header, body []byte, request bool) {
+	s.request = request
+	s.header = header
+	s.body = body
+	s.local = nil
+}
+
+func (s *callState) end() {
+	s.header = nil
+	s.body = nil
+}
+
+func stateFrom(ctx context.Context) *callState {
+	state, _ := ctx.Value(callStateKey{}).(*callState)
+	return state
+}
+
+// headerMap returns the header map a call may access; request headers are read-only while
+// filtering the response.
+func (s *callState) headerMap(mapType uint32) (http.Header, bool) {
+	switch {
+	case s == nil || s.header == nil:
+		return nil, false
+	case mapType == mapRequestHeaders && s.request, mapType == mapResponseHeaders && !s.request:
+		return s.header, true
+	}
+	return nil, false
+}
+
+func (s *callState) buffer(bufferType uint32) ([]byte, bool) {
+	switch {
+	case s == nil:
+		return nil, false
+	case bufferType == bufferPluginConfig:
+		return s.config, true
+	case bufferType == bufferRequestBody && s.request, bufferType == bufferResponseBody && !s.request:
+		return s.body, true
+	}
+	return nil, false
+}
+
+func instantiateHostModule(ctx context.Context, runtime wazero.Runtime) error {
+	_, errInstantiate := runtime.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(hostLog).Export("proxy_log").
+		NewFunctionBuilder().WithFunc(hostGetBufferBytes).Export("proxy_get_buffer_bytes").
+		NewFunctionBuilder().WithFunc(hostSetBufferBytes).Export("proxy_set_buffer_bytes").
+		NewFunctionBuilder().WithFunc(hostGetHeaderMapPairs).Export("proxy_get_header_map_pairs").
+		NewFunctionBuilder().WithFunc(hostGetHeaderMapValue).Export("proxy_get_header_map_value").
+		NewFunctionBuilder().WithFunc(hostAddHeaderMapValue).Export("proxy_add_header_map_value").
+		NewFunctionBuilder().WithFunc(hostReplaceHeaderMapValue).Export("proxy_replace_header_map_value").
+		NewFunctionBuilder().WithFunc(hostRemoveHeaderMapValue).Export("proxy_remove_header_map_value").
+		NewFunctionBuilder().WithFunc(hostSendLocalResponse).Export("proxy_send_local_response").
+		NewFunctionBuilder().WithFunc(hostSetEffectiveContext).Export("proxy_set_effective_context").
+		Instantiate(ctx)
+	return errInstantiate
+}
+
+func hostLog(ctx context.Context, m api.Module, level, ptr, size uint32) uint32 {
+	message, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		return statusBadArgument
+	}
+	name := ""
+	if state := stateFrom(ctx); state != nil {
+		name = state.filterName
+	}
+	entry := log.WithField("wasm_filter", name)
+	switch {
+	case level >= logLevelError:
+		entry.Error(string(message))
+	case level == logLevelWarn:
+		entry.Warn(string(message))
+	default:
+		entry.Debug(string(message))
+	}
+	return statusOK
+}
+
+func hostGetBufferBytes(ctx context.Context, m api.Module, bufferType, start, maxSize, returnPtrPtr, returnSizePtr uint32) uint32 {
+	buf, ok := stateFrom(ctx).buffer(bufferType)
+	if !ok {
+		return statusNotFound
+	}
+	if int(start) > len(buf) {
+		return statusBadArgument
+	}
+	end := len(buf)
+	if int(start)+int(maxSize) < end {
+		end = int(start) + int(maxSize)
+	}
+	return returnBytes(ctx, m, buf[start:end], returnPtrPtr, returnSizePtr)
+}
+
+// hostSetBufferBytes replaces size bytes at start with the given data; size covering the whole
+// buffer replaces it entirely.
+func hostSetBufferBytes(ctx context.Context, m api.Module, bufferType, start, size, dataPtr, dataSize uint32) uint32 {
+	state := stateFrom(ctx)
+	buf, ok := state.buffer(bufferType)
+	if !ok || bufferType == bufferPluginConfig {
+		return statusNotFound
+	}
+	data, okRead := m.Memory().Read(dataPtr, dataSize)
+	if !okRead || int(start) > len(buf) {
+		return statusBadArgument
+	}
+	end := int(start) + int(size)
+	if end > len(buf) {
+		end = len(buf)
+	}
+	out := make([]byte, 0, len(buf)-(end-int(start))+len(data))
+	out = append(out, buf[:start]...)
+	out = append(out, data...)
+	out = append(out, buf[end:]...)
+	state.body = out
+	return statusOK
+}
+
+func hostGetHeaderMapPairs(ctx context.Context, m api.Module, mapType, returnPtrPtr, returnSizePtr uint32) uint32 {
+	header, ok := stateFrom(ctx).headerMap(mapType)
+	if !ok {
+		return statusNotFound
+	}
+	return returnBytes(ctx, m, encodePairs(header), returnPtrPtr, returnSizePtr)
+}
+
+func hostGetHeaderMapValue(ctx context.Context, m api.Module, mapType, keyPtr, keySize, returnPtrPtr, returnSizePtr uint32) uint32 {
+	header, ok := stateFrom(ctx).headerMap(mapType)
+	if !ok {
+		return statusNotFound
+	}
+	key, okKey := m.Memory().Read(keyPtr, keySize)
+	if !okKey {
+		return statusBadArgument
+	}
+	values := header.Values(string(key))
+	if len(values) == 0 {
+		return statusNotFound
+	}
+	return returnBytes(ctx, m, []byte(strings.Join(values, ",")), returnPtrPtr, returnSizePtr)
+}
+
+func hostAddHeaderMapValue(ctx context.Context, m api.Module, mapType, keyPtr, keySize, valuePtr, valueSize uint32) uint32 {
+	return editHeader(ctx, m, mapType, keyPtr, keySize, valuePtr, valueSize, http.Header.Add)
+}
+
+func hostReplaceHeaderMapValue(ctx context.Context, m api.Module, mapType, keyPtr, keySize, valuePtr, valueSize uint32) uint32 {
+	return editHeader(ctx, m, mapType, keyPtr, keySize, valuePtr, valueSize, http.Header.Set)
+}
+
+func editHeader(ctx context.Context, m api.Module, mapType, keyPtr, keySize, valuePtr, valueSize uint32, edit func(http.Header, string, string)) uint32 {
+	header, ok := stateFrom(ctx).headerMap(mapType)
+	if !ok {
+		return statusNotFound
+	}
+	key, okKey := m.Memory().Read(keyPtr, keySize)
+	value, okValue := m.Memory().Read(valuePtr, valueSize)
+	if !okKey || !okValue || len(key) == 0 {
+		return statusBadArgument
+	}
+	edit(header, string(key), string(value))
+	return statusOK
+}
+
+func hostRemoveHeaderMapValue(ctx context.Context, m api.Module, mapType, keyPtr, keySize uint32) uint32 {
+	header, ok := stateFrom(ctx).headerMap(mapType)
+	if !ok {
+		return statusNotFound
+	}
+	key, okKey := m.Memory().Read(keyPtr, keySize)
+	if !okKey {
+		return statusBadArgument
+	}
+	header.Del(string(key))
+	return statusOK
+}
+
+func hostSendLocalResponse(ctx context.Context, m api.Module, statusCode, _, _, bodyPtr, bodySize, headersPtr, headersSize uint32, _ int32) uint32 {
+	state := stateFrom(ctx)
+	if state == nil {
+		return statusNotFound
+	}
+	body, okBody := m.Memory().Read(bodyPtr, bodySize)
+	pairs, okPairs := m.Memory().Read(headersPtr, headersSize)
+	if !okBody || !okPairs || statusCode < 100 || statusCode > 599 {
+		return statusBadArgument
+	}
+	header, okDecode := decodePairs(pairs)
+	if !okDecode {
+		return statusBadArgument
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", defaultLocalContentType)
+	}
+	state.local = &LocalResponse{StatusCode: int(statusCode), Header: header, Body: append([]byte(nil), body...)}
+	return statusOK
+}
+
+func hostSetEffectiveContext(context.Context, api.Module, uint32) uint32 {
+	return statusOK
+}
+
+// returnBytes copies data into memory allocated by the module and stores its address and size
+// at the given return pointers.
+func returnBytes(ctx context.Context, m api.Module, data []byte, returnPtrPtr, returnSizePtr uint32) uint32 {
+	var ptr uint32
+	if len(data) > 0 {
+		alloc := m.ExportedFunction("proxy_on_memory_allocate")
+		if alloc == nil {
+			alloc = m.ExportedFunction("malloc")
+		}
+		if alloc == nil {
+			return statusNotFound
+		}
+		results, errAlloc := alloc.Call(ctx, uint64(len(data)))
+		if errAlloc != nil || len(results) == 0 {
+			return statusBadArgument
+		}
+		ptr = uint32(results[0])
+		if !m.Memory().Write(ptr, data) {
+			return statusBadArgument
+		}
+	}
+	if !m.Memory().WriteUint32Le(returnPtrPtr, ptr) || !m.Memory().WriteUint32Le(returnSizePtr, uint32(len(data))) {
+		return statusBadArgument
+	}
+	return statusOK
+}
+
+// encodePairs serializes headers in the proxy-wasm map format: the pair count, the key and
+// value sizes of every pair, then every key and value followed by a NUL byte.
+func encodePairs(header http.Header) []byte {
+	type pair struct{ key, value string }
+	pairs := make([]pair, 0, len(header))
+	for key, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, pair{strings.ToLower(key), value})
+		}
+	}
+	size := 4 + 8*len(pairs)
+	for _, p := range pairs {
+		size += len(p.key) + len(p.value) + 2
+	}
+	out := make([]byte, 4+8*len(pairs), size)
+	binary.LittleEndian.PutUint32(out, uint32(len(pairs)))
+	for i, p := range pairs {
+		binary.LittleEndian.PutUint32(out[4+8*i:], uint32(len(p.key)))
+		binary.LittleEndian.PutUint32(out[8+8*i:], uint32(len(p.value)))
+	}
+	for _, p := range pairs {
+		out = append(out, p.key...)
+		out = append(out, 0)
+		out = append(out, p.value...)
+		out = append(out, 0)
+	}
+	return out
+}
+
+func decodePairs(data []byte) (http.Header, bool) {
+	header := make(http.Header)
+	if len(data) == 0 {
+		return header, true
+	}
+	if len(data) < 4 {
+		return nil, false
+	}
+	count := int(binary.LittleEndian.Uint32(data))
+	if count < 0 || 4+8*count > len(data) {
+		return nil, false
+	}
+	offset := 4 + 8*count
+	for i := 0; i < count; i++ {
+		keySize := int(binary.LittleEndian.Uint32(data[4+8*i:]))
+		valueSize := int(binary.LittleEndian.Uint32(data[8+8*i:]))
+		if offset+keySize+valueSize+2 > len(data) {
+			return nil, false
+		}
+		key := string(data[offset : offset+keySize])
+		offset += keySize + 1
+		value := string(data[offset : offset+valueSize])
+		offset += valueSize + 1
+		header.Add(key, value)
+	}
+	return header, true
+}
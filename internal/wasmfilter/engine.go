@@ -0,0 +1,340 @@
+// Package wasmfilter runs WebAssembly modules that rewrite API requests and responses. Modules
+// implement a subset of the proxy-wasm ABI (see abi.go), so filters can be written in any
+// language with a WebAssembly target and loaded without rebuilding the proxy.
+package wasmfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// LocalResponse is an answer produced by a filter instead of the upstream.
+type LocalResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// filter is one configured module, compiled once and instantiated per request.
+type filter struct {
+	cfg     config.WasmFilter
+	module  wazero.CompiledModule
+	modTime time.Time
+	size    int64
+
+	onRequest  bool
+	onResponse bool
+}
+
+// Engine owns the WebAssembly runtime and the compiled filters. A nil Engine runs no filters.
+type Engine struct {
+	mu      sync.RWMutex
+	runtime wazero.Runtime
+	filters []*filter
+}
+
+// NewEngine creates an engine without filters. Apply loads them.
+func NewEngine() *Engine {
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+	if errHost := instantiateHostModule(ctx, runtime); errHost != nil {
+		log.Errorf("wasm filters: %v", errHost)
+	}
+	return &Engine{runtime: runtime}
+}
+
+// Apply compiles the configured filters. Modules whose file is unchanged are reused; filters
+// that fail to load are logged and skipped.
+func (e *Engine) Apply(cfgs []config.WasmFilter) {
+	if e == nil {
+		return
+	}
+	e.mu.RLock()
+	previous := make(map[string]*filter, len(e.filters))
+	for _, f := range e.filters {
+		previous[f.cfg.Path] = f
+	}
+	e.mu.RUnlock()
+
+	ctx := context.Background()
+	next := make([]*filter, 0, len(cfgs))
+	reused := make(map[*filter]bool)
+	for _, cfg := range cfgs {
+		info, errStat := os.Stat(cfg.Path)
+		if errStat != nil {
+			log.Errorf("wasm filter %s: %v", cfg.Name, errStat)
+			continue
+		}
+		if old, ok := previous[cfg.Path]; ok && old.modTime.Equal(info.ModTime()) && old.size == info.Size() {
+			reused[old] = true
+			next = append(next, &filter{cfg: cfg, module: old.module, modTime: old.modTime, size: old.size, onRequest: old.onRequest, onResponse: old.onResponse})
+			continue
+		}
+		wasm, errRead := os.ReadFile(cfg.Path)
+		if errRead != nil {
+			log.Errorf("wasm filter %s: %v", cfg.Name, errRead)
+			continue
+		}
+		compiled, errCompile := e.runtime.CompileModule(ctx, wasm)
+		if errCompile != nil {
+			log.Errorf("wasm filter %s: compile: %v", cfg.Name, errCompile)
+			continue
+		}
+		exports := compiled.ExportedFunctions()
+		f := &filter{cfg: cfg, module: compiled, modTime: info.ModTime(), size: info.Size()}
+		_, hasRequestHeaders := exports["proxy_on_request_headers"]
+		_, hasRequestBody := exports["proxy_on_request_body"]
+		_, hasResponseHeaders := exports["proxy_on_response_headers"]
+		_, hasResponseBody := exports["proxy_on_response_body"]
+		f.onRequest = hasRequestHeaders || hasRequestBody
+		f.onResponse = hasResponseHeaders || hasResponseBody
+		if _, hasAlloc := exports["proxy_on_memory_allocate"]; !hasAlloc {
+			if _, hasMalloc := exports["malloc"]; !hasMalloc {
+				log.Errorf("wasm filter %s: module exports neither proxy_on_memory_allocate nor malloc", cfg.Name)
+				_ = compiled.Close(ctx)
+				continue
+			}
+		}
+		if !f.onRequest && !f.onResponse {
+			log.Warnf("wasm filter %s: module exports no request or response callbacks", cfg.Name)
+		}
+		next = append(next, f)
+	}
+
+	e.mu.Lock()
+	old := e.filters
+	e.filters = next
+	e.mu.Unlock()
+	for _, f := range old {
+		if !reused[f] {
+			_ = f.module.Close(ctx)
+		}
+	}
+}
+
+// Close releases the runtime and every compiled filter.
+func (e *Engine) Close() {
+	if e == nil {
+		return
+	}
+	_ = e.runtime.Close(context.Background())
+}
+
+// Matches reports whether any filter applies to path.
+func (e *Engine) Matches(path string) bool {
+	if e == nil {
+		return false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, f := range e.filters {
+		if f.cfg.Matches(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Session runs the filters matching one request. The same module instance serves the request
+// and response phase, so filters may keep per-request state. Close must be called when done.
+type Session struct {
+	instances []*instance
+}
+
+// instance is a filter module instantiated for one request.
+type instance struct {
+	filter *filter
+	module api.Module
+	state  *callState
+}
+
+// Begin instantiates the filters matching path.
+func (e *Engine) Begin(ctx context.Context, path string) (*Session, error) {
+	session := &Session{}
+	if e == nil {
+		return session, nil
+	}
+	e.mu.RLock()
+	filters := make([]*filter, 0, len(e.filters))
+	for _, f := range e.filters {
+		if f.cfg.Matches(path) {
+			filters = append(filters, f)
+		}
+	}
+	e.mu.RUnlock()
+
+	for _, f := range filters {
+		inst, errStart := e.start(ctx, f)
+		if errStart != nil {
+			if f.cfg.FailOpen {
+				log.Warnf("wasm filter %s: %v; skipped", f.cfg.Name, errStart)
+				continue
+			}
+			session.Close()
+			return nil, fmt.Errorf("wasm filter %s: %w", f.cfg.Name, errStart)
+		}
+		session.instances = append(session.instances, inst)
+	}
+	return session, nil
+}
+
+// rootContextID and httpContextID are the proxy-wasm context IDs used for every instance.
+const (
+	rootContextID = 1
+	httpContextID = 2
+)
+
+func (e *Engine) start(ctx context.Context, f *filter) (*instance, error) {
+	state := &callState{config: []byte(f.cfg.Config), filterName: f.cfg.Name}
+	inst := &instance{filter: f, state: state}
+	callCtx, cancel := inst.callContext(ctx)
+	defer cancel()
+	module, errInstantiate := e.runtime.InstantiateModule(callCtx, f.module, wazero.NewModuleConfig().WithName("").WithStartFunctions())
+	if errInstantiate != nil {
+		return nil, errInstantiate
+	}
+	inst.module = module
+	if _, errInit := inst.call(callCtx, "_initialize"); errInit != nil {
+		_ = module.Close(context.Background())
+		return nil, errInit
+	}
+	steps := []struct {
+		name   string
+		params []uint64
+	}{
+		{"proxy_on_context_create", []uint64{rootContextID, 0}},
+		{"proxy_on_vm_start", []uint64{rootContextID, 0}},
+		{"proxy_on_configure", []uint64{rootContextID, uint64(len(state.config))}},
+		{"proxy_on_context_create", []uint64{httpContextID, rootContextID}},
+	}
+	for _, step := range steps {
+		if _, errCall := inst.call(callCtx, step.name, step.params...); errCall != nil {
+			_ = module.Close(context.Background())
+			return nil, errCall
+		}
+	}
+	return inst, nil
+}
+
+func (i *instance) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, callStateKey{}, i.state)
+	return context.WithTimeout(ctx, time.Duration(i.filter.cfg.TimeoutMs)*time.Millisecond)
+}
+
+// call invokes an exported function when the module provides it.
+func (i *instance) call(ctx context.Context, name string, params ...uint64) ([]uint64, error) {
+	fn := i.module.ExportedFunction(name)
+	if fn == nil {
+		return nil, nil
+	}
+	results, errCall := fn.Call(ctx, params...)
+	if errCall != nil {
+		return nil, fmt.Errorf("%s: %w", name, errCall)
+	}
+	return results, nil
+}
+
+// errFilterFailed wraps failures of filters that are not configured to fail open.
+var errFilterFailed = errors.New("wasm filter failed")
+
+// Request runs the request callbacks of every filter in configuration order. header is
+// modified in place; the returned body replaces the request body. A non-nil LocalResponse
+// must be sent instead of forwarding the request.
+func (s *Session) Request(ctx context.Context, header http.Header, body []byte) ([]byte, *LocalResponse, error) {
+	for _, inst := range s.instances {
+		if !inst.filter.onRequest {
+			continue
+		}
+		out, local, errRun := inst.runPhase(ctx, "proxy_on_request_headers", "proxy_on_request_body", header, body, true)
+		if errRun != nil {
+			if inst.filter.cfg.FailOpen {
+				log.Warnf("wasm filter %s: %v; request forwarded unchanged", inst.filter.cfg.Name, errRun)
+				continue
+			}
+			return nil, nil, fmt.Errorf("%w: %s: %v", errFilterFailed, inst.filter.cfg.Name, errRun)
+		}
+		if local != nil {
+			return nil, local, nil
+		}
+		body = out
+	}
+	return body, nil, nil
+}
+
+// Response runs the response callbacks of every filter in reverse configuration order, so the
+// filter that saw the request first sees the response last.
+func (s *Session) Response(ctx context.Context, header http.Header, body []byte) ([]byte, *LocalResponse, error) {
+	for idx := len(s.instances) - 1; idx >= 0; idx-- {
+		inst := s.instances[idx]
+		if !inst.filter.onResponse {
+			continue
+		}
+		out, local, errRun := inst.runPhase(ctx, "proxy_on_response_headers", "proxy_on_response_body", header, body, false)
+		if errRun != nil {
+			if inst.filter.cfg.FailOpen {
+				log.Warnf("wasm filter %s: %v; response forwarded unchanged", inst.filter.cfg.Name, errRun)
+				continue
+			}
+			return nil, nil, fmt.Errorf("%w: %s: %v", errFilterFailed, inst.filter.cfg.Name, errRun)
+		}
+		if local != nil {
+			return nil, local, nil
+		}
+		body = out
+	}
+	return body, nil, nil
+}
+
+// FiltersResponses reports whether any filter of the session inspects responses.
+func (s *Session) FiltersResponses() bool {
+	for _, inst := range s.instances {
+		if inst.filter.onResponse {
+			return true
+		}
+	}
+	return false
+}
+
+// Close releases the module instances of the session.
+func (s *Session) Close() {
+	if s == nil {
+		return
+	}
+	for _, inst := range s.instances {
+		_ = inst.module.Close(context.Background())
+	}
+	s.instances = nil
+}
+
+func (i *instance) runPhase(ctx context.Context, headersFn, bodyFn string, header http.Header, body []byte, request bool) ([]byte, *LocalResponse, error) {
+	callCtx, cancel := i.callContext(ctx)
+	defer cancel()
+	i.state.begin(header, body, request)
+	defer i.state.end()
+
+	if _, errCall := i.call(callCtx, headersFn, httpContextID, uint64(len(header)), 0); errCall != nil {
+		return nil, nil, errCall
+	}
+	if local := i.state.local; local != nil {
+		return nil, local, nil
+	}
+	if _, errCall := i.call(callCtx, bodyFn, httpContextID, uint64(len(i.state.body)), 1); errCall != nil {
+		return nil, nil, errCall
+	}
+	if local := i.state.local; local != nil {
+		return nil, local, nil
+	}
+	return i.state.body, nil, nil
+}
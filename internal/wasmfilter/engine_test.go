@@ -0,0 +1,106 @@
+package wasmfilter
+
+import (
+	"context"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// buildTestFilter compiles testdata/filter for wasip1 and returns the module path.
+func buildTestFilter(t *testing.T) string {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "filter.wasm")
+	cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "build", "-buildmode=c-shared", "-o", out, ".")
+	cmd.Dir = filepath.Join("testdata", "filter")
+	cmd.Env = append(cmd.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, errBuild := cmd.CombinedOutput(); errBuild != nil {
+		t.Skipf("build test filter: %v\n%s", errBuild, output)
+	}
+	return out
+}
+
+func TestEngineFilters(t *testing.T) {
+	path := buildTestFilter(t)
+	engine := NewEngine()
+	defer engine.Close()
+	engine.Apply([]config.WasmFilter{{Name: "test", Path: path, Routes: []string{"/v1/*"}, Config: "cfg", TimeoutMs: 5000}})
+	ctx := context.Background()
+
+	t.Run("route mismatch", func(t *testing.T) {
+		if engine.Matches("/v1beta/models") {
+			t.Fatal("filter matched /v1beta/models")
+		}
+		if !engine.Matches("/v1/chat/completions") {
+			t.Fatal("filter did not match /v1/chat/completions")
+		}
+	})
+
+	t.Run("request and response", func(t *testing.T) {
+		session, errBegin := engine.Begin(ctx, "/v1/chat/completions")
+		if errBegin != nil {
+			t.Fatalf("Begin: %v", errBegin)
+		}
+		defer session.Close()
+
+		header := http.Header{}
+		body, local, errRequest := session.Request(ctx, header, []byte(`{"prompt":"my secret"}`))
+		if errRequest != nil || local != nil {
+			t.Fatalf("Request: local=%v err=%v", local, errRequest)
+		}
+		if got := string(body); got != `{"prompt":"my [redacted]"}` {
+			t.Fatalf("request body = %s", got)
+		}
+		if got := header.Get("X-Filtered"); got != "request" {
+			t.Fatalf("request x-filtered = %q", got)
+		}
+
+		if !session.FiltersResponses() {
+			t.Fatal("FiltersResponses() = false")
+		}
+		respHeader := http.Header{}
+		body, local, errResponse := session.Response(ctx, respHeader, []byte(`{"ok":true}`))
+		if errResponse != nil || local != nil {
+			t.Fatalf("Response: local=%v err=%v", local, errResponse)
+		}
+		if got := string(body); got != `cfg:{"ok":true}` {
+			t.Fatalf("response body = %s", got)
+		}
+		if got := respHeader.Get("X-Filtered"); got != "response" {
+			t.Fatalf("response x-filtered = %q", got)
+		}
+	})
+
+	t.Run("local response", func(t *testing.T) {
+		session, errBegin := engine.Begin(ctx, "/v1/messages")
+		if errBegin != nil {
+			t.Fatalf("Begin: %v", errBegin)
+		}
+		defer session.Close()
+
+		header := http.Header{"X-Block": {"1"}}
+		_, local, errRequest := session.Request(ctx, header, []byte(`{}`))
+		if errRequest != nil {
+			t.Fatalf("Request: %v", errRequest)
+		}
+		if local == nil {
+			t.Fatal("expected local response")
+		}
+		if local.StatusCode != http.StatusForbidden || string(local.Body) != `{"error":"blocked"}` {
+			t.Fatalf("local response = %d %s", local.StatusCode, local.Body)
+		}
+	})
+}
+
+func TestEngineSkipsMissingModule(t *testing.T) {
+	engine := NewEngine()
+	defer engine.Close()
+	engine.Apply([]config.WasmFilter{{Name: "missing", Path: filepath.Join(t.TempDir(), "missing.wasm"), TimeoutMs: 1000}})
+	if engine.Matches("/v1/chat/completions") {
+		t.Fatal("missing module should not be loaded")
+	}
+}
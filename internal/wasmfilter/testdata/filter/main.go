@@ -0,0 +1,95 @@
+// Command filter is a proxy-wasm test filter built with GOOS=wasip1 GOARCH=wasm -buildmode=c-shared.
+// It redacts "secret" from request bodies, rejects requests carrying x-block, tags both
+// directions with an x-filtered header and prefixes response bodies with its configuration.
+package main
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+func main() {}
+
+var allocations = map[uintptr][]byte{}
+
+//go:wasmexport proxy_on_memory_allocate
+func allocate(size uint32) uint32 {
+	buf := make([]byte, size+1)
+	ptr := uintptr(unsafe.Pointer(&buf[0]))
+	allocations[ptr] = buf
+	return uint32(ptr)
+}
+
+//go:wasmimport env proxy_get_buffer_bytes
+func proxyGetBufferBytes(bufferType, start, maxSize uint32, returnPtr, returnSize unsafe.Pointer) uint32
+
+//go:wasmimport env proxy_set_buffer_bytes
+func proxySetBufferBytes(bufferType, start, size uint32, data unsafe.Pointer, dataSize uint32) uint32
+
+//go:wasmimport env proxy_get_header_map_value
+func proxyGetHeaderMapValue(mapType uint32, key unsafe.Pointer, keySize uint32, returnPtr, returnSize unsafe.Pointer) uint32
+
+//go:wasmimport env proxy_replace_header_map_value
+func proxyReplaceHeaderMapValue(mapType uint32, key unsafe.Pointer, keySize uint32, value unsafe.Pointer, valueSize uint32) uint32
+
+//go:wasmimport env proxy_send_local_response
+func proxySendLocalResponse(status uint32, details unsafe.Pointer, detailsSize uint32, body unsafe.Pointer, bodySize uint32, headers unsafe.Pointer, headersSize uint32, grpcStatus int32) uint32
+
+func bufferBytes(bufferType uint32) []byte {
+	var ptr, size uint32
+	if proxyGetBufferBytes(bufferType, 0, 1<<30, unsafe.Pointer(&ptr), unsafe.Pointer(&size)) != 0 || size == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), size)
+}
+
+func setBuffer(bufferType uint32, data []byte) {
+	if len(data) == 0 {
+		data = []byte{0}[:0:1]
+	}
+	proxySetBufferBytes(bufferType, 0, 1<<30, unsafe.Pointer(unsafe.SliceData(data)), uint32(len(data)))
+}
+
+func headerValue(mapType uint32, key string) (string, bool) {
+	var ptr, size uint32
+	k := []byte(key)
+	if proxyGetHeaderMapValue(mapType, unsafe.Pointer(&k[0]), uint32(len(k)), unsafe.Pointer(&ptr), unsafe.Pointer(&size)) != 0 {
+		return "", false
+	}
+	return string(unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), size)), true
+}
+
+func setHeader(mapType uint32, key, value string) {
+	k, v := []byte(key), []byte(value)
+	proxyReplaceHeaderMapValue(mapType, unsafe.Pointer(&k[0]), uint32(len(k)), unsafe.Pointer(&v[0]), uint32(len(v)))
+}
+
+//go:wasmexport proxy_on_request_headers
+func onRequestHeaders(contextID, headers, endOfStream uint32) uint32 {
+	if _, blocked := headerValue(0, "x-block"); blocked {
+		body := []byte(`{"error":"blocked"}`)
+		proxySendLocalResponse(403, nil, 0, unsafe.Pointer(&body[0]), uint32(len(body)), nil, 0, -1)
+		return 1
+	}
+	setHeader(0, "x-filtered", "request")
+	return 0
+}
+
+//go:wasmexport proxy_on_request_body
+func onRequestBody(contextID, bodySize, endOfStream uint32) uint32 {
+	setBuffer(0, bytes.ReplaceAll(bufferBytes(0), []byte("secret"), []byte("[redacted]")))
+	return 0
+}
+
+//go:wasmexport proxy_on_response_headers
+func onResponseHeaders(contextID, headers, endOfStream uint32) uint32 {
+	setHeader(2, "x-filtered", "response")
+	return 0
+}
+
+//go:wasmexport proxy_on_response_body
+func onResponseBody(contextID, bodySize, endOfStream uint32) uint32 {
+	out := append(append([]byte(nil), bufferBytes(7)...), ':')
+	setBuffer(1, append(out, bufferBytes(1)...))
+	return 0
+}
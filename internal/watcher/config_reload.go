@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"os"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
@@ -136,9 +137,22 @@ func (w *Watcher) reloadConfig() bool {
 
 	authDirChanged := oldConfig == nil || oldConfig.AuthDir != newConfig.AuthDir
 	retryConfigChanged := oldConfig != nil && (oldConfig.RequestRetry != newConfig.RequestRetry || oldConfig.MaxRetryInterval != newConfig.MaxRetryInterval || oldConfig.MaxRetryCredentials != newConfig.MaxRetryCredentials)
-	forceAuthRefresh := oldConfig != nil && (oldConfig.ForceModelPrefix != newConfig.ForceModelPrefix || !reflect.DeepEqual(oldConfig.OAuthModelAlias, newConfig.OAuthModelAlias) || retryConfigChanged)
+	tenantPrefixesChanged := oldConfig != nil && !reflect.DeepEqual(tenantCredentialPrefixes(oldConfig), tenantCredentialPrefixes(newConfig))
+	forceAuthRefresh := oldConfig != nil && (oldConfig.ForceModelPrefix != newConfig.ForceModelPrefix || !reflect.DeepEqual(oldConfig.OAuthModelAlias, newConfig.OAuthModelAlias) || retryConfigChanged || tenantPrefixesChanged)
 
 	log.Infof("config successfully reloaded, triggering client reload")
 	w.reloadClients(authDirChanged, affectedOAuthProviders, forceAuthRefresh)
 	return true
 }
+
+// tenantCredentialPrefixes lists the credential prefixes dedicated to tenants, which decide how
+// the models of the matching credentials are registered.
+func tenantCredentialPrefixes(cfg *config.Config) []string {
+	var prefixes []string
+	for _, tenant := range cfg.Tenants {
+		if tenant.CredentialPrefix != "" {
+			prefixes = append(prefixes, strings.ToLower(tenant.CredentialPrefix))
+		}
+	}
+	return prefixes
+}
@@ -69,6 +69,87 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if oldCfg.LogOutput != newCfg.LogOutput {
+		changes = append(changes, fmt.Sprintf("log-output: %s/%dMB -> %s/%dMB", oldCfg.LogOutput.Format, oldCfg.LogOutput.MaxSizeMB, newCfg.LogOutput.Format, newCfg.LogOutput.MaxSizeMB))
+	}
+	if !reflect.DeepEqual(oldCfg.RequestQueue, newCfg.RequestQueue) {
+		changes = append(changes, fmt.Sprintf("request-queue: enable %t/max-concurrent %d -> enable %t/max-concurrent %d", oldCfg.RequestQueue.Enable, oldCfg.RequestQueue.MaxConcurrent, newCfg.RequestQueue.Enable, newCfg.RequestQueue.MaxConcurrent))
+	}
+	if oldCfg.ResponseCache != newCfg.ResponseCache {
+		changes = append(changes, fmt.Sprintf("response-cache: enable %t/ttl %s -> enable %t/ttl %s", oldCfg.ResponseCache.Enable, oldCfg.ResponseCache.TTLDuration(), newCfg.ResponseCache.Enable, newCfg.ResponseCache.TTLDuration()))
+	}
+	if oldCfg.Streaming.HedgeAfter != newCfg.Streaming.HedgeAfter || !reflect.DeepEqual(oldCfg.Streaming.HedgeModels, newCfg.Streaming.HedgeModels) {
+		changes = append(changes, fmt.Sprintf("streaming.hedge-after: %q -> %q", oldCfg.Streaming.HedgeAfter, newCfg.Streaming.HedgeAfter))
+	}
+	if oldCfg.Streaming.FirstByteHeartbeatSeconds != newCfg.Streaming.FirstByteHeartbeatSeconds {
+		changes = append(changes, fmt.Sprintf("streaming.first-byte-heartbeat-seconds: %d -> %d", oldCfg.Streaming.FirstByteHeartbeatSeconds, newCfg.Streaming.FirstByteHeartbeatSeconds))
+	}
+	if !reflect.DeepEqual(oldCfg.Streaming.ProviderTimeouts, newCfg.Streaming.ProviderTimeouts) {
+		changes = append(changes, fmt.Sprintf("streaming.provider-timeouts: %d -> %d", len(oldCfg.Streaming.ProviderTimeouts), len(newCfg.Streaming.ProviderTimeouts)))
+	}
+	if !reflect.DeepEqual(oldCfg.Experiments, newCfg.Experiments) {
+		changes = append(changes, fmt.Sprintf("experiments: %d -> %d", len(oldCfg.Experiments), len(newCfg.Experiments)))
+	}
+	if !reflect.DeepEqual(oldCfg.ShadowTraffic, newCfg.ShadowTraffic) {
+		changes = append(changes, fmt.Sprintf("shadow-traffic: rules %d -> %d", len(oldCfg.ShadowTraffic.Rules), len(newCfg.ShadowTraffic.Rules)))
+	}
+	if !reflect.DeepEqual(oldCfg.Race, newCfg.Race) {
+		changes = append(changes, fmt.Sprintf("race: fanout %d/allow-header %t -> fanout %d/allow-header %t", oldCfg.Race.Fanout, oldCfg.Race.AllowHeader, newCfg.Race.Fanout, newCfg.Race.AllowHeader))
+	}
+	if oldCfg.ReasoningOutput != newCfg.ReasoningOutput {
+		changes = append(changes, fmt.Sprintf("reasoning-output: %s -> %s", oldCfg.ReasoningOutput, newCfg.ReasoningOutput))
+	}
+	if oldCfg.LocalTokenCount != newCfg.LocalTokenCount {
+		changes = append(changes, fmt.Sprintf("local-token-count: %t -> %t", oldCfg.LocalTokenCount, newCfg.LocalTokenCount))
+	}
+	if oldCfg.PreflightTokenCheck != newCfg.PreflightTokenCheck {
+		changes = append(changes, fmt.Sprintf("preflight-token-check: enable %t/min %d bytes/strategy %s -> enable %t/min %d bytes/strategy %s", oldCfg.PreflightTokenCheck.Enable, oldCfg.PreflightTokenCheck.MinRequestBytes, oldCfg.PreflightTokenCheck.Strategy, newCfg.PreflightTokenCheck.Enable, newCfg.PreflightTokenCheck.MinRequestBytes, newCfg.PreflightTokenCheck.Strategy))
+	}
+	if oldCfg.Batch != newCfg.Batch {
+		changes = append(changes, fmt.Sprintf("batch: enable %t/concurrency %d -> enable %t/concurrency %d", oldCfg.Batch.Enable, oldCfg.Batch.Concurrency, newCfg.Batch.Enable, newCfg.Batch.Concurrency))
+	}
+	if oldCfg.Transcripts != newCfg.Transcripts {
+		changes = append(changes, fmt.Sprintf("transcripts: enable %t/store %s -> enable %t/store %s", oldCfg.Transcripts.Enable, oldCfg.Transcripts.Store, newCfg.Transcripts.Enable, newCfg.Transcripts.Store))
+	}
+	if oldCfg.Listen != newCfg.Listen {
+		changes = append(changes, fmt.Sprintf("listen: unix-socket %q/systemd-activation %t -> unix-socket %q/systemd-activation %t (restart required)", oldCfg.Listen.UnixSocket, oldCfg.Listen.SystemdActivation, newCfg.Listen.UnixSocket, newCfg.Listen.SystemdActivation))
+	}
+	if oldCfg.HTTP2 != newCfg.HTTP2 {
+		changes = append(changes, fmt.Sprintf("http2: h2c %t/max-concurrent-streams %d -> h2c %t/max-concurrent-streams %d", oldCfg.HTTP2.H2C, oldCfg.HTTP2.MaxConcurrentStreams, newCfg.HTTP2.H2C, newCfg.HTTP2.MaxConcurrentStreams))
+	}
+	if oldCfg.Shutdown != newCfg.Shutdown {
+		changes = append(changes, fmt.Sprintf("shutdown: drain-timeout %s/reuse-port %t -> drain-timeout %s/reuse-port %t", oldCfg.Shutdown.DrainDuration(), oldCfg.Shutdown.ReusePort, newCfg.Shutdown.DrainDuration(), newCfg.Shutdown.ReusePort))
+	}
+	if oldCfg.GRPC != newCfg.GRPC {
+		changes = append(changes, fmt.Sprintf("grpc: enable %t/%s:%d -> enable %t/%s:%d", oldCfg.GRPC.Enable, oldCfg.GRPC.Host, oldCfg.GRPC.Port, newCfg.GRPC.Enable, newCfg.GRPC.Host, newCfg.GRPC.Port))
+	}
+	if oldCfg.Idempotency != newCfg.Idempotency {
+		changes = append(changes, fmt.Sprintf("idempotency: enable %t/ttl %s -> enable %t/ttl %s", oldCfg.Idempotency.Enable, oldCfg.Idempotency.TTLDuration(), newCfg.Idempotency.Enable, newCfg.Idempotency.TTLDuration()))
+	}
+	if oldCfg.StreamResume != newCfg.StreamResume {
+		changes = append(changes, fmt.Sprintf("stream-resume: enable %t/window %s -> enable %t/window %s", oldCfg.StreamResume.Enable, oldCfg.StreamResume.WindowDuration(), newCfg.StreamResume.Enable, newCfg.StreamResume.WindowDuration()))
+	}
+	if oldCfg.ResponsesStore != newCfg.ResponsesStore {
+		changes = append(changes, fmt.Sprintf("responses-store: enable %t/ttl %s -> enable %t/ttl %s", oldCfg.ResponsesStore.Enable, oldCfg.ResponsesStore.TTLDuration(), newCfg.ResponsesStore.Enable, newCfg.ResponsesStore.TTLDuration()))
+	}
+	if oldCfg.SharedState != newCfg.SharedState {
+		changes = append(changes, fmt.Sprintf("shared-state: redis %t -> %t", oldCfg.SharedState.RedisURL != "", newCfg.SharedState.RedisURL != ""))
+	}
+	if oldCfg.RegionRouting != newCfg.RegionRouting {
+		changes = append(changes, fmt.Sprintf("region-routing: enable %t/interval %s -> enable %t/interval %s", oldCfg.RegionRouting.Enable, oldCfg.RegionRouting.IntervalDuration(), newCfg.RegionRouting.Enable, newCfg.RegionRouting.IntervalDuration()))
+	}
+	if oldCfg.AnthropicFiles != newCfg.AnthropicFiles {
+		changes = append(changes, fmt.Sprintf("anthropic-files: enable %t/mode %s -> enable %t/mode %s", oldCfg.AnthropicFiles.Enable, oldCfg.AnthropicFiles.Mode, newCfg.AnthropicFiles.Enable, newCfg.AnthropicFiles.Mode))
+	}
+	if oldCfg.Scheduler.Enable != newCfg.Scheduler.Enable || !reflect.DeepEqual(oldCfg.Scheduler.Jobs, newCfg.Scheduler.Jobs) {
+		changes = append(changes, fmt.Sprintf("scheduler: enable %t/%d jobs -> enable %t/%d jobs", oldCfg.Scheduler.Enable, len(oldCfg.Scheduler.Jobs), newCfg.Scheduler.Enable, len(newCfg.Scheduler.Jobs)))
+	}
+	if oldCfg.ModelCatalog.URL != newCfg.ModelCatalog.URL {
+		changes = append(changes, fmt.Sprintf("model-catalog.url: %s -> %s", oldCfg.ModelCatalog.URL, newCfg.ModelCatalog.URL))
+	}
+	if oldCfg.ModelCatalog.OverrideFile != newCfg.ModelCatalog.OverrideFile {
+		changes = append(changes, fmt.Sprintf("model-catalog.override-file: %s -> %s", oldCfg.ModelCatalog.OverrideFile, newCfg.ModelCatalog.OverrideFile))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
@@ -81,6 +162,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
+	if !reflect.DeepEqual(oldCfg.ProviderProxies, newCfg.ProviderProxies) {
+		changes = append(changes, fmt.Sprintf("provider-proxies: %d -> %d entries", len(oldCfg.ProviderProxies), len(newCfg.ProviderProxies)))
+	}
 	if oldCfg.WebsocketAuth != newCfg.WebsocketAuth {
 		changes = append(changes, fmt.Sprintf("ws-auth: %t -> %t", oldCfg.WebsocketAuth, newCfg.WebsocketAuth))
 	}
@@ -112,6 +196,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.Routing.MaxConcurrency != newCfg.Routing.MaxConcurrency {
+		changes = append(changes, fmt.Sprintf("routing.max-concurrency: %d -> %d", oldCfg.Routing.MaxConcurrency, newCfg.Routing.MaxConcurrency))
+	}
 	if !reflect.DeepEqual(oldCfg.Payload, newCfg.Payload) {
 		changes = appendPayloadConfigChanges(changes, oldCfg.Payload, newCfg.Payload)
 	}
@@ -122,6 +209,11 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if len(oldCfg.Tenants) != len(newCfg.Tenants) {
+		changes = append(changes, fmt.Sprintf("tenants count: %d -> %d", len(oldCfg.Tenants), len(newCfg.Tenants)))
+	} else if !reflect.DeepEqual(oldCfg.Tenants, newCfg.Tenants) {
+		changes = append(changes, "tenants: updated (redacted)")
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -134,6 +226,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if !reflect.DeepEqual(o.Regions, n.Regions) || o.Region != n.Region {
+				changes = append(changes, fmt.Sprintf("gemini[%d].regions: updated (%d -> %d regions)", i, len(o.Regions), len(n.Regions)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -202,6 +297,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if !reflect.DeepEqual(o.Regions, n.Regions) || o.Region != n.Region {
+				changes = append(changes, fmt.Sprintf("claude[%d].regions: updated (%d -> %d regions)", i, len(o.Regions), len(n.Regions)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("claude[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -251,6 +349,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if !reflect.DeepEqual(o.Regions, n.Regions) || o.Region != n.Region {
+				changes = append(changes, fmt.Sprintf("codex[%d].regions: updated (%d -> %d regions)", i, len(o.Regions), len(n.Regions)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("codex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -320,6 +421,202 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Bedrock credentials (do not print key material)
+	if len(oldCfg.BedrockKey) != len(newCfg.BedrockKey) {
+		changes = append(changes, fmt.Sprintf("bedrock-api-key count: %d -> %d", len(oldCfg.BedrockKey), len(newCfg.BedrockKey)))
+	} else {
+		for i := range oldCfg.BedrockKey {
+			o := oldCfg.BedrockKey[i]
+			n := newCfg.BedrockKey[i]
+			if o.AWSRegion != n.AWSRegion {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].aws-region: %s -> %s", i, o.AWSRegion, n.AWSRegion))
+			}
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if o.APIKey != n.APIKey || o.AccessKeyID != n.AccessKeyID || o.SecretAccessKey != n.SecretAccessKey || o.SessionToken != n.SessionToken {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].credentials: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].headers: updated", i))
+			}
+			oldModels := SummarizeClaudeModels(o.Models)
+			newModels := SummarizeClaudeModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// Azure OpenAI resources (do not print key material)
+	if len(oldCfg.AzureOpenAIKey) != len(newCfg.AzureOpenAIKey) {
+		changes = append(changes, fmt.Sprintf("azure-openai-api-key count: %d -> %d", len(oldCfg.AzureOpenAIKey), len(newCfg.AzureOpenAIKey)))
+	} else {
+		for i := range oldCfg.AzureOpenAIKey {
+			o := oldCfg.AzureOpenAIKey[i]
+			n := newCfg.AzureOpenAIKey[i]
+			if strings.TrimSpace(o.Endpoint) != strings.TrimSpace(n.Endpoint) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].endpoint: %s -> %s", i, strings.TrimSpace(o.Endpoint), strings.TrimSpace(n.Endpoint)))
+			}
+			if o.APIVersion != n.APIVersion {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].api-version: %s -> %s", i, o.APIVersion, n.APIVersion))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if o.APIKey != n.APIKey || o.TenantID != n.TenantID || o.ClientID != n.ClientID || o.ClientSecret != n.ClientSecret || o.AuthorityHost != n.AuthorityHost {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].credentials: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].headers: updated", i))
+			}
+			oldModels := SummarizeClaudeModels(o.Models)
+			newModels := SummarizeClaudeModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("azure-openai[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// Mistral API keys (do not print key material)
+	if len(oldCfg.MistralKey) != len(newCfg.MistralKey) {
+		changes = append(changes, fmt.Sprintf("mistral-api-key count: %d -> %d", len(oldCfg.MistralKey), len(newCfg.MistralKey)))
+	} else {
+		for i := range oldCfg.MistralKey {
+			o := oldCfg.MistralKey[i]
+			n := newCfg.MistralKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("mistral[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("mistral[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("mistral[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("mistral[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("mistral[%d].api-key: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("mistral[%d].headers: updated", i))
+			}
+			oldModels := SummarizeClaudeModels(o.Models)
+			newModels := SummarizeClaudeModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("mistral[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("mistral[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// DeepSeek API keys (do not print key material)
+	if len(oldCfg.DeepSeekKey) != len(newCfg.DeepSeekKey) {
+		changes = append(changes, fmt.Sprintf("deepseek-api-key count: %d -> %d", len(oldCfg.DeepSeekKey), len(newCfg.DeepSeekKey)))
+	} else {
+		for i := range oldCfg.DeepSeekKey {
+			o := oldCfg.DeepSeekKey[i]
+			n := newCfg.DeepSeekKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].api-key: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].headers: updated", i))
+			}
+			oldModels := SummarizeClaudeModels(o.Models)
+			newModels := SummarizeClaudeModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("deepseek[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// Cohere API keys (do not print key material)
+	if len(oldCfg.CohereKey) != len(newCfg.CohereKey) {
+		changes = append(changes, fmt.Sprintf("cohere-api-key count: %d -> %d", len(oldCfg.CohereKey), len(newCfg.CohereKey)))
+	} else {
+		for i := range oldCfg.CohereKey {
+			o := oldCfg.CohereKey[i]
+			n := newCfg.CohereKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("cohere[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("cohere[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("cohere[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("cohere[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("cohere[%d].api-key: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("cohere[%d].headers: updated", i))
+			}
+			oldModels := SummarizeClaudeModels(o.Models)
+			newModels := SummarizeClaudeModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("cohere[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("cohere[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
 	if entries, _ := DiffOAuthExcludedModelChanges(oldCfg.OAuthExcludedModels, newCfg.OAuthExcludedModels); len(entries) > 0 {
 		changes = append(changes, entries...)
 	}
@@ -396,6 +693,47 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Vertex service accounts
+	if len(oldCfg.VertexServiceAccount) != len(newCfg.VertexServiceAccount) {
+		changes = append(changes, fmt.Sprintf("vertex-service-account count: %d -> %d", len(oldCfg.VertexServiceAccount), len(newCfg.VertexServiceAccount)))
+	} else {
+		for i := range oldCfg.VertexServiceAccount {
+			o := oldCfg.VertexServiceAccount[i]
+			n := newCfg.VertexServiceAccount[i]
+			if o.CredentialsFile != n.CredentialsFile {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].credentials-file: %s -> %s", i, o.CredentialsFile, n.CredentialsFile))
+			}
+			if o.ProjectID != n.ProjectID {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].project-id: %s -> %s", i, o.ProjectID, n.ProjectID))
+			}
+			if o.Location != n.Location {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].location: %s -> %s", i, o.Location, n.Location))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			oldModels := SummarizeVertexModels(o.Models)
+			newModels := SummarizeVertexModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("vertex-service-account[%d].headers: updated", i))
+			}
+		}
+	}
+
 	return changes
 }
 
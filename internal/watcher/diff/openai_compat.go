@@ -78,6 +78,12 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if oldEntry.DiscoverModels != newEntry.DiscoverModels {
+		details = append(details, fmt.Sprintf("discover-models %t -> %t", oldEntry.DiscoverModels, newEntry.DiscoverModels))
+	}
+	if oldEntry.PromptCacheKey != newEntry.PromptCacheKey {
+		details = append(details, fmt.Sprintf("prompt-cache-key %t -> %t", oldEntry.PromptCacheKey, newEntry.PromptCacheKey))
+	}
 	if len(details) == 0 {
 		return ""
 	}
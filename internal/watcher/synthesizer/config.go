@@ -13,7 +13,8 @@ import (
 )
 
 // ConfigSynthesizer generates Auth entries from configuration API keys.
-// It handles Gemini, Interactions, Claude, Codex, xAI, OpenAI-compat, and Vertex-compat providers.
+// It handles Gemini, Interactions, Claude, Codex, xAI, Bedrock, Azure OpenAI, Mistral, DeepSeek,
+// Cohere, OpenAI-compat, Vertex-compat and Vertex service account providers.
 type ConfigSynthesizer struct{}
 
 // NewConfigSynthesizer creates a new ConfigSynthesizer instance.
@@ -38,10 +39,22 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeCodexKeys(ctx)...)
 	// xAI API Keys
 	out = append(out, s.synthesizeXAIKeys(ctx)...)
+	// AWS Bedrock credentials
+	out = append(out, s.synthesizeBedrockKeys(ctx)...)
+	// Azure OpenAI resources
+	out = append(out, s.synthesizeAzureOpenAIKeys(ctx)...)
+	// Mistral AI API keys
+	out = append(out, s.synthesizeMistralKeys(ctx)...)
+	// DeepSeek API keys
+	out = append(out, s.synthesizeDeepSeekKeys(ctx)...)
+	// Cohere API keys
+	out = append(out, s.synthesizeCohereKeys(ctx)...)
 	// OpenAI-compat
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
+	// Vertex service accounts
+	out = append(out, s.synthesizeVertexServiceAccounts(ctx)...)
 
 	return out, nil
 }
@@ -83,12 +96,16 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeyEntries(ctx *SynthesisContext, en
 		if entry.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(entry.Priority)
 		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
 		if base != "" {
 			attrs["base_url"] = base
 		}
 		if hash := diff.ComputeGeminiModelsHash(entry.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		addRegionsToAttrs(entry.Regions, entry.Region, attrs)
 		addConfigHeadersToAttrs(entry.Headers, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -138,6 +155,9 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if ck.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(ck.Priority)
 		}
+		if ck.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(ck.MaxConcurrency)
+		}
 		if base != "" {
 			attrs["base_url"] = base
 		}
@@ -147,6 +167,8 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if hash := diff.ComputeClaudeModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		addCredentialScopeToAttrs("", "", ck.Workspace, attrs)
+		addRegionsToAttrs(ck.Regions, ck.Region, attrs)
 		addConfigHeadersToAttrs(ck.Headers, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
@@ -180,6 +202,309 @@ func (s *ConfigSynthesizer) synthesizeXAIKeys(ctx *SynthesisContext) []*coreauth
 	return s.synthesizeCodexStyleKeys(ctx, ctx.Config.XAIKey, "xai")
 }
 
+// synthesizeBedrockKeys creates Auth entries for AWS Bedrock credentials.
+// api_key holds the Bedrock API key, or the IAM access key ID when SigV4 signing is used.
+func (s *ConfigSynthesizer) synthesizeBedrockKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		key := strings.TrimSpace(entry.GetAPIKey())
+		if key == "" {
+			continue
+		}
+		baseURL := strings.TrimSpace(entry.BaseURL)
+		id, token := idGen.Next("bedrock:apikey", key, entry.AWSRegion, baseURL)
+		attrs := map[string]string{
+			"source":     fmt.Sprintf("config:bedrock[%s]", token),
+			"api_key":    key,
+			"aws_region": entry.AWSRegion,
+		}
+		if strings.TrimSpace(entry.APIKey) == "" {
+			attrs["aws_secret_access_key"] = entry.SecretAccessKey
+			if entry.SessionToken != "" {
+				attrs["aws_session_token"] = entry.SessionToken
+			}
+		}
+		metadata := map[string]any{}
+		if entry.DisableCooling {
+			metadata["disable_cooling"] = true
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
+		if baseURL != "" {
+			attrs["base_url"] = baseURL
+		}
+		if hash := diff.ComputeClaudeModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "bedrock",
+			Label:      "bedrock-apikey",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			Metadata:   metadata,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		if len(a.Metadata) == 0 {
+			a.Metadata = nil
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeAzureOpenAIKeys creates Auth entries for Azure OpenAI resources.
+// api_key holds the resource key, or the Entra ID client ID when a service principal is used.
+func (s *ConfigSynthesizer) synthesizeAzureOpenAIKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.AzureOpenAIKey))
+	for i := range cfg.AzureOpenAIKey {
+		entry := cfg.AzureOpenAIKey[i]
+		key := strings.TrimSpace(entry.GetAPIKey())
+		endpoint := strings.TrimSpace(entry.Endpoint)
+		if key == "" || endpoint == "" {
+			continue
+		}
+		id, token := idGen.Next("azure-openai:apikey", key, endpoint)
+		attrs := map[string]string{
+			"source":      fmt.Sprintf("config:azure-openai[%s]", token),
+			"api_key":     key,
+			"base_url":    endpoint,
+			"api_version": entry.APIVersion,
+		}
+		if entry.UsesEntraID() {
+			attrs["tenant_id"] = entry.TenantID
+			attrs["client_secret"] = entry.ClientSecret
+			if entry.AuthorityHost != "" {
+				attrs["authority_host"] = entry.AuthorityHost
+			}
+		}
+		metadata := map[string]any{}
+		if entry.DisableCooling {
+			metadata["disable_cooling"] = true
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
+		if hash := diff.ComputeClaudeModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "azure-openai",
+			Label:      "azure-openai-apikey",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			Metadata:   metadata,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		if len(a.Metadata) == 0 {
+			a.Metadata = nil
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeMistralKeys creates Auth entries for Mistral AI API keys.
+func (s *ConfigSynthesizer) synthesizeMistralKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.MistralKey))
+	for i := range cfg.MistralKey {
+		entry := cfg.MistralKey[i]
+		key := strings.TrimSpace(entry.APIKey)
+		if key == "" {
+			continue
+		}
+		base := strings.TrimSpace(entry.BaseURL)
+		if base == "" {
+			base = config.DefaultMistralBaseURL
+		}
+		id, token := idGen.Next("mistral:apikey", key, base)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:mistral[%s]", token),
+			"api_key":  key,
+			"base_url": base,
+		}
+		metadata := map[string]any{}
+		if entry.DisableCooling {
+			metadata["disable_cooling"] = true
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
+		if hash := diff.ComputeClaudeModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "mistral",
+			Label:      "mistral-apikey",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			Metadata:   metadata,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		if len(a.Metadata) == 0 {
+			a.Metadata = nil
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeDeepSeekKeys creates Auth entries for DeepSeek API keys.
+func (s *ConfigSynthesizer) synthesizeDeepSeekKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.DeepSeekKey))
+	for i := range cfg.DeepSeekKey {
+		entry := cfg.DeepSeekKey[i]
+		key := strings.TrimSpace(entry.APIKey)
+		if key == "" {
+			continue
+		}
+		base := strings.TrimSpace(entry.BaseURL)
+		if base == "" {
+			base = config.DefaultDeepSeekBaseURL
+		}
+		id, token := idGen.Next("deepseek:apikey", key, base)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:deepseek[%s]", token),
+			"api_key":  key,
+			"base_url": base,
+		}
+		metadata := map[string]any{}
+		if entry.DisableCooling {
+			metadata["disable_cooling"] = true
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
+		if hash := diff.ComputeClaudeModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "deepseek",
+			Label:      "deepseek-apikey",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			Metadata:   metadata,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		if len(a.Metadata) == 0 {
+			a.Metadata = nil
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeCohereKeys creates Auth entries for Cohere API keys.
+func (s *ConfigSynthesizer) synthesizeCohereKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.CohereKey))
+	for i := range cfg.CohereKey {
+		entry := cfg.CohereKey[i]
+		key := strings.TrimSpace(entry.APIKey)
+		if key == "" {
+			continue
+		}
+		base := strings.TrimSpace(entry.BaseURL)
+		if base == "" {
+			base = config.DefaultCohereBaseURL
+		}
+		id, token := idGen.Next("cohere:apikey", key, base)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:cohere[%s]", token),
+			"api_key":  key,
+			"base_url": base,
+		}
+		metadata := map[string]any{}
+		if entry.DisableCooling {
+			metadata["disable_cooling"] = true
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
+		if hash := diff.ComputeClaudeModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "cohere",
+			Label:      "cohere-apikey",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			Metadata:   metadata,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		if len(a.Metadata) == 0 {
+			a.Metadata = nil
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
 func (s *ConfigSynthesizer) synthesizeCodexStyleKeys(ctx *SynthesisContext, entries []config.CodexKey, provider string) []*coreauth.Auth {
 	cfg := ctx.Config
 	now := ctx.Now
@@ -194,7 +519,7 @@ func (s *ConfigSynthesizer) synthesizeCodexStyleKeys(ctx *SynthesisContext, entr
 		}
 		prefix := strings.TrimSpace(entry.Prefix)
 		baseURL := strings.TrimSpace(entry.BaseURL)
-		id, token := idGen.Next(provider+":apikey", key, baseURL)
+		id, token := idGen.Next(provider+":apikey", scopedIDParts(entry.Organization, entry.Project, key, baseURL)...)
 		attrs := map[string]string{
 			"source":  fmt.Sprintf("config:%s[%s]", provider, token),
 			"api_key": key,
@@ -206,6 +531,9 @@ func (s *ConfigSynthesizer) synthesizeCodexStyleKeys(ctx *SynthesisContext, entr
 		if entry.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(entry.Priority)
 		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
 		if baseURL != "" {
 			attrs["base_url"] = baseURL
 		}
@@ -215,6 +543,8 @@ func (s *ConfigSynthesizer) synthesizeCodexStyleKeys(ctx *SynthesisContext, entr
 		if hash := diff.ComputeCodexModelsHash(entry.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		addCredentialScopeToAttrs(entry.Organization, entry.Project, "", attrs)
+		addRegionsToAttrs(entry.Regions, entry.Region, attrs)
 		addConfigHeadersToAttrs(entry.Headers, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -265,7 +595,7 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			key := strings.TrimSpace(entry.APIKey)
 			proxyURL := strings.TrimSpace(entry.ProxyURL)
 			idKind := fmt.Sprintf("openai-compatibility:%s", providerName)
-			id, token := idGen.Next(idKind, key, base, proxyURL)
+			id, token := idGen.Next(idKind, scopedIDParts(entry.Organization, entry.Project, key, base, proxyURL)...)
 			attrs := map[string]string{
 				"source":       fmt.Sprintf("config:%s[%s]", providerName, token),
 				"base_url":     base,
@@ -279,12 +609,19 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			if compat.MaxConcurrency > 0 {
+				attrs["max_concurrency"] = strconv.Itoa(compat.MaxConcurrency)
+			}
 			if key != "" {
 				attrs["api_key"] = key
 			}
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			if compat.DiscoverModels {
+				attrs["discover_models"] = "true"
+			}
+			addCredentialScopeToAttrs(entry.Organization, entry.Project, "", attrs)
 			addConfigHeadersToAttrs(compat.Headers, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
@@ -321,9 +658,15 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			if compat.MaxConcurrency > 0 {
+				attrs["max_concurrency"] = strconv.Itoa(compat.MaxConcurrency)
+			}
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
+			if compat.DiscoverModels {
+				attrs["discover_models"] = "true"
+			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
@@ -370,6 +713,9 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 		if compat.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(compat.Priority)
 		}
+		if compat.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(compat.MaxConcurrency)
+		}
 		if key != "" {
 			attrs["api_key"] = key
 		}
@@ -393,3 +739,60 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 	}
 	return out
 }
+
+// synthesizeVertexServiceAccounts creates Auth entries for vertex-service-account entries.
+// The executor reads credentials_file on use, or falls back to Application Default Credentials.
+func (s *ConfigSynthesizer) synthesizeVertexServiceAccounts(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.VertexServiceAccount))
+	for i := range cfg.VertexServiceAccount {
+		entry := &cfg.VertexServiceAccount[i]
+		credentialsFile := strings.TrimSpace(entry.CredentialsFile)
+		id, token := idGen.Next("vertex:service-account", credentialsFile, entry.ProjectID, entry.Location)
+		attrs := map[string]string{
+			"source":           fmt.Sprintf("config:vertex-service-account[%s]", token),
+			"provider_key":     "vertex",
+			"project_id":       entry.ProjectID,
+			"location":         entry.Location,
+			"credentials_file": credentialsFile,
+		}
+		metadata := map[string]any{
+			"project_id": entry.ProjectID,
+			"location":   entry.Location,
+		}
+		if entry.UsesADC() {
+			metadata["credential_source"] = "adc"
+		} else {
+			metadata["credentials_file"] = credentialsFile
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if entry.MaxConcurrency > 0 {
+			attrs["max_concurrency"] = strconv.Itoa(entry.MaxConcurrency)
+		}
+		if hash := diff.ComputeVertexCompatModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "vertex",
+			Label:      "vertex-service-account",
+			Prefix:     strings.TrimSpace(entry.Prefix),
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			Metadata:   metadata,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		// Config-defined credentials are static like API keys, so they share API key routing.
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
@@ -576,6 +576,50 @@ func TestConfigSynthesizer_VertexCompat(t *testing.T) {
 	}
 }
 
+func TestConfigSynthesizer_VertexServiceAccounts(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			VertexServiceAccount: []config.VertexServiceAccount{
+				{CredentialsFile: "/etc/vertex/sa.json", Location: "europe-west4", Prefix: "vx"},
+				{ProjectID: "wi-project", Location: "us-central1"},
+			},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 2 {
+		t.Fatalf("expected 2 auths, got %d", len(auths))
+	}
+
+	file := auths[0]
+	if file.Provider != "vertex" || file.Label != "vertex-service-account" || file.Prefix != "vx" {
+		t.Errorf("unexpected file auth: provider=%s label=%s prefix=%s", file.Provider, file.Label, file.Prefix)
+	}
+	if file.Metadata["credentials_file"] != "/etc/vertex/sa.json" || file.Metadata["location"] != "europe-west4" {
+		t.Errorf("unexpected file auth metadata: %v", file.Metadata)
+	}
+	if file.AuthKind() != coreauth.AuthKindAPIKey {
+		t.Errorf("expected config service account to route as apikey, got %s", file.AuthKind())
+	}
+
+	adc := auths[1]
+	if adc.Metadata["credential_source"] != "adc" || adc.Metadata["project_id"] != "wi-project" {
+		t.Errorf("unexpected adc auth metadata: %v", adc.Metadata)
+	}
+	if _, ok := adc.Metadata["credentials_file"]; ok {
+		t.Errorf("adc auth must not carry a credentials file: %v", adc.Metadata)
+	}
+	if file.ID == adc.ID {
+		t.Errorf("expected distinct IDs, got %s", file.ID)
+	}
+}
+
 func TestConfigSynthesizer_VertexCompat_SkipsEmptyAndHeaders(t *testing.T) {
 	synth := NewConfigSynthesizer()
 	ctx := &SynthesisContext{
@@ -790,3 +834,48 @@ func TestConfigSynthesizer_AllProviders(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigSynthesizer_CredentialScoping(t *testing.T) {
+	synth := NewConfigSynthesizer()
+	ctx := &SynthesisContext{
+		Config: &config.Config{
+			CodexKey: []config.CodexKey{
+				{APIKey: "sk-shared", BaseURL: "https://api.openai.com/v1", Project: "proj_a"},
+				{APIKey: "sk-shared", BaseURL: "https://api.openai.com/v1", Organization: "org-1", Project: "proj_b", Headers: map[string]string{"OpenAI-Project": "proj_override"}},
+			},
+			ClaudeKey: []config.ClaudeKey{{APIKey: "sk-ant", Workspace: "research"}},
+		},
+		Now:         time.Now(),
+		IDGenerator: NewStableIDGenerator(),
+	}
+
+	auths, err := synth.Synthesize(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(auths) != 3 {
+		t.Fatalf("expected 3 auths, got %d", len(auths))
+	}
+	claude, projectA, projectB := auths[0], auths[1], auths[2]
+	if projectA.ID == projectB.ID {
+		t.Fatalf("projects of one key should be distinct credentials, both got ID %s", projectA.ID)
+	}
+	if got := projectA.Attributes["header:OpenAI-Project"]; got != "proj_a" {
+		t.Errorf("project header = %q, want proj_a", got)
+	}
+	if _, ok := projectA.Attributes["header:OpenAI-Organization"]; ok {
+		t.Error("organization header should be absent when not configured")
+	}
+	if got := projectB.Attributes["header:OpenAI-Organization"]; got != "org-1" {
+		t.Errorf("organization header = %q, want org-1", got)
+	}
+	if got := projectB.Attributes["header:OpenAI-Project"]; got != "proj_override" {
+		t.Errorf("explicit header = %q, want proj_override", got)
+	}
+	if got := projectB.Attributes["project"]; got != "proj_b" {
+		t.Errorf("project attribute = %q, want proj_b", got)
+	}
+	if got := claude.Attributes["workspace"]; got != "research" {
+		t.Errorf("workspace attribute = %q, want research", got)
+	}
+}
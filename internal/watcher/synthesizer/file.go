@@ -11,6 +11,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/codex"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/credcrypt"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
 )
@@ -71,6 +72,12 @@ func synthesizeFileAuths(ctx *SynthesisContext, fullPath string, data []byte) []
 	}
 	now := ctx.Now
 	cfg := ctx.Config
+	// Files whose refresh token cannot be decrypted are skipped like unparseable ones; the file
+	// store reports the error when it loads them.
+	data, errOpen := credcrypt.Default().OpenJSON(data)
+	if errOpen != nil {
+		return nil
+	}
 	var metadata map[string]any
 	if errUnmarshal := json.Unmarshal(data, &metadata); errUnmarshal != nil {
 		return nil
@@ -196,6 +203,20 @@ func synthesizeFileAuths(ctx *SynthesisContext, fullPath string, data []byte) []
 			}
 		}
 	}
+	// Read per-credential concurrency cap from auth file.
+	if rawMaxConcurrency, ok := metadata["max_concurrency"]; ok {
+		switch v := rawMaxConcurrency.(type) {
+		case float64:
+			if v > 0 {
+				a.Attributes["max_concurrency"] = strconv.Itoa(int(v))
+			}
+		case string:
+			limit := strings.TrimSpace(v)
+			if parsed, errAtoi := strconv.Atoi(limit); errAtoi == nil && parsed > 0 {
+				a.Attributes["max_concurrency"] = limit
+			}
+		}
+	}
 	// Read note from auth file.
 	if rawNote, ok := metadata["note"]; ok {
 		if note, isStr := rawNote.(string); isStr {
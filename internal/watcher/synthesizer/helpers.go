@@ -3,6 +3,7 @@ package synthesizer
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -103,6 +104,54 @@ func ApplyAuthExcludedModelsMeta(auth *coreauth.Auth, cfg *config.Config, perKey
 	}
 }
 
+// scopedIDParts returns the stable ID parts for a credential, appending organization and
+// project only when set so unscoped credentials keep their existing IDs.
+func scopedIDParts(organization, project string, parts ...string) []string {
+	if organization = strings.TrimSpace(organization); organization != "" {
+		parts = append(parts, "org:"+organization)
+	}
+	if project = strings.TrimSpace(project); project != "" {
+		parts = append(parts, "project:"+project)
+	}
+	return parts
+}
+
+// addCredentialScopeToAttrs records provider-side organization, project and workspace scoping
+// for usage attribution. OpenAI organization and project are also sent as request headers;
+// call it before addConfigHeadersToAttrs so explicitly configured headers take precedence.
+func addCredentialScopeToAttrs(organization, project, workspace string, attrs map[string]string) {
+	if attrs == nil {
+		return
+	}
+	if organization = strings.TrimSpace(organization); organization != "" {
+		attrs["organization"] = organization
+		attrs["header:OpenAI-Organization"] = organization
+	}
+	if project = strings.TrimSpace(project); project != "" {
+		attrs["project"] = project
+		attrs["header:OpenAI-Project"] = project
+	}
+	if workspace = strings.TrimSpace(workspace); workspace != "" {
+		attrs["workspace"] = workspace
+	}
+}
+
+// addRegionsToAttrs records the regional endpoints and region pin of a credential for the
+// region router. Regions are stored as JSON because attributes are flat strings.
+func addRegionsToAttrs(regions []config.RegionEndpoint, pin string, attrs map[string]string) {
+	if len(regions) == 0 || attrs == nil {
+		return
+	}
+	data, errMarshal := json.Marshal(regions)
+	if errMarshal != nil {
+		return
+	}
+	attrs["regions"] = string(data)
+	if pin = strings.TrimSpace(pin); pin != "" {
+		attrs["region"] = pin
+	}
+}
+
 // addConfigHeadersToAttrs adds header configuration to auth attributes.
 // Headers are prefixed with "header:" in the attributes map.
 func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string) {
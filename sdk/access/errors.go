@@ -13,6 +13,7 @@ const (
 	AuthErrorCodeNoCredentials     AuthErrorCode = "no_credentials"
 	AuthErrorCodeInvalidCredential AuthErrorCode = "invalid_credential"
 	AuthErrorCodeNotHandled        AuthErrorCode = "not_handled"
+	AuthErrorCodeForbidden         AuthErrorCode = "forbidden"
 	AuthErrorCodeInternal          AuthErrorCode = "internal_error"
 )
 
@@ -70,6 +71,11 @@ func NewInvalidCredentialError() *AuthError {
 	return newAuthError(AuthErrorCodeInvalidCredential, "Invalid API key", http.StatusUnauthorized, nil)
 }
 
+// NewForbiddenError rejects valid credentials that carry no permission for the proxy.
+func NewForbiddenError(message string) *AuthError {
+	return newAuthError(AuthErrorCodeForbidden, message, http.StatusForbidden, nil)
+}
+
 func NewNotHandledError() *AuthError {
 	return newAuthError(AuthErrorCodeNotHandled, "authentication provider did not handle request", 0, nil)
 }
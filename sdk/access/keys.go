@@ -0,0 +1,439 @@
+package access
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// ScopedKeyProviderName identifies results produced by managed scoped keys.
+const ScopedKeyProviderName = "scoped-key"
+
+// scopedKeyPrefix starts every generated key so it is recognizable in logs and configs.
+const scopedKeyPrefix = "sk-cpa-"
+
+// Metadata keys set on Result.Metadata for requests authenticated with a scoped key or a
+// tenant API key.
+const (
+	MetadataKeyID             = "key-id"
+	MetadataKeyName           = "key-name"
+	MetadataAllowedProviders  = "allowed-providers"
+	MetadataAllowedModels     = "allowed-models"
+	MetadataReadOnly          = "read-only"
+	MetadataTenant            = "tenant"
+	MetadataCredentialPrefix  = "credential-prefix"
+	MetadataDisableRequestLog = "disable-request-log"
+)
+
+var (
+	// ErrKeyNotFound is returned when a scoped key id is unknown.
+	ErrKeyNotFound = errors.New("api key not found")
+	// ErrKeyRevoked is returned when revoking a key that is already revoked.
+	ErrKeyRevoked = errors.New("api key already revoked")
+)
+
+// ScopedKey is a managed client API key. Only a SHA-256 hash of the secret is kept.
+type ScopedKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Hash is the hex SHA-256 of the secret.
+	Hash string `json:"hash,omitempty"`
+	// Hint is the start of the secret, shown to identify the key without revealing it.
+	Hint string `json:"hint"`
+	// AllowedProviders restricts routing to these providers; empty allows every provider.
+	AllowedProviders []string `json:"allowed_providers,omitempty"`
+	// AllowedModels restricts requests to models matching these patterns ('*' wildcard);
+	// empty allows every model.
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	// ReadOnly keys may list models but not run requests against them.
+	ReadOnly  bool       `json:"read_only,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// KeySpec describes a scoped key to create.
+type KeySpec struct {
+	Name             string     `json:"name"`
+	AllowedProviders []string   `json:"allowed_providers"`
+	AllowedModels    []string   `json:"allowed_models"`
+	ReadOnly         bool       `json:"read_only"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+}
+
+// Active reports whether the key can authenticate at now.
+func (k ScopedKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// KeyStore persists scoped keys. Save receives the complete key list.
+type KeyStore interface {
+	Load(ctx context.Context) ([]ScopedKey, error)
+	Save(ctx context.Context, keys []ScopedKey) error
+}
+
+// FileKeyStore keeps scoped keys in a JSON file.
+type FileKeyStore struct {
+	path string
+}
+
+// NewFileKeyStore returns a store backed by the JSON file at path.
+func NewFileKeyStore(path string) *FileKeyStore {
+	return &FileKeyStore{path: path}
+}
+
+// Load reads the key file; a missing file yields no keys.
+func (s *FileKeyStore) Load(_ context.Context) ([]ScopedKey, error) {
+	data, errRead := os.ReadFile(s.path)
+	if errRead != nil {
+		if os.IsNotExist(errRead) {
+			return nil, nil
+		}
+		return nil, errRead
+	}
+	var file struct {
+		Keys []ScopedKey `json:"keys"`
+	}
+	if errUnmarshal := json.Unmarshal(data, &file); errUnmarshal != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, errUnmarshal)
+	}
+	return file.Keys, nil
+}
+
+// Save atomically replaces the key file.
+func (s *FileKeyStore) Save(_ context.Context, keys []ScopedKey) error {
+	data, errMarshal := json.MarshalIndent(struct {
+		Keys []ScopedKey `json:"keys"`
+	}{Keys: keys}, "", "  ")
+	if errMarshal != nil {
+		return errMarshal
+	}
+	return util.WriteFileAtomic(s.path, data)
+}
+
+// SetKeyStore attaches the scoped key backend and loads its keys.
+func (m *Manager) SetKeyStore(ctx context.Context, store KeyStore) error {
+	if m == nil {
+		return nil
+	}
+	var keys []ScopedKey
+	if store != nil {
+		loaded, errLoad := store.Load(ctx)
+		if errLoad != nil {
+			return errLoad
+		}
+		keys = loaded
+	}
+	m.mu.Lock()
+	m.keyStore = store
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+// KeyStore returns the attached scoped key backend, or nil.
+func (m *Manager) KeyStore() KeyStore {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keyStore
+}
+
+// Keys returns a snapshot of the scoped keys, including revoked and expired ones.
+func (m *Manager) Keys() []ScopedKey {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]ScopedKey(nil), m.keys...)
+}
+
+// CreateKey generates a scoped key and persists it. The secret is returned only here.
+func (m *Manager) CreateKey(ctx context.Context, spec KeySpec) (string, ScopedKey, error) {
+	if m == nil {
+		return "", ScopedKey{}, errors.New("access manager is nil")
+	}
+	raw := make([]byte, 24)
+	if _, errRand := rand.Read(raw); errRand != nil {
+		return "", ScopedKey{}, errRand
+	}
+	secret := scopedKeyPrefix + hex.EncodeToString(raw)
+	id := make([]byte, 6)
+	if _, errRand := rand.Read(id); errRand != nil {
+		return "", ScopedKey{}, errRand
+	}
+	key := ScopedKey{
+		ID:               "key_" + hex.EncodeToString(id),
+		Name:             strings.TrimSpace(spec.Name),
+		Hash:             hashKey(secret),
+		Hint:             secret[:len(scopedKeyPrefix)+4],
+		AllowedProviders: normalizeScopeList(spec.AllowedProviders),
+		AllowedModels:    normalizeScopeList(spec.AllowedModels),
+		ReadOnly:         spec.ReadOnly,
+		CreatedAt:        clock.Default().Now().UTC(),
+		ExpiresAt:        spec.ExpiresAt,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := append(append([]ScopedKey(nil), m.keys...), key)
+	if errSave := m.saveKeysLocked(ctx, keys); errSave != nil {
+		return "", ScopedKey{}, errSave
+	}
+	return secret, key, nil
+}
+
+// RevokeKey marks the key with id as revoked. Revoked keys stay listed for auditing.
+func (m *Manager) RevokeKey(ctx context.Context, id string) (ScopedKey, error) {
+	if m == nil {
+		return ScopedKey{}, ErrKeyNotFound
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.keys {
+		if m.keys[i].ID != id {
+			continue
+		}
+		if m.keys[i].RevokedAt != nil {
+			return m.keys[i], ErrKeyRevoked
+		}
+		keys := append([]ScopedKey(nil), m.keys...)
+		now := clock.Default().Now().UTC()
+		keys[i].RevokedAt = &now
+		if errSave := m.saveKeysLocked(ctx, keys); errSave != nil {
+			return ScopedKey{}, errSave
+		}
+		return keys[i], nil
+	}
+	return ScopedKey{}, ErrKeyNotFound
+}
+
+// saveKeysLocked persists keys and, on success, makes them current.
+func (m *Manager) saveKeysLocked(ctx context.Context, keys []ScopedKey) error {
+	if m.keyStore != nil {
+		if errSave := m.keyStore.Save(ctx, keys); errSave != nil {
+			return errSave
+		}
+	}
+	m.keys = keys
+	return nil
+}
+
+// authenticateScopedKey matches the request credentials against the scoped keys. It returns
+// NotHandled when no scoped keys exist, so plain providers keep their behavior.
+func (m *Manager) authenticateScopedKey(r *http.Request) (*Result, *AuthError) {
+	m.mu.RLock()
+	keys := m.keys
+	m.mu.RUnlock()
+	if len(keys) == 0 {
+		return nil, NewNotHandledError()
+	}
+	candidates := requestCredentials(r)
+	if len(candidates) == 0 {
+		return nil, NewNoCredentialsError()
+	}
+	now := clock.Default().Now()
+	for _, candidate := range candidates {
+		hash := hashKey(candidate.value)
+		for _, key := range keys {
+			if key.Hash != hash {
+				continue
+			}
+			if key.RevokedAt != nil {
+				return nil, newAuthError(AuthErrorCodeInvalidCredential, "API key revoked", http.StatusUnauthorized, nil)
+			}
+			if !key.Active(now) {
+				return nil, newAuthError(AuthErrorCodeInvalidCredential, "API key expired", http.StatusUnauthorized, nil)
+			}
+			return &Result{
+				Provider:  ScopedKeyProviderName,
+				Principal: candidate.value,
+				Metadata:  key.metadata(candidate.source),
+			}, nil
+		}
+	}
+	return nil, NewInvalidCredentialError()
+}
+
+func (k ScopedKey) metadata(source string) map[string]string {
+	meta := map[string]string{
+		"source":      source,
+		MetadataKeyID: k.ID,
+	}
+	if k.Name != "" {
+		meta[MetadataKeyName] = k.Name
+	}
+	AddScopeMetadata(meta, sdkconfig.AccessScope{AllowedProviders: k.AllowedProviders, AllowedModels: k.AllowedModels, ReadOnly: k.ReadOnly})
+	return meta
+}
+
+// AddScopeMetadata records scope in the result metadata meta, as ScopeFromMetadata reads it back.
+func AddScopeMetadata(meta map[string]string, scope sdkconfig.AccessScope) {
+	if len(scope.AllowedProviders) > 0 {
+		meta[MetadataAllowedProviders] = strings.Join(scope.AllowedProviders, ",")
+	}
+	if len(scope.AllowedModels) > 0 {
+		meta[MetadataAllowedModels] = strings.Join(scope.AllowedModels, ",")
+	}
+	if scope.ReadOnly {
+		meta[MetadataReadOnly] = "true"
+	}
+}
+
+// KeyScope is the restriction set carried by a scoped key's result metadata. The zero value
+// allows everything.
+type KeyScope struct {
+	Providers []string
+	Models    []string
+	ReadOnly  bool
+	// Tenant and CredentialPrefix are set for tenant API keys.
+	Tenant           string
+	CredentialPrefix string
+}
+
+// ScopeFromMetadata decodes the scope of an authenticated request from Result.Metadata.
+func ScopeFromMetadata(meta map[string]string) KeyScope {
+	readOnly, _ := strconv.ParseBool(meta[MetadataReadOnly])
+	return KeyScope{
+		Providers:        splitScopeList(meta[MetadataAllowedProviders]),
+		Models:           splitScopeList(meta[MetadataAllowedModels]),
+		ReadOnly:         readOnly,
+		Tenant:           meta[MetadataTenant],
+		CredentialPrefix: meta[MetadataCredentialPrefix],
+	}
+}
+
+// AllowsProvider reports whether provider is within the scope.
+func (s KeyScope) AllowsProvider(provider string) bool {
+	if len(s.Providers) == 0 {
+		return true
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, allowed := range s.Providers {
+		if allowed == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsModel reports whether model matches one of the scope's model patterns.
+func (s KeyScope) AllowsModel(model string) bool {
+	if len(s.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range s.Models {
+		if MatchWildcard(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+type credential struct {
+	value  string
+	source string
+}
+
+// requestCredentials lists the API key candidates of r, using the same sources as the
+// built-in config API key provider.
+func requestCredentials(r *http.Request) []credential {
+	if r == nil {
+		return nil
+	}
+	authHeader := r.Header.Get("Authorization")
+	if parts := strings.SplitN(authHeader, " ", 2); len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+		authHeader = strings.TrimSpace(parts[1])
+	}
+	all := []credential{
+		{authHeader, "authorization"},
+		{r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key"},
+		{r.Header.Get("X-Api-Key"), "x-api-key"},
+	}
+	if r.URL != nil {
+		query := r.URL.Query()
+		all = append(all, credential{query.Get("key"), "query-key"}, credential{query.Get("auth_token"), "query-auth-token"})
+	}
+	out := all[:0]
+	for _, c := range all {
+		if c.value != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func hashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeScopeList(values []string) []string {
+	var out []string
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if _, exists := seen[value]; exists {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	return out
+}
+
+func splitScopeList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return normalizeScopeList(strings.Split(value, ","))
+}
+
+// MatchWildcard reports whether value matches pattern, where '*' matches any substring. The
+// comparison is case-sensitive.
+func MatchWildcard(pattern, value string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	if !strings.HasSuffix(value, last) || len(value) < len(last) {
+		return false
+	}
+	value = value[:len(value)-len(last)]
+	for _, segment := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package access
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+)
+
+func TestManagerScopedKeyLifecycle(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "scoped-keys.json")
+	manager := NewManager()
+	if err := manager.SetKeyStore(ctx, NewFileKeyStore(path)); err != nil {
+		t.Fatalf("SetKeyStore() error = %v", err)
+	}
+
+	secret, key, err := manager.CreateKey(ctx, KeySpec{
+		Name:             "ci",
+		AllowedProviders: []string{" Claude ", "claude"},
+		AllowedModels:    []string{"claude-*"},
+	})
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if len(key.AllowedProviders) != 1 || key.AllowedProviders[0] != "claude" {
+		t.Fatalf("AllowedProviders = %v", key.AllowedProviders)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	req.Header.Set("X-Api-Key", secret)
+	res, authErr := manager.Authenticate(ctx, req)
+	if authErr != nil {
+		t.Fatalf("Authenticate() error = %v", authErr)
+	}
+	if res.Provider != ScopedKeyProviderName || res.Principal != secret || res.Metadata[MetadataKeyID] != key.ID {
+		t.Fatalf("result = %+v", res)
+	}
+	scope := ScopeFromMetadata(res.Metadata)
+	if !scope.AllowsModel("Claude-Sonnet-4") || scope.AllowsModel("gpt-5") {
+		t.Fatalf("model scope = %+v", scope)
+	}
+	if !scope.AllowsProvider("claude") || scope.AllowsProvider("gemini") {
+		t.Fatalf("provider scope = %+v", scope)
+	}
+
+	reloaded := NewManager()
+	if err := reloaded.SetKeyStore(ctx, NewFileKeyStore(path)); err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if keys := reloaded.Keys(); len(keys) != 1 || keys[0].ID != key.ID {
+		t.Fatalf("reloaded keys = %+v", keys)
+	}
+
+	if _, err := reloaded.RevokeKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+	if _, err := reloaded.RevokeKey(ctx, key.ID); err != ErrKeyRevoked {
+		t.Fatalf("second RevokeKey() error = %v, want ErrKeyRevoked", err)
+	}
+	if _, authErr := reloaded.Authenticate(ctx, req); authErr == nil || authErr.Message != "API key revoked" {
+		t.Fatalf("Authenticate() after revoke error = %v", authErr)
+	}
+}
+
+func TestManagerScopedKeyExpiryAndFallback(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager()
+	past := time.Now().Add(-time.Hour)
+	manager.keys = []ScopedKey{{ID: "key_old", Hash: hashKey("sk-cpa-old"), ExpiresAt: &past}}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer sk-cpa-old")
+	if _, authErr := manager.Authenticate(ctx, req); authErr == nil || authErr.Message != "API key expired" {
+		t.Fatalf("Authenticate() expired error = %v", authErr)
+	}
+
+	// Without any provider configured, unknown and missing credentials are still rejected.
+	req.Header.Set("Authorization", "Bearer unknown")
+	if _, authErr := manager.Authenticate(ctx, req); !IsAuthErrorCode(authErr, AuthErrorCodeInvalidCredential) {
+		t.Fatalf("Authenticate() unknown error = %v", authErr)
+	}
+	req.Header.Del("Authorization")
+	if _, authErr := manager.Authenticate(ctx, req); !IsAuthErrorCode(authErr, AuthErrorCodeNoCredentials) {
+		t.Fatalf("Authenticate() missing error = %v", authErr)
+	}
+
+	// Plain provider keys keep working alongside scoped keys.
+	manager.SetProviders([]Provider{testProvider{id: "inline"}})
+	req.Header.Set("Authorization", "Bearer unknown")
+	if res, authErr := manager.Authenticate(ctx, req); authErr != nil || res.Provider != "inline" {
+		t.Fatalf("Authenticate() provider fallback = %+v, %v", res, authErr)
+	}
+}
+
+func TestManagerScopedKeyExpiryFollowsDefaultClock(t *testing.T) {
+	ctx := context.Background()
+	sim := clock.NewSim(time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC))
+	t.Cleanup(clock.SetDefault(sim, nil))
+
+	manager := NewManager()
+	expiresAt := sim.Now().Add(time.Hour)
+	secret, key, errCreate := manager.CreateKey(ctx, KeySpec{Name: "ci", ExpiresAt: &expiresAt})
+	if errCreate != nil {
+		t.Fatalf("CreateKey() error = %v", errCreate)
+	}
+	if !key.CreatedAt.Equal(sim.Now()) {
+		t.Fatalf("CreatedAt = %v, want %v", key.CreatedAt, sim.Now())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer "+secret)
+	if _, authErr := manager.Authenticate(ctx, req); authErr != nil {
+		t.Fatalf("Authenticate() before expiry error = %v", authErr)
+	}
+	sim.Advance(2 * time.Hour)
+	if _, authErr := manager.Authenticate(ctx, req); authErr == nil || authErr.Message != "API key expired" {
+		t.Fatalf("Authenticate() after expiry error = %v", authErr)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"sync"
 )
 
-// Manager coordinates authentication providers.
+// Manager coordinates authentication providers and managed scoped keys.
 type Manager struct {
 	mu        sync.RWMutex
 	providers []Provider
+	keyStore  KeyStore
+	keys      []ScopedKey
 }
 
 // NewManager constructs an empty manager.
@@ -41,20 +43,33 @@ func (m *Manager) Providers() []Provider {
 	return snapshot
 }
 
-// Authenticate evaluates providers until one succeeds.
+// Authenticate checks the scoped keys, then evaluates providers until one succeeds.
 func (m *Manager) Authenticate(ctx context.Context, r *http.Request) (*Result, *AuthError) {
 	if m == nil {
 		return nil, nil
 	}
+	scopedRes, scopedErr := m.authenticateScopedKey(r)
+	if scopedErr == nil {
+		return scopedRes, nil
+	}
+	scopedHandled := !IsAuthErrorCode(scopedErr, AuthErrorCodeNotHandled)
 	providers := m.Providers()
-	if len(providers) == 0 {
+	if len(providers) == 0 && !scopedHandled {
 		return nil, nil
 	}
 
+	// invalid keeps the first credential rejection so specific messages such as "API key
+	// revoked" reach the client.
 	var (
 		missing bool
-		invalid bool
+		invalid *AuthError
 	)
+	if scopedHandled {
+		missing = IsAuthErrorCode(scopedErr, AuthErrorCodeNoCredentials)
+		if IsAuthErrorCode(scopedErr, AuthErrorCodeInvalidCredential) {
+			invalid = scopedErr
+		}
+	}
 
 	for _, provider := range providers {
 		if provider == nil {
@@ -72,14 +87,16 @@ func (m *Manager) Authenticate(ctx context.Context, r *http.Request) (*Result, *
 			continue
 		}
 		if IsAuthErrorCode(authErr, AuthErrorCodeInvalidCredential) {
-			invalid = true
+			if invalid == nil {
+				invalid = authErr
+			}
 			continue
 		}
 		return nil, authErr
 	}
 
-	if invalid {
-		return nil, NewInvalidCredentialError()
+	if invalid != nil {
+		return nil, invalid
 	}
 	if missing {
 		return nil, NewNoCredentialsError()
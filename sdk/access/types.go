@@ -28,6 +28,15 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeOIDC is the built-in provider validating JWTs from an OIDC issuer.
+	AccessProviderTypeOIDC = "oidc-jwt"
+
+	// AccessProviderTypeTenant is the built-in provider validating the API keys of tenants.
+	AccessProviderTypeTenant = "tenant-api-key"
+
+	// AccessProviderTypeMTLS is the built-in provider accepting verified TLS client certificates.
+	AccessProviderTypeMTLS = "mtls"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
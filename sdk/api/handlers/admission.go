@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+)
+
+// RequestPriorityGinKey is the Gin context key naming the request queue priority class of a
+// detached request, such as "batch" for batch lines. Unset, the key's configured class applies.
+const RequestPriorityGinKey = "requestPriority"
+
+var errMissingGinContext = errors.New("request context carries no gin context")
+
+// AdmissionFunc admits a request that bypasses the HTTP middleware chain, applying the same
+// quota, budget, rate-limit and request queue checks to the client API key carried by ginCtx.
+// The returned release func must be called once the request has finished; it is nil when the
+// request is rejected.
+type AdmissionFunc func(ginCtx *gin.Context) (release func(), errMsg *interfaces.ErrorMessage)
+
+// SetAdmission configures the admission checks applied by Admit.
+func (h *BaseAPIHandler) SetAdmission(admission AdmissionFunc) {
+	if h == nil {
+		return
+	}
+	h.Admission = admission
+}
+
+// Admit runs the configured admission checks for the request whose Gin context ctx carries.
+// Requests are admitted unchecked when no admission is configured.
+func (h *BaseAPIHandler) Admit(ctx context.Context) (func(), *interfaces.ErrorMessage) {
+	if h == nil || h.Admission == nil {
+		return func() {}, nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errMissingGinContext}
+	}
+	return h.Admission(ginCtx)
+}
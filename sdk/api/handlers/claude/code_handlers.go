@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/anthropicfiles"
 	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
@@ -33,6 +34,8 @@ import (
 // It holds a pool of clients to interact with the backend service.
 type ClaudeCodeAPIHandler struct {
 	*handlers.BaseAPIHandler
+	// files stores Anthropic Files API uploads; nil disables the file endpoints.
+	files *anthropicfiles.Store
 }
 
 // NewClaudeCodeAPIHandler creates a new Claude API handlers instance.
@@ -58,7 +61,7 @@ func (h *ClaudeCodeAPIHandler) HandlerType() string {
 func (h *ClaudeCodeAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
 	modelRegistry := registry.GetGlobalRegistry()
-	return modelRegistry.GetAvailableModels("claude")
+	return h.WithSyntheticModels(Claude, modelRegistry.GetAvailableModels("claude"))
 }
 
 // ClaudeMessages handles Claude-compatible streaming chat completions.
@@ -84,6 +87,12 @@ func (h *ClaudeCodeAPIHandler) ClaudeMessages(c *gin.Context) {
 	// Decode claude-fable-5-dd-<reversed> model IDs back to the real model name for routing.
 	rawJSON = rewriteClaudeDDModelInBody(rawJSON)
 
+	// Inline references to locally stored Files API uploads.
+	rawJSON, ok := h.inlineFileReferences(c, rawJSON)
+	if !ok {
+		return
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	if !streamResult.Exists() || streamResult.Type == gjson.False {
@@ -116,6 +125,12 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 	// Decode claude-fable-5-dd-<reversed> model IDs back to the real model name for routing.
 	rawJSON = rewriteClaudeDDModelInBody(rawJSON)
 
+	// Inline references to locally stored Files API uploads.
+	rawJSON, ok := h.inlineFileReferences(c, rawJSON)
+	if !ok {
+		return
+	}
+
 	c.Header("Content-Type", "application/json")
 
 	alt := h.GetAlt(c)
@@ -173,12 +188,18 @@ func (h *ClaudeCodeAPIHandler) ClaudeModels(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"data":     models,
 		"has_more": false,
 		"first_id": firstID,
 		"last_id":  lastID,
-	})
+	}
+	if len(models) == 0 {
+		if notice := h.ModelsNotice(); notice != nil {
+			response["notice"] = notice
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // sortClaudeModelsByDisplayName sorts models by display_name ascending.
@@ -274,13 +295,20 @@ func (h *ClaudeCodeAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON [
 	// This allows proper cleanup and cancellation of ongoing requests
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
 
-	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
 	setSSEHeaders := func() {
 		c.Header("Content-Type", "text/event-stream")
 		c.Header("Cache-Control", "no-cache")
 		c.Header("Connection", "keep-alive")
 		c.Header("Access-Control-Allow-Origin", "*")
 	}
+	dataChan, upstreamHeaders, errChan, started := h.ExecuteStreamWithHeartbeat(c, flusher, setSSEHeaders, func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+		return h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
+	})
+	if started {
+		// Heartbeats already committed the response; errors are reported in-stream.
+		h.forwardClaudeStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+		return
+	}
 
 	// Peek at the first chunk to determine success or failure before setting headers
 	for {
@@ -417,8 +445,8 @@ func (h *ClaudeCodeAPIHandler) WriteErrorResponse(c *gin.Context, msg *interface
 		status = msg.StatusCode
 	}
 	if msg != nil && msg.Addon != nil && handlers.PassthroughHeadersEnabled(h.Cfg) {
-		for key, values := range msg.Addon {
-			if len(values) == 0 || handlers.IsCPAReservedResponseHeader(key) {
+		for key, values := range handlers.FilterUpstreamHeadersWithPolicy(msg.Addon, h.Cfg.ResponseHeaders) {
+			if len(values) == 0 {
 				continue
 			}
 			c.Writer.Header().Del(key)
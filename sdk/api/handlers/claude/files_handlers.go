@@ -0,0 +1,419 @@
+package claude
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/anthropicfiles"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultClaudeFilesListLimit = 20
+	maxClaudeFilesListLimit     = 1000
+	// claudeFilesMultipartOverhead allows for multipart framing on top of the file size limit.
+	claudeFilesMultipartOverhead int64 = 1 << 20
+	defaultClaudeFilesBaseURL          = "https://api.anthropic.com"
+)
+
+// claudeFilesForwardHeaders are copied from the client request in passthrough mode.
+var claudeFilesForwardHeaders = []string{"Anthropic-Version", "Anthropic-Beta", "Content-Type", "Accept"}
+
+// SetFileStore installs the Anthropic Files API store used by the /v1/files handlers
+// and for inlining file references in /v1/messages.
+func (h *ClaudeCodeAPIHandler) SetFileStore(store *anthropicfiles.Store) {
+	h.files = store
+}
+
+// FilesEnabled reports whether Anthropic /v1/files requests are served by this handler.
+func (h *ClaudeCodeAPIHandler) FilesEnabled() bool {
+	return h.files.Enabled()
+}
+
+// ClaudeUploadFile handles POST /v1/files.
+func (h *ClaudeCodeAPIHandler) ClaudeUploadFile(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.files.MaxFileBytes()+claudeFilesMultipartOverhead)
+	if h.files.Passthrough() {
+		h.forwardFilesUpload(c)
+		return
+	}
+	header, errForm := c.FormFile("file")
+	if errForm != nil {
+		writeClaudeFilesError(c, http.StatusBadRequest, fmt.Sprintf("file is required: %v", errForm))
+		return
+	}
+	file, errOpen := header.Open()
+	if errOpen != nil {
+		writeClaudeFilesError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", errOpen))
+		return
+	}
+	content, errRead := io.ReadAll(file)
+	_ = file.Close()
+	if errRead != nil {
+		writeClaudeFilesError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", errRead))
+		return
+	}
+	meta, errCreate := h.files.Create(claudeFilesOwner(c), header.Filename, uploadMimeType(header.Header.Get("Content-Type"), header.Filename, content), content)
+	if errCreate != nil {
+		writeClaudeFilesStoreError(c, errCreate)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// ClaudeListFiles handles GET /v1/files with the before_id, after_id and limit parameters.
+func (h *ClaudeCodeAPIHandler) ClaudeListFiles(c *gin.Context) {
+	if h.files.Passthrough() {
+		h.forwardFilesList(c)
+		return
+	}
+	files, errList := h.files.List(claudeFilesOwner(c))
+	if errList != nil {
+		writeClaudeFilesStoreError(c, errList)
+		return
+	}
+	if beforeID := strings.TrimSpace(c.Query("before_id")); beforeID != "" {
+		for i, f := range files {
+			if f.ID == beforeID {
+				files = files[:i]
+				break
+			}
+		}
+	}
+	if afterID := strings.TrimSpace(c.Query("after_id")); afterID != "" {
+		for i, f := range files {
+			if f.ID == afterID {
+				files = files[i+1:]
+				break
+			}
+		}
+	}
+	limit := defaultClaudeFilesListLimit
+	if parsed, errParse := strconv.Atoi(c.Query("limit")); errParse == nil && parsed > 0 {
+		limit = min(parsed, maxClaudeFilesListLimit)
+	}
+	hasMore := len(files) > limit
+	if hasMore {
+		files = files[:limit]
+	}
+	response := gin.H{"data": files, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(files) > 0 {
+		response["first_id"] = files[0].ID
+		response["last_id"] = files[len(files)-1].ID
+	} else {
+		response["data"] = []anthropicfiles.File{}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ClaudeRetrieveFile handles GET /v1/files/:file_id.
+func (h *ClaudeCodeAPIHandler) ClaudeRetrieveFile(c *gin.Context) {
+	if h.files.Passthrough() {
+		h.forwardOwnedFileRequest(c)
+		return
+	}
+	meta, errFile := h.files.File(claudeFilesOwner(c), c.Param("file_id"))
+	if errFile != nil {
+		writeClaudeFilesStoreError(c, errFile)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// ClaudeFileContent handles GET /v1/files/:file_id/content.
+func (h *ClaudeCodeAPIHandler) ClaudeFileContent(c *gin.Context) {
+	if h.files.Passthrough() {
+		h.forwardOwnedFileRequest(c)
+		return
+	}
+	meta, content, errContent := h.files.Content(claudeFilesOwner(c), c.Param("file_id"))
+	if errContent != nil {
+		writeClaudeFilesStoreError(c, errContent)
+		return
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": meta.Filename}))
+	c.Data(http.StatusOK, meta.MimeType, content)
+}
+
+// ClaudeDeleteFile handles DELETE /v1/files/:file_id.
+func (h *ClaudeCodeAPIHandler) ClaudeDeleteFile(c *gin.Context) {
+	if h.files.Passthrough() {
+		h.forwardOwnedFileRequest(c)
+		return
+	}
+	id := c.Param("file_id")
+	if errDelete := h.files.Delete(claudeFilesOwner(c), id); errDelete != nil {
+		writeClaudeFilesStoreError(c, errDelete)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "type": "file_deleted"})
+}
+
+// inlineFileReferences replaces references to locally stored files in a Messages request. In
+// passthrough mode it instead pins the request to the credential the referenced files were
+// uploaded through, after checking the caller uploaded them. It writes an error response and
+// returns false when a referenced file cannot be used.
+func (h *ClaudeCodeAPIHandler) inlineFileReferences(c *gin.Context, rawJSON []byte) ([]byte, bool) {
+	if h.files.Passthrough() {
+		authID, errAuth := h.files.PassthroughReferencesAuth(claudeFilesOwner(c), rawJSON)
+		switch {
+		case errAuth == nil:
+			if authID != "" {
+				c.Set(handlers.PinnedAuthGinKey, authID)
+			}
+			return rawJSON, true
+		case errors.Is(errAuth, anthropicfiles.ErrNotFound):
+			writeClaudeFilesError(c, http.StatusNotFound, "referenced file not found")
+		case errors.Is(errAuth, anthropicfiles.ErrMixedCredentials):
+			writeClaudeFilesError(c, http.StatusBadRequest, errAuth.Error())
+		default:
+			log.Errorf("anthropic files: failed to resolve file reference: %v", errAuth)
+			writeClaudeFilesError(c, http.StatusInternalServerError, "failed to resolve referenced file")
+		}
+		return nil, false
+	}
+	inlined, errInline := h.files.Inline(claudeFilesOwner(c), rawJSON)
+	if errInline != nil {
+		log.Errorf("anthropic files: failed to inline file reference: %v", errInline)
+		writeClaudeFilesError(c, http.StatusInternalServerError, "failed to read referenced file")
+		return nil, false
+	}
+	return inlined, true
+}
+
+// forwardFilesUpload proxies an upload to Anthropic through the first active Claude API key
+// credential and records the caller as the owner of the created file, pinned to that credential.
+func (h *ClaudeCodeAPIHandler) forwardFilesUpload(c *gin.Context) {
+	auth := h.filesPassthroughAuth()
+	if auth == nil {
+		writeClaudeFilesError(c, http.StatusServiceUnavailable, "no Claude API key credential is available for the Files API")
+		return
+	}
+	resp, ok := h.forwardFilesRequest(c, auth)
+	if !ok {
+		return
+	}
+	defer closeFilesResponse(resp)
+	body, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		writeClaudeFilesError(c, http.StatusBadGateway, errRead.Error())
+		return
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		id := gjson.GetBytes(body, "id").String()
+		if errRecord := h.files.RecordPassthrough(claudeFilesOwner(c), id, auth.ID); errRecord != nil {
+			log.Errorf("anthropic files: failed to record owner of %s: %v", id, errRecord)
+			writeClaudeFilesError(c, http.StatusInternalServerError, "failed to record uploaded file")
+			return
+		}
+	}
+	writeFilesResponse(c, resp, body)
+}
+
+// forwardFilesList proxies a list request and keeps only the files the caller uploaded.
+func (h *ClaudeCodeAPIHandler) forwardFilesList(c *gin.Context) {
+	auth := h.filesPassthroughAuth()
+	if auth == nil {
+		writeClaudeFilesError(c, http.StatusServiceUnavailable, "no Claude API key credential is available for the Files API")
+		return
+	}
+	resp, ok := h.forwardFilesRequest(c, auth)
+	if !ok {
+		return
+	}
+	defer closeFilesResponse(resp)
+	body, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		writeClaudeFilesError(c, http.StatusBadGateway, errRead.Error())
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		writeFilesResponse(c, resp, body)
+		return
+	}
+	owner := claudeFilesOwner(c)
+	files := []json.RawMessage{}
+	for _, file := range gjson.GetBytes(body, "data").Array() {
+		if _, errOwner := h.files.PassthroughAuth(owner, file.Get("id").String()); errOwner == nil {
+			files = append(files, json.RawMessage(file.Raw))
+		}
+	}
+	response := gin.H{"data": files, "has_more": gjson.GetBytes(body, "has_more").Bool(), "first_id": nil, "last_id": nil}
+	if len(files) > 0 {
+		response["first_id"] = gjson.GetBytes(files[0], "id").String()
+		response["last_id"] = gjson.GetBytes(files[len(files)-1], "id").String()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// forwardOwnedFileRequest proxies a request for one file through the credential the file was
+// uploaded with. Files the caller did not upload are reported as not found.
+func (h *ClaudeCodeAPIHandler) forwardOwnedFileRequest(c *gin.Context) {
+	owner, id := claudeFilesOwner(c), c.Param("file_id")
+	authID, errOwner := h.files.PassthroughAuth(owner, id)
+	if errOwner != nil {
+		writeClaudeFilesStoreError(c, errOwner)
+		return
+	}
+	var auth *coreauth.Auth
+	if h.AuthManager != nil {
+		auth, _ = h.AuthManager.GetByID(authID)
+	}
+	if auth == nil || auth.Disabled {
+		writeClaudeFilesError(c, http.StatusServiceUnavailable, "the Claude credential holding this file is no longer available")
+		return
+	}
+	resp, ok := h.forwardFilesRequest(c, auth)
+	if !ok {
+		return
+	}
+	defer closeFilesResponse(resp)
+	if c.Request.Method == http.MethodDelete && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if errForget := h.files.ForgetPassthrough(owner, id); errForget != nil {
+			log.Warnf("anthropic files: failed to forget owner of %s: %v", id, errForget)
+		}
+	}
+	copyFilesResponseHeaders(c, resp)
+	c.Status(resp.StatusCode)
+	_, _ = io.Copy(c.Writer, resp.Body)
+}
+
+// forwardFilesRequest sends the Files API request to Anthropic with auth. It writes an error
+// response and returns false when the request cannot be made.
+func (h *ClaudeCodeAPIHandler) forwardFilesRequest(c *gin.Context, auth *coreauth.Auth) (*http.Response, bool) {
+	baseURL := defaultClaudeFilesBaseURL
+	if v := strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/"); v != "" {
+		baseURL = v
+	}
+	target := baseURL + c.Request.URL.Path
+	if c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+
+	body, errRead := io.ReadAll(c.Request.Body)
+	if errRead != nil {
+		var errTooLarge *http.MaxBytesError
+		if errors.As(errRead, &errTooLarge) {
+			writeClaudeFilesError(c, http.StatusRequestEntityTooLarge, anthropicfiles.ErrTooLarge.Error())
+			return nil, false
+		}
+		writeClaudeFilesError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", errRead))
+		return nil, false
+	}
+	req, errRequest := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, target, bytes.NewReader(body))
+	if errRequest != nil {
+		writeClaudeFilesError(c, http.StatusInternalServerError, errRequest.Error())
+		return nil, false
+	}
+	for _, key := range claudeFilesForwardHeaders {
+		if value := c.GetHeader(key); value != "" {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, errDo := h.AuthManager.HttpRequest(c.Request.Context(), auth, req)
+	if errDo != nil {
+		writeClaudeFilesError(c, http.StatusBadGateway, errDo.Error())
+		return nil, false
+	}
+	return resp, true
+}
+
+func closeFilesResponse(resp *http.Response) {
+	if errClose := resp.Body.Close(); errClose != nil {
+		log.Errorf("anthropic files: failed to close response body: %v", errClose)
+	}
+}
+
+func copyFilesResponseHeaders(c *gin.Context, resp *http.Response) {
+	for _, key := range []string{"Content-Type", "Content-Disposition", "Request-Id"} {
+		if value := resp.Header.Get(key); value != "" {
+			c.Header(key, value)
+		}
+	}
+}
+
+func writeFilesResponse(c *gin.Context, resp *http.Response, body []byte) {
+	copyFilesResponseHeaders(c, resp)
+	c.Status(resp.StatusCode)
+	_, _ = c.Writer.Write(body)
+}
+
+func (h *ClaudeCodeAPIHandler) filesPassthroughAuth() *coreauth.Auth {
+	if h.AuthManager == nil {
+		return nil
+	}
+	var candidates []*coreauth.Auth
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(auth.Provider), "claude") {
+			continue
+		}
+		if kind, apiKey := auth.AccountInfo(); kind != "api_key" || apiKey == "" {
+			continue
+		}
+		candidates = append(candidates, auth)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, k int) bool { return candidates[i].ID < candidates[k].ID })
+	return candidates[0]
+}
+
+// uploadMimeType prefers the part's declared type and falls back to the file extension,
+// then to content sniffing.
+func uploadMimeType(declared, filename string, content []byte) string {
+	if mediaType, _, errParse := mime.ParseMediaType(declared); errParse == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); byExt != "" {
+		if mediaType, _, errParse := mime.ParseMediaType(byExt); errParse == nil {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	return mediaType
+}
+
+func claudeFilesOwner(c *gin.Context) string {
+	return strings.TrimSpace(c.GetString("userApiKey"))
+}
+
+func writeClaudeFilesStoreError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, anthropicfiles.ErrNotFound):
+		writeClaudeFilesError(c, http.StatusNotFound, "File not found")
+	case errors.Is(err, anthropicfiles.ErrDisabled):
+		writeClaudeFilesError(c, http.StatusNotFound, "Files API is disabled")
+	case errors.Is(err, anthropicfiles.ErrTooLarge):
+		writeClaudeFilesError(c, http.StatusRequestEntityTooLarge, "File exceeds the size limit")
+	default:
+		writeClaudeFilesError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeClaudeFilesError(c *gin.Context, status int, message string) {
+	c.JSON(status, claudeErrorResponse{
+		Type: "error",
+		Error: claudeErrorDetail{
+			Type:    claudeErrorTypeFromStatus(status),
+			Message: message,
+		},
+	})
+}
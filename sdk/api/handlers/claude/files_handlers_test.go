@@ -0,0 +1,104 @@
+package claude
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/anthropicfiles"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+func newFilesTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := anthropicfiles.NewStore()
+	store.Apply(config.AnthropicFilesConfig{Enable: true, Mode: config.AnthropicFilesModeLocal, Dir: t.TempDir(), MaxFileSizeMB: 1}, "")
+	h := &ClaudeCodeAPIHandler{}
+	h.SetFileStore(store)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userApiKey", "key-a") })
+	router.POST("/v1/files", h.ClaudeUploadFile)
+	router.GET("/v1/files", h.ClaudeListFiles)
+	router.GET("/v1/files/:file_id", h.ClaudeRetrieveFile)
+	router.DELETE("/v1/files/:file_id", h.ClaudeDeleteFile)
+	return router
+}
+
+func TestClaudeFiles_UploadListRetrieveDelete(t *testing.T) {
+	router := newFilesTestRouter(t)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, _ := writer.CreateFormFile("file", "report.pdf")
+	_, _ = part.Write([]byte("%PDF-1.4 test"))
+	_ = writer.Close()
+	upload := httptest.NewRequest(http.MethodPost, "/v1/files", &form)
+	upload.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, upload)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	id := gjson.Get(rec.Body.String(), "id").String()
+	if gjson.Get(rec.Body.String(), "type").String() != "file" || gjson.Get(rec.Body.String(), "mime_type").String() != "application/pdf" {
+		t.Fatalf("unexpected file object: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/files?limit=10", nil))
+	if rec.Code != http.StatusOK || gjson.Get(rec.Body.String(), "first_id").String() != id || gjson.Get(rec.Body.String(), "has_more").Bool() {
+		t.Fatalf("list status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/v1/files/"+id, nil))
+	if rec.Code != http.StatusOK || gjson.Get(rec.Body.String(), "type").String() != "file_deleted" {
+		t.Fatalf("delete status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/files/"+id, nil))
+	if rec.Code != http.StatusNotFound || gjson.Get(rec.Body.String(), "error.type").String() != "not_found_error" {
+		t.Fatalf("retrieve after delete status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestClaudeFiles_PassthroughHidesFilesOfOtherKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := anthropicfiles.NewStore()
+	store.Apply(config.AnthropicFilesConfig{Enable: true, Mode: config.AnthropicFilesModePassthrough, Dir: t.TempDir()}, "")
+	if errRecord := store.RecordPassthrough("key-b", "file_b", "claude-1"); errRecord != nil {
+		t.Fatalf("RecordPassthrough error: %v", errRecord)
+	}
+	h := &ClaudeCodeAPIHandler{}
+	h.SetFileStore(store)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userApiKey", "key-a") })
+	router.GET("/v1/files/:file_id", h.ClaudeRetrieveFile)
+	router.DELETE("/v1/files/:file_id", h.ClaudeDeleteFile)
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, "/v1/files/file_b", nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s status = %d, want 404; body = %s", method, rec.Code, rec.Body.String())
+		}
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("userApiKey", "key-b")
+	body := []byte(`{"messages":[{"role":"user","content":[{"type":"document","source":{"type":"file","file_id":"file_b"}}]}]}`)
+	if _, ok := h.inlineFileReferences(c, body); !ok {
+		t.Fatal("inlineFileReferences rejected the owner's file")
+	}
+	if got := c.GetString(handlers.PinnedAuthGinKey); got != "claude-1" {
+		t.Fatalf("pinned auth = %q, want claude-1", got)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cancelstats"
+)
+
+// ErrClientDisconnected is the cancellation cause of request contexts whose client went away
+// before the response finished.
+var ErrClientDisconnected = errors.New("client disconnected")
+
+// clientDisconnected reports whether ctx was cancelled because its client went away.
+func clientDisconnected(ctx context.Context) bool {
+	return ctx != nil && ctx.Err() != nil && errors.Is(context.Cause(ctx), ErrClientDisconnected)
+}
+
+// recordClientCancel counts a stream of model abandoned by its client at stage. Streams
+// cancelled for any other reason, such as timeouts or a finished response, are not counted.
+func recordClientCancel(ctx context.Context, model string, stage cancelstats.Stage) {
+	if clientDisconnected(ctx) {
+		cancelstats.Default().Record(model, stage)
+	}
+}
+
+// withClientCancel returns a cancellable copy of parent. cancel ends it normally, while
+// disconnect ends it with ErrClientDisconnected as the cause.
+func withClientCancel(parent context.Context) (ctx context.Context, cancel, disconnect func()) {
+	ctx, cancelCause := context.WithCancelCause(parent)
+	return ctx, func() { cancelCause(nil) }, func() { cancelCause(ErrClientDisconnected) }
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cancelstats"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// endlessStreamExecutor sends one chunk and then keeps the stream open until it is cancelled.
+type endlessStreamExecutor struct {
+	cancelled chan struct{}
+}
+
+func (e *endlessStreamExecutor) Identifier() string { return "codex" }
+
+func (e *endlessStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *endlessStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	chunks := make(chan coreexecutor.StreamChunk, 1)
+	chunks <- coreexecutor.StreamChunk{Payload: []byte("first")}
+	go func() {
+		defer close(chunks)
+		<-ctx.Done()
+		close(e.cancelled)
+	}()
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (e *endlessStreamExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *endlessStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *endlessStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_CancelsUpstreamWhenClientDisconnects(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	executor := &endlessStreamExecutor{cancelled: make(chan struct{})}
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "disconnect-auth", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "disconnect@example.com"}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "codex", []*registry.ModelInfo{{ID: "disconnect-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	requestCtx, disconnectClient := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil).WithContext(requestCtx)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	cliCtx, cliCancel := handler.GetContextWithCancel(nil, c, context.Background())
+	defer cliCancel()
+
+	dataChan, _, _ := handler.ExecuteStreamWithAuthManager(cliCtx, "openai", "disconnect-model", []byte(`{"model":"disconnect-model"}`), "")
+	if chunk := <-dataChan; string(chunk) != "first" {
+		t.Fatalf("first chunk = %q, want %q", chunk, "first")
+	}
+	disconnectClient()
+
+	select {
+	case <-executor.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream stream was not cancelled after the client disconnected")
+	}
+	for range dataChan {
+	}
+	for _, stat := range cancelstats.Default().Snapshot().Stats {
+		if stat.Model == "disconnect-model" && stat.Stage == cancelstats.StageMidStream && stat.Streams == 1 {
+			return
+		}
+	}
+	t.Fatalf("cancelled streams = %+v, want one mid-stream cancellation of disconnect-model", cancelstats.Default().Snapshot().Stats)
+}
+
+func TestGetContextWithCancel_OnlyClientDisconnectsCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+	cliCtx, cliCancel := handler.GetContextWithCancel(nil, c, context.Background())
+	cliCancel()
+	if clientDisconnected(cliCtx) {
+		t.Fatal("a context cancelled by the handler was reported as a client disconnect")
+	}
+}
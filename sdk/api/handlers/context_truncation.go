@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bytes"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// conversationLayout describes where a protocol keeps its conversation turns.
+type conversationLayout struct {
+	path string
+	// pinned reports items that are never dropped, such as system messages.
+	pinned func(item gjson.Result) bool
+	// turnStart reports items that open a new conversational turn. Items up to the next
+	// turn start belong together so tool calls are never separated from their results.
+	turnStart func(item gjson.Result) bool
+}
+
+func conversationLayoutFor(entryProtocol string) (conversationLayout, bool) {
+	isSystemRole := func(item gjson.Result) bool {
+		role := item.Get("role").String()
+		return role == "system" || role == "developer"
+	}
+	switch sdktranslator.FromString(entryProtocol) {
+	case sdktranslator.FormatOpenAI:
+		return conversationLayout{
+			path:      "messages",
+			pinned:    isSystemRole,
+			turnStart: func(item gjson.Result) bool { return item.Get("role").String() == "user" },
+		}, true
+	case sdktranslator.FormatOpenAIResponse:
+		return conversationLayout{
+			path:   "input",
+			pinned: isSystemRole,
+			turnStart: func(item gjson.Result) bool {
+				return item.Get("role").String() == "user"
+			},
+		}, true
+	case sdktranslator.FormatClaude:
+		return conversationLayout{
+			path: "messages",
+			turnStart: func(item gjson.Result) bool {
+				return item.Get("role").String() == "user" && !onlyPartsOfType(item.Get("content"), "type", "tool_result")
+			},
+		}, true
+	case sdktranslator.FormatGemini:
+		return conversationLayout{
+			path: "contents",
+			turnStart: func(item gjson.Result) bool {
+				if item.Get("role").String() != "user" {
+					return false
+				}
+				for _, part := range item.Get("parts").Array() {
+					if part.Get("functionResponse").Exists() {
+						return false
+					}
+				}
+				return true
+			},
+		}, true
+	default:
+		return conversationLayout{}, false
+	}
+}
+
+// onlyPartsOfType reports whether content is a non-empty array whose parts all have the
+// given type.
+func onlyPartsOfType(content gjson.Result, field, value string) bool {
+	if !content.IsArray() {
+		return false
+	}
+	parts := content.Array()
+	if len(parts) == 0 {
+		return false
+	}
+	for _, part := range parts {
+		if part.Get(field).String() != value {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateConversationTurns drops whole conversation turns from rawJSON until the body is
+// at most targetBytes long. System messages and the latest turn are always kept; with
+// keepFirst the first turn is kept too and turns are dropped from the middle. It returns
+// the new body and the number of dropped items.
+func truncateConversationTurns(entryProtocol string, rawJSON []byte, targetBytes int, keepFirst bool) ([]byte, int) {
+	layout, ok := conversationLayoutFor(entryProtocol)
+	if !ok {
+		return rawJSON, 0
+	}
+	items := gjson.GetBytes(rawJSON, layout.path)
+	if !items.IsArray() {
+		return rawJSON, 0
+	}
+
+	var pinned []gjson.Result
+	var turns [][]gjson.Result
+	for _, item := range items.Array() {
+		if layout.pinned != nil && layout.pinned(item) {
+			pinned = append(pinned, item)
+			continue
+		}
+		if len(turns) == 0 || layout.turnStart(item) {
+			turns = append(turns, nil)
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], item)
+	}
+
+	first := 0
+	if keepFirst {
+		first = 1
+	}
+	excess := len(rawJSON) - targetBytes
+	dropped, droppedItems := 0, 0
+	for i := first; i < len(turns)-1 && excess > 0; i++ {
+		for _, item := range turns[i] {
+			excess -= len(item.Raw) + 1
+			droppedItems++
+		}
+		dropped++
+	}
+	if droppedItems == 0 {
+		return rawJSON, 0
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	write := func(item gjson.Result) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(item.Raw)
+	}
+	// Pinned items are moved ahead of the remaining conversation.
+	for _, item := range pinned {
+		write(item)
+	}
+	for i, turn := range turns {
+		if i >= first && i < first+dropped {
+			continue
+		}
+		for _, item := range turn {
+			write(item)
+		}
+	}
+	buf.WriteByte(']')
+
+	out, err := sjson.SetRawBytes(rawJSON, layout.path, buf.Bytes())
+	if err != nil {
+		return rawJSON, 0
+	}
+	return out, droppedItems
+}
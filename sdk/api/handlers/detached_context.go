@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+)
+
+// DetachedContext returns ctx carrying a Gin context for a request executed after its client
+// request has ended, such as a batch line or a background response. keys are copied into the
+// Gin context so usage accounting, quotas and key scopes apply to the key that created the
+// work. Response headers set while executing are discarded.
+func DetachedContext(ctx context.Context, method, endpoint string, header http.Header, keys map[string]any) context.Context {
+	req, errRequest := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if errRequest != nil {
+		return ctx
+	}
+	if header != nil {
+		req.Header = header.Clone()
+	}
+	ginCtx := &gin.Context{Request: req, Writer: &discardResponseWriter{header: make(http.Header)}}
+	for key, value := range keys {
+		ginCtx.Set(key, value)
+	}
+	ctx = logging.WithEndpoint(ctx, method+" "+endpoint)
+	return context.WithValue(ctx, "gin", ginCtx)
+}
+
+// discardResponseWriter is the response writer of a detached Gin context.
+type discardResponseWriter struct {
+	header http.Header
+	status int
+	size   int
+}
+
+func (w *discardResponseWriter) Header() http.Header { return w.header }
+
+func (w *discardResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *discardResponseWriter) WriteHeaderNow() { w.WriteHeader(http.StatusOK) }
+
+func (w *discardResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	w.size += len(data)
+	return len(data), nil
+}
+
+func (w *discardResponseWriter) WriteString(s string) (int, error) { return w.Write([]byte(s)) }
+
+func (w *discardResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *discardResponseWriter) Size() int {
+	if w.status == 0 {
+		return -1
+	}
+	return w.size
+}
+
+func (w *discardResponseWriter) Written() bool { return w.status != 0 }
+
+func (w *discardResponseWriter) Flush() {}
+
+func (w *discardResponseWriter) CloseNotify() <-chan bool { return nil }
+
+func (w *discardResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("detached request cannot be hijacked")
+}
+
+func (w *discardResponseWriter) Pusher() http.Pusher { return nil }
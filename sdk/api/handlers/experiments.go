@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// ExperimentHeader reports the experiment arm a request was assigned to as "<name>=<arm>".
+const ExperimentHeader = "X-CPA-Experiment"
+
+// experimentBuckets is the resolution of experiment percentages (0.01%).
+const experimentBuckets = 10000
+
+// experimentBucket maps a bucketing key to a bucket in [0, experimentBuckets). Hashing the
+// experiment name with the key keeps the assignments of different experiments independent.
+func experimentBucket(experiment, key string) int {
+	sum := sha256.Sum256([]byte(experiment + "\x00" + key))
+	return int(binary.BigEndian.Uint64(sum[:8]) % experimentBuckets)
+}
+
+// assignExperimentArm returns the arm whose percentage range holds the key's bucket.
+func assignExperimentArm(experiment internalconfig.Experiment, key string) (internalconfig.ExperimentArm, bool) {
+	bucket := float64(experimentBucket(strings.ToLower(experiment.Name), key))
+	bound := 0.0
+	for _, arm := range experiment.Arms {
+		bound += arm.Percent * experimentBuckets / 100
+		if bucket < bound {
+			return arm, true
+		}
+	}
+	return internalconfig.ExperimentArm{}, false
+}
+
+// experimentBucketKey returns the key a request is bucketed by; empty when the request
+// cannot be attributed to a client.
+func experimentBucketKey(ctx context.Context, bucketBy string) string {
+	if bucketBy == internalconfig.ExperimentBucketByConversation {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			if sessionID := strings.TrimSpace(ginCtx.GetHeader(SessionIDHeader)); sessionID != "" {
+				return "conversation:" + sessionID
+			}
+		}
+		if sessionID := executionSessionIDFromContext(ctx); sessionID != "" {
+			return "conversation:" + sessionID
+		}
+	}
+	if apiKey := clientAPIKeyFromContext(ctx); apiKey != "" {
+		return "api-key:" + apiKey
+	}
+	return ""
+}
+
+// applyExperiment rewrites modelName to the arm of the first experiment matching it. Requests
+// pinned to a provider, requests without a bucketing key and requests already assigned to an
+// arm keep their model. A thinking suffix is carried over to the arm's model.
+func (h *BaseAPIHandler) applyExperiment(ctx context.Context, modelName string, execOptions modelExecutionOptions) (string, modelExecutionOptions) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.Experiments) == 0 || ctx == nil {
+		return modelName, execOptions
+	}
+	if execOptions.experimentArm != "" || strings.TrimSpace(execOptions.ForcedProvider) != "" {
+		return modelName, execOptions
+	}
+	parsed := thinking.ParseSuffix(strings.TrimSpace(modelName))
+	base := strings.ToLower(parsed.ModelName)
+	for _, experiment := range h.Cfg.Experiments {
+		if !matchesAnyPattern(experiment.Models, base, true) {
+			continue
+		}
+		key := experimentBucketKey(ctx, experiment.BucketBy)
+		if key == "" {
+			return modelName, execOptions
+		}
+		arm, ok := assignExperimentArm(experiment, key)
+		if !ok {
+			return modelName, execOptions
+		}
+		rewritten := arm.Model
+		if parsed.HasSuffix && !thinking.ParseSuffix(rewritten).HasSuffix {
+			rewritten += "(" + parsed.RawSuffix + ")"
+		}
+		execOptions.experimentArm = experiment.Name + "=" + arm.Name
+		if arm.Provider != "" {
+			execOptions.aliasProvider = arm.Provider
+		}
+		if ginCtx, okGin := ctx.Value("gin").(*gin.Context); okGin && ginCtx != nil {
+			ginCtx.Header(ExperimentHeader, execOptions.experimentArm)
+		}
+		log.Debugf("experiment %s: assigned %s to arm %s (%s)", experiment.Name, modelName, arm.Name, rewritten)
+		return rewritten, execOptions
+	}
+	return modelName, execOptions
+}
+
+// addExperimentMetadata tags the request's usage records with its experiment arm.
+func addExperimentMetadata(meta map[string]any, experimentArm string) {
+	if meta == nil || experimentArm == "" {
+		return
+	}
+	tags, _ := meta[coreexecutor.RequestTagsMetadataKey].([]string)
+	meta[coreexecutor.RequestTagsMetadataKey] = append(tags, "experiment:"+experimentArm)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func experimentContext(apiKey, sessionID string) (context.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if sessionID != "" {
+		c.Request.Header.Set(SessionIDHeader, sessionID)
+	}
+	c.Set("userApiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c), recorder
+}
+
+func TestAssignExperimentArm_DeterministicSplit(t *testing.T) {
+	experiment := internalconfig.Experiment{
+		Name: "split",
+		Arms: []internalconfig.ExperimentArm{{Name: "control", Percent: 30}, {Name: "treatment", Percent: 50}},
+	}
+	counts := map[string]int{}
+	for i := range 10000 {
+		key := fmt.Sprintf("api-key:k%d", i)
+		arm, ok := assignExperimentArm(experiment, key)
+		again, okAgain := assignExperimentArm(experiment, key)
+		if ok != okAgain || arm.Name != again.Name {
+			t.Fatalf("key %s assigned to %q then %q", key, arm.Name, again.Name)
+		}
+		counts[arm.Name]++
+	}
+	for name, want := range map[string]int{"control": 3000, "treatment": 5000, "": 2000} {
+		if got := counts[name]; got < want-300 || got > want+300 {
+			t.Fatalf("arm %q got %d of 10000 keys, want about %d", name, got, want)
+		}
+	}
+}
+
+func TestExecuteWithAuthManager_ExperimentRoutesArm(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude"}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"exp-control", "exp-treatment"}, nil)
+	experiment := internalconfig.Experiment{
+		Name:     "migration",
+		Models:   []string{"exp-control"},
+		BucketBy: internalconfig.ExperimentBucketByConversation,
+		Arms: []internalconfig.ExperimentArm{
+			{Name: "control", Model: "exp-control", Percent: 50},
+			{Name: "treatment", Model: "exp-treatment", Provider: "gemini", Percent: 50},
+		},
+	}
+	handler.Cfg.Experiments = []internalconfig.Experiment{experiment}
+
+	var sessionID string
+	for i := 0; sessionID == ""; i++ {
+		if arm, _ := assignExperimentArm(experiment, fmt.Sprintf("conversation:s%d", i)); arm.Name == "treatment" {
+			sessionID = fmt.Sprintf("s%d", i)
+		}
+	}
+	ctx, recorder := experimentContext("key-a", sessionID)
+	body, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "exp-control", []byte(`{"model":"exp-control"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(body) != "gemini:exp-treatment" {
+		t.Fatalf("body = %q, want the treatment arm", body)
+	}
+	if got := recorder.Header().Get(ExperimentHeader); got != "migration=treatment" {
+		t.Fatalf("%s = %q, want migration=treatment", ExperimentHeader, got)
+	}
+
+	// Requests that cannot be attributed to a client keep their model.
+	body, _, errMsg = handler.ExecuteWithAuthManager(context.Background(), "openai", "exp-control", []byte(`{"model":"exp-control"}`), "")
+	if errMsg != nil || string(body) != "claude:exp-control" {
+		t.Fatalf("unattributed request = %q, %+v; want the requested model", body, errMsg)
+	}
+}
+
+func TestExecuteCountWithAuthManager_ExperimentMatchesGeneration(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude"}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"exp-control", "exp-treatment"}, nil)
+	handler.Cfg.Experiments = []internalconfig.Experiment{{
+		Name:     "virtual",
+		Models:   []string{"exp-virtual"},
+		BucketBy: internalconfig.ExperimentBucketByConversation,
+		Arms: []internalconfig.ExperimentArm{
+			{Name: "control", Model: "exp-control", Provider: "claude", Percent: 50},
+			{Name: "treatment", Model: "exp-treatment", Provider: "gemini", Percent: 50},
+		},
+	}}
+
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		sessionID := fmt.Sprintf("s%d", i)
+		ctx, _ := experimentContext("key-a", sessionID)
+		generated, _, errMsg := handler.ExecuteWithAuthManager(ctx, "claude", "exp-virtual", []byte(`{"model":"exp-virtual"}`), "")
+		if errMsg != nil {
+			t.Fatalf("generation for %s: %+v", sessionID, errMsg)
+		}
+		ctx, recorder := experimentContext("key-a", sessionID)
+		counted, _, errMsg := handler.ExecuteCountWithAuthManager(ctx, "claude", "exp-virtual", []byte(`{"model":"exp-virtual"}`), "")
+		if errMsg != nil {
+			t.Fatalf("count_tokens for %s: %+v", sessionID, errMsg)
+		}
+		if string(counted) != string(generated) {
+			t.Fatalf("session %s: count_tokens used %q, generation used %q", sessionID, counted, generated)
+		}
+		if recorder.Header().Get(ExperimentHeader) == "" {
+			t.Fatalf("session %s: count_tokens response lacks %s", sessionID, ExperimentHeader)
+		}
+		seen[string(counted)] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("arms used = %v, want both", seen)
+	}
+}
+
+func TestAddExperimentMetadata_AppendsUsageTag(t *testing.T) {
+	meta := map[string]any{coreexecutor.RequestTagsMetadataKey: []string{"ci"}}
+	addExperimentMetadata(meta, "migration=treatment")
+	tags, _ := meta[coreexecutor.RequestTagsMetadataKey].([]string)
+	if len(tags) != 2 || tags[1] != "experiment:migration=treatment" {
+		t.Fatalf("tags = %v, want the experiment tag appended", tags)
+	}
+}
@@ -42,7 +42,7 @@ func (h *GeminiAPIHandler) HandlerType() string {
 func (h *GeminiAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
 	modelRegistry := registry.GetGlobalRegistry()
-	return modelRegistry.GetAvailableModels("gemini")
+	return h.WithSyntheticModels(Gemini, modelRegistry.GetAvailableModels("gemini"))
 }
 
 // GeminiModels handles the Gemini models listing endpoint.
@@ -72,9 +72,15 @@ func (h *GeminiAPIHandler) GeminiModels(c *gin.Context) {
 		}
 		normalizedModels = append(normalizedModels, normalizedModel)
 	}
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"models": normalizedModels,
-	})
+	}
+	if len(normalizedModels) == 0 {
+		if notice := h.ModelsNotice(); notice != nil {
+			response["notice"] = notice
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // GeminiGetHandler handles GET requests for specific Gemini model information.
@@ -188,7 +194,6 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 	}
 
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
-	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 
 	setSSEHeaders := func() {
 		c.Header("Content-Type", "text/event-stream")
@@ -197,6 +202,24 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 		c.Header("Access-Control-Allow-Origin", "*")
 	}
 
+	execute := func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+		return h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
+	}
+	var dataChan <-chan []byte
+	var upstreamHeaders http.Header
+	var errChan <-chan *interfaces.ErrorMessage
+	if alt == "" {
+		var started bool
+		dataChan, upstreamHeaders, errChan, started = h.ExecuteStreamWithHeartbeat(c, flusher, setSSEHeaders, execute)
+		if started {
+			// Heartbeats already committed the response; errors are reported in-stream.
+			h.forwardGeminiStream(c, flusher, alt, func(err error) { cliCancel(err) }, dataChan, errChan)
+			return
+		}
+	} else {
+		dataChan, upstreamHeaders, errChan = execute()
+	}
+
 	// Peek at the first chunk
 	for {
 		select {
@@ -13,10 +13,12 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cancelstats"
 	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
@@ -120,6 +122,10 @@ type modelRouterSkipDetector interface {
 	HasModelRoutersExcept(string) bool
 }
 
+// PinnedAuthGinKey is the Gin context key through which a route handler pins the request to an
+// auth ID, like WithPinnedAuthID does for a context it controls.
+const PinnedAuthGinKey = "pinnedAuthID"
+
 // WithPinnedAuthID returns a child context that requests execution on a specific auth ID.
 func WithPinnedAuthID(ctx context.Context, authID string) context.Context {
 	authID = strings.TrimSpace(authID)
@@ -318,6 +324,9 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	if disallowFreeAuthFromContext(ctx) {
 		meta[coreexecutor.DisallowFreeAuthMetadataKey] = true
 	}
+	if ext := RequestExtensionsFromContext(ctx); ext != nil && len(ext.Tags) > 0 {
+		meta[coreexecutor.RequestTagsMetadataKey] = append([]string(nil), ext.Tags...)
+	}
 	return meta
 }
 
@@ -408,9 +417,11 @@ func pinnedAuthIDFromContext(ctx context.Context) string {
 		return strings.TrimSpace(v)
 	case []byte:
 		return strings.TrimSpace(string(v))
-	default:
-		return ""
 	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		return strings.TrimSpace(ginCtx.GetString(PinnedAuthGinKey))
+	}
+	return ""
 }
 
 func selectedAuthIDCallbackFromContext(ctx context.Context) func(string) {
@@ -463,6 +474,19 @@ type BaseAPIHandler struct {
 	// ModelRouterHost optionally routes matching requests to a plugin executor, the router's own
 	// executor, or a built-in provider before model-to-provider resolution and auth selection.
 	ModelRouterHost PluginModelRouterHost
+
+	// RateLimiter optionally enforces per-client budgets for the resolved upstream providers.
+	RateLimiter ProviderRateLimiter
+
+	// Admission optionally applies the HTTP middleware admission checks to requests that do not
+	// pass through the HTTP router, such as gRPC calls and batch lines.
+	Admission AdmissionFunc
+
+	pipelineMu sync.RWMutex
+	pipeline   []PipelineMiddleware
+
+	// shadowInFlight counts mirrored shadow traffic requests still running.
+	shadowInFlight atomic.Int64
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -559,6 +583,7 @@ func (h *BaseAPIHandler) GetAlt(c *gin.Context) string {
 // GetContextWithCancel creates a new context with cancellation capabilities.
 // It embeds the Gin context and the API handler into the new context for later use.
 // The returned cancel function also handles logging the API response if request logging is enabled.
+// When the client disconnects first, the context is cancelled with ErrClientDisconnected as its cause.
 //
 // Parameters:
 //   - handler: The API handler associated with the request.
@@ -586,7 +611,7 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
-	newCtx, cancel := context.WithCancel(parentCtx)
+	newCtx, cancel, disconnect := withClientCancel(parentCtx)
 
 	endpoint := ""
 	if c != nil && c.Request != nil {
@@ -614,7 +639,7 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 		go func() {
 			select {
 			case <-requestCtx.Done():
-				cancel()
+				disconnect()
 			case <-cancelCtx.Done():
 			}
 		}()
@@ -761,6 +786,36 @@ func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType
 }
 
 func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if mirror := h.startShadowTraffic(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, false, &execOptions); mirror != nil {
+		body, headers, errMsg := h.executeWithAuthManagerFormats(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, allowImageModel, execOptions)
+		mirror.finishPrimary(body, errMsg)
+		h.endShadowTraffic(mirror)
+		return body, headers, errMsg
+	}
+	execOptions = applyRequestRoutingHints(ctx, execOptions)
+	modelName, rawJSON = h.applySyntheticModel(entryProtocol, modelName, rawJSON)
+	modelName, execOptions = h.applyExperiment(ctx, modelName, execOptions)
+	modelName, execOptions = h.applyModelAlias(modelName, execOptions)
+	if targets := h.modelFailoverTargets(modelName, execOptions); len(targets) > 0 {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return executeWithModelFailover(ctx, modelName, targets, execOptions, func(targetModel string, targetOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.executeWithAuthManagerFormats(ctx, entryProtocol, exitProtocol, targetModel, rawJSON, alt, allowImageModel, targetOptions)
+		})
+	}
+	var errMsg *interfaces.ErrorMessage
+	if modelName, errMsg = h.applyTenantCredentialPrefix(ctx, modelName); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if errMsg = checkKeyScopeModel(ctx, modelName); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	rawJSON, errMsg = h.applyRequestMiddleware(ctx, entryProtocol, modelName, rawJSON, false)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	h.launchShadowTraffic(execOptions, rawJSON)
 	originalRequestedModel := modelName
 	routeDecision := h.applyModelRouter(ctx, entryProtocol, modelName, rawJSON, false, execOptions)
 	responseProtocol := modelExecutionResponseProtocol(entryProtocol, exitProtocol)
@@ -774,11 +829,26 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	providers, errMsg = filterProvidersByKeyScope(ctx, providers, normalizedModel)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if providers, errMsg = h.checkProviderRateLimit(ctx, providers); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if errMsg = validateModelCapabilities(entryProtocol, normalizedModel, rawJSON, alt); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if rawJSON, errMsg = h.preflightTokenCheck(ctx, entryProtocol, normalizedModel, providers, rawJSON, alt, execOptions); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers = adjustExecutionProvidersForEntryProtocol(entryProtocol, providers)
 	reqMeta := requestExecutionMetadata(ctx)
+	h.debitProviderRateLimit(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
+	addExperimentMetadata(reqMeta, execOptions.experimentArm)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, normalizedModel, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
 	setGenerateMetadata(reqMeta, rawJSON)
@@ -803,7 +873,7 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	}
 	opts.Metadata = reqMeta
 	req, opts = h.applyRequestInterceptorsBeforeAuth(ctx, entryProtocol, originalRequestedModel, req, opts, execOptions.SkipInterceptorPluginID)
-	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	resp, err := h.executeRaced(ctx, providers, req, opts)
 	if err != nil {
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		status := http.StatusInternalServerError
@@ -822,8 +892,11 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	}
 	executedReq, executedOpts := afterAuthCapture.apply(req, opts)
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.Cfg))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.Cfg)
 	body, responseHeaders := h.applyResponseInterceptors(ctx, responseProtocol, normalizedModel, originalRequestedModel, executedOpts, rawResponseHeaders, responseHeaders, executedOpts.OriginalRequest, executedReq.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
+	if body, errMsg = h.applyResponseMiddleware(ctx, responseProtocol, normalizedModel, body); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	return body, responseHeaders, nil
 }
 
@@ -834,6 +907,32 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 }
 
 func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	execOptions = applyRequestRoutingHints(ctx, execOptions)
+	modelName, rawJSON = h.applySyntheticModel(handlerType, modelName, rawJSON)
+	modelName, execOptions = h.applyExperiment(ctx, modelName, execOptions)
+	modelName, execOptions = h.applyModelAlias(modelName, execOptions)
+	if targets := h.modelFailoverTargets(modelName, execOptions); len(targets) > 0 {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return executeWithModelFailover(ctx, modelName, targets, execOptions, func(targetModel string, targetOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.executeCountWithAuthManager(ctx, handlerType, targetModel, rawJSON, alt, targetOptions)
+		})
+	}
+	var errMsg *interfaces.ErrorMessage
+	if modelName, errMsg = h.applyTenantCredentialPrefix(ctx, modelName); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if errMsg = checkKeyScopeModel(ctx, modelName); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	rawJSON, errMsg = h.applyRequestMiddleware(ctx, handlerType, modelName, rawJSON, true)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if count, ok := h.countTokensLocally(handlerType, modelName, rawJSON); ok {
+		return tokenCountResponse(handlerType, count), nil, nil
+	}
 	originalRequestedModel := modelName
 	routeDecision := h.applyModelRouter(ctx, handlerType, modelName, rawJSON, false, execOptions)
 	if routeDecision.ExecutorPluginID != "" {
@@ -843,6 +942,10 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	providers, errMsg = filterProvidersByKeyScope(ctx, providers, normalizedModel)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers = adjustExecutionProvidersForEntryProtocol(handlerType, providers)
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
@@ -887,9 +990,13 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	if opts.SourceFormat == sdktranslator.FormatOpenAIResponse {
+		// Executors answer in their upstream's count shape; Responses clients expect input_tokens.
+		resp.Payload = tokenCountResponse(handlerType, tokenCountFromResponse(resp.Payload))
+	}
 	executedReq, executedOpts := afterAuthCapture.apply(req, opts)
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.Cfg))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.Cfg)
 	body, responseHeaders := h.applyResponseInterceptors(ctx, handlerType, normalizedModel, originalRequestedModel, executedOpts, rawResponseHeaders, responseHeaders, executedOpts.OriginalRequest, executedReq.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	return body, responseHeaders, nil
 }
@@ -910,7 +1017,7 @@ func (h *BaseAPIHandler) executeWithPluginExecutor(ctx context.Context, entryPro
 		return nil, nil, executionErrorMessage(errExecute)
 	}
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.Cfg))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.Cfg)
 	body, responseHeaders := h.applyResponseInterceptors(ctx, responseProtocol, modelName, originalRequestedModel, opts, rawResponseHeaders, responseHeaders, opts.OriginalRequest, req.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	return body, responseHeaders, nil
 }
@@ -931,7 +1038,7 @@ func (h *BaseAPIHandler) countWithPluginExecutor(ctx context.Context, handlerTyp
 		return nil, nil, executionErrorMessage(errCount)
 	}
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.Cfg))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.Cfg)
 	body, responseHeaders := h.applyResponseInterceptors(ctx, handlerType, modelName, originalRequestedModel, opts, rawResponseHeaders, responseHeaders, opts.OriginalRequest, req.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	return body, responseHeaders, nil
 }
@@ -941,6 +1048,7 @@ func (h *BaseAPIHandler) pluginExecutorRequest(ctx context.Context, entryProtoco
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
+	addExperimentMetadata(reqMeta, execOptions.experimentArm)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, modelName, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
 	setGenerateMetadata(reqMeta, rawJSON)
@@ -1071,7 +1179,7 @@ func (h *BaseAPIHandler) streamWithPluginExecutor(ctx context.Context, entryProt
 		}, execOptions.SkipInterceptorPluginID)
 		applyStreamHeaders(intercepted.Headers)
 	}
-	upstreamHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, passthroughHeadersEnabled)
+	upstreamHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, h.Cfg)
 	if upstreamHeaders == nil && (passthroughHeadersEnabled || streamInterceptorsActive) {
 		upstreamHeaders = make(http.Header)
 	}
@@ -1164,6 +1272,45 @@ func (h *BaseAPIHandler) executeStreamWithAuthManager(ctx context.Context, handl
 }
 
 func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	if recorder := startTranscript(ctx, exitProtocol, modelName, &execOptions); recorder != nil {
+		dataChan, headers, errChan := h.executeStreamWithAuthManagerFormats(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, allowImageModel, execOptions)
+		dataChan, errChan = teeTranscript(ctx, recorder, dataChan, errChan)
+		return dataChan, headers, errChan
+	}
+	if mirror := h.startShadowTraffic(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, true, &execOptions); mirror != nil {
+		dataChan, headers, errChan := h.executeStreamWithAuthManagerFormats(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, allowImageModel, execOptions)
+		h.endShadowTraffic(mirror)
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return mirror.teeStream(ctx, dataChan), headers, errChan
+	}
+	execOptions = applyRequestRoutingHints(ctx, execOptions)
+	modelName, rawJSON = h.applySyntheticModel(entryProtocol, modelName, rawJSON)
+	modelName, execOptions = h.applyExperiment(ctx, modelName, execOptions)
+	modelName, execOptions = h.applyModelAlias(modelName, execOptions)
+	if targets := h.modelFailoverTargets(modelName, execOptions); len(targets) > 0 {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		return executeStreamWithModelFailover(modelFailoverContext(ctx), modelName, targets, execOptions, func(targetCtx context.Context, targetModel string, targetOptions modelExecutionOptions) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+			return h.executeStreamWithAuthManagerFormats(targetCtx, entryProtocol, exitProtocol, targetModel, rawJSON, alt, allowImageModel, targetOptions)
+		})
+	}
+	modelName, errMsg := h.applyTenantCredentialPrefix(ctx, modelName)
+	if errMsg == nil {
+		errMsg = checkKeyScopeModel(ctx, modelName)
+	}
+	if errMsg == nil {
+		rawJSON, errMsg = h.applyRequestMiddleware(ctx, entryProtocol, modelName, rawJSON, false)
+	}
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	h.launchShadowTraffic(execOptions, rawJSON)
 	originalRequestedModel := modelName
 	routeDecision, preparedRoute := preparedModelRouteFromContext(ctx)
 	if !preparedRoute {
@@ -1180,6 +1327,18 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		return h.streamWithPluginExecutor(ctx, entryProtocol, responseProtocol, modelName, originalRequestedModel, rawJSON, alt, routeDecision.ExecutorPluginID, execOptions)
 	}
 	providers, normalizedModel, errMsg := h.providersForExecution(modelName, originalRequestedModel, allowImageModel, routeDecision, execOptions)
+	if errMsg == nil {
+		providers, errMsg = filterProvidersByKeyScope(ctx, providers, normalizedModel)
+	}
+	if errMsg == nil {
+		providers, errMsg = h.checkProviderRateLimit(ctx, providers)
+	}
+	if errMsg == nil {
+		errMsg = validateModelCapabilities(entryProtocol, normalizedModel, rawJSON, alt)
+	}
+	if errMsg == nil {
+		rawJSON, errMsg = h.preflightTokenCheck(ctx, entryProtocol, normalizedModel, providers, rawJSON, alt, execOptions)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -1188,9 +1347,11 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 	}
 	providers = adjustExecutionProvidersForEntryProtocol(entryProtocol, providers)
 	reqMeta := requestExecutionMetadata(ctx)
+	h.debitProviderRateLimit(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
+	addExperimentMetadata(reqMeta, execOptions.experimentArm)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, normalizedModel, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
 	setGenerateMetadata(reqMeta, rawJSON)
@@ -1215,8 +1376,9 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 	}
 	opts.Metadata = reqMeta
 	req, opts = h.applyRequestInterceptorsBeforeAuth(ctx, entryProtocol, originalRequestedModel, req, opts, execOptions.SkipInterceptorPluginID)
-	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	streamResult, err := h.executeStreamTimed(ctx, providers, req, opts)
 	if err != nil {
+		recordClientCancel(ctx, modelName, cancelstats.StageBeforeFirstChunk)
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -1285,6 +1447,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		streamHeaderInitialized = true
 	}
 
+	streamMiddleware := h.newPipelineStream(ctx, responseProtocol, normalizedModel)
 	transformStreamPayload := func(payload []byte, chunkIndex *int, historyChunks [][]byte) ([]byte, bool, *interfaces.ErrorMessage) {
 		applyStreamHeaderInit()
 		payload = cloneBytes(payload)
@@ -1319,6 +1482,13 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 				return nil, false, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errValidate}
 			}
 		}
+		payload, errMsg := streamMiddleware.chunk(payload)
+		if errMsg != nil {
+			return nil, false, errMsg
+		}
+		if len(payload) == 0 {
+			return nil, false, nil
+		}
 		return payload, true, nil
 	}
 
@@ -1394,8 +1564,9 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 			break
 		}
 		bootstrapRetries++
-		retryResult, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+		retryResult, retryErr := h.executeStreamTimed(ctx, providers, req, opts)
 		if retryErr != nil {
+			recordClientCancel(ctx, modelName, cancelstats.StageBeforeFirstChunk)
 			bootstrapErr = executionErrorMessage(enrichAuthSelectionError(retryErr, providers, normalizedModel))
 			break
 		}
@@ -1419,7 +1590,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		}
 	}
 
-	upstreamHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, passthroughHeadersEnabled)
+	upstreamHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, h.Cfg)
 	if upstreamHeaders == nil && (passthroughHeadersEnabled || streamInterceptorsActive) {
 		upstreamHeaders = make(http.Header)
 	}
@@ -1430,6 +1601,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		defer close(dataChan)
 		defer close(errChan)
 		if streamCanceledBeforeRead {
+			recordClientCancel(ctx, modelName, cancelstats.StageBeforeFirstChunk)
 			return
 		}
 
@@ -1468,6 +1640,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		historyChunks := bootstrapHistoryChunks
 		if bootstrapPayload != nil {
 			if okSendData := sendData(bootstrapPayload); !okSendData {
+				recordClientCancel(ctx, modelName, cancelstats.StageMidStream)
 				return
 			}
 			if streamInterceptorsActive {
@@ -1476,7 +1649,14 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		}
 		for {
 			chunk, ok, canceled := nextStreamChunk(ctx, nil, &streamClosedBeforeRead, chunks)
-			if canceled || !ok {
+			if canceled {
+				recordClientCancel(ctx, modelName, cancelstats.StageMidStream)
+				return
+			}
+			if !ok {
+				if errMsg := streamMiddleware.close(); errMsg != nil {
+					_ = sendErr(errMsg)
+				}
 				return
 			}
 			if chunk.Err != nil {
@@ -1495,6 +1675,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 				continue
 			}
 			if okSendData := sendData(payload); !okSendData {
+				recordClientCancel(ctx, modelName, cancelstats.StageMidStream)
 				return
 			}
 			if streamInterceptorsActive {
@@ -1635,7 +1816,9 @@ func nativeInteractionsExecutionError() *interfaces.ErrorMessage {
 
 // providersForExecution resolves the providers and normalized model for a request. When a model
 // router selected a built-in provider, it skips model->provider resolution and uses the router's
-// provider (with an optional target model); otherwise it falls back to the registry-based path.
+// provider (with an optional target model); otherwise it falls back to the registry-based path,
+// narrowed to the providers named by a model alias rule, a model failover target and the
+// client's routing hint.
 func (h *BaseAPIHandler) providersForExecution(modelName, originalRequestedModel string, allowImageModel bool, routeDecision modelRouteDecision, execOptions modelExecutionOptions) ([]string, string, *interfaces.ErrorMessage) {
 	forcedProvider := strings.ToLower(strings.TrimSpace(execOptions.ForcedProvider))
 	if forcedProvider != "" {
@@ -1664,7 +1847,20 @@ func (h *BaseAPIHandler) providersForExecution(modelName, originalRequestedModel
 		}
 		return []string{routeDecision.Provider}, normalizedModel, nil
 	}
-	return h.getRequestDetailsWithOptions(modelName, allowImageModel)
+	providers, normalizedModel, errMsg := h.getRequestDetailsWithOptions(modelName, allowImageModel)
+	if errMsg != nil {
+		return nil, "", errMsg
+	}
+	if h.AuthManager != nil && h.AuthManager.HomeEnabled() {
+		return providers, normalizedModel, nil
+	}
+	for _, provider := range []string{execOptions.aliasProvider, execOptions.failoverProvider, execOptions.routingProvider} {
+		providers, errMsg = filterProvidersByName(providers, normalizedModel, provider)
+		if errMsg != nil {
+			return nil, "", errMsg
+		}
+	}
+	return providers, normalizedModel, nil
 }
 
 func (h *BaseAPIHandler) getRequestDetailsWithOptions(modelName string, allowImageModel bool) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
@@ -1711,6 +1907,9 @@ func (h *BaseAPIHandler) getRequestDetailsWithOptions(modelName string, allowIma
 	}
 
 	if len(providers) == 0 {
+		if errMsg := h.notReadyError(); errMsg != nil {
+			return nil, "", errMsg
+		}
 		return nil, "", &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("unknown provider for model %s", modelName)}
 	}
 
@@ -1841,16 +2040,18 @@ func finalInterceptorHeaders(current, intercepted http.Header) http.Header {
 	return cloneHeader(intercepted)
 }
 
-func downstreamHeadersFromExecutor(headers http.Header, passthrough bool) http.Header {
-	if !passthrough {
+func downstreamHeadersFromExecutor(headers http.Header, cfg *config.SDKConfig) http.Header {
+	if !PassthroughHeadersEnabled(cfg) {
 		return nil
 	}
-	return FilterUpstreamHeaders(headers)
+	return FilterUpstreamHeadersWithPolicy(headers, cfg.ResponseHeaders)
 }
 
-func downstreamHeadersAfterInterceptors(baseRaw, finalRaw http.Header, passthrough bool) http.Header {
-	if passthrough {
-		return FilterUpstreamHeaders(finalRaw)
+// downstreamHeadersAfterInterceptors returns the headers sent to the client. Without
+// passthrough only headers added or changed by interceptor plugins are forwarded.
+func downstreamHeadersAfterInterceptors(baseRaw, finalRaw http.Header, cfg *config.SDKConfig) http.Header {
+	if PassthroughHeadersEnabled(cfg) {
+		return FilterUpstreamHeadersWithPolicy(finalRaw, cfg.ResponseHeaders)
 	}
 	return FilterUpstreamHeaders(diffHeaders(baseRaw, finalRaw))
 }
@@ -2199,7 +2400,7 @@ func (h *BaseAPIHandler) applyResponseInterceptors(ctx context.Context, handlerT
 		StatusCode:      statusCode,
 		Metadata:        opts.Metadata,
 	}, skipPluginID)
-	responseHeaders = downstreamHeadersAfterInterceptors(rawResponseHeaders, finalInterceptorHeaders(rawResponseHeaders, resp.Headers), PassthroughHeadersEnabled(h.Cfg))
+	responseHeaders = downstreamHeadersAfterInterceptors(rawResponseHeaders, finalInterceptorHeaders(rawResponseHeaders, resp.Headers), h.Cfg)
 	if len(resp.Body) > 0 {
 		body = cloneBytes(resp.Body)
 	}
@@ -2264,10 +2465,14 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		for _, value := range coreauth.SafeResponseHeaders(msg.Error).Values("Retry-After") {
 			c.Writer.Header().Add("Retry-After", value)
 		}
+		var rateLimited *RateLimitError
+		if errors.As(msg.Error, &rateLimited) {
+			c.Writer.Header().Set("Retry-After", rateLimited.Headers().Get("Retry-After"))
+		}
 	}
 	if msg != nil && msg.Addon != nil && PassthroughHeadersEnabled(h.Cfg) {
-		for key, values := range msg.Addon {
-			if len(values) == 0 || IsCPAReservedResponseHeader(key) {
+		for key, values := range FilterUpstreamHeadersWithPolicy(msg.Addon, h.Cfg.ResponseHeaders) {
+			if len(values) == 0 {
 				continue
 			}
 			c.Writer.Header().Del(key)
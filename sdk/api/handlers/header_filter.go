@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 )
 
 // gatewayHeaderPrefixes lists header name prefixes injected by known AI gateway
@@ -87,6 +89,53 @@ func FilterUpstreamHeaders(src http.Header) http.Header {
 	return dst
 }
 
+// FilterUpstreamHeadersWithPolicy filters src like FilterUpstreamHeaders and then applies
+// the response-headers allow and deny lists.
+func FilterUpstreamHeadersWithPolicy(src http.Header, policy config.ResponseHeadersConfig) http.Header {
+	return ApplyResponseHeaderPolicy(FilterUpstreamHeaders(src), policy)
+}
+
+// ApplyResponseHeaderPolicy returns the headers of h permitted by policy. When the policy is
+// empty h is returned unchanged; otherwise a filtered copy is returned, or nil if nothing remains.
+func ApplyResponseHeaderPolicy(h http.Header, policy config.ResponseHeadersConfig) http.Header {
+	if len(h) == 0 || (len(policy.Allow) == 0 && len(policy.Deny) == 0) {
+		return h
+	}
+	dst := make(http.Header, len(h))
+	for key, values := range h {
+		if len(policy.Allow) > 0 && !matchHeaderPatterns(key, policy.Allow) {
+			continue
+		}
+		if matchHeaderPatterns(key, policy.Deny) {
+			continue
+		}
+		dst[key] = values
+	}
+	if len(dst) == 0 {
+		return nil
+	}
+	return dst
+}
+
+// matchHeaderPatterns reports whether name matches one of the patterns, ignoring case.
+// A trailing "*" matches any header with that prefix.
+func matchHeaderPatterns(name string, patterns []string) bool {
+	lowerName := strings.ToLower(name)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard {
+			if strings.HasPrefix(lowerName, prefix) {
+				return true
+			}
+			continue
+		}
+		if lowerName == pattern {
+			return true
+		}
+	}
+	return false
+}
+
 func connectionScopedHeaders(src http.Header) map[string]struct{} {
 	scoped := make(map[string]struct{})
 	for _, rawValue := range src.Values("Connection") {
@@ -117,3 +166,80 @@ func WriteUpstreamHeaders(dst http.Header, src http.Header) {
 		}
 	}
 }
+
+// clientIdentifyingRequestHeaders lists inbound headers stripped by default because they
+// carry the client's session state or network identity.
+var clientIdentifyingRequestHeaders = []string{
+	"Cookie",
+	"Forwarded",
+	"Via",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Proto",
+	"X-Forwarded-Port",
+	"X-Real-Ip",
+	"X-Client-Ip",
+	"X-Cluster-Client-Ip",
+	"True-Client-Ip",
+	"Cf-Connecting-Ip",
+	"Fastly-Client-Ip",
+}
+
+// protectedRequestHeaders are never stripped because handlers and executors depend on them.
+var protectedRequestHeaders = map[string]struct{}{
+	"Authorization":  {},
+	"X-Api-Key":      {},
+	"X-Goog-Api-Key": {},
+	"Content-Type":   {},
+	"Content-Length": {},
+}
+
+// FilterRequestHeaders removes inbound headers disallowed by cfg from h in place and
+// returns the canonical names of the removed headers. Cookies, client-identifying headers,
+// headers listed in cfg.Strip and headers larger than cfg.MaxHeaderBytesLimit are removed
+// unless they appear in cfg.Allow.
+func FilterRequestHeaders(h http.Header, cfg config.HeaderFilterConfig) []string {
+	if len(h) == 0 || cfg.Disable {
+		return nil
+	}
+	allow := make(map[string]struct{}, len(cfg.Allow))
+	for _, name := range cfg.Allow {
+		if name = strings.TrimSpace(name); name != "" {
+			allow[http.CanonicalHeaderKey(name)] = struct{}{}
+		}
+	}
+	strip := make(map[string]struct{}, len(clientIdentifyingRequestHeaders)+len(cfg.Strip))
+	for _, name := range clientIdentifyingRequestHeaders {
+		strip[name] = struct{}{}
+	}
+	for _, name := range cfg.Strip {
+		if name = strings.TrimSpace(name); name != "" {
+			strip[http.CanonicalHeaderKey(name)] = struct{}{}
+		}
+	}
+	maxBytes := cfg.MaxHeaderBytesLimit()
+
+	var removed []string
+	for key, values := range h {
+		canonicalKey := http.CanonicalHeaderKey(key)
+		if _, protected := protectedRequestHeaders[canonicalKey]; protected {
+			continue
+		}
+		if _, allowed := allow[canonicalKey]; allowed {
+			continue
+		}
+		_, blocked := strip[canonicalKey]
+		if !blocked && maxBytes > 0 {
+			size := len(key)
+			for _, value := range values {
+				size += len(value)
+			}
+			blocked = size > maxBytes
+		}
+		if blocked {
+			delete(h, key)
+			removed = append(removed, canonicalKey)
+		}
+	}
+	return removed
+}
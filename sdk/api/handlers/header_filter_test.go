@@ -2,7 +2,12 @@ package handlers
 
 import (
 	"net/http"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 )
 
 func TestFilterUpstreamHeaders_RemovesConnectionScopedHeaders(t *testing.T) {
@@ -57,3 +62,69 @@ func TestFilterUpstreamHeaders_ReturnsNilWhenAllHeadersBlocked(t *testing.T) {
 		t.Fatalf("expected nil when all headers are filtered, got %#v", filtered)
 	}
 }
+
+func TestFilterRequestHeaders_DefaultPolicy(t *testing.T) {
+	h := http.Header{}
+	h.Set("Cookie", "session=secret")
+	h.Set("X-Forwarded-For", "203.0.113.9")
+	h.Set("Cf-Connecting-Ip", "203.0.113.9")
+	h.Set("Authorization", "Bearer "+strings.Repeat("k", 10000))
+	h.Set("X-Huge", strings.Repeat("a", 9000))
+	h.Set("Anthropic-Beta", "prompt-caching-2024-07-31")
+	h.Set("X-Tracking", "1")
+
+	removed := FilterRequestHeaders(h, sdkconfig.HeaderFilterConfig{Strip: []string{"x-tracking"}})
+	sort.Strings(removed)
+	want := []string{"Cf-Connecting-Ip", "Cookie", "X-Forwarded-For", "X-Huge", "X-Tracking"}
+	if !reflect.DeepEqual(removed, want) {
+		t.Fatalf("removed = %v, want %v", removed, want)
+	}
+	if h.Get("Authorization") == "" {
+		t.Fatal("Authorization must never be stripped")
+	}
+	if h.Get("Anthropic-Beta") == "" {
+		t.Fatal("Anthropic-Beta should be preserved")
+	}
+}
+
+func TestFilterRequestHeaders_AllowlistAndDisable(t *testing.T) {
+	h := http.Header{}
+	h.Set("Cookie", "session=secret")
+	h.Set("X-Huge", strings.Repeat("a", 9000))
+
+	removed := FilterRequestHeaders(h, sdkconfig.HeaderFilterConfig{Allow: []string{"cookie"}, MaxHeaderBytes: -1})
+	if len(removed) != 0 || h.Get("Cookie") == "" || h.Get("X-Huge") == "" {
+		t.Fatalf("removed = %v, headers = %v; want nothing stripped", removed, h)
+	}
+
+	if removed := FilterRequestHeaders(h, sdkconfig.HeaderFilterConfig{Disable: true}); removed != nil {
+		t.Fatalf("disabled filter removed %v", removed)
+	}
+}
+
+func TestFilterUpstreamHeadersWithPolicy_AllowAndDeny(t *testing.T) {
+	src := http.Header{}
+	src.Set("X-Ratelimit-Remaining-Requests", "10")
+	src.Set("X-Ratelimit-Reset", "1s")
+	src.Set("Request-Id", "req_1")
+	src.Set("Openai-Organization", "org-secret")
+	src.Set("Set-Cookie", "a=b")
+
+	filtered := FilterUpstreamHeadersWithPolicy(src, sdkconfig.ResponseHeadersConfig{
+		Allow: []string{"x-ratelimit-*", "request-id", "set-cookie"},
+		Deny:  []string{"x-ratelimit-reset"},
+	})
+	var got []string
+	for key := range filtered {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+	want := []string{"Request-Id", "X-Ratelimit-Remaining-Requests"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("forwarded headers = %v, want %v", got, want)
+	}
+
+	if filtered := FilterUpstreamHeadersWithPolicy(src, sdkconfig.ResponseHeadersConfig{Deny: []string{"openai-*"}}); filtered.Get("Openai-Organization") != "" || filtered.Get("Request-Id") == "" {
+		t.Fatalf("deny-only policy = %v", filtered)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+// keyScopeFromContext returns the scope of the API key that authenticated the request. Keys
+// without a scope, including plain api-keys entries, yield the zero scope that allows everything.
+func keyScopeFromContext(ctx context.Context) sdkaccess.KeyScope {
+	if ctx == nil {
+		return sdkaccess.KeyScope{}
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return sdkaccess.KeyScope{}
+	}
+	value, exists := ginCtx.Get("accessMetadata")
+	if !exists {
+		return sdkaccess.KeyScope{}
+	}
+	meta, _ := value.(map[string]string)
+	return sdkaccess.ScopeFromMetadata(meta)
+}
+
+// checkKeyScopeModel rejects requests from read-only keys and for models outside the key's scope.
+func checkKeyScopeModel(ctx context.Context, modelName string) *interfaces.ErrorMessage {
+	scope := keyScopeFromContext(ctx)
+	if scope.ReadOnly {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("API key is read-only")}
+	}
+	if !scope.AllowsModel(modelName) {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("API key is not allowed to use model %s", modelName)}
+	}
+	return nil
+}
+
+// filterProvidersByKeyScope drops the providers the key may not route to. OpenAI-compatible
+// providers match either their compat key or their configured name.
+func filterProvidersByKeyScope(ctx context.Context, providers []string, modelName string) ([]string, *interfaces.ErrorMessage) {
+	scope := keyScopeFromContext(ctx)
+	if len(scope.Providers) == 0 {
+		return providers, nil
+	}
+	permitted := make(map[string]struct{}, len(scope.Providers)*2)
+	for _, name := range scope.Providers {
+		permitted[name] = struct{}{}
+		permitted[util.OpenAICompatibleProviderKey(name)] = struct{}{}
+	}
+	allowed := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		if _, ok := permitted[strings.ToLower(provider)]; ok {
+			allowed = append(allowed, provider)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("API key is not allowed to use the providers serving model %s", modelName)}
+	}
+	return allowed, nil
+}
+
+// applyTenantCredentialPrefix routes the requests of a tenant with dedicated credentials to the
+// models registered under its credential prefix, and rejects requests naming another tenant's
+// prefix.
+func (h *BaseAPIHandler) applyTenantCredentialPrefix(ctx context.Context, modelName string) (string, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.Tenants) == 0 {
+		return modelName, nil
+	}
+	scope := keyScopeFromContext(ctx)
+	lowerModel := strings.ToLower(modelName)
+	for _, tenant := range h.Cfg.Tenants {
+		if tenant.CredentialPrefix == "" || tenant.Name == scope.Tenant {
+			continue
+		}
+		if strings.HasPrefix(lowerModel, strings.ToLower(tenant.CredentialPrefix)+"/") {
+			return modelName, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("model %s is reserved for another tenant", modelName)}
+		}
+	}
+	if scope.CredentialPrefix == "" || strings.HasPrefix(lowerModel, strings.ToLower(scope.CredentialPrefix)+"/") {
+		return modelName, nil
+	}
+	dedicated := scope.CredentialPrefix + "/" + modelName
+	if len(util.GetProviderName(thinking.ParseSuffix(dedicated).ModelName)) == 0 {
+		return modelName, nil
+	}
+	return dedicated, nil
+}
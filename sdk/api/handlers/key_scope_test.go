@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func keyScopeTestContext(meta map[string]string) context.Context {
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if meta != nil {
+		ginCtx.Set("accessMetadata", meta)
+	}
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
+
+func TestKeyScopeEnforcement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	unscoped := keyScopeTestContext(nil)
+	if errMsg := checkKeyScopeModel(unscoped, "gpt-5"); errMsg != nil {
+		t.Fatalf("unscoped model check = %v", errMsg.Error)
+	}
+	if providers, errMsg := filterProvidersByKeyScope(unscoped, []string{"codex", "claude"}, "gpt-5"); errMsg != nil || len(providers) != 2 {
+		t.Fatalf("unscoped providers = %v, %v", providers, errMsg)
+	}
+
+	scoped := keyScopeTestContext(map[string]string{
+		sdkaccess.MetadataAllowedProviders: "claude,openrouter",
+		sdkaccess.MetadataAllowedModels:    "claude-*,gpt-5",
+	})
+	if errMsg := checkKeyScopeModel(scoped, "gemini-2.5-pro"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("out-of-scope model check = %+v", errMsg)
+	}
+	providers, errMsg := filterProvidersByKeyScope(scoped, []string{"codex", "openai-compatible-openrouter", "claude"}, "gpt-5")
+	if errMsg != nil || len(providers) != 2 || providers[0] != "openai-compatible-openrouter" || providers[1] != "claude" {
+		t.Fatalf("scoped providers = %v, %v", providers, errMsg)
+	}
+	if _, errMsg := filterProvidersByKeyScope(scoped, []string{"codex"}, "gpt-5"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("no allowed provider = %+v", errMsg)
+	}
+
+	readOnly := keyScopeTestContext(map[string]string{sdkaccess.MetadataReadOnly: "true"})
+	if errMsg := checkKeyScopeModel(readOnly, "gpt-5"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("read-only model check = %+v", errMsg)
+	}
+}
+
+func TestApplyTenantCredentialPrefix(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-tenant-prefix-acme", "claude", []*registry.ModelInfo{{ID: "acme/claude-sonnet-4-5"}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-tenant-prefix-acme") })
+
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{Tenants: []sdkconfig.Tenant{
+		{Name: "acme", APIKeys: []string{"acme-key"}, CredentialPrefix: "acme"},
+		{Name: "globex", APIKeys: []string{"globex-key"}},
+	}}}
+	acme := keyScopeTestContext(map[string]string{sdkaccess.MetadataTenant: "acme", sdkaccess.MetadataCredentialPrefix: "acme"})
+	globex := keyScopeTestContext(map[string]string{sdkaccess.MetadataTenant: "globex"})
+
+	if got, errMsg := h.applyTenantCredentialPrefix(acme, "claude-sonnet-4-5"); errMsg != nil || got != "acme/claude-sonnet-4-5" {
+		t.Fatalf("tenant model = %q, %v; want the dedicated credentials", got, errMsg)
+	}
+	if got, errMsg := h.applyTenantCredentialPrefix(acme, "gpt-5"); errMsg != nil || got != "gpt-5" {
+		t.Fatalf("model without dedicated credentials = %q, %v; want it unchanged", got, errMsg)
+	}
+	if got, errMsg := h.applyTenantCredentialPrefix(globex, "claude-sonnet-4-5"); errMsg != nil || got != "claude-sonnet-4-5" {
+		t.Fatalf("other tenant model = %q, %v; want it unchanged", got, errMsg)
+	}
+	if _, errMsg := h.applyTenantCredentialPrefix(globex, "acme/claude-sonnet-4-5"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("other tenant using reserved prefix = %+v, want 403", errMsg)
+	}
+	if _, errMsg := h.applyTenantCredentialPrefix(keyScopeTestContext(nil), "ACME/claude-sonnet-4-5"); errMsg == nil {
+		t.Fatal("plain api key allowed to use a reserved prefix")
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// applyLocaleHints appends a response language hint to the system prompt of rawJSON. The locale
+// comes from the client API key's override, the client's Accept-Language header or the
+// configured default, in that order. None of the supported entry protocols carries a native
+// locale field, so the hint is always expressed through the system prompt.
+func (h *BaseAPIHandler) applyLocaleHints(ctx context.Context, entryProtocol string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil || !h.Cfg.LocaleHints.Enable || len(rawJSON) == 0 {
+		return rawJSON
+	}
+	cfg := h.Cfg.LocaleHints
+	locale := ""
+	if override, ok := cfg.ForKey(clientAPIKeyFromContext(ctx)); ok {
+		if override.Disable {
+			return rawJSON
+		}
+		locale = override.Locale
+	}
+	if locale == "" {
+		locale = acceptLanguageFromContext(ctx)
+	}
+	if locale == "" {
+		locale = cfg.Default
+	}
+	tag, errParse := language.Parse(locale)
+	if errParse != nil {
+		return rawJSON
+	}
+	name := display.English.Languages().Name(tag)
+	if name == "" {
+		name = tag.String()
+	}
+	hint := strings.NewReplacer("{{locale}}", tag.String(), "{{language}}", name).Replace(cfg.Template)
+	return appendSystemPrompt(entryProtocol, rawJSON, strings.TrimSpace(hint))
+}
+
+// acceptLanguageFromContext returns the client's most preferred language tag, or "" when the
+// request has no usable Accept-Language header.
+func acceptLanguageFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return ""
+	}
+	header := strings.TrimSpace(ginCtx.GetHeader("Accept-Language"))
+	if header == "" {
+		return ""
+	}
+	tags, _, errParse := language.ParseAcceptLanguage(header)
+	if errParse != nil {
+		return ""
+	}
+	for _, tag := range tags {
+		if tag != language.Und {
+			return tag.String()
+		}
+	}
+	return ""
+}
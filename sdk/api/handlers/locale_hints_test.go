@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyLocaleHints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{LocaleHints: config.LocaleHintsConfig{
+		Enable:   true,
+		Default:  "en-US",
+		Template: "Language: {{language}} ({{locale}})",
+		Keys: []config.LocaleHintKey{
+			{APIKey: "pinned-key", Locale: "ja"},
+			{APIKey: "off-key", Disable: true},
+		},
+	}}}
+
+	run := func(apiKey, acceptLanguage string) string {
+		ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginCtx.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+		if acceptLanguage != "" {
+			ginCtx.Request.Header.Set("Accept-Language", acceptLanguage)
+		}
+		if apiKey != "" {
+			ginCtx.Set("userApiKey", apiKey)
+		}
+		ctx := context.WithValue(context.Background(), "gin", ginCtx)
+		out := handler.applyLocaleHints(ctx, "claude", []byte(`{"system":"Be brief.","messages":[]}`))
+		return gjson.GetBytes(out, "system").String()
+	}
+
+	cases := []struct {
+		name           string
+		apiKey         string
+		acceptLanguage string
+		want           string
+	}{
+		{"accept-language", "", "fr;q=0.5, de-DE, *;q=0.1", "Be brief.\n\nLanguage: German (de-DE)"},
+		{"default", "", "", "Be brief.\n\nLanguage: American English (en-US)"},
+		{"pinned key", "pinned-key", "de-DE", "Be brief.\n\nLanguage: Japanese (ja)"},
+		{"disabled key", "off-key", "de-DE", "Be brief."},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := run(tc.apiKey, tc.acceptLanguage); got != tc.want {
+				t.Fatalf("system = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+)
+
+// PipelineMiddleware intercepts requests and responses inside the provider handler pipeline.
+// Modules register middleware with BaseAPIHandler.UsePipelineMiddleware or the server's
+// WithPipelineMiddleware option; every hook is optional.
+type PipelineMiddleware struct {
+	// Name identifies the middleware in logs.
+	Name string
+	// Request rewrites or rejects the client payload once the target model is resolved and
+	// before it is routed to a provider. It runs again for every model failover target.
+	Request func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage)
+	// Response rewrites or rejects a completed non-streaming response before delivery.
+	Response func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage)
+	// Stream returns the observer for one streaming response, or nil to skip it.
+	Stream func(ctx context.Context, call PipelineCall) StreamObserver
+}
+
+// PipelineCall describes the request a middleware hook is invoked for.
+type PipelineCall struct {
+	// Handler is the handler executing the request; Handler.Cfg holds the live configuration.
+	Handler *BaseAPIHandler
+	// Protocol is the entry protocol for request hooks and the response protocol otherwise.
+	Protocol string
+	// Model is the resolved model name.
+	Model string
+	// CountOnly is set for token counting requests, which produce no completion.
+	CountOnly bool
+}
+
+// StreamObserver receives the chunks of one streaming response in order.
+type StreamObserver interface {
+	// Chunk returns the payload to forward, or an error that aborts the stream. An empty
+	// payload drops the chunk.
+	Chunk(payload []byte) ([]byte, *interfaces.ErrorMessage)
+	// Close is called once after the upstream stream ends successfully.
+	Close() *interfaces.ErrorMessage
+}
+
+// builtinPipelineMiddleware lists the built-in stages in execution order: redaction runs first
+// so neither prompt rules nor the moderation endpoint see the scrubbed values.
+var builtinPipelineMiddleware = []PipelineMiddleware{
+	{
+		Name: "pii-redaction",
+		Request: func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			return call.Handler.applyPIIRedaction(ctx, call.Model, body), nil
+		},
+	},
+	{
+		Name: "prompt-rules",
+		Request: func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			return call.Handler.applyPromptRules(ctx, call.Protocol, call.Model, body), nil
+		},
+	},
+	{
+		Name: "locale-hints",
+		Request: func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			return call.Handler.applyLocaleHints(ctx, call.Protocol, body), nil
+		},
+	},
+	{
+		Name: "moderation",
+		Request: func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			if call.CountOnly {
+				return body, nil
+			}
+			return body, call.Handler.moderateInput(ctx, call.Protocol, body)
+		},
+		Response: func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			return body, call.Handler.moderateOutput(ctx, call.Protocol, body)
+		},
+		Stream: func(ctx context.Context, call PipelineCall) StreamObserver {
+			if moderation := call.Handler.newStreamModeration(ctx, call.Protocol); moderation != nil {
+				return moderation
+			}
+			return nil
+		},
+	},
+	{
+		Name: "reasoning-output",
+		Response: func(ctx context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			return call.Handler.stripReasoning(call.Protocol, body), nil
+		},
+		Stream: func(ctx context.Context, call PipelineCall) StreamObserver {
+			if stream := call.Handler.newReasoningStripStream(call.Protocol); stream != nil {
+				return stream
+			}
+			return nil
+		},
+	},
+}
+
+// UsePipelineMiddleware appends middleware that runs after the built-in stages.
+func (h *BaseAPIHandler) UsePipelineMiddleware(mw ...PipelineMiddleware) {
+	if h == nil || len(mw) == 0 {
+		return
+	}
+	h.pipelineMu.Lock()
+	h.pipeline = append(h.pipeline, mw...)
+	h.pipelineMu.Unlock()
+}
+
+func (h *BaseAPIHandler) pipelineMiddleware() []PipelineMiddleware {
+	h.pipelineMu.RLock()
+	defer h.pipelineMu.RUnlock()
+	out := make([]PipelineMiddleware, 0, len(builtinPipelineMiddleware)+len(h.pipeline))
+	out = append(out, builtinPipelineMiddleware...)
+	return append(out, h.pipeline...)
+}
+
+// applyRequestMiddleware passes rawJSON through every request hook, stopping at the first error.
+func (h *BaseAPIHandler) applyRequestMiddleware(ctx context.Context, entryProtocol, modelName string, rawJSON []byte, countOnly bool) ([]byte, *interfaces.ErrorMessage) {
+	call := PipelineCall{Handler: h, Protocol: entryProtocol, Model: modelName, CountOnly: countOnly}
+	for _, mw := range h.pipelineMiddleware() {
+		if mw.Request == nil {
+			continue
+		}
+		out, errMsg := mw.Request(ctx, call, rawJSON)
+		if errMsg != nil {
+			return nil, errMsg
+		}
+		rawJSON = out
+	}
+	return rawJSON, nil
+}
+
+// applyResponseMiddleware passes a completed response through every response hook.
+func (h *BaseAPIHandler) applyResponseMiddleware(ctx context.Context, responseProtocol, modelName string, body []byte) ([]byte, *interfaces.ErrorMessage) {
+	call := PipelineCall{Handler: h, Protocol: responseProtocol, Model: modelName}
+	for _, mw := range h.pipelineMiddleware() {
+		if mw.Response == nil {
+			continue
+		}
+		out, errMsg := mw.Response(ctx, call, body)
+		if errMsg != nil {
+			return nil, errMsg
+		}
+		body = out
+	}
+	return body, nil
+}
+
+// pipelineStream fans the chunks of one streaming response out to the active observers.
+type pipelineStream struct {
+	observers []StreamObserver
+}
+
+func (h *BaseAPIHandler) newPipelineStream(ctx context.Context, responseProtocol, modelName string) *pipelineStream {
+	call := PipelineCall{Handler: h, Protocol: responseProtocol, Model: modelName}
+	stream := &pipelineStream{}
+	for _, mw := range h.pipelineMiddleware() {
+		if mw.Stream == nil {
+			continue
+		}
+		if observer := mw.Stream(ctx, call); observer != nil {
+			stream.observers = append(stream.observers, observer)
+		}
+	}
+	return stream
+}
+
+func (s *pipelineStream) chunk(payload []byte) ([]byte, *interfaces.ErrorMessage) {
+	for _, observer := range s.observers {
+		out, errMsg := observer.Chunk(payload)
+		if errMsg != nil {
+			return nil, errMsg
+		}
+		if len(out) == 0 {
+			return nil, nil
+		}
+		payload = out
+	}
+	return payload, nil
+}
+
+func (s *pipelineStream) close() *interfaces.ErrorMessage {
+	for _, observer := range s.observers {
+		if errMsg := observer.Close(); errMsg != nil {
+			return errMsg
+		}
+	}
+	return nil
+}
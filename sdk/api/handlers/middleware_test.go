@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+type upperStreamObserver struct{ closed *bool }
+
+func (o upperStreamObserver) Chunk(payload []byte) ([]byte, *interfaces.ErrorMessage) {
+	return []byte(strings.ToUpper(string(payload))), nil
+}
+
+func (o upperStreamObserver) Close() *interfaces.ErrorMessage {
+	*o.closed = true
+	return nil
+}
+
+func TestPipelineMiddlewareRunsInOrder(t *testing.T) {
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{}}
+	var calls []string
+	appendTag := func(tag string) PipelineMiddleware {
+		return PipelineMiddleware{
+			Name: tag,
+			Request: func(_ context.Context, call PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+				calls = append(calls, tag+":"+call.Model)
+				return append(body, tag...), nil
+			},
+		}
+	}
+	handler.UsePipelineMiddleware(appendTag("a"), appendTag("b"))
+
+	out, errMsg := handler.applyRequestMiddleware(context.Background(), "openai", "gpt-x", []byte("body-"), false)
+	if errMsg != nil || string(out) != "body-ab" {
+		t.Fatalf("out = %q, errMsg = %v", out, errMsg)
+	}
+	if strings.Join(calls, ",") != "a:gpt-x,b:gpt-x" {
+		t.Fatalf("calls = %v", calls)
+	}
+}
+
+func TestPipelineMiddlewareStopsAtFirstError(t *testing.T) {
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{}}
+	reached := false
+	handler.UsePipelineMiddleware(
+		PipelineMiddleware{Response: func(context.Context, PipelineCall, []byte) ([]byte, *interfaces.ErrorMessage) {
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: errors.New("denied")}
+		}},
+		PipelineMiddleware{Response: func(_ context.Context, _ PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			reached = true
+			return body, nil
+		}},
+	)
+
+	_, errMsg := handler.applyResponseMiddleware(context.Background(), "claude", "m", []byte("{}"))
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden || reached {
+		t.Fatalf("errMsg = %v, reached = %v", errMsg, reached)
+	}
+}
+
+func TestPipelineStreamRewritesChunks(t *testing.T) {
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{}}
+	closed := false
+	handler.UsePipelineMiddleware(PipelineMiddleware{
+		Stream: func(context.Context, PipelineCall) StreamObserver { return upperStreamObserver{closed: &closed} },
+	})
+
+	stream := handler.newPipelineStream(context.Background(), "openai", "m")
+	out, errMsg := stream.chunk([]byte("data: hi"))
+	if errMsg != nil || string(out) != "DATA: HI" {
+		t.Fatalf("out = %q, errMsg = %v", out, errMsg)
+	}
+	if errMsg = stream.close(); errMsg != nil || !closed {
+		t.Fatalf("close errMsg = %v, closed = %v", errMsg, closed)
+	}
+}
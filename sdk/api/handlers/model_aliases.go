@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// modelAliasPatterns caches compiled, fully anchored model alias regexes by source pattern.
+var modelAliasPatterns sync.Map
+
+func compiledModelAliasPattern(pattern string) *regexp.Regexp {
+	if cached, ok := modelAliasPatterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp)
+	}
+	re, errCompile := regexp.Compile("^(?:" + pattern + ")$")
+	if errCompile != nil {
+		return nil
+	}
+	modelAliasPatterns.Store(pattern, re)
+	return re
+}
+
+// rewriteModelAlias applies the first matching model alias rule to modelName. A thinking suffix
+// such as "(high)" is kept unless the rewritten model carries its own.
+func rewriteModelAlias(rules []config.ModelAliasRule, modelName string) (string, string, bool) {
+	if len(rules) == 0 {
+		return modelName, "", false
+	}
+	parsed := thinking.ParseSuffix(strings.TrimSpace(modelName))
+	base := parsed.ModelName
+	for _, rule := range rules {
+		var rewritten string
+		switch {
+		case rule.Match != "":
+			if !strings.EqualFold(rule.Match, base) {
+				continue
+			}
+			rewritten = rule.Model
+		case rule.Regex != "":
+			re := compiledModelAliasPattern(rule.Regex)
+			if re == nil || !re.MatchString(base) {
+				continue
+			}
+			rewritten = re.ReplaceAllString(base, rule.Model)
+		default:
+			continue
+		}
+		if rewritten = strings.TrimSpace(rewritten); rewritten == "" {
+			continue
+		}
+		if parsed.HasSuffix && !thinking.ParseSuffix(rewritten).HasSuffix {
+			rewritten += "(" + parsed.RawSuffix + ")"
+		}
+		return rewritten, rule.Provider, true
+	}
+	return modelName, "", false
+}
+
+// applyModelAlias rewrites modelName with the configured model aliases before provider lookup.
+// Requests pinned to a provider keep their model name.
+func (h *BaseAPIHandler) applyModelAlias(modelName string, execOptions modelExecutionOptions) (string, modelExecutionOptions) {
+	if h == nil || h.Cfg == nil || strings.TrimSpace(execOptions.ForcedProvider) != "" {
+		return modelName, execOptions
+	}
+	rewritten, provider, ok := rewriteModelAlias(h.Cfg.ModelAliases, modelName)
+	if !ok {
+		return modelName, execOptions
+	}
+	log.Debugf("model alias: rewrote %s to %s", modelName, rewritten)
+	execOptions.aliasProvider = provider
+	return rewritten, execOptions
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestRewriteModelAlias(t *testing.T) {
+	rules := []internalconfig.ModelAliasRule{
+		{Match: "gpt-4o", Model: "gemini-2.5-pro", Provider: "gemini"},
+		{Regex: "gpt-4o-(mini|nano)", Model: "gpt-5-$1"},
+		{Regex: "claude-.*", Model: "claude-sonnet-4-5(8192)"},
+	}
+	cases := []struct {
+		in, want, provider string
+		ok                 bool
+	}{
+		{in: "GPT-4o", want: "gemini-2.5-pro", provider: "gemini", ok: true},
+		{in: "gpt-4o(high)", want: "gemini-2.5-pro(high)", provider: "gemini", ok: true},
+		{in: "gpt-4o-mini", want: "gpt-5-mini", ok: true},
+		{in: "my-gpt-4o-mini", want: "my-gpt-4o-mini"},
+		{in: "claude-x(low)", want: "claude-sonnet-4-5(8192)", ok: true},
+		{in: "gemini-2.5-flash", want: "gemini-2.5-flash"},
+	}
+	for _, tc := range cases {
+		got, provider, ok := rewriteModelAlias(rules, tc.in)
+		if got != tc.want || provider != tc.provider || ok != tc.ok {
+			t.Errorf("rewriteModelAlias(%q) = %q, %q, %v; want %q, %q, %v", tc.in, got, provider, ok, tc.want, tc.provider, tc.ok)
+		}
+	}
+}
+
+func TestExecuteWithAuthManager_ModelAliasRewritesBeforeLookup(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude"}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"alias-target", "alias-target"}, nil)
+	handler.Cfg.ModelAliases = []internalconfig.ModelAliasRule{{Match: "gpt-4o", Model: "alias-target", Provider: "gemini"}}
+
+	for i := 0; i < 3; i++ {
+		body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "gpt-4o", []byte(`{"model":"gpt-4o"}`), "")
+		if errMsg != nil {
+			t.Fatalf("unexpected error: %+v", errMsg)
+		}
+		if string(body) != "gemini:alias-target" {
+			t.Fatalf("body = %q, want rewritten model served by gemini", body)
+		}
+	}
+	if got := claude.Models(); len(got) != 0 {
+		t.Fatalf("claude attempts = %v, want none", got)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// requestFeatures lists the model capabilities a request relies on.
+type requestFeatures struct {
+	images    bool
+	tools     bool
+	jsonMode  bool
+	maxOutput int64
+}
+
+// validateModelCapabilities rejects requests that use a feature the target model is declared
+// not to support, such as images for a text-only model, instead of forwarding them upstream.
+// Models without declared capabilities are not restricted.
+func validateModelCapabilities(entryProtocol, modelName string, rawJSON []byte, alt string) *interfaces.ErrorMessage {
+	if alt != "" || len(rawJSON) == 0 {
+		return nil
+	}
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	info := registry.LookupModelInfo(baseModel)
+	if info == nil || info.Capabilities == nil {
+		return nil
+	}
+	features, ok := detectRequestFeatures(entryProtocol, rawJSON)
+	if !ok {
+		return nil
+	}
+	var message string
+	switch {
+	case features.images && info.DeniesVision():
+		message = fmt.Sprintf("model %s does not support images", baseModel)
+	case features.tools && info.DeniesTools():
+		message = fmt.Sprintf("model %s does not support tools", baseModel)
+	case features.jsonMode && info.DeniesJSONMode():
+		message = fmt.Sprintf("model %s does not support JSON mode", baseModel)
+	case info.MaxOutputTokens() > 0 && features.maxOutput > int64(info.MaxOutputTokens()):
+		message = fmt.Sprintf("requested %d output tokens, but model %s supports at most %d", features.maxOutput, baseModel, info.MaxOutputTokens())
+	default:
+		return nil
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New(message)}
+}
+
+// detectRequestFeatures reads the capability-relevant fields of a request in entryProtocol.
+// It returns false for protocols it does not understand.
+func detectRequestFeatures(entryProtocol string, rawJSON []byte) (requestFeatures, bool) {
+	root := gjson.ParseBytes(rawJSON)
+	var features requestFeatures
+	switch sdktranslator.FromString(entryProtocol) {
+	case sdktranslator.FormatOpenAI:
+		features.images = anyContentPart(root.Get("messages.#.content"), func(part gjson.Result) bool {
+			t := part.Get("type").String()
+			return t == "image_url" || t == "input_image"
+		})
+		features.tools = len(root.Get("tools").Array()) > 0 || len(root.Get("functions").Array()) > 0
+		switch root.Get("response_format.type").String() {
+		case "json_object", "json_schema":
+			features.jsonMode = true
+		}
+		features.maxOutput = firstInt(root, "max_completion_tokens", "max_tokens")
+	case sdktranslator.FormatOpenAIResponse:
+		features.images = anyContentPart(root.Get("input.#.content"), func(part gjson.Result) bool {
+			return part.Get("type").String() == "input_image"
+		})
+		features.tools = len(root.Get("tools").Array()) > 0
+		switch root.Get("text.format.type").String() {
+		case "json_object", "json_schema":
+			features.jsonMode = true
+		}
+		features.maxOutput = firstInt(root, "max_output_tokens")
+	case sdktranslator.FormatClaude:
+		features.images = anyContentPart(root.Get("messages.#.content"), func(part gjson.Result) bool {
+			if part.Get("type").String() == "image" {
+				return true
+			}
+			// Tool results may carry images too.
+			for _, nested := range part.Get("content").Array() {
+				if nested.Get("type").String() == "image" {
+					return true
+				}
+			}
+			return false
+		})
+		features.tools = len(root.Get("tools").Array()) > 0
+		features.jsonMode = root.Get("output_format.type").String() == "json_schema"
+		features.maxOutput = firstInt(root, "max_tokens")
+	case sdktranslator.FormatGemini:
+		features.images = anyContentPart(root.Get("contents.#.parts"), func(part gjson.Result) bool {
+			mimeType := part.Get("inlineData.mimeType").String()
+			if mimeType == "" {
+				mimeType = part.Get("fileData.mimeType").String()
+			}
+			return strings.HasPrefix(strings.ToLower(mimeType), "image/")
+		})
+		features.tools = len(root.Get("tools").Array()) > 0
+		features.jsonMode = root.Get("generationConfig.responseMimeType").String() == "application/json" ||
+			root.Get("generationConfig.responseSchema").Exists() || root.Get("generationConfig.responseJsonSchema").Exists()
+		features.maxOutput = firstInt(root, "generationConfig.maxOutputTokens")
+	default:
+		return features, false
+	}
+	return features, true
+}
+
+// anyContentPart reports whether any part of the per-message content arrays matches.
+func anyContentPart(contents gjson.Result, match func(gjson.Result) bool) bool {
+	for _, content := range contents.Array() {
+		for _, part := range content.Array() {
+			if match(part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func firstInt(root gjson.Result, paths ...string) int64 {
+	for _, path := range paths {
+		if value := root.Get(path); value.Exists() {
+			return value.Int()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func newCapabilityTestHandler(t *testing.T, executor *preflightTestExecutor) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: t.Name() + "-claude", Provider: "claude", Status: coreauth.StatusActive}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("manager.Register: %v", errRegister)
+	}
+	noVision, tools := false, true
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{
+		ID:           "capability-model",
+		Capabilities: &registry.ModelCapabilities{Vision: &noVision, Tools: &tools, MaxOutputTokens: 4096},
+	}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+}
+
+func TestValidateModelCapabilities_RejectsUnsupportedFeatures(t *testing.T) {
+	executor := &preflightTestExecutor{}
+	handler := newCapabilityTestHandler(t, executor)
+
+	cases := []struct {
+		name     string
+		protocol string
+		payload  string
+		want     string
+	}{
+		{
+			name:     "openai image",
+			protocol: "openai",
+			payload:  `{"model":"capability-model","messages":[{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AA=="}}]}]}`,
+			want:     "model capability-model does not support images",
+		},
+		{
+			name:     "claude image in tool result",
+			protocol: "claude",
+			payload:  `{"model":"capability-model","max_tokens":10,"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AA=="}}]}]}]}`,
+			want:     "model capability-model does not support images",
+		},
+		{
+			name:     "gemini output limit",
+			protocol: "gemini",
+			payload:  `{"contents":[{"role":"user","parts":[{"text":"hi"}]}],"generationConfig":{"maxOutputTokens":8192}}`,
+			want:     "requested 8192 output tokens, but model capability-model supports at most 4096",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), tc.protocol, "capability-model", []byte(tc.payload), "")
+			if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+				t.Fatalf("errMsg = %+v, want 400", errMsg)
+			}
+			if !strings.Contains(errMsg.Error.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", errMsg.Error, tc.want)
+			}
+		})
+	}
+	if executor.executed != 0 {
+		t.Fatalf("executed = %d, want requests rejected before sending", executor.executed)
+	}
+
+	_, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "capability-model", []byte(cases[0].payload), "")
+	if errMsg := <-errChan; errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("stream errMsg = %+v, want 400", errMsg)
+	}
+}
+
+func TestValidateModelCapabilities_AllowsSupportedAndUndeclaredFeatures(t *testing.T) {
+	executor := &preflightTestExecutor{}
+	handler := newCapabilityTestHandler(t, executor)
+
+	payload := []byte(`{"model":"capability-model","max_tokens":1024,"tools":[{"type":"function","function":{"name":"f"}}],` +
+		`"response_format":{"type":"json_object"},"messages":[{"role":"user","content":"hi"}]}`)
+	if _, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "capability-model", payload, ""); errMsg != nil {
+		t.Fatalf("unexpected error %+v", errMsg)
+	}
+	if executor.executed != 1 {
+		t.Fatalf("executed = %d, want 1", executor.executed)
+	}
+}
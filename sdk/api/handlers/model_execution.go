@@ -22,6 +22,23 @@ type modelExecutionOptions struct {
 	SkipRouterPluginID      string
 	ForcedProvider          string
 	AuthSelectionModel      string
+
+	// failoverTarget marks an attempt started by a model failover rule.
+	failoverTarget bool
+	// failoverProvider restricts a failover attempt to one provider.
+	failoverProvider string
+	// routingProvider restricts execution to the provider named by the client's routing hint.
+	routingProvider string
+	// aliasProvider restricts execution to the provider named by the matching model alias rule.
+	aliasProvider string
+	// shadowChecked marks a request already considered for shadow traffic, including mirrored ones.
+	shadowChecked bool
+	// shadow is the request's pending mirror, launched once the request passes its checks.
+	shadow *shadowMirror
+	// transcriptChecked marks a stream already considered for transcript recording.
+	transcriptChecked bool
+	// experimentArm is the "<experiment>=<arm>" assignment of the request, if any.
+	experimentArm string
 }
 
 // ProtocolExecutionRequest describes a route-level model execution request with explicit protocols.
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// modelFailoverTargets returns the ordered failover targets configured for modelName.
+// Requests pinned to a provider and requests already running as a failover target are not rerouted.
+func (h *BaseAPIHandler) modelFailoverTargets(modelName string, execOptions modelExecutionOptions) []config.ModelFailoverTarget {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ModelFailover) == 0 {
+		return nil
+	}
+	if execOptions.failoverTarget || strings.TrimSpace(execOptions.ForcedProvider) != "" {
+		return nil
+	}
+	modelName = strings.TrimSpace(modelName)
+	if modelName == "" {
+		return nil
+	}
+	for _, rule := range h.Cfg.ModelFailover {
+		if strings.EqualFold(rule.Alias, modelName) {
+			return rule.Targets
+		}
+	}
+	return nil
+}
+
+// modelFailoverOptions returns the execution options used for one failover target.
+func modelFailoverOptions(execOptions modelExecutionOptions, target config.ModelFailoverTarget) modelExecutionOptions {
+	execOptions.failoverTarget = true
+	execOptions.failoverProvider = strings.ToLower(strings.TrimSpace(target.Provider))
+	execOptions.aliasProvider = ""
+	return execOptions
+}
+
+// modelFailoverContext drops a stream route prepared for the alias so each target is routed on its own.
+func modelFailoverContext(ctx context.Context) context.Context {
+	if _, prepared := preparedModelRouteFromContext(ctx); !prepared {
+		return ctx
+	}
+	return context.WithValue(ctx, preparedModelRouteContextKey{}, nil)
+}
+
+// modelFailoverEligible reports whether a target failure should hand the request to the next target.
+func modelFailoverEligible(errMsg *interfaces.ErrorMessage) bool {
+	if errMsg == nil {
+		return false
+	}
+	return errMsg.StatusCode == http.StatusTooManyRequests || errMsg.StatusCode >= http.StatusInternalServerError
+}
+
+func logModelFailover(alias string, target config.ModelFailoverTarget, errMsg *interfaces.ErrorMessage) {
+	provider := target.Provider
+	if provider == "" {
+		provider = "any"
+	}
+	log.Warnf("model failover: alias %s target %s (provider %s) failed with status %d, trying next target", alias, target.Model, provider, errMsg.StatusCode)
+}
+
+// filterProvidersByName narrows providers to the one named by a failover target or routing hint.
+// OpenAI-compatible providers may be named by their configured name.
+func filterProvidersByName(providers []string, modelName, provider string) ([]string, *interfaces.ErrorMessage) {
+	if provider == "" {
+		return providers, nil
+	}
+	compatKey := util.OpenAICompatibleProviderKey(provider)
+	for _, candidate := range providers {
+		if candidate == provider || candidate == compatKey {
+			return []string{candidate}, nil
+		}
+	}
+	return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("provider %s does not serve model %s", provider, modelName)}
+}
+
+// executeWithModelFailover runs a non-streaming request against each target in order until one
+// succeeds or fails with a status that is not eligible for failover.
+func executeWithModelFailover(ctx context.Context, alias string, targets []config.ModelFailoverTarget, execOptions modelExecutionOptions, run func(string, modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage)) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	var body []byte
+	var headers http.Header
+	var errMsg *interfaces.ErrorMessage
+	for i, target := range targets {
+		body, headers, errMsg = run(target.Model, modelFailoverOptions(execOptions, target))
+		if !modelFailoverEligible(errMsg) || i == len(targets)-1 || ctx.Err() != nil {
+			break
+		}
+		logModelFailover(alias, target, errMsg)
+	}
+	return body, headers, errMsg
+}
+
+// executeStreamWithModelFailover runs a streaming request against each target in order. A target
+// is abandoned only while nothing has been sent downstream: the first chunk or error of each
+// attempt is inspected before the stream is handed to the client. Every target runs under its
+// own context, cancelled once its stream is abandoned or done, and abandoned streams are
+// drained so their producers can finish.
+func executeStreamWithModelFailover(ctx context.Context, alias string, targets []config.ModelFailoverTarget, execOptions modelExecutionOptions, run func(context.Context, string, modelExecutionOptions) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage)) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	for i, target := range targets {
+		targetCtx, cancel := context.WithCancel(ctx)
+		dataChan, headers, errChan := run(targetCtx, target.Model, modelFailoverOptions(execOptions, target))
+		first, hasFirst, errMsg := peekModelStream(ctx, dataChan, errChan)
+		if errMsg != nil && modelFailoverEligible(errMsg) && i < len(targets)-1 && ctx.Err() == nil {
+			logModelFailover(alias, target, errMsg)
+			cancel()
+			go drainModelStream(dataChan, errChan)
+			continue
+		}
+		if errMsg != nil {
+			cancel()
+			go drainModelStream(dataChan, errChan)
+			errOut := make(chan *interfaces.ErrorMessage, 1)
+			errOut <- errMsg
+			close(errOut)
+			return nil, headers, errOut
+		}
+		dataOut, errOut := forwardModelStream(ctx, cancel, first, hasFirst, dataChan, errChan)
+		return dataOut, headers, errOut
+	}
+	errOut := make(chan *interfaces.ErrorMessage, 1)
+	close(errOut)
+	return nil, nil, errOut
+}
+
+// peekModelStream waits for the first chunk or error of a stream. Both channels may be nil.
+func peekModelStream(ctx context.Context, dataChan <-chan []byte, errChan <-chan *interfaces.ErrorMessage) ([]byte, bool, *interfaces.ErrorMessage) {
+	for dataChan != nil || errChan != nil {
+		select {
+		case <-ctx.Done():
+			return nil, false, nil
+		case chunk, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+				continue
+			}
+			return chunk, true, nil
+		case msg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			if msg != nil {
+				return nil, false, msg
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// forwardModelStream re-emits a peeked stream: the first chunk, the remaining chunks, then any
+// error. cancel stops the target once the stream is done; when the client goes away first, the
+// rest of the stream is drained.
+func forwardModelStream(ctx context.Context, cancel context.CancelFunc, first []byte, hasFirst bool, dataChan <-chan []byte, errChan <-chan *interfaces.ErrorMessage) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	dataOut := make(chan []byte)
+	errOut := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(dataOut)
+		defer close(errOut)
+		defer cancel()
+		send := func(chunk []byte) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case dataOut <- chunk:
+				return true
+			}
+		}
+		if hasFirst && !send(first) {
+			cancel()
+			drainModelStream(dataChan, errChan)
+			return
+		}
+		if dataChan != nil {
+			for chunk := range dataChan {
+				if !send(chunk) {
+					cancel()
+					drainModelStream(dataChan, errChan)
+					return
+				}
+			}
+		}
+		if errChan == nil {
+			return
+		}
+		for msg := range errChan {
+			if msg != nil {
+				errOut <- msg
+				break
+			}
+		}
+		drainModelStream(nil, errChan)
+	}()
+	return dataOut, errOut
+}
+
+// drainModelStream discards what is left of a stream until both channels are closed. Either
+// channel may be nil.
+func drainModelStream(dataChan <-chan []byte, errChan <-chan *interfaces.ErrorMessage) {
+	for dataChan != nil || errChan != nil {
+		select {
+		case _, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+			}
+		case _, ok := <-errChan:
+			if !ok {
+				errChan = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// failoverTestExecutor answers every request with a fixed status; 0 means success.
+type failoverTestExecutor struct {
+	provider string
+	status   int
+
+	mu     sync.Mutex
+	models []string
+}
+
+func (e *failoverTestExecutor) Identifier() string { return e.provider }
+
+func (e *failoverTestExecutor) record(model string) error {
+	e.mu.Lock()
+	e.models = append(e.models, model)
+	e.mu.Unlock()
+	if e.status != 0 {
+		return &coreauth.Error{Code: "upstream_error", Message: e.provider + " failed", HTTPStatus: e.status}
+	}
+	return nil
+}
+
+func (e *failoverTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if errRecord := e.record(req.Model); errRecord != nil {
+		return coreexecutor.Response{}, errRecord
+	}
+	return coreexecutor.Response{Payload: []byte(e.provider + ":" + req.Model)}, nil
+}
+
+func (e *failoverTestExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	chunks := make(chan coreexecutor.StreamChunk, 2)
+	if errRecord := e.record(req.Model); errRecord != nil {
+		chunks <- coreexecutor.StreamChunk{Err: errRecord}
+	} else {
+		chunks <- coreexecutor.StreamChunk{Payload: []byte(e.provider + ":")}
+		chunks <- coreexecutor.StreamChunk{Payload: []byte(req.Model)}
+	}
+	close(chunks)
+	return &coreexecutor.StreamResult{Headers: http.Header{"X-Provider": {e.provider}}, Chunks: chunks}, nil
+}
+
+func (e *failoverTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *failoverTestExecutor) CountTokens(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *failoverTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *failoverTestExecutor) Models() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.models...)
+}
+
+func newFailoverTestHandler(t *testing.T, executors []*failoverTestExecutor, models []string, rules []internalconfig.ModelFailoverRule) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	for i, executor := range executors {
+		manager.RegisterExecutor(executor)
+		auth := &coreauth.Auth{ID: t.Name() + "-" + executor.provider, Provider: executor.provider, Status: coreauth.StatusActive}
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("manager.Register(%s): %v", auth.ID, errRegister)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: models[i]}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	}
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{ModelFailover: rules}, manager)
+}
+
+func TestExecuteWithAuthManager_ModelFailoverSkipsFailingTarget(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude", status: http.StatusServiceUnavailable}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"failover-claude", "failover-gemini"}, []internalconfig.ModelFailoverRule{{
+		Alias: "smart-class",
+		Targets: []internalconfig.ModelFailoverTarget{
+			{Provider: "claude", Model: "failover-claude"},
+			{Provider: "gemini", Model: "failover-gemini"},
+		},
+	}})
+
+	body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "smart-class", []byte(`{"model":"smart-class"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(body) != "gemini:failover-gemini" {
+		t.Fatalf("body = %q, want reply from second target", body)
+	}
+	if got := claude.Models(); len(got) == 0 || got[0] != "failover-claude" {
+		t.Fatalf("claude attempts = %v, want first target tried", got)
+	}
+}
+
+func TestExecuteWithAuthManager_ModelFailoverStopsOnClientError(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude", status: http.StatusBadRequest}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"failover-claude", "failover-gemini"}, []internalconfig.ModelFailoverRule{{
+		Alias: "smart-class",
+		Targets: []internalconfig.ModelFailoverTarget{
+			{Provider: "claude", Model: "failover-claude"},
+			{Provider: "gemini", Model: "failover-gemini"},
+		},
+	}})
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "smart-class", []byte(`{"model":"smart-class"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("error = %+v, want the first target's 400", errMsg)
+	}
+	if got := gemini.Models(); len(got) != 0 {
+		t.Fatalf("gemini attempts = %v, want none after a client error", got)
+	}
+}
+
+func TestExecuteWithAuthManager_ModelFailoverHonorsTargetProvider(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude"}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"failover-shared", "failover-shared"}, []internalconfig.ModelFailoverRule{{
+		Alias:   "smart-class",
+		Targets: []internalconfig.ModelFailoverTarget{{Provider: "gemini", Model: "failover-shared"}},
+	}})
+
+	for i := 0; i < 3; i++ {
+		body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "smart-class", []byte(`{"model":"smart-class"}`), "")
+		if errMsg != nil {
+			t.Fatalf("unexpected error: %+v", errMsg)
+		}
+		if string(body) != "gemini:failover-shared" {
+			t.Fatalf("body = %q, want gemini only", body)
+		}
+	}
+	if got := claude.Models(); len(got) != 0 {
+		t.Fatalf("claude attempts = %v, want none", got)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_ModelFailoverBeforeFirstChunk(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude", status: http.StatusTooManyRequests}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"failover-claude", "failover-gemini"}, []internalconfig.ModelFailoverRule{{
+		Alias: "smart-class",
+		Targets: []internalconfig.ModelFailoverTarget{
+			{Provider: "claude", Model: "failover-claude"},
+			{Model: "failover-gemini"},
+		},
+	}})
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "smart-class", []byte(`{"model":"smart-class"}`), "")
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if string(got) != "gemini:failover-gemini" {
+		t.Fatalf("stream = %q, want second target's chunks in order", got)
+	}
+	if len(claude.Models()) == 0 {
+		t.Fatal("first target was not attempted")
+	}
+}
+
+func TestExecuteStreamWithAuthManager_ModelFailoverReturnsLastError(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude", status: http.StatusServiceUnavailable}
+	gemini := &failoverTestExecutor{provider: "gemini", status: http.StatusBadGateway}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"failover-claude", "failover-gemini"}, []internalconfig.ModelFailoverRule{{
+		Alias: "smart-class",
+		Targets: []internalconfig.ModelFailoverTarget{
+			{Provider: "claude", Model: "failover-claude"},
+			{Provider: "gemini", Model: "failover-gemini"},
+		},
+	}})
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "smart-class", []byte(`{"model":"smart-class"}`), "")
+	if dataChan != nil {
+		t.Fatal("unexpected stream data channel")
+	}
+	var last *int
+	for msg := range errChan {
+		if msg != nil {
+			status := msg.StatusCode
+			last = &status
+		}
+	}
+	if last == nil || *last != http.StatusBadGateway {
+		t.Fatalf("last error status = %v, want %d from the final target", last, http.StatusBadGateway)
+	}
+}
+
+// failoverTestStream is a target stream whose producer keeps sending chunks until its context is
+// cancelled, like an upstream that does not stop by itself. done is closed once it returned.
+func failoverTestStream(ctx context.Context, errMsg *interfaces.ErrorMessage) (<-chan []byte, <-chan *interfaces.ErrorMessage, <-chan struct{}) {
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(errChan)
+		defer close(dataChan)
+		if errMsg != nil {
+			select {
+			case errChan <- errMsg:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case dataChan <- []byte("chunk"):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return dataChan, errChan, done
+}
+
+func TestExecuteStreamWithModelFailover_CancelsAndDrainsAbandonedStreams(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	targets := []internalconfig.ModelFailoverTarget{{Model: "first"}, {Model: "second"}}
+	var done []<-chan struct{}
+	dataChan, _, _ := executeStreamWithModelFailover(ctx, "smart-class", targets, modelExecutionOptions{}, func(targetCtx context.Context, model string, _ modelExecutionOptions) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+		var errMsg *interfaces.ErrorMessage
+		if model == "first" {
+			errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable}
+		}
+		data, errs, finished := failoverTestStream(targetCtx, errMsg)
+		done = append(done, finished)
+		return data, nil, errs
+	})
+
+	if chunk := <-dataChan; string(chunk) != "chunk" {
+		t.Fatalf("first chunk = %q, want the second target's", chunk)
+	}
+	if len(done) != 2 {
+		t.Fatalf("targets run = %d, want 2", len(done))
+	}
+	select {
+	case <-done[0]:
+	case <-time.After(2 * time.Second):
+		t.Fatal("abandoned target was not cancelled")
+	}
+
+	cancel()
+	select {
+	case <-done[1]:
+	case <-time.After(2 * time.Second):
+		t.Fatal("forwarded target was not cancelled after the client went away")
+	}
+	for range dataChan {
+	}
+}
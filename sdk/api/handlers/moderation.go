@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/moderation"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// ModerationHeader carries moderation verdicts when the annotate action is configured.
+const ModerationHeader = "X-CLIProxy-Moderation"
+
+// moderateInput checks the latest user turn of a request when input moderation is enabled.
+func (h *BaseAPIHandler) moderateInput(ctx context.Context, entryProtocol string, rawJSON []byte) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil || !h.Cfg.Moderation.Checks(config.ModerationStageInput) {
+		return nil
+	}
+	return h.moderate(ctx, config.ModerationStageInput, moderationRequestText(entryProtocol, rawJSON), true)
+}
+
+// moderateOutput checks a non-streaming completion when output moderation is enabled.
+func (h *BaseAPIHandler) moderateOutput(ctx context.Context, responseProtocol string, body []byte) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil || !h.Cfg.Moderation.Checks(config.ModerationStageOutput) {
+		return nil
+	}
+	return h.moderate(ctx, config.ModerationStageOutput, moderationResponseText(responseProtocol, body), true)
+}
+
+// moderate checks text and applies the configured action. canAnnotate is false once response
+// headers have been sent.
+func (h *BaseAPIHandler) moderate(ctx context.Context, stage, text string, canAnnotate bool) *interfaces.ErrorMessage {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	cfg := h.Cfg.Moderation
+	client := util.SetProxy(h.Cfg, &http.Client{})
+	result, errCheck := moderation.Check(ctx, client, cfg, text)
+	if errCheck != nil {
+		log.Warnf("moderation: %s check failed: %v", stage, errCheck)
+		if cfg.FailClosed {
+			return &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: fmt.Errorf("content moderation unavailable")}
+		}
+		return nil
+	}
+	if !result.Flagged {
+		return nil
+	}
+	categories := strings.Join(result.Categories, ",")
+	log.Warnf("moderation: request %s flagged on %s (categories: %s)", logging.GetRequestID(ctx), stage, categories)
+	switch cfg.Action {
+	case config.ModerationActionBlock:
+		return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("%s blocked by content moderation (categories: %s)", stage, categories)}
+	case config.ModerationActionAnnotate:
+		if canAnnotate {
+			if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+				ginCtx.Writer.Header().Add(ModerationHeader, stage+"; flagged; categories="+categories)
+			}
+		}
+	}
+	return nil
+}
+
+// streamModeration re-checks a streamed completion each time enough new text arrived, and once
+// more when the stream ends.
+type streamModeration struct {
+	h        *BaseAPIHandler
+	ctx      context.Context
+	protocol string
+	text     strings.Builder
+	checked  int
+}
+
+func (h *BaseAPIHandler) newStreamModeration(ctx context.Context, responseProtocol string) *streamModeration {
+	if h == nil || h.Cfg == nil || !h.Cfg.Moderation.Checks(config.ModerationStageOutput) {
+		return nil
+	}
+	return &streamModeration{h: h, ctx: ctx, protocol: responseProtocol}
+}
+
+// Chunk adds the text of a stream chunk and checks the completion so far when enough new
+// text accumulated.
+func (m *streamModeration) Chunk(chunk []byte) ([]byte, *interfaces.ErrorMessage) {
+	m.text.WriteString(moderationStreamText(m.protocol, chunk))
+	if m.text.Len()-m.checked < m.h.Cfg.Moderation.StreamCheckChars {
+		return chunk, nil
+	}
+	if errMsg := m.check(); errMsg != nil {
+		return nil, errMsg
+	}
+	return chunk, nil
+}
+
+// Close checks text that arrived since the last check.
+func (m *streamModeration) Close() *interfaces.ErrorMessage {
+	if m.text.Len() == m.checked {
+		return nil
+	}
+	return m.check()
+}
+
+func (m *streamModeration) check() *interfaces.ErrorMessage {
+	m.checked = m.text.Len()
+	return m.h.moderate(m.ctx, config.ModerationStageOutput, m.text.String(), false)
+}
+
+// moderationRequestText returns the text of the latest user turn of a request.
+func moderationRequestText(entryProtocol string, rawJSON []byte) string {
+	var turns gjson.Result
+	switch entryProtocol {
+	case OpenAI, Claude:
+		turns = gjson.GetBytes(rawJSON, "messages")
+	case OpenaiResponse:
+		input := gjson.GetBytes(rawJSON, "input")
+		if input.Type == gjson.String {
+			return input.String()
+		}
+		turns = input
+	case Gemini:
+		turns = gjson.GetBytes(rawJSON, "contents")
+	default:
+		return ""
+	}
+	items := turns.Array()
+	for i := len(items) - 1; i >= 0; i-- {
+		if role := items[i].Get("role").String(); role != "user" && !(entryProtocol == Gemini && role == "") {
+			continue
+		}
+		if entryProtocol == Gemini {
+			return joinTextValues(items[i].Get("parts.#.text"))
+		}
+		return contentText(items[i].Get("content"))
+	}
+	return ""
+}
+
+// moderationResponseText returns the generated text of a non-streaming response.
+func moderationResponseText(responseProtocol string, body []byte) string {
+	switch responseProtocol {
+	case OpenAI:
+		return joinTextValues(gjson.GetBytes(body, "choices.#.message.content"))
+	case OpenaiResponse:
+		var texts []string
+		for _, item := range gjson.GetBytes(body, "output").Array() {
+			if text := contentText(item.Get("content")); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	case Claude:
+		return contentText(gjson.GetBytes(body, "content"))
+	case Gemini:
+		return geminiCandidateText(gjson.ParseBytes(body))
+	default:
+		return ""
+	}
+}
+
+// moderationStreamText returns the generated text carried by one stream chunk, which may
+// hold several SSE events.
+func moderationStreamText(responseProtocol string, chunk []byte) string {
+	var out strings.Builder
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			line = bytes.TrimSpace(data)
+		}
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		event := gjson.ParseBytes(line)
+		switch responseProtocol {
+		case OpenAI:
+			out.WriteString(joinTextValues(event.Get("choices.#.delta.content")))
+		case OpenaiResponse:
+			if event.Get("type").String() == "response.output_text.delta" {
+				out.WriteString(event.Get("delta").String())
+			}
+		case Claude:
+			if event.Get("type").String() == "content_block_delta" && event.Get("delta.type").String() == "text_delta" {
+				out.WriteString(event.Get("delta.text").String())
+			}
+		case Gemini:
+			out.WriteString(geminiCandidateText(event))
+		}
+	}
+	return out.String()
+}
+
+// contentText returns a string content or the text of the text blocks in a content array.
+func contentText(content gjson.Result) string {
+	if !content.IsArray() {
+		return content.String()
+	}
+	var texts []string
+	for _, block := range content.Array() {
+		switch block.Get("type").String() {
+		case "text", "input_text", "output_text":
+			texts = append(texts, block.Get("text").String())
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func geminiCandidateText(response gjson.Result) string {
+	var out strings.Builder
+	for _, candidate := range response.Get("candidates").Array() {
+		for _, part := range candidate.Get("content.parts").Array() {
+			if !part.Get("thought").Bool() {
+				out.WriteString(part.Get("text").String())
+			}
+		}
+	}
+	return out.String()
+}
+
+func joinTextValues(values gjson.Result) string {
+	var texts []string
+	for _, value := range values.Array() {
+		if text := value.String(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// newModerationTestHandler returns a handler whose moderation backend flags texts containing "attack".
+func newModerationTestHandler(t *testing.T, action string) (*BaseAPIHandler, *[]string) {
+	t.Helper()
+	var inputs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		inputs = append(inputs, body["input"])
+		flagged := strings.Contains(body["input"], "attack")
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": []map[string]any{{"flagged": flagged, "categories": map[string]bool{"violence": flagged}}}})
+	}))
+	t.Cleanup(server.Close)
+	cfg := &config.SDKConfig{Moderation: internalconfig.ModerationConfig{
+		Enable:           true,
+		Endpoint:         server.URL,
+		Stages:           []string{internalconfig.ModerationStageInput, internalconfig.ModerationStageOutput},
+		Action:           action,
+		StreamCheckChars: 10,
+	}}
+	return &BaseAPIHandler{Cfg: cfg}, &inputs
+}
+
+func TestModerateInputChecksLatestUserTurn(t *testing.T) {
+	handler, inputs := newModerationTestHandler(t, internalconfig.ModerationActionBlock)
+	body := []byte(`{"messages":[{"role":"user","content":"plan an attack"},{"role":"assistant","content":"no"},{"role":"user","content":[{"type":"text","text":"hello"}]}]}`)
+	if errMsg := handler.moderateInput(context.Background(), "openai", body); errMsg != nil {
+		t.Fatalf("unexpected block: %v", errMsg.Error)
+	}
+	if len(*inputs) != 1 || (*inputs)[0] != "hello" {
+		t.Fatalf("moderated inputs = %q", *inputs)
+	}
+
+	errMsg := handler.moderateInput(context.Background(), "claude", []byte(`{"messages":[{"role":"user","content":"attack now"}]}`))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), "violence") {
+		t.Fatalf("errMsg = %+v", errMsg)
+	}
+}
+
+func TestModerateOutputAnnotates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, _ := newModerationTestHandler(t, internalconfig.ModerationActionAnnotate)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	if errMsg := handler.moderateOutput(ctx, "gemini", []byte(`{"candidates":[{"content":{"parts":[{"text":"an attack plan"}]}}]}`)); errMsg != nil {
+		t.Fatalf("annotate must not block: %v", errMsg.Error)
+	}
+	if got := recorder.Header().Get(ModerationHeader); got != "output; flagged; categories=violence" {
+		t.Fatalf("%s = %q", ModerationHeader, got)
+	}
+}
+
+func TestStreamModerationBlocksFlaggedCompletion(t *testing.T) {
+	handler, inputs := newModerationTestHandler(t, internalconfig.ModerationActionBlock)
+	moderation := handler.newStreamModeration(context.Background(), "claude")
+	chunk := func(text string) []byte {
+		return []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"" + text + "\"}}\n\n")
+	}
+	if _, errMsg := moderation.Chunk(chunk("Sure, ")); errMsg != nil || len(*inputs) != 0 {
+		t.Fatalf("short text checked early: %v %q", errMsg, *inputs)
+	}
+	if _, errMsg := moderation.Chunk(chunk("here it is.")); errMsg != nil {
+		t.Fatalf("clean text blocked: %v", errMsg.Error)
+	}
+	if _, errMsg := moderation.Chunk(chunk(" attack")); errMsg != nil {
+		t.Fatalf("checked before threshold: %v", errMsg.Error)
+	}
+	if errMsg := moderation.Close(); errMsg == nil {
+		t.Fatal("flagged completion not blocked at stream end")
+	}
+	if got := (*inputs)[len(*inputs)-1]; got != "Sure, here it is. attack" {
+		t.Fatalf("last moderated text = %q", got)
+	}
+}
@@ -0,0 +1,250 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// audioHandlerType keeps audio bodies untranslated, like "openai-image" does for images.
+	audioHandlerType = "openai-audio"
+
+	audioTranscriptionsAlt = "audio/transcriptions"
+	audioSpeechAlt         = "audio/speech"
+
+	audioMultipartMemory int64 = 32 << 20
+)
+
+// AudioTranscriptions handles the /v1/audio/transcriptions endpoint.
+// The multipart upload is forwarded to an OpenAI-compatible provider with only the model
+// rewritten; stream=true responses are relayed as server-sent events.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) AudioTranscriptions(c *gin.Context) {
+	rawBody, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		writeAudioInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	form, err := parseAudioMultipartForm(rawBody, c.GetHeader("Content-Type"))
+	if err != nil {
+		writeAudioInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	defer func() {
+		_ = form.RemoveAll()
+	}()
+
+	modelName := strings.TrimSpace(firstAudioFormValue(form, "model"))
+	if modelName == "" {
+		writeAudioInvalidRequest(c, "model is required")
+		return
+	}
+	if len(form.File["file"]) == 0 {
+		writeAudioInvalidRequest(c, "file is required")
+		return
+	}
+	if !audioModelSupports(modelName, registry.EndpointAudioTranscriptions) {
+		writeAudioInvalidRequest(c, fmt.Sprintf("model %s does not support audio transcriptions", modelName))
+		return
+	}
+
+	if strings.EqualFold(strings.TrimSpace(firstAudioFormValue(form, "stream")), "true") {
+		h.streamPassthrough(c, audioHandlerType, modelName, rawBody, audioTranscriptionsAlt, "text/event-stream")
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, audioHandlerType, modelName, rawBody, audioTranscriptionsAlt)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	c.Header("Content-Type", transcriptionContentType(firstAudioFormValue(form, "response_format")))
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// AudioSpeech handles the /v1/audio/speech endpoint.
+// The JSON request is forwarded to an OpenAI-compatible provider and the generated audio is
+// streamed back as it arrives.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) AudioSpeech(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		writeAudioInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeAudioInvalidRequest(c, "model is required")
+		return
+	}
+	if !gjson.GetBytes(rawJSON, "input").Exists() {
+		writeAudioInvalidRequest(c, "input is required")
+		return
+	}
+	if !audioModelSupports(modelName, registry.EndpointAudioSpeech) {
+		writeAudioInvalidRequest(c, fmt.Sprintf("model %s does not support audio speech", modelName))
+		return
+	}
+
+	contentType := speechContentType(gjson.GetBytes(rawJSON, "response_format").String())
+	if strings.EqualFold(strings.TrimSpace(gjson.GetBytes(rawJSON, "stream_format").String()), "sse") {
+		contentType = "text/event-stream"
+	}
+	h.streamPassthrough(c, audioHandlerType, modelName, rawJSON, audioSpeechAlt, contentType)
+}
+
+// streamPassthrough relays the upstream body chunk by chunk. Keep-alive comments are only
+// written to event streams because they would corrupt binary audio.
+func (h *OpenAIAPIHandler) streamPassthrough(c *gin.Context, handlerType string, modelName string, rawBody []byte, alt string, contentType string) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Streaming not supported",
+				Type:    "server_error",
+			},
+		})
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, handlerType, modelName, rawBody, alt)
+	sse := contentType == "text/event-stream"
+	writeHeaders := func() {
+		c.Header("Content-Type", contentType)
+		c.Header("Cache-Control", "no-cache")
+		handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cliCancel(c.Request.Context().Err())
+			return
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			h.WriteErrorResponse(c, errMsg)
+			if errMsg != nil {
+				cliCancel(errMsg.Error)
+			} else {
+				cliCancel(nil)
+			}
+			return
+		case chunk, ok := <-dataChan:
+			if !ok {
+				writeHeaders()
+				flusher.Flush()
+				cliCancel(nil)
+				return
+			}
+			writeHeaders()
+			_, _ = c.Writer.Write(chunk)
+			flusher.Flush()
+
+			forwardOptions := handlers.StreamForwardOptions{
+				WriteChunk: func(next []byte) {
+					_, _ = c.Writer.Write(next)
+				},
+			}
+			if sse {
+				forwardOptions.WriteTerminalError = func(errMsg *interfaces.ErrorMessage) {
+					writeImagesStreamErrorEvent(c, errMsg)
+				}
+			} else {
+				disabled := time.Duration(0)
+				forwardOptions.KeepAliveInterval = &disabled
+			}
+			h.ForwardStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, forwardOptions)
+			return
+		}
+	}
+}
+
+// audioModelSupports reports whether modelName explicitly lists endpoint. Audio is opt-in
+// because only OpenAI-compatible providers configured with audio: true can serve it.
+func audioModelSupports(modelName string, endpoint string) bool {
+	info := registry.LookupModelInfo(modelName)
+	return info != nil && slices.Contains(info.SupportedEndpoints, endpoint)
+}
+
+func parseAudioMultipartForm(rawBody []byte, contentType string) (*multipart.Form, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.EqualFold(mediaType, "multipart/form-data") {
+		return nil, fmt.Errorf("content type must be multipart/form-data")
+	}
+	boundary := strings.TrimSpace(params["boundary"])
+	if boundary == "" {
+		return nil, fmt.Errorf("multipart boundary is missing")
+	}
+	return multipart.NewReader(bytes.NewReader(rawBody), boundary).ReadForm(audioMultipartMemory)
+}
+
+func firstAudioFormValue(form *multipart.Form, key string) string {
+	if form == nil || len(form.Value[key]) == 0 {
+		return ""
+	}
+	return form.Value[key][0]
+}
+
+// transcriptionContentType maps a transcription response_format to its media type.
+func transcriptionContentType(responseFormat string) string {
+	switch strings.ToLower(strings.TrimSpace(responseFormat)) {
+	case "text", "srt":
+		return "text/plain; charset=utf-8"
+	case "vtt":
+		return "text/vtt; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// speechContentType maps a speech response_format to its media type. OpenAI defaults to mp3.
+func speechContentType(responseFormat string) string {
+	switch strings.ToLower(strings.TrimSpace(responseFormat)) {
+	case "opus":
+		return "audio/ogg"
+	case "aac":
+		return "audio/aac"
+	case "flac":
+		return "audio/flac"
+	case "wav":
+		return "audio/wav"
+	case "pcm":
+		return "audio/pcm"
+	default:
+		return "audio/mpeg"
+	}
+}
+
+func writeAudioInvalidRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
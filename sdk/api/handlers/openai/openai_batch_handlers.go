@@ -0,0 +1,331 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	responsesconverter "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/openai/responses"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 100
+	// batchMultipartOverhead allows for multipart framing on top of the file size limit.
+	batchMultipartOverhead int64 = 1 << 20
+)
+
+// OpenAIBatchAPIHandler serves the emulated OpenAI Files and Batches endpoints.
+// Batch requests run through the same execution pipeline as /v1/chat/completions,
+// /v1/responses and /v1/embeddings; objects are scoped to the client API key.
+type OpenAIBatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	manager *batch.Manager
+}
+
+// NewOpenAIBatchAPIHandler creates the batch handlers and installs their request executor
+// in manager.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//   - manager: The batch manager storing files and running batches
+//
+// Returns:
+//   - *OpenAIBatchAPIHandler: A new batch API handlers instance
+func NewOpenAIBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, manager *batch.Manager) *OpenAIBatchAPIHandler {
+	h := &OpenAIBatchAPIHandler{BaseAPIHandler: apiHandlers, manager: manager}
+	manager.SetExecutor(h.executeBatchRequest)
+	return h
+}
+
+// UploadFile handles POST /v1/files. Only purpose "batch" is supported.
+func (h *OpenAIBatchAPIHandler) UploadFile(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.manager.MaxFileBytes()+batchMultipartOverhead)
+	header, errForm := c.FormFile("file")
+	if errForm != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("file is required: %v", errForm))
+		return
+	}
+	file, errOpen := header.Open()
+	if errOpen != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", errOpen))
+		return
+	}
+	content, errRead := io.ReadAll(file)
+	_ = file.Close()
+	if errRead != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", errRead))
+		return
+	}
+	meta, errCreate := h.manager.CreateFile(batchOwner(c), header.Filename, strings.TrimSpace(c.PostForm("purpose")), content)
+	if errCreate != nil {
+		writeBatchManagerError(c, errCreate)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// ListFiles handles GET /v1/files.
+func (h *OpenAIBatchAPIHandler) ListFiles(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	files, errList := h.manager.Files(batchOwner(c), strings.TrimSpace(c.Query("purpose")))
+	if errList != nil {
+		writeBatchManagerError(c, errList)
+		return
+	}
+	if files == nil {
+		files = []batch.File{}
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": files, "has_more": false})
+}
+
+// RetrieveFile handles GET /v1/files/:file_id.
+func (h *OpenAIBatchAPIHandler) RetrieveFile(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	meta, errFile := h.manager.File(batchOwner(c), c.Param("file_id"))
+	if errFile != nil {
+		writeBatchManagerError(c, errFile)
+		return
+	}
+	c.JSON(http.StatusOK, meta)
+}
+
+// FileContent handles GET /v1/files/:file_id/content.
+func (h *OpenAIBatchAPIHandler) FileContent(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	content, errContent := h.manager.FileContent(batchOwner(c), c.Param("file_id"))
+	if errContent != nil {
+		writeBatchManagerError(c, errContent)
+		return
+	}
+	c.Data(http.StatusOK, "application/jsonl", content)
+}
+
+// DeleteFile handles DELETE /v1/files/:file_id.
+func (h *OpenAIBatchAPIHandler) DeleteFile(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	id := c.Param("file_id")
+	if errDelete := h.manager.DeleteFile(batchOwner(c), id); errDelete != nil {
+		writeBatchManagerError(c, errDelete)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// CreateBatch handles POST /v1/batches.
+func (h *OpenAIBatchAPIHandler) CreateBatch(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	rawJSON, errRead := handlers.ReadRequestBody(c)
+	if errRead != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", errRead))
+		return
+	}
+	var req batch.CreateRequest
+	if errUnmarshal := json.Unmarshal(rawJSON, &req); errUnmarshal != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", errUnmarshal))
+		return
+	}
+	access, _ := c.Get("accessMetadata")
+	accessMetadata, _ := access.(map[string]string)
+	created, errCreate := h.manager.Create(batchOwner(c), accessMetadata, req)
+	if errCreate != nil {
+		writeBatchManagerError(c, errCreate)
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// RetrieveBatch handles GET /v1/batches/:batch_id.
+func (h *OpenAIBatchAPIHandler) RetrieveBatch(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	b, errBatch := h.manager.Batch(batchOwner(c), c.Param("batch_id"))
+	if errBatch != nil {
+		writeBatchManagerError(c, errBatch)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// CancelBatch handles POST /v1/batches/:batch_id/cancel.
+func (h *OpenAIBatchAPIHandler) CancelBatch(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	b, errCancel := h.manager.Cancel(batchOwner(c), c.Param("batch_id"))
+	if errCancel != nil {
+		writeBatchManagerError(c, errCancel)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// ListBatches handles GET /v1/batches with the after and limit pagination parameters.
+func (h *OpenAIBatchAPIHandler) ListBatches(c *gin.Context) {
+	if !h.requireEnabled(c) {
+		return
+	}
+	batches, errList := h.manager.Batches(batchOwner(c))
+	if errList != nil {
+		writeBatchManagerError(c, errList)
+		return
+	}
+	if after := strings.TrimSpace(c.Query("after")); after != "" {
+		for i, b := range batches {
+			if b.ID == after {
+				batches = batches[i+1:]
+				break
+			}
+		}
+	}
+	limit := defaultBatchListLimit
+	if parsed, errParse := strconv.Atoi(c.Query("limit")); errParse == nil && parsed > 0 {
+		limit = min(parsed, maxBatchListLimit)
+	}
+	hasMore := len(batches) > limit
+	if hasMore {
+		batches = batches[:limit]
+	}
+	response := gin.H{"object": "list", "data": batches, "has_more": hasMore}
+	if len(batches) > 0 {
+		response["first_id"] = batches[0].ID
+		response["last_id"] = batches[len(batches)-1].ID
+	} else {
+		response["data"] = []batch.Batch{}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// executeBatchRequest runs one batch line as a non-streaming request of its endpoint. The line is
+// admitted like an HTTP request of the owner in the batch queue class; rate-limited lines wait
+// for the limit instead of failing.
+func (h *OpenAIBatchAPIHandler) executeBatchRequest(ctx context.Context, owner string, access map[string]string, endpoint string, body []byte) (int, []byte) {
+	body, _ = sjson.DeleteBytes(body, "stream")
+	modelName := gjson.GetBytes(body, "model").String()
+	handlerType, alt := OpenAI, ""
+	switch endpoint {
+	case batch.EndpointResponses:
+		handlerType = OpenaiResponse
+	case batch.EndpointEmbeddings:
+		alt = embeddingsAlt
+	default:
+		if shouldTreatAsResponsesFormat(body) {
+			body = responsesconverter.ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName, body, false)
+		}
+	}
+
+	ctx = batchRequestContext(ctx, owner, access, endpoint)
+	release, errMsg := h.admitBatchRequest(ctx)
+	if errMsg != nil {
+		return batchErrorResult(errMsg)
+	}
+	defer release()
+	resp, _, errMsg := h.ExecuteWithAuthManager(ctx, handlerType, modelName, body, alt)
+	if errMsg != nil {
+		return batchErrorResult(errMsg)
+	}
+	return http.StatusOK, resp
+}
+
+// admitBatchRequest admits a batch line, waiting out rate-limit rejections until ctx ends.
+func (h *OpenAIBatchAPIHandler) admitBatchRequest(ctx context.Context) (func(), *interfaces.ErrorMessage) {
+	for {
+		release, errMsg := h.Admit(ctx)
+		var errLimited *handlers.RateLimitError
+		if errMsg == nil || !errors.As(errMsg.Error, &errLimited) {
+			return release, errMsg
+		}
+		timer := time.NewTimer(max(errLimited.RetryAfter, 10*time.Millisecond))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errLimited}
+		case <-timer.C:
+		}
+	}
+}
+
+func batchErrorResult(errMsg *interfaces.ErrorMessage) (int, []byte) {
+	status := errMsg.StatusCode
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	message := http.StatusText(status)
+	if errMsg.Error != nil {
+		message = errMsg.Error.Error()
+	}
+	return status, handlers.BuildErrorResponseBody(status, message)
+}
+
+// batchRequestContext attaches a detached Gin context carrying the batch owner's API key and
+// access metadata, so key scopes and tenant rules apply to batch lines as they would to the
+// owner's HTTP requests and usage accounting attributes them to the owner. Quotas, budgets,
+// rate limits and the request queue are applied separately by admitBatchRequest.
+func batchRequestContext(ctx context.Context, owner string, access map[string]string, endpoint string) context.Context {
+	keys := map[string]any{"userApiKey": owner, handlers.RequestPriorityGinKey: config.RequestPriorityBatch}
+	if len(access) > 0 {
+		keys["accessMetadata"] = access
+	}
+	return handlers.DetachedContext(ctx, http.MethodPost, endpoint, nil, keys)
+}
+
+func (h *OpenAIBatchAPIHandler) requireEnabled(c *gin.Context) bool {
+	if h.manager.Enabled() {
+		return true
+	}
+	writeBatchError(c, http.StatusNotFound, "invalid_request_error", "batch API is disabled")
+	return false
+}
+
+func batchOwner(c *gin.Context) string {
+	return strings.TrimSpace(c.GetString("userApiKey"))
+}
+
+func writeBatchManagerError(c *gin.Context, err error) {
+	var validation *batch.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", validation.Message)
+	case errors.Is(err, batch.ErrNotFound):
+		writeBatchError(c, http.StatusNotFound, "invalid_request_error", "No such object")
+	default:
+		writeBatchError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+}
+
+func writeBatchError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestExecuteBatchRequestAdmitsLineUnderOwnerScope(t *testing.T) {
+	executor := &grpcCaptureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "batch-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("Register auth: %v", errRegister)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "grpc-test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	attempts := 0
+	base.SetAdmission(func(ginCtx *gin.Context) (func(), *interfaces.ErrorMessage) {
+		attempts++
+		if got := ginCtx.GetString(handlers.RequestPriorityGinKey); got != "batch" {
+			t.Errorf("priority = %q, want batch", got)
+		}
+		if attempts == 1 {
+			errLimited := &handlers.RateLimitError{RetryAfter: time.Millisecond}
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errLimited}
+		}
+		return func() {}, nil
+	})
+	h := NewOpenAIBatchAPIHandler(base, batch.NewManager())
+	body := []byte(`{"model":"grpc-test-model","messages":[]}`)
+
+	status, resp := h.executeBatchRequest(context.Background(), "key-a", nil, batch.EndpointChatCompletions, body)
+	if status != http.StatusOK || attempts != 2 || len(executor.payloads) != 1 {
+		t.Fatalf("line = (%d, %s) after %d admissions, want it to wait out the rate limit and run", status, resp, attempts)
+	}
+
+	readOnly := map[string]string{sdkaccess.MetadataReadOnly: "true"}
+	if status, _ := h.executeBatchRequest(context.Background(), "key-a", readOnly, batch.EndpointChatCompletions, body); status != http.StatusForbidden {
+		t.Fatalf("read-only line status = %d, want 403", status)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatal("read-only line reached the executor")
+	}
+}
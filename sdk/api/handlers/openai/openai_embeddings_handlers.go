@@ -0,0 +1,64 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// embeddingsAlt marks embeddings requests for executors, like "responses/compact" does for compaction.
+const embeddingsAlt = "embeddings"
+
+// Embeddings handles the /v1/embeddings endpoint.
+// Gemini API keys serve it through batchEmbedContents; OpenAI-compatible providers
+// receive the request unchanged.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) Embeddings(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		writeEmbeddingsInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeEmbeddingsInvalidRequest(c, "model is required")
+		return
+	}
+	if !gjson.GetBytes(rawJSON, "input").Exists() {
+		writeEmbeddingsInvalidRequest(c, "input is required")
+		return
+	}
+	if info := registry.LookupModelInfo(modelName); !info.SupportsEndpoint(registry.EndpointEmbeddings) {
+		writeEmbeddingsInvalidRequest(c, fmt.Sprintf("model %s does not support embeddings", modelName))
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, embeddingsAlt)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func writeEmbeddingsInvalidRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// fimCompletionsAlt marks fill-in-the-middle requests for executors.
+const fimCompletionsAlt = "fim/completions"
+
+// FIMCompletions handles the /v1/fim/completions endpoint.
+// Fill-in-the-middle requests (prompt plus optional suffix) are forwarded unchanged to models
+// that list the endpoint, such as Mistral Codestral; stream=true responses are relayed as
+// server-sent events.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) FIMCompletions(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		writeFIMInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeFIMInvalidRequest(c, "model is required")
+		return
+	}
+	if gjson.GetBytes(rawJSON, "prompt").Type != gjson.String {
+		writeFIMInvalidRequest(c, "prompt is required")
+		return
+	}
+	if info := registry.LookupModelInfo(modelName); info == nil || !slices.Contains(info.SupportedEndpoints, registry.EndpointFIMCompletions) {
+		writeFIMInvalidRequest(c, fmt.Sprintf("model %s does not support fim completions", modelName))
+		return
+	}
+
+	if gjson.GetBytes(rawJSON, "stream").Bool() {
+		h.streamPassthrough(c, h.HandlerType(), modelName, rawJSON, fimCompletionsAlt, "text/event-stream")
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, fimCompletionsAlt)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func writeFIMInvalidRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package openai
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestFIMCompletionsRejectsModelsWithoutFIMEndpoint(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	clientID := "test-fim-model-validation"
+	modelRegistry.RegisterClient(clientID, "mistral", []*registry.ModelInfo{
+		{ID: "mistral-large-latest", Object: "model", OwnedBy: "mistralai", Type: "mistral"},
+	})
+	t.Cleanup(func() {
+		modelRegistry.UnregisterClient(clientID)
+	})
+	handler := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+
+	cases := map[string]string{
+		`{"prompt":"def f():"}`:                                "model is required",
+		`{"model":"mistral-large-latest"}`:                     "prompt is required",
+		`{"model":"mistral-large-latest","prompt":"def f():"}`: "model mistral-large-latest does not support fim completions",
+	}
+	for body, want := range cases {
+		resp := performImagesEndpointRequest(t, "/v1/fim/completions", "application/json", strings.NewReader(body), handler.FIMCompletions)
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", body, resp.Code)
+		}
+		if got := gjson.GetBytes(resp.Body.Bytes(), "error.message").String(); got != want {
+			t.Fatalf("%s: error message = %q, want %q", body, got, want)
+		}
+	}
+}
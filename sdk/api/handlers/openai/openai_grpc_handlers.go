@@ -0,0 +1,288 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	responsesconverter "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/openai/responses"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// ChatCompletionsServiceName is the fully qualified gRPC service mirroring /v1/chat/completions.
+	ChatCompletionsServiceName = "cliproxy.v1.ChatCompletions"
+	// ChatCompletionsCreateMethod is the unary method returning a chat completion.
+	ChatCompletionsCreateMethod = "/" + ChatCompletionsServiceName + "/Create"
+	// ChatCompletionsCreateStreamMethod is the server streaming method returning chat completion chunks.
+	ChatCompletionsCreateStreamMethod = "/" + ChatCompletionsServiceName + "/CreateStream"
+
+	grpcChatCompletionsEndpoint = "/v1/chat/completions"
+)
+
+// ChatCompletionsServer is the server API of the cliproxy.v1.ChatCompletions service.
+// Requests and responses are the /v1/chat/completions JSON bodies carried as
+// google.protobuf.Struct, so clients need no generated message types.
+type ChatCompletionsServer interface {
+	Create(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	CreateStream(*structpb.Struct, grpc.ServerStreamingServer[structpb.Struct]) error
+}
+
+// ChatCompletionsServiceDesc describes the cliproxy.v1.ChatCompletions service for grpc.Server.RegisterService.
+var ChatCompletionsServiceDesc = grpc.ServiceDesc{
+	ServiceName: ChatCompletionsServiceName,
+	HandlerType: (*ChatCompletionsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Create", Handler: chatCompletionsCreateHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "CreateStream", Handler: chatCompletionsCreateStreamHandler, ServerStreams: true},
+	},
+	Metadata: "cliproxy/v1/chat_completions.proto",
+}
+
+// OpenAIGRPCHandler serves chat completions over gRPC through the same execution pipeline
+// as the HTTP handlers. Clients authenticate with the API key in the authorization,
+// x-api-key or x-goog-api-key metadata.
+type OpenAIGRPCHandler struct {
+	*handlers.BaseAPIHandler
+	access *sdkaccess.Manager
+}
+
+// NewOpenAIGRPCHandler creates a gRPC chat completions handler.
+func NewOpenAIGRPCHandler(apiHandlers *handlers.BaseAPIHandler, access *sdkaccess.Manager) *OpenAIGRPCHandler {
+	return &OpenAIGRPCHandler{BaseAPIHandler: apiHandlers, access: access}
+}
+
+// Create handles the unary Create method.
+func (h *OpenAIGRPCHandler) Create(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	ctx, rawJSON, errPrepare := h.prepareGRPCRequest(ctx, req, false)
+	if errPrepare != nil {
+		return nil, errPrepare
+	}
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if ext := handlers.RequestExtensionsFromContext(ctx); ext != nil && ext.DryRun {
+		return grpcStructFromValue(h.PlanExecution(modelName, ext))
+	}
+	release, errAdmit := h.Admit(ctx)
+	if errAdmit != nil {
+		return nil, grpcStatusFromErrorMessage(errAdmit)
+	}
+	defer release()
+	resp, _, errMsg := h.ExecuteWithAuthManager(ctx, OpenAI, modelName, rawJSON, "")
+	if errMsg != nil {
+		return nil, grpcStatusFromErrorMessage(errMsg)
+	}
+	out := &structpb.Struct{}
+	if errUnmarshal := protojson.Unmarshal(resp, out); errUnmarshal != nil {
+		return nil, status.Errorf(codes.Internal, "invalid upstream response: %v", errUnmarshal)
+	}
+	return out, nil
+}
+
+// CreateStream handles the server streaming CreateStream method, sending one message per chunk.
+func (h *OpenAIGRPCHandler) CreateStream(req *structpb.Struct, stream grpc.ServerStreamingServer[structpb.Struct]) error {
+	ctx, rawJSON, errPrepare := h.prepareGRPCRequest(stream.Context(), req, true)
+	if errPrepare != nil {
+		return errPrepare
+	}
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if ext := handlers.RequestExtensionsFromContext(ctx); ext != nil && ext.DryRun {
+		plan, errPlan := grpcStructFromValue(h.PlanExecution(modelName, ext))
+		if errPlan != nil {
+			return errPlan
+		}
+		return stream.Send(plan)
+	}
+	release, errAdmit := h.Admit(ctx)
+	if errAdmit != nil {
+		return grpcStatusFromErrorMessage(errAdmit)
+	}
+	defer release()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	dataChan, _, errChan := h.ExecuteStreamWithAuthManager(ctx, OpenAI, modelName, rawJSON, "")
+	for dataChan != nil || errChan != nil {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case errMsg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			if errMsg != nil {
+				return grpcStatusFromErrorMessage(errMsg)
+			}
+		case chunk, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+				continue
+			}
+			chunk = []byte(strings.TrimSpace(string(chunk)))
+			if !gjson.ValidBytes(chunk) {
+				continue
+			}
+			out := &structpb.Struct{}
+			if errUnmarshal := protojson.Unmarshal(chunk, out); errUnmarshal != nil {
+				log.Debugf("grpc: skipping non-object stream chunk: %v", errUnmarshal)
+				continue
+			}
+			if errSend := stream.Send(out); errSend != nil {
+				return errSend
+			}
+		}
+	}
+	return nil
+}
+
+// prepareGRPCRequest authenticates the caller from the request metadata, encodes req as a chat
+// completions body with stream forced to the method's mode and attaches a detached Gin context
+// carrying the client API key, its access metadata and the request extensions, so the pipeline
+// treats the request as it would over HTTP. The caller must still admit the request with Admit.
+func (h *OpenAIGRPCHandler) prepareGRPCRequest(ctx context.Context, req *structpb.Struct, stream bool) (context.Context, []byte, error) {
+	httpReq, errRequest := http.NewRequestWithContext(ctx, http.MethodPost, grpcChatCompletionsEndpoint, nil)
+	if errRequest != nil {
+		return nil, nil, status.Error(codes.Internal, errRequest.Error())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			if strings.HasPrefix(key, ":") || strings.HasSuffix(key, "-bin") {
+				continue
+			}
+			for _, value := range values {
+				httpReq.Header.Add(key, value)
+			}
+		}
+	}
+	keys := make(map[string]any)
+	if h.access != nil {
+		result, errAuth := h.access.Authenticate(ctx, httpReq)
+		if errAuth != nil {
+			return nil, nil, status.Error(grpcCodeFromHTTPStatus(errAuth.HTTPStatusCode()), errAuth.Message)
+		}
+		if result != nil {
+			keys["userApiKey"] = result.Principal
+			keys["accessProvider"] = result.Provider
+			if len(result.Metadata) > 0 {
+				keys["accessMetadata"] = result.Metadata
+			}
+		}
+	}
+
+	rawJSON, errMarshal := protojson.Marshal(req)
+	if errMarshal != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", errMarshal)
+	}
+	ext, rawJSON, errExtract := handlers.ExtractRequestExtensions(rawJSON)
+	if errExtract != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, errExtract.Error())
+	}
+	if ext != nil {
+		keys[handlers.RequestExtensionsGinKey] = ext
+		if ext.Cache.SessionID != "" {
+			httpReq.Header.Set(handlers.SessionIDHeader, ext.Cache.SessionID)
+		}
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String()) == "" {
+		return nil, nil, status.Error(codes.InvalidArgument, "model is required")
+	}
+	if shouldTreatAsResponsesFormat(rawJSON) {
+		rawJSON = responsesconverter.ConvertOpenAIResponsesRequestToOpenAIChatCompletions(gjson.GetBytes(rawJSON, "model").String(), rawJSON, stream)
+	}
+	if updated, errSet := sjson.SetBytes(rawJSON, "stream", stream); errSet == nil {
+		rawJSON = updated
+	}
+
+	return handlers.DetachedContext(ctx, http.MethodPost, grpcChatCompletionsEndpoint, httpReq.Header, keys), rawJSON, nil
+}
+
+func grpcStructFromValue(value any) (*structpb.Struct, error) {
+	raw, errMarshal := json.Marshal(value)
+	if errMarshal != nil {
+		return nil, status.Error(codes.Internal, errMarshal.Error())
+	}
+	out := &structpb.Struct{}
+	if errUnmarshal := protojson.Unmarshal(raw, out); errUnmarshal != nil {
+		return nil, status.Error(codes.Internal, errUnmarshal.Error())
+	}
+	return out, nil
+}
+
+// grpcStatusFromErrorMessage converts a pipeline error into a gRPC status whose message is the
+// same JSON error body the HTTP endpoint would return.
+func grpcStatusFromErrorMessage(errMsg *interfaces.ErrorMessage) error {
+	statusCode := errMsg.StatusCode
+	if statusCode <= 0 {
+		statusCode = http.StatusInternalServerError
+	}
+	message := http.StatusText(statusCode)
+	if errMsg.Error != nil {
+		message = errMsg.Error.Error()
+	}
+	return status.Error(grpcCodeFromHTTPStatus(statusCode), string(handlers.BuildErrorResponseBody(statusCode, message)))
+}
+
+func grpcCodeFromHTTPStatus(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusRequestEntityTooLarge:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests, http.StatusPaymentRequired:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+func chatCompletionsCreateHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := &structpb.Struct{}
+	if errDecode := dec(in); errDecode != nil {
+		return nil, errDecode
+	}
+	if interceptor == nil {
+		return srv.(ChatCompletionsServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: ChatCompletionsCreateMethod}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(ChatCompletionsServer).Create(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func chatCompletionsCreateStreamHandler(srv any, stream grpc.ServerStream) error {
+	in := &structpb.Struct{}
+	if errRecv := stream.RecvMsg(in); errRecv != nil {
+		return errRecv
+	}
+	return srv.(ChatCompletionsServer).CreateStream(in, &grpc.GenericServerStream[structpb.Struct, structpb.Struct]{ServerStream: stream})
+}
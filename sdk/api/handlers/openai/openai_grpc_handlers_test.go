@@ -0,0 +1,245 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type grpcCaptureExecutor struct {
+	payloads [][]byte
+}
+
+func (e *grpcCaptureExecutor) Identifier() string { return "grpc-test-provider" }
+
+func (e *grpcCaptureExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, req.Payload)
+	return coreexecutor.Response{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[]}`)}, nil
+}
+
+func (e *grpcCaptureExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.payloads = append(e.payloads, req.Payload)
+	chunks := make(chan coreexecutor.StreamChunk, 3)
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"delta":{"content":"he"}}]}`)}
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"delta":{"content":"llo"}}]}`)}
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(`[DONE]`)}
+	close(chunks)
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (e *grpcCaptureExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *grpcCaptureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *grpcCaptureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+type grpcTestAccessProvider struct{}
+
+func (grpcTestAccessProvider) Identifier() string { return "grpc-test" }
+
+func (grpcTestAccessProvider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	switch r.Header.Get("Authorization") {
+	case "Bearer secret":
+		return &sdkaccess.Result{Provider: "grpc-test", Principal: "secret"}, nil
+	case "Bearer readonly":
+		return &sdkaccess.Result{Provider: "grpc-test", Principal: "readonly", Metadata: map[string]string{sdkaccess.MetadataReadOnly: "true"}}, nil
+	}
+	return nil, &sdkaccess.AuthError{Code: sdkaccess.AuthErrorCodeInvalidCredential, Message: "invalid API key", StatusCode: http.StatusUnauthorized}
+}
+
+func newGRPCTestClient(t *testing.T, admission handlers.AdmissionFunc) (*grpc.ClientConn, *grpcCaptureExecutor) {
+	t.Helper()
+	executor := &grpcCaptureExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "grpc-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("Register auth: %v", errRegister)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "grpc-test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	access := sdkaccess.NewManager()
+	access.SetProviders([]sdkaccess.Provider{grpcTestAccessProvider{}})
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	base.SetAdmission(admission)
+	handler := NewOpenAIGRPCHandler(base, access)
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	server.RegisterService(&ChatCompletionsServiceDesc, handler)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, errDial := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if errDial != nil {
+		t.Fatalf("grpc.NewClient: %v", errDial)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, executor
+}
+
+func grpcTestRequest(t *testing.T, fields map[string]any) *structpb.Struct {
+	t.Helper()
+	req, errNew := structpb.NewStruct(fields)
+	if errNew != nil {
+		t.Fatalf("NewStruct: %v", errNew)
+	}
+	return req
+}
+
+func TestGRPCCreateRunsChatCompletion(t *testing.T) {
+	conn, executor := newGRPCTestClient(t, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	req := grpcTestRequest(t, map[string]any{
+		"model":    "grpc-test-model",
+		"stream":   true,
+		"messages": []any{map[string]any{"role": "user", "content": "hi"}},
+	})
+
+	out := &structpb.Struct{}
+	if errInvoke := conn.Invoke(ctx, ChatCompletionsCreateMethod, req, out); errInvoke != nil {
+		t.Fatalf("Create: %v", errInvoke)
+	}
+	if got := out.GetFields()["object"].GetStringValue(); got != "chat.completion" {
+		t.Fatalf("object = %q, want chat.completion", got)
+	}
+	if len(executor.payloads) != 1 || gjson.GetBytes(executor.payloads[0], "stream").Bool() {
+		t.Fatalf("executor payloads = %q, want one non-streaming request", executor.payloads)
+	}
+}
+
+func TestGRPCCreateStreamSendsChunks(t *testing.T) {
+	conn, _ := newGRPCTestClient(t, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	stream, errStream := conn.NewStream(ctx, &ChatCompletionsServiceDesc.Streams[0], ChatCompletionsCreateStreamMethod)
+	if errStream != nil {
+		t.Fatalf("NewStream: %v", errStream)
+	}
+	if errSend := stream.SendMsg(grpcTestRequest(t, map[string]any{"model": "grpc-test-model", "messages": []any{}})); errSend != nil {
+		t.Fatalf("SendMsg: %v", errSend)
+	}
+	if errClose := stream.CloseSend(); errClose != nil {
+		t.Fatalf("CloseSend: %v", errClose)
+	}
+
+	var content strings.Builder
+	for {
+		chunk := &structpb.Struct{}
+		errRecv := stream.RecvMsg(chunk)
+		if errors.Is(errRecv, io.EOF) {
+			break
+		}
+		if errRecv != nil {
+			t.Fatalf("RecvMsg: %v", errRecv)
+		}
+		content.WriteString(chunk.GetFields()["choices"].GetListValue().GetValues()[0].GetStructValue().GetFields()["delta"].GetStructValue().GetFields()["content"].GetStringValue())
+	}
+	if content.String() != "hello" {
+		t.Fatalf("streamed content = %q, want hello", content.String())
+	}
+}
+
+func TestGRPCCreateRequiresAPIKey(t *testing.T) {
+	conn, executor := newGRPCTestClient(t, nil)
+	req := grpcTestRequest(t, map[string]any{"model": "grpc-test-model"})
+
+	errInvoke := conn.Invoke(context.Background(), ChatCompletionsCreateMethod, req, &structpb.Struct{})
+	if status.Code(errInvoke) != codes.Unauthenticated {
+		t.Fatalf("Create error = %v, want Unauthenticated", errInvoke)
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("executor was called without authentication")
+	}
+}
+
+func TestGRPCCreateAppliesAdmission(t *testing.T) {
+	var admitted []string
+	released := 0
+	conn, executor := newGRPCTestClient(t, func(ginCtx *gin.Context) (func(), *interfaces.ErrorMessage) {
+		if ginCtx.Writer == nil {
+			t.Error("admission got a gin context without response writer")
+		}
+		if ginCtx.GetString("userApiKey") == "readonly" {
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New("rate limit exceeded")}
+		}
+		admitted = append(admitted, ginCtx.GetString("userApiKey"))
+		return func() { released++ }, nil
+	})
+	req := grpcTestRequest(t, map[string]any{"model": "grpc-test-model", "messages": []any{}})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if errInvoke := conn.Invoke(ctx, ChatCompletionsCreateMethod, req, &structpb.Struct{}); errInvoke != nil {
+		t.Fatalf("Create: %v", errInvoke)
+	}
+	if len(admitted) != 1 || admitted[0] != "secret" || released != 1 {
+		t.Fatalf("admitted %q, released %d; want the secret key admitted and released once", admitted, released)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer readonly")
+	errInvoke := conn.Invoke(ctx, ChatCompletionsCreateMethod, req, &structpb.Struct{})
+	if status.Code(errInvoke) != codes.ResourceExhausted {
+		t.Fatalf("rejected key error = %v, want ResourceExhausted", errInvoke)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("executor payloads = %d, want the rejected request not executed", len(executor.payloads))
+	}
+}
+
+func TestGRPCCreateEnforcesKeyScope(t *testing.T) {
+	conn, executor := newGRPCTestClient(t, nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer readonly")
+	req := grpcTestRequest(t, map[string]any{"model": "grpc-test-model", "messages": []any{}})
+
+	errInvoke := conn.Invoke(ctx, ChatCompletionsCreateMethod, req, &structpb.Struct{})
+	if status.Code(errInvoke) != codes.PermissionDenied {
+		t.Fatalf("read-only key error = %v, want PermissionDenied", errInvoke)
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("executor was called for a read-only key")
+	}
+}
+
+func TestGRPCCodeFromHTTPStatus(t *testing.T) {
+	cases := map[int]codes.Code{
+		http.StatusBadRequest:          codes.InvalidArgument,
+		http.StatusTooManyRequests:     codes.ResourceExhausted,
+		http.StatusServiceUnavailable:  codes.Unavailable,
+		http.StatusInternalServerError: codes.Internal,
+	}
+	for httpStatus, want := range cases {
+		if got := grpcCodeFromHTTPStatus(httpStatus); got != want {
+			t.Fatalf("grpcCodeFromHTTPStatus(%d) = %v, want %v", httpStatus, got, want)
+		}
+	}
+}
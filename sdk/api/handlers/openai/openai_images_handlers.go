@@ -10,6 +10,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +32,7 @@ const (
 	defaultXAIImagesModel       = "grok-imagine-image"
 	xaiImagesQualityModel       = "grok-imagine-image-quality"
 	xaiImagesHandlerType        = "openai-image"
+	imagesGenerationsAlt        = "images/generations"
 	xaiImagesDefaultAspectRatio = "1:1"
 	xaiImagesDefaultResolution  = "1k"
 	imagesGenerationsPath       = "/v1/images/generations"
@@ -236,6 +238,17 @@ func isOpenAICompatImagesModel(model string) bool {
 	return info != nil && info.Type == registry.OpenAIImageModelType
 }
 
+// isTranslatedImagesGenerationsModel reports whether model lists /v1/images/generations in the
+// registry, meaning its provider translates OpenAI image requests (e.g. Gemini Imagen).
+func isTranslatedImagesGenerationsModel(model string) bool {
+	model = strings.TrimSpace(model)
+	if model == "" || isOpenAICompatImagesModel(model) {
+		return false
+	}
+	info := registry.LookupModelInfo(model)
+	return info != nil && slices.Contains(info.SupportedEndpoints, registry.EndpointImagesGenerations)
+}
+
 func rejectUnsupportedImagesModel(c *gin.Context, model string) bool {
 	if isSupportedImagesModel(model) {
 		return false
@@ -607,7 +620,8 @@ func (h *OpenAIAPIHandler) ImagesGenerations(c *gin.Context) {
 	if imageModel == "" {
 		imageModel = defaultImagesToolModel
 	}
-	if rejectUnsupportedImagesModel(c, imageModel) {
+	translatedModel := isTranslatedImagesGenerationsModel(imageModel)
+	if !translatedModel && rejectUnsupportedImagesModel(c, imageModel) {
 		return
 	}
 
@@ -628,6 +642,10 @@ func (h *OpenAIAPIHandler) ImagesGenerations(c *gin.Context) {
 	}
 	stream := gjson.GetBytes(rawJSON, "stream").Bool()
 
+	if translatedModel {
+		h.handleTranslatedImagesGenerations(c, rawJSON, imageModel, stream)
+		return
+	}
 	if isCodexImagesToolModel(imageModel) {
 		imageReq := buildOpenAICompatImagesJSONRequest(rawJSON, imageModel, stream)
 		h.handleRoutedImages(c, imageReq, imageModel, stream)
@@ -1144,6 +1162,36 @@ func (h *OpenAIAPIHandler) handleRoutedImages(c *gin.Context, imageReq []byte, i
 	h.collectRoutedImages(c, imageReq, imageModel)
 }
 
+// handleTranslatedImagesGenerations executes an image generation request for a model whose
+// provider translates it, such as Gemini Imagen. These providers return finished images only,
+// so streaming is rejected.
+func (h *OpenAIAPIHandler) handleTranslatedImagesGenerations(c *gin.Context, rawJSON []byte, imageModel string, stream bool) {
+	if stream {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: streaming is not supported for model %s", imageModel),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	c.Header("Content-Type", "application/json")
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), strings.TrimSpace(imageModel), rawJSON, imagesGenerationsAlt)
+	stopKeepAlive()
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel(nil)
+}
+
 func (h *OpenAIAPIHandler) collectRoutedImages(c *gin.Context, imageReq []byte, imageModel string) {
 	c.Header("Content-Type", "application/json")
 
@@ -85,6 +85,28 @@ func TestImagesModelValidationAllowsOpenAICompatImageModels(t *testing.T) {
 	}
 }
 
+func TestIsTranslatedImagesGenerationsModel(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	clientID := "test-translated-images-generations-model"
+	modelRegistry.RegisterClient(clientID, "gemini", []*registry.ModelInfo{
+		{ID: "imagen-test-model", Object: "model", OwnedBy: "google", Type: "gemini", SupportedEndpoints: []string{registry.EndpointImagesGenerations}},
+		{ID: "gemini-test-chat-model", Object: "model", OwnedBy: "google", Type: "gemini"},
+	})
+	t.Cleanup(func() {
+		modelRegistry.UnregisterClient(clientID)
+	})
+
+	if !isTranslatedImagesGenerationsModel("imagen-test-model") {
+		t.Fatal("expected model listing /v1/images/generations to be translated")
+	}
+	if isTranslatedImagesGenerationsModel("gemini-test-chat-model") {
+		t.Fatal("expected model without SupportedEndpoints to be rejected")
+	}
+	if isSupportedImagesModel("imagen-test-model") {
+		t.Fatal("expected translated generation model to stay unsupported on /v1/images/edits")
+	}
+}
+
 func TestBuildXAIImagesGenerationsRequest(t *testing.T) {
 	rawJSON := []byte(`{"model":"xai/grok-imagine-image-quality","prompt":"abstract art","aspect_ratio":"landscape","resolution":"2k","n":2,"response_format":"url"}`)
 
@@ -0,0 +1,187 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// responsesStateOwner returns the client API key stored responses are scoped to.
+func responsesStateOwner(c *gin.Context) string {
+	return strings.TrimSpace(c.GetString("userApiKey"))
+}
+
+// responsesInputItems returns a Responses API input as an item array. A string input is a
+// single user message.
+func responsesInputItems(input gjson.Result) string {
+	if input.Type == gjson.String {
+		item, _ := sjson.Set(`{"type":"message","role":"user","content":""}`, "content", input.String())
+		return "[" + item + "]"
+	}
+	return normalizeJSONArrayRaw([]byte(input.Raw))
+}
+
+// expandPreviousResponse resolves previous_response_id against the store: the request's input is
+// prefixed with the input and output of the previous response, so any backend sees the whole
+// conversation. It returns the request and its full input.
+func expandPreviousResponse(store *responsestore.Store, owner string, rawJSON []byte) ([]byte, string, *interfaces.ErrorMessage) {
+	input := responsesInputItems(gjson.GetBytes(rawJSON, "input"))
+	previousID := strings.TrimSpace(gjson.GetBytes(rawJSON, "previous_response_id").String())
+	if previousID == "" {
+		return rawJSON, input, nil
+	}
+	previous, errGet := store.Get(owner, previousID)
+	if errGet != nil || previous.Status != responsestore.StatusCompleted {
+		return nil, "", &interfaces.ErrorMessage{
+			StatusCode: http.StatusNotFound,
+			Error:      fmt.Errorf("previous response with id '%s' not found", previousID),
+		}
+	}
+	merged, errMerge := mergeJSONArrayRaw(string(previous.Input), normalizeJSONArrayRaw([]byte(gjson.GetBytes(previous.Body, "output").Raw)))
+	if errMerge == nil {
+		merged, errMerge = mergeJSONArrayRaw(merged, input)
+	}
+	if errMerge != nil {
+		return nil, "", &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("invalid request input: %w", errMerge)}
+	}
+	if deduped, errDedupe := dedupeFunctionCallsByCallID(merged); errDedupe == nil {
+		merged = deduped
+	}
+	expanded, _ := sjson.DeleteBytes(rawJSON, "previous_response_id")
+	expanded, _ = sjson.SetRawBytes(expanded, "input", []byte(merged))
+	if !gjson.GetBytes(expanded, "model").Exists() {
+		if model := gjson.GetBytes(previous.Body, "model").String(); model != "" {
+			expanded, _ = sjson.SetBytes(expanded, "model", model)
+		}
+	}
+	return expanded, merged, nil
+}
+
+// responseRecorder returns a function storing the response to rawJSON for later chaining and
+// retrieval, or nil when the store is disabled or the request asked not to be stored.
+func responseRecorder(c *gin.Context, store *responsestore.Store, rawJSON []byte, input string) func([]byte) {
+	if !store.Enabled() || gjson.GetBytes(rawJSON, "store").Type == gjson.False {
+		return nil
+	}
+	owner := responsesStateOwner(c)
+	return func(body []byte) {
+		id := gjson.GetBytes(body, "id").String()
+		if id == "" {
+			return
+		}
+		status := gjson.GetBytes(body, "status").String()
+		if status == "" {
+			status = responsestore.StatusCompleted
+		}
+		store.Put(responsestore.Response{ID: id, Owner: owner, Status: status, Input: []byte(input), Body: body}, nil)
+	}
+}
+
+// handleBackgroundResponse starts a request with "background": true detached from the client
+// and answers with the in-progress response object, which the client polls with GetResponse.
+func (h *OpenAIResponsesAPIHandler) handleBackgroundResponse(c *gin.Context, store *responsestore.Store, rawJSON []byte, input string) {
+	if gjson.GetBytes(rawJSON, "stream").Bool() {
+		h.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New("streaming background responses is not supported; poll GET /v1/responses/{id} instead")})
+		return
+	}
+	if gjson.GetBytes(rawJSON, "store").Type == gjson.False {
+		h.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New("background responses require store to be true")})
+		return
+	}
+	owner := responsesStateOwner(c)
+	id := "resp_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	body := []byte(`{"id":"","object":"response","created_at":0,"status":"","background":true,"model":"","output":[]}`)
+	body, _ = sjson.SetBytes(body, "id", id)
+	body, _ = sjson.SetBytes(body, "created_at", time.Now().Unix())
+	body, _ = sjson.SetBytes(body, "status", responsestore.StatusInProgress)
+	body, _ = sjson.SetBytes(body, "model", gjson.GetBytes(rawJSON, "model").String())
+
+	payload, _ := sjson.DeleteBytes(rawJSON, "background")
+	payload, _ = sjson.DeleteBytes(payload, "stream")
+	ctx, cancel := context.WithCancel(handlers.DetachedContext(context.Background(), http.MethodPost, c.Request.URL.Path, c.Request.Header, c.Keys))
+	response := responsestore.Response{ID: id, Owner: owner, Status: responsestore.StatusInProgress, Input: []byte(input), Body: body}
+	store.Put(response, cancel)
+	go h.runBackgroundResponse(ctx, cancel, store, response, payload)
+
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+func (h *OpenAIResponsesAPIHandler) runBackgroundResponse(ctx context.Context, cancel context.CancelFunc, store *responsestore.Store, response responsestore.Response, payload []byte) {
+	defer cancel()
+	resp, _, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), gjson.GetBytes(payload, "model").String(), payload, "")
+	if errMsg != nil {
+		message := http.StatusText(errMsg.StatusCode)
+		if errMsg.Error != nil {
+			message = errMsg.Error.Error()
+		}
+		response.Status = responsestore.StatusFailed
+		response.Body, _ = sjson.SetBytes(response.Body, "status", response.Status)
+		response.Body, _ = sjson.SetBytes(response.Body, "error.code", "server_error")
+		response.Body, _ = sjson.SetBytes(response.Body, "error.message", message)
+	} else {
+		response.Status = gjson.GetBytes(resp, "status").String()
+		if response.Status == "" {
+			response.Status = responsestore.StatusCompleted
+		}
+		response.Body, _ = sjson.SetBytes(resp, "id", response.ID)
+		response.Body, _ = sjson.SetBytes(response.Body, "background", true)
+	}
+	if !store.Finish(response) {
+		log.Debugf("responses: background response %s was cancelled before it finished", response.ID)
+	}
+}
+
+// GetResponse handles GET /v1/responses/:response_id for stored and background responses.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	response, errGet := responsestore.Default().Get(responsesStateOwner(c), c.Param("response_id"))
+	if errGet != nil {
+		h.writeResponseNotFound(c)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", response.Body)
+}
+
+// CancelResponse handles POST /v1/responses/:response_id/cancel for background responses.
+func (h *OpenAIResponsesAPIHandler) CancelResponse(c *gin.Context) {
+	response, errCancel := responsestore.Default().Cancel(responsesStateOwner(c), c.Param("response_id"), func(r responsestore.Response) []byte {
+		body, _ := sjson.SetBytes(r.Body, "status", responsestore.StatusCancelled)
+		return body
+	})
+	switch {
+	case errors.Is(errCancel, responsestore.ErrNotFound):
+		h.writeResponseNotFound(c)
+	case errCancel != nil:
+		h.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errCancel})
+	default:
+		c.Data(http.StatusOK, "application/json", response.Body)
+	}
+}
+
+// DeleteResponse handles DELETE /v1/responses/:response_id.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	id := c.Param("response_id")
+	if errDelete := responsestore.Default().Delete(responsesStateOwner(c), id); errDelete != nil {
+		h.writeResponseNotFound(c)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "response", "deleted": true})
+}
+
+func (h *OpenAIResponsesAPIHandler) writeResponseNotFound(c *gin.Context) {
+	h.WriteErrorResponse(c, &interfaces.ErrorMessage{
+		StatusCode: http.StatusNotFound,
+		Error:      fmt.Errorf("response with id '%s' not found", c.Param("response_id")),
+	})
+}
@@ -0,0 +1,204 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// responsesStateExecutor answers every request with a completed response and records the
+// payloads it received. With block set it waits for its context to end instead.
+type responsesStateExecutor struct {
+	mu        sync.Mutex
+	payloads  []string
+	block     bool
+	cancelled chan struct{}
+}
+
+func (e *responsesStateExecutor) Identifier() string { return "responses-state-provider" }
+
+func (e *responsesStateExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	n := len(e.payloads)
+	e.mu.Unlock()
+	if e.block {
+		<-ctx.Done()
+		close(e.cancelled)
+		return coreexecutor.Response{}, ctx.Err()
+	}
+	return coreexecutor.Response{Payload: []byte(fmt.Sprintf(`{"id":"resp_up_%d","object":"response","status":"completed","model":"state-model","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"answer %d"}]}]}`, n, n))}, nil
+}
+
+func (e *responsesStateExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *responsesStateExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *responsesStateExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *responsesStateExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *responsesStateExecutor) payload(i int) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if i >= len(e.payloads) {
+		return ""
+	}
+	return e.payloads[i]
+}
+
+func newResponsesStateRouter(t *testing.T, executor *responsesStateExecutor) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	responsestore.Default().Update(config.ResponsesStoreConfig{Enable: true})
+	t.Cleanup(func() { responsestore.Default().Update(config.ResponsesStoreConfig{}) })
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "responses-state-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "state-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIResponsesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userApiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	})
+	router.POST("/v1/responses", h.Responses)
+	router.GET("/v1/responses/:response_id", h.GetResponse)
+	router.DELETE("/v1/responses/:response_id", h.DeleteResponse)
+	router.POST("/v1/responses/:response_id/cancel", h.CancelResponse)
+	return router
+}
+
+func serveResponsesState(router *gin.Engine, method, path, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Key", apiKey)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestResponsesPreviousResponseIDExpandsStoredConversation(t *testing.T) {
+	executor := &responsesStateExecutor{}
+	router := newResponsesStateRouter(t, executor)
+
+	if rec := serveResponsesState(router, http.MethodPost, "/v1/responses", "alice", `{"model":"state-model","input":"hi"}`); rec.Code != http.StatusOK {
+		t.Fatalf("first turn = %d %s", rec.Code, rec.Body.String())
+	}
+	rec := serveResponsesState(router, http.MethodPost, "/v1/responses", "alice", `{"model":"state-model","previous_response_id":"resp_up_1","input":[{"type":"message","role":"user","content":"again"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("second turn = %d %s", rec.Code, rec.Body.String())
+	}
+
+	second := executor.payload(1)
+	input := gjson.Get(second, "input").Array()
+	if gjson.Get(second, "previous_response_id").Exists() || len(input) != 3 {
+		t.Fatalf("second payload = %s, want the expanded conversation", second)
+	}
+	if input[0].Get("content").String() != "hi" || input[1].Get("content.0.text").String() != "answer 1" || input[2].Get("content").String() != "again" {
+		t.Fatalf("expanded input = %s", gjson.Get(second, "input").Raw)
+	}
+
+	if rec = serveResponsesState(router, http.MethodPost, "/v1/responses", "bob", `{"model":"state-model","previous_response_id":"resp_up_1","input":"x"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("chaining another key's response = %d, want 404", rec.Code)
+	}
+	if rec = serveResponsesState(router, http.MethodPost, "/v1/responses", "alice", `{"model":"state-model","store":false,"previous_response_id":"resp_up_2","input":"x"}`); rec.Code != http.StatusOK {
+		t.Fatalf("third turn = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = serveResponsesState(router, http.MethodGet, "/v1/responses/resp_up_3", "alice", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("response created with store false = %d, want 404", rec.Code)
+	}
+}
+
+func TestResponsesBackgroundRunsDetachedAndIsPollable(t *testing.T) {
+	router := newResponsesStateRouter(t, &responsesStateExecutor{})
+
+	rec := serveResponsesState(router, http.MethodPost, "/v1/responses", "alice", `{"model":"state-model","input":"hi","background":true}`)
+	id := gjson.Get(rec.Body.String(), "id").String()
+	if rec.Code != http.StatusOK || gjson.Get(rec.Body.String(), "status").String() != responsestore.StatusInProgress || id == "" {
+		t.Fatalf("background create = %d %s", rec.Code, rec.Body.String())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec = serveResponsesState(router, http.MethodGet, "/v1/responses/"+id, "alice", "")
+		if gjson.Get(rec.Body.String(), "status").String() == responsestore.StatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background response did not complete: %d %s", rec.Code, rec.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	body := rec.Body.String()
+	if gjson.Get(body, "id").String() != id || !gjson.Get(body, "background").Bool() || gjson.Get(body, "output.0.content.0.text").String() != "answer 1" {
+		t.Fatalf("completed background response = %s", body)
+	}
+
+	if rec = serveResponsesState(router, http.MethodGet, "/v1/responses/"+id, "bob", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET by another key = %d, want 404", rec.Code)
+	}
+	if rec = serveResponsesState(router, http.MethodDelete, "/v1/responses/"+id, "alice", ""); rec.Code != http.StatusOK || !gjson.Get(rec.Body.String(), "deleted").Bool() {
+		t.Fatalf("DELETE = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = serveResponsesState(router, http.MethodGet, "/v1/responses/"+id, "alice", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("GET after DELETE = %d, want 404", rec.Code)
+	}
+}
+
+func TestResponsesCancelStopsBackgroundResponse(t *testing.T) {
+	executor := &responsesStateExecutor{block: true, cancelled: make(chan struct{})}
+	router := newResponsesStateRouter(t, executor)
+
+	rec := serveResponsesState(router, http.MethodPost, "/v1/responses", "alice", `{"model":"state-model","input":"hi","background":true}`)
+	id := gjson.Get(rec.Body.String(), "id").String()
+	for executor.payload(0) == "" {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rec = serveResponsesState(router, http.MethodPost, "/v1/responses/"+id+"/cancel", "alice", "")
+	if rec.Code != http.StatusOK || gjson.Get(rec.Body.String(), "status").String() != responsestore.StatusCancelled {
+		t.Fatalf("cancel = %d %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case <-executor.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("background request was not cancelled upstream")
+	}
+	if rec = serveResponsesState(router, http.MethodPost, "/v1/responses/"+id+"/cancel", "alice", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("second cancel = %d, want 400", rec.Code)
+	}
+	if rec = serveResponsesState(router, http.MethodGet, "/v1/responses/"+id, "alice", ""); gjson.Get(rec.Body.String(), "status").String() != responsestore.StatusCancelled {
+		t.Fatalf("GET after cancel = %s", rec.Body.String())
+	}
+}
@@ -481,8 +481,15 @@ func (h *OpenAIResponsesAPIHandler) ResponsesWebsocket(c *gin.Context) {
 		pinnedAuthID = ""
 	}
 
+	// Read client messages in the background so a disconnect during a turn is noticed at once
+	// and the upstream request is cancelled instead of running to completion.
+	clientMessages := make(chan responsesWebsocketClientMessage)
+	clientGone := make(chan struct{})
+	go readResponsesWebsocketClientMessages(conn, clientMessages, clientGone, wsDone)
+
 	for {
-		msgType, payload, errReadMessage := conn.ReadMessage()
+		clientMessage := <-clientMessages
+		msgType, payload, errReadMessage := clientMessage.msgType, clientMessage.payload, clientMessage.err
 		if errReadMessage != nil {
 			wsTerminateErr = errReadMessage
 			if websocket.IsCloseError(errReadMessage, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
@@ -692,7 +699,8 @@ func (h *OpenAIResponsesAPIHandler) ResponsesWebsocket(c *gin.Context) {
 		if pinnedAuthID != "" && !routeOverridesModelResolution {
 			cliCtx = handlers.WithPinnedAuthID(cliCtx, pinnedAuthID)
 		}
-		dataChan, _, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, requestJSON, "")
+		turnCtx, cancelTurn := context.WithCancelCause(cliCtx)
+		dataChan, _, errChan := h.ExecuteStreamWithAuthManager(turnCtx, h.HandlerType(), modelName, requestJSON, "")
 		if !selectedAuthObserved {
 			// Plugin/alternate routes bypass auth selection. Keep canonical HTTP-mode
 			// state instead of inheriting the previous pinned websocket mode.
@@ -716,8 +724,16 @@ func (h *OpenAIResponsesAPIHandler) ResponsesWebsocket(c *gin.Context) {
 			responsesWebsocketForwardOptions{
 				toolCacheTurn: toolCacheTurn,
 				suppressError: replayPinnedAuthFailure,
+				clientGone:    clientGone,
+				disconnect:    func() { cancelTurn(handlers.ErrClientDisconnected) },
 			},
 		)
+		cancelTurn(nil)
+		if errors.Is(errForward, handlers.ErrClientDisconnected) {
+			wsTerminateErr = (<-clientMessages).err
+			log.Infof("responses websocket: client disconnected mid-response id=%s error=%v", passthroughSessionID, wsTerminateErr)
+			return
+		}
 		if errForward != nil {
 			wsTerminateErr = errForward
 			if !errors.Is(errForward, websocket.ErrCloseSent) {
@@ -1691,6 +1707,36 @@ func normalizeJSONArrayRaw(raw []byte) string {
 type responsesWebsocketForwardOptions struct {
 	toolCacheTurn *responsesWebsocketToolCacheTurn
 	suppressError func(*interfaces.ErrorMessage) bool
+	// clientGone is closed when reading from the client fails; disconnect then cancels the
+	// upstream request with handlers.ErrClientDisconnected as the cause.
+	clientGone <-chan struct{}
+	disconnect func()
+}
+
+// responsesWebsocketClientMessage is one result of reading from the client connection.
+type responsesWebsocketClientMessage struct {
+	msgType int
+	payload []byte
+	err     error
+}
+
+// readResponsesWebsocketClientMessages reads client messages until a read fails, closing gone as
+// soon as it does. The failed read is delivered last.
+func readResponsesWebsocketClientMessages(conn *websocket.Conn, messages chan<- responsesWebsocketClientMessage, gone chan<- struct{}, done <-chan struct{}) {
+	for {
+		msgType, payload, errRead := conn.ReadMessage()
+		if errRead != nil {
+			close(gone)
+		}
+		select {
+		case messages <- responsesWebsocketClientMessage{msgType: msgType, payload: payload, err: errRead}:
+		case <-done:
+			return
+		}
+		if errRead != nil {
+			return
+		}
+	}
 }
 
 func (h *OpenAIResponsesAPIHandler) forwardResponsesWebsocket(
@@ -1724,6 +1770,12 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesWebsocket(
 		case <-c.Request.Context().Done():
 			cancel(c.Request.Context().Err())
 			return completedOutput, completedResponseID, sortedStringSet(pendingToolCallIDs), nil, c.Request.Context().Err()
+		case <-opts.clientGone:
+			if opts.disconnect != nil {
+				opts.disconnect()
+			}
+			cancel(handlers.ErrClientDisconnected)
+			return completedOutput, completedResponseID, sortedStringSet(pendingToolCallIDs), nil, handlers.ErrClientDisconnected
 		case errMsg, ok := <-errs:
 			if !ok {
 				errs = nil
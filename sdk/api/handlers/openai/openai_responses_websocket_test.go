@@ -4961,3 +4961,79 @@ func TestNormalizeSubsequentRequestAssistantInputTriggersTranscriptReplacement(t
 		t.Fatalf("input[0].id = %q, want %q", input[0].Get("id").String(), "msg-3")
 	}
 }
+
+// endlessResponsesWebsocketExecutor starts a response and keeps the stream open until it is
+// cancelled.
+type endlessResponsesWebsocketExecutor struct {
+	cancelled chan struct{}
+}
+
+func (*endlessResponsesWebsocketExecutor) Identifier() string { return "codex" }
+
+func (*endlessResponsesWebsocketExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *endlessResponsesWebsocketExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	chunks := make(chan coreexecutor.StreamChunk, 1)
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(`{"type":"response.created","response":{"id":"endless-response"}}`)}
+	go func() {
+		defer close(chunks)
+		<-ctx.Done()
+		close(e.cancelled)
+	}()
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (*endlessResponsesWebsocketExecutor) Refresh(context.Context, *coreauth.Auth) (*coreauth.Auth, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (*endlessResponsesWebsocketExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (*endlessResponsesWebsocketExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestResponsesWebsocketCancelsUpstreamWhenClientDisconnectsMidResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	executor := &endlessResponsesWebsocketExecutor{cancelled: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "endless-websocket-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("manager.Register: %v", errRegister)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "codex", []*registry.ModelInfo{{ID: "endless-websocket-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewOpenAIResponsesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.GET("/v1/responses/ws", h.ResponsesWebsocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/responses/ws"
+	conn, _, errDial := websocket.DefaultDialer.Dial(wsURL, nil)
+	if errDial != nil {
+		t.Fatalf("dial websocket: %v", errDial)
+	}
+	if errWrite := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"response.create","model":"endless-websocket-model","input":[]}`)); errWrite != nil {
+		t.Fatalf("write websocket request: %v", errWrite)
+	}
+	if _, payload, errRead := conn.ReadMessage(); errRead != nil || gjson.GetBytes(payload, "type").String() != "response.created" {
+		t.Fatalf("first event = %s, %v; want response.created", payload, errRead)
+	}
+	if errClose := conn.Close(); errClose != nil {
+		t.Fatalf("close websocket: %v", errClose)
+	}
+
+	select {
+	case <-executor.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream stream was not cancelled after the client disconnected")
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/redaction"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyPIIRedaction scrubs the configured PII patterns from the text of rawJSON unless the
+// client API key is exempt, and records the redaction counts in the audit log.
+func (h *BaseAPIHandler) applyPIIRedaction(ctx context.Context, modelName string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil || !h.Cfg.PIIRedaction.Enable || len(rawJSON) == 0 {
+		return rawJSON
+	}
+	cfg := h.Cfg.PIIRedaction
+	apiKey := clientAPIKeyFromContext(ctx)
+	for _, exempt := range cfg.ExemptAPIKeys {
+		if apiKey != "" && exempt == apiKey {
+			return rawJSON
+		}
+	}
+	out, counts := redaction.For(cfg).Redact(rawJSON)
+	if len(counts) == 0 {
+		return rawJSON
+	}
+	requestID := logging.GetRequestID(ctx)
+	log.Infof("pii redaction: request %s to model %s redacted %v", requestID, modelName, counts)
+	redaction.DefaultAudit().Record(cfg.AuditEntries, redaction.AuditEntry{
+		RequestID: requestID,
+		APIKey:    util.HideAPIKey(apiKey),
+		Model:     modelName,
+		Counts:    counts,
+	})
+	return out
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPIIRedactionHonorsExemptKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{PIIRedaction: internalconfig.PIIRedactionConfig{
+		Enable:        true,
+		Detectors:     []string{internalconfig.PIIDetectorEmail},
+		ExemptAPIKeys: []string{"trusted"},
+	}}}
+	body := []byte(`{"messages":[{"role":"user","content":"reach me at jane@example.com"}]}`)
+
+	run := func(apiKey string) string {
+		ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginCtx.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+		ginCtx.Set("userApiKey", apiKey)
+		ctx := context.WithValue(context.Background(), "gin", ginCtx)
+		return gjson.GetBytes(handler.applyPIIRedaction(ctx, "gpt-5", body), "messages.0.content").String()
+	}
+
+	if got := run("team"); got != "reach me at [REDACTED:email]" {
+		t.Fatalf("redacted content = %q", got)
+	}
+	if got := run("trusted"); got != "reach me at jane@example.com" {
+		t.Fatalf("exempt content = %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// preflightMaxTruncationRounds bounds how often a truncated request is recounted.
+const preflightMaxTruncationRounds = 4
+
+// preflightTokenCheck counts the input tokens of a large request through the providers'
+// count-tokens support (a provider API or a local tokenizer, depending on the executor). A
+// request that cannot fit the model's input limit is rejected, or truncated when a truncation
+// strategy is configured; the returned body replaces rawJSON. Counting failures never block
+// the request.
+func (h *BaseAPIHandler) preflightTokenCheck(ctx context.Context, entryProtocol, modelName string, providers []string, rawJSON []byte, alt string, execOptions modelExecutionOptions) ([]byte, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || h.AuthManager == nil || !h.Cfg.PreflightTokenCheck.Enable {
+		return rawJSON, nil
+	}
+	check := h.Cfg.PreflightTokenCheck
+	if alt != "" || len(rawJSON) < check.MinRequestBytes || !preflightTokenCheckProtocol(entryProtocol) {
+		return rawJSON, nil
+	}
+	limit := preflightInputTokenLimit(modelName, check.ReserveOutputTokens)
+	if limit <= 0 {
+		return rawJSON, nil
+	}
+
+	count, ok := h.preflightCountTokens(ctx, entryProtocol, modelName, providers, rawJSON, execOptions)
+	if !ok || count <= limit {
+		return rawJSON, nil
+	}
+	original := count
+	body := rawJSON
+	if check.Strategy == internalconfig.PreflightStrategyDropOldest || check.Strategy == internalconfig.PreflightStrategyDropMiddle {
+		keepFirst := check.Strategy == internalconfig.PreflightStrategyDropMiddle
+		for round := 0; round < preflightMaxTruncationRounds && count > limit; round++ {
+			// Aim slightly below the limit, assuming tokens scale with body size.
+			target := int(float64(len(body)) * float64(limit) / float64(count) * 0.95)
+			truncated, dropped := truncateConversationTurns(entryProtocol, body, target, keepFirst)
+			if dropped == 0 {
+				break
+			}
+			recount, okCount := h.preflightCountTokens(ctx, entryProtocol, modelName, providers, truncated, execOptions)
+			if !okCount {
+				break
+			}
+			body, count = truncated, recount
+		}
+		if count <= limit {
+			log.Infof("preflight token check: truncated request for model %s from %d to %d input tokens (%s)", modelName, original, count, check.Strategy)
+			return body, nil
+		}
+	}
+	return rawJSON, &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("request has %d input tokens, which exceeds the %d-token input limit of model %s", original, limit, modelName),
+	}
+}
+
+// preflightCountTokens returns the input token count of rawJSON, or false when counting fails.
+func (h *BaseAPIHandler) preflightCountTokens(ctx context.Context, entryProtocol, modelName string, providers []string, rawJSON []byte, execOptions modelExecutionOptions) (int64, bool) {
+	if count, ok := h.countTokensLocally(entryProtocol, modelName, rawJSON); ok {
+		return count, true
+	}
+	req := coreexecutor.Request{Model: modelName, Payload: rawJSON}
+	meta := requestExecutionMetadata(ctx)
+	// Count once on one credential; a failed count must not cool down credentials the request
+	// itself may still use.
+	meta[coreexecutor.PreflightCountMetadataKey] = true
+	opts := coreexecutor.Options{
+		OriginalRequest: rawJSON,
+		SourceFormat:    sdktranslator.FromString(entryProtocol),
+		Headers:         modelExecutionHeaders(ctx, execOptions.Headers),
+		Query:           modelExecutionQuery(ctx, execOptions.Query),
+		Metadata:        meta,
+	}
+	resp, errCount := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
+	if errCount != nil {
+		log.Debugf("preflight token check: counting tokens for model %s failed: %v", modelName, errCount)
+		return 0, false
+	}
+	return tokenCountFromResponse(resp.Payload), true
+}
+
+// preflightTokenCheckProtocol reports whether requests of protocol can be token counted.
+func preflightTokenCheckProtocol(protocol string) bool {
+	switch sdktranslator.FromString(protocol) {
+	case sdktranslator.FormatOpenAI, sdktranslator.FormatOpenAIResponse, sdktranslator.FormatClaude, sdktranslator.FormatGemini:
+		return true
+	default:
+		return false
+	}
+}
+
+// preflightInputTokenLimit returns the input token limit of modelName, or 0 when unknown.
+// Models without a separate input limit use their context window minus reserveOutput.
+func preflightInputTokenLimit(modelName string, reserveOutput int) int64 {
+	info := registry.LookupModelInfo(thinking.ParseSuffix(modelName).ModelName)
+	if info == nil {
+		return 0
+	}
+	if info.InputTokenLimit > 0 {
+		return int64(info.InputTokenLimit)
+	}
+	if window := info.ContextWindow(); window > reserveOutput {
+		return int64(window - reserveOutput)
+	}
+	return 0
+}
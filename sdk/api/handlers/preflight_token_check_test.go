@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// preflightTestExecutor reports a fixed input token count, or one token per bytesPerToken
+// bytes of payload when set, and records generate calls.
+type preflightTestExecutor struct {
+	tokens        int
+	bytesPerToken int
+	executed      int
+	lastPayload   []byte
+}
+
+func (e *preflightTestExecutor) Identifier() string { return "claude" }
+
+func (e *preflightTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.executed++
+	e.lastPayload = req.Payload
+	return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func (e *preflightTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.executed++
+	chunks := make(chan coreexecutor.StreamChunk)
+	close(chunks)
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (e *preflightTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *preflightTestExecutor) CountTokens(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	tokens := e.tokens
+	if e.bytesPerToken > 0 {
+		tokens = len(req.Payload) / e.bytesPerToken
+	}
+	return coreexecutor.Response{Payload: []byte(fmt.Sprintf(`{"input_tokens":%d}`, tokens))}, nil
+}
+
+func (e *preflightTestExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newPreflightTestHandler(t *testing.T, executor *preflightTestExecutor, check internalconfig.PreflightTokenCheckConfig) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: t.Name() + "-claude", Provider: "claude", Status: coreauth.StatusActive}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("manager.Register: %v", errRegister)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "preflight-model", ContextLength: 1000}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{PreflightTokenCheck: check}, manager)
+}
+
+func TestPreflightTokenCheck_RejectsOversizedRequest(t *testing.T) {
+	executor := &preflightTestExecutor{tokens: 950}
+	handler := newPreflightTestHandler(t, executor, internalconfig.PreflightTokenCheckConfig{Enable: true, MinRequestBytes: 1, ReserveOutputTokens: 100})
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "claude", "preflight-model", []byte(`{"model":"preflight-model"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("errMsg = %+v, want 400", errMsg)
+	}
+	if !strings.Contains(errMsg.Error.Error(), "950 input tokens") {
+		t.Fatalf("error = %v", errMsg.Error)
+	}
+	if executor.executed != 0 {
+		t.Fatalf("executed = %d, want request rejected before sending", executor.executed)
+	}
+
+	_, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "claude", "preflight-model", []byte(`{"model":"preflight-model"}`), "")
+	if errMsg := <-errChan; errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("stream errMsg = %+v, want 400", errMsg)
+	}
+	if executor.executed != 0 {
+		t.Fatalf("executed = %d, want stream rejected before sending", executor.executed)
+	}
+}
+
+func TestPreflightTokenCheck_SendsRequestsWithinLimitOrBelowThreshold(t *testing.T) {
+	executor := &preflightTestExecutor{tokens: 950}
+	handler := newPreflightTestHandler(t, executor, internalconfig.PreflightTokenCheckConfig{Enable: true, MinRequestBytes: 1 << 20})
+
+	if _, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "claude", "preflight-model", []byte(`{"model":"preflight-model"}`), ""); errMsg != nil {
+		t.Fatalf("small request: unexpected error %+v", errMsg)
+	}
+
+	handler.Cfg.PreflightTokenCheck.MinRequestBytes = 1
+	if _, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "claude", "preflight-model", []byte(`{"model":"preflight-model"}`), ""); errMsg != nil {
+		t.Fatalf("request within limit: unexpected error %+v", errMsg)
+	}
+	if executor.executed != 2 {
+		t.Fatalf("executed = %d, want 2", executor.executed)
+	}
+}
+
+// longConversation builds a claude request of n user/assistant turns padded to ~200 bytes each.
+func longConversation(n int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"model":"preflight-model","system":"be brief","messages":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"role":"user","content":"turn %d %s"},{"role":"assistant","content":"reply %d"}`, i, strings.Repeat("x", 150), i)
+	}
+	b.WriteString(`]}`)
+	return []byte(b.String())
+}
+
+func TestPreflightTokenCheck_TruncatesWithConfiguredStrategy(t *testing.T) {
+	cases := []struct {
+		strategy  string
+		keepFirst bool
+	}{
+		{strategy: internalconfig.PreflightStrategyDropOldest},
+		{strategy: internalconfig.PreflightStrategyDropMiddle, keepFirst: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.strategy, func(t *testing.T) {
+			// 20 turns of ~200 bytes at 4 bytes per token is ~1000 tokens, over the 900-token limit.
+			executor := &preflightTestExecutor{bytesPerToken: 4}
+			handler := newPreflightTestHandler(t, executor, internalconfig.PreflightTokenCheckConfig{
+				Enable: true, MinRequestBytes: 1, ReserveOutputTokens: 100, Strategy: tc.strategy,
+			})
+			payload := longConversation(20)
+			if _, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "claude", "preflight-model", payload, ""); errMsg != nil {
+				t.Fatalf("unexpected error %+v", errMsg)
+			}
+			if executor.executed != 1 {
+				t.Fatalf("executed = %d, want 1", executor.executed)
+			}
+			sent := gjson.GetBytes(executor.lastPayload, "messages")
+			if got := len(executor.lastPayload) / 4; got > 900 {
+				t.Fatalf("sent %d tokens, want at most 900", got)
+			}
+			if first := sent.Get("0.content").String(); strings.HasPrefix(first, "turn 0 ") != tc.keepFirst {
+				t.Fatalf("first message = %.10q, keep first turn = %v", first, tc.keepFirst)
+			}
+			if second := sent.Get("2.content").String(); strings.HasPrefix(second, "turn 1 ") {
+				t.Fatalf("second turn kept: %.10q", second)
+			}
+			if last := sent.Get("@reverse.0.content").String(); last != "reply 19" {
+				t.Fatalf("last message = %q, want latest turn kept", last)
+			}
+			if gjson.GetBytes(executor.lastPayload, "system").String() != "be brief" {
+				t.Fatalf("system prompt dropped: %s", executor.lastPayload)
+			}
+		})
+	}
+}
+
+func TestTruncateConversationTurns_KeepsToolResultsWithTheirCalls(t *testing.T) {
+	payload := []byte(`{"messages":[` +
+		`{"role":"system","content":"sys"},` +
+		`{"role":"user","content":"first"},` +
+		`{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},` +
+		`{"role":"tool","tool_call_id":"c1","content":"result"},` +
+		`{"role":"assistant","content":"done"},` +
+		`{"role":"user","content":"second"}]}`)
+
+	out, dropped := truncateConversationTurns("openai", payload, 1, false)
+	if dropped != 4 {
+		t.Fatalf("dropped = %d, want the whole first turn", dropped)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 2 || messages[0].Get("role").String() != "system" || messages[1].Get("content").String() != "second" {
+		t.Fatalf("messages = %s", gjson.GetBytes(out, "messages").Raw)
+	}
+}
+
+func TestExecuteCountWithAuthManager_LocalTokenCount(t *testing.T) {
+	executor := &preflightTestExecutor{tokens: 950}
+	handler := newPreflightTestHandler(t, executor, internalconfig.PreflightTokenCheckConfig{})
+	payload := []byte(`{"model":"preflight-model","messages":[{"role":"user","content":"hello there"}]}`)
+
+	resp, _, errMsg := handler.ExecuteCountWithAuthManager(context.Background(), "claude", "preflight-model", payload, "")
+	if errMsg != nil || gjson.GetBytes(resp, "input_tokens").Int() != 950 {
+		t.Fatalf("upstream count = %s, %+v", resp, errMsg)
+	}
+	resp, _, errMsg = handler.ExecuteCountWithAuthManager(context.Background(), "openai-response", "preflight-model",
+		[]byte(`{"model":"preflight-model","input":"hello there"}`), "")
+	if errMsg != nil || string(resp) != `{"object":"response.input_tokens","input_tokens":950}` {
+		t.Fatalf("upstream responses count = %s, %+v", resp, errMsg)
+	}
+
+	handler.Cfg.LocalTokenCount = true
+	resp, _, errMsg = handler.ExecuteCountWithAuthManager(context.Background(), "claude", "preflight-model", payload, "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error %+v", errMsg)
+	}
+	if count := gjson.GetBytes(resp, "input_tokens").Int(); count <= 0 || count >= 950 {
+		t.Fatalf("local count = %s", resp)
+	}
+
+	resp, _, errMsg = handler.ExecuteCountWithAuthManager(context.Background(), "openai-response", "preflight-model",
+		[]byte(`{"model":"preflight-model","input":"hello there"}`), "")
+	if errMsg != nil || gjson.GetBytes(resp, "object").String() != "response.input_tokens" || gjson.GetBytes(resp, "input_tokens").Int() <= 0 {
+		t.Fatalf("responses count = %s, %+v", resp, errMsg)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyPromptRules rewrites the system prompt of rawJSON with every configured prompt rule that
+// matches the request's model, client API key and route.
+func (h *BaseAPIHandler) applyPromptRules(ctx context.Context, entryProtocol, modelName string, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil || len(h.Cfg.PromptRules) == 0 || len(rawJSON) == 0 {
+		return rawJSON
+	}
+	baseModel := thinking.ParseSuffix(strings.TrimSpace(modelName)).ModelName
+	apiKey := clientAPIKeyFromContext(ctx)
+	route := requestPathFromContext(ctx)
+	out := rawJSON
+	for _, rule := range h.Cfg.PromptRules {
+		if !promptRuleMatches(rule, baseModel, apiKey, route) {
+			continue
+		}
+		log.Debugf("prompt rule %q applied to model %s", rule.Name, baseModel)
+		out = applyPromptRule(entryProtocol, rule, baseModel, out)
+	}
+	return out
+}
+
+func applyPromptRule(entryProtocol string, rule config.PromptRule, modelName string, rawJSON []byte) []byte {
+	out := rawJSON
+	if rule.Template != "" {
+		text := strings.NewReplacer("{{system}}", systemPromptText(entryProtocol, out), "{{model}}", modelName).Replace(rule.Template)
+		out = replaceSystemPrompt(entryProtocol, out, strings.TrimSpace(text))
+	}
+	out = prependSystemPrompt(entryProtocol, out, rule.Prepend)
+	return appendSystemPrompt(entryProtocol, out, rule.Append)
+}
+
+func promptRuleMatches(rule config.PromptRule, modelName, apiKey, route string) bool {
+	if len(rule.Models) > 0 && !matchesAnyPattern(rule.Models, strings.ToLower(modelName), true) {
+		return false
+	}
+	if len(rule.APIKeys) > 0 {
+		matched := false
+		for _, key := range rule.APIKeys {
+			if apiKey != "" && key == apiKey {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return len(rule.Routes) == 0 || matchesAnyPattern(rule.Routes, route, false)
+}
+
+func matchesAnyPattern(patterns []string, value string, foldCase bool) bool {
+	if value == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if foldCase {
+			pattern = strings.ToLower(pattern)
+		}
+		if sdkaccess.MatchWildcard(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func requestPathFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil && ginCtx.Request.URL != nil {
+		return ginCtx.Request.URL.Path
+	}
+	return ""
+}
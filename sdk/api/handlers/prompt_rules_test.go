@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPromptRulePerProtocol(t *testing.T) {
+	rule := internalconfig.PromptRule{
+		Template: "Policy for {{model}}.\n\n{{system}}",
+		Prepend:  "First.",
+		Append:   "Disclaimer.",
+	}
+	cases := []struct {
+		protocol string
+		in       string
+		checks   map[string]string
+	}{
+		{
+			protocol: "openai",
+			in:       `{"messages":[{"role":"system","content":"Be brief."},{"role":"developer","content":[{"type":"text","text":"Use JSON."}]},{"role":"user","content":"hi"}]}`,
+			checks: map[string]string{
+				"messages.#":         "4",
+				"messages.0.content": "First.",
+				"messages.1.content": "Policy for m1.\n\nBe brief.\n\nUse JSON.",
+				"messages.2.content": "Disclaimer.",
+				"messages.3.role":    "user",
+			},
+		},
+		{
+			protocol: "openai-response",
+			in:       `{"instructions":"Be brief.","input":"hi"}`,
+			checks: map[string]string{
+				"instructions": "First.\n\nPolicy for m1.\n\nBe brief.\n\nDisclaimer.",
+			},
+		},
+		{
+			protocol: "claude",
+			in:       `{"system":[{"type":"text","text":"Be brief."}],"messages":[]}`,
+			checks: map[string]string{
+				"system": "First.\n\nPolicy for m1.\n\nBe brief.\n\nDisclaimer.",
+			},
+		},
+		{
+			protocol: "gemini",
+			in:       `{"system_instruction":{"parts":[{"text":"Be brief."}]},"contents":[]}`,
+			checks: map[string]string{
+				"system_instruction.parts.#":      "3",
+				"system_instruction.parts.0.text": "First.",
+				"system_instruction.parts.1.text": "Policy for m1.\n\nBe brief.",
+				"system_instruction.parts.2.text": "Disclaimer.",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.protocol, func(t *testing.T) {
+			out := applyPromptRule(tc.protocol, rule, "m1", []byte(tc.in))
+			for path, want := range tc.checks {
+				if got := gjson.GetBytes(out, path).String(); got != want {
+					t.Fatalf("%s = %q, want %q; out=%s", path, got, want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPromptRulesMatchesSelectors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &BaseAPIHandler{Cfg: &config.SDKConfig{PromptRules: []config.PromptRule{
+		{Name: "claude", Models: []string{"Claude-*"}, Append: "Claude policy."},
+		{Name: "team", APIKeys: []string{"team-key"}, Routes: []string{"/v1/*"}, Prepend: "Team policy."},
+	}}}
+
+	run := func(model, apiKey, path string) string {
+		ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginCtx.Request = httptest.NewRequest("POST", path, nil)
+		if apiKey != "" {
+			ginCtx.Set("userApiKey", apiKey)
+		}
+		ctx := context.WithValue(context.Background(), "gin", ginCtx)
+		out := handler.applyPromptRules(ctx, "claude", model, []byte(`{"messages":[]}`))
+		return gjson.GetBytes(out, "system").String()
+	}
+
+	if got := run("claude-sonnet-4-5(high)", "", "/v1/messages"); got != "Claude policy." {
+		t.Fatalf("claude model system = %q", got)
+	}
+	if got := run("claude-sonnet-4-5", "team-key", "/v1/messages"); got != "Team policy.\n\nClaude policy." {
+		t.Fatalf("team key system = %q", got)
+	}
+	if got := run("gpt-5", "team-key", "/api/provider/claude/v1/messages"); got != "" {
+		t.Fatalf("unmatched route system = %q", got)
+	}
+	if got := run("gpt-5", "other-key", "/v1/messages"); got != "" {
+		t.Fatalf("unmatched key system = %q", got)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/racestats"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// RaceHeader opts a single request into race mode when race.allow-header is enabled. Its value
+// is the number of providers to race, capped by race.max-fanout.
+const RaceHeader = "X-CPA-Race"
+
+// RaceWinnerHeader names the provider whose response was returned for a raced request.
+const RaceWinnerHeader = "X-CPA-Race-Winner"
+
+// raceFanout returns the number of providers a request is raced across; values below 2
+// disable racing.
+func (h *BaseAPIHandler) raceFanout(ctx context.Context, model string, opts coreexecutor.Options) int {
+	if h == nil || h.Cfg == nil || h.AuthManager == nil || h.AuthManager.HomeEnabled() {
+		return 0
+	}
+	if _, pinned := opts.Metadata[coreexecutor.PinnedAuthMetadataKey]; pinned {
+		return 0
+	}
+	race := h.Cfg.Race
+	limit := race.MaxFanout
+	if limit <= 0 {
+		limit = config.DefaultRaceMaxFanout
+	}
+	if race.AllowHeader && ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			if value := strings.TrimSpace(ginCtx.GetHeader(RaceHeader)); value != "" {
+				if n, errParse := strconv.Atoi(value); errParse == nil {
+					return min(n, limit)
+				}
+			}
+		}
+	}
+	if race.Fanout < 2 {
+		return 0
+	}
+	if len(race.Models) > 0 && !matchesAnyPattern(race.Models, strings.ToLower(model), true) {
+		return 0
+	}
+	return min(race.Fanout, limit)
+}
+
+// executeRaced runs Execute, sending the request to several providers at once in race mode
+// and returning the first successful response. The other attempts are cancelled as soon as it
+// arrives.
+func (h *BaseAPIHandler) executeRaced(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	racers := raceProviders(providers, h.raceFanout(ctx, req.Model, opts))
+	if len(racers) < 2 || ctx == nil {
+		return h.AuthManager.Execute(ctx, providers, req, opts)
+	}
+	results := make(chan *hedgeAttempt, len(racers))
+	attempts := startRaceAttempts(ctx, opts, racers, func(attemptCtx context.Context, attempt *hedgeAttempt, attemptProviders []string, attemptOpts coreexecutor.Options) {
+		go func() {
+			attempt.resp, attempt.err = h.AuthManager.Execute(attemptCtx, attemptProviders, req, attemptOpts)
+			if attemptCtx.Err() == nil {
+				attempt.latency = time.Since(attempt.started)
+			}
+			results <- attempt
+		}()
+	})
+	winner, errRace := h.awaitRaceWinner(ctx, req.Model, attempts, results, func(attempt *hedgeAttempt) bool {
+		return attempt.err == nil
+	})
+	if errRace != nil {
+		return coreexecutor.Response{}, errRace
+	}
+	for _, attempt := range attempts {
+		attempt.cancel()
+	}
+	h.publishRaceWinner(ctx, opts.Metadata, winner)
+	return winner.resp, winner.err
+}
+
+// executeStreamRace sends a streaming request to several providers at once and returns the
+// first stream to produce a chunk. When every attempt fails, the first failure is returned so
+// bootstrap retries handle it.
+func (h *BaseAPIHandler) executeStreamRace(ctx context.Context, racers []string, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	results := make(chan *hedgeAttempt, len(racers))
+	attempts := startRaceAttempts(ctx, opts, racers, func(attemptCtx context.Context, attempt *hedgeAttempt, attemptProviders []string, attemptOpts coreexecutor.Options) {
+		go attempt.runStream(attemptCtx, h.AuthManager, attemptProviders, req, attemptOpts, results)
+	})
+	winner, errRace := h.awaitRaceWinner(ctx, req.Model, attempts, results, (*hedgeAttempt).answered)
+	if errRace != nil {
+		return nil, errRace
+	}
+	// Losers still waiting for their first chunk are cancelled once the winning stream ends.
+	cancels := make([]context.CancelFunc, 0, len(attempts))
+	for _, attempt := range attempts {
+		cancels = append(cancels, attempt.cancel)
+	}
+	winner.cancel = func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+	h.publishRaceWinner(ctx, opts.Metadata, winner)
+	return winner.stream(ctx)
+}
+
+// raceProviders returns the distinct providers a request is raced across, at most fanout of
+// them in routing order. Racing compares backends, so each attempt is pinned to its own
+// provider; fewer than two providers disable racing.
+func raceProviders(providers []string, fanout int) []string {
+	if fanout < 2 {
+		return nil
+	}
+	racers := make([]string, 0, fanout)
+	for _, provider := range providers {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" || slices.Contains(racers, provider) {
+			continue
+		}
+		racers = append(racers, provider)
+		if len(racers) == fanout {
+			break
+		}
+	}
+	return racers
+}
+
+// startRaceAttempts starts one attempt per racing provider, each restricted to its provider.
+func startRaceAttempts(ctx context.Context, opts coreexecutor.Options, racers []string, run func(context.Context, *hedgeAttempt, []string, coreexecutor.Options)) []*hedgeAttempt {
+	var excluded []string
+	if existing, ok := opts.Metadata[coreexecutor.ExcludedAuthsMetadataKey].([]string); ok {
+		excluded = existing
+	}
+	attempts := make([]*hedgeAttempt, 0, len(racers))
+	for _, provider := range racers {
+		attempt, attemptCtx, attemptOpts := newHedgeAttempt(ctx, opts, slices.Clone(excluded))
+		run(attemptCtx, attempt, []string{provider}, attemptOpts)
+		attempts = append(attempts, attempt)
+	}
+	return attempts
+}
+
+// awaitRaceWinner returns the first attempt that answered, or the first failed attempt when
+// none did. Attempts still running are recorded and discarded once they finish, so their
+// latency is measured against the winner's.
+func (h *BaseAPIHandler) awaitRaceWinner(ctx context.Context, model string, attempts []*hedgeAttempt, results <-chan *hedgeAttempt, answered func(*hedgeAttempt) bool) (*hedgeAttempt, error) {
+	log.Debugf("race: sent request for model %s to %d credentials", model, len(attempts))
+	var failed *hedgeAttempt
+	for pending := len(attempts); pending > 0; {
+		select {
+		case <-ctx.Done():
+			for _, attempt := range attempts {
+				attempt.cancel()
+			}
+			go drainHedgeAttempts(results, pending)
+			return nil, ctx.Err()
+		case attempt := <-results:
+			pending--
+			won := answered(attempt)
+			h.recordRaceAttempt(model, attempt, won)
+			if won {
+				go h.settleRace(model, results, pending)
+				return attempt, nil
+			}
+			if failed == nil {
+				failed = attempt
+			} else {
+				attempt.discard()
+			}
+		}
+	}
+	return failed, nil
+}
+
+// settleRace records and discards the attempts that lost a race as they finish.
+func (h *BaseAPIHandler) settleRace(model string, results <-chan *hedgeAttempt, pending int) {
+	for ; pending > 0; pending-- {
+		attempt := <-results
+		h.recordRaceAttempt(model, attempt, false)
+		attempt.discard()
+	}
+}
+
+// recordRaceAttempt adds the outcome of a race attempt to the race stats.
+func (h *BaseAPIHandler) recordRaceAttempt(model string, attempt *hedgeAttempt, won bool) {
+	outcome := racestats.Outcome{Provider: h.raceAttemptProvider(attempt), Won: won, Latency: attempt.latency}
+	switch {
+	case attempt.err != nil:
+		outcome.Failed = !errors.Is(attempt.err, context.Canceled)
+	case attempt.hasFirst && attempt.first.Err != nil:
+		outcome.Failed = true
+	}
+	racestats.Default().Record(model, outcome)
+}
+
+func (h *BaseAPIHandler) raceAttemptProvider(attempt *hedgeAttempt) string {
+	authID := attempt.selectedAuthID()
+	if authID == "" || h.AuthManager == nil {
+		return ""
+	}
+	if auth, ok := h.AuthManager.GetByID(authID); ok && auth != nil {
+		return auth.Provider
+	}
+	return ""
+}
+
+// publishRaceWinner reports the credential of the returned attempt and names its provider in
+// the RaceWinnerHeader response header.
+func (h *BaseAPIHandler) publishRaceWinner(ctx context.Context, meta map[string]any, winner *hedgeAttempt) {
+	publishHedgeWinner(meta, winner)
+	provider := h.raceAttemptProvider(winner)
+	if provider == "" {
+		return
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Header(RaceWinnerHeader, provider)
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/racestats"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// raceProviderExecutor serves one provider. A slow executor answers only when its call is
+// cancelled; a fast one answers at once.
+type raceProviderExecutor struct {
+	provider  string
+	slow      bool
+	cancelled chan struct{}
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *raceProviderExecutor) Identifier() string { return e.provider }
+
+func (e *raceProviderExecutor) record() {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+}
+
+func (e *raceProviderExecutor) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+func (e *raceProviderExecutor) Execute(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.record()
+	if e.slow {
+		<-ctx.Done()
+		close(e.cancelled)
+		return coreexecutor.Response{}, ctx.Err()
+	}
+	return coreexecutor.Response{Payload: []byte(e.provider)}, nil
+}
+
+func (e *raceProviderExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.record()
+	chunks := make(chan coreexecutor.StreamChunk, 1)
+	if e.slow {
+		go func() {
+			defer close(chunks)
+			<-ctx.Done()
+			close(e.cancelled)
+		}()
+		return &coreexecutor.StreamResult{Chunks: chunks}, nil
+	}
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(e.provider)}
+	close(chunks)
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (e *raceProviderExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *raceProviderExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *raceProviderExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+// newRaceTestHandler serves model from a slow codex credential, a second codex credential and
+// a fast claude credential, with race mode enabled by header.
+func newRaceTestHandler(t *testing.T, model string) (*BaseAPIHandler, *raceProviderExecutor, *raceProviderExecutor) {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	slow := &raceProviderExecutor{provider: "codex", slow: true, cancelled: make(chan struct{})}
+	fast := &raceProviderExecutor{provider: "claude"}
+	manager.RegisterExecutor(slow)
+	manager.RegisterExecutor(fast)
+	for id, provider := range map[string]string{model + "-codex1": "codex", model + "-codex2": "codex", model + "-claude": "claude"} {
+		auth := &coreauth.Auth{ID: id, Provider: provider, Status: coreauth.StatusActive, Metadata: map[string]any{"email": id + "@example.com"}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, provider, []*registry.ModelInfo{{ID: model}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+	racestats.Default().Reset()
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{Race: sdkconfig.RaceConfig{AllowHeader: true}}, manager)
+	return handler, slow, fast
+}
+
+func TestExecuteStreamWithAuthManager_RacesProvidersOnHeader(t *testing.T) {
+	handler, slow, fast := newRaceTestHandler(t, "race-stream-model")
+	ctx := contextWithHeaders(http.Header{RaceHeader: {"3"}})
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "race-stream-model", []byte(`{"model":"race-stream-model"}`), "")
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	if string(got) != "claude" {
+		t.Fatalf("payload = %q, want the fastest provider's", got)
+	}
+	if provider := ctx.Value("gin").(*gin.Context).Writer.Header().Get(RaceWinnerHeader); provider != "claude" {
+		t.Fatalf("%s = %q, want claude", RaceWinnerHeader, provider)
+	}
+	select {
+	case <-slow.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("losing attempt was not cancelled")
+	}
+	if slow.Calls() != 1 || fast.Calls() != 1 {
+		t.Fatalf("calls = codex %d, claude %d; want one attempt per provider", slow.Calls(), fast.Calls())
+	}
+
+	waitForRaceStats(t, 2)
+	for _, stat := range racestats.Default().Snapshot().Stats {
+		if stat.Model != "race-stream-model" || (stat.Provider == "claude") != (stat.Wins == 1) {
+			t.Fatalf("race stats = %+v, want one claude win", racestats.Default().Snapshot().Stats)
+		}
+	}
+}
+
+func TestExecuteWithAuthManager_CancelsLosingRaceAttempts(t *testing.T) {
+	handler, slow, fast := newRaceTestHandler(t, "race-model")
+	ctx := contextWithHeaders(http.Header{RaceHeader: {"2"}})
+
+	body, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "race-model", []byte(`{"model":"race-model"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(body) != "claude" {
+		t.Fatalf("body = %q, want the fastest provider's", body)
+	}
+	select {
+	case <-slow.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("losing attempt was not cancelled after the winner returned")
+	}
+	if slow.Calls() != 1 || fast.Calls() != 1 {
+		t.Fatalf("calls = codex %d, claude %d; want one attempt per provider", slow.Calls(), fast.Calls())
+	}
+}
+
+func TestRaceProviders(t *testing.T) {
+	if got := raceProviders([]string{"codex", "Claude", "codex", "gemini"}, 2); !slices.Equal(got, []string{"codex", "claude"}) {
+		t.Fatalf("raceProviders = %v, want [codex claude]", got)
+	}
+	if got := raceProviders([]string{"codex"}, 3); len(got) != 1 {
+		t.Fatalf("raceProviders for one provider = %v, want it alone", got)
+	}
+	if got := raceProviders([]string{"codex", "claude"}, 1); got != nil {
+		t.Fatalf("raceProviders without fanout = %v, want nil", got)
+	}
+}
+
+func waitForRaceStats(t *testing.T, attempts int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var recorded int64
+		for _, stat := range racestats.Default().Snapshot().Stats {
+			recorded += stat.Attempts
+		}
+		if recorded >= attempts {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("recorded %d race attempts, want %d", recorded, attempts)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRaceFanout(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Race: sdkconfig.RaceConfig{Fanout: 2, Models: []string{"gpt-5*"}, AllowHeader: true, MaxFanout: 3},
+	}, manager)
+	cases := []struct {
+		name   string
+		ctx    context.Context
+		model  string
+		opts   coreexecutor.Options
+		fanout int
+	}{
+		{name: "configured model", ctx: context.Background(), model: "GPT-5-codex", fanout: 2},
+		{name: "unmatched model", ctx: context.Background(), model: "claude-opus"},
+		{name: "header capped", ctx: contextWithHeaders(http.Header{RaceHeader: {"9"}}), model: "claude-opus", fanout: 3},
+		{name: "header opt-out", ctx: contextWithHeaders(http.Header{RaceHeader: {"0"}}), model: "gpt-5"},
+		{name: "pinned auth", ctx: context.Background(), model: "gpt-5", opts: coreexecutor.Options{Metadata: map[string]any{coreexecutor.PinnedAuthMetadataKey: "auth"}}},
+	}
+	for _, tc := range cases {
+		if got := handler.raceFanout(tc.ctx, tc.model, tc.opts); got != tc.fanout {
+			t.Errorf("%s: fanout = %d, want %d", tc.name, got, tc.fanout)
+		}
+	}
+}
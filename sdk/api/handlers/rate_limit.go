@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// RateLimitWarningHeader marks responses to requests that exceeded a rate limit still in its
+// warn-only period. Its value names the limit that would have rejected the request.
+const RateLimitWarningHeader = "X-CPA-RateLimit-Warning"
+
+// ProviderRateLimiter enforces per-client request budgets for a resolved upstream provider.
+type ProviderRateLimiter interface {
+	AllowProvider(apiKey, provider string) (bool, time.Duration)
+}
+
+// ProviderRateLimitChecker is implemented by limiters that support warn-only limits. warned
+// reports a request admitted only because the exceeded limit is still in its warn-only period.
+type ProviderRateLimitChecker interface {
+	CheckProvider(apiKey, provider string) (allowed, warned bool, retryAfter time.Duration)
+}
+
+// ProviderRateLimitPeeker is implemented by limiters that can report whether a provider's
+// budget would admit a request without consuming it. Without it every resolved provider stays
+// eligible and only the selected one is debited.
+type ProviderRateLimitPeeker interface {
+	PeekProvider(apiKey, provider string) (allowed, warned bool, retryAfter time.Duration)
+}
+
+// RateLimitError reports a request rejected by the proxy's own rate limiter.
+type RateLimitError struct {
+	// Provider is the upstream provider whose budget was exhausted; empty for the per-key budget.
+	Provider string
+	// RetryAfter is the wait until the next request would be admitted.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *RateLimitError) Error() string {
+	if e == nil || e.Provider == "" {
+		return "rate limit exceeded for this API key"
+	}
+	return fmt.Sprintf("rate limit exceeded for this API key on provider %s", e.Provider)
+}
+
+// StatusCode returns the HTTP status used for rate limited requests.
+func (e *RateLimitError) StatusCode() int { return http.StatusTooManyRequests }
+
+// Headers returns the Retry-After header for the rejected request.
+func (e *RateLimitError) Headers() http.Header {
+	if e == nil {
+		return nil
+	}
+	seconds := int64(e.RetryAfter / time.Second)
+	if e.RetryAfter%time.Second != 0 {
+		seconds++
+	}
+	return http.Header{"Retry-After": []string{strconv.FormatInt(max(seconds, 1), 10)}}
+}
+
+// SetRateLimiter configures the optional per-provider rate limiter.
+func (h *BaseAPIHandler) SetRateLimiter(limiter ProviderRateLimiter) {
+	if h == nil {
+		return
+	}
+	if isNilInterface(limiter) {
+		h.RateLimiter = nil
+		return
+	}
+	h.RateLimiter = limiter
+}
+
+// checkProviderRateLimit drops the resolved providers whose budget the calling API key has
+// exhausted, so routing falls back to the others. The request is rejected only when every
+// provider is exhausted. No budget is consumed here; debitProviderRateLimit charges the
+// provider of the credential the request is actually sent to.
+func (h *BaseAPIHandler) checkProviderRateLimit(ctx context.Context, providers []string) ([]string, *interfaces.ErrorMessage) {
+	if h == nil || h.RateLimiter == nil {
+		return providers, nil
+	}
+	peeker, ok := h.RateLimiter.(ProviderRateLimitPeeker)
+	if !ok {
+		return providers, nil
+	}
+	apiKey := clientAPIKeyFromContext(ctx)
+	if apiKey == "" {
+		return providers, nil
+	}
+	var exhausted *RateLimitError
+	allowed := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		if ok, _, retryAfter := peeker.PeekProvider(apiKey, provider); !ok {
+			if exhausted == nil || retryAfter < exhausted.RetryAfter {
+				exhausted = &RateLimitError{Provider: provider, RetryAfter: retryAfter}
+			}
+			continue
+		}
+		allowed = append(allowed, provider)
+	}
+	if len(allowed) == 0 && exhausted != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: exhausted}
+	}
+	return allowed, nil
+}
+
+// debitProviderRateLimit chains a selected-auth callback onto meta that consumes a token from
+// the budget of the selected credential's provider for the calling API key. Every credential
+// the request is sent to is charged, including retries on another credential.
+func (h *BaseAPIHandler) debitProviderRateLimit(ctx context.Context, meta map[string]any) {
+	if h == nil || h.RateLimiter == nil || h.AuthManager == nil || meta == nil {
+		return
+	}
+	apiKey := clientAPIKeyFromContext(ctx)
+	if apiKey == "" {
+		return
+	}
+	limiter := h.RateLimiter
+	checker, _ := limiter.(ProviderRateLimitChecker)
+	previous, _ := meta[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string))
+	meta[coreexecutor.SelectedAuthCallbackMetadataKey] = func(authID string) {
+		if previous != nil {
+			previous(authID)
+		}
+		auth, ok := h.AuthManager.GetByID(authID)
+		if !ok || auth == nil || auth.Provider == "" {
+			return
+		}
+		provider := auth.Provider
+		var allowed, warned bool
+		if checker != nil {
+			allowed, warned, _ = checker.CheckProvider(apiKey, provider)
+		} else {
+			allowed, _ = limiter.AllowProvider(apiKey, provider)
+		}
+		if !allowed {
+			// The budget ran out between the check and the selection; the request is already
+			// on its way, so it is let through.
+			log.Debugf("rate limit: key %s exceeded the budget of provider %s while the request was routed", util.HideAPIKey(apiKey), provider)
+			return
+		}
+		if warned {
+			log.Warnf("rate limit (warn-only): key %s would have been limited on provider %s", util.HideAPIKey(apiKey), provider)
+			if ginCtx, okGin := ctx.Value("gin").(*gin.Context); okGin && ginCtx != nil {
+				ginCtx.Header(RateLimitWarningHeader, "provider="+provider)
+			}
+		}
+	}
+}
+
+func clientAPIKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	value, exists := ginCtx.Get("userApiKey")
+	if !exists {
+		return ""
+	}
+	apiKey, _ := value.(string)
+	return strings.TrimSpace(apiKey)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+type fakeProviderRateLimiter struct {
+	calls   []string
+	peeks   []string
+	blocked string
+}
+
+func (f *fakeProviderRateLimiter) AllowProvider(apiKey, provider string) (bool, time.Duration) {
+	f.calls = append(f.calls, apiKey+"/"+provider)
+	if provider == f.blocked {
+		return false, 1500 * time.Millisecond
+	}
+	return true, 0
+}
+
+func (f *fakeProviderRateLimiter) PeekProvider(apiKey, provider string) (bool, bool, time.Duration) {
+	f.peeks = append(f.peeks, apiKey+"/"+provider)
+	if provider == f.blocked {
+		return false, false, 1500 * time.Millisecond
+	}
+	return true, false, 0
+}
+
+func rateLimitTestContext(apiKey string) (context.Context, *gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("userApiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c), c, recorder
+}
+
+func TestCheckProviderRateLimit_SkipsExhaustedProviders(t *testing.T) {
+	ctx, c, recorder := rateLimitTestContext("alice")
+	limiter := &fakeProviderRateLimiter{blocked: "claude"}
+	handler := NewBaseAPIHandlers(nil, nil)
+	handler.SetRateLimiter(limiter)
+
+	providers, errMsg := handler.checkProviderRateLimit(ctx, []string{"claude", "gemini"})
+	if errMsg != nil {
+		t.Fatalf("unexpected rejection: %v", errMsg.Error)
+	}
+	if len(providers) != 1 || providers[0] != "gemini" {
+		t.Fatalf("providers = %v, want [gemini]", providers)
+	}
+	if len(limiter.calls) != 0 {
+		t.Fatalf("limiter calls = %v, want no budget consumed by the check", limiter.calls)
+	}
+
+	_, errMsg = handler.checkProviderRateLimit(ctx, []string{"claude"})
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("errMsg = %+v, want 429", errMsg)
+	}
+	handler.WriteErrorResponse(c, errMsg)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want %q", got, "2")
+	}
+}
+
+func TestDebitProviderRateLimit_ChargesSelectedProvider(t *testing.T) {
+	ctx, _, _ := rateLimitTestContext("alice")
+	manager := coreauth.NewManager(nil, nil, nil)
+	auth := &coreauth.Auth{ID: "debit-auth", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	limiter := &fakeProviderRateLimiter{}
+	handler := NewBaseAPIHandlers(nil, manager)
+	handler.SetRateLimiter(limiter)
+
+	var selected string
+	meta := map[string]any{coreexecutor.SelectedAuthCallbackMetadataKey: func(authID string) { selected = authID }}
+	handler.debitProviderRateLimit(ctx, meta)
+	callback, _ := meta[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string))
+	if callback == nil {
+		t.Fatal("selected-auth callback missing")
+	}
+	callback("debit-auth")
+
+	if selected != "debit-auth" {
+		t.Fatalf("previous callback got %q, want debit-auth", selected)
+	}
+	if len(limiter.calls) != 1 || limiter.calls[0] != "alice/claude" {
+		t.Fatalf("limiter calls = %v, want only the selected provider charged", limiter.calls)
+	}
+}
+
+func TestCheckProviderRateLimit_SkipsWithoutClientKey(t *testing.T) {
+	limiter := &fakeProviderRateLimiter{blocked: "claude"}
+	handler := NewBaseAPIHandlers(nil, nil)
+	handler.SetRateLimiter(limiter)
+
+	if _, errMsg := handler.checkProviderRateLimit(context.Background(), []string{"claude"}); errMsg != nil {
+		t.Fatalf("unexpected rejection without client key: %v", errMsg.Error)
+	}
+	if len(limiter.peeks) != 0 {
+		t.Fatalf("limiter peeks = %v, want none", limiter.peeks)
+	}
+}
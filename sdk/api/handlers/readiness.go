@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/readiness"
+)
+
+// notReadyErrorResponse is the 503 body returned when no credential or model can serve a request.
+type notReadyErrorResponse struct {
+	Error notReadyErrorDetail `json:"error"`
+}
+
+type notReadyErrorDetail struct {
+	Message     string   `json:"message"`
+	Type        string   `json:"type"`
+	Code        string   `json:"code"`
+	Remediation []string `json:"remediation,omitempty"`
+}
+
+// Readiness reports whether the proxy has usable credentials and models.
+func (h *BaseAPIHandler) Readiness() readiness.Report {
+	return readiness.Check(h.AuthManager)
+}
+
+// ModelsNotice returns an explanation to attach to an empty model listing, or nil when
+// the proxy is ready and the listing is merely filtered.
+func (h *BaseAPIHandler) ModelsNotice() map[string]any {
+	if h == nil || h.AuthManager == nil {
+		return nil
+	}
+	report := h.Readiness()
+	if report.Ready {
+		return nil
+	}
+	return map[string]any{
+		"reason":      report.Reason,
+		"message":     report.Message,
+		"remediation": report.Remediation,
+	}
+}
+
+// notReadyError returns a 503 with remediation hints when a request cannot be routed because
+// no credentials or models are available, or nil when the proxy is ready. Handlers without an
+// auth manager cannot tell and keep the generic routing error.
+func (h *BaseAPIHandler) notReadyError() *interfaces.ErrorMessage {
+	if h == nil || h.AuthManager == nil {
+		return nil
+	}
+	report := h.Readiness()
+	if report.Ready {
+		return nil
+	}
+	body, errMarshal := json.Marshal(notReadyErrorResponse{Error: notReadyErrorDetail{
+		Message:     report.Message,
+		Type:        "server_error",
+		Code:        report.Reason,
+		Remediation: report.Remediation,
+	}})
+	if errMarshal != nil {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: errors.New(report.Message)}
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: errors.New(string(body))}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/readiness"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestExecuteWithoutCredentialsReturnsRemediation(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "readiness-unknown-model", []byte(`{"model":"readiness-unknown-model"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("ExecuteWithAuthManager() error = %+v, want 503", errMsg)
+	}
+	body := BuildErrorResponseBody(errMsg.StatusCode, errMsg.Error.Error())
+	if code := gjson.GetBytes(body, "error.code").String(); code != readiness.ReasonNoCredentials {
+		t.Fatalf("error.code = %q, want %q; body=%s", code, readiness.ReasonNoCredentials, body)
+	}
+	if hints := gjson.GetBytes(body, "error.remediation").Array(); len(hints) == 0 {
+		t.Fatalf("error.remediation is empty; body=%s", body)
+	}
+
+	notice := handler.ModelsNotice()
+	if notice == nil || notice["reason"] != readiness.ReasonNoCredentials {
+		t.Fatalf("ModelsNotice() = %v, want %q", notice, readiness.ReasonNoCredentials)
+	}
+}
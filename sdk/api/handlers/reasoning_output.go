@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openAIReasoningFields are the message and delta fields that carry reasoning in OpenAI chat
+// completions, including the non-standard ones some compatible upstreams use.
+var openAIReasoningFields = []string{"reasoning_content", "reasoning", "reasoning_details"}
+
+// responsesReasoningEvents are the Responses stream events that only carry reasoning.
+var responsesReasoningEvents = map[string]bool{
+	"response.reasoning_summary_part.added": true,
+	"response.reasoning_summary_part.done":  true,
+	"response.reasoning_summary_text.delta": true,
+	"response.reasoning_summary_text.done":  true,
+	"response.reasoning_text.delta":         true,
+	"response.reasoning_text.done":          true,
+}
+
+func (h *BaseAPIHandler) stripReasoningEnabled() bool {
+	return h != nil && h.Cfg != nil && h.Cfg.ReasoningOutput == internalconfig.ReasoningOutputStrip
+}
+
+// stripReasoning removes reasoning from a non-streaming response when reasoning-output is strip.
+func (h *BaseAPIHandler) stripReasoning(responseProtocol string, body []byte) []byte {
+	if !h.stripReasoningEnabled() || !gjson.ValidBytes(body) {
+		return body
+	}
+	switch responseProtocol {
+	case OpenAI:
+		for i := range gjson.GetBytes(body, "choices").Array() {
+			body = deleteFields(body, fmt.Sprintf("choices.%d.message", i), openAIReasoningFields)
+		}
+	case Claude:
+		body = filterArray(body, "content", isClaudeThinkingBlock)
+	case Gemini:
+		for i := range gjson.GetBytes(body, "candidates").Array() {
+			body = filterArray(body, fmt.Sprintf("candidates.%d.content.parts", i), isGeminiThoughtPart)
+		}
+	case OpenaiResponse:
+		body = filterArray(body, "output", isResponsesReasoningItem)
+	}
+	return body
+}
+
+// reasoningStripStream removes reasoning events from one streaming response. Claude content
+// blocks and Responses output items after a removed one are renumbered so indices stay
+// contiguous.
+type reasoningStripStream struct {
+	responseProtocol string
+	dropped          map[int64]bool
+}
+
+func (h *BaseAPIHandler) newReasoningStripStream(responseProtocol string) *reasoningStripStream {
+	if !h.stripReasoningEnabled() {
+		return nil
+	}
+	switch responseProtocol {
+	case OpenAI, Claude, Gemini, OpenaiResponse:
+		return &reasoningStripStream{responseProtocol: responseProtocol, dropped: make(map[int64]bool)}
+	default:
+		return nil
+	}
+}
+
+// Chunk rewrites the events of one chunk; a chunk left without events is dropped.
+func (s *reasoningStripStream) Chunk(chunk []byte) ([]byte, *interfaces.ErrorMessage) {
+	return rewriteStreamEvents(chunk, s.event), nil
+}
+
+// Close has nothing to flush.
+func (s *reasoningStripStream) Close() *interfaces.ErrorMessage { return nil }
+
+// event rewrites one stream event and reports whether it is kept.
+func (s *reasoningStripStream) event(data []byte) ([]byte, bool) {
+	event := gjson.ParseBytes(data)
+	switch s.responseProtocol {
+	case OpenAI:
+		choices := event.Get("choices").Array()
+		for i := range choices {
+			data = deleteFields(data, fmt.Sprintf("choices.%d.delta", i), openAIReasoningFields)
+		}
+		if len(choices) == 0 || event.Get("usage").IsObject() {
+			return data, true
+		}
+		// Drop chunks that only carried reasoning.
+		for _, choice := range gjson.GetBytes(data, "choices").Array() {
+			if len(choice.Get("delta").Map()) > 0 || choice.Get("finish_reason").String() != "" {
+				return data, true
+			}
+		}
+		return nil, false
+	case Claude:
+		return s.indexedEvent(data, event, "index", event.Get("type").String() == "content_block_start" && isClaudeThinkingBlock(event.Get("content_block")))
+	case OpenaiResponse:
+		eventType := event.Get("type").String()
+		if responsesReasoningEvents[eventType] {
+			return nil, false
+		}
+		if output := event.Get("response.output"); output.IsArray() {
+			data = filterArray(data, "response.output", isResponsesReasoningItem)
+		}
+		return s.indexedEvent(data, event, "output_index", eventType == "response.output_item.added" && isResponsesReasoningItem(event.Get("item")))
+	case Gemini:
+		candidates := event.Get("candidates").Array()
+		hadParts := false
+		for i, candidate := range candidates {
+			hadParts = hadParts || len(candidate.Get("content.parts").Array()) > 0
+			data = filterArray(data, fmt.Sprintf("candidates.%d.content.parts", i), isGeminiThoughtPart)
+		}
+		if !hadParts || event.Get("usageMetadata").Exists() {
+			return data, true
+		}
+		for _, candidate := range gjson.GetBytes(data, "candidates").Array() {
+			if len(candidate.Get("content.parts").Array()) > 0 || candidate.Get("finishReason").String() != "" {
+				return data, true
+			}
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+// indexedEvent drops events of removed indices and shifts later indices down. opens is set
+// for the event that starts a block or item to remove.
+func (s *reasoningStripStream) indexedEvent(data []byte, event gjson.Result, field string, opens bool) ([]byte, bool) {
+	indexValue := event.Get(field)
+	if !indexValue.Exists() {
+		return data, true
+	}
+	index := indexValue.Int()
+	if opens {
+		s.dropped[index] = true
+	}
+	if s.dropped[index] {
+		return nil, false
+	}
+	shift := int64(0)
+	for droppedIndex := range s.dropped {
+		if droppedIndex < index {
+			shift++
+		}
+	}
+	if shift == 0 {
+		return data, true
+	}
+	out, err := sjson.SetBytes(data, field, index-shift)
+	if err != nil {
+		return data, true
+	}
+	return out, true
+}
+
+// rewriteStreamEvents applies rewrite to every JSON event of an SSE chunk or bare JSON chunk.
+// Dropped events lose their event line and blank separator too.
+func rewriteStreamEvents(chunk []byte, rewrite func(data []byte) ([]byte, bool)) []byte {
+	lines := bytes.Split(chunk, []byte("\n"))
+	out := make([][]byte, 0, len(lines))
+	skipBlank := false
+	for _, line := range lines {
+		trimmed := bytes.TrimSpace(line)
+		if skipBlank && len(trimmed) == 0 {
+			skipBlank = false
+			continue
+		}
+		skipBlank = false
+		data, isData := bytes.CutPrefix(trimmed, []byte("data:"))
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || data[0] != '{' || !gjson.ValidBytes(data) {
+			out = append(out, line)
+			continue
+		}
+		rewritten, keep := rewrite(data)
+		if !keep {
+			if n := len(out); n > 0 && bytes.HasPrefix(bytes.TrimSpace(out[n-1]), []byte("event:")) {
+				out = out[:n-1]
+			}
+			skipBlank = true
+			continue
+		}
+		if isData {
+			rewritten = append([]byte("data: "), rewritten...)
+		}
+		out = append(out, rewritten)
+	}
+	joined := bytes.Join(out, []byte("\n"))
+	if len(bytes.TrimSpace(joined)) == 0 {
+		return nil
+	}
+	return joined
+}
+
+func deleteFields(body []byte, path string, fields []string) []byte {
+	for _, field := range fields {
+		if gjson.GetBytes(body, path+"."+field).Exists() {
+			body, _ = sjson.DeleteBytes(body, path+"."+field)
+		}
+	}
+	return body
+}
+
+// filterArray removes the elements of the array at path that match drop.
+func filterArray(body []byte, path string, drop func(gjson.Result) bool) []byte {
+	items := gjson.GetBytes(body, path)
+	if !items.IsArray() {
+		return body
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	removed := false
+	for _, item := range items.Array() {
+		if drop(item) {
+			removed = true
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(item.Raw)
+	}
+	buf.WriteByte(']')
+	if !removed {
+		return body
+	}
+	out, err := sjson.SetRawBytes(body, path, buf.Bytes())
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func isClaudeThinkingBlock(block gjson.Result) bool {
+	blockType := block.Get("type").String()
+	return blockType == "thinking" || blockType == "redacted_thinking"
+}
+
+func isGeminiThoughtPart(part gjson.Result) bool {
+	return part.Get("thought").Bool()
+}
+
+func isResponsesReasoningItem(item gjson.Result) bool {
+	return item.Get("type").String() == "reasoning"
+}
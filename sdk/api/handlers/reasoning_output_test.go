@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newReasoningStripHandler() *BaseAPIHandler {
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{ReasoningOutput: internalconfig.ReasoningOutputStrip}, nil)
+}
+
+func TestStripReasoning_NonStreaming(t *testing.T) {
+	handler := newReasoningStripHandler()
+
+	openai := handler.stripReasoning("openai", []byte(`{"choices":[{"message":{"role":"assistant","content":"4","reasoning_content":"2+2"}}]}`))
+	if gjson.GetBytes(openai, "choices.0.message.reasoning_content").Exists() || gjson.GetBytes(openai, "choices.0.message.content").String() != "4" {
+		t.Fatalf("openai = %s", openai)
+	}
+	claude := handler.stripReasoning("claude", []byte(`{"content":[{"type":"thinking","thinking":"2+2","signature":"s"},{"type":"text","text":"4"}]}`))
+	if blocks := gjson.GetBytes(claude, "content").Array(); len(blocks) != 1 || blocks[0].Get("type").String() != "text" {
+		t.Fatalf("claude = %s", claude)
+	}
+	gemini := handler.stripReasoning("gemini", []byte(`{"candidates":[{"content":{"parts":[{"text":"2+2","thought":true},{"text":"4"}]}}]}`))
+	if parts := gjson.GetBytes(gemini, "candidates.0.content.parts").Array(); len(parts) != 1 || parts[0].Get("text").String() != "4" {
+		t.Fatalf("gemini = %s", gemini)
+	}
+	responses := handler.stripReasoning("openai-response", []byte(`{"output":[{"type":"reasoning","summary":[]},{"type":"message","content":[]}]}`))
+	if items := gjson.GetBytes(responses, "output").Array(); len(items) != 1 || items[0].Get("type").String() != "message" {
+		t.Fatalf("responses = %s", responses)
+	}
+
+	passthrough := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	body := []byte(`{"content":[{"type":"thinking","thinking":"2+2"}]}`)
+	if out := passthrough.stripReasoning("claude", body); string(out) != string(body) {
+		t.Fatalf("passthrough changed body: %s", out)
+	}
+}
+
+func TestStripReasoning_ClaudeStreamRenumbersBlocks(t *testing.T) {
+	stream := newReasoningStripHandler().newReasoningStripStream("claude")
+	events := []string{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\",\"thinking\":\"\"}}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"hmm\"}}\n\n" +
+			"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n" +
+			"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"4\"}}\n\n",
+	}
+	var out strings.Builder
+	for _, event := range events {
+		chunk, errMsg := stream.Chunk([]byte(event))
+		if errMsg != nil {
+			t.Fatalf("Chunk() error = %+v", errMsg)
+		}
+		out.Write(chunk)
+	}
+	got := out.String()
+	if strings.Contains(got, "thinking") {
+		t.Fatalf("thinking kept: %q", got)
+	}
+	if !strings.Contains(got, "event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\"") ||
+		!strings.Contains(got, `"index":0,"delta":{"type":"text_delta","text":"4"}`) {
+		t.Fatalf("text block not renumbered: %q", got)
+	}
+	if strings.Count(got, "event:") != 3 {
+		t.Fatalf("events = %q", got)
+	}
+}
+
+func TestStripReasoning_OpenAIStreamDropsReasoningOnlyChunks(t *testing.T) {
+	stream := newReasoningStripHandler().newReasoningStripStream("openai")
+	chunk, _ := stream.Chunk([]byte(`{"choices":[{"index":0,"delta":{"reasoning_content":"hmm"},"finish_reason":null}]}`))
+	if len(chunk) != 0 {
+		t.Fatalf("reasoning-only chunk kept: %s", chunk)
+	}
+	chunk, _ = stream.Chunk([]byte(`{"choices":[{"index":0,"delta":{"content":"4","reasoning_content":"x"},"finish_reason":null}]}`))
+	if gjson.GetBytes(chunk, "choices.0.delta.content").String() != "4" || gjson.GetBytes(chunk, "choices.0.delta.reasoning_content").Exists() {
+		t.Fatalf("chunk = %s", chunk)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+const (
+	// RequestExtensionsField is the reserved top-level request body field carrying proxy extensions.
+	// It is always removed before the body reaches a handler or an upstream provider.
+	RequestExtensionsField = "cliproxy"
+
+	// RequestExtensionsGinKey stores the parsed *RequestExtensions on the gin context.
+	RequestExtensionsGinKey = "cliproxyRequestExtensions"
+
+	// SessionIDHeader is the inbound header used for session-affinity routing.
+	SessionIDHeader = "X-Session-ID"
+
+	maxRequestExtensionTags      = 16
+	maxRequestExtensionTagLength = 64
+)
+
+// RequestExtensions is the reserved "cliproxy" object clients may add to request bodies.
+//
+//	{"model": "...", "cliproxy": {"routing": {"provider": "claude"}, "cache": {"session_id": "s1"}, "tags": ["ci"], "dry_run": true}}
+type RequestExtensions struct {
+	// Routing narrows provider selection for this request.
+	Routing RequestRoutingHints `json:"routing"`
+	// Cache controls prompt-cache reuse for this request.
+	Cache RequestCacheControls `json:"cache"`
+	// Tags are attached to the request's usage records.
+	Tags []string `json:"tags"`
+	// DryRun resolves routing and returns the execution plan without calling any provider.
+	DryRun bool `json:"dry_run"`
+}
+
+// RequestRoutingHints narrows provider selection.
+type RequestRoutingHints struct {
+	// Provider restricts execution to one provider, e.g. "claude" or an openai-compatibility name.
+	Provider string `json:"provider"`
+}
+
+// RequestCacheControls controls prompt-cache reuse.
+type RequestCacheControls struct {
+	// SessionID pins the request to the credential used by earlier requests with the same ID
+	// (when session affinity is enabled) so upstream prompt caches are reused. It is equivalent
+	// to sending the X-Session-ID header.
+	SessionID string `json:"session_id"`
+}
+
+// ExtractRequestExtensions parses and removes the reserved "cliproxy" field from a JSON body.
+// It returns nil extensions and the unchanged body when the field is absent. Unknown extension
+// fields and invalid values are rejected so typos do not silently fall through.
+func ExtractRequestExtensions(body []byte) (*RequestExtensions, []byte, error) {
+	if !bytes.Contains(body, []byte(`"`+RequestExtensionsField+`"`)) {
+		return nil, body, nil
+	}
+	node := gjson.GetBytes(body, RequestExtensionsField)
+	if !node.Exists() {
+		return nil, body, nil
+	}
+	if !node.IsObject() {
+		return nil, nil, fmt.Errorf("%s must be an object", RequestExtensionsField)
+	}
+
+	ext := &RequestExtensions{}
+	decoder := json.NewDecoder(strings.NewReader(node.Raw))
+	decoder.DisallowUnknownFields()
+	if errDecode := decoder.Decode(ext); errDecode != nil {
+		return nil, nil, fmt.Errorf("invalid %s extension: %w", RequestExtensionsField, errDecode)
+	}
+	if errNormalize := ext.normalize(); errNormalize != nil {
+		return nil, nil, errNormalize
+	}
+
+	stripped, errDelete := sjson.DeleteBytes(body, RequestExtensionsField)
+	if errDelete != nil {
+		return nil, nil, fmt.Errorf("strip %s extension: %w", RequestExtensionsField, errDelete)
+	}
+	return ext, stripped, nil
+}
+
+func (ext *RequestExtensions) normalize() error {
+	ext.Routing.Provider = strings.ToLower(strings.TrimSpace(ext.Routing.Provider))
+	ext.Cache.SessionID = strings.TrimSpace(ext.Cache.SessionID)
+	if len(ext.Tags) > maxRequestExtensionTags {
+		return fmt.Errorf("%s.tags accepts at most %d tags", RequestExtensionsField, maxRequestExtensionTags)
+	}
+	tags := make([]string, 0, len(ext.Tags))
+	seen := make(map[string]struct{}, len(ext.Tags))
+	for _, tag := range ext.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if len(tag) > maxRequestExtensionTagLength {
+			return fmt.Errorf("%s.tags entries must be at most %d characters", RequestExtensionsField, maxRequestExtensionTagLength)
+		}
+		if _, exists := seen[tag]; exists {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	ext.Tags = tags
+	return nil
+}
+
+// RequestExtensionsFromContext returns the extensions stored on ctx when it is, or carries, a gin context.
+func RequestExtensionsFromContext(ctx context.Context) *RequestExtensions {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.(*gin.Context)
+	if !ok {
+		ginCtx, ok = ctx.Value("gin").(*gin.Context)
+	}
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	raw, exists := ginCtx.Get(RequestExtensionsGinKey)
+	if !exists {
+		return nil
+	}
+	ext, _ := raw.(*RequestExtensions)
+	return ext
+}
+
+// applyRequestRoutingHints copies the client's routing hints into the execution options.
+func applyRequestRoutingHints(ctx context.Context, execOptions modelExecutionOptions) modelExecutionOptions {
+	if ext := RequestExtensionsFromContext(ctx); ext != nil {
+		execOptions.routingProvider = ext.Routing.Provider
+	}
+	return execOptions
+}
+
+// ExecutionPlan describes how a request would be executed. It is returned for dry-run requests.
+type ExecutionPlan struct {
+	Object  string                `json:"object"`
+	Model   string                `json:"model"`
+	Targets []ExecutionPlanTarget `json:"targets"`
+	Tags    []string              `json:"tags,omitempty"`
+}
+
+// ExecutionPlanTarget is one model/provider candidate of an execution plan, in try order.
+type ExecutionPlanTarget struct {
+	Model     string   `json:"model"`
+	Providers []string `json:"providers,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// PlanExecution resolves the providers a request for modelName would be sent to, following
+// synthetic models, model aliases, model failover rules and the routing hints in ext. Model router plugins are
+// not consulted.
+func (h *BaseAPIHandler) PlanExecution(modelName string, ext *RequestExtensions) ExecutionPlan {
+	plan := ExecutionPlan{Object: "cliproxy.execution_plan", Model: modelName}
+	execOptions := modelExecutionOptions{}
+	if ext != nil {
+		execOptions.routingProvider = ext.Routing.Provider
+		plan.Tags = ext.Tags
+	}
+	modelName, _ = h.applySyntheticModel("", modelName, nil)
+	modelName, execOptions = h.applyModelAlias(modelName, execOptions)
+	targets := h.modelFailoverTargets(modelName, execOptions)
+	if len(targets) == 0 {
+		targets = []config.ModelFailoverTarget{{Model: modelName}}
+	}
+	for _, target := range targets {
+		targetOptions := modelFailoverOptions(execOptions, target)
+		entry := ExecutionPlanTarget{Model: target.Model}
+		providers, normalizedModel, errMsg := h.providersForExecution(target.Model, target.Model, true, modelRouteDecision{}, targetOptions)
+		if errMsg != nil {
+			entry.Error = errMsg.Error.Error()
+		} else {
+			entry.Model = normalizedModel
+			entry.Providers = providers
+		}
+		plan.Targets = append(plan.Targets, entry)
+	}
+	return plan
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestExtractRequestExtensions(t *testing.T) {
+	body := []byte(`{"model":"gpt-5","cliproxy":{"tags":["a"],"dry_run":true},"stream":true}`)
+	ext, stripped, errExtract := ExtractRequestExtensions(body)
+	if errExtract != nil {
+		t.Fatalf("ExtractRequestExtensions: %v", errExtract)
+	}
+	if !ext.DryRun || len(ext.Tags) != 1 {
+		t.Fatalf("extensions = %+v", ext)
+	}
+	if string(stripped) != `{"model":"gpt-5","stream":true}` {
+		t.Fatalf("stripped body = %s", stripped)
+	}
+
+	plain := []byte(`{"model":"gpt-5","messages":[{"content":"cliproxy"}]}`)
+	if ext, stripped, errExtract = ExtractRequestExtensions(plain); errExtract != nil || ext != nil || string(stripped) != string(plain) {
+		t.Fatalf("plain body: ext = %+v, body = %s, err = %v", ext, stripped, errExtract)
+	}
+
+	for _, invalid := range []string{
+		`{"cliproxy":"dry-run"}`,
+		`{"cliproxy":{"routing":{"region":"eu"}}}`,
+		`{"cliproxy":{"tags":["` + strings.Repeat("x", maxRequestExtensionTagLength+1) + `"]}}`,
+	} {
+		if _, _, errExtract = ExtractRequestExtensions([]byte(invalid)); errExtract == nil {
+			t.Fatalf("ExtractRequestExtensions(%s) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestExecuteWithAuthManager_RoutingHintRestrictsProvider(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude"}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"hinted-model", "hinted-model"}, nil)
+
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Set(RequestExtensionsGinKey, &RequestExtensions{Routing: RequestRoutingHints{Provider: "gemini"}, Tags: []string{"ci"}})
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	for i := 0; i < 3; i++ {
+		body, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "hinted-model", []byte(`{"model":"hinted-model"}`), "")
+		if errMsg != nil {
+			t.Fatalf("unexpected error: %+v", errMsg)
+		}
+		if string(body) != "gemini:hinted-model" {
+			t.Fatalf("body = %q, want gemini only", body)
+		}
+	}
+	if got := claude.Models(); len(got) != 0 {
+		t.Fatalf("claude attempts = %v, want none", got)
+	}
+
+	meta := requestExecutionMetadata(ctx)
+	if tags, _ := meta[coreexecutor.RequestTagsMetadataKey].([]string); len(tags) != 1 || tags[0] != "ci" {
+		t.Fatalf("request tags metadata = %v, want [ci]", meta[coreexecutor.RequestTagsMetadataKey])
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+)
+
+// shadowTrafficLogMu serializes writes to shadow traffic log files.
+var shadowTrafficLogMu sync.Mutex
+
+// shadowTrafficRecord is one line of the shadow traffic log.
+type shadowTrafficRecord struct {
+	Time             time.Time `json:"time"`
+	Protocol         string    `json:"protocol"`
+	Stream           bool      `json:"stream"`
+	Model            string    `json:"model"`
+	TargetModel      string    `json:"target_model"`
+	TargetProvider   string    `json:"target_provider,omitempty"`
+	Request          string    `json:"request"`
+	PrimaryStatus    int       `json:"primary_status"`
+	PrimaryError     string    `json:"primary_error,omitempty"`
+	PrimaryResponse  string    `json:"primary_response,omitempty"`
+	PrimaryLatencyMs int64     `json:"primary_latency_ms"`
+	ShadowStatus     int       `json:"shadow_status"`
+	ShadowError      string    `json:"shadow_error,omitempty"`
+	ShadowResponse   string    `json:"shadow_response,omitempty"`
+	ShadowLatencyMs  int64     `json:"shadow_latency_ms"`
+}
+
+// shadowMirror tracks a mirrored request until both its primary and its shadow response are known.
+type shadowMirror struct {
+	logFile       string
+	timeout       time.Duration
+	started       time.Time
+	entryProtocol string
+	exitProtocol  string
+	alt           string
+	options       modelExecutionOptions
+
+	// mu guards record, which the shadow and the primary request fill in concurrently.
+	mu     sync.Mutex
+	record shadowTrafficRecord
+
+	launchOnce  sync.Once
+	primaryOnce sync.Once
+	primaryDone chan struct{}
+}
+
+// shadowTrafficRule returns the first shadow traffic rule matching the requested model.
+func (h *BaseAPIHandler) shadowTrafficRule(modelName string) (internalconfig.ShadowTrafficRule, bool) {
+	if h == nil || h.Cfg == nil {
+		return internalconfig.ShadowTrafficRule{}, false
+	}
+	modelName = strings.ToLower(strings.TrimSpace(modelName))
+	if modelName == "" {
+		return internalconfig.ShadowTrafficRule{}, false
+	}
+	for _, rule := range h.Cfg.ShadowTraffic.Rules {
+		if rule.TargetModel != "" && matchesAnyPattern(rule.Models, modelName, true) {
+			return rule, true
+		}
+	}
+	return internalconfig.ShadowTrafficRule{}, false
+}
+
+// startShadowTraffic samples a request for mirroring to the target of the matching shadow
+// traffic rule. It returns nil when the request is not mirrored; otherwise the mirror is
+// launched by launchShadowTraffic once the request has passed its checks, and the caller reports
+// the primary response to the returned mirror and then calls endShadowTraffic. Mirrored requests
+// run detached from the client request and are never mirrored again.
+func (h *BaseAPIHandler) startShadowTraffic(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, stream bool, execOptions *modelExecutionOptions) *shadowMirror {
+	if execOptions.shadowChecked || execOptions.InternalSource {
+		return nil
+	}
+	execOptions.shadowChecked = true
+	rule, ok := h.shadowTrafficRule(modelName)
+	if !ok || clock.DefaultRand().Float64()*100 >= rule.Percent {
+		return nil
+	}
+	shadowCfg := h.Cfg.ShadowTraffic
+	limit := shadowCfg.MaxConcurrent
+	if limit <= 0 {
+		limit = internalconfig.DefaultShadowTrafficMaxConcurrent
+	}
+	if h.shadowInFlight.Add(1) > int64(limit) {
+		h.shadowInFlight.Add(-1)
+		log.Debugf("shadow traffic: %d mirrored requests in flight, not mirroring %s", limit, modelName)
+		return nil
+	}
+
+	// The mirrored request is executed like a failover target: restricted to the rule's
+	// provider and never rerouted.
+	mirror := &shadowMirror{
+		logFile:       shadowCfg.LogFile,
+		timeout:       shadowCfg.TimeoutDuration(),
+		started:       time.Now(),
+		entryProtocol: entryProtocol,
+		exitProtocol:  exitProtocol,
+		alt:           alt,
+		options: modelExecutionOptions{
+			Headers:          modelExecutionHeaders(ctx, execOptions.Headers),
+			Query:            modelExecutionQuery(ctx, execOptions.Query),
+			shadowChecked:    true,
+			failoverTarget:   true,
+			failoverProvider: rule.Provider,
+		},
+		primaryDone: make(chan struct{}),
+		record: shadowTrafficRecord{
+			Protocol:       entryProtocol,
+			Stream:         stream,
+			Model:          modelName,
+			TargetModel:    rule.TargetModel,
+			TargetProvider: rule.Provider,
+		},
+	}
+	mirror.record.Time = mirror.started.UTC()
+	execOptions.shadow = mirror
+	return mirror
+}
+
+// launchShadowTraffic starts the pending mirror of a request with rawJSON, the body sent upstream
+// after request middleware and key scope checks. Only the first attempt of a request launches it.
+func (h *BaseAPIHandler) launchShadowTraffic(execOptions modelExecutionOptions, rawJSON []byte) {
+	mirror := execOptions.shadow
+	if mirror == nil {
+		return
+	}
+	mirror.launchOnce.Do(func() {
+		payload := bytes.Clone(rawJSON)
+		if mirror.logFile != "" {
+			mirror.mu.Lock()
+			mirror.record.Request = string(payload)
+			mirror.mu.Unlock()
+		}
+		go h.runShadowTraffic(mirror, payload)
+	})
+}
+
+// endShadowTraffic releases the slot of a mirror that was never launched because the request
+// failed its checks.
+func (h *BaseAPIHandler) endShadowTraffic(mirror *shadowMirror) {
+	mirror.launchOnce.Do(func() { h.shadowInFlight.Add(-1) })
+}
+
+func (h *BaseAPIHandler) runShadowTraffic(mirror *shadowMirror, rawJSON []byte) {
+	defer h.shadowInFlight.Add(-1)
+	ctx, cancel := context.WithTimeout(context.Background(), mirror.timeout)
+	defer cancel()
+
+	started := time.Now()
+	var response []byte
+	var errMsg *interfaces.ErrorMessage
+	// Stream and TargetModel are set when the mirror is created and never written afterwards.
+	if mirror.record.Stream {
+		dataChan, _, errChan := h.executeStreamWithAuthManagerFormats(ctx, mirror.entryProtocol, mirror.exitProtocol, mirror.record.TargetModel, rawJSON, mirror.alt, false, mirror.options)
+		response, errMsg = collectShadowStream(dataChan, errChan)
+	} else {
+		response, _, errMsg = h.executeWithAuthManagerFormats(ctx, mirror.entryProtocol, mirror.exitProtocol, mirror.record.TargetModel, rawJSON, mirror.alt, false, mirror.options)
+	}
+	status, errText := shadowTrafficStatus(errMsg)
+	if errMsg != nil {
+		log.Debugf("shadow traffic: %s -> %s failed with status %d", mirror.record.Model, mirror.record.TargetModel, status)
+	}
+	if mirror.logFile == "" {
+		return
+	}
+	mirror.mu.Lock()
+	mirror.record.ShadowLatencyMs = time.Since(started).Milliseconds()
+	mirror.record.ShadowStatus, mirror.record.ShadowError = status, errText
+	mirror.record.ShadowResponse = string(response)
+	mirror.mu.Unlock()
+
+	timer := time.NewTimer(mirror.timeout)
+	defer timer.Stop()
+	primaryFinished := true
+	select {
+	case <-mirror.primaryDone:
+	case <-timer.C:
+		primaryFinished = false
+	}
+	mirror.mu.Lock()
+	record := mirror.record
+	mirror.mu.Unlock()
+	if !primaryFinished {
+		record.PrimaryError = "primary response not finished"
+	}
+	if errWrite := appendShadowTrafficRecord(mirror.logFile, &record); errWrite != nil {
+		log.Warnf("shadow traffic: failed to write %s: %v", mirror.logFile, errWrite)
+	}
+}
+
+// finishPrimary records the response returned to the client.
+func (m *shadowMirror) finishPrimary(body []byte, errMsg *interfaces.ErrorMessage) {
+	if m == nil {
+		return
+	}
+	m.primaryOnce.Do(func() {
+		if m.logFile != "" {
+			m.mu.Lock()
+			m.record.PrimaryLatencyMs = time.Since(m.started).Milliseconds()
+			m.record.PrimaryStatus, m.record.PrimaryError = shadowTrafficStatus(errMsg)
+			m.record.PrimaryResponse = string(body)
+			m.mu.Unlock()
+		}
+		close(m.primaryDone)
+	})
+}
+
+// teeStream returns the primary stream, capturing its chunks when responses are logged.
+func (m *shadowMirror) teeStream(ctx context.Context, data <-chan []byte) <-chan []byte {
+	if m.logFile == "" || data == nil {
+		m.finishPrimary(nil, nil)
+		return data
+	}
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		var body bytes.Buffer
+		defer func() { m.finishPrimary(body.Bytes(), nil) }()
+		for chunk := range data {
+			body.Write(chunk)
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				for range data {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// collectShadowStream reads a mirrored stream to its end.
+func collectShadowStream(dataChan <-chan []byte, errChan <-chan *interfaces.ErrorMessage) ([]byte, *interfaces.ErrorMessage) {
+	var body bytes.Buffer
+	var errMsg *interfaces.ErrorMessage
+	for dataChan != nil || errChan != nil {
+		select {
+		case chunk, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+				continue
+			}
+			body.Write(chunk)
+		case msg, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			if msg != nil && errMsg == nil {
+				errMsg = msg
+			}
+		}
+	}
+	return body.Bytes(), errMsg
+}
+
+func shadowTrafficStatus(errMsg *interfaces.ErrorMessage) (int, string) {
+	if errMsg == nil {
+		return http.StatusOK, ""
+	}
+	status := errMsg.StatusCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	if errMsg.Error != nil {
+		return status, errMsg.Error.Error()
+	}
+	return status, ""
+}
+
+func appendShadowTrafficRecord(path string, record *shadowTrafficRecord) error {
+	line, errMarshal := json.Marshal(record)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	line = append(line, '\n')
+	shadowTrafficLogMu.Lock()
+	defer shadowTrafficLogMu.Unlock()
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if errMkdir := os.MkdirAll(dir, 0o755); errMkdir != nil {
+			return errMkdir
+		}
+	}
+	file, errOpen := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if errOpen != nil {
+		return errOpen
+	}
+	_, errWrite := file.Write(line)
+	if errClose := file.Close(); errWrite == nil {
+		errWrite = errClose
+	}
+	return errWrite
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+)
+
+func readShadowTrafficRecords(t *testing.T, path string, want int) []shadowTrafficRecord {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+		if len(data) > 0 && len(lines) >= want {
+			records := make([]shadowTrafficRecord, 0, len(lines))
+			for _, line := range lines {
+				var record shadowTrafficRecord
+				if errUnmarshal := json.Unmarshal(line, &record); errUnmarshal != nil {
+					t.Fatalf("decode record %q: %v", line, errUnmarshal)
+				}
+				records = append(records, record)
+			}
+			return records
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("shadow traffic log has %d records, want %d", len(lines), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestExecuteWithAuthManager_ShadowTrafficMirrorsToTarget(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude"}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"shadow-primary", "shadow-target"}, nil)
+	logFile := filepath.Join(t.TempDir(), "shadow", "traffic.jsonl")
+	handler.Cfg.ShadowTraffic = internalconfig.ShadowTrafficConfig{
+		LogFile: logFile,
+		Rules:   []internalconfig.ShadowTrafficRule{{Models: []string{"shadow-prim*"}, TargetModel: "shadow-target", Provider: "gemini", Percent: 100}},
+	}
+
+	body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "shadow-primary", []byte(`{"model":"shadow-primary"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(body) != "claude:shadow-primary" {
+		t.Fatalf("body = %q, want the primary response", body)
+	}
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "shadow-primary", []byte(`{"model":"shadow-primary"}`), "")
+	var streamed []byte
+	for chunk := range dataChan {
+		streamed = append(streamed, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected stream error: %+v", msg)
+		}
+	}
+	if string(streamed) != "claude:shadow-primary" {
+		t.Fatalf("streamed = %q, want the primary response", streamed)
+	}
+
+	records := readShadowTrafficRecords(t, logFile, 2)
+	for _, record := range records {
+		if record.Model != "shadow-primary" || record.TargetModel != "shadow-target" || record.Request != `{"model":"shadow-primary"}` {
+			t.Fatalf("record = %+v, want shadow-primary mirrored to shadow-target", record)
+		}
+		if record.PrimaryResponse != "claude:shadow-primary" || record.ShadowResponse != "gemini:shadow-target" || record.ShadowStatus != 200 {
+			t.Fatalf("record = %+v, want both responses", record)
+		}
+	}
+	if got := gemini.Models(); len(got) != 2 {
+		t.Fatalf("mirrored requests = %v, want 2", got)
+	}
+}
+
+func TestExecuteWithAuthManager_ShadowTrafficSkipsRejectedRequests(t *testing.T) {
+	claude := &failoverTestExecutor{provider: "claude"}
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{claude, gemini}, []string{"shadow-primary", "shadow-target"}, nil)
+	handler.Cfg.ShadowTraffic = internalconfig.ShadowTrafficConfig{
+		Rules: []internalconfig.ShadowTrafficRule{{Models: []string{"shadow-primary"}, TargetModel: "shadow-target", Provider: "gemini", Percent: 100}},
+	}
+
+	ctx := keyScopeTestContext(map[string]string{sdkaccess.MetadataAllowedModels: "other-*"})
+	if _, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "shadow-primary", []byte(`{"model":"shadow-primary"}`), ""); errMsg == nil {
+		t.Fatal("out-of-scope request succeeded")
+	}
+	if got := gemini.Models(); len(got) != 0 {
+		t.Fatalf("mirrored requests = %v, want none for a rejected request", got)
+	}
+	if inFlight := handler.shadowInFlight.Load(); inFlight != 0 {
+		t.Fatalf("shadow requests in flight = %d, want the slot released", inFlight)
+	}
+}
+
+func TestStartShadowTraffic_SkipsUnmatchedAndMirroredRequests(t *testing.T) {
+	handler := newFailoverTestHandler(t, nil, nil, nil)
+	handler.Cfg.ShadowTraffic = internalconfig.ShadowTrafficConfig{
+		Rules: []internalconfig.ShadowTrafficRule{{Models: []string{"claude-*"}, TargetModel: "gemini-2.5-pro", Percent: 100}},
+	}
+	if mirror := handler.startShadowTraffic(context.Background(), "openai", "openai", "gpt-5", nil, "", false, &modelExecutionOptions{}); mirror != nil {
+		t.Fatal("unmatched model was mirrored")
+	}
+	if mirror := handler.startShadowTraffic(context.Background(), "openai", "openai", "claude-opus", nil, "", false, &modelExecutionOptions{shadowChecked: true}); mirror != nil {
+		t.Fatal("mirrored request was mirrored again")
+	}
+	handler.Cfg.ShadowTraffic.Rules[0].Percent = 0
+	if mirror := handler.startShadowTraffic(context.Background(), "openai", "openai", "claude-opus", nil, "", false, &modelExecutionOptions{}); mirror != nil {
+		t.Fatal("request was mirrored at 0 percent")
+	}
+}
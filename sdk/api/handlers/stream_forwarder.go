@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 )
 
 type StreamForwardOptions struct {
@@ -29,6 +30,61 @@ type StreamForwardOptions struct {
 	WriteKeepAlive func()
 }
 
+// FirstByteHeartbeatInterval returns how often SSE heartbeats are sent while waiting for the
+// upstream's first chunk. Returning 0 disables them (default when unset).
+func FirstByteHeartbeatInterval(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil || cfg.Streaming.FirstByteHeartbeatSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Streaming.FirstByteHeartbeatSeconds) * time.Second
+}
+
+// ExecuteStreamWithHeartbeat runs execute, typically ExecuteStreamWithAuthManager, and sends SSE
+// heartbeat comments while it waits for the upstream's first chunk so proxies and load balancers
+// do not drop the idle connection. The first heartbeat calls setHeaders and commits the response;
+// started reports whether that happened, in which case the caller must report errors in-stream
+// instead of with an error status. Without first-byte heartbeats configured, execute is called
+// directly.
+func (h *BaseAPIHandler) ExecuteStreamWithHeartbeat(c *gin.Context, flusher http.Flusher, setHeaders func(), execute func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage)) (data <-chan []byte, headers http.Header, errs <-chan *interfaces.ErrorMessage, started bool) {
+	interval := FirstByteHeartbeatInterval(h.Cfg)
+	if interval <= 0 || c == nil || flusher == nil {
+		data, headers, errs = execute()
+		return data, headers, errs, false
+	}
+
+	type executeResult struct {
+		data    <-chan []byte
+		headers http.Header
+		errs    <-chan *interfaces.ErrorMessage
+	}
+	resultChan := make(chan executeResult, 1)
+	go func() {
+		var result executeResult
+		result.data, result.headers, result.errs = execute()
+		resultChan <- result
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	tickC := ticker.C
+	for {
+		select {
+		case result := <-resultChan:
+			return result.data, result.headers, result.errs, started
+		case <-c.Request.Context().Done():
+			// The client is gone; execute returns once its context is cancelled.
+			tickC = nil
+		case <-tickC:
+			if !started && setHeaders != nil {
+				setHeaders()
+			}
+			started = true
+			_, _ = c.Writer.Write([]byte(": keep-alive\n\n"))
+			flusher.Flush()
+		}
+	}
+}
+
 func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, opts StreamForwardOptions) {
 	if c == nil {
 		return
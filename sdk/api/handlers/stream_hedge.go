@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// streamHedgeDelay returns how long a stream may go without a first chunk before a hedged
+// attempt is sent; 0 disables hedging for the model.
+func (h *BaseAPIHandler) streamHedgeDelay(model string, opts coreexecutor.Options) time.Duration {
+	if h == nil || h.Cfg == nil || h.AuthManager == nil || h.AuthManager.HomeEnabled() {
+		return 0
+	}
+	delay := h.Cfg.Streaming.HedgeAfterDuration()
+	if delay <= 0 {
+		return 0
+	}
+	if _, pinned := opts.Metadata[coreexecutor.PinnedAuthMetadataKey]; pinned {
+		return 0
+	}
+	if patterns := h.Cfg.Streaming.HedgeModels; len(patterns) > 0 && !matchesAnyPattern(patterns, strings.ToLower(model), true) {
+		return 0
+	}
+	return delay
+}
+
+// hedgeAttempt is one execution of a hedged or raced request, read up to its first chunk
+// when streaming.
+type hedgeAttempt struct {
+	cancel  context.CancelFunc
+	meta    map[string]any
+	started time.Time
+
+	mu     sync.Mutex
+	authID string
+
+	result   *coreexecutor.StreamResult
+	resp     coreexecutor.Response
+	err      error
+	first    coreexecutor.StreamChunk
+	hasFirst bool
+	// latency is the time to the first chunk or response; 0 when the attempt was cancelled first.
+	latency time.Duration
+}
+
+// newHedgeAttempt prepares an attempt that skips the excluded credentials. The selected
+// credential is recorded on the attempt instead of being reported, since selection callbacks
+// are reported for the returned attempt only.
+func newHedgeAttempt(ctx context.Context, opts coreexecutor.Options, excluded []string) (*hedgeAttempt, context.Context, coreexecutor.Options) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	attempt := &hedgeAttempt{
+		cancel:  cancel,
+		meta:    make(map[string]any, len(opts.Metadata)+2),
+		started: time.Now(),
+	}
+	maps.Copy(attempt.meta, opts.Metadata)
+	delete(attempt.meta, coreexecutor.SelectedAuthIndexCallbackMetadataKey)
+	attempt.meta[coreexecutor.SelectedAuthCallbackMetadataKey] = func(authID string) {
+		attempt.mu.Lock()
+		attempt.authID = authID
+		attempt.mu.Unlock()
+	}
+	if len(excluded) > 0 {
+		attempt.meta[coreexecutor.ExcludedAuthsMetadataKey] = excluded
+	}
+	attemptOpts := opts
+	attemptOpts.Metadata = attempt.meta
+	return attempt, attemptCtx, attemptOpts
+}
+
+// runStream executes the attempt, reads its first chunk and sends the attempt to results.
+func (a *hedgeAttempt) runStream(ctx context.Context, manager *coreauth.Manager, providers []string, req coreexecutor.Request, opts coreexecutor.Options, results chan<- *hedgeAttempt) {
+	a.result, a.err = manager.ExecuteStream(ctx, providers, req, opts)
+	if a.err == nil && a.result != nil && a.result.Chunks != nil {
+		select {
+		case a.first, a.hasFirst = <-a.result.Chunks:
+		case <-ctx.Done():
+		}
+	}
+	if ctx.Err() == nil {
+		a.latency = time.Since(a.started)
+	}
+	results <- a
+}
+
+func (a *hedgeAttempt) selectedAuthID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.authID
+}
+
+// answered reports whether the attempt produced a chunk or finished its stream cleanly.
+func (a *hedgeAttempt) answered() bool {
+	return a.err == nil && a.result != nil && (!a.hasFirst || a.first.Err == nil)
+}
+
+// stream returns the attempt's stream with the already read first chunk put back in front.
+func (a *hedgeAttempt) stream(ctx context.Context) (*coreexecutor.StreamResult, error) {
+	if a.err != nil || a.result == nil {
+		a.cancel()
+		return a.result, a.err
+	}
+	out := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer a.cancel()
+		if !a.hasFirst {
+			return
+		}
+		select {
+		case out <- a.first:
+		case <-ctx.Done():
+			return
+		}
+		for chunk := range a.result.Chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return &coreexecutor.StreamResult{Headers: a.result.Headers, Chunks: out}, nil
+}
+
+// discard cancels an attempt that lost the race and drains its stream.
+func (a *hedgeAttempt) discard() {
+	a.cancel()
+	if a.result == nil || a.result.Chunks == nil {
+		return
+	}
+	for range a.result.Chunks {
+	}
+}
+
+// executeStreamHedged runs ExecuteStream and, when no chunk has arrived within the hedge
+// delay, sends the same request to another credential. The first attempt to produce a chunk
+// is returned and the other one is cancelled. Attempts that fail before the hedge is sent are
+// returned as is so bootstrap retries handle them. Requests in race mode are raced instead.
+func (h *BaseAPIHandler) executeStreamHedged(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	if racers := raceProviders(providers, h.raceFanout(ctx, req.Model, opts)); len(racers) > 1 && ctx != nil {
+		return h.executeStreamRace(ctx, racers, req, opts)
+	}
+	delay := h.streamHedgeDelay(req.Model, opts)
+	if delay <= 0 || ctx == nil {
+		return h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	}
+
+	results := make(chan *hedgeAttempt, 2)
+	start := func(excluded []string) *hedgeAttempt {
+		attempt, attemptCtx, attemptOpts := newHedgeAttempt(ctx, opts, excluded)
+		go attempt.runStream(attemptCtx, h.AuthManager, providers, req, attemptOpts, results)
+		return attempt
+	}
+
+	primary := start(nil)
+	attempts := []*hedgeAttempt{primary}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	hedgeC := timer.C
+	var failed *hedgeAttempt
+	for pending := 1; pending > 0; {
+		select {
+		case <-ctx.Done():
+			for _, attempt := range attempts {
+				attempt.cancel()
+			}
+			go drainHedgeAttempts(results, pending)
+			return nil, ctx.Err()
+		case <-hedgeC:
+			hedgeC = nil
+			var excluded []string
+			if existing, ok := opts.Metadata[coreexecutor.ExcludedAuthsMetadataKey].([]string); ok {
+				excluded = append(excluded, existing...)
+			}
+			if authID := primary.selectedAuthID(); authID != "" {
+				excluded = append(excluded, authID)
+			}
+			log.Debugf("stream hedge: no first chunk after %s, sending hedged request for model %s", delay, req.Model)
+			attempts = append(attempts, start(excluded))
+			pending++
+		case attempt := <-results:
+			pending--
+			if attempt.answered() {
+				for _, other := range attempts {
+					if other != attempt {
+						other.cancel()
+					}
+				}
+				go drainHedgeAttempts(results, pending)
+				publishHedgeWinner(opts.Metadata, attempt)
+				return attempt.stream(ctx)
+			}
+			if failed == nil {
+				failed = attempt
+			} else {
+				attempt.discard()
+			}
+			if hedgeC != nil {
+				// Failed before hedging; let bootstrap retries take over.
+				publishHedgeWinner(opts.Metadata, attempt)
+				return attempt.stream(ctx)
+			}
+		}
+	}
+	publishHedgeWinner(opts.Metadata, failed)
+	return failed.stream(ctx)
+}
+
+// drainHedgeAttempts waits for the remaining attempts of a decided race and discards them.
+func drainHedgeAttempts(results <-chan *hedgeAttempt, pending int) {
+	for ; pending > 0; pending-- {
+		(<-results).discard()
+	}
+}
+
+// publishHedgeWinner copies the auth selected by the returned attempt into the request
+// metadata and reports it to the selection callbacks.
+func publishHedgeWinner(meta map[string]any, attempt *hedgeAttempt) {
+	if meta == nil || attempt == nil {
+		return
+	}
+	if authID := attempt.selectedAuthID(); authID != "" {
+		meta[coreexecutor.SelectedAuthMetadataKey] = authID
+		if callback, ok := meta[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string)); ok && callback != nil {
+			callback(authID)
+		}
+	}
+	if authIndex, ok := attempt.meta[coreexecutor.SelectedAuthIndexMetadataKey].(string); ok && authIndex != "" {
+		meta[coreexecutor.SelectedAuthIndexMetadataKey] = authIndex
+		if callback, ok := meta[coreexecutor.SelectedAuthIndexCallbackMetadataKey].(func(string)); ok && callback != nil {
+			callback(authIndex)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// slowFirstStreamExecutor never answers its first call until the call is cancelled and
+// answers every later call at once.
+type slowFirstStreamExecutor struct {
+	mu        sync.Mutex
+	auths     []string
+	cancelled chan struct{}
+}
+
+func (e *slowFirstStreamExecutor) Identifier() string { return "codex" }
+
+func (e *slowFirstStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *slowFirstStreamExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.auths = append(e.auths, auth.ID)
+	first := len(e.auths) == 1
+	e.mu.Unlock()
+
+	chunks := make(chan coreexecutor.StreamChunk, 1)
+	if first {
+		go func() {
+			defer close(chunks)
+			<-ctx.Done()
+			close(e.cancelled)
+		}()
+		return &coreexecutor.StreamResult{Headers: http.Header{"X-Upstream-Auth": {auth.ID}}, Chunks: chunks}, nil
+	}
+	chunks <- coreexecutor.StreamChunk{Payload: []byte("fast")}
+	close(chunks)
+	return &coreexecutor.StreamResult{Headers: http.Header{"X-Upstream-Auth": {auth.ID}}, Chunks: chunks}, nil
+}
+
+func (e *slowFirstStreamExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *slowFirstStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *slowFirstStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *slowFirstStreamExecutor) Auths() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.auths...)
+}
+
+func TestExecuteStreamWithAuthManager_HedgesSlowFirstChunk(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	executor := &slowFirstStreamExecutor{cancelled: make(chan struct{})}
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"hedge-auth1", "hedge-auth2"} {
+		auth := &coreauth.Auth{ID: id, Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": id + "@example.com"}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "codex", []*registry.ModelInfo{{ID: "hedge-model"}})
+	}
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient("hedge-auth1")
+		registry.GetGlobalRegistry().UnregisterClient("hedge-auth2")
+	})
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		PassthroughHeaders: true,
+		Streaming:          sdkconfig.StreamingConfig{HedgeAfter: "20ms"},
+	}, manager)
+	var mu sync.Mutex
+	var selected []string
+	ctx := WithSelectedAuthIDCallback(context.Background(), func(authID string) {
+		mu.Lock()
+		selected = append(selected, authID)
+		mu.Unlock()
+	})
+
+	dataChan, headers, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "hedge-model", []byte(`{"model":"hedge-model"}`), "")
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	if string(got) != "fast" {
+		t.Fatalf("payload = %q, want the hedged attempt's", got)
+	}
+	auths := executor.Auths()
+	if len(auths) != 2 || auths[0] == auths[1] {
+		t.Fatalf("attempted auths = %v, want two different credentials", auths)
+	}
+	if winner := headers.Get("X-Upstream-Auth"); winner != auths[1] {
+		t.Fatalf("headers from %q, want the hedged attempt %q", winner, auths[1])
+	}
+	select {
+	case <-executor.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow attempt was not cancelled")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(selected) != 1 || selected[0] != auths[1] {
+		t.Fatalf("selected auth callbacks = %v, want only %q", selected, auths[1])
+	}
+}
+
+func TestStreamHedgeDelay_ModelPatterns(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{HedgeAfter: "2s", HedgeModels: []string{"claude-*"}},
+	}, coreauth.NewManager(nil, nil, nil))
+	if got := handler.streamHedgeDelay("Claude-Sonnet-4-5", coreexecutor.Options{}); got != 2*time.Second {
+		t.Fatalf("delay = %v, want 2s", got)
+	}
+	if got := handler.streamHedgeDelay("gpt-5", coreexecutor.Options{}); got != 0 {
+		t.Fatalf("delay for unmatched model = %v, want 0", got)
+	}
+	pinned := coreexecutor.Options{Metadata: map[string]any{coreexecutor.PinnedAuthMetadataKey: "auth"}}
+	if got := handler.streamHedgeDelay("claude-opus", pinned); got != 0 {
+		t.Fatalf("delay for pinned auth = %v, want 0", got)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// streamTimeoutError reports an upstream stream stopped by a streaming provider timeout.
+type streamTimeoutError struct {
+	provider string
+	idle     bool
+	timeout  time.Duration
+}
+
+// Error implements error.
+func (e *streamTimeoutError) Error() string {
+	provider := e.provider
+	if provider == "" {
+		provider = "provider"
+	}
+	if e.idle {
+		return fmt.Sprintf("upstream stream from %s sent nothing for %s", provider, e.timeout)
+	}
+	return fmt.Sprintf("upstream stream from %s exceeded its total timeout of %s", provider, e.timeout)
+}
+
+// StatusCode returns the HTTP status used for timed out streams.
+func (e *streamTimeoutError) StatusCode() int { return http.StatusGatewayTimeout }
+
+// executeStreamTimed runs executeStreamHedged and enforces the idle and total timeouts
+// configured for the provider serving the stream. The wait for the first chunk, which happens
+// while the credential is selected, counts as idle time. A timed out stream is cancelled
+// upstream and fails with a 504 error, which bootstrap retries treat like any other upstream
+// failure.
+func (h *BaseAPIHandler) executeStreamTimed(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.Streaming.ProviderTimeouts) == 0 || ctx == nil || opts.Metadata == nil {
+		return h.executeStreamHedged(ctx, providers, req, opts)
+	}
+	started := time.Now()
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	// Until the stream is returned, each credential selection restarts the timer with the
+	// timeouts of the selected provider.
+	var mu sync.Mutex
+	var timer *time.Timer
+	var expired *streamTimeoutError
+	arm := func(authID string) {
+		provider := h.authProvider(authID)
+		idle, total := h.Cfg.Streaming.StreamTimeouts(provider)
+		timeoutErr := &streamTimeoutError{provider: provider, idle: idle > 0, timeout: idle}
+		wait := idle
+		if remaining := total - time.Since(started); total > 0 && (idle <= 0 || remaining < idle) {
+			timeoutErr.idle, timeoutErr.timeout, wait = false, total, max(remaining, 0)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		if idle <= 0 && total <= 0 {
+			return
+		}
+		timer = time.AfterFunc(wait, func() {
+			mu.Lock()
+			expired = timeoutErr
+			mu.Unlock()
+			cancel()
+		})
+	}
+	previous, _ := opts.Metadata[coreexecutor.SelectedAuthCallbackMetadataKey].(func(string))
+	opts.Metadata[coreexecutor.SelectedAuthCallbackMetadataKey] = func(authID string) {
+		if previous != nil {
+			previous(authID)
+		}
+		arm(authID)
+	}
+	result, err := h.executeStreamHedged(streamCtx, providers, req, opts)
+	if previous != nil {
+		opts.Metadata[coreexecutor.SelectedAuthCallbackMetadataKey] = previous
+	} else {
+		delete(opts.Metadata, coreexecutor.SelectedAuthCallbackMetadataKey)
+	}
+	mu.Lock()
+	if timer != nil {
+		timer.Stop()
+	}
+	timedOut := expired
+	mu.Unlock()
+
+	if timedOut != nil {
+		log.Warnf("streaming: %v", timedOut)
+		cancel()
+		if result != nil && result.Chunks != nil {
+			go func() {
+				for range result.Chunks {
+				}
+			}()
+		}
+		return nil, timedOut
+	}
+	if err != nil || result == nil || result.Chunks == nil {
+		cancel()
+		return result, err
+	}
+	provider := h.selectedAuthProvider(opts.Metadata)
+	idle, total := h.Cfg.Streaming.StreamTimeouts(provider)
+	if idle <= 0 && total <= 0 {
+		return &coreexecutor.StreamResult{Headers: result.Headers, Chunks: releaseOnClose(ctx, result.Chunks, cancel)}, nil
+	}
+	return &coreexecutor.StreamResult{
+		Headers: result.Headers,
+		Chunks:  watchStreamTimeouts(ctx, cancel, result.Chunks, &streamTimeoutError{provider: provider}, idle, total-time.Since(started), total),
+	}, nil
+}
+
+// selectedAuthProvider returns the provider of the credential selected for a request.
+func (h *BaseAPIHandler) selectedAuthProvider(meta map[string]any) string {
+	authID, _ := meta[coreexecutor.SelectedAuthMetadataKey].(string)
+	return h.authProvider(authID)
+}
+
+func (h *BaseAPIHandler) authProvider(authID string) string {
+	if authID == "" || h.AuthManager == nil {
+		return ""
+	}
+	if auth, ok := h.AuthManager.GetByID(authID); ok && auth != nil {
+		return auth.Provider
+	}
+	return ""
+}
+
+// releaseOnClose forwards chunks and calls release once the stream ends.
+func releaseOnClose(ctx context.Context, chunks <-chan coreexecutor.StreamChunk, release context.CancelFunc) <-chan coreexecutor.StreamChunk {
+	out := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer release()
+		for chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				for range chunks {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// watchStreamTimeouts forwards chunks until the stream ends, the gap between two upstream chunks
+// exceeds idle, or the remaining total time runs out. Time spent waiting for the consumer does
+// not count as idle time.
+func watchStreamTimeouts(ctx context.Context, cancel context.CancelFunc, chunks <-chan coreexecutor.StreamChunk, timeoutErr *streamTimeoutError, idle, remaining, total time.Duration) <-chan coreexecutor.StreamChunk {
+	out := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		var idleTimer *time.Timer
+		var idleC <-chan time.Time
+		if idle > 0 {
+			idleTimer = time.NewTimer(idle)
+			defer idleTimer.Stop()
+			idleC = idleTimer.C
+		}
+		var totalC <-chan time.Time
+		if total > 0 {
+			totalTimer := time.NewTimer(max(remaining, 0))
+			defer totalTimer.Stop()
+			totalC = totalTimer.C
+		}
+
+		stop := func(idleExpired bool) {
+			timeoutErr.idle = idleExpired
+			timeoutErr.timeout = total
+			if idleExpired {
+				timeoutErr.timeout = idle
+			}
+			log.Warnf("streaming: %v", timeoutErr)
+			cancel()
+			select {
+			case out <- coreexecutor.StreamChunk{Err: timeoutErr}:
+			case <-ctx.Done():
+			}
+			for range chunks {
+			}
+		}
+
+		for {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					return
+				}
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					for range chunks {
+					}
+					return
+				case <-totalC:
+					stop(false)
+					return
+				}
+				if idleTimer != nil {
+					idleTimer.Reset(idle)
+				}
+			case <-idleC:
+				stop(true)
+				return
+			case <-totalC:
+				stop(false)
+				return
+			}
+		}
+	}()
+	return out
+}
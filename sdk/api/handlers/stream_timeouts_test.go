@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestExecuteStreamWithAuthManager_StopsIdleStream(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	executor := &slowFirstStreamExecutor{cancelled: make(chan struct{})}
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "idle-auth", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "idle@example.com"}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "codex", []*registry.ModelInfo{{ID: "idle-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{ProviderTimeouts: []sdkconfig.StreamProviderTimeout{
+			{Provider: "claude", IdleTimeout: "1h"},
+			{Provider: "*", IdleTimeout: "30ms", TotalTimeout: "1h"},
+		}},
+	}, manager)
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "idle-model", []byte(`{"model":"idle-model"}`), "")
+	var errMsg *interfaces.ErrorMessage
+	for msg := range errChan {
+		if msg != nil {
+			errMsg = msg
+		}
+	}
+	if dataChan != nil {
+		for range dataChan {
+			t.Fatal("unexpected chunk from an idle stream")
+		}
+	}
+	if errMsg == nil || errMsg.StatusCode != http.StatusGatewayTimeout || !strings.Contains(errMsg.Error.Error(), "sent nothing for 30ms") {
+		t.Fatalf("error = %+v, want a 504 idle timeout", errMsg)
+	}
+	select {
+	case <-executor.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle upstream stream was not cancelled")
+	}
+}
+
+func TestExecuteStreamWithHeartbeat_CommitsWhileWaiting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{FirstByteHeartbeatSeconds: 1},
+	}, coreauth.NewManager(nil, nil, nil))
+	setHeaders := func() { c.Header("Content-Type", "text/event-stream") }
+	_, _, _, started := handler.ExecuteStreamWithHeartbeat(c, c.Writer, setHeaders, func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+		time.Sleep(1200 * time.Millisecond)
+		return nil, nil, nil
+	})
+	if !started {
+		t.Fatal("started = false, want the response committed by a heartbeat")
+	}
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "text/event-stream" || !strings.Contains(recorder.Body.String(), ": keep-alive\n\n") {
+		t.Fatalf("response = %d %q %q, want an SSE heartbeat", recorder.Code, recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+
+	handler.Cfg.Streaming.FirstByteHeartbeatSeconds = 0
+	if _, _, _, started = handler.ExecuteStreamWithHeartbeat(c, c.Writer, setHeaders, func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+		return nil, nil, nil
+	}); started {
+		t.Fatal("started = true with first-byte heartbeats disabled")
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"strings"
+
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// lookupSyntheticModel returns the synthetic model named by modelName, ignoring a thinking suffix.
+func lookupSyntheticModel(models []config.SyntheticModel, modelName string) (config.SyntheticModel, thinking.SuffixResult, bool) {
+	parsed := thinking.ParseSuffix(strings.TrimSpace(modelName))
+	for _, model := range models {
+		if strings.EqualFold(model.Name, parsed.ModelName) {
+			return model, parsed, true
+		}
+	}
+	return config.SyntheticModel{}, parsed, false
+}
+
+// applySyntheticModel rewrites a request for a synthetic model to its base model and applies the
+// model's preset system prompt, temperature and tools to rawJSON in the entry protocol's format.
+// A thinking suffix is kept unless the base model carries its own.
+func (h *BaseAPIHandler) applySyntheticModel(entryProtocol, modelName string, rawJSON []byte) (string, []byte) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.SyntheticModels) == 0 {
+		return modelName, rawJSON
+	}
+	model, parsed, ok := lookupSyntheticModel(h.Cfg.SyntheticModels, modelName)
+	if !ok {
+		return modelName, rawJSON
+	}
+	rewritten := model.Model
+	if parsed.HasSuffix && !thinking.ParseSuffix(rewritten).HasSuffix {
+		rewritten += "(" + parsed.RawSuffix + ")"
+	}
+	log.Debugf("synthetic model: %s uses base model %s", modelName, rewritten)
+	return rewritten, applySyntheticModelPayload(entryProtocol, model, rewritten, rawJSON)
+}
+
+func applySyntheticModelPayload(entryProtocol string, model config.SyntheticModel, baseModel string, rawJSON []byte) []byte {
+	if len(rawJSON) == 0 || !gjson.ValidBytes(rawJSON) {
+		return rawJSON
+	}
+	out := rawJSON
+	if gjson.GetBytes(out, "model").Exists() {
+		out, _ = sjson.SetBytes(out, "model", baseModel)
+	}
+	switch entryProtocol {
+	case OpenAI:
+		out = applySyntheticOpenAI(out, model)
+	case OpenaiResponse:
+		out = applySyntheticResponses(out, model)
+	case Claude:
+		out = applySyntheticClaude(out, model)
+	case Gemini:
+		out = applySyntheticGemini(out, model)
+	}
+	return out
+}
+
+func applySyntheticOpenAI(rawJSON []byte, model config.SyntheticModel) []byte {
+	out := prependSystemPrompt(OpenAI, rawJSON, model.SystemPrompt)
+	out = setSyntheticTemperature(out, "temperature", model.Temperature)
+	existing := syntheticToolNames(out, "tools.#.function.name")
+	for _, tool := range model.Tools {
+		if _, ok := existing[tool.Name]; ok {
+			continue
+		}
+		function := map[string]any{"name": tool.Name}
+		if tool.Description != "" {
+			function["description"] = tool.Description
+		}
+		if tool.Parameters != nil {
+			function["parameters"] = tool.Parameters
+		}
+		out, _ = sjson.SetBytes(out, "tools.-1", map[string]any{"type": "function", "function": function})
+	}
+	return out
+}
+
+func applySyntheticResponses(rawJSON []byte, model config.SyntheticModel) []byte {
+	out := prependSystemPrompt(OpenaiResponse, rawJSON, model.SystemPrompt)
+	out = setSyntheticTemperature(out, "temperature", model.Temperature)
+	existing := syntheticToolNames(out, "tools.#.name")
+	for _, tool := range model.Tools {
+		if _, ok := existing[tool.Name]; ok {
+			continue
+		}
+		entry := map[string]any{"type": "function", "name": tool.Name}
+		if tool.Description != "" {
+			entry["description"] = tool.Description
+		}
+		if tool.Parameters != nil {
+			entry["parameters"] = tool.Parameters
+		}
+		out, _ = sjson.SetBytes(out, "tools.-1", entry)
+	}
+	return out
+}
+
+func applySyntheticClaude(rawJSON []byte, model config.SyntheticModel) []byte {
+	out := prependSystemPrompt(Claude, rawJSON, model.SystemPrompt)
+	out = setSyntheticTemperature(out, "temperature", model.Temperature)
+	existing := syntheticToolNames(out, "tools.#.name")
+	for _, tool := range model.Tools {
+		if _, ok := existing[tool.Name]; ok {
+			continue
+		}
+		entry := map[string]any{"name": tool.Name, "input_schema": tool.Parameters}
+		if tool.Parameters == nil {
+			entry["input_schema"] = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		if tool.Description != "" {
+			entry["description"] = tool.Description
+		}
+		out, _ = sjson.SetBytes(out, "tools.-1", entry)
+	}
+	return out
+}
+
+func applySyntheticGemini(rawJSON []byte, model config.SyntheticModel) []byte {
+	out := prependSystemPrompt(Gemini, rawJSON, model.SystemPrompt)
+	temperaturePath := "generationConfig.temperature"
+	if !gjson.GetBytes(out, "generationConfig").Exists() && gjson.GetBytes(out, "generation_config").Exists() {
+		temperaturePath = "generation_config.temperature"
+	}
+	out = setSyntheticTemperature(out, temperaturePath, model.Temperature)
+	existing := syntheticToolNames(out, "tools.#.functionDeclarations.#.name")
+	for name := range syntheticToolNames(out, "tools.#.function_declarations.#.name") {
+		existing[name] = struct{}{}
+	}
+	declarations := make([]map[string]any, 0, len(model.Tools))
+	for _, tool := range model.Tools {
+		if _, ok := existing[tool.Name]; ok {
+			continue
+		}
+		declaration := map[string]any{"name": tool.Name}
+		if tool.Description != "" {
+			declaration["description"] = tool.Description
+		}
+		if tool.Parameters != nil {
+			declaration["parameters"] = tool.Parameters
+		}
+		declarations = append(declarations, declaration)
+	}
+	if len(declarations) > 0 {
+		out, _ = sjson.SetBytes(out, "tools.-1", map[string]any{"functionDeclarations": declarations})
+	}
+	return out
+}
+
+func setSyntheticTemperature(rawJSON []byte, path string, temperature *float64) []byte {
+	if temperature == nil || gjson.GetBytes(rawJSON, path).Exists() {
+		return rawJSON
+	}
+	out, errSet := sjson.SetBytes(rawJSON, path, *temperature)
+	if errSet != nil {
+		return rawJSON
+	}
+	return out
+}
+
+// syntheticToolNames collects the tool names found at a gjson path, flattening nested results.
+func syntheticToolNames(rawJSON []byte, path string) map[string]struct{} {
+	names := make(map[string]struct{})
+	var collect func(gjson.Result)
+	collect = func(value gjson.Result) {
+		if value.IsArray() {
+			for _, item := range value.Array() {
+				collect(item)
+			}
+			return
+		}
+		if name := value.String(); name != "" {
+			names[name] = struct{}{}
+		}
+	}
+	collect(gjson.GetBytes(rawJSON, path))
+	return names
+}
+
+// WithSyntheticModels appends the configured synthetic models to a model listing produced for
+// handlerType. A synthetic model is listed when its base model is, copying the base entry's
+// metadata; synthetic models whose base model is unavailable are left out.
+func (h *BaseAPIHandler) WithSyntheticModels(handlerType string, models []map[string]any) []map[string]any {
+	if h == nil || h.Cfg == nil || len(h.Cfg.SyntheticModels) == 0 {
+		return models
+	}
+	idKey := "id"
+	if handlerType == Gemini {
+		idKey = "name"
+	}
+	byID := make(map[string]map[string]any, len(models))
+	for _, model := range models {
+		if id, ok := model[idKey].(string); ok {
+			byID[strings.ToLower(strings.TrimPrefix(id, "models/"))] = model
+		}
+	}
+	for _, synthetic := range h.Cfg.SyntheticModels {
+		if _, exists := byID[strings.ToLower(synthetic.Name)]; exists {
+			continue
+		}
+		base, ok := byID[strings.ToLower(thinking.ParseSuffix(synthetic.Model).ModelName)]
+		if !ok {
+			continue
+		}
+		entry := make(map[string]any, len(base)+1)
+		for key, value := range base {
+			entry[key] = value
+		}
+		switch handlerType {
+		case Gemini:
+			entry["name"] = "models/" + synthetic.Name
+			entry["displayName"] = synthetic.Name
+			if synthetic.Description != "" {
+				entry["description"] = synthetic.Description
+			}
+		default:
+			entry["id"] = synthetic.Name
+			if _, hasDisplayName := entry["display_name"]; hasDisplayName {
+				entry["display_name"] = synthetic.Name
+			}
+			if synthetic.Description != "" {
+				entry["description"] = synthetic.Description
+			}
+		}
+		models = append(models, entry)
+	}
+	return models
+}
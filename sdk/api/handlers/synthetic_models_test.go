@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func syntheticTestModel() internalconfig.SyntheticModel {
+	temperature := 0.2
+	return internalconfig.SyntheticModel{
+		Name:         "reviewer",
+		Model:        "base-model",
+		SystemPrompt: "Review strictly.",
+		Temperature:  &temperature,
+		Tools: []internalconfig.SyntheticModelTool{
+			{Name: "read_file", Description: "Read a file", Parameters: map[string]any{"type": "object"}},
+			{Name: "client_tool"},
+		},
+	}
+}
+
+func TestApplySyntheticModelPayload(t *testing.T) {
+	model := syntheticTestModel()
+	cases := []struct {
+		protocol string
+		in       string
+		checks   map[string]string
+	}{
+		{
+			protocol: "openai",
+			in:       `{"model":"reviewer","temperature":0.9,"messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"client_tool"}}]}`,
+			checks: map[string]string{
+				"model":                            "base-model",
+				"temperature":                      "0.9",
+				"messages.0.role":                  "system",
+				"messages.0.content":               "Review strictly.",
+				"messages.1.content":               "hi",
+				"tools.#":                          "2",
+				"tools.1.function.name":            "read_file",
+				"tools.1.function.parameters.type": "object",
+			},
+		},
+		{
+			protocol: "openai-response",
+			in:       `{"model":"reviewer","instructions":"Be brief.","input":"hi"}`,
+			checks: map[string]string{
+				"instructions": "Review strictly.\n\nBe brief.",
+				"temperature":  "0.2",
+				"tools.#":      "2",
+				"tools.0.name": "read_file",
+				"tools.0.type": "function",
+			},
+		},
+		{
+			protocol: "claude",
+			in:       `{"model":"reviewer","system":[{"type":"text","text":"Be brief."}],"messages":[]}`,
+			checks: map[string]string{
+				"system.0.text":             "Review strictly.",
+				"system.1.text":             "Be brief.",
+				"temperature":               "0.2",
+				"tools.0.input_schema.type": "object",
+				"tools.1.name":              "client_tool",
+				"tools.1.input_schema.type": "object",
+			},
+		},
+		{
+			protocol: "gemini",
+			in:       `{"contents":[],"systemInstruction":{"parts":[{"text":"Be brief."}]},"tools":[{"functionDeclarations":[{"name":"client_tool"}]}]}`,
+			checks: map[string]string{
+				"systemInstruction.parts.0.text":      "Review strictly.",
+				"systemInstruction.parts.1.text":      "Be brief.",
+				"generationConfig.temperature":        "0.2",
+				"tools.#":                             "2",
+				"tools.1.functionDeclarations.#":      "1",
+				"tools.1.functionDeclarations.0.name": "read_file",
+				"model":                               "",
+			},
+		},
+	}
+	for _, tc := range cases {
+		out := applySyntheticModelPayload(tc.protocol, model, "base-model", []byte(tc.in))
+		for path, want := range tc.checks {
+			if got := gjson.GetBytes(out, path).String(); got != want {
+				t.Errorf("%s: %s = %q, want %q\n%s", tc.protocol, path, got, want, out)
+			}
+		}
+	}
+}
+
+func TestExecuteWithAuthManager_SyntheticModelRoutesToBaseModel(t *testing.T) {
+	gemini := &failoverTestExecutor{provider: "gemini"}
+	handler := newFailoverTestHandler(t, []*failoverTestExecutor{gemini}, []string{"synthetic-base"}, nil)
+	handler.Cfg.SyntheticModels = []internalconfig.SyntheticModel{{Name: "reviewer", Model: "synthetic-base"}}
+
+	body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "reviewer(high)", []byte(`{"model":"reviewer(high)"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(body) != "gemini:synthetic-base(high)" {
+		t.Fatalf("body = %q, want base model with thinking suffix", body)
+	}
+}
+
+func TestWithSyntheticModels(t *testing.T) {
+	handler := NewBaseAPIHandlers(nil, nil)
+	handler.Cfg = &internalconfig.SDKConfig{SyntheticModels: []internalconfig.SyntheticModel{
+		{Name: "reviewer", Model: "base-model", Description: "Reviews code"},
+		{Name: "orphan", Model: "missing-model"},
+	}}
+
+	openai := handler.WithSyntheticModels("openai", []map[string]any{{"id": "base-model", "object": "model", "owned_by": "test"}})
+	if len(openai) != 2 || openai[1]["id"] != "reviewer" || openai[1]["owned_by"] != "test" || openai[1]["description"] != "Reviews code" {
+		t.Fatalf("openai models = %v, want reviewer copied from base model", openai)
+	}
+	gemini := handler.WithSyntheticModels("gemini", []map[string]any{{"name": "models/base-model"}})
+	if len(gemini) != 2 || gemini[1]["name"] != "models/reviewer" {
+		t.Fatalf("gemini models = %v, want models/reviewer", gemini)
+	}
+}
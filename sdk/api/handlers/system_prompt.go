@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"strings"
+
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// prependSystemPrompt places text before the system prompt of rawJSON in the entry protocol's
+// format. Requests of other protocols are returned unchanged.
+func prependSystemPrompt(entryProtocol string, rawJSON []byte, text string) []byte {
+	return insertSystemPrompt(entryProtocol, rawJSON, text, true)
+}
+
+// appendSystemPrompt places text after the system prompt of rawJSON in the entry protocol's
+// format. Requests of other protocols are returned unchanged.
+func appendSystemPrompt(entryProtocol string, rawJSON []byte, text string) []byte {
+	return insertSystemPrompt(entryProtocol, rawJSON, text, false)
+}
+
+func insertSystemPrompt(entryProtocol string, rawJSON []byte, text string, before bool) []byte {
+	if text == "" || len(rawJSON) == 0 || !gjson.ValidBytes(rawJSON) {
+		return rawJSON
+	}
+	out := rawJSON
+	switch entryProtocol {
+	case OpenAI:
+		messages := gjson.GetBytes(out, "messages").Array()
+		insertAt := 0
+		if !before {
+			insertAt = leadingSystemMessageCount(messages)
+		}
+		rebuilt := []byte(`[]`)
+		for i, message := range messages {
+			if i == insertAt {
+				rebuilt, _ = sjson.SetBytes(rebuilt, "-1", map[string]any{"role": "system", "content": text})
+			}
+			rebuilt, _ = sjson.SetRawBytes(rebuilt, "-1", []byte(message.Raw))
+		}
+		if insertAt >= len(messages) {
+			rebuilt, _ = sjson.SetBytes(rebuilt, "-1", map[string]any{"role": "system", "content": text})
+		}
+		out, _ = sjson.SetRawBytes(out, "messages", rebuilt)
+	case OpenaiResponse:
+		out, _ = sjson.SetBytes(out, "instructions", joinSystemText(gjson.GetBytes(out, "instructions").String(), text, before))
+	case Claude:
+		system := gjson.GetBytes(out, "system")
+		switch {
+		case system.IsArray():
+			block := map[string]any{"type": "text", "text": text}
+			blocks := []byte(`[]`)
+			if before {
+				blocks, _ = sjson.SetBytes(blocks, "-1", block)
+			}
+			for _, existing := range system.Array() {
+				blocks, _ = sjson.SetRawBytes(blocks, "-1", []byte(existing.Raw))
+			}
+			if !before {
+				blocks, _ = sjson.SetBytes(blocks, "-1", block)
+			}
+			out, _ = sjson.SetRawBytes(out, "system", blocks)
+		default:
+			out, _ = sjson.SetBytes(out, "system", joinSystemText(system.String(), text, before))
+		}
+	case Gemini:
+		path := geminiSystemInstructionPath(out)
+		part := map[string]any{"text": text}
+		parts := []byte(`[]`)
+		if before {
+			parts, _ = sjson.SetBytes(parts, "-1", part)
+		}
+		for _, existing := range gjson.GetBytes(out, path+".parts").Array() {
+			parts, _ = sjson.SetRawBytes(parts, "-1", []byte(existing.Raw))
+		}
+		if !before {
+			parts, _ = sjson.SetBytes(parts, "-1", part)
+		}
+		out, _ = sjson.SetRawBytes(out, path+".parts", parts)
+	}
+	return out
+}
+
+// systemPromptText returns the text of the system prompt of rawJSON in the entry protocol's
+// format, joining multiple system messages, blocks or parts with blank lines.
+func systemPromptText(entryProtocol string, rawJSON []byte) string {
+	var texts []string
+	switch entryProtocol {
+	case OpenAI:
+		messages := gjson.GetBytes(rawJSON, "messages").Array()
+		for _, message := range messages[:leadingSystemMessageCount(messages)] {
+			texts = appendContentText(texts, message.Get("content"))
+		}
+	case OpenaiResponse:
+		texts = append(texts, gjson.GetBytes(rawJSON, "instructions").String())
+	case Claude:
+		texts = appendContentText(texts, gjson.GetBytes(rawJSON, "system"))
+	case Gemini:
+		for _, part := range gjson.GetBytes(rawJSON, geminiSystemInstructionPath(rawJSON)+".parts").Array() {
+			texts = append(texts, part.Get("text").String())
+		}
+	}
+	nonEmpty := texts[:0]
+	for _, text := range texts {
+		if text != "" {
+			nonEmpty = append(nonEmpty, text)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
+
+// replaceSystemPrompt sets the system prompt of rawJSON in the entry protocol's format to text,
+// dropping the existing one. An empty text removes the system prompt.
+func replaceSystemPrompt(entryProtocol string, rawJSON []byte, text string) []byte {
+	if len(rawJSON) == 0 || !gjson.ValidBytes(rawJSON) {
+		return rawJSON
+	}
+	out := rawJSON
+	switch entryProtocol {
+	case OpenAI:
+		messages := gjson.GetBytes(out, "messages").Array()
+		rebuilt := []byte(`[]`)
+		if text != "" {
+			rebuilt, _ = sjson.SetBytes(rebuilt, "-1", map[string]any{"role": "system", "content": text})
+		}
+		for _, message := range messages[leadingSystemMessageCount(messages):] {
+			rebuilt, _ = sjson.SetRawBytes(rebuilt, "-1", []byte(message.Raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "messages", rebuilt)
+	case OpenaiResponse:
+		out, _ = sjson.DeleteBytes(out, "instructions")
+	case Claude:
+		out, _ = sjson.DeleteBytes(out, "system")
+	case Gemini:
+		path := geminiSystemInstructionPath(out)
+		out, _ = sjson.DeleteBytes(out, path+".parts")
+		if text != "" {
+			out, _ = sjson.SetBytes(out, path+".parts", []map[string]any{{"text": text}})
+		} else {
+			out, _ = sjson.DeleteBytes(out, path)
+		}
+		return out
+	default:
+		return rawJSON
+	}
+	if entryProtocol != OpenAI {
+		out = insertSystemPrompt(entryProtocol, out, text, true)
+	}
+	return out
+}
+
+// leadingSystemMessageCount counts the system and developer messages opening an OpenAI
+// conversation.
+func leadingSystemMessageCount(messages []gjson.Result) int {
+	for i, message := range messages {
+		if role := message.Get("role").String(); role != "system" && role != "developer" {
+			return i
+		}
+	}
+	return len(messages)
+}
+
+// appendContentText appends the text of a string content or of the text blocks of a content array.
+func appendContentText(texts []string, content gjson.Result) []string {
+	if content.IsArray() {
+		for _, block := range content.Array() {
+			if block.Get("type").String() == "text" {
+				texts = append(texts, block.Get("text").String())
+			}
+		}
+		return texts
+	}
+	return append(texts, content.String())
+}
+
+func joinSystemText(existing, text string, before bool) string {
+	switch {
+	case existing == "":
+		return text
+	case before:
+		return text + "\n\n" + existing
+	default:
+		return existing + "\n\n" + text
+	}
+}
+
+func geminiSystemInstructionPath(rawJSON []byte) string {
+	if !gjson.GetBytes(rawJSON, "systemInstruction").Exists() && gjson.GetBytes(rawJSON, "system_instruction").Exists() {
+		return "system_instruction"
+	}
+	return "systemInstruction"
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// tokenCountPaths are the fields holding the input token count in the count-tokens
+// responses of the supported protocols.
+var tokenCountPaths = []string{
+	"input_tokens",
+	"totalTokens",
+	"usage.prompt_tokens",
+	"usage.input_tokens",
+	"response.usage.input_tokens",
+}
+
+// tokenCountFromResponse reads the input token count from a count-tokens response.
+func tokenCountFromResponse(payload []byte) int64 {
+	for _, path := range tokenCountPaths {
+		if value := gjson.GetBytes(payload, path); value.Exists() {
+			return value.Int()
+		}
+	}
+	return 0
+}
+
+// countTokensLocally counts the input tokens of rawJSON with the embedded tokenizers when
+// local-token-count is enabled. It returns false when local counting is disabled, the entry
+// protocol is not supported, or the request cannot be parsed, so callers fall back to the
+// providers' count-tokens support.
+func (h *BaseAPIHandler) countTokensLocally(entryProtocol, modelName string, rawJSON []byte) (int64, bool) {
+	if h == nil || h.Cfg == nil || !h.Cfg.LocalTokenCount {
+		return 0, false
+	}
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	count, err := helps.CountInputTokensLocally(sdktranslator.FromString(entryProtocol), baseModel, rawJSON)
+	if err != nil {
+		log.Debugf("local token count for model %s failed: %v", baseModel, err)
+		return 0, false
+	}
+	return count, true
+}
+
+// tokenCountResponse renders count in the count-tokens response shape of handlerType.
+func tokenCountResponse(handlerType string, count int64) []byte {
+	switch sdktranslator.FromString(handlerType) {
+	case sdktranslator.FormatGemini:
+		return []byte(fmt.Sprintf(`{"totalTokens":%d}`, count))
+	case sdktranslator.FormatOpenAI:
+		return helps.BuildOpenAIUsageJSON(count)
+	case sdktranslator.FormatOpenAIResponse:
+		return []byte(fmt.Sprintf(`{"object":"response.input_tokens","input_tokens":%d}`, count))
+	default:
+		return []byte(fmt.Sprintf(`{"input_tokens":%d}`, count))
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/transcripts"
+)
+
+// transcriptConversation returns the conversation a stream is recorded under: the client's
+// session ID header, or the execution session of a websocket connection.
+func transcriptConversation(ctx context.Context) string {
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		if sessionID := strings.TrimSpace(ginCtx.GetHeader(SessionIDHeader)); sessionID != "" {
+			return sessionID
+		}
+	}
+	return executionSessionIDFromContext(ctx)
+}
+
+// startTranscript begins recording a client stream when transcripts are enabled and the stream
+// belongs to a conversation. Transcripts are kept per client API key, so clients reusing a
+// session ID cannot read each other's streams. Internal requests and attempts started for the same client stream
+// are not recorded again.
+func startTranscript(ctx context.Context, exitProtocol, modelName string, execOptions *modelExecutionOptions) *transcripts.Recorder {
+	if ctx == nil || execOptions.transcriptChecked || execOptions.InternalSource {
+		return nil
+	}
+	execOptions.transcriptChecked = true
+	return transcripts.Default().Begin(clientAPIKeyFromContext(ctx), transcriptConversation(ctx), modelName, exitProtocol)
+}
+
+// teeTranscript forwards a client stream while recording its chunks. The transcript is stored
+// once the stream ends; it is complete only when the stream closed without an error before the
+// client went away.
+func teeTranscript(ctx context.Context, recorder *transcripts.Recorder, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	outData := make(chan []byte)
+	outErrs := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(outData)
+		defer close(outErrs)
+		var errText string
+		abandoned := false
+		for data != nil || errs != nil {
+			select {
+			case chunk, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				recorder.Write(chunk)
+				if abandoned {
+					continue
+				}
+				select {
+				case outData <- chunk:
+				case <-ctx.Done():
+					abandoned = true
+				}
+			case errMsg, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if errMsg == nil {
+					continue
+				}
+				if errText == "" {
+					errText = "stream failed"
+					if errMsg.Error != nil {
+						errText = errMsg.Error.Error()
+					}
+				}
+				if abandoned {
+					continue
+				}
+				select {
+				case outErrs <- errMsg:
+				case <-ctx.Done():
+					abandoned = true
+				}
+			}
+		}
+		complete := errText == "" && !abandoned && ctx.Err() == nil
+		if !complete && errText == "" {
+			errText = "stream interrupted"
+			if clientDisconnected(ctx) {
+				errText = ErrClientDisconnected.Error()
+			}
+		}
+		recorder.Finish(complete, errText)
+	}()
+	return outData, outErrs
+}
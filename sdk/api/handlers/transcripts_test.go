@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/transcripts"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestExecuteStreamWithAuthManager_RecordsTranscriptOfAbandonedStream(t *testing.T) {
+	transcripts.Default().Apply(internalconfig.TranscriptsConfig{Enable: true, Store: internalconfig.TranscriptStoreFile, Dir: t.TempDir(), MaxPerConversation: 5}, "")
+	t.Cleanup(func() { transcripts.Default().Apply(internalconfig.TranscriptsConfig{}, "") })
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&endlessStreamExecutor{cancelled: make(chan struct{})})
+	auth := &coreauth.Auth{ID: "transcript-auth", Provider: "codex", Status: coreauth.StatusActive, Metadata: map[string]any{"email": "transcript@example.com"}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "codex", []*registry.ModelInfo{{ID: "transcript-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	requestCtx, disconnectClient := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil).WithContext(requestCtx)
+	c.Request.Header.Set(SessionIDHeader, "cli-session-1")
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	cliCtx, cliCancel := handler.GetContextWithCancel(nil, c, context.Background())
+	defer cliCancel()
+
+	dataChan, _, _ := handler.ExecuteStreamWithAuthManager(cliCtx, "openai", "transcript-model", []byte(`{"model":"transcript-model"}`), "")
+	if chunk := <-dataChan; string(chunk) != "first" {
+		t.Fatalf("first chunk = %q, want %q", chunk, "first")
+	}
+	disconnectClient()
+	for range dataChan {
+	}
+
+	list, errList := transcripts.Default().List(context.Background(), "", "cli-session-1")
+	if errList != nil {
+		t.Fatalf("List: %v", errList)
+	}
+	if len(list) != 1 {
+		t.Fatalf("transcripts = %+v, want one", list)
+	}
+	if got := list[0]; got.Output != "first" || got.Complete || got.Error != ErrClientDisconnected.Error() || got.Model != "transcript-model" || got.Protocol != "openai" {
+		t.Fatalf("transcript = %+v, want the delivered chunk of an abandoned stream", got)
+	}
+}
@@ -20,6 +20,11 @@ type ServerOption = internalapi.ServerOption
 // WithMiddleware appends additional Gin middleware during server construction.
 func WithMiddleware(mw ...gin.HandlerFunc) ServerOption { return internalapi.WithMiddleware(mw...) }
 
+// WithPipelineMiddleware appends request and response interceptors to the provider handler pipeline.
+func WithPipelineMiddleware(mw ...handlers.PipelineMiddleware) ServerOption {
+	return internalapi.WithPipelineMiddleware(mw...)
+}
+
 // WithEngineConfigurator allows callers to mutate the Gin engine prior to middleware setup.
 func WithEngineConfigurator(fn func(*gin.Engine)) ServerOption {
 	return internalapi.WithEngineConfigurator(fn)
@@ -44,3 +49,14 @@ func WithKeepAliveEndpoint(timeout time.Duration, onTimeout func()) ServerOption
 func WithRequestLoggerFactory(factory func(*config.Config, string) logging.RequestLogger) ServerOption {
 	return internalapi.WithRequestLoggerFactory(factory)
 }
+
+// ConfigModule receives configuration updates after config.yaml is reloaded.
+type ConfigModule = internalapi.ConfigModule
+
+// ConfigModuleFunc adapts a function to the ConfigModule interface.
+type ConfigModuleFunc = internalapi.ConfigModuleFunc
+
+// WithConfigModule registers modules notified on every configuration reload.
+func WithConfigModule(modules ...ConfigModule) ServerOption {
+	return internalapi.WithConfigModule(modules...)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/credcrypt"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
 )
@@ -228,3 +231,106 @@ func (f fileStoreMultiAuthParserFunc) ParseAuth(context.Context, pluginapi.AuthP
 func (f fileStoreMultiAuthParserFunc) ParseAuths(ctx context.Context, req pluginapi.AuthParseRequest) ([]*cliproxyauth.Auth, bool, error) {
 	return f(ctx, req)
 }
+
+func TestFileTokenStoreSealsRefreshTokenOnDisk(t *testing.T) {
+	t.Setenv("TEST_CREDENTIAL_KEY", "filestore-secret")
+	credcrypt.Default().Apply(config.CredentialEncryptionConfig{Enable: true, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "TEST_CREDENTIAL_KEY"}})
+	t.Cleanup(func() { credcrypt.Default().Apply(config.CredentialEncryptionConfig{}) })
+
+	dir := t.TempDir()
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	auth := &cliproxyauth.Auth{
+		ID:       "claude-user.json",
+		Provider: "claude",
+		FileName: "claude-user.json",
+		Metadata: map[string]any{"type": "claude", "access_token": "at", "refresh_token": "rt-secret"},
+	}
+	path, errSave := store.Save(context.Background(), auth)
+	if errSave != nil {
+		t.Fatalf("Save: %v", errSave)
+	}
+	stored, errRead := os.ReadFile(path)
+	if errRead != nil {
+		t.Fatalf("read saved file: %v", errRead)
+	}
+	if strings.Contains(string(stored), "rt-secret") {
+		t.Fatalf("refresh token stored in plaintext: %s", stored)
+	}
+	if auth.Metadata["refresh_token"] != "rt-secret" {
+		t.Fatalf("in-memory refresh token = %v, want it left in plaintext", auth.Metadata["refresh_token"])
+	}
+
+	if _, errSave = store.Save(context.Background(), auth); errSave != nil {
+		t.Fatalf("second Save: %v", errSave)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(stored) {
+		t.Fatal("saving an unchanged credential rewrote its ciphertext")
+	}
+
+	auths, errList := store.List(context.Background())
+	if errList != nil {
+		t.Fatalf("List: %v", errList)
+	}
+	if len(auths) != 1 || auths[0].Metadata["refresh_token"] != "rt-secret" {
+		t.Fatalf("listed auths = %+v, want the decrypted refresh token", auths)
+	}
+}
+
+// plaintextGuardStorage fails the test when its payload is written without being sealed first.
+type plaintextGuardStorage struct {
+	t       *testing.T
+	payload []byte
+}
+
+func (s *plaintextGuardStorage) SaveTokenToFile(path string) error {
+	s.t.Fatalf("SaveTokenToFile(%s) wrote the refresh token in plaintext", path)
+	return nil
+}
+
+func (s *plaintextGuardStorage) MarshalToken() ([]byte, error) { return s.payload, nil }
+
+func TestFileTokenStoreNeverWritesStorageRefreshTokenInPlaintext(t *testing.T) {
+	t.Setenv("TEST_CREDENTIAL_KEY", "filestore-secret")
+	credcrypt.Default().Apply(config.CredentialEncryptionConfig{Enable: true, MasterKeyConfig: config.MasterKeyConfig{MasterKeyEnv: "TEST_CREDENTIAL_KEY"}})
+	t.Cleanup(func() { credcrypt.Default().Apply(config.CredentialEncryptionConfig{}) })
+
+	dir := t.TempDir()
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	auth := &cliproxyauth.Auth{
+		ID:       "claude-user.json",
+		Provider: "claude",
+		FileName: "claude-user.json",
+		Storage:  &plaintextGuardStorage{t: t, payload: []byte(`{"type":"claude","access_token":"at","refresh_token":"rt-secret"}`)},
+	}
+	path, errSave := store.Save(context.Background(), auth)
+	if errSave != nil {
+		t.Fatalf("Save: %v", errSave)
+	}
+	stored, errRead := os.ReadFile(path)
+	if errRead != nil {
+		t.Fatalf("read saved file: %v", errRead)
+	}
+	if strings.Contains(string(stored), "rt-secret") {
+		t.Fatalf("refresh token stored in plaintext: %s", stored)
+	}
+	opened, errOpen := credcrypt.Default().OpenJSON(stored)
+	if errOpen != nil || !strings.Contains(string(opened), "rt-secret") {
+		t.Fatalf("OpenJSON = %s, %v; want the refresh token back", opened, errOpen)
+	}
+
+	info, _ := os.Stat(path)
+	if _, errSave = store.Save(context.Background(), auth); errSave != nil {
+		t.Fatalf("second Save: %v", errSave)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(stored) {
+		t.Fatal("saving an unchanged credential rewrote its ciphertext")
+	}
+	if after, _ := os.Stat(path); !after.ModTime().Equal(info.ModTime()) {
+		t.Fatal("saving an unchanged credential rewrote the file")
+	}
+	if _, errStat := os.Stat(path + ".tmp"); !os.IsNotExist(errStat) {
+		t.Fatalf("temporary file left behind: %v", errStat)
+	}
+}
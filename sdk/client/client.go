@@ -0,0 +1,346 @@
+// Package client embeds the proxy service in a Go program. A Client owns a cliproxy.Service,
+// lets callers register pipeline middleware, executors and credentials programmatically, and
+// executes requests through the canonical handler pipeline in-process instead of over HTTP.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkapi "github.com/router-for-me/CLIProxyAPI/v7/sdk/api"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// Entry protocols accepted by Request.Protocol.
+const (
+	ProtocolOpenAI          = "openai"
+	ProtocolOpenAIResponses = "openai-response"
+	ProtocolClaude          = "claude"
+	ProtocolGemini          = "gemini"
+)
+
+// Option customizes a Client during construction.
+type Option func(*options)
+
+type options struct {
+	builder    []func(*cliproxy.Builder)
+	middleware []handlers.PipelineMiddleware
+	executors  []coreauth.ProviderExecutor
+	hooks      cliproxy.Hooks
+}
+
+// WithPipelineMiddleware registers middleware that runs after the built-in pipeline stages.
+func WithPipelineMiddleware(mw ...handlers.PipelineMiddleware) Option {
+	return func(o *options) { o.middleware = append(o.middleware, mw...) }
+}
+
+// WithExecutors registers provider executors with the runtime auth manager.
+func WithExecutors(executors ...coreauth.ProviderExecutor) Option {
+	return func(o *options) { o.executors = append(o.executors, executors...) }
+}
+
+// WithHooks registers lifecycle hooks. Builder.WithHooks must not be used through WithBuilder
+// because the client relies on its own start hook.
+func WithHooks(hooks cliproxy.Hooks) Option {
+	return func(o *options) { o.hooks = hooks }
+}
+
+// WithBuilder applies further customization to the underlying service builder.
+func WithBuilder(fn func(*cliproxy.Builder)) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.builder = append(o.builder, fn)
+		}
+	}
+}
+
+// Client runs an embedded proxy service and executes requests against it in-process.
+type Client struct {
+	service *cliproxy.Service
+
+	ready chan struct{}
+
+	mu      sync.Mutex
+	started bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+	runErr  error
+}
+
+// New builds an embedded service from cfg. configPath is the file watched for reloads, as
+// for the standalone binary. The service does not run until Start is called.
+func New(cfg *config.Config, configPath string, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	c := &Client{ready: make(chan struct{})}
+	userAfterStart := o.hooks.OnAfterStart
+	hooks := o.hooks
+	hooks.OnAfterStart = func(s *cliproxy.Service) {
+		if userAfterStart != nil {
+			userAfterStart(s)
+		}
+		close(c.ready)
+	}
+
+	builder := cliproxy.NewBuilder().WithConfig(cfg).WithConfigPath(configPath)
+	for _, fn := range o.builder {
+		fn(builder)
+	}
+	builder.WithHooks(hooks)
+	if len(o.middleware) > 0 {
+		builder.WithServerOptions(sdkapi.WithPipelineMiddleware(o.middleware...))
+	}
+	service, errBuild := builder.Build()
+	if errBuild != nil {
+		return nil, errBuild
+	}
+	for _, executor := range o.executors {
+		service.RegisterExecutor(executor)
+	}
+	c.service = service
+	return c, nil
+}
+
+// Service returns the embedded service.
+func (c *Client) Service() *cliproxy.Service {
+	return c.service
+}
+
+// Start runs the service in the background and returns once it accepts requests. The service
+// stops when ctx is cancelled or Close is called.
+func (c *Client) Start(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c.mu.Lock()
+	if c.started {
+		c.mu.Unlock()
+		return errors.New("client: already started")
+	}
+	c.started = true
+	runCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	done := c.done
+	c.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		errRun := c.service.Run(runCtx)
+		if errRun != nil && !errors.Is(errRun, context.Canceled) {
+			c.mu.Lock()
+			c.runErr = errRun
+			c.mu.Unlock()
+		}
+	}()
+
+	select {
+	case <-c.ready:
+		return nil
+	case <-done:
+		if errRun := c.err(); errRun != nil {
+			return errRun
+		}
+		return errors.New("client: service stopped during startup")
+	case <-ctx.Done():
+		cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Close stops the service and waits for it to exit or for ctx to expire.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-done:
+		return c.err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runErr
+}
+
+// RegisterAuth injects a credential together with its executor and models. Credentials added
+// this way are kept in memory only and are never written to the auth directory. models is
+// needed for providers the proxy cannot list models for, such as custom executors.
+func (c *Client) RegisterAuth(ctx context.Context, auth *coreauth.Auth, models ...*cliproxy.ModelInfo) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if errUpsert := c.service.UpsertAuth(coreauth.WithSkipPersist(ctx), auth); errUpsert != nil {
+		return errUpsert
+	}
+	if len(models) > 0 {
+		cliproxy.GlobalModelRegistry().RegisterClient(auth.ID, auth.Provider, models)
+		c.service.CoreManager().RefreshSchedulerEntry(auth.ID)
+	}
+	return nil
+}
+
+// RemoveAuth removes a credential previously registered by ID.
+func (c *Client) RemoveAuth(ctx context.Context, id string) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c.service.RemoveAuth(coreauth.WithSkipPersist(ctx), id)
+}
+
+// Request is one call into the pipeline. Body is the client payload in the entry protocol.
+type Request struct {
+	Protocol string
+	Model    string
+	Body     []byte
+	// Alt is the optional alternate response format used by Gemini routes.
+	Alt string
+}
+
+// Response is a completed non-streaming response in the entry protocol.
+type Response struct {
+	Body   []byte
+	Header http.Header
+}
+
+// Error is returned when the pipeline rejects or fails a request.
+type Error struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("client: request failed with status %d", e.StatusCode)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func wrapError(msg *interfaces.ErrorMessage) error {
+	if msg == nil {
+		return nil
+	}
+	return &Error{StatusCode: msg.StatusCode, Err: msg.Error}
+}
+
+func (c *Client) handler(req Request) (*handlers.BaseAPIHandler, error) {
+	select {
+	case <-c.ready:
+	default:
+		return nil, errors.New("client: service is not running")
+	}
+	handler := c.service.Handlers()
+	if handler == nil {
+		return nil, errors.New("client: service is not running")
+	}
+	if strings.TrimSpace(req.Protocol) == "" || strings.TrimSpace(req.Model) == "" {
+		return nil, errors.New("client: protocol and model are required")
+	}
+	return handler, nil
+}
+
+// Execute runs a non-streaming request through the pipeline.
+func (c *Client) Execute(ctx context.Context, req Request) (*Response, error) {
+	handler, errHandler := c.handler(req)
+	if errHandler != nil {
+		return nil, errHandler
+	}
+	body, header, errMsg := handler.ExecuteWithAuthManager(ctx, req.Protocol, req.Model, req.Body, req.Alt)
+	if errMsg != nil {
+		return nil, wrapError(errMsg)
+	}
+	return &Response{Body: body, Header: header}, nil
+}
+
+// CountTokens runs a token counting request through the pipeline.
+func (c *Client) CountTokens(ctx context.Context, req Request) (*Response, error) {
+	handler, errHandler := c.handler(req)
+	if errHandler != nil {
+		return nil, errHandler
+	}
+	body, header, errMsg := handler.ExecuteCountWithAuthManager(ctx, req.Protocol, req.Model, req.Body, req.Alt)
+	if errMsg != nil {
+		return nil, wrapError(errMsg)
+	}
+	return &Response{Body: body, Header: header}, nil
+}
+
+// Stream is a streaming response. Chunks is closed when the stream ends; Err reports why
+// once Chunks is drained.
+type Stream struct {
+	Header http.Header
+	Chunks <-chan []byte
+
+	err error
+}
+
+// Err returns the error that ended the stream, or nil after a complete stream.
+func (s *Stream) Err() error { return s.err }
+
+// Stream runs a streaming request through the pipeline. Cancel ctx to abandon the stream.
+func (c *Client) Stream(ctx context.Context, req Request) (*Stream, error) {
+	handler, errHandler := c.handler(req)
+	if errHandler != nil {
+		return nil, errHandler
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	data, header, errs := handler.ExecuteStreamWithAuthManager(ctx, req.Protocol, req.Model, req.Body, req.Alt)
+	out := make(chan []byte)
+	stream := &Stream{Header: header, Chunks: out}
+	go func() {
+		defer close(out)
+		for data != nil || errs != nil {
+			select {
+			case chunk, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					stream.err = ctx.Err()
+					return
+				}
+			case errMsg, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if errMsg != nil {
+					stream.err = wrapError(errMsg)
+					return
+				}
+			}
+		}
+	}()
+	return stream, nil
+}
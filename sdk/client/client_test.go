@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+const testProvider = "client-test"
+
+type testExecutor struct{}
+
+func (testExecutor) Identifier() string { return testProvider }
+
+func (testExecutor) Execute(_ context.Context, auth *coreauth.Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"auth":"` + auth.ID + `","model":"` + req.Model + `"}`)}, nil
+}
+
+func (testExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	chunks := make(chan cliproxyexecutor.StreamChunk, 2)
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte("data: one\n\n")}
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte("data: two\n\n")}
+	close(chunks)
+	return &cliproxyexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (testExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (testExecutor) CountTokens(_ context.Context, _ *coreauth.Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"total_tokens":3}`)}, nil
+}
+
+func (testExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not supported")
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("Listen() error = %v", errListen)
+	}
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func startTestClient(t *testing.T, opts ...Option) (*Client, string) {
+	t.Helper()
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if errWrite := os.WriteFile(configPath, []byte("{}\n"), 0o600); errWrite != nil {
+		t.Fatalf("WriteFile() error = %v", errWrite)
+	}
+	authDir := filepath.Join(dir, "auths")
+	cfg := &config.Config{Host: "127.0.0.1", Port: freePort(t), AuthDir: authDir}
+
+	c, errNew := New(cfg, configPath, append([]Option{WithExecutors(testExecutor{})}, opts...)...)
+	if errNew != nil {
+		t.Fatalf("New() error = %v", errNew)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if errStart := c.Start(ctx); errStart != nil {
+		t.Fatalf("Start() error = %v", errStart)
+	}
+	t.Cleanup(func() {
+		closeCtx, closeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer closeCancel()
+		_ = c.Close(closeCtx)
+	})
+	return c, authDir
+}
+
+func TestClientExecutesThroughPipeline(t *testing.T) {
+	var seen []byte
+	c, authDir := startTestClient(t, WithPipelineMiddleware(handlers.PipelineMiddleware{
+		Name: "capture",
+		Request: func(_ context.Context, _ handlers.PipelineCall, body []byte) ([]byte, *interfaces.ErrorMessage) {
+			seen = append([]byte(nil), body...)
+			return body, nil
+		},
+	}))
+
+	ctx := context.Background()
+	req := Request{Protocol: ProtocolOpenAI, Model: "client-test-model", Body: []byte(`{"model":"client-test-model","messages":[{"role":"user","content":"hi"}]}`)}
+	if _, errExec := c.Execute(ctx, req); errExec == nil {
+		t.Fatal("expected execution without credentials to fail")
+	}
+
+	auth := &coreauth.Auth{ID: "client-test-auth", Provider: testProvider, Status: coreauth.StatusActive}
+	if errRegister := c.RegisterAuth(ctx, auth, &cliproxy.ModelInfo{ID: "client-test-model"}); errRegister != nil {
+		t.Fatalf("RegisterAuth() error = %v", errRegister)
+	}
+	if entries, _ := os.ReadDir(authDir); len(entries) != 0 {
+		t.Fatal("injected auth must not be persisted")
+	}
+
+	resp, errExec := c.Execute(ctx, req)
+	if errExec != nil {
+		t.Fatalf("Execute() error = %v", errExec)
+	}
+	if len(resp.Body) == 0 || len(seen) == 0 {
+		t.Fatalf("Execute() body = %s, middleware saw %s", resp.Body, seen)
+	}
+
+	stream, errStream := c.Stream(ctx, req)
+	if errStream != nil {
+		t.Fatalf("Stream() error = %v", errStream)
+	}
+	var chunks int
+	for range stream.Chunks {
+		chunks++
+	}
+	if stream.Err() != nil || chunks == 0 {
+		t.Fatalf("stream chunks = %d, err = %v", chunks, stream.Err())
+	}
+
+	c.RemoveAuth(ctx, auth.ID)
+	_, errExec = c.Execute(ctx, req)
+	var clientErr *Error
+	if !errors.As(errExec, &clientErr) || clientErr.StatusCode == 0 {
+		t.Fatalf("Execute() after RemoveAuth error = %v", errExec)
+	}
+}
+
+func TestClientRejectsCallsBeforeStart(t *testing.T) {
+	dir := t.TempDir()
+	c, errNew := New(&config.Config{AuthDir: dir}, filepath.Join(dir, "config.yaml"))
+	if errNew != nil {
+		t.Fatalf("New() error = %v", errNew)
+	}
+	if _, errExec := c.Execute(context.Background(), Request{Protocol: ProtocolOpenAI, Model: "m"}); errExec == nil {
+		t.Fatal("expected Execute before Start to fail")
+	}
+	if errClose := c.Close(context.Background()); errClose != nil {
+		t.Fatalf("Close() before Start error = %v", errClose)
+	}
+}
@@ -81,7 +81,7 @@ func (s *Service) antigravityModelFetchProxyURL(auth *coreauth.Auth) string {
 		}
 	}
 	if s != nil && s.cfg != nil {
-		return strings.TrimSpace(s.cfg.ProxyURL)
+		return s.cfg.ProviderProxyURL("antigravity")
 	}
 	return ""
 }
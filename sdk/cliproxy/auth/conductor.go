@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"math/rand/v2"
 	"net/http"
 	"path/filepath"
 	"sort"
@@ -18,8 +17,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
@@ -263,15 +264,24 @@ type Manager struct {
 
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
+	// Optional per-request base URL override, e.g. regional endpoint selection.
+	endpointSelector EndpointSelector
 
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 	refreshLoop   *authAutoRefreshLoop
 
+	// credentialInFlight enforces per-credential max-concurrency for local execution.
+	credentialInFlight credentialInFlight
+
 	requestPrepareLocks sync.Map
 	// refreshLocks serializes credential refresh per auth ID so concurrent
 	// 401 recoveries and auto-refresh workers do not race the same refresh_token.
 	refreshLocks sync.Map
+
+	// clock and rand time cooldown waits and draw their jitter.
+	clock clock.Clock
+	rand  clock.Rand
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -293,6 +303,8 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		homeSessionSelections: make(map[string]map[homeSessionSelectionKey]*HomeDispatchSelection),
 		providerOffsets:       make(map[string]int),
 		modelPoolOffsets:      make(map[string]int),
+		clock:                 clock.Default(),
+		rand:                  clock.DefaultRand(),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
@@ -1560,9 +1572,31 @@ func (m *Manager) resolveAPIKeyModelAliasWithResult(auth *Auth, requestedModel s
 		if entry := resolveXAIAPIKeyConfig(cfg, auth); entry != nil {
 			models = asModelAliasEntries(entry.Models)
 		}
+	case "bedrock":
+		if entry := resolveBedrockAPIKeyConfig(cfg, auth); entry != nil {
+			models = asModelAliasEntries(entry.Models)
+		}
+	case "azure-openai":
+		if entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth); entry != nil {
+			models = asModelAliasEntries(entry.Models)
+		}
+	case "mistral":
+		if entry := resolveMistralAPIKeyConfig(cfg, auth); entry != nil {
+			models = asModelAliasEntries(entry.Models)
+		}
+	case "deepseek":
+		if entry := resolveDeepSeekAPIKeyConfig(cfg, auth); entry != nil {
+			models = asModelAliasEntries(entry.Models)
+		}
+	case "cohere":
+		if entry := resolveCohereAPIKeyConfig(cfg, auth); entry != nil {
+			models = asModelAliasEntries(entry.Models)
+		}
 	case "vertex":
 		if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
 			models = asModelAliasEntries(entry.Models)
+		} else if entry := resolveVertexServiceAccountConfig(cfg, auth); entry != nil {
+			models = asModelAliasEntries(entry.Models)
 		}
 	default:
 		providerKey := ""
@@ -2275,9 +2309,31 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 			if entry := resolveXAIAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
+		case "bedrock":
+			if entry := resolveBedrockAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "azure-openai":
+			if entry := resolveAzureOpenAIAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "mistral":
+			if entry := resolveMistralAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "deepseek":
+			if entry := resolveDeepSeekAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "cohere":
+			if entry := resolveCohereAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
 		case "vertex":
 			if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			} else if entry := resolveVertexServiceAccountConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
 		default:
 			// OpenAI-compat uses config selection from auth.Attributes.
@@ -2599,7 +2655,7 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		if !shouldRetry {
 			break
 		}
-		if errWait := waitForCooldown(ctx, wait, maxWait); errWait != nil {
+		if errWait := m.waitForCooldown(ctx, wait, maxWait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
 	}
@@ -2625,6 +2681,9 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 	if m.HomeEnabled() {
 		return m.executeHome(ctx, normalized, req, opts, true)
 	}
+	if preflightCountFromMetadata(opts.Metadata) {
+		return m.executeCountMixedOnce(ctx, normalized, req, opts, 1)
+	}
 
 	_, maxRetryCredentials, maxWait := m.retrySettings()
 
@@ -2640,7 +2699,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		if !shouldRetry {
 			break
 		}
-		if errWait := waitForCooldown(ctx, wait, maxWait); errWait != nil {
+		if errWait := m.waitForCooldown(ctx, wait, maxWait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
 	}
@@ -2677,7 +2736,7 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		if !shouldRetry {
 			break
 		}
-		if errWait := waitForCooldown(ctx, wait, maxWait); errWait != nil {
+		if errWait := m.waitForCooldown(ctx, wait, maxWait); errWait != nil {
 			return nil, errWait
 		}
 	}
@@ -2857,7 +2916,7 @@ func requestToFormat(provider string, executor ProviderExecutor, req cliproxyexe
 		}
 	}
 	source := opts.SourceFormat.String()
-	if source == "openai-image" || source == "openai-video" {
+	if source == "openai-image" || source == "openai-video" || source == "openai-audio" {
 		return opts.SourceFormat
 	}
 	if opts.Alt == "responses/compact" && !opts.Stream {
@@ -2921,10 +2980,14 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
+	releaseSlot := func() {}
+	defer func() { releaseSlot() }()
 	for {
+		releaseSlot()
+		releaseSlot = func() {}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -2945,9 +3008,17 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, routeModel)
-		publishSelectedAuthMetadata(opts.Metadata, auth)
 
 		tried[auth.ID] = struct{}{}
+		if !homeMode {
+			release, errSlot := m.acquireCredentialSlot(auth)
+			if errSlot != nil {
+				lastErr = errSlot
+				continue
+			}
+			releaseSlot = release
+		}
+		publishSelectedAuthMetadata(opts.Metadata, auth)
 		execCtx := ctx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
@@ -3032,6 +3103,8 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	routeModel := authSelectionModelFromOptions(opts, req.Model)
 	executionModel, restoreExecutionModel := executionModelForAuthSelection(opts, req.Model)
 	opts = ensureRequestedModelMetadata(opts, routeModel)
+	// A preflight count runs once and leaves credential availability alone.
+	preflight := preflightCountFromMetadata(opts.Metadata)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
 	tried := make(map[string]struct{})
@@ -3077,6 +3150,10 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		auth, errPrepare = m.prepareRequestAuth(execCtx, executor, auth)
 		if errPrepare != nil {
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: resultErrorFromError(errPrepare)}
+			if preflight {
+				m.recordAvailabilityNeutralResult(execCtx, result)
+				return cliproxyexecutor.Response{}, errPrepare
+			}
 			m.MarkResult(execCtx, result)
 			lastErr = errPrepare
 			continue
@@ -3093,7 +3170,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			execOpts := opts
 			execReq, execOpts = applyRequestAfterAuthInterceptor(execCtx, executor, provider, execReq, execOpts, requestedModelAliasFromOptions(execOpts, routeModel))
 			resp, errExec := executor.CountTokens(execCtx, auth, execReq, execOpts)
-			if errExec != nil {
+			if errExec != nil && !preflight {
 				if errCtx := execCtx.Err(); errCtx != nil {
 					return cliproxyexecutor.Response{}, errCtx
 				}
@@ -3118,18 +3195,22 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 				// count_tokens route and return a generic endpoint 404. Record
 				// the failure for hooks and metrics without suspending a model
 				// that remains usable through the messages endpoint.
-				if isCountTokensEndpointNotFoundError(errExec, execReq.Model) {
+				if preflight || isCountTokensEndpointNotFoundError(errExec, execReq.Model) {
 					m.recordAvailabilityNeutralResult(execCtx, result)
 				} else {
 					m.MarkResult(execCtx, result)
 				}
-				if isRequestInvalidError(errExec) {
+				if preflight || isRequestInvalidError(errExec) {
 					return cliproxyexecutor.Response{}, errExec
 				}
 				authErr = errExec
 				continue
 			}
-			m.MarkResult(execCtx, result)
+			if preflight {
+				m.recordAvailabilityNeutralResult(execCtx, result)
+			} else {
+				m.MarkResult(execCtx, result)
+			}
 			rewriteForceMappedResponse(&resp, aliasResult)
 			return resp, nil
 		}
@@ -3156,10 +3237,14 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
+	releaseSlot := func() {}
+	defer func() { releaseSlot() }()
 	for {
+		releaseSlot()
+		releaseSlot = func() {}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return nil, lastErr
@@ -3207,9 +3292,17 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 				return nil, errRuntimeAuth
 			}
 		}
-		publishSelectedAuthMetadata(opts.Metadata, auth)
 
 		tried[auth.ID] = struct{}{}
+		if selection == nil {
+			release, errSlot := m.acquireCredentialSlot(auth)
+			if errSlot != nil {
+				lastErr = errSlot
+				continue
+			}
+			releaseSlot = release
+		}
+		publishSelectedAuthMetadata(opts.Metadata, auth)
 		execCtx := ctx
 		releaseAttempt := func() {}
 		if selection != nil {
@@ -3299,7 +3392,9 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			}
 			return wrapHomeStream(ctx, streamResult, selection, releaseAttempt), nil
 		}
-		return streamResult, nil
+		release := releaseSlot
+		releaseSlot = func() {}
+		return wrapHomeStream(ctx, streamResult, nil, release), nil
 	}
 }
 
@@ -3518,7 +3613,17 @@ func (m *Manager) prepareHomeRequestAuth(ctx context.Context, executor ProviderE
 	return prepare()
 }
 
+// prepareRequestAuth lets the executor refresh request metadata and then applies the
+// endpoint selector to the auth used for this request.
 func (m *Manager) prepareRequestAuth(ctx context.Context, executor ProviderExecutor, auth *Auth) (*Auth, error) {
+	prepared, errPrepare := m.prepareRequestAuthMetadata(ctx, executor, auth)
+	if errPrepare != nil {
+		return prepared, errPrepare
+	}
+	return m.withSelectedEndpoint(prepared), nil
+}
+
+func (m *Manager) prepareRequestAuthMetadata(ctx context.Context, executor ProviderExecutor, auth *Auth) (*Auth, error) {
 	if m == nil || executor == nil || auth == nil {
 		return auth, nil
 	}
@@ -3584,6 +3689,9 @@ func contextWithRequestedModelAlias(ctx context.Context, opts cliproxyexecutor.O
 	if generate, ok := generateFromOptions(opts); ok {
 		ctx = coreusage.WithGenerate(ctx, generate)
 	}
+	if tags, ok := opts.Metadata[cliproxyexecutor.RequestTagsMetadataKey].([]string); ok {
+		ctx = coreusage.WithRequestTags(ctx, tags)
+	}
 	return ctx
 }
 
@@ -3686,6 +3794,26 @@ func pinnedAuthIDFromMetadata(meta map[string]any) string {
 	}
 }
 
+// excludedAuthIDsFromMetadata returns the auth IDs excluded from selection as a tried set.
+func excludedAuthIDsFromMetadata(meta map[string]any) map[string]struct{} {
+	tried := make(map[string]struct{})
+	ids, _ := meta[cliproxyexecutor.ExcludedAuthsMetadataKey].([]string)
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			tried[id] = struct{}{}
+		}
+	}
+	return tried
+}
+
+func preflightCountFromMetadata(meta map[string]any) bool {
+	if len(meta) == 0 {
+		return false
+	}
+	preflight, _ := meta[cliproxyexecutor.PreflightCountMetadataKey].(bool)
+	return preflight
+}
+
 func disallowFreeAuthFromMetadata(meta map[string]any) bool {
 	if len(meta) == 0 {
 		return false
@@ -3883,6 +4011,41 @@ func resolveXAIAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalcon
 	return resolveAPIKeyConfig(cfg.XAIKey, auth)
 }
 
+func resolveBedrockAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.BedrockKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.BedrockKey, auth)
+}
+
+func resolveAzureOpenAIAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.AzureOpenAIKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.AzureOpenAIKey, auth)
+}
+
+func resolveMistralAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.MistralKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.MistralKey, auth)
+}
+
+func resolveDeepSeekAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.DeepSeekKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.DeepSeekKey, auth)
+}
+
+func resolveCohereAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.CohereKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.CohereKey, auth)
+}
+
 func resolveVertexAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.VertexCompatKey {
 	if cfg == nil {
 		return nil
@@ -3890,6 +4053,26 @@ func resolveVertexAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internal
 	return resolveAPIKeyConfig(cfg.VertexCompatAPIKey, auth)
 }
 
+// resolveVertexServiceAccountConfig matches vertex-service-account entries by the
+// credentials file, project and location recorded on the synthesized auth.
+func resolveVertexServiceAccountConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.VertexServiceAccount {
+	if cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	if _, ok := auth.Attributes["credentials_file"]; !ok {
+		return nil
+	}
+	for i := range cfg.VertexServiceAccount {
+		entry := &cfg.VertexServiceAccount[i]
+		if entry.CredentialsFile == auth.Attributes["credentials_file"] &&
+			entry.ProjectID == auth.Attributes["project_id"] &&
+			entry.Location == auth.Attributes["location"] {
+			return entry
+		}
+	}
+	return nil
+}
+
 func resolveUpstreamModelForGeminiAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	entry := resolveGeminiAPIKeyConfig(cfg, auth)
 	if entry == nil {
@@ -3931,11 +4114,13 @@ func resolveUpstreamModelForXAIAPIKey(cfg *internalconfig.Config, auth *Auth, re
 }
 
 func resolveUpstreamModelForVertexAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
-	entry := resolveVertexAPIKeyConfig(cfg, auth)
-	if entry == nil {
-		return ""
+	if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
+		return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
 	}
-	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+	if entry := resolveVertexServiceAccountConfig(cfg, auth); entry != nil {
+		return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+	}
+	return ""
 }
 
 func resolveUpstreamModelForOpenAICompatAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
@@ -4235,7 +4420,7 @@ const cooldownWaitJitterCap = 2 * time.Second
 // lockstep and stampede the first credential that recovers. The jitter never
 // pushes the total wait past maxWait, which callers have already enforced as
 // the retry ceiling; maxWait <= 0 means no ceiling.
-func jitteredCooldownWait(wait, maxWait time.Duration) time.Duration {
+func jitteredCooldownWait(rng clock.Rand, wait, maxWait time.Duration) time.Duration {
 	if wait <= 0 {
 		return wait
 	}
@@ -4249,19 +4434,26 @@ func jitteredCooldownWait(wait, maxWait time.Duration) time.Duration {
 	if jitterRange <= 0 {
 		return wait
 	}
-	return wait + rand.N(jitterRange)
+	return wait + clock.Duration(rng, jitterRange)
 }
 
-func waitForCooldown(ctx context.Context, wait, maxWait time.Duration) error {
+func (m *Manager) waitForCooldown(ctx context.Context, wait, maxWait time.Duration) error {
 	if wait <= 0 {
 		return nil
 	}
-	timer := time.NewTimer(jitteredCooldownWait(wait, maxWait))
+	clk, rng := m.clock, m.rand
+	if clk == nil {
+		clk = clock.Default()
+	}
+	if rng == nil {
+		rng = clock.DefaultRand()
+	}
+	timer := clk.NewTimer(jitteredCooldownWait(rng, wait, maxWait))
 	defer timer.Stop()
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-timer.C:
+	case <-timer.C():
 		return nil
 	}
 }
@@ -4385,6 +4577,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 						case 429:
 							var next time.Time
 							backoffLevel := state.Quota.BackoffLevel
+							alreadyExceeded := state.Quota.Exceeded
 							if !disableCooling {
 								if result.RetryAfter != nil {
 									next = now.Add(*result.RetryAfter)
@@ -4392,6 +4585,19 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 									next, backoffLevel = quotaCooldownAfterFailure(state.Quota, now)
 								}
 							}
+							if !alreadyExceeded {
+								data := map[string]any{
+									"scope":    "credential",
+									"provider": auth.Provider,
+									"auth_id":  auth.ID,
+									"label":    auth.Label,
+									"model":    result.Model,
+								}
+								if !next.IsZero() {
+									data["recover_at"] = next
+								}
+								lifecycle.Default().Emit(lifecycle.EventQuotaExceeded, data)
+							}
 							state.NextRetryAfter = next
 							state.Quota = QuotaState{
 								Exceeded:      true,
@@ -6919,6 +7125,40 @@ func (m *Manager) StopAutoRefresh() {
 	}
 }
 
+// RefreshDue synchronously refreshes every credential whose token is due for refresh and
+// returns the number of refreshes attempted. It complements the auto-refresh loop for
+// on-demand and scheduled sweeps.
+func (m *Manager) RefreshDue(ctx context.Context) int {
+	if m == nil {
+		return 0
+	}
+	now := time.Now()
+	var due []string
+	m.mu.RLock()
+	for id, auth := range m.auths {
+		if auth == nil || auth.Disabled || m.executors[auth.Provider] == nil {
+			continue
+		}
+		if m.shouldRefresh(auth, now) {
+			due = append(due, id)
+		}
+	}
+	m.mu.RUnlock()
+
+	attempted := 0
+	for _, id := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if !m.markRefreshPending(id, now) {
+			continue
+		}
+		m.refreshAuth(ctx, id)
+		attempted++
+	}
+	return attempted
+}
+
 func (m *Manager) queueRefreshReschedule(authID string) {
 	if m == nil || authID == "" {
 		return
@@ -7233,6 +7473,12 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	_, _ = m.refreshAuthForRequest(ctx, id, "")
 }
 
+// RefreshAuth synchronously refreshes the credential id through its executor and returns
+// the updated auth. Failures are recorded on the auth like a background refresh.
+func (m *Manager) RefreshAuth(ctx context.Context, id string) (*Auth, error) {
+	return m.refreshAuthForRequest(ctx, id, "")
+}
+
 // refreshAuthForRequest performs a synchronous credential refresh for the given auth.
 // failedAccessToken lets concurrent callers reuse a refresh that already replaced the
 // access token that produced the unauthorized response.
@@ -7307,6 +7553,14 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 		if shouldReschedule {
 			m.queueRefreshReschedule(id)
 		}
+		if unauthorized {
+			lifecycle.Default().Emit(lifecycle.EventCredentialExpired, map[string]any{
+				"provider": auth.Provider,
+				"auth_id":  auth.ID,
+				"label":    auth.Label,
+				"error":    err.Error(),
+			})
+		}
 		return nil, err
 	}
 	if updated == nil {
@@ -7337,6 +7591,11 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 	if errUpdate != nil {
 		log.Debugf("persist refreshed auth %s (%s) failed: %v", auth.Provider, auth.ID, errUpdate)
 	}
+	lifecycle.Default().Emit(lifecycle.EventCredentialRefreshed, map[string]any{
+		"provider": auth.Provider,
+		"auth_id":  auth.ID,
+		"label":    auth.Label,
+	})
 	if saved != nil {
 		return saved, nil
 	}
@@ -1444,6 +1444,48 @@ func TestManager_ExecuteCount_ExplicitModelNotFoundSuspendsModel(t *testing.T) {
 	}
 }
 
+func TestManager_ExecuteCount_PreflightTriesOnceWithoutCooldown(t *testing.T) {
+	previous := quotaCooldownDisabled.Load()
+	quotaCooldownDisabled.Store(false)
+	t.Cleanup(func() { quotaCooldownDisabled.Store(previous) })
+
+	hook := &resultCaptureHook{}
+	m := NewManager(nil, nil, hook)
+	rateLimited := &Error{Code: "rate_limited", HTTPStatus: http.StatusTooManyRequests, Message: "slow down"}
+	executor := &authFallbackExecutor{
+		id: "claude",
+		countTokenErrors: map[string]error{
+			"preflight-count-auth-a": rateLimited,
+			"preflight-count-auth-b": rateLimited,
+		},
+	}
+	m.RegisterExecutor(executor)
+
+	model := "preflight-count-model"
+	reg := registry.GetGlobalRegistry()
+	for _, id := range []string{"preflight-count-auth-a", "preflight-count-auth-b"} {
+		reg.RegisterClient(id, "claude", []*registry.ModelInfo{{ID: model}})
+		t.Cleanup(func() { reg.UnregisterClient(id) })
+		if _, errRegister := m.Register(context.Background(), &Auth{ID: id, Provider: "claude"}); errRegister != nil {
+			t.Fatalf("register auth: %v", errRegister)
+		}
+	}
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PreflightCountMetadataKey: true}}
+	if _, errCount := m.ExecuteCount(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: model}, opts); errCount == nil {
+		t.Fatal("expected preflight count error")
+	}
+	if results := hook.Results(); len(results) != 1 {
+		t.Fatalf("hook results = %#v, want a single attempt", results)
+	}
+	for _, id := range []string{"preflight-count-auth-a", "preflight-count-auth-b"} {
+		updated, _ := m.GetByID(id)
+		if state := updated.ModelStates[model]; state != nil && state.Unavailable {
+			t.Fatalf("auth %s cooled down after a preflight count: %#v", id, state)
+		}
+	}
+}
+
 func TestIsCountTokensEndpointNotFoundError(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"testing"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/clock"
 )
 
 func withQuotaCooldownEnabled(t *testing.T) {
@@ -153,6 +155,7 @@ func TestApplyAuthFailureStateQuotaBackoffOncePerWindow(t *testing.T) {
 }
 
 func TestJitteredCooldownWaitBounds(t *testing.T) {
+	rng := clock.DefaultRand()
 	cases := []struct {
 		wait      time.Duration
 		maxWait   time.Duration
@@ -166,7 +169,7 @@ func TestJitteredCooldownWaitBounds(t *testing.T) {
 	}
 	for _, tc := range cases {
 		for i := 0; i < 200; i++ {
-			got := jitteredCooldownWait(tc.wait, tc.maxWait)
+			got := jitteredCooldownWait(rng, tc.wait, tc.maxWait)
 			if got < tc.wait || got >= tc.wait+tc.maxJitter {
 				t.Fatalf("jitteredCooldownWait(%v, %v) = %v, want in [%v, %v)", tc.wait, tc.maxWait, got, tc.wait, tc.wait+tc.maxJitter)
 			}
@@ -178,18 +181,52 @@ func TestJitteredCooldownWaitBounds(t *testing.T) {
 
 	// maxWait is a hard ceiling: zero headroom disables jitter entirely.
 	for i := 0; i < 50; i++ {
-		if got := jitteredCooldownWait(30*time.Second, 30*time.Second); got != 30*time.Second {
+		if got := jitteredCooldownWait(rng, 30*time.Second, 30*time.Second); got != 30*time.Second {
 			t.Fatalf("expected wait at maxWait to stay unjittered, got %v", got)
 		}
 	}
 
-	if got := jitteredCooldownWait(0, time.Minute); got != 0 {
+	if got := jitteredCooldownWait(rng, 0, time.Minute); got != 0 {
 		t.Fatalf("expected zero wait to stay zero, got %v", got)
 	}
-	if got := jitteredCooldownWait(-time.Second, time.Minute); got != -time.Second {
+	if got := jitteredCooldownWait(rng, -time.Second, time.Minute); got != -time.Second {
 		t.Fatalf("expected negative wait to pass through, got %v", got)
 	}
-	if got := jitteredCooldownWait(3, 0); got != 3 {
+	if got := jitteredCooldownWait(rng, 3, 0); got != 3 {
 		t.Fatalf("expected sub-4ns wait to stay unchanged, got %v", got)
 	}
 }
+
+func TestWaitForCooldownUsesInjectedClock(t *testing.T) {
+	sim := clock.NewSim(time.Unix(1_700_000_000, 0))
+	m := NewManager(nil, nil, nil)
+	m.clock, m.rand = sim, clock.NewRand(1)
+	wait := 8 * time.Second
+	jittered := jitteredCooldownWait(clock.NewRand(1), wait, 0)
+
+	done := make(chan error, 1)
+	go func() { done <- m.waitForCooldown(context.Background(), wait, 0) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for sim.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("waitForCooldown did not arm its timer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	sim.Advance(jittered - time.Nanosecond)
+	select {
+	case errWait := <-done:
+		t.Fatalf("waitForCooldown returned %v before the jittered wait elapsed", errWait)
+	case <-time.After(20 * time.Millisecond):
+	}
+	sim.Advance(time.Nanosecond)
+	select {
+	case errWait := <-done:
+		if errWait != nil {
+			t.Fatalf("waitForCooldown error = %v", errWait)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waitForCooldown did not return after the jittered wait")
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// maxConcurrencyAttributeKey holds a per-credential cap on local in-flight requests.
+const maxConcurrencyAttributeKey = "max_concurrency"
+
+// credentialBusyRetryAfter is the hint returned when every eligible credential is saturated.
+const credentialBusyRetryAfter = time.Second
+
+// CredentialBusyError reports that a credential already has its maximum number of in-flight requests.
+type CredentialBusyError struct {
+	cause *Error
+}
+
+func newCredentialBusyError(auth *Auth, limit int) *CredentialBusyError {
+	message := "credential concurrency limit exceeded"
+	if auth != nil {
+		message = "credential concurrency limit exceeded for " + auth.Provider + " (" + strconv.Itoa(limit) + " in flight)"
+	}
+	return &CredentialBusyError{cause: &Error{
+		Code:       "credential_concurrency_exceeded",
+		Message:    message,
+		Retryable:  true,
+		HTTPStatus: http.StatusTooManyRequests,
+	}}
+}
+
+func (e *CredentialBusyError) Error() string {
+	if e == nil || e.cause == nil {
+		return ""
+	}
+	return e.cause.Error()
+}
+
+// Unwrap exposes the underlying auth error for errors.As callers.
+func (e *CredentialBusyError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.cause
+}
+
+func (e *CredentialBusyError) StatusCode() int { return http.StatusTooManyRequests }
+
+func (e *CredentialBusyError) RetryAfter() *time.Duration {
+	value := credentialBusyRetryAfter
+	return &value
+}
+
+func (e *CredentialBusyError) SafeResponseHeaders() http.Header {
+	return safeRetryAfterHeader(credentialBusyRetryAfter)
+}
+
+// credentialInFlight counts local in-flight requests per credential ID.
+type credentialInFlight struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// maxConcurrencyForAuth returns the in-flight cap for auth; 0 means unlimited.
+// A positive per-credential max_concurrency attribute overrides routing.max-concurrency.
+func (m *Manager) maxConcurrencyForAuth(auth *Auth) int {
+	if auth == nil {
+		return 0
+	}
+	if raw := strings.TrimSpace(auth.Attributes[maxConcurrencyAttributeKey]); raw != "" {
+		if limit, errAtoi := strconv.Atoi(raw); errAtoi == nil && limit > 0 {
+			return limit
+		}
+	}
+	if cfg, ok := m.runtimeConfig.Load().(*internalconfig.Config); ok && cfg != nil && cfg.Routing.MaxConcurrency > 0 {
+		return cfg.Routing.MaxConcurrency
+	}
+	return 0
+}
+
+// acquireCredentialSlot reserves one in-flight slot on auth. The returned release func is idempotent.
+func (m *Manager) acquireCredentialSlot(auth *Auth) (func(), error) {
+	limit := m.maxConcurrencyForAuth(auth)
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	id := auth.ID
+	m.credentialInFlight.mu.Lock()
+	defer m.credentialInFlight.mu.Unlock()
+	if m.credentialInFlight.counts == nil {
+		m.credentialInFlight.counts = make(map[string]int)
+	}
+	if m.credentialInFlight.counts[id] >= limit {
+		return nil, newCredentialBusyError(auth, limit)
+	}
+	m.credentialInFlight.counts[id]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.credentialInFlight.mu.Lock()
+			defer m.credentialInFlight.mu.Unlock()
+			if m.credentialInFlight.counts[id] <= 1 {
+				delete(m.credentialInFlight.counts, id)
+				return
+			}
+			m.credentialInFlight.counts[id]--
+		})
+	}, nil
+}
+
+// CredentialInFlight returns the number of local in-flight requests holding a slot on the credential.
+func (m *Manager) CredentialInFlight(authID string) int {
+	if m == nil {
+		return 0
+	}
+	m.credentialInFlight.mu.Lock()
+	defer m.credentialInFlight.mu.Unlock()
+	return m.credentialInFlight.counts[authID]
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+const credentialConcurrencyTestProvider = "concurrency-test"
+
+type blockingConcurrencyExecutor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (e *blockingConcurrencyExecutor) Identifier() string { return credentialConcurrencyTestProvider }
+
+func (e *blockingConcurrencyExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.started <- auth.ID
+	select {
+	case <-e.release:
+	case <-ctx.Done():
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *blockingConcurrencyExecutor) ExecuteStream(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+	go func() {
+		<-e.release
+		close(ch)
+	}()
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *blockingConcurrencyExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	return auth, nil
+}
+
+func (e *blockingConcurrencyExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusNotImplemented, Message: "CountTokens not implemented"}
+}
+
+func (e *blockingConcurrencyExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, &Error{HTTPStatus: http.StatusNotImplemented, Message: "HttpRequest not implemented"}
+}
+
+func newCredentialConcurrencyTestManager(t *testing.T, cfg *internalconfig.Config, auths ...*Auth) (*Manager, *blockingConcurrencyExecutor) {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(cfg)
+	executor := &blockingConcurrencyExecutor{started: make(chan string, 4), release: make(chan struct{})}
+	m.RegisterExecutor(executor)
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range auths {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+		reg.RegisterClient(auth.ID, credentialConcurrencyTestProvider, []*registry.ModelInfo{{ID: "concurrency-model"}})
+		authID := auth.ID
+		t.Cleanup(func() { reg.UnregisterClient(authID) })
+	}
+	return m, executor
+}
+
+func TestManagerExecute_RejectsWhenCredentialSaturated(t *testing.T) {
+	auth := &Auth{
+		ID:         "concurrency-auth-" + t.Name(),
+		Provider:   credentialConcurrencyTestProvider,
+		Status:     StatusActive,
+		Attributes: map[string]string{"api_key": "k", maxConcurrencyAttributeKey: "1"},
+	}
+	m, executor := newCredentialConcurrencyTestManager(t, &internalconfig.Config{}, auth)
+	req := cliproxyexecutor.Request{Model: "concurrency-model"}
+
+	done := make(chan error, 1)
+	go func() {
+		_, errExec := m.Execute(context.Background(), []string{credentialConcurrencyTestProvider}, req, cliproxyexecutor.Options{})
+		done <- errExec
+	}()
+	<-executor.started
+	if got := m.CredentialInFlight(auth.ID); got != 1 {
+		t.Fatalf("in flight = %d, want 1", got)
+	}
+
+	_, errBusy := m.Execute(context.Background(), []string{credentialConcurrencyTestProvider}, req, cliproxyexecutor.Options{})
+	var busy *CredentialBusyError
+	if !errors.As(errBusy, &busy) {
+		t.Fatalf("second execute error = %v, want CredentialBusyError", errBusy)
+	}
+	if got := statusCodeFromError(errBusy); got != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+	if got := SafeResponseHeaders(errBusy).Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After = %q, want %q", got, "1")
+	}
+
+	close(executor.release)
+	if errExec := <-done; errExec != nil {
+		t.Fatalf("first execute: %v", errExec)
+	}
+	if got := m.CredentialInFlight(auth.ID); got != 0 {
+		t.Fatalf("in flight after completion = %d, want 0", got)
+	}
+}
+
+func TestManagerExecute_SkipsSaturatedCredential(t *testing.T) {
+	first := &Auth{ID: "concurrency-a-" + t.Name(), Provider: credentialConcurrencyTestProvider, Status: StatusActive, Attributes: map[string]string{"api_key": "a"}}
+	second := &Auth{ID: "concurrency-b-" + t.Name(), Provider: credentialConcurrencyTestProvider, Status: StatusActive, Attributes: map[string]string{"api_key": "b"}}
+	cfg := &internalconfig.Config{}
+	cfg.Routing.MaxConcurrency = 1
+	m, executor := newCredentialConcurrencyTestManager(t, cfg, first, second)
+	req := cliproxyexecutor.Request{Model: "concurrency-model"}
+
+	results := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			resp, errExec := m.Execute(context.Background(), []string{credentialConcurrencyTestProvider}, req, cliproxyexecutor.Options{})
+			if errExec != nil {
+				results <- "error: " + errExec.Error()
+				return
+			}
+			results <- string(resp.Payload)
+		}()
+	}
+	startedA, startedB := <-executor.started, <-executor.started
+	if startedA == startedB {
+		t.Fatalf("both requests ran on %s, want one per credential", startedA)
+	}
+	close(executor.release)
+	for i := 0; i < 2; i++ {
+		if got := <-results; got != first.ID && got != second.ID {
+			t.Fatalf("result = %q", got)
+		}
+	}
+}
+
+func TestManagerExecuteStream_HoldsSlotUntilStreamEnds(t *testing.T) {
+	auth := &Auth{
+		ID:         "concurrency-stream-" + t.Name(),
+		Provider:   credentialConcurrencyTestProvider,
+		Status:     StatusActive,
+		Attributes: map[string]string{"api_key": "k", maxConcurrencyAttributeKey: "1"},
+	}
+	m, executor := newCredentialConcurrencyTestManager(t, &internalconfig.Config{}, auth)
+	req := cliproxyexecutor.Request{Model: "concurrency-model"}
+
+	result, errStream := m.ExecuteStream(context.Background(), []string{credentialConcurrencyTestProvider}, req, cliproxyexecutor.Options{})
+	if errStream != nil {
+		t.Fatalf("ExecuteStream: %v", errStream)
+	}
+	if got := m.CredentialInFlight(auth.ID); got != 1 {
+		t.Fatalf("in flight during stream = %d, want 1", got)
+	}
+	if _, errBusy := m.ExecuteStream(context.Background(), []string{credentialConcurrencyTestProvider}, req, cliproxyexecutor.Options{}); !errors.As(errBusy, new(*CredentialBusyError)) {
+		t.Fatalf("second stream error = %v, want CredentialBusyError", errBusy)
+	}
+
+	close(executor.release)
+	for range result.Chunks {
+	}
+	if got := m.CredentialInFlight(auth.ID); got != 0 {
+		t.Fatalf("in flight after stream = %d, want 0", got)
+	}
+}
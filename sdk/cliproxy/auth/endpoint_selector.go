@@ -0,0 +1,43 @@
+package auth
+
+import "strings"
+
+// EndpointSelector overrides the upstream base URL of an auth at request time, for example
+// to route a credential to its fastest regional endpoint. Implementations return false to
+// keep the configured base URL.
+type EndpointSelector interface {
+	BaseURLFor(auth *Auth) (string, bool)
+}
+
+// SetEndpointSelector registers the selector consulted before each local execution.
+// Passing nil restores the configured base URLs.
+func (m *Manager) SetEndpointSelector(selector EndpointSelector) {
+	m.mu.Lock()
+	m.endpointSelector = selector
+	m.mu.Unlock()
+}
+
+// withSelectedEndpoint returns auth with its base_url attribute replaced by the selector's
+// choice. The stored auth is never modified; a clone is returned when the URL changes.
+func (m *Manager) withSelectedEndpoint(auth *Auth) *Auth {
+	if m == nil || auth == nil {
+		return auth
+	}
+	m.mu.RLock()
+	selector := m.endpointSelector
+	m.mu.RUnlock()
+	if selector == nil {
+		return auth
+	}
+	baseURL, ok := selector.BaseURLFor(auth)
+	baseURL = strings.TrimSpace(baseURL)
+	if !ok || baseURL == "" || (auth.Attributes != nil && auth.Attributes["base_url"] == baseURL) {
+		return auth
+	}
+	selected := auth.Clone()
+	if selected.Attributes == nil {
+		selected.Attributes = make(map[string]string)
+	}
+	selected.Attributes["base_url"] = baseURL
+	return selected
+}
@@ -0,0 +1,32 @@
+package auth
+
+import "testing"
+
+type staticEndpointSelector string
+
+func (s staticEndpointSelector) BaseURLFor(*Auth) (string, bool) {
+	return string(s), s != ""
+}
+
+func TestManagerWithSelectedEndpointOverridesBaseURL(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	auth := &Auth{ID: "a", Attributes: map[string]string{"base_url": "https://primary.example.com"}}
+
+	if got := m.withSelectedEndpoint(auth); got != auth {
+		t.Fatal("expected auth unchanged without a selector")
+	}
+
+	m.SetEndpointSelector(staticEndpointSelector("https://eu.example.com"))
+	got := m.withSelectedEndpoint(auth)
+	if got.Attributes["base_url"] != "https://eu.example.com" {
+		t.Fatalf("base_url = %q, want selected endpoint", got.Attributes["base_url"])
+	}
+	if auth.Attributes["base_url"] != "https://primary.example.com" {
+		t.Fatal("selector modified the original auth")
+	}
+
+	m.SetEndpointSelector(staticEndpointSelector(""))
+	if got := m.withSelectedEndpoint(auth); got != auth {
+		t.Fatal("expected auth unchanged when the selector declines")
+	}
+}
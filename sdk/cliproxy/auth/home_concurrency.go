@@ -262,13 +262,17 @@ func verifyAccountedHomeConcurrencyIdentity(tuple homeConcurrencyTuple, auth *Au
 	return nil
 }
 
-// SafeResponseHeaders returns trusted response headers only for CPA's concrete Home busy error.
+// SafeResponseHeaders returns trusted response headers only for CPA's concrete busy errors.
 func SafeResponseHeaders(err error) http.Header {
 	var busy *HomeConcurrencyBusyError
-	if !errors.As(err, &busy) || busy == nil {
-		return nil
+	if errors.As(err, &busy) && busy != nil {
+		return busy.SafeResponseHeaders()
+	}
+	var credentialBusy *CredentialBusyError
+	if errors.As(err, &credentialBusy) && credentialBusy != nil {
+		return credentialBusy.SafeResponseHeaders()
 	}
-	return busy.SafeResponseHeaders()
+	return nil
 }
 
 func safeRetryAfterHeader(retryAfter time.Duration) http.Header {
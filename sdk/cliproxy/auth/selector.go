@@ -371,12 +371,15 @@ var sessionPattern = regexp.MustCompile(`_session_([a-f0-9-]+)$`)
 type SessionAffinitySelector struct {
 	fallback Selector
 	cache    *SessionCache
+	header   string
 }
 
 // SessionAffinityConfig configures the session affinity selector.
 type SessionAffinityConfig struct {
 	Fallback Selector
 	TTL      time.Duration
+	// Header optionally names a client header carrying a conversation or thread ID.
+	Header string
 }
 
 // NewSessionAffinitySelector creates a new session-aware selector.
@@ -398,6 +401,7 @@ func NewSessionAffinitySelectorWithConfig(cfg SessionAffinityConfig) *SessionAff
 	return &SessionAffinitySelector{
 		fallback: cfg.Fallback,
 		cache:    NewSessionCache(cfg.TTL),
+		header:   strings.TrimSpace(cfg.Header),
 	}
 }
 
@@ -405,18 +409,20 @@ func NewSessionAffinitySelectorWithConfig(cfg SessionAffinityConfig) *SessionAff
 // Priority for session ID extraction:
 //  1. metadata.user_id (Claude Code format with _session_{uuid}) - highest priority
 //  2. X-Session-ID header
-//  3. Session_id header (Codex)
-//  4. X-Client-Request-Id header (PI)
-//  5. metadata.user_id (non-Claude Code format)
-//  6. conversation_id field in request body
-//  7. Stable hash from first few messages content (fallback)
+//  3. The configured conversation header (routing.session-affinity-header)
+//  4. Session_id header (Codex)
+//  5. X-Client-Request-Id header (PI)
+//  6. metadata.user_id (non-Claude Code format)
+//  7. user field in request body (OpenAI)
+//  8. conversation_id field in request body
+//  9. Stable hash from first few messages content (fallback)
 //
 // Note: The cache key includes provider, session ID, and model to handle cases where
 // a session uses multiple models (e.g., gemini-2.5-pro and gemini-3-flash-preview)
 // that may be supported by different auth credentials, and to avoid cross-provider conflicts.
 func (s *SessionAffinitySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
 	entry := selectorLogEntry(ctx)
-	primaryID, fallbackID := extractSessionIDs(opts.Headers, opts.OriginalRequest, opts.Metadata)
+	primaryID, fallbackID := extractSessionIDsWithHeader(opts.Headers, opts.OriginalRequest, opts.Metadata, s.header)
 	if primaryID == "" {
 		entry.Debugf("session-affinity: no session ID extracted, falling back to default selector | provider=%s model=%s", provider, model)
 		return s.fallback.Pick(ctx, provider, model, opts, auths)
@@ -509,8 +515,9 @@ func (s *SessionAffinitySelector) InvalidateAuth(authID string) {
 //  3. Session_id header (Codex)
 //  4. X-Client-Request-Id header (PI)
 //  5. metadata.user_id (non-Claude Code format)
-//  6. conversation_id field in request body
-//  7. Stable hash from first few messages content (fallback)
+//  6. user field in request body (OpenAI)
+//  7. conversation_id field in request body
+//  8. Stable hash from first few messages content (fallback)
 func ExtractSessionID(headers http.Header, payload []byte, metadata map[string]any) string {
 	primary, _ := extractSessionIDs(headers, payload, metadata)
 	return primary
@@ -520,6 +527,12 @@ func ExtractSessionID(headers http.Header, payload []byte, metadata map[string]a
 // primaryID: full hash including assistant response (stable after first turn)
 // fallbackID: short hash without assistant (used to inherit binding from first turn)
 func extractSessionIDs(headers http.Header, payload []byte, metadata map[string]any) (string, string) {
+	return extractSessionIDsWithHeader(headers, payload, metadata, "")
+}
+
+// extractSessionIDsWithHeader is extractSessionIDs with an additional conversation header,
+// checked right after X-Session-ID.
+func extractSessionIDsWithHeader(headers http.Header, payload []byte, metadata map[string]any, conversationHeader string) (string, string) {
 	// 1. metadata.user_id with Claude Code session format (highest priority)
 	if len(payload) > 0 {
 		userID := gjson.GetBytes(payload, "metadata.user_id").String()
@@ -539,11 +552,16 @@ func extractSessionIDs(headers http.Header, payload []byte, metadata map[string]
 		}
 	}
 
-	// 2. X-Session-ID header
+	// 2. X-Session-ID header, then the configured conversation header
 	if headers != nil {
 		if sid := headers.Get("X-Session-ID"); sid != "" {
 			return "header:" + sid, ""
 		}
+		if conversationHeader != "" {
+			if cid := strings.TrimSpace(headers.Get(conversationHeader)); cid != "" {
+				return hashSessionValue("thread", cid), ""
+			}
+		}
 	}
 
 	// 3. Session_id header (Codex)
@@ -573,12 +591,17 @@ func extractSessionIDs(headers http.Header, payload []byte, metadata map[string]
 		return "user:" + userID, ""
 	}
 
-	// 7. conversation_id field
+	// 7. user field (OpenAI); hashed so end-user identifiers are not kept in the cache
+	if user := strings.TrimSpace(gjson.GetBytes(payload, "user").String()); user != "" {
+		return hashSessionValue("user", user), ""
+	}
+
+	// 8. conversation_id field
 	if convID := gjson.GetBytes(payload, "conversation_id").String(); convID != "" {
 		return "conv:" + convID, ""
 	}
 
-	// 8. Hash-based fallback from message content
+	// 9. Hash-based fallback from message content
 	return extractMessageHashIDs(payload)
 }
 
@@ -749,6 +772,13 @@ func extractMessageHashIDs(payload []byte) (primaryID, fallbackID string) {
 	return fullHash, shortHash
 }
 
+// hashSessionValue returns a stable, non-reversible session ID for a client-supplied identifier.
+func hashSessionValue(kind, value string) string {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	return fmt.Sprintf("%s:%016x", kind, h.Sum64())
+}
+
 func computeSessionHash(systemPrompt, userMsg, assistantMsg string) string {
 	h := fnv.New64a()
 	if systemPrompt != "" {
@@ -1275,3 +1275,50 @@ func TestSessionAffinitySelector_Concurrent(t *testing.T) {
 	default:
 	}
 }
+
+func TestExtractSessionID_OpenAIUserFieldIsHashed(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(`{"user":"alice@example.com","messages":[{"role":"user","content":"hi"}]}`)
+
+	got := ExtractSessionID(nil, payload, nil)
+	if !strings.HasPrefix(got, "user:") || strings.Contains(got, "alice") {
+		t.Fatalf("ExtractSessionID() with user field = %q, want hashed user ID", got)
+	}
+	if again := ExtractSessionID(nil, payload, nil); again != got {
+		t.Fatalf("ExtractSessionID() = %q then %q, want stable ID", got, again)
+	}
+}
+
+func TestSessionAffinitySelector_ConversationHeaderPinsAuth(t *testing.T) {
+	t.Parallel()
+
+	selector := NewSessionAffinitySelectorWithConfig(SessionAffinityConfig{
+		Fallback: &RoundRobinSelector{},
+		TTL:      time.Minute,
+		Header:   "X-Conversation-ID",
+	})
+	defer selector.Stop()
+
+	auths := []*Auth{{ID: "auth-a"}, {ID: "auth-b"}, {ID: "auth-c"}}
+	pick := func(conversationID, content string) string {
+		headers := make(http.Header)
+		headers.Set("X-Conversation-ID", conversationID)
+		opts := cliproxyexecutor.Options{
+			Headers:         headers,
+			OriginalRequest: []byte(`{"messages":[{"role":"user","content":"` + content + `"}]}`),
+		}
+		got, err := selector.Pick(context.Background(), "codex", "gpt-5", opts, auths)
+		if err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+		return got.ID
+	}
+
+	first := pick("thread-1", "first turn")
+	for i := 0; i < 5; i++ {
+		if got := pick("thread-1", fmt.Sprintf("turn %d", i)); got != first {
+			t.Fatalf("Pick() turn %d auth = %q, want %q for the same conversation", i, got, first)
+		}
+	}
+}
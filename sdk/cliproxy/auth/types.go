@@ -363,6 +363,16 @@ func (a *Auth) indexSeed() string {
 			apiPrefix = "codex-api-key"
 		case strings.EqualFold(provider, "xai"):
 			apiPrefix = "xai-api-key"
+		case strings.EqualFold(provider, "bedrock"):
+			apiPrefix = "bedrock-api-key"
+		case strings.EqualFold(provider, "azure-openai"):
+			apiPrefix = "azure-openai-api-key"
+		case strings.EqualFold(provider, "mistral"):
+			apiPrefix = "mistral-api-key"
+		case strings.EqualFold(provider, "deepseek"):
+			apiPrefix = "deepseek-api-key"
+		case strings.EqualFold(provider, "cohere"):
+			apiPrefix = "cohere-api-key"
 		case strings.EqualFold(provider, "claude"):
 			apiPrefix = "claude-api-key"
 		}
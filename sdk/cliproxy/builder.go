@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	configaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/config_access"
+	mtlsaccess "github.com/router-for-me/CLIProxyAPI/v7/internal/access/mtls_access"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginhost"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher"
@@ -216,6 +217,7 @@ func (b *Builder) Build() (*Service, error) {
 	}
 
 	configaccess.Register(&b.cfg.SDKConfig)
+	mtlsaccess.Register(&b.cfg.TLS)
 	pluginHost := b.pluginHost
 	if pluginHost == nil {
 		pluginHost = pluginhost.New()
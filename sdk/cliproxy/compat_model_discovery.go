@@ -0,0 +1,175 @@
+package cliproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// compatModelDiscoveryTTL bounds how long a discovered /models listing is reused.
+	compatModelDiscoveryTTL = 10 * time.Minute
+	// compatModelDiscoveryTimeout bounds a single upstream /models request.
+	compatModelDiscoveryTimeout = 15 * time.Second
+)
+
+type compatDiscoveredModelsEntry struct {
+	models    []*ModelInfo
+	fetchedAt time.Time
+}
+
+// withDiscoveredCompatModels appends the upstream model listing of an openai-compatibility
+// provider with discover-models enabled to its static models. Static entries win on ID
+// collisions so configured aliases, thinking and modality settings are preserved. Failed
+// discoveries fall back to the last successful listing, or to the static models alone.
+func (s *Service) withDiscoveredCompatModels(ctx context.Context, a *coreauth.Auth, compatName, providerKey string, static []*ModelInfo) []*ModelInfo {
+	if s == nil || s.cfg == nil || a == nil {
+		return static
+	}
+	var baseURL, ownedBy string
+	discover := false
+	for i := range s.cfg.OpenAICompatibility {
+		compat := &s.cfg.OpenAICompatibility[i]
+		if compat.Disabled || !strings.EqualFold(compat.Name, compatName) {
+			continue
+		}
+		discover = compat.DiscoverModels
+		baseURL = compat.BaseURL
+		ownedBy = compat.Name
+		break
+	}
+	if !discover {
+		s.compatDiscoveredModels.Delete(a.ID)
+		return static
+	}
+	if v := strings.TrimSpace(a.Attributes["base_url"]); v != "" {
+		baseURL = v
+	}
+
+	var discovered []*ModelInfo
+	cached, hasCached := s.compatDiscoveredModels.Load(a.ID)
+	if hasCached && time.Since(cached.(compatDiscoveredModelsEntry).fetchedAt) < compatModelDiscoveryTTL {
+		discovered = cached.(compatDiscoveredModelsEntry).models
+	} else {
+		models, errFetch := s.fetchCompatModels(ctx, a, providerKey, baseURL, ownedBy)
+		if errFetch != nil {
+			log.Warnf("openai-compatibility %s: model discovery failed: %v", compatName, errFetch)
+			if hasCached {
+				discovered = cached.(compatDiscoveredModelsEntry).models
+			}
+		} else {
+			discovered = models
+			s.compatDiscoveredModels.Store(a.ID, compatDiscoveredModelsEntry{models: models, fetchedAt: time.Now()})
+		}
+	}
+	if len(discovered) == 0 {
+		return static
+	}
+
+	seen := make(map[string]struct{}, len(static)+len(discovered))
+	out := make([]*ModelInfo, 0, len(static)+len(discovered))
+	for _, model := range static {
+		if model == nil {
+			continue
+		}
+		seen[strings.ToLower(model.ID)] = struct{}{}
+		out = append(out, model)
+	}
+	for _, model := range discovered {
+		key := strings.ToLower(model.ID)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		clone := *model
+		out = append(out, &clone)
+	}
+	return out
+}
+
+// fetchCompatModels lists the models served by an OpenAI-compatible upstream.
+func (s *Service) fetchCompatModels(ctx context.Context, a *coreauth.Auth, providerKey, baseURL, ownedBy string) ([]*ModelInfo, error) {
+	baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("missing base-url")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, compatModelDiscoveryTimeout)
+	defer cancel()
+
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/models", nil)
+	if errReq != nil {
+		return nil, errReq
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, errDo := executor.NewOpenAICompatExecutor(providerKey, s.cfg).HttpRequest(ctx, a, req)
+	if errDo != nil {
+		return nil, errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("openai-compatibility: close models response body error: %v", errClose)
+		}
+	}()
+	body, errRead := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if errRead != nil {
+		return nil, errRead
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("GET %s/models returned status %d", baseURL, resp.StatusCode)
+	}
+	return parseCompatModelsListing(body, ownedBy)
+}
+
+// parseCompatModelsListing decodes an OpenAI-style {"data":[{"id":...}]} model listing.
+func parseCompatModelsListing(body []byte, ownedBy string) ([]*ModelInfo, error) {
+	var listing struct {
+		Data []struct {
+			ID            string `json:"id"`
+			Created       int64  `json:"created"`
+			Name          string `json:"name"`
+			ContextLength int    `json:"context_length"`
+		} `json:"data"`
+	}
+	if errUnmarshal := json.Unmarshal(body, &listing); errUnmarshal != nil {
+		return nil, fmt.Errorf("decode models listing: %w", errUnmarshal)
+	}
+	now := time.Now().Unix()
+	models := make([]*ModelInfo, 0, len(listing.Data))
+	for _, item := range listing.Data {
+		id := strings.TrimSpace(item.ID)
+		if id == "" {
+			continue
+		}
+		created := item.Created
+		if created <= 0 {
+			created = now
+		}
+		displayName := strings.TrimSpace(item.Name)
+		if displayName == "" {
+			displayName = id
+		}
+		models = append(models, &ModelInfo{
+			ID:            id,
+			Object:        "model",
+			Created:       created,
+			OwnedBy:       ownedBy,
+			Type:          "openai-compatibility",
+			DisplayName:   displayName,
+			ContextLength: item.ContextLength,
+			Thinking:      &registry.ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+		})
+	}
+	return models, nil
+}
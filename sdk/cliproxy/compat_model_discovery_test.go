@@ -0,0 +1,137 @@
+package cliproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	internalregistry "github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestRegisterModelsForAuth_OpenAICompatibilityDiscoversModels(t *testing.T) {
+	var listRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		listRequests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"qwen/qwen3-32b","context_length":32768},{"id":"static-upstream"},{"id":""}]}`))
+	}))
+	defer server.Close()
+
+	service := &Service{
+		cfg: &config.Config{
+			OpenAICompatibility: []config.OpenAICompatibility{
+				{
+					Name:           "vllm",
+					BaseURL:        server.URL + "/v1",
+					DiscoverModels: true,
+					Models: []config.OpenAICompatibilityModel{
+						{Name: "static-upstream", Alias: "static-upstream"},
+					},
+				},
+			},
+		},
+	}
+	auth := &coreauth.Auth{
+		ID:       "auth-openai-compat-discovery",
+		Provider: "vllm",
+		Status:   coreauth.StatusActive,
+		Attributes: map[string]string{
+			"auth_kind":    "api_key",
+			"api_key":      "sk-test",
+			"base_url":     server.URL + "/v1",
+			"compat_name":  "vllm",
+			"provider_key": "vllm",
+		},
+	}
+
+	modelRegistry := internalregistry.GetGlobalRegistry()
+	modelRegistry.UnregisterClient(auth.ID)
+	t.Cleanup(func() {
+		modelRegistry.UnregisterClient(auth.ID)
+	})
+
+	for i := 0; i < 2; i++ {
+		service.registerModelsForAuth(context.Background(), auth)
+	}
+
+	models := modelRegistry.GetModelsForClient(auth.ID)
+	byID := make(map[string]*internalregistry.ModelInfo, len(models))
+	for _, model := range models {
+		if model != nil {
+			byID[model.ID] = model
+		}
+	}
+	if len(byID) != 2 {
+		t.Fatalf("registered models = %v, want static-upstream and qwen/qwen3-32b", byID)
+	}
+	discovered := byID["qwen/qwen3-32b"]
+	if discovered == nil {
+		t.Fatal("expected discovered model to be registered")
+	}
+	if discovered.ContextLength != 32768 || discovered.OwnedBy != "vllm" {
+		t.Fatalf("discovered model = %+v", discovered)
+	}
+	if byID["static-upstream"] == nil {
+		t.Fatal("expected static model to stay registered")
+	}
+	if got := listRequests.Load(); got != 1 {
+		t.Fatalf("models listing requests = %d, want 1 (cached)", got)
+	}
+}
+
+func TestRegisterModelsForAuth_OpenAICompatibilityDiscoveryFailureKeepsStaticModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	service := &Service{
+		cfg: &config.Config{
+			OpenAICompatibility: []config.OpenAICompatibility{
+				{
+					Name:           "lmstudio",
+					BaseURL:        server.URL,
+					DiscoverModels: true,
+					Models: []config.OpenAICompatibilityModel{
+						{Name: "local-model", Alias: "local"},
+					},
+				},
+			},
+		},
+	}
+	auth := &coreauth.Auth{
+		ID:       "auth-openai-compat-discovery-failure",
+		Provider: "lmstudio",
+		Status:   coreauth.StatusActive,
+		Attributes: map[string]string{
+			"auth_kind":    "api_key",
+			"base_url":     server.URL,
+			"compat_name":  "lmstudio",
+			"provider_key": "lmstudio",
+		},
+	}
+
+	modelRegistry := internalregistry.GetGlobalRegistry()
+	modelRegistry.UnregisterClient(auth.ID)
+	t.Cleanup(func() {
+		modelRegistry.UnregisterClient(auth.ID)
+	})
+
+	service.registerModelsForAuth(context.Background(), auth)
+
+	models := modelRegistry.GetModelsForClient(auth.ID)
+	if len(models) != 1 || models[0].ID != "local" {
+		t.Fatalf("registered models = %+v, want only local", models)
+	}
+}
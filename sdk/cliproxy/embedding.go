@@ -0,0 +1,91 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// CoreManager returns the runtime auth manager responsible for request execution.
+func (s *Service) CoreManager() *coreauth.Manager {
+	if s == nil {
+		return nil
+	}
+	return s.coreManager
+}
+
+// RegisterExecutor registers a provider executor supplied by the embedding program. Unlike
+// executors registered on the core manager directly, it is kept when credentials for its
+// provider are added later.
+func (s *Service) RegisterExecutor(executor coreauth.ProviderExecutor) {
+	if s == nil || s.coreManager == nil || executor == nil {
+		return
+	}
+	providerKey := strings.ToLower(strings.TrimSpace(executor.Identifier()))
+	if providerKey == "" {
+		return
+	}
+	s.customExecutorsMu.Lock()
+	if s.customExecutors == nil {
+		s.customExecutors = make(map[string]struct{})
+	}
+	s.customExecutors[providerKey] = struct{}{}
+	s.customExecutorsMu.Unlock()
+	s.coreManager.RegisterExecutor(executor)
+}
+
+func (s *Service) hasCustomExecutor(providerKey string) bool {
+	s.customExecutorsMu.RLock()
+	defer s.customExecutorsMu.RUnlock()
+	_, ok := s.customExecutors[providerKey]
+	return ok
+}
+
+// Handlers returns the provider handler of the running HTTP server so embedders can execute
+// requests through the same pipeline without a network round trip. It returns nil until Run
+// has created the server; OnAfterStart hooks may rely on it.
+func (s *Service) Handlers() *handlers.BaseAPIHandler {
+	if s == nil || s.server == nil {
+		return nil
+	}
+	return s.server.Handlers()
+}
+
+// UpsertAuth registers auth, or replaces the record with the same ID, together with its executor
+// and models, exactly as if it had been discovered in the auth directory. The record is persisted
+// to the token store unless ctx carries coreauth.WithSkipPersist.
+func (s *Service) UpsertAuth(ctx context.Context, auth *coreauth.Auth) error {
+	if s == nil || s.coreManager == nil {
+		return fmt.Errorf("cliproxy: service is not initialized")
+	}
+	if auth == nil || strings.TrimSpace(auth.ID) == "" {
+		return fmt.Errorf("cliproxy: auth id is required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	action := watcher.AuthUpdateActionAdd
+	if _, ok := s.coreManager.GetByID(auth.ID); ok {
+		action = watcher.AuthUpdateActionModify
+	}
+	s.handleAuthUpdate(ctx, watcher.AuthUpdate{Action: action, ID: auth.ID, Auth: auth})
+	if _, ok := s.coreManager.GetByID(auth.ID); !ok {
+		return fmt.Errorf("cliproxy: failed to register auth %s", auth.ID)
+	}
+	return nil
+}
+
+// RemoveAuth unregisters the auth with the given ID and its models.
+func (s *Service) RemoveAuth(ctx context.Context, id string) {
+	if s == nil || strings.TrimSpace(id) == "" {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.handleAuthUpdate(ctx, watcher.AuthUpdate{Action: watcher.AuthUpdateActionDelete, ID: id})
+}
@@ -18,6 +18,10 @@ const RequestPathMetadataKey = "request_path"
 // DisallowFreeAuthMetadataKey instructs auth selection to skip known free-tier credentials.
 const DisallowFreeAuthMetadataKey = "disallow_free_auth"
 
+// PreflightCountMetadataKey marks a token count made before the request itself is executed.
+// Such a count is tried once on one credential and its failures do not cool credentials down.
+const PreflightCountMetadataKey = "preflight_count"
+
 // AuthSelectionModelMetadataKey overrides the model used only for auth selection.
 const AuthSelectionModelMetadataKey = "auth_selection_model"
 
@@ -31,9 +35,14 @@ const ServiceTierMetadataKey = "service_tier"
 // Missing or true means generation is enabled; only an explicit false disables generation.
 const GenerateMetadataKey = "generate"
 
+// RequestTagsMetadataKey stores client-supplied request tags ([]string) for usage records.
+const RequestTagsMetadataKey = "request_tags"
+
 const (
 	// PinnedAuthMetadataKey locks execution to a specific auth ID.
 	PinnedAuthMetadataKey = "pinned_auth_id"
+	// ExcludedAuthsMetadataKey lists auth IDs ([]string) that must not be selected.
+	ExcludedAuthsMetadataKey = "excluded_auth_ids"
 	// SelectedAuthMetadataKey stores the auth ID selected by the scheduler.
 	SelectedAuthMetadataKey = "selected_auth_id"
 	// SelectedAuthCallbackMetadataKey carries an optional callback invoked with the selected auth ID.
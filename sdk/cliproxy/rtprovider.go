@@ -3,7 +3,6 @@ package cliproxy
 import (
 	"net/http"
 	"strings"
-	"sync"
 
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
@@ -11,14 +10,12 @@ import (
 )
 
 // defaultRoundTripperProvider returns a per-auth HTTP RoundTripper based on
-// the Auth.ProxyURL value. It caches transports per proxy URL string.
-type defaultRoundTripperProvider struct {
-	mu    sync.RWMutex
-	cache map[string]http.RoundTripper
-}
+// the Auth.ProxyURL value. Transports are shared per proxy URL string by proxyutil,
+// which rebuilds them when the upstream transport tuning changes.
+type defaultRoundTripperProvider struct{}
 
 func newDefaultRoundTripperProvider() *defaultRoundTripperProvider {
-	return &defaultRoundTripperProvider{cache: make(map[string]http.RoundTripper)}
+	return &defaultRoundTripperProvider{}
 }
 
 // RoundTripperFor implements coreauth.RoundTripperProvider.
@@ -30,22 +27,10 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *coreauth.Auth) http.
 	if proxyStr == "" {
 		return nil
 	}
-	p.mu.RLock()
-	rt := p.cache[proxyStr]
-	p.mu.RUnlock()
-	if rt != nil {
-		return rt
-	}
-	transport, _, errBuild := proxyutil.BuildHTTPTransport(proxyStr)
+	transport, errBuild := proxyutil.SharedTransport(proxyStr)
 	if errBuild != nil {
 		log.Errorf("%v", errBuild)
 		return nil
 	}
-	if transport == nil {
-		return nil
-	}
-	p.mu.Lock()
-	p.cache[proxyStr] = transport
-	p.mu.Unlock()
 	return transport
 }
@@ -14,10 +14,12 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/homeplugins"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/lifecycle"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginhost"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
@@ -108,6 +110,15 @@ type Service struct {
 	// pluginHost owns dynamic plugin lifecycle and runtime capability adapters.
 	pluginHost *pluginhost.Host
 
+	// customExecutors records providers whose executor was supplied through RegisterExecutor;
+	// auth updates never replace them with the OpenAI-compatible fallback.
+	customExecutorsMu sync.RWMutex
+	customExecutors   map[string]struct{}
+
+	// compatDiscoveredModels caches upstream /models listings of openai-compatibility
+	// providers with discover-models enabled, keyed by auth ID.
+	compatDiscoveredModels sync.Map
+
 	// shutdownOnce ensures shutdown is called only once.
 	shutdownOnce sync.Once
 
@@ -1154,6 +1165,11 @@ func baselineExecutorAuths() []*coreauth.Auth {
 		"antigravity",
 		"kimi",
 		"xai",
+		"bedrock",
+		"azure-openai",
+		"mistral",
+		"deepseek",
+		"cohere",
 		"openai-compatibility",
 	}
 	auths := make([]*coreauth.Auth, 0, len(providers))
@@ -1243,11 +1259,24 @@ func (s *Service) registerExecutorForAuth(a *coreauth.Auth, forceReplace bool) {
 		s.coreManager.RegisterExecutor(executor.NewKimiExecutor(s.cfg))
 	case "xai":
 		s.coreManager.RegisterExecutor(executor.NewXAIAutoExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
+	case "azure-openai":
+		s.coreManager.RegisterExecutor(executor.NewAzureOpenAIExecutor(s.cfg))
+	case "mistral":
+		s.coreManager.RegisterExecutor(executor.NewMistralExecutor(s.cfg))
+	case "deepseek":
+		s.coreManager.RegisterExecutor(executor.NewDeepSeekExecutor(s.cfg))
+	case "cohere":
+		s.coreManager.RegisterExecutor(executor.NewCohereExecutor(s.cfg))
 	default:
 		providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 		if providerKey == "" {
 			providerKey = "openai-compatibility"
 		}
+		if s.hasCustomExecutor(providerKey) {
+			return
+		}
 		if s.pluginHost != nil &&
 			s.pluginHost.HasExecutorCandidateProvider(providerKey) &&
 			!s.hasNativeOpenAICompatExecutorConfig(a, providerKey) {
@@ -1402,7 +1431,7 @@ func (s *Service) tryRegisterPluginModelsForAuth(ctx context.Context, a *coreaut
 	models := applyExcludedModels(result.Models, activeExcluded)
 	models = applyOAuthModelAliasForAuth(s.cfg, providerKey, activeAuthKind, activeAuth.Attributes, models)
 	if len(models) > 0 {
-		s.registerResolvedModelsForAuth(activeAuth, providerKey, applyModelPrefixes(models, activeAuth.Prefix, s.cfg != nil && s.cfg.ForceModelPrefix))
+		s.registerResolvedModelsForAuth(activeAuth, providerKey, s.applyModelPrefixes(models, activeAuth))
 		return true
 	}
 	GlobalModelRegistry().UnregisterClient(activeAuth.ID)
@@ -1423,9 +1452,10 @@ type configCommit struct {
 }
 
 type routingRuntimeState struct {
-	strategy           string
-	sessionAffinity    bool
-	sessionAffinityTTL time.Duration
+	strategy              string
+	sessionAffinity       bool
+	sessionAffinityTTL    time.Duration
+	sessionAffinityHeader string
 }
 
 func normalizedRoutingRuntimeState(cfg *config.Config) routingRuntimeState {
@@ -1442,6 +1472,7 @@ func normalizedRoutingRuntimeState(cfg *config.Config) routingRuntimeState {
 		state.strategy = "fill-first"
 	}
 	state.sessionAffinity = cfg.Routing.SessionAffinity
+	state.sessionAffinityHeader = strings.TrimSpace(cfg.Routing.SessionAffinityHeader)
 	if ttl := strings.TrimSpace(cfg.Routing.SessionAffinityTTL); ttl != "" {
 		if parsed, errParse := time.ParseDuration(ttl); errParse == nil && parsed > 0 {
 			state.sessionAffinityTTL = parsed
@@ -1461,6 +1492,7 @@ func newRoutingSelector(state routingRuntimeState) coreauth.Selector {
 		selector = coreauth.NewSessionAffinitySelectorWithConfig(coreauth.SessionAffinityConfig{
 			Fallback: selector,
 			TTL:      state.sessionAffinityTTL,
+			Header:   state.sessionAffinityHeader,
 		})
 	}
 	return selector
@@ -1471,7 +1503,12 @@ func (s *Service) applyConfigUpdateWithAuthSynthesis(ctx context.Context, newCfg
 	if commit.cfg == nil {
 		return false
 	}
-	return s.applyConfigRuntime(ctx, commit, synthesizeConfigAuths)
+	lifecycle.Default().Configure(commit.cfg.LifecycleHooks)
+	if !s.applyConfigRuntime(ctx, commit, synthesizeConfigAuths) {
+		return false
+	}
+	lifecycle.Default().Emit(lifecycle.EventConfigReloaded, map[string]any{"config_path": s.configPath})
+	return true
 }
 
 // commitConfigUpdate applies only in-memory configuration state. Runtime work that
@@ -2345,15 +2382,19 @@ func (s *Service) Run(ctx context.Context) error {
 	}()
 
 	usage.StartDefault(ctx)
+	if s.cfg != nil {
+		lifecycle.Default().Configure(s.cfg.LifecycleHooks)
+	}
 	homeEnabled := s.cfg != nil && s.cfg.Home.Enabled
 	if homeEnabled {
 		forceHomeRuntimeConfig(s.cfg)
 		redisqueue.SetUsageStatisticsEnabled(true)
 	}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
 	defer func() {
+		// The deadline starts when shutdown begins; it covers the drain plus cleanup.
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), s.drainTimeout()+10*time.Second)
+		defer shutdownCancel()
 		if err := s.Shutdown(shutdownCtx); err != nil {
 			log.Errorf("service shutdown returned error: %v", err)
 		}
@@ -2466,6 +2507,11 @@ func (s *Service) Run(ctx context.Context) error {
 	if s.hooks.OnAfterStart != nil {
 		s.hooks.OnAfterStart(s)
 	}
+	lifecycle.Default().Emit(lifecycle.EventServerStarted, map[string]any{
+		"host":    s.cfg.Host,
+		"port":    s.cfg.Port,
+		"version": buildinfo.Version,
+	})
 
 	if !homeEnabled {
 		var watcherWrapper *WatcherWrapper
@@ -2612,7 +2658,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		// no legacy clients to persist
 
 		if s.server != nil {
-			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			shutdownCtx, cancel := context.WithTimeout(ctx, s.drainTimeout())
 			defer cancel()
 			if err := s.server.Stop(shutdownCtx); err != nil {
 				log.Errorf("error stopping API server: %v", err)
@@ -2645,6 +2691,14 @@ func (s *Service) Shutdown(ctx context.Context) error {
 	return shutdownErr
 }
 
+// drainTimeout returns how long shutdown waits for in-flight requests.
+func (s *Service) drainTimeout() time.Duration {
+	if s == nil || s.cfg == nil {
+		return config.DefaultShutdownDrainTimeout
+	}
+	return s.cfg.Shutdown.DrainDuration()
+}
+
 func (s *Service) ensureAuthDir() error {
 	info, err := os.Stat(s.cfg.AuthDir)
 	if err != nil {
@@ -2744,6 +2798,13 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 			if authKind == "apikey" {
 				excluded = entry.ExcludedModels
 			}
+		} else if entry := s.resolveConfigVertexServiceAccount(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildConfigModels(entry.Models, "google", "vertex")
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
 		}
 		models = applyExcludedModels(models, excluded)
 	case "aistudio":
@@ -2804,6 +2865,61 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 			}
 		}
 		models = applyExcludedModels(models, excluded)
+	case "bedrock":
+		models = registry.GetBedrockModels()
+		if entry := s.resolveConfigBedrockKey(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildBedrockConfigModels(entry)
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
+	case "azure-openai":
+		models = registry.GetAzureOpenAIModels()
+		if entry := s.resolveConfigAzureOpenAIKey(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildAzureOpenAIConfigModels(entry)
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
+	case "mistral":
+		models = registry.GetMistralModels()
+		if entry := s.resolveConfigMistralKey(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildMistralConfigModels(entry)
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
+	case "deepseek":
+		models = registry.GetDeepSeekModels()
+		if entry := s.resolveConfigDeepSeekKey(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildDeepSeekConfigModels(entry)
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
+	case "cohere":
+		models = registry.GetCohereModels()
+		if entry := s.resolveConfigCohereKey(a); entry != nil {
+			if len(entry.Models) > 0 {
+				models = buildCohereConfigModels(entry)
+			}
+			if authKind == "apikey" {
+				excluded = entry.ExcludedModels
+			}
+		}
+		models = applyExcludedModels(models, excluded)
 	default:
 		// Handle OpenAI-compatibility providers by name using config
 		if s.cfg != nil {
@@ -2851,14 +2967,14 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 				if providerKey == "" {
 					providerKey = "openai-compatibility"
 				}
-				ms := cached.models
+				ms := s.withDiscoveredCompatModels(ctx, a, compatName, providerKey, cached.models)
 				if len(ms) > 0 {
 					ms = s.appendPluginModels(providerKey, ms)
-					s.registerResolvedModelsForAuth(a, providerKey, applyModelPrefixes(ms, a.Prefix, s.cfg.ForceModelPrefix))
+					s.registerResolvedModelsForAuth(a, providerKey, s.applyModelPrefixes(ms, a))
 				} else {
 					ms = s.appendPluginModels(providerKey, nil)
 					if len(ms) > 0 {
-						s.registerResolvedModelsForAuth(a, providerKey, applyModelPrefixes(ms, a.Prefix, s.cfg.ForceModelPrefix))
+						s.registerResolvedModelsForAuth(a, providerKey, s.applyModelPrefixes(ms, a))
 					} else {
 						GlobalModelRegistry().UnregisterClient(a.ID)
 					}
@@ -2872,19 +2988,19 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 				}
 				if strings.EqualFold(compat.Name, compatName) {
 					isCompatAuth = true
-					ms := buildOpenAICompatibilityConfigModels(compat)
+					ms := s.withDiscoveredCompatModels(ctx, a, compatName, providerKey, buildOpenAICompatibilityConfigModels(compat))
 					// Register and return
 					if len(ms) > 0 {
 						if providerKey == "" {
 							providerKey = "openai-compatibility"
 						}
 						ms = s.appendPluginModels(providerKey, ms)
-						s.registerResolvedModelsForAuth(a, providerKey, applyModelPrefixes(ms, a.Prefix, s.cfg.ForceModelPrefix))
+						s.registerResolvedModelsForAuth(a, providerKey, s.applyModelPrefixes(ms, a))
 					} else {
 						// Ensure stale registrations are cleared when model list becomes empty.
 						ms = s.appendPluginModels(providerKey, nil)
 						if len(ms) > 0 {
-							s.registerResolvedModelsForAuth(a, providerKey, applyModelPrefixes(ms, a.Prefix, s.cfg.ForceModelPrefix))
+							s.registerResolvedModelsForAuth(a, providerKey, s.applyModelPrefixes(ms, a))
 						} else {
 							GlobalModelRegistry().UnregisterClient(a.ID)
 						}
@@ -2895,7 +3011,7 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 			if isCompatAuth {
 				models = s.appendPluginModels(providerKey, nil)
 				if len(models) > 0 {
-					s.registerResolvedModelsForAuth(a, providerKey, applyModelPrefixes(models, a.Prefix, s.cfg != nil && s.cfg.ForceModelPrefix))
+					s.registerResolvedModelsForAuth(a, providerKey, s.applyModelPrefixes(models, a))
 				} else {
 					// No matching provider found or models removed entirely; drop any prior registration.
 					GlobalModelRegistry().UnregisterClient(a.ID)
@@ -2917,7 +3033,7 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 	}
 	models = s.appendPluginModels(key, models)
 	if len(models) > 0 {
-		s.registerResolvedModelsForAuth(a, key, applyModelPrefixes(models, a.Prefix, s.cfg != nil && s.cfg.ForceModelPrefix))
+		s.registerResolvedModelsForAuth(a, key, s.applyModelPrefixes(models, a))
 		return
 	}
 
@@ -3119,6 +3235,98 @@ func (s *Service) resolveConfigXAIKey(auth *coreauth.Auth) *config.XAIKey {
 	return resolveConfigCodexStyleKey(auth, s.cfg.XAIKey)
 }
 
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if s == nil || s.cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	key := strings.TrimSpace(auth.Attributes["api_key"])
+	region := strings.TrimSpace(auth.Attributes["aws_region"])
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if strings.EqualFold(strings.TrimSpace(entry.GetAPIKey()), key) && strings.EqualFold(entry.AWSRegion, region) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigAzureOpenAIKey(auth *coreauth.Auth) *config.AzureOpenAIKey {
+	if s == nil || s.cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	key := strings.TrimSpace(auth.Attributes["api_key"])
+	endpoint := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.AzureOpenAIKey {
+		entry := &s.cfg.AzureOpenAIKey[i]
+		if strings.EqualFold(strings.TrimSpace(entry.GetAPIKey()), key) && strings.EqualFold(entry.Endpoint, endpoint) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigVertexServiceAccount(auth *coreauth.Auth) *config.VertexServiceAccount {
+	if s == nil || s.cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	credentialsFile, ok := auth.Attributes["credentials_file"]
+	if !ok {
+		return nil
+	}
+	for i := range s.cfg.VertexServiceAccount {
+		entry := &s.cfg.VertexServiceAccount[i]
+		if entry.CredentialsFile == credentialsFile && entry.ProjectID == auth.Attributes["project_id"] && entry.Location == auth.Attributes["location"] {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigMistralKey(auth *coreauth.Auth) *config.MistralKey {
+	if s == nil || s.cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	key := strings.TrimSpace(auth.Attributes["api_key"])
+	base := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.MistralKey {
+		entry := &s.cfg.MistralKey[i]
+		if strings.EqualFold(strings.TrimSpace(entry.APIKey), key) && strings.EqualFold(entry.BaseURL, base) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigDeepSeekKey(auth *coreauth.Auth) *config.DeepSeekKey {
+	if s == nil || s.cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	key := strings.TrimSpace(auth.Attributes["api_key"])
+	base := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.DeepSeekKey {
+		entry := &s.cfg.DeepSeekKey[i]
+		if strings.EqualFold(strings.TrimSpace(entry.APIKey), key) && strings.EqualFold(entry.BaseURL, base) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigCohereKey(auth *coreauth.Auth) *config.CohereKey {
+	if s == nil || s.cfg == nil || auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	key := strings.TrimSpace(auth.Attributes["api_key"])
+	base := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.CohereKey {
+		entry := &s.cfg.CohereKey[i]
+		if strings.EqualFold(strings.TrimSpace(entry.APIKey), key) && strings.EqualFold(entry.BaseURL, base) {
+			return entry
+		}
+	}
+	return nil
+}
+
 func resolveConfigCodexStyleKey(auth *coreauth.Auth, entries []config.CodexKey) *config.CodexKey {
 	if auth == nil {
 		return nil
@@ -3181,7 +3389,7 @@ func applyExcludedModels(models []*ModelInfo, excluded []string) []*ModelInfo {
 		modelID := strings.ToLower(strings.TrimSpace(model.ID))
 		blocked := false
 		for _, pattern := range patterns {
-			if matchWildcard(pattern, modelID) {
+			if sdkaccess.MatchWildcard(pattern, modelID) {
 				blocked = true
 				break
 			}
@@ -3193,6 +3401,15 @@ func applyExcludedModels(models []*ModelInfo, excluded []string) []*ModelInfo {
 	return filtered
 }
 
+// applyModelPrefixes registers the models of auth under its prefix. Credentials dedicated to a
+// tenant are registered under the prefix only, like every credential with force-model-prefix.
+func (s *Service) applyModelPrefixes(models []*ModelInfo, auth *coreauth.Auth) []*ModelInfo {
+	if s.cfg == nil {
+		return applyModelPrefixes(models, auth.Prefix, false)
+	}
+	return applyModelPrefixes(models, auth.Prefix, s.cfg.ForceModelPrefix || s.cfg.IsTenantCredentialPrefix(auth.Prefix))
+}
+
 func applyModelPrefixes(models []*ModelInfo, prefix string, forceModelPrefix bool) []*ModelInfo {
 	trimmedPrefix := strings.TrimSpace(prefix)
 	if trimmedPrefix == "" || len(models) == 0 {
@@ -3235,50 +3452,6 @@ func applyModelPrefixes(models []*ModelInfo, prefix string, forceModelPrefix boo
 	return out
 }
 
-// matchWildcard performs case-insensitive wildcard matching where '*' matches any substring.
-func matchWildcard(pattern, value string) bool {
-	if pattern == "" {
-		return false
-	}
-
-	// Fast path for exact match (no wildcard present).
-	if !strings.Contains(pattern, "*") {
-		return pattern == value
-	}
-
-	parts := strings.Split(pattern, "*")
-	// Handle prefix.
-	if prefix := parts[0]; prefix != "" {
-		if !strings.HasPrefix(value, prefix) {
-			return false
-		}
-		value = value[len(prefix):]
-	}
-
-	// Handle suffix.
-	if suffix := parts[len(parts)-1]; suffix != "" {
-		if !strings.HasSuffix(value, suffix) {
-			return false
-		}
-		value = value[:len(value)-len(suffix)]
-	}
-
-	// Handle middle segments in order.
-	for i := 1; i < len(parts)-1; i++ {
-		segment := parts[i]
-		if segment == "" {
-			continue
-		}
-		idx := strings.Index(value, segment)
-		if idx < 0 {
-			return false
-		}
-		value = value[idx+len(segment):]
-	}
-
-	return true
-}
-
 type modelEntry interface {
 	GetName() string
 	GetAlias() string
@@ -3329,10 +3502,13 @@ func buildOpenAICompatibilityConfigModels(compat *config.OpenAICompatibility) []
 			continue
 		}
 		thinking := model.Thinking
-		if thinking == nil && !model.Image {
+		if thinking == nil && !model.Image && !model.Audio {
 			thinking = &registry.ThinkingSupport{Levels: []string{"low", "medium", "high"}}
 		}
 		info.Thinking = thinking
+		if model.Audio {
+			info.SupportedEndpoints = []string{registry.EndpointAudioTranscriptions, registry.EndpointAudioSpeech}
+		}
 		info.SupportedInputModalities = normalizeCompatConfigModalities(model.InputModalities)
 		info.SupportedOutputModalities = normalizeCompatConfigModalities(model.OutputModalities)
 		models = append(models, info)
@@ -3421,6 +3597,60 @@ func buildXAIConfigModels(entry *config.XAIKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "xai", "xai")
 }
 
+func buildBedrockConfigModels(entry *config.BedrockKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "aws", "bedrock")
+}
+
+func buildAzureOpenAIConfigModels(entry *config.AzureOpenAIKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "azure", "azure-openai")
+}
+
+// buildMistralConfigModels registers configured Mistral models. Aliases of Codestral upstream
+// models also advertise /v1/fim/completions.
+func buildMistralConfigModels(entry *config.MistralKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	fimAliases := make(map[string]struct{})
+	for _, model := range entry.Models {
+		if !registry.MistralModelSupportsFIM(model.Name) {
+			continue
+		}
+		alias := strings.TrimSpace(model.Alias)
+		if alias == "" {
+			alias = strings.TrimSpace(model.Name)
+		}
+		fimAliases[strings.ToLower(alias)] = struct{}{}
+	}
+	models := buildConfigModels(entry.Models, "mistralai", "mistral")
+	for _, info := range models {
+		if _, ok := fimAliases[strings.ToLower(info.ID)]; ok {
+			info.SupportedEndpoints = []string{registry.EndpointChatCompletions, registry.EndpointFIMCompletions}
+		}
+	}
+	return models
+}
+
+func buildDeepSeekConfigModels(entry *config.DeepSeekKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "deepseek", "deepseek")
+}
+
+func buildCohereConfigModels(entry *config.CohereKey) []*ModelInfo {
+	if entry == nil {
+		return nil
+	}
+	return buildConfigModels(entry.Models, "cohere", "cohere")
+}
+
 func buildCodexConfigModels(entry *config.CodexKey) []*ModelInfo {
 	if entry == nil {
 		return nil
@@ -0,0 +1,30 @@
+package cliproxy
+
+import (
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestApplyModelPrefixes_TenantCredentialsRegisterPrefixedOnly(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Tenants = []internalconfig.Tenant{{Name: "acme", APIKeys: []string{"k"}, CredentialPrefix: "acme"}}
+	service := &Service{cfg: cfg}
+	models := []*ModelInfo{{ID: "gpt-5"}}
+
+	ids := func(models []*ModelInfo) []string {
+		out := make([]string, 0, len(models))
+		for _, model := range models {
+			out = append(out, model.ID)
+		}
+		return out
+	}
+	if got := ids(service.applyModelPrefixes(models, &coreauth.Auth{Prefix: "acme"})); len(got) != 1 || got[0] != "acme/gpt-5" {
+		t.Fatalf("tenant credential models = %v, want only acme/gpt-5", got)
+	}
+	if got := ids(service.applyModelPrefixes(models, &coreauth.Auth{Prefix: "team"})); len(got) != 2 || got[0] != "gpt-5" || got[1] != "team/gpt-5" {
+		t.Fatalf("shared credential models = %v, want gpt-5 and team/gpt-5", got)
+	}
+}
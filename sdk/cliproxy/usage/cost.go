@@ -0,0 +1,34 @@
+package usage
+
+import "sync"
+
+// CostTrackerGinKey is the gin context key holding the request's *CostTracker.
+const CostTrackerGinKey = "usageCostTracker"
+
+// CostTracker accumulates the estimated cost of every upstream call made for one client request.
+type CostTracker struct {
+	mu     sync.Mutex
+	total  float64
+	priced bool
+}
+
+// Add records the estimated cost of one upstream call. Non-positive costs are ignored.
+func (t *CostTracker) Add(cost float64) {
+	if t == nil || cost <= 0 {
+		return
+	}
+	t.mu.Lock()
+	t.total += cost
+	t.priced = true
+	t.mu.Unlock()
+}
+
+// Total returns the accumulated cost and whether any priced usage was recorded.
+func (t *CostTracker) Total() (float64, bool) {
+	if t == nil {
+		return 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total, t.priced
+}
@@ -30,6 +30,9 @@ type Record struct {
 	AuthIndex    string
 	AuthType     string
 	Source       string
+	// Scope is the provider-side organization/project (OpenAI) or workspace (Anthropic)
+	// configured for the credential, e.g. "org-abc/proj_123". Empty when unscoped.
+	Scope string
 	// ReasoningEffort stores the translated upstream thinking level for request event logs.
 	ReasoningEffort string
 	// ServiceTier stores the client-requested service tier.
@@ -49,6 +52,10 @@ type Record struct {
 	Failed      bool
 	Fail        Failure
 	Detail      Detail
+	// EstimatedCost is the USD cost estimated from model pricing; 0 when the model is unpriced.
+	EstimatedCost float64
+	// Tags are the client-supplied request tags from the "cliproxy" request body extension.
+	Tags []string
 	// ResponseHeaders stores a snapshot of upstream response headers for usage sinks.
 	ResponseHeaders http.Header
 }
@@ -76,6 +83,7 @@ type requestedModelAliasContextKey struct{}
 type reasoningEffortContextKey struct{}
 type serviceTierContextKey struct{}
 type generateContextKey struct{}
+type requestTagsContextKey struct{}
 
 // WithRequestedModelAlias stores the client-requested model name for usage sinks.
 func WithRequestedModelAlias(ctx context.Context, alias string) context.Context {
@@ -193,6 +201,26 @@ func GenerateFromContext(ctx context.Context) bool {
 	}
 }
 
+// WithRequestTags stores client-supplied request tags for usage sinks.
+func WithRequestTags(ctx context.Context, tags []string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(tags) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestTagsContextKey{}, append([]string(nil), tags...))
+}
+
+// RequestTagsFromContext returns the client-supplied request tags stored in ctx.
+func RequestTagsFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(requestTagsContextKey{}).([]string)
+	return tags
+}
+
 // GenerateFlag returns a pointer suitable for Record.Generate.
 func GenerateFlag(generate bool) *bool {
 	return &generate
@@ -11,21 +11,59 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type StreamProviderTimeout = internalconfig.StreamProviderTimeout
+type HeaderFilterConfig = internalconfig.HeaderFilterConfig
+type RaceConfig = internalconfig.RaceConfig
+type ShadowTrafficConfig = internalconfig.ShadowTrafficConfig
+type ShadowTrafficRule = internalconfig.ShadowTrafficRule
+type Experiment = internalconfig.Experiment
+type ExperimentArm = internalconfig.ExperimentArm
+type Tenant = internalconfig.Tenant
+type ResponseHeadersConfig = internalconfig.ResponseHeadersConfig
+type PreflightTokenCheckConfig = internalconfig.PreflightTokenCheckConfig
 type TLSConfig = internalconfig.TLSConfig
+type TLSACMEConfig = internalconfig.TLSACMEConfig
+type TLSClientAuthConfig = internalconfig.TLSClientAuthConfig
+type TLSClientIdentity = internalconfig.TLSClientIdentity
+type AccessScope = internalconfig.AccessScope
 type RemoteManagement = internalconfig.RemoteManagement
+type ShutdownConfig = internalconfig.ShutdownConfig
 type OAuthModelAlias = internalconfig.OAuthModelAlias
 type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadFilterRule = internalconfig.PayloadFilterRule
 type PayloadModelRule = internalconfig.PayloadModelRule
+type ModelFailoverRule = internalconfig.ModelFailoverRule
+type ModelFailoverTarget = internalconfig.ModelFailoverTarget
+type ModelAliasRule = internalconfig.ModelAliasRule
+type SyntheticModel = internalconfig.SyntheticModel
+type SyntheticModelTool = internalconfig.SyntheticModelTool
+type PromptRule = internalconfig.PromptRule
+type LocaleHintsConfig = internalconfig.LocaleHintsConfig
+type OIDCAuthConfig = internalconfig.OIDCAuthConfig
+type OIDCScopeMapping = internalconfig.OIDCScopeMapping
+type LocaleHintKey = internalconfig.LocaleHintKey
+type PIIRedactionConfig = internalconfig.PIIRedactionConfig
+type ModerationConfig = internalconfig.ModerationConfig
 
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
 type XAIKey = internalconfig.XAIKey
 type XAIModel = internalconfig.XAIModel
+type BedrockKey = internalconfig.BedrockKey
+type BedrockModel = internalconfig.BedrockModel
+type AzureOpenAIKey = internalconfig.AzureOpenAIKey
+type AzureOpenAIModel = internalconfig.AzureOpenAIModel
+type MistralKey = internalconfig.MistralKey
+type MistralModel = internalconfig.MistralModel
+type DeepSeekKey = internalconfig.DeepSeekKey
+type DeepSeekModel = internalconfig.DeepSeekModel
+type CohereKey = internalconfig.CohereKey
+type CohereModel = internalconfig.CohereModel
 type ClaudeKey = internalconfig.ClaudeKey
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
+type VertexServiceAccount = internalconfig.VertexServiceAccount
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
@@ -34,6 +72,20 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+	DefaultShutdownDrainTimeout  = internalconfig.DefaultShutdownDrainTimeout
+	DefaultBedrockRegion         = internalconfig.DefaultBedrockRegion
+	DefaultAzureOpenAIAPIVersion = internalconfig.DefaultAzureOpenAIAPIVersion
+	DefaultAzureAuthorityHost    = internalconfig.DefaultAzureAuthorityHost
+	DefaultMistralBaseURL        = internalconfig.DefaultMistralBaseURL
+	DefaultDeepSeekBaseURL       = internalconfig.DefaultDeepSeekBaseURL
+	DefaultCohereBaseURL         = internalconfig.DefaultCohereBaseURL
+	DefaultVertexLocation        = internalconfig.DefaultVertexLocation
+
+	ModerationStageInput     = internalconfig.ModerationStageInput
+	ModerationStageOutput    = internalconfig.ModerationStageOutput
+	ModerationActionBlock    = internalconfig.ModerationActionBlock
+	ModerationActionAnnotate = internalconfig.ModerationActionAnnotate
+	ModerationActionLog      = internalconfig.ModerationActionLog
 )
 
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
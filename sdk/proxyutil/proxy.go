@@ -71,11 +71,16 @@ func Parse(raw string) (Setting, error) {
 	}
 }
 
+// cloneDefaultTransport copies http.DefaultTransport and applies the configured tuning.
 func cloneDefaultTransport() *http.Transport {
-	if transport, ok := http.DefaultTransport.(*http.Transport); ok && transport != nil {
-		return transport.Clone()
+	var transport *http.Transport
+	if base, ok := http.DefaultTransport.(*http.Transport); ok && base != nil {
+		transport = base.Clone()
+	} else {
+		transport = &http.Transport{}
 	}
-	return &http.Transport{}
+	currentTuning().apply(transport)
+	return transport
 }
 
 // NewDirectTransport returns a transport that bypasses environment proxies.
@@ -0,0 +1,118 @@
+package proxyutil
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TransportTuning adjusts connection reuse of the transports built by this package. The zero
+// value keeps the http.DefaultTransport settings.
+type TransportTuning struct {
+	// DisableHTTP2 restricts connections to HTTP/1.1.
+	DisableHTTP2 bool
+	// MaxIdleConnsPerHost is the number of idle connections kept per host.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes idle connections after this duration.
+	IdleConnTimeout time.Duration
+	// PingInterval sends an HTTP/2 health-check ping after this long without frames.
+	PingInterval time.Duration
+	// PingTimeout closes an HTTP/2 connection whose ping is not answered in time.
+	PingTimeout time.Duration
+}
+
+var (
+	tuningMu sync.Mutex
+	tuning   TransportTuning
+	// generation changes with every tuning update so stale builds are not cached.
+	generation uint64
+	// shared caches one transport per proxy setting so clients reuse upstream connections.
+	shared map[string]*http.Transport
+)
+
+// SetTransportTuning changes the tuning of transports built afterwards. Shared transports are
+// rebuilt on next use and the idle connections of the previous ones are closed.
+func SetTransportTuning(t TransportTuning) {
+	tuningMu.Lock()
+	if tuning == t {
+		tuningMu.Unlock()
+		return
+	}
+	previous := shared
+	tuning = t
+	generation++
+	shared = nil
+	tuningMu.Unlock()
+
+	for _, transport := range previous {
+		transport.CloseIdleConnections()
+	}
+}
+
+func currentTuning() TransportTuning {
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+	return tuning
+}
+
+func (t TransportTuning) apply(transport *http.Transport) {
+	if t.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		transport.Protocols = protocols
+	}
+	if t.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+		if transport.MaxIdleConns > 0 && transport.MaxIdleConns < t.MaxIdleConnsPerHost {
+			transport.MaxIdleConns = t.MaxIdleConnsPerHost
+		}
+	}
+	if t.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = t.IdleConnTimeout
+	}
+	if !t.DisableHTTP2 && (t.PingInterval > 0 || t.PingTimeout > 0) {
+		transport.HTTP2 = &http.HTTP2Config{
+			SendPingTimeout: t.PingInterval,
+			PingTimeout:     t.PingTimeout,
+		}
+	}
+}
+
+// SharedTransport returns a cached transport for the proxy setting, so every client using the
+// same setting shares one connection pool. An empty setting yields a tuned copy of
+// http.DefaultTransport. Callers must not modify the returned transport.
+func SharedTransport(raw string) (*http.Transport, error) {
+	key := strings.TrimSpace(raw)
+	tuningMu.Lock()
+	transport := shared[key]
+	built := generation
+	tuningMu.Unlock()
+	if transport != nil {
+		return transport, nil
+	}
+
+	transport, mode, errBuild := BuildHTTPTransport(key)
+	if errBuild != nil {
+		return nil, errBuild
+	}
+	if mode == ModeInherit || transport == nil {
+		transport = cloneDefaultTransport()
+	}
+
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+	if existing := shared[key]; existing != nil {
+		return existing, nil
+	}
+	if built != generation {
+		// The tuning changed while building; hand out this transport without caching it.
+		return transport, nil
+	}
+	if shared == nil {
+		shared = make(map[string]*http.Transport)
+	}
+	shared[key] = transport
+	return transport, nil
+}
@@ -0,0 +1,39 @@
+package proxyutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSharedTransportAppliesTuning(t *testing.T) {
+	t.Cleanup(func() { SetTransportTuning(TransportTuning{}) })
+
+	SetTransportTuning(TransportTuning{MaxIdleConnsPerHost: 64, IdleConnTimeout: time.Minute, PingInterval: 30 * time.Second})
+	first, errShared := SharedTransport("")
+	if errShared != nil {
+		t.Fatalf("SharedTransport: %v", errShared)
+	}
+	if again, _ := SharedTransport(" "); again != first {
+		t.Fatal("expected the cached transport for the same setting")
+	}
+	if first.MaxIdleConnsPerHost != 64 || first.IdleConnTimeout != time.Minute || !first.ForceAttemptHTTP2 {
+		t.Fatalf("transport not tuned: perHost=%d idle=%v h2=%v", first.MaxIdleConnsPerHost, first.IdleConnTimeout, first.ForceAttemptHTTP2)
+	}
+	if first.HTTP2 == nil || first.HTTP2.SendPingTimeout != 30*time.Second {
+		t.Fatalf("HTTP2 config = %+v", first.HTTP2)
+	}
+
+	direct, _ := SharedTransport("direct")
+	if direct == first || direct.Proxy != nil {
+		t.Fatal("direct setting should get its own transport without a proxy function")
+	}
+
+	SetTransportTuning(TransportTuning{DisableHTTP2: true})
+	rebuilt, _ := SharedTransport("")
+	if rebuilt == first {
+		t.Fatal("expected a new transport after the tuning changed")
+	}
+	if rebuilt.ForceAttemptHTTP2 || rebuilt.Protocols == nil || rebuilt.Protocols.HTTP2() {
+		t.Fatal("HTTP/2 should be disabled")
+	}
+}
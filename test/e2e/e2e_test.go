@@ -0,0 +1,252 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func openAICompatConfig(name, baseURL, model string) string {
+	return fmt.Sprintf(`  - name: %q
+    base-url: "%s/v1"
+    api-key-entries:
+      - api-key: "upstream-key"
+    models:
+      - name: %q
+        alias: %q
+`, name, baseURL, model, model)
+}
+
+func TestOpenAICompatChatCompletions(t *testing.T) {
+	upstream, upstreamURL := StartUpstream(t)
+	upstream.SetReply("hello from upstream")
+	proxy := StartProxy(t, "openai-compatibility:\n"+openAICompatConfig("fake-openai", upstreamURL, "fake-gpt"))
+
+	status, body := proxy.Post(t, "/v1/chat/completions", `{"model":"fake-gpt","messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	if got := gjson.Get(body, "choices.0.message.content").String(); got != "hello from upstream" {
+		t.Fatalf("content = %q, body = %s", got, body)
+	}
+
+	status, body = proxy.Post(t, "/v1/chat/completions", `{"model":"fake-gpt","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("stream status = %d, body = %s", status, body)
+	}
+	if got := collectSSEText(body, "choices.0.delta.content"); got != "hello from upstream" {
+		t.Fatalf("streamed content = %q, body = %s", got, body)
+	}
+
+	requests := upstream.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("upstream requests = %d, want 2", len(requests))
+	}
+	if auth := requests[0].Header.Get("Authorization"); auth != "Bearer upstream-key" {
+		t.Fatalf("upstream Authorization = %q, want upstream credential", auth)
+	}
+}
+
+func TestClaudeMessagesTranslatedToOpenAICompat(t *testing.T) {
+	upstream, upstreamURL := StartUpstream(t)
+	upstream.SetReply("translated reply")
+	proxy := StartProxy(t, "openai-compatibility:\n"+openAICompatConfig("fake-openai", upstreamURL, "fake-gpt"))
+
+	status, body := proxy.Post(t, "/v1/messages", `{"model":"fake-gpt","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	if got := gjson.Get(body, "content.0.text").String(); got != "translated reply" {
+		t.Fatalf("content = %q, body = %s", got, body)
+	}
+
+	status, body = proxy.Post(t, "/v1/messages", `{"model":"fake-gpt","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("stream status = %d, body = %s", status, body)
+	}
+	if got := collectSSEText(body, "delta.text"); got != "translated reply" {
+		t.Fatalf("streamed content = %q, body = %s", got, body)
+	}
+
+	for _, req := range upstream.Requests() {
+		if !strings.HasSuffix(req.Path, "/chat/completions") {
+			t.Fatalf("upstream path = %q, want chat completions", req.Path)
+		}
+	}
+}
+
+func TestClaudeAPIKeyStreaming(t *testing.T) {
+	upstream, upstreamURL := StartUpstream(t)
+	upstream.SetReply("claude says hi")
+	proxy := StartProxy(t, fmt.Sprintf(`claude-api-key:
+  - api-key: "sk-ant-upstream"
+    base-url: %q
+    models:
+      - name: "claude-fake-upstream"
+        alias: "claude-fake"
+`, upstreamURL))
+
+	status, body := proxy.Post(t, "/v1/messages", `{"model":"claude-fake","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	if got := collectSSEText(body, "delta.text"); got != "claude says hi" {
+		t.Fatalf("streamed content = %q, body = %s", got, body)
+	}
+
+	requests := upstream.Requests()
+	if len(requests) != 1 || requests[0].Path != "/v1/messages" {
+		t.Fatalf("upstream requests = %+v, want one /v1/messages call", requests)
+	}
+	if model := gjson.GetBytes(requests[0].Body, "model").String(); model != "claude-fake-upstream" {
+		t.Fatalf("upstream model = %q, want alias resolved to upstream name", model)
+	}
+}
+
+func TestGeminiAPIKeyGenerateContent(t *testing.T) {
+	upstream, upstreamURL := StartUpstream(t)
+	upstream.SetReply("gemini says hi")
+	proxy := StartProxy(t, fmt.Sprintf(`gemini-api-key:
+  - api-key: "gemini-upstream-key"
+    base-url: %q
+    models:
+      - name: "gemini-fake-upstream"
+        alias: "gemini-fake"
+`, upstreamURL))
+
+	request := `{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`
+	status, body := proxy.Post(t, "/v1beta/models/gemini-fake:generateContent", request)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	if got := gjson.Get(body, "candidates.0.content.parts.0.text").String(); got != "gemini says hi" {
+		t.Fatalf("content = %q, body = %s", got, body)
+	}
+
+	status, body = proxy.Post(t, "/v1beta/models/gemini-fake:streamGenerateContent?alt=sse", request)
+	if status != http.StatusOK {
+		t.Fatalf("stream status = %d, body = %s", status, body)
+	}
+	if got := collectSSEText(body, "candidates.0.content.parts.0.text"); got != "gemini says hi" {
+		t.Fatalf("streamed content = %q, body = %s", got, body)
+	}
+
+	for _, req := range upstream.Requests() {
+		if !strings.HasPrefix(req.Path, "/v1beta/models/gemini-fake-upstream:") {
+			t.Fatalf("upstream path = %q, want upstream model name", req.Path)
+		}
+	}
+}
+
+func TestRetryWithNextCredentialAfterTransientError(t *testing.T) {
+	upstream, upstreamURL := StartUpstream(t)
+	upstream.FailNext(http.StatusServiceUnavailable)
+	proxy := StartProxy(t, fmt.Sprintf(`openai-compatibility:
+  - name: "fake-openai"
+    base-url: "%s/v1"
+    api-key-entries:
+      - api-key: "upstream-key-1"
+      - api-key: "upstream-key-2"
+    models:
+      - name: "fake-gpt"
+        alias: "fake-gpt"
+`, upstreamURL))
+
+	status, body := proxy.Post(t, "/v1/chat/completions", `{"model":"fake-gpt","messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	requests := upstream.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("upstream requests = %d, want failed attempt plus retry", len(requests))
+	}
+	if first, second := requests[0].Header.Get("Authorization"), requests[1].Header.Get("Authorization"); first == second {
+		t.Fatalf("retry reused credential %q, want the other key", first)
+	}
+}
+
+func TestFailoverToHealthyProvider(t *testing.T) {
+	broken, brokenURL := StartUpstream(t)
+	healthy, healthyURL := StartUpstream(t)
+	healthy.SetReply("from healthy")
+	broken.FailNext(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	proxy := StartProxy(t, "request-retry: 0\nopenai-compatibility:\n"+
+		openAICompatConfig("broken", brokenURL, "fake-gpt")+
+		openAICompatConfig("healthy", healthyURL, "fake-gpt"))
+
+	for i := 0; i < 3; i++ {
+		status, body := proxy.Post(t, "/v1/chat/completions", `{"model":"fake-gpt","messages":[{"role":"user","content":"hi"}]}`)
+		if status != http.StatusOK {
+			t.Fatalf("request %d status = %d, body = %s", i, status, body)
+		}
+		if got := gjson.Get(body, "choices.0.message.content").String(); got != "from healthy" {
+			t.Fatalf("request %d content = %q, want healthy provider reply", i, got)
+		}
+	}
+	if len(healthy.Requests()) != 3 {
+		t.Fatalf("healthy upstream requests = %d, want 3", len(healthy.Requests()))
+	}
+}
+
+func TestModelFailoverAliasSkipsFailingProvider(t *testing.T) {
+	primary, primaryURL := StartUpstream(t)
+	backup, backupURL := StartUpstream(t)
+	backup.SetReply("from backup")
+	primary.FailNext(http.StatusTooManyRequests, http.StatusTooManyRequests)
+	proxy := StartProxy(t, `request-retry: 0
+model-failover:
+  - alias: "fake-class"
+    targets:
+      - provider: "primary"
+        model: "primary-gpt"
+      - provider: "backup"
+        model: "backup-gpt"
+openai-compatibility:
+`+openAICompatConfig("primary", primaryURL, "primary-gpt")+openAICompatConfig("backup", backupURL, "backup-gpt"))
+
+	status, body := proxy.Post(t, "/v1/chat/completions", `{"model":"fake-class","messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	if got := gjson.Get(body, "choices.0.message.content").String(); got != "from backup" {
+		t.Fatalf("content = %q, body = %s", got, body)
+	}
+
+	status, body = proxy.Post(t, "/v1/chat/completions", `{"model":"fake-class","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if status != http.StatusOK {
+		t.Fatalf("stream status = %d, body = %s", status, body)
+	}
+	if got := collectSSEText(body, "choices.0.delta.content"); got != "from backup" {
+		t.Fatalf("streamed content = %q, body = %s", got, body)
+	}
+
+	requests := backup.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("backup upstream requests = %d, want 2", len(requests))
+	}
+	if model := gjson.GetBytes(requests[0].Body, "model").String(); model != "backup-gpt" {
+		t.Fatalf("backup upstream model = %q, want target model", model)
+	}
+}
+
+// collectSSEText concatenates the string at path across every data line of an SSE body.
+func collectSSEText(body, path string) string {
+	var out strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "" || data == "[DONE]" {
+			continue
+		}
+		out.WriteString(gjson.Get(data, path).String())
+	}
+	return out.String()
+}
@@ -0,0 +1,24 @@
+// Command fakeupstream serves the e2e fake OpenAI, Anthropic and Gemini upstream APIs on a fixed
+// address, for running the proxy against it outside of go test (see test/e2e/docker-compose.yml).
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/test/e2e"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "Listen address")
+	reply := flag.String("reply", "ok", "Assistant text returned by every response")
+	flag.Parse()
+
+	upstream := e2e.NewUpstream()
+	upstream.SetReply(*reply)
+	log.Infof("fake upstream listening on %s", *addr)
+	if errServe := http.ListenAndServe(*addr, upstream); errServe != nil {
+		log.Errorf("fake upstream stopped: %v", errServe)
+	}
+}
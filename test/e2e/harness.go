@@ -0,0 +1,249 @@
+// Package e2e runs the proxy binary against fake upstream providers so cross-cutting behavior
+// (retry, failover, protocol translation, streaming) can be validated without real credentials.
+//
+// The tests are behind the e2e build tag:
+//
+//	go test -tags e2e ./test/e2e/...
+//
+// Set CLIPROXY_E2E_BINARY to reuse a prebuilt binary instead of building ./cmd/server.
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// APIKey is the client API key accepted by proxies started with StartProxy.
+const APIKey = "e2e-client-key"
+
+const startupTimeout = 30 * time.Second
+
+var (
+	buildOnce   sync.Once
+	buildPath   string
+	buildOutput []byte
+	errBuild    error
+)
+
+// Binary returns the path of the proxy binary, building ./cmd/server once per test process.
+func Binary(t testing.TB) string {
+	t.Helper()
+	if path := strings.TrimSpace(os.Getenv("CLIPROXY_E2E_BINARY")); path != "" {
+		return path
+	}
+	buildOnce.Do(func() {
+		root, errRoot := moduleRoot()
+		if errRoot != nil {
+			errBuild = errRoot
+			return
+		}
+		dir, errDir := os.MkdirTemp("", "cliproxy-e2e-")
+		if errDir != nil {
+			errBuild = errDir
+			return
+		}
+		buildPath = filepath.Join(dir, "cli-proxy-api")
+		cmd := exec.Command("go", "build", "-o", buildPath, "./cmd/server")
+		cmd.Dir = root
+		buildOutput, errBuild = cmd.CombinedOutput()
+	})
+	if errBuild != nil {
+		t.Fatalf("build proxy binary: %v\n%s", errBuild, buildOutput)
+	}
+	return buildPath
+}
+
+func moduleRoot() (string, error) {
+	out, errGo := exec.Command("go", "env", "GOMOD").Output()
+	if errGo != nil {
+		return "", fmt.Errorf("locate module root: %w", errGo)
+	}
+	gomod := strings.TrimSpace(string(out))
+	if gomod == "" || gomod == os.DevNull {
+		return "", fmt.Errorf("locate module root: not inside a Go module")
+	}
+	return filepath.Dir(gomod), nil
+}
+
+// StartUpstream serves a fake upstream for the duration of the test.
+func StartUpstream(t testing.TB) (*Upstream, string) {
+	t.Helper()
+	upstream := NewUpstream()
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+	return upstream, server.URL
+}
+
+// Proxy is a running proxy process.
+type Proxy struct {
+	// BaseURL is the proxy's http://host:port address.
+	BaseURL string
+
+	cmd  *exec.Cmd
+	logs *syncBuffer
+	done chan struct{}
+}
+
+// StartProxy writes a config file from providerConfig, runs the proxy binary and waits until it
+// serves /v1/models. providerConfig is raw YAML appended after the generated host, port, auth-dir
+// and api-keys settings, typically provider credentials pointing at fake upstreams.
+func StartProxy(t testing.TB, providerConfig string) *Proxy {
+	t.Helper()
+	binary := Binary(t)
+	port := freePort(t)
+	dir := t.TempDir()
+	authDir := filepath.Join(dir, "auths")
+	if errMkdir := os.MkdirAll(authDir, 0o700); errMkdir != nil {
+		t.Fatalf("create auth dir: %v", errMkdir)
+	}
+
+	config := fmt.Sprintf(`host: "127.0.0.1"
+port: %d
+auth-dir: %q
+api-keys:
+  - %q
+remote-management:
+  disable-control-panel: true
+`, port, authDir, APIKey) + providerConfig
+	configPath := filepath.Join(dir, "config.yaml")
+	if errWrite := os.WriteFile(configPath, []byte(config), 0o600); errWrite != nil {
+		t.Fatalf("write config: %v", errWrite)
+	}
+
+	p := &Proxy{
+		BaseURL: fmt.Sprintf("http://127.0.0.1:%d", port),
+		logs:    &syncBuffer{},
+		done:    make(chan struct{}),
+	}
+	p.cmd = exec.Command(binary, "-config", configPath, "-local-model")
+	p.cmd.Dir = dir
+	p.cmd.Stdout = p.logs
+	p.cmd.Stderr = p.logs
+	if errStart := p.cmd.Start(); errStart != nil {
+		t.Fatalf("start proxy: %v", errStart)
+	}
+	go func() {
+		_ = p.cmd.Wait()
+		close(p.done)
+	}()
+	t.Cleanup(func() {
+		p.stop()
+		if t.Failed() {
+			t.Logf("proxy logs:\n%s", p.Logs())
+		}
+	})
+
+	p.waitReady(t)
+	return p
+}
+
+func (p *Proxy) waitReady(t testing.TB) {
+	t.Helper()
+	deadline := time.Now().Add(startupTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-p.done:
+			t.Fatalf("proxy exited during startup:\n%s", p.Logs())
+		default:
+		}
+		resp, errGet := p.Do(http.MethodGet, "/v1/models", "")
+		if errGet == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("proxy not ready after %s:\n%s", startupTimeout, p.Logs())
+}
+
+func (p *Proxy) stop() {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	_ = p.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-p.done:
+	case <-time.After(10 * time.Second):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+// Logs returns the proxy's combined stdout and stderr so far.
+func (p *Proxy) Logs() string {
+	return p.logs.String()
+}
+
+// Do sends an authenticated request to the proxy. An empty body sends no payload.
+func (p *Proxy) Do(method, path, body string) (*http.Response, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, errReq := http.NewRequest(method, p.BaseURL+path, reader)
+	if errReq != nil {
+		return nil, errReq
+	}
+	req.Header.Set("Authorization", "Bearer "+APIKey)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// Post sends an authenticated JSON request and returns the status code and full response body.
+func (p *Proxy) Post(t testing.TB, path, body string) (int, string) {
+	t.Helper()
+	resp, errDo := p.Do(http.MethodPost, path, body)
+	if errDo != nil {
+		t.Fatalf("POST %s: %v", path, errDo)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		t.Fatalf("read %s response: %v", path, errRead)
+	}
+	return resp.StatusCode, string(data)
+}
+
+func freePort(t testing.TB) int {
+	t.Helper()
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("reserve port: %v", errListen)
+	}
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,260 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RecordedRequest is one request received by a fake upstream.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// Upstream is a fake provider API serving the OpenAI chat completions, Anthropic messages and
+// Gemini generateContent endpoints, streaming included. Responses echo the requested model and
+// carry a fixed reply text and token usage; failures can be queued to exercise retry and failover.
+type Upstream struct {
+	mu       sync.Mutex
+	reply    string
+	failures []int
+	requests []RecordedRequest
+}
+
+// NewUpstream returns a fake upstream replying with "ok".
+func NewUpstream() *Upstream {
+	return &Upstream{reply: "ok"}
+}
+
+// SetReply changes the assistant text returned by successful responses.
+func (u *Upstream) SetReply(text string) {
+	u.mu.Lock()
+	u.reply = text
+	u.mu.Unlock()
+}
+
+// FailNext makes the next len(statuses) requests fail with the given HTTP status codes, in order.
+func (u *Upstream) FailNext(statuses ...int) {
+	u.mu.Lock()
+	u.failures = append(u.failures, statuses...)
+	u.mu.Unlock()
+}
+
+// Requests returns a copy of the requests received so far.
+func (u *Upstream) Requests() []RecordedRequest {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]RecordedRequest(nil), u.requests...)
+}
+
+// ServeHTTP implements http.Handler.
+func (u *Upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	u.mu.Lock()
+	u.requests = append(u.requests, RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	reply := u.reply
+	status := 0
+	if len(u.failures) > 0 {
+		status = u.failures[0]
+		u.failures = u.failures[1:]
+	}
+	u.mu.Unlock()
+
+	if status != 0 {
+		writeJSON(w, status, map[string]any{"error": map[string]any{
+			"message": fmt.Sprintf("fake upstream failure %d", status),
+			"type":    "upstream_error",
+			"code":    status,
+		}})
+		return
+	}
+
+	var payload struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	_ = json.Unmarshal(body, &payload)
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/chat/completions"):
+		if payload.Stream {
+			writeOpenAIStream(w, payload.Model, reply)
+		} else {
+			writeJSON(w, http.StatusOK, openAICompletion(payload.Model, reply))
+		}
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/messages"):
+		if payload.Stream {
+			writeClaudeStream(w, payload.Model, reply)
+		} else {
+			writeJSON(w, http.StatusOK, claudeMessage(payload.Model, reply))
+		}
+	case r.Method == http.MethodPost && strings.Contains(path, "/models/"):
+		model, method, _ := strings.Cut(path[strings.LastIndex(path, "/models/")+len("/models/"):], ":")
+		switch method {
+		case "generateContent":
+			writeJSON(w, http.StatusOK, geminiResponse(model, reply))
+		case "streamGenerateContent":
+			writeGeminiStream(w, model, reply)
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": map[string]any{"message": "unknown method " + method}})
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": map[string]any{"message": "unknown path " + path}})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func startSSE(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeSSE(w http.ResponseWriter, event string, value any) {
+	data, _ := json.Marshal(value)
+	if event != "" {
+		_, _ = fmt.Fprintf(w, "event: %s\n", event)
+	}
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// streamChunks splits the reply into word chunks so clients see more than one delta.
+func streamChunks(reply string) []string {
+	words := strings.SplitAfter(reply, " ")
+	chunks := words[:0]
+	for _, word := range words {
+		if word != "" {
+			chunks = append(chunks, word)
+		}
+	}
+	if len(chunks) == 0 {
+		return []string{""}
+	}
+	return chunks
+}
+
+func openAICompletion(model, reply string) map[string]any {
+	return map[string]any{
+		"id":      "chatcmpl-fake",
+		"object":  "chat.completion",
+		"created": 1700000000,
+		"model":   model,
+		"choices": []any{map[string]any{
+			"index":         0,
+			"message":       map[string]any{"role": "assistant", "content": reply},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]any{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+	}
+}
+
+func writeOpenAIStream(w http.ResponseWriter, model, reply string) {
+	startSSE(w)
+	chunk := func(delta map[string]any, finish any) map[string]any {
+		return map[string]any{
+			"id":      "chatcmpl-fake",
+			"object":  "chat.completion.chunk",
+			"created": 1700000000,
+			"model":   model,
+			"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+	}
+	writeSSE(w, "", chunk(map[string]any{"role": "assistant", "content": ""}, nil))
+	for _, text := range streamChunks(reply) {
+		writeSSE(w, "", chunk(map[string]any{"content": text}, nil))
+	}
+	final := chunk(map[string]any{}, "stop")
+	final["usage"] = map[string]any{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+	writeSSE(w, "", final)
+	_, _ = io.WriteString(w, "data: [DONE]\n\n")
+}
+
+func claudeMessage(model, reply string) map[string]any {
+	return map[string]any{
+		"id":            "msg_fake",
+		"type":          "message",
+		"role":          "assistant",
+		"model":         model,
+		"content":       []any{map[string]any{"type": "text", "text": reply}},
+		"stop_reason":   "end_turn",
+		"stop_sequence": nil,
+		"usage":         map[string]any{"input_tokens": 10, "output_tokens": 5},
+	}
+}
+
+func writeClaudeStream(w http.ResponseWriter, model, reply string) {
+	startSSE(w)
+	writeSSE(w, "message_start", map[string]any{
+		"type": "message_start",
+		"message": map[string]any{
+			"id": "msg_fake", "type": "message", "role": "assistant", "model": model,
+			"content": []any{}, "stop_reason": nil, "stop_sequence": nil,
+			"usage": map[string]any{"input_tokens": 10, "output_tokens": 0},
+		},
+	})
+	writeSSE(w, "content_block_start", map[string]any{
+		"type": "content_block_start", "index": 0,
+		"content_block": map[string]any{"type": "text", "text": ""},
+	})
+	for _, text := range streamChunks(reply) {
+		writeSSE(w, "content_block_delta", map[string]any{
+			"type": "content_block_delta", "index": 0,
+			"delta": map[string]any{"type": "text_delta", "text": text},
+		})
+	}
+	writeSSE(w, "content_block_stop", map[string]any{"type": "content_block_stop", "index": 0})
+	writeSSE(w, "message_delta", map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": "end_turn", "stop_sequence": nil},
+		"usage": map[string]any{"output_tokens": 5},
+	})
+	writeSSE(w, "message_stop", map[string]any{"type": "message_stop"})
+}
+
+func geminiChunk(model, text string, final bool) map[string]any {
+	candidate := map[string]any{
+		"content": map[string]any{"role": "model", "parts": []any{map[string]any{"text": text}}},
+		"index":   0,
+	}
+	resp := map[string]any{"candidates": []any{candidate}, "modelVersion": model}
+	if final {
+		candidate["finishReason"] = "STOP"
+		resp["usageMetadata"] = map[string]any{"promptTokenCount": 10, "candidatesTokenCount": 5, "totalTokenCount": 15}
+	}
+	return resp
+}
+
+func geminiResponse(model, reply string) map[string]any {
+	return geminiChunk(model, reply, true)
+}
+
+func writeGeminiStream(w http.ResponseWriter, model, reply string) {
+	startSSE(w)
+	chunks := streamChunks(reply)
+	for i, text := range chunks {
+		writeSSE(w, "", geminiChunk(model, text, i == len(chunks)-1))
+	}
+}
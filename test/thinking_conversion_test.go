@@ -1087,6 +1087,146 @@ func TestThinkingE2EMatrix_Suffix(t *testing.T) {
 	runThinkingTests(t, cases)
 }
 
+// TestThinkingE2EMatrix_BudgetShorthand tests the k-shorthand budget suffixes ("8k", ":8k-thinking")
+// and minimal effort across every provider target, following the conversion matrix in docs/thinking.md.
+func TestThinkingE2EMatrix_BudgetShorthand(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	uid := fmt.Sprintf("thinking-e2e-shorthand-%d", time.Now().UnixNano())
+
+	reg.RegisterClient(uid, "test", getTestModels())
+	defer reg.UnregisterClient(uid)
+
+	cases := []thinkingTestCase{
+		// Case S1: OpenAI target, 8k → 8192 → medium
+		{
+			name:        "S1",
+			from:        "openai",
+			to:          "openai",
+			model:       "level-model(8k)",
+			inputJSON:   `{"model":"level-model(8k)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField: "reasoning_effort",
+			expectValue: "medium",
+		},
+		// Case S2: Codex target, :24k-thinking → 24576 → high
+		{
+			name:        "S2",
+			from:        "openai",
+			to:          "codex",
+			model:       "level-model(:24k-thinking)",
+			inputJSON:   `{"model":"level-model(:24k-thinking)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField: "reasoning.effort",
+			expectValue: "high",
+		},
+		// Case S3: Codex target, minimal passes through
+		{
+			name:        "S3",
+			from:        "openai",
+			to:          "codex",
+			model:       "level-model(minimal)",
+			inputJSON:   `{"model":"level-model(minimal)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField: "reasoning.effort",
+			expectValue: "minimal",
+		},
+		// Case S4: Claude target, :8k-thinking → budget_tokens 8192
+		{
+			name:        "S4",
+			from:        "claude",
+			to:          "claude",
+			model:       "claude-budget-model(:8k-thinking)",
+			inputJSON:   `{"model":"claude-budget-model(:8k-thinking)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField: "thinking.budget_tokens",
+			expectValue: "8192",
+		},
+		// Case S5: Claude target, minimal → 512 → clamped to 1024 (min)
+		{
+			name:        "S5",
+			from:        "openai",
+			to:          "claude",
+			model:       "claude-budget-model(minimal)",
+			inputJSON:   `{"model":"claude-budget-model(minimal)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField: "thinking.budget_tokens",
+			expectValue: "1024",
+		},
+		// Case S6: Claude target, 0-thinking → disabled
+		{
+			name:        "S6",
+			from:        "claude",
+			to:          "claude",
+			model:       "claude-budget-model(0-thinking)",
+			inputJSON:   `{"model":"claude-budget-model(0-thinking)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField: "thinking.type",
+			expectValue: "disabled",
+		},
+		// Case S7: Gemini target, 16K → thinkingBudget 16384
+		{
+			name:            "S7",
+			from:            "gemini",
+			to:              "gemini",
+			model:           "gemini-budget-model(16K)",
+			inputJSON:       `{"model":"gemini-budget-model(16K)","contents":[{"role":"user","parts":[{"text":"hi"}]}]}`,
+			expectField:     "generationConfig.thinkingConfig.thinkingBudget",
+			expectValue:     "16384",
+			includeThoughts: "true",
+		},
+		// Case S8: Gemini target, minimal → thinkingBudget 512
+		{
+			name:            "S8",
+			from:            "openai",
+			to:              "gemini",
+			model:           "gemini-budget-model(minimal)",
+			inputJSON:       `{"model":"gemini-budget-model(minimal)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField:     "generationConfig.thinkingConfig.thinkingBudget",
+			expectValue:     "512",
+			includeThoughts: "true",
+		},
+		// Case S9: Gemini level target, :32k-thinking → 32768 → high
+		{
+			name:            "S9",
+			from:            "gemini",
+			to:              "gemini",
+			model:           "level-subset-model(:32k-thinking)",
+			inputJSON:       `{"model":"level-subset-model(:32k-thinking)","contents":[{"role":"user","parts":[{"text":"hi"}]}]}`,
+			expectField:     "generationConfig.thinkingConfig.thinkingLevel",
+			expectValue:     "high",
+			includeThoughts: "true",
+		},
+		// Case S10: Antigravity target, 8k → thinkingBudget 8192
+		{
+			name:            "S10",
+			from:            "gemini",
+			to:              "antigravity",
+			model:           "antigravity-budget-model(8k)",
+			inputJSON:       `{"model":"antigravity-budget-model(8k)","contents":[{"role":"user","parts":[{"text":"hi"}]}]}`,
+			expectField:     "request.generationConfig.thinkingConfig.thinkingBudget",
+			expectValue:     "8192",
+			includeThoughts: "true",
+		},
+		// Case S11: Kimi target, 32k → high effort
+		{
+			name:         "S11",
+			from:         "openai",
+			to:           "kimi",
+			model:        "kimi-toggle-thinking-model(32k)",
+			inputJSON:    `{"model":"kimi-toggle-thinking-model(32k)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField:  "thinking.type",
+			expectValue:  "enabled",
+			expectField2: "thinking.effort",
+			expectValue2: "high",
+		},
+		// Case S12: Fractional shorthand is not a budget → no thinking config
+		{
+			name:        "S12",
+			from:        "openai",
+			to:          "openai",
+			model:       "level-model(1.5k)",
+			inputJSON:   `{"model":"level-model(1.5k)","messages":[{"role":"user","content":"hi"}]}`,
+			expectField: "",
+		},
+	}
+
+	runThinkingTests(t, cases)
+}
+
 // TestThinkingE2EMatrix_Body tests the thinking configuration transformation using request body parameters.
 // Data flow: Input JSON with thinking params → TranslateRequest → ApplyThinking → Validate Output
 func TestThinkingE2EMatrix_Body(t *testing.T) {